@@ -64,6 +64,30 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "migrations:verify":
+		if err := migrationCmd.Verify(); err != nil {
+			fmt.Printf("❌ Verification failed: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "migrations:seed":
+		if err := migrationCmd.Seed(); err != nil {
+			fmt.Printf("❌ Failed to seed database: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "bootstrap":
+		if err := migrationCmd.Bootstrap(); err != nil {
+			fmt.Printf("❌ Bootstrap failed: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "migrations:reencrypt-secrets":
+		if err := migrationCmd.ReencryptSecrets(); err != nil {
+			fmt.Printf("❌ Failed to re-encrypt secrets: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "migrations:drop":
 		if err := migrationCmd.Drop(); err != nil {
 			fmt.Printf("❌ Failed to drop database: %v\n", err)
@@ -105,6 +129,10 @@ func showHelp() {
 	fmt.Println("  migrations:status           Show current migration status")
 	fmt.Println("  migrations:list             List all migration files")
 	fmt.Println("  migrations:rollback [steps] Rollback last N migrations (default: 1)")
+	fmt.Println("  migrations:verify           Check migrations against a scratch database")
+	fmt.Println("  migrations:seed             Create the admin and master configuration from the environment")
+	fmt.Println("  bootstrap                   Apply migrations, then seed")
+	fmt.Println("  migrations:reencrypt-secrets Encrypt stored secrets with the current master key")
 	fmt.Println("  migrations:drop             Drop all database tables")
 	fmt.Println()
 	fmt.Println("📋 Examples:")
@@ -120,6 +148,8 @@ func showHelp() {
 	fmt.Println("  go run ./cmd/migrations migrations:status")
 	fmt.Println("  go run ./cmd/migrations migrations:rollback")
 	fmt.Println("  go run ./cmd/migrations migrations:rollback 2")
+	fmt.Println("  go run ./cmd/migrations migrations:verify")
+	fmt.Println("  ADMIN_EMAIL=admin@example.com ADMIN_PASSWORD=... go run ./cmd/migrations bootstrap")
 	fmt.Println()
 	fmt.Println("⚙️  Environment:")
 	fmt.Println("  Set DATABASE_URL environment variable or it will default to:")
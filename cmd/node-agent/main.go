@@ -0,0 +1,64 @@
+// Command node-agent runs a storage node without the master's API or a Postgres database. It
+// serves only what the master stores content on nodes through, and registers itself with the
+// master on first boot.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"shbucket/src/Infrastructure/Diagnostics"
+	"shbucket/src/Infrastructure/NodeAgent"
+)
+
+// shutdownTimeout is how long running transfers get to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
+func main() {
+	// Errors are kept for the master to read through the diagnostics endpoint
+	log.SetOutput(diagnostics.Capture(os.Stderr))
+
+	cfg, err := nodeagent.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	store, err := nodeagent.OpenStore(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Failed to open node database: %v", err)
+	}
+	defer store.Close()
+
+	authKey, err := nodeagent.AuthKey(context.Background(), cfg, store)
+	if err != nil {
+		log.Fatalf("Failed to register with master: %v", err)
+	}
+
+	server, err := nodeagent.NewServer(cfg, store, authKey)
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
+	}
+
+	pinger := nodeagent.NewPinger(cfg, authKey)
+	pinger.Start()
+	defer pinger.Stop()
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		log.Printf("Shutting down node agent...")
+		if err := server.Shutdown(shutdownTimeout); err != nil {
+			log.Printf("Shutdown error: %v", err)
+		}
+	}()
+
+	log.Printf("Node agent %s serving %s on %s:%s for master %s", cfg.Name, cfg.StoragePath, cfg.Host, cfg.Port, cfg.MasterURL)
+	if err := server.Listen(); err != nil {
+		log.Printf("Server error: %v", err)
+	}
+}
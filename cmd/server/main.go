@@ -25,48 +25,135 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
 	"github.com/joho/godotenv"
 
+	"flag"
+	"shbucket/docs"
 	"shbucket/src/Application/APIKey"
+	"shbucket/src/Application/AdminTask"
+	"shbucket/src/Application/Alias"
+	"shbucket/src/Application/ClusterMember"
+	"shbucket/src/Application/Backup"
 	"shbucket/src/Application/Bucket"
+	"shbucket/src/Application/BucketSync"
+	"shbucket/src/Application/Replication"
+	"shbucket/src/Application/Comment"
+	"shbucket/src/Application/Durability"
+	"shbucket/src/Application/Egress"
+	"shbucket/src/Application/Event"
+	"shbucket/src/Application/Export"
+	"shbucket/src/Application/Favorite"
 	"shbucket/src/Application/File"
+	"shbucket/src/Application/Import"
+	"shbucket/src/Application/Job"
 	"shbucket/src/Application/Node"
+	"shbucket/src/Application/Notification"
+	"shbucket/src/Application/Permission"
+	"shbucket/src/Application/Rebalance"
+	"shbucket/src/Application/Reclamation"
+	"shbucket/src/Application/Residency"
+	"shbucket/src/Application/Role"
+	"shbucket/src/Application/UploadGrant"
+	"shbucket/src/Application/UploadSession"
+	"shbucket/src/Application/Setting"
 	"shbucket/src/Application/Setup"
+	"shbucket/src/Application/SigningKey"
+	"shbucket/src/Application/Snapshot"
+	"shbucket/src/Application/Stats"
 	"shbucket/src/Application/User"
+	"shbucket/src/Application/Webhook"
 	"shbucket/src/Controllers"
 	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Cluster"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Diagnostics"
+	"shbucket/src/Infrastructure/Domains"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Mail"
 	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Metrics"
+	"shbucket/src/Infrastructure/Middleware"
 	"shbucket/src/Infrastructure/Persistence"
-	_ "shbucket/docs"
+	"shbucket/src/Infrastructure/Routing"
+	"shbucket/src/Infrastructure/SFTP"
+	"shbucket/src/Infrastructure/Scanning"
+	"shbucket/src/Infrastructure/Secrets"
+	"shbucket/src/Infrastructure/Services"
+	"shbucket/src/Infrastructure/Storage"
 )
 
 func main() {
+	// Errors are kept for the master to read through the diagnostics endpoint, when running as a node
+	log.SetOutput(diagnostics.Capture(os.Stderr))
+
 	// Load environment variables from .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: No .env file found or error loading .env file:", err)
 	}
 	
-	databaseURL := os.Getenv("DATABASE_URL")
+	// Settings come from a config file as well, which the environment, .env included, overrides
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML file to read settings from, environment variables override it")
+	flag.Parse()
+	if *configFile != "" {
+		if err := config.LoadFile(*configFile); err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		log.Printf("Reading settings from %s", *configFile)
+	}
+
+	switch args := flag.Args(); {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "config" && args[1] == "print":
+		// The effective settings, with secrets masked, then exit
+		if err := config.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		return
+	default:
+		log.Fatalf("Unknown command %q, run without one to serve or with `config print` to show the effective settings", strings.Join(args, " "))
+	}
+
+	databaseURL := config.Getenv("DATABASE_URL")
 	if databaseURL == "" {
 		databaseURL = "postgres://postgres@localhost:5432/shbucket?sslmode=disable"
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-super-secret-jwt-key-here"
+	settings := config.GetSettings()
+	if !config.IsProfile(settings.Profile) {
+		log.Fatalf("Unknown PROFILE %q, use %s, %s or %s", settings.Profile, config.ProfileDev, config.ProfileStaging, config.ProfileProd)
+	}
+	log.Printf("Using the %s profile", settings.Profile)
+	if secrets := settings.DefaultSecrets(); len(secrets) > 0 {
+		if !settings.AllowDefaultSecrets {
+			log.Fatalf("%s still set to the default, the %s profile requires your own secrets", strings.Join(secrets, " and "), settings.Profile)
+		}
+		log.Printf("Warning: %s still set to the default, set your own before deploying", strings.Join(secrets, " and "))
+	}
+	// Node auth keys, webhook secrets and the like are encrypted in the database with the master key
+	if err := secrets.Check(); err != nil {
+		log.Fatalf("Invalid master key: %v", err)
+	}
+	if !secrets.Enabled() {
+		log.Printf("Warning: no ENCRYPTION_MASTER_KEY set, node auth keys and other secrets are stored unencrypted")
 	}
 
-	port := os.Getenv("PORT")
+	port := config.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
@@ -81,10 +168,21 @@ func main() {
 
 	log.Println("Database connected successfully")
 
-	
-	jwtHandler := auth.NewJWTHandler(jwtSecret, "SHBucket", 24)
+	// Apply settings saved through the admin API over the environment configuration
+	if err := setting.LoadSystemSettings(dbContext); err != nil {
+		log.Printf("Warning: using environment settings: %v", err)
+	}
+
+	// Servers sharing the database must accept each other's tokens and signed URLs
+	member := cluster.NewMember(dbContext)
+	if err := member.CheckSecrets(); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+
+	jwtHandler := auth.NewJWTHandler(settings.JWTSecret, "SHBucket", settings.JWTExpiryHours)
 	authService := auth.NewAuthorizationService(jwtHandler)
-	validator := validator.New()
+	validator := binding.NewValidator()
+	mailer := mail.NewMailer(settings)
 
 	// Initialize mediator
 	med := mediator.NewMediator()
@@ -92,207 +190,592 @@ func main() {
 	// Initialize handlers
 	loginHandler := user.NewLoginRequestHandler(dbContext, jwtHandler)
 	logoutHandler := user.NewLogoutRequestHandler(dbContext, jwtHandler)
+	refreshTokenHandler := user.NewRefreshTokenRequestHandler(dbContext, jwtHandler)
 	registerHandler := user.NewRegisterRequestHandler(dbContext)
 	changePasswordHandler := user.NewChangePasswordRequestHandler(dbContext)
 	getUserHandler := user.NewGetUserRequestHandler(dbContext)
 	listUsersHandler := user.NewListUsersRequestHandler(dbContext)
+	inviteUserHandler := user.NewInviteUserRequestHandler(dbContext, mailer)
+	acceptInvitationHandler := user.NewAcceptInvitationRequestHandler(dbContext)
+	forgotPasswordHandler := user.NewForgotPasswordRequestHandler(dbContext, mailer)
+	resetPasswordHandler := user.NewResetPasswordRequestHandler(dbContext)
+	loginTwoFactorHandler := user.NewLoginTwoFactorRequestHandler(dbContext, jwtHandler)
+	getTwoFactorStatusHandler := user.NewGetTwoFactorStatusRequestHandler(dbContext)
+	enrollTwoFactorHandler := user.NewEnrollTwoFactorRequestHandler(dbContext)
+	confirmTwoFactorHandler := user.NewConfirmTwoFactorRequestHandler(dbContext)
+	disableTwoFactorHandler := user.NewDisableTwoFactorRequestHandler(dbContext)
+	regenerateRecoveryCodesHandler := user.NewRegenerateRecoveryCodesRequestHandler(dbContext)
+	resetTwoFactorHandler := user.NewResetTwoFactorRequestHandler(dbContext)
+	setUserRoleHandler := user.NewSetUserRoleRequestHandler(dbContext)
+
+	listRolesHandler := role.NewListRolesRequestHandler(dbContext)
+	createRoleHandler := role.NewCreateRoleRequestHandler(dbContext)
+	updateRoleHandler := role.NewUpdateRoleRequestHandler(dbContext)
+	deleteRoleHandler := role.NewDeleteRoleRequestHandler(dbContext)
 
 	createBucketHandler := bucket.NewCreateBucketRequestHandler(dbContext)
 	deleteBucketHandler := bucket.NewDeleteBucketRequestHandler(dbContext)
 	getBucketHandler := bucket.NewGetBucketRequestHandler(dbContext)
 	listBucketsHandler := bucket.NewListBucketsRequestHandler(dbContext)
 	updateBucketHandler := bucket.NewUpdateBucketRequestHandler(dbContext)
+	rotateBucketKeyHandler := bucket.NewRotateBucketKeyRequestHandler(dbContext)
+	getKeyRotationJobHandler := bucket.NewGetKeyRotationJobRequestHandler(dbContext)
+	getBucketDeletionJobHandler := bucket.NewGetBucketDeletionJobRequestHandler(dbContext)
+	grantBucketAdminHandler := bucket.NewGrantBucketAdminRequestHandler(dbContext)
+	listBucketAdminsHandler := bucket.NewListBucketAdminsRequestHandler(dbContext)
+	revokeBucketAdminHandler := bucket.NewRevokeBucketAdminRequestHandler(dbContext)
+	getJobHandler := job.NewGetJobRequestHandler(dbContext)
 
 	uploadFileHandler := file.NewUploadFileRequestHandler(dbContext)
 	distributedUploadHandler := file.NewDistributedUploadRequestHandler(dbContext)
+	precheckUploadHandler := file.NewPrecheckUploadRequestHandler(dbContext)
 	deleteFileHandler := file.NewDeleteFileRequestHandler(dbContext)
+	relocateFileHandler := file.NewRelocateFileRequestHandler(dbContext)
+	setFileHeadersHandler := file.NewSetFileHeadersRequestHandler(dbContext)
+	setFileLockHandler := file.NewSetFileLockRequestHandler(dbContext)
 	getFileHandler := file.NewGetFileRequestHandler(dbContext)
 	listFilesHandler := file.NewListFilesRequestHandler(dbContext)
 	generateSignedURLHandler := file.NewGenerateSignedURLRequestHandler(dbContext)
+	createFileTokenHandler := file.NewCreateFileTokenRequestHandler(dbContext)
+	listFileTokensHandler := file.NewListFileTokensRequestHandler(dbContext)
+	revokeFileTokenHandler := file.NewRevokeFileTokenRequestHandler(dbContext)
+	createUploadGrantHandler := uploadgrant.NewCreateUploadGrantRequestHandler(dbContext)
+	listUploadGrantsHandler := uploadgrant.NewListUploadGrantsRequestHandler(dbContext)
+	revokeUploadGrantHandler := uploadgrant.NewRevokeUploadGrantRequestHandler(dbContext)
+	getUploadLinkHandler := uploadgrant.NewGetUploadLinkRequestHandler(dbContext)
+	uploadWithGrantHandler := uploadgrant.NewUploadWithGrantRequestHandler(dbContext)
+	createUploadPolicyHandler := uploadgrant.NewCreateUploadPolicyRequestHandler(dbContext)
+	uploadWithPolicyHandler := uploadgrant.NewUploadWithPolicyRequestHandler(dbContext)
+	createUploadSessionHandler := uploadsession.NewCreateUploadSessionRequestHandler(dbContext)
+	getUploadSessionHandler := uploadsession.NewGetUploadSessionRequestHandler(dbContext)
+	uploadChunkHandler := uploadsession.NewUploadChunkRequestHandler(dbContext)
+	completeUploadSessionHandler := uploadsession.NewCompleteUploadSessionRequestHandler(dbContext)
+	abortUploadSessionHandler := uploadsession.NewAbortUploadSessionRequestHandler(dbContext)
+	getBucketEgressHandler := egress.NewGetBucketEgressRequestHandler(dbContext)
+	getUserEgressHandler := egress.NewGetUserEgressRequestHandler(dbContext)
+	setUserEgressQuotaHandler := egress.NewSetUserEgressQuotaRequestHandler(dbContext)
+	getAPIKeyEgressHandler := egress.NewGetAPIKeyEgressRequestHandler(dbContext)
+	setAPIKeyEgressQuotaHandler := egress.NewSetAPIKeyEgressQuotaRequestHandler(dbContext)
+	getBucketDurabilityHandler := durability.NewGetBucketDurabilityRequestHandler(dbContext)
 	
 	createAPIKeyHandler := apikey.NewCreateAPIKeyRequestHandler(dbContext)
 	listAPIKeysHandler := apikey.NewListAPIKeysRequestHandler(dbContext)
 	deleteAPIKeyHandler := apikey.NewDeleteAPIKeyRequestHandler(dbContext)
+	listBucketAPIKeysHandler := apikey.NewListBucketAPIKeysRequestHandler(dbContext)
+	revokeBucketAPIKeyHandler := apikey.NewRevokeBucketAPIKeyRequestHandler(dbContext)
 
 	registerNodeHandler := node.NewRegisterNodeRequestHandler(dbContext)
+	updateNodeHandler := node.NewUpdateNodeRequestHandler(dbContext)
 	listNodesHandler := node.NewListNodesRequestHandler(dbContext)
+	failNodeHandler := node.NewFailNodeRequestHandler(dbContext)
+	setNodeMaintenanceHandler := node.NewSetNodeMaintenanceRequestHandler(dbContext)
+	getNodeDiagnosticsHandler := node.NewGetNodeDiagnosticsRequestHandler(dbContext)
+	getNodeRepairHandler := node.NewGetNodeRepairRequestHandler(dbContext)
+	selfRegisterNodeHandler := node.NewSelfRegisterNodeRequestHandler(dbContext)
+	createRegistrationTokenHandler := node.NewCreateRegistrationTokenRequestHandler(dbContext)
+	listRegistrationTokensHandler := node.NewListRegistrationTokensRequestHandler(dbContext)
+	revokeRegistrationTokenHandler := node.NewRevokeRegistrationTokenRequestHandler(dbContext)
 
 	checkSetupHandler := setup.NewCheckSetupRequestHandler(dbContext)
 	masterSetupHandler := setup.NewMasterSetupRequestHandler(dbContext)
 	nodeSetupHandler := setup.NewNodeSetupRequestHandler(dbContext)
 
+	runBackupHandler := backup.NewRunBackupRequestHandler(dbContext)
+	restoreBackupHandler := backup.NewRestoreBackupRequestHandler(dbContext)
+	listBackupRunsHandler := backup.NewListBackupRunsRequestHandler(dbContext)
+
+	importS3Handler := importer.NewImportS3RequestHandler(dbContext)
+	getS3ImportJobHandler := importer.NewGetS3ImportJobRequestHandler(dbContext)
+	exportS3Handler := export.NewExportS3RequestHandler(dbContext)
+	getS3ExportJobHandler := export.NewGetS3ExportJobRequestHandler(dbContext)
+	replayBucketEventsHandler := event.NewReplayBucketEventsRequestHandler(dbContext)
+	createWebhookHandler := webhook.NewCreateWebhookRequestHandler(dbContext)
+	listWebhooksHandler := webhook.NewListWebhooksRequestHandler(dbContext)
+	deleteWebhookHandler := webhook.NewDeleteWebhookRequestHandler(dbContext)
+	rotateWebhookSecretHandler := webhook.NewRotateWebhookSecretRequestHandler(dbContext)
+	webhookDeliverer := webhook.NewDeliverer(dbContext)
+	setAliasHandler := alias.NewSetAliasRequestHandler(dbContext)
+	listAliasesHandler := alias.NewListAliasesRequestHandler(dbContext)
+	deleteAliasHandler := alias.NewDeleteAliasRequestHandler(dbContext)
+	getBucketChangesHandler := bucketsync.NewGetBucketChangesRequestHandler(dbContext)
+	configureBucketSyncHandler := bucketsync.NewConfigureBucketSyncRequestHandler(dbContext)
+	getBucketSyncHandler := bucketsync.NewGetBucketSyncRequestHandler(dbContext)
+	deleteBucketSyncHandler := bucketsync.NewDeleteBucketSyncRequestHandler(dbContext)
+	runBucketSyncHandler := bucketsync.NewRunBucketSyncRequestHandler(dbContext)
+	configureBucketReplicationHandler := replication.NewConfigureBucketReplicationRequestHandler(dbContext)
+	getBucketReplicationHandler := replication.NewGetBucketReplicationRequestHandler(dbContext)
+	getReplicationStatusHandler := replication.NewGetReplicationStatusRequestHandler(dbContext)
+	deleteBucketReplicationHandler := replication.NewDeleteBucketReplicationRequestHandler(dbContext)
+	createCommentHandler := comment.NewCreateCommentRequestHandler(dbContext)
+	listCommentsHandler := comment.NewListCommentsRequestHandler(dbContext)
+	deleteCommentHandler := comment.NewDeleteCommentRequestHandler(dbContext)
+	listNotificationsHandler := notification.NewListNotificationsRequestHandler(dbContext)
+	markNotificationReadHandler := notification.NewMarkNotificationReadRequestHandler(dbContext)
+	addFavoriteHandler := favorite.NewAddFavoriteRequestHandler(dbContext)
+	removeFavoriteHandler := favorite.NewRemoveFavoriteRequestHandler(dbContext)
+	listFavoritesHandler := favorite.NewListFavoritesRequestHandler(dbContext)
+	getSystemSettingsHandler := setting.NewGetSystemSettingsRequestHandler(dbContext)
+	updateSystemSettingsHandler := setting.NewUpdateSystemSettingsRequestHandler(dbContext)
+	getReclamationReportHandler := reclamation.NewGetReclamationReportRequestHandler(dbContext)
+	reclaimStorageHandler := reclamation.NewReclaimStorageRequestHandler(dbContext)
+	runAdminTaskHandler := admintask.NewRunAdminTaskRequestHandler(dbContext)
+	listAdminTasksHandler := admintask.NewListAdminTasksRequestHandler(dbContext)
+	getRebalancePlanHandler := rebalance.NewGetRebalancePlanRequestHandler(dbContext)
+	startRebalanceHandler := rebalance.NewStartRebalanceRequestHandler(dbContext)
+	getResidencyReportHandler := residency.NewGetResidencyReportRequestHandler(dbContext)
+	getSystemStatsHandler := stats.NewGetSystemStatsRequestHandler(dbContext)
+	exportPermissionsHandler := permission.NewExportPermissionsRequestHandler(dbContext)
+	listClusterMembersHandler := clustermember.NewListClusterMembersRequestHandler(dbContext, member)
+	listSigningKeysHandler := signingkey.NewListSigningKeysRequestHandler(dbContext)
+	rotateSigningKeyHandler := signingkey.NewRotateSigningKeyRequestHandler(dbContext)
+	createSnapshotHandler := snapshot.NewCreateSnapshotRequestHandler(dbContext)
+	listSnapshotsHandler := snapshot.NewListSnapshotsRequestHandler(dbContext)
+	listSnapshotFilesHandler := snapshot.NewListSnapshotFilesRequestHandler(dbContext)
+	getSnapshotFileHandler := snapshot.NewGetSnapshotFileRequestHandler(dbContext)
+	deleteSnapshotHandler := snapshot.NewDeleteSnapshotRequestHandler(dbContext)
+	diffSnapshotsHandler := snapshot.NewDiffSnapshotsRequestHandler(dbContext)
+	getActivityHandler := user.NewGetActivityRequestHandler(dbContext)
+
 	// Register handlers with mediator
 	med.RegisterHandler(&user.LoginCommand{}, loginHandler)
 	med.RegisterHandler(&user.LogoutCommand{}, logoutHandler)
+	med.RegisterHandler(&user.RefreshTokenCommand{}, refreshTokenHandler)
 	med.RegisterHandler(&user.RegisterCommand{}, registerHandler)
 	med.RegisterHandler(&user.ChangePasswordCommand{}, changePasswordHandler)
 	med.RegisterHandler(&user.GetUserCommand{}, getUserHandler)
 	med.RegisterHandler(&user.ListUsersCommand{}, listUsersHandler)
+	med.RegisterHandler(&user.InviteUserCommand{}, inviteUserHandler)
+	med.RegisterHandler(&user.AcceptInvitationCommand{}, acceptInvitationHandler)
+	med.RegisterHandler(&user.ForgotPasswordCommand{}, forgotPasswordHandler)
+	med.RegisterHandler(&user.ResetPasswordCommand{}, resetPasswordHandler)
+	med.RegisterHandler(&user.LoginTwoFactorCommand{}, loginTwoFactorHandler)
+	med.RegisterHandler(&user.GetTwoFactorStatusCommand{}, getTwoFactorStatusHandler)
+	med.RegisterHandler(&user.EnrollTwoFactorCommand{}, enrollTwoFactorHandler)
+	med.RegisterHandler(&user.ConfirmTwoFactorCommand{}, confirmTwoFactorHandler)
+	med.RegisterHandler(&user.DisableTwoFactorCommand{}, disableTwoFactorHandler)
+	med.RegisterHandler(&user.RegenerateRecoveryCodesCommand{}, regenerateRecoveryCodesHandler)
+	med.RegisterHandler(&user.ResetTwoFactorCommand{}, resetTwoFactorHandler)
+	med.RegisterHandler(&user.SetUserRoleCommand{}, setUserRoleHandler)
+
+	med.RegisterHandler(&role.ListRolesCommand{}, listRolesHandler)
+	med.RegisterHandler(&role.CreateRoleCommand{}, createRoleHandler)
+	med.RegisterHandler(&role.UpdateRoleCommand{}, updateRoleHandler)
+	med.RegisterHandler(&role.DeleteRoleCommand{}, deleteRoleHandler)
 
 	med.RegisterHandler(&bucket.CreateBucketCommand{}, createBucketHandler)
 	med.RegisterHandler(&bucket.DeleteBucketCommand{}, deleteBucketHandler)
 	med.RegisterHandler(&bucket.GetBucketCommand{}, getBucketHandler)
 	med.RegisterHandler(&bucket.ListBucketsCommand{}, listBucketsHandler)
 	med.RegisterHandler(&bucket.UpdateBucketCommand{}, updateBucketHandler)
+	med.RegisterHandler(&bucket.RotateBucketKeyCommand{}, rotateBucketKeyHandler)
+	med.RegisterHandler(&bucket.GetKeyRotationJobCommand{}, getKeyRotationJobHandler)
+	med.RegisterHandler(&bucket.GetBucketDeletionJobCommand{}, getBucketDeletionJobHandler)
+	med.RegisterHandler(&bucket.GrantBucketAdminCommand{}, grantBucketAdminHandler)
+	med.RegisterHandler(&bucket.ListBucketAdminsCommand{}, listBucketAdminsHandler)
+	med.RegisterHandler(&bucket.RevokeBucketAdminCommand{}, revokeBucketAdminHandler)
+	med.RegisterHandler(&job.GetJobCommand{}, getJobHandler)
 
 	med.RegisterHandler(&file.UploadFileCommand{}, uploadFileHandler)
 	med.RegisterHandler(&file.DistributedUploadCommand{}, distributedUploadHandler)
+	med.RegisterHandler(&file.PrecheckUploadCommand{}, precheckUploadHandler)
 	med.RegisterHandler(&file.DeleteFileCommand{}, deleteFileHandler)
+	med.RegisterHandler(&file.RelocateFileCommand{}, relocateFileHandler)
+	med.RegisterHandler(&file.SetFileHeadersCommand{}, setFileHeadersHandler)
+	med.RegisterHandler(&file.SetFileLockCommand{}, setFileLockHandler)
 	med.RegisterHandler(&file.GetFileCommand{}, getFileHandler)
 	med.RegisterHandler(&file.ListFilesCommand{}, listFilesHandler)
 	med.RegisterHandler(&file.GenerateSignedURLCommand{}, generateSignedURLHandler)
+	med.RegisterHandler(&file.CreateFileTokenCommand{}, createFileTokenHandler)
+	med.RegisterHandler(&file.ListFileTokensCommand{}, listFileTokensHandler)
+	med.RegisterHandler(&file.RevokeFileTokenCommand{}, revokeFileTokenHandler)
+	med.RegisterHandler(&uploadgrant.CreateUploadGrantCommand{}, createUploadGrantHandler)
+	med.RegisterHandler(&uploadgrant.ListUploadGrantsCommand{}, listUploadGrantsHandler)
+	med.RegisterHandler(&uploadgrant.RevokeUploadGrantCommand{}, revokeUploadGrantHandler)
+	med.RegisterHandler(&uploadgrant.GetUploadLinkCommand{}, getUploadLinkHandler)
+	med.RegisterHandler(&uploadgrant.UploadWithGrantCommand{}, uploadWithGrantHandler)
+	med.RegisterHandler(&uploadgrant.CreateUploadPolicyCommand{}, createUploadPolicyHandler)
+	med.RegisterHandler(&uploadgrant.UploadWithPolicyCommand{}, uploadWithPolicyHandler)
+	med.RegisterHandler(&uploadsession.CreateUploadSessionCommand{}, createUploadSessionHandler)
+	med.RegisterHandler(&uploadsession.GetUploadSessionCommand{}, getUploadSessionHandler)
+	med.RegisterHandler(&uploadsession.UploadChunkCommand{}, uploadChunkHandler)
+	med.RegisterHandler(&uploadsession.CompleteUploadSessionCommand{}, completeUploadSessionHandler)
+	med.RegisterHandler(&uploadsession.AbortUploadSessionCommand{}, abortUploadSessionHandler)
+	med.RegisterHandler(&egress.GetBucketEgressCommand{}, getBucketEgressHandler)
+	med.RegisterHandler(&egress.GetUserEgressCommand{}, getUserEgressHandler)
+	med.RegisterHandler(&egress.SetUserEgressQuotaCommand{}, setUserEgressQuotaHandler)
+	med.RegisterHandler(&egress.GetAPIKeyEgressCommand{}, getAPIKeyEgressHandler)
+	med.RegisterHandler(&egress.SetAPIKeyEgressQuotaCommand{}, setAPIKeyEgressQuotaHandler)
+	med.RegisterHandler(&durability.GetBucketDurabilityCommand{}, getBucketDurabilityHandler)
 	
 	med.RegisterHandler(&apikey.CreateAPIKeyCommand{}, createAPIKeyHandler)
 	med.RegisterHandler(&apikey.ListAPIKeysCommand{}, listAPIKeysHandler)
 	med.RegisterHandler(&apikey.DeleteAPIKeyCommand{}, deleteAPIKeyHandler)
+	med.RegisterHandler(&apikey.ListBucketAPIKeysCommand{}, listBucketAPIKeysHandler)
+	med.RegisterHandler(&apikey.RevokeBucketAPIKeyCommand{}, revokeBucketAPIKeyHandler)
 
 	med.RegisterHandler(&node.RegisterNodeCommand{}, registerNodeHandler)
+	med.RegisterHandler(&node.UpdateNodeCommand{}, updateNodeHandler)
 	med.RegisterHandler(&node.ListNodesCommand{}, listNodesHandler)
+	med.RegisterHandler(&node.FailNodeCommand{}, failNodeHandler)
+	med.RegisterHandler(&node.SetNodeMaintenanceCommand{}, setNodeMaintenanceHandler)
+	med.RegisterHandler(&node.GetNodeDiagnosticsCommand{}, getNodeDiagnosticsHandler)
+	med.RegisterHandler(&node.GetNodeRepairCommand{}, getNodeRepairHandler)
+	med.RegisterHandler(&node.SelfRegisterNodeCommand{}, selfRegisterNodeHandler)
+	med.RegisterHandler(&node.CreateRegistrationTokenCommand{}, createRegistrationTokenHandler)
+	med.RegisterHandler(&node.ListRegistrationTokensCommand{}, listRegistrationTokensHandler)
+	med.RegisterHandler(&node.RevokeRegistrationTokenCommand{}, revokeRegistrationTokenHandler)
 
 	med.RegisterHandler(&setup.CheckSetupCommand{}, checkSetupHandler)
 	med.RegisterHandler(&setup.MasterSetupCommand{}, masterSetupHandler)
 	med.RegisterHandler(&setup.NodeSetupCommand{}, nodeSetupHandler)
 
+	med.RegisterHandler(&backup.RunBackupCommand{}, runBackupHandler)
+	med.RegisterHandler(&backup.RestoreBackupCommand{}, restoreBackupHandler)
+	med.RegisterHandler(&backup.ListBackupRunsCommand{}, listBackupRunsHandler)
+
+	med.RegisterHandler(&importer.ImportS3Command{}, importS3Handler)
+	med.RegisterHandler(&importer.GetS3ImportJobCommand{}, getS3ImportJobHandler)
+	med.RegisterHandler(&export.ExportS3Command{}, exportS3Handler)
+	med.RegisterHandler(&export.GetS3ExportJobCommand{}, getS3ExportJobHandler)
+	med.RegisterHandler(&event.ReplayBucketEventsCommand{}, replayBucketEventsHandler)
+	med.RegisterHandler(&webhook.CreateWebhookCommand{}, createWebhookHandler)
+	med.RegisterHandler(&webhook.ListWebhooksCommand{}, listWebhooksHandler)
+	med.RegisterHandler(&webhook.DeleteWebhookCommand{}, deleteWebhookHandler)
+	med.RegisterHandler(&webhook.RotateWebhookSecretCommand{}, rotateWebhookSecretHandler)
+	med.RegisterHandler(&alias.SetAliasCommand{}, setAliasHandler)
+	med.RegisterHandler(&alias.ListAliasesCommand{}, listAliasesHandler)
+	med.RegisterHandler(&alias.DeleteAliasCommand{}, deleteAliasHandler)
+	med.RegisterHandler(&bucketsync.GetBucketChangesCommand{}, getBucketChangesHandler)
+	med.RegisterHandler(&bucketsync.ConfigureBucketSyncCommand{}, configureBucketSyncHandler)
+	med.RegisterHandler(&bucketsync.GetBucketSyncCommand{}, getBucketSyncHandler)
+	med.RegisterHandler(&bucketsync.DeleteBucketSyncCommand{}, deleteBucketSyncHandler)
+	med.RegisterHandler(&bucketsync.RunBucketSyncCommand{}, runBucketSyncHandler)
+	med.RegisterHandler(&replication.ConfigureBucketReplicationCommand{}, configureBucketReplicationHandler)
+	med.RegisterHandler(&replication.GetBucketReplicationCommand{}, getBucketReplicationHandler)
+	med.RegisterHandler(&replication.GetReplicationStatusCommand{}, getReplicationStatusHandler)
+	med.RegisterHandler(&replication.DeleteBucketReplicationCommand{}, deleteBucketReplicationHandler)
+	med.RegisterHandler(&comment.CreateCommentCommand{}, createCommentHandler)
+	med.RegisterHandler(&comment.ListCommentsCommand{}, listCommentsHandler)
+	med.RegisterHandler(&comment.DeleteCommentCommand{}, deleteCommentHandler)
+	med.RegisterHandler(&notification.ListNotificationsCommand{}, listNotificationsHandler)
+	med.RegisterHandler(&notification.MarkNotificationReadCommand{}, markNotificationReadHandler)
+	med.RegisterHandler(&favorite.AddFavoriteCommand{}, addFavoriteHandler)
+	med.RegisterHandler(&favorite.RemoveFavoriteCommand{}, removeFavoriteHandler)
+	med.RegisterHandler(&favorite.ListFavoritesCommand{}, listFavoritesHandler)
+	med.RegisterHandler(&setting.GetSystemSettingsCommand{}, getSystemSettingsHandler)
+	med.RegisterHandler(&setting.UpdateSystemSettingsCommand{}, updateSystemSettingsHandler)
+	med.RegisterHandler(&reclamation.GetReclamationReportCommand{}, getReclamationReportHandler)
+	med.RegisterHandler(&reclamation.ReclaimStorageCommand{}, reclaimStorageHandler)
+	med.RegisterHandler(&admintask.RunAdminTaskCommand{}, runAdminTaskHandler)
+	med.RegisterHandler(&admintask.ListAdminTasksCommand{}, listAdminTasksHandler)
+	med.RegisterHandler(&rebalance.GetRebalancePlanCommand{}, getRebalancePlanHandler)
+	med.RegisterHandler(&rebalance.StartRebalanceCommand{}, startRebalanceHandler)
+	med.RegisterHandler(&residency.GetResidencyReportCommand{}, getResidencyReportHandler)
+	med.RegisterHandler(&stats.GetSystemStatsCommand{}, getSystemStatsHandler)
+	med.RegisterHandler(&permission.ExportPermissionsCommand{}, exportPermissionsHandler)
+	med.RegisterHandler(&clustermember.ListClusterMembersCommand{}, listClusterMembersHandler)
+	med.RegisterHandler(&signingkey.ListSigningKeysCommand{}, listSigningKeysHandler)
+	med.RegisterHandler(&signingkey.RotateSigningKeyCommand{}, rotateSigningKeyHandler)
+	med.RegisterHandler(&snapshot.CreateSnapshotCommand{}, createSnapshotHandler)
+	med.RegisterHandler(&snapshot.ListSnapshotsCommand{}, listSnapshotsHandler)
+	med.RegisterHandler(&snapshot.ListSnapshotFilesCommand{}, listSnapshotFilesHandler)
+	med.RegisterHandler(&snapshot.GetSnapshotFileCommand{}, getSnapshotFileHandler)
+	med.RegisterHandler(&snapshot.DeleteSnapshotCommand{}, deleteSnapshotHandler)
+	med.RegisterHandler(&snapshot.DiffSnapshotsCommand{}, diffSnapshotsHandler)
+	med.RegisterHandler(&user.GetActivityCommand{}, getActivityHandler)
+
+	// Uploads are refused while scanning is misconfigured, so refuse to start instead
+	if _, err := scanning.NewScanner(config.GetSettings()); err != nil {
+		log.Fatalf("Invalid upload scanning configuration: %v", err)
+	}
+
+	// Start background schedulers. Those that must run on one server at a time run on the
+	// cluster leader, the rest on every server.
+	backupScheduler := services.NewBackupScheduler(med)
+	if err := backupScheduler.Validate(); err != nil {
+		log.Fatalf("Failed to start backup scheduler: %v", err)
+	}
+	member.Lead("backup scheduler", func() {
+		if err := backupScheduler.Start(); err != nil {
+			log.Printf("Failed to start backup scheduler: %v", err)
+		}
+	}, backupScheduler.Stop)
+
+	videoWorker := services.NewVideoWorker(dbContext)
+	member.Lead("video worker", videoWorker.Start, videoWorker.Stop)
+
+	lifecycleWorker := services.NewLifecycleWorker(dbContext, med)
+	member.Lead("lifecycle worker", lifecycleWorker.Start, lifecycleWorker.Stop)
+
+	bucketSyncWorker := services.NewBucketSyncWorker(dbContext, med)
+	member.Lead("bucket sync worker", bucketSyncWorker.Start, bucketSyncWorker.Stop)
+
+	replicationWorker := services.NewReplicationWorker(dbContext, med)
+	member.Lead("replication worker", replicationWorker.Start, replicationWorker.Stop)
+
+	usageAlertWorker := services.NewUsageAlertWorker(dbContext)
+	member.Lead("usage alert worker", usageAlertWorker.Start, usageAlertWorker.Stop)
+
+	rebalanceWorker := services.NewRebalanceWorker(dbContext, med)
+	member.Lead("rebalance worker", rebalanceWorker.Start, rebalanceWorker.Stop)
+
+	member.Start()
+	defer member.Stop()
+
+	settingsReloader := services.NewSettingsReloader(dbContext)
+	settingsReloader.Start()
+	defer settingsReloader.Stop()
+
+	uploadCleanupWorker := services.NewUploadCleanupWorker(dbContext)
+	uploadCleanupWorker.Start()
+	defer uploadCleanupWorker.Stop()
+
+	concurrency := metrics.NewConcurrency()
+	saturationMonitor := metrics.NewSaturationMonitor(dbContext, concurrency)
+	saturationMonitor.Start()
+	defer saturationMonitor.Stop()
+
+	scannerHealthMonitor := services.NewScannerHealthMonitor(dbContext)
+	scannerHealthMonitor.Start()
+	defer scannerHealthMonitor.Stop()
+
+	jobRunner := jobs.NewRunner(dbContext)
+	jobRunner.Register(jobs.TypeBucketDelete, deleteBucketHandler.RunDeletionJob)
+	jobRunner.Register(jobs.TypeNodeRepair, failNodeHandler.RunRepairJob)
+	jobRunner.Register(jobs.TypeAdminTask, runAdminTaskHandler.RunTaskJob)
+	jobRunner.Register(jobs.TypeRebalance, startRebalanceHandler.RunRebalanceJob)
+	jobRunner.Register(jobs.TypeWebhookDelivery, webhookDeliverer.RunDeliveryJob)
+	jobRunner.Start()
+	defer jobRunner.Stop()
+
+	sftpServer := sftp.NewServer(dbContext, med, authService)
+	if err := sftpServer.Start(); err != nil {
+		log.Fatalf("Failed to start SFTP server: %v", err)
+	}
+	defer sftpServer.Stop()
+
+	meter := metering.NewMeter(dbContext)
+	meter.Start()
+	defer meter.Stop()
+
 	// Initialize controllers
 	setupController := controllers.NewSetupController(med, validator)
 	userController := controllers.NewUserController(med, validator, authService)
+	twoFactorController := controllers.NewTwoFactorController(med, validator, authService)
+	roleController := controllers.NewRoleController(med, validator, authService)
 	bucketController := controllers.NewBucketController(med, validator, authService)
-	fileController := controllers.NewFileController(med, validator, authService, dbContext)
+	fileController := controllers.NewFileController(med, validator, authService, dbContext, meter)
 	nodeController := controllers.NewNodeController(med, validator, authService, dbContext)
 	apiKeyController := controllers.NewAPIKeyController(med, validator, authService)
+	backupController := controllers.NewBackupController(med, validator, authService)
+	importController := controllers.NewImportController(med, validator, authService)
+	exportController := controllers.NewExportController(med, validator, authService)
+	eventController := controllers.NewEventController(med, validator, authService)
+	webhookController := controllers.NewWebhookController(med, validator, authService)
+	aliasController := controllers.NewAliasController(med, validator, authService)
+	bucketSyncController := controllers.NewBucketSyncController(med, validator, authService)
+	replicationController := controllers.NewReplicationController(med, validator, authService)
+	commentController := controllers.NewCommentController(med, validator, authService)
+	favoriteController := controllers.NewFavoriteController(med, validator, authService)
+	snapshotController := controllers.NewSnapshotController(med, validator, authService)
+	uploadGrantController := controllers.NewUploadGrantController(med, validator, authService)
+	uploadSessionController := controllers.NewUploadSessionController(med, validator, authService)
+	transferController := controllers.NewTransferController(authService)
+	egressController := controllers.NewEgressController(med, validator, authService)
+	durabilityController := controllers.NewDurabilityController(med, validator, authService)
+	settingsController := controllers.NewSettingsController(med, validator, authService)
+	reclamationController := controllers.NewReclamationController(med, validator)
+	adminTaskController := controllers.NewAdminTaskController(med, validator, authService)
+	rebalanceController := controllers.NewRebalanceController(med, authService)
+	residencyController := controllers.NewResidencyController(med)
+	statsController := controllers.NewStatsController(med)
+	permissionController := controllers.NewPermissionController(med)
+	clusterController := controllers.NewClusterController(med)
+	signingKeyController := controllers.NewSigningKeyController(med, validator, authService)
+	jobController := controllers.NewJobController(med, validator, authService)
+	metricsController := controllers.NewMetricsController(concurrency, saturationMonitor, storage.DefaultNodeCache())
+	webDAVController := controllers.NewWebDAVController(med, authService, dbContext)
+	websiteController := controllers.NewWebsiteController(dbContext, meter)
+	domainResolver := domains.NewResolver(dbContext)
+	domainController := controllers.NewDomainController(domainResolver, websiteController, fileController)
+
+	routes := controllers.Routes(controllers.Handlers{
+		Setup:         setupController,
+		User:          userController,
+		TwoFactor:     twoFactorController,
+		Role:          roleController,
+		Bucket:        bucketController,
+		File:          fileController,
+		Node:          nodeController,
+		APIKey:        apiKeyController,
+		Backup:        backupController,
+		Import:        importController,
+		Export:        exportController,
+		Event:         eventController,
+		Webhook:       webhookController,
+		Alias:         aliasController,
+		BucketSync:    bucketSyncController,
+		Replication:   replicationController,
+		Comment:       commentController,
+		Favorite:      favoriteController,
+		Snapshot:      snapshotController,
+		UploadGrant:   uploadGrantController,
+		UploadSession: uploadSessionController,
+		Transfer:      transferController,
+		Egress:        egressController,
+		Durability:    durabilityController,
+		Settings:      settingsController,
+		Reclamation:   reclamationController,
+		AdminTask:     adminTaskController,
+		Rebalance:     rebalanceController,
+		Residency:     residencyController,
+		Stats:         statsController,
+		Permission:    permissionController,
+		Cluster:       clusterController,
+		SigningKey:    signingKeyController,
+		Job:           jobController,
+		Metrics:       metricsController,
+		WebDAV:        webDAVController,
+		Website:       websiteController,
+		BucketCORS:    middleware.BucketCORS(dbContext),
+		Idempotency:   middleware.Idempotency(dbContext),
+		// Swagger security comes from the route table rather than the annotations
+		Swagger: swagger.New(swagger.Config{InstanceName: "routes"}),
+	})
+	if err := routes.Validate(); err != nil {
+		log.Fatalf("Invalid route table: %v", err)
+	}
+	routes.Document("routes", docs.SwaggerInfo)
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:      "SHBucket v2.0.0",
 		ReadTimeout:  time.Second * 30,
 		WriteTimeout: time.Second * 30,
+		// Bodies over BodyLimit are streamed rather than refused, middleware.BodyLimit refuses them
+		// except on upload routes, which stream to storage instead of holding uploads in memory
+		BodyLimit:                    int(config.GetSettings().BodyLimit),
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		// WebDAV clients use methods of their own
+		RequestMethods: append(append([]string{}, fiber.DefaultMethods...), controllers.WebDAVMethods...),
+		// Behind trusted reverse proxies the client is the one X-Forwarded-For names
+		ProxyHeader:             proxyHeader(config.GetSettings().TrustedProxies),
+		EnableTrustedProxyCheck: len(config.GetSettings().TrustedProxies) > 0,
+		TrustedProxies:          config.GetSettings().TrustedProxies,
+		EnableIPValidation:      true,
+		// Errors returned by handlers and middleware are answered with their status and code
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
 	// Middleware
 	app.Use(recover.New())
 	app.Use(logger.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "http://localhost:3000,http://127.0.0.1:3000",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization,X-Requested-With,X-API-Key",
-	}))
+	app.Use(middleware.TrackConcurrency(concurrency))
+	app.Use(metering.Untap)
+	app.Use(middleware.BodyLimit(config.GetSettings().BodyLimit, routes.Streamed()...))
+	// Global CORS for the API and dashboard. File-serving routes apply per-bucket rules instead
+	app.Use(middleware.GlobalCORS())
 
+	// Requests for a bucket's website or custom domain are served from the bucket, before any other route
+	app.Use(domainController.Serve)
 
 	// Serve static files from web/dist
 	app.Static("/", "./web/dist")
-	
-	// Swagger documentation
-	app.Get("/swagger/*", swagger.HandlerDefault)
-
-	// API routes
-	api := app.Group("/api/v1")
-
-	// Health check
-	api.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status": "healthy",
-			"time":   time.Now(),
-		})
-	})
-
-	// Setup routes (no auth required)
-	setup := api.Group("/setup")
-	setup.Get("/status", setupController.CheckSetup)
-	setup.Post("/master", setupController.SetupMaster)
-	setup.Post("/node", setupController.SetupNode)
-	setup.Get("/info", setupController.GetSystemInfo)
-
-	// Node self-registration routes (no auth required)
-	nodeSetup := api.Group("/node")
-	nodeSetup.Post("/register", nodeController.SelfRegister)
-	nodeSetup.Get("/auth-key", nodeController.GetAuthKey)
-	nodeSetup.Post("/ping", nodeController.Ping)
-
-	// Auth routes
-	auth := api.Group("/auth")
-	auth.Post("/login", userController.Login)
-	auth.Post("/register", userController.Register)
-	auth.Post("/logout", authService.RequireRoleOrAPIKey("viewer", dbContext), userController.Logout)
-	auth.Post("/change-password", authService.RequireRoleOrAPIKey("viewer", dbContext), userController.ChangePassword)
-
-	// User routes
-	users := api.Group("/users", authService.RequireRoleOrAPIKey("admin", dbContext))
-	users.Get("/", userController.ListUsers)
-	users.Get("/:id", userController.GetUser)
-
-	// Bucket routes
-	buckets := api.Group("/buckets", authService.RequireRoleOrAPIKey("viewer", dbContext))
-	buckets.Get("/", bucketController.ListBuckets)
-	buckets.Post("/", authService.RequireRoleOrAPIKey("editor", dbContext), bucketController.CreateBucket)
-	buckets.Put("/:id", authService.RequireRoleOrAPIKey("editor", dbContext), bucketController.UpdateBucket)
-	buckets.Get("/:id", bucketController.GetBucket)
-	buckets.Delete("/:id", authService.RequireRoleOrAPIKey("manager", dbContext), bucketController.DeleteBucket)
-
-	// File serving route (no auth middleware - handles auth internally)  
-	api.Get("/file/:bucketId/:fileId", fileController.ServeFile)
-	
-	// Internal routes for distributed storage (auth handled internally with node auth key)
-	api.Post("/internal/upload", fileController.InternalUpload)
-	api.Delete("/internal/delete", fileController.InternalDelete)
-	api.Get("/internal/file", fileController.InternalFile)
-
-	// File management routes (require auth)
-	files := api.Group("/buckets/:bucketId/files")
-	files.Get("/", authService.RequireRoleOrAPIKey("viewer", dbContext), fileController.ListFiles)
-	files.Post("/", authService.RequireRoleOrAPIKey("editor", dbContext), fileController.UploadFile)
-	files.Get("/:fileId/info", authService.RequireRoleOrAPIKey("viewer", dbContext), fileController.GetFile)  // Metadata only
-	files.Delete("/:fileId", authService.RequireRoleOrAPIKey("editor", dbContext), fileController.DeleteFile)
-	files.Post("/:fileId/signed-url", authService.RequireRoleOrAPIKey("viewer", dbContext), fileController.GenerateSignedURL)
-	
-	// API Key routes
-	apiKeys := api.Group("/api-keys", authService.RequireRoleOrAPIKey("viewer", dbContext))
-	apiKeys.Post("/", apiKeyController.CreateAPIKey)
-	apiKeys.Get("/", apiKeyController.ListAPIKeys)
-	apiKeys.Delete("/:id", apiKeyController.DeleteAPIKey)
-
-	// Node management routes
-	nodes := api.Group("/nodes", authService.RequireRoleOrAPIKey("manager", dbContext))
-	nodes.Get("/", nodeController.ListNodes)
-	nodes.Post("/", nodeController.RegisterNode)
-	nodes.Post("/install", nodeController.InstallNode)
-	nodes.Get("/health", nodeController.CheckAllNodesHealth)
-	nodes.Get("/:id/health", nodeController.HealthCheck)
-	nodes.Delete("/:id", nodeController.DeleteNode)
-
-	// Storage node routes
-	storageNodes := api.Group("/storage-nodes", authService.RequireRoleOrAPIKey("manager", dbContext))
-	storageNodes.Get("/", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"storage_nodes": []interface{}{},
-			"message":       "Storage nodes not yet implemented",
-		})
-	})
 
-	// Catch-all route for React Router (SPA)
-	app.Get("*", func(c *fiber.Ctx) error {
-		return c.SendFile("./web/dist/index.html")
+	// Every other route, with the access, rate limit and body limit it declares
+	routes.Register(app, routing.Guards{
+		Authorize: func(role, permission string) fiber.Handler {
+			return authService.RequireRoleOrAPIKey(role, permission, dbContext)
+		},
+		RateLimit: middleware.RateLimit(),
+		Context: func(operation routing.Operation) fiber.Handler {
+			switch operation {
+			case routing.TransferOperation:
+				return middleware.RequestContext(0)
+			case routing.MaintenanceOperation:
+				return middleware.RequestContext(time.Duration(config.GetSettings().MaintenanceRequestTimeout) * time.Second)
+			}
+			return middleware.RequestContext(time.Duration(config.GetSettings().RequestTimeout) * time.Second)
+		},
+		Params: binding.Params,
 	})
 
-	// Debug: Print all registered routes
-	for _, route := range app.GetRoutes() {
-		log.Printf("Registered route: %s %s", route.Method, route.Path)
+	if settings.Debug {
+		for _, route := range app.GetRoutes() {
+			log.Printf("Registered route: %s %s", route.Method, route.Path)
+		}
 	}
 
 	// Start server
-	host := os.Getenv("HOST")
+	host := config.Getenv("HOST")
 	if host == "" {
 		host = "0.0.0.0"
 	}
+	if unknown := config.UnknownFileKeys(); len(unknown) > 0 {
+		log.Printf("Warning: the config file sets %s, which no setting reads", strings.Join(unknown, ", "))
+	}
 
 	log.Printf("SHBucket v2.0 starting on %s:%s", host, port)
 	log.Printf("Database: %s", maskDatabaseURL(databaseURL))
 	log.Printf("Swagger documentation: http://%s:%s/swagger/", host, port)
 	log.Printf("Health check: http://%s:%s/api/v1/health", host, port)
 
-	log.Fatal(app.Listen(host + ":" + port))
+	tlsConfig, err := domains.TLSConfig(config.GetSettings(), domainResolver)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+
+	go func() {
+		listener, err := net.Listen("tcp", host+":"+port)
+		if err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+		// Downloads are metered on the connection, so egress quotas can count and throttle them
+		listener = metering.Listener(listener)
+		if tlsConfig != nil {
+			log.Printf("Serving HTTPS on %s:%s", host, port)
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+		if err := app.Listener(listener); err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Wait for a termination signal, then stop accepting requests and let in-flight uploads finish
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	shutdownTimeout := time.Duration(config.GetSettings().ShutdownTimeout) * time.Second
+	log.Printf("Shutting down, waiting up to %s for in-flight requests...", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		log.Printf("Warning: server did not shut down cleanly: %v", err)
+	}
+	if !storage.DrainTransfers(shutdownCtx) {
+		log.Printf("Warning: shutdown timeout reached with transfers still in progress")
+	}
+	// Only this server's partial writes, other servers sharing the storage directories keep theirs
+	if removed := storage.CleanupSpoolFiles(); removed > 0 {
+		log.Printf("Removed %d partially written file(s)", removed)
+	}
+
+	log.Println("Server stopped")
 }
 
 
+// proxyHeader is the header naming the client, only read when a trusted proxy sent it
+func proxyHeader(trustedProxies []string) string {
+	if len(trustedProxies) == 0 {
+		return ""
+	}
+	return fiber.HeaderXForwardedFor
+}
+
 func maskDatabaseURL(url string) string {
 	if len(url) > 20 {
 		return url[:10] + "***" + url[len(url)-7:]
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func runAlias(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError("subcommand required")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) != 2 {
+			return usageError("bucket required")
+		}
+		bucket, err := resolveBucket(c.ctx, api, args[1])
+		if err != nil {
+			return err
+		}
+		aliases, err := api.ListAliases(c.ctx, bucket.ID)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tFILE\tUPDATED\tURL")
+		for _, alias := range aliases {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", alias.Name, alias.FileID, alias.UpdatedAt.Format(time.RFC3339), alias.URL)
+		}
+		return w.Flush()
+
+	case "set":
+		if len(args) != 4 {
+			return usageError("bucket, alias and file required")
+		}
+		bucket, err := resolveBucket(c.ctx, api, args[1])
+		if err != nil {
+			return err
+		}
+		file, err := resolveFile(c.ctx, api, bucket.ID, args[3])
+		if err != nil {
+			return err
+		}
+		alias, err := api.SetAlias(c.ctx, bucket.ID, args[2], file.ID, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s now points at %s (%s)\n%s\n", alias.Name, file.Name, file.ID, alias.URL)
+
+	case "delete":
+		if len(args) != 3 {
+			return usageError("bucket and alias required")
+		}
+		bucket, err := resolveBucket(c.ctx, api, args[1])
+		if err != nil {
+			return err
+		}
+		if err := api.DeleteAlias(c.ctx, bucket.ID, args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️ Deleted alias %s\n", args[2])
+
+	default:
+		return usageError("unknown subcommand %q", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"shbucket/pkg/client"
+)
+
+func runLogin(c *cli, args []string) error {
+	fs := newFlagSet("login")
+	serverURL := fs.String("url", "", "server URL, saved to the profile")
+	email := fs.String("email", "", "account email")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+	apiKey := fs.String("api-key", "", "save an API key instead of logging in with a password")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	saved := c.cfg.Profiles[c.profileName]
+	if *serverURL != "" {
+		c.profile.URL = *serverURL
+	}
+	if c.profile.URL == "" {
+		return usageError("server URL required")
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+
+	if *apiKey != "" {
+		c.profile.APIKey, c.profile.Token = *apiKey, ""
+		api, err := c.client()
+		if err != nil {
+			return err
+		}
+		// Any authenticated call proves the key works
+		if _, err := api.ListBuckets(c.ctx, &client.ListOptions{Limit: 1}); err != nil {
+			return fmt.Errorf("API key rejected: %w", err)
+		}
+		saved.URL, saved.APIKey, saved.Token = c.profile.URL, *apiKey, ""
+	} else {
+		if *email == "" {
+			value, err := prompt(stdin, "Email: ")
+			if err != nil {
+				return err
+			}
+			*email = value
+		}
+
+		password := os.Getenv("SHBUCKET_PASSWORD")
+		if *passwordStdin {
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			password = strings.TrimRight(string(data), "\r\n")
+		} else if password == "" {
+			value, err := prompt(stdin, "Password: ")
+			if err != nil {
+				return err
+			}
+			password = value
+		}
+
+		c.profile.Token, c.profile.APIKey = "", ""
+		api, err := c.client()
+		if err != nil {
+			return err
+		}
+		session, err := api.Login(c.ctx, *email, password)
+		if err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+		saved.URL, saved.Token, saved.APIKey = c.profile.URL, session.Token, ""
+		fmt.Printf("✅ Logged in as %s (%s)\n", session.User.Email, session.User.Role)
+	}
+
+	c.cfg.Current = c.profileName
+	if err := c.cfg.save(); err != nil {
+		return err
+	}
+	fmt.Printf("💾 Saved profile %q\n", c.profileName)
+	return nil
+}
+
+func runLogout(c *cli, args []string) error {
+	saved, ok := c.cfg.Profiles[c.profileName]
+	if !ok || (saved.Token == "" && saved.APIKey == "") {
+		fmt.Printf("Profile %q has no saved credentials\n", c.profileName)
+		return nil
+	}
+
+	if saved.Token != "" && saved.URL != "" {
+		// Revoke the session server-side too, the local copy is removed either way
+		if api, err := newClient(saved); err == nil {
+			api.Logout(c.ctx)
+		}
+	}
+
+	saved.Token, saved.APIKey = "", ""
+	if err := c.cfg.save(); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Logged out of profile %q\n", c.profileName)
+	return nil
+}
+func runProfile(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError("subcommand required")
+	}
+
+	switch args[0] {
+	case "list":
+		for _, name := range c.cfg.names() {
+			marker := " "
+			if name == c.cfg.Current {
+				marker = "*"
+			}
+			profile := c.cfg.Profiles[name]
+			fmt.Printf("%s %-16s %-40s %s\n", marker, name, profile.URL, credentialKind(profile))
+		}
+
+	case "use":
+		if len(args) < 2 {
+			return usageError("profile name required")
+		}
+		if _, ok := c.cfg.Profiles[args[1]]; !ok {
+			return fmt.Errorf("profile %q not found", args[1])
+		}
+		c.cfg.Current = args[1]
+		if err := c.cfg.save(); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Switched to profile %q\n", args[1])
+
+	case "show":
+		fmt.Printf("Profile:     %s\n", c.profileName)
+		fmt.Printf("Server:      %s\n", c.profile.URL)
+		fmt.Printf("Credentials: %s\n", credentialKind(c.profile))
+
+	case "delete":
+		if len(args) < 2 {
+			return usageError("profile name required")
+		}
+		if _, ok := c.cfg.Profiles[args[1]]; !ok {
+			return fmt.Errorf("profile %q not found", args[1])
+		}
+		delete(c.cfg.Profiles, args[1])
+		if c.cfg.Current == args[1] {
+			c.cfg.Current = "default"
+		}
+		if err := c.cfg.save(); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️ Deleted profile %q\n", args[1])
+
+	default:
+		return usageError("unknown subcommand %q", args[0])
+	}
+	return nil
+}
+
+func credentialKind(profile *Profile) string {
+	switch {
+	case profile.APIKey != "":
+		return "api key"
+	case profile.Token != "":
+		return "token"
+	default:
+		return "none"
+	}
+}
+
+// prompt reads one line from stdin. Input is echoed, use --password-stdin or SHBUCKET_PASSWORD in scripts.
+func prompt(stdin *bufio.Reader, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
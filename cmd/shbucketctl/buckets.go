@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"shbucket/pkg/client"
+)
+
+func runBucket(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError("subcommand required")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tFILES\tSIZE\tPUBLIC")
+		for bucket, err := range api.Buckets(c.ctx) {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%t\n", bucket.ID, bucket.Name, bucket.Stats.TotalFiles,
+				formatSize(bucket.Stats.TotalSize), bucket.Settings.PublicRead)
+		}
+		return w.Flush()
+
+	case "create":
+		fs := newFlagSet("bucket create")
+		description := fs.String("description", "", "bucket description")
+		public := fs.Bool("public", false, "allow reads without authentication")
+		versioning := fs.Bool("versioning", false, "keep previous versions of overwritten files")
+		encryption := fs.Bool("encryption", false, "encrypt files at rest")
+		maxFileSize := fs.Int64("max-file-size", 0, "largest file accepted in bytes, 0 for the server default")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return usageError("bucket name required")
+		}
+
+		bucket, err := api.CreateBucket(c.ctx, client.CreateBucketInput{
+			Name:        positional[0],
+			Description: *description,
+			Settings: &client.BucketSettings{
+				PublicRead:     *public,
+				Versioning:     *versioning,
+				Encryption:     *encryption,
+				MaxFileSize:    *maxFileSize,
+				AllowOverwrite: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created bucket %s (%s)\n", bucket.Name, bucket.ID)
+
+	case "info":
+		if len(args) < 2 {
+			return usageError("bucket required")
+		}
+		bucket, err := resolveBucket(c.ctx, api, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("ID:          %s\n", bucket.ID)
+		fmt.Printf("Name:        %s\n", bucket.Name)
+		fmt.Printf("Description: %s\n", bucket.Description)
+		fmt.Printf("Files:       %d\n", bucket.Stats.TotalFiles)
+		fmt.Printf("Size:        %s\n", formatSize(bucket.Stats.TotalSize))
+		fmt.Printf("Public:      %t\n", bucket.Settings.PublicRead)
+		fmt.Printf("Encrypted:   %t\n", bucket.Settings.Encryption)
+
+	case "delete":
+		fs := newFlagSet("bucket delete")
+		force := fs.Bool("force", false, "delete the bucket's files too")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return usageError("bucket required")
+		}
+		bucket, err := resolveBucket(c.ctx, api, positional[0])
+		if err != nil {
+			return err
+		}
+
+		deletion, err := api.DeleteBucket(c.ctx, bucket.ID, *force)
+		if err != nil {
+			return err
+		}
+		if deletion != nil {
+			fmt.Printf("⏳ Deleting bucket %s in the background (%d files)\n", bucket.Name, deletion.TotalFiles)
+			return nil
+		}
+		fmt.Printf("🗑️ Deleted bucket %s\n", bucket.Name)
+
+	default:
+		return usageError("unknown subcommand %q", args[0])
+	}
+	return nil
+}
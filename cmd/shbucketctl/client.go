@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/pkg/client"
+)
+
+func newClient(profile *Profile) (*client.Client, error) {
+	if profile.URL == "" {
+		return nil, fmt.Errorf("no server configured, run `shbucketctl login --url <server>` or set SHBUCKET_URL")
+	}
+	opts := []client.Option{client.WithUserAgent("shbucketctl")}
+	if profile.APIKey != "" {
+		opts = append(opts, client.WithAPIKey(profile.APIKey))
+	} else if profile.Token != "" {
+		opts = append(opts, client.WithToken(profile.Token))
+	}
+	return client.New(profile.URL, opts...)
+}
+
+// resolveBucket accepts a bucket ID or name
+func resolveBucket(ctx context.Context, c *client.Client, ref string) (*client.Bucket, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return c.GetBucket(ctx, id)
+	}
+	bucket, err := c.FindBucket(ctx, ref)
+	if client.IsNotFound(err) {
+		return nil, fmt.Errorf("bucket %q not found", ref)
+	}
+	return bucket, err
+}
+
+// resolveFile accepts a file ID or name. A name matching several versions resolves to the latest.
+func resolveFile(ctx context.Context, c *client.Client, bucketID uuid.UUID, ref string) (*client.File, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return c.GetFile(ctx, bucketID, id)
+	}
+
+	var match *client.File
+	for file, err := range c.Files(ctx, bucketID) {
+		if err != nil {
+			return nil, err
+		}
+		if (file.Name == ref || file.OriginalName == ref) && (match == nil || file.Version > match.Version) {
+			match = &file
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("file %q not found", ref)
+	}
+	return match, nil
+}
+
+// resolveNode accepts a node ID or name
+func resolveNode(ctx context.Context, c *client.Client, ref string) (*client.Node, error) {
+	id, isID := uuid.Parse(ref)
+	for node, err := range c.Nodes(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		if (isID == nil && node.ID == id) || node.Name == ref {
+			return &node, nil
+		}
+	}
+	return nil, fmt.Errorf("node %q not found", ref)
+}
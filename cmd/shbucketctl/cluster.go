@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func runCluster(c *cli, args []string) error {
+	if len(args) > 0 {
+		return usageError("unexpected arguments")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	members, servedBy, err := api.ListClusterMembers(c.ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tHOST\tPROFILE\tROLE\tSTARTED\tLAST HEARTBEAT")
+	for _, member := range members {
+		role := "member"
+		if member.Leader {
+			role = "leader"
+		}
+		if member.ID == servedBy {
+			role += " (answered)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", member.ID, member.Hostname, member.Profile, role,
+			member.StartedAt.Format(time.RFC3339), member.HeartbeatAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
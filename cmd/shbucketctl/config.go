@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Profile is a saved server and the credentials used for it
+type Profile struct {
+	URL    string `json:"url"`
+	Token  string `json:"token,omitempty"`   // JWT from login
+	APIKey string `json:"api_key,omitempty"` // used instead of a token when set
+}
+
+// Config is the file holding every profile, by default ~/.config/shbucketctl/config.json
+type Config struct {
+	Current  string              `json:"current"`
+	Profiles map[string]*Profile `json:"profiles"`
+
+	path string
+}
+
+func configPath() (string, error) {
+	if path := os.Getenv("SHBUCKETCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "shbucketctl", "config.json"), nil
+}
+
+func loadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{Current: "default", Profiles: map[string]*Profile{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+	return cfg, nil
+}
+
+// save writes the config readable only by its owner, since it holds credentials
+func (c *Config) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// profile returns the named profile, or the current one when name is empty, creating it if needed
+func (c *Config) profile(name string) (string, *Profile) {
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		name = "default"
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		profile = &Profile{}
+		c.Profiles[name] = profile
+	}
+	return name, profile
+}
+
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/pkg/client"
+)
+
+func runList(c *cli, args []string) error {
+	if len(args) != 1 {
+		return usageError("bucket required")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	bucket, err := resolveBucket(c.ctx, api, args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSIZE\tTYPE\tVERSION")
+	for file, err := range api.Files(c.ctx, bucket.ID) {
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", file.ID, file.OriginalName, formatSize(file.Size), file.MimeType, file.Version)
+	}
+	return w.Flush()
+}
+
+// runUpload uploads files concurrently. The API takes each file as one request, so parallelism is across files.
+func runUpload(c *cli, args []string) error {
+	fs := newFlagSet("upload")
+	parallel := fs.Int("parallel", 4, "number of files uploaded at once")
+	precheck := fs.Bool("precheck", false, "skip sending files whose content the bucket already holds")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return usageError("bucket and at least one file required")
+	}
+	if *parallel < 1 {
+		*parallel = 1
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	bucket, err := resolveBucket(c.ctx, api, positional[0])
+	if err != nil {
+		return err
+	}
+
+	paths := positional[1:]
+	var total int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		total += info.Size()
+	}
+
+	progress := newProgress(fmt.Sprintf("Uploading %d file(s)", len(paths)), total, c.quiet)
+	results := make([]error, len(paths))
+	uploaded := make([]client.File, len(paths))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, *parallel)
+	for i, path := range paths {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			file, err := uploadFile(c, api, bucket.ID, path, *precheck, progress)
+			results[i] = err
+			if file != nil {
+				uploaded[i] = *file
+			}
+		}()
+	}
+	wg.Wait()
+	progress.finish()
+
+	failed := 0
+	for i, path := range paths {
+		if results[i] != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", path, results[i])
+			continue
+		}
+		fmt.Printf("✅ %s -> %s\n", path, uploaded[i].ID)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d uploads failed", failed, len(paths))
+	}
+	return nil
+}
+
+// uploadFile uploads one file, adding its bytes to the shared progress bar as they are sent.
+// With precheck, a file whose content the bucket already holds is stored from it instead.
+func uploadFile(c *cli, api *client.Client, bucketID uuid.UUID, path string, precheck bool, progress *Progress) (*client.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if precheck {
+		hash := sha256.New()
+		size, err := io.Copy(hash, f)
+		if err != nil {
+			return nil, err
+		}
+		file, exists, err := api.PrecheckUpload(c.ctx, bucketID, filepath.Base(path), hex.EncodeToString(hash.Sum(nil)), size, contentType)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			progress.add(size)
+			return file, nil
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	var reported int64
+	return api.Upload(c.ctx, bucketID, filepath.Base(path), f, &client.UploadOptions{
+		ContentType: contentType,
+		Progress: func(sent int64) {
+			// A retried upload starts again from zero
+			if sent < reported {
+				progress.add(-reported)
+				reported = 0
+			}
+			progress.add(sent - reported)
+			reported = sent
+		},
+	})
+}
+
+func runDownload(c *cli, args []string) error {
+	fs := newFlagSet("download")
+	output := fs.String("o", "", "output path, - for stdout, defaults to the file's name")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageError("bucket and file required")
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	bucket, err := resolveBucket(c.ctx, api, positional[0])
+	if err != nil {
+		return err
+	}
+	file, err := resolveFile(c.ctx, api, bucket.ID, positional[1])
+	if err != nil {
+		return err
+	}
+
+	content, err := api.Download(c.ctx, bucket.ID, file.ID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	path := *output
+	if path == "" {
+		path = filepath.Base(file.OriginalName)
+	}
+
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		// Written beside the destination and renamed, so a failed download leaves no partial file
+		tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		out = tmp
+	}
+
+	progress := newProgress("Downloading "+file.OriginalName, file.Size, c.quiet || path == "-")
+	_, err = io.Copy(out, progress.reader(content))
+	progress.finish()
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	if tmp, ok := out.(*os.File); ok && tmp != os.Stdout {
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✅ Saved %s\n", path)
+	}
+	return nil
+}
+
+func runRemove(c *cli, args []string) error {
+	if len(args) < 2 {
+		return usageError("bucket and at least one file required")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	bucket, err := resolveBucket(c.ctx, api, args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range args[1:] {
+		file, err := resolveFile(c.ctx, api, bucket.ID, ref)
+		if err != nil {
+			return err
+		}
+		if err := api.DeleteFile(c.ctx, bucket.ID, file.ID); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		fmt.Printf("🗑️ Deleted %s\n", file.OriginalName)
+	}
+	return nil
+}
+
+func runSign(c *cli, args []string) error {
+	fs := newFlagSet("sign")
+	expires := fs.Duration("expires", time.Hour, "how long the URL stays valid, between 1m and 168h")
+	singleUse := fs.Bool("single-use", false, "invalidate the URL after its first download")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageError("bucket and file required")
+	}
+
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+	bucket, err := resolveBucket(c.ctx, api, positional[0])
+	if err != nil {
+		return err
+	}
+	file, err := resolveFile(c.ctx, api, bucket.ID, positional[1])
+	if err != nil {
+		return err
+	}
+
+	signed, err := api.GenerateSignedURL(c.ctx, bucket.ID, file.ID, *expires, *singleUse)
+	if err != nil {
+		return err
+	}
+	fmt.Println(signed.URL)
+	fmt.Fprintf(os.Stderr, "Expires %s\n", signed.ExpiresAt.Local().Format(time.RFC1123))
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"shbucket/pkg/client"
+)
+
+// cli holds the state shared by every command: the loaded config and the selected profile
+type cli struct {
+	ctx         context.Context
+	cfg         *Config
+	profileName string
+	profile     *Profile
+	quiet       bool
+	api         *client.Client
+}
+
+type command struct {
+	usage string
+	run   func(*cli, []string) error
+}
+
+var commands = map[string]command{
+	"login":    {"login [--url URL] [--email EMAIL] [--password-stdin] [--api-key KEY]", runLogin},
+	"logout":   {"logout", runLogout},
+	"profile":  {"profile list | use NAME | show | delete NAME", runProfile},
+	"bucket":   {"bucket list | create NAME [flags] | info BUCKET | delete BUCKET [--force]", runBucket},
+	"ls":       {"ls BUCKET", runList},
+	"upload":   {"upload BUCKET FILE... [--parallel N] [--precheck]", runUpload},
+	"download": {"download BUCKET FILE [-o PATH]", runDownload},
+	"rm":       {"rm BUCKET FILE...", runRemove},
+	"sign":     {"sign BUCKET FILE [--expires DURATION] [--single-use]", runSign},
+	"alias":    {"alias list BUCKET | set BUCKET NAME FILE | delete BUCKET NAME", runAlias},
+	"node":     {"node list | add NAME URL [flags] | token [NAME] [--expires] | remove NODE | maintenance NODE [--drain-reads|--off] | diagnostics NODE | health [NODE]", runNode},
+	"task":     {"task list | run TASK [--job-type TYPE] [--wait]", runTask},
+	"cluster":  {"cluster", runCluster},
+}
+
+var commandOrder = []string{"login", "logout", "profile", "bucket", "ls", "upload", "download", "rm", "sign", "alias", "node", "task", "cluster"}
+
+func main() {
+	global := flag.NewFlagSet("shbucketctl", flag.ContinueOnError)
+	profileName := global.String("profile", os.Getenv("SHBUCKET_PROFILE"), "config profile to use")
+	serverURL := global.String("url", "", "server URL, overrides the profile")
+	quiet := global.Bool("quiet", false, "don't draw progress bars")
+	global.Usage = showHelp
+
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	args := global.Args()
+	if len(args) == 0 || args[0] == "help" {
+		showHelp()
+		return
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "❌ Unknown command: %s\n\n", args[0])
+		showHelp()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// Ctrl-C cancels in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	name, profile := cfg.profile(*profileName)
+	c := &cli{ctx: ctx, cfg: cfg, profileName: name, profile: profile, quiet: *quiet}
+
+	// Environment and flags override the saved profile for this run only
+	c.profile = c.effectiveProfile(*serverURL)
+
+	err = cmd.run(c, args[1:])
+	c.saveRefreshedToken()
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "💡 Usage: shbucketctl %s\n", cmd.usage)
+		}
+		os.Exit(1)
+	}
+}
+
+// effectiveProfile copies the saved profile with SHBUCKET_* variables and --url applied
+func (c *cli) effectiveProfile(serverURL string) *Profile {
+	saved := c.cfg.Profiles[c.profileName]
+	profile := *saved
+	if url := os.Getenv("SHBUCKET_URL"); url != "" {
+		profile.URL = url
+	}
+	if serverURL != "" {
+		profile.URL = serverURL
+	}
+	if token := os.Getenv("SHBUCKET_TOKEN"); token != "" {
+		profile.Token = token
+	}
+	if apiKey := os.Getenv("SHBUCKET_API_KEY"); apiKey != "" {
+		profile.APIKey = apiKey
+	}
+	return &profile
+}
+
+func (c *cli) client() (*client.Client, error) {
+	if c.api == nil {
+		api, err := newClient(c.profile)
+		if err != nil {
+			return nil, err
+		}
+		c.api = api
+	}
+	return c.api, nil
+}
+
+// saveRefreshedToken keeps the token the client refreshed during the run, so the profile doesn't expire
+func (c *cli) saveRefreshedToken() {
+	saved := c.cfg.Profiles[c.profileName]
+	if c.api == nil || saved.Token == "" || saved.Token != c.profile.Token {
+		return
+	}
+	if token := c.api.Token(); token != "" && token != saved.Token {
+		saved.Token = token
+		if err := c.cfg.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Failed to save refreshed token: %v\n", err)
+		}
+	}
+}
+
+var errUsage = errors.New("invalid arguments")
+
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), errUsage)
+}
+
+// parseFlags parses flags placed anywhere among the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Flags of shbucketctl %s:\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func showHelp() {
+	fmt.Println("shbucketctl - command-line client for SHBucket")
+	fmt.Println()
+	fmt.Println("Usage: shbucketctl [--profile NAME] [--url URL] [--quiet] <command> [args]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, name := range commandOrder {
+		fmt.Printf("  %s\n", commands[name].usage)
+	}
+	fmt.Println()
+	fmt.Println("Buckets and files may be given by name or ID.")
+	fmt.Println()
+	fmt.Println("Environment:")
+	fmt.Println("  SHBUCKET_PROFILE    profile to use instead of the current one")
+	fmt.Println("  SHBUCKET_URL        server URL")
+	fmt.Println("  SHBUCKET_TOKEN      access token")
+	fmt.Println("  SHBUCKET_API_KEY    API key, used instead of a token")
+	fmt.Println("  SHBUCKET_PASSWORD   password for login")
+	fmt.Println("  SHBUCKETCTL_CONFIG  config file, defaults to ~/.config/shbucketctl/config.json")
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/pkg/client"
+)
+
+func runNode(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError("subcommand required")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tURL\tUSED\tMAX\tPRIORITY\tSTATUS")
+		for node, err := range api.Nodes(c.ctx) {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", node.ID, node.Name, node.URL,
+				formatSize(node.UsedStorage), formatSize(node.MaxStorage), node.Priority, node.Status)
+		}
+		return w.Flush()
+
+	case "add":
+		fs := newFlagSet("node add")
+		authKey := fs.String("auth-key", os.Getenv("SHBUCKET_NODE_AUTH_KEY"), "key the node authenticates with, at least 32 characters")
+		maxStorage := fs.Int64("max-storage", 0, "storage capacity in bytes")
+		priority := fs.Int("priority", 50, "placement priority between 0 and 100")
+		inactive := fs.Bool("inactive", false, "register without placing files on the node")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 2 {
+			return usageError("node name and URL required")
+		}
+		if *authKey == "" {
+			return usageError("--auth-key or SHBUCKET_NODE_AUTH_KEY required")
+		}
+
+		node, err := api.RegisterNode(c.ctx, client.RegisterNodeInput{
+			Name:       positional[0],
+			URL:        positional[1],
+			AuthKey:    *authKey,
+			MaxStorage: *maxStorage,
+			Priority:   *priority,
+			IsActive:   !*inactive,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Registered node %s (%s)\n", node.Name, node.ID)
+
+	case "token":
+		fs := newFlagSet("node token")
+		expires := fs.Duration("expires", time.Hour, "how long the token is valid, up to 168h")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		name := ""
+		if len(positional) > 0 {
+			name = positional[0]
+		}
+
+		token, secret, err := api.CreateRegistrationToken(c.ctx, name, int(expires.Seconds()))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Registration token, valid until %s and usable once:\n%s\n", token.ExpiresAt.Format(time.RFC3339), secret)
+
+	case "remove":
+		if len(args) < 2 {
+			return usageError("node required")
+		}
+		node, err := resolveNode(c.ctx, api, args[1])
+		if err != nil {
+			return err
+		}
+		if err := api.DeleteNode(c.ctx, node.ID); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️ Removed node %s\n", node.Name)
+
+	case "maintenance":
+		fs := newFlagSet("node maintenance")
+		drain := fs.Bool("drain-reads", false, "read the node's files from other copies where there are any")
+		off := fs.Bool("off", false, "take the node out of maintenance")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return usageError("node required")
+		}
+		node, err := resolveNode(c.ctx, api, positional[0])
+		if err != nil {
+			return err
+		}
+		if _, err := api.SetNodeMaintenance(c.ctx, node.ID, !*off, *drain); err != nil {
+			return err
+		}
+		if *off {
+			fmt.Printf("✅ Node %s is out of maintenance\n", node.Name)
+		} else {
+			fmt.Printf("🔧 Node %s is in maintenance\n", node.Name)
+		}
+
+	case "diagnostics":
+		if len(args) < 2 {
+			return usageError("node required")
+		}
+		node, err := resolveNode(c.ctx, api, args[1])
+		if err != nil {
+			return err
+		}
+		diagnostics, err := api.NodeDiagnostics(c.ctx, node.ID)
+		if err != nil {
+			return err
+		}
+		if !diagnostics.Reachable {
+			return fmt.Errorf("node %s unreachable: %s", node.Name, diagnostics.Error)
+		}
+
+		fmt.Printf("Node:        %s (%s)\n", diagnostics.Name, diagnostics.Status)
+		fmt.Printf("Version:     %s\n", diagnostics.Version)
+		fmt.Printf("Round trip:  %dms\n", diagnostics.RoundTripMs)
+		fmt.Printf("Clock skew:  %dms\n", diagnostics.ClockSkewMs)
+		fmt.Printf("Storage:     %s\n", diagnostics.StoragePath)
+		if disk := diagnostics.Disk; disk != nil {
+			fmt.Printf("Disk:        %s free of %s (%.1f%% used)\n",
+				formatSize(int64(disk.FreeBytes)), formatSize(int64(disk.TotalBytes)), disk.UsedPercent)
+			fmt.Printf("Inodes:      %d free of %d (%.1f%% used)\n", disk.FreeInodes, disk.TotalInodes, disk.InodesUsedPercent)
+		} else if diagnostics.Error != "" {
+			fmt.Printf("Disk:        %s\n", diagnostics.Error)
+		}
+		fmt.Printf("Recent errors: %d\n", len(diagnostics.RecentErrors))
+		for _, entry := range diagnostics.RecentErrors {
+			fmt.Printf("  %s  %s\n", entry.Time.Format(time.RFC3339), entry.Message)
+		}
+
+	case "health":
+		names := make(map[uuid.UUID]string)
+		for node, err := range api.Nodes(c.ctx) {
+			if err != nil {
+				return err
+			}
+			names[node.ID] = node.Name
+		}
+
+		var results []client.NodeHealth
+		if len(args) > 1 {
+			node, err := resolveNode(c.ctx, api, args[1])
+			if err != nil {
+				return err
+			}
+			result, err := api.CheckNodeHealth(c.ctx, node.ID)
+			if err != nil {
+				return err
+			}
+			results = append(results, *result)
+		} else {
+			if results, err = api.CheckAllNodesHealth(c.ctx); err != nil {
+				return err
+			}
+		}
+
+		unhealthy := 0
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NODE\tSTATUS\tRESPONSE\tERROR")
+		for _, result := range results {
+			// Nodes in maintenance may well be down on purpose
+			if !result.IsHealthy && result.Status != "maintenance" {
+				unhealthy++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%dms\t%s\n", names[result.NodeID], result.Status, result.ResponseTimeMs, result.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if unhealthy > 0 {
+			return fmt.Errorf("%d node(s) unhealthy", unhealthy)
+		}
+
+	default:
+		return usageError("unknown subcommand %q", args[0])
+	}
+	return nil
+}
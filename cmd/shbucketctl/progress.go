@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress draws a single progress bar on stderr for one or more concurrent transfers
+type Progress struct {
+	label   string
+	total   int64
+	current atomic.Int64
+	start   time.Time
+	quiet   bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newProgress starts drawing a bar for total bytes, total <= 0 shows only the transferred size
+func newProgress(label string, total int64, quiet bool) *Progress {
+	p := &Progress{label: label, total: total, start: time.Now(), quiet: quiet, done: make(chan struct{})}
+	if quiet {
+		return p
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.draw()
+			}
+		}
+	}()
+	return p
+}
+
+func (p *Progress) add(n int64) {
+	p.current.Add(n)
+}
+
+// reader counts bytes read from r towards the bar
+func (p *Progress) reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, p: p}
+}
+
+// finish draws the final state and ends the line
+func (p *Progress) finish() {
+	if p.quiet {
+		return
+	}
+	close(p.done)
+	p.wg.Wait()
+	p.draw()
+	fmt.Fprintln(os.Stderr)
+}
+
+func (p *Progress) draw() {
+	current := p.current.Load()
+	elapsed := time.Since(p.start).Seconds()
+	rate := ""
+	if elapsed > 0 {
+		rate = formatSize(int64(float64(current)/elapsed)) + "/s"
+	}
+
+	if p.total <= 0 {
+		fmt.Fprintf(os.Stderr, "\r%s %s %s   ", p.label, formatSize(current), rate)
+		return
+	}
+
+	const width = 30
+	ratio := min(float64(current)/float64(p.total), 1)
+	filled := int(ratio * width)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	fmt.Fprintf(os.Stderr, "\r%s [%s] %3.0f%% %s/%s %s   ",
+		p.label, bar, ratio*100, formatSize(current), formatSize(p.total), rate)
+}
+
+type progressReader struct {
+	r io.Reader
+	p *Progress
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.p.add(int64(n))
+	return n, err
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func runTask(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usageError("subcommand required")
+	}
+	api, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		tasks, runs, err := api.ListAdminTasks(c.ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "TASK\tDESCRIPTION")
+		for _, task := range tasks {
+			fmt.Fprintf(w, "%s\t%s\n", task.Name, task.Description)
+		}
+		if len(runs) > 0 {
+			fmt.Fprintln(w, "\nRUN\tTASK\tSTATUS\tSTARTED BY\tCREATED")
+			for _, run := range runs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", run.Job.ID, run.Task, run.Job.Status, run.RunBy, run.Job.CreatedAt.Format(time.RFC3339))
+			}
+		}
+		return w.Flush()
+
+	case "run":
+		fs := newFlagSet("task run")
+		jobType := fs.String("job-type", "", "job type retry-failed-jobs is limited to")
+		wait := fs.Bool("wait", false, "wait for the task to finish and print its result")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return usageError("task required")
+		}
+
+		job, err := api.RunAdminTask(c.ctx, positional[0], *jobType)
+		if err != nil {
+			return err
+		}
+		if !*wait {
+			fmt.Printf("⏳ Task %s queued as job %s\n", positional[0], job.ID)
+			return nil
+		}
+
+		if job, err = api.WaitForJob(c.ctx, job.ID, time.Second); err != nil {
+			return err
+		}
+		if job.Status == "failed" {
+			return fmt.Errorf("task %s failed: %s", positional[0], job.Error)
+		}
+		result, err := json.MarshalIndent(job.Result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Task %s completed\n%s\n", positional[0], result)
+
+	default:
+		return usageError("unknown subcommand %q", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+#!/bin/sh
+set -e
+
+# Apply migrations and create the admin and master configuration from the environment when missing
+if [ "${BOOTSTRAP:-true}" != "false" ]; then
+	./shbucket-migrate bootstrap
+fi
+
+exec "$@"
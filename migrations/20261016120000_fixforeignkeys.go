@@ -0,0 +1,51 @@
+// Corrective migration for the foreign keys of 20250816103043_initialcreate, which puts constraints
+// on columns that aren't relations and points the API key and session users at the wrong table.
+// Generated migrations are left as they were applied, so the fix lives here.
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+type Migration20261016120000 struct{}
+
+func (m *Migration20261016120000) ID() string {
+	return "20261016120000_fixforeignkeys"
+}
+
+func (m *Migration20261016120000) Up(db *gorm.DB) error {
+	// Drop foreign keys of table Bucket that no relation declares
+	if err := db.Exec("ALTER TABLE IF EXISTS \"Bucket\" DROP CONSTRAINT IF EXISTS \"fk_Bucket_Id\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_Name\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_Settings\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_CreatedAt\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_UpdatedAt\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_Owner\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_AuthRule\", DROP CONSTRAINT IF EXISTS \"fk_Bucket_Files\"").Error; err != nil {
+		return err
+	}
+	// Drop foreign keys of table APIKey that no relation declares
+	if err := db.Exec("ALTER TABLE IF EXISTS \"APIKey\" DROP CONSTRAINT IF EXISTS \"fk_APIKey_Id\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_Name\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_KeyPrefix\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_Permissions\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_CreatedAt\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_UpdatedAt\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_KeyHash\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_IsActive\", DROP CONSTRAINT IF EXISTS \"fk_APIKey_User\"").Error; err != nil {
+		return err
+	}
+	// Drop foreign keys of table NodeFileMetadata that no relation declares
+	if err := db.Exec("ALTER TABLE IF EXISTS \"NodeFileMetadata\" DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_Id\", DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_BucketId\", DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_BucketName\", DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_Filename\", DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_Path\", DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_Size\", DROP CONSTRAINT IF EXISTS \"fk_NodeFileMetadata_CreatedAt\"").Error; err != nil {
+		return err
+	}
+	// Drop foreign keys of table File that no relation declares
+	if err := db.Exec("ALTER TABLE IF EXISTS \"File\" DROP CONSTRAINT IF EXISTS \"fk_File_Id\", DROP CONSTRAINT IF EXISTS \"fk_File_Bucket\", DROP CONSTRAINT IF EXISTS \"fk_File_SecuredUrl\", DROP CONSTRAINT IF EXISTS \"fk_File_OriginalName\", DROP CONSTRAINT IF EXISTS \"fk_File_Size\", DROP CONSTRAINT IF EXISTS \"fk_File_MimeType\", DROP CONSTRAINT IF EXISTS \"fk_File_UploadedBy\", DROP CONSTRAINT IF EXISTS \"fk_File_CreatedAt\", DROP CONSTRAINT IF EXISTS \"fk_File_Name\", DROP CONSTRAINT IF EXISTS \"fk_File_Path\", DROP CONSTRAINT IF EXISTS \"fk_File_Checksum\", DROP CONSTRAINT IF EXISTS \"fk_File_AuthRule\", DROP CONSTRAINT IF EXISTS \"fk_File_Metadata\", DROP CONSTRAINT IF EXISTS \"fk_File_Version\", DROP CONSTRAINT IF EXISTS \"fk_File_UpdatedAt\"").Error; err != nil {
+		return err
+	}
+	// Drop foreign keys of table Session that no relation declares
+	if err := db.Exec("ALTER TABLE IF EXISTS \"Session\" DROP CONSTRAINT IF EXISTS \"fk_Session_IsActive\", DROP CONSTRAINT IF EXISTS \"fk_Session_ExpiresAt\", DROP CONSTRAINT IF EXISTS \"fk_Session_CreatedAt\", DROP CONSTRAINT IF EXISTS \"fk_Session_LastUsed\", DROP CONSTRAINT IF EXISTS \"fk_Session_Id\", DROP CONSTRAINT IF EXISTS \"fk_Session_User\", DROP CONSTRAINT IF EXISTS \"fk_Session_TokenHash\"").Error; err != nil {
+		return err
+	}
+	// Point APIKey.UserId at the users
+	if err := db.Exec("ALTER TABLE IF EXISTS \"APIKey\" DROP CONSTRAINT IF EXISTS \"fk_APIKey_UserId\", ADD CONSTRAINT \"fk_APIKey_UserId\" FOREIGN KEY (\"UserId\") REFERENCES \"User\" (\"Id\")").Error; err != nil {
+		return err
+	}
+	// Point Session.UserId at the users
+	if err := db.Exec("ALTER TABLE IF EXISTS \"Session\" DROP CONSTRAINT IF EXISTS \"fk_Session_UserId\", ADD CONSTRAINT \"fk_Session_UserId\" FOREIGN KEY (\"UserId\") REFERENCES \"User\" (\"Id\")").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *Migration20261016120000) Down(db *gorm.DB) error {
+	// The dropped constraints were never valid, so there is nothing to restore
+	return nil
+}
@@ -0,0 +1,72 @@
+// Corrective migration for the columns of 20250816103043_initialcreate, which gives embedded structs
+// and relations TEXT columns of their own instead of the columns GORM reads and writes, stores JSON
+// as TEXT and leaves NodeFileMetadata without a primary key. Generated migrations are left as they
+// were applied, so the fix lives here.
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+type Migration20261017090000 struct{}
+
+func (m *Migration20261017090000) ID() string {
+	return "20261017090000_fixcolumns"
+}
+
+func (m *Migration20261017090000) Up(db *gorm.DB) error {
+	// Fix the columns of table User
+	if err := db.Exec("ALTER TABLE \"User\" DROP COLUMN \"Buckets\", DROP COLUMN \"Sessions\"").Error; err != nil {
+		return err
+	}
+	// Fix the columns of table Session
+	if err := db.Exec("ALTER TABLE \"Session\" DROP COLUMN \"User\"").Error; err != nil {
+		return err
+	}
+	// Create index idx_Session_UserId on table Session
+	if err := db.Exec("CREATE INDEX \"idx_Session_UserId\" ON \"Session\" (\"UserId\")").Error; err != nil {
+		return err
+	}
+	// Fix the columns of table Bucket
+	if err := db.Exec("ALTER TABLE \"Bucket\" DROP COLUMN \"Settings\", DROP COLUMN \"Owner\", DROP COLUMN \"AuthRule\", DROP COLUMN \"Files\", ADD COLUMN \"auth_Type\" TEXT NOT NULL DEFAULT 'jwt', ADD COLUMN \"auth_Enabled\" BOOLEAN NOT NULL DEFAULT true, ADD COLUMN \"auth_Config\" JSONB, ADD COLUMN \"settings_MaxFileSize\" BIGINT NOT NULL DEFAULT 0, ADD COLUMN \"settings_MaxTotalSize\" BIGINT NOT NULL DEFAULT 0, ADD COLUMN \"settings_AllowedMimeTypes\" TEXT[], ADD COLUMN \"settings_BlockedMimeTypes\" TEXT[], ADD COLUMN \"settings_AllowedExtensions\" TEXT[], ADD COLUMN \"settings_BlockedExtensions\" TEXT[], ADD COLUMN \"settings_MaxFilesPerBucket\" BIGINT NOT NULL DEFAULT 0, ADD COLUMN \"settings_PublicRead\" BOOLEAN NOT NULL DEFAULT false, ADD COLUMN \"settings_Versioning\" BOOLEAN NOT NULL DEFAULT false, ADD COLUMN \"settings_Encryption\" BOOLEAN NOT NULL DEFAULT false, ADD COLUMN \"settings_AllowOverwrite\" BOOLEAN NOT NULL DEFAULT true, ADD COLUMN \"settings_RequireContentType\" BOOLEAN NOT NULL DEFAULT false").Error; err != nil {
+		return err
+	}
+	// Create index idx_Bucket_OwnerId on table Bucket
+	if err := db.Exec("CREATE INDEX \"idx_Bucket_OwnerId\" ON \"Bucket\" (\"OwnerId\")").Error; err != nil {
+		return err
+	}
+	// Fix the columns of table File
+	if err := db.Exec("ALTER TABLE \"File\" DROP COLUMN \"Bucket\", DROP COLUMN \"AuthRule\", DROP COLUMN \"Metadata\", ADD COLUMN \"auth_Type\" TEXT NOT NULL DEFAULT 'jwt', ADD COLUMN \"auth_Enabled\" BOOLEAN NOT NULL DEFAULT true, ADD COLUMN \"auth_Config\" JSONB, ADD COLUMN \"metadata_ContentType\" TEXT NOT NULL DEFAULT '', ADD COLUMN \"metadata_ContentEncoding\" TEXT NOT NULL DEFAULT '', ADD COLUMN \"metadata_ContentDisposition\" TEXT NOT NULL DEFAULT '', ADD COLUMN \"metadata_CacheControl\" TEXT NOT NULL DEFAULT '', ADD COLUMN \"metadata_CustomMetadata\" JSONB").Error; err != nil {
+		return err
+	}
+	// Create index idx_File_BucketId on table File
+	if err := db.Exec("CREATE INDEX \"idx_File_BucketId\" ON \"File\" (\"BucketId\")").Error; err != nil {
+		return err
+	}
+	// Create index idx_File_UploadedBy on table File
+	if err := db.Exec("CREATE INDEX \"idx_File_UploadedBy\" ON \"File\" (\"UploadedBy\")").Error; err != nil {
+		return err
+	}
+	// Fix the columns of table APIKey
+	if err := db.Exec("ALTER TABLE \"APIKey\" DROP COLUMN \"User\", ALTER COLUMN \"Permissions\" TYPE JSONB USING NULLIF(\"Permissions\", '')::jsonb").Error; err != nil {
+		return err
+	}
+	// Create index idx_SignedURL_ExpiresAt on table SignedURL
+	if err := db.Exec("CREATE INDEX \"idx_SignedURL_ExpiresAt\" ON \"SignedURL\" (\"ExpiresAt\")").Error; err != nil {
+		return err
+	}
+	// Fix the columns of table SetupConfig
+	if err := db.Exec("ALTER TABLE \"SetupConfig\" ALTER COLUMN \"ConfigData\" TYPE JSONB USING NULLIF(\"ConfigData\", '')::jsonb").Error; err != nil {
+		return err
+	}
+	// Fix the columns of table NodeFileMetadata
+	if err := db.Exec("ALTER TABLE \"NodeFileMetadata\" ADD PRIMARY KEY (\"Id\")").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *Migration20261017090000) Down(db *gorm.DB) error {
+	// The dropped columns could never be written, so there is nothing to restore
+	return nil
+}
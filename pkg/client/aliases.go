@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alias is a name within a bucket pointing at one of its files
+type Alias struct {
+	ID        uuid.UUID `json:"id"`
+	BucketID  uuid.UUID `json:"bucket_id"`
+	Name      string    `json:"name"`
+	FileID    uuid.UUID `json:"file_id"`
+	URL       string    `json:"url"`
+	UpdatedBy uuid.UUID `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListAliases returns a bucket's aliases by name
+func (c *Client) ListAliases(ctx context.Context, bucketID uuid.UUID) ([]Alias, error) {
+	var resp struct {
+		Aliases []Alias `json:"aliases"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/buckets/"+bucketID.String()+"/aliases", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Aliases, nil
+}
+
+// SetAlias points an alias at a file, creating it if needed. With ifFileID the alias only moves
+// while it still points at that file, otherwise the server answers 409 Conflict.
+func (c *Client) SetAlias(ctx context.Context, bucketID uuid.UUID, name string, fileID uuid.UUID, ifFileID *uuid.UUID) (*Alias, error) {
+	var resp struct {
+		Alias Alias `json:"alias"`
+	}
+	input := map[string]interface{}{"file_id": fileID}
+	if ifFileID != nil {
+		input["if_file_id"] = *ifFileID
+	}
+	if err := c.call(ctx, http.MethodPut, "/buckets/"+bucketID.String()+"/aliases/"+url.PathEscape(name), nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Alias, nil
+}
+
+// DeleteAlias removes an alias, the file it points at stays
+func (c *Client) DeleteAlias(ctx context.Context, bucketID uuid.UUID, name string) error {
+	return c.call(ctx, http.MethodDelete, "/buckets/"+bucketID.String()+"/aliases/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// DownloadAlias opens the content of the file an alias points at. The caller closes the returned reader.
+func (c *Client) DownloadAlias(ctx context.Context, bucketID uuid.UUID, name string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, request{
+		method:    http.MethodGet,
+		path:      "/file/" + bucketID.String() + "/alias/" + url.PathEscape(name),
+		retryable: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
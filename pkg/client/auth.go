@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Tokens are refreshed once less than this much of their lifetime remains
+const refreshBefore = 5 * time.Minute
+
+// Session is the result of signing in or refreshing a token
+type Session struct {
+	User         User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds
+}
+
+// Login signs in with an email or username and password. The client uses the returned token for later requests.
+func (c *Client) Login(ctx context.Context, email, password string) (*Session, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.email, c.password = email, password
+	return c.login(ctx)
+}
+
+// Refresh exchanges the current token for a new one. It happens automatically shortly before the token expires.
+func (c *Client) Refresh(ctx context.Context) (*Session, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.refresh(ctx)
+}
+
+// Logout ends the server session of the current token and forgets it
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.call(ctx, http.MethodPost, "/auth/logout", nil, nil, nil)
+
+	c.authMu.Lock()
+	c.token, c.expiresAt = "", time.Time{}
+	c.authMu.Unlock()
+	return err
+}
+
+// validToken returns the token to send, signing in or refreshing first when needed
+func (c *Client) validToken(ctx context.Context) (string, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.token == "" {
+		if c.password == "" {
+			return "", nil
+		}
+		if _, err := c.login(ctx); err != nil {
+			return "", err
+		}
+		return c.token, nil
+	}
+
+	if c.expiresAt.IsZero() {
+		c.expiresAt = tokenExpiry(c.token)
+	}
+	if !c.expiresAt.IsZero() && time.Until(c.expiresAt) < refreshBefore {
+		if _, err := c.refresh(ctx); err != nil {
+			// An expired token can't be refreshed, only replaced by signing in again
+			if c.password == "" {
+				return "", err
+			}
+			if _, err := c.login(ctx); err != nil {
+				return "", err
+			}
+		}
+	}
+	return c.token, nil
+}
+
+// relogin signs in again unless another request already replaced the rejected token
+func (c *Client) relogin(ctx context.Context, rejected string) error {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.token != rejected {
+		return nil
+	}
+	_, err := c.login(ctx)
+	return err
+}
+
+// login and refresh are called with authMu held
+func (c *Client) login(ctx context.Context) (*Session, error) {
+	body, err := jsonBody(map[string]string{"email": c.email, "password": c.password})
+	if err != nil {
+		return nil, err
+	}
+	return c.startSession(ctx, "/auth/login", body)
+}
+
+func (c *Client) refresh(ctx context.Context) (*Session, error) {
+	body, err := jsonBody(map[string]string{"refresh_token": c.token})
+	if err != nil {
+		return nil, err
+	}
+	return c.startSession(ctx, "/auth/refresh", body)
+}
+
+func (c *Client) startSession(ctx context.Context, path string, body func() (io.Reader, error)) (*Session, error) {
+	resp, err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        path,
+		body:        body,
+		contentType: "application/json",
+		noAuth:      true,
+		retryable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var session Session
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return nil, err
+	}
+	c.token = session.Token
+	c.expiresAt = time.Now().Add(time.Duration(session.ExpiresIn) * time.Second)
+	if session.ExpiresIn <= 0 {
+		c.expiresAt = tokenExpiry(session.Token)
+	}
+	return &session, nil
+}
+
+type tokenClaims struct {
+	Exp int64 `json:"exp"`
+}
+
+// decodeClaims reads a token's claims without verifying it, the server does that
+func decodeClaims(token string) (*tokenClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	var claims tokenClaims
+	if json.Unmarshal(payload, &claims) != nil {
+		return nil, false
+	}
+	return &claims, true
+}
+
+func tokenExpiry(token string) time.Time {
+	claims, ok := decodeClaims(token)
+	if !ok || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateBucketInput describes a new bucket. Zero settings take the server's defaults.
+type CreateBucketInput struct {
+	Name        string          `json:"name"` // 3 to 63 letters and digits
+	Description string          `json:"description,omitempty"`
+	AuthRule    *AuthRule       `json:"auth_rule,omitempty"`
+	Settings    *BucketSettings `json:"settings,omitempty"`
+}
+
+// UpdateBucketInput changes a bucket. Nil fields are left as they are; Settings replaces all settings.
+type UpdateBucketInput struct {
+	Description *string         `json:"description,omitempty"`
+	AuthRule    *AuthRule       `json:"auth_rule,omitempty"`
+	Settings    *BucketSettings `json:"settings,omitempty"`
+}
+
+// BucketPage is one page of buckets
+type BucketPage struct {
+	Buckets    []Bucket `json:"buckets"`
+	Total      int64    `json:"total"`
+	Page       int      `json:"page"`
+	Limit      int      `json:"limit"`
+	NextCursor string   `json:"next_cursor,omitempty"` // pass as ListOptions.Cursor for the next page
+}
+
+// BucketDeletion tracks a forced bucket deletion running in the background
+type BucketDeletion struct {
+	ID           uuid.UUID  `json:"id"`
+	BucketID     uuid.UUID  `json:"bucket_id"`
+	BucketName   string     `json:"bucket_name"`
+	JobID        *uuid.UUID `json:"job_id,omitempty"`
+	Status       string     `json:"status"`
+	TotalFiles   int64      `json:"total_files"`
+	DeletedFiles int64      `json:"deleted_files"`
+	FailedFiles  int64      `json:"failed_files"`
+	DeletedBytes int64      `json:"deleted_bytes"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+func (c *Client) CreateBucket(ctx context.Context, input CreateBucketInput) (*Bucket, error) {
+	var resp struct {
+		Bucket Bucket `json:"bucket"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/buckets", nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Bucket, nil
+}
+
+func (c *Client) GetBucket(ctx context.Context, bucketID uuid.UUID) (*Bucket, error) {
+	var resp struct {
+		Bucket Bucket `json:"bucket"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/buckets/"+bucketID.String(), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Bucket, nil
+}
+
+func (c *Client) UpdateBucket(ctx context.Context, bucketID uuid.UUID, input UpdateBucketInput) (*Bucket, error) {
+	var resp struct {
+		Bucket Bucket `json:"bucket"`
+	}
+	if err := c.call(ctx, http.MethodPut, "/buckets/"+bucketID.String(), nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Bucket, nil
+}
+
+// DeleteBucket deletes an empty bucket. With force its files are deleted too, in the background:
+// the returned deletion can be followed with GetBucketDeletion. It is nil when nothing is left to do.
+func (c *Client) DeleteBucket(ctx context.Context, bucketID uuid.UUID, force bool) (*BucketDeletion, error) {
+	var query url.Values
+	if force {
+		query = url.Values{"force": {"true"}}
+	}
+	var resp struct {
+		Job *BucketDeletion `json:"job"`
+	}
+	if err := c.call(ctx, http.MethodDelete, "/buckets/"+bucketID.String(), query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Job, nil
+}
+
+// GetBucketDeletion returns the progress of a bucket's forced deletion
+func (c *Client) GetBucketDeletion(ctx context.Context, bucketID uuid.UUID) (*BucketDeletion, error) {
+	var resp struct {
+		Job BucketDeletion `json:"job"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/buckets/"+bucketID.String()+"/deletion", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Job, nil
+}
+
+// ListBuckets returns one page of the buckets visible to the caller
+func (c *Client) ListBuckets(ctx context.Context, opts *ListOptions) (*BucketPage, error) {
+	var page BucketPage
+	if err := c.call(ctx, http.MethodGet, "/buckets", opts.query(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Buckets iterates over every bucket visible to the caller, fetching pages as needed
+//
+//	for bucket, err := range c.Buckets(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(bucket.Name)
+//	}
+func (c *Client) Buckets(ctx context.Context) iter.Seq2[Bucket, error] {
+	return paginate(func(opts *ListOptions) ([]Bucket, int64, error) {
+		page, err := c.ListBuckets(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		return page.Buckets, page.Total, nil
+	})
+}
+
+// FindBucket returns the bucket with the given name
+func (c *Client) FindBucket(ctx context.Context, name string) (*Bucket, error) {
+	for bucket, err := range c.Buckets(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		if bucket.Name == name {
+			return &bucket, nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "bucket " + strconv.Quote(name) + " not found"}
+}
+
+// pageSize is the page size iterators request
+const pageSize = 100
+
+// paginate turns a page fetcher into an iterator that stops after the last page or the first error
+func paginate[T any](fetch func(opts *ListOptions) ([]T, int64, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		seen := int64(0)
+		for page := 1; ; page++ {
+			items, total, err := fetch(&ListOptions{Page: page, Limit: pageSize})
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			seen += int64(len(items))
+			if len(items) == 0 || seen >= total {
+				return
+			}
+		}
+	}
+}
+
+func (o *ListOptions) query() url.Values {
+	query := url.Values{}
+	if o == nil {
+		return query
+	}
+	if o.Page > 0 {
+		query.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	for key, value := range map[string]string{"sort_by": o.SortBy, "order": o.Order, "name": o.Name, "cursor": o.Cursor} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
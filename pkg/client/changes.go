@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileChange is the current state of a name in a bucket's changes feed
+type FileChange struct {
+	Name    string     `json:"name"`
+	Deleted bool       `json:"deleted"` // no version of the name is left
+	FileID  *uuid.UUID `json:"file_id,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Size    int64      `json:"size"`
+	// Checksum is the hex encoded sha256 of the content, "stored-on-node" for content stored on a node
+	Checksum          string                 `json:"checksum,omitempty"`
+	MimeType          string                 `json:"mime_type,omitempty"`
+	CustomMetadata    map[string]interface{} `json:"custom_metadata,omitempty"`
+	CustomerEncrypted bool                   `json:"customer_encrypted,omitempty"` // only readable with the customer's key
+	UpdatedAt         *time.Time             `json:"updated_at,omitempty"`
+}
+
+// ChangesPage is one page of a bucket's changes feed
+type ChangesPage struct {
+	Changes []FileChange `json:"changes"`
+	// Listing pages list the bucket's current files by name. Names after ListedAfter up to the
+	// page's last change, or to the end once ListingComplete, that the page doesn't list are gone.
+	Listing         bool   `json:"listing"`
+	ListedAfter     string `json:"listed_after,omitempty"`
+	ListingComplete bool   `json:"listing_complete,omitempty"`
+	Cursor          string `json:"cursor"`
+	HasMore         bool   `json:"has_more"`
+}
+
+// Changes reads a bucket's changes feed from cursor. An empty cursor starts with a listing of every
+// file, after which the feed reports the names changed since. Keep the returned cursor to resume,
+// and read again right away while HasMore.
+func (c *Client) Changes(ctx context.Context, bucketID uuid.UUID, cursor string, limit int) (*ChangesPage, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var page ChangesPage
+	if err := c.call(ctx, http.MethodGet, "/buckets/"+bucketID.String()+"/changes", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,291 @@
+// Package client is a Go client for the SHBucket API.
+//
+//	c, err := client.New("https://bucket.example.com", client.WithAPIKey(os.Getenv("SHBUCKET_API_KEY")))
+//	bucket, err := c.CreateBucket(ctx, client.CreateBucketInput{Name: "photos"})
+//	file, err := c.Upload(ctx, bucket.ID, "cat.jpg", f, nil)
+//
+// Requests authenticate with an API key, a token, or an email and password. Tokens are
+// refreshed before they expire, and with a password the client signs in again if a token
+// is rejected. Failed requests are retried with exponential backoff when it is safe to.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client calls the SHBucket API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	userAgent  string
+
+	maxRetries int
+	retryDelay time.Duration
+
+	authMu    sync.Mutex
+	token     string
+	expiresAt time.Time // zero when unknown
+	email     string
+	password  string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey authenticates every request with an API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithToken authenticates with an existing token, which is refreshed before it expires
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithCredentials signs in with an email or username and password on the first request,
+// and again whenever the token is rejected
+func WithCredentials(email, password string) Option {
+	return func(c *Client) { c.email, c.password = email, password }
+}
+
+// WithHTTPClient replaces the HTTP client, e.g. to set a transport or proxy
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetry sets how many times a failed request is retried and the delay before the first retry,
+// which doubles on each attempt. Zero retries disables retrying.
+func WithRetry(maxRetries int, delay time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.retryDelay = maxRetries, delay }
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New returns a client for the server at baseURL, e.g. "https://bucket.example.com"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/") + "/api/v1",
+		httpClient: &http.Client{},
+		userAgent:  "shbucket-go",
+		maxRetries: 3,
+		retryDelay: 500 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Token returns the token requests are currently made with, e.g. to save it for later runs
+func (c *Client) Token() string {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.token
+}
+
+// request describes one API call. body is a function so the body can be recreated for a retry.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        func() (io.Reader, error)
+	contentType string
+	header      http.Header // sent in addition to the headers every request gets
+	noAuth      bool
+	retryable   bool // safe to repeat even though the server may have received it
+}
+
+func jsonBody(in interface{}) (func() (io.Reader, error), error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.Reader, error) { return bytes.NewReader(data), nil }, nil
+}
+
+// call sends a JSON request and decodes the JSON response into out
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	req := request{method: method, path: path, query: query, retryable: idempotent(method)}
+	if in != nil {
+		body, err := jsonBody(in)
+		if err != nil {
+			return err
+		}
+		req.body = body
+		req.contentType = "application/json"
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// do sends the request with authentication and retries, returning a successful response
+// or an *APIError. The caller closes the response body.
+func (c *Client) do(ctx context.Context, req request) (*http.Response, error) {
+	reauthenticated := false
+
+	for attempt := 0; ; attempt++ {
+		var token string
+		if !req.noAuth && c.apiKey == "" {
+			var err error
+			if token, err = c.validToken(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.send(ctx, req, token)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		var apiErr *APIError
+		if err == nil {
+			apiErr = newAPIError(resp)
+			err = apiErr
+		}
+
+		// A rejected token is replaced once by signing in again when a password is known
+		if apiErr != nil && apiErr.StatusCode == http.StatusUnauthorized && token != "" && c.password != "" && !reauthenticated {
+			reauthenticated = true
+			if loginErr := c.relogin(ctx, token); loginErr != nil {
+				return nil, loginErr
+			}
+			attempt--
+			continue
+		}
+
+		if attempt >= c.maxRetries || !c.shouldRetry(req, err, apiErr) {
+			return nil, err
+		}
+
+		delay := c.backoff(attempt)
+		if apiErr != nil && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, token string) (*http.Response, error) {
+	reqURL := c.baseURL + req.path
+	if len(req.query) > 0 {
+		reqURL += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		var err error
+		if body, err = req.body(); err != nil {
+			return nil, err
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if !req.noAuth {
+		if c.apiKey != "" {
+			httpReq.Header.Set("X-API-Key", c.apiKey)
+		} else if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// shouldRetry retries throttling and unavailability always, and other server or network
+// failures only for requests that are safe to repeat
+func (c *Client) shouldRetry(req request, err error, apiErr *APIError) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// A network failure may have happened after the server acted on the request
+	if apiErr == nil {
+		return req.retryable
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return req.retryable
+	default:
+		return false
+	}
+}
+
+// backoff doubles the delay per attempt, with jitter so concurrent clients spread out, capped at 30s
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay << attempt
+	if delay <= 0 || delay > 30*time.Second {
+		delay = 30 * time.Second
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
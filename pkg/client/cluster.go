@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClusterMember is a master server running against the server's database
+type ClusterMember struct {
+	ID          uuid.UUID `json:"id"`
+	Hostname    string    `json:"hostname"`
+	Profile     string    `json:"profile"`
+	Leader      bool      `json:"leader"`
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// ListClusterMembers returns the running master servers, the leader first, and the one that answered
+func (c *Client) ListClusterMembers(ctx context.Context) ([]ClusterMember, uuid.UUID, error) {
+	var resp struct {
+		Members  []ClusterMember `json:"members"`
+		ServedBy uuid.UUID       `json:"served_by"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/admin/cluster", nil, nil, &resp); err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resp.Members, resp.ServedBy, nil
+}
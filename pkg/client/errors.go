@@ -0,0 +1,97 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// APIError is an error response from the server
+type APIError struct {
+	StatusCode int
+	Code       string // what went wrong, like "file_not_found", see the README for the codes
+	Message    string
+	Details    string        // validation details, when the server gives them
+	Fields     []FieldError  // the parameters and body fields that failed validation
+	RetryAfter time.Duration // from the Retry-After header, zero when absent
+}
+
+// FieldError is a parameter or body field the server refused
+type FieldError struct {
+	Field   string `json:"field"`   // the parameter's name, or the field's JSON path in the body
+	In      string `json:"in"`      // path or body
+	Message string `json:"message"` // what the field's value must be
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("shbucket: %s: %s (HTTP %d)", e.Message, e.Details, e.StatusCode)
+	}
+	return fmt.Sprintf("shbucket: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// newAPIError reads and closes an error response
+func newAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var body struct {
+		Error   string       `json:"error"`
+		Code    string       `json:"code"`
+		Message string       `json:"message"`
+		Details string       `json:"details"`
+		Fields  []FieldError `json:"fields"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(data, &body) == nil {
+		if body.Error != "" {
+			apiErr.Message = body.Error
+		} else if body.Message != "" {
+			apiErr.Message = body.Message
+		}
+		apiErr.Code = body.Code
+		apiErr.Details = body.Details
+		apiErr.Fields = body.Fields
+	}
+	return apiErr
+}
+
+// IsNotFound reports whether err is an API error for a missing resource
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an API error for missing or rejected credentials
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an API error for a request the credentials may not make
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is an API error for a request that clashes with the resource's
+// current state, such as an upload chunk that doesn't start at the upload's offset
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// HasCode reports whether err is an API error with the code, like "checksum_mismatch"
+func HasCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
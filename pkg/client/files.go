@@ -0,0 +1,272 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UploadOptions are optional settings of an upload
+type UploadOptions struct {
+	ContentType string // sent as the part's Content-Type, detected by the server when empty
+	// Progress is called with the number of bytes sent so far
+	Progress func(sent int64)
+	// TransferID, when set, lets WatchTransfer follow the server storing the upload
+	TransferID string
+}
+
+// FilePage is one page of a bucket's files
+type FilePage struct {
+	Files      []File `json:"files"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"` // pass as ListOptions.Cursor for the next page
+}
+
+// Upload stores the content of r in a bucket under name. The content is streamed, not buffered.
+// Uploads aren't retried once the server may have received them, except when r is an io.Seeker
+// and the server refused the upload outright (429, 503).
+func (c *Client) Upload(ctx context.Context, bucketID uuid.UUID, name string, r io.Reader, opts *UploadOptions) (*File, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	seeker, seekable := r.(io.Seeker)
+	start := int64(0)
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	boundary := multipart.NewWriter(nil).Boundary()
+	var previous *streamedBody
+	body := func() (io.Reader, error) {
+		if previous != nil {
+			// The previous attempt's writer must stop reading r before it is rewound
+			previous.stop()
+			if !seekable {
+				return nil, errors.New("upload content can't be re-read for a retry")
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		previous = multipartBody(ctx, boundary, name, r, opts)
+		return previous.reader, nil
+	}
+
+	resp, err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/buckets/" + bucketID.String() + "/files",
+		body:        body,
+		contentType: "multipart/form-data; boundary=" + boundary,
+		header:      transferHeader(opts.TransferID),
+	})
+	if previous != nil {
+		previous.stop()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		File File `json:"file"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &out.File, nil
+}
+
+// streamedBody is a request body written by a goroutine through a pipe
+type streamedBody struct {
+	reader *io.PipeReader
+	done   chan struct{}
+}
+
+// stop ends the writer and waits for it to return
+func (b *streamedBody) stop() {
+	b.reader.CloseWithError(errors.New("request finished"))
+	<-b.done
+}
+
+// multipartBody streams r as the "file" field of a form
+func multipartBody(ctx context.Context, boundary, name string, r io.Reader, opts *UploadOptions) *streamedBody {
+	pr, pw := io.Pipe()
+	body := &streamedBody{reader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(body.done)
+		form := multipart.NewWriter(pw)
+		form.SetBoundary(boundary)
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, escapeQuotes(name)))
+		contentType := opts.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+
+		part, err := form.CreatePart(header)
+		if err == nil {
+			src := r
+			if opts.Progress != nil {
+				src = &progressReader{r: r, progress: opts.Progress}
+			}
+			_, err = io.Copy(part, contextReader{ctx: ctx, r: src})
+		}
+		if err == nil {
+			// Servers drop folders from the file's name, the name field keeps them
+			err = form.WriteField("name", name)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return body
+}
+
+// Download opens a file's content. The caller closes the returned reader.
+func (c *Client) Download(ctx context.Context, bucketID, fileID uuid.UUID) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, request{
+		method:    http.MethodGet,
+		path:      "/file/" + bucketID.String() + "/" + fileID.String(),
+		retryable: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DownloadTo writes a file's content to w and returns the number of bytes written
+func (c *Client) DownloadTo(ctx context.Context, bucketID, fileID uuid.UUID, w io.Writer) (int64, error) {
+	body, err := c.Download(ctx, bucketID, fileID)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	return io.Copy(w, body)
+}
+
+// PrecheckUpload asks the server to store a file under name from identical content the bucket
+// already holds, identified by the content's hex encoded sha256 checksum and size. It returns the
+// stored file and true when there was such content, or false when the file must be uploaded.
+func (c *Client) PrecheckUpload(ctx context.Context, bucketID uuid.UUID, name, checksum string, size int64, contentType string) (*File, bool, error) {
+	var resp struct {
+		Exists bool  `json:"exists"`
+		File   *File `json:"file"`
+	}
+	input := map[string]interface{}{
+		"file_name":    name,
+		"checksum":     checksum,
+		"size":         size,
+		"content_type": contentType,
+	}
+	if err := c.call(ctx, http.MethodPost, "/buckets/"+bucketID.String()+"/files/precheck", nil, input, &resp); err != nil {
+		return nil, false, err
+	}
+	if !resp.Exists || resp.File == nil {
+		return nil, false, nil
+	}
+	return resp.File, true, nil
+}
+
+// GetFile returns a file's metadata
+func (c *Client) GetFile(ctx context.Context, bucketID, fileID uuid.UUID) (*File, error) {
+	var resp struct {
+		File File `json:"file"`
+	}
+	path := "/buckets/" + bucketID.String() + "/files/" + fileID.String() + "/info"
+	if err := c.call(ctx, http.MethodGet, path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.File, nil
+}
+
+func (c *Client) DeleteFile(ctx context.Context, bucketID, fileID uuid.UUID) error {
+	return c.call(ctx, http.MethodDelete, "/buckets/"+bucketID.String()+"/files/"+fileID.String(), nil, nil, nil)
+}
+
+// ListFiles returns one page of a bucket's files
+func (c *Client) ListFiles(ctx context.Context, bucketID uuid.UUID, opts *ListOptions) (*FilePage, error) {
+	var page FilePage
+	if err := c.call(ctx, http.MethodGet, "/buckets/"+bucketID.String()+"/files", opts.query(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Files iterates over every file of a bucket, fetching pages as needed
+func (c *Client) Files(ctx context.Context, bucketID uuid.UUID) iter.Seq2[File, error] {
+	return paginate(func(opts *ListOptions) ([]File, int64, error) {
+		page, err := c.ListFiles(ctx, bucketID, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		return page.Files, page.Total, nil
+	})
+}
+
+// GenerateSignedURL returns a link to a file valid for expiresIn (1 minute to 7 days).
+// A single-use link stops working after its first download.
+func (c *Client) GenerateSignedURL(ctx context.Context, bucketID, fileID uuid.UUID, expiresIn time.Duration, singleUse bool) (*SignedURL, error) {
+	body := map[string]interface{}{
+		"expires_in": int(expiresIn.Seconds()),
+		"single_use": singleUse,
+	}
+	var signed SignedURL
+	path := "/buckets/" + bucketID.String() + "/files/" + fileID.String() + "/signed-url"
+	if err := c.call(ctx, http.MethodPost, path, nil, body, &signed); err != nil {
+		return nil, err
+	}
+	return &signed, nil
+}
+
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	progress func(int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.progress(p.sent)
+	}
+	return n, err
+}
+
+// contextReader stops a streamed body when the request's context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(b)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (c *Client) GetJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
+	var resp struct {
+		Job Job `json:"job"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/jobs/"+jobID.String(), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Job, nil
+}
+
+// WaitForJob polls a job every interval until it completes or fails, or ctx is done
+func (c *Client) WaitForJob(ctx context.Context, jobID uuid.UUID, interval time.Duration) (*Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.GetJob(ctx, jobID)
+		if err != nil || job.Done() {
+			return job, err
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
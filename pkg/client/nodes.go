@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RegisterNodeInput describes a storage node to add
+type RegisterNodeInput struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	PublicURL  string `json:"public_url,omitempty"` // where clients reach the node for redirected downloads, when not at URL
+	AuthKey    string `json:"auth_key"`             // at least 32 characters, shared with the node
+	MaxStorage int64  `json:"max_storage"`
+	Priority   int    `json:"priority"` // 0 to 100
+	IsActive   bool   `json:"is_active"`
+}
+
+// NodePage is one page of storage nodes
+type NodePage struct {
+	Nodes []Node `json:"nodes"`
+	Total int64  `json:"total"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+// ListNodes returns one page of storage nodes
+func (c *Client) ListNodes(ctx context.Context, opts *ListOptions) (*NodePage, error) {
+	var page NodePage
+	if err := c.call(ctx, http.MethodGet, "/nodes", opts.query(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Nodes iterates over every storage node
+func (c *Client) Nodes(ctx context.Context) iter.Seq2[Node, error] {
+	return paginate(func(opts *ListOptions) ([]Node, int64, error) {
+		page, err := c.ListNodes(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		return page.Nodes, page.Total, nil
+	})
+}
+
+func (c *Client) RegisterNode(ctx context.Context, input RegisterNodeInput) (*Node, error) {
+	var resp struct {
+		Node Node `json:"node"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/nodes", nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Node, nil
+}
+
+func (c *Client) DeleteNode(ctx context.Context, nodeID uuid.UUID) error {
+	return c.call(ctx, http.MethodDelete, "/nodes/"+nodeID.String(), nil, nil, nil)
+}
+
+// SetNodeMaintenance puts a storage node in maintenance, where it takes no new content and, with
+// drainReads, serves no reads that other copies can, or takes it out of maintenance
+func (c *Client) SetNodeMaintenance(ctx context.Context, nodeID uuid.UUID, enabled, drainReads bool) (*Node, error) {
+	var resp struct {
+		Node Node `json:"node"`
+	}
+	input := map[string]interface{}{"enabled": enabled, "drain_reads": drainReads}
+	if err := c.call(ctx, http.MethodPost, "/nodes/"+nodeID.String()+"/maintenance", nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Node, nil
+}
+
+// CreateRegistrationToken issues a one-time token a storage node registers itself with, valid for
+// expiresIn seconds, or an hour when 0. It returns the token and its secret.
+func (c *Client) CreateRegistrationToken(ctx context.Context, name string, expiresIn int) (*RegistrationToken, string, error) {
+	var resp struct {
+		Token  RegistrationToken `json:"token"`
+		Secret string            `json:"secret"`
+	}
+	input := map[string]interface{}{"name": name, "expires_in": expiresIn}
+	if err := c.call(ctx, http.MethodPost, "/admin/node-tokens", nil, input, &resp); err != nil {
+		return nil, "", err
+	}
+	return &resp.Token, resp.Secret, nil
+}
+
+// CheckNodeHealth pings one node from the server
+func (c *Client) CheckNodeHealth(ctx context.Context, nodeID uuid.UUID) (*NodeHealth, error) {
+	var health NodeHealth
+	if err := c.call(ctx, http.MethodGet, "/nodes/"+nodeID.String()+"/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// NodeDiagnostics pulls a node's diagnostics through the server. A node the server couldn't reach
+// is returned with Reachable false, not as an error.
+func (c *Client) NodeDiagnostics(ctx context.Context, nodeID uuid.UUID) (*NodeDiagnostics, error) {
+	var resp struct {
+		Diagnostics NodeDiagnostics `json:"diagnostics"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/nodes/"+nodeID.String()+"/diagnostics", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Diagnostics, nil
+}
+
+// CheckAllNodesHealth pings every node from the server
+func (c *Client) CheckAllNodesHealth(ctx context.Context) ([]NodeHealth, error) {
+	var resp struct {
+		HealthResults []NodeHealth `json:"health_results"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/nodes/health", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.HealthResults, nil
+}
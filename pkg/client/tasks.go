@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// AdminTask is a maintenance task admins can run on the server
+type AdminTask struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AdminTaskRun is one run of an admin task
+type AdminTaskRun struct {
+	Task    string    `json:"task"`
+	JobType string    `json:"job_type,omitempty"`
+	RunBy   uuid.UUID `json:"run_by"`
+	Job     Job       `json:"job"`
+}
+
+// ListAdminTasks returns the tasks that can be run and the most recent runs, newest first
+func (c *Client) ListAdminTasks(ctx context.Context) ([]AdminTask, []AdminTaskRun, error) {
+	var resp struct {
+		Tasks []AdminTask    `json:"tasks"`
+		Runs  []AdminTaskRun `json:"runs"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/admin/tasks", nil, nil, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Tasks, resp.Runs, nil
+}
+
+// RunAdminTask queues a task and returns the job it runs as. jobType limits retry-failed-jobs to
+// one job type. A task already queued or running is refused with a conflict.
+func (c *Client) RunAdminTask(ctx context.Context, task, jobType string) (*Job, error) {
+	var resp struct {
+		Job Job `json:"job"`
+	}
+	input := map[string]string{"task": task}
+	if jobType != "" {
+		input["job_type"] = jobType
+	}
+	if err := c.call(ctx, http.MethodPost, "/admin/tasks", nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Job, nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stages of a transfer, in the order an upload goes through them
+const (
+	TransferWaiting   = "waiting"
+	TransferReceiving = "receiving"
+	TransferStoring   = "storing"
+	TransferNode      = "node_transfer"
+	TransferComplete  = "complete"
+	TransferFailed    = "failed"
+)
+
+// TransferEvent is the server's side of an upload sent with a transfer ID
+type TransferEvent struct {
+	TransferID string     `json:"transfer_id"`
+	Stage      string     `json:"stage"`
+	Bytes      int64      `json:"bytes"` // bytes of the stage done
+	Total      int64      `json:"total"` // bytes the stage takes, 0 when unknown
+	NodeID     *uuid.UUID `json:"node_id,omitempty"`
+	FileID     *uuid.UUID `json:"file_id,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Done reports whether the upload has finished, successfully or not
+func (e *TransferEvent) Done() bool {
+	return e.Stage == TransferComplete || e.Stage == TransferFailed
+}
+
+// NewTransferID returns a random ID for UploadOptions.TransferID and ResumeOptions.TransferID
+func NewTransferID() string {
+	return uuid.NewString()
+}
+
+// GetTransfer returns how far an upload sent with transferID got
+func (c *Client) GetTransfer(ctx context.Context, transferID string) (*TransferEvent, error) {
+	var event TransferEvent
+	if err := c.call(ctx, http.MethodGet, "/transfers/"+url.PathEscape(transferID), nil, nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// WatchTransfer follows an upload sent with transferID, calling fn with each update until the
+// upload completes or fails, and returns the last update. Start watching before the upload so
+// none of it is missed. The server handling the upload must be the one watched.
+func (c *Client) WatchTransfer(ctx context.Context, transferID string, fn func(TransferEvent)) (*TransferEvent, error) {
+	resp, err := c.do(ctx, request{
+		method:    http.MethodGet,
+		path:      "/transfers/" + url.PathEscape(transferID) + "/events",
+		header:    http.Header{"Accept": {"text/event-stream"}},
+		retryable: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var last *TransferEvent
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		var event TransferEvent
+		if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+			return last, fmt.Errorf("failed to decode transfer event: %w", err)
+		}
+		data.Reset()
+		last = &event
+		if fn != nil {
+			fn(event)
+		}
+		if event.Done() {
+			return last, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return last, err
+	}
+	if err := ctx.Err(); err != nil {
+		return last, err
+	}
+	return last, errors.New("transfer stream ended before the upload finished")
+}
+
+// transferHeader sends a transfer ID with an upload, nil when there is none
+func transferHeader(transferID string) http.Header {
+	if transferID == "" {
+		return nil
+	}
+	return http.Header{"X-Transfer-Id": {transferID}}
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is an account on the server
+type User struct {
+	ID        uuid.UUID  `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	LastLogin *time.Time `json:"last_login,omitempty"`
+}
+
+// AuthRule controls how a bucket's files are accessed
+type AuthRule struct {
+	Type    string                 `json:"type"` // none, signed, jwt, ...
+	Enabled bool                   `json:"enabled"`
+	Config  map[string]interface{} `json:"config,omitempty"`
+}
+
+// CORSRule allows cross-origin reads of a bucket's files
+type CORSRule struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	ExposeHeaders  []string `json:"expose_headers,omitempty"`
+	MaxAge         int      `json:"max_age,omitempty"`
+}
+
+// BucketSettings are a bucket's limits and features
+type BucketSettings struct {
+	MaxFileSize            int64      `json:"max_file_size"`
+	MaxTotalSize           int64      `json:"max_total_size"`
+	AllowedMimeTypes       []string   `json:"allowed_mime_types"`
+	BlockedMimeTypes       []string   `json:"blocked_mime_types"`
+	AllowedExtensions      []string   `json:"allowed_extensions"`
+	BlockedExtensions      []string   `json:"blocked_extensions"`
+	MaxFilesPerBucket      int64      `json:"max_files_per_bucket"`
+	PublicRead             bool       `json:"public_read"`
+	Versioning             bool       `json:"versioning"`
+	Encryption             bool       `json:"encryption"`
+	AllowOverwrite         bool       `json:"allow_overwrite"`
+	RequireContentType     bool       `json:"require_content_type"`
+	VideoProcessing        bool       `json:"video_processing"`
+	CORSRules              []CORSRule `json:"cors_rules"`
+	MaxVersions            int        `json:"max_versions"`
+	VersionRetentionDays   int        `json:"version_retention_days"`
+	DisableImageTransforms bool       `json:"disable_image_transforms"`
+	PlacementNodeID        *uuid.UUID `json:"placement_node_id,omitempty"`
+	PlacementGroup         string     `json:"placement_group"`
+	PlacementZone          string     `json:"placement_zone"`
+	MinZones               int        `json:"min_zones"`
+	WebsiteEnabled         bool       `json:"website_enabled"`
+	WebsiteIndexDocument   string     `json:"website_index_document"`
+	WebsiteErrorDocument   string     `json:"website_error_document"`
+	WebsiteDomain          string     `json:"website_domain"`
+	CustomDomain           string     `json:"custom_domain"`
+	EgressQuota            int64      `json:"egress_quota"`
+	EgressQuotaPolicy      string     `json:"egress_quota_policy"`
+	Compression            string     `json:"compression"`
+}
+
+// BucketStats are a bucket's usage
+type BucketStats struct {
+	TotalFiles int64      `json:"total_files"`
+	TotalSize  int64      `json:"total_size"`
+	LastAccess *time.Time `json:"last_access,omitempty"`
+}
+
+// Bucket is a container of files
+type Bucket struct {
+	ID          uuid.UUID      `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	OwnerID     uuid.UUID      `json:"owner_id"`
+	AuthRule    AuthRule       `json:"auth_rule"`
+	Settings    BucketSettings `json:"settings"`
+	Stats       BucketStats    `json:"stats"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// FileMetadata is a file's content headers and custom metadata
+type FileMetadata struct {
+	ContentType        string                 `json:"content_type"`
+	ContentEncoding    string                 `json:"content_encoding,omitempty"`
+	ContentDisposition string                 `json:"content_disposition,omitempty"`
+	CacheControl       string                 `json:"cache_control,omitempty"`
+	CustomMetadata     map[string]interface{} `json:"custom_metadata,omitempty"`
+	ScanStatus         string                 `json:"scan_status,omitempty"` // clean, infected (quarantined) or error
+	ScanSignature      string                 `json:"scan_signature,omitempty"`
+	ScannedAt          *time.Time             `json:"scanned_at,omitempty"`
+}
+
+// File is a stored object
+type File struct {
+	ID           uuid.UUID    `json:"id"`
+	BucketID     uuid.UUID    `json:"bucket_id"`
+	Name         string       `json:"name"`
+	OriginalName string       `json:"original_name"`
+	Path         string       `json:"path"`
+	Size         int64        `json:"size"`
+	MimeType     string       `json:"mime_type"`
+	Checksum     string       `json:"checksum"`
+	Version      int          `json:"version"`
+	Encrypted    bool         `json:"encrypted"`
+	AuthRule     *AuthRule    `json:"auth_rule,omitempty"`
+	Metadata     FileMetadata `json:"metadata"`
+	SecuredURL   string       `json:"secured_url,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	Replication  *Replication `json:"replication,omitempty"` // set by GetFile
+}
+
+// Replica is one stored copy of a file version
+type Replica struct {
+	Type       string     `json:"type"` // "primary" or "backup"
+	Location   string     `json:"location"`
+	NodeID     *uuid.UUID `json:"node_id,omitempty"`
+	Healthy    bool       `json:"healthy"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// Replication is how many copies of a file version exist and whether they are intact
+type Replication struct {
+	Replicas        int        `json:"replicas"`
+	HealthyReplicas int        `json:"healthy_replicas"`
+	LastVerifiedAt  *time.Time `json:"last_verified_at,omitempty"`
+	Degraded        bool       `json:"degraded"`
+	AtRisk          bool       `json:"at_risk"`
+	Unavailable     bool       `json:"unavailable"`
+	Copies          []Replica  `json:"copies"`
+}
+
+// SignedURL is a time-limited link to a file
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Node is a storage node of a distributed installation
+type Node struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	PublicURL   string     `json:"public_url,omitempty"`
+	MaxStorage  int64      `json:"max_storage"`
+	UsedStorage int64      `json:"used_storage"`
+	Priority    int        `json:"priority"`
+	IsActive    bool       `json:"is_active"`
+	IsHealthy   bool       `json:"is_healthy"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastPing    *time.Time `json:"last_ping,omitempty"`
+	Status      string     `json:"status"` // healthy, unhealthy, inactive, maintenance or failed
+	// Maintenance is set while the node is in maintenance
+	Maintenance *NodeMaintenance `json:"maintenance,omitempty"`
+}
+
+// NodeMaintenance describes a storage node's maintenance
+type NodeMaintenance struct {
+	Since      time.Time `json:"since"`
+	DrainReads bool      `json:"drain_reads"` // its files are read from other copies where there are any
+}
+
+// RegistrationToken lets one storage node register itself, its secret is only returned when created
+type RegistrationToken struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	NodeID      *uuid.UUID `json:"node_id,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NodeDiagnostics is what the server pulled from a node's diagnostics endpoint
+type NodeDiagnostics struct {
+	NodeID       uuid.UUID  `json:"node_id"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	Reachable    bool       `json:"reachable"`
+	Error        string     `json:"error,omitempty"`
+	CheckedAt    time.Time  `json:"checked_at"`
+	RoundTripMs  int64      `json:"round_trip_ms"`
+	ClockSkewMs  int64      `json:"clock_skew_ms"` // the node's clock ahead of the server's, negative when behind
+	Version      string     `json:"version,omitempty"`
+	StoragePath  string     `json:"storage_path,omitempty"`
+	Disk         *NodeDisk  `json:"disk,omitempty"`
+	RecentErrors []LogEntry `json:"recent_errors"`
+}
+
+// NodeDisk is the space and inodes of the filesystem a node stores content on
+type NodeDisk struct {
+	TotalBytes        uint64  `json:"total_bytes"`
+	FreeBytes         uint64  `json:"free_bytes"`
+	UsedPercent       float64 `json:"used_percent"`
+	TotalInodes       uint64  `json:"total_inodes"`
+	FreeInodes        uint64  `json:"free_inodes"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+}
+
+// LogEntry is a line a node logged
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// NodeHealth is the result of checking a node
+type NodeHealth struct {
+	NodeID         uuid.UUID `json:"node_id"`
+	IsHealthy      bool      `json:"is_healthy"`
+	ResponseTimeMs int64     `json:"response_time_ms"`
+	Status         string    `json:"status"` // maintenance for nodes in maintenance, answering or not
+	Error          string    `json:"error,omitempty"`
+}
+
+// Job is a background job, such as a forced bucket deletion
+type Job struct {
+	ID          uuid.UUID              `json:"id"`
+	Type        string                 `json:"type"`
+	Status      string                 `json:"status"` // queued, running, completed or failed
+	BucketID    *uuid.UUID             `json:"bucket_id,omitempty"`
+	Total       int64                  `json:"total"`
+	Completed   int64                  `json:"completed"`
+	Attempts    int                    `json:"attempts"`
+	MaxAttempts int                    `json:"max_attempts"`
+	Error       string                 `json:"error,omitempty"`
+	Result      map[string]interface{} `json:"result,omitempty"`
+	NextRunAt   *time.Time             `json:"next_run_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at,omitempty"`
+}
+
+// Done reports whether the job has finished, successfully or not
+func (j *Job) Done() bool {
+	return j.Status == "completed" || j.Status == "failed"
+}
+
+// ListOptions selects a page of a list, pages start at 1. SortBy, Order and Name sort and filter
+// it, each list names the fields it can be sorted by.
+type ListOptions struct {
+	Page   int
+	Limit  int
+	SortBy string
+	Order  string // asc or desc
+	Name   string // names containing it, case-insensitively
+	Cursor string // a page's NextCursor, continues buckets and files after it instead of Page
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultChunkSize is the chunk size ResumeUpload uses when none is given
+const DefaultChunkSize = 8 * 1024 * 1024
+
+// UploadSession is a resumable upload. The server keeps its progress across restarts until it
+// is completed, aborted or expires.
+type UploadSession struct {
+	ID          uuid.UUID `json:"id"`
+	BucketID    uuid.UUID `json:"bucket_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset"` // bytes received, where the next chunk starts
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ResumeOptions are optional settings of ResumeUpload
+type ResumeOptions struct {
+	ChunkSize int64 // bytes sent per request, DefaultChunkSize when zero
+	// Progress is called with the number of bytes the server has acknowledged so far
+	Progress func(sent int64)
+	// TransferID, when set, lets WatchTransfer follow the chunks arriving and the server storing the file
+	TransferID string
+}
+
+// StartUpload begins a resumable upload of a file of size bytes. contentType may be empty to
+// have the server guess it from the name.
+func (c *Client) StartUpload(ctx context.Context, bucketID uuid.UUID, name string, size int64, contentType string) (*UploadSession, error) {
+	body := map[string]interface{}{
+		"file_name":    name,
+		"content_type": contentType,
+		"size":         size,
+	}
+	var resp struct {
+		Upload UploadSession `json:"upload"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/buckets/"+bucketID.String()+"/uploads", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Upload, nil
+}
+
+// GetUpload returns a resumable upload's progress
+func (c *Client) GetUpload(ctx context.Context, bucketID, uploadID uuid.UUID) (*UploadSession, error) {
+	var resp struct {
+		Upload UploadSession `json:"upload"`
+	}
+	if err := c.call(ctx, http.MethodGet, uploadPath(bucketID, uploadID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Upload, nil
+}
+
+// UploadChunk sends the chunk of a resumable upload starting at offset, which must be the
+// upload's current offset. A chunk the server already received fails with a conflict.
+func (c *Client) UploadChunk(ctx context.Context, bucketID, uploadID uuid.UUID, offset int64, chunk []byte) (*UploadSession, error) {
+	return c.uploadChunk(ctx, bucketID, uploadID, offset, chunk, "")
+}
+
+func (c *Client) uploadChunk(ctx context.Context, bucketID, uploadID uuid.UUID, offset int64, chunk []byte, transferID string) (*UploadSession, error) {
+	resp, err := c.do(ctx, request{
+		method:      http.MethodPut,
+		path:        uploadPath(bucketID, uploadID),
+		query:       url.Values{"offset": {strconv.FormatInt(offset, 10)}},
+		body:        func() (io.Reader, error) { return bytes.NewReader(chunk), nil },
+		contentType: "application/octet-stream",
+		header:      transferHeader(transferID),
+		retryable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Upload UploadSession `json:"upload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &out.Upload, nil
+}
+
+// CompleteUpload stores the file of a resumable upload that has received all its content
+func (c *Client) CompleteUpload(ctx context.Context, bucketID, uploadID uuid.UUID) (*File, error) {
+	return c.completeUpload(ctx, bucketID, uploadID, "")
+}
+
+func (c *Client) completeUpload(ctx context.Context, bucketID, uploadID uuid.UUID, transferID string) (*File, error) {
+	resp, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   uploadPath(bucketID, uploadID) + "/complete",
+		header: transferHeader(transferID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		File File `json:"file"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &out.File, nil
+}
+
+// AbortUpload abandons a resumable upload
+func (c *Client) AbortUpload(ctx context.Context, bucketID, uploadID uuid.UUID) error {
+	return c.call(ctx, http.MethodDelete, uploadPath(bucketID, uploadID), nil, nil, nil)
+}
+
+// ResumeUpload sends the rest of a resumable upload's content from r, starting at the offset the
+// server last acknowledged, and completes it. After an interruption it can be called again with
+// the same upload, e.g. by a later run that saved the upload's ID.
+func (c *Client) ResumeUpload(ctx context.Context, upload *UploadSession, r io.ReaderAt, opts *ResumeOptions) (*File, error) {
+	if opts == nil {
+		opts = &ResumeOptions{}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	current, err := c.GetUpload(ctx, upload.BucketID, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, min(chunkSize, max(current.Size-current.Offset, 1)))
+	for current.Offset < current.Size {
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), current.Size-current.Offset)], current.Offset)
+		if err != nil && !(errors.Is(err, io.EOF) && n > 0) {
+			return nil, fmt.Errorf("failed to read upload content at %d: %w", current.Offset, err)
+		}
+
+		next, err := c.uploadChunk(ctx, upload.BucketID, upload.ID, current.Offset, buf[:n], opts.TransferID)
+		if IsConflict(err) {
+			// A retried chunk may have arrived the first time, the server knows where to go on from
+			next, err = c.GetUpload(ctx, upload.BucketID, upload.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		current = next
+		if opts.Progress != nil {
+			opts.Progress(current.Offset)
+		}
+	}
+
+	return c.completeUpload(ctx, upload.BucketID, upload.ID, opts.TransferID)
+}
+
+func uploadPath(bucketID, uploadID uuid.UUID) string {
+	return "/buckets/" + bucketID.String() + "/uploads/" + uploadID.String()
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers of webhook deliveries
+const (
+	WebhookEventHeader     = "X-SHBucket-Event"
+	WebhookDeliveryHeader  = "X-SHBucket-Delivery" // the event ID
+	WebhookTimestampHeader = "X-SHBucket-Timestamp"
+	WebhookSignatureHeader = "X-SHBucket-Signature"
+)
+
+// DefaultWebhookTolerance is how old a delivery's timestamp may be by default
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// maxWebhookBody limits the delivery bodies Verify reads
+const maxWebhookBody = 1 << 20
+
+var (
+	// ErrWebhookSignature is returned for deliveries that aren't signed with the webhook's secret
+	ErrWebhookSignature = errors.New("shbucket: webhook signature does not match")
+	// ErrWebhookTimestamp is returned for deliveries whose timestamp is missing or outside the tolerance
+	ErrWebhookTimestamp = errors.New("shbucket: webhook timestamp is missing or too old")
+	// ErrWebhookAcknowledged is returned for deliveries of events the receiver has already acknowledged.
+	// Answer them with a 2xx status so the server stops retrying.
+	ErrWebhookAcknowledged = errors.New("shbucket: webhook delivery was already acknowledged")
+)
+
+// WebhookEvent is a bucket event delivered to a webhook
+type WebhookEvent struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	BucketID  uuid.UUID              `json:"bucket_id"`
+	FileID    *uuid.UUID             `json:"file_id,omitempty"`
+	ActorID   uuid.UUID              `json:"actor_id"`
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AcknowledgedDeliveries remembers the events a receiver has handled. Receivers running several
+// instances share one kept in their database; by default they are remembered in memory for a day.
+type AcknowledgedDeliveries interface {
+	Acknowledged(ctx context.Context, eventID uuid.UUID) (bool, error)
+	Acknowledge(ctx context.Context, eventID uuid.UUID) error
+}
+
+// WebhookVerifier checks deliveries to a webhook: that they are signed with its secret, recent, and
+// not of an event already acknowledged, so a captured delivery can't be replayed to the receiver.
+//
+//	verifier := client.NewWebhookVerifier(secret)
+//	http.HandleFunc("/hooks/shbucket", func(w http.ResponseWriter, r *http.Request) {
+//		event, err := verifier.Verify(r)
+//		if errors.Is(err, client.ErrWebhookAcknowledged) {
+//			w.WriteHeader(http.StatusOK)
+//			return
+//		}
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusUnauthorized)
+//			return
+//		}
+//		handle(event)
+//		verifier.Acknowledge(r.Context(), event.ID)
+//	})
+type WebhookVerifier struct {
+	secret       string
+	tolerance    time.Duration
+	acknowledged AcknowledgedDeliveries
+}
+
+// WebhookVerifierOption configures a WebhookVerifier
+type WebhookVerifierOption func(*WebhookVerifier)
+
+// WithWebhookTolerance sets how old a delivery's timestamp may be
+func WithWebhookTolerance(tolerance time.Duration) WebhookVerifierOption {
+	return func(v *WebhookVerifier) { v.tolerance = tolerance }
+}
+
+// WithAcknowledgedDeliveries sets where acknowledged events are remembered
+func WithAcknowledgedDeliveries(acknowledged AcknowledgedDeliveries) WebhookVerifierOption {
+	return func(v *WebhookVerifier) { v.acknowledged = acknowledged }
+}
+
+// NewWebhookVerifier creates a verifier for deliveries signed with secret
+func NewWebhookVerifier(secret string, opts ...WebhookVerifierOption) *WebhookVerifier {
+	v := &WebhookVerifier{
+		secret:       secret,
+		tolerance:    DefaultWebhookTolerance,
+		acknowledged: &memoryAcknowledged{retention: 24 * time.Hour, at: make(map[uuid.UUID]time.Time)},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify reads a delivery request and returns its event
+func (v *WebhookVerifier) Verify(r *http.Request) (*WebhookEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		return nil, fmt.Errorf("shbucket: failed to read webhook delivery: %w", err)
+	}
+	return v.VerifyPayload(r.Context(), r.Header, body)
+}
+
+// VerifyPayload checks a delivery's headers and body and returns its event
+func (v *WebhookVerifier) VerifyPayload(ctx context.Context, header http.Header, body []byte) (*WebhookEvent, error) {
+	timestamp, err := strconv.ParseInt(header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrWebhookTimestamp
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > v.tolerance || age < -v.tolerance {
+		return nil, ErrWebhookTimestamp
+	}
+
+	expected := signWebhook(v.secret, timestamp, body)
+	signed := false
+	for _, signature := range strings.Split(header.Get(WebhookSignatureHeader), ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, ErrWebhookSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("shbucket: invalid webhook event: %w", err)
+	}
+	acknowledged, err := v.acknowledged.Acknowledged(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	if acknowledged {
+		return &event, ErrWebhookAcknowledged
+	}
+	return &event, nil
+}
+
+// Acknowledge records that an event was handled, later deliveries of it are refused
+func (v *WebhookVerifier) Acknowledge(ctx context.Context, eventID uuid.UUID) error {
+	return v.acknowledged.Acknowledge(ctx, eventID)
+}
+
+// signWebhook computes a delivery's signature the way the server does
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// memoryAcknowledged remembers acknowledged events in memory for the retention period
+type memoryAcknowledged struct {
+	mu        sync.Mutex
+	retention time.Duration
+	at        map[uuid.UUID]time.Time
+}
+
+func (m *memoryAcknowledged) Acknowledged(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at, ok := m.at[eventID]
+	return ok && time.Since(at) < m.retention, nil
+}
+
+func (m *memoryAcknowledged) Acknowledge(ctx context.Context, eventID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, at := range m.at {
+		if now.Sub(at) >= m.retention {
+			delete(m.at, id)
+		}
+	}
+	m.at[eventID] = now
+	return nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is an endpoint receiving a bucket's events. Its secret is only returned when issued.
+type Webhook struct {
+	ID           uuid.UUID `json:"id"`
+	BucketID     uuid.UUID `json:"bucket_id"`
+	URL          string    `json:"url"`
+	SecretPrefix string    `json:"secret_prefix"`
+	EventTypes   []string  `json:"event_types"` // empty for every event
+	CreatedBy    uuid.UUID `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ListWebhooks returns a bucket's webhooks
+func (c *Client) ListWebhooks(ctx context.Context, bucketID uuid.UUID) ([]Webhook, error) {
+	var resp struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/buckets/"+bucketID.String()+"/webhooks", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Webhooks, nil
+}
+
+// CreateWebhook adds a webhook receiving the bucket's events, only those of eventTypes when given.
+// It returns the webhook and the secret its deliveries are signed with, which can't be retrieved again.
+func (c *Client) CreateWebhook(ctx context.Context, bucketID uuid.UUID, url string, eventTypes ...string) (*Webhook, string, error) {
+	var resp struct {
+		Webhook Webhook `json:"webhook"`
+		Secret  string  `json:"secret"`
+	}
+	input := map[string]interface{}{"url": url, "event_types": eventTypes}
+	if err := c.call(ctx, http.MethodPost, "/buckets/"+bucketID.String()+"/webhooks", nil, input, &resp); err != nil {
+		return nil, "", err
+	}
+	return &resp.Webhook, resp.Secret, nil
+}
+
+// DeleteWebhook removes a webhook
+func (c *Client) DeleteWebhook(ctx context.Context, bucketID, webhookID uuid.UUID) error {
+	return c.call(ctx, http.MethodDelete, "/buckets/"+bucketID.String()+"/webhooks/"+webhookID.String(), nil, nil, nil)
+}
+
+// RotateWebhookSecret replaces a webhook's secret and returns the new one. Deliveries are signed
+// with it from then on.
+func (c *Client) RotateWebhookSecret(ctx context.Context, bucketID, webhookID uuid.UUID) (string, error) {
+	var resp struct {
+		Secret string `json:"secret"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/buckets/"+bucketID.String()+"/webhooks/"+webhookID.String()+"/rotate-secret", nil, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Secret, nil
+}
@@ -11,7 +11,9 @@ import (
 	
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/Auth"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/IPFilter"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 )
@@ -20,6 +22,9 @@ type CreateAPIKeyCommand struct {
 	Name        string                      `json:"name" validate:"required,min=3,max=100"`
 	UserID      uuid.UUID                   `json:"user_id" validate:"required"`
 	Permissions entities.APIKeyPermission  `json:"permissions"`
+	Role        string                      `json:"role,omitempty"` // limits the key to a built-in or custom role, empty for the owner's
+	EgressQuota      int64                  `json:"egress_quota" validate:"min=0"`       // bytes downloaded with the key per billing cycle, 0 for no quota
+	EgressDailyQuota int64                  `json:"egress_daily_quota" validate:"min=0"` // bytes downloaded with the key per day, 0 for no quota
 	ExpiresAt   *time.Time                  `json:"expires_at,omitempty"`
 }
 
@@ -41,12 +46,30 @@ func NewCreateAPIKeyRequestHandler(dbContext *persistence.AppDbContext) *CreateA
 }
 
 func (h *CreateAPIKeyRequestHandler) Handle(ctx context.Context, command *CreateAPIKeyCommand) (*CreateAPIKeyResponse, error) {
+	if command.Role != "" {
+		if err := auth.ValidateRole(h.dbContext, command.Role); err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate API key
 	plainKey, keyHash, keyPrefix, err := h.generateAPIKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate API key: %w", err)
 	}
 	
+	// Keep the address ranges the key is limited to in CIDR form
+	allowedIPs, err := ipfilter.Normalize(command.Permissions.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	deniedIPs, err := ipfilter.Normalize(command.Permissions.DeniedIPs)
+	if err != nil {
+		return nil, err
+	}
+	command.Permissions.AllowedIPs = allowedIPs
+	command.Permissions.DeniedIPs = deniedIPs
+	
 	// Marshal permissions to JSON
 	permissionsJSON, err := json.Marshal(command.Permissions)
 	if err != nil {
@@ -61,6 +84,9 @@ func (h *CreateAPIKeyRequestHandler) Handle(ctx context.Context, command *Create
 		UserId:      command.UserID, // Map to UserId field
 		IsActive:    true,
 		Permissions: datatypes.JSON(permissionsJSON),
+		Role:        command.Role,
+		EgressQuota:      command.EgressQuota,
+		EgressDailyQuota: command.EgressDailyQuota,
 		ExpiresAt:   command.ExpiresAt,
 	}
 	
@@ -88,6 +114,9 @@ func (h *CreateAPIKeyRequestHandler) Handle(ctx context.Context, command *Create
 		Username:    user.Username,
 		IsActive:    apiKey.IsActive,
 		Permissions: permissions,
+		Role:        apiKey.Role,
+		EgressQuota:      apiKey.EgressQuota,
+		EgressDailyQuota: apiKey.EgressDailyQuota,
 		ExpiresAt:   apiKey.ExpiresAt,
 		LastUsed:    apiKey.LastUsed,
 		CreatedAt:   apiKey.CreatedAt,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	
 	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 )
@@ -37,7 +38,7 @@ func (h *DeleteAPIKeyRequestHandler) Handle(ctx context.Context, command *Delete
 	}).FirstOrDefault()
 	
 	if err != nil || apiKey == nil {
-		return nil, fmt.Errorf("API key not found")
+		return nil, apierror.New(apierror.CodeNotFound, "API key not found")
 	}
 	
 	// Delete the API key using GoNtext
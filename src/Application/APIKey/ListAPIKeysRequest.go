@@ -80,6 +80,9 @@ func (h *ListAPIKeysRequestHandler) Handle(ctx context.Context, command *ListAPI
 			Username:    apiKey.User.Username,
 			IsActive:    apiKey.IsActive,
 			Permissions: permissions,
+			Role:        apiKey.Role,
+			EgressQuota:      apiKey.EgressQuota,
+			EgressDailyQuota: apiKey.EgressDailyQuota,
 			ExpiresAt:   apiKey.ExpiresAt,
 			LastUsed:    apiKey.LastUsed,
 			CreatedAt:   apiKey.CreatedAt,
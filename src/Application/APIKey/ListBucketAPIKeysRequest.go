@@ -0,0 +1,62 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListBucketAPIKeysCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type ListBucketAPIKeysResponse struct {
+	APIKeys []models.APIKeyResponse `json:"api_keys"`
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+}
+
+type ListBucketAPIKeysRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListBucketAPIKeysRequestHandler(dbContext *persistence.AppDbContext) *ListBucketAPIKeysRequestHandler {
+	return &ListBucketAPIKeysRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists the API keys scoped to a bucket, whoever they belong to, for the bucket's owner and bucket admins.
+// Keys without a bucket scope reach every bucket of their user and aren't listed.
+func (h *ListBucketAPIKeysRequestHandler) Handle(ctx context.Context, command *ListBucketAPIKeysCommand) (*ListBucketAPIKeysResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage its API keys")
+	}
+
+	keys, err := KeysGrantedBucket(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, toAPIKeyResponse(&keys[i]))
+	}
+
+	return &ListBucketAPIKeysResponse{
+		APIKeys: responses,
+		Success: true,
+		Message: "API keys retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type RevokeBucketAPIKeyCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	KeyID    uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type RevokeBucketAPIKeyResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type RevokeBucketAPIKeyRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewRevokeBucketAPIKeyRequestHandler(dbContext *persistence.AppDbContext) *RevokeBucketAPIKeyRequestHandler {
+	return &RevokeBucketAPIKeyRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle takes the bucket out of an API key's scope. The key keeps working for its other buckets,
+// and is deactivated when this was its only one.
+func (h *RevokeBucketAPIKeyRequestHandler) Handle(ctx context.Context, command *RevokeBucketAPIKeyCommand) (*RevokeBucketAPIKeyResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage its API keys")
+	}
+
+	keys, err := KeysGrantedBucket(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, err
+	}
+	var key *entities.APIKey
+	for i := range keys {
+		if keys[i].Id == command.KeyID {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "API key not found")
+	}
+
+	if err := RemoveBucketGrant(h.dbContext, key, bucket.Id); err != nil {
+		return nil, err
+	}
+
+	h.events.Publish(events.BucketAPIKeyRevoked, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"name":       bucket.Name,
+		"key_id":     key.Id.String(),
+		"key_prefix": key.KeyPrefix,
+		"key_user":   key.UserId.String(),
+	})
+
+	return &RevokeBucketAPIKeyResponse{
+		Success: true,
+		Message: "API key revoked for this bucket",
+	}, nil
+}
@@ -0,0 +1,87 @@
+package apikey
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// KeysGrantedBucket returns the API keys whose permissions name the bucket
+func KeysGrantedBucket(dbContext *persistence.AppDbContext, bucketID uuid.UUID) ([]entities.APIKey, error) {
+	return keysGrantedBucket(dbContext.GetDB(), bucketID)
+}
+
+func keysGrantedBucket(db *gorm.DB, bucketID uuid.UUID) ([]entities.APIKey, error) {
+	var keys []entities.APIKey
+	query := db.Preload("User")
+	if persistence.IsSQLite(db) {
+		query = query.Where(`EXISTS (SELECT 1 FROM json_each("Permissions", '$.buckets') WHERE value = ?)`, bucketID.String())
+	} else {
+		grant, _ := json.Marshal(map[string][]string{"buckets": {bucketID.String()}})
+		query = query.Where(`"Permissions" @> ?::jsonb`, string(grant))
+	}
+	if err := query.Order(`"CreatedAt" DESC`).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys granted the bucket: %w", err)
+	}
+	return keys, nil
+}
+
+// RemoveBucketGrant takes the bucket out of a key's permissions. A key left without buckets is
+// deactivated rather than widened to all of its user's buckets.
+func RemoveBucketGrant(dbContext *persistence.AppDbContext, key *entities.APIKey, bucketID uuid.UUID) error {
+	return removeBucketGrant(dbContext.GetDB(), key, bucketID)
+}
+
+func removeBucketGrant(db *gorm.DB, key *entities.APIKey, bucketID uuid.UUID) error {
+	var permissions entities.APIKeyPermission
+	if err := json.Unmarshal(key.Permissions, &permissions); err != nil {
+		return fmt.Errorf("failed to read permissions of API key %s: %w", key.Id, err)
+	}
+
+	remaining := []string{}
+	for _, id := range permissions.Buckets {
+		if id != bucketID.String() {
+			remaining = append(remaining, id)
+		}
+	}
+	permissions.Buckets = remaining
+
+	updates := map[string]interface{}{}
+	if data, err := json.Marshal(permissions); err == nil {
+		updates["Permissions"] = data
+	}
+	if len(remaining) == 0 {
+		updates["IsActive"] = false
+	}
+	if err := db.Model(&entities.APIKey{}).Where(`"Id" = ?`, key.Id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to revoke API key %s: %w", key.Id, err)
+	}
+	return nil
+}
+
+func toAPIKeyResponse(apiKey *entities.APIKey) models.APIKeyResponse {
+	var permissions entities.APIKeyPermission
+	json.Unmarshal(apiKey.Permissions, &permissions)
+
+	return models.APIKeyResponse{
+		ID:          apiKey.Id,
+		Name:        apiKey.Name,
+		KeyPrefix:   apiKey.KeyPrefix,
+		UserID:      apiKey.UserId,
+		Username:    apiKey.User.Username,
+		IsActive:    apiKey.IsActive,
+		Permissions: permissions,
+		Role:        apiKey.Role,
+		EgressQuota:      apiKey.EgressQuota,
+		EgressDailyQuota: apiKey.EgressDailyQuota,
+		ExpiresAt:   apiKey.ExpiresAt,
+		LastUsed:    apiKey.LastUsed,
+		CreatedAt:   apiKey.CreatedAt,
+		UpdatedAt:   apiKey.UpdatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package apikey
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRemoveBucketGrant finds the keys granted a bucket, takes it out of their permissions and
+// deactivates the keys left without buckets
+func TestRemoveBucketGrant(t *testing.T) {
+	db := sqlitetest.Open(t)
+	user := entities.User{Username: "ada", Email: "ada@example.com", PasswordHash: "hash", Role: "user", IsActive: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	bucketID, otherID := uuid.New(), uuid.New()
+	var keys []entities.APIKey
+	for i, buckets := range [][]string{{bucketID.String()}, {bucketID.String(), otherID.String()}, {otherID.String()}} {
+		permissions, _ := json.Marshal(entities.APIKeyPermission{Read: true, Buckets: buckets})
+		key := entities.APIKey{Name: "key", KeyHash: uuid.NewString(), KeyPrefix: "shb_", UserId: user.Id, IsActive: true, Permissions: permissions}
+		if err := db.Create(&key).Error; err != nil {
+			t.Fatalf("failed to create key %d: %v", i, err)
+		}
+		keys = append(keys, key)
+	}
+
+	granted, err := keysGrantedBucket(db, bucketID)
+	if err != nil {
+		t.Fatalf("keysGrantedBucket() = %v", err)
+	}
+	if len(granted) != 2 || granted[0].User.Id != user.Id {
+		t.Fatalf("keysGrantedBucket() = %d keys, want the two keys granted the bucket with their user", len(granted))
+	}
+	for i := range granted {
+		if err := removeBucketGrant(db, &granted[i], bucketID); err != nil {
+			t.Fatalf("removeBucketGrant() = %v", err)
+		}
+	}
+
+	var stored []entities.APIKey
+	if err := db.Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range stored {
+		var permissions entities.APIKeyPermission
+		json.Unmarshal(key.Permissions, &permissions)
+		// The key only granted the bucket is left without buckets, the others keep the other bucket
+		wantActive, wantBuckets := true, 1
+		if key.Id == keys[0].Id {
+			wantActive, wantBuckets = false, 0
+		}
+		if key.IsActive != wantActive || len(permissions.Buckets) != wantBuckets {
+			t.Errorf("key with buckets %v active = %v, want %v with %d bucket(s)", permissions.Buckets, key.IsActive, wantActive, wantBuckets)
+		}
+	}
+	if granted, err := keysGrantedBucket(db, bucketID); err != nil || len(granted) != 0 {
+		t.Errorf("keysGrantedBucket() after removing the grants = %d keys, %v, want none", len(granted), err)
+	}
+}
@@ -0,0 +1,82 @@
+package admintask
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/Job"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// recentRunsLimit is how many past runs are listed with the catalog
+const recentRunsLimit = 50
+
+type ListAdminTasksCommand struct{}
+
+// TaskRunResponse is one run of a task, the job it ran as and who ran it
+type TaskRunResponse struct {
+	Task    string             `json:"task"`
+	JobType string             `json:"job_type,omitempty"`
+	RunBy   uuid.UUID          `json:"run_by"`
+	Job     models.JobResponse `json:"job"`
+}
+
+type ListAdminTasksResponse struct {
+	Tasks []TaskDescription `json:"tasks"`
+	// Runs are the most recent task runs, newest first
+	Runs    []TaskRunResponse `json:"runs"`
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+}
+
+type ListAdminTasksRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListAdminTasksRequestHandler(dbContext *persistence.AppDbContext) *ListAdminTasksRequestHandler {
+	return &ListAdminTasksRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists the tasks that can be run and the most recent runs, with who ran them and their results
+func (h *ListAdminTasksRequestHandler) Handle(ctx context.Context, command *ListAdminTasksCommand) (*ListAdminTasksResponse, error) {
+	recent, err := recentRuns(h.dbContext.GetDB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task runs: %w", err)
+	}
+
+	runs := make([]TaskRunResponse, 0, len(recent))
+	for i := range recent {
+		var payload taskPayload
+		json.Unmarshal(recent[i].Payload, &payload)
+		runs = append(runs, TaskRunResponse{
+			Task:    payload.Task,
+			JobType: payload.JobType,
+			RunBy:   recent[i].CreatedBy,
+			Job:     job.ToJobResponse(&recent[i]),
+		})
+	}
+
+	return &ListAdminTasksResponse{
+		Tasks:   Catalog,
+		Runs:    runs,
+		Success: true,
+		Message: "Tasks retrieved successfully",
+	}, nil
+}
+
+// recentRuns returns the latest runs of admin tasks, latest first
+func recentRuns(db *gorm.DB) ([]entities.Job, error) {
+	var recent []entities.Job
+	err := db.Where(&entities.Job{Type: jobs.TypeAdminTask}).
+		Order(`"CreatedAt" DESC`).Limit(recentRunsLimit).Find(&recent).Error
+	return recent, err
+}
@@ -0,0 +1,100 @@
+package admintask
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/Job"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// ErrTaskRunning is returned when the task is already queued or running
+var ErrTaskRunning = apierror.New(apierror.CodeConflict, "task is already queued or running")
+
+type RunAdminTaskCommand struct {
+	Task string `json:"task" validate:"required,oneof=recalculate-node-usage purge-expired-uploads retry-failed-jobs retry-failed-videos"`
+	// JobType limits retry-failed-jobs to one job type
+	JobType string    `json:"job_type,omitempty" validate:"omitempty,oneof=bucket.delete node.repair webhook.deliver"`
+	UserID  uuid.UUID `json:"-"`
+}
+
+type RunAdminTaskResponse struct {
+	Job     models.JobResponse `json:"job"`
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+}
+
+type RunAdminTaskRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewRunAdminTaskRequestHandler(dbContext *persistence.AppDbContext) *RunAdminTaskRequestHandler {
+	return &RunAdminTaskRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle queues a task from the catalog as a background job. The job records who ran the task
+// and what it did, so every run stays on record. A task runs once at a time.
+func (h *RunAdminTaskRequestHandler) Handle(ctx context.Context, command *RunAdminTaskCommand) (*RunAdminTaskResponse, error) {
+	if command.JobType != "" && command.Task != TaskRetryFailedJobs {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "job_type only applies to %s", TaskRetryFailedJobs)
+	}
+
+	active, err := taskActive(h.dbContext.GetDB(), command.Task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check running tasks: %w", err)
+	}
+	if active {
+		return nil, ErrTaskRunning
+	}
+
+	queued, err := jobs.Enqueue(h.dbContext, jobs.TypeAdminTask, taskPayload{Task: command.Task, JobType: command.JobType}, jobs.Options{
+		CreatedBy:   command.UserID,
+		MaxAttempts: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Admin task %s queued by user %s as job %s", command.Task, command.UserID, queued.Id)
+
+	return &RunAdminTaskResponse{
+		Job:     job.ToJobResponse(queued),
+		Success: true,
+		Message: fmt.Sprintf("Task %s queued", command.Task),
+	}, nil
+}
+
+// RunTaskJob runs a task queued by Handle, it is registered with the job runner. Tasks aren't
+// retried, an admin reruns a failed task once they have seen why it failed.
+func (h *RunAdminTaskRequestHandler) RunTaskJob(ctx context.Context, run *jobs.Run) error {
+	var payload taskPayload
+	if err := run.Decode(&payload); err != nil {
+		return err
+	}
+
+	result, err := (&runner{dbContext: h.dbContext}).run(ctx, run, payload)
+	if err != nil {
+		return err
+	}
+	run.SetResult(result)
+	log.Printf("Admin task %s (job %s) completed: %v", payload.Task, run.Job.Id, result)
+	return nil
+}
+
+// taskActive reports whether a run of task is queued or running
+func taskActive(db *gorm.DB, task string) (bool, error) {
+	var active int64
+	err := db.Model(&entities.Job{}).
+		Where(`"Type" = ? AND "Status" IN ? AND "Payload"->>'task' = ?`, jobs.TypeAdminTask, []string{jobs.StatusQueued, jobs.StatusRunning}, task).
+		Count(&active).Error
+	return active > 0, err
+}
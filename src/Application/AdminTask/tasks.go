@@ -0,0 +1,164 @@
+package admintask
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Services"
+)
+
+// Tasks an admin can run. Nothing outside this catalog can be run through the task API.
+const (
+	TaskRecalculateNodeUsage = "recalculate-node-usage"
+	TaskPurgeExpiredUploads  = "purge-expired-uploads"
+	TaskRetryFailedJobs      = "retry-failed-jobs"
+	TaskRetryFailedVideos    = "retry-failed-videos"
+)
+
+// TaskDescription describes a task in the catalog
+type TaskDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Catalog lists every task, in the order they are shown
+var Catalog = []TaskDescription{
+	{
+		Name:        TaskRecalculateNodeUsage,
+		Description: "Recompute each storage node's used storage from the files stored on it",
+	},
+	{
+		Name:        TaskPurgeExpiredUploads,
+		Description: "Remove abandoned uploads, partially written files and expired resumable upload sessions now instead of on the next cleanup pass",
+	},
+	{
+		Name:        TaskRetryFailedJobs,
+		Description: "Queue failed bucket deletion, node repair and webhook delivery jobs again, or only those of job_type",
+	},
+	{
+		Name:        TaskRetryFailedVideos,
+		Description: "Queue videos whose thumbnail or HLS generation failed for processing again",
+	},
+}
+
+// retryableJobTypes are the job types retry-failed-jobs requeues. Admin tasks are left out, they
+// are run again through the task API.
+var retryableJobTypes = []string{jobs.TypeBucketDelete, jobs.TypeNodeRepair, jobs.TypeWebhookDelivery}
+
+// taskPayload is the payload of an admin task job
+type taskPayload struct {
+	Task    string `json:"task"`
+	JobType string `json:"job_type,omitempty"`
+}
+
+// runner runs the tasks of the catalog
+type runner struct {
+	dbContext *persistence.AppDbContext
+}
+
+func (r *runner) run(ctx context.Context, run *jobs.Run, payload taskPayload) (map[string]interface{}, error) {
+	switch payload.Task {
+	case TaskRecalculateNodeUsage:
+		return r.recalculateNodeUsage(ctx, run)
+	case TaskPurgeExpiredUploads:
+		result := services.NewUploadCleanupWorker(r.dbContext).Sweep(ctx)
+		return map[string]interface{}{
+			"abandoned_uploads":        result.AbandonedUploads,
+			"partial_files":            result.PartialFiles,
+			"expired_sessions":         result.ExpiredSessions,
+			"expired_idempotency_keys": result.ExpiredIdempotencyKeys,
+		}, nil
+	case TaskRetryFailedJobs:
+		return retryFailedJobs(r.dbContext.GetDB(), payload.JobType)
+	case TaskRetryFailedVideos:
+		return retryFailedVideos(r.dbContext.GetDB())
+	}
+	return nil, jobs.Permanent(fmt.Errorf("unknown task %q", payload.Task))
+}
+
+// recalculateNodeUsage sets each node's used storage to the size of the files whose content it
+// holds, correcting drift left by failed uploads or deletions
+func (r *runner) recalculateNodeUsage(ctx context.Context, run *jobs.Run) (map[string]interface{}, error) {
+	nodes, err := r.dbContext.StorageNodes.ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage nodes: %w", err)
+	}
+
+	db := r.dbContext.GetDB()
+	corrections := make([]map[string]interface{}, 0)
+	for i, node := range nodes {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		used, err := nodeUsage(db, &node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum files on storage node %s: %w", node.Name, err)
+		}
+		if used != node.UsedStorage {
+			if err := db.Model(&entities.StorageNode{Id: node.Id}).Update("UsedStorage", used).Error; err != nil {
+				return nil, fmt.Errorf("failed to update storage node %s: %w", node.Name, err)
+			}
+			corrections = append(corrections, map[string]interface{}{
+				"node_id":  node.Id,
+				"name":     node.Name,
+				"recorded": node.UsedStorage,
+				"actual":   used,
+			})
+		}
+		run.Progress(int64(i+1), int64(len(nodes)))
+	}
+
+	return map[string]interface{}{
+		"nodes":       len(nodes),
+		"corrections": corrections,
+	}, nil
+}
+
+// nodeUsage returns the size of the files whose content a node holds
+func nodeUsage(db *gorm.DB, node *entities.StorageNode) (int64, error) {
+	var used int64
+	err := db.Model(&entities.File{}).Where(`"Path" LIKE ?`, "node://"+node.Id.String()+"/%").
+		Select(`COALESCE(SUM("Size"), 0)`).Scan(&used).Error
+	return used, err
+}
+
+// retryFailedJobs queues failed jobs again in place, so records pointing at a job, such as a
+// node's repair job, follow it
+func retryFailedJobs(db *gorm.DB, jobType string) (map[string]interface{}, error) {
+	types := retryableJobTypes
+	if jobType != "" {
+		types = []string{jobType}
+	}
+
+	updated := db.Model(&entities.Job{}).
+		Where(`"Status" = ? AND "Type" IN ?`, jobs.StatusFailed, types).
+		Updates(map[string]interface{}{
+			"Status":      jobs.StatusQueued,
+			"Attempts":    0,
+			"RunAfter":    time.Now(),
+			"StartedAt":   nil,
+			"CompletedAt": nil,
+		})
+	if updated.Error != nil {
+		return nil, fmt.Errorf("failed to requeue jobs: %w", updated.Error)
+	}
+	return map[string]interface{}{"requeued": updated.RowsAffected}, nil
+}
+
+// retryFailedVideos hands failed video assets back to the video worker
+func retryFailedVideos(db *gorm.DB) (map[string]interface{}, error) {
+	updated := db.Model(&entities.VideoAsset{}).
+		Where(&entities.VideoAsset{Status: "failed"}).
+		Updates(map[string]interface{}{"Status": "pending", "Error": ""})
+	if updated.Error != nil {
+		return nil, fmt.Errorf("failed to requeue videos: %w", updated.Error)
+	}
+	return map[string]interface{}{"requeued": updated.RowsAffected}, nil
+}
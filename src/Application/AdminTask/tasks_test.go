@@ -0,0 +1,117 @@
+package admintask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+func createJob(t *testing.T, db *gorm.DB, job entities.Job) entities.Job {
+	t.Helper()
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatalf("failed to create job: %v", err)
+	}
+	return job
+}
+
+// TestNodeUsage sums the files stored on a node
+func TestNodeUsage(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	node := entities.StorageNode{Name: "node-1", URL: "http://node-1", AuthKey: "key"}
+	if err := db.Create(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range []string{"node://" + node.Id.String() + "/a", "node://" + node.Id.String() + "/b", "/data/photos/c"} {
+		file := entities.File{BucketId: bucket.Id, Name: path, OriginalName: path, Path: path, Size: int64(10 * (i + 1)), UploadedBy: bucket.OwnerId}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	used, err := nodeUsage(db, &node)
+	if err != nil {
+		t.Fatalf("nodeUsage() = %v", err)
+	}
+	if used != 30 {
+		t.Errorf("nodeUsage() = %d, want 30", used)
+	}
+}
+
+// TestRetryFailedJobs queues failed jobs of the given types again
+func TestRetryFailedJobs(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	failed := createJob(t, db, entities.Job{Type: jobs.TypeNodeRepair, Status: jobs.StatusFailed, Attempts: 3, RunAfter: now, StartedAt: &now, CompletedAt: &now})
+	createJob(t, db, entities.Job{Type: jobs.TypeBucketDelete, Status: jobs.StatusFailed, RunAfter: now})
+	createJob(t, db, entities.Job{Type: jobs.TypeNodeRepair, Status: jobs.StatusCompleted, RunAfter: now})
+
+	result, err := retryFailedJobs(db, jobs.TypeNodeRepair)
+	if err != nil {
+		t.Fatalf("retryFailedJobs() = %v", err)
+	}
+	if result["requeued"] != int64(1) {
+		t.Errorf("retryFailedJobs() requeued = %v, want 1", result["requeued"])
+	}
+	var requeued entities.Job
+	if err := db.First(&requeued, `"Id" = ?`, failed.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if requeued.Status != jobs.StatusQueued || requeued.Attempts != 0 || requeued.StartedAt != nil || requeued.CompletedAt != nil {
+		t.Errorf("requeued job = %+v, want it queued from scratch", requeued)
+	}
+}
+
+// TestRetryFailedVideos hands failed video assets back for processing
+func TestRetryFailedVideos(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "videos")
+	failed := entities.VideoAsset{FileId: uuid.New(), BucketId: bucket.Id, Status: "failed", Error: "ffmpeg exited"}
+	ready := entities.VideoAsset{FileId: uuid.New(), BucketId: bucket.Id, Status: "ready"}
+	for _, asset := range []*entities.VideoAsset{&failed, &ready} {
+		if err := db.Create(asset).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := retryFailedVideos(db); err != nil {
+		t.Fatalf("retryFailedVideos() = %v", err)
+	}
+	var assets []entities.VideoAsset
+	if err := db.Order(`"Status"`).Find(&assets).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].Status != "pending" || assets[0].Error != "" || assets[1].Status != "ready" {
+		t.Errorf("video assets = %+v, want the failed one pending again", assets)
+	}
+}
+
+// TestTaskActive finds queued or running runs of a task and lists the latest runs
+func TestTaskActive(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	createJob(t, db, entities.Job{Type: jobs.TypeAdminTask, Status: jobs.StatusRunning, Payload: datatypes.JSON(`{"task":"` + TaskRetryFailedJobs + `"}`), RunAfter: now})
+	createJob(t, db, entities.Job{Type: jobs.TypeAdminTask, Status: jobs.StatusCompleted, Payload: datatypes.JSON(`{"task":"` + TaskRetryFailedVideos + `"}`), RunAfter: now})
+
+	if active, err := taskActive(db, TaskRetryFailedJobs); err != nil || !active {
+		t.Errorf("taskActive() of a running task = %v, %v, want true", active, err)
+	}
+	if active, err := taskActive(db, TaskRetryFailedVideos); err != nil || active {
+		t.Errorf("taskActive() of a completed task = %v, %v, want false", active, err)
+	}
+
+	runs, err := recentRuns(db)
+	if err != nil {
+		t.Fatalf("recentRuns() = %v", err)
+	}
+	if len(runs) != 2 {
+		t.Errorf("recentRuns() = %d runs, want 2", len(runs))
+	}
+}
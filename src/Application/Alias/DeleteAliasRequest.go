@@ -0,0 +1,64 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DeleteAliasCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	Name     string    `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type DeleteAliasResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteAliasRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewDeleteAliasRequestHandler(dbContext *persistence.AppDbContext) *DeleteAliasRequestHandler {
+	return &DeleteAliasRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle removes an alias, the file it pointed at stays
+func (h *DeleteAliasRequestHandler) Handle(ctx context.Context, command *DeleteAliasCommand) (*DeleteAliasResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	alias, err := Resolve(ctx, h.dbContext, bucket.Id, command.Name)
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return nil, ErrAliasNotFound
+	}
+
+	if err := h.dbContext.GetDB().WithContext(ctx).Delete(&entities.FileAlias{}, `"Id" = ?`, alias.Id).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete alias: %w", err)
+	}
+
+	h.events.Publish(events.FileAliasDeleted, bucket.Id, &alias.FileId, command.UserID, map[string]interface{}{
+		"alias": alias.Name,
+	})
+
+	return &DeleteAliasResponse{
+		Success: true,
+		Message: "Alias deleted successfully",
+	}, nil
+}
@@ -0,0 +1,70 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListAliasesCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	// FileID limits the list to the aliases pointing at this file
+	FileID *uuid.UUID `json:"-"`
+}
+
+type ListAliasesResponse struct {
+	Aliases []models.AliasResponse `json:"aliases"`
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+}
+
+type ListAliasesRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListAliasesRequestHandler(dbContext *persistence.AppDbContext) *ListAliasesRequestHandler {
+	return &ListAliasesRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists a bucket's aliases by name
+func (h *ListAliasesRequestHandler) Handle(ctx context.Context, command *ListAliasesCommand) (*ListAliasesResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+
+	aliases, err := listAliases(h.dbContext.GetDB().WithContext(ctx), bucket.Id, command.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+
+	responses := make([]models.AliasResponse, 0, len(aliases))
+	for i := range aliases {
+		responses = append(responses, ToAliasResponse(&aliases[i]))
+	}
+
+	return &ListAliasesResponse{
+		Aliases: responses,
+		Success: true,
+		Message: "Aliases retrieved successfully",
+	}, nil
+}
+
+// listAliases returns a bucket's aliases by name, only those pointing at fileID when it is set
+func listAliases(db *gorm.DB, bucketID uuid.UUID, fileID *uuid.UUID) ([]entities.FileAlias, error) {
+	query := db.Where(`"BucketId" = ?`, bucketID)
+	if fileID != nil {
+		query = query.Where(`"FileId" = ?`, *fileID)
+	}
+	var aliases []entities.FileAlias
+	err := query.Order(`"Name"`).Find(&aliases).Error
+	return aliases, err
+}
@@ -0,0 +1,132 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type SetAliasCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	Name     string    `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	FileID   uuid.UUID `json:"file_id" validate:"required"`
+	// IfFileID only re-points the alias while it still points at this file, so concurrent
+	// publishers can't overwrite each other unnoticed
+	IfFileID *uuid.UUID `json:"if_file_id,omitempty"`
+}
+
+type SetAliasResponse struct {
+	Alias models.AliasResponse `json:"alias"`
+	// PreviousFileID is the file the alias pointed at before, nil for a new alias
+	PreviousFileID *uuid.UUID `json:"previous_file_id,omitempty"`
+	Success        bool       `json:"success"`
+	Message        string     `json:"message"`
+}
+
+type SetAliasRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewSetAliasRequestHandler(dbContext *persistence.AppDbContext) *SetAliasRequestHandler {
+	return &SetAliasRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle points an alias at a file of the bucket, creating the alias when it doesn't exist. The
+// alias moves in a single statement, so a request for it is served either the old file or the new one.
+func (h *SetAliasRequestHandler) Handle(ctx context.Context, command *SetAliasCommand) (*SetAliasResponse, error) {
+	if !namePattern.MatchString(command.Name) {
+		return nil, ErrInvalidName
+	}
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	target, err := h.dbContext.Files.Where(&entities.File{Id: command.FileID}).FirstOrDefault()
+	if err != nil || target == nil || target.BucketId != bucket.Id {
+		return nil, ErrFileNotFound
+	}
+
+	previous, err := Resolve(ctx, h.dbContext, bucket.Id, command.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := pointAlias(h.dbContext.GetDB().WithContext(ctx), &entities.FileAlias{
+		Id:        uuid.New(),
+		BucketId:  bucket.Id,
+		Name:      command.Name,
+		FileId:    target.Id,
+		UpdatedBy: command.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, command.IfFileID); err != nil {
+		return nil, err
+	}
+	var previousFileID *uuid.UUID
+	if command.IfFileID != nil {
+		previousFileID = command.IfFileID
+	} else if previous != nil {
+		previousFileID = &previous.FileId
+	}
+
+	alias, err := Resolve(ctx, h.dbContext, bucket.Id, command.Name)
+	if err != nil || alias == nil {
+		return nil, fmt.Errorf("failed to read saved alias: %w", err)
+	}
+
+	data := map[string]interface{}{"alias": alias.Name}
+	if previousFileID != nil {
+		data["previous_file_id"] = *previousFileID
+	}
+	h.events.Publish(events.FileAliasSet, bucket.Id, &target.Id, command.UserID, data)
+
+	return &SetAliasResponse{
+		Alias:          ToAliasResponse(alias),
+		PreviousFileID: previousFileID,
+		Success:        true,
+		Message:        "Alias set successfully",
+	}, nil
+}
+
+// pointAlias points the alias name of a bucket at a file, creating the alias when there is none.
+// With ifFileID, only an alias still pointing at that file is moved, otherwise ErrAliasMoved is
+// returned.
+func pointAlias(db *gorm.DB, alias *entities.FileAlias, ifFileID *uuid.UUID) error {
+	moved := map[string]interface{}{"FileId": alias.FileId, "UpdatedBy": alias.UpdatedBy, "UpdatedAt": alias.UpdatedAt}
+	if ifFileID != nil {
+		result := db.Model(&entities.FileAlias{}).
+			Where(`"BucketId" = ? AND "Name" = ? AND "FileId" = ?`, alias.BucketId, alias.Name, *ifFileID).
+			Updates(moved)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update alias: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrAliasMoved
+		}
+		return nil
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "BucketId"}, {Name: "Name"}},
+		DoUpdates: clause.Assignments(moved),
+	}).Create(alias).Error; err != nil {
+		return fmt.Errorf("failed to save alias: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// namePattern restricts alias names to characters that need no escaping in a URL path
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+var (
+	// ErrAliasNotFound is returned for aliases the bucket doesn't have
+	ErrAliasNotFound = apierror.New(apierror.CodeNotFound, "alias not found")
+	// ErrInvalidName is returned for alias names outside namePattern
+	ErrInvalidName = apierror.New(apierror.CodeInvalidRequest, "alias names are 1 to 128 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	// ErrFileNotFound is returned when the file to point at isn't in the bucket
+	ErrFileNotFound = apierror.New(apierror.CodeFileNotFound, "file not found in bucket")
+	// ErrAliasMoved is returned when the alias no longer points at the file the update expected
+	ErrAliasMoved = apierror.New(apierror.CodeConflict, "alias no longer points at the expected file")
+	// ErrBucketNotFound is returned when the bucket doesn't exist
+	ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	// ErrForbidden is returned to users who can't manage the bucket's aliases
+	ErrForbidden = apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage its aliases")
+)
+
+// Resolve looks up the alias name of a bucket, nil when there is none
+func Resolve(ctx context.Context, dbContext *persistence.AppDbContext, bucketID uuid.UUID, name string) (*entities.FileAlias, error) {
+	return resolve(dbContext.GetDB().WithContext(ctx), bucketID, name)
+}
+
+func resolve(db *gorm.DB, bucketID uuid.UUID, name string) (*entities.FileAlias, error) {
+	var aliases []entities.FileAlias
+	if err := db.Where(&entities.FileAlias{BucketId: bucketID, Name: name}).
+		Limit(1).Find(&aliases).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up alias: %w", err)
+	}
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+	return &aliases[0], nil
+}
+
+// URL is where an alias of a bucket is served
+func URL(bucketID uuid.UUID, name string) string {
+	return fmt.Sprintf("%s/api/v1/file/%s/alias/%s", config.GetSettings().BaseURL, bucketID, url.PathEscape(name))
+}
+
+// managedBucket loads a bucket whose aliases the user may manage
+func managedBucket(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, ErrForbidden
+	}
+	return bucket, nil
+}
+
+func ToAliasResponse(alias *entities.FileAlias) models.AliasResponse {
+	return models.AliasResponse{
+		ID:        alias.Id,
+		BucketID:  alias.BucketId,
+		Name:      alias.Name,
+		FileID:    alias.FileId,
+		URL:       URL(alias.BucketId, alias.Name),
+		UpdatedBy: alias.UpdatedBy,
+		CreatedAt: alias.CreatedAt,
+		UpdatedAt: alias.UpdatedAt,
+	}
+}
@@ -0,0 +1,79 @@
+package alias
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+func newAlias(bucketID, fileID uuid.UUID, name string) *entities.FileAlias {
+	now := time.Now()
+	return &entities.FileAlias{Id: uuid.New(), BucketId: bucketID, Name: name, FileId: fileID, UpdatedBy: uuid.New(), CreatedAt: now, UpdatedAt: now}
+}
+
+// TestPointAlias creates an alias, re-points it, and only moves it from the expected file
+func TestPointAlias(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID, first, second, third := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	if err := pointAlias(db, newAlias(bucketID, first, "latest"), nil); err != nil {
+		t.Fatalf("pointAlias() of a new alias = %v", err)
+	}
+	if err := pointAlias(db, newAlias(bucketID, second, "latest"), nil); err != nil {
+		t.Fatalf("pointAlias() of an existing alias = %v", err)
+	}
+	if err := pointAlias(db, newAlias(bucketID, third, "latest"), &first); !errors.Is(err, ErrAliasMoved) {
+		t.Errorf("pointAlias() from a file it no longer points at = %v, want ErrAliasMoved", err)
+	}
+	if err := pointAlias(db, newAlias(bucketID, third, "latest"), &second); err != nil {
+		t.Fatalf("pointAlias() from the file it points at = %v", err)
+	}
+
+	alias, err := resolve(db, bucketID, "latest")
+	if err != nil {
+		t.Fatalf("resolve() = %v", err)
+	}
+	if alias == nil || alias.FileId != third {
+		t.Errorf("resolve() = %+v, want the alias pointing at %s", alias, third)
+	}
+	if alias, err := resolve(db, bucketID, "missing"); err != nil || alias != nil {
+		t.Errorf("resolve() of a missing alias = %+v, %v, want nil", alias, err)
+	}
+}
+
+// TestListAliases lists a bucket's aliases by name, or those of one file
+func TestListAliases(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID, fileID := uuid.New(), uuid.New()
+	for _, alias := range []*entities.FileAlias{
+		newAlias(bucketID, fileID, "stable"),
+		newAlias(bucketID, uuid.New(), "beta"),
+		newAlias(bucketID, fileID, "latest"),
+		newAlias(uuid.New(), fileID, "other"),
+	} {
+		if err := db.Create(alias).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	aliases, err := listAliases(db, bucketID, nil)
+	if err != nil {
+		t.Fatalf("listAliases() = %v", err)
+	}
+	if len(aliases) != 3 || aliases[0].Name != "beta" || aliases[2].Name != "stable" {
+		t.Errorf("listAliases() = %+v, want the bucket's three aliases by name", aliases)
+	}
+
+	aliases, err = listAliases(db, bucketID, &fileID)
+	if err != nil {
+		t.Fatalf("listAliases() of a file = %v", err)
+	}
+	if len(aliases) != 2 || aliases[0].Name != "latest" || aliases[1].Name != "stable" {
+		t.Errorf("listAliases() of a file = %+v, want latest and stable", aliases)
+	}
+}
@@ -0,0 +1,70 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListBackupRunsCommand struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
+
+type ListBackupRunsResponse struct {
+	Runs    []models.BackupRunResponse `json:"runs"`
+	Total   int64                      `json:"total"`
+	Page    int                        `json:"page"`
+	Limit   int                        `json:"limit"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type ListBackupRunsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListBackupRunsRequestHandler(dbContext *persistence.AppDbContext) *ListBackupRunsRequestHandler {
+	return &ListBackupRunsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *ListBackupRunsRequestHandler) Handle(ctx context.Context, command *ListBackupRunsCommand) (*ListBackupRunsResponse, error) {
+	page := command.Page
+	limit := command.Limit
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	total, err := h.dbContext.BackupRuns.Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count backup runs: %w", err)
+	}
+
+	runs, err := h.dbContext.BackupRuns.OrderByDescending("StartedAt").
+		Skip((page - 1) * limit).Take(limit).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch backup runs: %w", err)
+	}
+
+	runResponses := make([]models.BackupRunResponse, len(runs))
+	for i := range runs {
+		runResponses[i] = toBackupRunResponse(&runs[i])
+	}
+
+	return &ListBackupRunsResponse{
+		Runs:    runResponses,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		Success: true,
+		Message: "Backup runs retrieved successfully",
+	}, nil
+}
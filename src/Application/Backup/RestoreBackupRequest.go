@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+type RestoreBackupCommand struct {
+	BucketID     uuid.UUID `json:"bucket_id" validate:"required"`
+	SourceBucket string    `json:"source_bucket"` // bucket name the backup was taken from, defaults to the target bucket's name
+	Overwrite    bool      `json:"overwrite"`     // restore files that still exist in the bucket
+}
+
+type RestoreBackupResponse struct {
+	Restored    int      `json:"restored"`
+	Skipped     int      `json:"skipped"`
+	FailedFiles []string `json:"failed_files"`
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+}
+
+type RestoreBackupRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func NewRestoreBackupRequestHandler(dbContext *persistence.AppDbContext) *RestoreBackupRequestHandler {
+	return &RestoreBackupRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+func (h *RestoreBackupRequestHandler) Handle(ctx context.Context, command *RestoreBackupCommand) (*RestoreBackupResponse, error) {
+	destination, err := NewDestination(h.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	// Content is written to the master's storage, where a pinned bucket's content may not go
+	if placement.Pinned(bucket) {
+		return nil, apierror.Newf(apierror.CodeConflict, "bucket is pinned to %s, so content can't be restored to this server", placement.Describe(bucket))
+	}
+
+	masterConfig, err := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil || masterConfig == nil || masterConfig.StoragePath == "" {
+		return nil, apierror.New(apierror.CodeUnavailable, "storage_path not configured in master config")
+	}
+
+	sourceBucket := command.SourceBucket
+	if sourceBucket == "" {
+		sourceBucket = bucket.Name
+	}
+
+	objects, err := h.dbContext.BackupObjects.Where(&entities.BackupObject{
+		Destination: destination.Name(),
+		BucketName:  sourceBucket,
+	}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch backup objects: %w", err)
+	}
+	if len(objects) == 0 {
+		return nil, apierror.Newf(apierror.CodeNotFound, "no backup found for bucket %s at %s", sourceBucket, destination.Name())
+	}
+
+	bucketDir := filepath.Join(masterConfig.StoragePath, bucket.Name)
+	if err := os.MkdirAll(bucketDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bucket directory: %w", err)
+	}
+
+	response := &RestoreBackupResponse{FailedFiles: []string{}}
+	for i := range objects {
+		object := &objects[i]
+
+		existing, _ := h.dbContext.Files.Where(&entities.File{Id: object.FileId}).FirstOrDefault()
+		if existing != nil && existing.BucketId == bucket.Id && !command.Overwrite && fileContentExists(existing) {
+			response.Skipped++
+			continue
+		}
+
+		fileID := object.FileId
+		if existing != nil && existing.BucketId != bucket.Id {
+			// The original ID now belongs to a file in another bucket
+			fileID = uuid.New()
+			existing = nil
+		}
+
+		filePath := filepath.Join(bucketDir, fileID.String())
+		contentEncoding := storage.ContentEncodingFor(bucket, object.MimeType)
+		fileEncryption, err := h.download(ctx, destination, bucket, object, contentEncoding, filePath)
+		if err != nil {
+			response.FailedFiles = append(response.FailedFiles, fmt.Sprintf("%s: %v", object.Name, err))
+			continue
+		}
+
+		if existing != nil {
+			existing.Path = filePath
+			existing.Size = object.Size
+			existing.Checksum = object.Checksum
+			existing.Encryption = fileEncryption
+			existing.Metadata.ContentEncoding = contentEncoding
+			h.dbContext.Files.Update(*existing)
+		} else {
+			h.dbContext.Files.Add(entities.File{
+				Id:           fileID,
+				BucketId:     bucket.Id,
+				Name:         object.Name,
+				OriginalName: object.OriginalName,
+				Path:         filePath,
+				Size:         object.Size,
+				MimeType:     object.MimeType,
+				Checksum:     object.Checksum,
+				Version:      1,
+				SecuredUrl:   fmt.Sprintf("%s/api/v1/file/%s/%s", h.settings.BaseURL, bucket.Id.String(), fileID.String()),
+				AuthRule: entities.AuthRule{
+					Type:    bucket.AuthRule.Type,
+					Enabled: bucket.AuthRule.Enabled,
+					Config:  bucket.AuthRule.Config,
+				},
+				Metadata: entities.FileMetadata{
+					ContentType:     object.MimeType,
+					ContentEncoding: contentEncoding,
+					CustomMetadata:  object.CustomMetadata,
+				},
+				Encryption: fileEncryption,
+				UploadedBy: object.UploadedBy,
+			})
+		}
+		if err := h.dbContext.SaveChanges(); err != nil {
+			response.FailedFiles = append(response.FailedFiles, fmt.Sprintf("%s: failed to save file record: %v", object.Name, err))
+			continue
+		}
+
+		response.Restored++
+	}
+
+	response.Success = len(response.FailedFiles) == 0
+	response.Message = fmt.Sprintf("Restored %d file(s), skipped %d, failed %d", response.Restored, response.Skipped, len(response.FailedFiles))
+	return response, nil
+}
+
+// download copies a backed up object to filePath, compressing it with contentEncoding and encrypting it
+// for encrypted buckets, and verifies its checksum
+func (h *RestoreBackupRequestHandler) download(ctx context.Context, destination Destination, bucket *entities.Bucket, object *entities.BackupObject, contentEncoding string, filePath string) (entities.FileEncryption, error) {
+	content, err := destination.Get(ctx, object)
+	if err != nil {
+		return entities.FileEncryption{}, err
+	}
+	defer content.Close()
+
+	checksum, _, fileEncryption, err := storage.SaveBucketFile(h.dbContext, bucket, nil, contentEncoding, filePath, content)
+	if err != nil {
+		return entities.FileEncryption{}, err
+	}
+
+	if checksum != object.Checksum {
+		os.Remove(filePath)
+		return entities.FileEncryption{}, fmt.Errorf("checksum mismatch")
+	}
+
+	return fileEncryption, nil
+}
+
+func fileContentExists(file *entities.File) bool {
+	if storage.IsNodePath(file.Path) {
+		return true
+	}
+	_, err := os.Stat(file.Path)
+	return err == nil
+}
@@ -0,0 +1,219 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+type RunBackupCommand struct {
+	BucketNames []string `json:"bucket_names"` // overrides BACKUP_BUCKETS when set
+	Trigger     string   `json:"-"`
+}
+
+type RunBackupResponse struct {
+	Run     models.BackupRunResponse `json:"run"`
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+}
+
+type RunBackupRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	running   sync.Mutex
+}
+
+func NewRunBackupRequestHandler(dbContext *persistence.AppDbContext) *RunBackupRequestHandler {
+	return &RunBackupRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Handle starts a backup run in the background and returns the run record so it can be polled
+func (h *RunBackupRequestHandler) Handle(ctx context.Context, command *RunBackupCommand) (*RunBackupResponse, error) {
+	destination, err := NewDestination(h.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if !h.running.TryLock() {
+		return nil, apierror.New(apierror.CodeConflict, "a backup is already running")
+	}
+
+	buckets, err := h.resolveBuckets(command.BucketNames)
+	if err != nil {
+		h.running.Unlock()
+		return nil, err
+	}
+
+	trigger := command.Trigger
+	if trigger == "" {
+		trigger = "manual"
+	}
+
+	run := entities.BackupRun{
+		Id:          uuid.New(),
+		Destination: destination.Name(),
+		Status:      "running",
+		Trigger:     trigger,
+		StartedAt:   time.Now(),
+	}
+	h.dbContext.BackupRuns.Add(run)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		h.running.Unlock()
+		return nil, fmt.Errorf("failed to create backup run: %w", err)
+	}
+
+	go func() {
+		defer h.running.Unlock()
+		h.execute(&run, destination, buckets)
+	}()
+
+	return &RunBackupResponse{
+		Run:     toBackupRunResponse(&run),
+		Success: true,
+		Message: fmt.Sprintf("Backup of %d bucket(s) started", len(buckets)),
+	}, nil
+}
+
+func (h *RunBackupRequestHandler) resolveBuckets(names []string) ([]entities.Bucket, error) {
+	if len(names) == 0 {
+		names = h.settings.BackupBuckets
+	}
+
+	if len(names) == 0 {
+		buckets, err := h.dbContext.Buckets.ToList()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch buckets: %w", err)
+		}
+		return buckets, nil
+	}
+
+	buckets := make([]entities.Bucket, 0, len(names))
+	for _, name := range names {
+		bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Name: name}).FirstOrDefault()
+		if err != nil || bucket == nil {
+			return nil, apierror.Newf(apierror.CodeBucketNotFound, "bucket not found: %s", name)
+		}
+		buckets = append(buckets, *bucket)
+	}
+	return buckets, nil
+}
+
+func (h *RunBackupRequestHandler) execute(run *entities.BackupRun, destination Destination, buckets []entities.Bucket) {
+	ctx := context.Background()
+
+	for _, bucket := range buckets {
+		files, err := h.dbContext.Files.Where(&entities.File{BucketId: bucket.Id}).ToList()
+		if err != nil {
+			run.Error = fmt.Sprintf("failed to list files for bucket %s: %v", bucket.Name, err)
+			break
+		}
+
+		for i := range files {
+			file := &files[i]
+			// Quarantined content isn't copied anywhere
+			if file.Metadata.Quarantined() {
+				run.FilesSkipped++
+				continue
+			}
+
+			existing, _ := h.dbContext.BackupObjects.Where(&entities.BackupObject{
+				Destination: destination.Name(),
+				FileId:      file.Id,
+			}).FirstOrDefault()
+
+			if !needsBackup(file, existing) {
+				run.FilesSkipped++
+				continue
+			}
+
+			object, err := h.copyFile(ctx, destination, &bucket, file, run.Id)
+			if err != nil {
+				log.Printf("Backup: failed to copy file %s from bucket %s: %v", file.Id, bucket.Name, err)
+				run.FilesFailed++
+				continue
+			}
+
+			if existing != nil {
+				object.Id = existing.Id
+				h.dbContext.BackupObjects.Update(*object)
+			} else {
+				object.Id = uuid.New()
+				h.dbContext.BackupObjects.Add(*object)
+			}
+			if err := h.dbContext.SaveChanges(); err != nil {
+				log.Printf("Backup: failed to record backup of file %s: %v", file.Id, err)
+				run.FilesFailed++
+				continue
+			}
+
+			run.FilesCopied++
+			run.BytesCopied += file.Size
+		}
+	}
+
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	run.Status = "completed"
+	if run.Error != "" {
+		run.Status = "failed"
+	}
+
+	h.dbContext.BackupRuns.Update(*run)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		log.Printf("Backup: failed to update backup run %s: %v", run.Id, err)
+	}
+
+	log.Printf("Backup run %s %s: %d copied, %d skipped, %d failed", run.Id, run.Status, run.FilesCopied, run.FilesSkipped, run.FilesFailed)
+}
+
+func (h *RunBackupRequestHandler) copyFile(ctx context.Context, destination Destination, bucket *entities.Bucket, file *entities.File, runID uuid.UUID) (*entities.BackupObject, error) {
+	content, err := storage.OpenFile(ctx, h.dbContext, file)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	object := &entities.BackupObject{
+		Destination:    destination.Name(),
+		FileId:         file.Id,
+		BucketId:       bucket.Id,
+		BucketName:     bucket.Name,
+		Name:           file.Name,
+		OriginalName:   file.OriginalName,
+		MimeType:       file.MimeType,
+		Size:           file.Size,
+		SourceChecksum: file.Checksum,
+		UploadedBy:     file.UploadedBy,
+		CustomMetadata: file.Metadata.CustomMetadata,
+		RunId:          runID,
+	}
+
+	hash := sha256.New()
+	if err := destination.Put(ctx, object, io.TeeReader(content, hash)); err != nil {
+		return nil, err
+	}
+
+	object.Checksum = fmt.Sprintf("%x", hash.Sum(nil))
+	object.BackedUpAt = time.Now()
+	return object, nil
+}
+
+// needsBackup reports whether a file changed since it was last copied to the destination
+func needsBackup(file *entities.File, existing *entities.BackupObject) bool {
+	return existing == nil || !existing.Covers(file)
+}
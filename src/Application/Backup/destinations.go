@@ -0,0 +1,145 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/NodeClient"
+	"shbucket/src/Infrastructure/S3"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// Destination is a secondary location that backed up file content is copied to
+type Destination interface {
+	// Name uniquely identifies the destination so incremental state is tracked per destination
+	Name() string
+	Put(ctx context.Context, object *entities.BackupObject, content io.Reader) error
+	Get(ctx context.Context, object *entities.BackupObject) (io.ReadCloser, error)
+}
+
+// NewDestination builds the backup destination configured in settings
+func NewDestination(settings *config.Settings) (Destination, error) {
+	switch settings.BackupDestinationType {
+	case "node":
+		if settings.BackupNodeURL == "" || settings.BackupNodeAuthKey == "" {
+			return nil, apierror.New(apierror.CodeUnavailable, "backup node destination requires BACKUP_NODE_URL and BACKUP_NODE_AUTH_KEY")
+		}
+		return &nodeDestination{
+			url:     strings.TrimRight(settings.BackupNodeURL, "/"),
+			authKey: settings.BackupNodeAuthKey,
+		}, nil
+	case "s3":
+		if settings.BackupS3Endpoint == "" || settings.BackupS3Bucket == "" {
+			return nil, apierror.New(apierror.CodeUnavailable, "backup s3 destination requires BACKUP_S3_ENDPOINT and BACKUP_S3_BUCKET")
+		}
+		client, err := s3.NewClient(s3.Config{
+			Endpoint:  settings.BackupS3Endpoint,
+			Region:    settings.BackupS3Region,
+			AccessKey: settings.BackupS3AccessKey,
+			SecretKey: settings.BackupS3SecretKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &s3Destination{
+			client: client,
+			bucket: settings.BackupS3Bucket,
+			prefix: settings.BackupS3Prefix,
+		}, nil
+	default:
+		return nil, apierror.Newf(apierror.CodeUnavailable, "unsupported backup destination type: %s", settings.BackupDestinationType)
+	}
+}
+
+// nodeDestination stores backups on another SHBucket node through its internal endpoints
+type nodeDestination struct {
+	url     string
+	authKey string
+}
+
+func (d *nodeDestination) Name() string {
+	return "node:" + d.url
+}
+
+func (d *nodeDestination) Put(ctx context.Context, object *entities.BackupObject, content io.Reader) error {
+	object.RemoteKey = object.BucketName + "/" + object.FileId.String()
+
+	err := storage.UploadToNode(ctx, d.url, d.authKey, storage.NodeUpload{
+		BucketID:    object.BucketId,
+		BucketName:  object.BucketName,
+		FileID:      object.FileId,
+		Name:        object.Name,
+		ContentType: object.MimeType,
+	}, content)
+	if err != nil {
+		return fmt.Errorf("failed to upload to backup node: %w", err)
+	}
+	return nil
+}
+
+func (d *nodeDestination) Get(ctx context.Context, object *entities.BackupObject) (io.ReadCloser, error) {
+	content, _, err := nodeclient.Default().Fetch(ctx, nodeclient.Node{URL: d.url, AuthKey: d.authKey}, internalapi.FileRequest{
+		BucketID: object.BucketId,
+		FileID:   object.FileId,
+		Filename: object.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from backup node: %w", err)
+	}
+	return content, nil
+}
+
+// s3Destination stores backups in an S3-compatible bucket under a key prefix
+type s3Destination struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func (d *s3Destination) Name() string {
+	return "s3:" + d.bucket + "/" + d.prefix
+}
+
+func (d *s3Destination) key(object *entities.BackupObject) string {
+	return d.prefix + object.BucketName + "/" + object.FileId.String()
+}
+
+func (d *s3Destination) Put(ctx context.Context, object *entities.BackupObject, content io.Reader) error {
+	object.RemoteKey = d.key(object)
+	return d.client.PutObject(ctx, d.bucket, object.RemoteKey, content, object.Size, object.MimeType, map[string]string{
+		"shbucket-name":     object.Name,
+		"shbucket-checksum": object.SourceChecksum,
+	})
+}
+
+func (d *s3Destination) Get(ctx context.Context, object *entities.BackupObject) (io.ReadCloser, error) {
+	key := object.RemoteKey
+	if key == "" {
+		key = d.key(object)
+	}
+	body, _, err := d.client.GetObject(ctx, d.bucket, key)
+	return body, err
+}
+
+func toBackupRunResponse(run *entities.BackupRun) models.BackupRunResponse {
+	return models.BackupRunResponse{
+		ID:           run.Id,
+		Destination:  run.Destination,
+		Status:       run.Status,
+		Trigger:      run.Trigger,
+		FilesCopied:  run.FilesCopied,
+		FilesSkipped: run.FilesSkipped,
+		FilesFailed:  run.FilesFailed,
+		BytesCopied:  run.BytesCopied,
+		Error:        run.Error,
+		StartedAt:    run.StartedAt,
+		CompletedAt:  run.CompletedAt,
+	}
+}
@@ -7,8 +7,18 @@ import (
 	
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Domains"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Hotlink"
+	"shbucket/src/Infrastructure/IPFilter"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Website"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
 )
@@ -29,11 +39,13 @@ type CreateBucketResponse struct {
 
 type CreateBucketRequestHandler struct {
 	dbContext *persistence.AppDbContext
+	events    *events.Publisher
 }
 
 func NewCreateBucketRequestHandler(dbContext *persistence.AppDbContext) *CreateBucketRequestHandler {
 	return &CreateBucketRequestHandler{
 		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
 	}
 }
 
@@ -41,7 +53,7 @@ func (h *CreateBucketRequestHandler) Handle(ctx context.Context, command *Create
 	// Check if bucket with this name already exists using static typing
 	existingBucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Name: command.Name}).FirstOrDefault()
 	if err == nil && existingBucket != nil {
-		return nil, fmt.Errorf("bucket with name '%s' already exists", command.Name)
+		return nil, apierror.Newf(apierror.CodeAlreadyExists, "bucket with name '%s' already exists", command.Name)
 	}
 
 	// Set default auth rule if not provided
@@ -66,19 +78,21 @@ func (h *CreateBucketRequestHandler) Handle(ctx context.Context, command *Create
 	}
 
 	// Set default settings if not provided
+	defaults := config.GetRuntimeSettings()
 	settings := entities.BucketSettings{
-		MaxFileSize:         100 * 1024 * 1024, // 100MB default
-		MaxTotalSize:        10 * 1024 * 1024 * 1024, // 10GB default
+		MaxFileSize:         defaults.DefaultBucketMaxFileSize,
+		MaxTotalSize:        defaults.DefaultBucketMaxTotalSize,
 		AllowedMimeTypes:    []string{},
 		BlockedMimeTypes:    []string{},
 		AllowedExtensions:   []string{},
 		BlockedExtensions:   []string{},
-		MaxFilesPerBucket:   10000,
+		MaxFilesPerBucket:   defaults.DefaultBucketMaxFiles,
 		PublicRead:          false,
 		Versioning:          false,
 		Encryption:          false,
 		AllowOverwrite:      true,
 		RequireContentType:  false,
+		VideoProcessing:     false,
 	}
 
 	// Override with provided settings
@@ -105,11 +119,60 @@ func (h *CreateBucketRequestHandler) Handle(ctx context.Context, command *Create
 	}
 	settings.PublicRead = command.Settings.PublicRead
 	settings.Versioning = command.Settings.Versioning
+	if command.Settings.Encryption && !encryption.Available() {
+		return nil, encryption.ErrNotConfigured
+	}
 	settings.Encryption = command.Settings.Encryption
 	settings.AllowOverwrite = command.Settings.AllowOverwrite
+	settings.KeyedObjects = command.Settings.KeyedObjects
 	settings.RequireContentType = command.Settings.RequireContentType
+	settings.VideoProcessing = command.Settings.VideoProcessing
+	settings.CORSRules = utils.ConvertCORSRulesToJSON(command.Settings.CORSRules)
+	settings.MaxVersions = command.Settings.MaxVersions
+	settings.VersionRetentionDays = command.Settings.VersionRetentionDays
+	settings.DisableImageTransforms = command.Settings.DisableImageTransforms
+	if err := placement.Validate(h.dbContext, command.Settings.PlacementNodeID, command.Settings.PlacementGroup, command.Settings.PlacementZone); err != nil {
+		return nil, err
+	}
+	if err := placement.ValidateSpread(h.dbContext, command.Settings.MinZones); err != nil {
+		return nil, err
+	}
+	settings.PlacementNodeId = command.Settings.PlacementNodeID
+	settings.PlacementGroup = command.Settings.PlacementGroup
+	settings.PlacementZone = command.Settings.PlacementZone
+	settings.MinZones = command.Settings.MinZones
+	settings.WebsiteEnabled = command.Settings.WebsiteEnabled
+	settings.WebsiteIndexDocument = command.Settings.WebsiteIndexDocument
+	settings.WebsiteErrorDocument = command.Settings.WebsiteErrorDocument
+	settings.WebsiteDomain = command.Settings.WebsiteDomain
+	settings.CustomDomain = command.Settings.CustomDomain
+	settings.EgressQuota = command.Settings.EgressQuota
+	settings.EgressDailyQuota = command.Settings.EgressDailyQuota
+	settings.EgressQuotaPolicy = command.Settings.EgressQuotaPolicy
+	settings.Compression = command.Settings.Compression
+	serveHeaders, err := defaultHeaders(command.Settings.DefaultHeaders)
+	if err != nil {
+		return nil, err
+	}
+	settings.DefaultHeaders = serveHeaders
+	if err := objectLock(&settings, command.Settings); err != nil {
+		return nil, err
+	}
+	if err := publicReadIPs(&settings, command.Settings); err != nil {
+		return nil, err
+	}
+	if err := hotlinkProtection(h.dbContext, uuid.Nil, &settings, command.Settings); err != nil {
+		return nil, err
+	}
+	if err := website.Configure(&settings); err != nil {
+		return nil, err
+	}
+	if err := domains.Configure(h.dbContext, uuid.Nil, &settings); err != nil {
+		return nil, err
+	}
 
 	bucket := &entities.Bucket{
+		Id:          uuid.New(),
 		Name:        command.Name,
 		Description: command.Description,
 		OwnerId:     command.OwnerID, // Fixed field name
@@ -123,6 +186,10 @@ func (h *CreateBucketRequestHandler) Handle(ctx context.Context, command *Create
 		return nil, fmt.Errorf("failed to create bucket: %w", err)
 	}
 
+	h.events.Publish(events.BucketCreated, bucket.Id, nil, command.OwnerID, map[string]interface{}{
+		"name": bucket.Name,
+	})
+
 	bucketResponse := models.BucketResponse{
 		ID:          bucket.Id,      // Fixed field name
 		Name:        bucket.Name,
@@ -145,7 +212,34 @@ func (h *CreateBucketRequestHandler) Handle(ctx context.Context, command *Create
 			Versioning:          bucket.Settings.Versioning,
 			Encryption:          bucket.Settings.Encryption,
 			AllowOverwrite:      bucket.Settings.AllowOverwrite,
+			KeyedObjects:        bucket.Settings.KeyedObjects,
 			RequireContentType:  bucket.Settings.RequireContentType,
+			VideoProcessing:     bucket.Settings.VideoProcessing,
+			CORSRules:           utils.ConvertJSONToCORSRules(bucket.Settings.CORSRules),
+			MaxVersions:          bucket.Settings.MaxVersions,
+			VersionRetentionDays: bucket.Settings.VersionRetentionDays,
+			DisableImageTransforms: bucket.Settings.DisableImageTransforms,
+			PlacementNodeID:     bucket.Settings.PlacementNodeId,
+			PlacementGroup:      bucket.Settings.PlacementGroup,
+			PlacementZone:       bucket.Settings.PlacementZone,
+			MinZones:            bucket.Settings.MinZones,
+			WebsiteEnabled:      bucket.Settings.WebsiteEnabled,
+			WebsiteIndexDocument: bucket.Settings.WebsiteIndexDocument,
+			WebsiteErrorDocument: bucket.Settings.WebsiteErrorDocument,
+			WebsiteDomain:       bucket.Settings.WebsiteDomain,
+			CustomDomain:        bucket.Settings.CustomDomain,
+			EgressQuota:         bucket.Settings.EgressQuota,
+			EgressDailyQuota:    bucket.Settings.EgressDailyQuota,
+			EgressQuotaPolicy:   bucket.Settings.EgressQuotaPolicy,
+			Compression:         bucket.Settings.Compression,
+			DefaultHeaders:      headers.Decode(bucket.Settings.DefaultHeaders),
+			ObjectLock:          bucket.Settings.ObjectLock,
+			DefaultRetentionDays: bucket.Settings.DefaultRetentionDays,
+			PublicReadAllowedIPs: ipfilter.Decode(bucket.Settings.PublicReadAllowedIPs),
+			PublicReadDeniedIPs: ipfilter.Decode(bucket.Settings.PublicReadDeniedIPs),
+			HotlinkAllowedReferers: hotlink.Decode(bucket.Settings.HotlinkReferers),
+			HotlinkBlockEmptyReferer: bucket.Settings.HotlinkBlockEmpty,
+			HotlinkPlaceholderFileID: bucket.Settings.HotlinkPlaceholderId,
 		},
 		Stats: models.BucketStatsResponse{
 			TotalFiles: 0,
@@ -160,4 +254,66 @@ func (h *CreateBucketRequestHandler) Handle(ctx context.Context, command *Create
 		Success: true,
 		Message: "Bucket created successfully",
 	}, nil
-}
\ No newline at end of file
+}
+
+// defaultHeaders checks the response headers a bucket serves its files with. Content-Encoding
+// describes the content of one file, so it can't be a default.
+func defaultHeaders(set map[string]string) (datatypes.JSON, error) {
+	normalized, err := headers.Normalize(set)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := normalized[headers.ContentEncoding]; ok {
+		return nil, fmt.Errorf("%w: Content-Encoding can only be set on a file", headers.ErrInvalidHeader)
+	}
+	return headers.Encode(normalized), nil
+}
+
+// objectLock applies the object lock settings of a bucket. Files locked in it have to stay
+// locked, so object lock can't be turned off again.
+func objectLock(settings *entities.BucketSettings, requested models.BucketSettingsResponse) error {
+	if settings.ObjectLock && !requested.ObjectLock {
+		return apierror.New(apierror.CodeInvalidRequest, "object lock can't be turned off once it is on")
+	}
+	if requested.DefaultRetentionDays > 0 && !requested.ObjectLock {
+		return apierror.New(apierror.CodeInvalidRequest, "default retention needs object lock")
+	}
+	settings.ObjectLock = requested.ObjectLock
+	settings.DefaultRetentionDays = requested.DefaultRetentionDays
+	return nil
+}
+
+// publicReadIPs applies the IP ranges public reads of a bucket are limited to. Requests with
+// credentials are authorized as usual wherever they come from.
+func publicReadIPs(settings *entities.BucketSettings, requested models.BucketSettingsResponse) error {
+	allowed, err := ipfilter.Normalize(requested.PublicReadAllowedIPs)
+	if err != nil {
+		return err
+	}
+	denied, err := ipfilter.Normalize(requested.PublicReadDeniedIPs)
+	if err != nil {
+		return err
+	}
+	settings.PublicReadAllowedIPs = ipfilter.Encode(allowed)
+	settings.PublicReadDeniedIPs = ipfilter.Encode(denied)
+	return nil
+}
+
+// hotlinkProtection applies the pages allowed to embed a bucket's public files. The placeholder
+// served to other pages has to be a file of the bucket, so a new bucket can't have one yet.
+func hotlinkProtection(dbContext *persistence.AppDbContext, bucketID uuid.UUID, settings *entities.BucketSettings, requested models.BucketSettingsResponse) error {
+	referers, err := hotlink.Normalize(requested.HotlinkAllowedReferers)
+	if err != nil {
+		return err
+	}
+	if placeholderID := requested.HotlinkPlaceholderFileID; placeholderID != nil {
+		placeholder, err := dbContext.Files.Where(&entities.File{Id: *placeholderID}).FirstOrDefault()
+		if err != nil || placeholder == nil || placeholder.BucketId != bucketID {
+			return apierror.New(apierror.CodeInvalidRequest, "hotlink placeholder must be a file of the bucket")
+		}
+	}
+	settings.HotlinkReferers = hotlink.Encode(referers)
+	settings.HotlinkBlockEmpty = requested.HotlinkBlockEmptyReferer
+	settings.HotlinkPlaceholderId = requested.HotlinkPlaceholderFileID
+	return nil
+}
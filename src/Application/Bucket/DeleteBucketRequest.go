@@ -3,29 +3,40 @@ package bucket
 import (
 	"context"
 	"fmt"
+	"time"
 	
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
 )
 
 type DeleteBucketCommand struct {
 	BucketID uuid.UUID `json:"bucket_id"`
 	UserID   uuid.UUID `json:"user_id"`
+	// Force deletes the bucket's files too, in a background job
+	Force bool `json:"force"`
 }
 
 type DeleteBucketResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	// Job tracks a forced deletion, poll it for progress
+	Job     *models.BucketDeletionJobResponse `json:"job,omitempty"`
+	Success bool                              `json:"success"`
+	Message string                            `json:"message"`
 }
 
 type DeleteBucketRequestHandler struct {
 	dbContext *persistence.AppDbContext
+	deleter   *bucketDeleter
 }
 
 func NewDeleteBucketRequestHandler(dbContext *persistence.AppDbContext) *DeleteBucketRequestHandler {
 	return &DeleteBucketRequestHandler{
 		dbContext: dbContext,
+		deleter:   newBucketDeleter(dbContext),
 	}
 }
 
@@ -33,12 +44,12 @@ func (h *DeleteBucketRequestHandler) Handle(ctx context.Context, command *Delete
 	// Find the bucket using GoNtext static typing
 	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
 	if err != nil || bucket == nil {
-		return nil, fmt.Errorf("bucket not found")
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
 	}
 
 	// Check authorization 
 	if bucket.OwnerId != command.UserID { // Fixed field name
-		return nil, fmt.Errorf("unauthorized: only bucket owner can delete bucket")
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only bucket owner can delete bucket")
 	}
 
 	// Check if bucket has files using GoNtext static typing
@@ -47,18 +58,89 @@ func (h *DeleteBucketRequestHandler) Handle(ctx context.Context, command *Delete
 		return nil, fmt.Errorf("failed to check bucket files: %w", err)
 	}
 
-	if fileCount > 0 {
-		return nil, fmt.Errorf("cannot delete bucket: bucket contains %d files", fileCount)
+	if fileCount > 0 && !command.Force {
+		return nil, apierror.Newf(apierror.CodeConflict, "cannot delete bucket: bucket contains %d files, use force=true to delete them with the bucket", fileCount)
 	}
 
-	// Delete bucket using GoNtext
-	h.dbContext.Buckets.Remove(*bucket)
+	// Locked files can't be deleted, so neither can the bucket holding them
+	locked, err := lockedFiles(h.dbContext.GetDB().WithContext(ctx), bucket.Id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if locked > 0 {
+		return nil, apierror.Newf(apierror.CodeFileLocked, "cannot delete bucket: %d file(s) are locked", locked)
+	}
+
+	deleting, err := jobs.Active(h.dbContext, jobs.TypeBucketDelete, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket deletions: %w", err)
+	}
+	if deleting {
+		return nil, apierror.New(apierror.CodeConflict, "bucket is already being deleted")
+	}
+
+	if !command.Force {
+		// Snapshots can still hold content of files deleted earlier
+		if err := h.deleter.removeSnapshots(ctx, bucket); err != nil {
+			return nil, err
+		}
+		if err := h.deleter.removePendingUploads(ctx, bucket); err != nil {
+			return nil, err
+		}
+		if err := h.deleter.removeBucket(bucket, command.UserID); err != nil {
+			return nil, err
+		}
+
+		return &DeleteBucketResponse{
+			Success: true,
+			Message: "Bucket deleted successfully",
+		}, nil
+	}
+
+	job := &entities.BucketDeletionJob{
+		Id:         uuid.New(),
+		BucketId:   bucket.Id,
+		BucketName: bucket.Name,
+		Status:     "running",
+		TotalFiles: fileCount,
+		StartedBy:  command.UserID,
+		StartedAt:  time.Now(),
+	}
+	queued, err := jobs.New(jobs.TypeBucketDelete, deleteBucketPayload{DeletionJobID: job.Id}, jobs.Options{
+		BucketID:  &bucket.Id,
+		CreatedBy: command.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	job.JobId = &queued.Id
+
+	h.dbContext.BucketDeletionJobs.Add(*job)
+	h.dbContext.Jobs.Add(*queued)
 	if err := h.dbContext.SaveChanges(); err != nil {
-		return nil, fmt.Errorf("failed to delete bucket: %w", err)
+		return nil, fmt.Errorf("failed to save bucket deletion job: %w", err)
 	}
 
+	jobResponse := ToBucketDeletionJobResponse(job)
 	return &DeleteBucketResponse{
+		Job:     &jobResponse,
 		Success: true,
-		Message: "Bucket deleted successfully",
+		Message: fmt.Sprintf("Deleting bucket %s and its %d file(s)", bucket.Name, fileCount),
 	}, nil
-}
\ No newline at end of file
+}
+
+// RunDeletionJob runs a forced deletion queued by Handle, it is registered with the job runner
+func (h *DeleteBucketRequestHandler) RunDeletionJob(ctx context.Context, run *jobs.Run) error {
+	return h.deleter.run(ctx, run)
+}
+
+// lockedFiles counts the files of a bucket object lock holds at now
+func lockedFiles(db *gorm.DB, bucketID uuid.UUID, now time.Time) (int64, error) {
+	var locked int64
+	if err := db.Model(&entities.File{}).
+		Where(`"BucketId" = ? AND ("lock_LegalHold" = ? OR "lock_RetainUntil" > ?)`, bucketID, true, now).
+		Count(&locked).Error; err != nil {
+		return 0, fmt.Errorf("failed to check locked files: %w", err)
+	}
+	return locked, nil
+}
@@ -0,0 +1,70 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetBucketDeletionJobCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetBucketDeletionJobResponse struct {
+	Job     models.BucketDeletionJobResponse `json:"job"`
+	Success bool                             `json:"success"`
+	Message string                           `json:"message"`
+}
+
+type GetBucketDeletionJobRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetBucketDeletionJobRequestHandler(dbContext *persistence.AppDbContext) *GetBucketDeletionJobRequestHandler {
+	return &GetBucketDeletionJobRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns the latest forced deletion of a bucket, which remains readable after the bucket is gone
+func (h *GetBucketDeletionJobRequestHandler) Handle(ctx context.Context, command *GetBucketDeletionJobCommand) (*GetBucketDeletionJobResponse, error) {
+	job, err := h.dbContext.BucketDeletionJobs.Where(&entities.BucketDeletionJob{BucketId: command.BucketID}).
+		OrderByDescending("StartedAt").
+		FirstOrDefault()
+	if err != nil || job == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "bucket deletion job not found")
+	}
+	if job.StartedBy != command.UserID && command.UserRole != "admin" {
+		return nil, apierror.New(apierror.CodeNotFound, "bucket deletion job not found")
+	}
+
+	// A deletion whose background job gave up without reaching it (e.g. its server kept dying) failed too
+	if job.Status == "running" && job.JobId != nil {
+		runner, err := h.dbContext.Jobs.Where(&entities.Job{Id: *job.JobId}).FirstOrDefault()
+		if err == nil && runner != nil && runner.Status == jobs.StatusFailed {
+			completedAt := time.Now()
+			job.Status = "failed"
+			job.Error = runner.Error
+			job.CompletedAt = &completedAt
+			h.dbContext.BucketDeletionJobs.Update(*job)
+			if err := h.dbContext.SaveChanges(); err != nil {
+				return nil, fmt.Errorf("failed to save bucket deletion job: %w", err)
+			}
+		}
+	}
+
+	return &GetBucketDeletionJobResponse{
+		Job:     ToBucketDeletionJobResponse(job),
+		Success: true,
+		Message: "Bucket deletion job retrieved successfully",
+	}, nil
+}
@@ -2,10 +2,13 @@ package bucket
 
 import (
 	"context"
-	"fmt"
 	
 	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Hotlink"
+	"shbucket/src/Infrastructure/IPFilter"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
@@ -35,20 +38,12 @@ func (h *GetBucketRequestHandler) Handle(ctx context.Context, command *GetBucket
 	// Find bucket using GoNtext static typing - like GORM: Where(&Bucket{Id: command.BucketID})
 	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
 	if err != nil || bucket == nil {
-		return nil, fmt.Errorf("bucket not found")
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
 	}
 
-	// Get total files count using GoNtext static typing
-	totalFiles, err := h.dbContext.Files.Where(&entities.File{BucketId: command.BucketID}).Count()
+	bucketStats, err := sumBucketFiles(h.dbContext.GetDB().WithContext(ctx), []uuid.UUID{bucket.Id})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file count: %w", err)
-	}
-	
-	// Get total size - we'll need to use raw query for SUM operation for now
-
-	totalSize, err := h.dbContext.Files.Where(&entities.File{BucketId: command.BucketID}).Sum(&entities.File{Size: 0})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get total size: %w", err)
+		return nil, err
 	}
 	bucketResponse := models.BucketResponse{
 		ID:          bucket.Id,
@@ -72,12 +67,36 @@ func (h *GetBucketRequestHandler) Handle(ctx context.Context, command *GetBucket
 			Versioning:          bucket.Settings.Versioning,
 			Encryption:          bucket.Settings.Encryption,
 			AllowOverwrite:      bucket.Settings.AllowOverwrite,
+			KeyedObjects:        bucket.Settings.KeyedObjects,
 			RequireContentType:  bucket.Settings.RequireContentType,
+			VideoProcessing:     bucket.Settings.VideoProcessing,
+			CORSRules:           utils.ConvertJSONToCORSRules(bucket.Settings.CORSRules),
+			MaxVersions:          bucket.Settings.MaxVersions,
+			VersionRetentionDays: bucket.Settings.VersionRetentionDays,
+			DisableImageTransforms: bucket.Settings.DisableImageTransforms,
+			PlacementNodeID:     bucket.Settings.PlacementNodeId,
+			PlacementGroup:      bucket.Settings.PlacementGroup,
+			PlacementZone:       bucket.Settings.PlacementZone,
+			MinZones:            bucket.Settings.MinZones,
+			WebsiteEnabled:      bucket.Settings.WebsiteEnabled,
+			WebsiteIndexDocument: bucket.Settings.WebsiteIndexDocument,
+			WebsiteErrorDocument: bucket.Settings.WebsiteErrorDocument,
+			WebsiteDomain:       bucket.Settings.WebsiteDomain,
+			CustomDomain:        bucket.Settings.CustomDomain,
+			EgressQuota:         bucket.Settings.EgressQuota,
+			EgressDailyQuota:    bucket.Settings.EgressDailyQuota,
+			EgressQuotaPolicy:   bucket.Settings.EgressQuotaPolicy,
+			Compression:         bucket.Settings.Compression,
+			DefaultHeaders:      headers.Decode(bucket.Settings.DefaultHeaders),
+			ObjectLock:          bucket.Settings.ObjectLock,
+			DefaultRetentionDays: bucket.Settings.DefaultRetentionDays,
+			PublicReadAllowedIPs: ipfilter.Decode(bucket.Settings.PublicReadAllowedIPs),
+			PublicReadDeniedIPs: ipfilter.Decode(bucket.Settings.PublicReadDeniedIPs),
+			HotlinkAllowedReferers: hotlink.Decode(bucket.Settings.HotlinkReferers),
+			HotlinkBlockEmptyReferer: bucket.Settings.HotlinkBlockEmpty,
+			HotlinkPlaceholderFileID: bucket.Settings.HotlinkPlaceholderId,
 		},
-		Stats: models.BucketStatsResponse{
-			TotalFiles: totalFiles,
-			TotalSize:  int64(totalSize),
-		},
+		Stats:     bucketStats[bucket.Id],
 		CreatedAt: bucket.CreatedAt,
 		UpdatedAt: bucket.UpdatedAt,
 	}
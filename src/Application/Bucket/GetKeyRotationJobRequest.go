@@ -0,0 +1,83 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetKeyRotationJobCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	JobID    uuid.UUID `json:"job_id"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetKeyRotationJobResponse struct {
+	Job     models.KeyRotationJobResponse `json:"job"`
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+}
+
+type GetKeyRotationJobRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewGetKeyRotationJobRequestHandler(dbContext *persistence.AppDbContext) *GetKeyRotationJobRequestHandler {
+	return &GetKeyRotationJobRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+func (h *GetKeyRotationJobRequestHandler) Handle(ctx context.Context, command *GetKeyRotationJobCommand) (*GetKeyRotationJobResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can view key rotations")
+	}
+
+	job, err := h.dbContext.KeyRotationJobs.Where(&entities.KeyRotationJob{Id: command.JobID, BucketId: bucket.Id}).FirstOrDefault()
+	if err != nil || job == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "key rotation job not found")
+	}
+
+	// Lazy rotations progress as files are read, so their progress is measured rather than recorded
+	if job.Mode == "lazy" && job.Status == "running" {
+		pending, err := pendingDataKeys(h.dbContext.GetDB(), bucket.Id, job.KeyId)
+		if err != nil {
+			return nil, err
+		}
+		job.RewrappedKeys = max(job.TotalKeys-pending, 0)
+		if pending == 0 {
+			completedAt := time.Now()
+			job.Status = "completed"
+			job.CompletedAt = &completedAt
+		}
+
+		h.dbContext.KeyRotationJobs.Update(*job)
+		if err := h.dbContext.SaveChanges(); err != nil {
+			return nil, fmt.Errorf("failed to save key rotation job: %w", err)
+		}
+		if job.Status == "completed" {
+			publishRotationCompleted(h.events, job)
+		}
+	}
+
+	return &GetKeyRotationJobResponse{
+		Job:     ToKeyRotationJobResponse(job),
+		Success: true,
+		Message: "Key rotation job retrieved successfully",
+	}, nil
+}
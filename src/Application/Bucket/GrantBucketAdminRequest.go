@@ -0,0 +1,97 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GrantBucketAdminCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	// User is the username, email or ID of the user to make bucket admin
+	User     string    `json:"user" validate:"required,max=255"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GrantBucketAdminResponse struct {
+	Admin   models.BucketAdminResponse `json:"admin"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type GrantBucketAdminRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewGrantBucketAdminRequestHandler(dbContext *persistence.AppDbContext) *GrantBucketAdminRequestHandler {
+	return &GrantBucketAdminRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle makes a user bucket admin, letting them manage the bucket like its owner except for deleting it
+func (h *GrantBucketAdminRequestHandler) Handle(ctx context.Context, command *GrantBucketAdminCommand) (*GrantBucketAdminResponse, error) {
+	bucket, err := loadManagedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	query := h.dbContext.Users.Where(&entities.User{Email: command.User}).OrField("Username", command.User)
+	if id, err := uuid.Parse(command.User); err == nil {
+		query = h.dbContext.Users.Where(&entities.User{Id: id})
+	}
+	user, err := query.FirstOrDefault()
+	if err != nil || user == nil || !user.IsActive {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+	if user.Id == bucket.OwnerId {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "%s owns the bucket", user.Username)
+	}
+	// Changing bucket settings needs the editor role
+	if user.Role == "viewer" {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "%s has the viewer role, bucket admins need at least the editor role", user.Username)
+	}
+
+	existing, err := h.dbContext.BucketAdminGrants.Where(&entities.BucketAdminGrant{BucketId: bucket.Id, UserId: user.Id}).FirstOrDefault()
+	if err == nil && existing != nil {
+		return &GrantBucketAdminResponse{
+			Admin:   toBucketAdminResponse(existing, user),
+			Success: true,
+			Message: fmt.Sprintf("%s is already a bucket admin", user.Username),
+		}, nil
+	}
+
+	grant := &entities.BucketAdminGrant{
+		Id:        uuid.New(),
+		BucketId:  bucket.Id,
+		UserId:    user.Id,
+		GrantedBy: command.UserID,
+		CreatedAt: time.Now(),
+	}
+	h.dbContext.BucketAdminGrants.Add(*grant)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to grant bucket admin: %w", err)
+	}
+
+	h.events.Publish(events.BucketAdminGranted, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"name":     bucket.Name,
+		"user_id":  user.Id.String(),
+		"username": user.Username,
+	})
+
+	return &GrantBucketAdminResponse{
+		Admin:   toBucketAdminResponse(grant, user),
+		Success: true,
+		Message: fmt.Sprintf("%s is now a bucket admin", user.Username),
+	}, nil
+}
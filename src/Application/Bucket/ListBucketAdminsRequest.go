@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListBucketAdminsCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type ListBucketAdminsResponse struct {
+	OwnerID uuid.UUID                    `json:"owner_id"`
+	Admins  []models.BucketAdminResponse `json:"admins"`
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+}
+
+type ListBucketAdminsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListBucketAdminsRequestHandler(dbContext *persistence.AppDbContext) *ListBucketAdminsRequestHandler {
+	return &ListBucketAdminsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists the users who manage a bucket besides its owner
+func (h *ListBucketAdminsRequestHandler) Handle(ctx context.Context, command *ListBucketAdminsCommand) (*ListBucketAdminsResponse, error) {
+	bucket, err := loadManagedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := h.dbContext.BucketAdminGrants.Where(&entities.BucketAdminGrant{BucketId: bucket.Id}).OrderBy("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bucket admins: %w", err)
+	}
+
+	admins := make([]models.BucketAdminResponse, 0, len(grants))
+	for i := range grants {
+		user, _ := h.dbContext.Users.Where(&entities.User{Id: grants[i].UserId}).FirstOrDefault()
+		admins = append(admins, toBucketAdminResponse(&grants[i], user))
+	}
+
+	return &ListBucketAdminsResponse{
+		OwnerID: bucket.OwnerId,
+		Admins:  admins,
+		Success: true,
+		Message: "Bucket admins retrieved successfully",
+	}, nil
+}
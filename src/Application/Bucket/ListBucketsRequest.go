@@ -5,25 +5,45 @@ import (
 	"fmt"
 	
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Hotlink"
+	"shbucket/src/Infrastructure/IPFilter"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
 )
 
 type ListBucketsCommand struct {
-	UserID uuid.UUID `json:"user_id"`
-	Page   int       `json:"page"`
-	Limit  int       `json:"limit"`
+	UserID   uuid.UUID        `json:"user_id"`
+	UserRole string           `json:"user_role"`
+	All      bool             `json:"all"` // every bucket of the system, admins only
+	Page     int              `json:"page"`
+	Limit    int              `json:"limit"`
+	List     models.ListQuery `json:"list"`
+}
+
+// bucketSort is what buckets can be sorted by, by name when unspecified
+var bucketSort = utils.ListSort{
+	Columns: map[string]string{
+		"name":       "Name",
+		"created_at": "CreatedAt",
+		"updated_at": "UpdatedAt",
+	},
+	DefaultField: "name",
+	DefaultOrder: "asc",
 }
 
 type ListBucketsResponse struct {
-	Buckets []models.BucketResponse `json:"buckets"`
-	Total   int64                   `json:"total"`
-	Page    int                     `json:"page"`
-	Limit   int                     `json:"limit"`
-	Success bool                    `json:"success"`
-	Message string                  `json:"message"`
+	Buckets    []models.BucketResponse `json:"buckets"`
+	Total      int64                   `json:"total"`
+	Page       int                     `json:"page"`
+	Limit      int                     `json:"limit"`
+	NextCursor string                  `json:"next_cursor,omitempty"` // continues the list after this page, when sorted by created_at
+	Success    bool                    `json:"success"`
+	Message    string                  `json:"message"`
 }
 
 type ListBucketsRequestHandler struct {
@@ -48,30 +68,61 @@ func (h *ListBucketsRequestHandler) Handle(ctx context.Context, command *ListBuc
 	}
 
 	offset := (page - 1) * limit
+	if command.List.Cursor != "" {
+		// The cursor says where the page starts
+		offset = 0
+	}
+
+	if command.All && command.UserRole != "admin" {
+		return nil, apierror.New(apierror.CodeForbidden, "only admins can list all buckets")
+	}
 
-	// Get total count using GoNtext
-	total, err := h.dbContext.Buckets.Where(&entities.Bucket{OwnerId: command.UserID}).Count()
+	// The user's own buckets and the ones they were made bucket admin of, or every bucket for admins
+	db := h.dbContext.GetDB().WithContext(ctx)
+	grants := db.Model(&entities.BucketAdminGrant{}).Select("BucketId").Where(`"UserId" = ?`, command.UserID)
+	visible := db.Model(&entities.Bucket{})
+	if !command.All {
+		visible = visible.Where(`"OwnerId" = ? OR "Id" IN (?)`, command.UserID, grants)
+	}
+	visible, err := utils.ApplyListQuery(visible, command.List, bucketSort, "Name")
 	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := visible.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, fmt.Errorf("failed to count buckets: %w", err)
 	}
 
-	// Get buckets using GoNtext
-	buckets, err := h.dbContext.Buckets.Where(&entities.Bucket{OwnerId: command.UserID}).
-		Skip(offset).Take(limit).ToList()
-	if err != nil {
+	var buckets []entities.Bucket
+	if err := visible.Session(&gorm.Session{}).Offset(offset).Limit(limit).Find(&buckets).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch buckets: %w", err)
 	}
+	nextCursor := ""
+	if len(buckets) > 0 {
+		last := buckets[len(buckets)-1]
+		nextCursor = utils.NextListCursor(command.List, bucketSort, len(buckets) == limit, last.CreatedAt, last.Id)
+	}
+
+	bucketIDs := make([]uuid.UUID, len(buckets))
+	for i, bucket := range buckets {
+		bucketIDs[i] = bucket.Id
+	}
+	bucketStats, err := sumBucketFiles(db, bucketIDs)
+	if err != nil {
+		return nil, err
+	}
+	var sharedIDs []uuid.UUID
+	if err := grants.Session(&gorm.Session{}).Where(`"BucketId" IN ?`, bucketIDs).Pluck("BucketId", &sharedIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch bucket admin grants: %w", err)
+	}
+	shared := make(map[uuid.UUID]bool, len(sharedIDs))
+	for _, id := range sharedIDs {
+		shared[id] = true
+	}
 
 	bucketResponses := make([]models.BucketResponse, len(buckets))
 	for i, bucket := range buckets {
-		// Get file count using GoNtext
-		totalFiles, _ := h.dbContext.Files.Where(&entities.File{BucketId: bucket.Id}).Count()
-		
-		// Get total size using raw query for now (SUM not implemented in GoNtext yet)
-		totalSize,err  := h.dbContext.Files.SumField("Size")
-		if err != nil {
-			return nil, fmt.Errorf("failed to get total size: %w", err)
-		}
 		bucketResponses[i] = models.BucketResponse{
 			ID:          bucket.Id,
 			Name:        bucket.Name,
@@ -94,23 +145,91 @@ func (h *ListBucketsRequestHandler) Handle(ctx context.Context, command *ListBuc
 				Versioning:          bucket.Settings.Versioning,
 				Encryption:          bucket.Settings.Encryption,
 				AllowOverwrite:      bucket.Settings.AllowOverwrite,
+				KeyedObjects:        bucket.Settings.KeyedObjects,
 				RequireContentType:  bucket.Settings.RequireContentType,
+				VideoProcessing:     bucket.Settings.VideoProcessing,
+				CORSRules:           utils.ConvertJSONToCORSRules(bucket.Settings.CORSRules),
+				MaxVersions:          bucket.Settings.MaxVersions,
+				VersionRetentionDays: bucket.Settings.VersionRetentionDays,
+				DisableImageTransforms: bucket.Settings.DisableImageTransforms,
+				PlacementNodeID:     bucket.Settings.PlacementNodeId,
+				PlacementGroup:      bucket.Settings.PlacementGroup,
+				PlacementZone:       bucket.Settings.PlacementZone,
+				MinZones:            bucket.Settings.MinZones,
+				WebsiteEnabled:      bucket.Settings.WebsiteEnabled,
+				WebsiteIndexDocument: bucket.Settings.WebsiteIndexDocument,
+				WebsiteErrorDocument: bucket.Settings.WebsiteErrorDocument,
+				WebsiteDomain:       bucket.Settings.WebsiteDomain,
+				CustomDomain:        bucket.Settings.CustomDomain,
+				EgressQuota:         bucket.Settings.EgressQuota,
+				EgressDailyQuota:    bucket.Settings.EgressDailyQuota,
+				EgressQuotaPolicy:   bucket.Settings.EgressQuotaPolicy,
+				Compression:         bucket.Settings.Compression,
+				DefaultHeaders:      headers.Decode(bucket.Settings.DefaultHeaders),
+				ObjectLock:          bucket.Settings.ObjectLock,
+				DefaultRetentionDays: bucket.Settings.DefaultRetentionDays,
+				PublicReadAllowedIPs: ipfilter.Decode(bucket.Settings.PublicReadAllowedIPs),
+				PublicReadDeniedIPs: ipfilter.Decode(bucket.Settings.PublicReadDeniedIPs),
+				HotlinkAllowedReferers: hotlink.Decode(bucket.Settings.HotlinkReferers),
+				HotlinkBlockEmptyReferer: bucket.Settings.HotlinkBlockEmpty,
+				HotlinkPlaceholderFileID: bucket.Settings.HotlinkPlaceholderId,
 			},
-			Stats: models.BucketStatsResponse{
-				TotalFiles: totalFiles,
-				TotalSize:  int64(totalSize),
-			},
+			Stats:     bucketStats[bucket.Id],
+			Access:    bucketAccess(bucket, command.UserID, shared[bucket.Id]),
 			CreatedAt: bucket.CreatedAt,
 			UpdatedAt: bucket.UpdatedAt,
 		}
 	}
 
 	return &ListBucketsResponse{
-		Buckets: bucketResponses,
-		Total:   total,
-		Page:    page,
-		Limit:   limit,
-		Success: true,
-		Message: "Buckets retrieved successfully",
+		Buckets:    bucketResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		NextCursor: nextCursor,
+		Success:    true,
+		Message:    "Buckets retrieved successfully",
 	}, nil
-}
\ No newline at end of file
+}
+
+// bucketAccess tells how the user reaches a bucket of a listing: as its owner, as a bucket admin it
+// was shared with, or only as a system admin listing every bucket
+func bucketAccess(bucket entities.Bucket, userID uuid.UUID, shared bool) string {
+	switch {
+	case bucket.OwnerId == userID:
+		return "owner"
+	case shared:
+		return "shared"
+	default:
+		return "admin"
+	}
+}
+
+// sumBucketFiles counts and sums the file versions of a page of buckets in one grouped query, which
+// the (bucket_id, size) index of files answers without reading the rows. Buckets without files
+// are missing from the result, their zero stats are right.
+func sumBucketFiles(db *gorm.DB, bucketIDs []uuid.UUID) (map[uuid.UUID]models.BucketStatsResponse, error) {
+	stats := make(map[uuid.UUID]models.BucketStatsResponse, len(bucketIDs))
+	if len(bucketIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		BucketId   uuid.UUID
+		TotalFiles int64
+		TotalSize  int64
+	}
+	err := db.Model(&entities.File{}).
+		Select(`"BucketId", COUNT(*) AS "TotalFiles", COALESCE(SUM("Size"), 0) AS "TotalSize"`).
+		Where(`"BucketId" IN ?`, bucketIDs).
+		Group(`"BucketId"`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum bucket sizes: %w", err)
+	}
+
+	for _, row := range rows {
+		stats[row.BucketId] = models.BucketStatsResponse{TotalFiles: row.TotalFiles, TotalSize: row.TotalSize}
+	}
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type RevokeBucketAdminCommand struct {
+	BucketID    uuid.UUID `json:"-"`
+	AdminUserID uuid.UUID `json:"-"`
+	UserID      uuid.UUID `json:"-"`
+	UserRole    string    `json:"-"`
+}
+
+type RevokeBucketAdminResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type RevokeBucketAdminRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewRevokeBucketAdminRequestHandler(dbContext *persistence.AppDbContext) *RevokeBucketAdminRequestHandler {
+	return &RevokeBucketAdminRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle takes bucket admin away from a user. Bucket admins can also step down themselves.
+func (h *RevokeBucketAdminRequestHandler) Handle(ctx context.Context, command *RevokeBucketAdminCommand) (*RevokeBucketAdminResponse, error) {
+	bucket, err := loadManagedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	grant, err := h.dbContext.BucketAdminGrants.Where(&entities.BucketAdminGrant{BucketId: bucket.Id, UserId: command.AdminUserID}).FirstOrDefault()
+	if err != nil || grant == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "user is not a bucket admin")
+	}
+
+	h.dbContext.BucketAdminGrants.Remove(*grant)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to revoke bucket admin: %w", err)
+	}
+
+	h.events.Publish(events.BucketAdminRevoked, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"name":    bucket.Name,
+		"user_id": grant.UserId.String(),
+	})
+
+	return &RevokeBucketAdminResponse{
+		Success: true,
+		Message: "Bucket admin revoked successfully",
+	}, nil
+}
@@ -0,0 +1,279 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// Data keys re-wrapped per batch by an eager rotation, progress is saved after each batch
+const rotationBatchSize = 500
+
+type RotateBucketKeyCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	// eager re-wraps every data key in the background, lazy re-wraps each one the next time its file is read
+	Mode     string    `json:"mode" validate:"omitempty,oneof=eager lazy"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type RotateBucketKeyResponse struct {
+	Job     models.KeyRotationJobResponse `json:"job"`
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+}
+
+type RotateBucketKeyRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+	mu        sync.Mutex
+	active    map[uuid.UUID]bool
+}
+
+func NewRotateBucketKeyRequestHandler(dbContext *persistence.AppDbContext) *RotateBucketKeyRequestHandler {
+	return &RotateBucketKeyRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+		active:    make(map[uuid.UUID]bool),
+	}
+}
+
+// Handle makes a new bucket key active and starts re-wrapping the data keys wrapped by older ones
+func (h *RotateBucketKeyRequestHandler) Handle(ctx context.Context, command *RotateBucketKeyCommand) (*RotateBucketKeyResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can rotate its key")
+	}
+
+	keyring, err := encryption.NewKeyring(h.dbContext)
+	if err != nil {
+		return nil, err
+	}
+
+	hasKeys, err := h.dbContext.BucketKeys.Where(&entities.BucketKey{BucketId: bucket.Id}).Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bucket keys: %w", err)
+	}
+	if !bucket.Settings.Encryption && hasKeys == 0 {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "bucket is not encrypted")
+	}
+
+	mode := command.Mode
+	if mode == "" {
+		mode = "eager"
+	}
+
+	// An eager rotation still running would race the new one for the same rows
+	if !h.claim(bucket.Id) {
+		return nil, apierror.New(apierror.CodeConflict, "a key rotation is already running for this bucket")
+	}
+
+	key, err := keyring.Rotate(bucket.Id)
+	if err != nil {
+		h.release(bucket.Id)
+		return nil, err
+	}
+
+	// Earlier lazy rotations are taken over by this one
+	now := time.Now()
+	h.dbContext.GetDB().Model(&entities.KeyRotationJob{}).
+		Where(&entities.KeyRotationJob{BucketId: bucket.Id, Status: "running"}).
+		Updates(map[string]interface{}{"Status": "superseded", "CompletedAt": now})
+
+	total, err := pendingDataKeys(h.dbContext.GetDB(), bucket.Id, key.Id)
+	if err != nil {
+		h.release(bucket.Id)
+		return nil, err
+	}
+
+	job := &entities.KeyRotationJob{
+		Id:         uuid.New(),
+		BucketId:   bucket.Id,
+		KeyId:      key.Id,
+		KeyVersion: key.Version,
+		Mode:       mode,
+		Status:     "running",
+		TotalKeys:  total,
+		StartedBy:  command.UserID,
+		StartedAt:  now,
+	}
+	if total == 0 {
+		job.Status = "completed"
+		job.CompletedAt = &now
+	}
+
+	h.dbContext.KeyRotationJobs.Add(*job)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		h.release(bucket.Id)
+		return nil, fmt.Errorf("failed to save key rotation job: %w", err)
+	}
+
+	h.events.Publish(events.BucketKeyRotationStarted, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"job_id":      job.Id.String(),
+		"key_version": key.Version,
+		"mode":        mode,
+		"total_keys":  total,
+	})
+
+	if mode == "eager" && job.Status == "running" {
+		go func() {
+			defer h.release(bucket.Id)
+			h.execute(keyring, job)
+		}()
+	} else {
+		h.release(bucket.Id)
+		if job.Status == "completed" {
+			publishRotationCompleted(h.events, job)
+		}
+	}
+
+	return &RotateBucketKeyResponse{
+		Job:     ToKeyRotationJobResponse(job),
+		Success: true,
+		Message: fmt.Sprintf("Bucket key rotated to version %d", key.Version),
+	}, nil
+}
+
+func (h *RotateBucketKeyRequestHandler) claim(bucketID uuid.UUID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.active[bucketID] {
+		return false
+	}
+	h.active[bucketID] = true
+	return true
+}
+
+func (h *RotateBucketKeyRequestHandler) release(bucketID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.active, bucketID)
+}
+
+// execute re-wraps every data key of the bucket not wrapped by the job's key, live files first, then snapshot copies
+func (h *RotateBucketKeyRequestHandler) execute(keyring *encryption.Keyring, job *entities.KeyRotationJob) {
+	db := h.dbContext.GetDB()
+
+	for job.Error == "" {
+		var files []entities.File
+		if err := staleFiles(db, job.BucketId, job.KeyId).Limit(rotationBatchSize).Find(&files).Error; err != nil {
+			job.Error = fmt.Sprintf("failed to list files: %v", err)
+			break
+		}
+		if len(files) == 0 {
+			break
+		}
+		for i := range files {
+			if err := keyring.RewrapFile(&files[i]); err != nil {
+				job.Error = fmt.Sprintf("failed to re-wrap data key of file %s: %v", files[i].Id, err)
+				break
+			}
+			job.RewrappedKeys++
+		}
+		h.saveProgress(job)
+	}
+
+	for job.Error == "" {
+		var files []entities.SnapshotFile
+		if err := staleSnapshotFiles(db, job.BucketId, job.KeyId).Limit(rotationBatchSize).Find(&files).Error; err != nil {
+			job.Error = fmt.Sprintf("failed to list snapshot files: %v", err)
+			break
+		}
+		if len(files) == 0 {
+			break
+		}
+		for i := range files {
+			if err := keyring.RewrapSnapshotFile(job.BucketId, &files[i]); err != nil {
+				job.Error = fmt.Sprintf("failed to re-wrap data key of snapshot file %s: %v", files[i].Id, err)
+				break
+			}
+			job.RewrappedKeys++
+		}
+		h.saveProgress(job)
+	}
+
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	job.Status = "completed"
+	if job.Error != "" {
+		job.Status = "failed"
+	}
+	h.saveProgress(job)
+
+	publishRotationCompleted(h.events, job)
+	log.Printf("Key rotation %s of bucket %s %s: %d of %d data keys re-wrapped", job.Id, job.BucketId, job.Status, job.RewrappedKeys, job.TotalKeys)
+}
+
+func (h *RotateBucketKeyRequestHandler) saveProgress(job *entities.KeyRotationJob) {
+	h.dbContext.KeyRotationJobs.Update(*job)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		log.Printf("Key rotation %s: failed to save progress: %v", job.Id, err)
+	}
+}
+
+// pendingDataKeys counts the bucket's files and snapshot copies whose data key isn't wrapped by keyID
+func pendingDataKeys(db *gorm.DB, bucketID, keyID uuid.UUID) (int64, error) {
+	var files, snapshotFiles int64
+	if err := staleFiles(db, bucketID, keyID).Count(&files).Error; err != nil {
+		return 0, fmt.Errorf("failed to count files: %w", err)
+	}
+	if err := staleSnapshotFiles(db, bucketID, keyID).Count(&snapshotFiles).Error; err != nil {
+		return 0, fmt.Errorf("failed to count snapshot files: %w", err)
+	}
+	return files + snapshotFiles, nil
+}
+
+// staleFiles selects the bucket's encrypted files whose data key isn't wrapped by keyID
+func staleFiles(db *gorm.DB, bucketID, keyID uuid.UUID) *gorm.DB {
+	return db.Model(&entities.File{}).
+		Where(`"BucketId" = ? AND "encryption_KeyId" IS NOT NULL AND "encryption_KeyId" <> ?`, bucketID, keyID)
+}
+
+// staleSnapshotFiles selects the encrypted snapshot copies of the bucket whose data key isn't wrapped by keyID
+func staleSnapshotFiles(db *gorm.DB, bucketID, keyID uuid.UUID) *gorm.DB {
+	snapshots := db.Model(&entities.BucketSnapshot{}).Select("Id").Where(`"BucketId" = ?`, bucketID)
+	return db.Model(&entities.SnapshotFile{}).
+		Where(`"SnapshotId" IN (?) AND "encryption_KeyId" IS NOT NULL AND "encryption_KeyId" <> ?`, snapshots, keyID)
+}
+
+func publishRotationCompleted(publisher *events.Publisher, job *entities.KeyRotationJob) {
+	publisher.Publish(events.BucketKeyRotationCompleted, job.BucketId, nil, job.StartedBy, map[string]interface{}{
+		"job_id":         job.Id.String(),
+		"key_version":    job.KeyVersion,
+		"mode":           job.Mode,
+		"status":         job.Status,
+		"rewrapped_keys": job.RewrappedKeys,
+		"error":          job.Error,
+	})
+}
+
+func ToKeyRotationJobResponse(job *entities.KeyRotationJob) models.KeyRotationJobResponse {
+	return models.KeyRotationJobResponse{
+		ID:            job.Id,
+		BucketID:      job.BucketId,
+		KeyID:         job.KeyId,
+		KeyVersion:    job.KeyVersion,
+		Mode:          job.Mode,
+		Status:        job.Status,
+		TotalKeys:     job.TotalKeys,
+		RewrappedKeys: job.RewrappedKeys,
+		Error:         job.Error,
+		StartedAt:     job.StartedAt,
+		CompletedAt:   job.CompletedAt,
+	}
+}
@@ -5,8 +5,18 @@ import (
 	"fmt"
 	
 	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Domains"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Hotlink"
+	"shbucket/src/Infrastructure/IPFilter"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Website"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
 )
@@ -14,6 +24,7 @@ import (
 type UpdateBucketCommand struct {
 	BucketID    uuid.UUID                        `json:"bucket_id"`
 	UserID      uuid.UUID                        `json:"user_id"`
+	UserRole    string                           `json:"-"`
 	Description *string                          `json:"description,omitempty" validate:"omitempty,max=500"`
 	AuthRule    *models.AuthRuleResponse         `json:"auth_rule,omitempty"`
 	Settings    *models.BucketSettingsResponse   `json:"settings,omitempty"`
@@ -27,22 +38,21 @@ type UpdateBucketResponse struct {
 
 type UpdateBucketRequestHandler struct {
 	dbContext *persistence.AppDbContext
+	events    *events.Publisher
 }
 
 func NewUpdateBucketRequestHandler(dbContext *persistence.AppDbContext) *UpdateBucketRequestHandler {
 	return &UpdateBucketRequestHandler{
 		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
 	}
 }
 
 func (h *UpdateBucketRequestHandler) Handle(ctx context.Context, command *UpdateBucketCommand) (*UpdateBucketResponse, error) {
 	// Get existing bucket
-	bucketPtr, err := h.dbContext.Buckets.Where(&entities.Bucket{
-		Id: command.BucketID,
-		OwnerId: command.UserID,
-	}).FirstOrDefault()
-	if err != nil || bucketPtr == nil {
-		return nil, fmt.Errorf("bucket not found or access denied")
+	bucketPtr, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucketPtr == nil || !access.CanManageBucket(h.dbContext, bucketPtr, command.UserID, command.UserRole) {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found or access denied")
 	}
 
 	bucket := *bucketPtr
@@ -63,6 +73,9 @@ func (h *UpdateBucketRequestHandler) Handle(ctx context.Context, command *Update
 
 	// Update settings if provided
 	if command.Settings != nil {
+		if command.Settings.Encryption && !bucket.Settings.Encryption && !encryption.Available() {
+			return nil, encryption.ErrNotConfigured
+		}
 		bucket.Settings.MaxFileSize = command.Settings.MaxFileSize
 		bucket.Settings.MaxTotalSize = command.Settings.MaxTotalSize
 		bucket.Settings.AllowedMimeTypes = command.Settings.AllowedMimeTypes
@@ -74,7 +87,52 @@ func (h *UpdateBucketRequestHandler) Handle(ctx context.Context, command *Update
 		bucket.Settings.Versioning = command.Settings.Versioning
 		bucket.Settings.Encryption = command.Settings.Encryption
 		bucket.Settings.AllowOverwrite = command.Settings.AllowOverwrite
+		bucket.Settings.KeyedObjects = command.Settings.KeyedObjects
 		bucket.Settings.RequireContentType = command.Settings.RequireContentType
+		bucket.Settings.VideoProcessing = command.Settings.VideoProcessing
+		bucket.Settings.CORSRules = utils.ConvertCORSRulesToJSON(command.Settings.CORSRules)
+		bucket.Settings.MaxVersions = command.Settings.MaxVersions
+		bucket.Settings.VersionRetentionDays = command.Settings.VersionRetentionDays
+		bucket.Settings.DisableImageTransforms = command.Settings.DisableImageTransforms
+		if err := placement.Validate(h.dbContext, command.Settings.PlacementNodeID, command.Settings.PlacementGroup, command.Settings.PlacementZone); err != nil {
+			return nil, err
+		}
+		if err := placement.ValidateSpread(h.dbContext, command.Settings.MinZones); err != nil {
+			return nil, err
+		}
+		bucket.Settings.PlacementNodeId = command.Settings.PlacementNodeID
+		bucket.Settings.PlacementGroup = command.Settings.PlacementGroup
+		bucket.Settings.PlacementZone = command.Settings.PlacementZone
+		bucket.Settings.MinZones = command.Settings.MinZones
+		bucket.Settings.WebsiteEnabled = command.Settings.WebsiteEnabled
+		bucket.Settings.WebsiteIndexDocument = command.Settings.WebsiteIndexDocument
+		bucket.Settings.WebsiteErrorDocument = command.Settings.WebsiteErrorDocument
+		bucket.Settings.WebsiteDomain = command.Settings.WebsiteDomain
+		bucket.Settings.CustomDomain = command.Settings.CustomDomain
+		bucket.Settings.EgressQuota = command.Settings.EgressQuota
+		bucket.Settings.EgressDailyQuota = command.Settings.EgressDailyQuota
+		bucket.Settings.EgressQuotaPolicy = command.Settings.EgressQuotaPolicy
+		bucket.Settings.Compression = command.Settings.Compression
+		serveHeaders, err := defaultHeaders(command.Settings.DefaultHeaders)
+		if err != nil {
+			return nil, err
+		}
+		bucket.Settings.DefaultHeaders = serveHeaders
+		if err := objectLock(&bucket.Settings, *command.Settings); err != nil {
+			return nil, err
+		}
+		if err := publicReadIPs(&bucket.Settings, *command.Settings); err != nil {
+			return nil, err
+		}
+		if err := hotlinkProtection(h.dbContext, bucket.Id, &bucket.Settings, *command.Settings); err != nil {
+			return nil, err
+		}
+		if err := website.Configure(&bucket.Settings); err != nil {
+			return nil, err
+		}
+		if err := domains.Configure(h.dbContext, bucket.Id, &bucket.Settings); err != nil {
+			return nil, err
+		}
 	}
 
 	// Save changes
@@ -83,6 +141,16 @@ func (h *UpdateBucketRequestHandler) Handle(ctx context.Context, command *Update
 		return nil, fmt.Errorf("failed to update bucket: %w", err)
 	}
 
+	h.events.Publish(events.BucketUpdated, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"name": bucket.Name,
+	})
+
+	// Pinning places new content only; existing files stay where they are until moved
+	message := "Bucket updated successfully"
+	if misplaced, err := placement.Misplaced(ctx, h.dbContext, &bucket); err == nil && misplaced > 0 {
+		message = fmt.Sprintf("Bucket updated successfully. %d existing file(s) are stored outside %s", misplaced, placement.Describe(&bucket))
+	}
+
 	// Return response
 	bucketResponse := models.BucketResponse{
 		ID:          bucket.Id,
@@ -106,7 +174,34 @@ func (h *UpdateBucketRequestHandler) Handle(ctx context.Context, command *Update
 			Versioning:          bucket.Settings.Versioning,
 			Encryption:          bucket.Settings.Encryption,
 			AllowOverwrite:      bucket.Settings.AllowOverwrite,
+			KeyedObjects:        bucket.Settings.KeyedObjects,
 			RequireContentType:  bucket.Settings.RequireContentType,
+			VideoProcessing:     bucket.Settings.VideoProcessing,
+			CORSRules:           utils.ConvertJSONToCORSRules(bucket.Settings.CORSRules),
+			MaxVersions:          bucket.Settings.MaxVersions,
+			VersionRetentionDays: bucket.Settings.VersionRetentionDays,
+			DisableImageTransforms: bucket.Settings.DisableImageTransforms,
+			PlacementNodeID:     bucket.Settings.PlacementNodeId,
+			PlacementGroup:      bucket.Settings.PlacementGroup,
+			PlacementZone:       bucket.Settings.PlacementZone,
+			MinZones:            bucket.Settings.MinZones,
+			WebsiteEnabled:      bucket.Settings.WebsiteEnabled,
+			WebsiteIndexDocument: bucket.Settings.WebsiteIndexDocument,
+			WebsiteErrorDocument: bucket.Settings.WebsiteErrorDocument,
+			WebsiteDomain:       bucket.Settings.WebsiteDomain,
+			CustomDomain:        bucket.Settings.CustomDomain,
+			EgressQuota:         bucket.Settings.EgressQuota,
+			EgressDailyQuota:    bucket.Settings.EgressDailyQuota,
+			EgressQuotaPolicy:   bucket.Settings.EgressQuotaPolicy,
+			Compression:         bucket.Settings.Compression,
+			DefaultHeaders:      headers.Decode(bucket.Settings.DefaultHeaders),
+			ObjectLock:          bucket.Settings.ObjectLock,
+			DefaultRetentionDays: bucket.Settings.DefaultRetentionDays,
+			PublicReadAllowedIPs: ipfilter.Decode(bucket.Settings.PublicReadAllowedIPs),
+			PublicReadDeniedIPs: ipfilter.Decode(bucket.Settings.PublicReadDeniedIPs),
+			HotlinkAllowedReferers: hotlink.Decode(bucket.Settings.HotlinkReferers),
+			HotlinkBlockEmptyReferer: bucket.Settings.HotlinkBlockEmpty,
+			HotlinkPlaceholderFileID: bucket.Settings.HotlinkPlaceholderId,
 		},
 		CreatedAt: bucket.CreatedAt,
 		UpdatedAt: bucket.UpdatedAt,
@@ -115,6 +210,6 @@ func (h *UpdateBucketRequestHandler) Handle(ctx context.Context, command *Update
 	return &UpdateBucketResponse{
 		Bucket:  bucketResponse,
 		Success: true,
-		Message: "Bucket updated successfully",
+		Message: message,
 	}, nil
 }
\ No newline at end of file
@@ -0,0 +1,35 @@
+package bucket
+
+import (
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// loadManagedBucket returns a bucket the user may manage: its owner, its bucket admins and admins
+func loadManagedBucket(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage this bucket")
+	}
+	return bucket, nil
+}
+
+func toBucketAdminResponse(grant *entities.BucketAdminGrant, user *entities.User) models.BucketAdminResponse {
+	response := models.BucketAdminResponse{
+		UserID:    grant.UserId,
+		GrantedBy: grant.GrantedBy,
+		CreatedAt: grant.CreatedAt,
+	}
+	if user != nil {
+		response.Username = user.Username
+		response.Email = user.Email
+	}
+	return response
+}
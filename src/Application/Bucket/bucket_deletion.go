@@ -0,0 +1,345 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Application/APIKey"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// Files removed per batch by a forced deletion, progress is saved after each batch
+const deletionBatchSize = 500
+
+// bucketDeleter removes a bucket together with everything stored for it
+type bucketDeleter struct {
+	dbContext    *persistence.AppDbContext
+	settings     *config.Settings
+	derivedCache *storage.DerivedCache
+	events       *events.Publisher
+}
+
+func newBucketDeleter(dbContext *persistence.AppDbContext) *bucketDeleter {
+	settings := config.GetSettings()
+	return &bucketDeleter{
+		dbContext:    dbContext,
+		settings:     settings,
+		derivedCache: storage.NewDerivedCache(settings.DerivedCachePath),
+		events:       events.NewPublisher(dbContext),
+	}
+}
+
+// deleteBucketPayload is the payload of a TypeBucketDelete job
+type deleteBucketPayload struct {
+	DeletionJobID uuid.UUID `json:"deletion_job_id"`
+}
+
+// run is one attempt of a forced deletion. A failed attempt keeps the job running so the job
+// runner retries it; files removed by earlier attempts are already gone and stay counted.
+func (d *bucketDeleter) run(ctx context.Context, run *jobs.Run) error {
+	var payload deleteBucketPayload
+	if err := run.Decode(&payload); err != nil {
+		return err
+	}
+
+	job, err := d.dbContext.BucketDeletionJobs.Where(&entities.BucketDeletionJob{Id: payload.DeletionJobID}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to load bucket deletion job: %w", err)
+	}
+	if job == nil || job.Status != "running" {
+		return nil
+	}
+
+	bucket, err := d.dbContext.Buckets.Where(&entities.Bucket{Id: job.BucketId}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to load bucket: %w", err)
+	}
+
+	job.FailedFiles = 0
+	job.Error = ""
+	if bucket != nil {
+		err = d.execute(ctx, bucket, job, run)
+	}
+	if err != nil && (ctx.Err() != nil || !run.Final()) {
+		job.Error = err.Error()
+		d.saveProgress(job)
+		return err
+	}
+
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	job.Status = "completed"
+	if err != nil {
+		job.Status = "failed"
+		job.Error = err.Error()
+	}
+	d.saveProgress(job)
+
+	run.SetResult(map[string]interface{}{
+		"deletion_job_id": job.Id,
+		"deleted_files":   job.DeletedFiles,
+		"failed_files":    job.FailedFiles,
+		"deleted_bytes":   job.DeletedBytes,
+	})
+	log.Printf("Bucket deletion %s of %s %s: %d file(s) removed, %d failed", job.Id, job.BucketName, job.Status, job.DeletedFiles, job.FailedFiles)
+	return err
+}
+
+// execute removes the bucket's files, then its snapshots and unfinished uploads, then the bucket itself.
+// Files whose content can't be removed keep their record and leave the bucket and its snapshots in
+// place, so the deletion can be retried.
+func (d *bucketDeleter) execute(ctx context.Context, bucket *entities.Bucket, job *entities.BucketDeletionJob, run *jobs.Run) error {
+	db := d.dbContext.GetDB()
+
+	lastID := uuid.Nil
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		files, err := filesAfter(db, bucket.Id, lastID)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		if len(files) == 0 {
+			break
+		}
+
+		for i := range files {
+			if err := d.removeFile(ctx, &files[i]); err != nil {
+				log.Printf("Bucket deletion %s: failed to remove file %s: %v", job.Id, files[i].Id, err)
+				job.FailedFiles++
+				continue
+			}
+			job.DeletedFiles++
+			job.DeletedBytes += files[i].Size
+		}
+		lastID = files[len(files)-1].Id
+		d.saveProgress(job)
+		run.Progress(job.DeletedFiles, job.TotalFiles)
+	}
+
+	if job.FailedFiles > 0 {
+		return fmt.Errorf("%d file(s) could not be removed, the bucket was kept so the deletion can be retried", job.FailedFiles)
+	}
+	if err := d.removeSnapshots(ctx, bucket); err != nil {
+		return err
+	}
+	if err := d.removePendingUploads(ctx, bucket); err != nil {
+		return err
+	}
+	return d.removeBucket(bucket, job.StartedBy)
+}
+
+// removeFile deletes a file's content and everything recorded about it
+func (d *bucketDeleter) removeFile(ctx context.Context, file *entities.File) error {
+	if err := storage.RemoveFile(ctx, d.dbContext, file.Path); err != nil {
+		return err
+	}
+
+	if err := d.derivedCache.Invalidate(file.Id); err != nil {
+		log.Printf("Warning: failed to remove cached variants of file %s: %v", file.Id, err)
+	}
+
+	return deleteFileRecords(d.dbContext.GetDB(), file)
+}
+
+// filesAfter lists the next batch of the bucket's files, in ID order after lastID
+func filesAfter(db *gorm.DB, bucketID, lastID uuid.UUID) ([]entities.File, error) {
+	var files []entities.File
+	err := db.Where(`"BucketId" = ? AND "Id" > ?`, bucketID, lastID).
+		Order(`"Id"`).Limit(deletionBatchSize).Find(&files).Error
+	return files, err
+}
+
+// deleteFileRecords deletes a file's record and everything recorded about it, and takes its
+// content off its node's usage
+func deleteFileRecords(db *gorm.DB, file *entities.File) error {
+	if err := db.Delete(&entities.File{}, `"Id" = ?`, file.Id).Error; err != nil {
+		return fmt.Errorf("failed to delete file record: %w", err)
+	}
+	for _, model := range []interface{}{&entities.VideoAsset{}, &entities.FileComment{}, &entities.FavoriteFile{}, &entities.FileToken{}, &entities.FileAlias{}} {
+		if err := db.Where(`"FileId" = ?`, file.Id).Delete(model).Error; err != nil {
+			log.Printf("Warning: failed to remove records of file %s: %v", file.Id, err)
+		}
+	}
+
+	// Node usage only counts content a file record points to
+	if storage.IsNodePath(file.Path) {
+		if nodePath, err := storage.ParseNodePath(file.Path); err == nil {
+			db.Model(&entities.StorageNode{}).Where(`"Id" = ?`, nodePath.NodeID).
+				Update("UsedStorage", gorm.Expr(persistence.Greatest(db)+`("UsedStorage" - ?, 0)`, file.Size))
+		}
+	}
+	return nil
+}
+
+// removeSnapshots deletes the bucket's snapshots. Local snapshot content lives in the snapshot's
+// directory, node content kept only for a snapshot is removed from its node.
+func (d *bucketDeleter) removeSnapshots(ctx context.Context, bucket *entities.Bucket) error {
+	snapshots, err := d.dbContext.BucketSnapshots.Where(&entities.BucketSnapshot{BucketId: bucket.Id}).ToList()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	db := d.dbContext.GetDB()
+	for _, snapshot := range snapshots {
+		nodePaths, err := snapshotNodePaths(db, snapshot.Id)
+		if err != nil {
+			return fmt.Errorf("failed to list content of snapshot %s: %w", snapshot.Name, err)
+		}
+		for _, path := range nodePaths {
+			if err := storage.RemoveFile(ctx, d.dbContext, path); err != nil {
+				return fmt.Errorf("failed to remove content of snapshot %s: %w", snapshot.Name, err)
+			}
+		}
+
+		if err := os.RemoveAll(filepath.Join(d.settings.StoragePath, ".snapshots", snapshot.Id.String())); err != nil {
+			return fmt.Errorf("failed to remove snapshot directory of %s: %w", snapshot.Name, err)
+		}
+		if err := db.Where(`"SnapshotId" = ?`, snapshot.Id).Delete(&entities.SnapshotFile{}).Error; err != nil {
+			return fmt.Errorf("failed to delete files of snapshot %s: %w", snapshot.Name, err)
+		}
+		if err := db.Delete(&entities.BucketSnapshot{}, `"Id" = ?`, snapshot.Id).Error; err != nil {
+			return fmt.Errorf("failed to delete snapshot %s: %w", snapshot.Name, err)
+		}
+	}
+	return nil
+}
+
+// snapshotNodePaths lists the node paths a snapshot's files keep content at
+func snapshotNodePaths(db *gorm.DB, snapshotID uuid.UUID) ([]string, error) {
+	var nodePaths []string
+	err := db.Model(&entities.SnapshotFile{}).
+		Where(`"SnapshotId" = ? AND "Path" LIKE ?`, snapshotID, "node://%").
+		Distinct().Pluck("Path", &nodePaths).Error
+	return nodePaths, err
+}
+
+// removePendingUploads removes content of unfinished and resumable uploads, which can't be cleaned up once the bucket is gone
+func (d *bucketDeleter) removePendingUploads(ctx context.Context, bucket *entities.Bucket) error {
+	var pending []entities.PendingUpload
+	if err := d.dbContext.GetDB().Where(`"BucketId" = ?`, bucket.Id).Find(&pending).Error; err != nil {
+		return fmt.Errorf("failed to list pending uploads: %w", err)
+	}
+
+	for _, upload := range pending {
+		if err := storage.RemoveFile(ctx, d.dbContext, upload.Path); err != nil {
+			return fmt.Errorf("failed to remove content of pending upload %s: %w", upload.Id, err)
+		}
+		if err := d.dbContext.GetDB().Delete(&entities.PendingUpload{}, `"Id" = ?`, upload.Id).Error; err != nil {
+			return fmt.Errorf("failed to clear pending upload %s: %w", upload.Id, err)
+		}
+	}
+
+	var sessions []entities.UploadSession
+	if err := d.dbContext.GetDB().Where(`"BucketId" = ?`, bucket.Id).Find(&sessions).Error; err != nil {
+		return fmt.Errorf("failed to list upload sessions: %w", err)
+	}
+	for _, session := range sessions {
+		if err := os.Remove(session.StagingPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove content of upload session %s: %w", session.Id, err)
+		}
+		if err := d.dbContext.GetDB().Delete(&entities.UploadSession{}, `"Id" = ?`, session.Id).Error; err != nil {
+			return fmt.Errorf("failed to delete upload session %s: %w", session.Id, err)
+		}
+	}
+	return nil
+}
+
+// removeBucket deletes the bucket and the records that only exist for it: signed URLs, API key grants,
+// upload links, webhooks, aliases, its syncs from and to other buckets, its replication, encryption keys and video
+// processing state.
+// The event log, job history and egress usage are kept.
+func (d *bucketDeleter) removeBucket(bucket *entities.Bucket, actorID uuid.UUID) error {
+	if err := d.revokeGrants(bucket); err != nil {
+		return err
+	}
+	if err := deleteBucketRecords(d.dbContext.GetDB(), bucket); err != nil {
+		return err
+	}
+
+	d.dbContext.Buckets.Remove(*bucket)
+	if err := d.dbContext.SaveChanges(); err != nil {
+		return fmt.Errorf("failed to delete bucket: %w", err)
+	}
+
+	d.events.Publish(events.BucketDeleted, bucket.Id, nil, actorID, map[string]interface{}{
+		"name": bucket.Name,
+	})
+	return nil
+}
+
+// deleteBucketRecords deletes the records that only exist for the bucket
+func deleteBucketRecords(db *gorm.DB, bucket *entities.Bucket) error {
+	if err := db.Where(`"BucketName" = ?`, bucket.Name).Delete(&entities.SignedURL{}).Error; err != nil {
+		return fmt.Errorf("failed to delete signed URLs: %w", err)
+	}
+	for _, model := range []interface{}{&entities.VideoAsset{}, &entities.BucketKey{}, &entities.KeyRotationJob{}, &entities.BucketFolder{}, &entities.SignedUploadGrant{}, &entities.BucketAdminGrant{}, &entities.BucketWebhook{}, &entities.FileAlias{}, &entities.BucketSync{}} {
+		if err := db.Where(`"BucketId" = ?`, bucket.Id).Delete(model).Error; err != nil {
+			return fmt.Errorf("failed to delete bucket records: %w", err)
+		}
+	}
+	if err := db.Where(`"SourceBucketId" = ?`, bucket.Id).Delete(&entities.BucketSync{}).Error; err != nil {
+		return fmt.Errorf("failed to delete bucket syncs: %w", err)
+	}
+	if err := db.Where(`"ReplicationId" IN (?)`, db.Model(&entities.BucketReplication{}).Select("Id").Where(`"BucketId" = ?`, bucket.Id)).
+		Delete(&entities.ReplicatedFile{}).Error; err != nil {
+		return fmt.Errorf("failed to delete replicated files: %w", err)
+	}
+	if err := db.Where(`"BucketId" = ?`, bucket.Id).Delete(&entities.BucketReplication{}).Error; err != nil {
+		return fmt.Errorf("failed to delete bucket replication: %w", err)
+	}
+	return nil
+}
+
+// revokeGrants removes the bucket from API keys scoped to specific buckets. A key left without
+// buckets is deactivated, since an empty bucket list would otherwise grant access to every bucket.
+func (d *bucketDeleter) revokeGrants(bucket *entities.Bucket) error {
+	keys, err := apikey.KeysGrantedBucket(d.dbContext, bucket.Id)
+	if err != nil {
+		return err
+	}
+	for i := range keys {
+		if err := apikey.RemoveBucketGrant(d.dbContext, &keys[i], bucket.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *bucketDeleter) saveProgress(job *entities.BucketDeletionJob) {
+	d.dbContext.BucketDeletionJobs.Update(*job)
+	if err := d.dbContext.SaveChanges(); err != nil {
+		log.Printf("Bucket deletion %s: failed to save progress: %v", job.Id, err)
+	}
+}
+
+func ToBucketDeletionJobResponse(job *entities.BucketDeletionJob) models.BucketDeletionJobResponse {
+	return models.BucketDeletionJobResponse{
+		ID:           job.Id,
+		BucketID:     job.BucketId,
+		BucketName:   job.BucketName,
+		JobID:        job.JobId,
+		Status:       job.Status,
+		TotalFiles:   job.TotalFiles,
+		DeletedFiles: job.DeletedFiles,
+		FailedFiles:  job.FailedFiles,
+		DeletedBytes: job.DeletedBytes,
+		Error:        job.Error,
+		StartedAt:    job.StartedAt,
+		CompletedAt:  job.CompletedAt,
+	}
+}
@@ -0,0 +1,158 @@
+package bucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestFilesAfter pages through a bucket's files in ID order
+func TestFilesAfter(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	otherID := sqlitetest.CreateBucket(t, db, "videos").Id
+	for i, id := range []uuid.UUID{bucketID, bucketID, otherID} {
+		file := entities.File{BucketId: id, Name: uuid.NewString(), OriginalName: "a.txt", Path: "/data/" + uuid.NewString()}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+
+	first, err := filesAfter(db, bucketID, uuid.Nil)
+	if err != nil {
+		t.Fatalf("filesAfter() = %v", err)
+	}
+	if len(first) != 2 || first[0].Id.String() > first[1].Id.String() {
+		t.Fatalf("filesAfter() = %+v, want the bucket's two files in ID order", first)
+	}
+	rest, err := filesAfter(db, bucketID, first[0].Id)
+	if err != nil {
+		t.Fatalf("filesAfter() the first = %v", err)
+	}
+	if len(rest) != 1 || rest[0].Id != first[1].Id {
+		t.Errorf("filesAfter() the first = %+v, want the second file", rest)
+	}
+}
+
+// TestDeleteFileRecords deletes a file with its records and takes its content off the node's usage
+func TestDeleteFileRecords(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	node := entities.StorageNode{Name: "node-1", URL: "http://node-1", AuthKey: "key", UsedStorage: 100}
+	if err := db.Create(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	file := entities.File{BucketId: bucketID, Name: "a.txt", OriginalName: "a.txt", Size: 150,
+		Path: "node://" + node.Id.String() + "/" + bucketID.String() + "/" + uuid.NewString()}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	comment := entities.FileComment{FileId: file.Id, BucketId: bucketID, AuthorId: uuid.New(), Body: "looks good"}
+	if err := db.Create(&comment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deleteFileRecords(db, &file); err != nil {
+		t.Fatalf("deleteFileRecords() = %v", err)
+	}
+
+	var files, comments int64
+	db.Model(&entities.File{}).Count(&files)
+	db.Model(&entities.FileComment{}).Count(&comments)
+	if files != 0 || comments != 0 {
+		t.Errorf("deleteFileRecords() left %d file(s) and %d comment(s), want none", files, comments)
+	}
+	var stored entities.StorageNode
+	if err := db.First(&stored, `"Id" = ?`, node.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.UsedStorage != 0 {
+		t.Errorf("node used storage = %d, want 0", stored.UsedStorage)
+	}
+}
+
+// TestSnapshotNodePaths lists the node content of a snapshot once, and not its local content
+func TestSnapshotNodePaths(t *testing.T) {
+	db := sqlitetest.Open(t)
+	snapshotID := uuid.New()
+	for _, file := range []entities.SnapshotFile{
+		{SnapshotId: snapshotID, Name: "a.txt", Path: "node://a"},
+		{SnapshotId: snapshotID, Name: "b.txt", Path: "node://a"},
+		{SnapshotId: snapshotID, Name: "c.txt", Path: "/data/.snapshots/c.txt"},
+		{SnapshotId: uuid.New(), Name: "d.txt", Path: "node://d"},
+	} {
+		file.FileId = uuid.New()
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := snapshotNodePaths(db, snapshotID)
+	if err != nil {
+		t.Fatalf("snapshotNodePaths() = %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "node://a" {
+		t.Errorf("snapshotNodePaths() = %v, want [node://a]", paths)
+	}
+}
+
+// TestDeleteBucketRecords deletes the syncs reading the bucket and its replication with the files it
+// pushed, leaving the records of other buckets alone
+func TestDeleteBucketRecords(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	other := sqlitetest.CreateBucket(t, db, "videos")
+	mirror := sqlitetest.CreateBucket(t, db, "mirror")
+	sync := entities.BucketSync{BucketId: mirror.Id, SourceBucketId: &bucket.Id, RemoteBucketId: bucket.Id, CreatedBy: mirror.OwnerId, NextRunAt: time.Now()}
+	if err := db.Create(&sync).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uuid.UUID{bucket.Id, other.Id} {
+		r := entities.BucketReplication{BucketId: id, RemoteURL: "https://remote", RemoteBucketId: uuid.New(), CreatedBy: bucket.OwnerId}
+		if err := db.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+		pushed := entities.ReplicatedFile{ReplicationId: r.Id, Name: "a.jpg", LocalFileId: uuid.New(), RemoteFileId: uuid.New()}
+		if err := db.Create(&pushed).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := deleteBucketRecords(db, &bucket); err != nil {
+		t.Fatalf("deleteBucketRecords() = %v", err)
+	}
+
+	var syncs, replications, pushed int64
+	db.Model(&entities.BucketSync{}).Count(&syncs)
+	db.Model(&entities.BucketReplication{}).Count(&replications)
+	db.Model(&entities.ReplicatedFile{}).Count(&pushed)
+	if syncs != 0 || replications != 1 || pushed != 1 {
+		t.Errorf("deleteBucketRecords() left %d sync(s), %d replication(s) and %d pushed file(s), want only the replication of the other bucket with its file", syncs, replications, pushed)
+	}
+}
+
+// TestLockedFiles counts the files under a legal hold or still retained
+func TestLockedFiles(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	for i, lock := range []entities.FileLock{{LegalHold: true}, {RetainUntil: &future}, {RetainUntil: &past}, {}} {
+		file := entities.File{BucketId: bucket.Id, Name: uuid.NewString(), OriginalName: "a.txt", Path: "/data/" + uuid.NewString(), Lock: lock}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+
+	locked, err := lockedFiles(db, bucket.Id, now)
+	if err != nil {
+		t.Fatalf("lockedFiles() = %v", err)
+	}
+	if locked != 2 {
+		t.Errorf("lockedFiles() = %d, want 2", locked)
+	}
+}
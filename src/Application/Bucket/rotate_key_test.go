@@ -0,0 +1,47 @@
+package bucket
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestPendingDataKeys counts the bucket's files and snapshot copies not yet wrapped by the new key
+func TestPendingDataKeys(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	otherID := sqlitetest.CreateBucket(t, db, "videos").Id
+	oldKey, newKey := uuid.New(), uuid.New()
+
+	snapshot := entities.BucketSnapshot{BucketId: bucketID, Name: "daily", CreatedBy: uuid.New()}
+	otherSnapshot := entities.BucketSnapshot{BucketId: otherID, Name: "daily", CreatedBy: uuid.New()}
+	for _, s := range []*entities.BucketSnapshot{&snapshot, &otherSnapshot} {
+		if err := db.Create(s).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	records := []interface{}{
+		&entities.File{BucketId: bucketID, Name: "a.txt", OriginalName: "a.txt", Path: "/data/a.txt", Encryption: entities.FileEncryption{KeyId: &oldKey}},
+		&entities.File{BucketId: bucketID, Name: "b.txt", OriginalName: "b.txt", Path: "/data/b.txt", Encryption: entities.FileEncryption{KeyId: &newKey}},
+		&entities.File{BucketId: bucketID, Name: "c.txt", OriginalName: "c.txt", Path: "/data/c.txt"},
+		&entities.File{BucketId: otherID, Name: "a.txt", OriginalName: "a.txt", Path: "/data/other/a.txt", Encryption: entities.FileEncryption{KeyId: &oldKey}},
+		&entities.SnapshotFile{SnapshotId: snapshot.Id, FileId: uuid.New(), Name: "a.txt", Path: "/data/.snapshots/a.txt", Encryption: entities.FileEncryption{KeyId: &oldKey}},
+		&entities.SnapshotFile{SnapshotId: otherSnapshot.Id, FileId: uuid.New(), Name: "a.txt", Path: "/data/other/.snapshots/a.txt", Encryption: entities.FileEncryption{KeyId: &oldKey}},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pending, err := pendingDataKeys(db, bucketID, newKey)
+	if err != nil {
+		t.Fatalf("pendingDataKeys() = %v", err)
+	}
+	if pending != 2 {
+		t.Errorf("pendingDataKeys() = %d, want the file and the snapshot copy under the old key", pending)
+	}
+}
@@ -0,0 +1,158 @@
+package bucketsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/pkg/client"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// defaultSyncInterval is how often a bucket is synced when the interval isn't given
+const defaultSyncInterval = 300
+
+// remoteCheckTimeout bounds checking that the remote bucket can be read
+const remoteCheckTimeout = 15 * time.Second
+
+type ConfigureBucketSyncCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	// RemoteURL is the other installation's address, e.g. "https://bucket.example.com"
+	RemoteURL      string    `json:"remote_url,omitempty" validate:"omitempty,url"`
+	RemoteBucketID uuid.UUID `json:"remote_bucket_id,omitempty"`
+	// APIKey reads the remote bucket. It may be left out to keep the key of an existing sync.
+	APIKey string `json:"api_key,omitempty"`
+	// SourceBucketID is a bucket of this installation to mirror instead of a remote one, e.g. to
+	// promote staging assets to production. The user must manage it too.
+	SourceBucketID *uuid.UUID `json:"source_bucket_id,omitempty"`
+	// Prefix and Tag (a custom metadata key=value) limit the files synced
+	Prefix          string `json:"prefix,omitempty" validate:"max=1024"`
+	Tag             string `json:"tag,omitempty" validate:"max=256"`
+	IntervalSeconds int    `json:"interval_seconds,omitempty" validate:"omitempty,min=60,max=604800"`
+	// OnDemand syncs only run when asked to through the run endpoint
+	OnDemand bool `json:"on_demand,omitempty"`
+}
+
+type ConfigureBucketSyncResponse struct {
+	Sync    models.BucketSyncResponse `json:"sync"`
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+}
+
+type ConfigureBucketSyncRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewConfigureBucketSyncRequestHandler(dbContext *persistence.AppDbContext) *ConfigureBucketSyncRequestHandler {
+	return &ConfigureBucketSyncRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle makes a bucket a mirror of a bucket of another installation or of this one, or changes its
+// sync, after checking the source bucket can be read. Pointing the sync at another source bucket
+// starts it over with a full listing. The first pass runs right away.
+func (h *ConfigureBucketSyncRequestHandler) Handle(ctx context.Context, command *ConfigureBucketSyncCommand) (*ConfigureBucketSyncResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	remote := command.RemoteURL != "" || command.RemoteBucketID != uuid.Nil
+	local := command.SourceBucketID != nil
+	if remote == local || (remote && (command.RemoteURL == "" || command.RemoteBucketID == uuid.Nil)) {
+		return nil, ErrSourceRequired
+	}
+	if command.RemoteBucketID == bucket.Id || (local && *command.SourceBucketID == bucket.Id) {
+		return nil, ErrSameBucket
+	}
+	if command.Tag != "" && !strings.Contains(command.Tag, "=") {
+		return nil, ErrInvalidTag
+	}
+
+	sync, err := findSync(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket sync: %w", err)
+	}
+	apiKey := ""
+	if local {
+		if _, err := managedBucket(h.dbContext, *command.SourceBucketID, command.UserID, command.UserRole); err != nil {
+			return nil, fmt.Errorf("source bucket: %w", err)
+		}
+	} else {
+		apiKey = command.APIKey
+		if apiKey == "" {
+			if sync == nil || Local(sync) {
+				return nil, ErrAPIKeyRequired
+			}
+			apiKey = sync.APIKey
+		}
+		if err := checkRemote(ctx, command.RemoteURL, command.RemoteBucketID, apiKey); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if sync == nil {
+		sync = &entities.BucketSync{
+			Id:              uuid.New(),
+			BucketId:        bucket.Id,
+			IntervalSeconds: defaultSyncInterval,
+			Status:          StatusPending,
+			CreatedBy:       command.UserID,
+			CreatedAt:       now,
+		}
+	} else if sync.RemoteURL != command.RemoteURL || sync.RemoteBucketId != command.RemoteBucketID ||
+		!sameBucket(sync.SourceBucketId, command.SourceBucketID) || sync.Prefix != command.Prefix || sync.Tag != command.Tag {
+		sync.Cursor = ""
+		sync.Status = StatusPending
+		sync.LastError = ""
+		sync.LastSyncedAt = nil
+	}
+	sync.RemoteURL = command.RemoteURL
+	sync.RemoteBucketId = command.RemoteBucketID
+	sync.APIKey = apiKey
+	sync.SourceBucketId = command.SourceBucketID
+	sync.Prefix = command.Prefix
+	sync.Tag = command.Tag
+	sync.OnDemand = command.OnDemand
+	if command.IntervalSeconds > 0 {
+		sync.IntervalSeconds = command.IntervalSeconds
+	}
+	sync.NextRunAt = now
+	sync.UpdatedAt = now
+
+	if err := h.dbContext.GetDB().WithContext(ctx).Save(sync).Error; err != nil {
+		return nil, fmt.Errorf("failed to save bucket sync: %w", err)
+	}
+
+	return &ConfigureBucketSyncResponse{
+		Sync:    ToBucketSyncResponse(sync),
+		Success: true,
+		Message: "Bucket sync configured successfully",
+	}, nil
+}
+
+func sameBucket(a, b *uuid.UUID) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// checkRemote reads the remote bucket with the sync's key
+func checkRemote(ctx context.Context, remoteURL string, remoteBucketID uuid.UUID, apiKey string) error {
+	remote, err := client.New(remoteURL, client.WithAPIKey(apiKey), client.WithRetry(1, time.Second))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteUnreadable, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteCheckTimeout)
+	defer cancel()
+	if _, err := remote.Changes(ctx, remoteBucketID, "", 1); err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteUnreadable, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package bucketsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DeleteBucketSyncCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type DeleteBucketSyncResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteBucketSyncRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDeleteBucketSyncRequestHandler(dbContext *persistence.AppDbContext) *DeleteBucketSyncRequestHandler {
+	return &DeleteBucketSyncRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle stops syncing a bucket. The files already copied stay in it.
+func (h *DeleteBucketSyncRequestHandler) Handle(ctx context.Context, command *DeleteBucketSyncCommand) (*DeleteBucketSyncResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	result := h.dbContext.GetDB().WithContext(ctx).Delete(&entities.BucketSync{}, `"BucketId" = ?`, bucket.Id)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to delete bucket sync: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrSyncNotFound
+	}
+
+	return &DeleteBucketSyncResponse{
+		Success: true,
+		Message: "Bucket sync removed successfully",
+	}, nil
+}
@@ -0,0 +1,230 @@
+package bucketsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+
+	// changesSettleDelay holds back the newest events, so an event another server records with an
+	// earlier time than one already passed isn't skipped
+	changesSettleDelay = 5 * time.Second
+)
+
+// changeEventTypes are the events that change what a name serves
+var changeEventTypes = []string{events.FileUploaded, events.FileDeleted, events.FileRenamed}
+
+type GetBucketChangesCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	Cursor   string    `json:"-"` // empty to start with a listing of the bucket
+	Limit    int       `json:"-"`
+}
+
+type GetBucketChangesResponse struct {
+	Changes []models.FileChangeResponse `json:"changes"`
+	// Listing is set while the feed lists the current files by name, before it follows events. A
+	// listing page covers the names after ListedAfter up to its last change, or to the end once
+	// ListingComplete, and names it covers but doesn't list have no current file.
+	Listing         bool   `json:"listing"`
+	ListedAfter     string `json:"listed_after,omitempty"`
+	ListingComplete bool   `json:"listing_complete,omitempty"`
+	Cursor          string `json:"cursor"`   // passed back to read on from here
+	HasMore         bool   `json:"has_more"` // more changes can be read right away
+	Success         bool   `json:"success"`
+	Message         string `json:"message"`
+}
+
+type GetBucketChangesRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetBucketChangesRequestHandler(dbContext *persistence.AppDbContext) *GetBucketChangesRequestHandler {
+	return &GetBucketChangesRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reads a bucket's changes feed. Without a cursor the feed first lists every name the bucket
+// serves, then reports the names uploads, deletes and renames have touched since the listing started,
+// each with its state when read. Reading a name more than once is harmless, so consumers apply each
+// change as it comes and keep the last cursor to resume from.
+func (h *GetBucketChangesRequestHandler) Handle(ctx context.Context, command *GetBucketChangesCommand) (*GetBucketChangesResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+
+	limit := command.Limit
+	if limit <= 0 {
+		limit = defaultChangesLimit
+	}
+	if limit > maxChangesLimit {
+		limit = maxChangesLimit
+	}
+
+	var cursor changesCursor
+	if command.Cursor == "" {
+		// Changes from here on are followed once the listing is done, so none made while
+		// listing are missed
+		if cursor, err = h.head(ctx, bucket.Id); err != nil {
+			return nil, err
+		}
+		cursor.Listing = true
+	} else if cursor, err = decodeCursor(command.Cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.Listing {
+		return h.list(ctx, bucket.Id, cursor, limit)
+	}
+	return h.follow(ctx, bucket.Id, cursor, limit)
+}
+
+// head is the position of the bucket's latest settled event
+func (h *GetBucketChangesRequestHandler) head(ctx context.Context, bucketID uuid.UUID) (changesCursor, error) {
+	return headCursor(h.dbContext.GetDB().WithContext(ctx), bucketID, time.Now().Add(-changesSettleDelay))
+}
+
+// headCursor is the position of the bucket's latest event up to settled
+func headCursor(db *gorm.DB, bucketID uuid.UUID, settled time.Time) (changesCursor, error) {
+	var latest []entities.BucketEvent
+	if err := eventsAfter(db, bucketID, changesCursor{}).Select("Id", "CreatedAt").
+		Where(`"CreatedAt" <= ?`, settled).
+		Order(`"CreatedAt" DESC, "Id" DESC`).Limit(1).Find(&latest).Error; err != nil {
+		return changesCursor{}, fmt.Errorf("failed to read bucket events: %w", err)
+	}
+	if len(latest) == 0 {
+		return changesCursor{}, nil
+	}
+	return changesCursor{Time: latest[0].CreatedAt.UnixMicro(), EventID: latest[0].Id}, nil
+}
+
+// list returns the next page of the bucket's current files by name
+func (h *GetBucketChangesRequestHandler) list(ctx context.Context, bucketID uuid.UUID, cursor changesCursor, limit int) (*GetBucketChangesResponse, error) {
+	// Fetch one extra file to know whether the listing goes on
+	files, err := listFiles(h.dbContext.GetDB().WithContext(ctx), bucketID, cursor.After, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	complete := len(files) <= limit
+	if !complete {
+		files = files[:limit]
+	}
+
+	changes := make([]models.FileChangeResponse, len(files))
+	for i := range files {
+		changes[i] = toChange(&files[i])
+	}
+
+	next := cursor
+	if complete {
+		next.Listing, next.After = false, ""
+	} else {
+		next.After = files[len(files)-1].Name
+	}
+
+	return &GetBucketChangesResponse{
+		Changes:         changes,
+		Listing:         true,
+		ListedAfter:     cursor.After,
+		ListingComplete: complete,
+		Cursor:          next.encode(),
+		HasMore:         true,
+		Success:         true,
+		Message:         fmt.Sprintf("Listed %d files", len(changes)),
+	}, nil
+}
+
+// follow returns the state of the names the next events after the cursor touched
+func (h *GetBucketChangesRequestHandler) follow(ctx context.Context, bucketID uuid.UUID, cursor changesCursor, limit int) (*GetBucketChangesResponse, error) {
+	db := h.dbContext.GetDB().WithContext(ctx)
+	bucketEvents, err := nextEvents(db, bucketID, cursor, time.Now().Add(-changesSettleDelay), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, event := range bucketEvents {
+		var data struct {
+			Name    string `json:"name"`
+			OldName string `json:"old_name"`
+		}
+		json.Unmarshal(event.Data, &data)
+		for _, name := range []string{data.OldName, data.Name} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	current := make(map[string]*entities.File, len(names))
+	if len(names) > 0 {
+		var files []entities.File
+		if err := currentVersions(db, bucketID).Where(`"Name" IN ?`, names).Find(&files).Error; err != nil {
+			return nil, fmt.Errorf("failed to look up changed files: %w", err)
+		}
+		for i := range files {
+			current[files[i].Name] = &files[i]
+		}
+	}
+
+	changes := make([]models.FileChangeResponse, 0, len(names))
+	for _, name := range names {
+		if file := current[name]; file != nil {
+			changes = append(changes, toChange(file))
+		} else {
+			changes = append(changes, models.FileChangeResponse{Name: name, Deleted: true})
+		}
+	}
+
+	next := cursor
+	if len(bucketEvents) > 0 {
+		last := bucketEvents[len(bucketEvents)-1]
+		next = changesCursor{Time: last.CreatedAt.UnixMicro(), EventID: last.Id}
+	}
+
+	return &GetBucketChangesResponse{
+		Changes: changes,
+		Cursor:  next.encode(),
+		HasMore: len(bucketEvents) == limit,
+		Success: true,
+		Message: fmt.Sprintf("Found %d changed files", len(changes)),
+	}, nil
+}
+
+// listFiles returns up to limit of the bucket's current files named after after, by name
+func listFiles(db *gorm.DB, bucketID uuid.UUID, after string, limit int) ([]entities.File, error) {
+	var files []entities.File
+	if err := currentVersions(db, bucketID).
+		Where(ByteOrderedName(db)+` > ?`, after).Limit(limit).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return files, nil
+}
+
+// nextEvents returns up to limit of the bucket's change events after the cursor and up to settled,
+// oldest first
+func nextEvents(db *gorm.DB, bucketID uuid.UUID, cursor changesCursor, settled time.Time, limit int) ([]entities.BucketEvent, error) {
+	var bucketEvents []entities.BucketEvent
+	if err := eventsAfter(db, bucketID, cursor).Where(`"CreatedAt" <= ?`, settled).
+		Order(`"CreatedAt" ASC, "Id" ASC`).Limit(limit).Find(&bucketEvents).Error; err != nil {
+		return nil, fmt.Errorf("failed to read bucket events: %w", err)
+	}
+	return bucketEvents, nil
+}
@@ -0,0 +1,54 @@
+package bucketsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetBucketSyncCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetBucketSyncResponse struct {
+	Sync    models.BucketSyncResponse `json:"sync"`
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+}
+
+type GetBucketSyncRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetBucketSyncRequestHandler(dbContext *persistence.AppDbContext) *GetBucketSyncRequestHandler {
+	return &GetBucketSyncRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns a bucket's sync with the progress of its passes
+func (h *GetBucketSyncRequestHandler) Handle(ctx context.Context, command *GetBucketSyncCommand) (*GetBucketSyncResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	sync, err := findSync(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket sync: %w", err)
+	}
+	if sync == nil {
+		return nil, ErrSyncNotFound
+	}
+
+	return &GetBucketSyncResponse{
+		Sync:    ToBucketSyncResponse(sync),
+		Success: true,
+		Message: "Bucket sync retrieved successfully",
+	}, nil
+}
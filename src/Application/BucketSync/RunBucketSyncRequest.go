@@ -0,0 +1,73 @@
+package bucketsync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type RunBucketSyncCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	// Full lists the remote bucket again instead of following its changes, which also picks up
+	// changes the feed doesn't carry, such as restored snapshots
+	Full bool `json:"full,omitempty"`
+}
+
+type RunBucketSyncResponse struct {
+	Sync    models.BucketSyncResponse `json:"sync"`
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+}
+
+type RunBucketSyncRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewRunBucketSyncRequestHandler(dbContext *persistence.AppDbContext) *RunBucketSyncRequestHandler {
+	return &RunBucketSyncRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle makes a bucket's sync due now instead of at the end of its interval. The sync worker
+// picks it up on its next check.
+func (h *RunBucketSyncRequestHandler) Handle(ctx context.Context, command *RunBucketSyncCommand) (*RunBucketSyncResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	sync, err := findSync(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket sync: %w", err)
+	}
+	if sync == nil {
+		return nil, ErrSyncNotFound
+	}
+
+	updates := map[string]interface{}{"NextRunAt": time.Now()}
+	if command.Full {
+		updates["Cursor"] = ""
+	}
+	if err := h.dbContext.GetDB().WithContext(ctx).Model(&entities.BucketSync{Id: sync.Id}).
+		Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to schedule bucket sync: %w", err)
+	}
+	sync.NextRunAt = updates["NextRunAt"].(time.Time)
+	if command.Full {
+		sync.Cursor = ""
+	}
+
+	return &RunBucketSyncResponse{
+		Sync:    ToBucketSyncResponse(sync),
+		Success: true,
+		Message: "Bucket sync scheduled",
+	}, nil
+}
@@ -0,0 +1,146 @@
+package bucketsync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+// changesCursor is a position in a bucket's changes feed. While Listing, the feed lists the current
+// files by name after After; the event position is where it follows events from once the listing is done.
+type changesCursor struct {
+	Listing bool      `json:"l,omitempty"`
+	After   string    `json:"a,omitempty"`
+	Time    int64     `json:"t,omitempty"` // creation time of the last event passed, in microseconds
+	EventID uuid.UUID `json:"e"`
+}
+
+func (c changesCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (changesCursor, error) {
+	var cursor changesCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// currentVersions selects the current version of each name: the highest version that isn't
+// quarantined, since quarantined content is never served. Names compare byte by byte, so a listing
+// orders names the same way on every installation whatever its database collation.
+func currentVersions(db *gorm.DB, bucketID uuid.UUID) *gorm.DB {
+	if persistence.IsSQLite(db) {
+		// SQLite has no DISTINCT ON, and compares names byte by byte already
+		return db.Model(&entities.File{}).Where(`"Id" IN (
+			SELECT "Id" FROM (
+				SELECT "Id", ROW_NUMBER() OVER (PARTITION BY "Name" ORDER BY "Version" DESC, "CreatedAt" DESC) AS position
+				FROM "File" WHERE "BucketId" = ? AND COALESCE("metadata_ScanStatus", '') <> ?
+			) WHERE position = 1
+		)`, bucketID, "infected").Order(`"Name"`)
+	}
+	return db.Model(&entities.File{}).Select(`DISTINCT ON ("Name" COLLATE "C") *`).
+		Where(`"BucketId" = ? AND COALESCE("metadata_ScanStatus", '') <> ?`, bucketID, "infected").
+		Order(`"Name" COLLATE "C"`).Order(`"Version" DESC`).Order(`"CreatedAt" DESC`)
+}
+
+// ByteOrderedName is the file name column compared byte by byte, the order of the changes feed's
+// listing. SQLite compares that way already and has no "C" collation.
+func ByteOrderedName(db *gorm.DB) string {
+	if persistence.IsSQLite(db) {
+		return `"Name"`
+	}
+	return `"Name" COLLATE "C"`
+}
+
+// CurrentFile returns the version of name a bucket currently serves, nil when there is none
+func CurrentFile(ctx context.Context, dbContext *persistence.AppDbContext, bucketID uuid.UUID, name string) (*entities.File, error) {
+	var files []entities.File
+	if err := currentVersions(dbContext.GetDB().WithContext(ctx), bucketID).
+		Where(`"Name" = ?`, name).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return &files[0], nil
+}
+
+func toChange(file *entities.File) models.FileChangeResponse {
+	updatedAt := file.CreatedAt
+	return models.FileChangeResponse{
+		Name:              file.Name,
+		FileID:            &file.Id,
+		Version:           file.Version,
+		Size:              file.Size,
+		Checksum:          file.Checksum,
+		MimeType:          file.MimeType,
+		CustomMetadata:    utils.ConvertJSONToMap(file.Metadata.CustomMetadata),
+		CustomerEncrypted: file.Encryption.CustomerEncrypted(),
+		UpdatedAt:         &updatedAt,
+	}
+}
+
+// Backlog counts what a reader at cursor hasn't read yet of a bucket's changes feed: the names left
+// to list, and the events after its position with the time of the oldest of them
+func Backlog(ctx context.Context, dbContext *persistence.AppDbContext, bucketID uuid.UUID, value string) (int64, *time.Time, error) {
+	db := dbContext.GetDB().WithContext(ctx)
+	cursor := changesCursor{Listing: true}
+	if value != "" {
+		var err error
+		if cursor, err = decodeCursor(value); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var listing int64
+	if cursor.Listing {
+		if err := db.Model(&entities.File{}).Where(`"BucketId" = ? AND `+ByteOrderedName(db)+` > ?`, bucketID, cursor.After).
+			Distinct("Name").Count(&listing).Error; err != nil {
+			return 0, nil, fmt.Errorf("failed to count files: %w", err)
+		}
+	}
+	// A listing without a cursor follows events from when it started, none are behind it yet
+	if value == "" {
+		return listing, nil, nil
+	}
+
+	query := eventsAfter(db, bucketID, cursor).Session(&gorm.Session{})
+	var pending int64
+	if err := query.Count(&pending).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to count bucket events: %w", err)
+	}
+	if pending == 0 {
+		return listing, nil, nil
+	}
+	var oldest []entities.BucketEvent
+	if err := query.Select("Id", "CreatedAt").Order(`"CreatedAt" ASC, "Id" ASC`).Limit(1).Find(&oldest).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to read bucket events: %w", err)
+	}
+	if len(oldest) == 0 {
+		return listing + pending, nil, nil
+	}
+	return listing + pending, &oldest[0].CreatedAt, nil
+}
+
+// eventsAfter selects a bucket's change events after the cursor's event position
+func eventsAfter(db *gorm.DB, bucketID uuid.UUID, cursor changesCursor) *gorm.DB {
+	query := db.Model(&entities.BucketEvent{}).Where(`"BucketId" = ? AND "Type" IN ?`, bucketID, changeEventTypes)
+	if cursor.Time != 0 {
+		query = query.Where(`("CreatedAt", "Id") > (?, ?)`, time.UnixMicro(cursor.Time), cursor.EventID)
+	}
+	return query
+}
@@ -0,0 +1,70 @@
+package bucketsync
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestListFiles lists the current version of each name after a name, leaving out quarantined versions
+func TestListFiles(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	for _, file := range []entities.File{
+		{BucketId: bucket.Id, Name: "a.jpg", Version: 1},
+		{BucketId: bucket.Id, Name: "b.jpg", Version: 1},
+		{BucketId: bucket.Id, Name: "b.jpg", Version: 2},
+		{BucketId: bucket.Id, Name: "c.jpg", Version: 1},
+		{BucketId: bucket.Id, Name: "c.jpg", Version: 2, Metadata: entities.FileMetadata{ScanStatus: "infected"}},
+		{BucketId: bucket.Id, Name: "d.jpg", Version: 1},
+	} {
+		file.OriginalName = file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := listFiles(db, bucket.Id, "a.jpg", 2)
+	if err != nil {
+		t.Fatalf("listFiles() = %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "b.jpg" || files[0].Version != 2 || files[1].Name != "c.jpg" || files[1].Version != 1 {
+		t.Errorf("listFiles() after a.jpg = %+v, want b.jpg version 2 then c.jpg version 1", files)
+	}
+}
+
+// TestEvents reads a bucket's change events after a cursor and up to the settled time
+func TestEvents(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	start := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+	created := make([]entities.BucketEvent, 0, 4)
+	for i, eventType := range []string{events.FileUploaded, "bucket.updated", events.FileDeleted, events.FileRenamed} {
+		event := entities.BucketEvent{BucketId: bucket.Id, Type: eventType, CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, event)
+	}
+	settled := start.Add(2 * time.Minute)
+
+	head, err := headCursor(db, bucket.Id, settled)
+	if err != nil {
+		t.Fatalf("headCursor() = %v", err)
+	}
+	if head.EventID != created[2].Id {
+		t.Errorf("headCursor() = %+v, want the delete, the latest settled change", head)
+	}
+
+	after := changesCursor{Time: created[0].CreatedAt.UnixMicro(), EventID: created[0].Id}
+	next, err := nextEvents(db, bucket.Id, after, settled, 10)
+	if err != nil {
+		t.Fatalf("nextEvents() = %v", err)
+	}
+	if len(next) != 1 || next[0].Id != created[2].Id {
+		t.Errorf("nextEvents() after the upload = %+v, want the delete alone", next)
+	}
+}
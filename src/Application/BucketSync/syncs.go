@@ -0,0 +1,119 @@
+package bucketsync
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// Statuses of a bucket sync
+const (
+	StatusPending = "pending" // configured, not run yet
+	StatusSyncing = "syncing"
+	StatusSynced  = "synced" // the last pass caught up with the remote bucket
+	StatusFailed  = "failed" // the last pass stopped at an error, it is retried on the next run
+)
+
+// SourceFileIDKey is the custom metadata key recording which remote file a mirrored file was copied from
+const SourceFileIDKey = "sync_source_file_id"
+
+var (
+	// ErrBucketNotFound is returned when the bucket doesn't exist
+	ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	// ErrSyncNotFound is returned for buckets that aren't synced from a remote bucket
+	ErrSyncNotFound = apierror.New(apierror.CodeNotFound, "bucket is not synced from a remote bucket")
+	// ErrForbidden is returned to users who can't manage the bucket's sync
+	ErrForbidden = apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage its sync")
+	// ErrAPIKeyRequired is returned when a new sync is configured without a key for the remote
+	ErrAPIKeyRequired = apierror.New(apierror.CodeInvalidRequest, "api_key is required")
+	// ErrSameBucket is returned when a bucket is asked to mirror itself
+	ErrSameBucket = apierror.New(apierror.CodeInvalidRequest, "a bucket can't be synced from itself")
+	// ErrSourceRequired is returned unless a sync names exactly one source: a remote bucket or a local one
+	ErrSourceRequired = apierror.New(apierror.CodeInvalidRequest, "either remote_url and remote_bucket_id or source_bucket_id is required")
+	// ErrInvalidTag is returned for tag filters that aren't key=value
+	ErrInvalidTag = apierror.New(apierror.CodeInvalidRequest, "tag must be key=value")
+	// ErrRemoteUnreadable is returned when the remote bucket can't be read with the sync's URL and key
+	ErrRemoteUnreadable = apierror.New(apierror.CodeInvalidRequest, "remote bucket can't be read")
+	// ErrInvalidCursor is returned for changes feed cursors the server didn't issue
+	ErrInvalidCursor = apierror.New(apierror.CodeInvalidRequest, "invalid cursor")
+)
+
+// managedBucket loads a bucket whose sync the user may manage
+func managedBucket(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, ErrForbidden
+	}
+	return bucket, nil
+}
+
+// findSync loads the sync of a bucket, nil when it has none
+func findSync(dbContext *persistence.AppDbContext, bucketID uuid.UUID) (*entities.BucketSync, error) {
+	var syncs []entities.BucketSync
+	if err := dbContext.GetDB().Where(&entities.BucketSync{BucketId: bucketID}).Limit(1).Find(&syncs).Error; err != nil {
+		return nil, err
+	}
+	if len(syncs) == 0 {
+		return nil, nil
+	}
+	return &syncs[0], nil
+}
+
+// Local reports whether a sync's source is a bucket of this installation
+func Local(sync *entities.BucketSync) bool {
+	return sync.SourceBucketId != nil
+}
+
+// Matches reports whether a sync's filters select a name. The tag filter needs the custom metadata
+// of the name's file, so it only applies to names that have one.
+func Matches(sync *entities.BucketSync, name string, customMetadata map[string]interface{}) bool {
+	if !strings.HasPrefix(name, sync.Prefix) {
+		return false
+	}
+	if sync.Tag == "" || customMetadata == nil {
+		return true
+	}
+	key, value, _ := strings.Cut(sync.Tag, "=")
+	tagged, ok := customMetadata[key]
+	return ok && fmt.Sprint(tagged) == value
+}
+
+func ToBucketSyncResponse(sync *entities.BucketSync) models.BucketSyncResponse {
+	response := models.BucketSyncResponse{
+		ID:              sync.Id,
+		BucketID:        sync.BucketId,
+		SourceBucketID:  sync.SourceBucketId,
+		Prefix:          sync.Prefix,
+		Tag:             sync.Tag,
+		IntervalSeconds: sync.IntervalSeconds,
+		OnDemand:        sync.OnDemand,
+		Status:          sync.Status,
+		LastError:       sync.LastError,
+		FilesCopied:     sync.FilesCopied,
+		FilesDeleted:    sync.FilesDeleted,
+		FilesSkipped:    sync.FilesSkipped,
+		BytesCopied:     sync.BytesCopied,
+		NextRunAt:       sync.NextRunAt,
+		LastRunAt:       sync.LastRunAt,
+		LastSyncedAt:    sync.LastSyncedAt,
+		CreatedBy:       sync.CreatedBy,
+		CreatedAt:       sync.CreatedAt,
+		UpdatedAt:       sync.UpdatedAt,
+	}
+	if !Local(sync) {
+		remoteBucketID := sync.RemoteBucketId
+		response.RemoteURL = sync.RemoteURL
+		response.RemoteBucketID = &remoteBucketID
+	}
+	return response
+}
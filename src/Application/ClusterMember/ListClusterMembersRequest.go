@@ -0,0 +1,62 @@
+package clustermember
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Cluster"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListClusterMembersCommand struct{}
+
+type ListClusterMembersResponse struct {
+	// Members are the running servers, the leader first
+	Members []models.ClusterMemberResponse `json:"members"`
+	// ServedBy is the member that answered the request
+	ServedBy uuid.UUID `json:"served_by"`
+	Success  bool      `json:"success"`
+	Message  string    `json:"message"`
+}
+
+type ListClusterMembersRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	member    *cluster.Member
+}
+
+func NewListClusterMembersRequestHandler(dbContext *persistence.AppDbContext, member *cluster.Member) *ListClusterMembersRequestHandler {
+	return &ListClusterMembersRequestHandler{
+		dbContext: dbContext,
+		member:    member,
+	}
+}
+
+// Handle lists the master servers running against the database and which of them is the leader
+func (h *ListClusterMembersRequestHandler) Handle(ctx context.Context, command *ListClusterMembersCommand) (*ListClusterMembersResponse, error) {
+	members, err := cluster.Members(h.dbContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster members: %w", err)
+	}
+
+	responses := make([]models.ClusterMemberResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, models.ClusterMemberResponse{
+			ID:          member.Id,
+			Hostname:    member.Hostname,
+			Profile:     member.Profile,
+			Leader:      member.Leader,
+			StartedAt:   member.StartedAt,
+			HeartbeatAt: member.HeartbeatAt,
+		})
+	}
+
+	return &ListClusterMembersResponse{
+		Members:  responses,
+		ServedBy: h.member.ID(),
+		Success:  true,
+		Message:  "Cluster members retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,118 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type CreateCommentCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	FileID   uuid.UUID `json:"-"`
+	AuthorID uuid.UUID `json:"-"`
+	Body     string    `json:"body" validate:"required,max=5000"`
+}
+
+type CreateCommentResponse struct {
+	Comment models.CommentResponse `json:"comment"`
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+}
+
+type CreateCommentRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewCreateCommentRequestHandler(dbContext *persistence.AppDbContext) *CreateCommentRequestHandler {
+	return &CreateCommentRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+func (h *CreateCommentRequestHandler) Handle(ctx context.Context, command *CreateCommentCommand) (*CreateCommentResponse, error) {
+	file, err := h.dbContext.Files.Where(&entities.File{
+		Id:       command.FileID,
+		BucketId: command.BucketID,
+	}).FirstOrDefault()
+	if err != nil || file == nil {
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
+	}
+
+	author, err := h.dbContext.Users.Where(&entities.User{Id: command.AuthorID}).FirstOrDefault()
+	if err != nil || author == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+
+	// Resolve @username mentions to users, ignoring unknown names and self-mentions
+	var mentioned []entities.User
+	for _, username := range parseMentions(command.Body) {
+		user, err := h.dbContext.Users.Where(&entities.User{Username: username}).FirstOrDefault()
+		if err != nil || user == nil || !user.IsActive || user.Id == author.Id {
+			continue
+		}
+		mentioned = append(mentioned, *user)
+	}
+
+	mentionIDs := make([]uuid.UUID, len(mentioned))
+	for i, user := range mentioned {
+		mentionIDs[i] = user.Id
+	}
+
+	now := time.Now()
+	comment := entities.FileComment{
+		Id:        uuid.New(),
+		FileId:    file.Id,
+		BucketId:  file.BucketId,
+		AuthorId:  author.Id,
+		Body:      command.Body,
+		Mentions:  mustMarshal(mentionIDs),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	h.dbContext.FileComments.Add(comment)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	h.events.Publish(events.CommentCreated, comment.BucketId, &comment.FileId, author.Id, map[string]interface{}{
+		"name":       file.Name,
+		"comment_id": comment.Id,
+		"body":       comment.Body,
+	})
+
+	// Notify mentioned users. The comment is already saved, so failures here are only logged
+	for _, user := range mentioned {
+		h.dbContext.Notifications.Add(entities.Notification{
+			UserId:    user.Id,
+			Type:      "mention",
+			Message:   fmt.Sprintf("%s mentioned you in a comment on %s", author.Username, file.Name),
+			ActorId:   author.Id,
+			BucketId:  &comment.BucketId,
+			FileId:    &comment.FileId,
+			CommentId: &comment.Id,
+		})
+	}
+	if len(mentioned) > 0 {
+		if err := h.dbContext.SaveChanges(); err != nil {
+			log.Printf("Warning: failed to create mention notifications for comment %s: %v", comment.Id, err)
+		}
+	}
+
+	return &CreateCommentResponse{
+		Comment: toCommentResponse(comment, author.Username),
+		Success: true,
+		Message: "Comment created successfully",
+	}, nil
+}
@@ -0,0 +1,64 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DeleteCommentCommand struct {
+	BucketID  uuid.UUID `json:"bucket_id"`
+	FileID    uuid.UUID `json:"file_id"`
+	CommentID uuid.UUID `json:"comment_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	UserRole  string    `json:"-"`
+}
+
+type DeleteCommentResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteCommentRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDeleteCommentRequestHandler(dbContext *persistence.AppDbContext) *DeleteCommentRequestHandler {
+	return &DeleteCommentRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *DeleteCommentRequestHandler) Handle(ctx context.Context, command *DeleteCommentCommand) (*DeleteCommentResponse, error) {
+	comment, err := h.dbContext.FileComments.Where(&entities.FileComment{
+		Id:       command.CommentID,
+		FileId:   command.FileID,
+		BucketId: command.BucketID,
+	}).FirstOrDefault()
+	if err != nil || comment == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "comment not found")
+	}
+
+	// Comments can be removed by their author, the bucket owner or an admin
+	if comment.AuthorId != command.UserID && command.UserRole != "admin" {
+		bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+		if err != nil || bucket == nil || bucket.OwnerId != command.UserID {
+			return nil, apierror.New(apierror.CodeForbidden, "unauthorized: insufficient permissions to delete comment")
+		}
+	}
+
+	h.dbContext.FileComments.Remove(*comment)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	return &DeleteCommentResponse{
+		Success: true,
+		Message: "Comment deleted successfully",
+	}, nil
+}
@@ -0,0 +1,62 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListCommentsCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	FileID   uuid.UUID `json:"file_id"`
+}
+
+type ListCommentsResponse struct {
+	Comments []models.CommentResponse `json:"comments"`
+	Success  bool                     `json:"success"`
+	Message  string                   `json:"message"`
+}
+
+type ListCommentsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListCommentsRequestHandler(dbContext *persistence.AppDbContext) *ListCommentsRequestHandler {
+	return &ListCommentsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *ListCommentsRequestHandler) Handle(ctx context.Context, command *ListCommentsCommand) (*ListCommentsResponse, error) {
+	comments, err := h.dbContext.FileComments.Where(&entities.FileComment{
+		FileId:   command.FileID,
+		BucketId: command.BucketID,
+	}).OrderBy("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	usernames := make(map[uuid.UUID]string)
+	commentResponses := make([]models.CommentResponse, len(comments))
+	for i, comment := range comments {
+		username, ok := usernames[comment.AuthorId]
+		if !ok {
+			if author, err := h.dbContext.Users.Where(&entities.User{Id: comment.AuthorId}).FirstOrDefault(); err == nil && author != nil {
+				username = author.Username
+			}
+			usernames[comment.AuthorId] = username
+		}
+		commentResponses[i] = toCommentResponse(comment, username)
+	}
+
+	return &ListCommentsResponse{
+		Comments: commentResponses,
+		Success:  true,
+		Message:  "Comments retrieved successfully",
+	}, nil
+}
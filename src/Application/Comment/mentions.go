@@ -0,0 +1,54 @@
+package comment
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Models"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.-]+)`)
+
+// parseMentions returns the distinct usernames mentioned as @username in a comment body
+func parseMentions(body string) []string {
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username == "" || seen[strings.ToLower(username)] {
+			continue
+		}
+		seen[strings.ToLower(username)] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
+
+func toCommentResponse(comment entities.FileComment, authorUsername string) models.CommentResponse {
+	mentions := []uuid.UUID{}
+	if len(comment.Mentions) > 0 {
+		json.Unmarshal(comment.Mentions, &mentions)
+	}
+
+	return models.CommentResponse{
+		ID:             comment.Id,
+		FileID:         comment.FileId,
+		BucketID:       comment.BucketId,
+		AuthorID:       comment.AuthorId,
+		AuthorUsername: authorUsername,
+		Body:           comment.Body,
+		Mentions:       mentions,
+		CreatedAt:      comment.CreatedAt,
+		UpdatedAt:      comment.UpdatedAt,
+	}
+}
+
+func mustMarshal(value interface{}) datatypes.JSON {
+	data, _ := json.Marshal(value)
+	return datatypes.JSON(data)
+}
@@ -0,0 +1,158 @@
+package durability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Durability"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+var (
+	ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	ErrForbidden      = apierror.New(apierror.CodeForbidden, "only the bucket owner or a bucket admin can view its durability")
+)
+
+type GetBucketDurabilityCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	Filter   string    `json:"filter" validate:"omitempty,oneof=degraded at_risk unavailable zone_shortfall"` // which issues to list, empty for all
+	Page     int       `json:"page"`
+	Limit    int       `json:"limit"`
+}
+
+type GetBucketDurabilityResponse struct {
+	Durability models.BucketDurabilityResponse `json:"durability"`
+	Success    bool                            `json:"success"`
+	Message    string                          `json:"message"`
+}
+
+type GetBucketDurabilityRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetBucketDurabilityRequestHandler(dbContext *persistence.AppDbContext) *GetBucketDurabilityRequestHandler {
+	return &GetBucketDurabilityRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reports the replication state of every file version in a bucket, listing the versions
+// that are degraded, at risk or short of the zones the bucket requires
+func (h *GetBucketDurabilityRequestHandler) Handle(ctx context.Context, command *GetBucketDurabilityCommand) (*GetBucketDurabilityResponse, error) {
+	page := command.Page
+	limit := command.Limit
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, ErrForbidden
+	}
+
+	files, err := h.dbContext.Files.Where(&entities.File{BucketId: bucket.Id}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch files: %w", err)
+	}
+	objects, err := h.dbContext.BackupObjects.Where(&entities.BackupObject{BucketId: bucket.Id}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch backup copies: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Name != files[j].Name {
+			return files[i].Name < files[j].Name
+		}
+		return files[i].Version < files[j].Version
+	})
+
+	backups := make(map[uuid.UUID][]entities.BackupObject)
+	for _, object := range objects {
+		backups[object.FileId] = append(backups[object.FileId], object)
+	}
+
+	assessor, err := durability.NewAssessor(h.dbContext)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.BucketDurabilityResponse{
+		BucketID:   bucket.Id,
+		BucketName: bucket.Name,
+		Objects:    int64(len(files)),
+		Issues:     []models.DurableObjectResponse{},
+		MinZones:   bucket.Settings.MinZones,
+		Page:       page,
+		Limit:      limit,
+	}
+	offset := int64((page - 1) * limit)
+	for i := range files {
+		file := &files[i]
+		status := assessor.Assess(file, backups[file.Id])
+		if status.Degraded {
+			report.Degraded++
+		}
+		if status.AtRisk {
+			report.AtRisk++
+		}
+		if status.Unavailable {
+			report.Unavailable++
+		}
+		if status.ZoneShortfall {
+			report.ZoneShortfall++
+		}
+		if !status.Degraded && !status.AtRisk && !status.ZoneShortfall {
+			report.Healthy++
+		}
+
+		if !matches(command.Filter, status) {
+			continue
+		}
+		if report.Total >= offset && len(report.Issues) < limit {
+			report.Issues = append(report.Issues, models.DurableObjectResponse{
+				FileID:      file.Id,
+				Name:        file.Name,
+				Version:     file.Version,
+				Size:        file.Size,
+				Replication: status,
+			})
+		}
+		report.Total++
+	}
+
+	return &GetBucketDurabilityResponse{
+		Durability: report,
+		Success:    true,
+		Message:    "Bucket durability retrieved successfully",
+	}, nil
+}
+
+// matches reports whether a version's state is an issue the report lists
+func matches(filter string, status models.ReplicationStatusResponse) bool {
+	switch filter {
+	case "degraded":
+		return status.Degraded
+	case "at_risk":
+		return status.AtRisk
+	case "unavailable":
+		return status.Unavailable
+	case "zone_shortfall":
+		return status.ZoneShortfall
+	default:
+		return status.Degraded || status.AtRisk || status.ZoneShortfall
+	}
+}
@@ -0,0 +1,51 @@
+package egress
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetAPIKeyEgressCommand struct {
+	APIKeyID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+}
+
+type GetAPIKeyEgressResponse struct {
+	Egress  models.EgressUsageResponse `json:"egress"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type GetAPIKeyEgressRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetAPIKeyEgressRequestHandler(dbContext *persistence.AppDbContext) *GetAPIKeyEgressRequestHandler {
+	return &GetAPIKeyEgressRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reports the bytes downloaded with one of the user's API keys in the current billing cycle and day
+func (h *GetAPIKeyEgressRequestHandler) Handle(ctx context.Context, command *GetAPIKeyEgressCommand) (*GetAPIKeyEgressResponse, error) {
+	apiKey, err := h.dbContext.APIKeys.Where(&entities.APIKey{Id: command.APIKeyID, UserId: command.UserID}).FirstOrDefault()
+	if err != nil || apiKey == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	usage, err := report(ctx, h.dbContext, metering.ScopeAPIKey, apiKey.Id, apiKey.EgressQuota, apiKey.EgressDailyQuota)
+	if err != nil {
+		return nil, err
+	}
+	return &GetAPIKeyEgressResponse{
+		Egress:  usage,
+		Success: true,
+		Message: "API key egress retrieved successfully",
+	}, nil
+}
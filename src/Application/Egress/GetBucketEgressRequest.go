@@ -0,0 +1,72 @@
+package egress
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetBucketEgressCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetBucketEgressResponse struct {
+	Egress  models.BucketEgressResponse `json:"egress"`
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+}
+
+type GetBucketEgressRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetBucketEgressRequestHandler(dbContext *persistence.AppDbContext) *GetBucketEgressRequestHandler {
+	return &GetBucketEgressRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reports a bucket's egress and its owner's in the current billing cycle. Either quota
+// being exceeded applies the bucket's policy to its downloads.
+func (h *GetBucketEgressRequestHandler) Handle(ctx context.Context, command *GetBucketEgressCommand) (*GetBucketEgressResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, ErrForbidden
+	}
+
+	bucketUsage, err := report(ctx, h.dbContext, metering.ScopeBucket, bucket.Id, bucket.Settings.EgressQuota, bucket.Settings.EgressDailyQuota)
+	if err != nil {
+		return nil, err
+	}
+	var ownerQuota int64
+	if owner, _ := h.dbContext.Users.Where(&entities.User{Id: bucket.OwnerId}).FirstOrDefault(); owner != nil {
+		ownerQuota = owner.EgressQuota
+	}
+	ownerUsage, err := report(ctx, h.dbContext, metering.ScopeUser, bucket.OwnerId, ownerQuota, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetBucketEgressResponse{
+		Egress: models.BucketEgressResponse{
+			Bucket:       bucketUsage,
+			Owner:        ownerUsage,
+			Policy:       metering.Policy(bucket),
+			ThrottleRate: config.GetSettings().EgressThrottleRate,
+		},
+		Success: true,
+		Message: "Bucket egress retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,50 @@
+package egress
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetUserEgressCommand struct {
+	UserID uuid.UUID `json:"-"`
+}
+
+type GetUserEgressResponse struct {
+	Egress  models.EgressUsageResponse `json:"egress"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type GetUserEgressRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetUserEgressRequestHandler(dbContext *persistence.AppDbContext) *GetUserEgressRequestHandler {
+	return &GetUserEgressRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reports the egress of all buckets a user owns in the current billing cycle
+func (h *GetUserEgressRequestHandler) Handle(ctx context.Context, command *GetUserEgressCommand) (*GetUserEgressResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	usage, err := report(ctx, h.dbContext, metering.ScopeUser, user.Id, user.EgressQuota, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &GetUserEgressResponse{
+		Egress:  usage,
+		Success: true,
+		Message: "User egress retrieved successfully",
+	}, nil
+}
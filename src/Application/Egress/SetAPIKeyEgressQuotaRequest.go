@@ -0,0 +1,71 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type SetAPIKeyEgressQuotaCommand struct {
+	APIKeyID         uuid.UUID `json:"-"`
+	UserID           uuid.UUID `json:"-"`
+	EgressQuota      int64     `json:"egress_quota" validate:"min=0"`       // bytes downloaded with the key per billing cycle, 0 for no quota
+	EgressDailyQuota int64     `json:"egress_daily_quota" validate:"min=0"` // bytes downloaded with the key per day (UTC), 0 for no quota
+}
+
+type SetAPIKeyEgressQuotaResponse struct {
+	Egress  models.EgressUsageResponse `json:"egress"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type SetAPIKeyEgressQuotaRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewSetAPIKeyEgressQuotaRequestHandler(dbContext *persistence.AppDbContext) *SetAPIKeyEgressQuotaRequestHandler {
+	return &SetAPIKeyEgressQuotaRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle sets the quotas on the bytes downloaded with one of the user's API keys. Servers apply
+// them within 30 seconds.
+func (h *SetAPIKeyEgressQuotaRequestHandler) Handle(ctx context.Context, command *SetAPIKeyEgressQuotaCommand) (*SetAPIKeyEgressQuotaResponse, error) {
+	apiKey, err := h.dbContext.APIKeys.Where(&entities.APIKey{Id: command.APIKeyID, UserId: command.UserID}).FirstOrDefault()
+	if err != nil || apiKey == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	if err := setAPIKeyQuotas(h.dbContext.GetDB().WithContext(ctx), apiKey, command.EgressQuota, command.EgressDailyQuota); err != nil {
+		return nil, err
+	}
+
+	usage, err := report(ctx, h.dbContext, metering.ScopeAPIKey, apiKey.Id, command.EgressQuota, command.EgressDailyQuota)
+	if err != nil {
+		return nil, err
+	}
+	return &SetAPIKeyEgressQuotaResponse{
+		Egress:  usage,
+		Success: true,
+		Message: "Egress quotas updated successfully",
+	}, nil
+}
+
+// setAPIKeyQuotas saves the key's quotas, 0 clears one
+func setAPIKeyQuotas(db *gorm.DB, apiKey *entities.APIKey, quota, dailyQuota int64) error {
+	if err := db.Model(apiKey).Updates(map[string]interface{}{
+		"EgressQuota":      quota,
+		"EgressDailyQuota": dailyQuota,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update egress quotas: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type SetUserEgressQuotaCommand struct {
+	UserID      uuid.UUID `json:"-"`
+	EgressQuota int64     `json:"egress_quota" validate:"min=0"` // bytes served from the user's buckets per billing cycle, 0 for no quota
+}
+
+type SetUserEgressQuotaResponse struct {
+	Egress  models.EgressUsageResponse `json:"egress"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type SetUserEgressQuotaRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewSetUserEgressQuotaRequestHandler(dbContext *persistence.AppDbContext) *SetUserEgressQuotaRequestHandler {
+	return &SetUserEgressQuotaRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle sets the quota on the egress of all buckets a user owns. Servers apply it within 30 seconds.
+func (h *SetUserEgressQuotaRequestHandler) Handle(ctx context.Context, command *SetUserEgressQuotaCommand) (*SetUserEgressQuotaResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := h.dbContext.GetDB().WithContext(ctx).Model(user).Update("EgressQuota", command.EgressQuota).Error; err != nil {
+		return nil, fmt.Errorf("failed to update egress quota: %w", err)
+	}
+
+	usage, err := report(ctx, h.dbContext, metering.ScopeUser, user.Id, command.EgressQuota, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &SetUserEgressQuotaResponse{
+		Egress:  usage,
+		Success: true,
+		Message: "Egress quota updated successfully",
+	}, nil
+}
@@ -0,0 +1,76 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// historyCycles is how many earlier billing cycles a report includes
+const historyCycles = 12
+
+var (
+	ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	ErrUserNotFound   = apierror.New(apierror.CodeUserNotFound, "user not found")
+	ErrAPIKeyNotFound = apierror.New(apierror.CodeNotFound, "API key not found")
+	ErrForbidden      = apierror.New(apierror.CodeForbidden, "only the bucket owner or a bucket admin can view its egress")
+)
+
+// report builds the egress of a bucket, user or API key in the current billing cycle, with
+// earlier cycles, and in the current day for the scopes with daily quotas
+func report(ctx context.Context, dbContext *persistence.AppDbContext, scope string, id uuid.UUID, quota, dailyQuota int64) (models.EgressUsageResponse, error) {
+	now := time.Now()
+	cycleStart := metering.CycleStart(now)
+	response := models.EgressUsageResponse{
+		Scope:      scope,
+		ID:         id,
+		CycleStart: cycleStart,
+		CycleEnd:   metering.CycleEnd(cycleStart),
+		Quota:      quota,
+		DailyQuota: dailyQuota,
+		History:    []models.EgressCycleResponse{},
+	}
+
+	if dailyScope := metering.DailyScope(scope); dailyScope != "" {
+		daily, err := metering.Usage(ctx, dbContext, dailyScope, id, metering.DayStart(now))
+		if err != nil {
+			return response, fmt.Errorf("failed to fetch daily egress usage: %w", err)
+		}
+		response.DailyBytes = daily
+	}
+
+	usages, err := cycleUsages(dbContext.GetDB().WithContext(ctx), scope, id, cycleStart.AddDate(0, -historyCycles, 0))
+	if err != nil {
+		return response, fmt.Errorf("failed to fetch egress usage: %w", err)
+	}
+	for _, usage := range usages {
+		if usage.CycleStart.Equal(cycleStart) {
+			response.Bytes = usage.Bytes
+			continue
+		}
+		response.History = append(response.History, models.EgressCycleResponse{
+			CycleStart: usage.CycleStart,
+			Bytes:      usage.Bytes,
+		})
+	}
+	response.Exceeded = (quota > 0 && response.Bytes >= quota) || (dailyQuota > 0 && response.DailyBytes >= dailyQuota)
+	return response, nil
+}
+
+// cycleUsages returns the usage of a bucket, user or API key in the billing cycles starting at
+// since or later, latest first
+func cycleUsages(db *gorm.DB, scope string, id uuid.UUID, since time.Time) ([]entities.EgressUsage, error) {
+	var usages []entities.EgressUsage
+	err := db.Where(`"Scope" = ? AND "ScopeId" = ? AND "CycleStart" >= ?`, scope, id, since).
+		Order(`"CycleStart" DESC`).Find(&usages).Error
+	return usages, err
+}
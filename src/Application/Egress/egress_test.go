@@ -0,0 +1,58 @@
+package egress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestCycleUsages returns the cycles of one scope from a date on, latest first
+func TestCycleUsages(t *testing.T) {
+	db := sqlitetest.Open(t)
+	id := uuid.New()
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for month := 0; month < 4; month++ {
+		usage := entities.EgressUsage{Scope: metering.ScopeUser, ScopeId: id, CycleStart: start.AddDate(0, month, 0), Bytes: int64(month + 1)}
+		if err := db.Create(&usage).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := entities.EgressUsage{Scope: metering.ScopeBucket, ScopeId: id, CycleStart: start.AddDate(0, 3, 0), Bytes: 99}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	usages, err := cycleUsages(db, metering.ScopeUser, id, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("cycleUsages() = %v", err)
+	}
+	if len(usages) != 3 || usages[0].Bytes != 4 || usages[2].Bytes != 2 {
+		t.Errorf("cycleUsages() = %+v, want the last three cycles of the user, latest first", usages)
+	}
+}
+
+// TestSetAPIKeyQuotas saves both quotas of a key
+func TestSetAPIKeyQuotas(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	key := entities.APIKey{Name: "ci", KeyHash: "hash", KeyPrefix: "shb_", UserId: bucket.OwnerId, IsActive: true, EgressQuota: 1}
+	if err := db.Create(&key).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setAPIKeyQuotas(db, &key, 0, 500); err != nil {
+		t.Fatalf("setAPIKeyQuotas() = %v", err)
+	}
+	var stored entities.APIKey
+	if err := db.First(&stored, `"Id" = ?`, key.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.EgressQuota != 0 || stored.EgressDailyQuota != 500 {
+		t.Errorf("setAPIKeyQuotas() saved %d and %d, want 0 and 500", stored.EgressQuota, stored.EgressDailyQuota)
+	}
+}
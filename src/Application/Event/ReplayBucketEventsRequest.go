@@ -0,0 +1,160 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+const (
+	defaultReplayLimit = 500
+	maxReplayLimit     = 5000
+)
+
+type ReplayBucketEventsCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	From     time.Time `json:"-"`
+	// WebhookID replays to one of the bucket's webhooks, signed with its secret. Otherwise
+	// events go unsigned to URL.
+	WebhookID *uuid.UUID `json:"webhook_id,omitempty"`
+	URL       string     `json:"url,omitempty" validate:"required_without=WebhookID,omitempty,url"`
+	Types     []string   `json:"types,omitempty"`
+	Limit     int        `json:"limit,omitempty" validate:"omitempty,min=1,max=5000"`
+}
+
+type ReplayBucketEventsResponse struct {
+	Replayed int        `json:"replayed"`
+	HasMore  bool       `json:"has_more"`
+	NextFrom *time.Time `json:"next_from,omitempty"`
+	Success  bool       `json:"success"`
+	Message  string     `json:"message"`
+}
+
+type ReplayBucketEventsRequestHandler struct {
+	dbContext  *persistence.AppDbContext
+	httpClient *http.Client
+}
+
+func NewReplayBucketEventsRequestHandler(dbContext *persistence.AppDbContext) *ReplayBucketEventsRequestHandler {
+	return &ReplayBucketEventsRequestHandler{
+		dbContext:  dbContext,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Handle re-delivers a bucket's historical events, oldest first, to a webhook.
+// Delivery stops at the first failure; callers resume by replaying again from NextFrom.
+// Events sharing NextFrom's timestamp may be delivered twice, so sinks should dedupe on the event ID.
+// Replays to a registered webhook are signed like live deliveries and default to its event types.
+func (h *ReplayBucketEventsRequestHandler) Handle(ctx context.Context, command *ReplayBucketEventsCommand) (*ReplayBucketEventsResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+
+	if !access.CanManageBucket(h.dbContext, bucket, command.UserID, command.UserRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can replay its events")
+	}
+
+	url, secret, types := command.URL, "", command.Types
+	if command.WebhookID != nil {
+		webhook, err := h.dbContext.BucketWebhooks.Where(&entities.BucketWebhook{Id: *command.WebhookID}).FirstOrDefault()
+		if err != nil || webhook == nil || webhook.BucketId != bucket.Id {
+			return nil, apierror.New(apierror.CodeNotFound, "webhook not found")
+		}
+		url, secret = webhook.URL, webhook.Secret
+		if len(types) == 0 && len(webhook.EventTypes) > 0 {
+			json.Unmarshal(webhook.EventTypes, &types)
+		}
+	}
+
+	limit := command.Limit
+	if limit <= 0 {
+		limit = defaultReplayLimit
+	}
+	if limit > maxReplayLimit {
+		limit = maxReplayLimit
+	}
+
+	// Fetch one extra event to know whether there is more history after this batch
+	events, err := loadEvents(h.dbContext.GetDB().WithContext(ctx), command.BucketID, command.From, types, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextFrom *time.Time
+	if len(events) > limit {
+		nextFrom = &events[limit].CreatedAt
+		events = events[:limit]
+	}
+
+	replayed := 0
+	for _, event := range events {
+		if err := h.deliver(ctx, url, secret, &event); err != nil {
+			nextFrom := event.CreatedAt
+			return &ReplayBucketEventsResponse{
+				Replayed: replayed,
+				HasMore:  true,
+				NextFrom: &nextFrom,
+				Success:  false,
+				Message:  fmt.Sprintf("Delivery of event %s failed: %v", event.Id, err),
+			}, nil
+		}
+		replayed++
+	}
+
+	return &ReplayBucketEventsResponse{
+		Replayed: replayed,
+		HasMore:  nextFrom != nil,
+		NextFrom: nextFrom,
+		Success:  true,
+		Message:  fmt.Sprintf("Replayed %d events", replayed),
+	}, nil
+}
+
+// loadEvents returns up to limit of the bucket's events from the given time on, oldest first,
+// only those of types when any are given
+func loadEvents(db *gorm.DB, bucketID uuid.UUID, from time.Time, types []string, limit int) ([]entities.BucketEvent, error) {
+	query := db.Where(`"BucketId" = ? AND "CreatedAt" >= ?`, bucketID, from)
+	if len(types) > 0 {
+		query = query.Where(`"Type" IN ?`, types)
+	}
+
+	var events []entities.BucketEvent
+	if err := query.Order(`"CreatedAt" ASC, "Id" ASC`).Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to load bucket events: %w", err)
+	}
+	return events, nil
+}
+
+func (h *ReplayBucketEventsRequestHandler) deliver(ctx context.Context, url, secret string, event *entities.BucketEvent) error {
+	req, err := events.NewDeliveryRequest(ctx, url, secret, event, true)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sink returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
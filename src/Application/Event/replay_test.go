@@ -0,0 +1,44 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestLoadEvents replays a bucket's events from a time on, oldest first and filtered by type
+func TestLoadEvents(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := uuid.New()
+	start := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+	for i, eventType := range []string{"file.uploaded", "file.deleted", "file.uploaded", "bucket.updated"} {
+		event := entities.BucketEvent{BucketId: bucketID, Type: eventType, CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := entities.BucketEvent{BucketId: uuid.New(), Type: "file.uploaded", CreatedAt: start}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := loadEvents(db, bucketID, start.Add(time.Minute), nil, 10)
+	if err != nil {
+		t.Fatalf("loadEvents() = %v", err)
+	}
+	if len(events) != 3 || events[0].Type != "file.deleted" || events[2].Type != "bucket.updated" {
+		t.Errorf("loadEvents() from the second event = %+v, want the last three events oldest first", events)
+	}
+
+	events, err = loadEvents(db, bucketID, start, []string{"file.uploaded"}, 1)
+	if err != nil {
+		t.Fatalf("loadEvents() with types = %v", err)
+	}
+	if len(events) != 1 || !events[0].CreatedAt.Equal(start) {
+		t.Errorf("loadEvents() of uploads limited to one = %+v, want the first upload", events)
+	}
+}
@@ -0,0 +1,245 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/S3"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+type ExportS3Command struct {
+	BucketID       uuid.UUID `json:"bucket_id" validate:"required"`
+	Target         s3.Config `json:"target" validate:"required"`
+	TargetBucket   string    `json:"target_bucket" validate:"required"`
+	Prefix         string    `json:"prefix"`
+	BandwidthLimit int64     `json:"bandwidth_limit" validate:"min=0"` // bytes per second, 0 is unlimited
+	StartedBy      uuid.UUID `json:"-"`
+}
+
+type ExportS3Response struct {
+	Job     models.S3ExportJobResponse `json:"job"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type ExportS3RequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewExportS3RequestHandler(dbContext *persistence.AppDbContext) *ExportS3RequestHandler {
+	return &ExportS3RequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle creates an export job and copies the bucket out in the background
+func (h *ExportS3RequestHandler) Handle(ctx context.Context, command *ExportS3Command) (*ExportS3Response, error) {
+	client, err := s3.NewClient(command.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+
+	// Fail fast on bad credentials or a missing target bucket
+	if _, err := client.ListObjectsV2(ctx, command.TargetBucket, s3.ListObjectsOptions{MaxKeys: 1}); err != nil {
+		return nil, fmt.Errorf("failed to access target bucket: %w", err)
+	}
+
+	prefix := command.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	job := &entities.S3ExportJob{
+		Id:             uuid.New(),
+		BucketId:       bucket.Id,
+		TargetEndpoint: command.Target.Endpoint,
+		TargetBucket:   command.TargetBucket,
+		Prefix:         prefix,
+		BandwidthLimit: command.BandwidthLimit,
+		Status:         "running",
+		FailedObjects:  datatypes.JSON("[]"),
+		StartedBy:      command.StartedBy,
+		StartedAt:      time.Now(),
+	}
+	h.dbContext.S3ExportJobs.Add(*job)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	go h.execute(client, job, bucket)
+
+	return &ExportS3Response{
+		Job:     ToS3ExportJobResponse(job),
+		Success: true,
+		Message: "Export started",
+	}, nil
+}
+
+func (h *ExportS3RequestHandler) execute(client *s3.Client, job *entities.S3ExportJob, bucket *entities.Bucket) {
+	ctx := context.Background()
+
+	failed := []models.FailedObjectResponse{}
+	manifest := []models.ExportManifestEntry{}
+	usedKeys := map[string]bool{}
+
+	files, err := h.dbContext.Files.Where(&entities.File{BucketId: bucket.Id}).ToList()
+	if err != nil {
+		job.Error = fmt.Sprintf("failed to list files: %v", err)
+	}
+
+	for i := range files {
+		file := &files[i]
+
+		key := job.Prefix + file.Name
+		if usedKeys[key] {
+			// Several files can share a name, keep them apart by ID
+			key = job.Prefix + file.Id.String() + "/" + file.Name
+		}
+		usedKeys[key] = true
+
+		entry, err := h.exportFile(ctx, client, job, file, key)
+		if err != nil {
+			log.Printf("Export %s: failed to export file %s: %v", job.Id, file.Id, err)
+			failed = append(failed, models.FailedObjectResponse{FileID: file.Id, Name: file.Name, Reason: err.Error()})
+			job.ObjectsFailed++
+			continue
+		}
+
+		manifest = append(manifest, *entry)
+		job.ObjectsExported++
+		job.BytesExported += entry.Size
+
+		if job.ObjectsExported%100 == 0 {
+			h.dbContext.S3ExportJobs.Update(*job)
+			h.dbContext.SaveChanges()
+		}
+	}
+
+	if job.Error == "" {
+		manifestData, _ := json.MarshalIndent(map[string]interface{}{
+			"bucket":      bucket.Name,
+			"bucket_id":   bucket.Id,
+			"exported_at": time.Now(),
+			"objects":     manifest,
+		}, "", "  ")
+
+		job.ManifestKey = job.Prefix + "manifest.json"
+		if err := client.PutObject(ctx, job.TargetBucket, job.ManifestKey, bytes.NewReader(manifestData), int64(len(manifestData)), "application/json", nil); err != nil {
+			job.Error = fmt.Sprintf("failed to upload manifest: %v", err)
+		}
+	}
+
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	job.Status = "completed"
+	if job.Error != "" {
+		job.Status = "failed"
+	}
+	failedJSON, _ := json.Marshal(failed)
+	job.FailedObjects = datatypes.JSON(failedJSON)
+
+	h.dbContext.S3ExportJobs.Update(*job)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		log.Printf("Export %s: failed to save final status: %v", job.Id, err)
+	}
+
+	log.Printf("Export %s %s: %d exported, %d failed", job.Id, job.Status, job.ObjectsExported, job.ObjectsFailed)
+}
+
+// exportFile uploads one file and verifies the stored copy against the checksums computed while streaming
+func (h *ExportS3RequestHandler) exportFile(ctx context.Context, client *s3.Client, job *entities.S3ExportJob, file *entities.File, key string) (*models.ExportManifestEntry, error) {
+	content, err := storage.OpenFile(ctx, h.dbContext, file)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	reader := io.TeeReader(utils.NewThrottledReader(content, job.BandwidthLimit), io.MultiWriter(md5Hash, sha256Hash))
+
+	err = client.PutObject(ctx, job.TargetBucket, key, reader, file.Size, file.MimeType, map[string]string{
+		"shbucket-file-id": file.Id.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	md5Sum := fmt.Sprintf("%x", md5Hash.Sum(nil))
+	sha256Sum := fmt.Sprintf("%x", sha256Hash.Sum(nil))
+
+	if file.Checksum != "" && file.Checksum != "stored-on-node" && file.Checksum != sha256Sum {
+		return nil, fmt.Errorf("source checksum mismatch: expected %s, read %s", file.Checksum, sha256Sum)
+	}
+
+	info, err := client.HeadObject(ctx, job.TargetBucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify uploaded object: %w", err)
+	}
+	if info.Size != file.Size {
+		return nil, fmt.Errorf("size mismatch after upload: expected %d, got %d", file.Size, info.Size)
+	}
+	// Single part uploads have the content MD5 as ETag unless the target encrypts with KMS
+	if !strings.Contains(info.ETag, "-") && len(info.ETag) == 32 && info.ETag != md5Sum {
+		return nil, fmt.Errorf("checksum mismatch after upload: expected md5 %s, got %s", md5Sum, info.ETag)
+	}
+
+	return &models.ExportManifestEntry{
+		FileID:         file.Id,
+		Key:            key,
+		Name:           file.Name,
+		OriginalName:   file.OriginalName,
+		Size:           file.Size,
+		MimeType:       file.MimeType,
+		SHA256:         sha256Sum,
+		MD5:            md5Sum,
+		CustomMetadata: utils.ConvertJSONToMap(file.Metadata.CustomMetadata),
+		CreatedAt:      file.CreatedAt,
+		UpdatedAt:      file.UpdatedAt,
+	}, nil
+}
+
+// ToS3ExportJobResponse converts an export job entity to its API response
+func ToS3ExportJobResponse(job *entities.S3ExportJob) models.S3ExportJobResponse {
+	failed := []models.FailedObjectResponse{}
+	json.Unmarshal(job.FailedObjects, &failed)
+
+	return models.S3ExportJobResponse{
+		ID:              job.Id,
+		BucketID:        job.BucketId,
+		TargetEndpoint:  job.TargetEndpoint,
+		TargetBucket:    job.TargetBucket,
+		Prefix:          job.Prefix,
+		BandwidthLimit:  job.BandwidthLimit,
+		Status:          job.Status,
+		ObjectsExported: job.ObjectsExported,
+		ObjectsFailed:   job.ObjectsFailed,
+		BytesExported:   job.BytesExported,
+		FailedObjects:   failed,
+		ManifestKey:     job.ManifestKey,
+		Error:           job.Error,
+		StartedAt:       job.StartedAt,
+		CompletedAt:     job.CompletedAt,
+	}
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetS3ExportJobCommand struct {
+	JobID uuid.UUID `json:"job_id" validate:"required"`
+}
+
+type GetS3ExportJobResponse struct {
+	Job     models.S3ExportJobResponse `json:"job"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type GetS3ExportJobRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetS3ExportJobRequestHandler(dbContext *persistence.AppDbContext) *GetS3ExportJobRequestHandler {
+	return &GetS3ExportJobRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *GetS3ExportJobRequestHandler) Handle(ctx context.Context, command *GetS3ExportJobCommand) (*GetS3ExportJobResponse, error) {
+	job, err := h.dbContext.S3ExportJobs.Where(&entities.S3ExportJob{Id: command.JobID}).FirstOrDefault()
+	if err != nil || job == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "export job not found")
+	}
+
+	return &GetS3ExportJobResponse{
+		Job:     ToS3ExportJobResponse(job),
+		Success: true,
+		Message: "Export job retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,69 @@
+package favorite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type AddFavoriteCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	FileID   uuid.UUID `json:"file_id"`
+	UserID   uuid.UUID `json:"user_id"`
+}
+
+type AddFavoriteResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type AddFavoriteRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewAddFavoriteRequestHandler(dbContext *persistence.AppDbContext) *AddFavoriteRequestHandler {
+	return &AddFavoriteRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *AddFavoriteRequestHandler) Handle(ctx context.Context, command *AddFavoriteCommand) (*AddFavoriteResponse, error) {
+	file, err := h.dbContext.Files.Where(&entities.File{
+		Id:       command.FileID,
+		BucketId: command.BucketID,
+	}).FirstOrDefault()
+	if err != nil || file == nil {
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
+	}
+
+	// Favoriting is idempotent
+	existing, err := h.dbContext.FavoriteFiles.Where(&entities.FavoriteFile{
+		UserId: command.UserID,
+		FileId: command.FileID,
+	}).FirstOrDefault()
+	if err == nil && existing != nil {
+		return &AddFavoriteResponse{
+			Success: true,
+			Message: "File is already a favorite",
+		}, nil
+	}
+
+	h.dbContext.FavoriteFiles.Add(entities.FavoriteFile{
+		UserId:   command.UserID,
+		FileId:   file.Id,
+		BucketId: file.BucketId,
+	})
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to add favorite: %w", err)
+	}
+
+	return &AddFavoriteResponse{
+		Success: true,
+		Message: "File added to favorites",
+	}, nil
+}
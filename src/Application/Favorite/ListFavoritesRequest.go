@@ -0,0 +1,64 @@
+package favorite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListFavoritesCommand struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type ListFavoritesResponse struct {
+	Favorites []models.FavoriteResponse `json:"favorites"`
+	Success   bool                      `json:"success"`
+	Message   string                    `json:"message"`
+}
+
+type ListFavoritesRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListFavoritesRequestHandler(dbContext *persistence.AppDbContext) *ListFavoritesRequestHandler {
+	return &ListFavoritesRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *ListFavoritesRequestHandler) Handle(ctx context.Context, command *ListFavoritesCommand) (*ListFavoritesResponse, error) {
+	favorites, err := h.dbContext.FavoriteFiles.Where(&entities.FavoriteFile{UserId: command.UserID}).
+		OrderByDescending("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch favorites: %w", err)
+	}
+
+	favoriteResponses := make([]models.FavoriteResponse, 0, len(favorites))
+	for _, favorite := range favorites {
+		file, err := h.dbContext.Files.Where(&entities.File{Id: favorite.FileId}).FirstOrDefault()
+		if err != nil || file == nil {
+			// The file has been deleted since it was favorited
+			continue
+		}
+
+		favoriteResponses = append(favoriteResponses, models.FavoriteResponse{
+			FileID:      file.Id,
+			BucketID:    file.BucketId,
+			Name:        file.Name,
+			Size:        file.Size,
+			MimeType:    file.MimeType,
+			FavoritedAt: favorite.CreatedAt,
+		})
+	}
+
+	return &ListFavoritesResponse{
+		Favorites: favoriteResponses,
+		Success:   true,
+		Message:   "Favorites retrieved successfully",
+	}, nil
+}
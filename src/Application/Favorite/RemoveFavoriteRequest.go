@@ -0,0 +1,52 @@
+package favorite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type RemoveFavoriteCommand struct {
+	FileID uuid.UUID `json:"file_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type RemoveFavoriteResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type RemoveFavoriteRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewRemoveFavoriteRequestHandler(dbContext *persistence.AppDbContext) *RemoveFavoriteRequestHandler {
+	return &RemoveFavoriteRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *RemoveFavoriteRequestHandler) Handle(ctx context.Context, command *RemoveFavoriteCommand) (*RemoveFavoriteResponse, error) {
+	favorite, err := h.dbContext.FavoriteFiles.Where(&entities.FavoriteFile{
+		UserId: command.UserID,
+		FileId: command.FileID,
+	}).FirstOrDefault()
+	if err != nil || favorite == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "favorite not found")
+	}
+
+	h.dbContext.FavoriteFiles.Remove(*favorite)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to remove favorite: %w", err)
+	}
+
+	return &RemoveFavoriteResponse{
+		Success: true,
+		Message: "File removed from favorites",
+	}, nil
+}
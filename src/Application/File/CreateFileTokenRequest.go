@@ -0,0 +1,89 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type CreateFileTokenCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	FileID   uuid.UUID `json:"-"`
+	// Name describes where the token is used, e.g. the site embedding the file
+	Name     string    `json:"name" validate:"max=100"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type CreateFileTokenResponse struct {
+	Token models.FileTokenResponse `json:"token"`
+	// Secret is only returned here, it can't be retrieved again
+	Secret  string `json:"secret"`
+	URL     string `json:"url"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type CreateFileTokenRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	events    *events.Publisher
+}
+
+func NewCreateFileTokenRequestHandler(dbContext *persistence.AppDbContext) *CreateFileTokenRequestHandler {
+	return &CreateFileTokenRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle issues a token granting read access to one file until it is revoked
+func (h *CreateFileTokenRequestHandler) Handle(ctx context.Context, command *CreateFileTokenCommand) (*CreateFileTokenResponse, error) {
+	file, err := loadTokenFile(h.dbContext, command.BucketID, command.FileID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, tokenHash, tokenPrefix, err := generateFileToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate file token: %w", err)
+	}
+
+	token := &entities.FileToken{
+		Id:          uuid.New(),
+		FileId:      file.Id,
+		BucketId:    file.BucketId,
+		Name:        command.Name,
+		TokenHash:   tokenHash,
+		TokenPrefix: tokenPrefix,
+		CreatedBy:   command.UserID,
+		CreatedAt:   time.Now(),
+	}
+	h.dbContext.FileTokens.Add(*token)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save file token: %w", err)
+	}
+
+	h.events.Publish(events.FileTokenCreated, file.BucketId, &file.Id, command.UserID, map[string]interface{}{
+		"name":         file.Name,
+		"token_id":     token.Id.String(),
+		"token_name":   token.Name,
+		"token_prefix": token.TokenPrefix,
+	})
+
+	return &CreateFileTokenResponse{
+		Token:   ToFileTokenResponse(token),
+		Secret:  secret,
+		URL:     fmt.Sprintf("%s/api/v1/file/%s/%s?token=%s", h.settings.BaseURL, file.BucketId, file.Id, secret),
+		Success: true,
+		Message: "File token created successfully",
+	}, nil
+}
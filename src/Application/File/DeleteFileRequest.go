@@ -3,19 +3,26 @@ package file
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"os"
-	"strings"
+	"log"
+	"time"
 	
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
 )
 
 type DeleteFileCommand struct {
 	FileID   uuid.UUID `json:"file_id"`
 	BucketID uuid.UUID `json:"bucket_id"`
 	UserID   uuid.UUID `json:"user_id"`
+	UserRole string    `json:"-"`
+	// OverrideLock lets an admin delete a file under retention or legal hold, the override is audited
+	OverrideLock bool `json:"-"`
 }
 
 type DeleteFileResponse struct {
@@ -24,12 +31,16 @@ type DeleteFileResponse struct {
 }
 
 type DeleteFileRequestHandler struct {
-	dbContext *persistence.AppDbContext
+	dbContext    *persistence.AppDbContext
+	derivedCache *storage.DerivedCache
+	events       *events.Publisher
 }
 
 func NewDeleteFileRequestHandler(dbContext *persistence.AppDbContext) *DeleteFileRequestHandler {
 	return &DeleteFileRequestHandler{
-		dbContext: dbContext,
+		dbContext:    dbContext,
+		derivedCache: storage.NewDerivedCache(config.GetSettings().DerivedCachePath),
+		events:       events.NewPublisher(dbContext),
 	}
 }
 
@@ -40,28 +51,33 @@ func (h *DeleteFileRequestHandler) Handle(ctx context.Context, command *DeleteFi
 		BucketId: command.BucketID,
 	}).FirstOrDefault()
 	if err != nil || file == nil {
-		return nil, fmt.Errorf("file not found")
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
 	}
 
 	// Find bucket using GoNtext static typing
 	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
 	if err != nil || bucket == nil {
-		return nil, fmt.Errorf("bucket not found")
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
 	}
 
-	if bucket.OwnerId != command.UserID && file.UploadedBy != command.UserID {
-		return nil, fmt.Errorf("unauthorized: insufficient permissions to delete file")
+	overriding := command.OverrideLock && command.UserRole == "admin"
+	if bucket.OwnerId != command.UserID && file.UploadedBy != command.UserID && !overriding {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: insufficient permissions to delete file")
 	}
 
-	// Delete physical file from storage
-	if err := h.deletePhysicalFile(file.Path); err != nil {
-		return nil, fmt.Errorf("failed to delete physical file: %w", err)
+	// Locked files are kept from everyone, owners included, unless an admin overrides the lock
+	if file.Lock.Active(time.Now()) {
+		if !command.OverrideLock {
+			return nil, ErrFileLocked
+		}
+		if !overriding {
+			return nil, ErrLockOverrideDenied
+		}
+		overrideLock(h.events, file, command.UserID, "delete")
 	}
 
-	// Delete from database using GoNtext
-	h.dbContext.Files.Remove(*file)
-	if err := h.dbContext.SaveChanges(); err != nil {
-		return nil, fmt.Errorf("failed to delete file record: %w", err)
+	if err := h.remove(ctx, file, command.UserID); err != nil {
+		return nil, err
 	}
 
 	return &DeleteFileResponse{
@@ -70,91 +86,62 @@ func (h *DeleteFileRequestHandler) Handle(ctx context.Context, command *DeleteFi
 	}, nil
 }
 
-func (h *DeleteFileRequestHandler) deletePhysicalFile(filePath string) error {
-	// Check if file is stored on a remote node
-	if strings.HasPrefix(filePath, "node://") {
-		return h.deleteFromNode(filePath)
+// remove deletes a file's content, its record and everything that refers to it, for a delete
+// actorID asked for or was allowed
+func (h *DeleteFileRequestHandler) remove(ctx context.Context, file *entities.File, actorID uuid.UUID) error {
+	// Delete physical file from storage. Snapshots hard-link local files, but node-stored
+	// content is shared with any snapshot referencing it and is kept until that snapshot is deleted
+	if storage.IsNodePath(file.Path) && h.referencedBySnapshot(file.Path) {
+		log.Printf("Keeping content of file %s for snapshots that reference it", file.Id)
+	} else if err := storage.RemoveFile(ctx, h.dbContext, file.Path); err != nil {
+		return fmt.Errorf("failed to delete physical file: %w", err)
 	}
-	
-	// Delete local file
-	if err := os.Remove(filePath); err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist, which is fine for our purposes
-			return nil
-		}
-		return fmt.Errorf("failed to remove file: %w", err)
+
+	// Drop any cached derived variants (resized images)
+	if err := h.derivedCache.Invalidate(file.Id); err != nil {
+		log.Printf("Warning: failed to remove cached variants of file %s: %v", file.Id, err)
 	}
-	
+
+	// Delete from database using GoNtext
+	h.dbContext.Files.Remove(*file)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return fmt.Errorf("failed to delete file record: %w", err)
+	}
+
+	removeFileReferences(h.dbContext.GetDB(), file.Id)
+
+	h.events.Publish(events.FileDeleted, file.BucketId, &file.Id, actorID, map[string]interface{}{
+		"name": file.Name,
+		"size": file.Size,
+	})
 	return nil
 }
 
-func (h *DeleteFileRequestHandler) deleteFromNode(filePath string) error {
-	// Extract node ID and file path from node:// URL
-	// Format: node://nodeID/bucketID/fileID
-	pathParts := strings.Split(strings.TrimPrefix(filePath, "node://"), "/")
-	if len(pathParts) < 3 {
-		return fmt.Errorf("invalid node file path format: %s", filePath)
-	}
-	
-	nodeID := pathParts[0]
-	bucketIDStr := pathParts[1] 
-	nodeFileID := pathParts[2]
-	
-	// Get bucket name from bucket ID
-	bucketID, err := uuid.Parse(bucketIDStr)
-	if err != nil {
-		return fmt.Errorf("invalid bucket ID in path: %w", err)
+// removeFileReferences removes what refers to a deleted file. Failures are logged, the file is
+// gone either way.
+func removeFileReferences(db *gorm.DB, fileID uuid.UUID) {
+	if err := db.Where(`"FileId" = ?`, fileID).Delete(&entities.VideoAsset{}).Error; err != nil {
+		log.Printf("Warning: failed to remove video processing state of file %s: %v", fileID, err)
 	}
-	
-	bucket, err := h.dbContext.Buckets.First(&entities.Bucket{Id: bucketID})
-	if err != nil {
-		return fmt.Errorf("bucket not found: %w", err)
-	}
-	
-	bucketName := bucket.Name
-	
-	// Get storage node info
-	nodeUUID, err := uuid.Parse(nodeID)
-	if err != nil {
-		return fmt.Errorf("invalid node ID: %w", err)
-	}
-	
-	storageNode, err := h.dbContext.StorageNodes.First(&entities.StorageNode{Id: nodeUUID})
-	if err != nil {
-		return fmt.Errorf("storage node not found: %w", err)
+
+	// Comments and favorites only make sense alongside the file they refer to
+	if err := db.Where(`"FileId" = ?`, fileID).Delete(&entities.FileComment{}).Error; err != nil {
+		log.Printf("Warning: failed to remove comments of file %s: %v", fileID, err)
 	}
-	
-	// Files are now stored using just the fileID on nodes
-	actualFileName := nodeFileID
-	
-	// Create DELETE request to the node's internal deletion endpoint
-	req, err := http.NewRequest("DELETE", 
-		fmt.Sprintf("%s/api/v1/internal/delete", storageNode.URL), 
-		nil)
-	if err != nil {
-		return fmt.Errorf("failed to create delete request: %w", err)
+	if err := db.Where(`"FileId" = ?`, fileID).Delete(&entities.FavoriteFile{}).Error; err != nil {
+		log.Printf("Warning: failed to remove favorites of file %s: %v", fileID, err)
 	}
-	
-	// Add query parameters for the file to delete
-	q := req.URL.Query()
-	q.Add("bucket_name", bucketName)
-	q.Add("file_name", actualFileName)
-	req.URL.RawQuery = q.Encode()
-	
-	// Add authentication header using the node's auth key
-	req.Header.Set("Authorization", "Bearer "+storageNode.AuthKey)
-	
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send delete request: %w", err)
+	if err := db.Where(`"FileId" = ?`, fileID).Delete(&entities.FileToken{}).Error; err != nil {
+		log.Printf("Warning: failed to remove tokens of file %s: %v", fileID, err)
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("node deletion failed with status: %d", resp.StatusCode)
+	// An alias left pointing at the deleted file could only ever answer 404
+	if err := db.Where(`"FileId" = ?`, fileID).Delete(&entities.FileAlias{}).Error; err != nil {
+		log.Printf("Warning: failed to remove aliases of file %s: %v", fileID, err)
 	}
-	
-	return nil
-}
\ No newline at end of file
+}
+
+// referencedBySnapshot reports whether any bucket snapshot still points at the stored content
+func (h *DeleteFileRequestHandler) referencedBySnapshot(path string) bool {
+	count, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{Path: path}).Count()
+	return err != nil || count > 0
+}
@@ -1,20 +1,27 @@
 package file
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
-	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Jobs"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Progress"
+	"shbucket/src/Infrastructure/Scanning"
+	"shbucket/src/Infrastructure/Storage"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
 
@@ -22,14 +29,27 @@ import (
 	"gorm.io/datatypes"
 )
 
+// ErrFileTooLarge is returned for uploads over the bucket's maximum file size
+var ErrFileTooLarge = apierror.New(apierror.CodeFileTooLarge, "file exceeds the bucket's maximum file size")
+
+// ErrSizeMismatch is returned for uploads whose content isn't as long as their declared size
+var ErrSizeMismatch = apierror.New(apierror.CodeSizeMismatch, "file content doesn't match its size")
+
+// ErrChecksumMismatch is returned for uploads whose content doesn't have the checksum the client sent
+var ErrChecksumMismatch = apierror.New(apierror.CodeChecksumMismatch, "file content doesn't match the expected checksum")
+
 type DistributedUploadCommand struct {
 	BucketID     uuid.UUID             `json:"bucket_id"`
 	File         *multipart.FileHeader `json:"-"`
 	FileReader   io.Reader             `json:"-"`
+	FileSize     int64                 `json:"-"` // size of FileReader's content when there is no File header
 	FileName     string                `json:"file_name"`
 	ContentType  string                `json:"content_type"`
 	Metadata     map[string]interface{} `json:"metadata"`
 	UploadedBy   uuid.UUID             `json:"uploaded_by"`
+	CustomerKey  *encryption.CustomerKey `json:"-"` // SSE-C key supplied with the request, never stored
+	ExpectedChecksum string             `json:"-"` // hex sha256 the client computed, the upload is refused when the content differs
+	Progress     *progress.Transfer    `json:"-"` // reports how far storing the content got, nil when no one follows the upload
 }
 
 type DistributedUploadResponse struct {
@@ -42,22 +62,70 @@ type DistributedUploadResponse struct {
 type DistributedUploadRequestHandler struct {
 	dbContext *persistence.AppDbContext
 	settings  *config.Settings
+	events    *events.Publisher
+	scanner   scanning.Scanner
+	scanErr   error // uploads are refused rather than stored unscanned when the scanner is misconfigured
 }
 
 func NewDistributedUploadRequestHandler(dbContext *persistence.AppDbContext) *DistributedUploadRequestHandler {
+	settings := config.GetSettings()
+	scanner, err := scanning.NewScanner(settings)
 	return &DistributedUploadRequestHandler{
 		dbContext: dbContext,
-		settings:  config.GetSettings(),
+		settings:  settings,
+		events:    events.NewPublisher(dbContext),
+		scanner:   scanner,
+		scanErr:   err,
 	}
 }
 
 func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *DistributedUploadCommand) (*DistributedUploadResponse, error) {
+	if h.scanErr != nil {
+		return nil, fmt.Errorf("upload scanning is misconfigured: %w", h.scanErr)
+	}
+	
+	// Register the upload so a shutdown waits for it instead of leaving a partial file or node copy
+	done, err := storage.BeginTransfer()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	// First check the master server storage
 	masterConfig, err := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get master configuration: %w", err)
 	}
-	fileSize := command.File.Size
+	fileSize := command.FileSize
+	if command.File != nil {
+		fileSize = command.File.Size
+	}
+
+	bucketPtr, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucketPtr == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	
+	bucket := *bucketPtr
+	
+	if limit := bucket.Settings.MaxFileSize; limit > 0 && fileSize > limit {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, limit)
+	}
+	
+	// Folder markers get the type S3 tools give them when the client doesn't send one
+	if command.ContentType == "" && IsFolderMarker(command.FileName, fileSize) {
+		command.ContentType = FolderMarkerContentType
+	}
+	
+	// A forced deletion would miss files added while it runs
+	if deleting, err := jobs.Active(h.dbContext, jobs.TypeBucketDelete, bucket.Id); err == nil && deleting {
+		return nil, apierror.New(apierror.CodeConflict, "bucket is being deleted")
+	}
+	
+	// A bucket with keyed objects refuses or replaces an upload of a name it holds
+	if err := checkOverwrite(ctx, h.dbContext, &bucket, command.FileName); err != nil {
+		return nil, err
+	}
 	
 	// Check if master has enough space
 	masterUsedStorage, err := h.dbContext.Files.SumField("Size")
@@ -66,22 +134,37 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 	}
 
 	masterFreeSpace := masterConfig.MaxStorage - int64(masterUsedStorage)
-	storageNode := (*models.StorageNodeResponse)(nil)
 	
 	// Generate file ID for storage path
 	fileID := uuid.New()
 	
-	if masterFreeSpace < fileSize {
-		var availableNode entities.StorageNode
-		availableNodePtr, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{
+	// Pick where the content goes before writing anything, so the pending upload can name it
+	var availableNode *entities.StorageNode
+	var filePath string
+	
+	if placement.Pinned(&bucket) {
+		// A pinned bucket's content goes only to its node or node group, never to the master
+		availableNode, err = placement.SelectNode(h.dbContext, &bucket, fileSize)
+		if err != nil {
+			return nil, fmt.Errorf("upload failed: %w", err)
+		}
+		filePath = fmt.Sprintf("node://%s/%s/%s", availableNode.Id.String(), command.BucketID.String(), fileID.String())
+	} else if fileSize > 0 && masterFreeSpace < fileSize {
+		// Empty files take no space, so they stay on the master even when it is full
+		nodes, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{
 			IsActive: true,
 			IsHealthy: true,
-		}).FirstOrDefault()
-		if err != nil || availableNodePtr == nil {
-			return nil, fmt.Errorf("upload failed: no active storage nodes available")
+		}).ToList()
+		// Nodes in maintenance keep what they hold but take nothing new
+		for i := range nodes {
+			if nodes[i].Placeable() {
+				availableNode = &nodes[i]
+				break
+			}
+		}
+		if err != nil || availableNode == nil {
+			return nil, apierror.New(apierror.CodeUnavailable, "upload failed: no active storage nodes available")
 		}
-		
-		availableNode = *availableNodePtr
 		
 		// Check if node has enough space
 		if availableNode.MaxStorage - availableNode.UsedStorage < fileSize {
@@ -89,85 +172,113 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 				masterFreeSpace, fileSize)
 		}
 		
-		// Upload to the storage node
-		success, err := h.uploadToNode(&availableNode, command, fileID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to upload to storage node: %w", err)
+		// File is stored on node, use bucket ID in path format: node://{nodeid}/{bucketid}/{fileid}
+		filePath = fmt.Sprintf("node://%s/%s/%s", availableNode.Id.String(), command.BucketID.String(), fileID.String())
+	} else {
+		// Get master storage path from config
+		storagePath  := masterConfig.StoragePath
+		if storagePath == "" {
+			return nil, apierror.New(apierror.CodeUnavailable, "storage_path not configured in master config")
 		}
 		
-		if !success {
-			return nil, fmt.Errorf("storage node rejected the upload")
+		// Create bucket directory if it doesn't exist
+		bucketDir := filepath.Join(storagePath, bucket.Name)
+		if err := os.MkdirAll(bucketDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create bucket directory: %w", err)
 		}
 		
-		// Update node storage usage
-		availableNode.UsedStorage += fileSize
-		h.dbContext.StorageNodes.Update(availableNode)
-		h.dbContext.SaveChanges()
+		// Set file path: storage_path/bucket_name/file_id
+		filePath = filepath.Join(bucketDir, fileID.String())
+	}
+	
+	// Phase one: record the upload as pending before any content is written. If the upload
+	// dies before the file record is committed, the upload cleanup worker removes the content.
+	pending := entities.PendingUpload{
+		Id:       fileID,
+		BucketId: command.BucketID,
+		Path:     filePath,
+		Size:     fileSize,
+	}
+	h.dbContext.PendingUploads.Add(pending)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to record pending upload: %w", err)
+	}
+	
+	// Content is scanned as it is stored, and the file only recorded once the scanner has passed it
+	content, scan := scanning.Begin(ctx, h.scanner, command.FileName, command.FileReader)
+	defer scan.Close()
+	command.FileReader = content
+	
+	// Followers see the content go to the master's disk or out to the node
+	if availableNode != nil {
+		command.Progress.Stage(progress.StageNode, fileSize)
+		command.Progress.Node(availableNode.Id)
+	} else {
+		command.Progress.Stage(progress.StageStoring, fileSize)
+	}
+	command.FileReader = command.Progress.Reader(command.FileReader)
+	
+	// Text-like content of compressing buckets is compressed, and content of encrypted buckets is
+	// encrypted, before it reaches the master's disk or a node
+	contentEncoding := storage.ContentEncodingFor(&bucket, command.ContentType)
+	if fileSize == 0 {
+		// Compressing nothing would only store the header of an empty stream
+		contentEncoding = ""
+	}
+	var fileEncryption entities.FileEncryption
+	var checksum string
+	var storedSize int64
+	var storageNode *models.StorageNodeResponse
+	
+	if availableNode != nil {
+		// Stream to the storage node, calculating the checksum on the way
+		checksum, storedSize, fileEncryption, err = storeOnNode(ctx, h.dbContext, &bucket, command.CustomerKey, contentEncoding, availableNode, storage.NodeUpload{
+			BucketID:    command.BucketID,
+			BucketName:  bucket.Name,
+			FileID:      fileID,
+			Name:        command.FileName,
+			ContentType: command.ContentType,
+		}, command.FileReader)
+		if err != nil {
+			h.abandon(ctx, pending)
+			return nil, err
+		}
 		
-		storageNodeResponse := &models.StorageNodeResponse{
+		storageNode = &models.StorageNodeResponse{
 			ID:          availableNode.Id,
 			Name:        availableNode.Name,
 			URL:         availableNode.URL,
+			PublicURL:   availableNode.PublicURL,
 			MaxStorage:  availableNode.MaxStorage,
-			UsedStorage: availableNode.UsedStorage,
+			UsedStorage: availableNode.UsedStorage + fileSize,
 			Priority:    availableNode.Priority,
+			Group:       availableNode.Group,
+			Zone:        availableNode.Zone,
 			IsActive:    availableNode.IsActive,
 			IsHealthy:   availableNode.IsHealthy,
 			CreatedAt:   availableNode.CreatedAt,
 			UpdatedAt:   availableNode.UpdatedAt,
 			LastPing:    availableNode.LastPing,
 		}
-		storageNode = storageNodeResponse
+	} else {
+		// Stream to disk, calculating the checksum on the way
+		checksum, storedSize, fileEncryption, err = storage.SaveBucketFile(h.dbContext, &bucket, command.CustomerKey, contentEncoding, filePath, command.FileReader)
+		if err != nil {
+			h.abandon(ctx, pending)
+			return nil, fmt.Errorf("failed to save file to disk: %w", err)
+		}
 	}
 	
-	// Create file record (stored on master regardless of where file is physically stored)
-	bucketPtr, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
-	if err != nil || bucketPtr == nil {
-		return nil, fmt.Errorf("bucket not found")
+	// Content that ended early, ran on or was corrupted on the way is refused rather than recorded
+	if err := checkReceived(storedSize, fileSize, checksum, command.ExpectedChecksum); err != nil {
+		h.abandon(ctx, pending)
+		return nil, err
 	}
 	
-	bucket := *bucketPtr
-	
-	// Save file to local storage if not uploaded to node
-	var filePath string
-	var checksum string
-	
-	if storageNode == nil {
-		// Get master storage path from config
-		// configData := utils.ConvertJSONToMap(masterConfig.ConfigData)
-		storagePath  := masterConfig.StoragePath
-		if storagePath == "" {
-			return nil, fmt.Errorf("storage_path not configured in master config")
-		}
-		
-		
-		// Create bucket directory if it doesn't exist
-		bucketDir := filepath.Join(storagePath, bucket.Name)
-		if err := os.MkdirAll(bucketDir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create bucket directory: %w", err)
-		}
-		
-		// Set file path: storage_path/bucket_name/file_id
-		filePath = filepath.Join(bucketDir, fileID.String())
-		
-		// Read file content for saving and checksum calculation
-		fileContent, err := io.ReadAll(command.FileReader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file content: %w", err)
-		}
-		
-		// Calculate checksum
-		hash := sha256.Sum256(fileContent)
-		checksum = fmt.Sprintf("%x", hash)
-		
-		// Save file to disk
-		if err := os.WriteFile(filePath, fileContent, 0644); err != nil {
-			return nil, fmt.Errorf("failed to save file to disk: %w", err)
-		}
-	} else {
-		// File is stored on node, use bucket ID in path format: node://{nodeid}/{bucketid}/{fileid}
-		filePath = fmt.Sprintf("node://%s/%s/%s", storageNode.ID.String(), command.BucketID.String(), fileID.String())
-		checksum = "stored-on-node"
+	verdict, err := scan.Wait()
+	if err != nil {
+		h.abandon(ctx, pending)
+		return nil, err
 	}
 	
 	customMetadata := command.Metadata
@@ -182,6 +293,7 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 	
 	customMetadataJSON, err := json.Marshal(customMetadata)
 	if err != nil {
+		h.abandon(ctx, pending)
 		return nil, fmt.Errorf("failed to marshal custom metadata: %w", err)
 	}
 	
@@ -201,7 +313,8 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 		MimeType:     command.ContentType,
 		Checksum:     checksum,
 		SecuredUrl:   securedURL,
-		Version:      1,
+		Version:      nextVersion(h.dbContext.GetDB(), &bucket, command.FileName),
+		Lock:         DefaultLock(&bucket, time.Now()),
 		AuthRule: entities.AuthRule{
 			Type:    bucket.AuthRule.Type,
 			Enabled: bucket.AuthRule.Enabled,
@@ -209,20 +322,50 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 		},
 		Metadata: entities.FileMetadata{
 			ContentType:        command.ContentType,
-			ContentEncoding:    "",
+			ContentEncoding:    contentEncoding,
 			ContentDisposition: "",
 			CacheControl:       "",
 			CustomMetadata:     datatypes.JSON(customMetadataJSON),
+			ScanStatus:         verdict.Status,
+			ScanSignature:      verdict.Signature,
+			ScannedAt:          verdict.ScannedAt,
 		},
+		Encryption: fileEncryption,
 		UploadedBy: command.UploadedBy,
 		// CreatedAt and UpdatedAt are automatically set by GORM autoCreateTime/autoUpdateTime tags
 	}
 	
+	// Phase two: commit the file record together with clearing the pending upload
 	h.dbContext.Files.Add(*file)
+	h.dbContext.PendingUploads.Remove(pending)
 	if err := h.dbContext.SaveChanges(); err != nil {
+		// Don't leave content behind that no file record points to
+		h.abandon(ctx, pending)
 		return nil, fmt.Errorf("failed to create file record: %w", err)
 	}
 	
+	replaceOlder(ctx, h.dbContext, &bucket, file, command.UploadedBy)
+	
+	// Node usage only counts content that a file record points to
+	if availableNode != nil {
+		availableNode.UsedStorage += fileSize
+		h.dbContext.StorageNodes.Update(*availableNode)
+		if err := h.dbContext.SaveChanges(); err != nil {
+			log.Printf("Warning: failed to update storage usage of node %s: %v", availableNode.Name, err)
+		}
+	}
+
+	if !file.Metadata.Quarantined() {
+		queueVideoProcessing(h.dbContext, &bucket, file)
+	}
+
+	h.events.Publish(events.FileUploaded, file.BucketId, &file.Id, command.UploadedBy, map[string]interface{}{
+		"name":      file.Name,
+		"size":      file.Size,
+		"mime_type": file.MimeType,
+		"checksum":  file.Checksum,
+	})
+	
 	fileResponse := models.FileResponse{
 		ID:           file.Id,
 		BucketID:     file.BucketId,
@@ -233,6 +376,8 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 		MimeType:     file.MimeType,
 		Checksum:     file.Checksum,
 		Version:      file.Version,
+		Encrypted:    file.Encryption.Encrypted(),
+		CustomerKeyMD5: file.Encryption.CustomerKeyMD5,
 		AuthRule: &models.AuthRuleResponse{
 			Type:    file.AuthRule.Type,
 			Enabled: file.AuthRule.Enabled,
@@ -244,6 +389,9 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 			ContentDisposition: file.Metadata.ContentDisposition,
 			CacheControl:       file.Metadata.CacheControl,
 			CustomMetadata:     utils.ConvertJSONToMap(file.Metadata.CustomMetadata),
+			ScanStatus:         file.Metadata.ScanStatus,
+			ScanSignature:      file.Metadata.ScanSignature,
+			ScannedAt:          file.Metadata.ScannedAt,
 		},
 		SecuredUrl:  file.SecuredUrl,
 		CreatedAt:  file.CreatedAt,
@@ -255,6 +403,9 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 	if storageNode != nil {
 		message = fmt.Sprintf("File uploaded successfully to storage node: %s", storageNode.Name)
 	}
+	if file.Metadata.Quarantined() {
+		message = fmt.Sprintf("File uploaded but quarantined, malware detected: %s", file.Metadata.ScanSignature)
+	}
 	
 	return &DistributedUploadResponse{
 		File:        fileResponse,
@@ -264,63 +415,46 @@ func (h *DistributedUploadRequestHandler) Handle(ctx context.Context, command *D
 	}, nil
 }
 
-func (h *DistributedUploadRequestHandler) uploadToNode(node *entities.StorageNode, command *DistributedUploadCommand, fileID uuid.UUID) (bool, error) {
-	// Create a buffer to store the file content for uploading to node
-	fileContent, err := io.ReadAll(command.FileReader)
-	if err != nil {
-		return false, fmt.Errorf("failed to read file content: %w", err)
-	}
-	
-	// Create multipart form data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	
-	// Add file field
-	fileWriter, err := writer.CreateFormFile("file", command.FileName)
-	if err != nil {
-		return false, err
+// abandon removes the content of an upload that failed before its file record was committed.
+// If the content can't be removed now, the pending upload stays for the upload cleanup worker.
+func (h *DistributedUploadRequestHandler) abandon(ctx context.Context, pending entities.PendingUpload) {
+	if err := storage.RemoveFile(ctx, h.dbContext, pending.Path); err != nil {
+		log.Printf("Warning: failed to remove content of abandoned upload %s, leaving it for cleanup: %v", pending.Id, err)
+		return
 	}
-	_, err = fileWriter.Write(fileContent)
-	if err != nil {
-		return false, err
+	if err := h.dbContext.GetDB().Delete(&entities.PendingUpload{}, `"Id" = ?`, pending.Id).Error; err != nil {
+		log.Printf("Warning: failed to clear pending upload %s: %v", pending.Id, err)
 	}
-	
-	// Get bucket name for the node
-	bucket, err := h.dbContext.Buckets.First(&entities.Bucket{Id: command.BucketID})
+}
+
+// storeOnNode compresses content with contentEncoding, encrypts it as the bucket or customer key
+// asks and streams it to node. The checksum and size returned are of the plaintext, taken as it is
+// sent.
+func storeOnNode(ctx context.Context, dbContext *persistence.AppDbContext, bucket *entities.Bucket, customerKey *encryption.CustomerKey, contentEncoding string, node *entities.StorageNode, upload storage.NodeUpload, content io.Reader) (string, int64, entities.FileEncryption, error) {
+	plain := storage.NewChecksumReader(content)
+	compressed, err := storage.Compress(contentEncoding, plain)
 	if err != nil {
-		return false, fmt.Errorf("bucket not found: %w", err)
+		return "", 0, entities.FileEncryption{}, fmt.Errorf("failed to compress file: %w", err)
 	}
-	
-	// Add metadata and required fields
-	metadataJSON, _ := json.Marshal(command.Metadata)
-	writer.WriteField("metadata", string(metadataJSON))
-	writer.WriteField("content_type", command.ContentType)
-	writer.WriteField("bucket_id", command.BucketID.String())
-	writer.WriteField("bucket_name", bucket.Name)
-	writer.WriteField("file_id", fileID.String())
-	writer.WriteField("filename", command.FileName)
-	
-	writer.Close()
-	
-	// Create HTTP request to storage node
-	req, err := http.NewRequest("POST", 
-		fmt.Sprintf("%s/api/v1/internal/upload", node.URL), 
-		body)
+	reader, enc, err := storage.EncryptContent(dbContext, bucket, customerKey, compressed)
 	if err != nil {
-		return false, err
+		return "", 0, entities.FileEncryption{}, fmt.Errorf("failed to encrypt file: %w", err)
 	}
-	
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", "Bearer "+node.AuthKey)
-	
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, err
+
+	if err := storage.UploadToNode(ctx, node.URL, node.AuthKey, upload, reader); err != nil {
+		return "", 0, entities.FileEncryption{}, fmt.Errorf("failed to upload to storage node: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated, nil
+	return plain.Checksum(), plain.Size(), enc, nil
 }
 
+// checkReceived refuses content that ended early or ran on, rather than record it under the size it
+// was announced with, and content corrupted on the way. An empty expectedChecksum isn't checked.
+func checkReceived(size, expectedSize int64, checksum, expectedChecksum string) error {
+	if size != expectedSize {
+		return fmt.Errorf("%w: received %d bytes, expected %d", ErrSizeMismatch, size, expectedSize)
+	}
+	if expectedChecksum != "" && checksum != expectedChecksum {
+		return fmt.Errorf("%w: received sha256 %s, expected %s", ErrChecksumMismatch, checksum, expectedChecksum)
+	}
+	return nil
+}
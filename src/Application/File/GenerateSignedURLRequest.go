@@ -2,16 +2,17 @@ package file
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 	
 	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Signing"
 )
 
 type GenerateSignedURLCommand struct {
@@ -32,12 +33,16 @@ type GenerateSignedURLResponse struct {
 type GenerateSignedURLRequestHandler struct {
 	dbContext *persistence.AppDbContext
 	settings  *config.Settings
+	events    *events.Publisher
+	keys      *signing.Keyring
 }
 
 func NewGenerateSignedURLRequestHandler(dbContext *persistence.AppDbContext) *GenerateSignedURLRequestHandler {
 	return &GenerateSignedURLRequestHandler{
 		dbContext: dbContext,
 		settings:  config.GetSettings(),
+		events:    events.NewPublisher(dbContext),
+		keys:      signing.Keys(dbContext),
 	}
 }
 
@@ -48,17 +53,14 @@ func (h *GenerateSignedURLRequestHandler) Handle(ctx context.Context, command *G
 		BucketId: command.BucketID,
 	}).FirstOrDefault()
 	if err != nil || file == nil {
-		return nil, fmt.Errorf("file not found")
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
 	}
 	// Get bucket information for the URL
 	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
 	if err != nil || bucket == nil {
-		return nil, fmt.Errorf("bucket not found")
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
 	}
 	
-	// Get signing secret from settings
-	signingSecret := h.settings.SignatureSecret
-	
 	// Calculate expiration time
 	expiresAt := time.Now().Add(time.Duration(command.ExpiresIn) * time.Second)
 	
@@ -67,8 +69,11 @@ func (h *GenerateSignedURLRequestHandler) Handle(ctx context.Context, command *G
 		command.BucketID.String(), 
 		command.FileID.String())
 	
-	// Generate HMAC signature
-	signature := h.generateHMAC(payload, signingSecret)
+	// Sign with the active signing key, whose ID the signature carries
+	signature, err := h.keys.Sign(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign URL: %w", err)
+	}
 	
 	// Check if signature already exists in database
 	existingSignedURL, err := h.dbContext.SignedURLs.Where(&entities.SignedURL{
@@ -116,6 +121,12 @@ func (h *GenerateSignedURLRequestHandler) Handle(ctx context.Context, command *G
 	if err := h.dbContext.SaveChanges(); err != nil {
 		return nil, fmt.Errorf("failed to store signature: %w", err)
 	}
+
+	h.events.Publish(events.FileShared, file.BucketId, &file.Id, command.UserID, map[string]interface{}{
+		"name":       file.Name,
+		"expires_at": expiresAt,
+		"single_use": command.SingleUse,
+	})
 	
 	// Generate signed URL using file endpoint with signature parameter
 	signedURL := fmt.Sprintf("%s/api/v1/file/%s/%s?signature=%s", 
@@ -132,41 +143,53 @@ func (h *GenerateSignedURLRequestHandler) Handle(ctx context.Context, command *G
 	}, nil
 }
 
-
-func (h *GenerateSignedURLRequestHandler) generateHMAC(payload, secret string) string {
-	hash := hmac.New(sha256.New, []byte(secret))
-	hash.Write([]byte(payload))
-	return base64.URLEncoding.EncodeToString(hash.Sum(nil))
-}
+// ErrSignedForOtherFile is returned for a valid signed URL presented for a file it wasn't issued for
+var ErrSignedForOtherFile = apierror.Forbidden("signed URL was issued for another file")
 
 // ValidateSignedURL validates a signed URL signature against the database
 // Now only needs the signature - gets bucketID, fileID, and expires from database
-func (h *GenerateSignedURLRequestHandler) ValidateSignedURL(signature string) (*entities.SignedURL, error) {
+func (h *GenerateSignedURLRequestHandler) ValidateSignedURL(ctx context.Context, signature string) (*entities.SignedURL, error) {
+	signedURL, _, err := h.validateSignedURL(ctx, signature)
+	return signedURL, err
+}
+
+// ValidateSignedURLForFile validates a signed URL signature like ValidateSignedURL and checks that it
+// was issued for the file being served
+func (h *GenerateSignedURLRequestHandler) ValidateSignedURLForFile(ctx context.Context, signature string, fileID uuid.UUID) (*entities.SignedURL, error) {
+	signedURL, file, err := h.validateSignedURL(ctx, signature)
+	if err != nil {
+		return nil, err
+	}
+	if file.Id != fileID {
+		return nil, ErrSignedForOtherFile
+	}
+	return signedURL, nil
+}
+
+// validateSignedURL validates a signed URL signature and returns it with the file it was issued for
+func (h *GenerateSignedURLRequestHandler) validateSignedURL(ctx context.Context, signature string) (*entities.SignedURL, *entities.File, error) {
 	// First, check if signature exists in database
 	signedURL, err := h.dbContext.SignedURLs.Where(&entities.SignedURL{
 		Signature: signature,
 	}).FirstOrDefault()
 	
 	if err != nil || signedURL == nil {
-		return nil, fmt.Errorf("signature not found in database")
+		return nil, nil, apierror.New(apierror.CodeForbidden, "signature not found in database")
 	}
 	
 	// Check if signature has expired (get expires from database)
 	if signedURL.ExpiresAt.Before(time.Now()) {
-		return nil, fmt.Errorf("signature has expired")
+		return nil, nil, apierror.New(apierror.CodeForbidden, "signature has expired")
 	}
 	
 	// Check if signature has already been used (only if single-use is enabled)
 	if signedURL.SingleUse && signedURL.Used {
-		return nil, fmt.Errorf("single-use signature has already been used")
+		return nil, nil, apierror.New(apierror.CodeForbidden, "single-use signature has already been used")
 	}
 	
-	// Get signing secret from settings
-	signingSecret := h.settings.SignatureSecret
-	
 	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Name: signedURL.BucketName}).FirstOrDefault()
 	if err != nil || bucket == nil {
-		return nil, fmt.Errorf("bucket not found for signature")
+		return nil, nil, apierror.New(apierror.CodeForbidden, "bucket not found for signature")
 	}
 	
 	file, err := h.dbContext.Files.Where(&entities.File{
@@ -174,22 +197,23 @@ func (h *GenerateSignedURLRequestHandler) ValidateSignedURL(signature string) (*
 		BucketId: bucket.Id,
 	}).FirstOrDefault()
 	if err != nil || file == nil {
-		return nil, fmt.Errorf("file not found for signature")
+		return nil, nil, apierror.New(apierror.CodeForbidden, "file not found for signature")
 	}
 	
 	payload := fmt.Sprintf("%s:%s", bucket.Id.String(), file.Id.String())
 	
-	// Generate expected signature
-	hash := hmac.New(sha256.New, []byte(signingSecret))
-	hash.Write([]byte(payload))
-	expectedSignature := base64.URLEncoding.EncodeToString(hash.Sum(nil))
-	
-	// Compare signatures for integrity check
-	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		return nil, fmt.Errorf("signature integrity check failed")
+	// Check the signature against the key it names, which verifies until it retires
+	if err := h.keys.Verify(ctx, payload, signature, signing.LegacyBase64); err != nil {
+		switch {
+		case errors.Is(err, signing.ErrRetired):
+			return nil, nil, apierror.New(apierror.CodeForbidden, "signature key has been retired")
+		case errors.Is(err, signing.ErrInvalid):
+			return nil, nil, apierror.New(apierror.CodeForbidden, "signature integrity check failed")
+		}
+		return nil, nil, err
 	}
 	
-	return signedURL, nil
+	return signedURL, file, nil
 }
 
 func (h *GenerateSignedURLRequestHandler) MarkSignatureAsUsed(signature string) error {
@@ -198,7 +222,7 @@ func (h *GenerateSignedURLRequestHandler) MarkSignatureAsUsed(signature string)
 	}).FirstOrDefault()
 	
 	if err != nil || signedURL == nil {
-		return fmt.Errorf("signature not found")
+		return apierror.New(apierror.CodeNotFound, "signature not found")
 	}
 	
 	// Only mark as used if it's a single-use URL
@@ -220,8 +244,8 @@ func (h *GenerateSignedURLRequestHandler) MarkSignatureAsUsed(signature string)
 }
 
 // GetFileInfoFromSignature returns file and bucket information from a signature
-func (h *GenerateSignedURLRequestHandler) GetFileInfoFromSignature(signature string) (*entities.File, *entities.Bucket, error) {
-	signedURL, err := h.ValidateSignedURL(signature)
+func (h *GenerateSignedURLRequestHandler) GetFileInfoFromSignature(ctx context.Context, signature string) (*entities.File, *entities.Bucket, error) {
+	signedURL, err := h.ValidateSignedURL(ctx, signature)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -229,7 +253,7 @@ func (h *GenerateSignedURLRequestHandler) GetFileInfoFromSignature(signature str
 	// Get bucket
 	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Name: signedURL.BucketName}).FirstOrDefault()
 	if err != nil || bucket == nil {
-		return nil, nil, fmt.Errorf("bucket not found")
+		return nil, nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
 	}
 	
 	// Get file
@@ -238,7 +262,7 @@ func (h *GenerateSignedURLRequestHandler) GetFileInfoFromSignature(signature str
 		BucketId: bucket.Id,
 	}).FirstOrDefault()
 	if err != nil || file == nil {
-		return nil, nil, fmt.Errorf("file not found")
+		return nil, nil, apierror.New(apierror.CodeFileNotFound, "file not found")
 	}
 	
 	return file, bucket, nil
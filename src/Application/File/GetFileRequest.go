@@ -6,15 +6,19 @@ import (
 	"time"
 	
 	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Durability"
+	"shbucket/src/Infrastructure/Headers"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
 )
 
 type GetFileCommand struct {
-	FileID   uuid.UUID `json:"file_id"`
-	BucketID uuid.UUID `json:"bucket_id"`
+	FileID      uuid.UUID `json:"file_id"`
+	BucketID    uuid.UUID `json:"bucket_id"`
+	Replication bool      `json:"-"` // include the replication state of the file
 }
 
 type GetFileResponse struct {
@@ -44,7 +48,7 @@ func (h *GetFileRequestHandler) Handle(ctx context.Context, command *GetFileComm
 	}
 
 	if(file == nil) {
-		return nil, fmt.Errorf("file not found")
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
 	}
 	now := time.Now()
 	file.AccessedAt = &now
@@ -60,6 +64,8 @@ func (h *GetFileRequestHandler) Handle(ctx context.Context, command *GetFileComm
 		MimeType:     file.MimeType,
 		Checksum:     file.Checksum,
 		Version:      file.Version,
+		Encrypted:    file.Encryption.Encrypted(),
+		CustomerKeyMD5: file.Encryption.CustomerKeyMD5,
 		AuthRule: &models.AuthRuleResponse{
 			Type:    file.AuthRule.Type,
 			Enabled: file.AuthRule.Enabled,
@@ -70,7 +76,11 @@ func (h *GetFileRequestHandler) Handle(ctx context.Context, command *GetFileComm
 			ContentEncoding:    file.Metadata.ContentEncoding,
 			ContentDisposition: file.Metadata.ContentDisposition,
 			CacheControl:       file.Metadata.CacheControl,
+			Headers:            headers.Decode(file.Metadata.Headers),
 			CustomMetadata:     utils.ConvertJSONToMap(file.Metadata.CustomMetadata),
+			ScanStatus:         file.Metadata.ScanStatus,
+			ScanSignature:      file.Metadata.ScanSignature,
+			ScannedAt:          file.Metadata.ScannedAt,
 		},
 		SecuredUrl:  file.SecuredUrl,
 		CreatedAt:  file.CreatedAt,
@@ -78,6 +88,15 @@ func (h *GetFileRequestHandler) Handle(ctx context.Context, command *GetFileComm
 		AccessedAt: file.AccessedAt,
 	}
 
+	if command.Replication {
+		replication, err := durability.Status(h.dbContext, file)
+		if err != nil {
+			return nil, err
+		}
+		fileResponse.Replication = &replication
+	}
+	fileResponse.Lock = ToFileLockResponse(file, now)
+
 	return &GetFileResponse{
 		File:    fileResponse,
 		Success: true,
@@ -0,0 +1,58 @@
+package file
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListFileTokensCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	FileID   uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type ListFileTokensResponse struct {
+	Tokens  []models.FileTokenResponse `json:"tokens"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type ListFileTokensRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListFileTokensRequestHandler(dbContext *persistence.AppDbContext) *ListFileTokensRequestHandler {
+	return &ListFileTokensRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists a file's tokens, revoked ones included, without their secrets
+func (h *ListFileTokensRequestHandler) Handle(ctx context.Context, command *ListFileTokensCommand) (*ListFileTokensResponse, error) {
+	file, err := loadTokenFile(h.dbContext, command.BucketID, command.FileID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := h.dbContext.FileTokens.Where(&entities.FileToken{FileId: file.Id}).OrderByDescending("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file tokens: %w", err)
+	}
+
+	responses := make([]models.FileTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, ToFileTokenResponse(&tokens[i]))
+	}
+
+	return &ListFileTokensResponse{
+		Tokens:  responses,
+		Success: true,
+		Message: "File tokens retrieved successfully",
+	}, nil
+}
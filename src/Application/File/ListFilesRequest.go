@@ -3,27 +3,50 @@ package file
 import (
 	"context"
 	"fmt"
+	"strings"
 	
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Headers"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
 )
 
 type ListFilesCommand struct {
-	BucketID uuid.UUID `json:"bucket_id"`
-	Page     int       `json:"page"`
-	Limit    int       `json:"limit"`
+	BucketID uuid.UUID        `json:"bucket_id"`
+	Page     int              `json:"page"`
+	Limit    int              `json:"limit"`
+	List     models.ListQuery `json:"list"`
+	MimeType string           `json:"mime_type"` // exact, or a type/* prefix
+	MinSize  *int64           `json:"min_size"`
+	MaxSize  *int64           `json:"max_size"`
+}
+
+// fileSort is what files can be sorted by, newest first when unspecified
+var fileSort = utils.ListSort{
+	Columns: map[string]string{
+		"name":       "Name",
+		"size":       "Size",
+		"mime_type":  "MimeType",
+		"version":    "Version",
+		"created_at": "CreatedAt",
+		"updated_at": "UpdatedAt",
+	},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
 }
 
 type ListFilesResponse struct {
-	Files   []models.FileResponse `json:"files"`
-	Total   int64                 `json:"total"`
-	Page    int                   `json:"page"`
-	Limit   int                   `json:"limit"`
-	Success bool                  `json:"success"`
-	Message string                `json:"message"`
+	Files      []models.FileResponse `json:"files"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	NextCursor string                `json:"next_cursor,omitempty"` // continues the list after this page, when sorted by created_at
+	Success    bool                  `json:"success"`
+	Message    string                `json:"message"`
 }
 
 type ListFilesRequestHandler struct {
@@ -48,17 +71,30 @@ func (h *ListFilesRequestHandler) Handle(ctx context.Context, command *ListFiles
 	}
 
 	offset := (page - 1) * limit
+	if command.List.Cursor != "" {
+		// The cursor says where the page starts
+		offset = 0
+	}
 
-	total, err := h.dbContext.Files.Where(&entities.File{BucketId: command.BucketID}).Count()
+	query, err := filesQuery(h.dbContext.GetDB().WithContext(ctx), command)
 	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, fmt.Errorf("failed to count files: %w", err)
 	}
 
-	files, err := h.dbContext.Files.Where(&entities.File{BucketId: command.BucketID}).
-		Skip(offset).Take(limit).ToList()
-	if err != nil {
+	var files []entities.File
+	if err := query.Session(&gorm.Session{}).Offset(offset).Limit(limit).Find(&files).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch files: %w", err)
 	}
+	nextCursor := ""
+	if len(files) > 0 {
+		last := files[len(files)-1]
+		nextCursor = utils.NextListCursor(command.List, fileSort, len(files) == limit, last.CreatedAt, last.Id)
+	}
 
 	fileResponses := make([]models.FileResponse, len(files))
 	for i, file := range files {
@@ -72,6 +108,8 @@ func (h *ListFilesRequestHandler) Handle(ctx context.Context, command *ListFiles
 			MimeType:     file.MimeType,
 			Checksum:     file.Checksum,
 			Version:      file.Version,
+			Encrypted:    file.Encryption.Encrypted(),
+			CustomerKeyMD5: file.Encryption.CustomerKeyMD5,
 			AuthRule: &models.AuthRuleResponse{
 				Type:    file.AuthRule.Type,
 				Enabled: file.AuthRule.Enabled,
@@ -82,7 +120,11 @@ func (h *ListFilesRequestHandler) Handle(ctx context.Context, command *ListFiles
 				ContentEncoding:    file.Metadata.ContentEncoding,
 				ContentDisposition: file.Metadata.ContentDisposition,
 				CacheControl:       file.Metadata.CacheControl,
+				Headers:            headers.Decode(file.Metadata.Headers),
 				CustomMetadata:     utils.ConvertJSONToMap(file.Metadata.CustomMetadata),
+				ScanStatus:         file.Metadata.ScanStatus,
+				ScanSignature:      file.Metadata.ScanSignature,
+				ScannedAt:          file.Metadata.ScannedAt,
 			},
 			SecuredUrl:  file.SecuredUrl,
 			CreatedAt:  file.CreatedAt,
@@ -92,11 +134,35 @@ func (h *ListFilesRequestHandler) Handle(ctx context.Context, command *ListFiles
 	}
 
 	return &ListFilesResponse{
-		Files:   fileResponses,
-		Total:   total,
-		Page:    page,
-		Limit:   limit,
-		Success: true,
-		Message: "Files retrieved successfully",
+		Files:      fileResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		NextCursor: nextCursor,
+		Success:    true,
+		Message:    "Files retrieved successfully",
 	}, nil
-}
\ No newline at end of file
+}
+
+// filesQuery selects the files of the command's bucket that its filters match, sorted as it asks
+// but not paged
+func filesQuery(db *gorm.DB, command *ListFilesCommand) (*gorm.DB, error) {
+	query := db.Model(&entities.File{}).Where(`"BucketId" = ?`, command.BucketID)
+	if command.MimeType != "" {
+		if prefix, ok := strings.CutSuffix(command.MimeType, "/*"); ok {
+			query = query.Where(`"MimeType" LIKE ? ESCAPE '\'`, utils.PrefixPattern(prefix+"/"))
+		} else {
+			query = query.Where(`"MimeType" = ?`, command.MimeType)
+		}
+	}
+	if command.MinSize != nil {
+		query = query.Where(`"Size" >= ?`, *command.MinSize)
+	}
+	if command.MaxSize != nil {
+		query = query.Where(`"Size" <= ?`, *command.MaxSize)
+	}
+	if command.MinSize != nil && command.MaxSize != nil && *command.MinSize > *command.MaxSize {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "min_size must not exceed max_size")
+	}
+	return utils.ApplyListQuery(query, command.List, fileSort, "Name")
+}
@@ -0,0 +1,246 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+type PrecheckUploadCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	// FileName is the name the file is stored under when identical content is found
+	FileName    string                 `json:"file_name" validate:"required,max=1024"`
+	Checksum    string                 `json:"checksum" validate:"required,len=64,hexadecimal"` // sha256 of the content, hex encoded
+	Size        int64                  `json:"size" validate:"min=0"`
+	ContentType string                 `json:"content_type"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	UploadedBy  uuid.UUID              `json:"-"`
+}
+
+type PrecheckUploadResponse struct {
+	// Exists reports whether identical content was found and the file recorded without an upload
+	Exists  bool                 `json:"exists"`
+	File    *models.FileResponse `json:"file,omitempty"`
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+}
+
+type PrecheckUploadRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	events    *events.Publisher
+}
+
+func NewPrecheckUploadRequestHandler(dbContext *persistence.AppDbContext) *PrecheckUploadRequestHandler {
+	return &PrecheckUploadRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle looks for content identical to the file the client is about to upload in the bucket, by
+// checksum and size. When there is some, the file is recorded under its name sharing that content
+// and nothing needs uploading; otherwise the client uploads the file as usual.
+// Only content on the master's disk can be shared, it is hard-linked like snapshots do. Content on
+// storage nodes has no checksum recorded, and content encrypted with a customer-provided key or
+// quarantined is never reused.
+func (h *PrecheckUploadRequestHandler) Handle(ctx context.Context, command *PrecheckUploadCommand) (*PrecheckUploadResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if limit := bucket.Settings.MaxFileSize; limit > 0 && command.Size > limit {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, limit)
+	}
+	if deleting, err := jobs.Active(h.dbContext, jobs.TypeBucketDelete, bucket.Id); err == nil && deleting {
+		return nil, apierror.New(apierror.CodeConflict, "bucket is being deleted")
+	}
+	if err := checkOverwrite(ctx, h.dbContext, bucket, command.FileName); err != nil {
+		return nil, err
+	}
+
+	notFound := &PrecheckUploadResponse{
+		Success: true,
+		Message: "No identical content in the bucket, upload the file",
+	}
+
+	candidates, err := identicalContent(h.dbContext.GetDB().WithContext(ctx), bucket.Id, strings.ToLower(command.Checksum), command.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up identical content: %w", err)
+	}
+
+	var source *entities.File
+	for i := range candidates {
+		if candidates[i].Metadata.Quarantined() {
+			continue
+		}
+		// The content may be gone from disk while its record remains
+		if _, err := os.Stat(candidates[i].Path); err == nil {
+			source = &candidates[i]
+			break
+		}
+	}
+	if source == nil {
+		return notFound, nil
+	}
+
+	masterConfig, err := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil || masterConfig == nil || masterConfig.StoragePath == "" {
+		return nil, apierror.New(apierror.CodeUnavailable, "storage_path not configured in master config")
+	}
+	bucketDir := filepath.Join(masterConfig.StoragePath, bucket.Name)
+	if err := os.MkdirAll(bucketDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bucket directory: %w", err)
+	}
+
+	fileID := uuid.New()
+	filePath := filepath.Join(bucketDir, fileID.String())
+
+	// Recorded as pending first, so a link left by a failure is cleaned up like an abandoned upload
+	pending := entities.PendingUpload{
+		Id:       fileID,
+		BucketId: bucket.Id,
+		Path:     filePath,
+		Size:     source.Size,
+	}
+	h.dbContext.PendingUploads.Add(pending)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to record pending upload: %w", err)
+	}
+
+	if err := storage.LinkFile(source.Path, filePath, 0644); err != nil {
+		log.Printf("Warning: failed to link content of file %s for precheck: %v", source.Id, err)
+		h.abandon(ctx, pending)
+		return notFound, nil
+	}
+
+	contentType := command.ContentType
+	if contentType == "" {
+		contentType = source.MimeType
+	}
+
+	// Stored content is the same, so it keeps the source's encoding, encryption and scan verdict
+	file := &entities.File{
+		Id:           fileID,
+		BucketId:     bucket.Id,
+		Name:         command.FileName,
+		OriginalName: command.FileName,
+		Path:         filePath,
+		Size:         source.Size,
+		MimeType:     contentType,
+		Checksum:     source.Checksum,
+		SecuredUrl:   fmt.Sprintf("%s/api/v1/file/%s/%s", h.settings.BaseURL, bucket.Id.String(), fileID.String()),
+		Version:      nextVersion(h.dbContext.GetDB(), bucket, command.FileName),
+		Lock:         DefaultLock(bucket, time.Now()),
+		AuthRule: entities.AuthRule{
+			Type:    bucket.AuthRule.Type,
+			Enabled: bucket.AuthRule.Enabled,
+			Config:  bucket.AuthRule.Config,
+		},
+		Metadata: entities.FileMetadata{
+			ContentType:     contentType,
+			ContentEncoding: source.Metadata.ContentEncoding,
+			CustomMetadata:  createJSONFromMetadata(command.Metadata),
+			ScanStatus:      source.Metadata.ScanStatus,
+			ScanSignature:   source.Metadata.ScanSignature,
+			ScannedAt:       source.Metadata.ScannedAt,
+		},
+		Encryption: source.Encryption,
+		UploadedBy: command.UploadedBy,
+	}
+
+	h.dbContext.Files.Add(*file)
+	h.dbContext.PendingUploads.Remove(pending)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		h.abandon(ctx, pending)
+		return nil, fmt.Errorf("failed to create file record: %w", err)
+	}
+
+	replaceOlder(ctx, h.dbContext, bucket, file, command.UploadedBy)
+	queueVideoProcessing(h.dbContext, bucket, file)
+
+	h.events.Publish(events.FileUploaded, file.BucketId, &file.Id, command.UploadedBy, map[string]interface{}{
+		"name":        file.Name,
+		"size":        file.Size,
+		"mime_type":   file.MimeType,
+		"checksum":    file.Checksum,
+		"linked_from": source.Id,
+	})
+
+	fileResponse := models.FileResponse{
+		ID:             file.Id,
+		BucketID:       file.BucketId,
+		Name:           file.Name,
+		OriginalName:   file.OriginalName,
+		Path:           file.Path,
+		Size:           file.Size,
+		MimeType:       file.MimeType,
+		Checksum:       file.Checksum,
+		Version:        file.Version,
+		Encrypted:      file.Encryption.Encrypted(),
+		CustomerKeyMD5: file.Encryption.CustomerKeyMD5,
+		AuthRule: &models.AuthRuleResponse{
+			Type:    file.AuthRule.Type,
+			Enabled: file.AuthRule.Enabled,
+			Config:  utils.ConvertJSONToMap(file.AuthRule.Config),
+		},
+		Metadata: models.FileMetadataResponse{
+			ContentType:     file.Metadata.ContentType,
+			ContentEncoding: file.Metadata.ContentEncoding,
+			CustomMetadata:  utils.ConvertJSONToMap(file.Metadata.CustomMetadata),
+			ScanStatus:      file.Metadata.ScanStatus,
+			ScanSignature:   file.Metadata.ScanSignature,
+			ScannedAt:       file.Metadata.ScannedAt,
+		},
+		SecuredUrl: file.SecuredUrl,
+		CreatedAt:  file.CreatedAt,
+		UpdatedAt:  file.UpdatedAt,
+	}
+
+	return &PrecheckUploadResponse{
+		Exists:  true,
+		File:    &fileResponse,
+		Success: true,
+		Message: "Identical content found, file stored without uploading",
+	}, nil
+}
+
+// identicalContent lists the files of a bucket stored on the master with the given checksum and
+// size, latest first. Content encrypted with a customer's key can't be shared.
+func identicalContent(db *gorm.DB, bucketID uuid.UUID, checksum string, size int64) ([]entities.File, error) {
+	var candidates []entities.File
+	err := db.Where(`"BucketId" = ? AND "Checksum" = ? AND "Size" = ?`, bucketID, checksum, size).
+		Where(`"Path" NOT LIKE ? AND COALESCE("encryption_CustomerKeyMD5", '') = ''`, "node://%").
+		Order(`"CreatedAt" DESC`).Find(&candidates).Error
+	return candidates, err
+}
+
+// abandon removes a link made for a file that wasn't recorded. If it can't be removed now, the
+// pending upload stays for the upload cleanup worker.
+func (h *PrecheckUploadRequestHandler) abandon(ctx context.Context, pending entities.PendingUpload) {
+	if err := storage.RemoveFile(ctx, h.dbContext, pending.Path); err != nil {
+		log.Printf("Warning: failed to remove linked content of %s, leaving it for cleanup: %v", pending.Id, err)
+		return
+	}
+	if err := h.dbContext.GetDB().Delete(&entities.PendingUpload{}, `"Id" = ?`, pending.Id).Error; err != nil {
+		log.Printf("Warning: failed to clear pending upload %s: %v", pending.Id, err)
+	}
+}
@@ -0,0 +1,335 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+// RelocateToMaster is the relocation target naming the master's own storage
+const RelocateToMaster = "master"
+
+var (
+	// ErrInvalidRelocateTarget is returned for a target that is neither "master" nor a node ID
+	ErrInvalidRelocateTarget = apierror.New(apierror.CodeInvalidRequest, `target must be "master" or a storage node ID`)
+	// ErrAlreadyThere is returned for relocating a file to where it is already stored
+	ErrAlreadyThere = apierror.New(apierror.CodeConflict, "file is already stored there")
+	// ErrTargetUnavailable is returned for a target that can't take content: a node that is
+	// inactive, unhealthy or failed, a master without storage, or one the bucket's placement rules out
+	ErrTargetUnavailable = apierror.New(apierror.CodeConflict, "target can't hold the file")
+	// ErrTargetFull is returned when the target has no room for the file
+	ErrTargetFull = apierror.New(apierror.CodeInsufficientStorage, "target has no room for the file")
+	// ErrRelocateChecksum is returned when the copy read back from the target doesn't match the file
+	ErrRelocateChecksum = apierror.New(apierror.CodeBadGateway, "checksum mismatch on the relocated copy")
+	// ErrFileChanged is returned when the file was overwritten or moved while it was being copied
+	ErrFileChanged = apierror.New(apierror.CodeConflict, "file changed while it was being relocated")
+)
+
+type RelocateFileCommand struct {
+	FileID uuid.UUID `json:"-"`
+	UserID uuid.UUID `json:"-"`
+	// Target is "master" or the ID of a storage node
+	Target string `json:"target" validate:"required"`
+	// Bandwidth limits how fast the content is copied, nil copies it as fast as it goes
+	Bandwidth *storage.Bandwidth `json:"-"`
+}
+
+type RelocateFileResponse struct {
+	FileID   uuid.UUID `json:"file_id"`
+	Source   string    `json:"source"` // "master" or the node ID the content was on
+	Target   string    `json:"target"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Checksum string    `json:"checksum"`
+	// References is how many files and snapshot copies pointed at the content and were moved with it
+	References int64 `json:"references"`
+}
+
+type RelocateFileRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewRelocateFileRequestHandler(dbContext *persistence.AppDbContext) *RelocateFileRequestHandler {
+	return &RelocateFileRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// relocation is where a file's content is copied to
+type relocation struct {
+	node *entities.StorageNode // nil for the master
+	path string
+}
+
+// Handle moves a file's stored content to the master or a storage node: the content is copied as
+// stored, read back from the target and verified, every record pointing at it is switched to the
+// copy, and the source is freed
+func (h *RelocateFileRequestHandler) Handle(ctx context.Context, command *RelocateFileCommand) (*RelocateFileResponse, error) {
+	file, err := h.dbContext.Files.Where(&entities.File{Id: command.FileID}).FirstOrDefault()
+	if err != nil || file == nil {
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: file.BucketId}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+
+	target, err := h.target(command.Target, bucket, file)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := storage.BeginTransfer()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	if err := h.copy(ctx, bucket, file, target, command.Bandwidth); err != nil {
+		return nil, err
+	}
+	if err := h.verify(ctx, file, target); err != nil {
+		storage.RemoveFile(ctx, h.dbContext, target.path)
+		return nil, err
+	}
+
+	references, err := switchPath(h.dbContext.GetDB().WithContext(ctx), file, target)
+	if err != nil {
+		storage.RemoveFile(ctx, h.dbContext, target.path)
+		return nil, err
+	}
+
+	// Every record points at the copy now, a source left behind only wastes space
+	if err := storage.RemoveFile(ctx, h.dbContext, file.Path); err != nil {
+		log.Printf("Warning: failed to free %s after relocating file %s: %v", file.Path, file.Id, err)
+	}
+
+	log.Printf("Audit: user %s relocated file %s (%s) in bucket %s from %s to %s",
+		command.UserID, file.Id, file.Name, file.BucketId, location(file.Path), location(target.path))
+
+	return &RelocateFileResponse{
+		FileID:     file.Id,
+		Source:     location(file.Path),
+		Target:     location(target.path),
+		Path:       target.path,
+		Size:       file.Size,
+		Checksum:   file.Checksum,
+		References: references,
+	}, nil
+}
+
+// target checks the requested target can take the file and works out where its copy goes
+func (h *RelocateFileRequestHandler) target(name string, bucket *entities.Bucket, file *entities.File) (*relocation, error) {
+	if name == RelocateToMaster {
+		if !storage.IsNodePath(file.Path) {
+			return nil, ErrAlreadyThere
+		}
+		if !placement.Allows(bucket, nil) {
+			return nil, fmt.Errorf("%w: the bucket is pinned to %s", ErrTargetUnavailable, placement.Describe(bucket))
+		}
+
+		masterConfig, err := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+		if err != nil || masterConfig == nil || masterConfig.StoragePath == "" {
+			return nil, fmt.Errorf("%w: storage_path not configured in master config", ErrTargetUnavailable)
+		}
+		used, err := h.dbContext.Files.SumField("Size")
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate master storage usage: %w", err)
+		}
+		if masterConfig.MaxStorage-int64(used) < file.Size {
+			return nil, ErrTargetFull
+		}
+
+		bucketDir := filepath.Join(masterConfig.StoragePath, bucket.Name)
+		if err := os.MkdirAll(bucketDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create bucket directory: %w", err)
+		}
+		return &relocation{path: filepath.Join(bucketDir, file.Id.String())}, nil
+	}
+
+	nodeID, err := uuid.Parse(name)
+	if err != nil {
+		return nil, ErrInvalidRelocateTarget
+	}
+	node, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{Id: nodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return nil, apierror.New(apierror.CodeNodeNotFound, "storage node not found")
+	}
+	if storage.IsNodePath(file.Path) {
+		if source, err := storage.ParseNodePath(file.Path); err == nil && source.NodeID == node.Id {
+			return nil, ErrAlreadyThere
+		}
+	}
+	if !node.Placeable() {
+		return nil, fmt.Errorf("%w: storage node %s is %s", ErrTargetUnavailable, node.Name, node.Status())
+	}
+	if !placement.Allows(bucket, node) {
+		return nil, fmt.Errorf("%w: the bucket is pinned to %s", ErrTargetUnavailable, placement.Describe(bucket))
+	}
+	if node.MaxStorage-node.UsedStorage < file.Size {
+		return nil, ErrTargetFull
+	}
+	return &relocation{
+		node: node,
+		path: fmt.Sprintf("node://%s/%s/%s", node.Id.String(), bucket.Id.String(), file.Id.String()),
+	}, nil
+}
+
+// copy writes the file's content to the target as it is stored, so its compression and
+// encryption, customer-provided keys included, carry over unchanged
+func (h *RelocateFileRequestHandler) copy(ctx context.Context, bucket *entities.Bucket, file *entities.File, target *relocation, bandwidth *storage.Bandwidth) error {
+	stored, err := storage.OpenPath(ctx, h.dbContext, file.Path, file.Name)
+	if err != nil {
+		return err
+	}
+	defer stored.Close()
+	content := bandwidth.Reader(ctx, stored)
+
+	if target.node == nil {
+		if _, _, err := storage.SaveFile(target.path, content); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = storage.UploadToNode(ctx, target.node.URL, target.node.AuthKey, storage.NodeUpload{
+		BucketID:    bucket.Id,
+		BucketName:  bucket.Name,
+		FileID:      file.Id,
+		Name:        file.Name,
+		ContentType: file.MimeType,
+	}, content)
+	if err != nil {
+		return fmt.Errorf("failed to upload to storage node: %w", err)
+	}
+	return nil
+}
+
+// verify reads the copy back from the target and checks it against the file's checksum. Content
+// the server can't decrypt on its own, or of a file without a checksum, is compared as stored
+// with the source instead.
+func (h *RelocateFileRequestHandler) verify(ctx context.Context, file *entities.File, target *relocation) error {
+	// A cached copy of node content would stand in for what the target actually holds
+	if nodePath, err := storage.ParseNodePath(target.path); err == nil {
+		storage.DefaultNodeCache().Invalidate(nodePath.BucketID, nodePath.FileID)
+	}
+
+	if file.Checksum != "" && !file.Encryption.CustomerEncrypted() {
+		stored, err := storage.OpenEncrypted(ctx, h.dbContext, target.path, file.Name, file.Encryption, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read back relocated copy: %w", err)
+		}
+		plain, err := storage.Decompress(file.Metadata.ContentEncoding, stored)
+		if err != nil {
+			stored.Close()
+			return fmt.Errorf("failed to read back relocated copy: %w", err)
+		}
+		defer plain.Close()
+
+		checksum, err := contentChecksum(plain)
+		if err != nil {
+			return fmt.Errorf("failed to read back relocated copy: %w", err)
+		}
+		if checksum != file.Checksum {
+			return ErrRelocateChecksum
+		}
+		return nil
+	}
+
+	sourceChecksum, err := h.storedChecksum(ctx, file.Path, file.Name)
+	if err != nil {
+		return err
+	}
+	targetChecksum, err := h.storedChecksum(ctx, target.path, file.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read back relocated copy: %w", err)
+	}
+	if sourceChecksum != targetChecksum {
+		return ErrRelocateChecksum
+	}
+	return nil
+}
+
+// storedChecksum hashes content as it is stored at path
+func (h *RelocateFileRequestHandler) storedChecksum(ctx context.Context, path, name string) (string, error) {
+	content, err := storage.OpenPath(ctx, h.dbContext, path, name)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	return contentChecksum(content)
+}
+
+// switchPath points the file, and every other file and snapshot copy sharing its content, at the
+// copy and moves the content's size between the nodes' usage. It returns how many records moved.
+func switchPath(db *gorm.DB, file *entities.File, target *relocation) (int64, error) {
+	var references int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// The file's own row only moves while it still points at the content that was copied
+		moved := tx.Model(&entities.File{}).Where(`"Id" = ? AND "Path" = ?`, file.Id, file.Path).Update("Path", target.path)
+		if moved.Error != nil {
+			return fmt.Errorf("failed to update file record: %w", moved.Error)
+		}
+		if moved.RowsAffected == 0 {
+			return ErrFileChanged
+		}
+		references = moved.RowsAffected
+
+		shared := tx.Model(&entities.File{}).Where(`"Path" = ?`, file.Path).Update("Path", target.path)
+		if shared.Error != nil {
+			return fmt.Errorf("failed to update file records: %w", shared.Error)
+		}
+		references += shared.RowsAffected
+
+		snapshots := tx.Model(&entities.SnapshotFile{}).Where(`"Path" = ?`, file.Path).Update("Path", target.path)
+		if snapshots.Error != nil {
+			return fmt.Errorf("failed to update snapshots: %w", snapshots.Error)
+		}
+		references += snapshots.RowsAffected
+
+		if nodePath, err := storage.ParseNodePath(file.Path); err == nil {
+			if err := tx.Model(&entities.StorageNode{}).Where(`"Id" = ?`, nodePath.NodeID).
+				Update("UsedStorage", gorm.Expr(persistence.Greatest(tx)+`("UsedStorage" - ?, 0)`, file.Size)).Error; err != nil {
+				return fmt.Errorf("failed to update node usage: %w", err)
+			}
+		}
+		if target.node != nil {
+			if err := tx.Model(&entities.StorageNode{}).Where(`"Id" = ?`, target.node.Id).
+				Update("UsedStorage", gorm.Expr(`"UsedStorage" + ?`, file.Size)).Error; err != nil {
+				return fmt.Errorf("failed to update node usage: %w", err)
+			}
+		}
+		return nil
+	})
+	return references, err
+}
+
+// location names where content at path is stored: "master" or the ID of its node
+func location(path string) string {
+	if nodePath, err := storage.ParseNodePath(path); err == nil {
+		return nodePath.NodeID.String()
+	}
+	return RelocateToMaster
+}
+
+// contentChecksum returns the hex sha256 of everything read from r
+func contentChecksum(r io.Reader) (string, error) {
+	reader := storage.NewChecksumReader(r)
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return "", err
+	}
+	return reader.Checksum(), nil
+}
@@ -0,0 +1,75 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type RevokeFileTokenCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	FileID   uuid.UUID `json:"-"`
+	TokenID  uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type RevokeFileTokenResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type RevokeFileTokenRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewRevokeFileTokenRequestHandler(dbContext *persistence.AppDbContext) *RevokeFileTokenRequestHandler {
+	return &RevokeFileTokenRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle revokes a file token, requests using it are refused from then on. The record is kept for auditing.
+func (h *RevokeFileTokenRequestHandler) Handle(ctx context.Context, command *RevokeFileTokenCommand) (*RevokeFileTokenResponse, error) {
+	file, err := loadTokenFile(h.dbContext, command.BucketID, command.FileID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := h.dbContext.FileTokens.Where(&entities.FileToken{Id: command.TokenID, FileId: file.Id}).FirstOrDefault()
+	if err != nil || token == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "file token not found")
+	}
+	if token.RevokedAt != nil {
+		return &RevokeFileTokenResponse{
+			Success: true,
+			Message: "File token was already revoked",
+		}, nil
+	}
+
+	now := time.Now()
+	token.RevokedAt = &now
+	h.dbContext.FileTokens.Update(*token)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to revoke file token: %w", err)
+	}
+
+	h.events.Publish(events.FileTokenRevoked, file.BucketId, &file.Id, command.UserID, map[string]interface{}{
+		"name":         file.Name,
+		"token_id":     token.Id.String(),
+		"token_prefix": token.TokenPrefix,
+	})
+
+	return &RevokeFileTokenResponse{
+		Success: true,
+		Message: "File token revoked successfully",
+	}, nil
+}
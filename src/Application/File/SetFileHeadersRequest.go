@@ -0,0 +1,107 @@
+package file
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type SetFileHeadersCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	FileID   uuid.UUID `json:"-"`
+	// CacheControl and ContentDisposition override the defaults files are served with, e.g.
+	// "public, max-age=86400" and "attachment"; a disposition without a filename gets the file's name
+	CacheControl       string `json:"cache_control" validate:"max=1024"`
+	ContentDisposition string `json:"content_disposition" validate:"max=1024"`
+	// Headers are other response headers served with the file: Content-Language, Expires, Link,
+	// X-Robots-Tag, X-Frame-Options, Content-Encoding for content uploaded already encoded, and X-
+	// headers of your own
+	Headers map[string]string `json:"headers"`
+}
+
+type SetFileHeadersResponse struct {
+	CacheControl       string            `json:"cache_control,omitempty"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	Headers            map[string]string `json:"headers"`
+	Success            bool              `json:"success"`
+	Message            string            `json:"message"`
+}
+
+type SetFileHeadersRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewSetFileHeadersRequestHandler(dbContext *persistence.AppDbContext) *SetFileHeadersRequestHandler {
+	return &SetFileHeadersRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle replaces the response headers a file version is served with. Cache-Control and
+// Content-Disposition given among the headers are kept as the file's metadata fields.
+func (h *SetFileHeadersRequestHandler) Handle(ctx context.Context, command *SetFileHeadersCommand) (*SetFileHeadersResponse, error) {
+	file, err := h.dbContext.Files.Where(&entities.File{
+		Id:       command.FileID,
+		BucketId: command.BucketID,
+	}).FirstOrDefault()
+	if err != nil || file == nil {
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
+	}
+
+	set := make(map[string]string, len(command.Headers)+2)
+	for name, value := range command.Headers {
+		set[name] = value
+	}
+	for name, value := range map[string]string{headers.CacheControl: command.CacheControl, headers.ContentDisposition: command.ContentDisposition} {
+		if value != "" {
+			if _, ok := set[name]; ok {
+				return nil, fmt.Errorf("%w: %s is set twice", headers.ErrInvalidHeader, name)
+			}
+			set[name] = value
+		}
+	}
+	normalized, err := headers.Normalize(set)
+	if err != nil {
+		return nil, err
+	}
+	// Content compressed at rest is decoded or sent with its own encoding, it can't declare another
+	if _, ok := normalized[headers.ContentEncoding]; ok && file.Metadata.ContentEncoding != "" {
+		return nil, fmt.Errorf("%w: the file is stored compressed by the bucket, Content-Encoding can't be set", headers.ErrInvalidHeader)
+	}
+
+	cacheControl := normalized[headers.CacheControl]
+	contentDisposition := normalized[headers.ContentDisposition]
+	delete(normalized, headers.CacheControl)
+	delete(normalized, headers.ContentDisposition)
+
+	if err := saveHeaders(h.dbContext.GetDB().WithContext(ctx), file, cacheControl, contentDisposition, normalized); err != nil {
+		return nil, err
+	}
+
+	return &SetFileHeadersResponse{
+		CacheControl:       cacheControl,
+		ContentDisposition: contentDisposition,
+		Headers:            normalized,
+		Success:            true,
+		Message:            "File headers updated successfully",
+	}, nil
+}
+
+// saveHeaders stores the response headers served with a file
+func saveHeaders(db *gorm.DB, file *entities.File, cacheControl, contentDisposition string, other map[string]string) error {
+	if err := db.Model(file).Updates(map[string]interface{}{
+		"metadata_CacheControl":       cacheControl,
+		"metadata_ContentDisposition": contentDisposition,
+		"metadata_Headers":            headers.Encode(other),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to save file headers: %w", err)
+	}
+	return nil
+}
@@ -8,11 +8,14 @@ import (
 	"io"
 	"mime/multipart"
 	"path/filepath"
+	"time"
 	
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 	"shbucket/src/Utils"
@@ -37,26 +40,28 @@ type UploadFileResponse struct {
 type UploadFileRequestHandler struct {
 	dbContext *persistence.AppDbContext
 	settings  *config.Settings
+	events    *events.Publisher
 }
 
 func NewUploadFileRequestHandler(dbContext *persistence.AppDbContext) *UploadFileRequestHandler {
 	return &UploadFileRequestHandler{
 		dbContext: dbContext,
 		settings:  config.GetSettings(),
+		events:    events.NewPublisher(dbContext),
 	}
 }
 
 func (h *UploadFileRequestHandler) Handle(ctx context.Context, command *UploadFileCommand) (*UploadFileResponse, error) {
 	bucketPtr, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
 	if err != nil || bucketPtr == nil {
-		return nil, fmt.Errorf("bucket not found")
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
 	}
 	
 	bucket := *bucketPtr
 
 	fileSize := command.File.Size
 	if bucket.Settings.MaxFileSize > 0 && fileSize > bucket.Settings.MaxFileSize {
-		return nil, fmt.Errorf("file size exceeds maximum allowed size")
+		return nil, apierror.New(apierror.CodeFileTooLarge, "file size exceeds maximum allowed size")
 	}
 
 	fileExtension := filepath.Ext(command.FileName)
@@ -69,13 +74,13 @@ func (h *UploadFileRequestHandler) Handle(ctx context.Context, command *UploadFi
 			}
 		}
 		if !allowed {
-			return nil, fmt.Errorf("file extension not allowed")
+			return nil, apierror.New(apierror.CodeInvalidRequest, "file extension not allowed")
 		}
 	}
 
 	for _, ext := range bucket.Settings.BlockedExtensions {
 		if ext == fileExtension {
-			return nil, fmt.Errorf("file extension is blocked")
+			return nil, apierror.New(apierror.CodeInvalidRequest, "file extension is blocked")
 		}
 	}
 
@@ -104,7 +109,8 @@ func (h *UploadFileRequestHandler) Handle(ctx context.Context, command *UploadFi
 		MimeType:     command.ContentType,
 		Checksum:     checksum,
 		SecuredUrl:   securedURL,
-		Version:      1,
+		Version:      nextVersion(h.dbContext.GetDB(), &bucket, command.FileName),
+		Lock:         DefaultLock(&bucket, time.Now()),
 		AuthRule: entities.AuthRule{
 			Type:    bucket.AuthRule.Type,
 			Enabled: bucket.AuthRule.Enabled,
@@ -125,6 +131,15 @@ func (h *UploadFileRequestHandler) Handle(ctx context.Context, command *UploadFi
 		return nil, fmt.Errorf("failed to create file record: %w", err)
 	}
 
+	queueVideoProcessing(h.dbContext, &bucket, file)
+
+	h.events.Publish(events.FileUploaded, file.BucketId, &file.Id, command.UploadedBy, map[string]interface{}{
+		"name":      file.Name,
+		"size":      file.Size,
+		"mime_type": file.MimeType,
+		"checksum":  file.Checksum,
+	})
+
 	fileResponse := models.FileResponse{
 		ID:           file.Id,
 		BucketID:     file.BucketId,
@@ -135,6 +150,8 @@ func (h *UploadFileRequestHandler) Handle(ctx context.Context, command *UploadFi
 		MimeType:     file.MimeType,
 		Checksum:     file.Checksum,
 		Version:      file.Version,
+		Encrypted:    file.Encryption.Encrypted(),
+		CustomerKeyMD5: file.Encryption.CustomerKeyMD5,
 		AuthRule: &models.AuthRuleResponse{
 			Type:    file.AuthRule.Type,
 			Enabled: file.AuthRule.Enabled,
@@ -0,0 +1,80 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRemoveFileReferences removes what refers to a deleted file and leaves other files' alone
+func TestRemoveFileReferences(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID, fileID, otherID := uuid.New(), uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{fileID, otherID} {
+		comment := entities.FileComment{FileId: id, BucketId: bucketID, AuthorId: uuid.New(), Body: "looks good"}
+		if err := db.Create(&comment).Error; err != nil {
+			t.Fatal(err)
+		}
+		favorite := entities.FavoriteFile{UserId: uuid.New(), FileId: id, BucketId: bucketID}
+		if err := db.Create(&favorite).Error; err != nil {
+			t.Fatal(err)
+		}
+		asset := entities.VideoAsset{FileId: id, BucketId: bucketID, Status: "ready"}
+		if err := db.Create(&asset).Error; err != nil {
+			t.Fatal(err)
+		}
+		token := entities.FileToken{FileId: id, BucketId: bucketID, TokenHash: id.String(), TokenPrefix: "shf_", CreatedBy: uuid.New()}
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatal(err)
+		}
+		alias := entities.FileAlias{BucketId: bucketID, Name: id.String(), FileId: id, UpdatedBy: uuid.New()}
+		if err := db.Create(&alias).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removeFileReferences(db, fileID)
+
+	var comments []entities.FileComment
+	if err := db.Find(&comments).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].FileId != otherID {
+		t.Errorf("comments left = %+v, want only the other file's", comments)
+	}
+
+	var assets []entities.VideoAsset
+	if err := db.Find(&assets).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || assets[0].FileId != otherID {
+		t.Errorf("video assets left = %+v, want only the other file's", assets)
+	}
+
+	var favorites []entities.FavoriteFile
+	if err := db.Find(&favorites).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(favorites) != 1 || favorites[0].FileId != otherID {
+		t.Errorf("favorites left = %+v, want only the other file's", favorites)
+	}
+
+	var tokens []entities.FileToken
+	if err := db.Find(&tokens).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].FileId != otherID {
+		t.Errorf("file tokens left = %+v, want only the other file's", tokens)
+	}
+
+	var aliases []entities.FileAlias
+	if err := db.Find(&aliases).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 1 || aliases[0].FileId != otherID {
+		t.Errorf("aliases left = %+v, want only the other file's", aliases)
+	}
+}
@@ -0,0 +1,220 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/NodeAgent"
+	"shbucket/src/Infrastructure/NodeClient"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// emptySHA256 is the checksum of empty content
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// TestStoreOnNodeEmptyFile sends a zero-byte upload to a node agent the way the distributed
+// upload does and reads it back from the node
+func TestStoreOnNodeEmptyFile(t *testing.T) {
+	node := startNodeAgent(t)
+	bucket := entities.Bucket{Id: uuid.New(), Name: "photos"}
+	upload := storage.NodeUpload{
+		BucketID:    bucket.Id,
+		BucketName:  bucket.Name,
+		FileID:      uuid.New(),
+		Name:        "albums/",
+		ContentType: FolderMarkerContentType,
+	}
+
+	checksum, size, enc, err := storeOnNode(context.Background(), nil, &bucket, nil, "", node, upload, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("storeOnNode() = %v", err)
+	}
+	if checksum != emptySHA256 {
+		t.Errorf("checksum = %s, want %s", checksum, emptySHA256)
+	}
+	if size != 0 {
+		t.Errorf("size = %d, want 0", size)
+	}
+	if enc.Encrypted() {
+		t.Errorf("empty content of a plain bucket was encrypted")
+	}
+	if err := checkReceived(size, 0, checksum, emptySHA256); err != nil {
+		t.Errorf("checkReceived() = %v, want nil", err)
+	}
+
+	content, _, err := nodeclient.Default().Fetch(context.Background(), nodeclient.Node{URL: node.URL, AuthKey: node.AuthKey}, internalapi.FileRequest{
+		BucketID: upload.BucketID,
+		FileID:   upload.FileID,
+		Filename: upload.Name,
+	})
+	if err != nil {
+		t.Fatalf("failed to read the file back from the node: %v", err)
+	}
+	defer content.Close()
+	if data, err := io.ReadAll(content); err != nil || len(data) != 0 {
+		t.Errorf("node content = %q, %v, want empty", data, err)
+	}
+}
+
+// TestCheckReceived checks uploads are refused when their content isn't as long as announced, empty
+// content included
+func TestCheckReceived(t *testing.T) {
+	tests := []struct {
+		name             string
+		size, expected   int64
+		checksum, wanted string
+		err              error
+	}{
+		{"empty", 0, 0, emptySHA256, "", nil},
+		{"empty with checksum", 0, 0, emptySHA256, emptySHA256, nil},
+		{"empty announced as content", 0, 5, emptySHA256, "", ErrSizeMismatch},
+		{"content announced as empty", 5, 0, "", "", ErrSizeMismatch},
+		{"empty with another checksum", 0, 0, emptySHA256, strings.Repeat("0", 64), ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReceived(tt.size, tt.expected, tt.checksum, tt.wanted)
+			if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("checkReceived() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestFolderMarkers stores a folder marker on the master's disk, lists it with the files of its
+// folder and reads it back
+func TestFolderMarkers(t *testing.T) {
+	db := sqlitetest.Open(t)
+	user := entities.User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	bucket := entities.Bucket{Name: "photos", OwnerId: user.Id}
+	if err := db.Create(&bucket).Error; err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+
+	if !IsFolderMarker("albums/", 0) || IsFolderMarker("albums/", 1) || IsFolderMarker("albums", 0) {
+		t.Fatalf("IsFolderMarker() doesn't take only empty files named with a trailing / for markers")
+	}
+	dir := t.TempDir()
+	store := func(name, mimeType, content string) entities.File {
+		t.Helper()
+		path := filepath.Join(dir, uuid.NewString())
+		checksum, size, _, err := storage.SaveBucketFile(nil, &bucket, nil, "", path, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to save %s: %v", name, err)
+		}
+		file := entities.File{
+			BucketId:     bucket.Id,
+			Name:         name,
+			OriginalName: name,
+			Path:         path,
+			Size:         size,
+			MimeType:     mimeType,
+			Checksum:     checksum,
+			UploadedBy:   user.Id,
+		}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to record %s: %v", name, err)
+		}
+		return file
+	}
+	marker := store("albums/", FolderMarkerContentType, "")
+	store("albums/cat.png", "image/png", "not really a png")
+	store("cover.png", "image/png", "not really a png either")
+
+	list := func(command ListFilesCommand) []string {
+		t.Helper()
+		command.BucketID = bucket.Id
+		query, err := filesQuery(db, &command)
+		if err != nil {
+			t.Fatalf("filesQuery() = %v", err)
+		}
+		var names []string
+		if err := query.Pluck("Name", &names).Error; err != nil {
+			t.Fatalf("failed to list files: %v", err)
+		}
+		return names
+	}
+	byName := models.ListQuery{SortBy: "name", Order: "asc"}
+	if got := list(ListFilesCommand{List: models.ListQuery{SortBy: "name", Order: "asc", Name: "albums/"}}); strings.Join(got, " ") != "albums/ albums/cat.png" {
+		t.Errorf("files named albums/ = %v, want the marker and the file in it", got)
+	}
+	if got := list(ListFilesCommand{List: byName, MimeType: FolderMarkerContentType}); strings.Join(got, " ") != "albums/" {
+		t.Errorf("files of type %s = %v, want the marker", FolderMarkerContentType, got)
+	}
+	empty := int64(0)
+	if got := list(ListFilesCommand{List: byName, MaxSize: &empty}); strings.Join(got, " ") != "albums/" {
+		t.Errorf("empty files = %v, want the marker", got)
+	}
+
+	var stored entities.File
+	if err := db.First(&stored, `"Id" = ?`, marker.Id).Error; err != nil {
+		t.Fatalf("failed to read the marker back: %v", err)
+	}
+	if stored.Size != 0 || stored.Checksum != emptySHA256 || stored.MimeType != FolderMarkerContentType {
+		t.Errorf("marker = size %d, checksum %s, type %s, want an empty %s", stored.Size, stored.Checksum, stored.MimeType, FolderMarkerContentType)
+	}
+	content, err := storage.OpenPath(context.Background(), nil, stored.Path, stored.Name)
+	if err != nil {
+		t.Fatalf("failed to open the marker's content: %v", err)
+	}
+	defer content.Close()
+	if data, err := io.ReadAll(content); err != nil || len(data) != 0 {
+		t.Errorf("marker content = %q, %v, want empty", data, err)
+	}
+}
+
+// startNodeAgent runs a node agent storing in a temporary directory until the test ends
+func startNodeAgent(t *testing.T) *entities.StorageNode {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+
+	dir := t.TempDir()
+	cfg := &nodeagent.Config{Host: "127.0.0.1", Port: strconv.Itoa(addr.Port), StoragePath: dir}
+	store, err := nodeagent.OpenStore(filepath.Join(dir, "node.db"))
+	if err != nil {
+		t.Fatalf("OpenStore() = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	authKey := uuid.NewString()
+	server, err := nodeagent.NewServer(cfg, store, authKey)
+	if err != nil {
+		t.Fatalf("NewServer() = %v", err)
+	}
+	go server.Listen()
+	t.Cleanup(func() { server.Shutdown(time.Second) })
+
+	node := &entities.StorageNode{Id: uuid.New(), Name: "node-1", URL: "http://" + addr.String(), AuthKey: authKey}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(20 * time.Millisecond) {
+		resp, err := http.Get(node.URL + internalapi.HealthPath)
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("node agent on %s didn't start: %v", addr, err)
+		}
+	}
+	return node
+}
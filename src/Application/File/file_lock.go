@@ -0,0 +1,57 @@
+package file
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Models"
+)
+
+// ErrFileLocked is returned for deleting, overwriting or renaming a file under retention or legal hold
+var ErrFileLocked = apierror.New(apierror.CodeFileLocked, "file is locked by object lock")
+
+// ErrObjectLockDisabled is returned for locking a file in a bucket without object lock
+var ErrObjectLockDisabled = apierror.New(apierror.CodeInvalidRequest, "object lock isn't turned on for the bucket")
+
+// ErrLockOverrideDenied is returned when anyone but an admin asks to override a file lock
+var ErrLockOverrideDenied = apierror.New(apierror.CodeForbidden, "only admins can override a file lock")
+
+// DefaultLock is the lock a new upload to bucket gets, its default retention counted from now
+func DefaultLock(bucket *entities.Bucket, now time.Time) entities.FileLock {
+	if !bucket.Settings.ObjectLock || bucket.Settings.DefaultRetentionDays <= 0 {
+		return entities.FileLock{}
+	}
+	retainUntil := now.AddDate(0, 0, bucket.Settings.DefaultRetentionDays)
+	return entities.FileLock{RetainUntil: &retainUntil}
+}
+
+// ToFileLockResponse describes the lock of a file, nil for a file that was never locked
+func ToFileLockResponse(file *entities.File, now time.Time) *models.FileLockResponse {
+	if file.Lock.RetainUntil == nil && !file.Lock.LegalHold {
+		return nil
+	}
+	return &models.FileLockResponse{
+		RetainUntil: file.Lock.RetainUntil,
+		LegalHold:   file.Lock.LegalHold,
+		Locked:      file.Lock.Active(now),
+	}
+}
+
+// overrideLock records an admin going past the lock of a file, both in the bucket event log and
+// the server log, so every override can be audited
+func overrideLock(publisher *events.Publisher, file *entities.File, actorID uuid.UUID, action string) {
+	log.Printf("Audit: user %s overrode the lock of file %s (%s, version %d) in bucket %s to %s",
+		actorID, file.Id, file.Name, file.Version, file.BucketId, action)
+	publisher.Publish(events.FileLockOverridden, file.BucketId, &file.Id, actorID, map[string]interface{}{
+		"name":         file.Name,
+		"version":      file.Version,
+		"action":       action,
+		"retain_until": file.Lock.RetainUntil,
+		"legal_hold":   file.Lock.LegalHold,
+	})
+}
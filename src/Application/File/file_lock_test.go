@@ -0,0 +1,32 @@
+package file
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestSaveLock stores the retention and legal hold of a file version
+func TestSaveLock(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	file := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "/data/photos/a"}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	retainUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	if err := saveLock(db, &file, entities.FileLock{RetainUntil: &retainUntil, LegalHold: true}); err != nil {
+		t.Fatalf("saveLock() = %v", err)
+	}
+
+	var stored entities.File
+	if err := db.First(&stored, `"Id" = ?`, file.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Lock.LegalHold || stored.Lock.RetainUntil == nil || !stored.Lock.RetainUntil.Equal(retainUntil) {
+		t.Errorf("file lock after saveLock() = %+v, want a legal hold retained until %s", stored.Lock, retainUntil)
+	}
+}
@@ -0,0 +1,61 @@
+package file
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// FileTokenPrefix starts every file token, telling them apart from API keys (shb_)
+const FileTokenPrefix = "shf_"
+
+// loadTokenFile returns a file whose tokens the user may manage: the bucket owner and bucket admins, the uploader and admins
+func loadTokenFile(dbContext *persistence.AppDbContext, bucketID, fileID, userID uuid.UUID, userRole string) (*entities.File, error) {
+	file, err := dbContext.Files.Where(&entities.File{Id: fileID, BucketId: bucketID}).FirstOrDefault()
+	if err != nil || file == nil {
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found")
+	}
+
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+
+	if file.UploadedBy != userID && !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or the uploader can manage file tokens")
+	}
+	return file, nil
+}
+
+// generateFileToken returns a new token with the hash and prefix stored for it
+func generateFileToken() (token, tokenHash, tokenPrefix string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	token = FileTokenPrefix + hex.EncodeToString(bytes)
+	hash := sha256.Sum256([]byte(token))
+	return token, hex.EncodeToString(hash[:]), token[:12], nil
+}
+
+func ToFileTokenResponse(token *entities.FileToken) models.FileTokenResponse {
+	return models.FileTokenResponse{
+		ID:          token.Id,
+		FileID:      token.FileId,
+		BucketID:    token.BucketId,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		CreatedBy:   token.CreatedBy,
+		CreatedAt:   token.CreatedAt,
+		LastUsedAt:  token.LastUsedAt,
+		RevokedAt:   token.RevokedAt,
+	}
+}
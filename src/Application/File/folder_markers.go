@@ -0,0 +1,13 @@
+package file
+
+import "strings"
+
+// FolderMarkerContentType is the content type of folder markers without one of their own, the one
+// S3 tools give them
+const FolderMarkerContentType = "application/x-directory"
+
+// IsFolderMarker reports whether a file named name of size bytes is a folder marker: an empty file
+// whose name ends with "/", which S3 tools store to keep a folder that holds nothing else
+func IsFolderMarker(name string, size int64) bool {
+	return size == 0 && strings.HasSuffix(name, "/")
+}
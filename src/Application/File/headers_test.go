@@ -0,0 +1,32 @@
+package file
+
+import (
+	"testing"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestSaveHeaders stores the caching, disposition and other headers of a file
+func TestSaveHeaders(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	file := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "/data/photos/a"}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveHeaders(db, &file, "max-age=60", "attachment", map[string]string{"X-Robots-Tag": "noindex"}); err != nil {
+		t.Fatalf("saveHeaders() = %v", err)
+	}
+
+	var stored entities.File
+	if err := db.First(&stored, `"Id" = ?`, file.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	other := headers.Decode(stored.Metadata.Headers)
+	if stored.Metadata.CacheControl != "max-age=60" || stored.Metadata.ContentDisposition != "attachment" || other["X-Robots-Tag"] != "noindex" {
+		t.Errorf("file metadata after saveHeaders() = %+v, want the three headers", stored.Metadata)
+	}
+}
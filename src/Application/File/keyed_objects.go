@@ -0,0 +1,86 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// ErrFileExists is returned for uploading a name a bucket with keyed objects already holds, when
+// the bucket doesn't allow overwriting
+var ErrFileExists = apierror.New(apierror.CodeAlreadyExists, "a file with this name already exists and the bucket doesn't allow overwriting")
+
+// checkOverwrite checks an upload of name may go ahead in a bucket with keyed objects, where a
+// name is one object. Without allow_overwrite an existing name is refused; with it, the upload
+// becomes a new version in a versioned bucket and replaces the file otherwise, which a lock
+// prevents. Other buckets keep every upload as a file of its own.
+func checkOverwrite(ctx context.Context, dbContext *persistence.AppDbContext, bucket *entities.Bucket, name string) error {
+	if !bucket.Settings.KeyedObjects {
+		return nil
+	}
+
+	var existing []entities.File
+	if err := dbContext.GetDB().WithContext(ctx).Where(&entities.File{BucketId: bucket.Id, Name: name}).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check for an existing file: %w", err)
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+	if !bucket.Settings.AllowOverwrite {
+		return ErrFileExists
+	}
+	if bucket.Settings.Versioning {
+		return nil
+	}
+	now := time.Now()
+	for i := range existing {
+		if existing[i].Lock.Active(now) {
+			return fmt.Errorf("%w, it can't be overwritten", ErrFileLocked)
+		}
+	}
+	return nil
+}
+
+// replaceOlder deletes the files uploaded under the name of current before it, in a bucket with
+// keyed objects that doesn't keep versions, so the name only refers to current. Of two uploads of
+// a name racing, the later one wins. Files locked meanwhile are kept.
+func replaceOlder(ctx context.Context, dbContext *persistence.AppDbContext, bucket *entities.Bucket, current *entities.File, actorID uuid.UUID) {
+	if !bucket.Settings.KeyedObjects || bucket.Settings.Versioning {
+		return
+	}
+
+	older, err := olderUploads(dbContext.GetDB().WithContext(ctx), current)
+	if err != nil {
+		log.Printf("Warning: failed to list files replaced by %s in bucket %s: %v", current.Name, bucket.Name, err)
+		return
+	}
+
+	deleter := NewDeleteFileRequestHandler(dbContext)
+	now := time.Now()
+	for i := range older {
+		if older[i].Lock.Active(now) {
+			continue
+		}
+		if err := deleter.remove(ctx, &older[i], actorID); err != nil {
+			log.Printf("Warning: failed to remove file %s replaced by %s: %v", older[i].Id, current.Id, err)
+		}
+	}
+}
+
+// olderUploads lists the other files of current's bucket under its name uploaded before it, or
+// at the same time
+func olderUploads(db *gorm.DB, current *entities.File) ([]entities.File, error) {
+	uploadedAt := db.Model(&entities.File{}).Select(`"CreatedAt"`).Where(`"Id" = ?`, current.Id)
+	var older []entities.File
+	err := db.Where(`"BucketId" = ? AND "Name" = ? AND "Id" <> ? AND "CreatedAt" <= (?)`, current.BucketId, current.Name, current.Id, uploadedAt).
+		Find(&older).Error
+	return older, err
+}
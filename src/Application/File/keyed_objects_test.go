@@ -0,0 +1,36 @@
+package file
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestOlderUploads lists the uploads of a name before the current one, not later ones or other names
+func TestOlderUploads(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	now := time.Now()
+	files := []entities.File{
+		{Name: "a.jpg", Path: "/data/photos/1", CreatedAt: now.Add(-time.Hour)},
+		{Name: "a.jpg", Path: "/data/photos/2", CreatedAt: now},
+		{Name: "a.jpg", Path: "/data/photos/3", CreatedAt: now.Add(time.Minute)},
+		{Name: "b.jpg", Path: "/data/photos/4", CreatedAt: now.Add(-time.Hour)},
+	}
+	for i := range files {
+		files[i].BucketId, files[i].OriginalName = bucket.Id, files[i].Name
+		if err := db.Create(&files[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	older, err := olderUploads(db, &files[1])
+	if err != nil {
+		t.Fatalf("olderUploads() = %v", err)
+	}
+	if len(older) != 1 || older[0].Id != files[0].Id {
+		t.Errorf("olderUploads() = %+v, want the earlier upload of a.jpg", older)
+	}
+}
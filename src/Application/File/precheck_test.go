@@ -0,0 +1,40 @@
+package file
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestIdenticalContent finds the master-stored files of a bucket with the same content, latest
+// first, leaving out node-stored and customer-encrypted content
+func TestIdenticalContent(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	other := sqlitetest.CreateBucket(t, db, "videos")
+	now := time.Now()
+	for i, file := range []entities.File{
+		{BucketId: bucket.Id, Name: "old.jpg", Path: "/data/photos/old", Checksum: "abc", Size: 10},
+		{BucketId: bucket.Id, Name: "new.jpg", Path: "/data/photos/new", Checksum: "abc", Size: 10},
+		{BucketId: bucket.Id, Name: "node.jpg", Path: "node://node/photos/node", Checksum: "abc", Size: 10},
+		{BucketId: bucket.Id, Name: "sse-c.jpg", Path: "/data/photos/sse-c", Checksum: "abc", Size: 10, Encryption: entities.FileEncryption{CustomerKeyMD5: "md5"}},
+		{BucketId: bucket.Id, Name: "resized.jpg", Path: "/data/photos/resized", Checksum: "abc", Size: 5},
+		{BucketId: other.Id, Name: "copy.jpg", Path: "/data/videos/copy", Checksum: "abc", Size: 10},
+	} {
+		file.OriginalName = file.Name
+		file.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	candidates, err := identicalContent(db, bucket.Id, "abc", 10)
+	if err != nil {
+		t.Fatalf("identicalContent() = %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].Name != "new.jpg" || candidates[1].Name != "old.jpg" {
+		t.Errorf("identicalContent() = %+v, want new.jpg then old.jpg", candidates)
+	}
+}
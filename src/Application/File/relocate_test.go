@@ -0,0 +1,56 @@
+package file
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestSwitchPath points every record sharing the content at the copy and moves its size between
+// the nodes
+func TestSwitchPath(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	source := entities.StorageNode{Name: "source", URL: "http://source", AuthKey: "key", UsedStorage: 100}
+	target := entities.StorageNode{Name: "target", URL: "http://target", AuthKey: "key", UsedStorage: 5}
+	for _, node := range []*entities.StorageNode{&source, &target} {
+		if err := db.Create(node).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := "node://" + source.Id.String() + "/" + bucket.Id.String() + "/" + uuid.NewString()
+	files := []entities.File{{Name: "a.jpg", Path: path, Size: 30}, {Name: "copy.jpg", Path: path, Size: 30}}
+	for i := range files {
+		files[i].BucketId, files[i].OriginalName = bucket.Id, files[i].Name
+		if err := db.Create(&files[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot := entities.SnapshotFile{SnapshotId: uuid.New(), FileId: files[0].Id, Name: "a.jpg", Path: path, Size: 30}
+	if err := db.Create(&snapshot).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	targetPath := "node://" + target.Id.String() + "/" + bucket.Id.String() + "/" + uuid.NewString()
+	references, err := switchPath(db, &files[0], &relocation{node: &target, path: targetPath})
+	if err != nil || references != 3 {
+		t.Fatalf("switchPath() = %d, %v, want 3 records moved", references, err)
+	}
+	var moved int64
+	db.Model(&entities.File{}).Where(`"Path" = ?`, targetPath).Count(&moved)
+	var nodes []entities.StorageNode
+	if err := db.Order(`"Name"`).Find(&nodes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if moved != 2 || nodes[0].UsedStorage != 70 || nodes[1].UsedStorage != 35 {
+		t.Errorf("switchPath() moved %d files, left usage %d and %d, want 2 files and 70 and 35", moved, nodes[0].UsedStorage, nodes[1].UsedStorage)
+	}
+
+	if _, err := switchPath(db, &files[0], &relocation{path: "/data/photos/a"}); !errors.Is(err, ErrFileChanged) {
+		t.Errorf("switchPath() of a file that moved meanwhile = %v, want ErrFileChanged", err)
+	}
+}
@@ -0,0 +1,70 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+)
+
+// nextVersion returns the version number a new upload of name gets. Versioned buckets keep every
+// upload of a name as its own file record, numbered from 1; the highest version is the current one.
+func nextVersion(db *gorm.DB, bucket *entities.Bucket, name string) int {
+	if !bucket.Settings.Versioning {
+		return 1
+	}
+
+	var latest int
+	if err := db.Model(&entities.File{}).
+		Where(`"BucketId" = ? AND "Name" = ?`, bucket.Id, name).
+		Select(`COALESCE(MAX("Version"), 0)`).Scan(&latest).Error; err != nil {
+		return 1
+	}
+	return latest + 1
+}
+
+// ExpiredVersions returns the noncurrent versions in a bucket that its version limits no longer keep.
+// A version's retention is counted from when it was replaced, the upload time of the next version.
+// The current version of an object is never returned, and neither are locked versions.
+func ExpiredVersions(ctx context.Context, db *gorm.DB, bucket *entities.Bucket, now time.Time) ([]entities.File, error) {
+	maxVersions := bucket.Settings.MaxVersions
+	retentionDays := bucket.Settings.VersionRetentionDays
+	if !bucket.Settings.Versioning || (maxVersions <= 0 && retentionDays <= 0) {
+		return nil, nil
+	}
+
+	db = db.WithContext(ctx)
+	versionedNames := db.Model(&entities.File{}).Select("Name").
+		Where(`"BucketId" = ?`, bucket.Id).Group("Name").Having("COUNT(*) > 1")
+
+	var files []entities.File
+	if err := db.Where(`"BucketId" = ? AND "Name" IN (?)`, bucket.Id, versionedNames).
+		Order(`"Name"`).Order(`"Version" DESC`).Order(`"CreatedAt" DESC`).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch versions: %w", err)
+	}
+
+	retentionCutoff := now.AddDate(0, 0, -retentionDays)
+
+	var expired []entities.File
+	rank := 0 // position among the noncurrent versions of the name, 1 is the most recent
+	for i := range files {
+		// Versions are grouped by name, newest first; the first of each group is current
+		if i == 0 || files[i].Name != files[i-1].Name {
+			rank = 0
+			continue
+		}
+		rank++
+
+		replacedAt := files[i-1].CreatedAt
+		if files[i].Lock.Active(now) {
+			continue
+		}
+		if (maxVersions > 0 && rank > maxVersions) || (retentionDays > 0 && replacedAt.Before(retentionCutoff)) {
+			expired = append(expired, files[i])
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,45 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestVersions numbers a new upload after the name's latest version and expires the noncurrent
+// versions over the bucket's limit
+func TestVersions(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	bucket.Settings.Versioning = true
+	bucket.Settings.MaxVersions = 1
+
+	now := time.Now()
+	for i, name := range []string{"a.txt", "a.txt", "a.txt", "b.txt"} {
+		file := entities.File{BucketId: bucket.Id, Name: name, OriginalName: name, Path: "/data/" + name, Version: i + 1, CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+		if name == "b.txt" {
+			file.Version = 1
+		}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if version := nextVersion(db, &bucket, "a.txt"); version != 4 {
+		t.Errorf("nextVersion() of a.txt = %d, want 4", version)
+	}
+	if version := nextVersion(db, &bucket, "c.txt"); version != 1 {
+		t.Errorf("nextVersion() of a new name = %d, want 1", version)
+	}
+
+	expired, err := ExpiredVersions(context.Background(), db, &bucket, now)
+	if err != nil {
+		t.Fatalf("ExpiredVersions() = %v", err)
+	}
+	if len(expired) != 1 || expired[0].Name != "a.txt" || expired[0].Version != 1 {
+		t.Errorf("ExpiredVersions() = %+v, want version 1 of a.txt", expired)
+	}
+}
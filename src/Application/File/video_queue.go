@@ -0,0 +1,27 @@
+package file
+
+import (
+	"log"
+	"strings"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// queueVideoProcessing schedules thumbnail and HLS generation for a newly stored video
+// when its bucket has video processing enabled. The video worker picks up pending assets.
+func queueVideoProcessing(dbContext *persistence.AppDbContext, bucket *entities.Bucket, file *entities.File) {
+	// Content encrypted with a customer-provided key can't be read without the client
+	if !bucket.Settings.VideoProcessing || !strings.HasPrefix(file.MimeType, "video/") || file.Encryption.CustomerEncrypted() {
+		return
+	}
+
+	dbContext.VideoAssets.Add(entities.VideoAsset{
+		FileId:   file.Id,
+		BucketId: file.BucketId,
+		Status:   "pending",
+	})
+	if err := dbContext.SaveChanges(); err != nil {
+		log.Printf("Warning: failed to queue video processing for file %s: %v", file.Id, err)
+	}
+}
@@ -0,0 +1,44 @@
+package importer
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetS3ImportJobCommand struct {
+	JobID uuid.UUID `json:"job_id" validate:"required"`
+}
+
+type GetS3ImportJobResponse struct {
+	Job     models.S3ImportJobResponse `json:"job"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type GetS3ImportJobRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetS3ImportJobRequestHandler(dbContext *persistence.AppDbContext) *GetS3ImportJobRequestHandler {
+	return &GetS3ImportJobRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *GetS3ImportJobRequestHandler) Handle(ctx context.Context, command *GetS3ImportJobCommand) (*GetS3ImportJobResponse, error) {
+	job, err := h.dbContext.S3ImportJobs.Where(&entities.S3ImportJob{Id: command.JobID}).FirstOrDefault()
+	if err != nil || job == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "import job not found")
+	}
+
+	return &GetS3ImportJobResponse{
+		Job:     ToS3ImportJobResponse(job),
+		Success: true,
+		Message: "Import job retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,349 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/S3"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+type ImportS3Command struct {
+	Source         s3.Config  `json:"source" validate:"required"`
+	SourceBucket   string     `json:"source_bucket" validate:"required"`
+	Prefix         string     `json:"prefix"`
+	TargetBucketID uuid.UUID  `json:"target_bucket_id" validate:"required"`
+	ResumeJobID    *uuid.UUID `json:"resume_job_id,omitempty"` // continue an interrupted import after its last processed key
+	StartedBy      uuid.UUID  `json:"-"`
+}
+
+type ImportS3Response struct {
+	Job     models.S3ImportJobResponse `json:"job"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type ImportS3RequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	events    *events.Publisher
+	mu        sync.Mutex
+	active    map[uuid.UUID]bool
+}
+
+func NewImportS3RequestHandler(dbContext *persistence.AppDbContext) *ImportS3RequestHandler {
+	return &ImportS3RequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		events:    events.NewPublisher(dbContext),
+		active:    make(map[uuid.UUID]bool),
+	}
+}
+
+// Handle validates the source, creates (or resumes) an import job and runs it in the background
+func (h *ImportS3RequestHandler) Handle(ctx context.Context, command *ImportS3Command) (*ImportS3Response, error) {
+	client, err := s3.NewClient(command.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.TargetBucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	// Content is written to the master's storage, where a pinned bucket's content may not go
+	if placement.Pinned(bucket) {
+		return nil, apierror.Newf(apierror.CodeConflict, "bucket is pinned to %s, so content can't be imported to this server", placement.Describe(bucket))
+	}
+
+	masterConfig, err := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil || masterConfig == nil || masterConfig.StoragePath == "" {
+		return nil, apierror.New(apierror.CodeUnavailable, "storage_path not configured in master config")
+	}
+
+	// Fail fast on bad credentials or a missing source bucket
+	if _, err := client.ListObjectsV2(ctx, command.SourceBucket, s3.ListObjectsOptions{Prefix: command.Prefix, MaxKeys: 1}); err != nil {
+		return nil, fmt.Errorf("failed to access source bucket: %w", err)
+	}
+
+	var job *entities.S3ImportJob
+	if command.ResumeJobID != nil {
+		job, err = h.dbContext.S3ImportJobs.Where(&entities.S3ImportJob{Id: *command.ResumeJobID}).FirstOrDefault()
+		if err != nil || job == nil {
+			return nil, apierror.New(apierror.CodeNotFound, "import job not found")
+		}
+		if job.Status == "completed" {
+			return nil, apierror.New(apierror.CodeConflict, "import job already completed")
+		}
+		if job.TargetBucketId != bucket.Id || job.SourceBucket != command.SourceBucket || job.Prefix != command.Prefix {
+			return nil, apierror.New(apierror.CodeInvalidRequest, "resume parameters do not match the original import job")
+		}
+	} else {
+		job = &entities.S3ImportJob{
+			Id:             uuid.New(),
+			SourceEndpoint: command.Source.Endpoint,
+			SourceBucket:   command.SourceBucket,
+			Prefix:         command.Prefix,
+			TargetBucketId: bucket.Id,
+			SkippedObjects: datatypes.JSON("[]"),
+			StartedBy:      command.StartedBy,
+			StartedAt:      time.Now(),
+		}
+	}
+
+	if !h.claim(job.Id) {
+		return nil, apierror.New(apierror.CodeConflict, "import job is already running")
+	}
+
+	job.Status = "running"
+	job.Error = ""
+	job.CompletedAt = nil
+	if command.ResumeJobID != nil {
+		h.dbContext.S3ImportJobs.Update(*job)
+	} else {
+		h.dbContext.S3ImportJobs.Add(*job)
+	}
+	if err := h.dbContext.SaveChanges(); err != nil {
+		h.release(job.Id)
+		return nil, fmt.Errorf("failed to save import job: %w", err)
+	}
+
+	bucketDir := filepath.Join(masterConfig.StoragePath, bucket.Name)
+	go func() {
+		defer h.release(job.Id)
+		h.execute(client, job, bucket, bucketDir)
+	}()
+
+	message := "Import started"
+	if command.ResumeJobID != nil {
+		message = fmt.Sprintf("Import resumed after key %q", job.LastKey)
+	}
+
+	return &ImportS3Response{
+		Job:     ToS3ImportJobResponse(job),
+		Success: true,
+		Message: message,
+	}, nil
+}
+
+func (h *ImportS3RequestHandler) claim(jobID uuid.UUID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.active[jobID] {
+		return false
+	}
+	h.active[jobID] = true
+	return true
+}
+
+func (h *ImportS3RequestHandler) release(jobID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.active, jobID)
+}
+
+func (h *ImportS3RequestHandler) execute(client *s3.Client, job *entities.S3ImportJob, bucket *entities.Bucket, bucketDir string) {
+	ctx := context.Background()
+
+	var skipped []models.SkippedObjectResponse
+	json.Unmarshal(job.SkippedObjects, &skipped)
+
+	if err := os.MkdirAll(bucketDir, 0755); err != nil {
+		job.Error = fmt.Sprintf("failed to create bucket directory: %v", err)
+	}
+
+	opts := s3.ListObjectsOptions{Prefix: job.Prefix, StartAfter: job.LastKey, MaxKeys: 1000}
+	for job.Error == "" {
+		page, err := client.ListObjectsV2(ctx, job.SourceBucket, opts)
+		if err != nil {
+			job.Error = fmt.Sprintf("failed to list source objects: %v", err)
+			break
+		}
+
+		for _, object := range page.Objects {
+			done, err := storage.BeginTransfer()
+			if err != nil {
+				job.Error = "interrupted by server shutdown, resume with resume_job_id"
+				break
+			}
+			reason := h.importObject(ctx, client, job, bucket, bucketDir, object)
+			done()
+
+			if reason != "" {
+				skipped = append(skipped, models.SkippedObjectResponse{Key: object.Key, Reason: reason})
+				job.ObjectsSkipped++
+			}
+			job.LastKey = object.Key
+		}
+
+		// Persist progress after every page so the import can be resumed
+		job.SkippedObjects = mustMarshal(skipped)
+		h.dbContext.S3ImportJobs.Update(*job)
+		if err := h.dbContext.SaveChanges(); err != nil {
+			log.Printf("Import %s: failed to save progress: %v", job.Id, err)
+		}
+
+		if !page.IsTruncated {
+			break
+		}
+		opts = s3.ListObjectsOptions{Prefix: job.Prefix, ContinuationToken: page.NextContinuationToken, MaxKeys: 1000}
+	}
+
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	job.Status = "completed"
+	if job.Error != "" {
+		job.Status = "failed"
+	}
+	job.SkippedObjects = mustMarshal(skipped)
+
+	h.dbContext.S3ImportJobs.Update(*job)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		log.Printf("Import %s: failed to save final status: %v", job.Id, err)
+	}
+
+	log.Printf("Import %s %s: %d imported, %d skipped", job.Id, job.Status, job.ObjectsImported, job.ObjectsSkipped)
+}
+
+// importObject copies a single object into the target bucket and returns a skip reason, or "" on success
+func (h *ImportS3RequestHandler) importObject(ctx context.Context, client *s3.Client, job *entities.S3ImportJob, bucket *entities.Bucket, bucketDir string, object s3.ObjectInfo) string {
+	if bucket.Settings.MaxFileSize > 0 && object.Size > bucket.Settings.MaxFileSize {
+		return fmt.Sprintf("exceeds bucket max file size of %d bytes", bucket.Settings.MaxFileSize)
+	}
+
+	existing, _ := h.dbContext.Files.Where(&entities.File{BucketId: bucket.Id, Name: object.Key}).FirstOrDefault()
+	if existing != nil {
+		return "file with the same key already exists in target bucket"
+	}
+
+	body, info, err := client.GetObject(ctx, job.SourceBucket, object.Key)
+	if err != nil {
+		return fmt.Sprintf("failed to download: %v", err)
+	}
+	defer body.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+		if file.IsFolderMarker(object.Key, object.Size) {
+			contentType = file.FolderMarkerContentType
+		}
+	}
+
+	fileID := uuid.New()
+	filePath := filepath.Join(bucketDir, fileID.String())
+	contentEncoding := storage.ContentEncodingFor(bucket, contentType)
+	if object.Size == 0 {
+		// Directory markers and other empty objects are stored as they are
+		contentEncoding = ""
+	}
+	checksum, size, fileEncryption, err := storage.SaveBucketFile(h.dbContext, bucket, nil, contentEncoding, filePath, body)
+	if err != nil {
+		return fmt.Sprintf("failed to store: %v", err)
+	}
+
+	customMetadata := map[string]interface{}{
+		"imported_from": fmt.Sprintf("s3://%s/%s", job.SourceBucket, object.Key),
+		"source_etag":   object.ETag,
+		"import_job_id": job.Id.String(),
+	}
+	for key, value := range info.Metadata {
+		customMetadata[key] = value
+	}
+
+	createdAt := object.LastModified
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	h.dbContext.Files.Add(entities.File{
+		Id:           fileID,
+		BucketId:     bucket.Id,
+		Name:         object.Key,
+		OriginalName: object.Key,
+		Path:         filePath,
+		Size:         size,
+		MimeType:     contentType,
+		Checksum:     checksum,
+		Version:      1,
+		Lock:         file.DefaultLock(bucket, time.Now()),
+		SecuredUrl:   fmt.Sprintf("%s/api/v1/file/%s/%s", h.settings.BaseURL, bucket.Id.String(), fileID.String()),
+		AuthRule: entities.AuthRule{
+			Type:    bucket.AuthRule.Type,
+			Enabled: bucket.AuthRule.Enabled,
+			Config:  bucket.AuthRule.Config,
+		},
+		Metadata: entities.FileMetadata{
+			ContentType:     contentType,
+			ContentEncoding: contentEncoding,
+			CustomMetadata:  mustMarshal(customMetadata),
+		},
+		Encryption: fileEncryption,
+		UploadedBy: job.StartedBy,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	})
+	if err := h.dbContext.SaveChanges(); err != nil {
+		os.Remove(filePath)
+		return fmt.Sprintf("failed to create file record: %v", err)
+	}
+
+	h.events.Publish(events.FileUploaded, bucket.Id, &fileID, job.StartedBy, map[string]interface{}{
+		"name":      object.Key,
+		"size":      size,
+		"mime_type": contentType,
+		"checksum":  checksum,
+		"source":    "s3-import",
+	})
+
+	job.ObjectsImported++
+	job.BytesImported += size
+	return ""
+}
+
+func mustMarshal(value interface{}) datatypes.JSON {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return datatypes.JSON("null")
+	}
+	return datatypes.JSON(data)
+}
+
+// ToS3ImportJobResponse converts an import job entity to its API response
+func ToS3ImportJobResponse(job *entities.S3ImportJob) models.S3ImportJobResponse {
+	skipped := []models.SkippedObjectResponse{}
+	json.Unmarshal(job.SkippedObjects, &skipped)
+
+	return models.S3ImportJobResponse{
+		ID:              job.Id,
+		SourceEndpoint:  job.SourceEndpoint,
+		SourceBucket:    job.SourceBucket,
+		Prefix:          job.Prefix,
+		TargetBucketID:  job.TargetBucketId,
+		Status:          job.Status,
+		LastKey:         job.LastKey,
+		ObjectsImported: job.ObjectsImported,
+		ObjectsSkipped:  job.ObjectsSkipped,
+		BytesImported:   job.BytesImported,
+		SkippedObjects:  skipped,
+		Error:           job.Error,
+		StartedAt:       job.StartedAt,
+		CompletedAt:     job.CompletedAt,
+	}
+}
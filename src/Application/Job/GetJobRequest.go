@@ -0,0 +1,85 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+type GetJobCommand struct {
+	JobID    uuid.UUID `json:"job_id"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetJobResponse struct {
+	Job     models.JobResponse `json:"job"`
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+}
+
+type GetJobRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetJobRequestHandler(dbContext *persistence.AppDbContext) *GetJobRequestHandler {
+	return &GetJobRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns a background job, visible to the user who started it and to admins
+func (h *GetJobRequestHandler) Handle(ctx context.Context, command *GetJobCommand) (*GetJobResponse, error) {
+	job, err := h.dbContext.Jobs.Where(&entities.Job{Id: command.JobID}).FirstOrDefault()
+	if err != nil || job == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "job not found")
+	}
+	if job.CreatedBy != command.UserID && command.UserRole != "admin" {
+		return nil, apierror.New(apierror.CodeNotFound, "job not found")
+	}
+
+	return &GetJobResponse{
+		Job:     ToJobResponse(job),
+		Success: true,
+		Message: "Job retrieved successfully",
+	}, nil
+}
+
+func ToJobResponse(job *entities.Job) models.JobResponse {
+	response := models.JobResponse{
+		ID:          job.Id,
+		Type:        job.Type,
+		Status:      job.Status,
+		BucketID:    job.BucketId,
+		Total:       job.Total,
+		Completed:   job.Completed,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if len(job.Result) > 0 {
+		response.Result = utils.ConvertJSONToMap(job.Result)
+	}
+	if job.Status == jobs.StatusQueued {
+		nextRunAt := job.RunAfter
+		response.NextRunAt = &nextRunAt
+	}
+	if job.Status == jobs.StatusRunning && job.StartedAt != nil && job.Completed > 0 && job.Total > job.Completed {
+		// Assumes the remaining work goes at the pace of the work done in this attempt
+		elapsed := time.Since(*job.StartedAt)
+		remaining := time.Duration(float64(elapsed) / float64(job.Completed) * float64(job.Total-job.Completed))
+		estimated := time.Now().Add(remaining)
+		response.EstimatedCompletionAt = &estimated
+	}
+	return response
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// defaultRegistrationTokenTTL is how long a registration token is valid when no expiry is given
+const defaultRegistrationTokenTTL = time.Hour
+
+type CreateRegistrationTokenCommand struct {
+	// Name describes the node the token is for
+	Name      string    `json:"name" validate:"max=100"`
+	ExpiresIn int       `json:"expires_in" validate:"omitempty,min=60,max=604800"` // 1 minute to 7 days, an hour when 0
+	UserID    uuid.UUID `json:"-"`
+}
+
+type CreateRegistrationTokenResponse struct {
+	Token models.NodeRegistrationTokenResponse `json:"token"`
+	// Secret is only returned here, it can't be retrieved again
+	Secret  string `json:"secret"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type CreateRegistrationTokenRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewCreateRegistrationTokenRequestHandler(dbContext *persistence.AppDbContext) *CreateRegistrationTokenRequestHandler {
+	return &CreateRegistrationTokenRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle issues a token a storage node can register itself with, once
+func (h *CreateRegistrationTokenRequestHandler) Handle(ctx context.Context, command *CreateRegistrationTokenCommand) (*CreateRegistrationTokenResponse, error) {
+	secret, tokenHash, tokenPrefix, err := generateRegistrationToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate registration token: %w", err)
+	}
+
+	ttl := defaultRegistrationTokenTTL
+	if command.ExpiresIn > 0 {
+		ttl = time.Duration(command.ExpiresIn) * time.Second
+	}
+
+	now := time.Now()
+	token := &entities.NodeRegistrationToken{
+		Id:          uuid.New(),
+		Name:        command.Name,
+		TokenHash:   tokenHash,
+		TokenPrefix: tokenPrefix,
+		ExpiresAt:   now.Add(ttl),
+		CreatedBy:   command.UserID,
+		CreatedAt:   now,
+	}
+	h.dbContext.RegistrationTokens.Add(*token)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save registration token: %w", err)
+	}
+
+	return &CreateRegistrationTokenResponse{
+		Token:   ToRegistrationTokenResponse(token),
+		Secret:  secret,
+		Success: true,
+		Message: "Registration token created successfully",
+	}, nil
+}
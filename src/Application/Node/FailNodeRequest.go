@@ -0,0 +1,107 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/Job"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type FailNodeCommand struct {
+	NodeID uuid.UUID `json:"node_id"`
+	UserID uuid.UUID `json:"-"`
+}
+
+type FailNodeResponse struct {
+	Node    models.StorageNodeResponse `json:"node"`
+	Repair  models.JobResponse         `json:"repair"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type FailNodeRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewFailNodeRequestHandler(dbContext *persistence.AppDbContext) *FailNodeRequestHandler {
+	return &FailNodeRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle marks a storage node permanently failed, so it takes no more uploads, and queues the job
+// restoring its files elsewhere from their backup copies. Marking a node failed again requeues the
+// repair once the previous one has finished, for files it couldn't repair then.
+func (h *FailNodeRequestHandler) Handle(ctx context.Context, command *FailNodeCommand) (*FailNodeResponse, error) {
+	node, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{Id: command.NodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return nil, apierror.New(apierror.CodeNodeNotFound, "storage node not found")
+	}
+
+	if node.RepairJobId != nil {
+		repair, err := h.dbContext.Jobs.Where(&entities.Job{Id: *node.RepairJobId}).FirstOrDefault()
+		if err == nil && repair != nil && (repair.Status == jobs.StatusQueued || repair.Status == jobs.StatusRunning) {
+			return nil, apierror.New(apierror.CodeConflict, "storage node is already being repaired")
+		}
+	}
+
+	queued, err := jobs.New(jobs.TypeNodeRepair, repairNodePayload{NodeID: node.Id}, jobs.Options{
+		CreatedBy: command.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if node.FailedAt == nil {
+		now := time.Now()
+		node.FailedAt = &now
+	}
+	node.IsActive = false
+	node.IsHealthy = false
+	node.RepairJobId = &queued.Id
+
+	h.dbContext.Jobs.Add(*queued)
+	h.dbContext.StorageNodes.Update(*node)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to mark storage node failed: %w", err)
+	}
+
+	return &FailNodeResponse{
+		Node: models.StorageNodeResponse{
+			ID:          node.Id,
+			Name:        node.Name,
+			URL:         node.URL,
+			PublicURL:   node.PublicURL,
+			MaxStorage:  node.MaxStorage,
+			UsedStorage: node.UsedStorage,
+			Priority:    node.Priority,
+			Group:       node.Group,
+			Zone:        node.Zone,
+			IsActive:    node.IsActive,
+			IsHealthy:   node.IsHealthy,
+			CreatedAt:   node.CreatedAt,
+			UpdatedAt:   node.UpdatedAt,
+			LastPing:    node.LastPing,
+			FailedAt:    node.FailedAt,
+			RepairJobID: node.RepairJobId,
+			Status:      node.Status(),
+			Maintenance: maintenanceResponse(node),
+		},
+		Repair:  job.ToJobResponse(queued),
+		Success: true,
+		Message: fmt.Sprintf("Storage node %s marked failed, repairing its content", node.Name),
+	}, nil
+}
+
+// RunRepairJob runs a repair queued by Handle, it is registered with the job runner
+func (h *FailNodeRequestHandler) RunRepairJob(ctx context.Context, run *jobs.Run) error {
+	return newRepairer(h.dbContext).run(ctx, run)
+}
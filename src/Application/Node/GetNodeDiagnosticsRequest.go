@@ -0,0 +1,108 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/NodeClient"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetNodeDiagnosticsCommand struct {
+	NodeID uuid.UUID `json:"node_id"`
+}
+
+type GetNodeDiagnosticsResponse struct {
+	Diagnostics models.NodeDiagnosticsResponse `json:"diagnostics"`
+	Success     bool                           `json:"success"`
+	Message     string                         `json:"message"`
+}
+
+type GetNodeDiagnosticsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetNodeDiagnosticsRequestHandler(dbContext *persistence.AppDbContext) *GetNodeDiagnosticsRequestHandler {
+	return &GetNodeDiagnosticsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle pulls a storage node's diagnostics through its internal endpoint, authenticated with the
+// node's auth key, and works out how far its clock is off the master's. A node that can't be asked
+// is reported unreachable with the reason, rather than failing the request.
+func (h *GetNodeDiagnosticsRequestHandler) Handle(ctx context.Context, command *GetNodeDiagnosticsCommand) (*GetNodeDiagnosticsResponse, error) {
+	node, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{Id: command.NodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return nil, apierror.New(apierror.CodeNodeNotFound, "storage node not found")
+	}
+
+	response := models.NodeDiagnosticsResponse{
+		NodeID:       node.Id,
+		Name:         node.Name,
+		URL:          node.URL,
+		Status:       node.Status(),
+		RecentErrors: []models.NodeLogEntryResponse{},
+	}
+	message := "Node diagnostics retrieved successfully"
+	if err := h.pull(ctx, node, &response); err != nil {
+		response.Error = err.Error()
+		message = "Node couldn't be reached for diagnostics"
+	}
+
+	return &GetNodeDiagnosticsResponse{
+		Diagnostics: response,
+		Success:     true,
+		Message:     message,
+	}, nil
+}
+
+// pull asks the node for its diagnostics and fills them into response
+func (h *GetNodeDiagnosticsRequestHandler) pull(ctx context.Context, node *entities.StorageNode, response *models.NodeDiagnosticsResponse) error {
+	sent := time.Now()
+	diagnostics, err := nodeclient.Default().Diagnostics(ctx, nodeclient.Node{URL: node.URL, AuthKey: node.AuthKey})
+	received := time.Now()
+	response.CheckedAt = received
+	if err != nil {
+		return err
+	}
+
+	response.Reachable = true
+	response.RoundTripMs = received.Sub(sent).Milliseconds()
+	// The node read its clock about halfway through the round trip
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	response.ClockSkewMs = diagnostics.Time.Sub(midpoint).Milliseconds()
+	response.Version = diagnostics.Version
+	response.APIVersion = diagnostics.APIVersion
+	response.StoragePath = diagnostics.StoragePath
+	response.Error = diagnostics.DiskError
+	if disk := diagnostics.Disk; disk != nil {
+		response.Disk = &models.NodeDiskResponse{
+			TotalBytes:        disk.TotalBytes,
+			FreeBytes:         disk.FreeBytes,
+			UsedPercent:       usedPercent(disk.TotalBytes, disk.FreeBytes),
+			TotalInodes:       disk.TotalInodes,
+			FreeInodes:        disk.FreeInodes,
+			InodesUsedPercent: usedPercent(disk.TotalInodes, disk.FreeInodes),
+		}
+	}
+	for _, entry := range diagnostics.RecentErrors {
+		response.RecentErrors = append(response.RecentErrors, models.NodeLogEntryResponse{
+			Time:    entry.Time,
+			Message: entry.Message,
+		})
+	}
+	return nil
+}
+
+func usedPercent(total, free uint64) float64 {
+	if total == 0 || free > total {
+		return 0
+	}
+	return 100 * float64(total-free) / float64(total)
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/Job"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetNodeRepairCommand struct {
+	NodeID uuid.UUID `json:"node_id"`
+}
+
+type GetNodeRepairResponse struct {
+	Repair  models.JobResponse `json:"repair"`
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+}
+
+type GetNodeRepairRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetNodeRepairRequestHandler(dbContext *persistence.AppDbContext) *GetNodeRepairRequestHandler {
+	return &GetNodeRepairRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns the latest repair of a failed storage node, with its progress and estimated completion
+func (h *GetNodeRepairRequestHandler) Handle(ctx context.Context, command *GetNodeRepairCommand) (*GetNodeRepairResponse, error) {
+	node, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{Id: command.NodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return nil, apierror.New(apierror.CodeNodeNotFound, "storage node not found")
+	}
+	if node.RepairJobId == nil {
+		return nil, apierror.New(apierror.CodeConflict, "storage node has not been marked failed")
+	}
+
+	repair, err := h.dbContext.Jobs.Where(&entities.Job{Id: *node.RepairJobId}).FirstOrDefault()
+	if err != nil || repair == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "repair job not found")
+	}
+
+	return &GetNodeRepairResponse{
+		Repair:  job.ToJobResponse(repair),
+		Success: true,
+		Message: "Repair retrieved successfully",
+	}, nil
+}
@@ -3,15 +3,38 @@ package node
 import (
 	"context"
 	"fmt"
+
+	"gorm.io/gorm"
 	entities "shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
+	"shbucket/src/Utils"
 )
 
 type ListNodesCommand struct {
-	Page     int  `json:"page"`
-	Limit    int  `json:"limit"`
-	OnlyActive bool `json:"only_active"`
+	Page        int              `json:"page"`
+	Limit       int              `json:"limit"`
+	OnlyActive  bool             `json:"only_active"`
+	OnlyHealthy bool             `json:"only_healthy"` // healthy and not failed
+	Group       string           `json:"group"`
+	Zone        string           `json:"zone"`
+	List        models.ListQuery `json:"list"`
+}
+
+// nodeSort is what storage nodes can be sorted by, by name when unspecified
+var nodeSort = utils.ListSort{
+	Columns: map[string]string{
+		"name":         "Name",
+		"priority":     "Priority",
+		"group":        "node_group",
+		"zone":         "Zone",
+		"max_storage":  "MaxStorage",
+		"used_storage": "UsedStorage",
+		"last_ping":    "LastPing",
+		"created_at":   "CreatedAt",
+	},
+	DefaultField: "name",
+	DefaultOrder: "asc",
 }
 
 type ListNodesResponse struct {
@@ -46,22 +69,31 @@ func (h *ListNodesRequestHandler) Handle(ctx context.Context, command *ListNodes
 
 	offset := (page - 1) * limit
 
-	// Use GoNtext LINQ-style querying
-	nodeQuery := h.dbContext.StorageNodes
+	query := h.dbContext.GetDB().WithContext(ctx).Model(&entities.StorageNode{})
 	if command.OnlyActive {
-		nodeQuery = nodeQuery.Where(&entities.StorageNode{IsActive: true})
+		query = query.Where(`"IsActive" = ?`, true)
 	}
-	
-	// Get total count
-	totalCount, err := nodeQuery.Count()
+	if command.OnlyHealthy {
+		query = query.Where(`"IsHealthy" = ? AND "FailedAt" IS NULL`, true)
+	}
+	if command.Group != "" {
+		query = query.Where(`"node_group" = ?`, command.Group)
+	}
+	if command.Zone != "" {
+		query = query.Where(`"Zone" = ?`, command.Zone)
+	}
+	query, err := utils.ApplyListQuery(query, command.List, nodeSort, "Name")
 	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, fmt.Errorf("failed to count storage nodes: %w", err)
 	}
-	total := int64(totalCount)
 
-	// Get paginated results
-	nodes, err := nodeQuery.Skip(offset).Take(limit).ToList()
-	if err != nil {
+	var nodes []entities.StorageNode
+	if err := query.Session(&gorm.Session{}).Offset(offset).Limit(limit).Find(&nodes).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch storage nodes: %w", err)
 	}
 
@@ -71,14 +103,21 @@ func (h *ListNodesRequestHandler) Handle(ctx context.Context, command *ListNodes
 			ID:          node.Id, // Updated to use Id (Go naming convention)
 			Name:        node.Name,
 			URL:         node.URL,
+			PublicURL:   node.PublicURL,
 			MaxStorage:  node.MaxStorage,
 			UsedStorage: node.UsedStorage,
 			Priority:    node.Priority,
+			Group:       node.Group,
+			Zone:        node.Zone,
 			IsActive:    node.IsActive,
 			IsHealthy:   node.IsHealthy,
 			CreatedAt:   node.CreatedAt,
 			UpdatedAt:   node.UpdatedAt,
 			LastPing:    node.LastPing,
+			FailedAt:    node.FailedAt,
+			RepairJobID: node.RepairJobId,
+			Status:      node.Status(),
+			Maintenance: maintenanceResponse(&node),
 		}
 	}
 
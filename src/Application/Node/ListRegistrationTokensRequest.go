@@ -0,0 +1,46 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListRegistrationTokensCommand struct{}
+
+type ListRegistrationTokensResponse struct {
+	Tokens  []models.NodeRegistrationTokenResponse `json:"tokens"`
+	Success bool                                   `json:"success"`
+	Message string                                 `json:"message"`
+}
+
+type ListRegistrationTokensRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListRegistrationTokensRequestHandler(dbContext *persistence.AppDbContext) *ListRegistrationTokensRequestHandler {
+	return &ListRegistrationTokensRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists registration tokens, used, expired and revoked ones included, without their secrets
+func (h *ListRegistrationTokensRequestHandler) Handle(ctx context.Context, command *ListRegistrationTokensCommand) (*ListRegistrationTokensResponse, error) {
+	tokens, err := h.dbContext.RegistrationTokens.OrderByDescending("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registration tokens: %w", err)
+	}
+
+	responses := make([]models.NodeRegistrationTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, ToRegistrationTokenResponse(&tokens[i]))
+	}
+
+	return &ListRegistrationTokensResponse{
+		Tokens:  responses,
+		Success: true,
+		Message: "Registration tokens retrieved successfully",
+	}, nil
+}
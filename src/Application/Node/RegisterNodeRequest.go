@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
@@ -13,9 +14,12 @@ import (
 type RegisterNodeCommand struct {
 	Name       string `json:"name" validate:"required,min=3,max=100"`
 	URL        string `json:"url" validate:"required,url"`
+	PublicURL  string `json:"public_url" validate:"omitempty,url"` // where clients reach the node, when not at URL
 	AuthKey    string `json:"auth_key" validate:"required,min=32"` // 32+ chars for security
 	MaxStorage int64  `json:"max_storage" validate:"min=0"`
 	Priority   int    `json:"priority" validate:"min=0,max=100"`
+	Group      string `json:"group" validate:"max=100"`
+	Zone       string `json:"zone" validate:"max=100"`
 	IsActive   bool   `json:"is_active"`
 }
 
@@ -39,22 +43,25 @@ func (h *RegisterNodeRequestHandler) Handle(ctx context.Context, command *Regist
 	// Validate URL
 	_, err := url.Parse(command.URL)
 	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "invalid URL: %v", err)
 	}
 
 	// Check if storage node with this URL already exists
 	existingNode, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{URL: command.URL}).FirstOrDefault()
 	if err == nil && existingNode != nil {
-		return nil, fmt.Errorf("storage node with this URL already exists")
+		return nil, apierror.New(apierror.CodeAlreadyExists, "storage node with this URL already exists")
 	}
 
 	node := &entities.StorageNode{
 		Name:        command.Name,
 		URL:         command.URL,
+		PublicURL:   command.PublicURL,
 		AuthKey:     command.AuthKey,
 		MaxStorage:  command.MaxStorage,
 		UsedStorage: 0,
 		Priority:    command.Priority,
+		Group:       command.Group,
+		Zone:        command.Zone,
 		IsActive:    command.IsActive,
 		IsHealthy:   false, // Will be set to true on first successful ping
 	}
@@ -69,14 +76,21 @@ func (h *RegisterNodeRequestHandler) Handle(ctx context.Context, command *Regist
 		ID:          node.Id,
 		Name:        node.Name,
 		URL:         node.URL,
+		PublicURL:   node.PublicURL,
 		MaxStorage:  node.MaxStorage,
 		UsedStorage: node.UsedStorage,
 		Priority:    node.Priority,
+		Group:       node.Group,
+		Zone:        node.Zone,
 		IsActive:    node.IsActive,
 		IsHealthy:   node.IsHealthy,
 		CreatedAt:   node.CreatedAt,
 		UpdatedAt:   node.UpdatedAt,
 		LastPing:    node.LastPing,
+		FailedAt:    node.FailedAt,
+		RepairJobID: node.RepairJobId,
+		Status:      node.Status(),
+		Maintenance: maintenanceResponse(node),
 	}
 
 	
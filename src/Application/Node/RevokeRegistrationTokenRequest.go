@@ -0,0 +1,75 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// errTokenUsed is returned for revoking a token a node was registered with
+var errTokenUsed = apierror.New(apierror.CodeConflict, "registration token was already used, remove the node it registered instead")
+
+type RevokeRegistrationTokenCommand struct {
+	TokenID uuid.UUID `json:"-"`
+}
+
+type RevokeRegistrationTokenResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type RevokeRegistrationTokenRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewRevokeRegistrationTokenRequestHandler(dbContext *persistence.AppDbContext) *RevokeRegistrationTokenRequestHandler {
+	return &RevokeRegistrationTokenRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle revokes a registration token that hasn't been used yet. The record is kept for auditing.
+func (h *RevokeRegistrationTokenRequestHandler) Handle(ctx context.Context, command *RevokeRegistrationTokenCommand) (*RevokeRegistrationTokenResponse, error) {
+	token, err := h.dbContext.RegistrationTokens.Where(&entities.NodeRegistrationToken{Id: command.TokenID}).FirstOrDefault()
+	if err != nil || token == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "registration token not found")
+	}
+	if token.UsedAt != nil {
+		return nil, errTokenUsed
+	}
+	if token.RevokedAt != nil {
+		return &RevokeRegistrationTokenResponse{
+			Success: true,
+			Message: "Registration token was already revoked",
+		}, nil
+	}
+
+	// A registration racing the revocation either spends the token first or finds it revoked
+	if err := revokeRegistrationToken(h.dbContext.GetDB().WithContext(ctx), token, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &RevokeRegistrationTokenResponse{
+		Success: true,
+		Message: "Registration token revoked successfully",
+	}, nil
+}
+
+// revokeRegistrationToken revokes a token unless a registration spent it first
+func revokeRegistrationToken(db *gorm.DB, token *entities.NodeRegistrationToken, now time.Time) error {
+	result := db.Model(&entities.NodeRegistrationToken{}).
+		Where(`"Id" = ? AND "UsedAt" IS NULL`, token.Id).Update("RevokedAt", now)
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke registration token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errTokenUsed
+	}
+	return nil
+}
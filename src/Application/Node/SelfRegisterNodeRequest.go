@@ -0,0 +1,120 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type SelfRegisterNodeCommand struct {
+	RegistrationToken string `json:"registration_token" validate:"required"`
+	Name              string `json:"name" validate:"required,min=3,max=100"`
+	URL               string `json:"url" validate:"required,url"`
+	PublicURL         string `json:"public_url" validate:"omitempty,url"` // where clients reach the node, when not at URL
+	MaxStorage        int64  `json:"max_storage" validate:"min=0"`
+	Priority          int    `json:"priority" validate:"min=0,max=100"`
+}
+
+type SelfRegisterNodeResponse struct {
+	Node models.StorageNodeResponse `json:"node"`
+	// AuthKey is shared by the node and the master to authenticate each other, it is only returned here
+	AuthKey string `json:"auth_key"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type SelfRegisterNodeRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewSelfRegisterNodeRequestHandler(dbContext *persistence.AppDbContext) *SelfRegisterNodeRequestHandler {
+	return &SelfRegisterNodeRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle registers a storage node that presents a registration token, spending the token. The node is
+// issued the auth key it and the master authenticate each other with from then on.
+func (h *SelfRegisterNodeRequestHandler) Handle(ctx context.Context, command *SelfRegisterNodeCommand) (*SelfRegisterNodeResponse, error) {
+	authKey, err := generateNodeAuthKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate auth key: %w", err)
+	}
+
+	now := time.Now()
+	node := &entities.StorageNode{
+		Id:         uuid.New(),
+		Name:       command.Name,
+		URL:        command.URL,
+		PublicURL:  command.PublicURL,
+		AuthKey:    authKey,
+		MaxStorage: command.MaxStorage,
+		Priority:   command.Priority,
+		IsActive:   true,
+		IsHealthy:  false, // Will be set to true on first successful ping
+	}
+
+	err = h.dbContext.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return registerWithToken(tx, command.RegistrationToken, node, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SelfRegisterNodeResponse{
+		Node: models.StorageNodeResponse{
+			ID:          node.Id,
+			Name:        node.Name,
+			URL:         node.URL,
+			PublicURL:   node.PublicURL,
+			MaxStorage:  node.MaxStorage,
+			UsedStorage: node.UsedStorage,
+			Priority:    node.Priority,
+			Group:       node.Group,
+			Zone:        node.Zone,
+			IsActive:    node.IsActive,
+			IsHealthy:   node.IsHealthy,
+			CreatedAt:   node.CreatedAt,
+			UpdatedAt:   node.UpdatedAt,
+			Status:      node.Status(),
+		},
+		AuthKey: authKey,
+		Success: true,
+		Message: "Node registered successfully. Save the auth_key, the node authenticates with it.",
+	}, nil
+}
+
+// registerWithToken spends a registration token on node and creates it
+func registerWithToken(tx *gorm.DB, token string, node *entities.StorageNode, now time.Time) error {
+	// Spending the token is conditional, so two registrations can't both use it
+	result := tx.Model(&entities.NodeRegistrationToken{}).
+		Where(`"TokenHash" = ? AND "UsedAt" IS NULL AND "RevokedAt" IS NULL AND "ExpiresAt" > ?`, hashRegistrationToken(token), now).
+		Updates(map[string]interface{}{"UsedAt": now, "NodeId": node.Id})
+	if result.Error != nil {
+		return fmt.Errorf("failed to check registration token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRegistrationTokenInvalid
+	}
+
+	var existing int64
+	if err := tx.Model(&entities.StorageNode{}).Where(&entities.StorageNode{URL: node.URL}).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check storage nodes: %w", err)
+	}
+	if existing > 0 {
+		return apierror.New(apierror.CodeAlreadyExists, "storage node with this URL already exists")
+	}
+
+	if err := tx.Create(node).Error; err != nil {
+		return fmt.Errorf("failed to register storage node: %w", err)
+	}
+	return nil
+}
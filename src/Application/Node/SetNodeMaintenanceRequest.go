@@ -0,0 +1,107 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type SetNodeMaintenanceCommand struct {
+	NodeID     uuid.UUID `json:"node_id"`
+	UserID     uuid.UUID `json:"-"`
+	Enabled    bool      `json:"enabled"`
+	DrainReads bool      `json:"drain_reads"`
+}
+
+type SetNodeMaintenanceResponse struct {
+	Node    models.StorageNodeResponse `json:"node"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type SetNodeMaintenanceRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewSetNodeMaintenanceRequestHandler(dbContext *persistence.AppDbContext) *SetNodeMaintenanceRequestHandler {
+	return &SetNodeMaintenanceRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle puts a storage node in maintenance or takes it out. In maintenance the node stays
+// registered and keeps being health checked, but no new content is placed on it, and with
+// DrainReads its content is read from other copies where there are any. Setting maintenance on a
+// node already in it changes whether it drains, keeping when it started.
+func (h *SetNodeMaintenanceRequestHandler) Handle(ctx context.Context, command *SetNodeMaintenanceCommand) (*SetNodeMaintenanceResponse, error) {
+	node, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{Id: command.NodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return nil, apierror.New(apierror.CodeNodeNotFound, "storage node not found")
+	}
+	if node.FailedAt != nil {
+		return nil, apierror.New(apierror.CodeConflict, "storage node was marked failed, maintenance doesn't apply to it")
+	}
+
+	message := fmt.Sprintf("Storage node %s is in maintenance", node.Name)
+	if command.Enabled {
+		if node.MaintenanceAt == nil {
+			now := time.Now()
+			node.MaintenanceAt = &now
+		}
+		node.DrainReads = command.DrainReads
+	} else {
+		node.MaintenanceAt = nil
+		node.DrainReads = false
+		message = fmt.Sprintf("Storage node %s is out of maintenance", node.Name)
+	}
+
+	if err := h.dbContext.StorageNodes.Save(node); err != nil {
+		return nil, fmt.Errorf("failed to update storage node: %w", err)
+	}
+	log.Printf("Audit: user %s set maintenance of storage node %s (%s) to %t, draining reads: %t",
+		command.UserID, node.Name, node.Id, command.Enabled, node.DrainReads)
+
+	return &SetNodeMaintenanceResponse{
+		Node: models.StorageNodeResponse{
+			ID:          node.Id,
+			Name:        node.Name,
+			URL:         node.URL,
+			PublicURL:   node.PublicURL,
+			MaxStorage:  node.MaxStorage,
+			UsedStorage: node.UsedStorage,
+			Priority:    node.Priority,
+			Group:       node.Group,
+			Zone:        node.Zone,
+			IsActive:    node.IsActive,
+			IsHealthy:   node.IsHealthy,
+			CreatedAt:   node.CreatedAt,
+			UpdatedAt:   node.UpdatedAt,
+			LastPing:    node.LastPing,
+			FailedAt:    node.FailedAt,
+			RepairJobID: node.RepairJobId,
+			Status:      node.Status(),
+			Maintenance: maintenanceResponse(node),
+		},
+		Success: true,
+		Message: message,
+	}, nil
+}
+
+// maintenanceResponse describes a node's maintenance, nil when it isn't in maintenance
+func maintenanceResponse(node *entities.StorageNode) *models.NodeMaintenanceResponse {
+	if node.MaintenanceAt == nil {
+		return nil
+	}
+	return &models.NodeMaintenanceResponse{
+		Since:      *node.MaintenanceAt,
+		DrainReads: node.DrainReads,
+	}
+}
@@ -0,0 +1,158 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type UpdateNodeCommand struct {
+	NodeID     uuid.UUID `json:"node_id"`
+	Name       *string   `json:"name,omitempty"`
+	PublicURL  *string   `json:"public_url,omitempty"`
+	MaxStorage *int64    `json:"max_storage,omitempty"`
+	Priority   *int      `json:"priority,omitempty"`
+	Group      *string   `json:"group,omitempty"`
+	Zone       *string   `json:"zone,omitempty"`
+	IsActive   *bool     `json:"is_active,omitempty"`
+}
+
+type UpdateNodeResponse struct {
+	Node    models.StorageNodeResponse `json:"node"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+type UpdateNodeRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewUpdateNodeRequestHandler(dbContext *persistence.AppDbContext) *UpdateNodeRequestHandler {
+	return &UpdateNodeRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *UpdateNodeRequestHandler) Handle(ctx context.Context, command *UpdateNodeCommand) (*UpdateNodeResponse, error) {
+	node, err := h.dbContext.StorageNodes.Where(&entities.StorageNode{Id: command.NodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return nil, apierror.New(apierror.CodeNodeNotFound, "storage node not found")
+	}
+
+	if command.Name != nil {
+		node.Name = *command.Name
+	}
+	if command.PublicURL != nil {
+		node.PublicURL = *command.PublicURL
+	}
+	if command.MaxStorage != nil {
+		node.MaxStorage = *command.MaxStorage
+	}
+	if command.Priority != nil {
+		node.Priority = *command.Priority
+	}
+	if command.IsActive != nil {
+		// A failed node's content has been repaired elsewhere, what it still holds is stale
+		if *command.IsActive && node.FailedAt != nil {
+			return nil, apierror.New(apierror.CodeConflict, "storage node was marked failed and can't be reactivated")
+		}
+		node.IsActive = *command.IsActive
+	}
+	if command.Group != nil && *command.Group != node.Group {
+		if err := checkGroupStillServed(h.dbContext.GetDB().WithContext(ctx), node); err != nil {
+			return nil, err
+		}
+		node.Group = *command.Group
+	}
+	if command.Zone != nil && *command.Zone != node.Zone {
+		if err := checkZoneStillServed(h.dbContext.GetDB().WithContext(ctx), node); err != nil {
+			return nil, err
+		}
+		node.Zone = *command.Zone
+	}
+
+	if err := h.dbContext.StorageNodes.Save(node); err != nil {
+		return nil, fmt.Errorf("failed to update storage node: %w", err)
+	}
+
+	return &UpdateNodeResponse{
+		Node: models.StorageNodeResponse{
+			ID:          node.Id,
+			Name:        node.Name,
+			URL:         node.URL,
+			PublicURL:   node.PublicURL,
+			MaxStorage:  node.MaxStorage,
+			UsedStorage: node.UsedStorage,
+			Priority:    node.Priority,
+			Group:       node.Group,
+			Zone:        node.Zone,
+			IsActive:    node.IsActive,
+			IsHealthy:   node.IsHealthy,
+			CreatedAt:   node.CreatedAt,
+			UpdatedAt:   node.UpdatedAt,
+			LastPing:    node.LastPing,
+			FailedAt:    node.FailedAt,
+			RepairJobID: node.RepairJobId,
+			Status:      node.Status(),
+			Maintenance: maintenanceResponse(node),
+		},
+		Success: true,
+		Message: "Storage node updated successfully",
+	}, nil
+}
+
+// checkGroupStillServed refuses to take the last node out of a group that buckets are pinned to,
+// since their uploads would have nowhere to go
+func checkGroupStillServed(db *gorm.DB, node *entities.StorageNode) error {
+	if node.Group == "" {
+		return nil
+	}
+
+	var pinned int64
+	if err := db.Model(&entities.Bucket{}).Where(`"settings_PlacementGroup" = ?`, node.Group).Count(&pinned).Error; err != nil {
+		return fmt.Errorf("failed to check pinned buckets: %w", err)
+	}
+	if pinned == 0 {
+		return nil
+	}
+
+	var others int64
+	if err := db.Model(&entities.StorageNode{}).Where(`"node_group" = ? AND "Id" <> ?`, node.Group, node.Id).Count(&others).Error; err != nil {
+		return fmt.Errorf("failed to check node group: %w", err)
+	}
+	if others == 0 {
+		return apierror.Newf(apierror.CodeConflict, "%d bucket(s) are pinned to group %q and this is its last node", pinned, node.Group)
+	}
+	return nil
+}
+
+// checkZoneStillServed refuses to take the last node out of a zone that buckets are pinned to
+func checkZoneStillServed(db *gorm.DB, node *entities.StorageNode) error {
+	if node.Zone == "" {
+		return nil
+	}
+
+	var pinned int64
+	if err := db.Model(&entities.Bucket{}).Where(`"settings_PlacementZone" = ?`, node.Zone).Count(&pinned).Error; err != nil {
+		return fmt.Errorf("failed to check pinned buckets: %w", err)
+	}
+	if pinned == 0 {
+		return nil
+	}
+
+	var others int64
+	if err := db.Model(&entities.StorageNode{}).Where(`"Zone" = ? AND "Id" <> ?`, node.Zone, node.Id).Count(&others).Error; err != nil {
+		return fmt.Errorf("failed to check zone: %w", err)
+	}
+	if others == 0 {
+		return apierror.Newf(apierror.CodeConflict, "%d bucket(s) are pinned to zone %q and this is its last node", pinned, node.Zone)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,348 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/Backup"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Durability"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+const (
+	// backupLookupBatch is how many files' backup copies are looked up per query
+	backupLookupBatch = 500
+	// maxReportedLostFiles caps the names of unrecoverable files kept in the job result
+	maxReportedLostFiles = 100
+)
+
+type repairNodePayload struct {
+	NodeID uuid.UUID `json:"node_id"`
+}
+
+// repairer restores the files of a failed storage node from their backup copies, to the master
+// or to another node the file's bucket allows
+type repairer struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func newRepairer(dbContext *persistence.AppDbContext) *repairer {
+	return &repairer{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// damagedFile is a file whose primary copy was on the failed node
+type damagedFile struct {
+	file      entities.File
+	surviving int                    // healthy backup copies still holding its content
+	source    *entities.BackupObject // the copy at the configured destination it is restored from
+}
+
+// repairTarget is where restored content goes
+type repairTarget struct {
+	node     *entities.StorageNode // nil for the master
+	path     string
+	encoding string
+}
+
+func (r *repairer) run(ctx context.Context, run *jobs.Run) error {
+	var payload repairNodePayload
+	if err := run.Decode(&payload); err != nil {
+		return err
+	}
+
+	failedNode, err := r.dbContext.StorageNodes.Where(&entities.StorageNode{Id: payload.NodeID}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to fetch storage node: %w", err)
+	}
+	if failedNode == nil || failedNode.FailedAt == nil {
+		// Removed or no longer failed, nothing left to repair
+		return nil
+	}
+
+	destination, err := backup.NewDestination(r.settings)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("no backup destination to repair from: %w", err))
+	}
+
+	damaged, err := r.damagedFiles(ctx, failedNode, destination.Name())
+	if err != nil {
+		return err
+	}
+	total := int64(len(damaged))
+	run.Progress(0, total)
+
+	masterConfig, err := r.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to fetch master config: %w", err)
+	}
+	var masterFree int64
+	if masterConfig != nil && masterConfig.StoragePath != "" {
+		used, err := r.dbContext.Files.SumField("Size")
+		if err != nil {
+			return fmt.Errorf("failed to calculate master storage usage: %w", err)
+		}
+		masterFree = masterConfig.MaxStorage - int64(used)
+	}
+
+	buckets := make(map[uuid.UUID]*entities.Bucket)
+	repaired, failed := 0, 0
+	var lost []string
+	for i := range damaged {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item := &damaged[i]
+
+		if item.source == nil {
+			lost = append(lost, item.file.Name)
+			run.Progress(int64(i+1), total)
+			continue
+		}
+
+		bucket, ok := buckets[item.file.BucketId]
+		if !ok {
+			bucket, err = r.dbContext.Buckets.Where(&entities.Bucket{Id: item.file.BucketId}).FirstOrDefault()
+			if err != nil {
+				return fmt.Errorf("failed to fetch bucket: %w", err)
+			}
+			buckets[item.file.BucketId] = bucket
+		}
+		if bucket == nil {
+			lost = append(lost, item.file.Name)
+			run.Progress(int64(i+1), total)
+			continue
+		}
+
+		target, err := r.target(bucket, &item.file, masterConfig, masterFree)
+		if err == nil {
+			err = r.restore(ctx, destination, bucket, item, target)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to repair %s of failed node %s: %v", item.file.Name, failedNode.Name, err)
+			failed++
+		} else {
+			repaired++
+			if target.node == nil {
+				masterFree -= item.file.Size
+			}
+		}
+		run.Progress(int64(i+1), total)
+	}
+
+	// Files that failed are still on the failed node, so the next attempt picks them up again
+	if failed > 0 && !run.Final() {
+		return fmt.Errorf("%d of %d file(s) of node %s failed to repair", failed, total, failedNode.Name)
+	}
+
+	result := map[string]interface{}{
+		"node_id":  failedNode.Id,
+		"repaired": repaired,
+		"lost":     len(lost),
+		"failed":   failed,
+	}
+	if len(lost) > 0 {
+		result["lost_files"] = lost[:min(len(lost), maxReportedLostFiles)]
+	}
+	run.SetResult(result)
+	return nil
+}
+
+// damagedFiles lists the files stored on the failed node, those with the fewest surviving copies
+// first so the files closest to being lost are repaired before the rest
+func (r *repairer) damagedFiles(ctx context.Context, failedNode *entities.StorageNode, destination string) ([]damagedFile, error) {
+	db := r.dbContext.GetDB().WithContext(ctx)
+
+	files, err := filesOnNode(db, failedNode)
+	if err != nil {
+		return nil, err
+	}
+	backups, err := backupCopies(db, files)
+	if err != nil {
+		return nil, err
+	}
+
+	assessor, err := durability.NewAssessor(r.dbContext)
+	if err != nil {
+		return nil, err
+	}
+
+	damaged := make([]damagedFile, 0, len(files))
+	for _, file := range files {
+		item := damagedFile{file: file}
+		// The failed node's copy counts as unhealthy, so only backup copies remain
+		item.surviving = assessor.Assess(&file, backups[file.Id]).HealthyReplicas
+		// Backups hold plaintext, which can't be encrypted again without the customer's key
+		if !file.Encryption.CustomerEncrypted() {
+			objects := backups[file.Id]
+			for i := range objects {
+				if objects[i].Destination == destination && objects[i].Covers(&file) {
+					item.source = &objects[i]
+					break
+				}
+			}
+		}
+		damaged = append(damaged, item)
+	}
+
+	sort.SliceStable(damaged, func(i, j int) bool {
+		return damaged[i].surviving < damaged[j].surviving
+	})
+	return damaged, nil
+}
+
+// filesOnNode lists the files whose content is stored on a node
+func filesOnNode(db *gorm.DB, node *entities.StorageNode) ([]entities.File, error) {
+	var files []entities.File
+	if err := db.Where(`"Path" LIKE ?`, "node://"+node.Id.String()+"/%").Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to list files of storage node: %w", err)
+	}
+	return files, nil
+}
+
+// backupCopies returns the backup copies of files by file
+func backupCopies(db *gorm.DB, files []entities.File) (map[uuid.UUID][]entities.BackupObject, error) {
+	backups := make(map[uuid.UUID][]entities.BackupObject, len(files))
+	for start := 0; start < len(files); start += backupLookupBatch {
+		end := min(start+backupLookupBatch, len(files))
+		ids := make([]uuid.UUID, 0, end-start)
+		for _, file := range files[start:end] {
+			ids = append(ids, file.Id)
+		}
+		var objects []entities.BackupObject
+		if err := db.Where(`"FileId" IN ?`, ids).Find(&objects).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch backup copies: %w", err)
+		}
+		for _, object := range objects {
+			backups[object.FileId] = append(backups[object.FileId], object)
+		}
+	}
+	return backups, nil
+}
+
+// target picks where a file's content is restored: the master while it has room and the bucket
+// isn't pinned, otherwise a node the bucket's placement allows
+func (r *repairer) target(bucket *entities.Bucket, file *entities.File, masterConfig *entities.SetupConfig, masterFree int64) (*repairTarget, error) {
+	encoding := storage.ContentEncodingFor(bucket, file.MimeType)
+
+	if !placement.Pinned(bucket) && masterConfig != nil && masterConfig.StoragePath != "" && masterFree >= file.Size {
+		bucketDir := filepath.Join(masterConfig.StoragePath, bucket.Name)
+		if err := os.MkdirAll(bucketDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create bucket directory: %w", err)
+		}
+		return &repairTarget{path: filepath.Join(bucketDir, file.Id.String()), encoding: encoding}, nil
+	}
+
+	node, err := placement.SelectNode(r.dbContext, bucket, file.Size)
+	if err != nil {
+		return nil, err
+	}
+	return &repairTarget{
+		node:     node,
+		path:     fmt.Sprintf("node://%s/%s/%s", node.Id.String(), bucket.Id.String(), file.Id.String()),
+		encoding: encoding,
+	}, nil
+}
+
+// restore copies a file's backup to target, verifies it and points the file, and snapshots
+// preserving the same content, at the new copy
+func (r *repairer) restore(ctx context.Context, destination backup.Destination, bucket *entities.Bucket, item *damagedFile, target *repairTarget) error {
+	content, err := destination.Get(ctx, item.source)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	var checksum string
+	var fileEncryption entities.FileEncryption
+	if target.node == nil {
+		checksum, _, fileEncryption, err = storage.SaveBucketFile(r.dbContext, bucket, nil, target.encoding, target.path, content)
+		if err != nil {
+			return err
+		}
+	} else {
+		hash := sha256.New()
+		compressed, err := storage.Compress(target.encoding, io.TeeReader(content, hash))
+		if err != nil {
+			return err
+		}
+		encrypted, enc, err := storage.EncryptContent(r.dbContext, bucket, nil, compressed)
+		if err != nil {
+			return err
+		}
+		err = storage.UploadToNode(ctx, target.node.URL, target.node.AuthKey, storage.NodeUpload{
+			BucketID:    bucket.Id,
+			BucketName:  bucket.Name,
+			FileID:      item.file.Id,
+			Name:        item.file.Name,
+			ContentType: item.file.MimeType,
+		}, encrypted)
+		if err != nil {
+			return fmt.Errorf("failed to upload to storage node: %w", err)
+		}
+		checksum = fmt.Sprintf("%x", hash.Sum(nil))
+		fileEncryption = enc
+	}
+
+	if checksum != item.source.Checksum {
+		storage.RemoveFile(ctx, r.dbContext, target.path)
+		return fmt.Errorf("checksum mismatch")
+	}
+
+	return r.dbContext.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return recordRestore(tx, item, target, checksum, fileEncryption)
+	})
+}
+
+// recordRestore points a restored file, and snapshots preserving the same content, at its new
+// copy and counts the copy on the node it went to
+func recordRestore(tx *gorm.DB, item *damagedFile, target *repairTarget, checksum string, fileEncryption entities.FileEncryption) error {
+	// The verified checksum is recorded so the backup copy keeps covering the file
+	if err := tx.Model(&entities.File{Id: item.file.Id}).Updates(map[string]interface{}{
+		"Path":                      target.path,
+		"Checksum":                  checksum,
+		"metadata_ContentEncoding":  target.encoding,
+		"encryption_KeyId":          fileEncryption.KeyId,
+		"encryption_WrappedKey":     fileEncryption.WrappedKey,
+		"encryption_CustomerKeyMD5": fileEncryption.CustomerKeyMD5,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update file record: %w", err)
+	}
+	if err := tx.Model(&entities.BackupObject{Id: item.source.Id}).
+		Update("SourceChecksum", checksum).Error; err != nil {
+		return fmt.Errorf("failed to update backup record: %w", err)
+	}
+	if err := tx.Model(&entities.SnapshotFile{}).Where(`"Path" = ?`, item.file.Path).Updates(map[string]interface{}{
+		"Path":                      target.path,
+		"ContentEncoding":           target.encoding,
+		"encryption_KeyId":          fileEncryption.KeyId,
+		"encryption_WrappedKey":     fileEncryption.WrappedKey,
+		"encryption_CustomerKeyMD5": fileEncryption.CustomerKeyMD5,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update snapshots: %w", err)
+	}
+	if target.node != nil {
+		if err := tx.Model(&entities.StorageNode{Id: target.node.Id}).
+			Update("UsedStorage", gorm.Expr(`"UsedStorage" + ?`, item.file.Size)).Error; err != nil {
+			return fmt.Errorf("failed to update node usage: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestDamagedFileRecords finds the files stored on a failed node and their backup copies
+func TestDamagedFileRecords(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	failed := entities.StorageNode{Name: "failed", URL: "http://failed", AuthKey: "key"}
+	if err := db.Create(&failed).Error; err != nil {
+		t.Fatal(err)
+	}
+	onNode := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "node://" + failed.Id.String() + "/" + bucket.Id.String() + "/a", UploadedBy: bucket.OwnerId}
+	onMaster := entities.File{BucketId: bucket.Id, Name: "b.jpg", OriginalName: "b.jpg", Path: "/data/photos/b", UploadedBy: bucket.OwnerId}
+	for _, file := range []*entities.File{&onNode, &onMaster} {
+		if err := db.Create(file).Error; err != nil {
+			t.Fatal(err)
+		}
+		object := entities.BackupObject{Destination: "s3:backups", FileId: file.Id, BucketId: bucket.Id, BucketName: bucket.Name, Name: file.Name, RunId: uuid.New()}
+		if err := db.Create(&object).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filesOnNode(db, &failed)
+	if err != nil {
+		t.Fatalf("filesOnNode() = %v", err)
+	}
+	if len(files) != 1 || files[0].Id != onNode.Id {
+		t.Fatalf("filesOnNode() = %+v, want the file stored on the node", files)
+	}
+
+	backups, err := backupCopies(db, files)
+	if err != nil {
+		t.Fatalf("backupCopies() = %v", err)
+	}
+	if len(backups) != 1 || len(backups[onNode.Id]) != 1 {
+		t.Errorf("backupCopies() = %+v, want the copy of the node's file", backups)
+	}
+}
+
+// TestRecordRestore points the file and its snapshots at the restored copy and counts it on its node
+func TestRecordRestore(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	node := entities.StorageNode{Name: "target", URL: "http://target", AuthKey: "key", UsedStorage: 100}
+	if err := db.Create(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	file := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "node://failed/" + bucket.Id.String() + "/a", Size: 10, UploadedBy: bucket.OwnerId}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	source := entities.BackupObject{Destination: "s3:backups", FileId: file.Id, BucketId: bucket.Id, BucketName: bucket.Name, Name: file.Name, SourceChecksum: "old", RunId: uuid.New()}
+	if err := db.Create(&source).Error; err != nil {
+		t.Fatal(err)
+	}
+	preserved := entities.SnapshotFile{SnapshotId: uuid.New(), FileId: file.Id, Name: file.Name, Path: file.Path}
+	if err := db.Create(&preserved).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	keyID := uuid.New()
+	target := &repairTarget{node: &node, path: "node://" + node.Id.String() + "/" + bucket.Id.String() + "/a", encoding: "gzip"}
+	item := &damagedFile{file: file, source: &source}
+	if err := recordRestore(db, item, target, "new", entities.FileEncryption{KeyId: &keyID, WrappedKey: []byte("wrapped")}); err != nil {
+		t.Fatalf("recordRestore() = %v", err)
+	}
+
+	var restored entities.File
+	if err := db.First(&restored, `"Id" = ?`, file.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if restored.Path != target.path || restored.Checksum != "new" || restored.Metadata.ContentEncoding != "gzip" || restored.Encryption.KeyId == nil || *restored.Encryption.KeyId != keyID {
+		t.Errorf("restored file = %+v, want it pointed at the new copy", restored)
+	}
+	var snapshot entities.SnapshotFile
+	if err := db.First(&snapshot, `"Id" = ?`, preserved.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Path != target.path || snapshot.ContentEncoding != "gzip" || string(snapshot.Encryption.WrappedKey) != "wrapped" {
+		t.Errorf("snapshot file = %+v, want it pointed at the new copy", snapshot)
+	}
+	var object entities.BackupObject
+	if err := db.First(&object, `"Id" = ?`, source.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if object.SourceChecksum != "new" {
+		t.Errorf("backup SourceChecksum = %q, want %q", object.SourceChecksum, "new")
+	}
+	var counted entities.StorageNode
+	if err := db.First(&counted, `"Id" = ?`, node.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if counted.UsedStorage != 110 {
+		t.Errorf("node UsedStorage = %d, want 110", counted.UsedStorage)
+	}
+}
@@ -0,0 +1,58 @@
+package node
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Models"
+)
+
+// RegistrationTokenPrefix starts every node registration token, telling them apart from API keys (shb_),
+// file tokens (shf_) and upload link tokens (shu_)
+const RegistrationTokenPrefix = "shn_"
+
+// ErrRegistrationTokenInvalid is returned for registration tokens that are unknown, used, revoked or expired
+var ErrRegistrationTokenInvalid = apierror.New(apierror.CodeInvalidCredentials, "invalid or expired registration token")
+
+// hashRegistrationToken is what is stored for a registration token
+func hashRegistrationToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// generateRegistrationToken returns a new token with the hash and prefix stored for it
+func generateRegistrationToken() (token, tokenHash, tokenPrefix string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	token = RegistrationTokenPrefix + hex.EncodeToString(bytes)
+	return token, hashRegistrationToken(token), token[:12], nil
+}
+
+// generateNodeAuthKey returns the key a self-registered node and the master authenticate to each other with
+func generateNodeAuthKey() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "shbucket_node_auth_" + hex.EncodeToString(bytes), nil
+}
+
+func ToRegistrationTokenResponse(token *entities.NodeRegistrationToken) models.NodeRegistrationTokenResponse {
+	return models.NodeRegistrationTokenResponse{
+		ID:          token.Id,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		ExpiresAt:   token.ExpiresAt,
+		CreatedBy:   token.CreatedBy,
+		CreatedAt:   token.CreatedAt,
+		UsedAt:      token.UsedAt,
+		NodeID:      token.NodeId,
+		RevokedAt:   token.RevokedAt,
+	}
+}
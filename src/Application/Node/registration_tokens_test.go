@@ -0,0 +1,71 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRegisterWithToken spends a registration token once, on the node it registers
+func TestRegisterWithToken(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	token, tokenHash, tokenPrefix, err := generateRegistrationToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := entities.NodeRegistrationToken{TokenHash: tokenHash, TokenPrefix: tokenPrefix, ExpiresAt: now.Add(time.Hour)}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	node := entities.StorageNode{Id: uuid.New(), Name: "node-1", URL: "http://node-1", AuthKey: "key"}
+	if err := registerWithToken(db, token, &node, now); err != nil {
+		t.Fatalf("registerWithToken() = %v", err)
+	}
+	again := entities.StorageNode{Id: uuid.New(), Name: "node-2", URL: "http://node-2", AuthKey: "key"}
+	if err := registerWithToken(db, token, &again, now); !errors.Is(err, ErrRegistrationTokenInvalid) {
+		t.Errorf("registerWithToken() with a spent token = %v, want ErrRegistrationTokenInvalid", err)
+	}
+
+	var spent entities.NodeRegistrationToken
+	if err := db.First(&spent, `"Id" = ?`, record.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if spent.UsedAt == nil || spent.NodeId == nil || *spent.NodeId != node.Id {
+		t.Errorf("spent token = %+v, want it used by node %s", spent, node.Id)
+	}
+}
+
+// TestRevokeRegistrationToken revokes tokens no node was registered with
+func TestRevokeRegistrationToken(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	unused := entities.NodeRegistrationToken{TokenHash: "unused", TokenPrefix: "shn_", ExpiresAt: now.Add(time.Hour)}
+	used := entities.NodeRegistrationToken{TokenHash: "used", TokenPrefix: "shn_", ExpiresAt: now.Add(time.Hour), UsedAt: &now}
+	for _, token := range []*entities.NodeRegistrationToken{&unused, &used} {
+		if err := db.Create(token).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := revokeRegistrationToken(db, &unused, now); err != nil {
+		t.Errorf("revokeRegistrationToken() = %v", err)
+	}
+	if err := revokeRegistrationToken(db, &used, now); !errors.Is(err, errTokenUsed) {
+		t.Errorf("revokeRegistrationToken() of a used token = %v, want errTokenUsed", err)
+	}
+
+	var revoked entities.NodeRegistrationToken
+	if err := db.First(&revoked, `"Id" = ?`, unused.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if revoked.RevokedAt == nil {
+		t.Error("revoked token RevokedAt = nil, want the time it was revoked")
+	}
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestCheckGroupStillServed refuses to take the last node out of a group buckets are pinned to
+func TestCheckGroupStillServed(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	if err := db.Model(&bucket).Update("settings_PlacementGroup", "eu").Error; err != nil {
+		t.Fatal(err)
+	}
+	var nodes []entities.StorageNode
+	for i, group := range []string{"eu", "eu", "us"} {
+		node := entities.StorageNode{Name: fmt.Sprintf("node-%d", i), URL: fmt.Sprintf("http://node-%d", i), AuthKey: "key", Group: group}
+		if err := db.Create(&node).Error; err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := checkGroupStillServed(db, &nodes[0]); err != nil {
+		t.Errorf("checkGroupStillServed() with another node in the group = %v, want nil", err)
+	}
+	if err := checkGroupStillServed(db, &nodes[2]); err != nil {
+		t.Errorf("checkGroupStillServed() of a group without pinned buckets = %v, want nil", err)
+	}
+
+	if err := db.Delete(&nodes[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := checkGroupStillServed(db, &nodes[0]); err == nil {
+		t.Error("checkGroupStillServed() of the group's last node = nil, want a conflict")
+	}
+}
+
+// TestCheckZoneStillServed refuses to take the last node out of a zone buckets are pinned to
+func TestCheckZoneStillServed(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	if err := db.Model(&bucket).Update("settings_PlacementZone", "rack-1").Error; err != nil {
+		t.Fatal(err)
+	}
+	var nodes []entities.StorageNode
+	for i, zone := range []string{"rack-1", "rack-1", "rack-2"} {
+		node := entities.StorageNode{Name: fmt.Sprintf("node-%d", i), URL: fmt.Sprintf("http://node-%d", i), AuthKey: "key", Zone: zone}
+		if err := db.Create(&node).Error; err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := checkZoneStillServed(db, &nodes[0]); err != nil {
+		t.Errorf("checkZoneStillServed() with another node in the zone = %v, want nil", err)
+	}
+	if err := checkZoneStillServed(db, &nodes[2]); err != nil {
+		t.Errorf("checkZoneStillServed() of a zone without pinned buckets = %v, want nil", err)
+	}
+
+	if err := db.Delete(&nodes[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := checkZoneStillServed(db, &nodes[0]); err == nil {
+		t.Error("checkZoneStillServed() of the zone's last node = nil, want a conflict")
+	}
+}
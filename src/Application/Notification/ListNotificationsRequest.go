@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListNotificationsCommand struct {
+	UserID     uuid.UUID `json:"user_id"`
+	UnreadOnly bool      `json:"unread_only"`
+	Limit      int       `json:"limit"`
+}
+
+type ListNotificationsResponse struct {
+	Notifications []models.NotificationResponse `json:"notifications"`
+	Unread        int64                         `json:"unread"`
+	Success       bool                          `json:"success"`
+	Message       string                        `json:"message"`
+}
+
+type ListNotificationsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListNotificationsRequestHandler(dbContext *persistence.AppDbContext) *ListNotificationsRequestHandler {
+	return &ListNotificationsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *ListNotificationsRequestHandler) Handle(ctx context.Context, command *ListNotificationsCommand) (*ListNotificationsResponse, error) {
+	limit := command.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	notifications, unread, err := listNotifications(h.dbContext.GetDB().WithContext(ctx), command.UserID, command.UnreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationResponses := make([]models.NotificationResponse, len(notifications))
+	for i, notification := range notifications {
+		notificationResponses[i] = models.NotificationResponse{
+			ID:        notification.Id,
+			Type:      notification.Type,
+			Message:   notification.Message,
+			ActorID:   notification.ActorId,
+			BucketID:  notification.BucketId,
+			FileID:    notification.FileId,
+			CommentID: notification.CommentId,
+			ReadAt:    notification.ReadAt,
+			CreatedAt: notification.CreatedAt,
+		}
+	}
+
+	return &ListNotificationsResponse{
+		Notifications: notificationResponses,
+		Unread:        unread,
+		Success:       true,
+		Message:       "Notifications retrieved successfully",
+	}, nil
+}
+
+// listNotifications returns up to limit of the user's notifications, newest first, and how many of
+// all of them are unread
+func listNotifications(db *gorm.DB, userID uuid.UUID, unreadOnly bool, limit int) ([]entities.Notification, int64, error) {
+	var unread int64
+	if err := db.Model(&entities.Notification{}).Where(`"UserId" = ? AND "ReadAt" IS NULL`, userID).Count(&unread).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	query := db.Where(`"UserId" = ?`, userID)
+	if unreadOnly {
+		query = query.Where(`"ReadAt" IS NULL`)
+	}
+
+	var notifications []entities.Notification
+	if err := query.Order(`"CreatedAt" DESC`).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	return notifications, unread, nil
+}
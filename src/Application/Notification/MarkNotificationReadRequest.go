@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type MarkNotificationReadCommand struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+	UserID         uuid.UUID `json:"user_id"`
+}
+
+type MarkNotificationReadResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type MarkNotificationReadRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewMarkNotificationReadRequestHandler(dbContext *persistence.AppDbContext) *MarkNotificationReadRequestHandler {
+	return &MarkNotificationReadRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *MarkNotificationReadRequestHandler) Handle(ctx context.Context, command *MarkNotificationReadCommand) (*MarkNotificationReadResponse, error) {
+	notification, err := h.dbContext.Notifications.Where(&entities.Notification{
+		Id:     command.NotificationID,
+		UserId: command.UserID,
+	}).FirstOrDefault()
+	if err != nil || notification == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "notification not found")
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		h.dbContext.Notifications.Update(*notification)
+		if err := h.dbContext.SaveChanges(); err != nil {
+			return nil, fmt.Errorf("failed to update notification: %w", err)
+		}
+	}
+
+	return &MarkNotificationReadResponse{
+		Success: true,
+		Message: "Notification marked as read",
+	}, nil
+}
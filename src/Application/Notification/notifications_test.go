@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestListNotifications lists a user's notifications newest first and counts the unread ones
+func TestListNotifications(t *testing.T) {
+	db := sqlitetest.Open(t)
+	userID := uuid.New()
+	now := time.Now()
+	for i, read := range []bool{true, false, false} {
+		notification := entities.Notification{UserId: userID, Type: "mention", Message: "mentioned", CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+		if read {
+			notification.ReadAt = &now
+		}
+		if err := db.Create(&notification).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := entities.Notification{UserId: uuid.New(), Type: "mention", Message: "mentioned"}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	notifications, unread, err := listNotifications(db, userID, false, 2)
+	if err != nil {
+		t.Fatalf("listNotifications() = %v", err)
+	}
+	if unread != 2 {
+		t.Errorf("unread = %d, want 2", unread)
+	}
+	if len(notifications) != 2 || notifications[0].CreatedAt.Before(notifications[1].CreatedAt) {
+		t.Errorf("listNotifications() = %+v, want the two newest, newest first", notifications)
+	}
+
+	notifications, _, err = listNotifications(db, userID, true, 50)
+	if err != nil {
+		t.Fatalf("listNotifications() of unread = %v", err)
+	}
+	for _, notification := range notifications {
+		if notification.ReadAt != nil {
+			t.Errorf("listNotifications() of unread returned read notification %s", notification.Id)
+		}
+	}
+	if len(notifications) != 2 {
+		t.Errorf("listNotifications() of unread = %d notifications, want 2", len(notifications))
+	}
+}
@@ -0,0 +1,449 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// ErrBucketNotFound is returned when the export is asked for a bucket that doesn't exist
+var ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+
+// ErrUserNotFound is returned when the export is asked for a user that doesn't exist
+var ErrUserNotFound = apierror.New(apierror.CodeUserNotFound, "user not found")
+
+// roleLevels ranks the roles like the authorization service does, unknown roles get nothing
+var roleLevels = map[string]int{
+	"viewer":  1,
+	"editor":  2,
+	"manager": 3,
+	"admin":   4,
+}
+
+type ExportPermissionsCommand struct {
+	BucketID *uuid.UUID `json:"bucket_id,omitempty"` // only access to this bucket
+	UserID   *uuid.UUID `json:"user_id,omitempty"`   // only this user and the credentials they hold or issued
+}
+
+type ExportPermissionsResponse struct {
+	Entries     []models.PermissionEntryResponse `json:"entries"`
+	GeneratedAt time.Time                        `json:"generated_at"`
+	Success     bool                             `json:"success"`
+	Message     string                           `json:"message"`
+}
+
+type ExportPermissionsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewExportPermissionsRequestHandler(dbContext *persistence.AppDbContext) *ExportPermissionsRequestHandler {
+	return &ExportPermissionsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// export holds what the entries are built from
+type export struct {
+	db           *gorm.DB
+	buckets      []entities.Bucket
+	bucketsByID  map[uuid.UUID]*entities.Bucket
+	users        map[uuid.UUID]*entities.User
+	bucketAdmins map[uuid.UUID]map[uuid.UUID]bool // bucket, then user
+	now          time.Time
+}
+
+// Handle lists who can access which bucket with what rights right now: every active user, the live
+// API keys of active users, unrevoked file tokens, upload links and signed URLs still usable, and
+// anonymous readers of public buckets. Rights are the ones the server enforces, so a user's role
+// grants them in every bucket, and ownership or bucket admin adds managing it.
+func (h *ExportPermissionsRequestHandler) Handle(ctx context.Context, command *ExportPermissionsCommand) (*ExportPermissionsResponse, error) {
+	now := time.Now()
+	entries, err := exportPermissions(h.dbContext.GetDB().WithContext(ctx), command, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportPermissionsResponse{
+		Entries:     entries,
+		GeneratedAt: now,
+		Success:     true,
+		Message:     "Permissions exported successfully",
+	}, nil
+}
+
+// exportPermissions builds the entries of the export as of now
+func exportPermissions(db *gorm.DB, command *ExportPermissionsCommand, now time.Time) ([]models.PermissionEntryResponse, error) {
+	e := &export{
+		db:           db,
+		bucketsByID:  make(map[uuid.UUID]*entities.Bucket),
+		users:        make(map[uuid.UUID]*entities.User),
+		bucketAdmins: make(map[uuid.UUID]map[uuid.UUID]bool),
+		now:          now,
+	}
+
+	query := db.Model(&entities.Bucket{})
+	if command.BucketID != nil {
+		query = query.Where(`"Id" = ?`, *command.BucketID)
+	}
+	if err := query.Order(`"Name"`).Find(&e.buckets).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch buckets: %w", err)
+	}
+	if command.BucketID != nil && len(e.buckets) == 0 {
+		return nil, ErrBucketNotFound
+	}
+	for i := range e.buckets {
+		e.bucketsByID[e.buckets[i].Id] = &e.buckets[i]
+	}
+
+	var users []entities.User
+	if err := db.Where(`"IsActive" = ?`, true).Order(`"Username"`).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	for i := range users {
+		e.users[users[i].Id] = &users[i]
+	}
+	if command.UserID != nil {
+		var count int64
+		if err := db.Model(&entities.User{}).Where(&entities.User{Id: *command.UserID}).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch user: %w", err)
+		}
+		if count == 0 {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	var grants []entities.BucketAdminGrant
+	if err := db.Find(&grants).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch bucket admins: %w", err)
+	}
+	for _, grant := range grants {
+		if e.bucketAdmins[grant.BucketId] == nil {
+			e.bucketAdmins[grant.BucketId] = make(map[uuid.UUID]bool)
+		}
+		e.bucketAdmins[grant.BucketId][grant.UserId] = true
+	}
+
+	entries := []models.PermissionEntryResponse{}
+	if command.UserID == nil {
+		entries = append(entries, e.publicEntries()...)
+	}
+	entries = append(entries, e.userEntries(users, command.UserID)...)
+
+	keyEntries, err := e.apiKeyEntries(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, keyEntries...)
+
+	tokenEntries, err := e.fileTokenEntries(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, tokenEntries...)
+
+	grantEntries, err := e.uploadGrantEntries(command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, grantEntries...)
+
+	// Signed URLs don't record who issued them
+	if command.UserID == nil {
+		signedEntries, err := e.signedURLEntries()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, signedEntries...)
+	}
+	return entries, nil
+}
+
+// publicEntries lets anyone read public buckets
+func (e *export) publicEntries() []models.PermissionEntryResponse {
+	var entries []models.PermissionEntryResponse
+	for _, bucket := range e.buckets {
+		if !bucket.Settings.PublicRead {
+			continue
+		}
+		entries = append(entries, models.PermissionEntryResponse{
+			PrincipalType: models.PrincipalPublic,
+			Principal:     "anyone",
+			BucketID:      bucket.Id,
+			BucketName:    bucket.Name,
+			Rights:        []string{models.RightRead},
+			Via:           []string{"public_read"},
+		})
+	}
+	return entries
+}
+
+// userEntries lists each active user's rights in every bucket
+func (e *export) userEntries(users []entities.User, only *uuid.UUID) []models.PermissionEntryResponse {
+	var entries []models.PermissionEntryResponse
+	for i := range users {
+		user := &users[i]
+		if only != nil && user.Id != *only {
+			continue
+		}
+		for j := range e.buckets {
+			bucket := &e.buckets[j]
+			rights, via := e.userRights(user, bucket)
+			if len(rights) == 0 {
+				continue
+			}
+			entries = append(entries, models.PermissionEntryResponse{
+				PrincipalType: models.PrincipalUser,
+				PrincipalID:   &user.Id,
+				Principal:     user.Username,
+				UserID:        &user.Id,
+				Username:      user.Username,
+				BucketID:      bucket.Id,
+				BucketName:    bucket.Name,
+				Rights:        rights,
+				Via:           via,
+				LastUsedAt:    user.LastLoginTime,
+			})
+		}
+	}
+	return entries
+}
+
+// userRights is what the user's role, ownership and bucket admin grants let them do in the bucket
+func (e *export) userRights(user *entities.User, bucket *entities.Bucket) ([]string, []string) {
+	role := strings.ToLower(user.Role)
+	level := roleLevels[role]
+	if level == 0 {
+		return nil, nil
+	}
+
+	owner := bucket.OwnerId == user.Id
+	bucketAdmin := e.bucketAdmins[bucket.Id][user.Id]
+
+	rights := []string{models.RightRead, models.RightSignURLs}
+	via := []string{"role:" + role}
+	if owner {
+		via = append(via, "owner")
+	}
+	if bucketAdmin {
+		via = append(via, "bucket_admin")
+	}
+	if level >= roleLevels["editor"] {
+		rights = append(rights, models.RightWrite)
+		if owner || bucketAdmin || role == "admin" {
+			rights = append(rights, models.RightManage)
+		}
+	}
+	if level >= roleLevels["manager"] && owner {
+		rights = append(rights, models.RightDeleteBucket)
+	}
+	return rights, via
+}
+
+// apiKeyEntries lists what each live API key can do: its user's rights, limited by the key's
+// permissions and, when it names any, to its buckets
+func (e *export) apiKeyEntries(only *uuid.UUID) ([]models.PermissionEntryResponse, error) {
+	var keys []entities.APIKey
+	query := e.db.Where(`"IsActive" = ? AND ("ExpiresAt" IS NULL OR "ExpiresAt" > ?)`, true, e.now)
+	if only != nil {
+		query = query.Where(`"UserId" = ?`, *only)
+	}
+	if err := query.Order(`"CreatedAt"`).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch API keys: %w", err)
+	}
+
+	var entries []models.PermissionEntryResponse
+	for i := range keys {
+		key := &keys[i]
+		user := e.users[key.UserId]
+		if user == nil {
+			// Keys of disabled users are refused
+			continue
+		}
+		var permissions entities.APIKeyPermission
+		if err := json.Unmarshal(key.Permissions, &permissions); err != nil || !permissions.Read {
+			continue
+		}
+
+		scope := "api_key:all_buckets"
+		if len(permissions.Buckets) > 0 {
+			scope = "api_key:scoped"
+		}
+		for j := range e.buckets {
+			bucket := &e.buckets[j]
+			if len(permissions.Buckets) > 0 && !slices.Contains(permissions.Buckets, bucket.Id.String()) {
+				continue
+			}
+			rights, via := e.userRights(user, bucket)
+			if !permissions.Write {
+				// Read-only keys only pass viewer checks
+				rights = slices.DeleteFunc(rights, func(right string) bool {
+					return right != models.RightRead && right != models.RightSignURLs
+				})
+			}
+			if len(rights) == 0 {
+				continue
+			}
+			entries = append(entries, models.PermissionEntryResponse{
+				PrincipalType: models.PrincipalAPIKey,
+				PrincipalID:   &key.Id,
+				Principal:     credentialName(key.Name, key.KeyPrefix),
+				UserID:        &user.Id,
+				Username:      user.Username,
+				BucketID:      bucket.Id,
+				BucketName:    bucket.Name,
+				Rights:        rights,
+				Via:           append([]string{scope}, via...),
+				ExpiresAt:     key.ExpiresAt,
+				LastUsedAt:    key.LastUsed,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// fileTokenEntries lists the unrevoked file tokens, each reading one file
+func (e *export) fileTokenEntries(only *uuid.UUID) ([]models.PermissionEntryResponse, error) {
+	var tokens []struct {
+		entities.FileToken
+		FileName string
+	}
+	query := e.db.Model(&entities.FileToken{}).
+		Select(`"FileToken".*, "File"."Name" AS "FileName"`).
+		Joins(`JOIN "File" ON "File"."Id" = "FileToken"."FileId"`).
+		Where(`"FileToken"."RevokedAt" IS NULL`)
+	if only != nil {
+		query = query.Where(`"FileToken"."CreatedBy" = ?`, *only)
+	}
+	if err := query.Order(`"FileToken"."CreatedAt"`).Scan(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch file tokens: %w", err)
+	}
+
+	var entries []models.PermissionEntryResponse
+	for i := range tokens {
+		token := &tokens[i]
+		bucket := e.bucketsByID[token.BucketId]
+		if bucket == nil {
+			continue
+		}
+		entries = append(entries, models.PermissionEntryResponse{
+			PrincipalType: models.PrincipalFileToken,
+			PrincipalID:   &token.Id,
+			Principal:     credentialName(token.Name, token.TokenPrefix),
+			UserID:        &token.CreatedBy,
+			Username:      e.username(token.CreatedBy),
+			BucketID:      bucket.Id,
+			BucketName:    bucket.Name,
+			FileID:        &token.FileId,
+			FileName:      token.FileName,
+			Rights:        []string{models.RightRead},
+			Via:           []string{"file_token"},
+			LastUsedAt:    token.LastUsedAt,
+		})
+	}
+	return entries, nil
+}
+
+// uploadGrantEntries lists the upload links that can still take uploads
+func (e *export) uploadGrantEntries(only *uuid.UUID) ([]models.PermissionEntryResponse, error) {
+	var grants []entities.SignedUploadGrant
+	query := e.db.Where(`"RevokedAt" IS NULL AND "ExpiresAt" > ?`, e.now).
+		Where(`"MaxFiles" = 0 OR "UploadCount" < "MaxFiles"`)
+	if only != nil {
+		query = query.Where(`"CreatedBy" = ?`, *only)
+	}
+	if err := query.Order(`"CreatedAt"`).Find(&grants).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch upload links: %w", err)
+	}
+
+	var entries []models.PermissionEntryResponse
+	for i := range grants {
+		grant := &grants[i]
+		bucket := e.bucketsByID[grant.BucketId]
+		if bucket == nil {
+			continue
+		}
+		via := []string{"upload_link"}
+		if grant.Prefix != "" {
+			via = append(via, "prefix:"+grant.Prefix)
+		}
+		entries = append(entries, models.PermissionEntryResponse{
+			PrincipalType: models.PrincipalUploadGrant,
+			PrincipalID:   &grant.Id,
+			Principal:     credentialName(grant.Name, grant.TokenPrefix),
+			UserID:        &grant.CreatedBy,
+			Username:      e.username(grant.CreatedBy),
+			BucketID:      bucket.Id,
+			BucketName:    bucket.Name,
+			Rights:        []string{models.RightUpload},
+			Via:           via,
+			ExpiresAt:     &grant.ExpiresAt,
+			LastUsedAt:    grant.LastUsedAt,
+		})
+	}
+	return entries, nil
+}
+
+// signedURLEntries lists the signed URLs that haven't expired or been used up
+func (e *export) signedURLEntries() ([]models.PermissionEntryResponse, error) {
+	var signedURLs []entities.SignedURL
+	if err := e.db.Where(`"ExpiresAt" > ? AND NOT ("SingleUse" AND "Used")`, e.now).
+		Order(`"CreatedAt"`).Find(&signedURLs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch signed URLs: %w", err)
+	}
+
+	bucketsByName := make(map[string]*entities.Bucket, len(e.buckets))
+	for i := range e.buckets {
+		bucketsByName[e.buckets[i].Name] = &e.buckets[i]
+	}
+
+	var entries []models.PermissionEntryResponse
+	for i := range signedURLs {
+		signedURL := &signedURLs[i]
+		bucket := bucketsByName[signedURL.BucketName]
+		if bucket == nil {
+			continue
+		}
+		via := []string{"signed_url"}
+		if signedURL.SingleUse {
+			via = append(via, "single_use")
+		}
+		entries = append(entries, models.PermissionEntryResponse{
+			PrincipalType: models.PrincipalSignedURL,
+			PrincipalID:   &signedURL.ID,
+			Principal:     credentialName("", signedURL.Signature[:min(8, len(signedURL.Signature))]),
+			BucketID:      bucket.Id,
+			BucketName:    bucket.Name,
+			FileName:      signedURL.FileName,
+			Rights:        []string{models.RightRead},
+			Via:           via,
+			ExpiresAt:     &signedURL.ExpiresAt,
+		})
+	}
+	return entries, nil
+}
+
+func (e *export) username(userID uuid.UUID) string {
+	if user := e.users[userID]; user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+// credentialName identifies a credential by its name and prefix, never by its secret
+func credentialName(name, prefix string) string {
+	if name == "" {
+		return prefix + "..."
+	}
+	return fmt.Sprintf("%s (%s...)", name, prefix)
+}
@@ -0,0 +1,61 @@
+package permission
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Models"
+)
+
+// csvHeader names the columns of the CSV export, multi-valued columns are separated by semicolons
+var csvHeader = []string{
+	"principal_type", "principal_id", "principal", "user_id", "username", "bucket_id", "bucket_name",
+	"file_id", "file_name", "rights", "via", "expires_at", "last_used_at",
+}
+
+// WriteCSV writes permission entries as CSV, one row per entry, for spreadsheets and review tools
+func WriteCSV(w io.Writer, entries []models.PermissionEntryResponse) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := out.Write([]string{
+			entry.PrincipalType,
+			optionalID(entry.PrincipalID),
+			entry.Principal,
+			optionalID(entry.UserID),
+			entry.Username,
+			entry.BucketID.String(),
+			entry.BucketName,
+			optionalID(entry.FileID),
+			entry.FileName,
+			strings.Join(entry.Rights, ";"),
+			strings.Join(entry.Via, ";"),
+			optionalTime(entry.ExpiresAt),
+			optionalTime(entry.LastUsedAt),
+		}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
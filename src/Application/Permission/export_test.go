@@ -0,0 +1,64 @@
+package permission
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+	"shbucket/src/Models"
+)
+
+// TestExportPermissions lists the live credentials of every kind and leaves out the spent ones
+func TestExportPermissions(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	if err := db.Model(&entities.User{Id: bucket.OwnerId}).Update("Role", "editor").Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	file := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "/data/photos/a", UploadedBy: bucket.OwnerId}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []interface{}{
+		&entities.APIKey{Name: "live", KeyHash: "live", KeyPrefix: "shb_live", UserId: bucket.OwnerId, IsActive: true, Permissions: datatypes.JSON(`{"read":true}`)},
+		&entities.APIKey{Name: "expired", KeyHash: "expired", KeyPrefix: "shb_expired", UserId: bucket.OwnerId, IsActive: true, Permissions: datatypes.JSON(`{"read":true}`), ExpiresAt: &past},
+		&entities.FileToken{FileId: file.Id, BucketId: bucket.Id, Name: "share", TokenHash: "live", TokenPrefix: "shf_live", CreatedBy: bucket.OwnerId},
+		&entities.FileToken{FileId: file.Id, BucketId: bucket.Id, Name: "revoked", TokenHash: "revoked", TokenPrefix: "shf_revoked", CreatedBy: bucket.OwnerId, RevokedAt: &past},
+		&entities.SignedUploadGrant{BucketId: bucket.Id, Name: "drop", TokenHash: "live", TokenPrefix: "shu_live", MaxFiles: 2, UploadCount: 1, ExpiresAt: future, CreatedBy: bucket.OwnerId},
+		&entities.SignedUploadGrant{BucketId: bucket.Id, Name: "full", TokenHash: "full", TokenPrefix: "shu_full", MaxFiles: 1, UploadCount: 1, ExpiresAt: future, CreatedBy: bucket.OwnerId},
+		&entities.SignedURL{Signature: "livesignature", BucketName: bucket.Name, FileName: "a.jpg", Method: "GET", ExpiresAt: future},
+		&entities.SignedURL{Signature: "usedsignature", BucketName: bucket.Name, FileName: "a.jpg", Method: "GET", ExpiresAt: future, SingleUse: true, Used: true},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := exportPermissions(db, &ExportPermissionsCommand{}, now)
+	if err != nil {
+		t.Fatalf("exportPermissions() = %v", err)
+	}
+	principals := make(map[string][]string)
+	for _, entry := range entries {
+		principals[entry.PrincipalType] = append(principals[entry.PrincipalType], entry.Principal)
+		if entry.PrincipalType == models.PrincipalFileToken && entry.FileName != "a.jpg" {
+			t.Errorf("file token entry FileName = %q, want %q", entry.FileName, "a.jpg")
+		}
+	}
+	for principalType, want := range map[string]string{
+		models.PrincipalUser:        "photos-owner",
+		models.PrincipalAPIKey:      "live (shb_live...)",
+		models.PrincipalFileToken:   "share (shf_live...)",
+		models.PrincipalUploadGrant: "drop (shu_live...)",
+		models.PrincipalSignedURL:   "livesign...",
+	} {
+		if got := principals[principalType]; len(got) != 1 || got[0] != want {
+			t.Errorf("exportPermissions() %s entries = %v, want [%s]", principalType, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package rebalance
+
+import (
+	"context"
+	"time"
+
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type GetRebalancePlanCommand struct{}
+
+type GetRebalancePlanResponse struct {
+	Plan
+	GeneratedAt time.Time `json:"generated_at"`
+	Success     bool      `json:"success"`
+	Message     string    `json:"message"`
+}
+
+type GetRebalancePlanRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetRebalancePlanRequestHandler(dbContext *persistence.AppDbContext) *GetRebalancePlanRequestHandler {
+	return &GetRebalancePlanRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle plans a rebalancing run under the configured policy without moving anything, the dry run
+// of what a run started now would do
+func (h *GetRebalancePlanRequestHandler) Handle(ctx context.Context, command *GetRebalancePlanCommand) (*GetRebalancePlanResponse, error) {
+	now := time.Now()
+	plan, err := BuildPlan(ctx, h.dbContext, CurrentPolicy(), now)
+	if err != nil {
+		return nil, err
+	}
+
+	message := "Nothing to rebalance"
+	if len(plan.Moves) > 0 {
+		message = "Rebalancing would move files"
+	}
+	return &GetRebalancePlanResponse{
+		Plan:        *plan,
+		GeneratedAt: now,
+		Success:     true,
+		Message:     message,
+	}, nil
+}
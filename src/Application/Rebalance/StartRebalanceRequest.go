@@ -0,0 +1,171 @@
+package rebalance
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Application/Job"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// maxReportedFailures caps the failed moves kept in the job result
+const maxReportedFailures = 100
+
+// ErrRebalanceRunning is returned when a rebalancing run is already queued or running
+var ErrRebalanceRunning = apierror.New(apierror.CodeConflict, "rebalancing is already queued or running")
+
+type StartRebalanceCommand struct {
+	UserID uuid.UUID `json:"-"` // nil for runs the rebalance worker starts
+}
+
+type StartRebalanceResponse struct {
+	Job     models.JobResponse `json:"job"`
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+}
+
+type StartRebalanceRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	relocator *file.RelocateFileRequestHandler
+}
+
+func NewStartRebalanceRequestHandler(dbContext *persistence.AppDbContext) *StartRebalanceRequestHandler {
+	return &StartRebalanceRequestHandler{
+		dbContext: dbContext,
+		relocator: file.NewRelocateFileRequestHandler(dbContext),
+	}
+}
+
+// Handle queues a rebalancing run as a background job. The run plans again when it starts, so it
+// moves what is cold and over the band by then. One run goes at a time.
+func (h *StartRebalanceRequestHandler) Handle(ctx context.Context, command *StartRebalanceCommand) (*StartRebalanceResponse, error) {
+	running, err := rebalanceRunning(h.dbContext.GetDB().WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if running {
+		return nil, ErrRebalanceRunning
+	}
+
+	queued, err := jobs.Enqueue(h.dbContext, jobs.TypeRebalance, struct{}{}, jobs.Options{
+		CreatedBy:   command.UserID,
+		MaxAttempts: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Rebalancing queued by user %s as job %s", command.UserID, queued.Id)
+
+	return &StartRebalanceResponse{
+		Job:     job.ToJobResponse(queued),
+		Success: true,
+		Message: "Rebalancing queued",
+	}, nil
+}
+
+// RunRebalanceJob runs a rebalancing run queued by Handle, it is registered with the job runner.
+// Moves go policy.MaxMoves at a time within the policy's bandwidth, each relocated and verified
+// like an admin relocation. A move that fails leaves its file where it was, the next run plans it
+// again, so runs aren't retried.
+func (h *StartRebalanceRequestHandler) RunRebalanceJob(ctx context.Context, run *jobs.Run) error {
+	policy := CurrentPolicy()
+	plan, err := BuildPlan(ctx, h.dbContext, policy, time.Now())
+	if err != nil {
+		return err
+	}
+	total := int64(len(plan.Moves))
+	run.Progress(0, total)
+
+	moves := make(chan Move)
+	go func() {
+		defer close(moves)
+		for _, move := range plan.Moves {
+			select {
+			case moves <- move:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mutex            sync.Mutex
+		completed, bytes int64
+		moved            int
+		failures         []map[string]interface{}
+		failed           int
+	)
+	bandwidth := storage.NewBandwidth(policy.Bandwidth)
+	var workers sync.WaitGroup
+	for range min(policy.MaxMoves, max(len(plan.Moves), 1)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for move := range moves {
+				_, err := h.relocator.Handle(ctx, &file.RelocateFileCommand{
+					FileID:    move.FileID,
+					UserID:    run.Job.CreatedBy,
+					Target:    move.Target,
+					Bandwidth: bandwidth,
+				})
+
+				mutex.Lock()
+				completed++
+				if err != nil {
+					log.Printf("Warning: rebalancing failed to move %s from %s to %s: %v", move.Name, move.Source, move.Target, err)
+					failed++
+					if len(failures) < maxReportedFailures {
+						failures = append(failures, map[string]interface{}{"file_id": move.FileID, "name": move.Name, "error": err.Error()})
+					}
+				} else {
+					moved++
+					bytes += move.Size
+				}
+				run.Progress(completed, total)
+				mutex.Unlock()
+			}
+		}()
+	}
+	workers.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{
+		"planned":     len(plan.Moves),
+		"moved":       moved,
+		"bytes_moved": bytes,
+		"failed":      failed,
+		"locations":   plan.Locations,
+	}
+	if len(failures) > 0 {
+		result["failures"] = failures
+	}
+	run.SetResult(result)
+	log.Printf("Rebalancing moved %d of %d file(s), %d bytes", moved, len(plan.Moves), bytes)
+	return nil
+}
+
+// rebalanceRunning reports whether a rebalancing run is queued or running
+func rebalanceRunning(db *gorm.DB) (bool, error) {
+	var active int64
+	if err := db.Model(&entities.Job{}).
+		Where(`"Type" = ? AND "Status" IN ?`, jobs.TypeRebalance, []string{jobs.StatusQueued, jobs.StatusRunning}).
+		Count(&active).Error; err != nil {
+		return false, fmt.Errorf("failed to check running rebalancing: %w", err)
+	}
+	return active > 0, nil
+}
@@ -0,0 +1,309 @@
+package rebalance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+)
+
+// candidateBatch is how many of a location's coldest files are looked at per query
+const candidateBatch = 500
+
+// Roles of a location in a plan
+const (
+	RoleSource = "source" // over the band, files move off it
+	RoleTarget = "target" // a node under the band, files move onto it
+)
+
+// Policy decides what the rebalancer moves
+type Policy struct {
+	LowWatermark  int   `json:"low_watermark"`  // utilization percentage nodes taking files are filled up to
+	HighWatermark int   `json:"high_watermark"` // utilization percentage above which files move off a location
+	ColdDays      int   `json:"cold_days"`      // days a file must go unread before it moves
+	MaxMoves      int   `json:"max_moves"`      // files moved at once
+	Bandwidth     int64 `json:"bandwidth"`      // bytes per second all moves share, zero doesn't limit them
+}
+
+// CurrentPolicy is the policy configured with the REBALANCE_* settings
+func CurrentPolicy() Policy {
+	settings := config.GetSettings()
+	policy := Policy{
+		LowWatermark:  min(max(settings.RebalanceLowWatermark, 0), 100),
+		HighWatermark: min(max(settings.RebalanceHighWatermark, 0), 100),
+		ColdDays:      max(settings.RebalanceColdDays, 0),
+		MaxMoves:      max(settings.RebalanceMaxMoves, 1),
+		Bandwidth:     max(settings.RebalanceBandwidth, 0),
+	}
+	// A node filled past where others are drained would take turns with them
+	policy.LowWatermark = min(policy.LowWatermark, policy.HighWatermark)
+	return policy
+}
+
+// Location is the master or a storage node as the planner sees it
+type Location struct {
+	ID          string  `json:"id"` // "master" or the node ID
+	Name        string  `json:"name"`
+	Role        string  `json:"role,omitempty"`
+	Used        int64   `json:"used"`
+	Capacity    int64   `json:"capacity"`
+	Utilization float64 `json:"utilization"`
+	// ProjectedUtilization is the utilization once the plan's moves are done
+	ProjectedUtilization float64 `json:"projected_utilization"`
+
+	node      *entities.StorageNode // nil for the master
+	planned   int64                 // used once the moves planned so far are done
+	accepts   bool                  // active and healthy, so files can be moved onto it
+	watermark int64                 // bytes: what a source is drained down to, what a target is filled up to
+}
+
+// Move is a file the plan moves
+type Move struct {
+	FileID   uuid.UUID `json:"file_id"`
+	BucketID uuid.UUID `json:"bucket_id"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	LastRead time.Time `json:"last_read"` // when the file was last read, its upload when it never was
+	Source   string    `json:"source"`
+	Target   string    `json:"target"`
+}
+
+// Plan is what a rebalancing run would do
+type Plan struct {
+	Policy    Policy     `json:"policy"`
+	Locations []Location `json:"locations"`
+	Moves     []Move     `json:"moves"`
+	Bytes     int64      `json:"bytes"` // bytes the moves carry
+}
+
+// BuildPlan works out which cold files move where: locations over the policy's high watermark
+// shed their least recently read files, the coldest first, down to the watermark onto active,
+// healthy nodes under the low watermark, each file to the emptiest node its bucket's placement
+// allows, without filling any node past the low watermark. What a location holds is summed from
+// the files stored there, as usage alerts do.
+func BuildPlan(ctx context.Context, dbContext *persistence.AppDbContext, policy Policy, now time.Time) (*Plan, error) {
+	db := dbContext.GetDB().WithContext(ctx)
+
+	locations, err := loadLocations(db, dbContext, policy)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Policy: policy, Moves: []Move{}}
+
+	var sources, targets []*Location
+	for i := range locations {
+		location := &locations[i]
+		switch location.Role {
+		case RoleSource:
+			sources = append(sources, location)
+		case RoleTarget:
+			targets = append(targets, location)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Utilization > sources[j].Utilization
+	})
+
+	if len(targets) > 0 {
+		cutoff := now.AddDate(0, 0, -policy.ColdDays)
+		buckets := make(map[uuid.UUID]*entities.Bucket)
+		planned := make(map[string]bool) // paths already moved, files sharing content move together
+		for _, source := range sources {
+			if err := planSource(db, dbContext, plan, source, targets, cutoff, buckets, planned); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i := range locations {
+		locations[i].ProjectedUtilization = utilization(locations[i].planned, locations[i].Capacity)
+	}
+	plan.Locations = locations
+	return plan, nil
+}
+
+// loadLocations lists the master and the nodes with a capacity, with what they hold and their
+// role under policy
+func loadLocations(db *gorm.DB, dbContext *persistence.AppDbContext, policy Policy) ([]Location, error) {
+	used, err := usedByLocation(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []Location
+	master, err := dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch master configuration: %w", err)
+	}
+	if master != nil && master.MaxStorage > 0 {
+		locations = append(locations, newLocation(file.RelocateToMaster, "master", nil, used[""], master.MaxStorage, policy))
+	}
+
+	nodes, err := sizedNodes(db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		node := &nodes[i]
+		locations = append(locations, newLocation(node.Id.String(), node.Name, node, used[node.Id.String()], node.MaxStorage, policy))
+	}
+	return locations, nil
+}
+
+// usedByLocation sums the size of the files by the node holding them, "" for the master. The
+// location of a file is the node ID in its node://{nodeID}/... path.
+func usedByLocation(db *gorm.DB) (map[string]int64, error) {
+	var sums []struct {
+		NodeId string
+		Bytes  int64
+	}
+	location := `CASE WHEN "Path" LIKE 'node://%' THEN SUBSTR("Path", 8, 36) ELSE '' END`
+	if err := db.Model(&entities.File{}).
+		Select(location + ` AS "NodeId", COALESCE(SUM("Size"), 0) AS "Bytes"`).
+		Group(location).
+		Scan(&sums).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum storage locations: %w", err)
+	}
+	used := make(map[string]int64, len(sums))
+	for _, sum := range sums {
+		used[sum.NodeId] = sum.Bytes
+	}
+	return used, nil
+}
+
+// sizedNodes lists the nodes with a capacity that haven't failed, by name
+func sizedNodes(db *gorm.DB) ([]entities.StorageNode, error) {
+	var nodes []entities.StorageNode
+	if err := db.Where(`"MaxStorage" > 0 AND "FailedAt" IS NULL`).Order(`"Name"`).Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch storage nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+func newLocation(id, name string, node *entities.StorageNode, used, capacity int64, policy Policy) Location {
+	location := Location{
+		ID:          id,
+		Name:        name,
+		Used:        used,
+		Capacity:    capacity,
+		Utilization: utilization(used, capacity),
+		node:        node,
+		planned:     used,
+		// Only nodes take files, the master is where content lands first
+		accepts: node != nil && node.Placeable(),
+	}
+	switch {
+	case location.Utilization > float64(policy.HighWatermark):
+		location.Role = RoleSource
+		location.watermark = capacity * int64(policy.HighWatermark) / 100
+	case location.accepts && location.Utilization < float64(policy.LowWatermark):
+		location.Role = RoleTarget
+		location.watermark = capacity * int64(policy.LowWatermark) / 100
+	}
+	return location
+}
+
+// planSource plans the moves draining source down to its watermark
+func planSource(db *gorm.DB, dbContext *persistence.AppDbContext, plan *Plan, source *Location, targets []*Location, cutoff time.Time, buckets map[uuid.UUID]*entities.Bucket, planned map[string]bool) error {
+	query := coldFiles(db, source.node, cutoff)
+
+	for offset := 0; source.planned > source.watermark; offset += candidateBatch {
+		var files []entities.File
+		if err := query.Session(&gorm.Session{}).
+			Order(`COALESCE("AccessedAt", "CreatedAt")`).
+			Offset(offset).Limit(candidateBatch).
+			Find(&files).Error; err != nil {
+			return fmt.Errorf("failed to list cold files of %s: %w", source.Name, err)
+		}
+
+		for i := range files {
+			candidate := &files[i]
+			if source.planned <= source.watermark {
+				return nil
+			}
+			if planned[candidate.Path] {
+				continue
+			}
+
+			bucket, ok := buckets[candidate.BucketId]
+			if !ok {
+				var err error
+				bucket, err = dbContext.Buckets.Where(&entities.Bucket{Id: candidate.BucketId}).FirstOrDefault()
+				if err != nil {
+					return fmt.Errorf("failed to fetch bucket: %w", err)
+				}
+				buckets[candidate.BucketId] = bucket
+			}
+			if bucket == nil {
+				continue
+			}
+
+			target := pickTarget(targets, bucket, candidate.Size)
+			if target == nil {
+				continue
+			}
+
+			lastRead := candidate.CreatedAt
+			if candidate.AccessedAt != nil {
+				lastRead = *candidate.AccessedAt
+			}
+			plan.Moves = append(plan.Moves, Move{
+				FileID:   candidate.Id,
+				BucketID: candidate.BucketId,
+				Name:     candidate.Name,
+				Size:     candidate.Size,
+				LastRead: lastRead,
+				Source:   source.ID,
+				Target:   target.ID,
+			})
+			plan.Bytes += candidate.Size
+			planned[candidate.Path] = true
+			source.planned -= candidate.Size
+			target.planned += candidate.Size
+		}
+		if len(files) < candidateBatch {
+			return nil
+		}
+	}
+	return nil
+}
+
+// coldFiles queries the files on node, the master when nil, last read or written before cutoff
+func coldFiles(db *gorm.DB, node *entities.StorageNode, cutoff time.Time) *gorm.DB {
+	query := db.Model(&entities.File{}).Where(`COALESCE("AccessedAt", "CreatedAt") < ?`, cutoff)
+	if node == nil {
+		return query.Where(`"Path" NOT LIKE ?`, "node://%")
+	}
+	return query.Where(`"Path" LIKE ?`, "node://"+node.Id.String()+"/%")
+}
+
+// pickTarget returns the least utilized target the bucket may use with room for size bytes below
+// its watermark, and that keeps its copies spread across zones, nil when there is none
+func pickTarget(targets []*Location, bucket *entities.Bucket, size int64) *Location {
+	var picked *Location
+	for _, target := range targets {
+		if target.planned+size > target.watermark || !placement.Allows(bucket, target.node) || !placement.KeepsSpread(bucket, target.node) {
+			continue
+		}
+		if picked == nil || utilization(target.planned, target.Capacity) < utilization(picked.planned, picked.Capacity) {
+			picked = target
+		}
+	}
+	return picked
+}
+
+func utilization(used, capacity int64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	return 100 * float64(used) / float64(capacity)
+}
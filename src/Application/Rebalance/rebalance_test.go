@@ -0,0 +1,119 @@
+package rebalance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestLocations sums the files of the master and each node, and lists the nodes with a capacity
+func TestLocations(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	failedAt := time.Now()
+	nodes := []entities.StorageNode{
+		{Name: "b", URL: "http://b", MaxStorage: 100},
+		{Name: "a", URL: "http://a", MaxStorage: 100},
+		{Name: "unsized", URL: "http://unsized"},
+		{Name: "failed", URL: "http://failed", MaxStorage: 100, FailedAt: &failedAt},
+	}
+	for i := range nodes {
+		nodes[i].AuthKey = "key"
+		if err := db.Create(&nodes[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	onNode := "node://" + nodes[0].Id.String() + "/" + bucket.Id.String() + "/"
+	for i, file := range []entities.File{
+		{Name: "a.jpg", Path: "/data/photos/a", Size: 10},
+		{Name: "b.jpg", Path: onNode + uuid.NewString(), Size: 20},
+		{Name: "c.jpg", Path: onNode + uuid.NewString(), Size: 30},
+	} {
+		file.BucketId, file.OriginalName = bucket.Id, file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+
+	used, err := usedByLocation(db)
+	if err != nil {
+		t.Fatalf("usedByLocation() = %v", err)
+	}
+	if used[""] != 10 || used[nodes[0].Id.String()] != 50 {
+		t.Errorf("usedByLocation() = %v, want 10 bytes on the master and 50 on node b", used)
+	}
+
+	sized, err := sizedNodes(db)
+	if err != nil {
+		t.Fatalf("sizedNodes() = %v", err)
+	}
+	if len(sized) != 2 || sized[0].Name != "a" || sized[1].Name != "b" {
+		t.Errorf("sizedNodes() = %+v, want nodes a and b", sized)
+	}
+}
+
+// TestColdFiles queries the files of a location not read or written since the cutoff
+func TestColdFiles(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	node := entities.StorageNode{Name: "node-1", URL: "http://node-1", AuthKey: "key"}
+	if err := db.Create(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	cutoff := time.Now().Add(-time.Hour)
+	old, recent := cutoff.Add(-time.Hour), cutoff.Add(time.Minute)
+	onNode := "node://" + node.Id.String() + "/" + bucket.Id.String() + "/"
+	for i, file := range []entities.File{
+		{Name: "cold.jpg", Path: onNode + uuid.NewString(), CreatedAt: old},
+		{Name: "read.jpg", Path: onNode + uuid.NewString(), CreatedAt: old, AccessedAt: &recent},
+		{Name: "new.jpg", Path: onNode + uuid.NewString(), CreatedAt: recent},
+		{Name: "master.jpg", Path: "/data/photos/m", CreatedAt: old},
+	} {
+		file.BucketId, file.OriginalName = bucket.Id, file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+
+	for _, test := range []struct {
+		node *entities.StorageNode
+		want string
+	}{{&node, "cold.jpg"}, {nil, "master.jpg"}} {
+		var files []entities.File
+		if err := coldFiles(db, test.node, cutoff).Find(&files).Error; err != nil {
+			t.Fatalf("coldFiles() = %v", err)
+		}
+		if len(files) != 1 || files[0].Name != test.want {
+			t.Errorf("coldFiles() = %+v, want %s", files, test.want)
+		}
+	}
+}
+
+// TestRebalanceRunning sees a queued or running rebalancing, not a finished one or other jobs
+func TestRebalanceRunning(t *testing.T) {
+	db := sqlitetest.Open(t)
+	for _, job := range []entities.Job{
+		{Type: jobs.TypeRebalance, Status: jobs.StatusCompleted},
+		{Type: jobs.TypeBucketDelete, Status: jobs.StatusRunning},
+	} {
+		if err := db.Create(&job).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if running, err := rebalanceRunning(db); err != nil || running {
+		t.Errorf("rebalanceRunning() = %v, %v, want false", running, err)
+	}
+
+	queued := entities.Job{Type: jobs.TypeRebalance, Status: jobs.StatusQueued}
+	if err := db.Create(&queued).Error; err != nil {
+		t.Fatal(err)
+	}
+	if running, err := rebalanceRunning(db); err != nil || !running {
+		t.Errorf("rebalanceRunning() with a queued run = %v, %v, want true", running, err)
+	}
+}
@@ -0,0 +1,53 @@
+package reclamation
+
+import (
+	"context"
+	"time"
+
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetReclamationReportCommand struct{}
+
+type GetReclamationReportResponse struct {
+	Categories       []models.ReclamationCategoryResponse `json:"categories"`
+	ReclaimableBytes int64                                `json:"reclaimable_bytes"`
+	GeneratedAt      time.Time                            `json:"generated_at"`
+	Success          bool                                 `json:"success"`
+	Message          string                               `json:"message"`
+}
+
+type GetReclamationReportRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetReclamationReportRequestHandler(dbContext *persistence.AppDbContext) *GetReclamationReportRequestHandler {
+	return &GetReclamationReportRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reports what could be reclaimed without removing anything
+func (h *GetReclamationReportRequestHandler) Handle(ctx context.Context, command *GetReclamationReportCommand) (*GetReclamationReportResponse, error) {
+	scanner := newScanner(h.dbContext)
+
+	response := &GetReclamationReportResponse{
+		Categories:  make([]models.ReclamationCategoryResponse, 0, len(Categories)),
+		GeneratedAt: scanner.now,
+		Success:     true,
+		Message:     "Reclamation report generated successfully",
+	}
+
+	for _, category := range Categories {
+		items, err := scanner.scan(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+		categoryResponse := toCategoryResponse(category, items)
+		response.Categories = append(response.Categories, categoryResponse)
+		response.ReclaimableBytes += categoryResponse.Bytes
+	}
+
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package reclamation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type ReclaimStorageCommand struct {
+	// Categories to reclaim, all categories when empty
+	Categories []string `json:"categories" validate:"omitempty,dive,oneof=expired_signed_urls unreferenced_content stale_partial_uploads orphaned_derived_content versions_over_limit"`
+}
+
+// ReclaimedCategoryResponse reports what was removed for one category
+type ReclaimedCategoryResponse struct {
+	Category string `json:"category"`
+	Removed  int    `json:"removed"`
+	Failed   int    `json:"failed"`
+	Bytes    int64  `json:"bytes"`
+}
+
+type ReclaimStorageResponse struct {
+	Categories     []ReclaimedCategoryResponse `json:"categories"`
+	ReclaimedBytes int64                       `json:"reclaimed_bytes"`
+	Success        bool                        `json:"success"`
+	Message        string                      `json:"message"`
+}
+
+type ReclaimStorageRequestHandler struct {
+	dbContext  *persistence.AppDbContext
+	deleteFile *file.DeleteFileRequestHandler
+}
+
+func NewReclaimStorageRequestHandler(dbContext *persistence.AppDbContext) *ReclaimStorageRequestHandler {
+	return &ReclaimStorageRequestHandler{
+		dbContext:  dbContext,
+		deleteFile: file.NewDeleteFileRequestHandler(dbContext),
+	}
+}
+
+// Handle rescans the requested categories and removes everything found, so nothing is
+// removed based on a stale report
+func (h *ReclaimStorageRequestHandler) Handle(ctx context.Context, command *ReclaimStorageCommand) (*ReclaimStorageResponse, error) {
+	categories := command.Categories
+	if len(categories) == 0 {
+		categories = Categories
+	}
+
+	scanner := newScanner(h.dbContext)
+	response := &ReclaimStorageResponse{
+		Categories: make([]ReclaimedCategoryResponse, 0, len(categories)),
+		Success:    true,
+	}
+
+	for _, category := range categories {
+		items, err := scanner.scan(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+
+		var reclaimed ReclaimedCategoryResponse
+		switch category {
+		case CategoryExpiredSignedURLs:
+			reclaimed, err = removeSignedURLs(h.dbContext.GetDB().WithContext(ctx), items)
+			if err != nil {
+				return nil, err
+			}
+		case CategoryVersionsOverLimit:
+			reclaimed = h.removeVersions(ctx, items)
+		default:
+			reclaimed = removeFromDisk(items)
+		}
+		reclaimed.Category = category
+
+		response.Categories = append(response.Categories, reclaimed)
+		response.ReclaimedBytes += reclaimed.Bytes
+	}
+
+	response.Message = fmt.Sprintf("Reclaimed %d bytes", response.ReclaimedBytes)
+	return response, nil
+}
+
+func removeSignedURLs(db *gorm.DB, items []reclaimable) (ReclaimedCategoryResponse, error) {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		if id, err := uuid.Parse(item.reference); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ReclaimedCategoryResponse{}, nil
+	}
+
+	result := db.Where(`"ID" IN ?`, ids).Delete(&entities.SignedURL{})
+	if result.Error != nil {
+		return ReclaimedCategoryResponse{}, fmt.Errorf("failed to delete expired signed URLs: %w", result.Error)
+	}
+	return ReclaimedCategoryResponse{Removed: int(result.RowsAffected)}, nil
+}
+
+func (h *ReclaimStorageRequestHandler) removeVersions(ctx context.Context, items []reclaimable) ReclaimedCategoryResponse {
+	var reclaimed ReclaimedCategoryResponse
+	for _, item := range items {
+		_, err := h.deleteFile.Handle(ctx, &file.DeleteFileCommand{
+			FileID:   item.version.Id,
+			BucketID: item.version.BucketId,
+			UserID:   item.ownerID,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to reclaim version %d of %s: %v", item.version.Version, item.version.Name, err)
+			reclaimed.Failed++
+			continue
+		}
+		reclaimed.Removed++
+		reclaimed.Bytes += item.size
+	}
+	return reclaimed
+}
+
+func removeFromDisk(items []reclaimable) ReclaimedCategoryResponse {
+	var reclaimed ReclaimedCategoryResponse
+	for _, item := range items {
+		var err error
+		if item.isDir {
+			err = os.RemoveAll(item.reference)
+		} else {
+			err = os.Remove(item.reference)
+		}
+
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: failed to reclaim %s: %v", item.reference, err)
+			reclaimed.Failed++
+			continue
+		}
+		reclaimed.Removed++
+		reclaimed.Bytes += item.size
+	}
+	return reclaimed
+}
@@ -0,0 +1,317 @@
+package reclamation
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// Reclamation categories
+const (
+	CategoryExpiredSignedURLs      = "expired_signed_urls"
+	CategoryUnreferencedContent    = "unreferenced_content"
+	CategoryStalePartialUploads    = "stale_partial_uploads"
+	CategoryOrphanedDerivedContent = "orphaned_derived_content"
+	CategoryVersionsOverLimit      = "versions_over_limit"
+)
+
+// Categories lists every reclamation category in report order
+var Categories = []string{
+	CategoryExpiredSignedURLs,
+	CategoryUnreferencedContent,
+	CategoryStalePartialUploads,
+	CategoryOrphanedDerivedContent,
+	CategoryVersionsOverLimit,
+}
+
+var categoryDescriptions = map[string]string{
+	CategoryExpiredSignedURLs:      "Signed URL records that have expired or whose single use was consumed",
+	CategoryUnreferencedContent:    "Stored content no file, snapshot or node record points to",
+	CategoryStalePartialUploads:    "Partially written uploads left behind by interrupted transfers",
+	CategoryOrphanedDerivedContent: "Cached image variants, thumbnails and HLS renditions of deleted files",
+	CategoryVersionsOverLimit:      "Noncurrent versions beyond their bucket's version count or retention limit",
+}
+
+// gracePeriod keeps content written moments ago out of the report, its file record may not be saved yet
+const gracePeriod = time.Hour
+
+// sampleSize caps the items listed per category in a report
+const sampleSize = 50
+
+// reclaimable is something that can be removed to free space
+type reclaimable struct {
+	reference  string // path on disk, or record ID
+	size       int64
+	modifiedAt time.Time
+	isDir      bool
+	version    *entities.File // set for versions, which are removed through the regular file delete
+	ownerID    uuid.UUID
+}
+
+type scanner struct {
+	dbContext *persistence.AppDbContext
+	db        *gorm.DB
+	settings  *config.Settings
+	now       time.Time
+
+	referenced map[string]bool // loaded on first use
+}
+
+func newScanner(dbContext *persistence.AppDbContext) *scanner {
+	return &scanner{
+		dbContext: dbContext,
+		db:        dbContext.GetDB(),
+		settings:  config.GetSettings(),
+		now:       time.Now(),
+	}
+}
+
+func (s *scanner) scan(ctx context.Context, category string) ([]reclaimable, error) {
+	switch category {
+	case CategoryExpiredSignedURLs:
+		return s.expiredSignedURLs(ctx)
+	case CategoryUnreferencedContent:
+		return s.unreferencedContent(ctx)
+	case CategoryStalePartialUploads:
+		return s.stalePartialUploads(ctx)
+	case CategoryOrphanedDerivedContent:
+		return s.orphanedDerivedContent(ctx)
+	case CategoryVersionsOverLimit:
+		return s.versionsOverLimit(ctx)
+	}
+	return nil, apierror.Newf(apierror.CodeInvalidRequest, "unknown reclamation category: %s", category)
+}
+
+func (s *scanner) expiredSignedURLs(ctx context.Context) ([]reclaimable, error) {
+	var signedURLs []entities.SignedURL
+	if err := s.db.WithContext(ctx).
+		Where(`"ExpiresAt" < ? OR ("SingleUse" = ? AND "Used" = ?)`, s.now, true, true).
+		Find(&signedURLs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch expired signed URLs: %w", err)
+	}
+
+	items := make([]reclaimable, len(signedURLs))
+	for i, signedURL := range signedURLs {
+		items[i] = reclaimable{reference: signedURL.ID.String(), modifiedAt: signedURL.ExpiresAt}
+	}
+	return items, nil
+}
+
+// unreferencedContent walks the local storage roots for content no database record points to
+func (s *scanner) unreferencedContent(ctx context.Context) ([]reclaimable, error) {
+	referenced, err := s.referencedPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []reclaimable
+	for _, root := range storage.LocalRoots(s.dbContext) {
+		err := s.walkFiles(root, func(path string, info fs.FileInfo) {
+			if isSpoolFile(info.Name()) || referenced[normalizePath(path)] {
+				return
+			}
+			items = append(items, reclaimable{reference: path, size: info.Size(), modifiedAt: info.ModTime()})
+		}, normalizePath(s.settings.DerivedCachePath), normalizePath(s.settings.NodeCachePath), normalizePath(s.settings.UploadSessionPath))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (s *scanner) stalePartialUploads(ctx context.Context) ([]reclaimable, error) {
+	referenced, err := s.referencedPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []reclaimable
+	for _, root := range storage.LocalRoots(s.dbContext) {
+		err := s.walkFiles(root, func(path string, info fs.FileInfo) {
+			if isSpoolFile(info.Name()) && !referenced[normalizePath(path)] {
+				items = append(items, reclaimable{reference: path, size: info.Size(), modifiedAt: info.ModTime()})
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// orphanedDerivedContent finds derived cache directories of files that no longer exist
+func (s *scanner) orphanedDerivedContent(ctx context.Context) ([]reclaimable, error) {
+	entries, err := os.ReadDir(s.settings.DerivedCachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read derived cache: %w", err)
+	}
+
+	var fileIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&entities.File{}).Pluck("Id", &fileIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch file IDs: %w", err)
+	}
+	existing := make(map[uuid.UUID]bool, len(fileIDs))
+	for _, fileID := range fileIDs {
+		existing[fileID] = true
+	}
+
+	var items []reclaimable
+	for _, entry := range entries {
+		fileID, err := uuid.Parse(entry.Name())
+		if err != nil || !entry.IsDir() || existing[fileID] {
+			continue
+		}
+
+		dir := filepath.Join(s.settings.DerivedCachePath, entry.Name())
+		item := reclaimable{reference: dir, isDir: true}
+		filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				if info, err := d.Info(); err == nil {
+					item.size += info.Size()
+					if info.ModTime().After(item.modifiedAt) {
+						item.modifiedAt = info.ModTime()
+					}
+				}
+			}
+			return nil
+		})
+		if s.now.Sub(item.modifiedAt) >= gracePeriod {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// versionsOverLimit lists what the lifecycle worker would remove on its next pass
+func (s *scanner) versionsOverLimit(ctx context.Context) ([]reclaimable, error) {
+	buckets, err := s.dbContext.Buckets.Where(&entities.Bucket{
+		Settings: entities.BucketSettings{Versioning: true},
+	}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch versioned buckets: %w", err)
+	}
+
+	var items []reclaimable
+	for _, bucket := range buckets {
+		expired, err := file.ExpiredVersions(ctx, s.db, &bucket, s.now)
+		if err != nil {
+			return nil, err
+		}
+		for i := range expired {
+			items = append(items, reclaimable{
+				reference:  expired[i].Id.String(),
+				size:       expired[i].Size,
+				modifiedAt: expired[i].CreatedAt,
+				version:    &expired[i],
+				ownerID:    bucket.OwnerId,
+			})
+		}
+	}
+	return items, nil
+}
+
+// referencedPaths collects every local path a file, snapshot or node metadata record points to.
+// Content of pending uploads counts as referenced, the upload cleanup worker decides when it's abandoned.
+func (s *scanner) referencedPaths(ctx context.Context) (map[string]bool, error) {
+	if s.referenced != nil {
+		return s.referenced, nil
+	}
+
+	referenced := map[string]bool{}
+	for _, model := range []interface{}{&entities.File{}, &entities.SnapshotFile{}, &entities.NodeFileMetadata{}, &entities.PendingUpload{}} {
+		var paths []string
+		if err := s.db.WithContext(ctx).Model(model).Pluck("Path", &paths).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch referenced paths: %w", err)
+		}
+		for _, path := range paths {
+			if !storage.IsNodePath(path) {
+				referenced[normalizePath(path)] = true
+			}
+		}
+	}
+	s.referenced = referenced
+	return referenced, nil
+}
+
+// walkFiles calls visit for regular files under root older than the grace period, skipping the excluded directories
+func (s *scanner) walkFiles(root string, visit func(path string, info fs.FileInfo), excluded ...string) error {
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			for _, dir := range excluded {
+				if normalizePath(path) == dir {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err == nil && s.now.Sub(info.ModTime()) >= gracePeriod {
+			visit(path, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return nil
+}
+
+// normalizePath makes paths comparable whether they were recorded relative or absolute
+func normalizePath(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return filepath.Clean(path)
+}
+
+func isSpoolFile(name string) bool {
+	return strings.HasSuffix(name, ".tmp")
+}
+
+func toCategoryResponse(category string, items []reclaimable) models.ReclamationCategoryResponse {
+	response := models.ReclamationCategoryResponse{
+		Category:    category,
+		Description: categoryDescriptions[category],
+		Count:       len(items),
+		Items:       []models.ReclaimableItemResponse{},
+	}
+	for i, item := range items {
+		response.Bytes += item.size
+		if i < sampleSize {
+			response.Items = append(response.Items, models.ReclaimableItemResponse{
+				Reference:  item.reference,
+				Size:       item.size,
+				ModifiedAt: item.modifiedAt,
+			})
+		}
+	}
+	return response
+}
@@ -0,0 +1,78 @@
+package reclamation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestExpiredSignedURLs reclaims signed URLs that expired or were used up, and only those
+func TestExpiredSignedURLs(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	for i, signedURL := range []entities.SignedURL{
+		{ExpiresAt: now.Add(-time.Hour)},
+		{ExpiresAt: now.Add(time.Hour), SingleUse: true, Used: true},
+		{ExpiresAt: now.Add(time.Hour), SingleUse: true},
+	} {
+		signedURL.Signature = uuid.NewString()
+		signedURL.BucketName, signedURL.FileName, signedURL.Method = "photos", "a.jpg", "GET"
+		if err := db.Create(&signedURL).Error; err != nil {
+			t.Fatalf("failed to create signed URL %d: %v", i, err)
+		}
+	}
+
+	s := &scanner{db: db, now: now}
+	items, err := s.expiredSignedURLs(context.Background())
+	if err != nil {
+		t.Fatalf("expiredSignedURLs() = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expiredSignedURLs() = %d items, want 2", len(items))
+	}
+
+	reclaimed, err := removeSignedURLs(db, items)
+	if err != nil {
+		t.Fatalf("removeSignedURLs() = %v", err)
+	}
+	if reclaimed.Removed != 2 {
+		t.Errorf("removeSignedURLs() removed %d, want 2", reclaimed.Removed)
+	}
+	var left []entities.SignedURL
+	if err := db.Find(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].Used {
+		t.Errorf("signed URLs left = %+v, want the unused one", left)
+	}
+}
+
+// TestReferencedPaths collects the local paths of files, snapshots and pending uploads, not node paths
+func TestReferencedPaths(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	records := []interface{}{
+		&entities.File{BucketId: bucketID, Name: "a.txt", OriginalName: "a.txt", Path: "/data/a.txt"},
+		&entities.File{BucketId: bucketID, Name: "b.txt", OriginalName: "b.txt", Path: "node://" + uuid.NewString() + "/b.txt"},
+		&entities.SnapshotFile{SnapshotId: uuid.New(), FileId: uuid.New(), Name: "c.txt", Path: "/data/.snapshots/c.txt"},
+		&entities.PendingUpload{BucketId: bucketID, Path: "/data/d.txt"},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	referenced, err := (&scanner{db: db}).referencedPaths(context.Background())
+	if err != nil {
+		t.Fatalf("referencedPaths() = %v", err)
+	}
+	if len(referenced) != 3 || !referenced["/data/a.txt"] || !referenced["/data/.snapshots/c.txt"] || !referenced["/data/d.txt"] {
+		t.Errorf("referencedPaths() = %v, want the three local paths", referenced)
+	}
+}
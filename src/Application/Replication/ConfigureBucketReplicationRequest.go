@@ -0,0 +1,126 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/pkg/client"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// remoteCheckTimeout bounds checking that the remote bucket can be reached
+const remoteCheckTimeout = 15 * time.Second
+
+type ConfigureBucketReplicationCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	// RemoteURL is the other installation's address, e.g. "https://dr.example.com"
+	RemoteURL      string    `json:"remote_url" validate:"required,url"`
+	RemoteBucketID uuid.UUID `json:"remote_bucket_id" validate:"required"`
+	// APIKey writes to the remote bucket. It may be left out to keep the key of an existing replication.
+	APIKey         string `json:"api_key,omitempty"`
+	ConflictPolicy string `json:"conflict_policy,omitempty" validate:"omitempty,oneof=overwrite skip newer"`
+	// ReplicateDeletes removes the remote files the replication pushed once their names are deleted here
+	ReplicateDeletes bool `json:"replicate_deletes,omitempty"`
+}
+
+type ConfigureBucketReplicationResponse struct {
+	Replication models.BucketReplicationResponse `json:"replication"`
+	Success     bool                             `json:"success"`
+	Message     string                           `json:"message"`
+}
+
+type ConfigureBucketReplicationRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewConfigureBucketReplicationRequestHandler(dbContext *persistence.AppDbContext) *ConfigureBucketReplicationRequestHandler {
+	return &ConfigureBucketReplicationRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle replicates a bucket to a bucket of another installation, or changes its replication, after
+// checking the remote bucket can be reached. Pointing the replication at another remote bucket starts
+// it over, pushing every file the bucket serves.
+func (h *ConfigureBucketReplicationRequestHandler) Handle(ctx context.Context, command *ConfigureBucketReplicationCommand) (*ConfigureBucketReplicationResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	replication, err := findReplication(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket replication: %w", err)
+	}
+	apiKey := command.APIKey
+	if apiKey == "" {
+		if replication == nil {
+			return nil, ErrAPIKeyRequired
+		}
+		apiKey = replication.APIKey
+	}
+	if err := checkRemote(ctx, command.RemoteURL, command.RemoteBucketID, apiKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	db := h.dbContext.GetDB().WithContext(ctx)
+	if replication == nil {
+		replication = &entities.BucketReplication{
+			Id:        uuid.New(),
+			BucketId:  bucket.Id,
+			Status:    StatusPending,
+			CreatedBy: command.UserID,
+			CreatedAt: now,
+		}
+	} else if replication.RemoteURL != command.RemoteURL || replication.RemoteBucketId != command.RemoteBucketID {
+		// What was pushed to the previous remote says nothing about the new one
+		if err := db.Delete(&entities.ReplicatedFile{}, `"ReplicationId" = ?`, replication.Id).Error; err != nil {
+			return nil, fmt.Errorf("failed to reset replicated files: %w", err)
+		}
+		replication.Cursor = ""
+		replication.Status = StatusPending
+		replication.LastError = ""
+		replication.LastReplicatedAt = nil
+	}
+	replication.RemoteURL = command.RemoteURL
+	replication.RemoteBucketId = command.RemoteBucketID
+	replication.APIKey = apiKey
+	replication.ConflictPolicy = command.ConflictPolicy
+	if replication.ConflictPolicy == "" {
+		replication.ConflictPolicy = ConflictOverwrite
+	}
+	replication.ReplicateDeletes = command.ReplicateDeletes
+	replication.UpdatedAt = now
+
+	if err := db.Save(replication).Error; err != nil {
+		return nil, fmt.Errorf("failed to save bucket replication: %w", err)
+	}
+
+	return &ConfigureBucketReplicationResponse{
+		Replication: ToBucketReplicationResponse(replication),
+		Success:     true,
+		Message:     "Bucket replication configured successfully",
+	}, nil
+}
+
+// checkRemote reads the remote bucket with the replication's key
+func checkRemote(ctx context.Context, remoteURL string, remoteBucketID uuid.UUID, apiKey string) error {
+	remote, err := client.New(remoteURL, client.WithAPIKey(apiKey), client.WithRetry(1, time.Second))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteUnreachable, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteCheckTimeout)
+	defer cancel()
+	if _, err := remote.GetBucket(ctx, remoteBucketID); err != nil {
+		return fmt.Errorf("%w: %v", ErrRemoteUnreachable, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DeleteBucketReplicationCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type DeleteBucketReplicationResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteBucketReplicationRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDeleteBucketReplicationRequestHandler(dbContext *persistence.AppDbContext) *DeleteBucketReplicationRequestHandler {
+	return &DeleteBucketReplicationRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle stops replicating a bucket. The files already pushed stay on the remote.
+func (h *DeleteBucketReplicationRequestHandler) Handle(ctx context.Context, command *DeleteBucketReplicationCommand) (*DeleteBucketReplicationResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	replication, err := findReplication(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket replication: %w", err)
+	}
+	if replication == nil {
+		return nil, ErrReplicationNotFound
+	}
+
+	if err := Remove(h.dbContext, replication.Id); err != nil {
+		return nil, err
+	}
+
+	return &DeleteBucketReplicationResponse{
+		Success: true,
+		Message: "Bucket replication removed successfully",
+	}, nil
+}
+
+// Remove deletes a replication with its record of the files it pushed
+func Remove(dbContext *persistence.AppDbContext, replicationID uuid.UUID) error {
+	return remove(dbContext.GetDB(), replicationID)
+}
+
+func remove(db *gorm.DB, replicationID uuid.UUID) error {
+	if err := db.Delete(&entities.ReplicatedFile{}, `"ReplicationId" = ?`, replicationID).Error; err != nil {
+		return fmt.Errorf("failed to delete replicated files: %w", err)
+	}
+	if err := db.Delete(&entities.BucketReplication{}, `"Id" = ?`, replicationID).Error; err != nil {
+		return fmt.Errorf("failed to delete bucket replication: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetBucketReplicationCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetBucketReplicationResponse struct {
+	Replication models.BucketReplicationResponse `json:"replication"`
+	Success     bool                             `json:"success"`
+	Message     string                           `json:"message"`
+}
+
+type GetBucketReplicationRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetBucketReplicationRequestHandler(dbContext *persistence.AppDbContext) *GetBucketReplicationRequestHandler {
+	return &GetBucketReplicationRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns a bucket's replication with the counters of its passes
+func (h *GetBucketReplicationRequestHandler) Handle(ctx context.Context, command *GetBucketReplicationCommand) (*GetBucketReplicationResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	replication, err := findReplication(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket replication: %w", err)
+	}
+	if replication == nil {
+		return nil, ErrReplicationNotFound
+	}
+
+	return &GetBucketReplicationResponse{
+		Replication: ToBucketReplicationResponse(replication),
+		Success:     true,
+		Message:     "Bucket replication retrieved successfully",
+	}, nil
+}
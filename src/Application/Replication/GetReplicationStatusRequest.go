@@ -0,0 +1,85 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/BucketSync"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// healthyLag is how far behind its bucket a replication may fall and still be healthy
+const healthyLag = 15 * time.Minute
+
+type GetReplicationStatusCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type GetReplicationStatusResponse struct {
+	Status  models.BucketReplicationStatusResponse `json:"status"`
+	Success bool                                   `json:"success"`
+	Message string                                 `json:"message"`
+}
+
+type GetReplicationStatusRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetReplicationStatusRequestHandler(dbContext *persistence.AppDbContext) *GetReplicationStatusRequestHandler {
+	return &GetReplicationStatusRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle reports how far a bucket's replication is behind: the changes it hasn't pushed yet and the
+// age of the oldest of them. It is healthy while its last pass succeeded and that lag stays under
+// healthyLag; during the first pass, the lag counts from when the replication was configured.
+func (h *GetReplicationStatusRequestHandler) Handle(ctx context.Context, command *GetReplicationStatusCommand) (*GetReplicationStatusResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	replication, err := findReplication(h.dbContext, bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket replication: %w", err)
+	}
+	if replication == nil {
+		return nil, ErrReplicationNotFound
+	}
+
+	pending, oldest, err := bucketsync.Backlog(ctx, h.dbContext, bucket.Id, replication.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if oldest == nil && pending > 0 {
+		oldest = &replication.UpdatedAt
+	}
+
+	now := time.Now()
+	status := models.BucketReplicationStatusResponse{
+		Status:           replication.Status,
+		PendingChanges:   pending,
+		OldestPendingAt:  oldest,
+		LastRunAt:        replication.LastRunAt,
+		LastReplicatedAt: replication.LastReplicatedAt,
+		LastError:        replication.LastError,
+		Conflicts:        replication.Conflicts,
+		CheckedAt:        now,
+	}
+	if oldest != nil && oldest.Before(now) {
+		status.LagSeconds = int64(now.Sub(*oldest).Seconds())
+	}
+	status.Healthy = replication.Status != StatusFailed && time.Duration(status.LagSeconds)*time.Second < healthyLag
+
+	return &GetReplicationStatusResponse{
+		Status:  status,
+		Success: true,
+		Message: "Replication status retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,86 @@
+package replication
+
+import (
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// Statuses of a bucket replication
+const (
+	StatusPending     = "pending" // configured, not run yet
+	StatusReplicating = "replicating"
+	StatusReplicated  = "replicated" // the last pass pushed every change of the bucket
+	StatusFailed      = "failed"     // the last pass stopped at an error, it is retried on the next run
+)
+
+// Conflict policies, for names a replication didn't write last on the remote
+const (
+	ConflictOverwrite = "overwrite" // push a new version over the remote's file
+	ConflictSkip      = "skip"      // keep the remote's file
+	ConflictNewer     = "newer"     // push only when the local file is newer than the remote's
+)
+
+var (
+	// ErrBucketNotFound is returned when the bucket doesn't exist
+	ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	// ErrReplicationNotFound is returned for buckets that aren't replicated
+	ErrReplicationNotFound = apierror.New(apierror.CodeNotFound, "bucket is not replicated")
+	// ErrForbidden is returned to users who can't manage the bucket's replication
+	ErrForbidden = apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage its replication")
+	// ErrAPIKeyRequired is returned when a new replication is configured without a key for the remote
+	ErrAPIKeyRequired = apierror.New(apierror.CodeInvalidRequest, "api_key is required")
+	// ErrRemoteUnreachable is returned when the remote bucket can't be reached with the replication's URL and key
+	ErrRemoteUnreachable = apierror.New(apierror.CodeInvalidRequest, "remote bucket can't be reached")
+)
+
+// managedBucket loads a bucket whose replication the user may manage
+func managedBucket(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, ErrForbidden
+	}
+	return bucket, nil
+}
+
+// findReplication loads the replication of a bucket, nil when it has none
+func findReplication(dbContext *persistence.AppDbContext, bucketID uuid.UUID) (*entities.BucketReplication, error) {
+	var replications []entities.BucketReplication
+	if err := dbContext.GetDB().Where(&entities.BucketReplication{BucketId: bucketID}).Limit(1).Find(&replications).Error; err != nil {
+		return nil, err
+	}
+	if len(replications) == 0 {
+		return nil, nil
+	}
+	return &replications[0], nil
+}
+
+func ToBucketReplicationResponse(replication *entities.BucketReplication) models.BucketReplicationResponse {
+	return models.BucketReplicationResponse{
+		ID:               replication.Id,
+		BucketID:         replication.BucketId,
+		RemoteURL:        replication.RemoteURL,
+		RemoteBucketID:   replication.RemoteBucketId,
+		ConflictPolicy:   replication.ConflictPolicy,
+		ReplicateDeletes: replication.ReplicateDeletes,
+		Status:           replication.Status,
+		LastError:        replication.LastError,
+		FilesPushed:      replication.FilesPushed,
+		FilesDeleted:     replication.FilesDeleted,
+		FilesSkipped:     replication.FilesSkipped,
+		Conflicts:        replication.Conflicts,
+		BytesPushed:      replication.BytesPushed,
+		LastRunAt:        replication.LastRunAt,
+		LastReplicatedAt: replication.LastReplicatedAt,
+		CreatedBy:        replication.CreatedBy,
+		CreatedAt:        replication.CreatedAt,
+		UpdatedAt:        replication.UpdatedAt,
+	}
+}
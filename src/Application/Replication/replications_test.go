@@ -0,0 +1,40 @@
+package replication
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRemove deletes a replication with the files it pushed, leaving other replications alone
+func TestRemove(t *testing.T) {
+	db := sqlitetest.Open(t)
+	var replications []entities.BucketReplication
+	for _, name := range []string{"photos", "videos"} {
+		bucket := sqlitetest.CreateBucket(t, db, name)
+		r := entities.BucketReplication{BucketId: bucket.Id, RemoteURL: "https://remote", RemoteBucketId: uuid.New(), CreatedBy: bucket.OwnerId}
+		if err := db.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+		pushed := entities.ReplicatedFile{ReplicationId: r.Id, Name: "a.jpg", LocalFileId: uuid.New(), RemoteFileId: uuid.New()}
+		if err := db.Create(&pushed).Error; err != nil {
+			t.Fatal(err)
+		}
+		replications = append(replications, r)
+	}
+
+	if err := remove(db, replications[0].Id); err != nil {
+		t.Fatalf("remove() = %v", err)
+	}
+
+	var left []entities.BucketReplication
+	var pushed []entities.ReplicatedFile
+	db.Find(&left)
+	db.Find(&pushed)
+	if len(left) != 1 || left[0].Id != replications[1].Id || len(pushed) != 1 || pushed[0].ReplicationId != replications[1].Id {
+		t.Errorf("remove() left %d replication(s) and %d pushed file(s), want those of the other bucket", len(left), len(pushed))
+	}
+}
@@ -0,0 +1,329 @@
+package residency
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// ErrBucketNotFound is returned when the report is asked for a bucket that doesn't exist
+var ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+
+type GetResidencyReportCommand struct {
+	BucketID     *uuid.UUID `json:"bucket_id,omitempty"`
+	NonCompliant bool       `json:"non_compliant"` // only buckets with content outside their placement
+}
+
+type GetResidencyReportResponse struct {
+	Buckets     []models.BucketResidencyResponse `json:"buckets"`
+	Compliant   bool                             `json:"compliant"` // every reported bucket is compliant
+	GeneratedAt time.Time                        `json:"generated_at"`
+	Success     bool                             `json:"success"`
+	Message     string                           `json:"message"`
+}
+
+type GetResidencyReportRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func NewGetResidencyReportRequestHandler(dbContext *persistence.AppDbContext) *GetResidencyReportRequestHandler {
+	return &GetResidencyReportRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// report collects the locations of each bucket's content, keyed by location
+type report struct {
+	buckets   map[uuid.UUID]*entities.Bucket
+	nodes     map[uuid.UUID]*entities.StorageNode
+	nodeURLs  map[string]*entities.StorageNode
+	locations map[uuid.UUID]map[string]*models.ResidencyLocationResponse
+}
+
+// Handle reports where the content of every bucket physically is: file versions on the master or
+// nodes, snapshot copies, cached derivatives on this server, and backup copies at their destinations
+func (h *GetResidencyReportRequestHandler) Handle(ctx context.Context, command *GetResidencyReportCommand) (*GetResidencyReportResponse, error) {
+	db := h.dbContext.GetDB().WithContext(ctx)
+
+	buckets, err := reportedBuckets(db, command.BucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch buckets: %w", err)
+	}
+	if command.BucketID != nil && len(buckets) == 0 {
+		return nil, ErrBucketNotFound
+	}
+
+	nodes, err := h.dbContext.StorageNodes.ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch storage nodes: %w", err)
+	}
+
+	r := &report{
+		buckets:   make(map[uuid.UUID]*entities.Bucket, len(buckets)),
+		nodes:     make(map[uuid.UUID]*entities.StorageNode, len(nodes)),
+		nodeURLs:  make(map[string]*entities.StorageNode, len(nodes)),
+		locations: make(map[uuid.UUID]map[string]*models.ResidencyLocationResponse, len(buckets)),
+	}
+	for i := range buckets {
+		r.buckets[buckets[i].Id] = &buckets[i]
+	}
+	for i := range nodes {
+		r.nodes[nodes[i].Id] = &nodes[i]
+		r.nodeURLs[strings.TrimRight(nodes[i].URL, "/")] = &nodes[i]
+	}
+
+	if err := h.collectFiles(db, r); err != nil {
+		return nil, err
+	}
+	if err := h.collectSnapshots(db, r); err != nil {
+		return nil, err
+	}
+	if err := h.collectBackups(db, r); err != nil {
+		return nil, err
+	}
+
+	response := &GetResidencyReportResponse{
+		Buckets:     []models.BucketResidencyResponse{},
+		Compliant:   true,
+		GeneratedAt: time.Now(),
+		Success:     true,
+		Message:     "Residency report generated successfully",
+	}
+	for _, bucket := range buckets {
+		residency := r.bucketResidency(&bucket)
+		if !residency.Compliant {
+			response.Compliant = false
+		} else if command.NonCompliant {
+			continue
+		}
+		response.Buckets = append(response.Buckets, residency)
+	}
+	return response, nil
+}
+
+// reportedBuckets loads the bucket with bucketID, or every bucket when it is nil, by name
+func reportedBuckets(db *gorm.DB, bucketID *uuid.UUID) ([]entities.Bucket, error) {
+	var buckets []entities.Bucket
+	query := db.Model(&entities.Bucket{})
+	if bucketID != nil {
+		query = query.Where(`"Id" = ?`, *bucketID)
+	}
+	err := query.Order(`"Name"`).Find(&buckets).Error
+	return buckets, err
+}
+
+// collectFiles counts file versions where their content is, and the derived content this server caches for them
+func (h *GetResidencyReportRequestHandler) collectFiles(db *gorm.DB, r *report) error {
+	var files []struct {
+		Id       uuid.UUID
+		BucketId uuid.UUID
+		Path     string
+		Size     int64
+	}
+	if err := db.Model(&entities.File{}).
+		Select("Id", "BucketId", "Path", "Size").Find(&files).Error; err != nil {
+		return fmt.Errorf("failed to fetch files: %w", err)
+	}
+
+	cached := h.cachedFiles()
+	for _, file := range files {
+		if r.buckets[file.BucketId] == nil {
+			continue
+		}
+		location := r.contentLocation(file.BucketId, file.Path)
+		location.Files++
+		location.Bytes += file.Size
+
+		if cached[file.Id] {
+			location := r.location(file.BucketId, "master", nil)
+			location.CachedFiles++
+			location.Bytes += dirSize(filepath.Join(h.settings.DerivedCachePath, file.Id.String()))
+		}
+	}
+	return nil
+}
+
+// collectSnapshots counts content kept for snapshots: hard links on the master, or the node copy a file had
+func (h *GetResidencyReportRequestHandler) collectSnapshots(db *gorm.DB, r *report) error {
+	var snapshots []entities.BucketSnapshot
+	if err := db.Select("Id", "BucketId").Find(&snapshots).Error; err != nil {
+		return fmt.Errorf("failed to fetch snapshots: %w", err)
+	}
+	snapshotBuckets := make(map[uuid.UUID]uuid.UUID, len(snapshots))
+	for _, snapshot := range snapshots {
+		snapshotBuckets[snapshot.Id] = snapshot.BucketId
+	}
+
+	var snapshotFiles []struct {
+		SnapshotId uuid.UUID
+		Path       string
+		Size       int64
+	}
+	if err := db.Model(&entities.SnapshotFile{}).Select("SnapshotId", "Path", "Size").Find(&snapshotFiles).Error; err != nil {
+		return fmt.Errorf("failed to fetch snapshot files: %w", err)
+	}
+	for _, file := range snapshotFiles {
+		bucketID := snapshotBuckets[file.SnapshotId]
+		if r.buckets[bucketID] == nil {
+			continue
+		}
+		location := r.contentLocation(bucketID, file.Path)
+		location.SnapshotCopies++
+		location.Bytes += file.Size
+	}
+	return nil
+}
+
+// collectBackups counts backup copies per destination. Backup nodes that are also registered
+// storage nodes are reported with their group; other destinations are outside every placement.
+func (h *GetResidencyReportRequestHandler) collectBackups(db *gorm.DB, r *report) error {
+	var backups []struct {
+		BucketId    uuid.UUID
+		Destination string
+		Count       int64
+		Bytes       int64
+	}
+	if err := db.Model(&entities.BackupObject{}).
+		Select(`"BucketId", "Destination", COUNT(*) AS "Count", SUM("Size") AS "Bytes"`).
+		Group(`"BucketId", "Destination"`).Scan(&backups).Error; err != nil {
+		return fmt.Errorf("failed to fetch backups: %w", err)
+	}
+
+	for _, backup := range backups {
+		if r.buckets[backup.BucketId] == nil {
+			continue
+		}
+		location := r.location(backup.BucketId, "backup:"+backup.Destination, nil)
+		location.Type = "backup"
+		location.Name = backup.Destination
+		location.Zone = h.settings.BackupZone
+		if url, ok := strings.CutPrefix(backup.Destination, "node:"); ok {
+			if node := r.nodeURLs[url]; node != nil {
+				location.NodeID = &node.Id
+				location.Group = node.Group
+				if location.Zone == "" {
+					location.Zone = node.Zone
+				}
+				location.Allowed = placement.Allows(r.buckets[backup.BucketId], node)
+			}
+		}
+		location.BackupCopies += backup.Count
+		location.Bytes += backup.Bytes
+	}
+	return nil
+}
+
+// cachedFiles lists the files this server holds derived content for
+func (h *GetResidencyReportRequestHandler) cachedFiles() map[uuid.UUID]bool {
+	cached := make(map[uuid.UUID]bool)
+	entries, err := os.ReadDir(h.settings.DerivedCachePath)
+	if err != nil {
+		return cached
+	}
+	for _, entry := range entries {
+		if fileID, err := uuid.Parse(entry.Name()); err == nil && entry.IsDir() {
+			cached[fileID] = true
+		}
+	}
+	return cached
+}
+
+// contentLocation finds the location of content at path: the node of a node:// path, otherwise the master
+func (r *report) contentLocation(bucketID uuid.UUID, path string) *models.ResidencyLocationResponse {
+	if !storage.IsNodePath(path) {
+		return r.location(bucketID, "master", nil)
+	}
+	nodePath, err := storage.ParseNodePath(path)
+	if err != nil {
+		return r.location(bucketID, "node:unknown", nil)
+	}
+	return r.location(bucketID, "node:"+nodePath.NodeID.String(), &nodePath.NodeID)
+}
+
+// location returns the entry for a location of a bucket, creating it on first use
+func (r *report) location(bucketID uuid.UUID, key string, nodeID *uuid.UUID) *models.ResidencyLocationResponse {
+	locations := r.locations[bucketID]
+	if locations == nil {
+		locations = make(map[string]*models.ResidencyLocationResponse)
+		r.locations[bucketID] = locations
+	}
+	if location := locations[key]; location != nil {
+		return location
+	}
+
+	bucket := r.buckets[bucketID]
+	location := &models.ResidencyLocationResponse{Type: "master", Name: "master", Zone: placement.Zone(nil), Allowed: placement.Allows(bucket, nil)}
+	if strings.HasPrefix(key, "node:") {
+		location.Type = "node"
+		location.Name = "unknown node"
+		location.Allowed = !placement.Pinned(bucket)
+		if nodeID != nil {
+			location.NodeID = nodeID
+			if node := r.nodes[*nodeID]; node != nil {
+				location.Name = node.Name
+				location.Group = node.Group
+				location.Zone = node.Zone
+				location.Allowed = placement.Allows(bucket, node)
+			}
+		}
+	}
+	locations[key] = location
+	return location
+}
+
+func (r *report) bucketResidency(bucket *entities.Bucket) models.BucketResidencyResponse {
+	residency := models.BucketResidencyResponse{
+		BucketID:        bucket.Id,
+		BucketName:      bucket.Name,
+		PlacementNodeID: bucket.Settings.PlacementNodeId,
+		PlacementGroup:  bucket.Settings.PlacementGroup,
+		PlacementZone:   bucket.Settings.PlacementZone,
+		Compliant:       true,
+		Locations:       []models.ResidencyLocationResponse{},
+	}
+	for _, location := range r.locations[bucket.Id] {
+		if !location.Allowed {
+			residency.Compliant = false
+		}
+		residency.Locations = append(residency.Locations, *location)
+	}
+	sort.Slice(residency.Locations, func(i, j int) bool {
+		a, b := residency.Locations[i], residency.Locations[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+	return residency
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
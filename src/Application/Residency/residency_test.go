@@ -0,0 +1,84 @@
+package residency
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+	"shbucket/src/Models"
+)
+
+// TestCollect counts a bucket's files, snapshot copies and backups by location
+func TestCollect(t *testing.T) {
+	db := sqlitetest.Open(t)
+	videos := sqlitetest.CreateBucket(t, db, "videos")
+	photos := sqlitetest.CreateBucket(t, db, "photos")
+	nodeID := uuid.New()
+	snapshot := entities.BucketSnapshot{BucketId: photos.Id, Name: "daily", CreatedBy: uuid.New()}
+	records := []interface{}{
+		&entities.File{BucketId: photos.Id, Name: "a.txt", OriginalName: "a.txt", Path: "/data/a.txt", Size: 10},
+		&entities.File{BucketId: photos.Id, Name: "b.txt", OriginalName: "b.txt", Path: "node://" + nodeID.String() + "/" + photos.Id.String() + "/" + uuid.NewString(), Size: 20},
+		&entities.File{BucketId: videos.Id, Name: "c.mp4", OriginalName: "c.mp4", Path: "/data/c.mp4", Size: 40},
+		&snapshot,
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, record := range []interface{}{
+		&entities.SnapshotFile{SnapshotId: snapshot.Id, FileId: uuid.New(), Name: "a.txt", Path: "/data/.snapshots/a.txt", Size: 10},
+		&entities.BackupObject{Destination: "s3:backups", FileId: uuid.New(), BucketId: photos.Id, BucketName: "photos", Name: "a.txt", Size: 10, RunId: uuid.New()},
+		&entities.BackupObject{Destination: "s3:backups", FileId: uuid.New(), BucketId: photos.Id, BucketName: "photos", Name: "b.txt", Size: 20, RunId: uuid.New()},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buckets, err := reportedBuckets(db, &photos.Id)
+	if err != nil {
+		t.Fatalf("reportedBuckets() = %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Id != photos.Id {
+		t.Fatalf("reportedBuckets() of photos = %+v, want photos", buckets)
+	}
+	all, err := reportedBuckets(db, nil)
+	if err != nil || len(all) != 2 || all[0].Name != "photos" {
+		t.Errorf("reportedBuckets() = %+v, %v, want both buckets by name", all, err)
+	}
+
+	h := &GetResidencyReportRequestHandler{settings: &config.Settings{}}
+	r := &report{
+		buckets:   map[uuid.UUID]*entities.Bucket{photos.Id: &buckets[0]},
+		nodes:     map[uuid.UUID]*entities.StorageNode{},
+		nodeURLs:  map[string]*entities.StorageNode{},
+		locations: map[uuid.UUID]map[string]*models.ResidencyLocationResponse{},
+	}
+	if err := h.collectFiles(db, r); err != nil {
+		t.Fatalf("collectFiles() = %v", err)
+	}
+	if err := h.collectSnapshots(db, r); err != nil {
+		t.Fatalf("collectSnapshots() = %v", err)
+	}
+	if err := h.collectBackups(db, r); err != nil {
+		t.Fatalf("collectBackups() = %v", err)
+	}
+
+	locations := r.locations[photos.Id]
+	if master := locations["master"]; master == nil || master.Files != 1 || master.SnapshotCopies != 1 || master.Bytes != 20 {
+		t.Errorf("master location = %+v, want a file and a snapshot copy of 20 bytes", master)
+	}
+	if node := locations["node:"+nodeID.String()]; node == nil || node.Files != 1 || node.Bytes != 20 {
+		t.Errorf("node location = %+v, want a file of 20 bytes", node)
+	}
+	if backup := locations["backup:s3:backups"]; backup == nil || backup.BackupCopies != 2 || backup.Bytes != 30 {
+		t.Errorf("backup location = %+v, want two copies of 30 bytes", backup)
+	}
+	if len(r.locations) != 1 {
+		t.Errorf("report has locations for %d buckets, want only photos", len(r.locations))
+	}
+}
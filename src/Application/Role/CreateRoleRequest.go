@@ -0,0 +1,82 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type CreateRoleCommand struct {
+	Name        string    `json:"name" validate:"required"`
+	Description string    `json:"description" validate:"max=500"`
+	Permissions []string  `json:"permissions" validate:"required,min=1"`
+	UserID      uuid.UUID `json:"-"`
+}
+
+type CreateRoleResponse struct {
+	Role    models.RoleResponse `json:"role"`
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+}
+
+type CreateRoleRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewCreateRoleRequestHandler(dbContext *persistence.AppDbContext) *CreateRoleRequestHandler {
+	return &CreateRoleRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle creates a custom role of the named permissions, which users and API keys can then be given
+func (h *CreateRoleRequestHandler) Handle(ctx context.Context, command *CreateRoleCommand) (*CreateRoleResponse, error) {
+	if auth.IsBuiltinRole(command.Name) {
+		return nil, ErrBuiltinRole
+	}
+	if !namePattern.MatchString(command.Name) {
+		return nil, ErrInvalidName
+	}
+	permissions, err := normalizePermissions(command.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := h.dbContext.Roles.Where(&entities.Role{Name: command.Name}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up role: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrRoleExists
+	}
+
+	permissionsJSON, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal permissions: %w", err)
+	}
+	role := entities.Role{
+		Id:          uuid.New(),
+		Name:        command.Name,
+		Description: command.Description,
+		Permissions: permissionsJSON,
+		CreatedBy:   command.UserID,
+	}
+	if err := h.dbContext.GetDB().WithContext(ctx).Create(&role).Error; err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+	auth.InvalidateRoles()
+	log.Printf("Audit: admin %s created role %s with %v", command.UserID, role.Name, permissions)
+
+	return &CreateRoleResponse{
+		Role:    ToRoleResponse(&role),
+		Success: true,
+		Message: "Role created successfully",
+	}, nil
+}
@@ -0,0 +1,79 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DeleteRoleCommand struct {
+	RoleID uuid.UUID `json:"-"`
+	UserID uuid.UUID `json:"-"`
+}
+
+type DeleteRoleResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteRoleRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDeleteRoleRequestHandler(dbContext *persistence.AppDbContext) *DeleteRoleRequestHandler {
+	return &DeleteRoleRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle deletes a custom role nobody holds. Users and API keys holding it are given another role first,
+// a role that disappeared from under them would lock them out.
+func (h *DeleteRoleRequestHandler) Handle(ctx context.Context, command *DeleteRoleCommand) (*DeleteRoleResponse, error) {
+	role, err := h.dbContext.Roles.Where(&entities.Role{Id: command.RoleID}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up role: %w", err)
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	db := h.dbContext.GetDB().WithContext(ctx)
+	users, apiKeys, invitations, err := roleHolders(db, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if users+apiKeys+invitations > 0 {
+		return nil, fmt.Errorf("%w: %d users, %d API keys and %d pending invitations", ErrRoleInUse, users, apiKeys, invitations)
+	}
+
+	if err := db.Delete(&entities.Role{}, `"Id" = ?`, role.Id).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete role: %w", err)
+	}
+	auth.InvalidateRoles()
+	log.Printf("Audit: admin %s deleted role %s", command.UserID, role.Name)
+
+	return &DeleteRoleResponse{
+		Success: true,
+		Message: "Role deleted successfully",
+	}, nil
+}
+
+// roleHolders counts the users, API keys and pending invitations holding a role
+func roleHolders(db *gorm.DB, name string) (users, apiKeys, invitations int64, err error) {
+	if err := db.Model(&entities.User{}).Where(`"Role" = ?`, name).Count(&users).Error; err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	if err := db.Model(&entities.APIKey{}).Where(`"Role" = ?`, name).Count(&apiKeys).Error; err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if err := db.Model(&entities.UserInvitation{}).Where(`"Role" = ? AND "AcceptedAt" IS NULL`, name).Count(&invitations).Error; err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to count invitations: %w", err)
+	}
+	return users, apiKeys, invitations, nil
+}
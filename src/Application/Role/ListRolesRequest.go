@@ -0,0 +1,54 @@
+package role
+
+import (
+	"context"
+	"fmt"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Routing"
+	"shbucket/src/Models"
+)
+
+type ListRolesCommand struct{}
+
+type ListRolesResponse struct {
+	Roles []models.RoleResponse `json:"roles"`
+	// Permissions are every permission a custom role can hold
+	Permissions []string `json:"permissions"`
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+}
+
+type ListRolesRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListRolesRequestHandler(dbContext *persistence.AppDbContext) *ListRolesRequestHandler {
+	return &ListRolesRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists the built-in roles, lowest first, then the custom roles by name
+func (h *ListRolesRequestHandler) Handle(ctx context.Context, command *ListRolesCommand) (*ListRolesResponse, error) {
+	var roles []entities.Role
+	if err := h.dbContext.GetDB().WithContext(ctx).Order(`"Name"`).Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+
+	responses := make([]models.RoleResponse, 0, len(routing.Roles)+len(roles))
+	for _, name := range routing.Roles {
+		responses = append(responses, builtinRoleResponse(name))
+	}
+	for i := range roles {
+		responses = append(responses, ToRoleResponse(&roles[i]))
+	}
+
+	return &ListRolesResponse{
+		Roles:       responses,
+		Permissions: routing.Permissions,
+		Success:     true,
+		Message:     "Roles retrieved successfully",
+	}, nil
+}
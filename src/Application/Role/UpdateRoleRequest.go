@@ -0,0 +1,84 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type UpdateRoleCommand struct {
+	RoleID      uuid.UUID `json:"-"`
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=500"`
+	Permissions []string  `json:"permissions,omitempty"` // replaces the role's permissions when set
+	UserID      uuid.UUID `json:"-"`
+}
+
+type UpdateRoleResponse struct {
+	Role    models.RoleResponse `json:"role"`
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+}
+
+type UpdateRoleRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewUpdateRoleRequestHandler(dbContext *persistence.AppDbContext) *UpdateRoleRequestHandler {
+	return &UpdateRoleRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle changes a custom role's description or permissions. The name stays, users and API keys
+// hold the role by it. The new permissions apply to every holder at once.
+func (h *UpdateRoleRequestHandler) Handle(ctx context.Context, command *UpdateRoleCommand) (*UpdateRoleResponse, error) {
+	role, err := h.dbContext.Roles.Where(&entities.Role{Id: command.RoleID}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up role: %w", err)
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	updates := map[string]interface{}{}
+	if command.Description != nil {
+		role.Description = *command.Description
+		updates["description"] = role.Description
+	}
+	if command.Permissions != nil {
+		permissions, err := normalizePermissions(command.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		if len(permissions) == 0 {
+			return nil, apierror.New(apierror.CodeInvalidRequest, "a role needs at least one permission")
+		}
+		permissionsJSON, err := json.Marshal(permissions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal permissions: %w", err)
+		}
+		role.Permissions = permissionsJSON
+		updates["permissions"] = role.Permissions
+		log.Printf("Audit: admin %s set the permissions of role %s to %v", command.UserID, role.Name, permissions)
+	}
+	if len(updates) > 0 {
+		if err := h.dbContext.GetDB().WithContext(ctx).Model(role).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update role: %w", err)
+		}
+		auth.InvalidateRoles()
+	}
+
+	return &UpdateRoleResponse{
+		Role:    ToRoleResponse(role),
+		Success: true,
+		Message: "Role updated successfully",
+	}, nil
+}
@@ -0,0 +1,76 @@
+package role
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Routing"
+	"shbucket/src/Models"
+)
+
+// namePattern restricts role names to lowercase words, as the built-in roles are named
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+var (
+	// ErrRoleNotFound is returned for custom roles that don't exist
+	ErrRoleNotFound = apierror.New(apierror.CodeNotFound, "role not found")
+	// ErrInvalidName is returned for role names outside namePattern
+	ErrInvalidName = apierror.New(apierror.CodeInvalidRequest, "role names are 2 to 50 lowercase letters, digits, '_' or '-', starting with a letter")
+	// ErrBuiltinRole is returned when creating a role named like a built-in role
+	ErrBuiltinRole = apierror.New(apierror.CodeInvalidRequest, "built-in roles can't be created or changed")
+	// ErrRoleExists is returned when creating a role whose name is taken
+	ErrRoleExists = apierror.New(apierror.CodeAlreadyExists, "a role with this name already exists")
+	// ErrRoleInUse is returned when deleting a role users or API keys still hold
+	ErrRoleInUse = apierror.New(apierror.CodeConflict, "role is assigned to users or API keys")
+	// ErrUnknownPermission is returned for permissions custom roles can't hold
+	ErrUnknownPermission = apierror.New(apierror.CodeInvalidRequest, "unknown permission")
+)
+
+// normalizePermissions checks every permission is known and drops repeats, in the order of routing.Permissions
+func normalizePermissions(permissions []string) ([]string, error) {
+	for _, permission := range permissions {
+		if !routing.IsPermission(permission) {
+			return nil, fmt.Errorf("%w %q, use one of %v", ErrUnknownPermission, permission, routing.Permissions)
+		}
+	}
+	normalized := []string{}
+	for _, permission := range routing.Permissions {
+		if slices.Contains(permissions, permission) {
+			normalized = append(normalized, permission)
+		}
+	}
+	return normalized, nil
+}
+
+// ToRoleResponse converts a custom role to its response model
+func ToRoleResponse(role *entities.Role) models.RoleResponse {
+	permissions := []string{}
+	json.Unmarshal(role.Permissions, &permissions)
+
+	id := role.Id
+	createdAt := role.CreatedAt
+	updatedAt := role.UpdatedAt
+	return models.RoleResponse{
+		ID:          &id,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   &createdAt,
+		UpdatedAt:   &updatedAt,
+	}
+}
+
+// builtinRoleResponse describes a built-in role
+func builtinRoleResponse(name string) models.RoleResponse {
+	return models.RoleResponse{
+		Name:        name,
+		Description: "Built-in role, holds everything the roles below it hold",
+		Permissions: auth.BuiltinPermissions(name),
+		Builtin:     true,
+	}
+}
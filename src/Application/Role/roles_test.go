@@ -0,0 +1,39 @@
+package role
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRoleHolders counts the users, API keys and pending invitations of a role, not accepted ones
+func TestRoleHolders(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	user := entities.User{Username: "ada", Email: "ada@example.com", PasswordHash: "hash", Role: "auditor", IsActive: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	key := entities.APIKey{Name: "ci", KeyHash: "hash", KeyPrefix: "sk_", UserId: user.Id, IsActive: true, Role: "auditor"}
+	if err := db.Create(&key).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, accepted := range []*time.Time{nil, &now} {
+		invitation := entities.UserInvitation{Email: "invited@example.com", Role: "auditor", TokenHash: string(rune('a' + i)), ExpiresAt: now.Add(time.Hour), InvitedBy: uuid.New(), AcceptedAt: accepted}
+		if err := db.Create(&invitation).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, apiKeys, invitations, err := roleHolders(db, "auditor")
+	if err != nil || users != 1 || apiKeys != 1 || invitations != 1 {
+		t.Errorf("roleHolders() = %d, %d, %d, %v, want 1, 1, 1", users, apiKeys, invitations, err)
+	}
+	if users, apiKeys, invitations, err := roleHolders(db, "editor"); err != nil || users+apiKeys+invitations != 0 {
+		t.Errorf("roleHolders() of an unused role = %d, %d, %d, %v, want none", users, apiKeys, invitations, err)
+	}
+}
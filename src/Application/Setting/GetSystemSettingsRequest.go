@@ -0,0 +1,45 @@
+package setting
+
+import (
+	"context"
+	"fmt"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetSystemSettingsCommand struct{}
+
+type GetSystemSettingsResponse struct {
+	Settings        config.RuntimeSettings                 `json:"settings"`
+	RestartRequired models.RestartRequiredSettingsResponse `json:"restart_required"`
+	Metadata        models.SystemSettingsMetadataResponse  `json:"metadata"`
+	Success         bool                                   `json:"success"`
+	Message         string                                 `json:"message"`
+}
+
+type GetSystemSettingsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetSystemSettingsRequestHandler(dbContext *persistence.AppDbContext) *GetSystemSettingsRequestHandler {
+	return &GetSystemSettingsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *GetSystemSettingsRequestHandler) Handle(ctx context.Context, command *GetSystemSettingsCommand) (*GetSystemSettingsResponse, error) {
+	stored, err := h.dbContext.SystemSettings.FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch system settings: %w", err)
+	}
+
+	return &GetSystemSettingsResponse{
+		Settings:        config.GetRuntimeSettings(),
+		RestartRequired: restartRequiredSettings(),
+		Metadata:        settingsMetadata(stored),
+		Success:         true,
+		Message:         "System settings retrieved successfully",
+	}, nil
+}
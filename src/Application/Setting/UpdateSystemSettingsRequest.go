@@ -0,0 +1,160 @@
+package setting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// UpdateSystemSettingsCommand changes hot-reloadable settings; omitted fields keep their current value
+type UpdateSystemSettingsCommand struct {
+	UserID                    uuid.UUID `json:"-"`
+	CORSAllowOrigins          *string   `json:"cors_allow_origins" validate:"omitempty,min=1"`
+	CORSAllowMethods          *string   `json:"cors_allow_methods" validate:"omitempty,min=1"`
+	CORSAllowHeaders          *string   `json:"cors_allow_headers"`
+	CORSExposeHeaders         *string   `json:"cors_expose_headers"`
+	CORSAllowCredentials      *bool     `json:"cors_allow_credentials"`
+	CORSMaxAge                *int      `json:"cors_max_age" validate:"omitempty,min=0"`
+	RateLimitRequests         *int      `json:"rate_limit_requests" validate:"omitempty,min=0"`
+	RateLimitWindow           *int      `json:"rate_limit_window" validate:"omitempty,min=1"`
+	DefaultBucketMaxFileSize  *int64    `json:"default_bucket_max_file_size" validate:"omitempty,min=0"`
+	DefaultBucketMaxTotalSize *int64    `json:"default_bucket_max_total_size" validate:"omitempty,min=0"`
+	DefaultBucketMaxFiles     *int64    `json:"default_bucket_max_files" validate:"omitempty,min=0"`
+	ImageTransformsEnabled    *bool     `json:"image_transforms_enabled"`
+	ImageMaxInputDimension    *int      `json:"image_max_input_dimension" validate:"omitempty,min=0"`
+	ImageMaxInputMegapixels   *int      `json:"image_max_input_megapixels" validate:"omitempty,min=0"`
+	ImageMaxOutputDimension   *int      `json:"image_max_output_dimension" validate:"omitempty,min=0"`
+	ImageAllowedFormats       *string   `json:"image_allowed_formats"`     // comma-separated, e.g. "jpeg,png,webp"
+	TwoFactorRequiredRoles    *string   `json:"two_factor_required_roles"` // comma-separated, e.g. "admin,manager"
+}
+
+type UpdateSystemSettingsResponse struct {
+	Settings        config.RuntimeSettings                 `json:"settings"`
+	RestartRequired models.RestartRequiredSettingsResponse `json:"restart_required"`
+	Metadata        models.SystemSettingsMetadataResponse  `json:"metadata"`
+	Success         bool                                   `json:"success"`
+	Message         string                                 `json:"message"`
+}
+
+type UpdateSystemSettingsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewUpdateSystemSettingsRequestHandler(dbContext *persistence.AppDbContext) *UpdateSystemSettingsRequestHandler {
+	return &UpdateSystemSettingsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle stores the new settings and applies them to the running server
+func (h *UpdateSystemSettingsRequestHandler) Handle(ctx context.Context, command *UpdateSystemSettingsCommand) (*UpdateSystemSettingsResponse, error) {
+	stored, err := h.dbContext.SystemSettings.FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch system settings: %w", err)
+	}
+
+	// Changes apply to the saved settings, which another server may have saved since this one loaded them
+	settings := config.GetRuntimeSettings()
+	if stored != nil {
+		settings = toRuntimeSettings(stored)
+	}
+
+	if command.CORSAllowOrigins != nil {
+		settings.CORSAllowOrigins = *command.CORSAllowOrigins
+	}
+	if command.CORSAllowMethods != nil {
+		settings.CORSAllowMethods = *command.CORSAllowMethods
+	}
+	if command.CORSAllowHeaders != nil {
+		settings.CORSAllowHeaders = *command.CORSAllowHeaders
+	}
+	if command.CORSExposeHeaders != nil {
+		settings.CORSExposeHeaders = *command.CORSExposeHeaders
+	}
+	if command.CORSAllowCredentials != nil {
+		settings.CORSAllowCredentials = *command.CORSAllowCredentials
+	}
+	if command.CORSMaxAge != nil {
+		settings.CORSMaxAge = *command.CORSMaxAge
+	}
+	if command.RateLimitRequests != nil {
+		settings.RateLimitRequests = *command.RateLimitRequests
+	}
+	if command.RateLimitWindow != nil {
+		settings.RateLimitWindow = *command.RateLimitWindow
+	}
+	if command.DefaultBucketMaxFileSize != nil {
+		settings.DefaultBucketMaxFileSize = *command.DefaultBucketMaxFileSize
+	}
+	if command.DefaultBucketMaxTotalSize != nil {
+		settings.DefaultBucketMaxTotalSize = *command.DefaultBucketMaxTotalSize
+	}
+	if command.DefaultBucketMaxFiles != nil {
+		settings.DefaultBucketMaxFiles = *command.DefaultBucketMaxFiles
+	}
+	if command.ImageTransformsEnabled != nil {
+		settings.ImageTransformsEnabled = *command.ImageTransformsEnabled
+	}
+	if command.ImageMaxInputDimension != nil {
+		settings.ImageMaxInputDimension = *command.ImageMaxInputDimension
+	}
+	if command.ImageMaxInputMegapixels != nil {
+		settings.ImageMaxInputMegapixels = *command.ImageMaxInputMegapixels
+	}
+	if command.ImageMaxOutputDimension != nil {
+		settings.ImageMaxOutputDimension = *command.ImageMaxOutputDimension
+	}
+	if command.ImageAllowedFormats != nil {
+		formats, err := normalizeImageFormats(*command.ImageAllowedFormats)
+		if err != nil {
+			return nil, err
+		}
+		settings.ImageAllowedFormats = formats
+	}
+	if command.TwoFactorRequiredRoles != nil {
+		roles, err := normalizeRoles(*command.TwoFactorRequiredRoles)
+		if err != nil {
+			return nil, err
+		}
+		settings.TwoFactorRequiredRoles = roles
+	}
+
+	if err := validateCORSOrigins(settings.CORSAllowOrigins, settings.CORSAllowCredentials); err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		stored = &entities.SystemSettings{Id: uuid.New()}
+		applyRuntimeSettings(stored, settings)
+		stored.UpdatedBy = &command.UserID
+		if _, err := h.dbContext.SystemSettings.Add(*stored); err != nil {
+			return nil, fmt.Errorf("failed to save system settings: %w", err)
+		}
+	} else {
+		applyRuntimeSettings(stored, settings)
+		stored.UpdatedBy = &command.UserID
+		if err := h.dbContext.SystemSettings.Update(*stored); err != nil {
+			return nil, fmt.Errorf("failed to save system settings: %w", err)
+		}
+	}
+
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save system settings: %w", err)
+	}
+
+	config.SetRuntimeSettings(settings)
+
+	return &UpdateSystemSettingsResponse{
+		Settings:        settings,
+		RestartRequired: restartRequiredSettings(),
+		Metadata:        settingsMetadata(stored),
+		Success:         true,
+		Message:         "System settings updated successfully",
+	}, nil
+}
@@ -0,0 +1,153 @@
+package setting
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Media"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Routing"
+	"shbucket/src/Models"
+)
+
+// LoadSystemSettings applies settings saved through the admin API on top of the environment configuration.
+// Settings already applied are left alone, so it is also run periodically to pick up settings saved
+// through another server.
+func LoadSystemSettings(dbContext *persistence.AppDbContext) error {
+	stored, err := dbContext.SystemSettings.FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to load system settings: %w", err)
+	}
+	if stored != nil {
+		if settings := toRuntimeSettings(stored); settings != config.GetRuntimeSettings() {
+			config.SetRuntimeSettings(settings)
+		}
+	}
+	return nil
+}
+
+func toRuntimeSettings(stored *entities.SystemSettings) config.RuntimeSettings {
+	return config.RuntimeSettings{
+		CORSAllowOrigins:          stored.CORSAllowOrigins,
+		CORSAllowMethods:          stored.CORSAllowMethods,
+		CORSAllowHeaders:          stored.CORSAllowHeaders,
+		CORSExposeHeaders:         stored.CORSExposeHeaders,
+		CORSAllowCredentials:      stored.CORSAllowCredentials,
+		CORSMaxAge:                stored.CORSMaxAge,
+		RateLimitRequests:         stored.RateLimitRequests,
+		RateLimitWindow:           stored.RateLimitWindow,
+		DefaultBucketMaxFileSize:  stored.DefaultBucketMaxFileSize,
+		DefaultBucketMaxTotalSize: stored.DefaultBucketMaxTotalSize,
+		DefaultBucketMaxFiles:     stored.DefaultBucketMaxFiles,
+		ImageTransformsEnabled:    stored.ImageTransformsEnabled,
+		ImageMaxInputDimension:    stored.ImageMaxInputDimension,
+		ImageMaxInputMegapixels:   stored.ImageMaxInputMegapixels,
+		ImageMaxOutputDimension:   stored.ImageMaxOutputDimension,
+		ImageAllowedFormats:       stored.ImageAllowedFormats,
+		TwoFactorRequiredRoles:    stored.TwoFactorRequiredRoles,
+	}
+}
+
+func applyRuntimeSettings(stored *entities.SystemSettings, settings config.RuntimeSettings) {
+	stored.CORSAllowOrigins = settings.CORSAllowOrigins
+	stored.CORSAllowMethods = settings.CORSAllowMethods
+	stored.CORSAllowHeaders = settings.CORSAllowHeaders
+	stored.CORSExposeHeaders = settings.CORSExposeHeaders
+	stored.CORSAllowCredentials = settings.CORSAllowCredentials
+	stored.CORSMaxAge = settings.CORSMaxAge
+	stored.RateLimitRequests = settings.RateLimitRequests
+	stored.RateLimitWindow = settings.RateLimitWindow
+	stored.DefaultBucketMaxFileSize = settings.DefaultBucketMaxFileSize
+	stored.DefaultBucketMaxTotalSize = settings.DefaultBucketMaxTotalSize
+	stored.DefaultBucketMaxFiles = settings.DefaultBucketMaxFiles
+	stored.ImageTransformsEnabled = settings.ImageTransformsEnabled
+	stored.ImageMaxInputDimension = settings.ImageMaxInputDimension
+	stored.ImageMaxInputMegapixels = settings.ImageMaxInputMegapixels
+	stored.ImageMaxOutputDimension = settings.ImageMaxOutputDimension
+	stored.ImageAllowedFormats = settings.ImageAllowedFormats
+	stored.TwoFactorRequiredRoles = settings.TwoFactorRequiredRoles
+}
+
+func restartRequiredSettings() models.RestartRequiredSettingsResponse {
+	settings := config.GetSettings()
+	return models.RestartRequiredSettingsResponse{
+		BaseURL:        settings.BaseURL,
+		Port:           settings.Port,
+		JWTExpiryHours: settings.JWTExpiryHours,
+		StoragePath:    settings.StoragePath,
+		MaxStorage:     settings.MaxStorage,
+		SystemName:     settings.SystemName,
+	}
+}
+
+func settingsMetadata(stored *entities.SystemSettings) models.SystemSettingsMetadataResponse {
+	if stored == nil {
+		return models.SystemSettingsMetadataResponse{Source: "environment"}
+	}
+	return models.SystemSettingsMetadataResponse{
+		Source:    "database",
+		UpdatedBy: stored.UpdatedBy,
+		UpdatedAt: &stored.UpdatedAt,
+	}
+}
+
+// normalizeImageFormats checks a comma-separated list of output formats and rewrites aliases such as jpg
+func normalizeImageFormats(formats string) (string, error) {
+	var normalized []string
+	for _, format := range strings.Split(formats, ",") {
+		if strings.TrimSpace(format) == "" {
+			continue
+		}
+		name := media.NormalizeFormat(format)
+		if name == "" {
+			return "", apierror.Newf(apierror.CodeInvalidRequest, "invalid image format: %q", strings.TrimSpace(format))
+		}
+		if !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// normalizeRoles checks a comma-separated list of roles and drops blanks and duplicates
+func normalizeRoles(roles string) (string, error) {
+	var normalized []string
+	for _, role := range strings.Split(roles, ",") {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" {
+			continue
+		}
+		if !slices.Contains(routing.Roles, role) {
+			return "", apierror.Newf(apierror.CodeInvalidRequest, "invalid role: %q", role)
+		}
+		if !slices.Contains(normalized, role) {
+			normalized = append(normalized, role)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// validateCORSOrigins rejects origin lists the CORS middleware would refuse to start with
+func validateCORSOrigins(origins string, allowCredentials bool) error {
+	if strings.TrimSpace(origins) == "*" {
+		if allowCredentials {
+			return apierror.New(apierror.CodeInvalidRequest, "cors_allow_credentials cannot be used with a wildcard origin")
+		}
+		return nil
+	}
+
+	for _, origin := range strings.Split(origins, ",") {
+		origin = strings.TrimSpace(strings.Replace(origin, "://*.", "://", 1))
+		parsed, err := url.Parse(origin)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			strings.Contains(parsed.Host, "*") || (parsed.Path != "" && parsed.Path != "/") {
+			return apierror.Newf(apierror.CodeInvalidRequest, "invalid CORS origin: %q", origin)
+		}
+	}
+	return nil
+}
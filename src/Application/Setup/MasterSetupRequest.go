@@ -8,6 +8,7 @@ import (
 	
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
@@ -46,14 +47,14 @@ func (h *MasterSetupRequestHandler) Handle(ctx context.Context, command *MasterS
 	// Check if already setup using GoNtext
 	existingConfig, _ := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{IsSetup: true}).FirstOrDefault()
 	if existingConfig != nil {
-		return nil, fmt.Errorf("system is already configured")
+		return nil, apierror.New(apierror.CodeConflict, "system is already configured")
 	}
 
 	// Check if admin user already exists 
 	existingUser, _ := h.dbContext.Users.Where(&entities.User{Email: command.AdminEmail}).
 		OrField("Username", command.AdminUsername).FirstOrDefault()
 	if existingUser != nil {
-		return nil, fmt.Errorf("admin user already exists") 
+		return nil, apierror.New(apierror.CodeAlreadyExists, "admin user already exists") 
 	}
 
 	// Create storage directory
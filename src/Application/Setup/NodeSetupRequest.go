@@ -10,6 +10,7 @@ import (
 	"time"
 	
 	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
@@ -22,6 +23,8 @@ type NodeSetupCommand struct {
 	StoragePath   string `json:"storage_path" validate:"required"`
 	MaxStorage    int64  `json:"max_storage" validate:"min=1"`
 	MasterAPIKey  string `json:"master_api_key" validate:"required"`
+	// RegistrationToken is the one-time token an admin of the master issued for this node
+	RegistrationToken string `json:"registration_token" validate:"required"`
 }
 
 type NodeSetupResponse struct {
@@ -45,7 +48,7 @@ func (h *NodeSetupRequestHandler) Handle(ctx context.Context, command *NodeSetup
 	// Check if already setup using GoNtext
 	existingConfig, _ := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{IsSetup: true}).FirstOrDefault()
 	if existingConfig != nil {
-		return nil, fmt.Errorf("system is already configured")
+		return nil, apierror.New(apierror.CodeConflict, "system is already configured")
 	}
 
 	// Create storage directory
@@ -53,8 +56,9 @@ func (h *NodeSetupRequestHandler) Handle(ctx context.Context, command *NodeSetup
 		return nil, fmt.Errorf("failed to create storage directory: %w", err)
 	}
 
-	// Register with master server using self-registration endpoint (no auth required)
+	// Register with master server using self-registration endpoint, which spends the registration token
 	nodeRegistration := map[string]interface{}{
+		"registration_token": command.RegistrationToken,
 		"name":        command.NodeName,
 		"url":         getCurrentServerURL(),
 		"max_storage": command.MaxStorage,
@@ -78,8 +82,11 @@ func (h *NodeSetupRequestHandler) Handle(ctx context.Context, command *NodeSetup
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "master server rejected the registration token, it may be used, revoked or expired")
+	}
 	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("master server rejected node registration: status %d", resp.StatusCode)
+		return nil, apierror.Newf(apierror.CodeBadGateway, "master server rejected node registration: status %d", resp.StatusCode)
 	}
 
 	var masterResponse struct {
@@ -93,7 +100,7 @@ func (h *NodeSetupRequestHandler) Handle(ctx context.Context, command *NodeSetup
 	}
 
 	if !masterResponse.Success {
-		return nil, fmt.Errorf("master server registration failed: %s", masterResponse.Message)
+		return nil, apierror.Newf(apierror.CodeBadGateway, "master server registration failed: %s", masterResponse.Message)
 	}
 
 	// Save local node configuration
@@ -0,0 +1,60 @@
+package signingkey
+
+import (
+	"context"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Signing"
+	"shbucket/src/Models"
+)
+
+type ListSigningKeysCommand struct{}
+
+type ListSigningKeysResponse struct {
+	// Keys are the signing keys, newest first
+	Keys    []models.SigningKeyResponse `json:"keys"`
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+}
+
+type ListSigningKeysRequestHandler struct {
+	keys *signing.Keyring
+}
+
+func NewListSigningKeysRequestHandler(dbContext *persistence.AppDbContext) *ListSigningKeysRequestHandler {
+	return &ListSigningKeysRequestHandler{
+		keys: signing.Keys(dbContext),
+	}
+}
+
+// Handle lists the signing keys, which one signs and when the others retire
+func (h *ListSigningKeysRequestHandler) Handle(ctx context.Context, command *ListSigningKeysCommand) (*ListSigningKeysResponse, error) {
+	keys, err := h.keys.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.SigningKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		responses = append(responses, keyResponse(keys, key))
+	}
+
+	return &ListSigningKeysResponse{
+		Keys:    responses,
+		Success: true,
+		Message: "Signing keys retrieved successfully",
+	}, nil
+}
+
+// keyResponse describes key, one of keys
+func keyResponse(keys []entities.SigningKey, key entities.SigningKey) models.SigningKeyResponse {
+	return models.SigningKeyResponse{
+		KeyID:     key.KeyID,
+		Active:    signing.Active(keys, key),
+		Retired:   key.RetiresAt != nil && !key.RetiresAt.After(time.Now()),
+		CreatedAt: key.CreatedAt,
+		RetiresAt: key.RetiresAt,
+	}
+}
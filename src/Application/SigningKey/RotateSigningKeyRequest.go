@@ -0,0 +1,64 @@
+package signingkey
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Signing"
+	"shbucket/src/Models"
+)
+
+type RotateSigningKeyCommand struct {
+	// RetireIn is how many seconds the replaced key still verifies what it signed, SIGNING_KEY_RETIREMENT
+	// when left out. 0 retires it at once, invalidating every URL and policy it signed.
+	RetireIn *int      `json:"retire_in" validate:"omitempty,min=0,max=31536000"`
+	UserID   uuid.UUID `json:"-"`
+}
+
+type RotateSigningKeyResponse struct {
+	// Key is the new key, which signs from now on
+	Key     models.SigningKeyResponse `json:"key"`
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+}
+
+type RotateSigningKeyRequestHandler struct {
+	keys     *signing.Keyring
+	settings *config.Settings
+}
+
+func NewRotateSigningKeyRequestHandler(dbContext *persistence.AppDbContext) *RotateSigningKeyRequestHandler {
+	return &RotateSigningKeyRequestHandler{
+		keys:     signing.Keys(dbContext),
+		settings: config.GetSettings(),
+	}
+}
+
+// Handle starts signing with a new key and gives the one it replaces its retirement date
+func (h *RotateSigningKeyRequestHandler) Handle(ctx context.Context, command *RotateSigningKeyCommand) (*RotateSigningKeyResponse, error) {
+	retireIn := h.settings.SigningKeyRetirement
+	if command.RetireIn != nil {
+		retireIn = *command.RetireIn
+	}
+
+	key, err := h.keys.Rotate(ctx, time.Duration(max(retireIn, 0))*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Audit: admin %s rotated the signing key to %s, the previous key retires in %ds", command.UserID, key.KeyID, retireIn)
+
+	keys, err := h.keys.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RotateSigningKeyResponse{
+		Key:     keyResponse(keys, *key),
+		Success: true,
+		Message: "Signing key rotated",
+	}, nil
+}
@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+type CreateSnapshotCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	Name     string    `json:"name" validate:"required,max=63,excludesall=/\\?#%"`
+}
+
+type CreateSnapshotResponse struct {
+	Snapshot models.SnapshotResponse `json:"snapshot"`
+	Success  bool                    `json:"success"`
+	Message  string                  `json:"message"`
+}
+
+type CreateSnapshotRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func NewCreateSnapshotRequestHandler(dbContext *persistence.AppDbContext) *CreateSnapshotRequestHandler {
+	return &CreateSnapshotRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Handle records the bucket's current object list and preserves each object's content.
+// Stored files never change in place, so hard links give copy-on-write semantics without
+// duplicating data. Node-stored content is shared by path and kept alive by the snapshot.
+func (h *CreateSnapshotRequestHandler) Handle(ctx context.Context, command *CreateSnapshotCommand) (*CreateSnapshotResponse, error) {
+	bucket, err := authorizeBucketOwner(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := findSnapshot(h.dbContext, bucket.Id, command.Name); err == nil && existing != nil {
+		return nil, apierror.Newf(apierror.CodeAlreadyExists, "snapshot %s already exists", command.Name)
+	}
+
+	files, err := h.dbContext.Files.Where(&entities.File{BucketId: bucket.Id}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch files: %w", err)
+	}
+
+	snapshot := entities.BucketSnapshot{
+		Id:        uuid.New(),
+		BucketId:  bucket.Id,
+		Name:      command.Name,
+		CreatedBy: command.UserID,
+	}
+
+	snapshotDir := filepath.Join(h.settings.StoragePath, ".snapshots", snapshot.Id.String())
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	for _, file := range files {
+		path := file.Path
+		if !storage.IsNodePath(file.Path) {
+			path = filepath.Join(snapshotDir, file.Id.String())
+			if err := storage.LinkFile(file.Path, path, 0444); err != nil {
+				os.RemoveAll(snapshotDir)
+				return nil, fmt.Errorf("failed to preserve %s: %w", file.Name, err)
+			}
+		}
+
+		h.dbContext.SnapshotFiles.Add(entities.SnapshotFile{
+			SnapshotId:    snapshot.Id,
+			FileId:        file.Id,
+			Name:          file.Name,
+			Path:          path,
+			Size:          file.Size,
+			MimeType:      file.MimeType,
+			Checksum:      file.Checksum,
+			FileCreatedAt: file.CreatedAt,
+			ContentEncoding: file.Metadata.ContentEncoding,
+			Encryption:    file.Encryption,
+		})
+		snapshot.FileCount++
+		snapshot.TotalSize += file.Size
+	}
+
+	h.dbContext.BucketSnapshots.Add(snapshot)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	log.Printf("Created snapshot %s of bucket %s with %d files", snapshot.Name, bucket.Name, snapshot.FileCount)
+
+	return &CreateSnapshotResponse{
+		Snapshot: toSnapshotResponse(snapshot),
+		Success:  true,
+		Message:  "Snapshot created successfully",
+	}, nil
+}
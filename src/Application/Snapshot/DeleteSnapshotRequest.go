@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+type DeleteSnapshotCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	Name     string    `json:"name"`
+	UserID   uuid.UUID `json:"user_id"`
+	UserRole string    `json:"-"`
+}
+
+type DeleteSnapshotResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteSnapshotRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func NewDeleteSnapshotRequestHandler(dbContext *persistence.AppDbContext) *DeleteSnapshotRequestHandler {
+	return &DeleteSnapshotRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Handle removes a snapshot and the content only it was keeping alive
+func (h *DeleteSnapshotRequestHandler) Handle(ctx context.Context, command *DeleteSnapshotCommand) (*DeleteSnapshotResponse, error) {
+	if _, err := authorizeBucketOwner(h.dbContext, command.BucketID, command.UserID, command.UserRole); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := findSnapshot(h.dbContext, command.BucketID, command.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{SnapshotId: snapshot.Id}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshot files: %w", err)
+	}
+
+	if err := deleteSnapshotFiles(h.dbContext.GetDB().WithContext(ctx), snapshot.Id); err != nil {
+		return nil, err
+	}
+	h.dbContext.BucketSnapshots.Remove(*snapshot)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	// Local content is hard-linked, so removing the snapshot's links is enough
+	if err := os.RemoveAll(filepath.Join(h.settings.StoragePath, ".snapshots", snapshot.Id.String())); err != nil {
+		log.Printf("Warning: failed to remove snapshot directory of %s: %v", snapshot.Name, err)
+	}
+
+	// Node content is removed once neither a live file nor another snapshot references it
+	for _, file := range files {
+		if !storage.IsNodePath(file.Path) {
+			continue
+		}
+		if count, err := h.dbContext.Files.Where(&entities.File{Path: file.Path}).Count(); err != nil || count > 0 {
+			continue
+		}
+		if count, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{Path: file.Path}).Count(); err != nil || count > 0 {
+			continue
+		}
+		if err := storage.RemoveFile(ctx, h.dbContext, file.Path); err != nil {
+			log.Printf("Warning: failed to remove content of %s kept for snapshot %s: %v", file.Name, snapshot.Name, err)
+		}
+	}
+
+	return &DeleteSnapshotResponse{
+		Success: true,
+		Message: "Snapshot deleted successfully",
+	}, nil
+}
+
+// deleteSnapshotFiles removes the file records of a snapshot
+func deleteSnapshotFiles(db *gorm.DB, snapshotID uuid.UUID) error {
+	if err := db.Where(&entities.SnapshotFile{SnapshotId: snapshotID}).Delete(&entities.SnapshotFile{}).Error; err != nil {
+		return fmt.Errorf("failed to delete snapshot files: %w", err)
+	}
+	return nil
+}
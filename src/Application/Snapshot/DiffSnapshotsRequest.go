@@ -0,0 +1,115 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type DiffSnapshotsCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	Base     string    `json:"base"`
+	Target   string    `json:"target"`
+}
+
+// DiffSnapshotsResponse compares objects by name; an object is changed when its content checksum or size differs
+type DiffSnapshotsResponse struct {
+	Base      models.SnapshotResponse             `json:"base"`
+	Target    models.SnapshotResponse             `json:"target"`
+	Added     []models.SnapshotFileResponse       `json:"added"`
+	Removed   []models.SnapshotFileResponse       `json:"removed"`
+	Changed   []models.SnapshotFileChangeResponse `json:"changed"`
+	Unchanged int                                 `json:"unchanged"`
+	Success   bool                                `json:"success"`
+	Message   string                              `json:"message"`
+}
+
+type DiffSnapshotsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDiffSnapshotsRequestHandler(dbContext *persistence.AppDbContext) *DiffSnapshotsRequestHandler {
+	return &DiffSnapshotsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *DiffSnapshotsRequestHandler) Handle(ctx context.Context, command *DiffSnapshotsCommand) (*DiffSnapshotsResponse, error) {
+	base, err := findSnapshot(h.dbContext, command.BucketID, command.Base)
+	if err != nil {
+		return nil, apierror.Newf(apierror.CodeNotFound, "snapshot %q not found", command.Base)
+	}
+	target, err := findSnapshot(h.dbContext, command.BucketID, command.Target)
+	if err != nil {
+		return nil, apierror.Newf(apierror.CodeNotFound, "snapshot %q not found", command.Target)
+	}
+
+	baseFiles, err := h.snapshotFilesByName(base.Id)
+	if err != nil {
+		return nil, err
+	}
+	targetFiles, err := h.snapshotFilesByName(target.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DiffSnapshotsResponse{
+		Base:    toSnapshotResponse(*base),
+		Target:  toSnapshotResponse(*target),
+		Added:   []models.SnapshotFileResponse{},
+		Removed: []models.SnapshotFileResponse{},
+		Changed: []models.SnapshotFileChangeResponse{},
+		Success: true,
+		Message: "Snapshots compared successfully",
+	}
+
+	for name, after := range targetFiles {
+		before, existed := baseFiles[name]
+		switch {
+		case !existed:
+			response.Added = append(response.Added, toSnapshotFileResponse(after))
+		case before.Checksum != after.Checksum || before.Size != after.Size:
+			response.Changed = append(response.Changed, models.SnapshotFileChangeResponse{
+				Name:   name,
+				Before: toSnapshotFileResponse(before),
+				After:  toSnapshotFileResponse(after),
+			})
+		default:
+			response.Unchanged++
+		}
+	}
+	for name, before := range baseFiles {
+		if _, kept := targetFiles[name]; !kept {
+			response.Removed = append(response.Removed, toSnapshotFileResponse(before))
+		}
+	}
+
+	sort.Slice(response.Added, func(i, j int) bool { return response.Added[i].Name < response.Added[j].Name })
+	sort.Slice(response.Removed, func(i, j int) bool { return response.Removed[i].Name < response.Removed[j].Name })
+	sort.Slice(response.Changed, func(i, j int) bool { return response.Changed[i].Name < response.Changed[j].Name })
+
+	return response, nil
+}
+
+// snapshotFilesByName indexes a snapshot's files by name, keeping the newest upload when a name repeats
+func (h *DiffSnapshotsRequestHandler) snapshotFilesByName(snapshotID uuid.UUID) (map[string]entities.SnapshotFile, error) {
+	files, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{SnapshotId: snapshotID}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshot files: %w", err)
+	}
+
+	byName := make(map[string]entities.SnapshotFile, len(files))
+	for _, file := range files {
+		if existing, ok := byName[file.Name]; !ok || file.FileCreatedAt.After(existing.FileCreatedAt) {
+			byName[file.Name] = file
+		}
+	}
+	return byName, nil
+}
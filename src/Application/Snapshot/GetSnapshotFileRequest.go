@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+type GetSnapshotFileCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	Name     string    `json:"name"`
+	FileID   uuid.UUID `json:"file_id"`
+	// CustomerKey is required for content encrypted with a customer-provided key
+	CustomerKey *encryption.CustomerKey `json:"-"`
+}
+
+// GetSnapshotFileResponse carries the preserved content, which the caller must close
+type GetSnapshotFileResponse struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	Checksum string `json:"checksum"`
+	// CustomerKeyMD5 is set when the content is encrypted with a customer-provided key
+	CustomerKeyMD5 string        `json:"customer_key_md5,omitempty"`
+	Content        io.ReadCloser `json:"-"`
+	Success        bool          `json:"success"`
+	Message        string        `json:"message"`
+}
+
+type GetSnapshotFileRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetSnapshotFileRequestHandler(dbContext *persistence.AppDbContext) *GetSnapshotFileRequestHandler {
+	return &GetSnapshotFileRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *GetSnapshotFileRequestHandler) Handle(ctx context.Context, command *GetSnapshotFileCommand) (*GetSnapshotFileResponse, error) {
+	snapshot, err := findSnapshot(h.dbContext, command.BucketID, command.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{
+		SnapshotId: snapshot.Id,
+		FileId:     command.FileID,
+	}).FirstOrDefault()
+	if err != nil || file == nil {
+		return nil, apierror.New(apierror.CodeFileNotFound, "file not found in snapshot")
+	}
+
+	if file.Encryption.Encrypted() {
+		if keyring, err := encryption.NewKeyring(h.dbContext); err == nil {
+			if err := keyring.RewrapSnapshotFile(command.BucketID, file); err != nil {
+				log.Printf("Warning: failed to re-wrap data key of snapshot file %s: %v", file.Id, err)
+			}
+		}
+	}
+
+	stored, err := storage.OpenEncrypted(ctx, h.dbContext, file.Path, file.Name, file.Encryption, command.CustomerKey)
+	if err != nil {
+		return nil, err
+	}
+	content, err := storage.Decompress(file.ContentEncoding, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetSnapshotFileResponse{
+		Name:           file.Name,
+		Size:           file.Size,
+		MimeType:       file.MimeType,
+		Checksum:       file.Checksum,
+		CustomerKeyMD5: file.Encryption.CustomerKeyMD5,
+		Content:        content,
+		Success:        true,
+		Message:        "Snapshot file retrieved successfully",
+	}, nil
+}
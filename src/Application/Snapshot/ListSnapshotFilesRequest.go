@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListSnapshotFilesCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	Name     string    `json:"name"`
+	Page     int       `json:"page"`
+	Limit    int       `json:"limit"`
+}
+
+type ListSnapshotFilesResponse struct {
+	Snapshot models.SnapshotResponse       `json:"snapshot"`
+	Files    []models.SnapshotFileResponse `json:"files"`
+	Total    int64                         `json:"total"`
+	Page     int                           `json:"page"`
+	Limit    int                           `json:"limit"`
+	Success  bool                          `json:"success"`
+	Message  string                        `json:"message"`
+}
+
+type ListSnapshotFilesRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListSnapshotFilesRequestHandler(dbContext *persistence.AppDbContext) *ListSnapshotFilesRequestHandler {
+	return &ListSnapshotFilesRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *ListSnapshotFilesRequestHandler) Handle(ctx context.Context, command *ListSnapshotFilesCommand) (*ListSnapshotFilesResponse, error) {
+	page := command.Page
+	limit := command.Limit
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	snapshot, err := findSnapshot(h.dbContext, command.BucketID, command.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{SnapshotId: snapshot.Id}).Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count files: %w", err)
+	}
+
+	files, err := h.dbContext.SnapshotFiles.Where(&entities.SnapshotFile{SnapshotId: snapshot.Id}).
+		OrderBy("Name").Skip((page - 1) * limit).Take(limit).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch files: %w", err)
+	}
+
+	fileResponses := make([]models.SnapshotFileResponse, len(files))
+	for i, file := range files {
+		fileResponses[i] = toSnapshotFileResponse(file)
+	}
+
+	return &ListSnapshotFilesResponse{
+		Snapshot: toSnapshotResponse(*snapshot),
+		Files:    fileResponses,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+		Success:  true,
+		Message:  "Snapshot files retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListSnapshotsCommand struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+}
+
+type ListSnapshotsResponse struct {
+	Snapshots []models.SnapshotResponse `json:"snapshots"`
+	Success   bool                      `json:"success"`
+	Message   string                    `json:"message"`
+}
+
+type ListSnapshotsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListSnapshotsRequestHandler(dbContext *persistence.AppDbContext) *ListSnapshotsRequestHandler {
+	return &ListSnapshotsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *ListSnapshotsRequestHandler) Handle(ctx context.Context, command *ListSnapshotsCommand) (*ListSnapshotsResponse, error) {
+	snapshots, err := h.dbContext.BucketSnapshots.Where(&entities.BucketSnapshot{BucketId: command.BucketID}).
+		OrderByDescending("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshots: %w", err)
+	}
+
+	snapshotResponses := make([]models.SnapshotResponse, len(snapshots))
+	for i, snapshot := range snapshots {
+		snapshotResponses[i] = toSnapshotResponse(snapshot)
+	}
+
+	return &ListSnapshotsResponse{
+		Snapshots: snapshotResponses,
+		Success:   true,
+		Message:   "Snapshots retrieved successfully",
+	}, nil
+}
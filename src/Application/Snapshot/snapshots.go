@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+func toSnapshotResponse(snapshot entities.BucketSnapshot) models.SnapshotResponse {
+	return models.SnapshotResponse{
+		ID:        snapshot.Id,
+		BucketID:  snapshot.BucketId,
+		Name:      snapshot.Name,
+		FileCount: snapshot.FileCount,
+		TotalSize: snapshot.TotalSize,
+		CreatedBy: snapshot.CreatedBy,
+		CreatedAt: snapshot.CreatedAt,
+	}
+}
+
+func toSnapshotFileResponse(file entities.SnapshotFile) models.SnapshotFileResponse {
+	return models.SnapshotFileResponse{
+		FileID:    file.FileId,
+		Name:      file.Name,
+		Size:      file.Size,
+		MimeType:  file.MimeType,
+		Checksum:  file.Checksum,
+		CreatedAt: file.FileCreatedAt,
+	}
+}
+
+func findSnapshot(dbContext *persistence.AppDbContext, bucketID uuid.UUID, name string) (*entities.BucketSnapshot, error) {
+	snapshot, err := dbContext.BucketSnapshots.Where(&entities.BucketSnapshot{
+		BucketId: bucketID,
+		Name:     name,
+	}).FirstOrDefault()
+	if err != nil || snapshot == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "snapshot not found")
+	}
+	return snapshot, nil
+}
+
+// authorizeBucketOwner allows changes to a bucket's snapshots for its owner, bucket admins and admins
+func authorizeBucketOwner(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage snapshots")
+	}
+	return bucket, nil
+}
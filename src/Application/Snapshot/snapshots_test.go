@@ -0,0 +1,34 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestDeleteSnapshotFiles removes the file records of one snapshot and leaves the others'
+func TestDeleteSnapshotFiles(t *testing.T) {
+	db := sqlitetest.Open(t)
+	snapshotID, otherID := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{snapshotID, snapshotID, otherID} {
+		file := entities.SnapshotFile{SnapshotId: id, FileId: uuid.New(), Name: "a.txt", Path: "/data/a.txt"}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := deleteSnapshotFiles(db, snapshotID); err != nil {
+		t.Fatalf("deleteSnapshotFiles() = %v", err)
+	}
+
+	var files []entities.SnapshotFile
+	if err := db.Find(&files).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].SnapshotId != otherID {
+		t.Errorf("snapshot files left = %+v, want only the other snapshot's", files)
+	}
+}
@@ -0,0 +1,258 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Scanning"
+	"shbucket/src/Infrastructure/Services"
+	"shbucket/src/Models"
+)
+
+type GetSystemStatsCommand struct{}
+
+type GetSystemStatsResponse struct {
+	Stats   models.SystemStatsResponse `json:"stats"`
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+}
+
+// scannerStatsTimeout is how long the scanner has to answer while stats are collected
+const scannerStatsTimeout = 5 * time.Second
+
+type GetSystemStatsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	scanner   scanning.Scanner // nil when uploads aren't scanned
+}
+
+func NewGetSystemStatsRequestHandler(dbContext *persistence.AppDbContext) *GetSystemStatsRequestHandler {
+	// main refuses to start with a misconfigured scanner
+	scanner, _ := scanning.NewScanner(config.GetSettings())
+	return &GetSystemStatsRequestHandler{
+		dbContext: dbContext,
+		scanner:   scanner,
+	}
+}
+
+// Handle collects system-wide statistics. Every figure is an aggregate computed by the database,
+// the number of queries doesn't grow with the number of buckets, files or nodes.
+func (h *GetSystemStatsRequestHandler) Handle(ctx context.Context, command *GetSystemStatsCommand) (*GetSystemStatsResponse, error) {
+	db := h.dbContext.GetDB().WithContext(ctx)
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+
+	stats := models.SystemStatsResponse{
+		Storage:     []models.StorageStatsResponse{},
+		PerBucket:   []models.BucketUsageStatsResponse{},
+		Alerts:      []models.UsageAlertResponse{},
+		GeneratedAt: now,
+	}
+
+	if err := collectCounts(db, now, since, &stats); err != nil {
+		return nil, err
+	}
+
+	downloads, err := metering.Downloads(ctx, h.dbContext, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads: %w", err)
+	}
+	stats.Last24Hours.Downloads = downloads
+
+	if err := collectBuckets(db, &stats); err != nil {
+		return nil, err
+	}
+	if err := h.collectStorage(db, &stats); err != nil {
+		return nil, err
+	}
+
+	if err := collectAlerts(db, &stats); err != nil {
+		return nil, err
+	}
+
+	if h.scanner != nil {
+		scanCtx, cancel := context.WithTimeout(ctx, scannerStatsTimeout)
+		health := scanning.CheckHealth(scanCtx, h.scanner)
+		cancel()
+		scanner := services.ToScannerHealthResponse(health, config.GetSettings(), now)
+		stats.Scanner = &scanner
+	}
+
+	return &GetSystemStatsResponse{
+		Stats:   stats,
+		Success: true,
+		Message: "System statistics generated successfully",
+	}, nil
+}
+
+// collectCounts counts the buckets, users and API keys, and the uploads since since
+func collectCounts(db *gorm.DB, now, since time.Time, stats *models.SystemStatsResponse) error {
+	counts := []struct {
+		what  string
+		query *gorm.DB
+		into  *int64
+	}{
+		{"buckets", db.Model(&entities.Bucket{}), &stats.Buckets},
+		{"users", db.Model(&entities.User{}), &stats.Users.Total},
+		{"active users", db.Model(&entities.User{}).Where(`"IsActive" = ?`, true), &stats.Users.Active},
+		{"signed in users", db.Model(&entities.User{}).Where(`"LastLoginTime" >= ?`, since), &stats.Users.SignedIn24h},
+		{"API keys", db.Model(&entities.APIKey{}).Where(`"IsActive" = ? AND ("ExpiresAt" IS NULL OR "ExpiresAt" > ?)`, true, now), &stats.APIKeys},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.into).Error; err != nil {
+			return fmt.Errorf("failed to count %s: %w", count.what, err)
+		}
+	}
+
+	var uploads struct {
+		Uploads int64
+		Bytes   int64
+	}
+	if err := db.Model(&entities.File{}).Where(`"CreatedAt" >= ?`, since).
+		Select(`COUNT(*) AS "Uploads", COALESCE(SUM("Size"), 0) AS "Bytes"`).Scan(&uploads).Error; err != nil {
+		return fmt.Errorf("failed to count uploads: %w", err)
+	}
+	stats.Last24Hours.Uploads = uploads.Uploads
+	stats.Last24Hours.UploadBytes = uploads.Bytes
+	return nil
+}
+
+// collectAlerts adds the usage alerts still firing, the most utilized first
+func collectAlerts(db *gorm.DB, stats *models.SystemStatsResponse) error {
+	var alerts []entities.UsageAlert
+	if err := db.Where(`"ResolvedAt" IS NULL`).Order(`"Utilization" DESC`).Order(`"Threshold" DESC`).Find(&alerts).Error; err != nil {
+		return fmt.Errorf("failed to fetch usage alerts: %w", err)
+	}
+	for i := range alerts {
+		stats.Alerts = append(stats.Alerts, services.ToUsageAlertResponse(&alerts[i]))
+	}
+	return nil
+}
+
+// collectBuckets sums the files of every bucket, and of all buckets, with the egress of each
+func collectBuckets(db *gorm.DB, stats *models.SystemStatsResponse) error {
+	var buckets []struct {
+		Id    uuid.UUID
+		Name  string
+		Files int64
+		Bytes int64
+	}
+	err := db.Model(&entities.Bucket{}).
+		Select(`"Bucket"."Id", "Bucket"."Name", COUNT("File"."BucketId") AS "Files", COALESCE(SUM("File"."Size"), 0) AS "Bytes"`).
+		Joins(`LEFT JOIN "File" ON "File"."BucketId" = "Bucket"."Id"`).
+		Group(`"Bucket"."Id", "Bucket"."Name"`).
+		Scan(&buckets).Error
+	if err != nil {
+		return fmt.Errorf("failed to sum bucket sizes: %w", err)
+	}
+
+	now := time.Now()
+	var usages []entities.EgressUsage
+	if err := db.Where(`("Scope" = ? AND "CycleStart" = ?) OR ("Scope" = ? AND "CycleStart" = ?)`,
+		metering.ScopeBucket, metering.CycleStart(now), metering.ScopeBucketDaily, metering.DayStart(now)).
+		Find(&usages).Error; err != nil {
+		return fmt.Errorf("failed to fetch bucket egress: %w", err)
+	}
+	egress := make(map[string]int64, len(usages))
+	for _, usage := range usages {
+		egress[usage.Scope+" "+usage.ScopeId.String()] = usage.Bytes
+	}
+
+	for _, bucket := range buckets {
+		stats.Files += bucket.Files
+		stats.Bytes += bucket.Bytes
+		stats.PerBucket = append(stats.PerBucket, models.BucketUsageStatsResponse{
+			BucketID:    bucket.Id,
+			BucketName:  bucket.Name,
+			Files:       bucket.Files,
+			Bytes:       bucket.Bytes,
+			EgressBytes: egress[metering.ScopeBucket+" "+bucket.Id.String()],
+			EgressToday: egress[metering.ScopeBucketDaily+" "+bucket.Id.String()],
+		})
+	}
+	sort.SliceStable(stats.PerBucket, func(i, j int) bool {
+		if stats.PerBucket[i].Bytes != stats.PerBucket[j].Bytes {
+			return stats.PerBucket[i].Bytes > stats.PerBucket[j].Bytes
+		}
+		return stats.PerBucket[i].BucketName < stats.PerBucket[j].BucketName
+	})
+	return nil
+}
+
+// collectStorage sums the files on the master and on each node, against their capacity. The
+// location of a file is the node ID in its node://{nodeID}/... path, the master for local paths.
+func (h *GetSystemStatsRequestHandler) collectStorage(db *gorm.DB, stats *models.SystemStatsResponse) error {
+	byNode, err := storageLocations(db)
+	if err != nil {
+		return err
+	}
+
+	master := byNode[""]
+	master.Type = "master"
+	master.Name = "master"
+	master.IsHealthy = true
+	master.Status = entities.NodeStatusHealthy
+	masterConfig, err := h.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to fetch master configuration: %w", err)
+	}
+	if masterConfig != nil {
+		master.MaxStorage = masterConfig.MaxStorage
+	}
+	stats.Storage = append(stats.Storage, withUtilization(master))
+
+	nodes, err := h.dbContext.StorageNodes.ToList()
+	if err != nil {
+		return fmt.Errorf("failed to fetch storage nodes: %w", err)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	for _, node := range nodes {
+		nodeID := node.Id
+		location := byNode[nodeID.String()]
+		location.Type = "node"
+		location.NodeID = &nodeID
+		location.Name = node.Name
+		location.MaxStorage = node.MaxStorage
+		location.IsHealthy = node.IsHealthy && node.FailedAt == nil
+		location.Status = node.Status()
+		stats.Storage = append(stats.Storage, withUtilization(location))
+	}
+	return nil
+}
+
+// storageLocations sums the files of each location by node ID, "" for the master
+func storageLocations(db *gorm.DB) (map[string]models.StorageStatsResponse, error) {
+	var locations []struct {
+		NodeId string
+		Files  int64
+		Bytes  int64
+	}
+	location := `CASE WHEN "Path" LIKE 'node://%' THEN SUBSTR("Path", 8, 36) ELSE '' END`
+	err := db.Model(&entities.File{}).
+		Select(location + ` AS "NodeId", COUNT(*) AS "Files", COALESCE(SUM("Size"), 0) AS "Bytes"`).
+		Group(location).
+		Scan(&locations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum storage locations: %w", err)
+	}
+	byNode := make(map[string]models.StorageStatsResponse, len(locations))
+	for _, location := range locations {
+		byNode[location.NodeId] = models.StorageStatsResponse{Files: location.Files, Bytes: location.Bytes}
+	}
+	return byNode, nil
+}
+
+func withUtilization(location models.StorageStatsResponse) models.StorageStatsResponse {
+	if location.MaxStorage > 0 {
+		location.Utilization = float64(location.Bytes) / float64(location.MaxStorage)
+	}
+	return location
+}
@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+	"shbucket/src/Models"
+)
+
+// TestCollectCounts counts the active users and API keys and the uploads of the last day
+func TestCollectCounts(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	for i, file := range []entities.File{
+		{BucketId: bucket.Id, Name: "old.jpg", Size: 100, CreatedAt: since.Add(-time.Hour)},
+		{BucketId: bucket.Id, Name: "a.jpg", Size: 10, CreatedAt: now},
+		{BucketId: bucket.Id, Name: "b.jpg", Size: 20, CreatedAt: now},
+	} {
+		file.OriginalName = file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+	expired := now.Add(-time.Minute)
+	for i, key := range []entities.APIKey{
+		{IsActive: true},
+		{IsActive: true, ExpiresAt: &expired},
+	} {
+		key.Name, key.KeyHash, key.KeyPrefix, key.UserId = "key", uuid.NewString(), "shb_", bucket.OwnerId
+		if err := db.Create(&key).Error; err != nil {
+			t.Fatalf("failed to create key %d: %v", i, err)
+		}
+	}
+
+	var stats models.SystemStatsResponse
+	if err := collectCounts(db, now, since, &stats); err != nil {
+		t.Fatalf("collectCounts() = %v", err)
+	}
+	if stats.Buckets != 1 || stats.Users.Active != 1 || stats.APIKeys != 1 {
+		t.Errorf("collectCounts() = %d buckets, %d active users, %d API keys, want 1 of each", stats.Buckets, stats.Users.Active, stats.APIKeys)
+	}
+	if stats.Last24Hours.Uploads != 2 || stats.Last24Hours.UploadBytes != 30 {
+		t.Errorf("collectCounts() uploads = %d of %d bytes, want 2 of 30 bytes", stats.Last24Hours.Uploads, stats.Last24Hours.UploadBytes)
+	}
+}
+
+// TestStorageLocations sums the files on the master and on each node
+func TestStorageLocations(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	nodeID := uuid.New()
+	for i, file := range []entities.File{
+		{BucketId: bucket.Id, Name: "a.jpg", Path: "/data/photos/a", Size: 10},
+		{BucketId: bucket.Id, Name: "b.jpg", Path: "node://" + nodeID.String() + "/" + bucket.Id.String() + "/b", Size: 20},
+		{BucketId: bucket.Id, Name: "c.jpg", Path: "node://" + nodeID.String() + "/" + bucket.Id.String() + "/c", Size: 30},
+	} {
+		file.OriginalName = file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+
+	byNode, err := storageLocations(db)
+	if err != nil {
+		t.Fatalf("storageLocations() = %v", err)
+	}
+	if master := byNode[""]; master.Files != 1 || master.Bytes != 10 {
+		t.Errorf("storageLocations() master = %+v, want 1 file of 10 bytes", master)
+	}
+	if node := byNode[nodeID.String()]; node.Files != 2 || node.Bytes != 50 {
+		t.Errorf("storageLocations() node = %+v, want 2 files of 50 bytes", node)
+	}
+}
+
+// TestCollectAlerts adds the alerts still firing, the most utilized first
+func TestCollectAlerts(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	for _, alert := range []entities.UsageAlert{
+		{Name: "photos", Threshold: 80, Utilization: 85},
+		{Name: "videos", Threshold: 90, Utilization: 95},
+		{Name: "docs", Threshold: 80, Utilization: 70, ResolvedAt: &now},
+	} {
+		alert.Scope, alert.TargetId, alert.FiredAt = "bucket", uuid.New(), now
+		if err := db.Create(&alert).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stats models.SystemStatsResponse
+	if err := collectAlerts(db, &stats); err != nil {
+		t.Fatalf("collectAlerts() = %v", err)
+	}
+	if len(stats.Alerts) != 2 || stats.Alerts[0].Name != "videos" || stats.Alerts[1].Name != "photos" {
+		t.Errorf("collectAlerts() = %+v, want videos then photos", stats.Alerts)
+	}
+}
+
+// TestCollectBuckets sums the files of each bucket with its egress of the cycle and of the day
+func TestCollectBuckets(t *testing.T) {
+	db := sqlitetest.Open(t)
+	photos := sqlitetest.CreateBucket(t, db, "photos")
+	sqlitetest.CreateBucket(t, db, "empty")
+	for i, file := range []entities.File{
+		{BucketId: photos.Id, Name: "a.jpg", Size: 10},
+		{BucketId: photos.Id, Name: "b.jpg", Size: 20},
+	} {
+		file.OriginalName = file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+	now := time.Now()
+	for _, usage := range []entities.EgressUsage{
+		{Scope: metering.ScopeBucket, CycleStart: metering.CycleStart(now), Bytes: 300},
+		{Scope: metering.ScopeBucket, CycleStart: metering.CycleStart(now).AddDate(0, -1, 0), Bytes: 999},
+		{Scope: metering.ScopeBucketDaily, CycleStart: metering.DayStart(now), Bytes: 40},
+	} {
+		usage.ScopeId = photos.Id
+		if err := db.Create(&usage).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stats models.SystemStatsResponse
+	if err := collectBuckets(db, &stats); err != nil {
+		t.Fatalf("collectBuckets() = %v", err)
+	}
+	if stats.Files != 2 || stats.Bytes != 30 || len(stats.PerBucket) != 2 {
+		t.Fatalf("collectBuckets() = %d files of %d bytes in %d buckets, want 2 of 30 bytes in 2 buckets", stats.Files, stats.Bytes, len(stats.PerBucket))
+	}
+	if bucket := stats.PerBucket[0]; bucket.BucketName != "photos" || bucket.EgressBytes != 300 || bucket.EgressToday != 40 {
+		t.Errorf("collectBuckets() first bucket = %+v, want photos with 300 bytes of egress, 40 today", bucket)
+	}
+}
@@ -0,0 +1,122 @@
+package uploadgrant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type CreateUploadGrantCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	// Name describes who the link is for, e.g. "Client assets"
+	Name string `json:"name" validate:"max=100"`
+	// Prefix is prepended to the names of uploaded files, e.g. "submissions/"
+	Prefix      string    `json:"prefix" validate:"max=500"`
+	MaxFileSize int64     `json:"max_file_size" validate:"min=0"`                    // bytes per file, 0 for the bucket's limit
+	MaxFiles    int       `json:"max_files" validate:"min=0"`                        // 0 for no limit
+	ExpiresIn   int       `json:"expires_in" validate:"required,min=60,max=2592000"` // 1 minute to 30 days
+	UserID      uuid.UUID `json:"-"`
+	UserRole    string    `json:"-"`
+}
+
+type CreateUploadGrantResponse struct {
+	Grant models.UploadGrantResponse `json:"grant"`
+	// Secret is only returned here, it can't be retrieved again
+	Secret  string `json:"secret"`
+	URL     string `json:"url"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type CreateUploadGrantRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	events    *events.Publisher
+}
+
+func NewCreateUploadGrantRequestHandler(dbContext *persistence.AppDbContext) *CreateUploadGrantRequestHandler {
+	return &CreateUploadGrantRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle issues an upload link letting anyone holding it add files to the bucket until it expires
+func (h *CreateUploadGrantRequestHandler) Handle(ctx context.Context, command *CreateUploadGrantCommand) (*CreateUploadGrantResponse, error) {
+	bucket, err := loadGrantBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := normalizePrefix(command.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if limit := bucket.Settings.MaxFileSize; limit > 0 && command.MaxFileSize > limit {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "max_file_size can't exceed the bucket's limit of %d bytes", limit)
+	}
+
+	secret, tokenHash, tokenPrefix, err := generateUploadToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate upload link: %w", err)
+	}
+
+	now := time.Now()
+	grant := &entities.SignedUploadGrant{
+		Id:          uuid.New(),
+		BucketId:    bucket.Id,
+		Name:        command.Name,
+		Prefix:      prefix,
+		TokenHash:   tokenHash,
+		TokenPrefix: tokenPrefix,
+		MaxFileSize: command.MaxFileSize,
+		MaxFiles:    command.MaxFiles,
+		ExpiresAt:   now.Add(time.Duration(command.ExpiresIn) * time.Second),
+		CreatedBy:   command.UserID,
+		CreatedAt:   now,
+	}
+	h.dbContext.SignedUploadGrants.Add(*grant)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save upload link: %w", err)
+	}
+
+	h.events.Publish(events.UploadGrantCreated, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"grant_id":     grant.Id.String(),
+		"grant_name":   grant.Name,
+		"token_prefix": grant.TokenPrefix,
+		"prefix":       grant.Prefix,
+		"expires_at":   grant.ExpiresAt,
+	})
+
+	return &CreateUploadGrantResponse{
+		Grant:   ToUploadGrantResponse(grant),
+		Secret:  secret,
+		URL:     fmt.Sprintf("%s/api/v1/upload/%s", h.settings.BaseURL, secret),
+		Success: true,
+		Message: "Upload link created successfully",
+	}, nil
+}
+
+// normalizePrefix cleans up a name prefix so it ends with "/" and can't climb out of the bucket
+func normalizePrefix(prefix string) (string, error) {
+	prefix = strings.Trim(strings.ReplaceAll(strings.TrimSpace(prefix), "\\", "/"), "/")
+	if prefix == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", apierror.Newf(apierror.CodeInvalidRequest, "invalid prefix %q", prefix)
+		}
+	}
+	return prefix + "/", nil
+}
@@ -0,0 +1,92 @@
+package uploadgrant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Signing"
+)
+
+type CreateUploadPolicyCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	// KeyPrefix is what the names of uploaded files must start with, e.g. "avatars/"
+	KeyPrefix         string    `json:"key_prefix" validate:"max=500"`
+	MaxFileSize       int64     `json:"max_file_size" validate:"min=0"`                   // bytes, 0 for the bucket's limit
+	ContentTypePrefix string    `json:"content_type_prefix" validate:"max=255"`           // e.g. "image/", empty for any type
+	ExpiresIn         int       `json:"expires_in" validate:"required,min=60,max=604800"` // 1 minute to 7 days
+	UserID            uuid.UUID `json:"-"`
+	UserRole          string    `json:"-"`
+}
+
+type CreateUploadPolicyResponse struct {
+	// URL is where the browser posts the form
+	URL string `json:"url"`
+	// Fields go into the form as they are, next to "key" and "file"
+	Fields    map[string]string `json:"fields"`
+	Policy    UploadPolicy      `json:"policy"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Success   bool              `json:"success"`
+	Message   string            `json:"message"`
+}
+
+type CreateUploadPolicyRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	keys      *signing.Keyring
+}
+
+func NewCreateUploadPolicyRequestHandler(dbContext *persistence.AppDbContext) *CreateUploadPolicyRequestHandler {
+	return &CreateUploadPolicyRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		keys:      signing.Keys(dbContext),
+	}
+}
+
+// Handle signs a policy a browser can upload one file with, straight to the bucket and without
+// credentials of its own. Nothing is stored: the policy stops working when it expires or when
+// its issuer can no longer manage the bucket.
+func (h *CreateUploadPolicyRequestHandler) Handle(ctx context.Context, command *CreateUploadPolicyCommand) (*CreateUploadPolicyResponse, error) {
+	bucket, err := loadGrantBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := normalizePrefix(command.KeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if limit := bucket.Settings.MaxFileSize; limit > 0 && command.MaxFileSize > limit {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "max_file_size can't exceed the bucket's limit of %d bytes", limit)
+	}
+
+	policy := UploadPolicy{
+		BucketID:          bucket.Id,
+		KeyPrefix:         prefix,
+		MaxFileSize:       command.MaxFileSize,
+		ContentTypePrefix: command.ContentTypePrefix,
+		ExpiresAt:         time.Now().Add(time.Duration(command.ExpiresIn) * time.Second).UTC().Truncate(time.Second),
+		IssuedBy:          command.UserID,
+	}
+	encoded, signature, err := encodePolicy(ctx, &policy, h.keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign upload policy: %w", err)
+	}
+
+	return &CreateUploadPolicyResponse{
+		URL: fmt.Sprintf("%s/api/v1/upload-policy", h.settings.BaseURL),
+		Fields: map[string]string{
+			"policy":    encoded,
+			"signature": signature,
+		},
+		Policy:    policy,
+		ExpiresAt: policy.ExpiresAt,
+		Success:   true,
+		Message:   "Upload policy created successfully",
+	}, nil
+}
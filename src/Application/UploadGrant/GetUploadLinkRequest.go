@@ -0,0 +1,63 @@
+package uploadgrant
+
+import (
+	"context"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetUploadLinkCommand struct {
+	Token string `json:"-"`
+}
+
+type GetUploadLinkResponse struct {
+	Link    models.UploadLinkInfoResponse `json:"link"`
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+}
+
+type GetUploadLinkRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetUploadLinkRequestHandler(dbContext *persistence.AppDbContext) *GetUploadLinkRequestHandler {
+	return &GetUploadLinkRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle tells an anonymous uploader what a link accepts, without revealing the bucket behind it
+func (h *GetUploadLinkRequestHandler) Handle(ctx context.Context, command *GetUploadLinkCommand) (*GetUploadLinkResponse, error) {
+	grant, err := findGrant(h.dbContext, command.Token)
+	if err != nil {
+		return nil, err
+	}
+	if !open(grant, time.Now()) {
+		return nil, ErrGrantClosed
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: grant.BucketId}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrGrantNotFound
+	}
+
+	link := models.UploadLinkInfoResponse{
+		Name:        grant.Name,
+		MaxFileSize: maxFileSize(grant, bucket),
+		MaxFiles:    grant.MaxFiles,
+		ExpiresAt:   grant.ExpiresAt,
+	}
+	if grant.MaxFiles > 0 {
+		remaining := grant.MaxFiles - grant.UploadCount
+		link.RemainingFiles = &remaining
+	}
+
+	return &GetUploadLinkResponse{
+		Link:    link,
+		Success: true,
+		Message: "Upload link retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,57 @@
+package uploadgrant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListUploadGrantsCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type ListUploadGrantsResponse struct {
+	Grants  []models.UploadGrantResponse `json:"grants"`
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+}
+
+type ListUploadGrantsRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListUploadGrantsRequestHandler(dbContext *persistence.AppDbContext) *ListUploadGrantsRequestHandler {
+	return &ListUploadGrantsRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists a bucket's upload links, expired and revoked ones included, without their secrets
+func (h *ListUploadGrantsRequestHandler) Handle(ctx context.Context, command *ListUploadGrantsCommand) (*ListUploadGrantsResponse, error) {
+	bucket, err := loadGrantBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := h.dbContext.SignedUploadGrants.Where(&entities.SignedUploadGrant{BucketId: bucket.Id}).OrderByDescending("CreatedAt").ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upload links: %w", err)
+	}
+
+	responses := make([]models.UploadGrantResponse, 0, len(grants))
+	for i := range grants {
+		responses = append(responses, ToUploadGrantResponse(&grants[i]))
+	}
+
+	return &ListUploadGrantsResponse{
+		Grants:  responses,
+		Success: true,
+		Message: "Upload links retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,80 @@
+package uploadgrant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type RevokeUploadGrantCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	GrantID  uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type RevokeUploadGrantResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type RevokeUploadGrantRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	events    *events.Publisher
+}
+
+func NewRevokeUploadGrantRequestHandler(dbContext *persistence.AppDbContext) *RevokeUploadGrantRequestHandler {
+	return &RevokeUploadGrantRequestHandler{
+		dbContext: dbContext,
+		events:    events.NewPublisher(dbContext),
+	}
+}
+
+// Handle revokes an upload link, uploads through it are refused from then on. Files already
+// uploaded stay, and the record is kept for auditing.
+func (h *RevokeUploadGrantRequestHandler) Handle(ctx context.Context, command *RevokeUploadGrantCommand) (*RevokeUploadGrantResponse, error) {
+	bucket, err := loadGrantBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	grant, err := h.dbContext.SignedUploadGrants.Where(&entities.SignedUploadGrant{Id: command.GrantID, BucketId: bucket.Id}).FirstOrDefault()
+	if err != nil || grant == nil {
+		return nil, apierror.New(apierror.CodeNotFound, "upload link not found")
+	}
+	if grant.RevokedAt != nil {
+		return &RevokeUploadGrantResponse{
+			Success: true,
+			Message: "Upload link was already revoked",
+		}, nil
+	}
+
+	// Only the revocation is written, so uploads counted meanwhile aren't lost
+	now := time.Now()
+	if err := revoke(h.dbContext.GetDB().WithContext(ctx), grant, now); err != nil {
+		return nil, fmt.Errorf("failed to revoke upload link: %w", err)
+	}
+
+	h.events.Publish(events.UploadGrantRevoked, bucket.Id, nil, command.UserID, map[string]interface{}{
+		"grant_id":     grant.Id.String(),
+		"grant_name":   grant.Name,
+		"token_prefix": grant.TokenPrefix,
+	})
+
+	return &RevokeUploadGrantResponse{
+		Success: true,
+		Message: "Upload link revoked successfully",
+	}, nil
+}
+
+// revoke closes the link at now
+func revoke(db *gorm.DB, grant *entities.SignedUploadGrant, now time.Time) error {
+	return db.Model(&entities.SignedUploadGrant{}).Where(`"Id" = ?`, grant.Id).Update("RevokedAt", now).Error
+}
@@ -0,0 +1,167 @@
+package uploadgrant
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type UploadWithGrantCommand struct {
+	Token       string                `json:"-"`
+	File        *multipart.FileHeader `json:"-"`
+	FileReader  io.Reader             `json:"-"`
+	FileName    string                `json:"file_name"`
+	ContentType string                `json:"content_type"`
+	// ExpectedChecksum is the hex sha256 of the file the client computed, the upload is refused when the content differs
+	ExpectedChecksum string `json:"-"`
+}
+
+type UploadWithGrantResponse struct {
+	FileID         uuid.UUID `json:"file_id"`
+	FileName       string    `json:"file_name"`
+	Size           int64     `json:"size"`
+	RemainingFiles *int      `json:"remaining_files,omitempty"` // unset when the link has no limit
+	Success        bool      `json:"success"`
+	Message        string    `json:"message"`
+}
+
+type UploadWithGrantRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewUploadWithGrantRequestHandler(dbContext *persistence.AppDbContext) *UploadWithGrantRequestHandler {
+	return &UploadWithGrantRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle stores a file uploaded anonymously through an upload link. The file is named under the
+// link's prefix, never replaces an existing file, and is recorded as uploaded by the link's creator.
+func (h *UploadWithGrantRequestHandler) Handle(ctx context.Context, command *UploadWithGrantCommand) (*UploadWithGrantResponse, error) {
+	grant, err := findGrant(h.dbContext, command.Token)
+	if err != nil {
+		return nil, err
+	}
+	if !open(grant, time.Now()) {
+		return nil, ErrGrantClosed
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: grant.BucketId}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrGrantNotFound
+	}
+
+	size := command.File.Size
+	if limit := maxFileSize(grant, bucket); limit > 0 && size > limit {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, limit)
+	}
+
+	baseName := path.Base(strings.ReplaceAll(command.FileName, "\\", "/"))
+	if baseName == "." || baseName == "/" || baseName == ".." {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "invalid file name")
+	}
+	name, err := availableName(h.dbContext.GetDB().WithContext(ctx), bucket.Id, grant.Prefix+baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Take a slot before writing anything, so concurrent uploads can't go past the link's limit
+	if err := reserve(h.dbContext.GetDB().WithContext(ctx), grant); err != nil {
+		return nil, err
+	}
+
+	upload, err := file.NewDistributedUploadRequestHandler(h.dbContext).Handle(ctx, &file.DistributedUploadCommand{
+		BucketID:    bucket.Id,
+		File:        command.File,
+		FileReader:  command.FileReader,
+		FileName:    name,
+		ContentType: command.ContentType,
+		Metadata: map[string]interface{}{
+			"upload_grant_id": grant.Id.String(),
+		},
+		UploadedBy:       grant.CreatedBy,
+		ExpectedChecksum: command.ExpectedChecksum,
+	})
+	if err != nil {
+		release(h.dbContext.GetDB(), grant)
+		return nil, err
+	}
+
+	if err := recordUpload(h.dbContext.GetDB().WithContext(ctx), grant, upload.File.Size); err != nil {
+		return nil, fmt.Errorf("failed to update upload link: %w", err)
+	}
+
+	response := &UploadWithGrantResponse{
+		FileID:   upload.File.ID,
+		FileName: name,
+		Size:     upload.File.Size,
+		Success:  true,
+		Message:  "File uploaded successfully",
+	}
+	if grant.MaxFiles > 0 {
+		remaining := max(grant.MaxFiles-grant.UploadCount-1, 0)
+		response.RemainingFiles = &remaining
+	}
+	return response, nil
+}
+
+// reserve counts an upload against the link, failing when the link closed since it was loaded
+func reserve(db *gorm.DB, grant *entities.SignedUploadGrant) error {
+	result := db.Model(&entities.SignedUploadGrant{}).
+		Where(`"Id" = ? AND "RevokedAt" IS NULL AND "ExpiresAt" > ? AND ("MaxFiles" = 0 OR "UploadCount" < "MaxFiles")`, grant.Id, time.Now()).
+		Update("UploadCount", gorm.Expr(`"UploadCount" + 1`))
+	if result.Error != nil {
+		return fmt.Errorf("failed to update upload link: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrGrantClosed
+	}
+	return nil
+}
+
+// release gives back the slot of an upload that failed
+func release(db *gorm.DB, grant *entities.SignedUploadGrant) {
+	db.Model(&entities.SignedUploadGrant{}).
+		Where(`"Id" = ? AND "UploadCount" > 0`, grant.Id).
+		Update("UploadCount", gorm.Expr(`"UploadCount" - 1`))
+}
+
+// recordUpload adds a completed upload's size to the link
+func recordUpload(db *gorm.DB, grant *entities.SignedUploadGrant, size int64) error {
+	return db.Model(&entities.SignedUploadGrant{}).Where(`"Id" = ?`, grant.Id).
+		Updates(map[string]interface{}{
+			"UploadedBytes": gorm.Expr(`"UploadedBytes" + ?`, size),
+			"LastUsedAt":    time.Now(),
+		}).Error
+}
+
+// availableName returns name, or name with " (n)" before its extension when the bucket already has a file by that name
+func availableName(db *gorm.DB, bucketID uuid.UUID, name string) (string, error) {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 2; n <= 1000; n++ {
+		var count int64
+		if err := db.Model(&entities.File{}).
+			Where(`"BucketId" = ? AND "Name" = ?`, bucketID, candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("failed to check file name: %w", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+	}
+	return "", apierror.Newf(apierror.CodeInvalidRequest, "too many files named %q", name)
+}
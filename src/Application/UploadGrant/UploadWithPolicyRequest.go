@@ -0,0 +1,111 @@
+package uploadgrant
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Signing"
+)
+
+type UploadWithPolicyCommand struct {
+	Policy      string                `json:"-"`
+	Signature   string                `json:"-"`
+	Key         string                `json:"-"`
+	File        *multipart.FileHeader `json:"-"`
+	FileReader  io.Reader             `json:"-"`
+	ContentType string                `json:"-"`
+	// ExpectedChecksum is the hex sha256 of the file the client computed, the upload is refused when the content differs
+	ExpectedChecksum string `json:"-"`
+}
+
+type UploadWithPolicyResponse struct {
+	FileID   uuid.UUID `json:"file_id"`
+	FileName string    `json:"file_name"`
+	Size     int64     `json:"size"`
+	Success  bool      `json:"success"`
+	Message  string    `json:"message"`
+}
+
+type UploadWithPolicyRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	keys      *signing.Keyring
+}
+
+func NewUploadWithPolicyRequestHandler(dbContext *persistence.AppDbContext) *UploadWithPolicyRequestHandler {
+	return &UploadWithPolicyRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		keys:      signing.Keys(dbContext),
+	}
+}
+
+// Handle stores a file posted by a browser with a signed upload policy, once the signature, the
+// expiry and the policy's conditions on name, size and content type check out. The file is
+// recorded as uploaded by the policy's issuer.
+func (h *UploadWithPolicyRequestHandler) Handle(ctx context.Context, command *UploadWithPolicyCommand) (*UploadWithPolicyResponse, error) {
+	policy, err := decodePolicy(ctx, command.Policy, command.Signature, h.keys)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(policy.ExpiresAt) {
+		return nil, ErrPolicyExpired
+	}
+
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: policy.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrPolicyInvalid
+	}
+	issuer, err := h.dbContext.Users.Where(&entities.User{Id: policy.IssuedBy}).FirstOrDefault()
+	if err != nil || issuer == nil || !issuer.IsActive || !access.CanManageBucket(h.dbContext, bucket, issuer.Id, issuer.Role) {
+		return nil, ErrPolicyInvalid
+	}
+
+	baseName := path.Base(strings.ReplaceAll(command.File.Filename, "\\", "/"))
+	key := strings.ReplaceAll(command.Key, FilenameVariable, baseName)
+	if !policy.keyAllowed(key) {
+		return nil, fmt.Errorf("%w: key must start with %q", ErrPolicyViolated, policy.KeyPrefix)
+	}
+	if policy.ContentTypePrefix != "" && !policy.contentTypeAllowed(command.ContentType) {
+		return nil, fmt.Errorf("%w: content type must start with %q", ErrPolicyViolated, policy.ContentTypePrefix)
+	}
+	if policy.MaxFileSize > 0 && command.File.Size > policy.MaxFileSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrPolicyFileTooLarge, policy.MaxFileSize)
+	}
+
+	upload, err := file.NewDistributedUploadRequestHandler(h.dbContext).Handle(ctx, &file.DistributedUploadCommand{
+		BucketID:    bucket.Id,
+		File:        command.File,
+		FileReader:  command.FileReader,
+		FileName:    key,
+		ContentType: command.ContentType,
+		Metadata: map[string]interface{}{
+			"upload_policy": true,
+		},
+		UploadedBy:       issuer.Id,
+		ExpectedChecksum: command.ExpectedChecksum,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadWithPolicyResponse{
+		FileID:   upload.File.ID,
+		FileName: key,
+		Size:     upload.File.Size,
+		Success:  true,
+		Message:  "File uploaded successfully",
+	}, nil
+}
@@ -0,0 +1,96 @@
+package uploadgrant
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// UploadTokenPrefix starts every upload link token, telling them apart from API keys (shb_) and file tokens (shf_)
+const UploadTokenPrefix = "shu_"
+
+var (
+	// ErrGrantNotFound is returned for tokens that don't match an upload link
+	ErrGrantNotFound = apierror.New(apierror.CodeNotFound, "upload link not found")
+	// ErrGrantClosed is returned for upload links that are revoked, expired or used up
+	ErrGrantClosed = apierror.New(apierror.CodeGone, "upload link is no longer accepting files")
+	// ErrFileTooLarge is returned for uploads over the link's or the bucket's size limit
+	ErrFileTooLarge = apierror.New(apierror.CodeFileTooLarge, "file exceeds the upload link's size limit")
+)
+
+// loadGrantBucket returns a bucket whose upload links the user may manage: the bucket owner, bucket admins and admins
+func loadGrantBucket(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage upload links")
+	}
+	return bucket, nil
+}
+
+// findGrant looks up an upload link by its token
+func findGrant(dbContext *persistence.AppDbContext, token string) (*entities.SignedUploadGrant, error) {
+	hash := sha256.Sum256([]byte(token))
+	grant, err := dbContext.SignedUploadGrants.Where(&entities.SignedUploadGrant{TokenHash: hex.EncodeToString(hash[:])}).FirstOrDefault()
+	if err != nil || grant == nil {
+		return nil, ErrGrantNotFound
+	}
+	return grant, nil
+}
+
+// open reports whether an upload link still accepts files
+func open(grant *entities.SignedUploadGrant, now time.Time) bool {
+	return grant.RevokedAt == nil && now.Before(grant.ExpiresAt) &&
+		(grant.MaxFiles == 0 || grant.UploadCount < grant.MaxFiles)
+}
+
+// maxFileSize is the size limit uploads through a link get: the link's own, capped by the bucket's
+func maxFileSize(grant *entities.SignedUploadGrant, bucket *entities.Bucket) int64 {
+	limit := grant.MaxFileSize
+	if bucketLimit := bucket.Settings.MaxFileSize; bucketLimit > 0 && (limit == 0 || bucketLimit < limit) {
+		limit = bucketLimit
+	}
+	return limit
+}
+
+// generateUploadToken returns a new token with the hash and prefix stored for it
+func generateUploadToken() (token, tokenHash, tokenPrefix string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	token = UploadTokenPrefix + hex.EncodeToString(bytes)
+	hash := sha256.Sum256([]byte(token))
+	return token, hex.EncodeToString(hash[:]), token[:12], nil
+}
+
+func ToUploadGrantResponse(grant *entities.SignedUploadGrant) models.UploadGrantResponse {
+	return models.UploadGrantResponse{
+		ID:            grant.Id,
+		BucketID:      grant.BucketId,
+		Name:          grant.Name,
+		Prefix:        grant.Prefix,
+		TokenPrefix:   grant.TokenPrefix,
+		MaxFileSize:   grant.MaxFileSize,
+		MaxFiles:      grant.MaxFiles,
+		UploadCount:   grant.UploadCount,
+		UploadedBytes: grant.UploadedBytes,
+		ExpiresAt:     grant.ExpiresAt,
+		CreatedBy:     grant.CreatedBy,
+		CreatedAt:     grant.CreatedAt,
+		LastUsedAt:    grant.LastUsedAt,
+		RevokedAt:     grant.RevokedAt,
+	}
+}
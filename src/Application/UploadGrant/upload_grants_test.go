@@ -0,0 +1,76 @@
+package uploadgrant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+func loadGrant(t *testing.T, db *gorm.DB, id uuid.UUID) entities.SignedUploadGrant {
+	t.Helper()
+	var grant entities.SignedUploadGrant
+	if err := db.First(&grant, `"Id" = ?`, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return grant
+}
+
+// TestReserve counts uploads against a link until it runs out of files or is revoked
+func TestReserve(t *testing.T) {
+	db := sqlitetest.Open(t)
+	grant := entities.SignedUploadGrant{BucketId: uuid.New(), TokenHash: "hash", TokenPrefix: "shu_", MaxFiles: 2,
+		ExpiresAt: time.Now().Add(time.Hour), CreatedBy: uuid.New()}
+	if err := db.Create(&grant).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := reserve(db, &grant); err != nil {
+			t.Fatalf("reserve() %d = %v", i, err)
+		}
+	}
+	if err := reserve(db, &grant); err != ErrGrantClosed {
+		t.Errorf("reserve() past the limit = %v, want %v", err, ErrGrantClosed)
+	}
+	release(db, &grant)
+	if err := recordUpload(db, &grant, 100); err != nil {
+		t.Fatalf("recordUpload() = %v", err)
+	}
+	stored := loadGrant(t, db, grant.Id)
+	if stored.UploadCount != 1 || stored.UploadedBytes != 100 || stored.LastUsedAt == nil {
+		t.Errorf("grant after a released and a recorded upload = %+v, want one upload of 100 bytes", stored)
+	}
+
+	if err := revoke(db, &grant, time.Now()); err != nil {
+		t.Fatalf("revoke() = %v", err)
+	}
+	if err := reserve(db, &grant); err != ErrGrantClosed {
+		t.Errorf("reserve() of a revoked link = %v, want %v", err, ErrGrantClosed)
+	}
+	if stored := loadGrant(t, db, grant.Id); stored.RevokedAt == nil {
+		t.Error("revoke() didn't set RevokedAt")
+	}
+}
+
+// TestAvailableName numbers a name the bucket already has a file by
+func TestAvailableName(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	for _, name := range []string{"report.pdf", "report (2).pdf"} {
+		file := entities.File{BucketId: bucketID, Name: name, OriginalName: name, Path: "/data/" + name}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{"report.pdf": "report (3).pdf", "notes.txt": "notes.txt"} {
+		if got, err := availableName(db, bucketID, name); err != nil || got != want {
+			t.Errorf("availableName(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
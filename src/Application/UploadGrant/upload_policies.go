@@ -0,0 +1,97 @@
+package uploadgrant
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Signing"
+)
+
+var (
+	// ErrPolicyInvalid is returned for upload policies that are malformed or whose signature doesn't match
+	ErrPolicyInvalid = apierror.New(apierror.CodeForbidden, "invalid upload policy or signature")
+	// ErrPolicyExpired is returned for upload policies past their expiry
+	ErrPolicyExpired = apierror.New(apierror.CodeForbidden, "upload policy has expired")
+	// ErrPolicyViolated is returned for uploads that don't meet the policy's conditions
+	ErrPolicyViolated = apierror.New(apierror.CodeForbidden, "upload doesn't meet the policy's conditions")
+	// ErrPolicyFileTooLarge is returned for uploads over the policy's size limit
+	ErrPolicyFileTooLarge = apierror.New(apierror.CodeFileTooLarge, "file exceeds the upload policy's size limit")
+)
+
+// FilenameVariable in a policy upload's key is replaced with the name of the uploaded file
+const FilenameVariable = "${filename}"
+
+// UploadPolicy is the document a pre-signed POST policy carries. It is sent base64 encoded along
+// with its signature, so the server keeps no record of the policies it issued.
+type UploadPolicy struct {
+	BucketID          uuid.UUID `json:"bucket_id"`
+	KeyPrefix         string    `json:"key_prefix,omitempty"`
+	MaxFileSize       int64     `json:"max_file_size,omitempty"` // bytes, 0 for the bucket's limit
+	ContentTypePrefix string    `json:"content_type_prefix,omitempty"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	IssuedBy          uuid.UUID `json:"issued_by"`
+}
+
+// encodePolicy returns the base64 policy document and its signature, made with the active signing key
+func encodePolicy(ctx context.Context, policy *UploadPolicy, keys *signing.Keyring) (string, string, error) {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return "", "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString(document)
+	signature, err := keys.Sign(ctx, policyMessage(encoded))
+	if err != nil {
+		return "", "", err
+	}
+	return encoded, signature, nil
+}
+
+// decodePolicy checks the signature of a base64 policy document and returns the policy. Policies
+// signed with a key that has since retired are invalid.
+func decodePolicy(ctx context.Context, encoded, signature string, keys *signing.Keyring) (*UploadPolicy, error) {
+	if err := keys.Verify(ctx, policyMessage(encoded), signature, signing.LegacyHex); err != nil {
+		if errors.Is(err, signing.ErrInvalid) || errors.Is(err, signing.ErrRetired) {
+			return nil, ErrPolicyInvalid
+		}
+		return nil, err
+	}
+	document, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrPolicyInvalid
+	}
+	var policy UploadPolicy
+	if err := json.Unmarshal(document, &policy); err != nil {
+		return nil, ErrPolicyInvalid
+	}
+	return &policy, nil
+}
+
+// policyMessage is what a policy's signature signs
+func policyMessage(encoded string) string {
+	return "upload-policy:" + encoded
+}
+
+// keyAllowed reports whether a file name is under the policy's prefix and can't climb out of it
+func (p *UploadPolicy) keyAllowed(key string) bool {
+	if key == "" || strings.HasSuffix(key, "/") || !strings.HasPrefix(key, p.KeyPrefix) {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// contentTypeAllowed reports whether a content type starts with the policy's prefix, ignoring case
+func (p *UploadPolicy) contentTypeAllowed(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), strings.ToLower(p.ContentTypePrefix))
+}
@@ -0,0 +1,59 @@
+package uploadsession
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type AbortUploadSessionCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UploadID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+}
+
+type AbortUploadSessionResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type AbortUploadSessionRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewAbortUploadSessionRequestHandler(dbContext *persistence.AppDbContext) *AbortUploadSessionRequestHandler {
+	return &AbortUploadSessionRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle abandons a resumable upload, dropping the content received so far
+func (h *AbortUploadSessionRequestHandler) Handle(ctx context.Context, command *AbortUploadSessionCommand) (*AbortUploadSessionResponse, error) {
+	unlock, err := lockSession(h.dbContext.GetDB(), command.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	session, err := findSession(h.dbContext, command.BucketID, command.UploadID, command.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.dbContext.GetDB().WithContext(ctx).Delete(&entities.UploadSession{}, `"Id" = ?`, session.Id).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete upload session: %w", err)
+	}
+	if err := os.Remove(session.StagingPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove staged content: %w", err)
+	}
+
+	return &AbortUploadSessionResponse{
+		Success: true,
+		Message: "Upload session aborted successfully",
+	}, nil
+}
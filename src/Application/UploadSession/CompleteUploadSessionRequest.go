@@ -0,0 +1,99 @@
+package uploadsession
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Progress"
+	"shbucket/src/Models"
+)
+
+type CompleteUploadSessionCommand struct {
+	BucketID    uuid.UUID               `json:"-"`
+	UploadID    uuid.UUID               `json:"-"`
+	UserID      uuid.UUID               `json:"-"`
+	CustomerKey *encryption.CustomerKey `json:"-"` // SSE-C key supplied with the request, never stored
+	// ExpectedChecksum is the hex sha256 of the whole file the client computed, checked as it is stored
+	ExpectedChecksum string `json:"-"`
+	// Progress reports how far storing the file got, nil when no one follows the upload
+	Progress *progress.Transfer `json:"-"`
+}
+
+type CompleteUploadSessionResponse struct {
+	File        models.FileResponse         `json:"file"`
+	StorageNode *models.StorageNodeResponse `json:"storage_node,omitempty"`
+	Success     bool                        `json:"success"`
+	Message     string                      `json:"message"`
+}
+
+type CompleteUploadSessionRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewCompleteUploadSessionRequestHandler(dbContext *persistence.AppDbContext) *CompleteUploadSessionRequestHandler {
+	return &CompleteUploadSessionRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle stores the file of a resumable upload that has received all its content, as a regular
+// upload of the staged content. The session stays open when storing fails, so it can be retried.
+func (h *CompleteUploadSessionRequestHandler) Handle(ctx context.Context, command *CompleteUploadSessionCommand) (*CompleteUploadSessionResponse, error) {
+	unlock, err := lockSession(h.dbContext.GetDB(), command.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	session, err := findSession(h.dbContext, command.BucketID, command.UploadID, command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Received != session.Size {
+		return nil, fmt.Errorf("%w: %d of %d bytes received", ErrIncomplete, session.Received, session.Size)
+	}
+
+	staging, err := os.Open(session.StagingPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer staging.Close()
+
+	upload, err := file.NewDistributedUploadRequestHandler(h.dbContext).Handle(ctx, &file.DistributedUploadCommand{
+		BucketID:         session.BucketId,
+		FileReader:       io.LimitReader(staging, session.Size),
+		FileSize:         session.Size,
+		FileName:         session.FileName,
+		ContentType:      session.ContentType,
+		UploadedBy:       session.UserId,
+		CustomerKey:      command.CustomerKey,
+		ExpectedChecksum: command.ExpectedChecksum,
+		Progress:         command.Progress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is stored, a session left behind only costs disk space until it expires
+	if err := h.dbContext.GetDB().Delete(&entities.UploadSession{}, `"Id" = ?`, session.Id).Error; err != nil {
+		log.Printf("Warning: failed to close upload session %s: %v", session.Id, err)
+	} else {
+		os.Remove(session.StagingPath)
+	}
+
+	return &CompleteUploadSessionResponse{
+		File:        upload.File,
+		StorageNode: upload.StorageNode,
+		Success:     true,
+		Message:     upload.Message,
+	}, nil
+}
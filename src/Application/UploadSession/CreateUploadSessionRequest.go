@@ -0,0 +1,104 @@
+package uploadsession
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type CreateUploadSessionCommand struct {
+	BucketID    uuid.UUID `json:"-"`
+	UserID      uuid.UUID `json:"-"`
+	FileName    string    `json:"file_name" validate:"required,max=1024"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size" validate:"min=0"` // total size of the file in bytes
+}
+
+type CreateUploadSessionResponse struct {
+	Upload  models.UploadSessionResponse `json:"upload"`
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+}
+
+type CreateUploadSessionRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func NewCreateUploadSessionRequestHandler(dbContext *persistence.AppDbContext) *CreateUploadSessionRequestHandler {
+	return &CreateUploadSessionRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Handle starts a resumable upload. Its content is then sent in chunks, in order, and the file is
+// stored once the upload is completed.
+func (h *CreateUploadSessionRequestHandler) Handle(ctx context.Context, command *CreateUploadSessionCommand) (*CreateUploadSessionResponse, error) {
+	bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: command.BucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	}
+	if deleting, err := jobs.Active(h.dbContext, jobs.TypeBucketDelete, bucket.Id); err == nil && deleting {
+		return nil, apierror.New(apierror.CodeConflict, "bucket is being deleted")
+	}
+	if limit := bucket.Settings.MaxFileSize; limit > 0 && command.Size > limit {
+		return nil, fmt.Errorf("%w of %d bytes", file.ErrFileTooLarge, limit)
+	}
+
+	if err := os.MkdirAll(h.settings.UploadSessionPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload staging directory: %w", err)
+	}
+
+	contentType := command.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(command.FileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	now := time.Now()
+	session := &entities.UploadSession{
+		Id:          uuid.New(),
+		BucketId:    bucket.Id,
+		UserId:      command.UserID,
+		FileName:    command.FileName,
+		ContentType: contentType,
+		Size:        command.Size,
+		ExpiresAt:   now.Add(time.Duration(h.settings.UploadSessionTTL) * time.Second),
+		CreatedAt:   now,
+	}
+	session.StagingPath = stagingPath(h.settings.UploadSessionPath, session.Id)
+
+	staging, err := os.Create(session.StagingPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging file: %w", err)
+	}
+	staging.Close()
+
+	h.dbContext.UploadSessions.Add(*session)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		os.Remove(session.StagingPath)
+		return nil, fmt.Errorf("failed to save upload session: %w", err)
+	}
+
+	return &CreateUploadSessionResponse{
+		Upload:  ToUploadSessionResponse(session),
+		Success: true,
+		Message: "Upload session created successfully",
+	}, nil
+}
@@ -0,0 +1,47 @@
+package uploadsession
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type GetUploadSessionCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UploadID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+}
+
+type GetUploadSessionResponse struct {
+	Upload  models.UploadSessionResponse `json:"upload"`
+	Success bool                         `json:"success"`
+	Message string                       `json:"message"`
+}
+
+type GetUploadSessionRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetUploadSessionRequestHandler(dbContext *persistence.AppDbContext) *GetUploadSessionRequestHandler {
+	return &GetUploadSessionRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns a resumable upload's progress. Its offset is where a client continues after an
+// interruption, on either side.
+func (h *GetUploadSessionRequestHandler) Handle(ctx context.Context, command *GetUploadSessionCommand) (*GetUploadSessionResponse, error) {
+	session, err := findSession(h.dbContext, command.BucketID, command.UploadID, command.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetUploadSessionResponse{
+		Upload:  ToUploadSessionResponse(session),
+		Success: true,
+		Message: "Upload session retrieved successfully",
+	}, nil
+}
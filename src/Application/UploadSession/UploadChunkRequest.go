@@ -0,0 +1,149 @@
+package uploadsession
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Progress"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+type UploadChunkCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UploadID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	Offset   int64     `json:"-"` // where the chunk starts, the upload's current offset
+	Content  io.Reader `json:"-"`
+	// ExpectedChecksum is the hex sha256 of the chunk the client computed, the chunk is dropped when it differs
+	ExpectedChecksum string `json:"-"`
+	// Progress reports the bytes the upload has received, nil when no one follows the upload
+	Progress *progress.Transfer `json:"-"`
+}
+
+type UploadChunkResponse struct {
+	Upload models.UploadSessionResponse `json:"upload"`
+	// ChunkChecksum is the hex sha256 of the chunk as it was written
+	ChunkChecksum string `json:"chunk_checksum"`
+	Success       bool   `json:"success"`
+	Message       string `json:"message"`
+}
+
+type UploadChunkRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+}
+
+func NewUploadChunkRequestHandler(dbContext *persistence.AppDbContext) *UploadChunkRequestHandler {
+	return &UploadChunkRequestHandler{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Handle appends a chunk to a resumable upload. The chunk is only acknowledged once it is on disk
+// in full; a chunk cut off part way is dropped and sent again from the same offset.
+func (h *UploadChunkRequestHandler) Handle(ctx context.Context, command *UploadChunkCommand) (*UploadChunkResponse, error) {
+	unlock, err := lockSession(h.dbContext.GetDB(), command.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Register the write so a shutdown waits for the chunk instead of cutting it off
+	done, err := storage.BeginTransfer()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	session, err := findSession(h.dbContext, command.BucketID, command.UploadID, command.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if command.Offset != session.Received {
+		return nil, fmt.Errorf("%w: the upload is at %d", ErrOffsetMismatch, session.Received)
+	}
+
+	staging, err := os.OpenFile(session.StagingPath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer staging.Close()
+
+	// Anything past the acknowledged offset was left by a chunk that never completed
+	if err := staging.Truncate(session.Received); err != nil {
+		return nil, fmt.Errorf("failed to prepare staging file: %w", err)
+	}
+	if _, err := staging.Seek(session.Received, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to prepare staging file: %w", err)
+	}
+
+	command.Progress.Stage(progress.StageReceiving, session.Size)
+	command.Progress.Set(session.Received)
+
+	remaining := session.Size - session.Received
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(staging, hash), command.Progress.Reader(io.LimitReader(command.Content, remaining+1)))
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err != nil {
+		err = fmt.Errorf("failed to write chunk: %w", err)
+	} else if written > remaining {
+		err = fmt.Errorf("%w of %d bytes, %d bytes remain", ErrChunkTooLarge, session.Size, remaining)
+	} else if command.ExpectedChecksum != "" && checksum != command.ExpectedChecksum {
+		err = fmt.Errorf("%w: received sha256 %s, expected %s", ErrChunkChecksumMismatch, checksum, command.ExpectedChecksum)
+	} else if err = staging.Sync(); err != nil {
+		err = fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if err != nil {
+		staging.Truncate(session.Received)
+		command.Progress.Set(session.Received)
+		return nil, err
+	}
+
+	// Saving the offset is what acknowledges the chunk. It only moves forward from where this
+	// chunk started, in case another server took a chunk of the same upload meanwhile.
+	received := session.Received + written
+	expiresAt := time.Now().Add(time.Duration(h.settings.UploadSessionTTL) * time.Second)
+	if err := saveProgress(h.dbContext.GetDB().WithContext(ctx), session, received, expiresAt); err != nil {
+		return nil, err
+	}
+	session.Received = received
+	session.ExpiresAt = expiresAt
+
+	return &UploadChunkResponse{
+		Upload:        ToUploadSessionResponse(session),
+		ChunkChecksum: checksum,
+		Success:       true,
+		Message:       "Chunk received",
+	}, nil
+}
+
+// saveProgress moves an upload session's offset from where session says it is to received.
+// It returns ErrOffsetMismatch when the offset moved meanwhile.
+func saveProgress(db *gorm.DB, session *entities.UploadSession, received int64, expiresAt time.Time) error {
+	result := db.Model(&entities.UploadSession{}).
+		Where(`"Id" = ? AND "Received" = ?`, session.Id, session.Received).
+		Updates(map[string]interface{}{
+			"Received":  received,
+			"ExpiresAt": expiresAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save upload progress: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOffsetMismatch
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package uploadsession
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+var (
+	// ErrSessionNotFound is returned for upload sessions that don't exist, belong to someone else or have expired
+	ErrSessionNotFound = apierror.New(apierror.CodeNotFound, "upload session not found")
+	// ErrOffsetMismatch is returned for a chunk that doesn't start where the upload left off
+	ErrOffsetMismatch = apierror.New(apierror.CodeOffsetMismatch, "chunk does not start at the upload's offset")
+	// ErrSessionBusy is returned while another request is writing to the same upload
+	ErrSessionBusy = apierror.New(apierror.CodeConflict, "upload session is busy with another request")
+	// ErrChunkTooLarge is returned for a chunk that goes past the upload's declared size
+	ErrChunkTooLarge = apierror.New(apierror.CodePayloadTooLarge, "chunk goes past the upload's declared size")
+	// ErrIncomplete is returned when completing an upload that hasn't received all its content
+	ErrIncomplete = apierror.New(apierror.CodeConflict, "upload has not received all of its content")
+	// ErrChunkChecksumMismatch is returned for a chunk that doesn't have the checksum the client sent
+	ErrChunkChecksumMismatch = apierror.New(apierror.CodeChecksumMismatch, "chunk doesn't match the expected checksum")
+)
+
+// stagingSuffix marks a resumable upload's staging file
+const stagingSuffix = ".part"
+
+// sessionHoldLease is how long a request's hold on an upload session lasts unless it is renewed.
+// The hold of a server that stopped in the middle of a request lapses after it.
+const sessionHoldLease = 30 * time.Second
+
+// lockSession claims an upload session for one request, on whichever server it arrives, and
+// returns the function releasing it. The hold is renewed until it is released.
+func lockSession(db *gorm.DB, id uuid.UUID) (func(), error) {
+	now := time.Now()
+	result := db.Model(&entities.UploadSession{}).
+		Where(`"Id" = ? AND ("HeldUntil" IS NULL OR "HeldUntil" < ?)`, id, now).
+		Update("HeldUntil", now.Add(sessionHoldLease))
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim upload session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var exists int64
+		if err := db.Model(&entities.UploadSession{}).Where(&entities.UploadSession{Id: id}).Count(&exists).Error; err == nil && exists == 0 {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionBusy
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(sessionHoldLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				db.Model(&entities.UploadSession{Id: id}).Update("HeldUntil", time.Now().Add(sessionHoldLease))
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+		db.Model(&entities.UploadSession{Id: id}).Update("HeldUntil", nil)
+	}, nil
+}
+
+// findSession loads an open upload session of the user in the bucket
+func findSession(dbContext *persistence.AppDbContext, bucketID, uploadID, userID uuid.UUID) (*entities.UploadSession, error) {
+	session, err := dbContext.UploadSessions.Where(&entities.UploadSession{Id: uploadID}).FirstOrDefault()
+	if err != nil || session == nil {
+		return nil, ErrSessionNotFound
+	}
+	if session.BucketId != bucketID || session.UserId != userID || !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// stagingPath is where the content of an upload session is kept until it completes
+func stagingPath(root string, id uuid.UUID) string {
+	return filepath.Join(root, id.String()+stagingSuffix)
+}
+
+func ToUploadSessionResponse(session *entities.UploadSession) models.UploadSessionResponse {
+	return models.UploadSessionResponse{
+		ID:          session.Id,
+		BucketID:    session.BucketId,
+		FileName:    session.FileName,
+		ContentType: session.ContentType,
+		Size:        session.Size,
+		Offset:      session.Received,
+		ExpiresAt:   session.ExpiresAt,
+		CreatedAt:   session.CreatedAt,
+	}
+}
@@ -0,0 +1,66 @@
+package uploadsession
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestLockSession holds an upload session for one request at a time
+func TestLockSession(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	session := entities.UploadSession{BucketId: bucket.Id, UserId: bucket.OwnerId, FileName: "a.mp4", Size: 100, StagingPath: "/tmp/a.part", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockSession(db, session.Id)
+	if err != nil {
+		t.Fatalf("lockSession() = %v", err)
+	}
+	if _, err := lockSession(db, session.Id); !errors.Is(err, ErrSessionBusy) {
+		t.Errorf("lockSession() of a held session = %v, want ErrSessionBusy", err)
+	}
+	unlock()
+
+	unlock, err = lockSession(db, session.Id)
+	if err != nil {
+		t.Fatalf("lockSession() after unlock = %v", err)
+	}
+	unlock()
+
+	if _, err := lockSession(db, bucket.Id); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("lockSession() of a missing session = %v, want ErrSessionNotFound", err)
+	}
+}
+
+// TestSaveProgress moves the offset only from where the chunk started
+func TestSaveProgress(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	session := entities.UploadSession{BucketId: bucket.Id, UserId: bucket.OwnerId, FileName: "a.mp4", Size: 100, StagingPath: "/tmp/a.part", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	stale := session
+	expiresAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	if err := saveProgress(db, &session, 40, expiresAt); err != nil {
+		t.Fatalf("saveProgress() = %v", err)
+	}
+	if err := saveProgress(db, &stale, 30, expiresAt); !errors.Is(err, ErrOffsetMismatch) {
+		t.Errorf("saveProgress() from an old offset = %v, want ErrOffsetMismatch", err)
+	}
+
+	var saved entities.UploadSession
+	if err := db.First(&saved, `"Id" = ?`, session.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Received != 40 || !saved.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("saved session = %+v, want 40 bytes received, expiring at %v", saved, expiresAt)
+	}
+}
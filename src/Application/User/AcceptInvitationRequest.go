@@ -0,0 +1,111 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type AcceptInvitationCommand struct {
+	Token    string `json:"token" validate:"required"`
+	Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type AcceptInvitationResponse struct {
+	User    models.UserResponse `json:"user"`
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+}
+
+type AcceptInvitationRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewAcceptInvitationRequestHandler(dbContext *persistence.AppDbContext) *AcceptInvitationRequestHandler {
+	return &AcceptInvitationRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle creates the account an invitation is for, with the invited email and role. The
+// invitation is spent in the same transaction, so it creates one account at most.
+func (h *AcceptInvitationRequestHandler) Handle(ctx context.Context, command *AcceptInvitationCommand) (*AcceptInvitationResponse, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(command.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := acceptInvitation(h.dbContext.GetDB().WithContext(ctx), command.Token, command.Username, string(hashedPassword), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &AcceptInvitationResponse{
+		User: models.UserResponse{
+			ID:        user.Id,
+			Username:  user.Username,
+			Email:     user.Email,
+			Role:      user.Role,
+			IsActive:  user.IsActive,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		Success: true,
+		Message: "Invitation accepted successfully",
+	}, nil
+}
+
+// acceptInvitation creates the account of the invitation with the token and spends the invitation
+func acceptInvitation(db *gorm.DB, token, username, passwordHash string, now time.Time) (entities.User, error) {
+	var user entities.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var invitation entities.UserInvitation
+		result := tx.Where(`"TokenHash" = ? AND "AcceptedAt" IS NULL AND "ExpiresAt" > ?`, hashUserToken(token), now).
+			Limit(1).Find(&invitation)
+		if result.Error != nil {
+			return fmt.Errorf("failed to look up invitation: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+
+		var taken int64
+		if err := tx.Model(&entities.User{}).Where(`"Email" = ? OR "Username" = ?`, invitation.Email, username).Count(&taken).Error; err != nil {
+			return fmt.Errorf("failed to look up user: %w", err)
+		}
+		if taken > 0 {
+			return apierror.New(apierror.CodeAlreadyExists, "user with this email or username already exists")
+		}
+
+		user = entities.User{
+			Username:     username,
+			Email:        invitation.Email,
+			PasswordHash: passwordHash,
+			Role:         invitation.Role,
+			IsActive:     true,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		// Guarded on AcceptedAt, so of two concurrent acceptances only one creates the account
+		spent := tx.Model(&entities.UserInvitation{}).Where(`"Id" = ? AND "AcceptedAt" IS NULL`, invitation.Id).
+			Updates(map[string]interface{}{"AcceptedAt": now, "UserId": user.Id})
+		if spent.Error != nil {
+			return fmt.Errorf("failed to accept invitation: %w", spent.Error)
+		}
+		if spent.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+		return nil
+	})
+	return user, err
+}
@@ -6,6 +6,7 @@ import (
 	
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 )
@@ -35,11 +36,11 @@ func (h *ChangePasswordRequestHandler) Handle(ctx context.Context, command *Chan
 	// Find user using GoNtext
 	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
 	if err != nil || user == nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(command.OldPassword)); err != nil {
-		return nil, fmt.Errorf("invalid old password")
+		return nil, apierror.New(apierror.CodeInvalidRequest, "invalid old password")
 	}
 
 	hashedNewPassword, err := bcrypt.GenerateFromPassword([]byte(command.NewPassword), bcrypt.DefaultCost)
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type ConfirmTwoFactorCommand struct {
+	UserID uuid.UUID `json:"-"`
+	Code   string    `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ConfirmTwoFactorResponse struct {
+	// RecoveryCodes each sign in once without the authenticator app. They are only returned here.
+	RecoveryCodes []string `json:"recovery_codes"`
+	Success       bool     `json:"success"`
+	Message       string   `json:"message"`
+}
+
+type ConfirmTwoFactorRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewConfirmTwoFactorRequestHandler(dbContext *persistence.AppDbContext) *ConfirmTwoFactorRequestHandler {
+	return &ConfirmTwoFactorRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle enables two-factor authentication once the user shows a code from the enrolled
+// authenticator app, and issues their recovery codes. The user's other sessions end.
+func (h *ConfirmTwoFactorRequestHandler) Handle(ctx context.Context, command *ConfirmTwoFactorCommand) (*ConfirmTwoFactorResponse, error) {
+	var codes []string
+	err := h.dbContext.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user entities.User
+		result := tx.Where(`"Id" = ?`, command.UserID).Limit(1).Find(&user)
+		if result.Error != nil {
+			return fmt.Errorf("failed to look up user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apierror.New(apierror.CodeUserNotFound, "user not found")
+		}
+		if user.TwoFactorEnabled {
+			return ErrTwoFactorEnabled
+		}
+		if user.TOTPSecret == "" {
+			return ErrTwoFactorNotEnrolled
+		}
+
+		if err := verifySecondFactor(tx, &user, command.Code, "", time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.User{}).Where(`"Id" = ?`, user.Id).Update("TwoFactorEnabled", true).Error; err != nil {
+			return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfirmTwoFactorResponse{
+		RecoveryCodes: codes,
+		Success:       true,
+		Message:       "Two-factor authentication enabled, store the recovery codes somewhere safe",
+	}, nil
+}
@@ -0,0 +1,88 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DisableTwoFactorCommand struct {
+	UserID       uuid.UUID `json:"-"`
+	Password     string    `json:"password" validate:"required"`
+	Code         string    `json:"code" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string    `json:"recovery_code" validate:"omitempty,max=32"`
+}
+
+type DisableTwoFactorResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DisableTwoFactorRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDisableTwoFactorRequestHandler(dbContext *persistence.AppDbContext) *DisableTwoFactorRequestHandler {
+	return &DisableTwoFactorRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle turns two-factor authentication off with the user's password and a second factor,
+// unless their role requires it
+func (h *DisableTwoFactorRequestHandler) Handle(ctx context.Context, command *DisableTwoFactorCommand) (*DisableTwoFactorResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if auth.TwoFactorRequired(user.Role) {
+		return nil, ErrTwoFactorRequiredByRole
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(command.Password)); err != nil {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "invalid password")
+	}
+
+	err = h.dbContext.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, command.Code, command.RecoveryCode, time.Now()); err != nil {
+			return err
+		}
+		return disableTwoFactor(tx, user.Id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DisableTwoFactorResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	}, nil
+}
+
+// disableTwoFactor clears the user's secret, recovery codes and pending sign ins
+func disableTwoFactor(tx *gorm.DB, userID uuid.UUID) error {
+	if err := tx.Model(&entities.User{}).Where(`"Id" = ?`, userID).Updates(map[string]interface{}{
+		"TwoFactorEnabled": false,
+		"totp_secret":      "",
+		"totp_last_step":   0,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	if err := tx.Delete(&entities.UserRecoveryCode{}, `"UserId" = ?`, userID).Error; err != nil {
+		return fmt.Errorf("failed to remove recovery codes: %w", err)
+	}
+	if err := tx.Delete(&entities.LoginChallenge{}, `"UserId" = ?`, userID).Error; err != nil {
+		return fmt.Errorf("failed to remove login challenges: %w", err)
+	}
+	return nil
+}
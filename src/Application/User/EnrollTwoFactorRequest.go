@@ -0,0 +1,71 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Secrets"
+)
+
+type EnrollTwoFactorCommand struct {
+	UserID uuid.UUID `json:"-"`
+}
+
+type EnrollTwoFactorResponse struct {
+	// Secret is for entering in the authenticator app by hand
+	Secret string `json:"secret"`
+	// OTPAuthURL is what the authenticator app scans, as a QR code
+	OTPAuthURL string `json:"otpauth_url"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+}
+
+type EnrollTwoFactorRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewEnrollTwoFactorRequestHandler(dbContext *persistence.AppDbContext) *EnrollTwoFactorRequestHandler {
+	return &EnrollTwoFactorRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle starts setting up two-factor authentication with a new secret for the user's
+// authenticator app. It is enabled once a code from the app is confirmed; enrolling again before
+// that replaces the secret.
+func (h *EnrollTwoFactorRequestHandler) Handle(ctx context.Context, command *EnrollTwoFactorCommand) (*EnrollTwoFactorResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate two-factor secret: %w", err)
+	}
+	// Updates with a map skip the column's serializer, so the secret is sealed here
+	sealed, err := secrets.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+	}
+	if err := h.dbContext.GetDB().WithContext(ctx).Model(&entities.User{}).Where(`"Id" = ? AND "TwoFactorEnabled" = ?`, user.Id, false).
+		Updates(map[string]interface{}{"totp_secret": sealed, "totp_last_step": 0}).Error; err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	return &EnrollTwoFactorResponse{
+		Secret:     secret,
+		OTPAuthURL: auth.TOTPURL(config.GetSettings().SystemName, user.Email, secret),
+		Success:    true,
+		Message:    "Add the secret to your authenticator app and confirm a code at /auth/2fa/confirm",
+	}, nil
+}
@@ -0,0 +1,109 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Mail"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// passwordResetInterval is how soon after a reset link another one is sent to the same user
+const passwordResetInterval = time.Minute
+
+type ForgotPasswordCommand struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type ForgotPasswordRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	mailer    *mail.Mailer
+}
+
+func NewForgotPasswordRequestHandler(dbContext *persistence.AppDbContext, mailer *mail.Mailer) *ForgotPasswordRequestHandler {
+	return &ForgotPasswordRequestHandler{
+		dbContext: dbContext,
+		mailer:    mailer,
+	}
+}
+
+// Handle emails a password reset link to the active user with the email. The response is the
+// same whether or not there is one, so it doesn't tell which addresses have accounts.
+func (h *ForgotPasswordRequestHandler) Handle(ctx context.Context, command *ForgotPasswordCommand) (*ForgotPasswordResponse, error) {
+	if !h.mailer.Enabled() {
+		return nil, mail.ErrNotConfigured
+	}
+
+	response := &ForgotPasswordResponse{
+		Success: true,
+		Message: "If an account uses this email, a password reset link was sent to it",
+	}
+
+	user, err := h.dbContext.Users.Where(&entities.User{Email: strings.TrimSpace(command.Email)}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return response, nil
+	}
+
+	now := time.Now()
+	recent, err := resetsSince(h.dbContext.GetDB().WithContext(ctx), user.Id, now.Add(-passwordResetInterval))
+	if err != nil {
+		return nil, err
+	}
+	if recent > 0 {
+		return response, nil
+	}
+
+	token, tokenHash, err := generateUserToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password reset: %w", err)
+	}
+	reset := entities.PasswordResetToken{
+		Id:        uuid.New(),
+		UserId:    user.Id,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(time.Duration(config.GetSettings().PasswordResetExpiry) * time.Minute),
+		CreatedAt: now,
+	}
+	h.dbContext.PasswordResets.Add(reset)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save password reset: %w", err)
+	}
+
+	// A delivery failure isn't reported, it would tell the address has an account
+	err = h.mailer.Send(ctx, user.Email, mail.TemplatePasswordReset, map[string]interface{}{
+		"Username":  user.Username,
+		"Link":      h.mailer.Link("/reset-password?token=" + url.QueryEscape(token)),
+		"ExpiresAt": reset.ExpiresAt.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		log.Printf("Failed to send password reset to user %s: %v", user.Id, err)
+	}
+	return response, nil
+}
+
+// resetsSince counts the password resets sent to a user since a time
+func resetsSince(db *gorm.DB, userID uuid.UUID, since time.Time) (int64, error) {
+	var recent int64
+	if err := db.Model(&entities.PasswordResetToken{}).
+		Where(`"UserId" = ? AND "CreatedAt" > ?`, userID, since).
+		Count(&recent).Error; err != nil {
+		return 0, fmt.Errorf("failed to look up password resets: %w", err)
+	}
+	return recent, nil
+}
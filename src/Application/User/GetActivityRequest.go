@@ -0,0 +1,119 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+// activityEventTypes are the bucket events shown in the activity feed
+var activityEventTypes = []string{events.FileUploaded, events.FileShared, events.CommentCreated}
+
+type GetActivityCommand struct {
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int       `json:"limit"`
+}
+
+type GetActivityResponse struct {
+	Activity []models.ActivityResponse `json:"activity"`
+	Success  bool                      `json:"success"`
+	Message  string                    `json:"message"`
+}
+
+type GetActivityRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetActivityRequestHandler(dbContext *persistence.AppDbContext) *GetActivityRequestHandler {
+	return &GetActivityRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle returns recent uploads, shares and comments in the user's buckets, along with the user's own activity elsewhere
+func (h *GetActivityRequestHandler) Handle(ctx context.Context, command *GetActivityCommand) (*GetActivityResponse, error) {
+	limit := command.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	buckets, err := h.dbContext.Buckets.Where(&entities.Bucket{OwnerId: command.UserID}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch buckets: %w", err)
+	}
+
+	bucketNames := make(map[uuid.UUID]string)
+	bucketIDs := make([]uuid.UUID, 0, len(buckets))
+	for _, bucket := range buckets {
+		bucketNames[bucket.Id] = bucket.Name
+		bucketIDs = append(bucketIDs, bucket.Id)
+	}
+
+	bucketEvents, err := recentActivity(h.dbContext.GetDB().WithContext(ctx), command.UserID, bucketIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[uuid.UUID]string)
+	activity := make([]models.ActivityResponse, len(bucketEvents))
+	for i, event := range bucketEvents {
+		bucketName, ok := bucketNames[event.BucketId]
+		if !ok {
+			if bucket, err := h.dbContext.Buckets.Where(&entities.Bucket{Id: event.BucketId}).FirstOrDefault(); err == nil && bucket != nil {
+				bucketName = bucket.Name
+			}
+			bucketNames[event.BucketId] = bucketName
+		}
+
+		username, ok := usernames[event.ActorId]
+		if !ok {
+			if actor, err := h.dbContext.Users.Where(&entities.User{Id: event.ActorId}).FirstOrDefault(); err == nil && actor != nil {
+				username = actor.Username
+			}
+			usernames[event.ActorId] = username
+		}
+
+		activity[i] = models.ActivityResponse{
+			ID:            event.Id,
+			Type:          event.Type,
+			BucketID:      event.BucketId,
+			BucketName:    bucketName,
+			FileID:        event.FileId,
+			ActorID:       event.ActorId,
+			ActorUsername: username,
+			Data:          utils.ConvertJSONToMap(event.Data),
+			CreatedAt:     event.CreatedAt,
+		}
+	}
+
+	return &GetActivityResponse{
+		Activity: activity,
+		Success:  true,
+		Message:  "Activity retrieved successfully",
+	}, nil
+}
+
+// recentActivity returns up to limit of the newest feed events in the given buckets and of the user
+// anywhere
+func recentActivity(db *gorm.DB, userID uuid.UUID, bucketIDs []uuid.UUID, limit int) ([]entities.BucketEvent, error) {
+	query := db.Where(`"Type" IN ?`, activityEventTypes)
+	if len(bucketIDs) > 0 {
+		query = query.Where(`"BucketId" IN ? OR "ActorId" = ?`, bucketIDs, userID)
+	} else {
+		query = query.Where(`"ActorId" = ?`, userID)
+	}
+
+	var bucketEvents []entities.BucketEvent
+	if err := query.Order(`"CreatedAt" DESC`).Limit(limit).Find(&bucketEvents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activity: %w", err)
+	}
+	return bucketEvents, nil
+}
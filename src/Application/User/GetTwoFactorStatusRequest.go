@@ -0,0 +1,67 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type GetTwoFactorStatusCommand struct {
+	UserID uuid.UUID `json:"-"`
+}
+
+type GetTwoFactorStatusResponse struct {
+	Enabled bool `json:"enabled"`
+	// Required is set when the user's role must use two-factor authentication
+	Required               bool   `json:"required"`
+	RecoveryCodesRemaining int64  `json:"recovery_codes_remaining"`
+	Success                bool   `json:"success"`
+	Message                string `json:"message"`
+}
+
+type GetTwoFactorStatusRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewGetTwoFactorStatusRequestHandler(dbContext *persistence.AppDbContext) *GetTwoFactorStatusRequestHandler {
+	return &GetTwoFactorStatusRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+func (h *GetTwoFactorStatusRequestHandler) Handle(ctx context.Context, command *GetTwoFactorStatusCommand) (*GetTwoFactorStatusResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+
+	response := &GetTwoFactorStatusResponse{
+		Enabled:  user.TwoFactorEnabled,
+		Required: auth.TwoFactorRequired(user.Role),
+		Success:  true,
+		Message:  "Two-factor status retrieved successfully",
+	}
+	if user.TwoFactorEnabled {
+		remaining, err := unusedRecoveryCodes(h.dbContext.GetDB().WithContext(ctx), user.Id)
+		if err != nil {
+			return nil, err
+		}
+		response.RecoveryCodesRemaining = remaining
+	}
+	return response, nil
+}
+
+// unusedRecoveryCodes counts the user's recovery codes that can still be used
+func unusedRecoveryCodes(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := db.Model(&entities.UserRecoveryCode{}).Where(`"UserId" = ? AND "UsedAt" IS NULL`, userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
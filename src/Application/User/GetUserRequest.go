@@ -2,9 +2,9 @@ package user
 
 import (
 	"context"
-	"fmt"
 	
 	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 )
@@ -33,17 +33,18 @@ func (h *GetUserRequestHandler) Handle(ctx context.Context, command *GetUserComm
 	// Use GoNtext LINQ to find by ID (like EF Core: context.Users.Find(id) or FirstOrDefault())
 	user, err := h.dbContext.Users.ById(command.UserID)
 	if err != nil || user == nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
 	}
 
 	userResponse := models.UserResponse{
-		ID:        user.Id,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:               user.Id,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             user.Role,
+		IsActive:         user.IsActive,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
 	}
 
 	return &GetUserResponse{
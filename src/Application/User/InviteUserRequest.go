@@ -0,0 +1,110 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Mail"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type InviteUserCommand struct {
+	Email  string    `json:"email" validate:"required,email"`
+	Role   string    `json:"role" validate:"omitempty,max=50"` // a built-in or custom role, viewer when empty
+	UserID uuid.UUID `json:"-"`
+}
+
+type InviteUserResponse struct {
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+}
+
+type InviteUserRequestHandler struct {
+	dbContext *persistence.AppDbContext
+	mailer    *mail.Mailer
+}
+
+func NewInviteUserRequestHandler(dbContext *persistence.AppDbContext, mailer *mail.Mailer) *InviteUserRequestHandler {
+	return &InviteUserRequestHandler{
+		dbContext: dbContext,
+		mailer:    mailer,
+	}
+}
+
+// Handle emails someone a link to create an account with the given role. Inviting an address
+// again sends a new link, the earlier ones stay valid until they expire.
+func (h *InviteUserRequestHandler) Handle(ctx context.Context, command *InviteUserCommand) (*InviteUserResponse, error) {
+	if !h.mailer.Enabled() {
+		return nil, mail.ErrNotConfigured
+	}
+
+	email := strings.TrimSpace(command.Email)
+	existing, err := h.dbContext.Users.Where(&entities.User{Email: email}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if existing != nil {
+		return nil, apierror.New(apierror.CodeAlreadyExists, "user with this email already exists")
+	}
+
+	inviter, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || inviter == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+
+	token, tokenHash, err := generateUserToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation: %w", err)
+	}
+
+	role := command.Role
+	if role == "" {
+		role = "viewer"
+	}
+	if err := auth.ValidateRole(h.dbContext, role); err != nil {
+		return nil, err
+	}
+	invitation := entities.UserInvitation{
+		Id:        uuid.New(),
+		Email:     email,
+		Role:      role,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Duration(config.GetSettings().InvitationExpiryHours) * time.Hour),
+		InvitedBy: inviter.Id,
+	}
+	h.dbContext.UserInvitations.Add(invitation)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save invitation: %w", err)
+	}
+
+	err = h.mailer.Send(ctx, email, mail.TemplateInvitation, map[string]interface{}{
+		"InvitedBy": inviter.Username,
+		"Role":      role,
+		"Link":      h.mailer.Link("/accept-invitation?token=" + url.QueryEscape(token)),
+		"ExpiresAt": invitation.ExpiresAt.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		// An invitation nobody received can't be accepted, don't leave it behind
+		h.dbContext.GetDB().Delete(&entities.UserInvitation{}, `"Id" = ?`, invitation.Id)
+		return nil, err
+	}
+
+	return &InviteUserResponse{
+		Email:     email,
+		Role:      role,
+		ExpiresAt: invitation.ExpiresAt,
+		Success:   true,
+		Message:   "Invitation sent successfully",
+	}, nil
+}
@@ -4,17 +4,35 @@ import (
 	"context"
 	"fmt"
 	
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
+	"shbucket/src/Utils"
 )
 
 type ListUsersCommand struct {
-	Page            int  `json:"page"`
-	Limit           int  `json:"limit"`
-	IncludeBuckets  bool `json:"include_buckets"`
-	IncludeSessions bool `json:"include_sessions"`
-	IncludeAll      bool `json:"include_all"`
+	Page            int              `json:"page"`
+	Limit           int              `json:"limit"`
+	IncludeBuckets  bool             `json:"include_buckets"`
+	IncludeSessions bool             `json:"include_sessions"`
+	IncludeAll      bool             `json:"include_all"`
+	List            models.ListQuery `json:"list"` // the name filter matches usernames and emails
+	Role            string           `json:"role"`
+}
+
+// userSort is what users can be sorted by, by username when unspecified
+var userSort = utils.ListSort{
+	Columns: map[string]string{
+		"username":   "Username",
+		"email":      "Email",
+		"role":       "Role",
+		"created_at": "CreatedAt",
+		"last_login": "LastLoginTime",
+	},
+	DefaultField: "username",
+	DefaultOrder: "asc",
 }
 
 type ListUsersResponse struct {
@@ -49,52 +67,49 @@ func (h *ListUsersRequestHandler) Handle(ctx context.Context, command *ListUsers
 
 	offset := (page - 1) * limit
 
-	var users []entities.User
-	var total int64
-
-	// Use GoNtext LINQ for counting (like EF Core: context.Users.Count())
-	userCount, err := h.dbContext.Users.Count()
+	query := h.dbContext.GetDB().WithContext(ctx).Model(&entities.User{})
+	if command.Role != "" {
+		query = query.Where(`"Role" = ?`, command.Role)
+	}
+	query, err := utils.ApplyListQuery(query, command.List, userSort, "Username", "Email")
 	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, fmt.Errorf("failed to count users: %w", err)
 	}
-	total = int64(userCount)
 
-	// Use GoNtext LINQ for pagination with Include functionality (like EF Core)
-	query := h.dbContext.Users
-	
-	// Apply Include based on command parameters
+	// Load the relationships asked for
+	fetch := query.Session(&gorm.Session{})
 	if command.IncludeAll {
-		// Load all relationships automatically: context.Users.IncludeAll()
-		query = query.IncludeAll()
+		fetch = fetch.Preload(clause.Associations)
 	} else {
-		// Type-safe includes with field validation (cleaner syntax):
-		var includes []interface{}
 		if command.IncludeBuckets {
-			includes = append(includes, "Buckets")
+			fetch = fetch.Preload("Buckets")
 		}
 		if command.IncludeSessions {
-			includes = append(includes, "Sessions")
-		}
-		if len(includes) > 0 {
-			query = query.Include(includes...)
+			fetch = fetch.Preload("Sessions")
 		}
 	}
-	
-	users, err = query.Skip(offset).Take(limit).ToList()
-	if err != nil {
+
+	var users []entities.User
+	if err := fetch.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch users: %w", err)
 	}
 
 	userResponses := make([]models.UserResponse, len(users))
 	for i, user := range users {
 		userResponses[i] = models.UserResponse{
-			ID:        user.Id,
-			Username:  user.Username,
-			Email:     user.Email,
-			Role:      user.Role,
-			IsActive:  user.IsActive,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
+			ID:               user.Id,
+			Username:         user.Username,
+			Email:            user.Email,
+			Role:             user.Role,
+			IsActive:         user.IsActive,
+			TwoFactorEnabled: user.TwoFactorEnabled,
+			CreatedAt:        user.CreatedAt,
+			UpdatedAt:        user.UpdatedAt,
 		}
 	}
 
@@ -3,7 +3,9 @@ package user
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Auth"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
@@ -22,9 +24,16 @@ type LoginResponse struct {
 	User         models.UserResponse `json:"user"`
 	Token        string              `json:"token"`
 	RefreshToken string              `json:"refresh_token"`
-	ExpiresIn    int                 `json:"expires_in"`
-	Success      bool                `json:"success"`
-	Message      string              `json:"message"`
+	ExpiresIn    int                 `json:"expires_in"` // of the challenge token when two-factor is required
+	// TwoFactorRequired is set instead of a token for users with two-factor authentication: send
+	// the challenge token with a code to /auth/login/2fa
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	// TwoFactorSetupRequired is set when the token can only set up two-factor authentication,
+	// which the user's role requires
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+	Success                bool   `json:"success"`
+	Message                string `json:"message"`
 }
 
 type LoginRequestHandler struct {
@@ -44,14 +53,51 @@ func (h *LoginRequestHandler) Handle(ctx context.Context, command *LoginCommand)
 	// (like EF Core: context.Users.Where(u => u.Email == emailOrUsername || u.Username == emailOrUsername).FirstOrDefault())
 	user, err := h.dbContext.Users.Where(&entities.User{Email: command.EmailOrUsername}).OrField("Username", command.EmailOrUsername).FirstOrDefault()
 	if err != nil || user == nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, apierror.New(apierror.CodeInvalidCredentials, "invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(command.Password)); err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, apierror.New(apierror.CodeInvalidCredentials, "invalid credentials")
 	}
 
-	token, sessionInfo, err := h.jwtHandler.GenerateToken(user.Id, user.Username, user.Email, user.Role)
+	if user.TwoFactorEnabled {
+		return h.challenge(user)
+	}
+	return startSession(h.dbContext, h.jwtHandler, user, "Login successful")
+}
+
+// challenge starts a sign in that waits for the user's second factor
+func (h *LoginRequestHandler) challenge(user *entities.User) (*LoginResponse, error) {
+	token, tokenHash, err := generateUserToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate login challenge: %w", err)
+	}
+
+	now := time.Now()
+	// Sign ins that were abandoned are cleared when the user signs in again
+	h.dbContext.GetDB().Delete(&entities.LoginChallenge{}, `"UserId" = ? AND "ExpiresAt" < ?`, user.Id, now)
+	h.dbContext.LoginChallenges.Add(entities.LoginChallenge{
+		Id:        uuid.New(),
+		UserId:    user.Id,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(loginChallengeTTL),
+	})
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save login challenge: %w", err)
+	}
+
+	return &LoginResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(loginChallengeTTL.Seconds()),
+		Success:           true,
+		Message:           "Two-factor code required, send it with the challenge token to /auth/login/2fa",
+	}, nil
+}
+
+// startSession issues the user's token and records its session
+func startSession(dbContext *persistence.AppDbContext, jwtHandler *auth.JWTHandler, user *entities.User, message string) (*LoginResponse, error) {
+	token, sessionInfo, setupOnly, err := generateLoginToken(jwtHandler, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
@@ -65,31 +111,26 @@ func (h *LoginRequestHandler) Handle(ctx context.Context, command *LoginCommand)
 	}
 
 	// Use GoNtext to add session (like EF Core: context.Sessions.Add(session))
-	_, err = h.dbContext.Sessions.Add(session)
+	_, err = dbContext.Sessions.Add(session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add session: %w", err)
 	}
 	
-	if err := h.dbContext.SaveChanges(); err != nil {
+	if err := dbContext.SaveChanges(); err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
-	userResponse := models.UserResponse{
-		ID:        user.Id,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+	if setupOnly {
+		message = "Two-factor authentication is required for your role, set it up with this token at /auth/2fa/enroll"
 	}
 
 	return &LoginResponse{
-		User:         userResponse,
-		Token:        token,
-		RefreshToken: token,
-		ExpiresIn:    24 * 3600,
-		Success:      true,
-		Message:      "Login successful",
+		User:                   toUserResponse(user),
+		Token:                  token,
+		RefreshToken:           token,
+		ExpiresIn:              int(time.Until(sessionInfo.ExpiresAt).Seconds()),
+		TwoFactorSetupRequired: setupOnly,
+		Success:                true,
+		Message:                message,
 	}, nil
 }
\ No newline at end of file
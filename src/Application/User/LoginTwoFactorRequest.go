@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type LoginTwoFactorCommand struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"omitempty,len=6,numeric"`   // from the authenticator app
+	RecoveryCode   string `json:"recovery_code" validate:"omitempty,max=32"` // in place of a code
+}
+
+type LoginTwoFactorRequestHandler struct {
+	dbContext  *persistence.AppDbContext
+	jwtHandler *auth.JWTHandler
+}
+
+func NewLoginTwoFactorRequestHandler(dbContext *persistence.AppDbContext, jwtHandler *auth.JWTHandler) *LoginTwoFactorRequestHandler {
+	return &LoginTwoFactorRequestHandler{
+		dbContext:  dbContext,
+		jwtHandler: jwtHandler,
+	}
+}
+
+// Handle completes a sign in that passed the password check with a code from the user's
+// authenticator app or a recovery code. Wrong codes count against the sign in's attempts.
+func (h *LoginTwoFactorRequestHandler) Handle(ctx context.Context, command *LoginTwoFactorCommand) (*LoginResponse, error) {
+	db := h.dbContext.GetDB().WithContext(ctx)
+	now := time.Now()
+
+	challenge, err := openLoginChallenge(db, command.ChallengeToken, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.dbContext.Users.Where(&entities.User{Id: challenge.UserId}).FirstOrDefault()
+	if err != nil || user == nil || !user.IsActive || !user.TwoFactorEnabled {
+		db.Delete(&entities.LoginChallenge{}, `"Id" = ?`, challenge.Id)
+		return nil, ErrInvalidLoginChallenge
+	}
+
+	if err := verifySecondFactor(db, user, command.Code, command.RecoveryCode, now); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			db.Model(&entities.LoginChallenge{}).Where(`"Id" = ?`, challenge.Id).Update("Attempts", challenge.Attempts+1)
+		}
+		return nil, err
+	}
+
+	// Spent whether or not another request completed it meanwhile, the code was only good once
+	spent := db.Delete(&entities.LoginChallenge{}, `"Id" = ?`, challenge.Id)
+	if spent.Error != nil {
+		return nil, fmt.Errorf("failed to complete login challenge: %w", spent.Error)
+	}
+	if spent.RowsAffected == 0 {
+		return nil, ErrInvalidLoginChallenge
+	}
+
+	return startSession(h.dbContext, h.jwtHandler, user, "Login successful")
+}
+
+// openLoginChallenge finds the sign in of a challenge token that hasn't expired or run out of
+// attempts
+func openLoginChallenge(db *gorm.DB, token string, now time.Time) (entities.LoginChallenge, error) {
+	var challenge entities.LoginChallenge
+	result := db.Where(`"TokenHash" = ? AND "ExpiresAt" > ? AND "Attempts" < ?`, hashUserToken(token), now, maxChallengeAttempts).
+		Limit(1).Find(&challenge)
+	if result.Error != nil {
+		return challenge, fmt.Errorf("failed to look up login challenge: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return challenge, ErrInvalidLoginChallenge
+	}
+	return challenge, nil
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenCommand struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RefreshTokenResponse struct {
+	User         models.UserResponse `json:"user"`
+	Token        string              `json:"token"`
+	RefreshToken string              `json:"refresh_token"`
+	ExpiresIn    int                 `json:"expires_in"`
+	// TwoFactorSetupRequired is set when the token can only set up two-factor authentication,
+	// which the user's role requires
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+	Success                bool   `json:"success"`
+	Message                string `json:"message"`
+}
+
+type RefreshTokenRequestHandler struct {
+	dbContext  *persistence.AppDbContext
+	jwtHandler *auth.JWTHandler
+}
+
+func NewRefreshTokenRequestHandler(dbContext *persistence.AppDbContext, jwtHandler *auth.JWTHandler) *RefreshTokenRequestHandler {
+	return &RefreshTokenRequestHandler{
+		dbContext:  dbContext,
+		jwtHandler: jwtHandler,
+	}
+}
+
+// Handle exchanges a token that hasn't expired yet for a new one, replacing its session.
+// The user is reloaded so a role change or deactivation applies to the new token.
+func (h *RefreshTokenRequestHandler) Handle(ctx context.Context, command *RefreshTokenCommand) (*RefreshTokenResponse, error) {
+	claims, err := h.jwtHandler.ValidateToken(command.RefreshToken)
+	if err != nil {
+		return nil, apierror.New(apierror.CodeInvalidCredentials, "invalid refresh token")
+	}
+
+	user, err := h.dbContext.Users.Where(&entities.User{Id: claims.UserID}).FirstOrDefault()
+	if err != nil || user == nil || !user.IsActive {
+		return nil, apierror.New(apierror.CodeInvalidCredentials, "invalid refresh token")
+	}
+
+	// A token for setting up two-factor authentication doesn't become a full one by refreshing,
+	// once two-factor is set up the user signs in with a code
+	if claims.TwoFactorSetup && user.TwoFactorEnabled {
+		return nil, apierror.New(apierror.CodeUnauthorized, "two-factor authentication is set up, sign in again")
+	}
+
+	token, sessionInfo, setupOnly, err := generateLoginToken(h.jwtHandler, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	oldSession, err := h.dbContext.Sessions.Where(&entities.Session{
+		UserId:    user.Id,
+		TokenHash: h.jwtHandler.GetTokenHash(command.RefreshToken),
+	}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+	if oldSession != nil {
+		h.dbContext.Sessions.Remove(*oldSession)
+	}
+
+	h.dbContext.Sessions.Add(entities.Session{
+		Id:        uuid.Nil,
+		UserId:    sessionInfo.UserID,
+		TokenHash: sessionInfo.TokenHash,
+		ExpiresAt: sessionInfo.ExpiresAt,
+		IsActive:  true,
+	})
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &RefreshTokenResponse{
+		User:                   toUserResponse(user),
+		Token:                  token,
+		RefreshToken:           token,
+		ExpiresIn:              int(time.Until(sessionInfo.ExpiresAt).Seconds()),
+		TwoFactorSetupRequired: setupOnly,
+		Success:                true,
+		Message:                "Token refreshed successfully",
+	}, nil
+}
@@ -0,0 +1,63 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type RegenerateRecoveryCodesCommand struct {
+	UserID uuid.UUID `json:"-"`
+	Code   string    `json:"code" validate:"required,len=6,numeric"`
+}
+
+type RegenerateRecoveryCodesResponse struct {
+	// RecoveryCodes replace every earlier code. They are only returned here.
+	RecoveryCodes []string `json:"recovery_codes"`
+	Success       bool     `json:"success"`
+	Message       string   `json:"message"`
+}
+
+type RegenerateRecoveryCodesRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewRegenerateRecoveryCodesRequestHandler(dbContext *persistence.AppDbContext) *RegenerateRecoveryCodesRequestHandler {
+	return &RegenerateRecoveryCodesRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle replaces the user's recovery codes, with a code from their authenticator app
+func (h *RegenerateRecoveryCodesRequestHandler) Handle(ctx context.Context, command *RegenerateRecoveryCodesCommand) (*RegenerateRecoveryCodesResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.UserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	var codes []string
+	err = h.dbContext.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, command.Code, "", time.Now()); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegenerateRecoveryCodesResponse{
+		RecoveryCodes: codes,
+		Success:       true,
+		Message:       "Recovery codes replaced, the earlier ones no longer work",
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	
 	"golang.org/x/crypto/bcrypt"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
@@ -38,7 +39,7 @@ func (h *RegisterRequestHandler) Handle(ctx context.Context, command *RegisterCo
 	existingUserByEmail, _ := h.dbContext.Users.Where(&entities.User{Email: command.Email}).FirstOrDefault()
 	existingUserByUsername, _ := h.dbContext.Users.Where(&entities.User{Username: command.Username}).FirstOrDefault()
 	if existingUserByEmail != nil || existingUserByUsername != nil {
-		return nil, fmt.Errorf("user with this email or username already exists")
+		return nil, apierror.New(apierror.CodeAlreadyExists, "user with this email or username already exists")
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(command.Password), bcrypt.DefaultCost)
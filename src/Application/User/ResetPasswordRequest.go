@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type ResetPasswordCommand struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+type ResetPasswordResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type ResetPasswordRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewResetPasswordRequestHandler(dbContext *persistence.AppDbContext) *ResetPasswordRequestHandler {
+	return &ResetPasswordRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle sets a new password with an emailed reset token. The token is spent, with every other
+// reset token of the user, and the user's sessions end.
+func (h *ResetPasswordRequestHandler) Handle(ctx context.Context, command *ResetPasswordCommand) (*ResetPasswordResponse, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(command.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	if err := resetPassword(h.dbContext.GetDB().WithContext(ctx), command.Token, string(hashedPassword), time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &ResetPasswordResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	}, nil
+}
+
+// resetPassword sets the password of the user of the reset token, spending the user's reset tokens
+// and ending their sessions
+func resetPassword(db *gorm.DB, token, passwordHash string, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var reset entities.PasswordResetToken
+		result := tx.Where(`"TokenHash" = ? AND "UsedAt" IS NULL AND "ExpiresAt" > ?`, hashUserToken(token), now).
+			Limit(1).Find(&reset)
+		if result.Error != nil {
+			return fmt.Errorf("failed to look up password reset: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+
+		spent := tx.Model(&entities.PasswordResetToken{}).Where(`"UserId" = ? AND "UsedAt" IS NULL`, reset.UserId).Update("UsedAt", now)
+		if spent.Error != nil {
+			return fmt.Errorf("failed to spend password reset: %w", spent.Error)
+		}
+
+		updated := tx.Model(&entities.User{}).Where(`"Id" = ? AND "IsActive" = ?`, reset.UserId, true).Update("PasswordHash", passwordHash)
+		if updated.Error != nil {
+			return fmt.Errorf("failed to update password: %w", updated.Error)
+		}
+		if updated.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+
+		if err := tx.Delete(&entities.Session{}, `"UserId" = ?`, reset.UserId).Error; err != nil {
+			return fmt.Errorf("failed to end sessions: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type ResetTwoFactorCommand struct {
+	TargetUserID uuid.UUID `json:"-"`
+	UserID       uuid.UUID `json:"-"` // the admin resetting it
+}
+
+type ResetTwoFactorResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type ResetTwoFactorRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewResetTwoFactorRequestHandler(dbContext *persistence.AppDbContext) *ResetTwoFactorRequestHandler {
+	return &ResetTwoFactorRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle turns off two-factor authentication of a user who lost their authenticator app and
+// recovery codes, and ends their sessions. Users whose role requires it set it up again on their
+// next sign in.
+func (h *ResetTwoFactorRequestHandler) Handle(ctx context.Context, command *ResetTwoFactorCommand) (*ResetTwoFactorResponse, error) {
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.TargetUserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+	if !user.TwoFactorEnabled && user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	err = h.dbContext.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := disableTwoFactor(tx, user.Id); err != nil {
+			return err
+		}
+		if err := tx.Delete(&entities.Session{}, `"UserId" = ?`, user.Id).Error; err != nil {
+			return fmt.Errorf("failed to end sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Audit: admin %s reset two-factor authentication of user %s", command.UserID, user.Id)
+
+	return &ResetTwoFactorResponse{
+		Success: true,
+		Message: "Two-factor authentication reset",
+	}, nil
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type SetUserRoleCommand struct {
+	TargetUserID uuid.UUID `json:"-"`
+	UserID       uuid.UUID `json:"-"`                               // the admin changing it
+	Role         string    `json:"role" validate:"required,max=50"` // a built-in or custom role
+}
+
+type SetUserRoleResponse struct {
+	User    models.UserResponse `json:"user"`
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+}
+
+type SetUserRoleRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewSetUserRoleRequestHandler(dbContext *persistence.AppDbContext) *SetUserRoleRequestHandler {
+	return &SetUserRoleRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle gives a user a built-in or custom role and ends their sessions. Tokens issued before carry
+// the old role until they expire or are refreshed. Admins can't change their own role, so one always remains.
+func (h *SetUserRoleRequestHandler) Handle(ctx context.Context, command *SetUserRoleCommand) (*SetUserRoleResponse, error) {
+	if command.TargetUserID == command.UserID {
+		return nil, apierror.New(apierror.CodeForbidden, "you can't change your own role")
+	}
+	if err := auth.ValidateRole(h.dbContext, command.Role); err != nil {
+		return nil, err
+	}
+	user, err := h.dbContext.Users.Where(&entities.User{Id: command.TargetUserID}).FirstOrDefault()
+	if err != nil || user == nil {
+		return nil, apierror.New(apierror.CodeUserNotFound, "user not found")
+	}
+
+	previous := user.Role
+	if err := setUserRole(h.dbContext.GetDB().WithContext(ctx), user.Id, command.Role); err != nil {
+		return nil, err
+	}
+	user.Role = command.Role
+	log.Printf("Audit: admin %s changed the role of user %s from %s to %s", command.UserID, user.Id, previous, command.Role)
+
+	return &SetUserRoleResponse{
+		User:    toUserResponse(user),
+		Success: true,
+		Message: "Role updated successfully",
+	}, nil
+}
+
+// setUserRole changes the user's role and ends their sessions
+func setUserRole(db *gorm.DB, userID uuid.UUID, role string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.User{}).Where(`"Id" = ?`, userID).Update("Role", role).Error; err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		if err := tx.Delete(&entities.Session{}, `"UserId" = ?`, userID).Error; err != nil {
+			return fmt.Errorf("failed to end sessions: %w", err)
+		}
+		return nil
+	})
+}
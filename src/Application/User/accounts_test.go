@@ -0,0 +1,121 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestAcceptInvitation creates the invited account once, with the invitation's email and role
+func TestAcceptInvitation(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	token, tokenHash, err := generateUserToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation := entities.UserInvitation{Email: "ada@example.com", Role: "admin", TokenHash: tokenHash, ExpiresAt: now.Add(time.Hour), InvitedBy: uuid.New()}
+	if err := db.Create(&invitation).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := acceptInvitation(db, token, "ada", "hash", now)
+	if err != nil {
+		t.Fatalf("acceptInvitation() = %v", err)
+	}
+	if user.Email != "ada@example.com" || user.Role != "admin" || user.Username != "ada" {
+		t.Errorf("acceptInvitation() = %+v, want ada with the invited email and role", user)
+	}
+	var stored entities.UserInvitation
+	if err := db.First(&stored, `"Id" = ?`, invitation.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.AcceptedAt == nil || stored.UserId == nil || *stored.UserId != user.Id {
+		t.Errorf("invitation after acceptInvitation() = %+v, want it accepted by the new user", stored)
+	}
+
+	if _, err := acceptInvitation(db, token, "ada2", "hash", now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("acceptInvitation() of a spent invitation = %v, want ErrInvalidToken", err)
+	}
+}
+
+// TestResetPassword sets the password, spends the user's reset tokens and ends their sessions
+func TestResetPassword(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	user := entities.User{Username: "ada", Email: "ada@example.com", PasswordHash: "old", Role: "user", IsActive: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, tokenHash, err := generateUserToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hash := range []string{tokenHash, "other"} {
+		reset := entities.PasswordResetToken{UserId: user.Id, TokenHash: hash, ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+		if err := db.Create(&reset).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	session := entities.Session{UserId: user.Id, TokenHash: "session", ExpiresAt: now.Add(time.Hour)}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if recent, err := resetsSince(db, user.Id, now.Add(-time.Minute)); err != nil || recent != 2 {
+		t.Errorf("resetsSince() = %d, %v, want 2", recent, err)
+	}
+	if err := resetPassword(db, token, "new", now); err != nil {
+		t.Fatalf("resetPassword() = %v", err)
+	}
+
+	var stored entities.User
+	if err := db.First(&stored, `"Id" = ?`, user.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	var unused, sessions int64
+	db.Model(&entities.PasswordResetToken{}).Where(`"UsedAt" IS NULL`).Count(&unused)
+	db.Model(&entities.Session{}).Count(&sessions)
+	if stored.PasswordHash != "new" || unused != 0 || sessions != 0 {
+		t.Errorf("resetPassword() left password %q, %d unused reset(s) and %d session(s), want the new password and none", stored.PasswordHash, unused, sessions)
+	}
+	if err := resetPassword(db, token, "again", now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("resetPassword() with a spent token = %v, want ErrInvalidToken", err)
+	}
+}
+
+// TestSetUserRole changes the user's role and ends their sessions only
+func TestSetUserRole(t *testing.T) {
+	db := sqlitetest.Open(t)
+	var users [2]entities.User
+	for i, name := range []string{"ada", "grace"} {
+		users[i] = entities.User{Username: name, Email: name + "@example.com", PasswordHash: "hash", Role: "user", IsActive: true}
+		if err := db.Create(&users[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		session := entities.Session{UserId: users[i].Id, TokenHash: name, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := db.Create(&session).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := setUserRole(db, users[0].Id, "admin"); err != nil {
+		t.Fatalf("setUserRole() = %v", err)
+	}
+	var stored entities.User
+	if err := db.First(&stored, `"Id" = ?`, users[0].Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	var sessions []entities.Session
+	if err := db.Find(&sessions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != "admin" || len(sessions) != 1 || sessions[0].UserId != users[1].Id {
+		t.Errorf("setUserRole() left role %q and sessions %+v, want admin and only the other user's session", stored.Role, sessions)
+	}
+}
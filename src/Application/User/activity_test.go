@@ -0,0 +1,46 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRecentActivity shows feed events of the user's buckets and of the user elsewhere, newest first
+func TestRecentActivity(t *testing.T) {
+	db := sqlitetest.Open(t)
+	userID, bucketID, elsewhere := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	for i, event := range []entities.BucketEvent{
+		{BucketId: bucketID, Type: events.FileUploaded, ActorId: uuid.New()},
+		{BucketId: elsewhere, Type: events.CommentCreated, ActorId: userID},
+		{BucketId: elsewhere, Type: events.FileUploaded, ActorId: uuid.New()},
+		{BucketId: bucketID, Type: events.FileDeleted, ActorId: userID},
+	} {
+		event.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	activity, err := recentActivity(db, userID, []uuid.UUID{bucketID}, 10)
+	if err != nil {
+		t.Fatalf("recentActivity() = %v", err)
+	}
+	if len(activity) != 2 || activity[0].Type != events.CommentCreated || activity[1].Type != events.FileUploaded {
+		t.Errorf("recentActivity() = %+v, want the comment elsewhere then the upload to the user's bucket", activity)
+	}
+
+	activity, err = recentActivity(db, userID, nil, 10)
+	if err != nil {
+		t.Fatalf("recentActivity() without buckets = %v", err)
+	}
+	if len(activity) != 1 || activity[0].ActorId != userID {
+		t.Errorf("recentActivity() without buckets = %+v, want the user's comment", activity)
+	}
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Models"
+)
+
+const (
+	// loginChallengeTTL is how long a sign in waits for the second factor
+	loginChallengeTTL = 5 * time.Minute
+	// maxChallengeAttempts is how many wrong codes a sign in allows before it must start over
+	maxChallengeAttempts = 5
+	// recoveryCodeCount is how many recovery codes a user gets at a time
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidTwoFactorCode    = apierror.New(apierror.CodeInvalidCredentials, "invalid two-factor code")
+	ErrTwoFactorEnabled        = apierror.New(apierror.CodeConflict, "two-factor authentication is already enabled, disable it first")
+	ErrTwoFactorNotEnabled     = apierror.New(apierror.CodeConflict, "two-factor authentication isn't enabled")
+	ErrTwoFactorNotEnrolled    = apierror.New(apierror.CodeConflict, "no two-factor enrollment to confirm, enroll first")
+	ErrTwoFactorRequiredByRole = apierror.New(apierror.CodeForbidden, "two-factor authentication is required for your role and can't be disabled")
+	ErrInvalidLoginChallenge   = apierror.New(apierror.CodeInvalidCredentials, "the sign in has expired or had too many attempts, sign in again")
+)
+
+// generateLoginToken issues the user's token. Users whose role requires two-factor authentication
+// and who haven't set it up get a token that can only set it up.
+func generateLoginToken(jwtHandler *auth.JWTHandler, user *entities.User) (string, *auth.SessionInfo, bool, error) {
+	if !user.TwoFactorEnabled && auth.TwoFactorRequired(user.Role) {
+		token, sessionInfo, err := jwtHandler.GenerateTwoFactorSetupToken(user.Id, user.Username, user.Email, user.Role)
+		return token, sessionInfo, true, err
+	}
+	token, sessionInfo, err := jwtHandler.GenerateToken(user.Id, user.Username, user.Email, user.Role)
+	return token, sessionInfo, false, err
+}
+
+// verifySecondFactor checks an authenticator code or spends a recovery code of the user. An
+// authenticator code is accepted once, a second use fails as a wrong code.
+func verifySecondFactor(db *gorm.DB, user *entities.User, code, recoveryCode string, now time.Time) error {
+	if code != "" {
+		step, ok := auth.VerifyTOTP(user.TOTPSecret, code, now, user.TOTPLastStep)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		// Guarded on the last step, so of two concurrent uses of a code only one succeeds
+		used := db.Model(&entities.User{}).Where(`"Id" = ? AND "totp_last_step" < ?`, user.Id, step).Update("totp_last_step", step)
+		if used.Error != nil {
+			return fmt.Errorf("failed to record two-factor code: %w", used.Error)
+		}
+		if used.RowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	if recoveryCode == "" {
+		return ErrInvalidTwoFactorCode
+	}
+	spent := db.Model(&entities.UserRecoveryCode{}).
+		Where(`"UserId" = ? AND "CodeHash" = ? AND "UsedAt" IS NULL`, user.Id, hashRecoveryCode(recoveryCode)).
+		Update("UsedAt", now)
+	if spent.Error != nil {
+		return fmt.Errorf("failed to use recovery code: %w", spent.Error)
+	}
+	if spent.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes gives the user a new set of recovery codes, the earlier ones stop working
+func replaceRecoveryCodes(db *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := db.Delete(&entities.UserRecoveryCode{}, `"UserId" = ?`, userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove recovery codes: %w", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]entities.UserRecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		encoded := hex.EncodeToString(bytes)
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+		rows[i] = entities.UserRecoveryCode{Id: uuid.New(), UserId: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code as typed, without dashes, spaces or case
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	return hashUserToken(normalized)
+}
+
+func toUserResponse(user *entities.User) models.UserResponse {
+	return models.UserResponse{
+		ID:               user.Id,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             user.Role,
+		IsActive:         user.IsActive,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
+}
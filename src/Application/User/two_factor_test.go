@@ -0,0 +1,74 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRecoveryCodes spends a recovery code once and disables two-factor with the rest
+func TestRecoveryCodes(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	user := entities.User{Username: "ada", Email: "ada@example.com", PasswordHash: "hash", Role: "user", IsActive: true, TwoFactorEnabled: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	codes, err := replaceRecoveryCodes(db, user.Id)
+	if err != nil {
+		t.Fatalf("replaceRecoveryCodes() = %v", err)
+	}
+
+	if err := verifySecondFactor(db, &user, "", codes[0], now); err != nil {
+		t.Fatalf("verifySecondFactor() with a recovery code = %v", err)
+	}
+	if err := verifySecondFactor(db, &user, "", codes[0], now); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("verifySecondFactor() with a spent recovery code = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if left, err := unusedRecoveryCodes(db, user.Id); err != nil || left != recoveryCodeCount-1 {
+		t.Errorf("unusedRecoveryCodes() = %d, %v, want %d", left, err, recoveryCodeCount-1)
+	}
+
+	if err := disableTwoFactor(db, user.Id); err != nil {
+		t.Fatalf("disableTwoFactor() = %v", err)
+	}
+	var stored entities.User
+	if err := db.First(&stored, `"Id" = ?`, user.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left, err := unusedRecoveryCodes(db, user.Id); err != nil || left != 0 || stored.TwoFactorEnabled {
+		t.Errorf("after disableTwoFactor() enabled = %v with %d recovery code(s), want disabled without codes", stored.TwoFactorEnabled, left)
+	}
+}
+
+// TestOpenLoginChallenge finds a sign in by its token until it expires or runs out of attempts
+func TestOpenLoginChallenge(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	user := entities.User{Username: "ada", Email: "ada@example.com", PasswordHash: "hash", Role: "user", IsActive: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, tokenHash, err := generateUserToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := entities.LoginChallenge{UserId: user.Id, TokenHash: tokenHash, ExpiresAt: now.Add(loginChallengeTTL)}
+	if err := db.Create(&challenge).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := openLoginChallenge(db, token, now); err != nil || found.Id != challenge.Id {
+		t.Errorf("openLoginChallenge() = %v, %v, want the challenge", found.Id, err)
+	}
+	if _, err := openLoginChallenge(db, token, now.Add(2*loginChallengeTTL)); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Errorf("openLoginChallenge() after it expired = %v, want ErrInvalidLoginChallenge", err)
+	}
+	db.Model(&entities.LoginChallenge{}).Where(`"Id" = ?`, challenge.Id).Update("Attempts", maxChallengeAttempts)
+	if _, err := openLoginChallenge(db, token, now); !errors.Is(err, ErrInvalidLoginChallenge) {
+		t.Errorf("openLoginChallenge() out of attempts = %v, want ErrInvalidLoginChallenge", err)
+	}
+}
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// ErrInvalidToken is returned for invitation and password reset tokens that don't exist, expired
+// or were used
+var ErrInvalidToken = apierror.New(apierror.CodeInvalidRequest, "the link is invalid or has expired")
+
+// hashUserToken returns the hash invitation and password reset tokens are stored and looked up by
+func hashUserToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// generateUserToken returns a new token for an emailed link, with the hash stored for it
+func generateUserToken() (token, tokenHash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+
+	token = hex.EncodeToString(bytes)
+	return token, hashUserToken(token), nil
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type CreateWebhookCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+	URL      string    `json:"url" validate:"required,url,max=2048"`
+	// EventTypes limits the webhook to these event types, it receives every event when empty
+	EventTypes []string `json:"event_types,omitempty" validate:"omitempty,dive,required,max=100"`
+}
+
+type CreateWebhookResponse struct {
+	Webhook models.WebhookResponse `json:"webhook"`
+	// Secret signs the webhook's deliveries. It is only returned here, it can't be retrieved again.
+	Secret  string `json:"secret"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type CreateWebhookRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewCreateWebhookRequestHandler(dbContext *persistence.AppDbContext) *CreateWebhookRequestHandler {
+	return &CreateWebhookRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle adds a webhook to a bucket with a signing secret of its own. Events published from then on
+// are delivered to it.
+func (h *CreateWebhookRequestHandler) Handle(ctx context.Context, command *CreateWebhookCommand) (*CreateWebhookResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, prefix, err := generateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	var eventTypes datatypes.JSON
+	if len(command.EventTypes) > 0 {
+		data, err := json.Marshal(command.EventTypes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event types: %w", err)
+		}
+		eventTypes = datatypes.JSON(data)
+	}
+
+	now := time.Now()
+	webhook := &entities.BucketWebhook{
+		Id:           uuid.New(),
+		BucketId:     bucket.Id,
+		URL:          command.URL,
+		Secret:       secret,
+		SecretPrefix: prefix,
+		EventTypes:   eventTypes,
+		CreatedBy:    command.UserID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	h.dbContext.BucketWebhooks.Add(*webhook)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return &CreateWebhookResponse{
+		Webhook: ToWebhookResponse(webhook),
+		Secret:  secret,
+		Success: true,
+		Message: "Webhook created successfully",
+	}, nil
+}
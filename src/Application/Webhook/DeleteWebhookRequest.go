@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+type DeleteWebhookCommand struct {
+	BucketID  uuid.UUID `json:"-"`
+	WebhookID uuid.UUID `json:"-"`
+	UserID    uuid.UUID `json:"-"`
+	UserRole  string    `json:"-"`
+}
+
+type DeleteWebhookResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type DeleteWebhookRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewDeleteWebhookRequestHandler(dbContext *persistence.AppDbContext) *DeleteWebhookRequestHandler {
+	return &DeleteWebhookRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle removes a webhook. Deliveries still queued for it are dropped when their turn comes.
+func (h *DeleteWebhookRequestHandler) Handle(ctx context.Context, command *DeleteWebhookCommand) (*DeleteWebhookResponse, error) {
+	if _, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole); err != nil {
+		return nil, err
+	}
+	webhook, err := findWebhook(h.dbContext, command.BucketID, command.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	h.dbContext.BucketWebhooks.Remove(*webhook)
+	if err := h.dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return &DeleteWebhookResponse{
+		Success: true,
+		Message: "Webhook deleted successfully",
+	}, nil
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+type ListWebhooksCommand struct {
+	BucketID uuid.UUID `json:"-"`
+	UserID   uuid.UUID `json:"-"`
+	UserRole string    `json:"-"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []models.WebhookResponse `json:"webhooks"`
+	Success  bool                     `json:"success"`
+	Message  string                   `json:"message"`
+}
+
+type ListWebhooksRequestHandler struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewListWebhooksRequestHandler(dbContext *persistence.AppDbContext) *ListWebhooksRequestHandler {
+	return &ListWebhooksRequestHandler{
+		dbContext: dbContext,
+	}
+}
+
+// Handle lists a bucket's webhooks, oldest first
+func (h *ListWebhooksRequestHandler) Handle(ctx context.Context, command *ListWebhooksCommand) (*ListWebhooksResponse, error) {
+	bucket, err := managedBucket(h.dbContext, command.BucketID, command.UserID, command.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := bucketWebhooks(h.dbContext.GetDB().WithContext(ctx), bucket.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	responses := make([]models.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		responses = append(responses, ToWebhookResponse(&webhooks[i]))
+	}
+
+	return &ListWebhooksResponse{
+		Webhooks: responses,
+		Success:  true,
+		Message:  "Webhooks retrieved successfully",
+	}, nil
+}
+
+// bucketWebhooks returns the webhooks of a bucket, oldest first
+func bucketWebhooks(db *gorm.DB, bucketID uuid.UUID) ([]entities.BucketWebhook, error) {
+	var webhooks []entities.BucketWebhook
+	err := db.Where(&entities.BucketWebhook{BucketId: bucketID}).Order(`"CreatedAt"`).Find(&webhooks).Error
+	return webhooks, err
+}
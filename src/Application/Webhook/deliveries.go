@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Deliverer sends the webhook deliveries queued when events are published
+type Deliverer struct {
+	dbContext  *persistence.AppDbContext
+	httpClient *http.Client
+}
+
+func NewDeliverer(dbContext *persistence.AppDbContext) *Deliverer {
+	return &Deliverer{
+		dbContext:  dbContext,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// RunDeliveryJob delivers one event to one webhook, it is registered with the job runner. The
+// delivery is acknowledged once the receiver answers with a 2xx status, anything else is retried
+// with backoff until the job runs out of attempts.
+func (d *Deliverer) RunDeliveryJob(ctx context.Context, run *jobs.Run) error {
+	var payload events.DeliveryPayload
+	if err := run.Decode(&payload); err != nil {
+		return err
+	}
+
+	webhook, err := d.dbContext.BucketWebhooks.Where(&entities.BucketWebhook{Id: payload.WebhookID}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to load webhook: %w", err)
+	}
+	if webhook == nil {
+		return jobs.Permanent(fmt.Errorf("webhook was deleted"))
+	}
+	event, err := d.dbContext.BucketEvents.Where(&entities.BucketEvent{Id: payload.EventID}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to load event: %w", err)
+	}
+	if event == nil {
+		return jobs.Permanent(fmt.Errorf("event no longer exists"))
+	}
+
+	req, err := events.NewDeliveryRequest(ctx, webhook.URL, webhook.Secret, event, false)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver answered with status %d", resp.StatusCode)
+	}
+
+	run.SetResult(map[string]interface{}{
+		"webhook_id": webhook.Id,
+		"event_id":   event.Id,
+		"status":     resp.StatusCode,
+	})
+	return nil
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Access"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// SecretPrefix starts every webhook signing secret
+const SecretPrefix = "whsec_"
+
+var (
+	// ErrWebhookNotFound is returned for webhooks that don't exist or belong to another bucket
+	ErrWebhookNotFound = apierror.New(apierror.CodeNotFound, "webhook not found")
+	// ErrBucketNotFound is returned when the bucket doesn't exist
+	ErrBucketNotFound = apierror.New(apierror.CodeBucketNotFound, "bucket not found")
+	// ErrForbidden is returned to users who can't manage the bucket's webhooks
+	ErrForbidden = apierror.New(apierror.CodeForbidden, "unauthorized: only the bucket owner or a bucket admin can manage its webhooks")
+)
+
+// generateSecret returns a new signing secret and the prefix shown for it
+func generateSecret() (secret, prefix string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+	secret = SecretPrefix + hex.EncodeToString(bytes)
+	return secret, secret[:12], nil
+}
+
+// managedBucket loads a bucket whose webhooks the user may manage
+func managedBucket(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID, userRole string) (*entities.Bucket, error) {
+	bucket, err := dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return nil, ErrBucketNotFound
+	}
+	if !access.CanManageBucket(dbContext, bucket, userID, userRole) {
+		return nil, ErrForbidden
+	}
+	return bucket, nil
+}
+
+// findWebhook loads one of a bucket's webhooks
+func findWebhook(dbContext *persistence.AppDbContext, bucketID, webhookID uuid.UUID) (*entities.BucketWebhook, error) {
+	webhook, err := dbContext.BucketWebhooks.Where(&entities.BucketWebhook{Id: webhookID}).FirstOrDefault()
+	if err != nil || webhook == nil || webhook.BucketId != bucketID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func ToWebhookResponse(webhook *entities.BucketWebhook) models.WebhookResponse {
+	types := []string{}
+	if len(webhook.EventTypes) > 0 {
+		json.Unmarshal(webhook.EventTypes, &types)
+	}
+	return models.WebhookResponse{
+		ID:           webhook.Id,
+		BucketID:     webhook.BucketId,
+		URL:          webhook.URL,
+		SecretPrefix: webhook.SecretPrefix,
+		EventTypes:   types,
+		CreatedBy:    webhook.CreatedBy,
+		CreatedAt:    webhook.CreatedAt,
+		UpdatedAt:    webhook.UpdatedAt,
+	}
+}
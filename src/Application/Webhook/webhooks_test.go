@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestBucketWebhooks lists a bucket's webhooks, oldest first
+func TestBucketWebhooks(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	other := sqlitetest.CreateBucket(t, db, "videos")
+	now := time.Now()
+	for _, webhook := range []entities.BucketWebhook{
+		{BucketId: bucket.Id, URL: "https://example.com/new", CreatedAt: now},
+		{BucketId: bucket.Id, URL: "https://example.com/old", CreatedAt: now.Add(-time.Hour)},
+		{BucketId: other.Id, URL: "https://example.com/other", CreatedAt: now.Add(-2 * time.Hour)},
+	} {
+		webhook.Secret, webhook.SecretPrefix = "secret", "whsec_"
+		if err := db.Create(&webhook).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	webhooks, err := bucketWebhooks(db, bucket.Id)
+	if err != nil {
+		t.Fatalf("bucketWebhooks() = %v", err)
+	}
+	if len(webhooks) != 2 || webhooks[0].URL != "https://example.com/old" || webhooks[1].URL != "https://example.com/new" {
+		t.Errorf("bucketWebhooks() = %+v, want the bucket's two webhooks, oldest first", webhooks)
+	}
+}
+
+// TestSaveSecret replaces the webhook's secret and its prefix
+func TestSaveSecret(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	webhook := entities.BucketWebhook{BucketId: bucket.Id, URL: "https://example.com/hook", Secret: "old", SecretPrefix: "whsec_old"}
+	if err := db.Create(&webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveSecret(db, &webhook, "new", "whsec_new"); err != nil {
+		t.Fatalf("saveSecret() = %v", err)
+	}
+	var stored entities.BucketWebhook
+	if err := db.First(&stored, `"Id" = ?`, webhook.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Secret != "new" || stored.SecretPrefix != "whsec_new" {
+		t.Errorf("webhook after saveSecret() = %q, %q, want new, whsec_new", stored.Secret, stored.SecretPrefix)
+	}
+}
@@ -1,17 +1,17 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"time"
 	
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	
 	apikey "shbucket/src/Application/APIKey"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Mediator"
 )
@@ -39,33 +39,26 @@ func NewAPIKeyController(mediator *mediator.Mediator, validator *validator.Valid
 //	@Security		ApiKeyAuth
 //	@Param			request	body		object							true	"API key creation request"
 //	@Success		201		{object}	apikey.CreateAPIKeyResponse		"API key created successfully"
-//	@Failure		400		{object}	map[string]string				"Bad request"
-//	@Failure		401		{object}	map[string]string				"Unauthorized"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
 //	@Router			/api-keys [post]
 func (ctrl *APIKeyController) CreateAPIKey(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
 	var request struct {
 		Name        string                      `json:"name" validate:"required,min=3,max=100"`
 		Permissions entities.APIKeyPermission  `json:"permissions"`
+		Role        string                      `json:"role,omitempty" validate:"omitempty,max=50"` // limits the key to a built-in or custom role
+		EgressQuota      int64                  `json:"egress_quota" validate:"min=0"`       // bytes downloaded with the key per billing cycle
+		EgressDailyQuota int64                  `json:"egress_daily_quota" validate:"min=0"` // bytes downloaded with the key per day
 		ExpiresIn   *int                        `json:"expires_in,omitempty"` // Seconds from now
 	}
 	
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&request); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &request); err != nil {
+		return err
 	}
 	
 	// Calculate expiration time
@@ -79,14 +72,15 @@ func (ctrl *APIKeyController) CreateAPIKey(c *fiber.Ctx) error {
 		Name:        request.Name,
 		UserID:      userContext.UserID,
 		Permissions: request.Permissions,
+		Role:        request.Role,
+		EgressQuota:      request.EgressQuota,
+		EgressDailyQuota: request.EgressDailyQuota,
 		ExpiresAt:   expiresAt,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	createResponse := response.(*apikey.CreateAPIKeyResponse)
@@ -103,15 +97,13 @@ func (ctrl *APIKeyController) CreateAPIKey(c *fiber.Ctx) error {
 //	@Param			page	query		int						false	"Page number (default: 1)"
 //	@Param			limit	query		int						false	"Items per page (default: 20)"
 //	@Success		200	{object}	apikey.ListAPIKeysResponse	"List of API keys"
-//	@Failure		400	{object}	map[string]string			"Bad request"
-//	@Failure		401	{object}	map[string]string			"Unauthorized"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
 //	@Router			/api-keys [get]
 func (ctrl *APIKeyController) ListAPIKeys(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -123,11 +115,9 @@ func (ctrl *APIKeyController) ListAPIKeys(c *fiber.Ctx) error {
 		Limit:  limit,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	listResponse := response.(*apikey.ListAPIKeysResponse)
@@ -143,37 +133,96 @@ func (ctrl *APIKeyController) ListAPIKeys(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			id	path		string						true	"API Key ID"
 //	@Success		200	{object}	apikey.DeleteAPIKeyResponse	"API key deleted successfully"
-//	@Failure		400	{object}	map[string]string			"Bad request"
-//	@Failure		401	{object}	map[string]string			"Unauthorized"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
 //	@Router			/api-keys/{id} [delete]
 func (ctrl *APIKeyController) DeleteAPIKey(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
-	keyIDParam := c.Params("id")
-	keyID, err := uuid.Parse(keyIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid API key ID",
-		})
-	}
+	keyID := binding.UUID(c, "id")
 	
 	command := &apikey.DeleteAPIKeyCommand{
 		ID:     keyID,
 		UserID: userContext.UserID,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	deleteResponse := response.(*apikey.DeleteAPIKeyResponse)
 	return c.JSON(deleteResponse)
-}
\ No newline at end of file
+}
+//	@Summary		List bucket API keys
+//	@Description	List the API keys scoped to a bucket, whichever user they belong to (bucket owner and bucket admins)
+//	@Tags			api-keys
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string								true	"Bucket ID"
+//	@Success		200	{object}	apikey.ListBucketAPIKeysResponse	"API keys scoped to the bucket"
+//	@Failure		400	{object}	apierror.Error						"Bad request"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Router			/buckets/{id}/api-keys [get]
+func (ctrl *APIKeyController) ListBucketAPIKeys(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := &apikey.ListBucketAPIKeysCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*apikey.ListBucketAPIKeysResponse))
+}
+
+//	@Summary		Revoke bucket API key
+//	@Description	Take a bucket out of an API key's scope (bucket owner and bucket admins). The key keeps its other buckets and is deactivated when this was its only one
+//	@Tags			api-keys
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"Bucket ID"
+//	@Param			keyId	path		string								true	"API key ID"
+//	@Success		200		{object}	apikey.RevokeBucketAPIKeyResponse	"API key revoked for the bucket"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Router			/buckets/{id}/api-keys/{keyId} [delete]
+func (ctrl *APIKeyController) RevokeBucketAPIKey(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	keyID := binding.UUID(c, "keyId")
+
+	command := &apikey.RevokeBucketAPIKeyCommand{
+		BucketID: bucketID,
+		KeyID:    keyID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*apikey.RevokeBucketAPIKeyResponse))
+}
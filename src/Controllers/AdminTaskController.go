@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/AdminTask"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type AdminTaskController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewAdminTaskController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *AdminTaskController {
+	return &AdminTaskController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		List admin tasks
+//	@Description	List the maintenance tasks that can be run and the most recent runs, with who ran them and their results (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	admintask.ListAdminTasksResponse	"Task catalog and recent runs"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		403	{object}	apierror.Error						"Forbidden"
+//	@Router			/admin/tasks [get]
+func (ctrl *AdminTaskController) ListTasks(c *fiber.Ctx) error {
+	command := admintask.ListAdminTasksCommand{}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	tasksResponse := response.(*admintask.ListAdminTasksResponse)
+	return c.JSON(tasksResponse)
+}
+
+//	@Summary		Run admin task
+//	@Description	Queue a maintenance task from the task catalog as a background job and return the job, whose status is polled at /jobs/{id}. A task can't be queued while it is already queued or running (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		admintask.RunAdminTaskCommand	true	"Task to run"
+//	@Success		202		{object}	admintask.RunAdminTaskResponse	"Task queued"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Failure		403		{object}	apierror.Error					"Forbidden"
+//	@Failure		409		{object}	apierror.Error					"Task already queued or running"
+//	@Router			/admin/tasks [post]
+func (ctrl *AdminTaskController) RunTask(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command admintask.RunAdminTaskCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	taskResponse := response.(*admintask.RunAdminTaskResponse)
+	return c.Status(http.StatusAccepted).JSON(taskResponse)
+}
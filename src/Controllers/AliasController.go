@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/Alias"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type AliasController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewAliasController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *AliasController {
+	return &AliasController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		List bucket aliases
+//	@Description	List a bucket's aliases, the names pointing at its files, with the URL each is served at
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string						true	"Bucket ID"
+//	@Param			file_id	query		string						false	"Only aliases pointing at this file"
+//	@Success		200		{object}	alias.ListAliasesResponse	"Aliases"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Failure		404		{object}	apierror.Error				"Bucket not found"
+//	@Router			/buckets/{id}/aliases [get]
+func (ctrl *AliasController) ListAliases(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "id")
+
+	command := alias.ListAliasesCommand{
+		BucketID: bucketID,
+	}
+	if fileIDParam := c.Query("file_id"); fileIDParam != "" {
+		fileID, err := uuid.Parse(fileIDParam)
+		if err != nil {
+			return apierror.Invalid("Invalid file ID")
+		}
+		command.FileID = &fileID
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	aliasesResponse := response.(*alias.ListAliasesResponse)
+	return c.JSON(aliasesResponse)
+}
+
+//	@Summary		Set bucket alias
+//	@Description	Point an alias at a file of the bucket, creating the alias if needed. The alias moves atomically, and with if_file_id only while it still points at that file (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string					true	"Bucket ID"
+//	@Param			name	path		string					true	"Alias name"
+//	@Param			request	body		alias.SetAliasCommand	true	"File to point at"
+//	@Success		200		{object}	alias.SetAliasResponse	"Alias set"
+//	@Failure		400		{object}	apierror.Error			"Bad request"
+//	@Failure		401		{object}	apierror.Error			"Unauthorized"
+//	@Failure		403		{object}	apierror.Error			"Forbidden"
+//	@Failure		404		{object}	apierror.Error			"Bucket or file not found"
+//	@Failure		409		{object}	apierror.Error			"Alias no longer points at if_file_id"
+//	@Router			/buckets/{id}/aliases/{name} [put]
+func (ctrl *AliasController) SetAlias(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command alias.SetAliasCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.Name = c.Params("name")
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	aliasResponse := response.(*alias.SetAliasResponse)
+	return c.JSON(aliasResponse)
+}
+
+//	@Summary		Delete bucket alias
+//	@Description	Remove an alias, the file it points at stays (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string						true	"Bucket ID"
+//	@Param			name	path		string						true	"Alias name"
+//	@Success		200		{object}	alias.DeleteAliasResponse	"Alias deleted"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Failure		403		{object}	apierror.Error				"Forbidden"
+//	@Failure		404		{object}	apierror.Error				"Alias not found"
+//	@Router			/buckets/{id}/aliases/{name} [delete]
+func (ctrl *AliasController) DeleteAlias(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := alias.DeleteAliasCommand{
+		BucketID: bucketID,
+		Name:     c.Params("name"),
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := response.(*alias.DeleteAliasResponse)
+	return c.JSON(deleteResponse)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Backup"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type BackupController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewBackupController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *BackupController {
+	return &BackupController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		List backup runs
+//	@Description	List scheduled and manual backup runs, newest first
+//	@Tags			backups
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			page	query		int								false	"Page number"		default(1)
+//	@Param			limit	query		int								false	"Items per page"	default(10)
+//	@Success		200		{object}	backup.ListBackupRunsResponse	"List of backup runs"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Router			/admin/backups [get]
+func (ctrl *BackupController) ListBackupRuns(c *fiber.Ctx) error {
+	command := &backup.ListBackupRunsCommand{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	listBackupRunsResponse := response.(*backup.ListBackupRunsResponse)
+	return c.JSON(listBackupRunsResponse)
+}
+
+//	@Summary		Start a backup
+//	@Description	Start an incremental backup of the configured (or given) buckets to the backup destination
+//	@Tags			backups
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		backup.RunBackupCommand		false	"Buckets to back up"
+//	@Success		202		{object}	backup.RunBackupResponse	"Backup started"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Router			/admin/backups [post]
+func (ctrl *BackupController) RunBackup(c *fiber.Ctx) error {
+	var command backup.RunBackupCommand
+
+	if len(c.Body()) > 0 {
+		if err := binding.Parse(c, &command); err != nil {
+			return err
+		}
+	}
+
+	command.Trigger = "manual"
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	runBackupResponse := response.(*backup.RunBackupResponse)
+	return c.Status(http.StatusAccepted).JSON(runBackupResponse)
+}
+
+//	@Summary		Restore a bucket from backup
+//	@Description	Restore missing (or all, with overwrite) files of a bucket from the backup destination
+//	@Tags			backups
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		backup.RestoreBackupCommand		true	"Restore details"
+//	@Success		200		{object}	backup.RestoreBackupResponse	"Restore result"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Router			/admin/backups/restore [post]
+func (ctrl *BackupController) RestoreBackup(c *fiber.Ctx) error {
+	var command backup.RestoreBackupCommand
+
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	restoreBackupResponse := response.(*backup.RestoreBackupResponse)
+	return c.JSON(restoreBackupResponse)
+}
@@ -1,15 +1,15 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	
 	"shbucket/src/Application/Bucket"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
 	"shbucket/src/Infrastructure/Mediator"
 )
 
@@ -36,39 +36,30 @@ func NewBucketController(mediator *mediator.Mediator, validator *validator.Valid
 //	@Security		ApiKeyAuth
 //	@Param			request	body		bucket.CreateBucketCommand	true	"Bucket creation details"
 //	@Success		201		{object}	bucket.CreateBucketResponse	"Bucket created successfully"
-//	@Failure		400		{object}	map[string]string			"Bad request"
-//	@Failure		401		{object}	map[string]string			"Unauthorized"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
 //	@Router			/buckets [post]
 func (ctrl *BucketController) CreateBucket(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
 	var command bucket.CreateBucketCommand
 	
-	if err := c.BodyParser(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := binding.Parse(c, &command); err != nil {
+		return err
 	}
 	
 	command.OwnerID = userContext.UserID
 	
-	if err := ctrl.validator.Struct(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), &command)
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	createBucketResponse := response.(*bucket.CreateBucketResponse)
@@ -76,46 +67,42 @@ func (ctrl *BucketController) CreateBucket(c *fiber.Ctx) error {
 }
 
 //	@Summary		Delete bucket
-//	@Description	Delete a storage bucket by ID
+//	@Description	Delete a storage bucket by ID. A bucket with files is only deleted with force=true, which removes every file in a background job; poll /buckets/{id}/deletion or /jobs/{job_id} for progress
 //	@Tags			buckets
 //	@Accept			json
 //	@Produce		json
 //	@Security		Bearer
 //	@Security		ApiKeyAuth
-//	@Param			id	path		string						true	"Bucket ID"
+//	@Param			id		path		string						true	"Bucket ID"
+//	@Param			force	query		bool						false	"Delete the bucket's files too"
 //	@Success		200	{object}	bucket.DeleteBucketResponse	"Bucket deleted successfully"
-//	@Failure		400	{object}	map[string]string			"Bad request"
-//	@Failure		401	{object}	map[string]string			"Unauthorized"
+//	@Success		202	{object}	bucket.DeleteBucketResponse	"Forced deletion started"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
 //	@Router			/buckets/{id} [delete]
 func (ctrl *BucketController) DeleteBucket(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
-	bucketIDParam := c.Params("id")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "id")
 	
 	command := &bucket.DeleteBucketCommand{
 		BucketID: bucketID,
 		UserID:   userContext.UserID,
+		Force:    c.QueryBool("force"),
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	deleteBucketResponse := response.(*bucket.DeleteBucketResponse)
+	if deleteBucketResponse.Job != nil {
+		return c.Status(http.StatusAccepted).JSON(deleteBucketResponse)
+	}
 	return c.JSON(deleteBucketResponse)
 }
 
@@ -128,27 +115,18 @@ func (ctrl *BucketController) DeleteBucket(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			id	path		string						true	"Bucket ID"
 //	@Success		200	{object}	bucket.GetBucketResponse	"Bucket information"
-//	@Failure		400	{object}	map[string]string			"Invalid bucket ID"
-//	@Failure		404	{object}	map[string]string			"Bucket not found"
+//	@Failure		404	{object}	apierror.Error				"Bucket not found"
 //	@Router			/buckets/{id} [get]
 func (ctrl *BucketController) GetBucket(c *fiber.Ctx) error {
-	bucketIDParam := c.Params("id")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "id")
 	
 	command := &bucket.GetBucketCommand{
 		BucketID: bucketID,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	getBucketResponse := response.(*bucket.GetBucketResponse)
@@ -156,7 +134,7 @@ func (ctrl *BucketController) GetBucket(c *fiber.Ctx) error {
 }
 
 //	@Summary		List buckets
-//	@Description	Retrieve a paginated list of buckets for the authenticated user
+//	@Description	Retrieve a paginated list of the buckets the authenticated user owns or was made bucket admin of, or with all=true every bucket (admin only). Each bucket's access says which: owner, shared or admin. Sorted by name unless sort_by (name, created_at, updated_at) says otherwise
 //	@Tags			buckets
 //	@Accept			json
 //	@Produce		json
@@ -164,32 +142,49 @@ func (ctrl *BucketController) GetBucket(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			page	query		int						false	"Page number (default: 1)"
 //	@Param			limit	query		int						false	"Items per page (default: 10)"
+//	@Param			all		query		bool					false	"List every bucket of the system (admin only)"
+//	@Param			sort_by			query		string		false	"Field to sort by"
+//	@Param			order			query		string		false	"asc or desc"
+//	@Param			name			query		string		false	"Only names containing this, case-insensitively"
+//	@Param			created_after	query		string		false	"Only created at or after this RFC 3339 time"
+//	@Param			created_before	query		string		false	"Only created before this RFC 3339 time"
+//	@Param			cursor			query		string		false	"The next_cursor of the previous page, instead of page"
 //	@Success		200	{object}	bucket.ListBucketsResponse	"List of buckets"
-//	@Failure		400	{object}	map[string]string			"Bad request"
-//	@Failure		401	{object}	map[string]string			"Unauthorized"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
+//	@Failure		403	{object}	apierror.Error				"Forbidden"
 //	@Router			/buckets [get]
 func (ctrl *BucketController) ListBuckets(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
+	all := c.QueryBool("all", false)
+	if all && userContext.Role != "admin" {
+		return apierror.Forbidden("Only admins can list all buckets")
+	}
+	list, err := parseListQuery(c)
+	if err != nil {
+		return err
+	}
+	
+	list.Cursor = c.Query("cursor")
 	
 	command := &bucket.ListBucketsCommand{
-		UserID: userContext.UserID,
-		Page:   page,
-		Limit:  limit,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+		All:      all,
+		List:     list,
+		Page:     page,
+		Limit:    limit,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	listBucketsResponse := response.(*bucket.ListBucketsResponse)
@@ -206,50 +201,262 @@ func (ctrl *BucketController) ListBuckets(c *fiber.Ctx) error {
 //	@Param			id		path		string						true	"Bucket ID"
 //	@Param			request	body		bucket.UpdateBucketCommand	true	"Bucket update details"
 //	@Success		200	{object}	bucket.UpdateBucketResponse	"Bucket updated successfully"
-//	@Failure		400	{object}	map[string]string			"Bad request"
-//	@Failure		401	{object}	map[string]string			"Unauthorized"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
 //	@Router			/buckets/{id} [put]
 func (ctrl *BucketController) UpdateBucket(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
-	bucketIDParam := c.Params("id")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "id")
 	
 	var command bucket.UpdateBucketCommand
 	
-	if err := c.BodyParser(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := binding.Parse(c, &command); err != nil {
+		return err
 	}
 	
 	command.BucketID = bucketID
 	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
 	
-	if err := ctrl.validator.Struct(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), &command)
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	updateBucketResponse := response.(*bucket.UpdateBucketResponse)
 	return c.JSON(updateBucketResponse)
-}
\ No newline at end of file
+}
+//	@Summary		Rotate bucket encryption key
+//	@Description	Make a new version of an encrypted bucket's key active and re-wrap the per-file data keys under it. Eager mode (default) re-wraps every key in the background, lazy mode re-wraps each key the next time its file is read. File content is never re-encrypted
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Bucket ID"
+//	@Param			request	body		bucket.RotateBucketKeyCommand	false	"Rotation mode"
+//	@Success		202		{object}	bucket.RotateBucketKeyResponse	"Key rotated, re-wrapping started"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{id}/rotate-key [post]
+func (ctrl *BucketController) RotateBucketKey(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command bucket.RotateBucketKeyCommand
+
+	// The body is optional, an empty request rotates eagerly
+	if len(c.Body()) > 0 {
+		if err := binding.Parse(c, &command); err != nil {
+			return err
+		}
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	rotateResponse := response.(*bucket.RotateBucketKeyResponse)
+	return c.Status(http.StatusAccepted).JSON(rotateResponse)
+}
+
+//	@Summary		Get key rotation progress
+//	@Description	Report how many of a bucket's data keys a key rotation has re-wrapped
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"Bucket ID"
+//	@Param			jobId	path		string								true	"Key rotation job ID"
+//	@Success		200		{object}	bucket.GetKeyRotationJobResponse	"Key rotation progress"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		404		{object}	apierror.Error						"Job not found"
+//	@Router			/buckets/{id}/rotate-key/{jobId} [get]
+func (ctrl *BucketController) GetKeyRotationJob(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	jobID := binding.UUID(c, "jobId")
+
+	command := &bucket.GetKeyRotationJobCommand{
+		BucketID: bucketID,
+		JobID:    jobID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	jobResponse := response.(*bucket.GetKeyRotationJobResponse)
+	return c.JSON(jobResponse)
+}
+
+//	@Summary		Get bucket deletion progress
+//	@Description	Report the progress of the latest forced deletion of a bucket. Remains available after the bucket is deleted
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string								true	"Bucket ID"
+//	@Success		200	{object}	bucket.GetBucketDeletionJobResponse	"Bucket deletion progress"
+//	@Failure		400	{object}	apierror.Error						"Bad request"
+//	@Failure		404	{object}	apierror.Error						"Job not found"
+//	@Router			/buckets/{id}/deletion [get]
+func (ctrl *BucketController) GetBucketDeletionJob(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := &bucket.GetBucketDeletionJobCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	jobResponse := response.(*bucket.GetBucketDeletionJobResponse)
+	return c.JSON(jobResponse)
+}
+
+//	@Summary		Grant bucket admin
+//	@Description	Let a user manage a bucket they don't own: its settings, bucket admins, scoped API keys, snapshots, keys and upload links. Only the owner can delete the bucket. The user needs at least the editor role
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Bucket ID"
+//	@Param			request	body		bucket.GrantBucketAdminCommand	true	"Username, email or ID of the user"
+//	@Success		201		{object}	bucket.GrantBucketAdminResponse	"Bucket admin granted"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{id}/admins [post]
+func (ctrl *BucketController) GrantBucketAdmin(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command bucket.GrantBucketAdminCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(response.(*bucket.GrantBucketAdminResponse))
+}
+
+//	@Summary		List bucket admins
+//	@Description	List the users who manage a bucket besides its owner
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Bucket ID"
+//	@Success		200	{object}	bucket.ListBucketAdminsResponse	"Bucket admins"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{id}/admins [get]
+func (ctrl *BucketController) ListBucketAdmins(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := &bucket.ListBucketAdminsCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*bucket.ListBucketAdminsResponse))
+}
+
+//	@Summary		Revoke bucket admin
+//	@Description	Stop a user managing a bucket they don't own. Bucket admins can remove themselves
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"Bucket ID"
+//	@Param			userId	path		string								true	"User ID of the bucket admin"
+//	@Success		200		{object}	bucket.RevokeBucketAdminResponse	"Bucket admin revoked"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Router			/buckets/{id}/admins/{userId} [delete]
+func (ctrl *BucketController) RevokeBucketAdmin(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	adminUserID := binding.UUID(c, "userId")
+
+	command := &bucket.RevokeBucketAdminCommand{
+		BucketID:    bucketID,
+		AdminUserID: adminUserID,
+		UserID:      userContext.UserID,
+		UserRole:    userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*bucket.RevokeBucketAdminResponse))
+}
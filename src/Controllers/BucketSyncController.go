@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/BucketSync"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type BucketSyncController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewBucketSyncController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *BucketSyncController {
+	return &BucketSyncController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Read bucket changes
+//	@Description	Read a bucket's changes feed. Without a cursor it first lists every file the bucket serves by name, then reports each name that uploads, deletes and renames touched since, with its current state. Keep the returned cursor to resume, and read again right away while has_more
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"Bucket ID"
+//	@Param			cursor	query		string								false	"Cursor returned by the previous read"
+//	@Param			limit	query		int									false	"Files or events per page"	default(100)	maximum(1000)
+//	@Success		200		{object}	bucketsync.GetBucketChangesResponse	"Changes"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Failure		404		{object}	apierror.Error						"Bucket not found"
+//	@Router			/buckets/{id}/changes [get]
+func (ctrl *BucketSyncController) GetBucketChanges(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "id")
+
+	command := bucketsync.GetBucketChangesCommand{
+		BucketID: bucketID,
+		Cursor:   c.Query("cursor"),
+		Limit:    c.QueryInt("limit"),
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	changesResponse := response.(*bucketsync.GetBucketChangesResponse)
+	return c.JSON(changesResponse)
+}
+
+//	@Summary		Configure bucket sync
+//	@Description	Keep the bucket a one-way mirror of a bucket of another SHBucket installation, read with an API key of that installation, or copy files from another bucket of this installation named by source_bucket_id. The source's changes feed is followed on every interval, so only changed files are copied. Files a remote no longer has are removed; a local source only adds and updates files. prefix and tag (key=value of custom metadata) limit the files copied, and on_demand syncs only run when asked to. api_key may be left out to keep the current key (bucket owner or bucket admin, managing the source bucket too)
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string									true	"Bucket ID"
+//	@Param			request	body		bucketsync.ConfigureBucketSyncCommand	true	"Source bucket"
+//	@Success		200		{object}	bucketsync.ConfigureBucketSyncResponse	"Sync configured"
+//	@Failure		400		{object}	apierror.Error							"Bad request or remote bucket can't be read"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Failure		403		{object}	apierror.Error							"Forbidden"
+//	@Failure		404		{object}	apierror.Error							"Bucket not found"
+//	@Router			/buckets/{id}/sync [put]
+func (ctrl *BucketSyncController) ConfigureBucketSync(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command bucketsync.ConfigureBucketSyncCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	syncResponse := response.(*bucketsync.ConfigureBucketSyncResponse)
+	return c.JSON(syncResponse)
+}
+
+//	@Summary		Get bucket sync
+//	@Description	Get the bucket's sync from a remote bucket, with the status and counters of its passes (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Bucket ID"
+//	@Success		200	{object}	bucketsync.GetBucketSyncResponse	"Bucket sync"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		403	{object}	apierror.Error					"Forbidden"
+//	@Failure		404	{object}	apierror.Error					"Bucket not found or not synced"
+//	@Router			/buckets/{id}/sync [get]
+func (ctrl *BucketSyncController) GetBucketSync(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := bucketsync.GetBucketSyncCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	syncResponse := response.(*bucketsync.GetBucketSyncResponse)
+	return c.JSON(syncResponse)
+}
+
+//	@Summary		Delete bucket sync
+//	@Description	Stop syncing the bucket from its remote bucket, the files already copied stay (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string								true	"Bucket ID"
+//	@Success		200	{object}	bucketsync.DeleteBucketSyncResponse	"Sync removed"
+//	@Failure		400	{object}	apierror.Error						"Bad request"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		403	{object}	apierror.Error						"Forbidden"
+//	@Failure		404	{object}	apierror.Error						"Bucket not found or not synced"
+//	@Router			/buckets/{id}/sync [delete]
+func (ctrl *BucketSyncController) DeleteBucketSync(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := bucketsync.DeleteBucketSyncCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := response.(*bucketsync.DeleteBucketSyncResponse)
+	return c.JSON(deleteResponse)
+}
+
+//	@Summary		Run bucket sync
+//	@Description	Sync the bucket on the worker's next check instead of at the end of its interval. full lists the remote bucket again, which also picks up changes its feed doesn't carry such as restored snapshots (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Bucket ID"
+//	@Param			request	body		bucketsync.RunBucketSyncCommand	false	"Run options"
+//	@Success		200		{object}	bucketsync.RunBucketSyncResponse	"Sync scheduled"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Failure		403		{object}	apierror.Error					"Forbidden"
+//	@Failure		404		{object}	apierror.Error					"Bucket not found or not synced"
+//	@Router			/buckets/{id}/sync/run [post]
+func (ctrl *BucketSyncController) RunBucketSync(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command bucketsync.RunBucketSyncCommand
+	if len(c.Body()) > 0 {
+		if err := binding.Parse(c, &command); err != nil {
+			return err
+		}
+	}
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	runResponse := response.(*bucketsync.RunBucketSyncResponse)
+	return c.JSON(runResponse)
+}
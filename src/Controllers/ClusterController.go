@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/ClusterMember"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type ClusterController struct {
+	mediator *mediator.Mediator
+}
+
+func NewClusterController(mediator *mediator.Mediator) *ClusterController {
+	return &ClusterController{
+		mediator: mediator,
+	}
+}
+
+//	@Summary		List cluster members
+//	@Description	List the master servers running against the database, which of them is the leader running the backup scheduler, video worker and lifecycle worker, and which answered (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	clustermember.ListClusterMembersResponse	"Running servers"
+//	@Failure		401	{object}	apierror.Error								"Unauthorized"
+//	@Failure		403	{object}	apierror.Error								"Forbidden"
+//	@Router			/admin/cluster [get]
+func (ctrl *ClusterController) ListMembers(c *fiber.Ctx) error {
+	command := clustermember.ListClusterMembersCommand{}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	membersResponse := response.(*clustermember.ListClusterMembersResponse)
+	return c.JSON(membersResponse)
+}
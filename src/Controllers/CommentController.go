@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Comment"
+	"shbucket/src/Application/Notification"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type CommentController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewCommentController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *CommentController {
+	return &CommentController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Comment on a file
+//	@Description	Attach a note to a file. Users mentioned as @username are notified
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Param			request		body		comment.CreateCommentCommand	true	"Comment body"
+//	@Success		201			{object}	comment.CreateCommentResponse	"Comment created"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{bucketId}/files/{fileId}/comments [post]
+func (ctrl *CommentController) CreateComment(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+	fileID := binding.UUID(c, "fileId")
+
+	var command comment.CreateCommentCommand
+
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.FileID = fileID
+	command.AuthorID = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	createCommentResponse := response.(*comment.CreateCommentResponse)
+	return c.Status(http.StatusCreated).JSON(createCommentResponse)
+}
+
+//	@Summary		List file comments
+//	@Description	List the comments on a file, oldest first
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Success		200			{object}	comment.ListCommentsResponse	"Comments"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Router			/buckets/{bucketId}/files/{fileId}/comments [get]
+func (ctrl *CommentController) ListComments(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "bucketId")
+	fileID := binding.UUID(c, "fileId")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &comment.ListCommentsCommand{
+		BucketID: bucketID,
+		FileID:   fileID,
+	})
+	if err != nil {
+		return err
+	}
+
+	listCommentsResponse := response.(*comment.ListCommentsResponse)
+	return c.JSON(listCommentsResponse)
+}
+
+//	@Summary		Delete a file comment
+//	@Description	Delete a comment. Allowed for its author, the bucket owner and admins
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Param			commentId	path		string							true	"Comment ID"
+//	@Success		200			{object}	comment.DeleteCommentResponse	"Comment deleted"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{bucketId}/files/{fileId}/comments/{commentId} [delete]
+func (ctrl *CommentController) DeleteComment(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+	fileID := binding.UUID(c, "fileId")
+
+	commentID := binding.UUID(c, "commentId")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &comment.DeleteCommentCommand{
+		BucketID:  bucketID,
+		FileID:    fileID,
+		CommentID: commentID,
+		UserID:    userContext.UserID,
+		UserRole:  userContext.Role,
+	})
+	if err != nil {
+		return err
+	}
+
+	deleteCommentResponse := response.(*comment.DeleteCommentResponse)
+	return c.JSON(deleteCommentResponse)
+}
+
+//	@Summary		List notifications
+//	@Description	List the current user's notifications, newest first
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			unread	query		bool										false	"Only unread notifications"
+//	@Param			limit	query		int											false	"Maximum notifications to return (default 50)"
+//	@Success		200		{object}	notification.ListNotificationsResponse		"Notifications"
+//	@Failure		401		{object}	apierror.Error								"Unauthorized"
+//	@Router			/notifications [get]
+func (ctrl *CommentController) ListNotifications(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &notification.ListNotificationsCommand{
+		UserID:     userContext.UserID,
+		UnreadOnly: c.QueryBool("unread", false),
+		Limit:      c.QueryInt("limit", 50),
+	})
+	if err != nil {
+		return err
+	}
+
+	listNotificationsResponse := response.(*notification.ListNotificationsResponse)
+	return c.JSON(listNotificationsResponse)
+}
+
+//	@Summary		Mark notification read
+//	@Description	Mark one of the current user's notifications as read
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string										true	"Notification ID"
+//	@Success		200	{object}	notification.MarkNotificationReadResponse	"Notification marked as read"
+//	@Failure		404	{object}	apierror.Error								"Notification not found"
+//	@Router			/notifications/{id}/read [post]
+func (ctrl *CommentController) MarkNotificationRead(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	notificationID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &notification.MarkNotificationReadCommand{
+		NotificationID: notificationID,
+		UserID:         userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	markNotificationReadResponse := response.(*notification.MarkNotificationReadResponse)
+	return c.JSON(markNotificationReadResponse)
+}
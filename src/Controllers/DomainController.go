@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Domains"
+)
+
+// DomainController routes requests arriving for a bucket's own domain: website domains serve the
+// bucket's static site, custom domains serve its files by name
+type DomainController struct {
+	resolver *domains.Resolver
+	website  *WebsiteController
+	files    *FileController
+}
+
+func NewDomainController(resolver *domains.Resolver, website *WebsiteController, files *FileController) *DomainController {
+	return &DomainController{
+		resolver: resolver,
+		website:  website,
+		files:    files,
+	}
+}
+
+// Serve answers GET and HEAD requests whose host is a bucket domain, and passes every other
+// request on
+func (ctrl *DomainController) Serve(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return c.Next()
+	}
+	route, ok := ctrl.resolver.Resolve(c.Hostname())
+	if !ok {
+		return c.Next()
+	}
+	if route.Website {
+		return ctrl.website.serveDomain(c, route.BucketID)
+	}
+
+	// files.example.com/photos/cat.jpg is the file named photos/cat.jpg
+	name, err := url.PathUnescape(strings.TrimPrefix(c.Path(), "/"))
+	if err != nil || name == "" || strings.HasSuffix(name, "/") {
+		return apierror.New(apierror.CodeFileNotFound, "File not found")
+	}
+	return ctrl.files.serveNamed(c, route.BucketID, name)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Durability"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type DurabilityController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewDurabilityController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *DurabilityController {
+	return &DurabilityController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Get bucket durability
+//	@Description	Report the replication state of every file version in a bucket: its primary copy on the master or a storage node and its backup copies. Versions with an unhealthy copy are degraded, with fewer than two healthy copies at risk, with an unreachable primary unavailable, and with healthy copies in fewer zones than the bucket's min_zones short of zones
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string									true	"Bucket ID"
+//	@Param			filter	query		string									false	"Only list degraded, at_risk, unavailable or zone_shortfall versions"
+//	@Param			page	query		int										false	"Page number"		default(1)
+//	@Param			limit	query		int										false	"Versions per page"	default(10)
+//	@Success		200		{object}	durability.GetBucketDurabilityResponse	"Bucket durability"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Failure		403		{object}	apierror.Error							"Forbidden"
+//	@Failure		404		{object}	apierror.Error							"Bucket not found"
+//	@Router			/buckets/{id}/durability [get]
+func (ctrl *DurabilityController) GetBucketDurability(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := durability.GetBucketDurabilityCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+		Filter:   c.Query("filter"),
+		Page:     c.QueryInt("page", 1),
+		Limit:    c.QueryInt("limit", 10),
+	}
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*durability.GetBucketDurabilityResponse))
+}
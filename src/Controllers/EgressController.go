@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Egress"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type EgressController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewEgressController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *EgressController {
+	return &EgressController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Get bucket egress
+//	@Description	Get the bytes served from a bucket and from all buckets of its owner in the current billing cycle, against their quotas, with earlier cycles. Past either quota the bucket's downloads are throttled or blocked
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Bucket ID"
+//	@Success		200	{object}	egress.GetBucketEgressResponse	"Bucket egress"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		403	{object}	apierror.Error					"Forbidden"
+//	@Failure		404	{object}	apierror.Error					"Bucket not found"
+//	@Router			/buckets/{id}/egress [get]
+func (ctrl *EgressController) GetBucketEgress(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &egress.GetBucketEgressCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*egress.GetBucketEgressResponse))
+}
+
+//	@Summary		Get user egress
+//	@Description	Get the bytes served from all buckets a user owns in the current billing cycle, against the user's quota, with earlier cycles (admin only)
+//	@Tags			users
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"User ID"
+//	@Success		200	{object}	egress.GetUserEgressResponse	"User egress"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		404	{object}	apierror.Error					"User not found"
+//	@Router			/users/{id}/egress [get]
+func (ctrl *EgressController) GetUserEgress(c *fiber.Ctx) error {
+	userID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &egress.GetUserEgressCommand{
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*egress.GetUserEgressResponse))
+}
+
+//	@Summary		Set user egress quota
+//	@Description	Set the bytes all buckets a user owns may serve per billing cycle, 0 for no quota (admin only)
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"User ID"
+//	@Param			request	body		egress.SetUserEgressQuotaCommand	true	"Quota in bytes"
+//	@Success		200		{object}	egress.SetUserEgressQuotaResponse	"Quota updated"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Failure		404		{object}	apierror.Error						"User not found"
+//	@Router			/users/{id}/egress-quota [put]
+func (ctrl *EgressController) SetUserEgressQuota(c *fiber.Ctx) error {
+	userID := binding.UUID(c, "id")
+
+	var command egress.SetUserEgressQuotaCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.UserID = userID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*egress.SetUserEgressQuotaResponse))
+}
+
+//	@Summary		Get API key egress
+//	@Description	Get the bytes downloaded with one of your API keys in the current billing cycle and day, against its quotas, with earlier cycles
+//	@Tags			api-keys
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"API key ID"
+//	@Success		200	{object}	egress.GetAPIKeyEgressResponse	"API key egress"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		404	{object}	apierror.Error					"API key not found"
+//	@Router			/api-keys/{id}/egress [get]
+func (ctrl *EgressController) GetAPIKeyEgress(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	apiKeyID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &egress.GetAPIKeyEgressCommand{
+		APIKeyID: apiKeyID,
+		UserID:   userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*egress.GetAPIKeyEgressResponse))
+}
+
+//	@Summary		Set API key egress quotas
+//	@Description	Set the bytes one of your API keys may download per billing cycle and per day, 0 for no quota. Past either, its downloads are throttled or blocked by the policy of the bucket
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"API key ID"
+//	@Param			request	body		egress.SetAPIKeyEgressQuotaCommand	true	"Quotas in bytes"
+//	@Success		200		{object}	egress.SetAPIKeyEgressQuotaResponse	"Quotas updated"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Failure		404		{object}	apierror.Error						"API key not found"
+//	@Router			/api-keys/{id}/egress-quota [put]
+func (ctrl *EgressController) SetAPIKeyEgressQuota(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	apiKeyID := binding.UUID(c, "id")
+
+	var command egress.SetAPIKeyEgressQuotaCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.APIKeyID = apiKeyID
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*egress.SetAPIKeyEgressQuotaResponse))
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Event"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type EventController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewEventController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *EventController {
+	return &EventController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Replay bucket events
+//	@Description	Re-deliver a bucket's historical events, oldest first, to a URL or one of the bucket's webhooks so a new consumer can be backfilled. Deliveries to a webhook are signed with its secret and marked with X-SHBucket-Replay. Delivery is at-least-once; resume with next_from when has_more is set
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"Bucket ID"
+//	@Param			from	query		string								false	"Replay events created at or after this RFC3339 timestamp"
+//	@Param			request	body		event.ReplayBucketEventsCommand		true	"Webhook URL or ID and optional event type filter"
+//	@Success		200		{object}	event.ReplayBucketEventsResponse	"Replay result"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Router			/buckets/{id}/events/replay [post]
+func (ctrl *EventController) ReplayBucketEvents(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command event.ReplayBucketEventsCommand
+
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+
+	if from := c.Query("from"); from != "" {
+		command.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return apierror.Invalid("Invalid from timestamp, expected RFC3339")
+		}
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	replayResponse := response.(*event.ReplayBucketEventsResponse)
+	return c.JSON(replayResponse)
+}
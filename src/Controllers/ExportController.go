@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Export"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type ExportController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewExportController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *ExportController {
+	return &ExportController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Export to S3
+//	@Description	Copy a bucket's objects and a metadata manifest to an external S3-compatible bucket, verifying each object by checksum
+//	@Tags			migrations
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		export.ExportS3Command	true	"Source bucket and target credentials"
+//	@Success		202		{object}	export.ExportS3Response	"Export started"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Router			/admin/exports/s3 [post]
+func (ctrl *ExportController) ExportS3(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command export.ExportS3Command
+
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+
+	command.StartedBy = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	exportS3Response := response.(*export.ExportS3Response)
+	return c.Status(http.StatusAccepted).JSON(exportS3Response)
+}
+
+//	@Summary		Get S3 export progress
+//	@Description	Get progress and the failed object report of an S3 export
+//	@Tags			migrations
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Export job ID"
+//	@Success		200	{object}	export.GetS3ExportJobResponse	"Export job"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		404	{object}	apierror.Error					"Export job not found"
+//	@Router			/admin/exports/s3/{id} [get]
+func (ctrl *ExportController) GetS3ExportJob(c *fiber.Ctx) error {
+	jobID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &export.GetS3ExportJobCommand{JobID: jobID})
+	if err != nil {
+		return err
+	}
+
+	getS3ExportJobResponse := response.(*export.GetS3ExportJobResponse)
+	return c.JSON(getS3ExportJobResponse)
+}
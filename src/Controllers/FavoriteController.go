@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Favorite"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type FavoriteController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewFavoriteController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *FavoriteController {
+	return &FavoriteController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Favorite a file
+//	@Description	Pin a file to the current user's favorites
+//	@Tags			favorites
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Success		200			{object}	favorite.AddFavoriteResponse	"File added to favorites"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{bucketId}/files/{fileId}/favorite [put]
+func (ctrl *FavoriteController) AddFavorite(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+	fileID := binding.UUID(c, "fileId")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &favorite.AddFavoriteCommand{
+		BucketID: bucketID,
+		FileID:   fileID,
+		UserID:   userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	addFavoriteResponse := response.(*favorite.AddFavoriteResponse)
+	return c.JSON(addFavoriteResponse)
+}
+
+//	@Summary		Unfavorite a file
+//	@Description	Remove a file from the current user's favorites
+//	@Tags			favorites
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Success		200			{object}	favorite.RemoveFavoriteResponse	"File removed from favorites"
+//	@Failure		404			{object}	apierror.Error					"Favorite not found"
+//	@Router			/buckets/{bucketId}/files/{fileId}/favorite [delete]
+func (ctrl *FavoriteController) RemoveFavorite(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	fileID := binding.UUID(c, "fileId")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &favorite.RemoveFavoriteCommand{
+		FileID: fileID,
+		UserID: userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	removeFavoriteResponse := response.(*favorite.RemoveFavoriteResponse)
+	return c.JSON(removeFavoriteResponse)
+}
+
+//	@Summary		List favorites
+//	@Description	List the current user's favorite files, most recently favorited first
+//	@Tags			favorites
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	favorite.ListFavoritesResponse	"Favorites"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Router			/auth/me/favorites [get]
+func (ctrl *FavoriteController) ListFavorites(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &favorite.ListFavoritesCommand{UserID: userContext.UserID})
+	if err != nil {
+		return err
+	}
+
+	listFavoritesResponse := response.(*favorite.ListFavoritesResponse)
+	return c.JSON(listFavoritesResponse)
+}
@@ -3,16 +3,18 @@ package controllers
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -21,13 +23,31 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"shbucket/src/Application/Alias"
 	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Diagnostics"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/Hotlink"
+	"shbucket/src/Infrastructure/IPFilter"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/Media"
 	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Metering"
 	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Replicas"
+	"shbucket/src/Infrastructure/Scanning"
 	"shbucket/src/Infrastructure/Services"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Infrastructure/Website"
+	"shbucket/src/Models"
 )
 
 type FileController struct {
@@ -36,15 +56,21 @@ type FileController struct {
 	authService         *auth.AuthorizationService
 	dbContext           *persistence.AppDbContext
 	signatureService    *services.SignatureValidationService
+	derivedCache        *storage.DerivedCache
+	imageEncoders       *media.ImageEncoders
+	meter               *metering.Meter
 }
 
-func NewFileController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService, dbContext *persistence.AppDbContext) *FileController {
+func NewFileController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService, dbContext *persistence.AppDbContext, meter *metering.Meter) *FileController {
 	return &FileController{
 		mediator:         mediator,
 		validator:        validator,
 		authService:      authService,
 		dbContext:        dbContext,
 		signatureService: services.NewSignatureValidationService(dbContext),
+		derivedCache:     storage.NewDerivedCache(config.GetSettings().DerivedCachePath),
+		imageEncoders:    media.NewImageEncoders(config.GetSettings()),
+		meter:            meter,
 	}
 }
 
@@ -57,62 +83,140 @@ func NewFileController(mediator *mediator.Mediator, validator *validator.Validat
 //	@Security		ApiKeyAuth
 //	@Param			bucketId	path		string							true	"Bucket ID"
 //	@Param			file		formData	file							true	"File to upload"
+//	@Param			name		formData	string							false	"Name to store the file under instead of the uploaded file's, which may contain \"/\" for folders. An empty file whose name ends with \"/\" is a folder marker"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Algorithm	header	string	false	"AES256, when the content is encrypted with a customer-provided key"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key		header	string	false	"Base64 encoded 256-bit customer-provided key, never stored"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key-MD5	header	string	false	"Base64 encoded MD5 of the customer-provided key"
+//	@Param			Idempotency-Key	header	string	false	"Key retries of this upload are sent with, to get the first response back instead of uploading again"
+//	@Param			X-Checksum-Sha256	header	string	false	"Hex or base64 encoded sha256 of the file, the upload is refused when the content differs. X-Amz-Checksum-Sha256 is accepted as well"
+//	@Param			X-Transfer-Id	header	string	false	"ID the client chose to follow the upload's progress under, at /transfers/{transferId}/events"
 //	@Success		201			{object}	file.DistributedUploadResponse	"File uploaded successfully"
-//	@Failure		400			{object}	map[string]string				"Bad request"
-//	@Failure		401			{object}	map[string]string				"Unauthorized"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Failure		409			{object}	apierror.Error					"Name exists and can't be overwritten"
+//	@Failure		413			{object}	apierror.Error					"File too large"
+//	@Failure		422			{object}	apierror.Error					"Malware detected"
 //	@Router			/buckets/{bucketId}/files [post]
 func (ctrl *FileController) UploadFile(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
-	bucketIDParam := c.Params("bucketId")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
+	bucketID := binding.UUID(c, "bucketId")
+	
+	// A file over the bucket's limit is refused from its declared size, before the body is read
+	bucket, err := ctrl.dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err == nil && bucket != nil && declaredUploadTooLarge(c, bucket.Settings.MaxFileSize) {
+		return uploadTooLarge(c, bucket.Settings.MaxFileSize)
 	}
 	
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "No file provided",
-		})
+		return apierror.Invalid("No file provided")
 	}
 	
 	fileReader, err := fileHeader.Open()
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Failed to open file",
-		})
+		return apierror.Invalid("Failed to open file")
 	}
 	defer fileReader.Close()
 	
+	// The uploaded file's name loses any folders, a name given alongside keeps them
+	fileName := fileHeader.Filename
+	if name := c.FormValue("name"); name != "" {
+		if len(name) > 1024 {
+			return apierror.Invalid("name must be at most 1024 bytes")
+		}
+		fileName = name
+	}
+	
+	// An SSE-C key encrypts this file only and is never stored
+	customerKey, err := customerKeyFromRequest(c)
+	if err != nil {
+		return err
+	}
+	
+	checksum, err := expectedChecksum(c)
+	if err != nil {
+		return err
+	}
+	
+	transfer, err := startTransfer(c, userContext.UserID)
+	if err != nil {
+		return err
+	}
+	
 	// Use distributed upload by default
 	command := &file.DistributedUploadCommand{
 		BucketID:    bucketID,
 		File:        fileHeader,
 		FileReader:  fileReader,
-		FileName:    fileHeader.Filename,
+		FileName:    fileName,
 		ContentType: fileHeader.Header.Get("Content-Type"),
 		UploadedBy:  userContext.UserID,
+		CustomerKey: customerKey,
+		ExpectedChecksum: checksum,
+		Progress:    transfer,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		transfer.Fail(err)
+		return err
 	}
 	
 	uploadFileResponse := response.(*file.DistributedUploadResponse)
+	transfer.Complete(uploadFileResponse.File.ID)
+	setCustomerKeyHeaders(c, uploadFileResponse.File.CustomerKeyMD5)
+	setChecksumHeaders(c, uploadFileResponse.File.Checksum)
 	return c.Status(http.StatusCreated).JSON(uploadFileResponse)
 }
 
+//	@Summary		Precheck upload
+//	@Description	Send a file's sha256 checksum and size before uploading it. When the bucket already holds identical content, the file is stored under the given name without transferring its bytes and returned with exists set; otherwise upload it as usual
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			request		body		file.PrecheckUploadCommand		true	"File to check"
+//	@Success		200			{object}	file.PrecheckUploadResponse	"No identical content, upload the file"
+//	@Param			Idempotency-Key	header	string	false	"Key retries of this upload are sent with, to get the first response back instead of uploading again"
+//	@Success		201			{object}	file.PrecheckUploadResponse	"File stored from identical content"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Failure		409			{object}	apierror.Error					"Name exists and can't be overwritten"
+//	@Failure		413			{object}	apierror.Error					"File too large"
+//	@Router			/buckets/{bucketId}/files/precheck [post]
+func (ctrl *FileController) PrecheckUpload(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+
+	var command file.PrecheckUploadCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.UploadedBy = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	precheckResponse := response.(*file.PrecheckUploadResponse)
+	if precheckResponse.Exists {
+		return c.Status(http.StatusCreated).JSON(precheckResponse)
+	}
+	return c.JSON(precheckResponse)
+}
+
 //	@Summary		Delete file from bucket
 //	@Description	Delete a specific file from a bucket
 //	@Tags			files
@@ -122,54 +226,162 @@ func (ctrl *FileController) UploadFile(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			bucketId	path		string	true	"Bucket ID"
 //	@Param			fileId		path		string	true	"File ID"
+//	@Param			override_lock	query	bool	false	"Admins only: delete the file even though it is locked, the override is audited"
 //	@Success		200			{object}	file.DeleteFileResponse	"File deleted successfully"
-//	@Failure		400			{object}	map[string]string		"Bad request"
-//	@Failure		401			{object}	map[string]string		"Unauthorized"
-//	@Failure		404			{object}	map[string]string		"File not found"
+//	@Failure		400			{object}	apierror.Error			"Bad request"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		403			{object}	apierror.Error			"Only admins can override a file lock"
+//	@Failure		404			{object}	apierror.Error			"File not found"
+//	@Failure		409			{object}	apierror.Error			"File is locked"
 //	@Router			/buckets/{bucketId}/files/{fileId} [delete]
 func (ctrl *FileController) DeleteFile(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
-	bucketIDParam := c.Params("bucketId")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "bucketId")
 	
-	fileIDParam := c.Params("fileId")
-	fileID, err := uuid.Parse(fileIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid file ID",
-		})
-	}
+	fileID := binding.UUID(c, "fileId")
 	
 	command := &file.DeleteFileCommand{
-		FileID:   fileID,
-		BucketID: bucketID,
-		UserID:   userContext.UserID,
+		FileID:       fileID,
+		BucketID:     bucketID,
+		UserID:       userContext.UserID,
+		UserRole:     userContext.Role,
+		OverrideLock: c.QueryBool("override_lock"),
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	deleteFileResponse := response.(*file.DeleteFileResponse)
 	return c.JSON(deleteFileResponse)
 }
 
+//	@Summary		Set file response headers
+//	@Description	Replace the response headers a file is served with: Cache-Control, Content-Disposition (inline or attachment), Content-Language, Expires, Link, X-Robots-Tag, X-Frame-Options, Content-Encoding for content uploaded already encoded, and X- headers of your own. They take precedence over the bucket's default_headers setting; files that need authentication only take a private Cache-Control
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Param			request		body		file.SetFileHeadersCommand		true	"Response headers"
+//	@Success		200			{object}	file.SetFileHeadersResponse	"Response headers set successfully"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Failure		404			{object}	apierror.Error					"File not found"
+//	@Router			/buckets/{bucketId}/files/{fileId}/headers [put]
+func (ctrl *FileController) SetFileHeaders(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "bucketId")
+
+	fileID := binding.UUID(c, "fileId")
+
+	var command file.SetFileHeadersCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.FileID = fileID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*file.SetFileHeadersResponse))
+}
+
+//	@Summary		Lock file
+//	@Description	Lock a file version in a bucket with object lock: until retain_until, and with a legal hold until it is removed. Locked files can't be deleted, overwritten or renamed, not even by the bucket's owner. Retention can only be extended; an admin can shorten it with override, which is audited
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string						true	"Bucket ID"
+//	@Param			fileId		path		string						true	"File ID"
+//	@Param			request		body		file.SetFileLockCommand	true	"File lock"
+//	@Success		200			{object}	file.SetFileLockResponse	"File lock updated successfully"
+//	@Failure		400			{object}	apierror.Error				"Bad request"
+//	@Failure		401			{object}	apierror.Error				"Unauthorized"
+//	@Failure		403			{object}	apierror.Error				"Only admins can override a file lock"
+//	@Failure		404			{object}	apierror.Error				"File not found"
+//	@Failure		409			{object}	apierror.Error				"Retention can only be extended"
+//	@Router			/buckets/{bucketId}/files/{fileId}/lock [put]
+func (ctrl *FileController) SetFileLock(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+
+	fileID := binding.UUID(c, "fileId")
+
+	var command file.SetFileLockCommand
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.FileID = fileID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*file.SetFileLockResponse))
+}
+
+//	@Summary		Relocate a file
+//	@Description	Move a file's stored content to the master or a storage node, for rebalancing by hand. The content is copied as stored, read back from the target and checked against the file's checksum, then every file and snapshot copy pointing at it is switched over and the source is freed. The bucket's placement must allow the target (admin only)
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			fileId		path		string						true	"File ID"
+//	@Param			request		body		file.RelocateFileCommand	true	"Target: master or a storage node ID"
+//	@Success		200			{object}	file.RelocateFileResponse	"File relocated"
+//	@Failure		400			{object}	apierror.Error				"Bad request"
+//	@Failure		401			{object}	apierror.Error				"Unauthorized"
+//	@Failure		404			{object}	apierror.Error				"File or storage node not found"
+//	@Failure		409			{object}	apierror.Error				"Already stored there, or the target can't hold the file"
+//	@Failure		502			{object}	apierror.Error				"Copy failed verification"
+//	@Failure		507			{object}	apierror.Error				"Target has no room for the file"
+//	@Router			/admin/files/{fileId}/relocate [post]
+func (ctrl *FileController) RelocateFile(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	fileID := binding.UUID(c, "fileId")
+
+	var command file.RelocateFileCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.FileID = fileID
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*file.RelocateFileResponse))
+}
+
 //	@Summary		Get file metadata
-//	@Description	Get metadata and information about a specific file
+//	@Description	Get metadata and information about a specific file, with the replication state of its content
 //	@Tags			files
 //	@Accept			json
 //	@Produce		json
@@ -178,37 +390,24 @@ func (ctrl *FileController) DeleteFile(c *fiber.Ctx) error {
 //	@Param			bucketId	path		string	true	"Bucket ID"
 //	@Param			fileId		path		string	true	"File ID"
 //	@Success		200			{object}	file.GetFileResponse	"File metadata retrieved successfully"
-//	@Failure		400			{object}	map[string]string		"Bad request"
-//	@Failure		401			{object}	map[string]string		"Unauthorized"
-//	@Failure		404			{object}	map[string]string		"File not found"
+//	@Failure		400			{object}	apierror.Error			"Bad request"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		404			{object}	apierror.Error			"File not found"
 //	@Router			/buckets/{bucketId}/files/{fileId}/info [get]
 func (ctrl *FileController) GetFile(c *fiber.Ctx) error {
-	bucketIDParam := c.Params("bucketId")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "bucketId")
 	
-	fileIDParam := c.Params("fileId")
-	fileID, err := uuid.Parse(fileIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid file ID",
-		})
-	}
+	fileID := binding.UUID(c, "fileId")
 	
 	command := &file.GetFileCommand{
-		FileID:   fileID,
-		BucketID: bucketID,
+		FileID:      fileID,
+		BucketID:    bucketID,
+		Replication: true,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	getFileResponse := response.(*file.GetFileResponse)
@@ -216,7 +415,7 @@ func (ctrl *FileController) GetFile(c *fiber.Ctx) error {
 }
 
 //	@Summary		Serve file content
-//	@Description	Serve file content directly with support for signed URLs, API keys, and image processing
+//	@Description	Serve file content directly with support for signed URLs, API keys, and image processing. Conditional requests are answered from the ETag and Last-Modified, and HEAD returns the headers only
 //	@Tags			files
 //	@Accept			json
 //	@Produce		application/octet-stream
@@ -227,44 +426,142 @@ func (ctrl *FileController) GetFile(c *fiber.Ctx) error {
 //	@Param			bucketId	path		string	true	"Bucket ID"
 //	@Param			fileId		path		string	true	"File ID"
 //	@Param			signature	query		string	false	"Signed URL signature for temporary access"
+//	@Param			token		query		string	false	"File token granting read access to this file until revoked"
 //	@Param			width		query		int		false	"Image width for scaling (images only)"
 //	@Param			height		query		int		false	"Image height for scaling (images only)"
 //	@Param			quality		query		int		false	"Image quality for JPEG compression"	default(85)
 //	@Param			resolution	query		string	false	"Predefined resolution (144p, 240p, 360p, 480p, 720p, 1080p, 1440p, 2160p, 4k)"
+//	@Param			format		query		string	false	"Output format for images (webp, avif, png, jpeg), negotiated from Accept when omitted"
+//	@Param			thumbnail	query		bool	false	"Serve the generated poster frame of a video (requires video processing on the bucket)"
+//	@Param			If-None-Match	header	string	false	"ETag from a previous response"
+//	@Param			If-Modified-Since	header	string	false	"Last-Modified of a previous response"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Algorithm	header	string	false	"AES256, when the content is encrypted with a customer-provided key"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key		header	string	false	"Base64 encoded 256-bit customer-provided key, never stored"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key-MD5	header	string	false	"Base64 encoded MD5 of the customer-provided key"
 //	@Success		200			"File content served successfully"
-//	@Failure		400			{object}	map[string]string		"Bad request"
-//	@Failure		401			{object}	map[string]string		"Unauthorized"
-//	@Failure		404			{object}	map[string]string		"File not found"
+//	@Header			200			{string}	X-Checksum-Sha256	"Hex encoded sha256 of the file's content, base64 encoded in X-Amz-Checksum-Sha256"
+//	@Success		304			"Not modified"
+//	@Failure		400			{object}	apierror.Error			"Bad request"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		403			{object}	apierror.Error			"File is quarantined"
+//	@Failure		404			{object}	apierror.Error			"File not found"
 //	@Router			/file/{bucketId}/{fileId} [get]
+//	@Router			/file/{bucketId}/{fileId} [head]
 func (ctrl *FileController) ServeFile(c *fiber.Ctx) error {
 	
-	bucketIDParam := c.Params("bucketId")
-	bucketID, err := uuid.Parse(bucketIDParam)
+	bucketID := binding.UUID(c, "bucketId")
+	
+	fileID := binding.UUID(c, "fileId")
+	
+	return ctrl.serveFile(c, bucketID, fileID)
+}
+
+//	@Summary		Serve file by alias
+//	@Description	Serve the file a bucket alias points at, like /file/{bucketId}/{fileId}. Content-Location names the file served, and public responses are revalidated on every use since the alias can be re-pointed
+//	@Tags			files
+//	@Produce		octet-stream
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string	true	"Bucket ID"
+//	@Param			name		path		string	true	"Alias name"
+//	@Param			signature	query		string	false	"Signed URL signature for temporary access"
+//	@Param			token		query		string	false	"File token of the file the alias points at"
+//	@Success		200			"File content served successfully"
+//	@Success		304			"Not modified"
+//	@Failure		400			{object}	apierror.Error			"Bad request"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		404			{object}	apierror.Error			"Alias not found"
+//	@Router			/file/{bucketId}/alias/{name} [get]
+func (ctrl *FileController) ServeAlias(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "bucketId")
+	
+	target, err := alias.Resolve(c.UserContext(), ctrl.dbContext, bucketID, c.Params("name"))
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
+		return apierror.Internal("Failed to look up alias")
+	}
+	if target == nil {
+		return apierror.NotFound("Alias not found")
+	}
+	
+	c.Set("Content-Location", fmt.Sprintf("%s/file/%s/%s", APIPrefix, bucketID, target.FileId))
+	if err := ctrl.serveFile(c, bucketID, target.FileId); err != nil {
+		return err
+	}
+	// The ETag follows the file, so caches revalidate and pick up a re-pointed alias
+	if strings.HasPrefix(string(c.Response().Header.Peek("Cache-Control")), "public") {
+		c.Set("Cache-Control", "public, no-cache")
+	}
+	return nil
+}
+
+//	@Summary		Serve file by name
+//	@Description	Serve the current version of a file by bucket name and file name, a stable human-readable URL. Object names may contain slashes. Access and query parameters work as for /file/{bucketId}/{fileId}, and Content-Location names the file served
+//	@Tags			files
+//	@Produce		octet-stream
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketName	path		string	true	"Bucket name"
+//	@Param			objectName	path		string	true	"File name"
+//	@Param			signature	query		string	false	"Signed URL signature for temporary access"
+//	@Param			token		query		string	false	"File token of the current version"
+//	@Success		200			"File content served successfully"
+//	@Success		304			"Not modified"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		404			{object}	apierror.Error			"Bucket or file not found"
+//	@Router			/b/{bucketName}/o/{objectName} [get]
+func (ctrl *FileController) ServeByName(c *fiber.Ctx) error {
+	bucket, err := ctrl.dbContext.Buckets.Where(&entities.Bucket{Name: c.Params("bucketName")}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return apierror.New(apierror.CodeBucketNotFound, "Bucket not found")
+	}
+	
+	name, err := url.PathUnescape(c.Params("*"))
+	if err != nil || name == "" || strings.HasSuffix(name, "/") {
+		return apierror.New(apierror.CodeFileNotFound, "File not found")
 	}
 	
-	fileIDParam := c.Params("fileId")
-	fileID, err := uuid.Parse(fileIDParam)
+	current, err := website.Current(c.UserContext(), ctrl.dbContext, bucket.Id, name)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid file ID",
-		})
+		return apierror.Internal("Failed to look up file")
+	}
+	if current == nil {
+		return apierror.New(apierror.CodeFileNotFound, "File not found")
 	}
 	
+	c.Set("Content-Location", fmt.Sprintf("%s/file/%s/%s", APIPrefix, bucket.Id, current.Id))
+	if err := ctrl.serveFile(c, bucket.Id, current.Id); err != nil {
+		return err
+	}
+	// The ETag follows the version, so caches revalidate and pick up a newer upload
+	if strings.HasPrefix(string(c.Response().Header.Peek("Cache-Control")), "public") {
+		c.Set("Cache-Control", "public, no-cache")
+	}
+	return nil
+}
+
+// serveNamed serves the current version of the file named name, for requests to a bucket's custom
+// domain. Access and query parameters work as for ServeFile.
+func (ctrl *FileController) serveNamed(c *fiber.Ctx, bucketID uuid.UUID, name string) error {
+	current, err := website.Current(c.UserContext(), ctrl.dbContext, bucketID, name)
+	if err != nil {
+		return apierror.Internal("Failed to look up file")
+	}
+	if current == nil {
+		return apierror.New(apierror.CodeFileNotFound, "File not found")
+	}
+	return ctrl.serveFile(c, bucketID, current.Id)
+}
+
+func (ctrl *FileController) serveFile(c *fiber.Ctx, bucketID, fileID uuid.UUID) error {
 	// First get file metadata to check access rules
 	command := &file.GetFileCommand{
 		FileID:   fileID,
 		BucketID: bucketID,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	getFileResponse := response.(*file.GetFileResponse)
@@ -273,54 +570,58 @@ func (ctrl *FileController) ServeFile(c *fiber.Ctx) error {
 	// Get bucket information to check public_read setting using static typing
 	bucket, err := ctrl.dbContext.Buckets.First(&entities.Bucket{Id: bucketID})
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Bucket not found",
-		})
+		return apierror.New(apierror.CodeBucketNotFound, "Bucket not found")
 	}
 	
-	// Check if authentication is required
-	// public_read: true means files can be read without authentication
-	// public_read: false means authentication is required for reading
-	requiresAuth := !bucket.Settings.PublicRead
+	requiresAuth, err := ctrl.authorizeRead(c, bucket, fileInfo.ID)
+	if err != nil {
+		return err
+	}
 	
-	if requiresAuth {
-		// Check for API key or signed URL
-		apiKey := c.Get("X-API-Key")
-		signedToken := c.Query("signature")
-		
-		if signedToken != "" {
-			// Validate signature and mark as used if single-use (simple approach)
-			signedURL, err := ctrl.signatureService.ValidateSignatureOnly(signedToken)
-			if err != nil {
-				return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-					"error": "Invalid or expired signed URL",
-				})
-			}
-			
-			// If it's single-use, mark as used on first access
-			if signedURL.SingleUse && !signedURL.Used {
-				if err := ctrl.signatureService.MarkSignatureAsUsed(signedToken); err != nil {
-					return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-						"error": "Failed to mark signature as used",
-					})
+	// Public files may only be embedded in the pages the bucket allows, the placeholder always can
+	if referers := hotlink.Decode(bucket.Settings.HotlinkReferers); !requiresAuth && len(referers) > 0 {
+		c.Vary("Origin", "Referer")
+		placeholder := bucket.Settings.HotlinkPlaceholderId
+		if placeholder == nil || *placeholder != fileID {
+			if err := hotlink.Check(referers, bucket.Settings.HotlinkBlockEmpty, c.Get("Origin"), c.Get("Referer")); err != nil {
+				if placeholder != nil {
+					return ctrl.serveFile(c, bucketID, *placeholder)
 				}
+				return err
 			}
-		} else if apiKey != "" {
-			// Validate API key
-			if !ctrl.validateAPIKey(apiKey, bucketID) {
-				return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-					"error": "Invalid or expired API key",
-				})
-			}
+		}
+	}
+	
+	if fileInfo.Metadata.ScanStatus == scanning.StatusInfected {
+		return apierror.Forbidden(fmt.Sprintf("File is quarantined, malware detected: %s", fileInfo.Metadata.ScanSignature))
+	}
+	
+	decision := ctrl.meter.Begin(c, bucket, downloadAPIKey(c))
+	if decision.Blocked() {
+		return egressQuotaExceeded(c, decision)
+	}
+	
+	// Headers the bucket and the file set replace the defaults set below
+	served := headers.Resolve(headers.Decode(bucket.Settings.DefaultHeaders), fileInfo.Metadata.CacheControl,
+		fileInfo.Metadata.ContentDisposition, fileInfo.Metadata.Headers, fileInfo.Name)
+	
+	if fileInfo.CustomerKeyMD5 != "" {
+		return ctrl.serveCustomerEncrypted(c, &fileInfo, served)
+	}
+	
+	// Video poster frames are generated ahead of time by the video worker
+	if c.QueryBool("thumbnail") {
+		if _, err := ctrl.readyVideoAsset(bucket, fileInfo.ID, fileInfo.MimeType); err != nil {
+			return err
+		}
+		
+		if requiresAuth {
+			c.Set("Cache-Control", "private, no-cache")
 		} else {
-			// Check JWT auth as fallback
-			_, err := ctrl.authService.AuthorizeRequest(c)
-			if err != nil {
-				return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-					"error": "Authentication required. Use API key, signed URL, or JWT token.",
-				})
-			}
+			c.Set("Cache-Control", "public, max-age=31536000")
 		}
+		c.Set("Content-Type", "image/jpeg")
+		return c.SendFile(filepath.Join(ctrl.derivedCache.Dir(fileID), "thumbnail.jpg"))
 	}
 	
 	// Check for image scaling parameters
@@ -359,28 +660,94 @@ func (ctrl *FileController) ServeFile(c *fiber.Ctx) error {
 		}
 	}
 	
-	// Check if this is an image and scaling is requested
-	isImage := strings.HasPrefix(fileInfo.MimeType, "image/")
-	needsProcessing := isImage && (width > 0 || height > 0 || resolution != "" || quality != 85)
+	// Output format: explicit format parameter, otherwise negotiated from Accept for transformed images
+	limits := media.CurrentImageLimits()
+	format := c.Query("format")
+	if format != "" {
+		if format = media.NormalizeFormat(format); format == "" {
+			return apierror.Invalid("Invalid format. Supported formats: webp, avif, png, jpeg")
+		}
+		if _, ok := ctrl.imageEncoders.Get(format); !ok {
+			return apierror.Invalid(fmt.Sprintf("Output format %s is not available on this server", format))
+		}
+		if !limits.FormatAllowed(format) {
+			return apierror.Invalid(fmt.Sprintf("Output format %s is not allowed for image transforms", format))
+		}
+	}
+	
+	// Check if this is an image and scaling is requested, an empty file has no image to scale and
+	// content uploaded already encoded has none to decode
+	isImage := strings.HasPrefix(fileInfo.MimeType, "image/") && fileInfo.Size > 0 && served[headers.ContentEncoding] == ""
+	needsProcessing := isImage && (width > 0 || height > 0 || resolution != "" || quality != 85 || format != "")
+	
+	if needsProcessing {
+		if !limits.Enabled || bucket.Settings.DisableImageTransforms {
+			return apierror.Forbidden("Image transforms are disabled for this bucket")
+		}
+		if width < 0 || height < 0 || quality < 1 || quality > 100 {
+			return apierror.Invalid("width and height must be positive and quality between 1 and 100")
+		}
+		if err := limits.CheckOutput(width, height); err != nil {
+			return apierror.Invalid(err.Error())
+		}
+	}
+	
+	if needsProcessing && format == "" {
+		c.Vary("Accept")
+		format = ctrl.imageEncoders.Negotiate(c.Get("Accept"), limits)
+	}
+	
+	// Validators for conditional requests. Stored files never change in place, so the upload time is the last modification
+	etag := fileETag(&fileInfo)
+	lastModified := fileInfo.CreatedAt.UTC().Format(http.TimeFormat)
 	
 	if needsProcessing {
+		// Each transform of the file is cached and validated separately
+		variant := storage.VariantKey(fmt.Sprintf("%s|w=%d&h=%d&q=%d&f=%s", etag, width, height, quality, format))
+		
+		c.Set("ETag", fmt.Sprintf("\"%s\"", variant))
+		c.Set("Last-Modified", lastModified)
+		c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileInfo.Name))
+		
+		// Set cache headers based on access level
+		if requiresAuth {
+			c.Set("Cache-Control", "private, no-cache")
+		} else {
+			c.Set("Cache-Control", "public, max-age=3600") // Cache processed images for 1 hour
+		}
+		setServedHeaders(c, served, requiresAuth, false)
+		
+		if c.Fresh() {
+			return c.SendStatus(http.StatusNotModified)
+		}
+		
+		if cachedPath, cachedMimeType, ok := ctrl.derivedCache.Get(fileID, variant); ok {
+			c.Set("Content-Type", cachedMimeType)
+			return c.SendFile(cachedPath)
+		}
+		
+		// A variant isn't made to answer a HEAD, its type and length are known once a GET made it
+		if c.Method() == fiber.MethodHead {
+			return nil
+		}
+		
 		// Process the image
-		processedImage, outputMimeType, err := ctrl.processImage(fileInfo.Path, fileInfo.MimeType, width, height, quality)
+		processedImage, outputMimeType, err := ctrl.processImage(c.UserContext(), &fileInfo, width, height, quality, format, limits)
+		if errors.Is(err, media.ErrImageTooLarge) {
+			return err
+		}
 		if err != nil {
+			log.Printf("Warning: failed to process image %s, serving original: %v", fileID, err)
 			// Fallback to serving original file
 			needsProcessing = false
 		} else {
+			if _, err := ctrl.derivedCache.Put(fileID, variant, processedImage, outputMimeType); err != nil {
+				log.Printf("Warning: failed to cache processed image for file %s: %v", fileID, err)
+			}
+			
 			// Set headers for processed image
 			c.Set("Content-Type", outputMimeType)
 			c.Set("Content-Length", fmt.Sprintf("%d", len(processedImage)))
-			c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileInfo.Name))
-			
-			// Set cache headers based on access level
-			if requiresAuth {
-				c.Set("Cache-Control", "private, no-cache")
-			} else {
-				c.Set("Cache-Control", "public, max-age=3600") // Cache processed images for 1 hour
-			}
 			
 			// Send processed image
 			return c.Send(processedImage)
@@ -388,34 +755,96 @@ func (ctrl *FileController) ServeFile(c *fiber.Ctx) error {
 	}
 	
 	// Send original file (either not an image, no scaling requested, or processing failed)
-	c.Set("Content-Type", fileInfo.MimeType)
-	c.Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size))
+	// Compressed content goes out as stored to clients accepting its encoding, with its own validator
+	contentEncoding := fileInfo.Metadata.ContentEncoding
+	sendEncoded := contentEncoding != "" && storage.AcceptsEncoding(c.Get("Accept-Encoding"), contentEncoding)
+	if contentEncoding != "" {
+		c.Vary("Accept-Encoding")
+	}
+	if sendEncoded {
+		etag = encodedETag(etag, contentEncoding)
+	}
+	c.Set("ETag", etag)
+	c.Set("Last-Modified", lastModified)
 	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileInfo.Name))
+	setChecksumHeaders(c, fileInfo.Checksum)
 	
 	if requiresAuth {
 		c.Set("Cache-Control", "private, no-cache")
 	} else {
 		c.Set("Cache-Control", "public, max-age=31536000")
 	}
+	setServedHeaders(c, served, requiresAuth, contentEncoding == "")
 	
-	// Check if file is stored on a node (path starts with "node://")
-	if strings.HasPrefix(fileInfo.Path, "node://") {
-		// Extract node ID from path: node://nodeID/bucketID/fileID
-		pathParts := strings.Split(strings.TrimPrefix(fileInfo.Path, "node://"), "/")
-		if len(pathParts) >= 3 {
-			nodeID := pathParts[0]
-			// pathParts[1] is bucketID, pathParts[2] is fileID
-			
-			// Fetch file from storage node
-			fileData, err := ctrl.fetchFileFromNode(nodeID, bucketID, fileID, fileInfo.Name)
-			if err != nil {
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-					"error": fmt.Sprintf("Failed to fetch file from storage node: %v", err),
-				})
-			}
-			
-			return c.Send(fileData)
+	if c.Fresh() {
+		return c.SendStatus(http.StatusNotModified)
+	}
+	
+	c.Set("Content-Type", fileInfo.MimeType)
+
+	// An empty file has nothing to read, wherever it is stored and however it is encoded
+	if fileInfo.Size == 0 && !sendEncoded {
+		return c.Status(http.StatusOK).Send(nil)
+	}
+	
+	// A HEAD gets the headers a GET would, without the content being read. Only the plaintext size
+	// is recorded, so encoded content has no length.
+	if c.Method() == fiber.MethodHead {
+		if !sendEncoded {
+			c.Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size))
+		}
+		c.Status(http.StatusOK)
+		return nil
+	}
+
+	// Only the plaintext size is recorded, so encoded content is streamed without a length
+	if sendEncoded {
+		content, err := ctrl.openStored(c.UserContext(), &fileInfo)
+		if err != nil {
+			return apierror.Internal(fmt.Sprintf("Failed to read file: %v", err))
+		}
+		c.Set("Content-Encoding", contentEncoding)
+		return c.SendStream(content)
+	}
+	
+	// Large content stored as is on a node is downloaded from the node itself, a node holding a
+	// copy in the client's zone when the primary copy isn't there. Throttled downloads stay
+	// proxied, the master can't throttle a node, and so do files served with headers of their
+	// own, which a node doesn't send.
+	settings := config.GetSettings()
+	if !fileInfo.Encrypted && contentEncoding == "" && !decision.Exceeded && len(served) == 0 &&
+		c.Method() == fiber.MethodGet && settings.NodeRedirectDownloads && fileInfo.Size >= settings.NodeRedirectMinSize {
+		ttl := time.Duration(settings.NodeRedirectTTL) * time.Second
+		path := replicas.Nearest(c.UserContext(), ctrl.dbContext, fileInfo.ID, fileInfo.Path, replicas.ClientZone(c))
+		if location, err := storage.NodeDownloadURL(c.UserContext(), ctrl.dbContext, path, fileInfo.Name, fileInfo.MimeType, ttl); err == nil {
+			// The whole file is counted, the master doesn't see how much of it the node sends
+			ctrl.meter.Record(decision, fileInfo.Size)
+			c.Set("Cache-Control", "private, no-store")
+			return c.Redirect(location, http.StatusFound)
+		}
+	}
+	
+	c.Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size))
+	
+	// Encrypted and compressed content is decoded while it streams, wherever it is stored
+	if fileInfo.Encrypted || contentEncoding != "" {
+		content, err := ctrl.openContent(c.UserContext(), &fileInfo)
+		if err != nil {
+			return apierror.Internal(fmt.Sprintf("Failed to read file: %v", err))
+		}
+		return c.SendStream(content, int(fileInfo.Size))
+	}
+	
+	// Content on a storage node streams from the node cache, or from the node filling it, one in
+	// the master's zone when the file has a copy there
+	if storage.IsNodePath(fileInfo.Path) {
+		path := replicas.Nearest(c.UserContext(), ctrl.dbContext, fileInfo.ID, fileInfo.Path, placement.Zone(nil))
+		content, err := storage.OpenPath(c.UserContext(), ctrl.dbContext, path, fileInfo.Name)
+		if err != nil {
+			return apierror.Internal(fmt.Sprintf("Failed to fetch file from storage node: %v", err))
 		}
+		
+		return c.SendStream(content, int(fileInfo.Size))
 	}
 	
 	return c.SendFile(fileInfo.Path)
@@ -423,7 +852,7 @@ func (ctrl *FileController) ServeFile(c *fiber.Ctx) error {
 
 
 //	@Summary		List files in bucket
-//	@Description	Get a list of all files in a specific bucket
+//	@Description	Get a list of all files in a specific bucket, newest first unless sort_by (name, size, mime_type, version, created_at, updated_at) says otherwise
 //	@Tags			files
 //	@Accept			json
 //	@Produce		json
@@ -432,34 +861,54 @@ func (ctrl *FileController) ServeFile(c *fiber.Ctx) error {
 //	@Param			bucketId	path		string	true	"Bucket ID"
 //	@Param			page		query		int		false	"Page number"		default(1)
 //	@Param			limit		query		int		false	"Items per page"	default(10)
+//	@Param			sort_by			query		string		false	"Field to sort by"
+//	@Param			order			query		string		false	"asc or desc"
+//	@Param			name			query		string		false	"Only names containing this, case-insensitively"
+//	@Param			created_after	query		string		false	"Only created at or after this RFC 3339 time"
+//	@Param			created_before	query		string		false	"Only created before this RFC 3339 time"
+//	@Param			cursor			query		string		false	"The next_cursor of the previous page, instead of page"
+//	@Param			mime_type		query		string		false	"Only this MIME type, or a type/* family"
+//	@Param			min_size		query		int			false	"Only files of at least this many bytes"
+//	@Param			max_size		query		int			false	"Only files of at most this many bytes"
 //	@Success		200			{object}	file.ListFilesResponse	"Files retrieved successfully"
-//	@Failure		400			{object}	map[string]string		"Bad request"
-//	@Failure		401			{object}	map[string]string		"Unauthorized"
-//	@Failure		404			{object}	map[string]string		"Bucket not found"
+//	@Failure		400			{object}	apierror.Error			"Bad request"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		404			{object}	apierror.Error			"Bucket not found"
 //	@Router			/buckets/{bucketId}/files [get]
 func (ctrl *FileController) ListFiles(c *fiber.Ctx) error {
-	bucketIDParam := c.Params("bucketId")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "bucketId")
 	
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 	
+	list, err := parseListQuery(c)
+	if err != nil {
+		return err
+	}
+	minSize, err := parseSizeQuery(c, "min_size")
+	if err != nil {
+		return err
+	}
+	maxSize, err := parseSizeQuery(c, "max_size")
+	if err != nil {
+		return err
+	}
+	
+	list.Cursor = c.Query("cursor")
+	
 	command := &file.ListFilesCommand{
 		BucketID: bucketID,
 		Page:     page,
 		Limit:    limit,
+		List:     list,
+		MimeType: c.Query("mime_type"),
+		MinSize:  minSize,
+		MaxSize:  maxSize,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	listFilesResponse := response.(*file.ListFilesResponse)
@@ -477,49 +926,27 @@ func (ctrl *FileController) ListFiles(c *fiber.Ctx) error {
 //	@Param			fileId		path		string	true	"File ID"
 //	@Param			request		body		object	true	"Signed URL generation parameters"	example({"expires_in":3600,"single_use":false})
 //	@Success		200			{object}	file.GenerateSignedURLResponse	"Signed URL generated successfully"
-//	@Failure		400			{object}	map[string]string				"Bad request"
-//	@Failure		401			{object}	map[string]string				"Unauthorized"
-//	@Failure		404			{object}	map[string]string				"File not found"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Failure		404			{object}	apierror.Error					"File not found"
 //	@Router			/buckets/{bucketId}/files/{fileId}/signed-url [post]
 func (ctrl *FileController) GenerateSignedURL(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
-	bucketIDParam := c.Params("bucketId")
-	bucketID, err := uuid.Parse(bucketIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID",
-		})
-	}
+	bucketID := binding.UUID(c, "bucketId")
 	
-	fileIDParam := c.Params("fileId")
-	fileID, err := uuid.Parse(fileIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid file ID",
-		})
-	}
+	fileID := binding.UUID(c, "fileId")
 	
 	var request struct {
 		ExpiresIn int  `json:"expires_in" validate:"required,min=60,max=604800"` // 1 minute to 7 days
 		SingleUse bool `json:"single_use"`                                        // Optional single-use checkbox
 	}
 	
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&request); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &request); err != nil {
+		return err
 	}
 	
 	command := &file.GenerateSignedURLCommand{
@@ -530,38 +957,192 @@ func (ctrl *FileController) GenerateSignedURL(c *fiber.Ctx) error {
 		SingleUse: request.SingleUse,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	signedURLResponse := response.(*file.GenerateSignedURLResponse)
 	return c.JSON(signedURLResponse)
 }
 
-// validateAPIKey validates an API key and checks permissions
-func (ctrl *FileController) validateAPIKey(apiKey string, bucketID uuid.UUID) bool {
-	// Hash the provided API key
-	hash := sha256.Sum256([]byte(apiKey))
-	keyHash := hex.EncodeToString(hash[:])
-	
-	// Find API key in database using GoNtext
-	dbAPIKey, err := ctrl.dbContext.APIKeys.Where(&entities.APIKey{KeyHash: keyHash, IsActive: true}).FirstOrDefault()
-	if err != nil || dbAPIKey == nil {
-		return false
+//	@Summary		Create file token
+//	@Description	Create a read-only token for exactly one file. Unlike a signed URL it doesn't expire: it works until revoked or until the file is deleted. The secret is only returned once
+//	@Tags			files
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Param			request		body		file.CreateFileTokenCommand		false	"Token name"
+//	@Success		201			{object}	file.CreateFileTokenResponse	"File token created"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{bucketId}/files/{fileId}/tokens [post]
+func (ctrl *FileController) CreateFileToken(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
 	}
-	
-	// Check if API key has expired
-	if dbAPIKey.ExpiresAt != nil && dbAPIKey.ExpiresAt.Before(time.Now()) {
-		return false
+
+	bucketID := binding.UUID(c, "bucketId")
+
+	fileID := binding.UUID(c, "fileId")
+
+	var command file.CreateFileTokenCommand
+	if len(c.Body()) > 0 {
+		if err := binding.Parse(c, &command); err != nil {
+			return err
+		}
 	}
-	
-	// Check bucket permissions (if specific buckets are specified)
-	var permissions entities.APIKeyPermission
-	if err := json.Unmarshal(dbAPIKey.Permissions, &permissions); err != nil {
-		return false
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.FileID = fileID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	createFileTokenResponse := response.(*file.CreateFileTokenResponse)
+	return c.Status(http.StatusCreated).JSON(createFileTokenResponse)
+}
+
+//	@Summary		List file tokens
+//	@Description	List the tokens issued for a file, revoked ones included. Secrets are never returned
+//	@Tags			files
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Success		200			{object}	file.ListFileTokensResponse		"File tokens"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{bucketId}/files/{fileId}/tokens [get]
+func (ctrl *FileController) ListFileTokens(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+
+	fileID := binding.UUID(c, "fileId")
+
+	command := &file.ListFileTokensCommand{
+		BucketID: bucketID,
+		FileID:   fileID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	listFileTokensResponse := response.(*file.ListFileTokensResponse)
+	return c.JSON(listFileTokensResponse)
+}
+
+//	@Summary		Revoke file token
+//	@Description	Revoke a file token, requests using it are refused from then on
+//	@Tags			files
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string							true	"Bucket ID"
+//	@Param			fileId		path		string							true	"File ID"
+//	@Param			tokenId		path		string							true	"Token ID"
+//	@Success		200			{object}	file.RevokeFileTokenResponse	"File token revoked"
+//	@Failure		400			{object}	apierror.Error					"Bad request"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{bucketId}/files/{fileId}/tokens/{tokenId} [delete]
+func (ctrl *FileController) RevokeFileToken(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+
+	fileID := binding.UUID(c, "fileId")
+
+	tokenID := binding.UUID(c, "tokenId")
+
+	command := &file.RevokeFileTokenCommand{
+		BucketID: bucketID,
+		FileID:   fileID,
+		TokenID:  tokenID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	revokeFileTokenResponse := response.(*file.RevokeFileTokenResponse)
+	return c.JSON(revokeFileTokenResponse)
+}
+
+// fileETag builds the strong ETag of a served file, as entities.File.ETag does
+func fileETag(fileInfo *models.FileResponse) string {
+	return (&entities.File{Id: fileInfo.ID, Checksum: fileInfo.Checksum, Size: fileInfo.Size, Version: fileInfo.Version}).ETag()
+}
+
+// setServedHeaders sets the response headers a bucket and a file set. Content that needs
+// authentication stays out of shared caches, so only a private Cache-Control replaces the default
+// for it. A declared Content-Encoding only goes out with content sent as it was uploaded.
+func setServedHeaders(c *fiber.Ctx, served map[string]string, requiresAuth, asUploaded bool) {
+	for name, value := range served {
+		switch {
+		case name == headers.CacheControl && requiresAuth && !headers.Private(value):
+		case name == headers.ContentEncoding && !asUploaded:
+		default:
+			c.Set(name, value)
+		}
+	}
+}
+
+// encodedETag derives the validator of a file's content sent compressed with encoding from its plain etag
+func encodedETag(etag, encoding string) string {
+	return strings.TrimSuffix(etag, "\"") + "-" + encoding + "\""
+}
+
+// errInvalidAPIKey is returned for API keys that don't exist, expired or can't read the bucket
+var errInvalidAPIKey = errors.New("invalid or expired API key")
+
+// validateAPIKey validates an API key used from clientIP, checks permissions and returns the key
+func (ctrl *FileController) validateAPIKey(apiKey string, bucketID uuid.UUID, clientIP string) (*entities.APIKey, error) {
+	// Hash the provided API key
+	hash := sha256.Sum256([]byte(apiKey))
+	keyHash := hex.EncodeToString(hash[:])
+	
+	// Find API key in database using GoNtext
+	dbAPIKey, err := ctrl.dbContext.APIKeys.Where(&entities.APIKey{KeyHash: keyHash, IsActive: true}).FirstOrDefault()
+	if err != nil || dbAPIKey == nil {
+		return nil, errInvalidAPIKey
+	}
+	
+	// Check if API key has expired
+	if dbAPIKey.ExpiresAt != nil && dbAPIKey.ExpiresAt.Before(time.Now()) {
+		return nil, errInvalidAPIKey
+	}
+	
+	// Check bucket permissions (if specific buckets are specified)
+	var permissions entities.APIKeyPermission
+	if err := json.Unmarshal(dbAPIKey.Permissions, &permissions); err != nil {
+		return nil, errInvalidAPIKey
 	}
 	
 	// If buckets array is specified, check if this bucket is allowed
@@ -574,22 +1155,296 @@ func (ctrl *FileController) validateAPIKey(apiKey string, bucketID uuid.UUID) bo
 			}
 		}
 		if !bucketAllowed {
-			return false
+			return nil, errInvalidAPIKey
 		}
 	}
 	
 	// Check if API key has read permission
-	return permissions.Read
+	if !permissions.Read {
+		return nil, errInvalidAPIKey
+	}
+
+	// Check the key may be used from the client's address
+	if err := ipfilter.Check(clientIP, permissions.AllowedIPs, permissions.DeniedIPs); err != nil {
+		ipfilter.Audit("API key "+dbAPIKey.KeyPrefix, clientIP, err)
+		return nil, err
+	}
+	return dbAPIKey, nil
+}
+
+// publicReadRefused returns why the client is refused public reads of the bucket, nil when it isn't
+func publicReadRefused(c *fiber.Ctx, bucket *entities.Bucket) error {
+	return ipfilter.Check(c.IP(), ipfilter.Decode(bucket.Settings.PublicReadAllowedIPs), ipfilter.Decode(bucket.Settings.PublicReadDeniedIPs))
+}
+
+// validateFileToken checks that a file token is live and grants access to the given file
+func (ctrl *FileController) validateFileToken(token string, fileID uuid.UUID) bool {
+	hash := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(hash[:])
+
+	fileToken, err := ctrl.dbContext.FileTokens.Where(&entities.FileToken{TokenHash: tokenHash}).FirstOrDefault()
+	if err != nil || fileToken == nil || fileToken.RevokedAt != nil || fileToken.FileId != fileID {
+		return false
+	}
+
+	// Usage is tracked to the minute so embedded files don't write on every request
+	now := time.Now()
+	if fileToken.LastUsedAt == nil || now.Sub(*fileToken.LastUsedAt) > time.Minute {
+		markFileTokenUsed(ctrl.dbContext.GetDB(), fileToken.Id, now)
+	}
+	return true
+}
+
+// markFileTokenUsed records when a file token was last used
+func markFileTokenUsed(db *gorm.DB, tokenID uuid.UUID, now time.Time) error {
+	return db.Model(&entities.FileToken{}).Where(`"Id" = ?`, tokenID).Update("LastUsedAt", now).Error
+}
+
+//	@Summary		Stream video over HLS
+//	@Description	Serve the HLS master playlist, rendition playlists and segments generated for a video. Requires video processing on the bucket. Query parameters (e.g. signature) are carried over to playlist entries; use a multi-use signed URL
+//	@Tags			files
+//	@Produce		application/vnd.apple.mpegurl
+//	@Produce		video/mp2t
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string	true	"Bucket ID"
+//	@Param			fileId		path		string	true	"File ID"
+//	@Param			path		path		string	true	"Playlist or segment path, starting with master.m3u8"
+//	@Param			signature	query		string	false	"Signed URL signature for temporary access"
+//	@Param			token		query		string	false	"File token granting read access to the video until revoked"
+//	@Success		200			"Playlist or segment"
+//	@Failure		401			{object}	apierror.Error			"Unauthorized"
+//	@Failure		404			{object}	apierror.Error			"Not found or not processed yet"
+//	@Router			/file/{bucketId}/{fileId}/hls/{path} [get]
+func (ctrl *FileController) ServeHLS(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "bucketId")
+	
+	fileID := binding.UUID(c, "fileId")
+	
+	// Only the generated playlist tree is reachable
+	relPath := filepath.Clean("/" + c.Params("*"))[1:]
+	if relPath == "" {
+		relPath = media.HLSMasterPlaylist
+	}
+	
+	fileInfo, err := ctrl.dbContext.Files.Where(&entities.File{Id: fileID, BucketId: bucketID}).FirstOrDefault()
+	if err != nil || fileInfo == nil {
+		return apierror.NotFound("file not found")
+	}
+	
+	bucket, err := ctrl.dbContext.Buckets.First(&entities.Bucket{Id: bucketID})
+	if err != nil {
+		return apierror.New(apierror.CodeBucketNotFound, "Bucket not found")
+	}
+	
+	requiresAuth, err := ctrl.authorizeRead(c, bucket, fileID)
+	if err != nil {
+		return err
+	}
+	
+	if decision := ctrl.meter.Begin(c, bucket, downloadAPIKey(c)); decision.Blocked() {
+		return egressQuotaExceeded(c, decision)
+	}
+	
+	if _, err := ctrl.readyVideoAsset(bucket, fileID, fileInfo.MimeType); err != nil {
+		return err
+	}
+	
+	fullPath := filepath.Join(ctrl.derivedCache.Dir(fileID), "hls", relPath)
+	
+	if requiresAuth {
+		c.Set("Cache-Control", "private, no-cache")
+	} else {
+		c.Set("Cache-Control", "public, max-age=31536000")
+	}
+	
+	if !strings.HasSuffix(relPath, ".m3u8") {
+		if _, err := os.Stat(fullPath); err != nil {
+			return apierror.NotFound("Segment not found")
+		}
+		c.Set("Content-Type", "video/mp2t")
+		return c.SendFile(fullPath)
+	}
+	
+	playlist, err := os.ReadFile(fullPath)
+	if err != nil {
+		return apierror.NotFound("Playlist not found")
+	}
+	
+	// Players resolve entries relative to the playlist and drop its query string,
+	// so credentials such as the signature are appended to every entry
+	if query := string(c.Request().URI().QueryString()); query != "" {
+		lines := strings.Split(string(playlist), "\n")
+		for i, line := range lines {
+			if line != "" && !strings.HasPrefix(line, "#") {
+				lines[i] = line + "?" + query
+			}
+		}
+		playlist = []byte(strings.Join(lines, "\n"))
+	}
+	
+	c.Set("Content-Type", "application/vnd.apple.mpegurl")
+	return c.Send(playlist)
+}
+
+// readyVideoAsset returns the processed video asset of a file, or the error to respond with
+func (ctrl *FileController) readyVideoAsset(bucket *entities.Bucket, fileID uuid.UUID, mimeType string) (*entities.VideoAsset, error) {
+	if !bucket.Settings.VideoProcessing {
+		return nil, apierror.NotFound("Video processing is not enabled for this bucket")
+	}
+	if !strings.HasPrefix(mimeType, "video/") {
+		return nil, apierror.Invalid("Thumbnails and HLS are only available for videos")
+	}
+	
+	asset, err := ctrl.dbContext.VideoAssets.Where(&entities.VideoAsset{FileId: fileID}).FirstOrDefault()
+	if err != nil || asset == nil {
+		return nil, apierror.NotFound("Video has not been processed")
+	}
+	if asset.Status != "ready" {
+		return nil, apierror.Newf(apierror.CodeNotFound, "Video processing is %s", asset.Status)
+	}
+	
+	return asset, nil
+}
+
+// authorizeRead checks that the request may read a file from the bucket.
+// It reports whether the bucket requires authentication, and on failure the error to respond with.
+func (ctrl *FileController) authorizeRead(c *fiber.Ctx, bucket *entities.Bucket, fileID uuid.UUID) (bool, error) {
+	// public_read: true means files can be read without authentication
+	// public_read: false means authentication is required for reading
+	requiresAuth := !bucket.Settings.PublicRead
+
+	// Check for API key, file token or signed URL
+	apiKey := c.Get("X-API-Key")
+	signedToken := c.Query("signature")
+	fileToken := c.Query("token")
+
+	// Public reads can be limited to IP ranges, requests with credentials are authorized as usual
+	if !requiresAuth {
+		err := publicReadRefused(c, bucket)
+		if err == nil {
+			return false, nil
+		}
+		if apiKey == "" && signedToken == "" && fileToken == "" && c.Get("Authorization") == "" {
+			ipfilter.Audit("public read of bucket "+bucket.Name, c.IP(), err)
+			return true, err
+		}
+	}
+
+	if fileToken != "" {
+		if !ctrl.validateFileToken(fileToken, fileID) {
+			return true, apierror.Unauthorized("Invalid or revoked file token")
+		}
+	} else if signedToken != "" {
+		// Validate signature and mark as used if single-use (simple approach). A signed URL only
+		// opens the file it was issued for, whichever path the file is served at.
+		signedURL, err := ctrl.signatureService.ValidateSignatureForFile(c.UserContext(), signedToken, fileID)
+		if errors.Is(err, file.ErrSignedForOtherFile) {
+			return true, err
+		} else if err != nil {
+			return true, apierror.Unauthorized("Invalid or expired signed URL")
+		}
+
+		// If it's single-use, mark as used on first access
+		if signedURL.SingleUse && !signedURL.Used {
+			if err := ctrl.signatureService.MarkSignatureAsUsed(signedToken); err != nil {
+				return true, apierror.Internal("Failed to mark signature as used")
+			}
+		}
+	} else if apiKey != "" {
+		// Validate API key
+		dbAPIKey, err := ctrl.validateAPIKey(apiKey, bucket.Id, c.IP())
+		if errors.Is(err, ipfilter.ErrDenied) {
+			return true, err
+		} else if err != nil {
+			return true, apierror.Unauthorized("Invalid or expired API key")
+		}
+		// The download counts toward the key's egress quotas
+		c.Locals(downloadAPIKeyLocal, dbAPIKey.Id)
+	} else {
+		// Check JWT auth as fallback
+		if _, err := ctrl.authService.AuthorizeRequest(c); err != nil {
+			return true, apierror.Unauthorized("Authentication required. Use API key, file token, signed URL, or JWT token.")
+		}
+	}
+
+	return true, nil
+}
+
+// serveCustomerEncrypted streams a file encrypted with a customer-provided key, which the request must supply.
+// The server never holds the plaintext, so transforms aren't available and responses are never cached.
+func (ctrl *FileController) serveCustomerEncrypted(c *fiber.Ctx, fileInfo *models.FileResponse, served map[string]string) error {
+	customerKey, err := customerKeyFromRequest(c)
+	if err != nil {
+		return err
+	}
+	
+	if c.QueryBool("thumbnail") || c.Query("width") != "" || c.Query("height") != "" || c.Query("resolution") != "" || c.Query("format") != "" {
+		return apierror.Invalid("Transformations are not available for files encrypted with a customer-provided key")
+	}
+	
+	stored, err := ctrl.dbContext.Files.Where(&entities.File{Id: fileInfo.ID}).FirstOrDefault()
+	if err != nil || stored == nil {
+		return apierror.New(apierror.CodeFileNotFound, "File not found")
+	}
+	
+	content, err := storage.OpenFileWithKey(c.UserContext(), ctrl.dbContext, stored, customerKey)
+	if err != nil {
+		return err
+	}
+	
+	setCustomerKeyHeaders(c, fileInfo.CustomerKeyMD5)
+	setChecksumHeaders(c, fileInfo.Checksum)
+	c.Set("ETag", fileETag(fileInfo))
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileInfo.Name))
+	setServedHeaders(c, served, true, true)
+	c.Set("Cache-Control", "private, no-store")
+	c.Set("Content-Type", fileInfo.MimeType)
+	return c.SendStream(content, int(fileInfo.Size))
+}
+
+// openContent opens a file's content, decrypting it when the file is encrypted and decompressing it when compressed
+func (ctrl *FileController) openContent(ctx context.Context, fileInfo *models.FileResponse) (io.ReadCloser, error) {
+	content, err := ctrl.openStored(ctx, fileInfo)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Decompress(fileInfo.Metadata.ContentEncoding, content)
 }
 
+// openStored opens a file's content as stored, decrypted when the file is encrypted but still compressed
+func (ctrl *FileController) openStored(ctx context.Context, fileInfo *models.FileResponse) (io.ReadCloser, error) {
+	if !fileInfo.Encrypted {
+		return storage.OpenPath(ctx, ctrl.dbContext, fileInfo.Path, fileInfo.Name)
+	}
 
-// processImage processes an image file with scaling parameters
-func (ctrl *FileController) processImage(filePath, mimeType string, width, height, quality int) ([]byte, string, error) {
-	// Open the image file
-	src, err := imaging.Open(filePath)
+	stored, err := ctrl.dbContext.Files.Where(&entities.File{Id: fileInfo.ID}).FirstOrDefault()
+	if err != nil || stored == nil {
+		return nil, fmt.Errorf("file not found")
+	}
+	return storage.OpenStored(ctx, ctrl.dbContext, stored, nil)
+}
+
+// processImage processes an image file with scaling parameters.
+// Node-stored files are streamed from their node, so transforms behave the same regardless of placement.
+// An empty format keeps PNGs that aren't resized as PNG and converts everything else to JPEG.
+func (ctrl *FileController) processImage(ctx context.Context, fileInfo *models.FileResponse, width, height, quality int, format string, limits media.ImageLimits) ([]byte, string, error) {
+	mimeType := fileInfo.MimeType
+
+	// Open the image file, locally or from its storage node
+	reader, err := ctrl.openContent(ctx, fileInfo)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to open image: %w", err)
 	}
+	defer reader.Close()
+
+	src, err := limits.Decode(reader)
+	if errors.Is(err, media.ErrImageTooLarge) {
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
 
 	// Get original dimensions
 	bounds := src.Bounds()
@@ -620,176 +1475,126 @@ func (ctrl *FileController) processImage(filePath, mimeType string, width, heigh
 		height = 1
 	}
 
+	// A side derived from the aspect ratio can still exceed the limit
+	if err := limits.CheckOutput(width, height); err != nil {
+		return nil, "", err
+	}
+
 	// Only scale if dimensions are different
 	var processed image.Image = src
 	if width != originalWidth || height != originalHeight {
 		processed = imaging.Resize(src, width, height, imaging.Lanczos)
 	}
 
-	// Encode to bytes
-	var buf []byte
-	var outputMimeType string
-
 	// Determine output format and quality
 	if quality == 0 {
 		quality = 85 // Default quality
 	}
 
-	// Convert to JPEG for better compression if quality is specified or if scaling was applied
-	if strings.Contains(strings.ToLower(mimeType), "png") && (width == originalWidth && height == originalHeight) {
+	var encoder media.ImageEncoder
+	if format != "" {
+		var ok bool
+		if encoder, ok = ctrl.imageEncoders.Get(format); !ok {
+			return nil, "", fmt.Errorf("output format %s is not available", format)
+		}
+	} else if strings.Contains(strings.ToLower(mimeType), "png") && (width == originalWidth && height == originalHeight) && limits.FormatAllowed("png") {
 		// Keep as PNG if no scaling and original is PNG
-		buf, err = encodePNG(processed)
-		outputMimeType = "image/png"
-	} else {
+		encoder, _ = ctrl.imageEncoders.Get("png")
+	} else if limits.FormatAllowed("jpeg") {
 		// Convert to JPEG for scaling or if quality parameter is used
-		buf, err = encodeJPEG(processed, quality)
-		outputMimeType = "image/jpeg"
+		encoder, _ = ctrl.imageEncoders.Get("jpeg")
+	} else {
+		// Otherwise the first allowed format available on this server
+		for _, allowed := range limits.AllowedFormats {
+			if allowedEncoder, ok := ctrl.imageEncoders.Get(allowed); ok {
+				encoder = allowedEncoder
+				break
+			}
+		}
+		if encoder == nil {
+			return nil, "", fmt.Errorf("no allowed output format is available")
+		}
 	}
 
+	buf, err := encoder.Encode(processed, quality)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to encode image: %w", err)
 	}
+	outputMimeType := encoder.MimeType()
 
 	return buf, outputMimeType, nil
 }
 
-// encodeJPEG encodes an image to JPEG with specified quality
-func encodeJPEG(img image.Image, quality int) ([]byte, error) {
-	buf := make([]byte, 0)
-	w := &bytesWriter{buf: &buf}
-	
-	options := &jpeg.Options{Quality: quality}
-	err := jpeg.Encode(w, img, options)
-	return buf, err
-}
-
 //	@Summary		Internal upload for distributed storage
 //	@Description	Receives files from master node for storage on this node
 //	@Tags			files
 //	@Accept			multipart/form-data
 //	@Produce		json
 //	@Security		Bearer
-//	@Param			file		formData	file	true	"File to upload"
-//	@Param			bucket_id	formData	string	true	"Bucket ID"
-//	@Param			file_id		formData	string	true	"File ID"
-//	@Param			filename	formData	string	true	"Original filename"
-//	@Success		200			{object}	map[string]interface{}	"Upload successful"
-//	@Failure		400			{object}	map[string]string		"Bad request"
-//	@Failure		401			{object}	map[string]string		"Unauthorized"
+//	@Param			X-SHBucket-Internal-API	header		int		false	"Internal API version of the master"
+//	@Param			file					formData	file	true	"File to upload"
+//	@Param			bucket_id				formData	string	true	"Bucket ID"
+//	@Param			bucket_name				formData	string	true	"Bucket name"
+//	@Param			file_id					formData	string	true	"File ID"
+//	@Param			filename				formData	string	true	"Original filename"
+//	@Param			content_type			formData	string	false	"Content type"
+//	@Success		200						{object}	internalapi.Envelope[internalapi.UploadResult]	"Upload successful"
+//	@Failure		400						{object}	apierror.Error			"Bad request"
+//	@Failure		401						{object}	apierror.Error			"Unauthorized"
 //	@Router			/internal/upload [post]
 func (ctrl *FileController) InternalUpload(c *fiber.Ctx) error {
-	// Validate node auth key from Authorization header
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Missing Authorization header",
-		})
-	}
-	
-	// Extract Bearer token (auth key)
-	var authKey string
-	if strings.HasPrefix(authHeader, "Bearer ") {
-		authKey = strings.TrimPrefix(authHeader, "Bearer ")
-	} else {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid Authorization header format",
-		})
-	}
-	
-	// Validate auth key against node setup config
-	nodeConfig, err := ctrl.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "node"}).FirstOrDefault()
-	if err != nil || nodeConfig == nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Node configuration not found",
-		})
-	}
-	
-	// Parse ConfigData JSON to get node_auth_key
-	var configData map[string]interface{}
-	if err := json.Unmarshal(nodeConfig.ConfigData, &configData); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to parse node configuration",
-		})
-	}
-	
-	nodeAuthKey, ok := configData["node_auth_key"].(string)
-	if !ok || nodeAuthKey == "" {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Node auth key not found in configuration",
-		})
-	}
-	
-	if nodeAuthKey != authKey {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid auth key",
-		})
+	nodeConfig, status, err := ctrl.authorizeInternal(c)
+	if err != nil {
+		return c.Status(status).JSON(internalapi.Failure(err.Error()))
 	}
-	
+
 	// Get file from multipart form
-	file, err := c.FormFile("file")
+	file, err := c.FormFile(internalapi.FileField)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "No file provided",
-		})
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("No file provided"))
 	}
 
-	// Get metadata from form
-	bucketID := c.FormValue("bucket_id")
-	bucketName := c.FormValue("bucket_name")
-	fileID := c.FormValue("file_id")
-	filename := c.FormValue("filename")
-
-	if bucketID == "" || bucketName == "" || fileID == "" || filename == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing required metadata (bucket_id, bucket_name, file_id, filename)",
-		})
+	request, err := internalapi.ParseUploadRequest(c.FormValue)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
 	}
 
 	// Use the same nodeConfig for storage path
 	storagePath := nodeConfig.StoragePath
 	if storagePath == "" {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Storage path not configured in node config",
-		})
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Storage path not configured in node config"))
 	}
 
 	// Create bucket directory using bucket name from form
-	storageDir := fmt.Sprintf("%s/%s", storagePath, bucketName)
+	storageDir := fmt.Sprintf("%s/%s", storagePath, request.BucketName)
 	if err := os.MkdirAll(storageDir, 0755); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create storage directory",
-		})
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to create storage directory"))
 	}
 
-	// Save file to local storage using node's configured path - just use fileID
-	filePath := fmt.Sprintf("%s/%s", storageDir, fileID)
-	if err := c.SaveFile(file, filePath); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to save file",
-		})
+	done, err := storage.BeginTransfer()
+	if err != nil {
+		return c.Status(http.StatusServiceUnavailable).JSON(internalapi.Failure(err.Error()))
 	}
+	defer done()
 
-	// Parse UUIDs for database record
-	fileUUID, err := uuid.Parse(fileID)
+	content, err := file.Open()
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Invalid file ID format",
-		})
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Failed to read uploaded file"))
 	}
+	defer content.Close()
 
-	bucketUUID, err := uuid.Parse(bucketID)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Invalid bucket ID format",
-		})
+	// Save file to local storage using node's configured path - just use fileID
+	filePath := fmt.Sprintf("%s/%s", storageDir, request.FileID)
+	if _, _, err := storage.SaveFile(filePath, content); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to save file"))
 	}
 
 	// Create a file metadata record using GoNtext entity
 	nodeMetadata := entities.NodeFileMetadata{
-		Id:         fileUUID,
-		BucketId:   bucketUUID,
-		BucketName: bucketName,
-		Filename:   filename,
+		Id:         request.FileID,
+		BucketId:   request.BucketID,
+		BucketName: request.BucketName,
+		Filename:   request.Filename,
 		Path:       filePath,
 		Size:       file.Size,
 		CreatedAt:  time.Now(),
@@ -801,86 +1606,10 @@ func (ctrl *FileController) InternalUpload(c *fiber.Ctx) error {
 		log.Printf("Warning: Failed to create file metadata record: %v", err)
 	}
 
-	return c.JSON(fiber.Map{
-		"success":   true,
-		"message":   "File uploaded successfully to storage node",
-		"file_path": filePath,
-		"file_size": file.Size,
-	})
-}
-
-// encodePNG encodes an image to PNG
-func encodePNG(img image.Image) ([]byte, error) {
-	buf := make([]byte, 0)
-	w := &bytesWriter{buf: &buf}
-	
-	err := png.Encode(w, img)
-	return buf, err
-}
-
-// bytesWriter implements io.Writer for writing to a byte slice
-type bytesWriter struct {
-	buf *[]byte
-}
-
-func (w *bytesWriter) Write(p []byte) (n int, err error) {
-	*w.buf = append(*w.buf, p...)
-	return len(p), nil
-}
-
-// fetchFileFromNode retrieves a file from a storage node
-func (ctrl *FileController) fetchFileFromNode(nodeID string, bucketID uuid.UUID, fileID uuid.UUID, filename string) ([]byte, error) {
-	// Get storage node info
-	nodeUUID, err := uuid.Parse(nodeID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid node ID: %w", err)
-	}
-	
-	storageNode, err := ctrl.dbContext.StorageNodes.First(&entities.StorageNode{Id: nodeUUID})
-	if err != nil {
-		return nil, fmt.Errorf("storage node not found: %w", err)
-	}
-	
-	// Use the storage node's auth key (the master uses this to authenticate with the node)
-	nodeAuthKey := storageNode.AuthKey
-	
-	// Create request to fetch file from node using internal endpoint
-	req, err := http.NewRequest("GET", 
-		fmt.Sprintf("%s/api/v1/internal/file", storageNode.URL), 
-		nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	
-	// Add query parameters for the file to fetch
-	q := req.URL.Query()
-	q.Add("bucket_id", bucketID.String())
-	q.Add("file_id", fileID.String())
-	q.Add("filename", filename)
-	req.URL.RawQuery = q.Encode()
-	
-	// Add authentication header using the node's auth key
-	req.Header.Set("Authorization", "Bearer "+nodeAuthKey)
-	
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch file: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("node returned status: %d", resp.StatusCode)
-	}
-	
-	// Read file data
-	fileData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file data: %w", err)
-	}
-	
-	return fileData, nil
+	return c.JSON(internalapi.Success("File uploaded successfully to storage node", internalapi.UploadResult{
+		FilePath: filePath,
+		FileSize: file.Size,
+	}))
 }
 
 //	@Summary		Internal delete for distributed storage
@@ -889,100 +1618,48 @@ func (ctrl *FileController) fetchFileFromNode(nodeID string, bucketID uuid.UUID,
 //	@Accept			json
 //	@Produce		json
 //	@Security		Bearer
-//	@Param			bucket_name	query	string	true	"Bucket name"
-//	@Param			file_name	query	string	true	"File name to delete"
-//	@Success		200			{object}	map[string]interface{}	"Delete successful"
-//	@Failure		400			{object}	map[string]string		"Bad request"
-//	@Failure		401			{object}	map[string]string		"Unauthorized"
+//	@Param			X-SHBucket-Internal-API	header		int		false	"Internal API version of the master"
+//	@Param			bucket_name				query		string	true	"Bucket name"
+//	@Param			file_id					query		string	true	"ID of the file to delete"
+//	@Success		200						{object}	internalapi.Envelope[internalapi.DeleteResult]	"Delete successful"
+//	@Failure		400						{object}	apierror.Error			"Bad request"
+//	@Failure		401						{object}	apierror.Error			"Unauthorized"
 //	@Router			/internal/delete [delete]
 func (ctrl *FileController) InternalDelete(c *fiber.Ctx) error {
-	// Validate node auth key from Authorization header
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Missing Authorization header",
-		})
-	}
-	
-	// Extract Bearer token (auth key)
-	var authKey string
-	if strings.HasPrefix(authHeader, "Bearer ") {
-		authKey = strings.TrimPrefix(authHeader, "Bearer ")
-	} else {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid Authorization header format",
-		})
-	}
-	
-	// Validate auth key against node setup config
-	nodeConfig, err := ctrl.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "node"}).FirstOrDefault()
-	if err != nil || nodeConfig == nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Node configuration not found",
-		})
-	}
-	
-	// Parse ConfigData JSON to get node_auth_key
-	var configData map[string]interface{}
-	if err := json.Unmarshal(nodeConfig.ConfigData, &configData); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to parse node configuration",
-		})
-	}
-	
-	nodeAuthKey, ok := configData["node_auth_key"].(string)
-	if !ok || nodeAuthKey == "" {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Node auth key not found in configuration",
-		})
-	}
-	
-	if nodeAuthKey != authKey {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid auth key",
-		})
+	nodeConfig, status, err := ctrl.authorizeInternal(c)
+	if err != nil {
+		return c.Status(status).JSON(internalapi.Failure(err.Error()))
 	}
 
-	// Get query parameters
-	bucketName := c.Query("bucket_name")
-	fileName := c.Query("file_name")
-
-	if bucketName == "" || fileName == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing required parameters (bucket_name, file_name)",
-		})
+	request, err := internalapi.ParseDeleteRequest(c.Query)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
 	}
 
 	// Use the same nodeConfig for storage path
 	storagePath := nodeConfig.StoragePath
 	if storagePath == "" {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Storage path not configured in node config",
-		})
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Storage path not configured in node config"))
 	}
 
-	// Construct file path: storage_path/bucket_name/file_name
-	filePath := fmt.Sprintf("%s/%s/%s", storagePath, bucketName, fileName)
+	// Construct file path: storage_path/bucket_name/file_id
+	filePath := fmt.Sprintf("%s/%s/%s", storagePath, request.BucketName, request.FileID)
 	
 	// Delete the file
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
 			// File doesn't exist, which is fine
-			return c.JSON(fiber.Map{
-				"success": true,
-				"message": "File already deleted or does not exist",
-			})
+			return c.JSON(internalapi.Success("File already deleted or does not exist", internalapi.DeleteResult{
+				FilePath: filePath,
+			}))
 		}
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete file",
-		})
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to delete file"))
 	}
 
-	return c.JSON(fiber.Map{
-		"success":   true,
-		"message":   "File deleted successfully from storage node",
-		"file_path": filePath,
-	})
+	return c.JSON(internalapi.Success("File deleted successfully from storage node", internalapi.DeleteResult{
+		FilePath: filePath,
+		Existed:  true,
+	}))
 }
 
 //	@Summary		Internal file serving for distributed storage
@@ -991,106 +1668,166 @@ func (ctrl *FileController) InternalDelete(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		application/octet-stream
 //	@Security		Bearer
-//	@Param			bucket_id	query	string	true	"Bucket ID"
-//	@Param			file_id		query	string	true	"File ID"
-//	@Param			filename	query	string	true	"Filename"
-//	@Success		200			"File content"
-//	@Failure		400			{object}	map[string]string	"Bad request"
-//	@Failure		401			{object}	map[string]string	"Unauthorized"
-//	@Failure		404			{object}	map[string]string	"File not found"
+//	@Param			X-SHBucket-Internal-API	header		int		false	"Internal API version of the master"
+//	@Param			bucket_id				query		string	true	"Bucket ID"
+//	@Param			file_id					query		string	true	"File ID"
+//	@Param			filename				query		string	false	"Filename"
+//	@Success		200						"File content"
+//	@Failure		400						{object}	apierror.Error			"Bad request"
+//	@Failure		401						{object}	apierror.Error			"Unauthorized"
+//	@Failure		404						{object}	apierror.Error			"File not found"
 //	@Router			/internal/file [get]
 func (ctrl *FileController) InternalFile(c *fiber.Ctx) error {
-	// Validate node auth key from Authorization header
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Missing Authorization header",
-		})
-	}
-	
-	// Extract Bearer token (auth key)
-	var authKey string
-	if strings.HasPrefix(authHeader, "Bearer ") {
-		authKey = strings.TrimPrefix(authHeader, "Bearer ")
-	} else {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid Authorization header format",
-		})
-	}
-	
-	// Validate auth key against node setup config
-	nodeConfig, err := ctrl.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "node"}).FirstOrDefault()
-	if err != nil || nodeConfig == nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Node configuration not found",
-		})
+	if _, status, err := ctrl.authorizeInternal(c); err != nil {
+		return c.Status(status).JSON(internalapi.Failure(err.Error()))
 	}
-	
-	// Parse ConfigData JSON to get node_auth_key
-	var configData map[string]interface{}
-	if err := json.Unmarshal(nodeConfig.ConfigData, &configData); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to parse node configuration",
-		})
+
+	request, err := internalapi.ParseFileRequest(c.Query)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
 	}
-	
-	nodeAuthKey, ok := configData["node_auth_key"].(string)
-	if !ok || nodeAuthKey == "" {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Node auth key not found in configuration",
-		})
+
+	// Look up file in node's metadata using GoNtext
+	nodeMetadata, err := ctrl.dbContext.NodeFileMetadata.Where(&entities.NodeFileMetadata{
+		Id:       request.FileID,
+		BucketId: request.BucketID,
+	}).FirstOrDefault()
+	if err != nil || nodeMetadata == nil {
+		return c.Status(http.StatusNotFound).JSON(internalapi.Failure("File not found in node metadata"))
 	}
-	
-	if nodeAuthKey != authKey {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid auth key",
-		})
+
+	// Check if file exists on disk
+	if _, err := os.Stat(nodeMetadata.Path); os.IsNotExist(err) {
+		return c.Status(http.StatusNotFound).JSON(internalapi.Failure("File not found on disk"))
 	}
 
-	// Get query parameters
-	bucketID := c.Query("bucket_id")
-	fileID := c.Query("file_id")
-	filename := c.Query("filename")
+	// Serve the file directly using the path from metadata
+	return c.SendFile(nodeMetadata.Path)
+}
+
+//	@Summary		Internal diagnostics for distributed storage
+//	@Description	Reports this storage node's version, clock, disk and inode usage and recently logged errors to the master
+//	@Tags			files
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			X-SHBucket-Internal-API	header		int												false	"Internal API version of the master"
+//	@Success		200						{object}	internalapi.Envelope[internalapi.Diagnostics]	"Node diagnostics"
+//	@Failure		401						{object}	apierror.Error									"Unauthorized"
+//	@Router			/internal/diagnostics [get]
+func (ctrl *FileController) InternalDiagnostics(c *fiber.Ctx) error {
+	nodeConfig, status, err := ctrl.authorizeInternal(c)
+	if err != nil {
+		return c.Status(status).JSON(internalapi.Failure(err.Error()))
+	}
+	return c.JSON(internalapi.Success("Node diagnostics", diagnostics.Collect(nodeConfig.StoragePath)))
+}
 
-	if bucketID == "" || fileID == "" || filename == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing required parameters (bucket_id, file_id, filename)",
-		})
+//	@Summary		Serve a file from this storage node by signed URL
+//	@Description	Serves a file held by this storage node to a client the master redirected here. The URL is signed by the master with the node's auth key and expires; range requests are supported
+//	@Tags			files
+//	@Produce		application/octet-stream
+//	@Param			bucket_id	query	string	true	"Bucket ID"
+//	@Param			file_id		query	string	true	"File ID"
+//	@Param			name		query	string	true	"File name to serve the file as"
+//	@Param			type		query	string	false	"Content type to serve the file with"
+//	@Param			expires		query	int		true	"Expiry, in Unix seconds"
+//	@Param			signature	query	string	true	"Signature"
+//	@Success		200			"File content"
+//	@Success		206			"Partial file content"
+//	@Failure		400			{object}	apierror.Error		"Bad request"
+//	@Failure		403			{object}	apierror.Error		"Invalid or expired URL"
+//	@Failure		404			{object}	apierror.Error		"File not found"
+//	@Router			/node/file [get]
+func (ctrl *FileController) NodeFile(c *fiber.Ctx) error {
+	authKey, err := ctrl.nodeAuthKey()
+	if err != nil {
+		return apierror.NotFound(err.Error())
 	}
 
-	// Parse file and bucket IDs
-	fileUUID, err := uuid.Parse(fileID)
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid file ID format",
-		})
+		return apierror.Invalid("Invalid query string")
+	}
+	if err := storage.VerifyNodeURL(authKey, query); err != nil {
+		return apierror.Forbidden(err.Error())
 	}
 
-	bucketUUID, err := uuid.Parse(bucketID)
+	bucketID, err := uuid.Parse(query.Get("bucket_id"))
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid bucket ID format",
-		})
+		return apierror.Invalid("Invalid bucket ID format")
+	}
+	fileID, err := uuid.Parse(query.Get("file_id"))
+	if err != nil {
+		return apierror.Invalid("Invalid file ID format")
 	}
 
-	// Look up file in node's metadata using GoNtext
 	nodeMetadata, err := ctrl.dbContext.NodeFileMetadata.Where(&entities.NodeFileMetadata{
-		Id:       fileUUID,
-		BucketId: bucketUUID,
+		Id:       fileID,
+		BucketId: bucketID,
 	}).FirstOrDefault()
 	if err != nil || nodeMetadata == nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "File not found in node metadata",
-		})
+		return apierror.New(apierror.CodeFileNotFound, "File not found in node metadata")
 	}
-
-	// Check if file exists on disk
 	if _, err := os.Stat(nodeMetadata.Path); os.IsNotExist(err) {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "File not found on disk",
-		})
+		return apierror.New(apierror.CodeFileNotFound, "File not found on disk")
 	}
 
-	// Serve the file directly using the path from metadata
-	return c.SendFile(nodeMetadata.Path)
+	if err := c.SendFile(nodeMetadata.Path); err != nil {
+		return err
+	}
+	// Set after SendFile, which sets a type from the stored file's extension
+	if contentType := query.Get("type"); contentType != "" {
+		c.Set("Content-Type", contentType)
+	}
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", query.Get("name")))
+	c.Set("Cache-Control", "private")
+	return nil
+}
+
+// authorizeInternal checks that a request to an internal endpoint carries this node's auth key and
+// an internal API version it speaks, returning the node's configuration or the status to refuse with
+func (ctrl *FileController) authorizeInternal(c *fiber.Ctx) (*entities.SetupConfig, int, error) {
+	authHeader := c.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, http.StatusUnauthorized, errors.New("Missing or invalid Authorization header")
+	}
+
+	nodeConfig, err := ctrl.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "node"}).FirstOrDefault()
+	if err != nil || nodeConfig == nil {
+		return nil, http.StatusUnauthorized, errors.New("Node configuration not found")
+	}
+	authKey, err := configAuthKey(nodeConfig)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if subtle.ConstantTimeCompare([]byte(authKey), []byte(strings.TrimPrefix(authHeader, "Bearer "))) != 1 {
+		return nil, http.StatusUnauthorized, errors.New("Invalid auth key")
+	}
+
+	if err := internalapi.CheckVersion(c.Get(internalapi.VersionHeader)); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return nodeConfig, 0, nil
+}
+
+// nodeAuthKey returns the auth key this storage node shares with its master
+func (ctrl *FileController) nodeAuthKey() (string, error) {
+	nodeConfig, err := ctrl.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "node"}).FirstOrDefault()
+	if err != nil || nodeConfig == nil {
+		return "", fmt.Errorf("node configuration not found")
+	}
+	return configAuthKey(nodeConfig)
+}
+
+// configAuthKey returns the auth key of a node's setup configuration
+func configAuthKey(nodeConfig *entities.SetupConfig) (string, error) {
+	var configData map[string]interface{}
+	if err := json.Unmarshal(nodeConfig.ConfigData, &configData); err != nil {
+		return "", fmt.Errorf("failed to parse node configuration")
+	}
+	authKey, ok := configData["node_auth_key"].(string)
+	if !ok || authKey == "" {
+		return "", fmt.Errorf("node auth key not found in configuration")
+	}
+	return authKey, nil
 }
\ No newline at end of file
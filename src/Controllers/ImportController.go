@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Import"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type ImportController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewImportController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *ImportController {
+	return &ImportController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Import from S3
+//	@Description	Stream objects from an external S3/MinIO bucket into a SHBucket bucket. Pass resume_job_id to continue an interrupted import.
+//	@Tags			migrations
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		importer.ImportS3Command	true	"Source credentials and target bucket"
+//	@Success		202		{object}	importer.ImportS3Response	"Import started"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Router			/admin/migrations/s3 [post]
+func (ctrl *ImportController) ImportS3(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command importer.ImportS3Command
+
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+
+	command.StartedBy = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	importS3Response := response.(*importer.ImportS3Response)
+	return c.Status(http.StatusAccepted).JSON(importS3Response)
+}
+
+//	@Summary		Get S3 import progress
+//	@Description	Get progress and the skipped object report of an S3 import
+//	@Tags			migrations
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Import job ID"
+//	@Success		200	{object}	importer.GetS3ImportJobResponse	"Import job"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		404	{object}	apierror.Error					"Import job not found"
+//	@Router			/admin/migrations/s3/{id} [get]
+func (ctrl *ImportController) GetS3ImportJob(c *fiber.Ctx) error {
+	jobID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &importer.GetS3ImportJobCommand{JobID: jobID})
+	if err != nil {
+		return err
+	}
+
+	getS3ImportJobResponse := response.(*importer.GetS3ImportJobResponse)
+	return c.JSON(getS3ImportJobResponse)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Job"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type JobController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewJobController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *JobController {
+	return &JobController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Get background job
+//	@Description	Report the status and progress of a background job started by a request that returned 202
+//	@Tags			jobs
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string				true	"Job ID"
+//	@Success		200	{object}	job.GetJobResponse	"Job status"
+//	@Failure		400	{object}	apierror.Error		"Bad request"
+//	@Failure		401	{object}	apierror.Error		"Unauthorized"
+//	@Failure		404	{object}	apierror.Error		"Job not found"
+//	@Router			/jobs/{id} [get]
+func (ctrl *JobController) GetJob(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	jobID := binding.UUID(c, "id")
+
+	command := &job.GetJobCommand{
+		JobID:    jobID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	jobResponse := response.(*job.GetJobResponse)
+	return c.JSON(jobResponse)
+}
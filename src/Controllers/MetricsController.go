@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Metrics"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+type MetricsController struct {
+	concurrency *metrics.Concurrency
+	monitor     *metrics.SaturationMonitor
+	nodeCache   *storage.NodeCache
+}
+
+func NewMetricsController(concurrency *metrics.Concurrency, monitor *metrics.SaturationMonitor, nodeCache *storage.NodeCache) *MetricsController {
+	return &MetricsController{
+		concurrency: concurrency,
+		monitor:     monitor,
+		nodeCache:   nodeCache,
+	}
+}
+
+// ConcurrencyResponse lists the requests in flight and the saturation alerts firing
+type ConcurrencyResponse struct {
+	metrics.Snapshot
+	Alerts []metrics.Alert `json:"alerts"`
+}
+
+// NodeCacheResponse is the state of the cache of node-stored content
+type NodeCacheResponse struct {
+	metrics.CacheStats
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+//	@Summary		Get request concurrency
+//	@Description	Get the requests in flight overall, per route and per bucket, with their peaks and the saturation alerts currently firing (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	controllers.ConcurrencyResponse	"Request concurrency"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		403	{object}	apierror.Error					"Forbidden"
+//	@Router			/admin/concurrency [get]
+func (ctrl *MetricsController) GetConcurrency(c *fiber.Ctx) error {
+	return c.JSON(ConcurrencyResponse{
+		Snapshot: ctrl.concurrency.Snapshot(),
+		Alerts:   ctrl.monitor.Alerts(),
+	})
+}
+
+//	@Summary		Get node cache statistics
+//	@Description	Get the size of the master's cache of content stored on nodes and the reads it served since the server started (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	controllers.NodeCacheResponse	"Node cache statistics"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		403	{object}	apierror.Error					"Forbidden"
+//	@Router			/admin/node-cache [get]
+func (ctrl *MetricsController) GetNodeCache(c *fiber.Ctx) error {
+	stats := ctrl.nodeCache.Stats()
+	return c.JSON(NodeCacheResponse{
+		CacheStats: stats,
+		HitRatio:   stats.HitRatio(),
+	})
+}
+
+//	@Summary		Prometheus metrics
+//	@Description	Request concurrency gauges, saturation alerts and node cache counters in the Prometheus text format. Only served when METRICS_TOKEN is set, which must be sent as a bearer token
+//	@Tags			admin
+//	@Produce		plain
+//	@Success		200	{string}	string				"Metrics"
+//	@Failure		401	{object}	apierror.Error		"Unauthorized"
+//	@Router			/metrics [get]
+func (ctrl *MetricsController) Prometheus(c *fiber.Ctx) error {
+	token := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	expected := config.GetSettings().MetricsToken
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return apierror.Unauthorized("Invalid metrics token")
+	}
+
+	c.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.WritePrometheus(c, ctrl.concurrency.Snapshot(), ctrl.monitor.Alerts())
+	metrics.WriteCachePrometheus(c, "shbucket_node_cache", "Node cache", ctrl.nodeCache.Stats())
+	return nil
+}
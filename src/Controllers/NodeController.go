@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strings"
@@ -12,9 +13,12 @@ import (
 	"github.com/google/uuid"
 	
 	"shbucket/src/Application/Node"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/NodeClient"
 	"shbucket/src/Infrastructure/Persistence"
 	"shbucket/src/Models"
 )
@@ -44,39 +48,31 @@ func NewNodeController(mediator *mediator.Mediator, validator *validator.Validat
 //	@Security		ApiKeyAuth
 //	@Param			request	body		models.RegisterNodeRequest		true	"Node registration details"
 //	@Success		201		{object}	node.RegisterNodeResponse		"Node registered successfully"
-//	@Failure		400		{object}	map[string]string				"Bad request"
-//	@Failure		401		{object}	map[string]string				"Unauthorized"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
 //	@Router			/nodes [post]
 func (ctrl *NodeController) RegisterNode(c *fiber.Ctx) error {
 	var req models.RegisterNodeRequest
 	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &req); err != nil {
+		return err
 	}
 	
 	command := &node.RegisterNodeCommand{
 		Name:       req.Name,
 		URL:        req.URL,
+		PublicURL:  req.PublicURL,
 		AuthKey:    req.AuthKey,
 		MaxStorage: req.MaxStorage,
 		Priority:   req.Priority,
+		Group:      req.Group,
+		Zone:       req.Zone,
 		IsActive:   req.IsActive,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	registerResponse := response.(*node.RegisterNodeResponse)
@@ -84,7 +80,7 @@ func (ctrl *NodeController) RegisterNode(c *fiber.Ctx) error {
 }
 
 //	@Summary		List storage nodes
-//	@Description	Get a list of all storage nodes in the distributed system
+//	@Description	Get a list of all storage nodes in the distributed system, by name unless sort_by (name, priority, group, zone, max_storage, used_storage, last_ping, created_at) says otherwise
 //	@Tags			nodes
 //	@Accept			json
 //	@Produce		json
@@ -93,32 +89,206 @@ func (ctrl *NodeController) RegisterNode(c *fiber.Ctx) error {
 //	@Param			page	query		int		false	"Page number"		default(1)
 //	@Param			limit	query		int		false	"Items per page"	default(10)
 //	@Param			active	query		bool	false	"Show only active nodes"	default(false)
+//	@Param			healthy	query		bool	false	"Show only healthy nodes that haven't failed"	default(false)
+//	@Param			group	query		string	false	"Show only nodes of this group"
+//	@Param			zone	query		string	false	"Show only nodes in this zone"
+//	@Param			sort_by			query		string		false	"Field to sort by"
+//	@Param			order			query		string		false	"asc or desc"
+//	@Param			name			query		string		false	"Only names containing this, case-insensitively"
+//	@Param			created_after	query		string		false	"Only created at or after this RFC 3339 time"
+//	@Param			created_before	query		string		false	"Only created before this RFC 3339 time"
 //	@Success		200		{object}	node.ListNodesResponse			"Nodes retrieved successfully"
-//	@Failure		400		{object}	map[string]string				"Bad request"
-//	@Failure		401		{object}	map[string]string				"Unauthorized"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
 //	@Router			/nodes [get]
 func (ctrl *NodeController) ListNodes(c *fiber.Ctx) error {
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 	onlyActive := c.QueryBool("active", false)
+	list, err := parseListQuery(c)
+	if err != nil {
+		return err
+	}
 	
 	command := &node.ListNodesCommand{
-		Page:       page,
-		Limit:      limit,
-		OnlyActive: onlyActive,
+		Page:        page,
+		Limit:       limit,
+		OnlyActive:  onlyActive,
+		OnlyHealthy: c.QueryBool("healthy", false),
+		Group:       c.Query("group"),
+		Zone:        c.Query("zone"),
+		List:        list,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	listResponse := response.(*node.ListNodesResponse)
 	return c.JSON(listResponse)
 }
 
+//	@Summary		Update storage node
+//	@Description	Change a storage node's name, capacity, priority, group, zone or whether it takes new content
+//	@Tags			nodes
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string					true	"Node ID"
+//	@Param			request	body		models.UpdateNodeRequest	true	"Fields to change"
+//	@Success		200		{object}	node.UpdateNodeResponse	"Node updated successfully"
+//	@Failure		400		{object}	apierror.Error			"Bad request"
+//	@Failure		401		{object}	apierror.Error			"Unauthorized"
+//	@Router			/nodes/{id} [patch]
+func (ctrl *NodeController) UpdateNode(c *fiber.Ctx) error {
+	nodeID := binding.UUID(c, "id")
+
+	var req models.UpdateNodeRequest
+	if err := binding.Body(c, ctrl.validator, &req); err != nil {
+		return err
+	}
+
+	command := &node.UpdateNodeCommand{
+		NodeID:     nodeID,
+		Name:       req.Name,
+		PublicURL:  req.PublicURL,
+		MaxStorage: req.MaxStorage,
+		Priority:   req.Priority,
+		Group:      req.Group,
+		Zone:       req.Zone,
+		IsActive:   req.IsActive,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*node.UpdateNodeResponse))
+}
+
+//	@Summary		Mark storage node failed
+//	@Description	Mark a storage node permanently failed and repair its files from their backup copies, those with the fewest surviving copies first. A failed node takes no new content and can't be reactivated.
+//	@Tags			nodes
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string					true	"Node ID"
+//	@Success		202	{object}	node.FailNodeResponse	"Repair started"
+//	@Failure		400	{object}	apierror.Error			"Bad request"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
+//	@Router			/admin/nodes/{id}/fail [post]
+func (ctrl *NodeController) FailNode(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	nodeID := binding.UUID(c, "id")
+
+	command := &node.FailNodeCommand{
+		NodeID: nodeID,
+		UserID: userContext.UserID,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusAccepted).JSON(response.(*node.FailNodeResponse))
+}
+
+//	@Summary		Get storage node diagnostics
+//	@Description	Pull a storage node's diagnostics through its internal endpoint: its version, disk and inode usage, recently logged errors, and how far its clock is off the master's. An unreachable node is reported with reachable false and the reason in error.
+//	@Tags			nodes
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Node ID"
+//	@Success		200	{object}	node.GetNodeDiagnosticsResponse	"Node diagnostics"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		404	{object}	apierror.Error					"Node not found"
+//	@Router			/nodes/{id}/diagnostics [get]
+func (ctrl *NodeController) GetDiagnostics(c *fiber.Ctx) error {
+	nodeID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &node.GetNodeDiagnosticsCommand{NodeID: nodeID})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*node.GetNodeDiagnosticsResponse))
+}
+
+//	@Summary		Set storage node maintenance
+//	@Description	Put a storage node in maintenance, or take it out. A node in maintenance stays registered and health checked but takes no new content; with drain_reads its files are read from other copies where there are any.
+//	@Tags			nodes
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Node ID"
+//	@Param			request	body		models.NodeMaintenanceRequest	true	"Maintenance state"
+//	@Success		200		{object}	node.SetNodeMaintenanceResponse	"Maintenance updated"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Failure		404		{object}	apierror.Error					"Node not found"
+//	@Router			/nodes/{id}/maintenance [post]
+func (ctrl *NodeController) SetMaintenance(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	nodeID := binding.UUID(c, "id")
+
+	var req models.NodeMaintenanceRequest
+	if err := binding.Body(c, ctrl.validator, &req); err != nil {
+		return err
+	}
+
+	command := &node.SetNodeMaintenanceCommand{
+		NodeID:     nodeID,
+		UserID:     userContext.UserID,
+		Enabled:    *req.Enabled,
+		DrainReads: req.DrainReads,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*node.SetNodeMaintenanceResponse))
+}
+
+//	@Summary		Get storage node repair
+//	@Description	Get progress and estimated completion of repairing a failed storage node's files
+//	@Tags			nodes
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string						true	"Node ID"
+//	@Success		200	{object}	node.GetNodeRepairResponse	"Repair progress"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
+//	@Failure		404	{object}	apierror.Error				"No repair found"
+//	@Router			/admin/nodes/{id}/repair [get]
+func (ctrl *NodeController) GetNodeRepair(c *fiber.Ctx) error {
+	nodeID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &node.GetNodeRepairCommand{NodeID: nodeID})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*node.GetNodeRepairResponse))
+}
+
 //	@Summary		Install storage node
 //	@Description	Install and configure a new storage node
 //	@Tags			nodes
@@ -128,23 +298,14 @@ func (ctrl *NodeController) ListNodes(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			request	body		models.NodeInstallationRequest	true	"Node installation details"
 //	@Success		201		{object}	models.NodeInstallationResponse	"Node installed successfully"
-//	@Failure		400		{object}	map[string]string				"Bad request"
-//	@Failure		401		{object}	map[string]string				"Unauthorized"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
 //	@Router			/nodes/install [post]
 func (ctrl *NodeController) InstallNode(c *fiber.Ctx) error {
 	var req models.NodeInstallationRequest
 	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &req); err != nil {
+		return err
 	}
 	
 	// Generate API key if not provided
@@ -165,11 +326,9 @@ func (ctrl *NodeController) InstallNode(c *fiber.Ctx) error {
 		IsActive:   true,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), registerCommand)
+	response, err := ctrl.mediator.Send(c.UserContext(), registerCommand)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	registerResponse := response.(*node.RegisterNodeResponse)
@@ -195,42 +354,33 @@ func (ctrl *NodeController) InstallNode(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			id	path		string	true	"Node ID"
 //	@Success		200	{object}	models.NodeHealthCheckResponse	"Node health status"
-//	@Failure		400	{object}	map[string]string				"Bad request"
-//	@Failure		401	{object}	map[string]string				"Unauthorized"
-//	@Failure		404	{object}	map[string]string				"Node not found"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		404	{object}	apierror.Error					"Node not found"
 //	@Router			/nodes/{id}/health [get]
 func (ctrl *NodeController) HealthCheck(c *fiber.Ctx) error {
-	nodeIDParam := c.Params("id")
-	nodeID, err := uuid.Parse(nodeIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid node ID",
-		})
-	}
+	nodeID := binding.UUID(c, "id")
 	
 	// Get the node from database
 	storageNode, err := ctrl.dbContext.StorageNodes.Where(entities.StorageNode{Id: nodeID}).FirstOrDefault()
 	if err != nil || storageNode == nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Node not found",
-		})
+		return apierror.New(apierror.CodeNodeNotFound, "Node not found")
 	}
 	
 	// Perform actual health check
-	isHealthy, responseTime, errorMsg := ctrl.pingNode(storageNode)
+	isHealthy, responseTime, errorMsg := ctrl.pingNode(c.UserContext(), storageNode)
 	
 	// Update node health status in database
 	now := time.Now()
 	storageNode.IsHealthy = isHealthy
 	storageNode.LastPing = &now
-	storageNode.IsActive = true
+	// A failed node answering again is still failed, its content has been repaired elsewhere
+	if storageNode.FailedAt == nil {
+		storageNode.IsActive = true
+	}
 	
 	if err := ctrl.dbContext.SaveChanges(); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update node health status",
-		})
-	}else{
-		fmt.Sprintf("Healthy node %s",storageNode.IsHealthy)
+		return apierror.Internal("Failed to update node health status")
 	}
 
 	
@@ -239,12 +389,8 @@ func (ctrl *NodeController) HealthCheck(c *fiber.Ctx) error {
 		IsHealthy:    isHealthy,
 		ResponseTime: responseTime,
 		Success:      isHealthy,
-		Message:      func() string {
-			if isHealthy {
-				return "Node is healthy"
-			}
-			return fmt.Sprintf("Node is unhealthy: %s", errorMsg)
-		}(),
+		Status:       healthStatus(storageNode, isHealthy),
+		Message:      healthMessage(storageNode, isHealthy, errorMsg),
 		Error: errorMsg,
 	}
 	
@@ -259,45 +405,45 @@ func (ctrl *NodeController) HealthCheck(c *fiber.Ctx) error {
 //	@Security		Bearer
 //	@Security		ApiKeyAuth
 //	@Success		200	{object}	map[string]interface{}	"Health check results for all nodes"
-//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
 //	@Router			/nodes/health [get]
 func (ctrl *NodeController) CheckAllNodesHealth(c *fiber.Ctx) error {
 	// Get all nodes from database
 	allNodes, err := ctrl.dbContext.StorageNodes.ToList()
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch nodes",
-		})
+		return apierror.Internal("Failed to fetch nodes")
 	}
 	
 	// Perform health checks on all nodes
 	healthResults := make([]models.NodeHealthCheckResponse, 0, len(allNodes))
 	healthyCount := 0
+	maintenanceCount := 0
 	
 	for i := range allNodes {
-		isHealthy, responseTime, errorMsg := ctrl.pingNode(&allNodes[i])
+		isHealthy, responseTime, errorMsg := ctrl.pingNode(c.UserContext(), &allNodes[i])
 		
 		// Update node health status directly in the original slice
 		now := time.Now()
 		allNodes[i].IsHealthy = isHealthy
 		allNodes[i].LastPing = &now
-		allNodes[i].IsActive = true
+		if allNodes[i].FailedAt == nil {
+			allNodes[i].IsActive = true
+		}
 		
 		if isHealthy {
 			healthyCount++
 		}
+		if allNodes[i].MaintenanceAt != nil {
+			maintenanceCount++
+		}
 		
 		result := models.NodeHealthCheckResponse{
 			NodeID:       allNodes[i].Id,
 			IsHealthy:    isHealthy,
 			ResponseTime: responseTime,
 			Success:      isHealthy,
-			Message: func() string {
-				if isHealthy {
-					return "Node is healthy"
-				}
-				return fmt.Sprintf("Node is unhealthy: %s", errorMsg)
-			}(),
+			Status:       healthStatus(&allNodes[i], isHealthy),
+			Message:      healthMessage(&allNodes[i], isHealthy, errorMsg),
 			Error: errorMsg,
 		}
 		healthResults = append(healthResults, result)
@@ -307,9 +453,7 @@ func (ctrl *NodeController) CheckAllNodesHealth(c *fiber.Ctx) error {
 	ctrl.dbContext.StorageNodes.UpdateRange(allNodes)
 	
 	if err := ctrl.dbContext.SaveChanges(); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to save health check changes",
-		})
+		return apierror.Internal("Failed to save health check changes")
 	}
 	
 	return c.JSON(fiber.Map{
@@ -317,6 +461,7 @@ func (ctrl *NodeController) CheckAllNodesHealth(c *fiber.Ctx) error {
 		"total_nodes":    len(allNodes),
 		"healthy_nodes":  healthyCount,
 		"unhealthy_nodes": len(allNodes) - healthyCount,
+		"maintenance_nodes": maintenanceCount,
 		"health_results": healthResults,
 	})
 }
@@ -330,18 +475,12 @@ func (ctrl *NodeController) CheckAllNodesHealth(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			id	path		string	true	"Node ID"
 //	@Success		200	{object}	map[string]interface{}	"Node deleted successfully"
-//	@Failure		400	{object}	map[string]string		"Bad request"
-//	@Failure		401	{object}	map[string]string		"Unauthorized"
-//	@Failure		404	{object}	map[string]string		"Node not found"
+//	@Failure		400	{object}	apierror.Error			"Bad request"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
+//	@Failure		404	{object}	apierror.Error			"Node not found"
 //	@Router			/nodes/{id} [delete]
 func (ctrl *NodeController) DeleteNode(c *fiber.Ctx) error {
-	nodeIDStr := c.Params("id")
-	nodeID, err := uuid.Parse(nodeIDStr)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid node ID",
-		})
-	}
+	nodeID := binding.UUID(c, "id")
 	
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -351,103 +490,104 @@ func (ctrl *NodeController) DeleteNode(c *fiber.Ctx) error {
 }
 
 //	@Summary		Self-register storage node
-//	@Description	Allow a node to register itself without authentication (for node setup)
+//	@Description	Allow a node to register itself with a one-time registration token issued by an admin. The auth key returned is what the node and the master authenticate each other with, it is only returned here
 //	@Tags			nodes
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		object	true	"Node self-registration details"	example({"name":"node-1","url":"http://localhost:8081","max_storage":1073741824,"priority":1})
-//	@Success		201		{object}	map[string]interface{}	"Node registered successfully with auth key"
-//	@Failure		400		{object}	map[string]string		"Bad request"
+//	@Param			request	body		node.SelfRegisterNodeCommand	true	"Node self-registration details"
+//	@Success		201		{object}	node.SelfRegisterNodeResponse	"Node registered successfully with auth key"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Invalid or expired registration token"
 //	@Router			/node/register [post]
 func (ctrl *NodeController) SelfRegister(c *fiber.Ctx) error {
-	var req struct {
-		Name       string `json:"name" validate:"required,min=3,max=100"`
-		URL        string `json:"url" validate:"required,url"`
-		MaxStorage int64  `json:"max_storage" validate:"min=0"`
-		Priority   int    `json:"priority" validate:"min=0,max=100"`
+	var command node.SelfRegisterNodeCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
 	}
 
-	if err := ctrl.validator.Struct(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
-	}
+	return c.Status(http.StatusCreated).JSON(response.(*node.SelfRegisterNodeResponse))
+}
 
-	// Generate auth key for this node
-	authKey := generateAuthKey()
+//	@Summary		Create node registration token
+//	@Description	Issue a one-time token a storage node registers itself with. The secret is only returned here
+//	@Tags			nodes
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		node.CreateRegistrationTokenCommand		true	"Token details"
+//	@Success		201		{object}	node.CreateRegistrationTokenResponse	"Registration token created"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Router			/admin/node-tokens [post]
+func (ctrl *NodeController) CreateRegistrationToken(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
 
-	command := &node.RegisterNodeCommand{
-		Name:       req.Name,
-		URL:        req.URL,
-		AuthKey:    authKey,
-		MaxStorage: req.MaxStorage,
-		Priority:   req.Priority,
-		IsActive:   true,
+	var command node.CreateRegistrationTokenCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
 	}
+	command.UserID = userContext.UserID
 
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	registerResponse := response.(*node.RegisterNodeResponse)
-	
-	// Return the auth key so the node can store it
-	return c.Status(http.StatusCreated).JSON(fiber.Map{
-		"node":     registerResponse.Node,
-		"auth_key": authKey,
-		"success":  true,
-		"message":  "Node registered successfully. Save the auth_key - you'll need it for master registration.",
-	})
+	return c.Status(http.StatusCreated).JSON(response.(*node.CreateRegistrationTokenResponse))
 }
 
-//	@Summary		Get node auth key
-//	@Description	Retrieve the authentication key for a specific node by URL
+//	@Summary		List node registration tokens
+//	@Description	List node registration tokens, used, expired and revoked ones included. Secrets are never returned
 //	@Tags			nodes
-//	@Accept			json
 //	@Produce		json
-//	@Param			url	query		string	true	"Node URL"
-//	@Success		200	{object}	map[string]interface{}	"Node authentication key"
-//	@Failure		400	{object}	map[string]string		"Bad request"
-//	@Failure		404	{object}	map[string]string		"Node not found"
-//	@Router			/node/auth-key [get]
-func (ctrl *NodeController) GetAuthKey(c *fiber.Ctx) error {
-	nodeURL := c.Query("url")
-	if nodeURL == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Node URL parameter required",
-		})
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	node.ListRegistrationTokensResponse	"Registration tokens"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		500	{object}	apierror.Error						"Internal server error"
+//	@Router			/admin/node-tokens [get]
+func (ctrl *NodeController) ListRegistrationTokens(c *fiber.Ctx) error {
+	response, err := ctrl.mediator.Send(c.UserContext(), &node.ListRegistrationTokensCommand{})
+	if err != nil {
+		return err
 	}
 
-	// Find the node by URL using static typing
-	storageNode, err := ctrl.dbContext.StorageNodes.First(&entities.StorageNode{URL: nodeURL})
+	return c.JSON(response.(*node.ListRegistrationTokensResponse))
+}
+
+//	@Summary		Revoke node registration token
+//	@Description	Revoke a registration token that hasn't been used, no node can register with it afterwards
+//	@Tags			nodes
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string								true	"Token ID"
+//	@Success		200	{object}	node.RevokeRegistrationTokenResponse	"Registration token revoked"
+//	@Failure		400	{object}	apierror.Error						"Bad request"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Router			/admin/node-tokens/{id} [delete]
+func (ctrl *NodeController) RevokeRegistrationToken(c *fiber.Ctx) error {
+	tokenID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &node.RevokeRegistrationTokenCommand{TokenID: tokenID})
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Node not found",
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"auth_key": storageNode.AuthKey,
-		"node_id":  storageNode.Id,
-		"name":     storageNode.Name,
-		"url":      storageNode.URL,
-		"success":  true,
-	})
+	return c.JSON(response.(*node.RevokeRegistrationTokenResponse))
 }
 
 //	@Summary		Node ping
-//	@Description	Allow a node to ping the master node to update health status
+//	@Description	Allow a node to ping the master node to update health status, authenticated with the node's auth key
 //	@Tags			nodes
 //	@Accept			json
 //	@Produce		json
@@ -455,8 +595,8 @@ func (ctrl *NodeController) GetAuthKey(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			url	query		string	true	"Node URL"
 //	@Success		200	{object}	map[string]interface{}	"Ping successful"
-//	@Failure		400	{object}	map[string]string		"Bad request"
-//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		400	{object}	apierror.Error			"Bad request"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
 //	@Router			/node/ping [post]
 func (ctrl *NodeController) Ping(c *fiber.Ctx) error {
 	nodeURL := c.Query("url")
@@ -468,15 +608,11 @@ func (ctrl *NodeController) Ping(c *fiber.Ctx) error {
 	}
 	
 	if nodeURL == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Node URL parameter required",
-		})
+		return apierror.Invalid("Node URL parameter required")
 	}
 
 	if authKey == "" {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Authorization header or X-API-Key header required",
-		})
+		return apierror.Unauthorized("Authorization header or X-API-Key header required")
 	}
 
 	// Remove "Bearer " prefix if present
@@ -484,12 +620,11 @@ func (ctrl *NodeController) Ping(c *fiber.Ctx) error {
 		authKey = strings.TrimPrefix(authKey, "Bearer ")
 	}
 
-	// Find and validate the node using static typing (can't use AND with struct yet, so check separately)
-	storageNode, err := ctrl.dbContext.StorageNodes.Where(&entities.StorageNode{URL: nodeURL, AuthKey: authKey}).FirstOrDefault()
-	if err != nil || storageNode == nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid node credentials",
-		})
+	// The node proves its identity with the auth key it shares with the master, compared in constant time
+	storageNode, err := ctrl.dbContext.StorageNodes.Where(&entities.StorageNode{URL: nodeURL}).FirstOrDefault()
+	if err != nil || storageNode == nil || storageNode.AuthKey == "" ||
+		subtle.ConstantTimeCompare([]byte(storageNode.AuthKey), []byte(authKey)) != 1 {
+		return apierror.Unauthorized("Invalid node credentials")
 	}
 
 	// Update last ping and health status
@@ -498,9 +633,7 @@ func (ctrl *NodeController) Ping(c *fiber.Ctx) error {
 	storageNode.IsHealthy = true
 
 	if err := ctrl.dbContext.StorageNodes.Save(storageNode); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update node status",
-		})
+		return apierror.Internal("Failed to update node status")
 	}
 
 	return c.JSON(fiber.Map{
@@ -516,40 +649,38 @@ func generateAuthKey() string {
 }
 
 // pingNode performs an actual health check by calling the node's health endpoint
-func (ctrl *NodeController) pingNode(node *entities.StorageNode) (bool, int64, string) {
+func (ctrl *NodeController) pingNode(ctx context.Context, node *entities.StorageNode) (bool, int64, string) {
 	start := time.Now()
-	
-	// Create health check request to the node
-	healthURL := strings.TrimSuffix(node.URL, "/") + "/api/v1/health"
-	
-	client := &http.Client{
-		Timeout: 10 * time.Second, // 10 second timeout
-	}
-	
-	req, err := http.NewRequest("GET", healthURL, nil)
-	if err != nil {
-		responseTime := time.Since(start).Milliseconds()
-		return false, responseTime, fmt.Sprintf("Failed to create request: %v", err)
-	}
-	
-	// Add authentication if node has auth key
-	if node.AuthKey != "" {
-		req.Header.Set("X-API-Key", node.AuthKey)
-	}
-	
-	resp, err := client.Do(req)
+	err := nodeclient.Default().Health(ctx, nodeclient.Node{URL: node.URL, AuthKey: node.AuthKey})
 	responseTime := time.Since(start).Milliseconds()
-	
 	if err != nil {
-		return false, responseTime, fmt.Sprintf("Request failed: %v", err)
+		return false, responseTime, err.Error()
 	}
-	defer resp.Body.Close()
-	
-	// Check if response is successful
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		
-		return true, responseTime, ""
-	}
-	
-	return false, responseTime, fmt.Sprintf("Node returned status %d", resp.StatusCode)
-}
\ No newline at end of file
+	return true, responseTime, ""
+}
+
+// healthStatus is what a health check reports of a node: maintenance for a node in maintenance,
+// whether or not it answered, so it isn't taken for an unhealthy one
+func healthStatus(node *entities.StorageNode, isHealthy bool) string {
+	switch {
+	case node.FailedAt != nil:
+		return entities.NodeStatusFailed
+	case node.MaintenanceAt != nil:
+		return entities.NodeStatusMaintenance
+	case isHealthy:
+		return entities.NodeStatusHealthy
+	}
+	return entities.NodeStatusUnhealthy
+}
+
+func healthMessage(node *entities.StorageNode, isHealthy bool, errorMsg string) string {
+	switch {
+	case node.MaintenanceAt != nil && isHealthy:
+		return "Node is in maintenance"
+	case node.MaintenanceAt != nil:
+		return fmt.Sprintf("Node is in maintenance and not answering: %s", errorMsg)
+	case isHealthy:
+		return "Node is healthy"
+	}
+	return fmt.Sprintf("Node is unhealthy: %s", errorMsg)
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/Permission"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type PermissionController struct {
+	mediator *mediator.Mediator
+}
+
+func NewPermissionController(mediator *mediator.Mediator) *PermissionController {
+	return &PermissionController{
+		mediator: mediator,
+	}
+}
+
+//	@Summary		Export permissions
+//	@Description	Export who can access which bucket and with what rights, for security reviews: users through their role, ownership and bucket admin grants, live API keys, unrevoked file tokens, open upload links, outstanding signed URLs and anonymous readers of public buckets. format=csv returns the same entries as a CSV file (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Produce		text/csv
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucket_id	query		string	false	"Only this bucket"
+//	@Param			user_id		query		string	false	"Only this user and the credentials they hold or issued"
+//	@Param			format		query		string	false	"json or csv"	default(json)
+//	@Success		200			{object}	permission.ExportPermissionsResponse	"Permission entries"
+//	@Failure		400			{object}	apierror.Error							"Bad request"
+//	@Failure		401			{object}	apierror.Error							"Unauthorized"
+//	@Failure		403			{object}	apierror.Error							"Forbidden"
+//	@Failure		404			{object}	apierror.Error							"Bucket or user not found"
+//	@Router			/admin/permissions/export [get]
+func (ctrl *PermissionController) ExportPermissions(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return apierror.Invalid("format must be json or csv")
+	}
+
+	command := permission.ExportPermissionsCommand{}
+	for name, target := range map[string]**uuid.UUID{"bucket_id": &command.BucketID, "user_id": &command.UserID} {
+		if value := c.Query(name); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return apierror.Invalid(fmt.Sprintf("Invalid %s", name))
+			}
+			*target = &id
+		}
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	exportResponse := response.(*permission.ExportPermissionsResponse)
+	if format == "json" {
+		return c.JSON(exportResponse)
+	}
+
+	var body bytes.Buffer
+	if err := permission.WriteCSV(&body, exportResponse.Entries); err != nil {
+		return apierror.Internal(fmt.Sprintf("Failed to write CSV: %v", err))
+	}
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"permissions-%s.csv\"", exportResponse.GeneratedAt.UTC().Format("20060102-150405")))
+	return c.Send(body.Bytes())
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Rebalance"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type RebalanceController struct {
+	mediator    *mediator.Mediator
+	authService *auth.AuthorizationService
+}
+
+func NewRebalanceController(mediator *mediator.Mediator, authService *auth.AuthorizationService) *RebalanceController {
+	return &RebalanceController{
+		mediator:    mediator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Plan storage rebalancing
+//	@Description	Dry run of rebalancing under the configured policy: each location's utilization now and once done, and the cold files that would move off the master and nodes over the band to nodes under it. Nothing is moved (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	rebalance.GetRebalancePlanResponse	"Rebalancing plan"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		403	{object}	apierror.Error						"Forbidden"
+//	@Router			/admin/rebalance/plan [get]
+func (ctrl *RebalanceController) GetPlan(c *fiber.Ctx) error {
+	response, err := ctrl.mediator.Send(c.UserContext(), &rebalance.GetRebalancePlanCommand{})
+	if err != nil {
+		return err
+	}
+
+	planResponse := response.(*rebalance.GetRebalancePlanResponse)
+	return c.JSON(planResponse)
+}
+
+//	@Summary		Start storage rebalancing
+//	@Description	Queue a rebalancing run as a background job and return the job, whose status is polled at /jobs/{id}. The run plans again when it starts and moves the files it finds, verifying each copy; its result counts the files moved and failed. A run can't be queued while one is queued or running (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		202	{object}	rebalance.StartRebalanceResponse	"Rebalancing queued"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		403	{object}	apierror.Error						"Forbidden"
+//	@Failure		409	{object}	apierror.Error						"Rebalancing already queued or running"
+//	@Router			/admin/rebalance [post]
+func (ctrl *RebalanceController) StartRebalance(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &rebalance.StartRebalanceCommand{
+		UserID: userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	startResponse := response.(*rebalance.StartRebalanceResponse)
+	return c.Status(http.StatusAccepted).JSON(startResponse)
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Reclamation"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type ReclamationController struct {
+	mediator  *mediator.Mediator
+	validator *validator.Validate
+}
+
+func NewReclamationController(mediator *mediator.Mediator, validator *validator.Validate) *ReclamationController {
+	return &ReclamationController{
+		mediator:  mediator,
+		validator: validator,
+	}
+}
+
+//	@Summary		Storage reclamation report
+//	@Description	Report space that can be reclaimed: expired signed URLs, unreferenced content, stale partial uploads, derived content of deleted files and versions beyond bucket version limits (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	reclamation.GetReclamationReportResponse	"Reclamation report"
+//	@Failure		401	{object}	apierror.Error								"Unauthorized"
+//	@Failure		403	{object}	apierror.Error								"Forbidden"
+//	@Router			/admin/reclamation [get]
+func (ctrl *ReclamationController) GetReclamationReport(c *fiber.Ctx) error {
+	command := reclamation.GetReclamationReportCommand{}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	reportResponse := response.(*reclamation.GetReclamationReportResponse)
+	return c.JSON(reportResponse)
+}
+
+//	@Summary		Reclaim storage
+//	@Description	Remove everything the reclamation report lists for the given categories, or for all categories when none are given (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		reclamation.ReclaimStorageCommand	false	"Categories to reclaim"
+//	@Success		200		{object}	reclamation.ReclaimStorageResponse	"Reclamation result"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Failure		403		{object}	apierror.Error						"Forbidden"
+//	@Router			/admin/reclamation [post]
+func (ctrl *ReclamationController) ReclaimStorage(c *fiber.Ctx) error {
+	var command reclamation.ReclaimStorageCommand
+
+	if len(c.Body()) > 0 {
+		if err := binding.Parse(c, &command); err != nil {
+			return err
+		}
+	}
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	reclaimResponse := response.(*reclamation.ReclaimStorageResponse)
+	return c.JSON(reclaimResponse)
+}
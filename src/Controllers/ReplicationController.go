@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Replication"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type ReplicationController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewReplicationController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *ReplicationController {
+	return &ReplicationController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Configure bucket replication
+//	@Description	Push the bucket's files to a bucket of another SHBucket installation, written with an API key of that installation. Files are pushed shortly after they are uploaded. conflict_policy decides what happens to names written on the remote by others: overwrite (default), skip, or newer to overwrite only with newer files. replicate_deletes removes pushed files once deleted here. api_key may be left out to keep the current key (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string											true	"Bucket ID"
+//	@Param			request	body		replication.ConfigureBucketReplicationCommand	true	"Remote bucket"
+//	@Success		200		{object}	replication.ConfigureBucketReplicationResponse	"Replication configured"
+//	@Failure		400		{object}	apierror.Error									"Bad request or remote bucket can't be reached"
+//	@Failure		401		{object}	apierror.Error									"Unauthorized"
+//	@Failure		403		{object}	apierror.Error									"Forbidden"
+//	@Failure		404		{object}	apierror.Error									"Bucket not found"
+//	@Router			/buckets/{id}/replication [put]
+func (ctrl *ReplicationController) ConfigureBucketReplication(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command replication.ConfigureBucketReplicationCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	replicationResponse := response.(*replication.ConfigureBucketReplicationResponse)
+	return c.JSON(replicationResponse)
+}
+
+//	@Summary		Get bucket replication
+//	@Description	Get the bucket's replication to a remote bucket, with the counters of its passes (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string										true	"Bucket ID"
+//	@Success		200	{object}	replication.GetBucketReplicationResponse	"Bucket replication"
+//	@Failure		400	{object}	apierror.Error								"Bad request"
+//	@Failure		401	{object}	apierror.Error								"Unauthorized"
+//	@Failure		403	{object}	apierror.Error								"Forbidden"
+//	@Failure		404	{object}	apierror.Error								"Bucket not found or not replicated"
+//	@Router			/buckets/{id}/replication [get]
+func (ctrl *ReplicationController) GetBucketReplication(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := replication.GetBucketReplicationCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	replicationResponse := response.(*replication.GetBucketReplicationResponse)
+	return c.JSON(replicationResponse)
+}
+
+//	@Summary		Get bucket replication status
+//	@Description	How far the bucket's replication is behind: the changes not pushed yet and the age of the oldest of them. healthy is false once the last pass failed or the lag passes 15 minutes (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string										true	"Bucket ID"
+//	@Success		200	{object}	replication.GetReplicationStatusResponse	"Replication status"
+//	@Failure		400	{object}	apierror.Error								"Bad request"
+//	@Failure		401	{object}	apierror.Error								"Unauthorized"
+//	@Failure		403	{object}	apierror.Error								"Forbidden"
+//	@Failure		404	{object}	apierror.Error								"Bucket not found or not replicated"
+//	@Router			/buckets/{id}/replication/status [get]
+func (ctrl *ReplicationController) GetReplicationStatus(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := replication.GetReplicationStatusCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	statusResponse := response.(*replication.GetReplicationStatusResponse)
+	return c.JSON(statusResponse)
+}
+
+//	@Summary		Delete bucket replication
+//	@Description	Stop replicating the bucket, the files already pushed stay on the remote (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string										true	"Bucket ID"
+//	@Success		200	{object}	replication.DeleteBucketReplicationResponse	"Replication removed"
+//	@Failure		400	{object}	apierror.Error								"Bad request"
+//	@Failure		401	{object}	apierror.Error								"Unauthorized"
+//	@Failure		403	{object}	apierror.Error								"Forbidden"
+//	@Failure		404	{object}	apierror.Error								"Bucket not found or not replicated"
+//	@Router			/buckets/{id}/replication [delete]
+func (ctrl *ReplicationController) DeleteBucketReplication(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := replication.DeleteBucketReplicationCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := response.(*replication.DeleteBucketReplicationResponse)
+	return c.JSON(deleteResponse)
+}
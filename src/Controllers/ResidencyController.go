@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/Residency"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type ResidencyController struct {
+	mediator *mediator.Mediator
+}
+
+func NewResidencyController(mediator *mediator.Mediator) *ResidencyController {
+	return &ResidencyController{
+		mediator: mediator,
+	}
+}
+
+//	@Summary		Data residency report
+//	@Description	Report, per bucket, which nodes and node groups hold its content, counting file versions, snapshot copies, cached derivatives and backup copies, and whether each location is allowed by the bucket's placement (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucket_id		query		string	false	"Only this bucket"
+//	@Param			non_compliant	query		bool	false	"Only buckets with content outside their placement"
+//	@Success		200				{object}	residency.GetResidencyReportResponse	"Residency report"
+//	@Failure		400				{object}	apierror.Error							"Bad request"
+//	@Failure		401				{object}	apierror.Error							"Unauthorized"
+//	@Failure		403				{object}	apierror.Error							"Forbidden"
+//	@Router			/admin/residency [get]
+func (ctrl *ResidencyController) GetResidencyReport(c *fiber.Ctx) error {
+	command := residency.GetResidencyReportCommand{
+		NonCompliant: c.QueryBool("non_compliant", false),
+	}
+	if bucketID := c.Query("bucket_id"); bucketID != "" {
+		id, err := uuid.Parse(bucketID)
+		if err != nil {
+			return apierror.Invalid("Invalid bucket ID")
+		}
+		command.BucketID = &id
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*residency.GetResidencyReportResponse))
+}
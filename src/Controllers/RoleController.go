@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Role"
+	"shbucket/src/Application/User"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type RoleController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewRoleController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *RoleController {
+	return &RoleController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		List roles
+//	@Description	List the built-in roles and the custom roles, with the permissions each holds and every permission a custom role can hold (admin only)
+//	@Tags			roles
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	role.ListRolesResponse	"Roles"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
+//	@Router			/admin/roles [get]
+func (ctrl *RoleController) ListRoles(c *fiber.Ctx) error {
+	response, err := ctrl.mediator.Send(c.UserContext(), &role.ListRolesCommand{})
+	if err != nil {
+		return err
+	}
+
+	listResponse := response.(*role.ListRolesResponse)
+	return c.JSON(listResponse)
+}
+
+//	@Summary		Create a custom role
+//	@Description	Create a named set of permissions, such as an uploader that may only upload and list, which users and API keys can be given (admin only)
+//	@Tags			roles
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		role.CreateRoleCommand	true	"Role"
+//	@Success		201		{object}	role.CreateRoleResponse	"Role created"
+//	@Failure		400		{object}	apierror.Error			"Bad request"
+//	@Failure		401		{object}	apierror.Error			"Unauthorized"
+//	@Failure		409		{object}	apierror.Error			"Role exists"
+//	@Router			/admin/roles [post]
+func (ctrl *RoleController) CreateRole(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command role.CreateRoleCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	createResponse := response.(*role.CreateRoleResponse)
+	return c.Status(http.StatusCreated).JSON(createResponse)
+}
+
+//	@Summary		Update a custom role
+//	@Description	Change a custom role's description or replace its permissions, which applies to every user and API key holding it (admin only)
+//	@Tags			roles
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string					true	"Role ID"
+//	@Param			request	body		role.UpdateRoleCommand	true	"Changes"
+//	@Success		200		{object}	role.UpdateRoleResponse	"Role updated"
+//	@Failure		400		{object}	apierror.Error			"Bad request"
+//	@Failure		401		{object}	apierror.Error			"Unauthorized"
+//	@Failure		404		{object}	apierror.Error			"Role not found"
+//	@Router			/admin/roles/{id} [put]
+func (ctrl *RoleController) UpdateRole(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	roleID := binding.UUID(c, "id")
+
+	var command role.UpdateRoleCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.RoleID = roleID
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	updateResponse := response.(*role.UpdateRoleResponse)
+	return c.JSON(updateResponse)
+}
+
+//	@Summary		Delete a custom role
+//	@Description	Delete a custom role no user, API key or pending invitation holds (admin only)
+//	@Tags			roles
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string					true	"Role ID"
+//	@Success		200	{object}	role.DeleteRoleResponse	"Role deleted"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
+//	@Failure		404	{object}	apierror.Error			"Role not found"
+//	@Failure		409	{object}	apierror.Error			"Role in use"
+//	@Router			/admin/roles/{id} [delete]
+func (ctrl *RoleController) DeleteRole(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	roleID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &role.DeleteRoleCommand{
+		RoleID: roleID,
+		UserID: userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := response.(*role.DeleteRoleResponse)
+	return c.JSON(deleteResponse)
+}
+
+//	@Summary		Set a user's role
+//	@Description	Give a user a built-in or custom role and end their sessions. Admins can't change their own role (admin only)
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string						true	"User ID"
+//	@Param			request	body		user.SetUserRoleCommand		true	"Role"
+//	@Success		200		{object}	user.SetUserRoleResponse	"Role updated"
+//	@Failure		400		{object}	apierror.Error				"Bad request or unknown role"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Router			/users/{id}/role [put]
+func (ctrl *RoleController) SetUserRole(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	targetUserID := binding.UUID(c, "id")
+
+	var command user.SetUserRoleCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.TargetUserID = targetUserID
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	setResponse := response.(*user.SetUserRoleResponse)
+	return c.JSON(setResponse)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Setting"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type SettingsController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewSettingsController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *SettingsController {
+	return &SettingsController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Get system settings
+//	@Description	Get the hot-reloadable settings in effect, plus the environment settings that need a restart to change (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	setting.GetSystemSettingsResponse	"System settings"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		403	{object}	apierror.Error						"Forbidden"
+//	@Router			/admin/settings [get]
+func (ctrl *SettingsController) GetSystemSettings(c *fiber.Ctx) error {
+	command := setting.GetSystemSettingsCommand{}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	settingsResponse := response.(*setting.GetSystemSettingsResponse)
+	return c.JSON(settingsResponse)
+}
+
+//	@Summary		Update system settings
+//	@Description	Change CORS, rate limit, default bucket and image transform settings. Changes are stored and take effect immediately without a restart (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		setting.UpdateSystemSettingsCommand		true	"Settings to change"
+//	@Success		200		{object}	setting.UpdateSystemSettingsResponse	"Updated settings"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Failure		403		{object}	apierror.Error							"Forbidden"
+//	@Router			/admin/settings [put]
+func (ctrl *SettingsController) UpdateSystemSettings(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command setting.UpdateSystemSettingsCommand
+
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+
+	command.UserID = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	settingsResponse := response.(*setting.UpdateSystemSettingsResponse)
+	return c.JSON(settingsResponse)
+}
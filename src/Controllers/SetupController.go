@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	"time"
 	
@@ -9,6 +8,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 	
 	"shbucket/src/Application/Setup"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Mediator"
 	"shbucket/src/Models"
 )
@@ -31,16 +32,14 @@ func NewSetupController(mediator *mediator.Mediator, validator *validator.Valida
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	setup.CheckSetupResponse	"Setup status information"
-//	@Failure		500	{object}	map[string]string			"Internal server error"
+//	@Failure		500	{object}	apierror.Error				"Internal server error"
 //	@Router			/setup/status [get]
 func (ctrl *SetupController) CheckSetup(c *fiber.Ctx) error {
 	command := &setup.CheckSetupCommand{}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	checkResponse := response.(*setup.CheckSetupResponse)
@@ -54,22 +53,13 @@ func (ctrl *SetupController) CheckSetup(c *fiber.Ctx) error {
 //	@Produce		json
 //	@Param			request	body		models.MasterSetupRequest	true	"Master setup configuration"
 //	@Success		200		{object}	setup.MasterSetupResponse	"Master setup successful"
-//	@Failure		400		{object}	map[string]string			"Invalid request or setup failed"
+//	@Failure		400		{object}	apierror.Error				"Invalid request or setup failed"
 //	@Router			/setup/master [post]
 func (ctrl *SetupController) SetupMaster(c *fiber.Ctx) error {
 	var req models.MasterSetupRequest
 	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &req); err != nil {
+		return err
 	}
 	
 	command := &setup.MasterSetupCommand{
@@ -84,11 +74,9 @@ func (ctrl *SetupController) SetupMaster(c *fiber.Ctx) error {
 		SystemName:      req.SystemName,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	masterResponse := response.(*setup.MasterSetupResponse)
@@ -102,22 +90,13 @@ func (ctrl *SetupController) SetupMaster(c *fiber.Ctx) error {
 //	@Produce		json
 //	@Param			request	body		models.NodeSetupRequest	true	"Node setup configuration"
 //	@Success		201	{object}	setup.NodeSetupResponse	"Node setup successful"
-//	@Failure		400	{object}	map[string]string		"Invalid request or setup failed"
+//	@Failure		400	{object}	apierror.Error			"Invalid request or setup failed"
 //	@Router			/setup/node [post]
 func (ctrl *SetupController) SetupNode(c *fiber.Ctx) error {
 	var req models.NodeSetupRequest
 	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &req); err != nil {
+		return err
 	}
 	
 	command := &setup.NodeSetupCommand{
@@ -127,13 +106,12 @@ func (ctrl *SetupController) SetupNode(c *fiber.Ctx) error {
 		StoragePath:  req.StoragePath,
 		MaxStorage:   req.MaxStorage,
 		MasterAPIKey: req.MasterAPIKey,
+		RegistrationToken: req.RegistrationToken,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	nodeResponse := response.(*setup.NodeSetupResponse)
@@ -146,13 +124,14 @@ func (ctrl *SetupController) SetupNode(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	models.SystemInfoResponse	"System information"
-//	@Failure		500	{object}	map[string]string			"Internal server error"
+//	@Failure		500	{object}	apierror.Error				"Internal server error"
 //	@Router			/setup/info [get]
 func (ctrl *SetupController) GetSystemInfo(c *fiber.Ctx) error {
 	// This would return system information after setup
 	return c.JSON(models.SystemInfoResponse{
 		SystemName:  "SHBucket",
 		Version:     "2.0.0",
+		Profile:     config.GetSettings().Profile,
 		IsHealthy:   true,
 		LastChecked: time.Now(),
 	})
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/SigningKey"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type SigningKeyController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewSigningKeyController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *SigningKeyController {
+	return &SigningKeyController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		List signing keys
+//	@Description	List the keys signed URLs and upload policies are signed with, which one signs and when the others retire, without their secrets (admin only)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	signingkey.ListSigningKeysResponse	"Signing keys"
+//	@Failure		401	{object}	apierror.Error						"Unauthorized"
+//	@Failure		403	{object}	apierror.Error						"Forbidden"
+//	@Router			/admin/signing-keys [get]
+func (ctrl *SigningKeyController) ListSigningKeys(c *fiber.Ctx) error {
+	response, err := ctrl.mediator.Send(c.UserContext(), &signingkey.ListSigningKeysCommand{})
+	if err != nil {
+		return err
+	}
+
+	listResponse := response.(*signingkey.ListSigningKeysResponse)
+	return c.JSON(listResponse)
+}
+
+//	@Summary		Rotate the signing key
+//	@Description	Start signing URLs and upload policies with a new key. What the replaced key signed still verifies for retire_in seconds, SIGNING_KEY_RETIREMENT when left out, and 0 invalidates it at once (admin only)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		signingkey.RotateSigningKeyCommand	false	"Retirement of the replaced key"
+//	@Success		200		{object}	signingkey.RotateSigningKeyResponse	"Signing key rotated"
+//	@Failure		400		{object}	apierror.Error						"Bad request"
+//	@Failure		401		{object}	apierror.Error						"Unauthorized"
+//	@Failure		403		{object}	apierror.Error						"Forbidden"
+//	@Router			/admin/signing-keys/rotate [post]
+func (ctrl *SigningKeyController) RotateSigningKey(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command signingkey.RotateSigningKeyCommand
+	if len(c.Body()) > 0 {
+		if err := binding.Parse(c, &command); err != nil {
+			return err
+		}
+	}
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	rotateResponse := response.(*signingkey.RotateSigningKeyResponse)
+	return c.JSON(rotateResponse)
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Snapshot"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type SnapshotController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewSnapshotController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *SnapshotController {
+	return &SnapshotController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Create a bucket snapshot
+//	@Description	Record an immutable, named point-in-time view of a bucket's objects. Content is preserved with hard links, so unchanged data is not duplicated
+//	@Tags			snapshots
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Bucket ID"
+//	@Param			request	body		snapshot.CreateSnapshotCommand	true	"Snapshot name"
+//	@Success		201		{object}	snapshot.CreateSnapshotResponse	"Snapshot created"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{id}/snapshots [post]
+func (ctrl *SnapshotController) CreateSnapshot(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command snapshot.CreateSnapshotCommand
+
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	createResponse := response.(*snapshot.CreateSnapshotResponse)
+	return c.Status(http.StatusCreated).JSON(createResponse)
+}
+
+//	@Summary		List bucket snapshots
+//	@Description	List a bucket's snapshots, newest first
+//	@Tags			snapshots
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Bucket ID"
+//	@Success		200	{object}	snapshot.ListSnapshotsResponse	"Snapshots"
+//	@Failure		400	{object}	apierror.Error					"Bad request"
+//	@Router			/buckets/{id}/snapshots [get]
+func (ctrl *SnapshotController) ListSnapshots(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "id")
+
+	command := snapshot.ListSnapshotsCommand{
+		BucketID: bucketID,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	listResponse := response.(*snapshot.ListSnapshotsResponse)
+	return c.JSON(listResponse)
+}
+
+//	@Summary		List files in a snapshot
+//	@Description	Browse the read-only object list a snapshot captured
+//	@Tags			snapshots
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string								true	"Bucket ID"
+//	@Param			name	path		string								true	"Snapshot name"
+//	@Param			page	query		int									false	"Page number"	default(1)
+//	@Param			limit	query		int									false	"Page size"		default(10)
+//	@Success		200		{object}	snapshot.ListSnapshotFilesResponse	"Snapshot files"
+//	@Failure		404		{object}	apierror.Error						"Snapshot not found"
+//	@Router			/buckets/{id}/snapshots/{name}/files [get]
+func (ctrl *SnapshotController) ListSnapshotFiles(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "id")
+
+	command := snapshot.ListSnapshotFilesCommand{
+		BucketID: bucketID,
+		Name:     c.Params("name"),
+		Page:     c.QueryInt("page", 1),
+		Limit:    c.QueryInt("limit", 10),
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	listResponse := response.(*snapshot.ListSnapshotFilesResponse)
+	return c.JSON(listResponse)
+}
+
+//	@Summary		Compare two snapshots
+//	@Description	Report the objects added, removed and changed between two snapshots of a bucket, matched by name
+//	@Tags			snapshots
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Bucket ID"
+//	@Param			a	path		string							true	"Base snapshot name"
+//	@Param			b	path		string							true	"Target snapshot name"
+//	@Success		200	{object}	snapshot.DiffSnapshotsResponse	"Snapshot differences"
+//	@Failure		404	{object}	apierror.Error					"Snapshot not found"
+//	@Router			/buckets/{id}/snapshots/{a}/diff/{b} [get]
+func (ctrl *SnapshotController) DiffSnapshots(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "id")
+
+	command := snapshot.DiffSnapshotsCommand{
+		BucketID: bucketID,
+		Base:     c.Params("a"),
+		Target:   c.Params("b"),
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	diffResponse := response.(*snapshot.DiffSnapshotsResponse)
+	return c.JSON(diffResponse)
+}
+
+//	@Summary		Download a file from a snapshot
+//	@Description	Stream a file's content as it was when the snapshot was taken
+//	@Tags			snapshots
+//	@Produce		application/octet-stream
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string				true	"Bucket ID"
+//	@Param			name	path		string				true	"Snapshot name"
+//	@Param			fileId	path		string				true	"File ID"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Algorithm	header	string	false	"AES256, when the content is encrypted with a customer-provided key"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key		header	string	false	"Base64 encoded 256-bit customer-provided key, never stored"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key-MD5	header	string	false	"Base64 encoded MD5 of the customer-provided key"
+//	@Success		200		{file}		binary				"File content"
+//	@Failure		404		{object}	apierror.Error		"File not found"
+//	@Router			/buckets/{id}/snapshots/{name}/files/{fileId} [get]
+func (ctrl *SnapshotController) GetSnapshotFile(c *fiber.Ctx) error {
+	bucketID := binding.UUID(c, "id")
+
+	fileID := binding.UUID(c, "fileId")
+
+	customerKey, err := customerKeyFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	command := snapshot.GetSnapshotFileCommand{
+		BucketID:    bucketID,
+		Name:        c.Params("name"),
+		FileID:      fileID,
+		CustomerKey: customerKey,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	fileResponse := response.(*snapshot.GetSnapshotFileResponse)
+	setCustomerKeyHeaders(c, fileResponse.CustomerKeyMD5)
+
+	// Snapshot content never changes, so the checksum is a stable validator
+	c.Set("ETag", fmt.Sprintf("\"%s\"", fileResponse.Checksum))
+	c.Set("Cache-Control", "private, max-age=31536000, immutable")
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileResponse.Name))
+
+	if c.Fresh() {
+		fileResponse.Content.Close()
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	c.Set("Content-Type", fileResponse.MimeType)
+	return c.SendStream(fileResponse.Content, int(fileResponse.Size))
+}
+
+//	@Summary		Delete a bucket snapshot
+//	@Description	Delete a snapshot and release content that only it was preserving
+//	@Tags			snapshots
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Bucket ID"
+//	@Param			name	path		string							true	"Snapshot name"
+//	@Success		200		{object}	snapshot.DeleteSnapshotResponse	"Snapshot deleted"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Router			/buckets/{id}/snapshots/{name} [delete]
+func (ctrl *SnapshotController) DeleteSnapshot(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := snapshot.DeleteSnapshotCommand{
+		BucketID: bucketID,
+		Name:     c.Params("name"),
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := response.(*snapshot.DeleteSnapshotResponse)
+	return c.JSON(deleteResponse)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Stats"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type StatsController struct {
+	mediator *mediator.Mediator
+}
+
+func NewStatsController(mediator *mediator.Mediator) *StatsController {
+	return &StatsController{
+		mediator: mediator,
+	}
+}
+
+// @Summary		System statistics
+// @Description	Report system-wide figures for the admin dashboard: buckets, file versions and bytes stored in total, per bucket with its egress and per storage location against its capacity, uploads and downloads in the last 24 hours, and users and active API keys (admin only)
+// @Tags			admin
+// @Produce		json
+// @Security		Bearer
+// @Security		ApiKeyAuth
+// @Success		200	{object}	stats.GetSystemStatsResponse	"System statistics"
+// @Failure		401	{object}	apierror.Error					"Unauthorized"
+// @Failure		403	{object}	apierror.Error					"Forbidden"
+// @Router			/admin/stats [get]
+func (ctrl *StatsController) GetSystemStats(c *fiber.Ctx) error {
+	response, err := ctrl.mediator.Send(c.UserContext(), &stats.GetSystemStatsCommand{})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*stats.GetSystemStatsResponse))
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Progress"
+)
+
+// transferHeartbeat is how often an idle progress stream sends a comment, keeping proxies from closing it
+const transferHeartbeat = 15 * time.Second
+
+// transferStreamIdle is how long a progress stream stays open without the transfer changing
+const transferStreamIdle = 10 * time.Minute
+
+type TransferController struct {
+	authService *auth.AuthorizationService
+}
+
+func NewTransferController(authService *auth.AuthorizationService) *TransferController {
+	return &TransferController{
+		authService: authService,
+	}
+}
+
+//	@Summary		Get upload progress
+//	@Description	Report how far an upload sent with the X-Transfer-Id header got: its stage, the bytes of the stage done and, once complete, the file. Only the user uploading sees it, on the server handling the upload
+//	@Tags			uploads
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			transferId	path		string				true	"Transfer ID"
+//	@Success		200			{object}	progress.Event		"Upload progress"
+//	@Failure		400			{object}	apierror.Error		"Invalid transfer ID"
+//	@Failure		401			{object}	apierror.Error		"Unauthorized"
+//	@Failure		404			{object}	apierror.Error		"Transfer not found"
+//	@Router			/transfers/{transferId} [get]
+func (ctrl *TransferController) GetTransfer(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	transferID := c.Params("transferId")
+	if !progress.ValidID(transferID) {
+		return apierror.Invalid("Invalid transfer ID")
+	}
+
+	event, ok := progress.Get(userContext.UserID, transferID)
+	if !ok {
+		return apierror.NotFound("Transfer not found")
+	}
+	return c.JSON(event)
+}
+
+//	@Summary		Stream upload progress
+//	@Description	Follow an upload sent with the X-Transfer-Id header as server-sent events. Subscribe before the upload starts to see all of it; each "progress" event carries the transfer's state, and the stream ends after the upload completes or fails
+//	@Tags			uploads
+//	@Produce		text/event-stream
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			transferId	path		string				true	"Transfer ID"
+//	@Success		200			{object}	progress.Event		"Stream of progress events"
+//	@Failure		400			{object}	apierror.Error		"Invalid transfer ID"
+//	@Failure		401			{object}	apierror.Error		"Unauthorized"
+//	@Router			/transfers/{transferId}/events [get]
+func (ctrl *TransferController) StreamTransfer(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	transferID := c.Params("transferId")
+	if !progress.ValidID(transferID) {
+		return apierror.Invalid("Invalid transfer ID")
+	}
+
+	events, unsubscribe := progress.Subscribe(userContext.UserID, transferID)
+	conn := c.Context().Conn()
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		heartbeat := time.NewTicker(transferHeartbeat)
+		defer heartbeat.Stop()
+		idle := time.NewTimer(transferStreamIdle)
+		defer idle.Stop()
+
+		for {
+			// The server's write timeout is for a response, a stream gets it per write
+			conn.SetWriteDeadline(time.Now().Add(transferHeartbeat * 2))
+			select {
+			case event := <-events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+				if w.Flush() != nil || event.Done() {
+					return
+				}
+				idle.Reset(transferStreamIdle)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				if w.Flush() != nil {
+					return
+				}
+			case <-idle.C:
+				return
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/User"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type TwoFactorController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewTwoFactorController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *TwoFactorController {
+	return &TwoFactorController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Complete two-factor login
+//	@Description	Complete a sign in that returned two_factor_required, with the challenge token and a code from the authenticator app or a recovery code. A sign in allows 5 wrong codes within 5 minutes
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		user.LoginTwoFactorCommand	true	"Challenge token and code"
+//	@Success		200		{object}	user.LoginResponse			"Login successful"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Invalid code or expired sign in"
+//	@Router			/auth/login/2fa [post]
+func (ctrl *TwoFactorController) LoginTwoFactor(c *fiber.Ctx) error {
+	var command user.LoginTwoFactorCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	loginResponse := response.(*user.LoginResponse)
+	return c.JSON(loginResponse)
+}
+
+//	@Summary		Get two-factor status
+//	@Description	Whether two-factor authentication is enabled for the current user, whether their role requires it, and how many recovery codes are left
+//	@Tags			auth
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	user.GetTwoFactorStatusResponse	"Two-factor status"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Router			/auth/2fa [get]
+func (ctrl *TwoFactorController) GetStatus(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &user.GetTwoFactorStatusCommand{UserID: userContext.UserID})
+	if err != nil {
+		return err
+	}
+
+	statusResponse := response.(*user.GetTwoFactorStatusResponse)
+	return c.JSON(statusResponse)
+}
+
+//	@Summary		Enroll in two-factor authentication
+//	@Description	Generate a TOTP secret for the current user's authenticator app. otpauth_url is the payload of the QR code to scan. Two-factor is enabled once a code is confirmed
+//	@Tags			auth
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	user.EnrollTwoFactorResponse	"Secret generated"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		409	{object}	apierror.Error					"Two-factor already enabled"
+//	@Router			/auth/2fa/enroll [post]
+func (ctrl *TwoFactorController) Enroll(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &user.EnrollTwoFactorCommand{UserID: userContext.UserID})
+	if err != nil {
+		return err
+	}
+
+	enrollResponse := response.(*user.EnrollTwoFactorResponse)
+	return c.JSON(enrollResponse)
+}
+
+//	@Summary		Confirm two-factor enrollment
+//	@Description	Enable two-factor authentication with a code from the enrolled authenticator app. Returns the recovery codes, which aren't shown again
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		user.ConfirmTwoFactorCommand	true	"Code from the authenticator app"
+//	@Success		200		{object}	user.ConfirmTwoFactorResponse	"Two-factor enabled"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized or invalid code"
+//	@Failure		409		{object}	apierror.Error					"Two-factor already enabled or not enrolled"
+//	@Router			/auth/2fa/confirm [post]
+func (ctrl *TwoFactorController) Confirm(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command user.ConfirmTwoFactorCommand
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	confirmResponse := response.(*user.ConfirmTwoFactorResponse)
+	return c.JSON(confirmResponse)
+}
+
+//	@Summary		Disable two-factor authentication
+//	@Description	Turn two-factor authentication off with the password and a code or recovery code. Refused when the user's role requires two-factor
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		user.DisableTwoFactorCommand	true	"Password and code"
+//	@Success		200		{object}	user.DisableTwoFactorResponse	"Two-factor disabled"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized or invalid code"
+//	@Failure		403		{object}	apierror.Error					"Required for the user's role"
+//	@Router			/auth/2fa/disable [post]
+func (ctrl *TwoFactorController) Disable(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command user.DisableTwoFactorCommand
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	disableResponse := response.(*user.DisableTwoFactorResponse)
+	return c.JSON(disableResponse)
+}
+
+//	@Summary		Regenerate recovery codes
+//	@Description	Replace the current user's recovery codes, with a code from the authenticator app. The earlier codes stop working
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		user.RegenerateRecoveryCodesCommand		true	"Code from the authenticator app"
+//	@Success		200		{object}	user.RegenerateRecoveryCodesResponse	"New recovery codes"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized or invalid code"
+//	@Router			/auth/2fa/recovery-codes [post]
+func (ctrl *TwoFactorController) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command user.RegenerateRecoveryCodesCommand
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	regenerateResponse := response.(*user.RegenerateRecoveryCodesResponse)
+	return c.JSON(regenerateResponse)
+}
+
+//	@Summary		Reset a user's two-factor authentication
+//	@Description	Turn off two-factor authentication of a user who lost their authenticator app and recovery codes, and end their sessions (admin only)
+//	@Tags			users
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"User ID"
+//	@Success		200	{object}	user.ResetTwoFactorResponse	"Two-factor reset"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
+//	@Failure		409	{object}	apierror.Error				"Two-factor not enabled"
+//	@Router			/users/{id}/2fa [delete]
+func (ctrl *TwoFactorController) ResetTwoFactor(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	targetUserID := binding.UUID(c, "id")
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &user.ResetTwoFactorCommand{
+		TargetUserID: targetUserID,
+		UserID:       userContext.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	resetResponse := response.(*user.ResetTwoFactorResponse)
+	return c.JSON(resetResponse)
+}
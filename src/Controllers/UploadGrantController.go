@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/UploadGrant"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type UploadGrantController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewUploadGrantController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *UploadGrantController {
+	return &UploadGrantController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Create upload link
+//	@Description	Create a time-limited link that lets anyone holding it upload files into the bucket, under a name prefix and within limits on file size and count. The secret is only returned once
+//	@Tags			upload-links
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string									true	"Bucket ID"
+//	@Param			request	body		uploadgrant.CreateUploadGrantCommand	true	"Upload link limits"
+//	@Success		201		{object}	uploadgrant.CreateUploadGrantResponse	"Upload link created"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Router			/buckets/{id}/upload-grants [post]
+func (ctrl *UploadGrantController) CreateUploadGrant(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command uploadgrant.CreateUploadGrantCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(response.(*uploadgrant.CreateUploadGrantResponse))
+}
+
+//	@Summary		List upload links
+//	@Description	List a bucket's upload links with their usage, expired and revoked ones included. Secrets are never returned
+//	@Tags			upload-links
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string									true	"Bucket ID"
+//	@Success		200	{object}	uploadgrant.ListUploadGrantsResponse	"Upload links"
+//	@Failure		400	{object}	apierror.Error							"Bad request"
+//	@Failure		401	{object}	apierror.Error							"Unauthorized"
+//	@Router			/buckets/{id}/upload-grants [get]
+func (ctrl *UploadGrantController) ListUploadGrants(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := &uploadgrant.ListUploadGrantsCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*uploadgrant.ListUploadGrantsResponse))
+}
+
+//	@Summary		Revoke upload link
+//	@Description	Revoke an upload link. Uploads through it are refused from then on; files already uploaded stay
+//	@Tags			upload-links
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string									true	"Bucket ID"
+//	@Param			grantId	path		string									true	"Upload link ID"
+//	@Success		200		{object}	uploadgrant.RevokeUploadGrantResponse	"Upload link revoked"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Router			/buckets/{id}/upload-grants/{grantId} [delete]
+func (ctrl *UploadGrantController) RevokeUploadGrant(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	grantID := binding.UUID(c, "grantId")
+
+	command := &uploadgrant.RevokeUploadGrantCommand{
+		BucketID: bucketID,
+		GrantID:  grantID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*uploadgrant.RevokeUploadGrantResponse))
+}
+
+//	@Summary		Get upload link
+//	@Description	Show what an upload link accepts: its size and count limits, the uploads left and when it expires. No authentication, the token in the path is the credential
+//	@Tags			upload-links
+//	@Produce		json
+//	@Param			token	path		string								true	"Upload link token"
+//	@Success		200		{object}	uploadgrant.GetUploadLinkResponse	"Upload link"
+//	@Failure		404		{object}	apierror.Error						"Upload link not found"
+//	@Failure		410		{object}	apierror.Error						"Upload link revoked, expired or used up"
+//	@Router			/upload/{token} [get]
+func (ctrl *UploadGrantController) GetUploadLink(c *fiber.Ctx) error {
+	command := &uploadgrant.GetUploadLinkCommand{
+		Token: c.Params("token"),
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*uploadgrant.GetUploadLinkResponse))
+}
+
+//	@Summary		Upload through upload link
+//	@Description	Upload one file anonymously through an upload link. The file is named under the link's prefix and gets a numbered name instead of replacing an existing file
+//	@Tags			upload-links
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			token	path		string									true	"Upload link token"
+//	@Param			file	formData	file									true	"File to upload"
+//	@Param			Idempotency-Key	header	string	false	"Key retries of this upload are sent with, to get the first response back instead of uploading again"
+//	@Param			X-Checksum-Sha256	header	string	false	"Hex or base64 encoded sha256 of the file, the upload is refused when the content differs. X-Amz-Checksum-Sha256 is accepted as well"
+//	@Success		201		{object}	uploadgrant.UploadWithGrantResponse		"File uploaded"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		404		{object}	apierror.Error							"Upload link not found"
+//	@Failure		410		{object}	apierror.Error							"Upload link revoked, expired or used up"
+//	@Failure		413		{object}	apierror.Error							"File too large"
+//	@Router			/upload/{token} [post]
+func (ctrl *UploadGrantController) UploadWithGrant(c *fiber.Ctx) error {
+	// A closed link, or a file over the link's limit, is refused before the body is read
+	link, err := ctrl.mediator.Send(c.UserContext(), &uploadgrant.GetUploadLinkCommand{Token: c.Params("token")})
+	if err != nil {
+		return err
+	}
+	if limit := link.(*uploadgrant.GetUploadLinkResponse).Link.MaxFileSize; declaredUploadTooLarge(c, limit) {
+		return uploadTooLarge(c, limit)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return apierror.Invalid("No file provided")
+	}
+
+	fileReader, err := fileHeader.Open()
+	if err != nil {
+		return apierror.Invalid("Failed to open file")
+	}
+	defer fileReader.Close()
+
+	checksum, err := expectedChecksum(c)
+	if err != nil {
+		return err
+	}
+
+	command := &uploadgrant.UploadWithGrantCommand{
+		Token:       c.Params("token"),
+		File:        fileHeader,
+		FileReader:  fileReader,
+		FileName:         fileHeader.Filename,
+		ContentType:      fileHeader.Header.Get("Content-Type"),
+		ExpectedChecksum: checksum,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(response.(*uploadgrant.UploadWithGrantResponse))
+}
+
+//	@Summary		Create upload policy
+//	@Description	Sign a policy a browser can upload a file with by posting a form straight to the server, without credentials of its own. The policy limits the file's name prefix, size and content type and expires; nothing is stored, so it can't be revoked other than by its issuer losing access to the bucket
+//	@Tags			upload-links
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string									true	"Bucket ID"
+//	@Param			request	body		uploadgrant.CreateUploadPolicyCommand	true	"Policy conditions"
+//	@Success		201		{object}	uploadgrant.CreateUploadPolicyResponse	"Upload policy signed"
+//	@Failure		400		{object}	apierror.Error							"Bad request"
+//	@Failure		401		{object}	apierror.Error							"Unauthorized"
+//	@Router			/buckets/{id}/upload-policies [post]
+func (ctrl *UploadGrantController) CreateUploadPolicy(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command uploadgrant.CreateUploadPolicyCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(response.(*uploadgrant.CreateUploadPolicyResponse))
+}
+
+//	@Summary		Upload with upload policy
+//	@Description	Upload one file from a browser form signed with an upload policy. The form carries the policy and signature fields as issued, the file's name in key ("${filename}" is replaced with the uploaded file's name) and the file last. No authentication, the signed policy is the credential
+//	@Tags			upload-links
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			policy			formData	string								true	"Policy, as issued"
+//	@Param			signature		formData	string								true	"Policy signature, as issued"
+//	@Param			key				formData	string								true	"File name, under the policy's key prefix"
+//	@Param			Content-Type	formData	string								false	"Content type of the file, instead of the one the browser sends"
+//	@Param			file			formData	file								true	"File to upload"
+//	@Param			X-Checksum-Sha256	header	string	false	"Hex or base64 encoded sha256 of the file, the upload is refused when the content differs. X-Amz-Checksum-Sha256 is accepted as well"
+//	@Success		201				{object}	uploadgrant.UploadWithPolicyResponse	"File uploaded"
+//	@Failure		400				{object}	apierror.Error						"Bad request"
+//	@Failure		403				{object}	apierror.Error						"Invalid, expired or violated policy"
+//	@Failure		409				{object}	apierror.Error						"Name exists and can't be overwritten"
+//	@Failure		413				{object}	apierror.Error						"File too large"
+//	@Router			/upload-policy [post]
+func (ctrl *UploadGrantController) UploadWithPolicy(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return apierror.Invalid("No file provided")
+	}
+
+	fileReader, err := fileHeader.Open()
+	if err != nil {
+		return apierror.Invalid("Failed to open file")
+	}
+	defer fileReader.Close()
+
+	checksum, err := expectedChecksum(c)
+	if err != nil {
+		return err
+	}
+
+	contentType := c.FormValue("Content-Type")
+	if contentType == "" {
+		contentType = fileHeader.Header.Get("Content-Type")
+	}
+
+	command := &uploadgrant.UploadWithPolicyCommand{
+		Policy:      c.FormValue("policy"),
+		Signature:   c.FormValue("signature"),
+		Key:         c.FormValue("key"),
+		File:        fileHeader,
+		FileReader:       fileReader,
+		ContentType:      contentType,
+		ExpectedChecksum: checksum,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(response.(*uploadgrant.UploadWithPolicyResponse))
+}
@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Application/UploadSession"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type UploadSessionController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewUploadSessionController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *UploadSessionController {
+	return &UploadSessionController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		Start resumable upload
+//	@Description	Start an upload whose content is sent in chunks. Progress is saved after every chunk, so an upload interrupted on either side, or by a server restart, continues from its offset
+//	@Tags			uploads
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string											true	"Bucket ID"
+//	@Param			request		body		uploadsession.CreateUploadSessionCommand		true	"File name and size"
+//	@Success		201			{object}	uploadsession.CreateUploadSessionResponse		"Upload started"
+//	@Failure		400			{object}	apierror.Error									"Bad request"
+//	@Failure		401			{object}	apierror.Error									"Unauthorized"
+//	@Failure		413			{object}	apierror.Error									"File too large"
+//	@Router			/buckets/{bucketId}/uploads [post]
+func (ctrl *UploadSessionController) CreateUploadSession(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "bucketId")
+
+	var command uploadsession.CreateUploadSessionCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(response.(*uploadsession.CreateUploadSessionResponse))
+}
+
+//	@Summary		Get resumable upload
+//	@Description	Get a resumable upload's progress. Its offset is where the next chunk starts
+//	@Tags			uploads
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string										true	"Bucket ID"
+//	@Param			uploadId	path		string										true	"Upload ID"
+//	@Success		200			{object}	uploadsession.GetUploadSessionResponse		"Upload progress"
+//	@Failure		400			{object}	apierror.Error								"Bad request"
+//	@Failure		401			{object}	apierror.Error								"Unauthorized"
+//	@Failure		404			{object}	apierror.Error								"Upload not found or expired"
+//	@Router			/buckets/{bucketId}/uploads/{uploadId} [get]
+func (ctrl *UploadSessionController) GetUploadSession(c *fiber.Ctx) error {
+	route, err := ctrl.sessionRoute(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &uploadsession.GetUploadSessionCommand{
+		BucketID: route.bucketID,
+		UploadID: route.uploadID,
+		UserID:   route.userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*uploadsession.GetUploadSessionResponse))
+}
+
+//	@Summary		Upload chunk
+//	@Description	Append the request body to a resumable upload. The chunk must start at the upload's offset, given as the offset query parameter; a chunk that doesn't arrive in full is dropped and sent again from the same offset
+//	@Tags			uploads
+//	@Accept			application/octet-stream
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string								true	"Bucket ID"
+//	@Param			uploadId	path		string								true	"Upload ID"
+//	@Param			offset		query		int									true	"Offset the chunk starts at"
+//	@Param			X-Checksum-Sha256	header	string	false	"Hex or base64 encoded sha256 of the chunk, the chunk is dropped when its content differs. X-Amz-Checksum-Sha256 is accepted as well"
+//	@Param			X-Transfer-Id	header	string	false	"ID the client chose to follow the upload's progress under at /transfers/{transferId}/events, the same for every chunk and completing"
+//	@Success		200			{object}	uploadsession.UploadChunkResponse	"Chunk received"
+//	@Failure		400			{object}	apierror.Error						"Bad request"
+//	@Failure		401			{object}	apierror.Error						"Unauthorized"
+//	@Failure		404			{object}	apierror.Error						"Upload not found or expired"
+//	@Failure		409			{object}	apierror.Error						"Chunk doesn't start at the upload's offset"
+//	@Failure		413			{object}	apierror.Error						"Chunk goes past the upload's size"
+//	@Router			/buckets/{bucketId}/uploads/{uploadId} [put]
+func (ctrl *UploadSessionController) UploadChunk(c *fiber.Ctx) error {
+	route, err := ctrl.sessionRoute(c)
+	if err != nil {
+		return err
+	}
+
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return apierror.Invalid("Invalid or missing offset")
+	}
+
+	checksum, err := expectedChecksum(c)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := startTransfer(c, route.userID)
+	if err != nil {
+		return err
+	}
+
+	// Large chunks arrive as a stream and are written to the upload as they are read
+	var content io.Reader = c.Context().RequestBodyStream()
+	if content == nil {
+		content = bytes.NewReader(c.Body())
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &uploadsession.UploadChunkCommand{
+		BucketID: route.bucketID,
+		UploadID: route.uploadID,
+		UserID:   route.userID,
+		Offset:           offset,
+		Content:          content,
+		ExpectedChecksum: checksum,
+		Progress:         transfer,
+	})
+	if err != nil {
+		return err
+	}
+
+	chunk := response.(*uploadsession.UploadChunkResponse)
+	setChecksumHeaders(c, chunk.ChunkChecksum)
+	return c.JSON(chunk)
+}
+
+//	@Summary		Complete resumable upload
+//	@Description	Store the file of a resumable upload that has received all its content. When storing fails the upload stays open and completing can be retried
+//	@Tags			uploads
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string											true	"Bucket ID"
+//	@Param			uploadId	path		string											true	"Upload ID"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Algorithm	header	string	false	"AES256, when the content is encrypted with a customer-provided key"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key		header	string	false	"Base64 encoded 256-bit customer-provided key, never stored"
+//	@Param			X-Amz-Server-Side-Encryption-Customer-Key-MD5	header	string	false	"Base64 encoded MD5 of the customer-provided key"
+//	@Param			Idempotency-Key	header	string	false	"Key retries of this upload are sent with, to get the first response back instead of uploading again"
+//	@Param			X-Checksum-Sha256	header	string	false	"Hex or base64 encoded sha256 of the file, the upload is refused when the content differs. X-Amz-Checksum-Sha256 is accepted as well"
+//	@Param			X-Transfer-Id	header	string	false	"ID the client chose to follow the upload's progress under at /transfers/{transferId}/events, the same for every chunk and completing"
+//	@Success		201			{object}	uploadsession.CompleteUploadSessionResponse		"File uploaded"
+//	@Failure		400			{object}	apierror.Error									"Bad request"
+//	@Failure		401			{object}	apierror.Error									"Unauthorized"
+//	@Failure		404			{object}	apierror.Error									"Upload not found or expired"
+//	@Failure		409			{object}	apierror.Error									"Upload hasn't received all its content"
+//	@Failure		422			{object}	apierror.Error									"Malware detected"
+//	@Router			/buckets/{bucketId}/uploads/{uploadId}/complete [post]
+func (ctrl *UploadSessionController) CompleteUploadSession(c *fiber.Ctx) error {
+	route, err := ctrl.sessionRoute(c)
+	if err != nil {
+		return err
+	}
+
+	customerKey, err := customerKeyFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := expectedChecksum(c)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := startTransfer(c, route.userID)
+	if err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &uploadsession.CompleteUploadSessionCommand{
+		BucketID:         route.bucketID,
+		UploadID:         route.uploadID,
+		UserID:           route.userID,
+		CustomerKey:      customerKey,
+		ExpectedChecksum: checksum,
+		Progress:         transfer,
+	})
+	if err != nil {
+		transfer.Fail(err)
+		return err
+	}
+
+	completed := response.(*uploadsession.CompleteUploadSessionResponse)
+	transfer.Complete(completed.File.ID)
+	setCustomerKeyHeaders(c, completed.File.CustomerKeyMD5)
+	setChecksumHeaders(c, completed.File.Checksum)
+	return c.Status(http.StatusCreated).JSON(completed)
+}
+
+//	@Summary		Abort resumable upload
+//	@Description	Abandon a resumable upload and drop the content received so far
+//	@Tags			uploads
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			bucketId	path		string										true	"Bucket ID"
+//	@Param			uploadId	path		string										true	"Upload ID"
+//	@Success		200			{object}	uploadsession.AbortUploadSessionResponse	"Upload aborted"
+//	@Failure		400			{object}	apierror.Error								"Bad request"
+//	@Failure		401			{object}	apierror.Error								"Unauthorized"
+//	@Failure		404			{object}	apierror.Error								"Upload not found or expired"
+//	@Router			/buckets/{bucketId}/uploads/{uploadId} [delete]
+func (ctrl *UploadSessionController) AbortUploadSession(c *fiber.Ctx) error {
+	route, err := ctrl.sessionRoute(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &uploadsession.AbortUploadSessionCommand{
+		BucketID: route.bucketID,
+		UploadID: route.uploadID,
+		UserID:   route.userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.(*uploadsession.AbortUploadSessionResponse))
+}
+
+// uploadSessionRoute is the upload a request addresses, and the user making it
+type uploadSessionRoute struct {
+	bucketID uuid.UUID
+	uploadID uuid.UUID
+	userID   uuid.UUID
+}
+
+// sessionRoute reads the user and the upload addressed by the route
+func (ctrl *UploadSessionController) sessionRoute(c *fiber.Ctx) (*uploadSessionRoute, error) {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return nil, apierror.Unauthorized("Unauthorized")
+	}
+
+	return &uploadSessionRoute{
+		bucketID: binding.UUID(c, "bucketId"),
+		uploadID: binding.UUID(c, "uploadId"),
+		userID:   userContext.UserID,
+	}, nil
+}
@@ -1,15 +1,15 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	
 	"shbucket/src/Application/User"
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
 	"shbucket/src/Infrastructure/Mediator"
 )
 
@@ -34,29 +34,19 @@ func NewUserController(mediator *mediator.Mediator, validator *validator.Validat
 //	@Produce		json
 //	@Param			credentials	body		user.LoginCommand							true	"Login credentials"
 //	@Success		200			{object}	user.LoginResponse							"Login successful"
-//	@Failure		400			{object}	map[string]string							"Invalid credentials"
+//	@Failure		400			{object}	apierror.Error								"Bad request"
+//	@Failure		401			{object}	apierror.Error								"Invalid credentials"
 //	@Router			/auth/login [post]
 func (ctrl *UserController) Login(c *fiber.Ctx) error {
 	var command user.LoginCommand
 	
-	if err := c.BodyParser(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
 	}
 	
-	if err := ctrl.validator.Struct(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
-	}
-	
-	response, err := ctrl.mediator.Send(context.Background(), &command)
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	loginResponse := response.(*user.LoginResponse)
@@ -70,35 +60,50 @@ func (ctrl *UserController) Login(c *fiber.Ctx) error {
 //	@Produce		json
 //	@Param			user	body		user.RegisterCommand	true	"User registration data"
 //	@Success		201		{object}	user.RegisterResponse	"User created successfully"
-//	@Failure		400		{object}	map[string]string		"Validation error"
+//	@Failure		400		{object}	apierror.Error			"Bad request"
 //	@Router			/auth/register [post]
 func (ctrl *UserController) Register(c *fiber.Ctx) error {
 	var command user.RegisterCommand
 	
-	if err := c.BodyParser(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-	
-	if err := ctrl.validator.Struct(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), &command)
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	registerResponse := response.(*user.RegisterResponse)
 	return c.Status(http.StatusCreated).JSON(registerResponse)
 }
 
+//	@Summary		Refresh token
+//	@Description	Exchange a token that hasn't expired yet for a new one, so clients can stay signed in without the password
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		user.RefreshTokenCommand	true	"Current token"
+//	@Success		200		{object}	user.RefreshTokenResponse	"Token refreshed"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		401		{object}	apierror.Error				"Invalid or expired token"
+//	@Router			/auth/refresh [post]
+func (ctrl *UserController) RefreshToken(c *fiber.Ctx) error {
+	var command user.RefreshTokenCommand
+	
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+	
+	refreshResponse := response.(*user.RefreshTokenResponse)
+	return c.JSON(refreshResponse)
+}
+
 //	@Summary		User logout
 //	@Description	Logout user and invalidate session token
 //	@Tags			auth
@@ -107,15 +112,13 @@ func (ctrl *UserController) Register(c *fiber.Ctx) error {
 //	@Security		Bearer
 //	@Security		ApiKeyAuth
 //	@Success		200	{object}	user.LogoutResponse	"Logout successful"
-//	@Failure		400	{object}	map[string]string	"Bad request"
-//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		400	{object}	apierror.Error		"Bad request"
+//	@Failure		401	{object}	apierror.Error		"Unauthorized"
 //	@Router			/auth/logout [post]
 func (ctrl *UserController) Logout(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
 	command := &user.LogoutCommand{
@@ -123,11 +126,9 @@ func (ctrl *UserController) Logout(c *fiber.Ctx) error {
 		TokenHash: "",
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	logoutResponse := response.(*user.LogoutResponse)
@@ -143,39 +144,30 @@ func (ctrl *UserController) Logout(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			request	body		user.ChangePasswordCommand	true	"Password change details"
 //	@Success		200	{object}	user.ChangePasswordResponse	"Password changed successfully"
-//	@Failure		400	{object}	map[string]string			"Bad request"
-//	@Failure		401	{object}	map[string]string			"Unauthorized"
+//	@Failure		400	{object}	apierror.Error				"Bad request"
+//	@Failure		401	{object}	apierror.Error				"Unauthorized"
 //	@Router			/auth/change-password [post]
 func (ctrl *UserController) ChangePassword(c *fiber.Ctx) error {
 	userContext, err := ctrl.authService.GetUserFromContext(c)
 	if err != nil {
-		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return apierror.Unauthorized("Unauthorized")
 	}
 	
 	var command user.ChangePasswordCommand
 	
-	if err := c.BodyParser(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := binding.Parse(c, &command); err != nil {
+		return err
 	}
 	
 	command.UserID = userContext.UserID
 	
-	if err := ctrl.validator.Struct(&command); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), &command)
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	changePasswordResponse := response.(*user.ChangePasswordResponse)
@@ -191,28 +183,19 @@ func (ctrl *UserController) ChangePassword(c *fiber.Ctx) error {
 //	@Security		ApiKeyAuth
 //	@Param			id	path		string					true	"User ID"
 //	@Success		200	{object}	user.GetUserResponse	"User information"
-//	@Failure		400	{object}	map[string]string		"Invalid user ID"
-//	@Failure		404	{object}	map[string]string		"User not found"
-//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		404	{object}	apierror.Error			"User not found"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
 //	@Router			/users/{id} [get]
 func (ctrl *UserController) GetUser(c *fiber.Ctx) error {
-	userIDParam := c.Params("id")
-	userID, err := uuid.Parse(userIDParam)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID",
-		})
-	}
+	userID := binding.UUID(c, "id")
 	
 	command := &user.GetUserCommand{
 		UserID: userID,
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	getUserResponse := response.(*user.GetUserResponse)
@@ -220,7 +203,7 @@ func (ctrl *UserController) GetUser(c *fiber.Ctx) error {
 }
 
 //	@Summary		List users
-//	@Description	Retrieve a paginated list of all users (admin only)
+//	@Description	Retrieve a paginated list of all users, by username unless sort_by (username, email, role, created_at, last_login) says otherwise (admin only)
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -231,9 +214,15 @@ func (ctrl *UserController) GetUser(c *fiber.Ctx) error {
 //	@Param			include_buckets	query		bool		false	"Include user buckets"
 //	@Param			include_sessions	query		bool		false	"Include user sessions"
 //	@Param			include_all		query		bool		false	"Include all related data"
+//	@Param			role			query		string		false	"Only users of this role"
+//	@Param			sort_by			query		string		false	"Field to sort by"
+//	@Param			order			query		string		false	"asc or desc"
+//	@Param			name			query		string		false	"Only usernames or emails containing this, case-insensitively"
+//	@Param			created_after	query		string		false	"Only created at or after this RFC 3339 time"
+//	@Param			created_before	query		string		false	"Only created before this RFC 3339 time"
 //	@Success		200	{object}	user.ListUsersResponse	"List of users"
-//	@Failure		400	{object}	map[string]string		"Bad request"
-//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		400	{object}	apierror.Error			"Bad request"
+//	@Failure		401	{object}	apierror.Error			"Unauthorized"
 //	@Router			/users [get]
 func (ctrl *UserController) ListUsers(c *fiber.Ctx) error {
 	page := c.QueryInt("page", 1)
@@ -244,21 +233,165 @@ func (ctrl *UserController) ListUsers(c *fiber.Ctx) error {
 	includeSessions := c.QueryBool("include_sessions", false)
 	includeAll := c.QueryBool("include_all", false)
 	
+	list, err := parseListQuery(c)
+	if err != nil {
+		return err
+	}
+	
 	command := &user.ListUsersCommand{
 		Page:            page,
 		Limit:           limit,
 		IncludeBuckets:  includeBuckets,
 		IncludeSessions: includeSessions,
 		IncludeAll:      includeAll,
+		List:            list,
+		Role:            c.Query("role"),
 	}
 	
-	response, err := ctrl.mediator.Send(context.Background(), command)
+	response, err := ctrl.mediator.Send(c.UserContext(), command)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 	
 	listUsersResponse := response.(*user.ListUsersResponse)
 	return c.JSON(listUsersResponse)
-}
\ No newline at end of file
+}
+//	@Summary		Get recent activity
+//	@Description	Recent uploads, shares and comments in the current user's buckets, plus the user's own activity, newest first
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			limit	query		int							false	"Maximum entries to return (default 50)"
+//	@Success		200		{object}	user.GetActivityResponse	"Activity feed"
+//	@Failure		401		{object}	apierror.Error				"Unauthorized"
+//	@Router			/auth/me/activity [get]
+func (ctrl *UserController) GetActivity(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &user.GetActivityCommand{
+		UserID: userContext.UserID,
+		Limit:  c.QueryInt("limit", 50),
+	})
+	if err != nil {
+		return err
+	}
+
+	getActivityResponse := response.(*user.GetActivityResponse)
+	return c.JSON(getActivityResponse)
+}
+
+//	@Summary		Invite user
+//	@Description	Email someone a link to create an account with the given role, viewer by default. The link expires after INVITATION_EXPIRY_HOURS
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			request	body		user.InviteUserCommand	true	"Invitation"
+//	@Success		201		{object}	user.InviteUserResponse	"Invitation sent"
+//	@Failure		400		{object}	apierror.Error			"Bad request"
+//	@Failure		401		{object}	apierror.Error			"Unauthorized"
+//	@Failure		503		{object}	apierror.Error			"Email isn't configured"
+//	@Router			/users/invite [post]
+func (ctrl *UserController) InviteUser(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	var command user.InviteUserCommand
+	if err := binding.Parse(c, &command); err != nil {
+		return err
+	}
+	command.UserID = userContext.UserID
+
+	if err := binding.Validate(ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	inviteResponse := response.(*user.InviteUserResponse)
+	return c.Status(http.StatusCreated).JSON(inviteResponse)
+}
+
+//	@Summary		Accept invitation
+//	@Description	Create the account an emailed invitation is for, with the invited email and role
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		user.AcceptInvitationCommand	true	"Invitation token and the new account's username and password"
+//	@Success		201		{object}	user.AcceptInvitationResponse	"Account created"
+//	@Failure		400		{object}	apierror.Error					"Invalid or expired invitation"
+//	@Router			/auth/accept-invitation [post]
+func (ctrl *UserController) AcceptInvitation(c *fiber.Ctx) error {
+	var command user.AcceptInvitationCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	acceptResponse := response.(*user.AcceptInvitationResponse)
+	return c.Status(http.StatusCreated).JSON(acceptResponse)
+}
+
+//	@Summary		Forgot password
+//	@Description	Email a password reset link to the account with the email. The response doesn't tell whether there is one
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		user.ForgotPasswordCommand	true	"Account email"
+//	@Success		200		{object}	user.ForgotPasswordResponse	"Request accepted"
+//	@Failure		400		{object}	apierror.Error				"Bad request"
+//	@Failure		503		{object}	apierror.Error				"Email isn't configured"
+//	@Router			/auth/forgot-password [post]
+func (ctrl *UserController) ForgotPassword(c *fiber.Ctx) error {
+	var command user.ForgotPasswordCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	forgotResponse := response.(*user.ForgotPasswordResponse)
+	return c.JSON(forgotResponse)
+}
+
+//	@Summary		Reset password
+//	@Description	Set a new password with the token of an emailed reset link. The user's sessions end
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		user.ResetPasswordCommand	true	"Reset token and new password"
+//	@Success		200		{object}	user.ResetPasswordResponse	"Password reset"
+//	@Failure		400		{object}	apierror.Error				"Invalid or expired token"
+//	@Router			/auth/reset-password [post]
+func (ctrl *UserController) ResetPassword(c *fiber.Ctx) error {
+	var command user.ResetPasswordCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	resetResponse := response.(*user.ResetPasswordResponse)
+	return c.JSON(resetResponse)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	netdav "golang.org/x/net/webdav"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/IPFilter"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/WebDAV"
+)
+
+// WebDAVMethods are the request methods WebDAV adds to HTTP, which the server has to accept
+var WebDAVMethods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+// webDAVReadMethods are the methods that don't change anything
+var webDAVReadMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	"PROPFIND":         true,
+}
+
+type WebDAVController struct {
+	mediator    *mediator.Mediator
+	authService *auth.AuthorizationService
+	dbContext   *persistence.AppDbContext
+	// Locks are advisory and held by this server only; clients use them to avoid overwriting each other
+	locks netdav.LockSystem
+}
+
+func NewWebDAVController(mediator *mediator.Mediator, authService *auth.AuthorizationService, dbContext *persistence.AppDbContext) *WebDAVController {
+	return &WebDAVController{
+		mediator:    mediator,
+		authService: authService,
+		dbContext:   dbContext,
+		locks:       netdav.NewMemLS(),
+	}
+}
+
+// Serve handles WebDAV requests under /dav. The root lists the buckets the user owns and each bucket is
+// a folder of its files. Clients authenticate with basic auth (username or email, and the password or an
+// API key), an X-API-Key header or a Bearer token. Changes need the editor role and a key with write permission.
+func (ctrl *WebDAVController) Serve(c *fiber.Ctx) error {
+	user, err := ctrl.authService.AuthenticateCredentials(c, ctrl.dbContext)
+	if errors.Is(err, ipfilter.ErrDenied) {
+		return err
+	}
+	if err != nil {
+		c.Set("WWW-Authenticate", `Basic realm="SHBucket", charset="UTF-8"`)
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	access := webdav.NewAccess(ctrl.authService, ctrl.dbContext, user)
+	if !webDAVReadMethods[c.Method()] && !access.Write {
+		return apierror.Forbidden("forbidden: insufficient permissions")
+	}
+
+	handler := &netdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewFileSystem(ctrl.dbContext, ctrl.mediator, access),
+		LockSystem: ctrl.locks,
+		Logger: func(r *http.Request, err error) {
+			if err != nil && config.GetSettings().Debug {
+				log.Printf("WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	return adaptor.HTTPHandler(handler)(c)
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Application/Webhook"
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Binding"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+type WebhookController struct {
+	mediator    *mediator.Mediator
+	validator   *validator.Validate
+	authService *auth.AuthorizationService
+}
+
+func NewWebhookController(mediator *mediator.Mediator, validator *validator.Validate, authService *auth.AuthorizationService) *WebhookController {
+	return &WebhookController{
+		mediator:    mediator,
+		validator:   validator,
+		authService: authService,
+	}
+}
+
+//	@Summary		List bucket webhooks
+//	@Description	List the webhooks receiving a bucket's events. Secrets are not returned, only their prefixes (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id	path		string							true	"Bucket ID"
+//	@Success		200	{object}	webhook.ListWebhooksResponse	"Webhooks"
+//	@Failure		401	{object}	apierror.Error					"Unauthorized"
+//	@Failure		403	{object}	apierror.Error					"Forbidden"
+//	@Failure		404	{object}	apierror.Error					"Bucket not found"
+//	@Router			/buckets/{id}/webhooks [get]
+func (ctrl *WebhookController) ListWebhooks(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	command := webhook.ListWebhooksCommand{
+		BucketID: bucketID,
+		UserID:   userContext.UserID,
+		UserRole: userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	webhooksResponse := response.(*webhook.ListWebhooksResponse)
+	return c.JSON(webhooksResponse)
+}
+
+//	@Summary		Create bucket webhook
+//	@Description	Add a webhook receiving the bucket's events as they are published, optionally limited to some event types. Deliveries are signed with a secret of the webhook's own, returned only in this response (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id		path		string							true	"Bucket ID"
+//	@Param			request	body		webhook.CreateWebhookCommand	true	"Webhook URL and event types"
+//	@Success		201		{object}	webhook.CreateWebhookResponse	"Webhook and its secret"
+//	@Failure		400		{object}	apierror.Error					"Bad request"
+//	@Failure		401		{object}	apierror.Error					"Unauthorized"
+//	@Failure		403		{object}	apierror.Error					"Forbidden"
+//	@Failure		404		{object}	apierror.Error					"Bucket not found"
+//	@Router			/buckets/{id}/webhooks [post]
+func (ctrl *WebhookController) CreateWebhook(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+
+	var command webhook.CreateWebhookCommand
+	if err := binding.Body(c, ctrl.validator, &command); err != nil {
+		return err
+	}
+	command.BucketID = bucketID
+	command.UserID = userContext.UserID
+	command.UserRole = userContext.Role
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	webhookResponse := response.(*webhook.CreateWebhookResponse)
+	return c.Status(http.StatusCreated).JSON(webhookResponse)
+}
+
+//	@Summary		Delete bucket webhook
+//	@Description	Remove a webhook, deliveries still queued for it are dropped (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id			path		string							true	"Bucket ID"
+//	@Param			webhookId	path		string							true	"Webhook ID"
+//	@Success		200			{object}	webhook.DeleteWebhookResponse	"Webhook deleted"
+//	@Failure		401			{object}	apierror.Error					"Unauthorized"
+//	@Failure		403			{object}	apierror.Error					"Forbidden"
+//	@Failure		404			{object}	apierror.Error					"Webhook not found"
+//	@Router			/buckets/{id}/webhooks/{webhookId} [delete]
+func (ctrl *WebhookController) DeleteWebhook(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+	webhookID := binding.UUID(c, "webhookId")
+
+	command := webhook.DeleteWebhookCommand{
+		BucketID:  bucketID,
+		WebhookID: webhookID,
+		UserID:    userContext.UserID,
+		UserRole:  userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := response.(*webhook.DeleteWebhookResponse)
+	return c.JSON(deleteResponse)
+}
+
+//	@Summary		Rotate bucket webhook secret
+//	@Description	Replace a webhook's signing secret. Deliveries from then on, retries included, are signed with the new secret, returned only in this response (bucket owner or bucket admin)
+//	@Tags			buckets
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKeyAuth
+//	@Param			id			path		string								true	"Bucket ID"
+//	@Param			webhookId	path		string								true	"Webhook ID"
+//	@Success		200			{object}	webhook.RotateWebhookSecretResponse	"Webhook and its new secret"
+//	@Failure		401			{object}	apierror.Error						"Unauthorized"
+//	@Failure		403			{object}	apierror.Error						"Forbidden"
+//	@Failure		404			{object}	apierror.Error						"Webhook not found"
+//	@Router			/buckets/{id}/webhooks/{webhookId}/rotate-secret [post]
+func (ctrl *WebhookController) RotateWebhookSecret(c *fiber.Ctx) error {
+	userContext, err := ctrl.authService.GetUserFromContext(c)
+	if err != nil {
+		return apierror.Unauthorized("Unauthorized")
+	}
+
+	bucketID := binding.UUID(c, "id")
+	webhookID := binding.UUID(c, "webhookId")
+
+	command := webhook.RotateWebhookSecretCommand{
+		BucketID:  bucketID,
+		WebhookID: webhookID,
+		UserID:    userContext.UserID,
+		UserRole:  userContext.Role,
+	}
+
+	response, err := ctrl.mediator.Send(c.UserContext(), &command)
+	if err != nil {
+		return err
+	}
+
+	rotateResponse := response.(*webhook.RotateWebhookSecretResponse)
+	return c.JSON(rotateResponse)
+}
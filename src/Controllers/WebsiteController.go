@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Headers"
+	"shbucket/src/Infrastructure/IPFilter"
+	"shbucket/src/Infrastructure/Metering"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Infrastructure/Website"
+)
+
+// WebsiteController serves buckets with website hosting enabled as static sites, under
+// /site/{bucketName}/ and at the root of their own domain
+type WebsiteController struct {
+	dbContext *persistence.AppDbContext
+	meter     *metering.Meter
+}
+
+func NewWebsiteController(dbContext *persistence.AppDbContext, meter *metering.Meter) *WebsiteController {
+	return &WebsiteController{
+		dbContext: dbContext,
+		meter:     meter,
+	}
+}
+
+//	@Summary		Serve static website
+//	@Description	Serve a file of a bucket with website hosting enabled. Folder paths serve the index document, missing paths the error document with 404
+//	@Tags			website
+//	@Produce		*/*
+//	@Param			bucketName	path		string	true	"Bucket name"
+//	@Param			path		path		string	false	"File path"
+//	@Success		200			{file}		file	"File content"
+//	@Failure		404			{string}	string	"Not found"
+//	@Router			/site/{bucketName}/{path} [get]
+func (ctrl *WebsiteController) ServeSite(c *fiber.Ctx) error {
+	bucket, err := ctrl.dbContext.Buckets.Where(&entities.Bucket{Name: c.Params("bucketName")}).FirstOrDefault()
+	if err != nil || bucket == nil || !bucket.Settings.WebsiteEnabled || !bucket.Settings.PublicRead {
+		return c.Status(http.StatusNotFound).SendString("Not Found")
+	}
+
+	// Relative links in the index document resolve against the folder, so the root needs its slash
+	sitePath := c.Params("*")
+	if sitePath == "" && !strings.HasSuffix(c.Path(), "/") {
+		return c.Redirect(withQuery(c, c.Path()+"/"), http.StatusMovedPermanently)
+	}
+	return ctrl.serve(c, bucket, sitePath)
+}
+
+// serveDomain serves the site of a bucket at the root of its website domain
+func (ctrl *WebsiteController) serveDomain(c *fiber.Ctx, bucketID uuid.UUID) error {
+	bucket, err := ctrl.dbContext.Buckets.Where(&entities.Bucket{Id: bucketID}).FirstOrDefault()
+	if err != nil || bucket == nil || !bucket.Settings.WebsiteEnabled || !bucket.Settings.PublicRead {
+		return c.Status(http.StatusNotFound).SendString("Not Found")
+	}
+	return ctrl.serve(c, bucket, strings.TrimPrefix(c.Path(), "/"))
+}
+
+// serve answers a request for sitePath, a path relative to the site root
+func (ctrl *WebsiteController) serve(c *fiber.Ctx, bucket *entities.Bucket, sitePath string) error {
+	ctx := c.UserContext()
+	if err := publicReadRefused(c, bucket); err != nil {
+		ipfilter.Audit("website of bucket "+bucket.Name, c.IP(), err)
+		return c.Status(http.StatusForbidden).SendString("Forbidden: " + err.Error())
+	}
+	// Wildcard parameters lose their trailing slash, which marks a folder
+	if sitePath != "" && strings.HasSuffix(c.Path(), "/") && !strings.HasSuffix(sitePath, "/") {
+		sitePath += "/"
+	}
+	name, err := url.PathUnescape(sitePath)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).SendString("Bad Request")
+	}
+
+	index := bucket.Settings.WebsiteIndexDocument
+	if index == "" {
+		index = website.DefaultIndexDocument
+	}
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += index
+	}
+
+	file, err := ctrl.lookup(ctx, bucket.Id, name)
+	if err != nil {
+		log.Printf("Warning: failed to look up %s in website bucket %s: %v", name, bucket.Name, err)
+		return c.Status(http.StatusInternalServerError).SendString("Internal Server Error")
+	}
+
+	// A folder asked for without its trailing slash
+	if file == nil && !strings.HasSuffix(name, "/"+index) && name != index {
+		if folderIndex, err := ctrl.lookup(ctx, bucket.Id, name+"/"+index); err == nil && folderIndex != nil {
+			return c.Redirect(withQuery(c, c.Path()+"/"), http.StatusMovedPermanently)
+		}
+	}
+
+	status := http.StatusOK
+	if file == nil {
+		status = http.StatusNotFound
+		if errorDocument := bucket.Settings.WebsiteErrorDocument; errorDocument != "" {
+			file, _ = ctrl.lookup(ctx, bucket.Id, errorDocument)
+		}
+		if file == nil {
+			return c.Status(http.StatusNotFound).SendString("Not Found")
+		}
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(file.Name))
+	if contentType == "" {
+		contentType = file.MimeType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if decision := ctrl.meter.Begin(c, bucket, uuid.Nil); decision.Blocked() {
+		return egressQuotaExceeded(c, decision)
+	}
+
+	// Compressed pages and assets go out as stored to clients accepting their encoding
+	contentEncoding := file.Metadata.ContentEncoding
+	sendEncoded := contentEncoding != "" && storage.AcceptsEncoding(c.Get("Accept-Encoding"), contentEncoding)
+	if contentEncoding != "" {
+		c.Vary("Accept-Encoding")
+	}
+
+	c.Set("X-Content-Type-Options", "nosniff")
+	if status == http.StatusOK {
+		// Names are replaced in place on redeploys, so pages are revalidated on every visit and assets after an hour
+		if strings.HasPrefix(contentType, "text/html") {
+			c.Set("Cache-Control", "public, no-cache")
+		} else {
+			c.Set("Cache-Control", "public, max-age=3600")
+		}
+		// then the headers the bucket and the file set
+		served := headers.Resolve(headers.Decode(bucket.Settings.DefaultHeaders), file.Metadata.CacheControl,
+			file.Metadata.ContentDisposition, headers.Decode(file.Metadata.Headers), path.Base(file.Name))
+		setServedHeaders(c, served, false, contentEncoding == "")
+		etag := file.ETag()
+		if sendEncoded {
+			etag = encodedETag(etag, contentEncoding)
+		}
+		c.Set("ETag", etag)
+		c.Set("Last-Modified", file.CreatedAt.UTC().Format(http.TimeFormat))
+		if c.Fresh() {
+			return c.SendStatus(http.StatusNotModified)
+		}
+	} else {
+		c.Set("Cache-Control", "no-cache")
+	}
+
+	open := storage.OpenFileWithKey
+	if sendEncoded {
+		open = storage.OpenStored
+	}
+	content, err := open(ctx, ctrl.dbContext, file, nil)
+	if err != nil {
+		log.Printf("Warning: failed to open %s in website bucket %s: %v", file.Name, bucket.Name, err)
+		return c.Status(http.StatusInternalServerError).SendString("Internal Server Error")
+	}
+	c.Status(status)
+	c.Set("Content-Type", contentType)
+	if sendEncoded {
+		// Only the plaintext size is recorded, so encoded content is streamed without a length
+		c.Set("Content-Encoding", contentEncoding)
+		return c.SendStream(content)
+	}
+	return c.SendStream(content, int(file.Size))
+}
+
+// lookup finds the current version of a site file. Files encrypted with a customer-provided key
+// can't be served without the key and quarantined files aren't served, so a site doesn't see them.
+func (ctrl *WebsiteController) lookup(ctx context.Context, bucketID uuid.UUID, name string) (*entities.File, error) {
+	file, err := website.Current(ctx, ctrl.dbContext, bucketID, name)
+	if err != nil || file == nil || file.Encryption.CustomerEncrypted() || file.Metadata.Quarantined() {
+		return nil, err
+	}
+	return file, nil
+}
+
+func withQuery(c *fiber.Ctx, location string) string {
+	if query := string(c.Request().URI().QueryString()); query != "" {
+		return location + "?" + query
+	}
+	return location
+}
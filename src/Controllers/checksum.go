@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// Headers carrying the sha256 of content, sent by clients with uploads and returned with downloads.
+// The S3 header takes base64, ours hex as the checksum in file responses; either is accepted.
+const (
+	checksumHeader    = "X-Checksum-Sha256"
+	amzChecksumHeader = "X-Amz-Checksum-Sha256"
+)
+
+var errInvalidChecksum = apierror.Invalid("checksum must be a hex or base64 encoded sha256")
+
+// expectedChecksum returns the sha256 the client sent with the request as lowercase hex, or "" when it sent none
+func expectedChecksum(c *fiber.Ctx) (string, error) {
+	value := strings.TrimSpace(c.Get(checksumHeader))
+	if value == "" {
+		value = strings.TrimSpace(c.Get(amzChecksumHeader))
+	}
+	if value == "" {
+		return "", nil
+	}
+	if sum, err := hex.DecodeString(value); err == nil && len(sum) == 32 {
+		return hex.EncodeToString(sum), nil
+	}
+	if sum, err := base64.StdEncoding.DecodeString(value); err == nil && len(sum) == 32 {
+		return hex.EncodeToString(sum), nil
+	}
+	return "", errInvalidChecksum
+}
+
+// setChecksumHeaders returns the sha256 of a file's content, so clients can verify what they downloaded
+func setChecksumHeaders(c *fiber.Ctx, checksum string) {
+	sum, err := hex.DecodeString(checksum)
+	if err != nil || len(sum) != 32 {
+		return
+	}
+	c.Set(checksumHeader, checksum)
+	c.Set(amzChecksumHeader, base64.StdEncoding.EncodeToString(sum))
+}
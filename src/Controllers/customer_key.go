@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/Encryption"
+)
+
+// Headers carrying a customer-provided encryption key, named as in S3 SSE-C so existing clients work unchanged
+const (
+	customerKeyAlgorithmHeader = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+	customerKeyHeader          = "X-Amz-Server-Side-Encryption-Customer-Key"
+	customerKeyMD5Header       = "X-Amz-Server-Side-Encryption-Customer-Key-MD5"
+)
+
+// customerKeyFromRequest returns the customer-provided key sent with the request, or nil when none was sent
+func customerKeyFromRequest(c *fiber.Ctx) (*encryption.CustomerKey, error) {
+	return encryption.ParseCustomerKey(c.Get(customerKeyAlgorithmHeader), c.Get(customerKeyHeader), c.Get(customerKeyMD5Header))
+}
+
+// setCustomerKeyHeaders echoes the algorithm and key MD5 of content encrypted with a customer-provided key
+func setCustomerKeyHeaders(c *fiber.Ctx, keyMD5 string) {
+	if keyMD5 == "" {
+		return
+	}
+	c.Set(customerKeyAlgorithmHeader, encryption.CustomerAlgorithm)
+	c.Set(customerKeyMD5Header, keyMD5)
+}
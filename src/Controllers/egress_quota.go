@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Metering"
+)
+
+// downloadAPIKeyLocal holds the ID of the API key a download was authorized with
+const downloadAPIKeyLocal = "download_api_key"
+
+// egressQuotaExceeded refuses a download blocked by an egress quota until the quota resets
+func egressQuotaExceeded(c *fiber.Ctx, decision metering.Decision) error {
+	message := "Egress quota exceeded for this billing cycle"
+	if decision.Scope == metering.ScopeBucketDaily || decision.Scope == metering.ScopeAPIKeyDaily {
+		message = "Daily egress quota exceeded"
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(time.Until(decision.ResetAt).Seconds())+1))
+	return c.Status(apierror.CodeRateLimited.Status()).JSON(fiber.Map{
+		"error":    message,
+		"code":     apierror.CodeRateLimited,
+		"quota":    decision.Scope,
+		"reset_at": decision.ResetAt,
+	})
+}
+
+// downloadAPIKey returns the API key a download is made with, uuid.Nil for none
+func downloadAPIKey(c *fiber.Ctx) uuid.UUID {
+	if apiKeyID, ok := c.Locals(downloadAPIKeyLocal).(uuid.UUID); ok {
+		return apiKeyID
+	}
+	if apiKeyContext, ok := auth.GetAPIKeyContextFromRequest(c); ok {
+		return apiKeyContext.APIKeyID
+	}
+	return uuid.Nil
+}
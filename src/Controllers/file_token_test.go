@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestMarkFileTokenUsed records the use on the token used and no other
+func TestMarkFileTokenUsed(t *testing.T) {
+	db := sqlitetest.Open(t)
+	var tokens []entities.FileToken
+	for _, name := range []string{"embed", "other"} {
+		token := entities.FileToken{FileId: uuid.New(), BucketId: uuid.New(), Name: name, TokenHash: name, TokenPrefix: name, CreatedBy: uuid.New()}
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	now := time.Now()
+	if err := markFileTokenUsed(db, tokens[0].Id, now); err != nil {
+		t.Fatalf("markFileTokenUsed() = %v", err)
+	}
+
+	var stored []entities.FileToken
+	if err := db.Order(`"Name"`).Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].LastUsedAt == nil || !stored[0].LastUsedAt.Equal(now) || stored[1].LastUsedAt != nil {
+		t.Errorf("last used = %v and %v, want %v and never", stored[0].LastUsedAt, stored[1].LastUsedAt, now)
+	}
+}
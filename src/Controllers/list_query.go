@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Models"
+)
+
+// parseListQuery reads the sorting and filtering parameters list endpoints share
+func parseListQuery(c *fiber.Ctx) (models.ListQuery, error) {
+	list := models.ListQuery{
+		SortBy: c.Query("sort_by"),
+		Order:  c.Query("order"),
+		Name:   c.Query("name"),
+	}
+	var err error
+	if list.CreatedBefore, err = parseTimeQuery(c, "created_before"); err != nil {
+		return list, err
+	}
+	if list.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		return list, err
+	}
+	return list, nil
+}
+
+// parseTimeQuery reads an optional RFC 3339 time query parameter
+func parseTimeQuery(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "%s must be an RFC 3339 time", key)
+	}
+	return &t, nil
+}
+
+// parseSizeQuery reads an optional size in bytes query parameter
+func parseSizeQuery(c *fiber.Ctx, key string) (*int64, error) {
+	if c.Query(key) == "" {
+		return nil, nil
+	}
+	size := int64(c.QueryInt(key, -1))
+	if size < 0 {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "%s must be a size in bytes", key)
+	}
+	return &size, nil
+}
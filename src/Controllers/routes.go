@@ -0,0 +1,349 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Routing"
+)
+
+// APIPrefix is where the API is served
+const APIPrefix = "/api/v1"
+
+// Handlers are the controllers and middleware the route table serves requests with
+type Handlers struct {
+	Setup         *SetupController
+	User          *UserController
+	TwoFactor     *TwoFactorController
+	Role          *RoleController
+	Bucket        *BucketController
+	File          *FileController
+	Node          *NodeController
+	APIKey        *APIKeyController
+	Backup        *BackupController
+	Import        *ImportController
+	Export        *ExportController
+	Event         *EventController
+	Webhook       *WebhookController
+	Alias         *AliasController
+	BucketSync    *BucketSyncController
+	Replication   *ReplicationController
+	Comment       *CommentController
+	Favorite      *FavoriteController
+	Snapshot      *SnapshotController
+	UploadGrant   *UploadGrantController
+	UploadSession *UploadSessionController
+	Transfer      *TransferController
+	Egress        *EgressController
+	Durability    *DurabilityController
+	Settings      *SettingsController
+	Reclamation   *ReclamationController
+	AdminTask     *AdminTaskController
+	Rebalance     *RebalanceController
+	Residency     *ResidencyController
+	Stats         *StatsController
+	Permission    *PermissionController
+	Cluster       *ClusterController
+	SigningKey    *SigningKeyController
+	Job           *JobController
+	Metrics       *MetricsController
+	WebDAV        *WebDAVController
+	Website       *WebsiteController
+	// BucketCORS applies a bucket's CORS rules to the file-serving routes
+	BucketCORS fiber.Handler
+	// Idempotency replays uploads retried with the same Idempotency-Key
+	Idempotency fiber.Handler
+	Swagger     fiber.Handler
+}
+
+// Routes is the server's route table. Every route declares who may call it, whether it counts
+// against the API rate limit, whether its body is streamed and how long its requests may run.
+func Routes(h Handlers) routing.Table {
+	var (
+		public  = routing.Public()
+		viewer  = routing.Role("viewer")
+		editor  = routing.Role("editor")
+		manager = routing.Role("manager")
+		admin   = routing.Role("admin")
+		// Custom roles are held to a permission rather than a role
+		account   = viewer.WithPermission(routing.PermissionAccount)
+		lister    = viewer.WithPermission("list")
+		uploader  = editor.WithPermission("upload")
+		deleter   = editor.WithPermission("delete")
+		userAdmin = admin.WithPermission("manage_users")
+		nodeAdmin = manager.WithPermission("manage_nodes")
+		// Storage nodes authenticate to the master with the auth key it issued them
+		nodeKey    = routing.Verified("node auth key as a bearer token")
+		fileAccess = routing.Verified("public bucket, signed URL, file token or user credentials")
+		davAccess  = routing.Verified("basic auth, API key or bearer token")
+	)
+	api := func(method, path string, access routing.Access, handler fiber.Handler) routing.Route {
+		return routing.Route{Method: method, Path: APIPrefix + path, Handler: handler, Access: access}
+	}
+	// Routes outside the API aren't rate limited
+	page := func(method, path string, access routing.Access, handler fiber.Handler) routing.Route {
+		return routing.Route{Method: method, Path: path, Handler: handler, Access: access, RateLimit: routing.Unlimited}
+	}
+	unlimited := func(route routing.Route) routing.Route {
+		route.RateLimit = routing.Unlimited
+		return route
+	}
+	// Uploads and downloads run as long as the client keeps up
+	transfer := func(route routing.Route) routing.Route {
+		route.Operation = routing.TransferOperation
+		return route
+	}
+	streamed := func(route routing.Route) routing.Route {
+		route.BodyLimit = routing.StreamedBody
+		return transfer(route)
+	}
+	// Administrative work going through many files gets longer than other requests
+	maintenance := func(route routing.Route) routing.Route {
+		route.Operation = routing.MaintenanceOperation
+		return route
+	}
+	withCORS := func(route routing.Route) routing.Route {
+		route.Middleware = append(route.Middleware, h.BucketCORS)
+		return route
+	}
+	idempotent := func(route routing.Route) routing.Route {
+		route.Middleware = append(route.Middleware, h.Idempotency)
+		return route
+	}
+
+	table := routing.Table{
+		// Static websites of public buckets
+		transfer(page(fiber.MethodGet, "/site/:bucketName", public, h.Website.ServeSite)),
+		transfer(page(fiber.MethodGet, "/site/:bucketName/*", public, h.Website.ServeSite)),
+	}
+
+	// Prometheus metrics, only served when a metrics token is configured
+	if config.GetSettings().MetricsToken != "" {
+		table = append(table, page(fiber.MethodGet, "/metrics", routing.Verified("METRICS_TOKEN as a bearer token"), h.Metrics.Prometheus))
+	}
+
+	table = append(table,
+		page(fiber.MethodGet, "/swagger/*", public, h.Swagger),
+
+		unlimited(api(fiber.MethodGet, "/health", public, health)),
+
+		// Setup
+		api(fiber.MethodGet, "/setup/status", public, h.Setup.CheckSetup),
+		api(fiber.MethodPost, "/setup/master", public, h.Setup.SetupMaster),
+		api(fiber.MethodPost, "/setup/node", public, h.Setup.SetupNode),
+		api(fiber.MethodGet, "/setup/info", public, h.Setup.GetSystemInfo),
+
+		// Node self-registration
+		api(fiber.MethodPost, "/node/register", routing.Verified("one-time registration token issued by an admin"), h.Node.SelfRegister),
+		api(fiber.MethodPost, "/node/ping", routing.Verified("node URL and auth key"), h.Node.Ping),
+
+		// Auth
+		api(fiber.MethodPost, "/auth/login", public, h.User.Login),
+		api(fiber.MethodPost, "/auth/login/2fa", public, h.TwoFactor.LoginTwoFactor),
+		api(fiber.MethodPost, "/auth/register", public, h.User.Register),
+		api(fiber.MethodPost, "/auth/accept-invitation", public, h.User.AcceptInvitation),
+		api(fiber.MethodPost, "/auth/forgot-password", public, h.User.ForgotPassword),
+		api(fiber.MethodPost, "/auth/reset-password", public, h.User.ResetPassword),
+		api(fiber.MethodPost, "/auth/refresh", routing.Verified("refresh token"), h.User.RefreshToken),
+		api(fiber.MethodPost, "/auth/logout", account, h.User.Logout),
+		api(fiber.MethodPost, "/auth/change-password", account, h.User.ChangePassword),
+		api(fiber.MethodGet, "/auth/2fa", account, h.TwoFactor.GetStatus),
+		api(fiber.MethodPost, "/auth/2fa/enroll", account, h.TwoFactor.Enroll),
+		api(fiber.MethodPost, "/auth/2fa/confirm", account, h.TwoFactor.Confirm),
+		api(fiber.MethodPost, "/auth/2fa/disable", account, h.TwoFactor.Disable),
+		api(fiber.MethodPost, "/auth/2fa/recovery-codes", account, h.TwoFactor.RegenerateRecoveryCodes),
+		api(fiber.MethodGet, "/auth/me/activity", account, h.User.GetActivity),
+		api(fiber.MethodGet, "/auth/me/favorites", account, h.Favorite.ListFavorites),
+
+		// Users
+		api(fiber.MethodGet, "/users", userAdmin, h.User.ListUsers),
+		api(fiber.MethodPost, "/users/invite", userAdmin, h.User.InviteUser),
+		api(fiber.MethodGet, "/users/:id", userAdmin, h.User.GetUser),
+		api(fiber.MethodDelete, "/users/:id/2fa", userAdmin, h.TwoFactor.ResetTwoFactor),
+		api(fiber.MethodPut, "/users/:id/role", userAdmin, h.Role.SetUserRole),
+		api(fiber.MethodGet, "/users/:id/egress", userAdmin, h.Egress.GetUserEgress),
+		api(fiber.MethodPut, "/users/:id/egress-quota", userAdmin, h.Egress.SetUserEgressQuota),
+
+		// Buckets
+		api(fiber.MethodGet, "/buckets", lister, h.Bucket.ListBuckets),
+		api(fiber.MethodPost, "/buckets", editor, h.Bucket.CreateBucket),
+		api(fiber.MethodPut, "/buckets/:id", editor, h.Bucket.UpdateBucket),
+		api(fiber.MethodGet, "/buckets/:id", lister, h.Bucket.GetBucket),
+		api(fiber.MethodDelete, "/buckets/:id", manager, h.Bucket.DeleteBucket),
+		api(fiber.MethodGet, "/buckets/:id/deletion", manager, h.Bucket.GetBucketDeletionJob),
+		api(fiber.MethodPost, "/buckets/:id/rotate-key", editor, h.Bucket.RotateBucketKey),
+		api(fiber.MethodGet, "/buckets/:id/admins", viewer, h.Bucket.ListBucketAdmins),
+		api(fiber.MethodPost, "/buckets/:id/admins", editor, h.Bucket.GrantBucketAdmin),
+		api(fiber.MethodDelete, "/buckets/:id/admins/:userId", editor, h.Bucket.RevokeBucketAdmin),
+		api(fiber.MethodGet, "/buckets/:id/api-keys", viewer, h.APIKey.ListBucketAPIKeys),
+		api(fiber.MethodDelete, "/buckets/:id/api-keys/:keyId", editor, h.APIKey.RevokeBucketAPIKey),
+		api(fiber.MethodGet, "/buckets/:id/rotate-key/:jobId", viewer, h.Bucket.GetKeyRotationJob),
+		api(fiber.MethodPost, "/buckets/:id/events/replay", editor, h.Event.ReplayBucketEvents),
+		api(fiber.MethodGet, "/buckets/:id/webhooks", editor, h.Webhook.ListWebhooks),
+		api(fiber.MethodPost, "/buckets/:id/webhooks", editor, h.Webhook.CreateWebhook),
+		api(fiber.MethodDelete, "/buckets/:id/webhooks/:webhookId", editor, h.Webhook.DeleteWebhook),
+		api(fiber.MethodPost, "/buckets/:id/webhooks/:webhookId/rotate-secret", editor, h.Webhook.RotateWebhookSecret),
+		api(fiber.MethodGet, "/buckets/:id/aliases", viewer, h.Alias.ListAliases),
+		api(fiber.MethodPut, "/buckets/:id/aliases/:name", editor, h.Alias.SetAlias),
+		api(fiber.MethodDelete, "/buckets/:id/aliases/:name", editor, h.Alias.DeleteAlias),
+		api(fiber.MethodGet, "/buckets/:id/changes", viewer, h.BucketSync.GetBucketChanges),
+		api(fiber.MethodGet, "/buckets/:id/sync", editor, h.BucketSync.GetBucketSync),
+		api(fiber.MethodPut, "/buckets/:id/sync", editor, h.BucketSync.ConfigureBucketSync),
+		api(fiber.MethodDelete, "/buckets/:id/sync", editor, h.BucketSync.DeleteBucketSync),
+		api(fiber.MethodPost, "/buckets/:id/sync/run", editor, h.BucketSync.RunBucketSync),
+		api(fiber.MethodGet, "/buckets/:id/replication", editor, h.Replication.GetBucketReplication),
+		api(fiber.MethodPut, "/buckets/:id/replication", editor, h.Replication.ConfigureBucketReplication),
+		api(fiber.MethodDelete, "/buckets/:id/replication", editor, h.Replication.DeleteBucketReplication),
+		api(fiber.MethodGet, "/buckets/:id/replication/status", editor, h.Replication.GetReplicationStatus),
+		api(fiber.MethodPost, "/buckets/:id/snapshots", editor, h.Snapshot.CreateSnapshot),
+		api(fiber.MethodGet, "/buckets/:id/snapshots", viewer, h.Snapshot.ListSnapshots),
+		api(fiber.MethodGet, "/buckets/:id/snapshots/:name/files", viewer, h.Snapshot.ListSnapshotFiles),
+		transfer(api(fiber.MethodGet, "/buckets/:id/snapshots/:name/files/:fileId", viewer, h.Snapshot.GetSnapshotFile)),
+		api(fiber.MethodGet, "/buckets/:id/snapshots/:a/diff/:b", viewer, h.Snapshot.DiffSnapshots),
+		api(fiber.MethodDelete, "/buckets/:id/snapshots/:name", editor, h.Snapshot.DeleteSnapshot),
+		api(fiber.MethodPost, "/buckets/:id/upload-grants", editor, h.UploadGrant.CreateUploadGrant),
+		api(fiber.MethodGet, "/buckets/:id/upload-grants", viewer, h.UploadGrant.ListUploadGrants),
+		api(fiber.MethodDelete, "/buckets/:id/upload-grants/:grantId", editor, h.UploadGrant.RevokeUploadGrant),
+		api(fiber.MethodPost, "/buckets/:id/upload-policies", editor, h.UploadGrant.CreateUploadPolicy),
+		api(fiber.MethodGet, "/buckets/:id/egress", viewer, h.Egress.GetBucketEgress),
+		api(fiber.MethodGet, "/buckets/:id/durability", viewer, h.Durability.GetBucketDurability),
+
+		// Background jobs
+		api(fiber.MethodGet, "/jobs/:id", viewer, h.Job.GetJob),
+
+		// File serving checks access itself, public buckets need no credentials
+		api(fiber.MethodOptions, "/file/:bucketId/*", public, h.BucketCORS),
+		transfer(withCORS(api(fiber.MethodGet, "/file/:bucketId/alias/:name", fileAccess, h.File.ServeAlias))),
+		transfer(withCORS(api(fiber.MethodGet, "/file/:bucketId/:fileId", fileAccess, h.File.ServeFile))),
+		withCORS(api(fiber.MethodHead, "/file/:bucketId/:fileId", fileAccess, h.File.ServeFile)),
+		transfer(withCORS(api(fiber.MethodGet, "/file/:bucketId/:fileId/hls/*", fileAccess, h.File.ServeHLS))),
+		api(fiber.MethodOptions, "/b/:bucketName/o/*", public, h.BucketCORS),
+		transfer(withCORS(api(fiber.MethodGet, "/b/:bucketName/o/*", fileAccess, h.File.ServeByName))),
+		withCORS(api(fiber.MethodHead, "/b/:bucketName/o/*", fileAccess, h.File.ServeByName)),
+
+		// Upload links and policies, the token in the path or the signed policy is the credential
+		api(fiber.MethodGet, "/upload/:token", routing.Verified("upload link token"), h.UploadGrant.GetUploadLink),
+		idempotent(streamed(api(fiber.MethodPost, "/upload/:token", routing.Verified("upload link token"), h.UploadGrant.UploadWithGrant))),
+		streamed(api(fiber.MethodPost, "/upload-policy", routing.Verified("signed upload policy in the form"), h.UploadGrant.UploadWithPolicy)),
+
+		// Distributed storage, between the master and its storage nodes
+		unlimited(streamed(api(fiber.MethodPost, "/internal/upload", nodeKey, h.File.InternalUpload))),
+		unlimited(api(fiber.MethodDelete, "/internal/delete", nodeKey, h.File.InternalDelete)),
+		transfer(unlimited(api(fiber.MethodGet, "/internal/file", nodeKey, h.File.InternalFile))),
+		unlimited(api(fiber.MethodGet, "/internal/diagnostics", nodeKey, h.File.InternalDiagnostics)),
+		transfer(unlimited(api(fiber.MethodGet, "/node/file", routing.Verified("URL signed by the master with the node auth key"), h.File.NodeFile))),
+
+		// Files
+		api(fiber.MethodGet, "/buckets/:bucketId/files", lister, h.File.ListFiles),
+		idempotent(streamed(api(fiber.MethodPost, "/buckets/:bucketId/files", uploader, h.File.UploadFile))),
+		idempotent(api(fiber.MethodPost, "/buckets/:bucketId/files/precheck", uploader, h.File.PrecheckUpload)),
+		api(fiber.MethodGet, "/buckets/:bucketId/files/:fileId/info", viewer, h.File.GetFile),
+		api(fiber.MethodDelete, "/buckets/:bucketId/files/:fileId", deleter, h.File.DeleteFile),
+		api(fiber.MethodPut, "/buckets/:bucketId/files/:fileId/headers", editor, h.File.SetFileHeaders),
+		api(fiber.MethodPut, "/buckets/:bucketId/files/:fileId/lock", editor, h.File.SetFileLock),
+		api(fiber.MethodPost, "/buckets/:bucketId/files/:fileId/signed-url", viewer, h.File.GenerateSignedURL),
+		api(fiber.MethodPost, "/buckets/:bucketId/files/:fileId/tokens", editor, h.File.CreateFileToken),
+		api(fiber.MethodGet, "/buckets/:bucketId/files/:fileId/tokens", viewer, h.File.ListFileTokens),
+		api(fiber.MethodDelete, "/buckets/:bucketId/files/:fileId/tokens/:tokenId", editor, h.File.RevokeFileToken),
+		api(fiber.MethodGet, "/buckets/:bucketId/files/:fileId/comments", viewer, h.Comment.ListComments),
+		api(fiber.MethodPost, "/buckets/:bucketId/files/:fileId/comments", viewer, h.Comment.CreateComment),
+		api(fiber.MethodDelete, "/buckets/:bucketId/files/:fileId/comments/:commentId", viewer, h.Comment.DeleteComment),
+		api(fiber.MethodPut, "/buckets/:bucketId/files/:fileId/favorite", viewer, h.Favorite.AddFavorite),
+		api(fiber.MethodDelete, "/buckets/:bucketId/files/:fileId/favorite", viewer, h.Favorite.RemoveFavorite),
+
+		// Resumable uploads
+		api(fiber.MethodPost, "/buckets/:bucketId/uploads", uploader, h.UploadSession.CreateUploadSession),
+		api(fiber.MethodGet, "/buckets/:bucketId/uploads/:uploadId", uploader, h.UploadSession.GetUploadSession),
+		streamed(api(fiber.MethodPut, "/buckets/:bucketId/uploads/:uploadId", uploader, h.UploadSession.UploadChunk)),
+		idempotent(api(fiber.MethodPost, "/buckets/:bucketId/uploads/:uploadId/complete", uploader, h.UploadSession.CompleteUploadSession)),
+		api(fiber.MethodDelete, "/buckets/:bucketId/uploads/:uploadId", uploader, h.UploadSession.AbortUploadSession),
+		api(fiber.MethodGet, "/transfers/:transferId", uploader, h.Transfer.GetTransfer),
+		transfer(unlimited(api(fiber.MethodGet, "/transfers/:transferId/events", uploader, h.Transfer.StreamTransfer))),
+
+		// Notifications
+		api(fiber.MethodGet, "/notifications", account, h.Comment.ListNotifications),
+		api(fiber.MethodPost, "/notifications/:id/read", account, h.Comment.MarkNotificationRead),
+
+		// API keys
+		api(fiber.MethodPost, "/api-keys", account, h.APIKey.CreateAPIKey),
+		api(fiber.MethodGet, "/api-keys", account, h.APIKey.ListAPIKeys),
+		api(fiber.MethodDelete, "/api-keys/:id", account, h.APIKey.DeleteAPIKey),
+		api(fiber.MethodGet, "/api-keys/:id/egress", account, h.Egress.GetAPIKeyEgress),
+		api(fiber.MethodPut, "/api-keys/:id/egress-quota", account, h.Egress.SetAPIKeyEgressQuota),
+
+		// Node management
+		api(fiber.MethodGet, "/nodes", nodeAdmin, h.Node.ListNodes),
+		api(fiber.MethodPost, "/nodes", nodeAdmin, h.Node.RegisterNode),
+		api(fiber.MethodPost, "/nodes/install", nodeAdmin, h.Node.InstallNode),
+		maintenance(api(fiber.MethodGet, "/nodes/health", nodeAdmin, h.Node.CheckAllNodesHealth)),
+		api(fiber.MethodGet, "/nodes/:id/health", nodeAdmin, h.Node.HealthCheck),
+		api(fiber.MethodGet, "/nodes/:id/diagnostics", nodeAdmin, h.Node.GetDiagnostics),
+		api(fiber.MethodPatch, "/nodes/:id", nodeAdmin, h.Node.UpdateNode),
+		api(fiber.MethodPost, "/nodes/:id/maintenance", nodeAdmin, h.Node.SetMaintenance),
+		api(fiber.MethodDelete, "/nodes/:id", nodeAdmin, h.Node.DeleteNode),
+		api(fiber.MethodGet, "/storage-nodes", nodeAdmin, listStorageNodes),
+
+		// Administration
+		api(fiber.MethodGet, "/admin/settings", admin, h.Settings.GetSystemSettings),
+		api(fiber.MethodPut, "/admin/settings", admin, h.Settings.UpdateSystemSettings),
+		api(fiber.MethodGet, "/admin/concurrency", admin, h.Metrics.GetConcurrency),
+		api(fiber.MethodGet, "/admin/node-cache", admin, h.Metrics.GetNodeCache),
+		api(fiber.MethodGet, "/admin/reclamation", admin, h.Reclamation.GetReclamationReport),
+		maintenance(api(fiber.MethodPost, "/admin/reclamation", admin, h.Reclamation.ReclaimStorage)),
+		api(fiber.MethodGet, "/admin/tasks", admin, h.AdminTask.ListTasks),
+		maintenance(api(fiber.MethodPost, "/admin/tasks", admin, h.AdminTask.RunTask)),
+		maintenance(api(fiber.MethodGet, "/admin/residency", admin, h.Residency.GetResidencyReport)),
+		api(fiber.MethodGet, "/admin/stats", admin, h.Stats.GetSystemStats),
+		maintenance(api(fiber.MethodGet, "/admin/permissions/export", admin, h.Permission.ExportPermissions)),
+		api(fiber.MethodGet, "/admin/roles", userAdmin, h.Role.ListRoles),
+		api(fiber.MethodPost, "/admin/roles", admin, h.Role.CreateRole),
+		api(fiber.MethodPut, "/admin/roles/:id", admin, h.Role.UpdateRole),
+		api(fiber.MethodDelete, "/admin/roles/:id", admin, h.Role.DeleteRole),
+		api(fiber.MethodGet, "/admin/cluster", admin, h.Cluster.ListMembers),
+		api(fiber.MethodGet, "/admin/signing-keys", admin, h.SigningKey.ListSigningKeys),
+		api(fiber.MethodPost, "/admin/signing-keys/rotate", admin, h.SigningKey.RotateSigningKey),
+		api(fiber.MethodPost, "/admin/nodes/:id/fail", admin, h.Node.FailNode),
+		api(fiber.MethodGet, "/admin/nodes/:id/repair", admin, h.Node.GetNodeRepair),
+		maintenance(api(fiber.MethodPost, "/admin/files/:fileId/relocate", admin, h.File.RelocateFile)),
+		maintenance(api(fiber.MethodGet, "/admin/rebalance/plan", admin, h.Rebalance.GetPlan)),
+		api(fiber.MethodPost, "/admin/rebalance", admin, h.Rebalance.StartRebalance),
+		api(fiber.MethodPost, "/admin/node-tokens", admin, h.Node.CreateRegistrationToken),
+		api(fiber.MethodGet, "/admin/node-tokens", admin, h.Node.ListRegistrationTokens),
+		api(fiber.MethodDelete, "/admin/node-tokens/:id", admin, h.Node.RevokeRegistrationToken),
+		api(fiber.MethodGet, "/admin/backups", admin, h.Backup.ListBackupRuns),
+		api(fiber.MethodPost, "/admin/backups", admin, h.Backup.RunBackup),
+		maintenance(api(fiber.MethodPost, "/admin/backups/restore", admin, h.Backup.RestoreBackup)),
+		api(fiber.MethodPost, "/admin/migrations/s3", admin, h.Import.ImportS3),
+		api(fiber.MethodGet, "/admin/migrations/s3/:id", admin, h.Import.GetS3ImportJob),
+		api(fiber.MethodPost, "/admin/exports/s3", admin, h.Export.ExportS3),
+		api(fiber.MethodGet, "/admin/exports/s3/:id", admin, h.Export.GetS3ExportJob),
+
+		// WebDAV access to buckets, clients mostly use basic auth
+		transfer(page(routing.AnyMethod, "/dav", davAccess, h.WebDAV.Serve)),
+		transfer(page(routing.AnyMethod, "/dav/*", davAccess, h.WebDAV.Serve)),
+
+		// Catch-all for React Router (SPA)
+		page(fiber.MethodGet, "*", public, spa),
+	)
+	return table
+}
+
+func health(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": "healthy",
+		"time":   time.Now(),
+	})
+}
+
+func listStorageNodes(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"storage_nodes": []interface{}{},
+		"message":       "Storage nodes not yet implemented",
+	})
+}
+
+func spa(c *fiber.Ctx) error {
+	return c.SendFile("./web/dist/index.html")
+}
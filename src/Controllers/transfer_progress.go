@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Progress"
+)
+
+// transferIDHeader carries the ID a client chose for an upload, to follow its progress under
+const transferIDHeader = "X-Transfer-Id"
+
+var errInvalidTransferID = apierror.Invalid("X-Transfer-Id must be 1 to 128 letters, digits, '.', '_' or '-'")
+
+// startTransfer begins reporting the progress of the request's upload, nil when the client didn't ask for it
+func startTransfer(c *fiber.Ctx, userID uuid.UUID) (*progress.Transfer, error) {
+	id := c.Get(transferIDHeader)
+	if id == "" {
+		return nil, nil
+	}
+	if !progress.ValidID(id) {
+		return nil, errInvalidTransferID
+	}
+	return progress.Start(userID, id), nil
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// multipartOverhead allows for the boundaries, part headers and other form fields sent around an uploaded file
+const multipartOverhead = 64 * 1024
+
+// declaredUploadTooLarge reports whether an upload's Content-Length already rules out a file of at most
+// limit bytes, so it can be refused before its body is read. A limit of 0 means no limit. Uploads sent
+// in chunks declare no length and are checked once their file has been received.
+func declaredUploadTooLarge(c *fiber.Ctx, limit int64) bool {
+	return limit > 0 && int64(c.Request().Header.ContentLength()) > limit+multipartOverhead
+}
+
+// uploadTooLarge answers 413 for an upload refused from its declared size. Its body is never read,
+// so the connection is closed rather than reused.
+func uploadTooLarge(c *fiber.Ctx, limit int64) error {
+	c.Context().SetConnectionClose()
+	return apierror.New(apierror.CodePayloadTooLarge, fmt.Sprintf("file exceeds the maximum size of %d bytes", limit))
+}
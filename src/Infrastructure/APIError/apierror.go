@@ -0,0 +1,281 @@
+// Package apierror is the error type the API answers failed requests with. An Error carries a
+// machine-readable code, which decides the response status, a message for people and optional
+// details. Handlers return Errors, or errors wrapping them, and middleware.ErrorHandler writes them
+// out; any other error is answered as an internal error without its message.
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Code identifies what went wrong, for clients to handle failures without parsing messages
+type Code string
+
+// Codes of failures that aren't specific to a resource
+const (
+	// CodeInvalidRequest is for a request with a malformed path, query or body
+	CodeInvalidRequest Code = "invalid_request"
+	// CodeValidationFailed is for path parameters or body fields that don't pass validation, each
+	// described in fields
+	CodeValidationFailed Code = "validation_failed"
+	// CodeUnauthorized is for a request without valid credentials
+	CodeUnauthorized Code = "unauthorized"
+	// CodeForbidden is for a request the credentials may not make
+	CodeForbidden Code = "forbidden"
+	// CodeNotFound is for a resource that doesn't exist, or that the caller may not see
+	CodeNotFound Code = "not_found"
+	// CodeMethodNotAllowed is for a method the route doesn't serve
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	// CodeConflict is for a request that clashes with the resource's current state, such as a
+	// job that is already running
+	CodeConflict Code = "conflict"
+	// CodeGone is for a resource that no longer accepts requests
+	CodeGone Code = "gone"
+	// CodePayloadTooLarge is for a request body over the server's limits
+	CodePayloadTooLarge Code = "payload_too_large"
+	// CodeUnprocessable is for a well-formed request the server won't act on
+	CodeUnprocessable Code = "unprocessable"
+	// CodeRateLimited is for a caller over a rate limit or quota, the Retry-After header says when
+	// to try again
+	CodeRateLimited Code = "rate_limited"
+	// CodeCanceled is for a request whose client went away before it finished
+	CodeCanceled Code = "canceled"
+	// CodeInternal is for a failure of the server, whose cause is logged rather than returned
+	CodeInternal Code = "internal_error"
+	// CodeNotImplemented is for a feature the server doesn't support
+	CodeNotImplemented Code = "not_implemented"
+	// CodeBadGateway is for a storage node or remote server that answered wrongly
+	CodeBadGateway Code = "bad_gateway"
+	// CodeUnavailable is for a feature that isn't configured, or a server that is shutting down
+	CodeUnavailable Code = "unavailable"
+	// CodeTimeout is for a request that ran past its timeout
+	CodeTimeout Code = "timeout"
+	// CodeInsufficientStorage is for content that no storage has room for
+	CodeInsufficientStorage Code = "insufficient_storage"
+)
+
+// Codes of failures specific to a resource
+const (
+	// CodeInvalidCredentials is for a sign in with a wrong username, password or token
+	CodeInvalidCredentials Code = "invalid_credentials"
+	// CodeTwoFactorRequired is for an account that must set up two-factor authentication first
+	CodeTwoFactorRequired Code = "two_factor_required"
+	// CodeBucketNotFound is for a bucket that doesn't exist
+	CodeBucketNotFound Code = "bucket_not_found"
+	// CodeFileNotFound is for a file that doesn't exist
+	CodeFileNotFound Code = "file_not_found"
+	// CodeUserNotFound is for a user that doesn't exist
+	CodeUserNotFound Code = "user_not_found"
+	// CodeNodeNotFound is for a storage node that doesn't exist
+	CodeNodeNotFound Code = "node_not_found"
+	// CodeAlreadyExists is for a resource whose name or address is already taken
+	CodeAlreadyExists Code = "already_exists"
+	// CodeFileLocked is for a file that object lock keeps from being changed or deleted
+	CodeFileLocked Code = "file_locked"
+	// CodeFileTooLarge is for a file over the size its bucket, upload link or policy allows
+	CodeFileTooLarge Code = "file_too_large"
+	// CodeChecksumMismatch is for content that doesn't match the checksum sent with it
+	CodeChecksumMismatch Code = "checksum_mismatch"
+	// CodeSizeMismatch is for content that doesn't match the size sent with it
+	CodeSizeMismatch Code = "size_mismatch"
+	// CodeOffsetMismatch is for an upload chunk that doesn't start where the upload is at
+	CodeOffsetMismatch Code = "offset_mismatch"
+	// CodeFileInfected is for an upload the malware scanner rejected
+	CodeFileInfected Code = "file_infected"
+	// CodeCustomerKeyRequired is for a file encrypted with a customer key read without one
+	CodeCustomerKeyRequired Code = "customer_key_required"
+	// CodeCustomerKeyMismatch is for a customer key other than the one a file was encrypted with
+	CodeCustomerKeyMismatch Code = "customer_key_mismatch"
+)
+
+// statuses is the response status of each code
+var statuses = map[Code]int{
+	CodeInvalidRequest:      http.StatusBadRequest,
+	CodeValidationFailed:    http.StatusUnprocessableEntity,
+	CodeUnauthorized:        http.StatusUnauthorized,
+	CodeForbidden:           http.StatusForbidden,
+	CodeNotFound:            http.StatusNotFound,
+	CodeMethodNotAllowed:    http.StatusMethodNotAllowed,
+	CodeConflict:            http.StatusConflict,
+	CodeGone:                http.StatusGone,
+	CodePayloadTooLarge:     http.StatusRequestEntityTooLarge,
+	CodeUnprocessable:       http.StatusUnprocessableEntity,
+	CodeRateLimited:         http.StatusTooManyRequests,
+	CodeCanceled:            StatusClientClosedRequest,
+	CodeInternal:            http.StatusInternalServerError,
+	CodeNotImplemented:      http.StatusNotImplemented,
+	CodeBadGateway:          http.StatusBadGateway,
+	CodeUnavailable:         http.StatusServiceUnavailable,
+	CodeTimeout:             http.StatusGatewayTimeout,
+	CodeInsufficientStorage: http.StatusInsufficientStorage,
+
+	CodeInvalidCredentials:  http.StatusUnauthorized,
+	CodeTwoFactorRequired:   http.StatusForbidden,
+	CodeBucketNotFound:      http.StatusNotFound,
+	CodeFileNotFound:        http.StatusNotFound,
+	CodeUserNotFound:        http.StatusNotFound,
+	CodeNodeNotFound:        http.StatusNotFound,
+	CodeAlreadyExists:       http.StatusConflict,
+	CodeFileLocked:          http.StatusConflict,
+	CodeFileTooLarge:        http.StatusRequestEntityTooLarge,
+	CodeChecksumMismatch:    http.StatusBadRequest,
+	CodeSizeMismatch:        http.StatusBadRequest,
+	CodeOffsetMismatch:      http.StatusConflict,
+	CodeFileInfected:        http.StatusUnprocessableEntity,
+	CodeCustomerKeyRequired: http.StatusBadRequest,
+	CodeCustomerKeyMismatch: http.StatusForbidden,
+}
+
+// StatusClientClosedRequest is the status logged for requests whose client went away, which it
+// never sees
+const StatusClientClosedRequest = 499
+
+// Status is the response status of failures with the code
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ForStatus is the code for failures that have nothing more specific than their status, such as
+// the ones Fiber answers itself
+func ForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway:
+		return CodeBadGateway
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return CodeTimeout
+	case http.StatusInsufficientStorage:
+		return CodeInsufficientStorage
+	}
+	if status >= 400 && status < 500 {
+		return CodeInvalidRequest
+	}
+	return CodeInternal
+}
+
+// Error is a failure the API reports to its caller. It is also the body of error responses.
+type Error struct {
+	// Message says what went wrong, for people
+	Message string `json:"error"`
+	// Code says what went wrong, for programs
+	Code Code `json:"code"`
+	// Details say more about what went wrong
+	Details string `json:"details,omitempty"`
+	// Fields are the parameters and body fields that failed validation
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is a parameter or body field that failed validation
+type FieldError struct {
+	// Field is the parameter's name, or the field's JSON path in the body, like settings.max_file_size
+	Field string `json:"field"`
+	// In is where the field is: path or body
+	In string `json:"in"`
+	// Message says what the field's value must be
+	Message string `json:"message"`
+}
+
+// New returns an Error with the code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf returns an Error with the code and a message formatted from format and args
+func Newf(code Code, format string, args ...any) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Invalid returns an invalid_request Error with the message
+func Invalid(message string) *Error {
+	return New(CodeInvalidRequest, message)
+}
+
+// Validation returns a validation_failed Error for the fields, with them summed up in its details
+func Validation(fields ...FieldError) *Error {
+	failures := make([]string, len(fields))
+	for i, field := range fields {
+		failures[i] = field.Field + " " + field.Message
+	}
+	e := New(CodeValidationFailed, "Validation failed").WithDetails(strings.Join(failures, "; "))
+	e.Fields = fields
+	return e
+}
+
+// Unauthorized returns an unauthorized Error with the message
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+// Forbidden returns a forbidden Error with the message
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+// NotFound returns a not_found Error with the message
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+// Conflict returns a conflict Error with the message
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// Internal returns an internal_error Error with the message, for failures whose cause was logged
+func Internal(message string) *Error {
+	return New(CodeInternal, message)
+}
+
+// WithDetails returns a copy of e with the details
+func (e *Error) WithDetails(details string) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Status is the response status of e
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// As returns the Error in err's chain, or nil when there is none
+func As(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package access
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// IsBucketAdmin reports whether the user was granted bucket admin on the bucket
+func IsBucketAdmin(dbContext *persistence.AppDbContext, bucketID, userID uuid.UUID) bool {
+	count, err := dbContext.BucketAdminGrants.Where(&entities.BucketAdminGrant{BucketId: bucketID, UserId: userID}).Count()
+	return err == nil && count > 0
+}
+
+// CanManageBucket reports whether the user may manage the bucket: its owner, system admins
+// and its bucket admins. Deleting a bucket is left to its owner.
+func CanManageBucket(dbContext *persistence.AppDbContext, bucket *entities.Bucket, userID uuid.UUID, userRole string) bool {
+	return bucket.OwnerId == userID || userRole == "admin" || IsBucketAdmin(dbContext, bucket.Id, userID)
+}
+
+// ManagedBucketIDs returns the buckets the user was granted bucket admin on
+func ManagedBucketIDs(dbContext *persistence.AppDbContext, userID uuid.UUID) ([]uuid.UUID, error) {
+	return managedBucketIDs(dbContext.GetDB(), userID)
+}
+
+func managedBucketIDs(db *gorm.DB, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Model(&entities.BucketAdminGrant{}).Where(`"UserId" = ?`, userID).Pluck("BucketId", &ids).Error
+	return ids, err
+}
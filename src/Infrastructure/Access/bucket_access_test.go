@@ -0,0 +1,33 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestManagedBucketIDs lists the buckets a user was granted bucket admin on
+func TestManagedBucketIDs(t *testing.T) {
+	db := sqlitetest.Open(t)
+	userID, bucketID := uuid.New(), uuid.New()
+	for _, grant := range []entities.BucketAdminGrant{
+		{BucketId: bucketID, UserId: userID},
+		{BucketId: uuid.New(), UserId: uuid.New()},
+	} {
+		grant.GrantedBy = uuid.New()
+		if err := db.Create(&grant).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := managedBucketIDs(db, userID)
+	if err != nil {
+		t.Fatalf("managedBucketIDs() = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != bucketID {
+		t.Errorf("managedBucketIDs() = %v, want [%s]", ids, bucketID)
+	}
+}
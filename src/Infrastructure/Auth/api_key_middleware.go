@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,7 +12,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/IPFilter"
 	"shbucket/src/Infrastructure/Persistence"
 )
 
@@ -24,26 +27,27 @@ type APIKeyUserContext struct {
 	Role        string
 	IsActive    bool
 	Permissions entities.APIKeyPermission
+	KeyRole     string // the role an API key is limited to, empty when it has its owner's
 	Source      string // "jwt" or "api_key"
 }
 
-// RequireRoleOrAPIKey creates middleware that supports both JWT and API key authentication
-func (a *AuthorizationService) RequireRoleOrAPIKey(requiredRole string, dbContext *persistence.AppDbContext) fiber.Handler {
+// RequireRoleOrAPIKey creates middleware that supports both JWT and API key authentication.
+// Callers with a custom role need permission instead of requiredRole.
+func (a *AuthorizationService) RequireRoleOrAPIKey(requiredRole, permission string, dbContext *persistence.AppDbContext) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// First try API key authentication
 		if apiKeyHeader := c.Get("X-API-Key"); apiKeyHeader != "" {
-			userContext, err := a.validateAPIKeyAuth(apiKeyHeader, dbContext)
+			userContext, err := a.validateAPIKeyAuth(apiKeyHeader, c.IP(), dbContext)
+			if errors.Is(err, ipfilter.ErrDenied) {
+				return apierror.Forbidden("API key can't be used from this address: " + err.Error())
+			}
 			if err != nil {
-				return c.Status(401).JSON(fiber.Map{
-					"error": "Invalid API key: " + err.Error(),
-				})
+				return apierror.Unauthorized("Invalid API key: " + err.Error())
 			}
 
-			// Check if API key has required permissions based on role
-			if !a.hasAPIKeyPermissionForRole(userContext.Permissions, requiredRole) {
-				return c.Status(403).JSON(fiber.Map{
-					"error": "API key does not have sufficient permissions",
-				})
+			// Check if API key has required permissions based on role, and its owner and its own role allow the route
+			if !a.hasAPIKeyPermissionForRole(userContext.Permissions, requiredRole) || !a.CredentialsAllowed(dbContext, userContext, requiredRole, permission) {
+				return apierror.Forbidden("API key does not have sufficient permissions")
 			}
 
 			// Store API key user context in fiber locals
@@ -60,16 +64,15 @@ func (a *AuthorizationService) RequireRoleOrAPIKey(requiredRole string, dbContex
 
 		// Fall back to JWT authentication
 		userContext, err := a.AuthorizeRequest(c)
+		if errors.Is(err, ErrTwoFactorSetupRequired) {
+			return err
+		}
 		if err != nil {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "unauthorized: " + err.Error(),
-			})
+			return apierror.Unauthorized("unauthorized: " + err.Error())
 		}
 
-		if !a.HasRole(userContext.Role, requiredRole) {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: insufficient permissions",
-			})
+		if !a.Allowed(dbContext, userContext.Role, requiredRole, permission) {
+			return apierror.Forbidden("forbidden: insufficient permissions")
 		}
 
 		// Store user context in fiber locals
@@ -78,8 +81,8 @@ func (a *AuthorizationService) RequireRoleOrAPIKey(requiredRole string, dbContex
 	}
 }
 
-// validateAPIKeyAuth validates an API key and returns user context
-func (a *AuthorizationService) validateAPIKeyAuth(apiKey string, dbContext *persistence.AppDbContext) (*APIKeyUserContext, error) {
+// validateAPIKeyAuth validates an API key used from clientIP and returns user context
+func (a *AuthorizationService) validateAPIKeyAuth(apiKey, clientIP string, dbContext *persistence.AppDbContext) (*APIKeyUserContext, error) {
 	// Hash the provided API key
 	hash := sha256.Sum256([]byte(apiKey))
 	keyHash := hex.EncodeToString(hash[:])
@@ -118,6 +121,12 @@ func (a *AuthorizationService) validateAPIKeyAuth(apiKey string, dbContext *pers
 		return nil, fmt.Errorf("failed to parse API key permissions: %w", err)
 	}
 
+	// Check the key may be used from the client's address
+	if err := ipfilter.Check(clientIP, permissions.AllowedIPs, permissions.DeniedIPs); err != nil {
+		ipfilter.Audit("API key "+dbAPIKey.KeyPrefix, clientIP, err)
+		return nil, err
+	}
+
 	// Create API key user context
 	userContext := &APIKeyUserContext{
 		APIKeyID:    dbAPIKey.Id,
@@ -127,6 +136,7 @@ func (a *AuthorizationService) validateAPIKeyAuth(apiKey string, dbContext *pers
 		Role:        user.Role,
 		IsActive:    user.IsActive,
 		Permissions: permissions,
+		KeyRole:     dbAPIKey.Role,
 		Source:      "api_key",
 	}
 
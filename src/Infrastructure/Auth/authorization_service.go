@@ -7,6 +7,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
+	"shbucket/src/Infrastructure/APIError"
 	"shbucket/src/Infrastructure/Data/Entities"
 	"shbucket/src/Utils"
 )
@@ -51,6 +52,9 @@ func (a *AuthorizationService) AuthorizeRequest(c *fiber.Ctx) (*UserContext, err
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
+	if claims.TwoFactorSetup && !isTwoFactorSetupPath(c.Path()) {
+		return nil, ErrTwoFactorSetupRequired
+	}
 
 	// Create user context
 	userContext := &UserContext{
@@ -64,20 +68,26 @@ func (a *AuthorizationService) AuthorizeRequest(c *fiber.Ctx) (*UserContext, err
 	return userContext, nil
 }
 
+// isTwoFactorSetupPath reports whether a token limited to setting up two-factor authentication can call path
+func isTwoFactorSetupPath(path string) bool {
+	for _, allowed := range TwoFactorSetupPaths {
+		if path == allowed || strings.HasPrefix(path, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // RequireRole creates middleware that requires specific role
 func (a *AuthorizationService) RequireRole(requiredRole string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userContext, err := a.AuthorizeRequest(c)
 		if err != nil {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "unauthorized: " + err.Error(),
-			})
+			return apierror.Unauthorized("unauthorized: " + err.Error())
 		}
 
 		if !a.HasRole(userContext.Role, requiredRole) {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: insufficient permissions",
-			})
+			return apierror.Forbidden("forbidden: insufficient permissions")
 		}
 
 		// Store user context in fiber locals
@@ -91,15 +101,11 @@ func (a *AuthorizationService) RequirePermission(permission string) fiber.Handle
 	return func(c *fiber.Ctx) error {
 		userContext, err := a.AuthorizeRequest(c)
 		if err != nil {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "unauthorized: " + err.Error(),
-			})
+			return apierror.Unauthorized("unauthorized: " + err.Error())
 		}
 
 		if !a.HasPermission(userContext.Role, permission) {
-			return c.Status(403).JSON(fiber.Map{
-				"error": "forbidden: missing permission: " + permission,
-			})
+			return apierror.Forbidden("forbidden: missing permission: " + permission)
 		}
 
 		// Store user context in fiber locals
@@ -121,9 +127,7 @@ func (a *AuthorizationService) ValidateAccess(authRule entities.AuthRule) fiber.
 			// Validate JWT token
 			userContext, err := a.AuthorizeRequest(c)
 			if err != nil {
-				return c.Status(401).JSON(fiber.Map{
-					"error": "unauthorized: " + err.Error(),
-				})
+				return apierror.Unauthorized("unauthorized: " + err.Error())
 			}
 			c.Locals("user", userContext)
 			return c.Next()
@@ -138,9 +142,7 @@ func (a *AuthorizationService) ValidateAccess(authRule entities.AuthRule) fiber.
 			return a.validateSession(c, utils.ConvertJSONToMap(authRule.Config))
 
 		default:
-			return c.Status(401).JSON(fiber.Map{
-				"error": "unsupported authentication type: " + authRule.Type,
-			})
+			return apierror.Unauthorized("unsupported authentication type: " + authRule.Type)
 		}
 	}
 }
@@ -167,21 +169,9 @@ func (a *AuthorizationService) HasRole(userRole, requiredRole string) bool {
 	return userLevel >= requiredLevel
 }
 
-// HasPermission checks if user has the required permission
+// HasPermission checks if a built-in role has the required permission
 func (a *AuthorizationService) HasPermission(userRole, permission string) bool {
-	permissions := map[string][]string{
-		"viewer": {"read", "list"},
-		"editor": {"read", "list", "write", "upload", "download"},
-		"manager": {"read", "list", "write", "upload", "download", "delete", "manage_buckets"},
-		"admin": {"read", "list", "write", "upload", "download", "delete", "manage_buckets", "manage_users", "manage_system"},
-	}
-
-	userPermissions, exists := permissions[strings.ToLower(userRole)]
-	if !exists {
-		return false
-	}
-
-	for _, perm := range userPermissions {
+	for _, perm := range builtinPermissions[strings.ToLower(userRole)] {
 		if perm == permission {
 			return true
 		}
@@ -218,24 +208,18 @@ func (a *AuthorizationService) validateAPIKey(c *fiber.Ctx, config map[string]in
 	}
 
 	if apiKey == "" {
-		return c.Status(401).JSON(fiber.Map{
-			"error": "API key is required",
-		})
+		return apierror.Unauthorized("API key is required")
 	}
 
 	// Extract allowed keys from config
 	allowedKeysInterface, ok := config["allowed_api_keys"]
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{
-			"error": "no API keys configured",
-		})
+		return apierror.Unauthorized("no API keys configured")
 	}
 
 	allowedKeys, ok := allowedKeysInterface.([]interface{})
 	if !ok {
-		return c.Status(401).JSON(fiber.Map{
-			"error": "invalid API keys configuration",
-		})
+		return apierror.Unauthorized("invalid API keys configuration")
 	}
 
 	// Check if provided key is allowed
@@ -246,27 +230,21 @@ func (a *AuthorizationService) validateAPIKey(c *fiber.Ctx, config map[string]in
 		}
 	}
 
-	return c.Status(401).JSON(fiber.Map{
-		"error": "invalid API key",
-	})
+	return apierror.Unauthorized("invalid API key")
 }
 
 // validateSignedURL validates signed URL authentication
 func (a *AuthorizationService) validateSignedURL(c *fiber.Ctx, config map[string]interface{}) error {
 	signature := c.Query("signature")
 	if signature == "" {
-		return c.Status(401).JSON(fiber.Map{
-			"error": "signature parameter is required",
-		})
+		return apierror.Unauthorized("signature parameter is required")
 	}
 
 	// TODO: Implement signed URL validation logic
 	// This would involve checking the signature against the signing secret
 	// and validating expiration time
 
-	return c.Status(501).JSON(fiber.Map{
-		"error": "signed URL authentication not fully implemented",
-	})
+	return apierror.New(apierror.CodeNotImplemented, "signed URL authentication not fully implemented")
 }
 
 // validateSession validates session-based authentication
@@ -277,15 +255,11 @@ func (a *AuthorizationService) validateSession(c *fiber.Ctx, config map[string]i
 	}
 
 	if sessionToken == "" {
-		return c.Status(401).JSON(fiber.Map{
-			"error": "session token is required",
-		})
+		return apierror.Unauthorized("session token is required")
 	}
 
 	// TODO: Implement session validation logic
 	// This would involve checking the session against the database
 
-	return c.Status(501).JSON(fiber.Map{
-		"error": "session authentication not fully implemented",
-	})
+	return apierror.New(apierror.CodeNotImplemented, "session authentication not fully implemented")
 }
\ No newline at end of file
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/IPFilter"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// passwordCacheTTL is how long a verified basic auth password is trusted without another bcrypt comparison.
+// Clients using basic auth send the password with every request, and a comparison takes tens of milliseconds.
+const passwordCacheTTL = 5 * time.Minute
+
+var verifiedPasswords = struct {
+	mutex   sync.Mutex
+	expires map[[32]byte]time.Time // by hash of the password and the stored password hash
+}{expires: make(map[[32]byte]time.Time)}
+
+// AuthenticateCredentials authenticates clients that send credentials with every request instead of
+// holding a session, such as WebDAV clients: an X-API-Key header, a Bearer token, or HTTP basic auth
+// with a username or email and either the account password or one of the account's API keys.
+// Tokens and passwords get read and write permissions, limited by the user's role as usual.
+func (a *AuthorizationService) AuthenticateCredentials(c *fiber.Ctx, dbContext *persistence.AppDbContext) (*APIKeyUserContext, error) {
+	if apiKey := c.Get("X-API-Key"); apiKey != "" {
+		return a.validateAPIKeyAuth(apiKey, c.IP(), dbContext)
+	}
+
+	scheme, credentials, _ := strings.Cut(c.Get("Authorization"), " ")
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		userContext, err := a.AuthorizeRequest(c)
+		if err != nil {
+			return nil, err
+		}
+		return &APIKeyUserContext{
+			UserID:      userContext.UserID,
+			Username:    userContext.Username,
+			Email:       userContext.Email,
+			Role:        userContext.Role,
+			IsActive:    userContext.IsActive,
+			Permissions: entities.APIKeyPermission{Read: true, Write: true},
+			Source:      "jwt",
+		}, nil
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(credentials))
+		if err != nil {
+			return nil, fmt.Errorf("malformed basic auth credentials")
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed basic auth credentials")
+		}
+		return a.AuthenticatePassword(username, password, c.IP(), dbContext)
+	}
+	return nil, fmt.Errorf("credentials are required")
+}
+
+// AuthenticatePassword checks password as the account password of username (or email),
+// then as one of the account's API keys used from clientIP. Accounts using two-factor
+// authentication need an API key.
+func (a *AuthorizationService) AuthenticatePassword(username, password, clientIP string, dbContext *persistence.AppDbContext) (*APIKeyUserContext, error) {
+	user, err := dbContext.Users.Where(&entities.User{Email: username}).OrField("Username", username).FirstOrDefault()
+	if err != nil || user == nil || !user.IsActive {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	// The account password alone doesn't sign in users with two-factor authentication, they use an API key
+	if !user.TwoFactorEnabled && !TwoFactorRequired(user.Role) && verifyPassword(user, password) {
+		return &APIKeyUserContext{
+			UserID:      user.Id,
+			Username:    user.Username,
+			Email:       user.Email,
+			Role:        user.Role,
+			IsActive:    user.IsActive,
+			Permissions: entities.APIKeyPermission{Read: true, Write: true},
+			Source:      "password",
+		}, nil
+	}
+
+	// Clients that can't keep a password out of their config store an API key instead
+	userContext, err := a.validateAPIKeyAuth(password, clientIP, dbContext)
+	if errors.Is(err, ipfilter.ErrDenied) {
+		return nil, err
+	}
+	if err == nil && userContext.UserID == user.Id {
+		return userContext, nil
+	}
+	return nil, fmt.Errorf("invalid credentials")
+}
+
+func verifyPassword(user *entities.User, password string) bool {
+	// Keyed by the stored hash as well, so changing the password drops the cached verification
+	key := sha256.Sum256([]byte(user.PasswordHash + "\x00" + password))
+
+	verifiedPasswords.mutex.Lock()
+	expires, ok := verifiedPasswords.expires[key]
+	verifiedPasswords.mutex.Unlock()
+	if ok && time.Now().Before(expires) {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return false
+	}
+
+	verifiedPasswords.mutex.Lock()
+	defer verifiedPasswords.mutex.Unlock()
+	now := time.Now()
+	for cached, cachedExpires := range verifiedPasswords.expires {
+		if now.After(cachedExpires) {
+			delete(verifiedPasswords.expires, cached)
+		}
+	}
+	verifiedPasswords.expires[key] = now.Add(passwordCacheTTL)
+	return true
+}
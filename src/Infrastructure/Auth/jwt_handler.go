@@ -22,6 +22,9 @@ type JWTClaims struct {
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
 	Role     string    `json:"role"`
+	// TwoFactorSetup limits the token to setting up two-factor authentication, which the user's
+	// role requires
+	TwoFactorSetup bool `json:"two_factor_setup,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -50,14 +53,25 @@ func NewJWTHandler(secretKey string, issuer string, expiryHours int) *JWTHandler
 
 // GenerateToken generates a new JWT token for the user
 func (j *JWTHandler) GenerateToken(userID uuid.UUID, username, email, role string) (string, *SessionInfo, error) {
+	return j.generateToken(userID, username, email, role, false)
+}
+
+// GenerateTwoFactorSetupToken generates a token that can only set up two-factor authentication,
+// for users whose role requires it who haven't yet
+func (j *JWTHandler) GenerateTwoFactorSetupToken(userID uuid.UUID, username, email, role string) (string, *SessionInfo, error) {
+	return j.generateToken(userID, username, email, role, true)
+}
+
+func (j *JWTHandler) generateToken(userID uuid.UUID, username, email, role string, twoFactorSetup bool) (string, *SessionInfo, error) {
 	now := time.Now()
 	expiresAt := now.Add(time.Duration(j.expiryHours) * time.Hour)
 
 	claims := &JWTClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
+		UserID:         userID,
+		Username:       username,
+		Email:          email,
+		Role:           role,
+		TwoFactorSetup: twoFactorSetup,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.issuer,
 			Subject:   userID.String(),
@@ -128,7 +142,7 @@ func (j *JWTHandler) RefreshToken(tokenString string) (string, *SessionInfo, err
 	}
 
 	// Generate new token with same user info
-	return j.GenerateToken(claims.UserID, claims.Username, claims.Email, claims.Role)
+	return j.generateToken(claims.UserID, claims.Username, claims.Email, claims.Role, claims.TwoFactorSetup)
 }
 
 // GetTokenHash generates hash for token storage
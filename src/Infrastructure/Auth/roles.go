@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Routing"
+)
+
+// roleCacheTTL is how long custom roles are trusted before they're read again. Changes made on
+// this server drop the cache at once, other masters sharing the database pick them up this late.
+const roleCacheTTL = time.Minute
+
+// builtinPermissions are what the built-in roles hold. Routes check built-in roles against the
+// hierarchy, this is for HasPermission and for listing roles.
+var builtinPermissions = map[string][]string{
+	"viewer":  {routing.PermissionAccount, "read", "list"},
+	"editor":  {routing.PermissionAccount, "read", "list", "write", "upload", "download"},
+	"manager": {routing.PermissionAccount, "read", "list", "write", "upload", "download", "delete", "manage_buckets", "manage_nodes"},
+	"admin":   {routing.PermissionAccount, "read", "list", "write", "upload", "download", "delete", "manage_buckets", "manage_nodes", "manage_users", "manage_system"},
+}
+
+// ErrUnknownRole is returned for a role that is neither built-in nor a custom role
+var ErrUnknownRole = apierror.New(apierror.CodeInvalidRequest, "unknown role")
+
+var customRoles = struct {
+	mutex       sync.Mutex
+	loadedAt    time.Time
+	permissions map[string]map[string]bool // by role name
+}{}
+
+// BuiltinPermissions returns the permissions of a built-in role, nil for any other role
+func BuiltinPermissions(role string) []string {
+	return builtinPermissions[role]
+}
+
+// IsBuiltinRole reports whether role is one of the built-in roles
+func IsBuiltinRole(role string) bool {
+	_, ok := builtinPermissions[role]
+	return ok
+}
+
+// InvalidateRoles drops the cached custom roles, after one is created, changed or deleted
+func InvalidateRoles() {
+	customRoles.mutex.Lock()
+	defer customRoles.mutex.Unlock()
+	customRoles.permissions = nil
+}
+
+// ValidateRole checks role is a built-in role or a custom role, so it can be given to a user or API key
+func ValidateRole(dbContext *persistence.AppDbContext, role string) error {
+	if IsBuiltinRole(role) {
+		return nil
+	}
+	roles, err := loadCustomRoles(dbContext)
+	if err != nil {
+		return err
+	}
+	if _, ok := roles[role]; !ok {
+		return ErrUnknownRole
+	}
+	return nil
+}
+
+// Allowed reports whether a user or API key with role may call a route requiring requiredRole,
+// which custom roles need permission for. Built-in roles are held to the hierarchy, custom roles
+// to the permissions an admin gave them, and every role may manage its own account.
+func (a *AuthorizationService) Allowed(dbContext *persistence.AppDbContext, role, requiredRole, permission string) bool {
+	if IsBuiltinRole(role) {
+		return a.HasRole(role, requiredRole)
+	}
+
+	roles, err := loadCustomRoles(dbContext)
+	if err != nil {
+		log.Printf("Failed to load custom roles: %v", err)
+		return false
+	}
+	held, ok := roles[role]
+	if !ok {
+		return false
+	}
+	return permission == routing.PermissionAccount || held[permission]
+}
+
+// CredentialsAllowed reports whether an authenticated client may call a route requiring
+// requiredRole, or permission of custom roles: the user's role must allow it and, for an API
+// key limited to a role, the key's role as well
+func (a *AuthorizationService) CredentialsAllowed(dbContext *persistence.AppDbContext, user *APIKeyUserContext, requiredRole, permission string) bool {
+	if !a.Allowed(dbContext, user.Role, requiredRole, permission) {
+		return false
+	}
+	return user.KeyRole == "" || a.Allowed(dbContext, user.KeyRole, requiredRole, permission)
+}
+
+func loadCustomRoles(dbContext *persistence.AppDbContext) (map[string]map[string]bool, error) {
+	customRoles.mutex.Lock()
+	defer customRoles.mutex.Unlock()
+	if customRoles.permissions != nil && time.Since(customRoles.loadedAt) < roleCacheTTL {
+		return customRoles.permissions, nil
+	}
+
+	roles, err := dbContext.Roles.ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	permissions := make(map[string]map[string]bool, len(roles))
+	for _, role := range roles {
+		var names []string
+		if err := json.Unmarshal(role.Permissions, &names); err != nil {
+			return nil, fmt.Errorf("failed to parse permissions of role %s: %w", role.Name, err)
+		}
+		held := make(map[string]bool, len(names))
+		for _, name := range names {
+			held[name] = true
+		}
+		permissions[role.Name] = held
+	}
+
+	customRoles.permissions = permissions
+	customRoles.loadedAt = time.Now()
+	return permissions, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+)
+
+// TOTP parameters, the defaults of authenticator apps (RFC 6238)
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is how many periods before and after the current one a code is accepted from,
+	// for clocks that drift
+	totpSkew = 1
+)
+
+// ErrTwoFactorSetupRequired is returned for tokens of users who must set up two-factor
+// authentication before using anything but the two-factor endpoints
+var ErrTwoFactorSetupRequired = apierror.New(apierror.CodeTwoFactorRequired, "two-factor authentication must be set up first, see /auth/2fa/enroll")
+
+// TwoFactorSetupPaths are the routes a token issued for setting up two-factor authentication can call
+var TwoFactorSetupPaths = []string{"/api/v1/auth/2fa", "/api/v1/auth/logout"}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a new base32 encoded secret for an authenticator app
+func NewTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURL is the otpauth:// URL authenticator apps enroll a secret with, usually shown as a QR code
+func TOTPURL(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// VerifyTOTP checks a code against the secret at now. It returns the time step the code is for,
+// which must be later than afterStep so a code can't be used twice.
+func VerifyTOTP(secret, code string, now time.Time, afterStep int64) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= afterStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode is the code of a time step (RFC 4226 dynamic truncation)
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// TwoFactorRequired reports whether users with the role must use two-factor authentication
+func TwoFactorRequired(role string) bool {
+	for _, required := range strings.Split(config.GetRuntimeSettings().TwoFactorRequiredRoles, ",") {
+		if strings.TrimSpace(required) == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+// Package binding reads requests into the values handlers work with. The ID parameters of a
+// route's path are checked before its handler runs, and bodies are parsed and validated in one
+// step. Whatever fails is answered with 422 and an apierror.FieldError for each parameter or
+// field, which names body fields as clients send them, by their JSON names.
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// Where a field is, as reported in apierror.FieldError
+const (
+	InPath = "path"
+	InBody = "body"
+)
+
+// paramLocal prefixes the locals holding the ID parameters Params parsed
+const paramLocal = "param:"
+
+// NewValidator returns a validator that names fields by their JSON names
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Params checks that the named path parameters are UUIDs, answering with all of those that
+// aren't, and keeps the parsed IDs for UUID
+func Params(names []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var fields []apierror.FieldError
+		for _, name := range names {
+			id, err := uuid.Parse(c.Params(name))
+			if err != nil {
+				fields = append(fields, apierror.FieldError{Field: name, In: InPath, Message: "must be a UUID"})
+				continue
+			}
+			c.Locals(paramLocal+name, id)
+		}
+		if len(fields) > 0 {
+			return apierror.Validation(fields...)
+		}
+		return c.Next()
+	}
+}
+
+// UUID returns the route's ID parameter name, which Params checked before the handler ran. It is
+// uuid.Nil for a parameter the route doesn't have.
+func UUID(c *fiber.Ctx, name string) uuid.UUID {
+	if id, ok := c.Locals(paramLocal + name).(uuid.UUID); ok {
+		return id
+	}
+	id, _ := uuid.Parse(c.Params(name))
+	return id
+}
+
+// Body parses the request body into out and validates it
+func Body(c *fiber.Ctx, v *validator.Validate, out any) error {
+	if err := Parse(c, out); err != nil {
+		return err
+	}
+	return Validate(v, out)
+}
+
+// Parse parses the request body into out, for handlers that fill in more of it before it is
+// validated. A field of the wrong type fails as the field, anything else as a malformed body.
+func Parse(c *fiber.Ctx, out any) error {
+	err := c.BodyParser(out)
+	if err == nil {
+		return nil
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apierror.Validation(apierror.FieldError{
+			Field:   typeErr.Field,
+			In:      InBody,
+			Message: "must be " + expected(typeErr.Type),
+		})
+	}
+	return apierror.Invalid("Invalid request body").WithDetails(err.Error())
+}
+
+// Validate checks out against its validate tags, with a FieldError for each field that fails
+func Validate(v *validator.Validate, out any) error {
+	err := v.Struct(out)
+	var failures validator.ValidationErrors
+	if !errors.As(err, &failures) {
+		return err
+	}
+	fields := make([]apierror.FieldError, len(failures))
+	for i, failure := range failures {
+		fields[i] = apierror.FieldError{Field: fieldPath(failure), In: InBody, Message: message(failure)}
+	}
+	return apierror.Validation(fields...)
+}
+
+// fieldPath is the failed field's path from the top of the body, like settings.max_file_size
+func fieldPath(failure validator.FieldError) string {
+	_, path, found := strings.Cut(failure.Namespace(), ".")
+	if !found {
+		return failure.Field()
+	}
+	return path
+}
+
+// message says what a field that failed its rule must be
+func message(failure validator.FieldError) string {
+	param := failure.Param()
+	switch failure.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required without " + param
+	case "min":
+		return bound("at least", failure)
+	case "max":
+		return bound("at most", failure)
+	case "len":
+		return bound("exactly", failure)
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "email":
+		return "must be an email address"
+	case "url":
+		return "must be a URL"
+	case "fqdn":
+		return "must be a domain name"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "numeric":
+		return "must be a number"
+	case "alphanum":
+		return "must only hold letters and digits"
+	case "hexadecimal":
+		return "must be hexadecimal"
+	case "excludesall":
+		return fmt.Sprintf("must not contain any of %q", param)
+	}
+	return "must satisfy " + failure.Tag()
+}
+
+// bound describes a min, max or len rule, which counts characters of strings and items of lists
+func bound(limit string, failure validator.FieldError) string {
+	switch failure.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", limit, failure.Param())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must have %s %s items", limit, failure.Param())
+	}
+	return fmt.Sprintf("must be %s %s", limit, failure.Param())
+}
+
+// expected names the JSON type a field of type t is sent as
+func expected(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return "of another type"
+}
@@ -0,0 +1,303 @@
+package cluster
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// leaderLockKey is the PostgreSQL advisory lock the leader holds for as long as it leads
+const leaderLockKey int64 = 0x73686275636b6574 // "shbucket"
+
+// fingerprintMessage is signed with each shared secret to compare secrets between servers
+const fingerprintMessage = "shbucket cluster secret fingerprint"
+
+// leaderService is background work that runs on the leader only
+type leaderService struct {
+	name  string
+	start func()
+	stop  func()
+}
+
+// Member joins this server to the masters sharing the database. It keeps the server's member
+// record heartbeating and competes for leadership with a PostgreSQL advisory lock. The lock is
+// held on a connection of its own, so a leader that stops or loses the database loses the lock
+// with its session and another server takes over on its next check.
+type Member struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	record    entities.ClusterMember
+	services  []leaderService
+
+	mu      sync.Mutex
+	conn    *sql.Conn // session holding the leader lock, nil unless leading
+	leading bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewMember creates a new instance of Member
+func NewMember(dbContext *persistence.AppDbContext) *Member {
+	settings := config.GetSettings()
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	return &Member{
+		dbContext: dbContext,
+		settings:  settings,
+		record: entities.ClusterMember{
+			Id:                         uuid.New(),
+			Hostname:                   hostname,
+			Profile:                    settings.Profile,
+			StartedAt:                  now,
+			HeartbeatAt:                now,
+			JWTSecretFingerprint:       fingerprint(settings.JWTSecret),
+			SignatureSecretFingerprint: fingerprint(settings.SignatureSecret),
+		},
+	}
+}
+
+// MemberTimeout is how long a member goes without a heartbeat before it no longer counts as running
+func MemberTimeout(settings *config.Settings) time.Duration {
+	return 3 * time.Duration(max(settings.LeaderCheckInterval, 1)) * time.Second
+}
+
+// Members lists the servers currently running, the leader first
+func Members(dbContext *persistence.AppDbContext) ([]entities.ClusterMember, error) {
+	return runningMembers(dbContext.GetDB(), time.Now().Add(-MemberTimeout(config.GetSettings())))
+}
+
+// runningMembers lists the members with a heartbeat after since, the leader first
+func runningMembers(db *gorm.DB, since time.Time) ([]entities.ClusterMember, error) {
+	var members []entities.ClusterMember
+	err := db.Where(`"HeartbeatAt" > ?`, since).
+		Order(`"Leader" DESC, "StartedAt"`).Find(&members).Error
+	return members, err
+}
+
+// ID identifies this server among the members
+func (m *Member) ID() uuid.UUID {
+	return m.record.Id
+}
+
+// IsLeader reports whether this server currently runs the leader's background work
+func (m *Member) IsLeader() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leading
+}
+
+// Lead registers background work that runs on one server at a time. The leader starts it in the
+// order registered and stops it in reverse order when it stops leading. Register before Start.
+func (m *Member) Lead(name string, start func(), stop func()) {
+	m.services = append(m.services, leaderService{name: name, start: start, stop: stop})
+}
+
+// CheckSecrets refuses to join servers using other JWT or signature secrets. Tokens and signed
+// URLs issued by one server would be rejected by the others behind the same load balancer.
+func (m *Member) CheckSecrets() error {
+	running, err := runningMembers(m.dbContext.GetDB().Where(`"Id" <> ?`, m.record.Id), time.Now().Add(-MemberTimeout(m.settings)))
+	if err != nil {
+		return fmt.Errorf("failed to list running servers: %w", err)
+	}
+
+	var secrets, hosts []string
+	for _, member := range running {
+		jwt := member.JWTSecretFingerprint != m.record.JWTSecretFingerprint
+		signature := member.SignatureSecretFingerprint != m.record.SignatureSecretFingerprint
+		if !jwt && !signature {
+			continue
+		}
+		hosts = append(hosts, member.Hostname)
+		if jwt && !slices.Contains(secrets, "JWT_SECRET") {
+			secrets = append(secrets, "JWT_SECRET")
+		}
+		if signature && !slices.Contains(secrets, "SIGNATURE_SECRET") {
+			secrets = append(secrets, "SIGNATURE_SECRET")
+		}
+	}
+	if len(hosts) > 0 {
+		return fmt.Errorf("%s differs from the running server(s) %s, every server sharing the database must use the same values",
+			strings.Join(secrets, " and "), strings.Join(hosts, ", "))
+	}
+	return nil
+}
+
+// Start records this server as a member, tries for leadership right away and then checks again
+// on every LEADER_CHECK_INTERVAL
+func (m *Member) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	m.check(ctx)
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(time.Duration(max(m.settings.LeaderCheckInterval, 1)) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(ctx)
+			}
+		}
+	}()
+
+	log.Printf("Joined the cluster as %s (%s)", m.record.Id, m.record.Hostname)
+}
+
+// Stop stops the leader's background work, gives up leadership so another server takes over
+// without waiting and removes this server's member record
+func (m *Member) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+
+	m.resign()
+	if err := m.dbContext.GetDB().Delete(&entities.ClusterMember{}, `"Id" = ?`, m.record.Id).Error; err != nil {
+		log.Printf("Warning: failed to remove cluster member record: %v", err)
+	}
+}
+
+// check confirms or tries for leadership, then heartbeats
+func (m *Member) check(ctx context.Context) {
+	m.mu.Lock()
+	conn, leading := m.conn, m.leading
+	m.mu.Unlock()
+
+	switch {
+	case conn != nil:
+		if err := conn.PingContext(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Warning: lost the connection holding cluster leadership: %v", err)
+			m.resign()
+		}
+	case !leading:
+		m.elect(ctx)
+	}
+
+	m.heartbeat()
+}
+
+// elect takes the leader lock when no other server holds it and starts the leader's work
+func (m *Member) elect(ctx context.Context) {
+	// A SQLite database belongs to one server, which leads without a lock
+	if m.dbContext.IsSQLite() {
+		m.lead(nil)
+		return
+	}
+
+	sqlDB, err := m.dbContext.GetDB().DB()
+	if err != nil {
+		return
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to connect for cluster leadership: %v", err)
+		return
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: failed to check cluster leadership: %v", err)
+		}
+		// Whether the lock was taken is unknown, so the session goes
+		discard(conn)
+		return
+	}
+	if !acquired {
+		conn.Close()
+		return
+	}
+	m.lead(conn)
+}
+
+// lead records this server as the leader, holding the leader lock on conn, and starts the
+// leader's work
+func (m *Member) lead(conn *sql.Conn) {
+	m.mu.Lock()
+	m.conn = conn
+	m.leading = true
+	m.mu.Unlock()
+
+	log.Printf("This server is now the cluster leader")
+	for _, service := range m.services {
+		log.Printf("Starting %s on the leader", service.name)
+		service.start()
+	}
+}
+
+// resign stops the leader's work and releases the leader lock by ending its session
+func (m *Member) resign() {
+	m.mu.Lock()
+	conn, leading := m.conn, m.leading
+	m.conn = nil
+	m.leading = false
+	m.mu.Unlock()
+
+	if !leading {
+		return
+	}
+	for i := len(m.services) - 1; i >= 0; i-- {
+		m.services[i].stop()
+	}
+	if conn != nil {
+		discard(conn)
+	}
+	log.Printf("This server is no longer the cluster leader")
+}
+
+// heartbeat saves this server's member record and removes records of servers long gone
+func (m *Member) heartbeat() {
+	now := time.Now()
+	m.record.HeartbeatAt = now
+	m.record.Leader = m.IsLeader()
+
+	db := m.dbContext.GetDB()
+	if err := db.Save(&m.record).Error; err != nil {
+		log.Printf("Warning: failed to record cluster heartbeat: %v", err)
+	}
+	removeGoneMembers(db, now.Add(-10*MemberTimeout(m.settings)))
+}
+
+// removeGoneMembers removes the records of members without a heartbeat since before
+func removeGoneMembers(db *gorm.DB, before time.Time) error {
+	return db.Where(`"HeartbeatAt" < ?`, before).Delete(&entities.ClusterMember{}).Error
+}
+
+// discard closes a connection's session instead of returning it to the pool, releasing any
+// advisory lock it holds
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
+
+// fingerprint identifies a secret without revealing it
+func fingerprint(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fingerprintMessage))
+	return hex.EncodeToString(mac.Sum(nil))
+}
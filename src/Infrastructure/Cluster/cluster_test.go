@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRunningMembers lists the members with a recent heartbeat, the leader first, and removes
+// those long gone
+func TestRunningMembers(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	for _, member := range []entities.ClusterMember{
+		{Id: uuid.New(), Hostname: "follower", StartedAt: now.Add(-2 * time.Hour), HeartbeatAt: now},
+		{Id: uuid.New(), Hostname: "leader", Leader: true, StartedAt: now.Add(-time.Hour), HeartbeatAt: now},
+		{Id: uuid.New(), Hostname: "gone", StartedAt: now.Add(-3 * time.Hour), HeartbeatAt: now.Add(-time.Hour)},
+	} {
+		if err := db.Create(&member).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	members, err := runningMembers(db, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("runningMembers() = %v", err)
+	}
+	if len(members) != 2 || members[0].Hostname != "leader" || members[1].Hostname != "follower" {
+		t.Errorf("runningMembers() = %+v, want the leader then the follower", members)
+	}
+
+	if err := removeGoneMembers(db, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("removeGoneMembers() = %v", err)
+	}
+	var left int64
+	if err := db.Model(&entities.ClusterMember{}).Count(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left != 2 {
+		t.Errorf("members left = %d, want 2", left)
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting's value came from
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	// SourceDerived is a setting left unset and worked out from others, like APP_URL from BASE_URL
+	SourceDerived = "derived"
+)
+
+var (
+	// fileValues are the settings of the config file, by environment variable
+	fileValues map[string]string
+	// fileKeysRead are the config file's settings something has read
+	fileKeysRead = map[string]bool{}
+	fileMu       sync.Mutex
+)
+
+// LoadFile reads settings from the YAML file at path. Its keys are the settings' environment
+// variables in either case, and a mapping prefixes the keys inside it, so
+//
+//	smtp:
+//	  host: mail.example.com
+//
+// sets SMTP_HOST. Lists are read as comma separated values. Environment variables override the
+// file. It must be called before GetSettings.
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var document map[string]any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten(values, "", document); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	fileValues = values
+	return nil
+}
+
+// flatten adds the settings of a mapping to values, with their keys under prefix
+func flatten(values map[string]string, prefix string, mapping map[string]any) error {
+	for key, value := range mapping {
+		key = prefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		switch value := value.(type) {
+		case map[string]any:
+			if err := flatten(values, key+"_", value); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				if !isScalar(item) {
+					return fmt.Errorf("%s must be a list of values", key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			if !isScalar(value) {
+				return fmt.Errorf("%s must be a value, a list or a mapping", key)
+			}
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// isScalar reports whether a decoded YAML value is a string, number or boolean
+func isScalar(value any) bool {
+	switch value.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return true
+	}
+	return false
+}
+
+// lookup returns the setting of an environment variable and where it came from. The environment
+// overrides the config file, and an empty value counts as unset.
+func lookup(key string) (string, string) {
+	if value := os.Getenv(key); value != "" {
+		return value, SourceEnv
+	}
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	value, ok := fileValues[key]
+	if ok {
+		fileKeysRead[key] = true
+	}
+	if value != "" {
+		return value, SourceFile
+	}
+	return "", SourceDefault
+}
+
+// Getenv returns the setting of an environment variable, or the config file's when the environment
+// doesn't set it, for the settings read outside of Settings
+func Getenv(key string) string {
+	value, _ := lookup(key)
+	return value
+}
+
+// UnknownFileKeys lists the config file's settings nothing has read, most likely misspelled.
+// Settings read late, like HOST, only count once they have been.
+func UnknownFileKeys() []string {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	var keys []string
+	for key := range fileValues {
+		if !fileKeysRead[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mask replaces the value of secrets when printing the effective settings
+const mask = "********"
+
+// setting is a setting's effective value, as NewSettings resolved it
+type setting struct {
+	key    string
+	value  string
+	source string
+}
+
+var (
+	// effective are the settings in the order NewSettings read them
+	effective []setting
+	// effectiveIndex finds a setting in effective by its environment variable
+	effectiveIndex = map[string]int{}
+)
+
+// record keeps the value a setting resolved to, replacing an earlier one for the same key
+func record(key, value, source string) {
+	if i, ok := effectiveIndex[key]; ok {
+		effective[i] = setting{key, value, source}
+		return
+	}
+	effectiveIndex[key] = len(effective)
+	effective = append(effective, setting{key, value, source})
+}
+
+// derive records the value worked out for a setting that nothing set
+func derive(key, value string) {
+	record(key, value, SourceDerived)
+}
+
+// Print writes the effective settings as a config file, each commented with where its value came
+// from. Secrets are masked, and so is the password of DATABASE_URL.
+func Print(w io.Writer) error {
+	GetSettings()
+
+	document := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range effective {
+		document.Content = append(document.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: strings.ToLower(s.key)},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: masked(s.key, s.value), LineComment: s.source},
+		)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// masked hides the value of a secret setting
+func masked(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if isSecret(key) {
+		return mask
+	}
+	if key == "DATABASE_URL" {
+		if parsed, err := url.Parse(value); err == nil {
+			return parsed.Redacted()
+		}
+	}
+	return value
+}
+
+// secretSuffixes end the environment variables of secrets
+var secretSuffixes = []string{"_SECRET", "_SECRET_KEY", "_SECRET_ACCESS_KEY", "_PASSWORD", "_TOKEN", "_AUTH_KEY", "_MASTER_KEY", "_MASTER_KEYS"}
+
+// isSecret reports whether a setting, by its environment variable, holds a secret
+func isSecret(key string) bool {
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
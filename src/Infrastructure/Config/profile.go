@@ -0,0 +1,101 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Deployment profiles, chosen with PROFILE
+const (
+	ProfileDev     = "dev"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// profileDefaults are the defaults a profile sets. Environment variables still override each of them.
+type profileDefaults struct {
+	debug bool
+	// corsAllowAnyOrigin lets any origin call the API, otherwise only the server's own origin can
+	corsAllowAnyOrigin  bool
+	allowDefaultSecrets bool
+	// consoleMail writes email to the log when no SMTP server is configured
+	consoleMail bool
+}
+
+var profiles = map[string]profileDefaults{
+	// Local development: verbose, callable from any dev server, runs without configuring secrets or email
+	ProfileDev: {debug: true, corsAllowAnyOrigin: true, allowDefaultSecrets: true, consoleMail: true},
+	// Like production, with debug logging
+	ProfileStaging: {debug: true},
+	ProfileProd:    {},
+}
+
+// defaultSecrets are the placeholder secrets of the built-in defaults and the example configuration
+var defaultSecrets = map[string]bool{
+	"your-jwt-secret-change-in-production":                     true,
+	"your-super-secret-jwt-key-here":                           true,
+	"your-signature-secret-change-in-production":               true,
+	"your-super-secret-jwt-key-change-this-in-production":      true,
+	"your-super-secret-jwt-key-here-change-this-in-production": true,
+	"your-signature-secret-change-this":                        true,
+	"your-signature-secret-change-this-in-production":          true,
+	"change-me": true,
+}
+
+// IsProfile reports whether name is a known profile
+func IsProfile(name string) bool {
+	_, ok := profiles[name]
+	return ok
+}
+
+// applyProfile fills in the settings the profile decides and the environment doesn't set
+func (s *Settings) applyProfile() {
+	defaults, ok := profiles[s.Profile]
+	if !ok {
+		// An unknown profile gets production's defaults, never the permissive ones
+		defaults = profiles[ProfileProd]
+	}
+
+	s.Debug = getEnvAsBool("DEBUG", defaults.debug)
+	s.AllowDefaultSecrets = getEnvAsBool("ALLOW_DEFAULT_SECRETS", defaults.allowDefaultSecrets)
+	if s.Profile == ProfileProd && s.AllowDefaultSecrets {
+		// Production never runs with placeholder secrets, whatever ALLOW_DEFAULT_SECRETS says
+		s.AllowDefaultSecrets = false
+		derive("ALLOW_DEFAULT_SECRETS", "false")
+	}
+
+	// Email goes out over SMTP once a server is configured, and to the log in development
+	if s.MailTransport == "" {
+		if s.SMTPHost != "" {
+			s.MailTransport = "smtp"
+		} else if defaults.consoleMail {
+			s.MailTransport = "console"
+		}
+		if s.MailTransport != "" {
+			derive("MAIL_TRANSPORT", s.MailTransport)
+		}
+	}
+
+	if value, _ := lookup("CORS_ALLOW_ORIGINS"); value == "" {
+		if defaults.corsAllowAnyOrigin {
+			s.CORSAllowOrigins = "*"
+		} else if base, err := url.Parse(s.BaseURL); err == nil && base.Scheme != "" && base.Host != "" {
+			s.CORSAllowOrigins = base.Scheme + "://" + base.Host
+		}
+		derive("CORS_ALLOW_ORIGINS", s.CORSAllowOrigins)
+	}
+}
+
+// DefaultSecrets lists the secrets, by environment variable, still set to a placeholder value
+func (s *Settings) DefaultSecrets() []string {
+	var names []string
+	for _, secret := range []struct{ name, value string }{
+		{"JWT_SECRET", s.JWTSecret},
+		{"SIGNATURE_SECRET", s.SignatureSecret},
+	} {
+		if strings.TrimSpace(secret.value) == "" || defaultSecrets[secret.value] {
+			names = append(names, secret.name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"sync"
+)
+
+// RuntimeSettings holds the settings that can be changed while the server is running.
+// They start from the environment and are replaced by values stored through the admin settings API.
+type RuntimeSettings struct {
+	// CORS
+	CORSAllowOrigins     string `json:"cors_allow_origins"`
+	CORSAllowMethods     string `json:"cors_allow_methods"`
+	CORSAllowHeaders     string `json:"cors_allow_headers"`
+	CORSExposeHeaders    string `json:"cors_expose_headers"`
+	CORSAllowCredentials bool   `json:"cors_allow_credentials"`
+	CORSMaxAge           int    `json:"cors_max_age"`
+
+	// Rate limit
+	RateLimitRequests int `json:"rate_limit_requests"`
+	RateLimitWindow   int `json:"rate_limit_window"`
+
+	// Default bucket
+	DefaultBucketMaxFileSize  int64 `json:"default_bucket_max_file_size"`
+	DefaultBucketMaxTotalSize int64 `json:"default_bucket_max_total_size"`
+	DefaultBucketMaxFiles     int64 `json:"default_bucket_max_files"`
+
+	// Image transforms
+	ImageTransformsEnabled  bool   `json:"image_transforms_enabled"`
+	ImageMaxInputDimension  int    `json:"image_max_input_dimension"`
+	ImageMaxInputMegapixels int    `json:"image_max_input_megapixels"`
+	ImageMaxOutputDimension int    `json:"image_max_output_dimension"`
+	ImageAllowedFormats     string `json:"image_allowed_formats"`
+
+	// Two-factor authentication
+	TwoFactorRequiredRoles string `json:"two_factor_required_roles"` // comma-separated roles
+}
+
+var (
+	runtimeMutex    sync.RWMutex
+	runtimeSettings *RuntimeSettings
+	runtimeVersion  uint64
+)
+
+// GetRuntimeSettings returns a copy of the current runtime settings
+func GetRuntimeSettings() RuntimeSettings {
+	runtimeMutex.RLock()
+	if runtimeSettings != nil {
+		defer runtimeMutex.RUnlock()
+		return *runtimeSettings
+	}
+	runtimeMutex.RUnlock()
+
+	runtimeMutex.Lock()
+	defer runtimeMutex.Unlock()
+	if runtimeSettings == nil {
+		initial := runtimeSettingsFromEnvironment(GetSettings())
+		runtimeSettings = &initial
+	}
+	return *runtimeSettings
+}
+
+// SetRuntimeSettings replaces the runtime settings; middleware picks them up on the next request
+func SetRuntimeSettings(settings RuntimeSettings) {
+	runtimeMutex.Lock()
+	defer runtimeMutex.Unlock()
+	runtimeSettings = &settings
+	runtimeVersion++
+}
+
+// RuntimeVersion changes every time the runtime settings are replaced
+func RuntimeVersion() uint64 {
+	runtimeMutex.RLock()
+	defer runtimeMutex.RUnlock()
+	return runtimeVersion
+}
+
+// EnvironmentRuntimeSettings returns the runtime settings as configured by environment variables
+func EnvironmentRuntimeSettings() RuntimeSettings {
+	return runtimeSettingsFromEnvironment(GetSettings())
+}
+
+func runtimeSettingsFromEnvironment(settings *Settings) RuntimeSettings {
+	return RuntimeSettings{
+		CORSAllowOrigins:          settings.CORSAllowOrigins,
+		CORSAllowMethods:          settings.CORSAllowMethods,
+		CORSAllowHeaders:          settings.CORSAllowHeaders,
+		CORSExposeHeaders:         settings.CORSExposeHeaders,
+		CORSAllowCredentials:      settings.CORSAllowCredentials,
+		CORSMaxAge:                settings.CORSMaxAge,
+		RateLimitRequests:         settings.RateLimitRequests,
+		RateLimitWindow:           settings.RateLimitWindow,
+		DefaultBucketMaxFileSize:  settings.DefaultBucketMaxFileSize,
+		DefaultBucketMaxTotalSize: settings.DefaultBucketMaxTotalSize,
+		DefaultBucketMaxFiles:     settings.DefaultBucketMaxFiles,
+		ImageTransformsEnabled:    settings.ImageTransformsEnabled,
+		ImageMaxInputDimension:    settings.ImageMaxInputDimension,
+		ImageMaxInputMegapixels:   settings.ImageMaxInputMegapixels,
+		ImageMaxOutputDimension:   settings.ImageMaxOutputDimension,
+		ImageAllowedFormats:       settings.ImageAllowedFormats,
+		TwoFactorRequiredRoles:    settings.TwoFactorRequiredRoles,
+	}
+}
@@ -14,6 +14,9 @@ type APIKey struct {
 	UserId      uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
 	IsActive    bool           `gorm:"not null;default:true" json:"is_active"`
 	Permissions datatypes.JSON `gorm:"type:jsonb" json:"permissions"`
+	Role        string         `json:"role,omitempty"` // limits the key to a role below its owner's, empty for the owner's
+	EgressQuota      int64     `gorm:"not null;default:0" json:"egress_quota"`       // bytes downloaded with the key per billing cycle, 0 for no quota
+	EgressDailyQuota int64     `gorm:"not null;default:0" json:"egress_daily_quota"` // bytes downloaded with the key per day (UTC), 0 for no quota
 	ExpiresAt   *time.Time     `json:"expires_at"`
 	LastUsed    *time.Time     `json:"last_used"`
 	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -24,9 +27,11 @@ type APIKey struct {
 }
 
 type APIKeyPermission struct {
-	Read     bool     `json:"read"`
-	Write    bool     `json:"write"`
-	SignURLs bool     `json:"sign_urls"`  
-	Buckets  []string `json:"buckets,omitempty"` 
+	Read       bool     `json:"read"`
+	Write      bool     `json:"write"`
+	SignURLs   bool     `json:"sign_urls"`  
+	Buckets    []string `json:"buckets,omitempty"` 
+	AllowedIPs []string `json:"allowed_ips,omitempty"` // CIDR ranges the key may be used from, empty for anywhere
+	DeniedIPs  []string `json:"denied_ips,omitempty"`  // CIDR ranges the key may never be used from
 }
 
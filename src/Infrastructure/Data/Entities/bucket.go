@@ -34,16 +34,43 @@ type AuthRule struct {
 type BucketSettings struct {
 	MaxFileSize         int64    `gorm:"not null;default:0" json:"max_file_size"`
 	MaxTotalSize        int64    `gorm:"not null;default:0" json:"max_total_size"`
-	AllowedMimeTypes    []string `gorm:"type:text[]" json:"allowed_mime_types"`
-	BlockedMimeTypes    []string `gorm:"type:text[]" json:"blocked_mime_types"`
-	AllowedExtensions   []string `gorm:"type:text[]" json:"allowed_extensions"`
-	BlockedExtensions   []string `gorm:"type:text[]" json:"blocked_extensions"`
+	AllowedMimeTypes    StringArray `gorm:"type:text[]" json:"allowed_mime_types"`
+	BlockedMimeTypes    StringArray `gorm:"type:text[]" json:"blocked_mime_types"`
+	AllowedExtensions   StringArray `gorm:"type:text[]" json:"allowed_extensions"`
+	BlockedExtensions   StringArray `gorm:"type:text[]" json:"blocked_extensions"`
 	MaxFilesPerBucket   int64    `gorm:"not null;default:0" json:"max_files_per_bucket"`
 	PublicRead          bool     `gorm:"not null;default:false" json:"public_read"`
 	Versioning          bool     `gorm:"not null;default:false" json:"versioning"`
 	Encryption          bool     `gorm:"not null;default:false" json:"encryption"`
 	AllowOverwrite      bool     `gorm:"not null;default:true" json:"allow_overwrite"`
+	KeyedObjects        bool     `gorm:"not null;default:false" json:"keyed_objects"` // a name is one object: uploads of it overwrite it, or fail without allow_overwrite
 	RequireContentType  bool     `gorm:"not null;default:false" json:"require_content_type"`
+	VideoProcessing     bool     `gorm:"not null;default:false" json:"video_processing"` // generate thumbnails and HLS renditions for uploaded videos
+	CORSRules           datatypes.JSON `gorm:"type:jsonb" json:"cors_rules"`                // []models.CORSRuleResponse enforced on file-serving routes
+	MaxVersions         int      `gorm:"not null;default:0" json:"max_versions"`          // noncurrent versions kept per object when versioning, 0 keeps all
+	VersionRetentionDays int     `gorm:"not null;default:0" json:"version_retention_days"` // days a version is kept after being replaced, 0 keeps forever
+	DisableImageTransforms bool  `gorm:"not null;default:false" json:"disable_image_transforms"` // serve images only as stored, rejecting resize and format parameters
+	PlacementNodeId     *uuid.UUID `gorm:"type:uuid" json:"placement_node_id"`              // storage node holding all content, nil for no pin
+	PlacementGroup      string   `gorm:"not null;default:''" json:"placement_group"`        // node group holding all content, empty for no pin
+	PlacementZone       string   `gorm:"not null;default:''" json:"placement_zone"`         // zone holding all content, empty for no pin
+	MinZones            int      `gorm:"not null;default:0" json:"min_zones"`               // zones a file's healthy copies must span, 0 or 1 for no requirement
+	WebsiteEnabled      bool     `gorm:"not null;default:false" json:"website_enabled"`     // serve the bucket as a static site under /site/{name}/
+	WebsiteIndexDocument string  `gorm:"not null;default:'index.html'" json:"website_index_document"` // served for the site root and folder paths
+	WebsiteErrorDocument string  `gorm:"not null;default:''" json:"website_error_document"` // served with 404 for missing paths, empty for a plain 404
+	WebsiteDomain       string   `gorm:"not null;default:'';index" json:"website_domain"`   // host name serving the site at its root, empty for none
+	CustomDomain        string   `gorm:"not null;default:'';index" json:"custom_domain"`    // host name serving files by name at its root, empty for none
+	EgressQuota         int64    `gorm:"not null;default:0" json:"egress_quota"`            // bytes served per billing cycle, 0 for no quota
+	EgressDailyQuota    int64    `gorm:"not null;default:0" json:"egress_daily_quota"`      // bytes served per day (UTC), 0 for no quota
+	EgressQuotaPolicy   string   `gorm:"not null;default:''" json:"egress_quota_policy"`    // "throttle" or "block" past the quota, empty for the server default
+	Compression         string   `gorm:"not null;default:''" json:"compression"`            // "gzip" or "zstd" for text-like content stored from now on, empty for none
+	DefaultHeaders      datatypes.JSON `gorm:"type:jsonb" json:"default_headers"`          // response headers served with every file that doesn't set its own
+	ObjectLock          bool     `gorm:"not null;default:false" json:"object_lock"`        // files can be locked against deletion, can't be turned off again
+	DefaultRetentionDays int     `gorm:"not null;default:0" json:"default_retention_days"` // days new uploads are locked for with object lock, 0 for no default
+	PublicReadAllowedIPs datatypes.JSON `gorm:"type:jsonb" json:"public_read_allowed_ips"` // CIDR ranges public reads are served to, empty for anywhere
+	PublicReadDeniedIPs datatypes.JSON `gorm:"type:jsonb" json:"public_read_denied_ips"`   // CIDR ranges public reads are refused to
+	HotlinkReferers     datatypes.JSON `gorm:"type:jsonb" json:"hotlink_referers"`        // host patterns of pages public files may be embedded in, empty for any
+	HotlinkBlockEmpty   bool     `gorm:"not null;default:false" json:"hotlink_block_empty"` // refuse requests without a Referer or Origin when hotlink protection is on
+	HotlinkPlaceholderId *uuid.UUID `gorm:"type:uuid" json:"hotlink_placeholder_id"`          // file of the bucket served to refused referers instead of a 403
 }
 
 // BeforeCreate is a GORM hook that runs before creating a Bucket record
@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BucketAdminGrant lets a user who doesn't own a bucket manage it like its owner: its settings,
+// bucket admins, scoped API keys, snapshots and keys. Deleting the bucket stays with the owner.
+type BucketAdminGrant struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bucket_admin_grants_bucket_user" json:"bucket_id"`
+	UserId    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bucket_admin_grants_bucket_user;index" json:"user_id"`
+	GrantedBy uuid.UUID `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketAdminGrant record
+func (g *BucketAdminGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BucketDeletionJob tracks a forced bucket deletion, which removes every file of the bucket
+// before the bucket itself. The job outlives the bucket so its outcome can still be read.
+type BucketDeletionJob struct {
+	Id           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId     uuid.UUID  `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	BucketName   string     `gorm:"not null" json:"bucket_name"`
+	JobId        *uuid.UUID `gorm:"type:uuid;index" json:"job_id,omitempty"` // background job running the deletion
+	Status       string     `gorm:"not null" json:"status"` // running, completed, failed
+	TotalFiles   int64      `gorm:"not null;default:0" json:"total_files"`
+	DeletedFiles int64      `gorm:"not null;default:0" json:"deleted_files"`
+	FailedFiles  int64      `gorm:"not null;default:0" json:"failed_files"`
+	DeletedBytes int64      `gorm:"not null;default:0" json:"deleted_bytes"`
+	Error        string     `gorm:"type:text" json:"error"`
+	StartedBy    uuid.UUID  `gorm:"type:uuid;not null" json:"started_by"`
+	StartedAt    time.Time  `gorm:"not null" json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketDeletionJob record
+func (j *BucketDeletionJob) BeforeCreate(tx *gorm.DB) error {
+	if j.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
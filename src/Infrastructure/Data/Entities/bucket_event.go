@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// BucketEvent is an entry in a bucket's event log, kept so that downstream consumers can be backfilled by replay
+type BucketEvent struct {
+	Id        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId  uuid.UUID      `gorm:"type:uuid;not null;index:idx_bucket_events_bucket_created" json:"bucket_id"`
+	Type      string         `gorm:"not null;index" json:"type"` // e.g. "file.uploaded", "file.deleted", "bucket.updated"
+	FileId    *uuid.UUID     `gorm:"type:uuid;index" json:"file_id,omitempty"`
+	ActorId   uuid.UUID      `gorm:"type:uuid" json:"actor_id"`
+	Data      datatypes.JSON `gorm:"type:jsonb" json:"data"`
+	CreatedAt time.Time      `gorm:"not null;index:idx_bucket_events_bucket_created" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketEvent record
+func (e *BucketEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BucketFolder is a folder created explicitly in a bucket, e.g. through WebDAV. Folders otherwise
+// only exist through the "/"-separated names of the files in them; a record keeps one that is empty.
+// Path has no leading or trailing slash.
+type BucketFolder struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bucket_folder_path" json:"bucket_id"`
+	Path      string    `gorm:"not null;uniqueIndex:idx_bucket_folder_path" json:"path"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketFolder record
+func (f *BucketFolder) BeforeCreate(tx *gorm.DB) error {
+	if f.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BucketKey is a version of a bucket's encryption key, stored wrapped by the master key or a KMS.
+// Each bucket has at most one active key; retired keys stay available to unwrap older data keys.
+type BucketKey struct {
+	Id         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_bucket_keys_bucket_version" json:"bucket_id"`
+	Version    int        `gorm:"not null;uniqueIndex:idx_bucket_keys_bucket_version" json:"version"`
+	WrappedKey []byte     `gorm:"type:bytea;not null" json:"-"`
+	Provider   string     `gorm:"not null;default:'local'" json:"provider"` // key provider that wrapped it
+	Status     string     `gorm:"not null;default:'active'" json:"status"` // active, retired
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketKey record
+func (k *BucketKey) BeforeCreate(tx *gorm.DB) error {
+	if k.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
+
+// KeyRotationJob tracks re-wrapping a bucket's data keys under a new bucket key
+type KeyRotationJob struct {
+	Id           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId     uuid.UUID  `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	KeyId        uuid.UUID  `gorm:"type:uuid;not null" json:"key_id"`
+	KeyVersion   int        `gorm:"not null" json:"key_version"`
+	Mode         string     `gorm:"not null" json:"mode"`   // eager, lazy
+	Status       string     `gorm:"not null" json:"status"` // running, completed, failed
+	TotalKeys    int64      `gorm:"not null;default:0" json:"total_keys"`
+	RewrappedKeys int64     `gorm:"not null;default:0" json:"rewrapped_keys"`
+	Error        string     `gorm:"type:text" json:"error"`
+	StartedBy    uuid.UUID  `gorm:"type:uuid;not null" json:"started_by"`
+	StartedAt    time.Time  `gorm:"not null" json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a KeyRotationJob record
+func (j *KeyRotationJob) BeforeCreate(tx *gorm.DB) error {
+	if j.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
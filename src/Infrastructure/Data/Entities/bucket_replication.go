@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BucketReplication pushes the files of a local bucket to a bucket of another SHBucket installation,
+// asynchronously, by following the local bucket's changes feed
+type BucketReplication struct {
+	Id               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"bucket_id"` // local bucket replicated
+	RemoteURL        string     `gorm:"not null" json:"remote_url"`
+	RemoteBucketId   uuid.UUID  `gorm:"type:uuid;not null" json:"remote_bucket_id"`
+	APIKey           string     `gorm:"not null;serializer:secret" json:"-"`                 // write key for the remote installation
+	ConflictPolicy   string     `gorm:"not null;default:'overwrite'" json:"conflict_policy"` // "overwrite", "skip" or "newer"
+	ReplicateDeletes bool       `gorm:"not null;default:false" json:"replicate_deletes"`
+	Cursor           string     `gorm:"not null;default:''" json:"-"`             // position in the local changes feed, empty before the first pass
+	Status           string     `gorm:"not null;default:'pending'" json:"status"` // "pending", "replicating", "replicated" or "failed"
+	LastError        string     `gorm:"not null;default:''" json:"last_error"`
+	FilesPushed      int64      `gorm:"not null;default:0" json:"files_pushed"`
+	FilesDeleted     int64      `gorm:"not null;default:0" json:"files_deleted"`
+	FilesSkipped     int64      `gorm:"not null;default:0" json:"files_skipped"`
+	Conflicts        int64      `gorm:"not null;default:0" json:"conflicts"`
+	BytesPushed      int64      `gorm:"not null;default:0" json:"bytes_pushed"`
+	LastRunAt        *time.Time `json:"last_run_at,omitempty"`
+	LastReplicatedAt *time.Time `json:"last_replicated_at,omitempty"` // end of the last pass that caught up with the bucket
+	CreatedBy        uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketReplication record
+func (r *BucketReplication) BeforeCreate(tx *gorm.DB) error {
+	if r.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
+
+// ReplicatedFile records the remote file a replication last pushed for a name, so files written on
+// the remote by anyone else are told apart as conflicts
+type ReplicatedFile struct {
+	Id            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReplicationId uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_replicated_files_name,priority:1" json:"replication_id"`
+	Name          string    `gorm:"not null;uniqueIndex:idx_replicated_files_name,priority:2" json:"name"`
+	LocalFileId   uuid.UUID `gorm:"type:uuid;not null" json:"local_file_id"`
+	RemoteFileId  uuid.UUID `gorm:"type:uuid;not null" json:"remote_file_id"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a ReplicatedFile record
+func (f *ReplicatedFile) BeforeCreate(tx *gorm.DB) error {
+	if f.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// BucketSnapshot is a named, immutable point-in-time view of a bucket's objects
+type BucketSnapshot struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bucket_snapshots_bucket_name" json:"bucket_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_bucket_snapshots_bucket_name" json:"name"`
+	FileCount int64     `gorm:"not null;default:0" json:"file_count"`
+	TotalSize int64     `gorm:"not null;default:0" json:"total_size"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketSnapshot record
+func (s *BucketSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
+
+// SnapshotFile is a file as it was when its snapshot was taken.
+// Path points at content preserved for the snapshot: a hard link for local files, the shared node path otherwise.
+type SnapshotFile struct {
+	Id            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SnapshotId    uuid.UUID `gorm:"type:uuid;not null;index" json:"snapshot_id"`
+	FileId        uuid.UUID `gorm:"type:uuid;not null" json:"file_id"`
+	Name          string    `gorm:"not null" json:"name"`
+	Path          string    `gorm:"not null;index" json:"path"`
+	Size          int64     `gorm:"not null" json:"size"`
+	MimeType      string    `json:"mime_type"`
+	Checksum      string    `json:"checksum"`
+	FileCreatedAt time.Time `json:"file_created_at"`
+	ContentEncoding string  `gorm:"not null;default:''" json:"content_encoding"` // compression of the preserved content, as for the file
+	Encryption    FileEncryption `gorm:"embedded;embeddedPrefix:encryption_" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a SnapshotFile record
+func (f *SnapshotFile) BeforeCreate(tx *gorm.DB) error {
+	if f.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
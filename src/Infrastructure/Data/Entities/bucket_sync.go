@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BucketSync mirrors a bucket of another SHBucket installation, or another bucket of this one, into
+// a local bucket, one way, by following the source bucket's changes feed on a schedule
+type BucketSync struct {
+	Id              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"bucket_id"` // local bucket kept as a mirror
+	RemoteURL       string     `gorm:"not null" json:"remote_url"` // empty when the source is a bucket of this installation
+	RemoteBucketId  uuid.UUID  `gorm:"type:uuid;not null" json:"remote_bucket_id"`
+	APIKey          string     `gorm:"not null;serializer:secret" json:"-"` // read key for the remote installation
+	SourceBucketId  *uuid.UUID `gorm:"type:uuid;index" json:"source_bucket_id,omitempty"` // bucket of this installation mirrored instead of a remote one
+	Prefix          string     `gorm:"not null;default:''" json:"prefix"` // only names starting with it are synced
+	Tag             string     `gorm:"not null;default:''" json:"tag"`    // only files whose custom metadata has this key=value are copied
+	IntervalSeconds int        `gorm:"not null;default:300" json:"interval_seconds"`
+	OnDemand        bool       `gorm:"not null;default:false" json:"on_demand"` // only runs when asked to, not every interval
+	Cursor          string     `gorm:"not null;default:''" json:"-"` // position in the remote changes feed, empty before the first pass
+	Status          string     `gorm:"not null;default:'pending'" json:"status"` // "pending", "syncing", "synced" or "failed"
+	LastError       string     `gorm:"not null;default:''" json:"last_error"`
+	FilesCopied     int64      `gorm:"not null;default:0" json:"files_copied"`
+	FilesDeleted    int64      `gorm:"not null;default:0" json:"files_deleted"`
+	FilesSkipped    int64      `gorm:"not null;default:0" json:"files_skipped"`
+	BytesCopied     int64      `gorm:"not null;default:0" json:"bytes_copied"`
+	NextRunAt       time.Time  `gorm:"not null;index" json:"next_run_at"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"` // end of the last pass that caught up with the remote
+	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketSync record
+func (s *BucketSync) BeforeCreate(tx *gorm.DB) error {
+	if s.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
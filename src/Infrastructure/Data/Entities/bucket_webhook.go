@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// BucketWebhook is an endpoint receiving a bucket's events as they are published. Deliveries are
+// signed with the endpoint's own secret, which is kept to sign with and only shown when issued.
+type BucketWebhook struct {
+	Id           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId     uuid.UUID      `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	URL          string         `gorm:"not null" json:"url"`
+	Secret       string         `gorm:"not null;serializer:secret" json:"-"`
+	SecretPrefix string         `gorm:"not null" json:"secret_prefix"`
+	EventTypes   datatypes.JSON `gorm:"type:jsonb" json:"event_types"` // []string, empty for every event
+	CreatedBy    uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a BucketWebhook record
+func (w *BucketWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+// ClusterMember is a master server sharing the database. Each server keeps its own record
+// heartbeating while it runs and removes it when it stops.
+type ClusterMember struct {
+	Id          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"` // chosen by the server when it starts
+	Hostname    string    `gorm:"not null" json:"hostname"`
+	Profile     string    `gorm:"not null;default:''" json:"profile"`
+	Leader      bool      `gorm:"not null;default:false" json:"leader"`
+	StartedAt   time.Time `gorm:"not null" json:"started_at"`
+	HeartbeatAt time.Time `gorm:"not null;index" json:"heartbeat_at"`
+	// Fingerprints of the secrets every master must share, HMACs of a fixed message and never the secrets
+	JWTSecretFingerprint       string `gorm:"not null" json:"-"`
+	SignatureSecretFingerprint string `gorm:"not null" json:"-"`
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DownloadCount counts the downloads begun on all servers in one hour, for the admin statistics.
+// Rows are kept as history.
+type DownloadCount struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Hour      time.Time `gorm:"not null;uniqueIndex" json:"hour"`
+	Downloads int64     `gorm:"not null;default:0" json:"downloads"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a DownloadCount record
+func (d *DownloadCount) BeforeCreate(tx *gorm.DB) error {
+	if d.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EgressUsage counts the bytes served for a bucket, for all buckets of a user, or with an API key,
+// in one billing cycle or, for the daily scopes, one day. Rows of past cycles are kept as history.
+type EgressUsage struct {
+	Id         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Scope      string    `gorm:"not null;uniqueIndex:idx_egress_usages_scope_cycle" json:"scope"` // "bucket", "user", "api_key", "bucket_daily" or "api_key_daily"
+	ScopeId    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_egress_usages_scope_cycle" json:"scope_id"`
+	CycleStart time.Time `gorm:"not null;uniqueIndex:idx_egress_usages_scope_cycle" json:"cycle_start"`
+	Bytes      int64     `gorm:"not null;default:0" json:"bytes"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating an EgressUsage record
+func (u *EgressUsage) BeforeCreate(tx *gorm.DB) error {
+	if u.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FavoriteFile is a file a user has pinned for quick access
+type FavoriteFile struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserId    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_favorite_files_user_file" json:"user_id"`
+	FileId    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_favorite_files_user_file;index" json:"file_id"`
+	BucketId  uuid.UUID `gorm:"type:uuid;not null" json:"bucket_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a FavoriteFile record
+func (f *FavoriteFile) BeforeCreate(tx *gorm.DB) error {
+	if f.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
@@ -10,17 +11,19 @@ import (
 // File represents the file entity in the database
 type File struct {
 	Id             uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid();column:Id" json:"id"`
-	BucketId       uuid.UUID    `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	BucketId       uuid.UUID    `gorm:"type:uuid;not null;index;index:idx_files_bucket_size,priority:1;index:idx_files_bucket_name,priority:1" json:"bucket_id"`
 	Bucket         Bucket       `gorm:"foreignKey:BucketId" json:"bucket,omitempty"`
-	Name           string       `gorm:"not null" json:"name"`
+	Name           string       `gorm:"not null;index:idx_files_bucket_name,priority:2" json:"name"` // indexed with the bucket for lookups by name
 	OriginalName   string       `gorm:"not null" json:"original_name"`
 	Path           string       `gorm:"not null" json:"path"`
-	Size           int64        `gorm:"not null" json:"size"`
+	Size           int64        `gorm:"not null;index:idx_files_bucket_size,priority:2" json:"size"` // indexed with the bucket so bucket totals are index-only
 	MimeType       string       `gorm:"not null" json:"mime_type"`
 	Checksum       string       `gorm:"not null" json:"checksum"`
 	Version        int          `gorm:"not null;default:1" json:"version"`
 	AuthRule       AuthRule     `gorm:"embedded;embeddedPrefix:auth_" json:"auth_rule"`
 	Metadata       FileMetadata `gorm:"embedded;embeddedPrefix:metadata_" json:"metadata"`
+	Encryption     FileEncryption `gorm:"embedded;embeddedPrefix:encryption_" json:"-"`
+	Lock           FileLock     `gorm:"embedded;embeddedPrefix:lock_" json:"lock"`
 	UploadedBy     uuid.UUID    `gorm:"type:uuid;not null;index" json:"uploaded_by"`
 	CreatedAt      time.Time    `gorm:"autoCreateTime" json:"created_at"`
 	SecuredUrl     string 		`gorm:"not null" json:"secured_url"`
@@ -34,7 +37,57 @@ type FileMetadata struct {
 	ContentEncoding    string                 `json:"content_encoding"`
 	ContentDisposition string                 `json:"content_disposition"`
 	CacheControl       string                 `json:"cache_control"`
+	Headers            datatypes.JSON `gorm:"type:jsonb" json:"headers"` // response headers served with the file, by name
 	CustomMetadata     datatypes.JSON `gorm:"type:jsonb" json:"custom_metadata"`
+	ScanStatus         string     `json:"scan_status"` // clean, infected or error, empty when uploads weren't scanned
+	ScanSignature      string     `json:"scan_signature"`
+	ScannedAt          *time.Time `json:"scanned_at"`
+}
+
+// Quarantined reports whether the content was found infected and is kept but never served
+func (m FileMetadata) Quarantined() bool {
+	return m.ScanStatus == "infected"
+}
+
+// FileEncryption holds the data key a file's content is encrypted with, wrapped by a bucket key.
+// Content encrypted with a customer-provided key has no stored key, only the key's MD5.
+// Both are empty for content stored in plaintext.
+type FileEncryption struct {
+	KeyId          *uuid.UUID `gorm:"type:uuid;index" json:"key_id"`
+	WrappedKey     []byte     `gorm:"type:bytea" json:"-"`
+	CustomerKeyMD5 string     `json:"customer_key_md5,omitempty"`
+}
+
+// Encrypted reports whether the content is stored encrypted under a bucket key
+func (e FileEncryption) Encrypted() bool {
+	return e.KeyId != nil
+}
+
+// CustomerEncrypted reports whether the content is stored encrypted under a customer-provided key
+func (e FileEncryption) CustomerEncrypted() bool {
+	return e.CustomerKeyMD5 != ""
+}
+
+// FileLock keeps a file version in a bucket with object lock from being deleted, overwritten or
+// renamed: until a retention date, and for as long as a legal hold is placed on it
+type FileLock struct {
+	RetainUntil *time.Time `gorm:"index" json:"retain_until"`
+	LegalHold   bool       `gorm:"not null;default:false" json:"legal_hold"`
+}
+
+// Active reports whether the lock holds the file at now
+func (l FileLock) Active(now time.Time) bool {
+	return l.LegalHold || (l.RetainUntil != nil && l.RetainUntil.After(now))
+}
+
+// ETag is the strong validator of the file's content, the same for file serving, websites and
+// WebDAV: its checksum and version, or its ID and size for content stored on a node, which has no
+// checksum on the master
+func (f *File) ETag() string {
+	if f.Checksum != "" && f.Checksum != "stored-on-node" {
+		return fmt.Sprintf("\"%s-%d\"", f.Checksum, f.Version)
+	}
+	return fmt.Sprintf("\"%s-%d\"", f.Id.String(), f.Size)
 }
 
 // BeforeCreate is a GORM hook that runs before creating a File record
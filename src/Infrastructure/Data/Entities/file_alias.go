@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FileAlias is a stable name within a bucket pointing at one of its files, e.g. "latest". It is
+// re-pointed at another file in place, so consumers fetch it by name without tracking file IDs.
+type FileAlias struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_file_alias_bucket_name" json:"bucket_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_file_alias_bucket_name" json:"name"`
+	FileId    uuid.UUID `gorm:"type:uuid;not null;index" json:"file_id"`
+	UpdatedBy uuid.UUID `gorm:"type:uuid;not null" json:"updated_by"` // who last pointed the alias
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a FileAlias record
+func (a *FileAlias) BeforeCreate(tx *gorm.DB) error {
+	if a.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
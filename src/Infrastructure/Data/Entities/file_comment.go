@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// FileComment is a note attached to a file by someone reviewing it
+type FileComment struct {
+	Id        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FileId    uuid.UUID      `gorm:"type:uuid;not null;index" json:"file_id"`
+	BucketId  uuid.UUID      `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	AuthorId  uuid.UUID      `gorm:"type:uuid;not null" json:"author_id"`
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	Mentions  datatypes.JSON `gorm:"type:jsonb" json:"mentions"` // IDs of mentioned users
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a FileComment record
+func (c *FileComment) BeforeCreate(tx *gorm.DB) error {
+	if c.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FileToken is a read-only capability for exactly one file. Unlike a signed URL it never expires,
+// it stays valid until revoked or until the file is deleted. Only the token's hash is stored.
+type FileToken struct {
+	Id          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FileId      uuid.UUID  `gorm:"type:uuid;not null;index" json:"file_id"`
+	BucketId    uuid.UUID  `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	Name        string     `json:"name"`
+	TokenHash   string     `gorm:"not null;uniqueIndex" json:"-"`
+	TokenPrefix string     `gorm:"not null" json:"token_prefix"`
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a FileToken record
+func (t *FileToken) BeforeCreate(tx *gorm.DB) error {
+	if t.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey remembers an upload sent with an Idempotency-Key header, so a retry with the
+// same key gets the original response instead of creating another file. The record is claimed
+// before the upload runs, so a retry sent while it's still running is refused rather than run twice.
+type IdempotencyKey struct {
+	Id          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Scope       string    `gorm:"not null;uniqueIndex:idx_idempotency_keys_scope_key" json:"scope"` // the user who sent the key, or the upload link it was sent to
+	Key         string    `gorm:"not null;uniqueIndex:idx_idempotency_keys_scope_key" json:"key"`
+	Request     string    `gorm:"not null" json:"request"`               // "METHOD /path" the key was first sent with
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"` // 0 while the upload is running
+	ContentType string    `json:"content_type"`
+	Response    []byte    `json:"-"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"` // a running upload's claim lapses at this time too
+}
+
+// BeforeCreate is a GORM hook that runs before creating an IdempotencyKey record
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
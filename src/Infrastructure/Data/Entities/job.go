@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Job is a unit of long-running work queued for the background job runner.
+// Failed attempts are retried with backoff until MaxAttempts is reached.
+type Job struct {
+	Id          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type        string         `gorm:"not null;index" json:"type"`                                   // e.g. "bucket.delete"
+	Status      string         `gorm:"not null;index:idx_jobs_status_run_after" json:"status"`       // queued, running, completed, failed
+	Payload     datatypes.JSON `gorm:"type:jsonb" json:"payload"`
+	Result      datatypes.JSON `gorm:"type:jsonb" json:"result"`
+	BucketId    *uuid.UUID     `gorm:"type:uuid;index" json:"bucket_id,omitempty"`
+	Total       int64          `gorm:"not null;default:0" json:"total"`
+	Completed   int64          `gorm:"not null;default:0" json:"completed"`
+	Attempts    int            `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int            `gorm:"not null;default:1" json:"max_attempts"`
+	Error       string         `gorm:"type:text" json:"error"`
+	CreatedBy   uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	RunAfter    time.Time      `gorm:"not null;index:idx_jobs_status_run_after" json:"run_after"`
+	HeartbeatAt *time.Time     `json:"heartbeat_at,omitempty"` // refreshed while running, a stale heartbeat means the runner died
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a Job record
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginChallenge is a sign in that passed the password check and waits for the user's second
+// factor. It allows a few attempts before it expires. Only the challenge token's hash is stored.
+type LoginChallenge struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserId    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string    `gorm:"not null;uniqueIndex" json:"-"`
+	Attempts  int       `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a LoginChallenge record
+func (c *LoginChallenge) BeforeCreate(tx *gorm.DB) error {
+	if c.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NodeRegistrationToken lets one storage node register itself with the master. Admins issue them
+// and each is spent by the first registration using it. Only the token's hash is stored.
+type NodeRegistrationToken struct {
+	Id          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string     `json:"name"` // describes the node it is for, e.g. "eu-west storage"
+	TokenHash   string     `gorm:"not null;uniqueIndex" json:"-"`
+	TokenPrefix string     `gorm:"not null" json:"token_prefix"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	NodeId      *uuid.UUID `gorm:"type:uuid" json:"node_id,omitempty"` // the node registered with it
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a NodeRegistrationToken record
+func (t *NodeRegistrationToken) BeforeCreate(tx *gorm.DB) error {
+	if t.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification is a message for a single user, e.g. when they are mentioned in a file comment or
+// a bucket of theirs is filling up
+type Notification struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type      string     `gorm:"not null" json:"type"` // "mention" or "usage_alert"
+	Message   string     `gorm:"type:text;not null" json:"message"`
+	ActorId   uuid.UUID  `gorm:"type:uuid" json:"actor_id"`
+	BucketId  *uuid.UUID `gorm:"type:uuid" json:"bucket_id,omitempty"`
+	FileId    *uuid.UUID `gorm:"type:uuid" json:"file_id,omitempty"`
+	CommentId *uuid.UUID `gorm:"type:uuid" json:"comment_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a Notification record
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken lets a user who forgot their password choose a new one, through the link
+// emailed to them. It can be used once. Only the token's hash is stored.
+type PasswordResetToken struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a PasswordResetToken record
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	if t.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PendingUpload records content being written for a file whose record isn't committed yet.
+// Its Id is the ID the file will get. The record is removed in the same save that creates the
+// file; one left behind belongs to an upload that failed half way, and its content is removed
+// by the upload cleanup worker.
+type PendingUpload struct {
+	Id        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId  uuid.UUID `gorm:"type:uuid;not null" json:"bucket_id"`
+	Path      string    `gorm:"not null" json:"path"`
+	Size      int64     `gorm:"not null" json:"size"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a PendingUpload record
+func (p *PendingUpload) BeforeCreate(tx *gorm.DB) error {
+	if p.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Role is a custom role an admin made of named permissions, such as an "uploader" that may only
+// upload and list. Users and API keys hold it by name, next to the built-in roles.
+type Role struct {
+	Id          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string         `gorm:"not null;uniqueIndex" json:"name"`
+	Description string         `json:"description"`
+	Permissions datatypes.JSON `gorm:"type:jsonb" json:"permissions"` // permission names
+	CreatedBy   uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a Role record
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// S3ExportJob tracks copying a bucket's objects and manifest out to an external S3 bucket
+type S3ExportJob struct {
+	Id              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId        uuid.UUID      `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	TargetEndpoint  string         `gorm:"not null" json:"target_endpoint"`
+	TargetBucket    string         `gorm:"not null" json:"target_bucket"`
+	Prefix          string         `json:"prefix"`
+	BandwidthLimit  int64          `gorm:"not null;default:0" json:"bandwidth_limit"` // bytes per second, 0 is unlimited
+	Status          string         `gorm:"not null;default:'running'" json:"status"` // "running", "completed" or "failed"
+	ObjectsExported int64          `gorm:"not null;default:0" json:"objects_exported"`
+	ObjectsFailed   int64          `gorm:"not null;default:0" json:"objects_failed"`
+	BytesExported   int64          `gorm:"not null;default:0" json:"bytes_exported"`
+	FailedObjects   datatypes.JSON `gorm:"type:jsonb" json:"failed_objects"`
+	ManifestKey     string         `json:"manifest_key"`
+	Error           string         `gorm:"type:text" json:"error,omitempty"`
+	StartedBy       uuid.UUID      `gorm:"type:uuid;not null" json:"started_by"`
+	StartedAt       time.Time      `gorm:"not null" json:"started_at"`
+	CompletedAt     *time.Time     `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating an S3ExportJob record
+func (j *S3ExportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
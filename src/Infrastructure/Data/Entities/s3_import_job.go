@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// S3ImportJob tracks the progress of a bulk import from an external S3 bucket.
+// LastKey is the last object key processed so an interrupted import can resume after it.
+type S3ImportJob struct {
+	Id              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SourceEndpoint  string         `gorm:"not null" json:"source_endpoint"`
+	SourceBucket    string         `gorm:"not null" json:"source_bucket"`
+	Prefix          string         `json:"prefix"`
+	TargetBucketId  uuid.UUID      `gorm:"type:uuid;not null;index" json:"target_bucket_id"`
+	Status          string         `gorm:"not null;default:'running'" json:"status"` // "running", "completed" or "failed"
+	LastKey         string         `json:"last_key"`
+	ObjectsImported int64          `gorm:"not null;default:0" json:"objects_imported"`
+	ObjectsSkipped  int64          `gorm:"not null;default:0" json:"objects_skipped"`
+	BytesImported   int64          `gorm:"not null;default:0" json:"bytes_imported"`
+	SkippedObjects  datatypes.JSON `gorm:"type:jsonb" json:"skipped_objects"`
+	Error           string         `gorm:"type:text" json:"error,omitempty"`
+	StartedBy       uuid.UUID      `gorm:"type:uuid;not null" json:"started_by"`
+	StartedAt       time.Time      `gorm:"not null" json:"started_at"`
+	CompletedAt     *time.Time     `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating an S3ImportJob record
+func (j *S3ImportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
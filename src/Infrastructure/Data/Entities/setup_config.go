@@ -14,7 +14,7 @@ type SetupConfig struct {
 	NodeName     string         `gorm:"size:100" json:"node_name,omitempty"`
 	StoragePath  string         `gorm:"size:500" json:"storage_path"`
 	MaxStorage   int64          `gorm:"default:0" json:"max_storage"`
-	ConfigData   datatypes.JSON `gorm:"type:jsonb" json:"config_data"`
+	ConfigData   datatypes.JSON `gorm:"type:jsonb;serializer:secretjson" json:"config_data"`
 	CreatedAt    time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt    time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
\ No newline at end of file
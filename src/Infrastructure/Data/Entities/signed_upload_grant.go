@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SignedUploadGrant lets anyone holding its token upload into a bucket, under a name prefix, until it
+// expires or is revoked and within limits on file size and count. Only the token's hash is stored.
+type SignedUploadGrant struct {
+	Id            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId      uuid.UUID  `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	Name          string     `json:"name"`
+	Prefix        string     `gorm:"not null;default:''" json:"prefix"`      // prepended to uploaded file names, e.g. "submissions/"
+	TokenHash     string     `gorm:"not null;uniqueIndex" json:"-"`
+	TokenPrefix   string     `gorm:"not null" json:"token_prefix"`
+	MaxFileSize   int64      `gorm:"not null;default:0" json:"max_file_size"` // bytes per file, 0 for the bucket's limit
+	MaxFiles      int        `gorm:"not null;default:0" json:"max_files"`     // uploads allowed in total, 0 for no limit
+	UploadCount   int        `gorm:"not null;default:0" json:"upload_count"`
+	UploadedBytes int64      `gorm:"not null;default:0" json:"uploaded_bytes"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedBy     uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a SignedUploadGrant record
+func (g *SignedUploadGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SigningKey is a key signed URLs and upload policies are signed with. The key without a retirement
+// date signs, and the others still verify what they signed until they retire. The key with an
+// empty secret is the one SIGNATURE_SECRET sets.
+type SigningKey struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	KeyID     string     `gorm:"not null;uniqueIndex" json:"key_id"` // named in each signature made with the key
+	Secret    string     `gorm:"serializer:secret" json:"-"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	RetiresAt *time.Time `gorm:"index" json:"retires_at,omitempty"` // set when a newer key replaced it
+}
+
+// BeforeCreate is a GORM hook that runs before creating a SigningKey record
+func (k *SigningKey) BeforeCreate(tx *gorm.DB) error {
+	if k.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -10,13 +10,55 @@ type StorageNode struct {
 	Id            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Name          string     `gorm:"not null" json:"name"`
 	URL           string     `gorm:"not null;unique" json:"url"`
-	AuthKey       string     `gorm:"not null" json:"-"` // Hidden from JSON for security
+	PublicURL     string     `gorm:"not null;default:''" json:"public_url"` // where clients reach the node for redirected downloads, URL when empty
+	AuthKey       string     `gorm:"not null;serializer:secret" json:"-"` // Hidden from JSON for security
 	IsActive      bool       `gorm:"not null;default:true" json:"is_active"`
 	IsHealthy     bool       `gorm:"not null;default:false" json:"is_healthy"` // Start as unhealthy until first ping
 	Priority      int        `gorm:"not null;default:0" json:"priority"`
+	Group         string     `gorm:"column:node_group;not null;default:'';index" json:"group"` // e.g. a region, for pinning buckets to a set of nodes
+	Zone          string     `gorm:"not null;default:'';index" json:"zone"` // failure domain such as a rack or availability zone, for spreading copies
 	MaxStorage    int64      `gorm:"not null;default:0" json:"max_storage"`
 	UsedStorage   int64      `gorm:"not null;default:0" json:"used_storage"`
 	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 	LastPing      *time.Time `json:"last_ping,omitempty"`
+	FailedAt      *time.Time `json:"failed_at,omitempty"`                   // marked permanently failed, its content is repaired elsewhere
+	RepairJobId   *uuid.UUID `gorm:"type:uuid" json:"repair_job_id,omitempty"` // the job repairing its content
+	MaintenanceAt *time.Time `json:"maintenance_at,omitempty"`             // in maintenance since, it takes no new content meanwhile
+	DrainReads    bool       `gorm:"not null;default:false" json:"drain_reads"` // during maintenance, reads go to other copies where there are any
+}
+
+// Node states, as health checks and listings report them
+const (
+	NodeStatusHealthy     = "healthy"
+	NodeStatusUnhealthy   = "unhealthy"
+	NodeStatusInactive    = "inactive"
+	NodeStatusMaintenance = "maintenance"
+	NodeStatusFailed      = "failed"
+)
+
+// Status sums up the node's state. A node in maintenance is reported as such whatever its health,
+// which health checks keep tracking.
+func (n *StorageNode) Status() string {
+	switch {
+	case n.FailedAt != nil:
+		return NodeStatusFailed
+	case n.MaintenanceAt != nil:
+		return NodeStatusMaintenance
+	case !n.IsActive:
+		return NodeStatusInactive
+	case !n.IsHealthy:
+		return NodeStatusUnhealthy
+	}
+	return NodeStatusHealthy
+}
+
+// Placeable reports whether new content may be placed on the node
+func (n *StorageNode) Placeable() bool {
+	return n.IsActive && n.IsHealthy && n.FailedAt == nil && n.MaintenanceAt == nil
+}
+
+// Draining reports whether reads of the node's content go to other copies where there are any
+func (n *StorageNode) Draining() bool {
+	return n.MaintenanceAt != nil && n.DrainReads
 }
\ No newline at end of file
@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// StringArray is a list of strings stored as a PostgreSQL array literal, {"a","b"}. PostgreSQL
+// reads it into its text[] column and SQLite, which has no arrays, keeps the literal as text. A
+// plain []string can't be written to either, GORM expands it into a row value.
+type StringArray []string
+
+// Value writes the list as an array literal, NULL for a nil list
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, s := range a {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		for _, r := range s {
+			if r == '"' || r == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// Scan reads an array literal into the list
+func (a *StringArray) Scan(src interface{}) error {
+	var literal string
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		literal = v
+	case []byte:
+		literal = string(v)
+	default:
+		return fmt.Errorf("unsupported value for a string array: %T", src)
+	}
+
+	elements, ok := parseArrayLiteral(literal)
+	if !ok {
+		return fmt.Errorf("invalid string array %q", literal)
+	}
+	*a = elements
+	return nil
+}
+
+// parseArrayLiteral splits a one-dimensional array literal into its elements. PostgreSQL only
+// quotes the elements that need it, so both quoted and bare elements are read.
+func parseArrayLiteral(literal string) ([]string, bool) {
+	if len(literal) < 2 || literal[0] != '{' || literal[len(literal)-1] != '}' {
+		return nil, false
+	}
+	body := literal[1 : len(literal)-1]
+	elements := []string{}
+	if body == "" {
+		return elements, true
+	}
+
+	for i := 0; ; {
+		var element strings.Builder
+		if i < len(body) && body[i] == '"' {
+			for i++; ; i++ {
+				if i >= len(body) {
+					return nil, false
+				}
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				} else if body[i] == '"' {
+					i++
+					break
+				}
+				element.WriteByte(body[i])
+			}
+		} else {
+			end := strings.IndexByte(body[i:], ',')
+			if end < 0 {
+				end = len(body) - i
+			}
+			element.WriteString(strings.TrimSpace(body[i : i+end]))
+			i += end
+		}
+		elements = append(elements, element.String())
+
+		if i == len(body) {
+			return elements, true
+		}
+		if body[i] != ',' {
+			return nil, false
+		}
+		i++
+	}
+}
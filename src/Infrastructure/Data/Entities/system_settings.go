@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SystemSettings holds the hot-reloadable settings changed through the admin API.
+// There is at most one row; without it the environment configuration applies.
+type SystemSettings struct {
+	Id                        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CORSAllowOrigins          string     `gorm:"type:text;not null;default:''" json:"cors_allow_origins"`
+	CORSAllowMethods          string     `gorm:"type:text;not null;default:''" json:"cors_allow_methods"`
+	CORSAllowHeaders          string     `gorm:"type:text;not null;default:''" json:"cors_allow_headers"`
+	CORSExposeHeaders         string     `gorm:"type:text;not null;default:''" json:"cors_expose_headers"`
+	CORSAllowCredentials      bool       `gorm:"not null;default:false" json:"cors_allow_credentials"`
+	CORSMaxAge                int        `gorm:"not null;default:0" json:"cors_max_age"`
+	RateLimitRequests         int        `gorm:"not null;default:0" json:"rate_limit_requests"`
+	RateLimitWindow           int        `gorm:"not null;default:60" json:"rate_limit_window"`
+	DefaultBucketMaxFileSize  int64      `gorm:"not null;default:0" json:"default_bucket_max_file_size"`
+	DefaultBucketMaxTotalSize int64      `gorm:"not null;default:0" json:"default_bucket_max_total_size"`
+	DefaultBucketMaxFiles     int64      `gorm:"not null;default:0" json:"default_bucket_max_files"`
+	ImageTransformsEnabled    bool       `gorm:"not null;default:true" json:"image_transforms_enabled"`
+	ImageMaxInputDimension    int        `gorm:"not null;default:16384" json:"image_max_input_dimension"`
+	ImageMaxInputMegapixels   int        `gorm:"not null;default:50" json:"image_max_input_megapixels"`
+	ImageMaxOutputDimension   int        `gorm:"not null;default:4096" json:"image_max_output_dimension"`
+	ImageAllowedFormats       string     `gorm:"type:text;not null;default:'jpeg,png,webp,avif'" json:"image_allowed_formats"`
+	TwoFactorRequiredRoles    string     `gorm:"type:text;not null;default:''" json:"two_factor_required_roles"`
+	UpdatedBy                 *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt                 time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt                 time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a SystemSettings record
+func (s *SystemSettings) BeforeCreate(tx *gorm.DB) error {
+	if s.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UploadSession is a resumable upload. Content received so far is kept in a staging file and
+// Received, the bytes acknowledged to the client, is saved after every chunk, so an upload
+// survives a server restart and continues on any server sharing the staging directory.
+type UploadSession struct {
+	Id          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BucketId    uuid.UUID  `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	UserId      uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	FileName    string     `gorm:"not null" json:"file_name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `gorm:"not null" json:"size"`               // total size declared when the upload started
+	Received    int64      `gorm:"not null;default:0" json:"received"` // bytes acknowledged, where the next chunk starts
+	StagingPath string     `gorm:"not null" json:"-"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"` // pushed back by every chunk
+	HeldUntil   *time.Time `json:"-"`                                // set while a request on any server works on the upload
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating an UploadSession record
+func (s *UploadSession) BeforeCreate(tx *gorm.DB) error {
+	if s.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UsageAlert is a utilization threshold a bucket, the master or a node crossed. It stays active
+// until utilization falls below the threshold by the configured hysteresis, then it is resolved
+// and kept as history.
+type UsageAlert struct {
+	Id          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Scope       string     `gorm:"not null;index:idx_usage_alerts_target,priority:1" json:"scope"`               // "bucket", "master" or "node"
+	TargetId    uuid.UUID  `gorm:"type:uuid;not null;index:idx_usage_alerts_target,priority:2" json:"target_id"` // bucket or node, nil for the master
+	Name        string     `gorm:"not null" json:"name"`
+	Threshold   int        `gorm:"not null" json:"threshold"`   // percentage crossed
+	Utilization float64    `gorm:"not null" json:"utilization"` // percentage when last checked
+	Used        int64      `gorm:"not null" json:"used"`
+	Capacity    int64      `gorm:"not null" json:"capacity"`
+	FiredAt     time.Time  `gorm:"not null" json:"fired_at"`
+	ResolvedAt  *time.Time `gorm:"index" json:"resolved_at,omitempty"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a UsageAlert record
+func (a *UsageAlert) BeforeCreate(tx *gorm.DB) error {
+	if a.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
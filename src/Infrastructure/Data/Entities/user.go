@@ -18,6 +18,10 @@ type User struct {
 	CreatedAt    time.Time  `gorm:"autoCreateTime;old_name:created_at" json:"created_at"`
 	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 	LastLoginTime    *time.Time `gorm:"old_name:last_login" json:"last_login"`
+	EgressQuota  int64      `gorm:"not null;default:0" json:"egress_quota"` // bytes served from all owned buckets per billing cycle, 0 for no quota
+	TwoFactorEnabled bool   `gorm:"not null;default:false" json:"two_factor_enabled"`
+	TOTPSecret   string     `gorm:"column:totp_secret;not null;default:'';serializer:secret" json:"-"` // set on enrollment, used once two-factor is enabled
+	TOTPLastStep int64      `gorm:"column:totp_last_step;not null;default:0" json:"-"` // time step of the last code used, codes can't be reused
 	
 	// Navigation properties
 	Buckets  []Bucket  `gorm:"foreignKey:OwnerId" json:"buckets,omitempty"`
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserInvitation lets someone create an account with the role an admin chose, through the link
+// emailed to them. Each is spent by the account created with it. Only the token's hash is stored.
+type UserInvitation struct {
+	Id         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email      string     `gorm:"not null;index" json:"email"`
+	Role       string     `gorm:"not null" json:"role"`
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	InvitedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"invited_by"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	UserId     *uuid.UUID `gorm:"type:uuid" json:"user_id,omitempty"` // the account created with it
+}
+
+// BeforeCreate is a GORM hook that runs before creating a UserInvitation record
+func (i *UserInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
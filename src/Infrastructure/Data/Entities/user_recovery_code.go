@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserRecoveryCode signs a user with two-factor authentication in once, in place of a code from
+// their authenticator app. Only the code's hash is stored.
+type UserRecoveryCode struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a UserRecoveryCode record
+func (c *UserRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if c.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VideoAsset tracks thumbnail and HLS generation for an uploaded video
+type VideoAsset struct {
+	Id          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FileId      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"file_id"`
+	BucketId    uuid.UUID  `gorm:"type:uuid;not null;index" json:"bucket_id"`
+	Status      string     `gorm:"not null;default:'pending';index" json:"status"` // "pending", "processing", "ready" or "failed"
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a VideoAsset record
+func (v *VideoAsset) BeforeCreate(tx *gorm.DB) error {
+	if v.Id == uuid.Nil {
+		tx.Statement.Omit("id", "Id")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package diagnostics gathers what a storage node reports to its master's operators: its build,
+// its clock, how full its disk is and the errors it logged lately. It depends on nothing of the
+// master's so the node agent can use it.
+package diagnostics
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"shbucket/src/Infrastructure/InternalAPI"
+)
+
+// recentErrors is how many error lines are kept
+const recentErrors = 50
+
+// errorLine matches the log lines kept as errors
+var errorLine = regexp.MustCompile(`(?i)\b(error|failed|failure|panic)\b`)
+
+var (
+	mutex  sync.Mutex
+	logged []internalapi.LogEntry
+)
+
+// Capture returns a writer for the log package that writes to w and keeps the lines that look like
+// errors for Collect
+func Capture(w io.Writer) io.Writer {
+	return &capture{out: w}
+}
+
+type capture struct {
+	out io.Writer
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if errorLine.Match(line) {
+			record(string(line))
+		}
+	}
+	return c.out.Write(p)
+}
+
+func record(line string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(logged) == recentErrors {
+		logged = append(logged[:0], logged[1:]...)
+	}
+	logged = append(logged, internalapi.LogEntry{Time: time.Now(), Message: strings.TrimSpace(line)})
+}
+
+// Collect returns the node's diagnostics, with the disk usage of storagePath
+func Collect(storagePath string) internalapi.Diagnostics {
+	diagnostics := internalapi.Diagnostics{
+		Version:     Version(),
+		APIVersion:  internalapi.Version,
+		StoragePath: storagePath,
+	}
+	if usage, err := diskUsage(storagePath); err != nil {
+		diagnostics.DiskError = err.Error()
+	} else {
+		diagnostics.Disk = &usage
+	}
+
+	mutex.Lock()
+	diagnostics.RecentErrors = append([]internalapi.LogEntry{}, logged...)
+	mutex.Unlock()
+
+	// Taken last, as close to answering as can be
+	diagnostics.Time = time.Now()
+	return diagnostics
+}
+
+// Version is the version of the running build: its module version when it was built from a tagged
+// module, otherwise the commit it was built from, empty when neither was recorded
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if revision != "" && modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
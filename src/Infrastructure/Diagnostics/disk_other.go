@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package diagnostics
+
+import (
+	"errors"
+
+	"shbucket/src/Infrastructure/InternalAPI"
+)
+
+// diskUsage isn't supported here, nodes run on Linux
+func diskUsage(path string) (internalapi.DiskUsage, error) {
+	return internalapi.DiskUsage{}, errors.New("disk usage isn't supported on this platform")
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin
+
+package diagnostics
+
+import (
+	"fmt"
+	"syscall"
+
+	"shbucket/src/Infrastructure/InternalAPI"
+)
+
+// diskUsage returns the space and inodes of the filesystem holding path
+func diskUsage(path string) (internalapi.DiskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return internalapi.DiskUsage{}, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	return internalapi.DiskUsage{
+		TotalBytes:  uint64(stat.Blocks) * uint64(stat.Bsize),
+		FreeBytes:   uint64(stat.Bavail) * uint64(stat.Bsize),
+		TotalInodes: uint64(stat.Files),
+		FreeInodes:  uint64(stat.Ffree),
+	}, nil
+}
@@ -0,0 +1,164 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// resolverTTL is how long the domain routes are cached, so a domain set on another server takes
+// effect within this time
+const resolverTTL = 30 * time.Second
+
+// Normalize returns host lower-cased, without a port or trailing dot
+func Normalize(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// APIHost is the host name of BASE_URL, empty when it isn't set
+func APIHost() string {
+	base, err := url.Parse(config.GetSettings().BaseURL)
+	if err != nil {
+		return ""
+	}
+	return Normalize(base.Host)
+}
+
+// Configure normalizes a bucket's website and custom domains and checks that each belongs to this
+// bucket alone, is used for one purpose, and isn't the host the API is served on
+func Configure(dbContext *persistence.AppDbContext, bucketID uuid.UUID, settings *entities.BucketSettings) error {
+	settings.WebsiteDomain = Normalize(settings.WebsiteDomain)
+	settings.CustomDomain = Normalize(settings.CustomDomain)
+
+	var claimed []string
+	for _, field := range []struct{ name, domain string }{
+		{"website_domain", settings.WebsiteDomain},
+		{"custom_domain", settings.CustomDomain},
+	} {
+		if field.domain == "" {
+			continue
+		}
+		if field.domain == APIHost() {
+			return apierror.Newf(apierror.CodeInvalidRequest, "%s can't be the host the API is served on", field.name)
+		}
+		claimed = append(claimed, field.domain)
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+	if len(claimed) == 2 && claimed[0] == claimed[1] {
+		return apierror.New(apierror.CodeInvalidRequest, "website_domain and custom_domain must differ")
+	}
+
+	other, err := claimedBy(dbContext.GetDB(), claimed, bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket domains: %w", err)
+	}
+	if other != nil {
+		return apierror.Newf(apierror.CodeAlreadyExists, "domain %s is already used by bucket %s", strings.Join(claimed, " or "), other.Name)
+	}
+	return nil
+}
+
+// claimedBy finds a bucket other than bucketID using one of domains, nil when there is none
+func claimedBy(db *gorm.DB, domains []string, bucketID uuid.UUID) (*entities.Bucket, error) {
+	var other entities.Bucket
+	err := db.Select("Id", "Name").
+		Where(`("settings_WebsiteDomain" IN ? OR "settings_CustomDomain" IN ?) AND "Id" <> ?`, domains, domains, bucketID).
+		First(&other).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &other, nil
+}
+
+// Route is what a request for a bucket domain is served from
+type Route struct {
+	BucketID uuid.UUID
+	Website  bool // the bucket's static site, otherwise its files by name
+}
+
+// Resolver maps host names to the buckets whose domains they are, from a periodically refreshed list
+type Resolver struct {
+	dbContext *persistence.AppDbContext
+
+	mu       sync.Mutex
+	routes   map[string]Route
+	loadedAt time.Time
+}
+
+func NewResolver(dbContext *persistence.AppDbContext) *Resolver {
+	return &Resolver{
+		dbContext: dbContext,
+	}
+}
+
+// Resolve finds the route of a request's host
+func (r *Resolver) Resolve(host string) (Route, bool) {
+	host = Normalize(host)
+	if host == "" {
+		return Route{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.loadedAt) > resolverTTL {
+		r.load()
+	}
+	route, ok := r.routes[host]
+	return route, ok
+}
+
+// load replaces the routes with the current domains. A failed load keeps the previous routes until
+// the next refresh.
+func (r *Resolver) load() {
+	r.loadedAt = time.Now()
+
+	routes, err := loadRoutes(r.dbContext.GetDB())
+	if err != nil {
+		log.Printf("Warning: failed to load bucket domains: %v", err)
+		return
+	}
+	r.routes = routes
+}
+
+// loadRoutes maps the website and custom domains of every bucket to their routes
+func loadRoutes(db *gorm.DB) (map[string]Route, error) {
+	var buckets []entities.Bucket
+	if err := db.Select("Id", "settings_WebsiteEnabled", "settings_WebsiteDomain", "settings_CustomDomain").
+		Where(`("settings_WebsiteEnabled" = ? AND "settings_WebsiteDomain" <> '') OR "settings_CustomDomain" <> ''`, true).
+		Find(&buckets).Error; err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]Route, len(buckets))
+	for _, bucket := range buckets {
+		if bucket.Settings.WebsiteEnabled && bucket.Settings.WebsiteDomain != "" {
+			routes[bucket.Settings.WebsiteDomain] = Route{BucketID: bucket.Id, Website: true}
+		}
+		if bucket.Settings.CustomDomain != "" {
+			routes[bucket.Settings.CustomDomain] = Route{BucketID: bucket.Id}
+		}
+	}
+	return routes, nil
+}
@@ -0,0 +1,37 @@
+package domains
+
+import (
+	"testing"
+
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestDomains routes the website and custom domains of buckets and finds the bucket using a domain
+func TestDomains(t *testing.T) {
+	db := sqlitetest.Open(t)
+	site := sqlitetest.CreateBucket(t, db, "site")
+	files := sqlitetest.CreateBucket(t, db, "files")
+	if err := db.Model(&site).Updates(map[string]interface{}{"settings_WebsiteEnabled": true, "settings_WebsiteDomain": "www.example.com"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&files).Update("settings_CustomDomain", "files.example.com").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	routes, err := loadRoutes(db)
+	if err != nil {
+		t.Fatalf("loadRoutes() = %v", err)
+	}
+	want := map[string]Route{"www.example.com": {BucketID: site.Id, Website: true}, "files.example.com": {BucketID: files.Id}}
+	if len(routes) != len(want) || routes["www.example.com"] != want["www.example.com"] || routes["files.example.com"] != want["files.example.com"] {
+		t.Errorf("loadRoutes() = %v, want %v", routes, want)
+	}
+
+	other, err := claimedBy(db, []string{"files.example.com"}, site.Id)
+	if err != nil || other == nil || other.Name != "files" {
+		t.Errorf("claimedBy(files.example.com) from site = %+v, %v, want files", other, err)
+	}
+	if other, err := claimedBy(db, []string{"files.example.com"}, files.Id); err != nil || other != nil {
+		t.Errorf("claimedBy(files.example.com) from files = %+v, %v, want nil", other, err)
+	}
+}
@@ -0,0 +1,117 @@
+package domains
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"shbucket/src/Infrastructure/Config"
+)
+
+// TLSConfig returns the TLS configuration serving HTTPS for the API host and bucket domains, nil
+// when no certificate source is configured. Certificates are picked per host name: one from
+// TLS_CERT_DIR, then one from Let's Encrypt, then the default certificate.
+func TLSConfig(settings *config.Settings, resolver *Resolver) (*tls.Config, error) {
+	if settings.TLSCertFile == "" && settings.TLSCertDir == "" && !settings.TLSAutocert {
+		return nil, nil
+	}
+
+	certs := &certificates{dir: settings.TLSCertDir, loaded: make(map[string]*loadedCertificate)}
+	if settings.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.TLSCertFile, settings.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		certs.fallback = &cert
+	}
+
+	if settings.TLSAutocert {
+		certs.manager = &autocert.Manager{
+			Prompt: autocert.AcceptTOS,
+			Cache:  autocert.DirCache(settings.TLSAutocertCacheDir),
+			Email:  settings.TLSAutocertEmail,
+			// Only names this server answers for, so arbitrary SNI can't trigger issuance
+			HostPolicy: func(_ context.Context, host string) error {
+				if host == APIHost() {
+					return nil
+				}
+				if _, ok := resolver.Resolve(host); ok {
+					return nil
+				}
+				return fmt.Errorf("%s is not a bucket domain", host)
+			},
+		}
+	}
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"http/1.1", acme.ALPNProto},
+		GetCertificate: certs.get,
+	}, nil
+}
+
+type loadedCertificate struct {
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+type certificates struct {
+	dir      string
+	fallback *tls.Certificate
+	manager  *autocert.Manager
+
+	mu     sync.Mutex
+	loaded map[string]*loadedCertificate
+}
+
+func (c *certificates) get(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := Normalize(hello.ServerName)
+	if cert := c.fromDir(host); cert != nil {
+		return cert, nil
+	}
+	if c.manager != nil && host != "" {
+		cert, err := c.manager.GetCertificate(hello)
+		if err == nil || c.fallback == nil {
+			return cert, err
+		}
+	}
+	if c.fallback != nil {
+		return c.fallback, nil
+	}
+	return nil, fmt.Errorf("no certificate for %q", host)
+}
+
+// fromDir loads host's certificate from the certificate directory, again whenever its file
+// changes so renewed certificates are picked up without a restart
+func (c *certificates) fromDir(host string) *tls.Certificate {
+	if c.dir == "" || host == "" || strings.ContainsAny(host, `/\`) || strings.Contains(host, "..") {
+		return nil
+	}
+	certFile := filepath.Join(c.dir, host+".crt")
+	info, err := os.Stat(certFile)
+	if err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if loaded := c.loaded[host]; loaded != nil && loaded.modTime.Equal(info.ModTime()) {
+		return loaded.cert
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, filepath.Join(c.dir, host+".key"))
+	if err != nil {
+		log.Printf("Warning: failed to load TLS certificate for %s: %v", host, err)
+		return nil
+	}
+	c.loaded[host] = &loadedCertificate{cert: &cert, modTime: info.ModTime()}
+	return &cert
+}
@@ -0,0 +1,169 @@
+package durability
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+// minHealthyReplicas is how many healthy copies a file version needs to survive losing one
+const minHealthyReplicas = 2
+
+// Assessor works out the replication state of file versions: the primary copy they are served
+// from, on the master or a storage node, and their copies at backup destinations
+type Assessor struct {
+	nodes    map[uuid.UUID]*entities.StorageNode
+	nodeURLs map[string]*entities.StorageNode
+	minZones map[uuid.UUID]int // by bucket, for buckets requiring copies in several zones
+}
+
+func NewAssessor(dbContext *persistence.AppDbContext) (*Assessor, error) {
+	nodes, err := dbContext.StorageNodes.ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch storage nodes: %w", err)
+	}
+
+	minZones, err := zoneRequirements(dbContext.GetDB())
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Assessor{
+		nodes:    make(map[uuid.UUID]*entities.StorageNode, len(nodes)),
+		nodeURLs: make(map[string]*entities.StorageNode, len(nodes)),
+		minZones: minZones,
+	}
+	for i := range nodes {
+		a.nodes[nodes[i].Id] = &nodes[i]
+		a.nodeURLs[strings.TrimRight(nodes[i].URL, "/")] = &nodes[i]
+	}
+	return a, nil
+}
+
+// zoneRequirements returns the zones each bucket requiring copies in several zones asks for
+func zoneRequirements(db *gorm.DB) (map[uuid.UUID]int, error) {
+	var spread []entities.Bucket
+	if err := db.Select(`"Id"`, `"settings_MinZones"`).
+		Where(`"settings_MinZones" > 1`).Find(&spread).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch bucket zone requirements: %w", err)
+	}
+	minZones := make(map[uuid.UUID]int, len(spread))
+	for _, bucket := range spread {
+		minZones[bucket.Id] = bucket.Settings.MinZones
+	}
+	return minZones, nil
+}
+
+// Status returns the replication state of a single file version
+func Status(dbContext *persistence.AppDbContext, file *entities.File) (models.ReplicationStatusResponse, error) {
+	a, err := NewAssessor(dbContext)
+	if err != nil {
+		return models.ReplicationStatusResponse{}, err
+	}
+	backups, err := dbContext.BackupObjects.Where(&entities.BackupObject{FileId: file.Id}).ToList()
+	if err != nil {
+		return models.ReplicationStatusResponse{}, fmt.Errorf("failed to fetch backup copies: %w", err)
+	}
+	return a.Assess(file, backups), nil
+}
+
+// Assess returns the replication state of file given its backup copies. A version is degraded
+// when any copy is unhealthy, at risk with fewer than two healthy copies, and short of zones
+// when its healthy copies span fewer zones than its bucket requires.
+func (a *Assessor) Assess(file *entities.File, backups []entities.BackupObject) models.ReplicationStatusResponse {
+	status := models.ReplicationStatusResponse{Copies: []models.ReplicaResponse{a.primary(file)}}
+	for i := range backups {
+		status.Copies = append(status.Copies, a.backup(file, &backups[i]))
+	}
+
+	zones := make(map[string]bool)
+	for _, replica := range status.Copies {
+		status.Replicas++
+		if !replica.Healthy {
+			continue
+		}
+		status.HealthyReplicas++
+		if replica.Zone != "" {
+			zones[replica.Zone] = true
+		}
+		if replica.VerifiedAt != nil && (status.LastVerifiedAt == nil || replica.VerifiedAt.After(*status.LastVerifiedAt)) {
+			status.LastVerifiedAt = replica.VerifiedAt
+		}
+	}
+	status.Degraded = status.HealthyReplicas < status.Replicas
+	status.AtRisk = status.HealthyReplicas < minHealthyReplicas
+	status.Unavailable = !status.Copies[0].Healthy
+	status.Zones = len(zones)
+	status.ZoneShortfall = status.Zones < a.minZones[file.BucketId]
+	return status
+}
+
+// primary checks the copy a file is served from. Master copies are confirmed on disk; node copies
+// count as healthy while their node is, as of its last health check.
+func (a *Assessor) primary(file *entities.File) models.ReplicaResponse {
+	replica := models.ReplicaResponse{Type: "primary", Location: "master"}
+	if !storage.IsNodePath(file.Path) {
+		replica.Zone = placement.Zone(nil)
+		if info, err := os.Stat(file.Path); err == nil && info.Mode().IsRegular() {
+			now := time.Now()
+			replica.Healthy = true
+			replica.VerifiedAt = &now
+		}
+		return replica
+	}
+
+	replica.Location = "unknown node"
+	nodePath, err := storage.ParseNodePath(file.Path)
+	if err != nil {
+		return replica
+	}
+	replica.NodeID = &nodePath.NodeID
+	if node := a.nodes[nodePath.NodeID]; node != nil {
+		replica.Location = node.Name
+		replica.Zone = placement.Zone(node)
+		replica.Healthy = nodeHealthy(node)
+		if replica.Healthy {
+			replica.VerifiedAt = node.LastPing
+		}
+	}
+	return replica
+}
+
+// backup checks a backup copy: it must hold the file's current content, and a backup node that is
+// a registered storage node must be healthy. The copy was verified when it was written, and is in
+// the configured backup zone, or its node's.
+func (a *Assessor) backup(file *entities.File, object *entities.BackupObject) models.ReplicaResponse {
+	backedUpAt := object.BackedUpAt
+	replica := models.ReplicaResponse{
+		Type:       "backup",
+		Location:   object.Destination,
+		Zone:       config.GetSettings().BackupZone,
+		Healthy:    object.Covers(file),
+		VerifiedAt: &backedUpAt,
+	}
+	if url, ok := strings.CutPrefix(object.Destination, "node:"); ok {
+		if node := a.nodeURLs[url]; node != nil {
+			replica.NodeID = &node.Id
+			replica.Healthy = replica.Healthy && nodeHealthy(node)
+			if replica.Zone == "" {
+				replica.Zone = node.Zone
+			}
+		}
+	}
+	return replica
+}
+
+func nodeHealthy(node *entities.StorageNode) bool {
+	return node.IsActive && node.IsHealthy
+}
@@ -0,0 +1,25 @@
+package durability
+
+import (
+	"testing"
+
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestZoneRequirements returns the buckets requiring copies in several zones only
+func TestZoneRequirements(t *testing.T) {
+	db := sqlitetest.Open(t)
+	spread := sqlitetest.CreateBucket(t, db, "photos")
+	if err := db.Model(&spread).Update("settings_MinZones", 3).Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlitetest.CreateBucket(t, db, "videos")
+
+	minZones, err := zoneRequirements(db)
+	if err != nil {
+		t.Fatalf("zoneRequirements() = %v", err)
+	}
+	if len(minZones) != 1 || minZones[spread.Id] != 3 {
+		t.Errorf("zoneRequirements() = %v, want 3 zones for photos only", minZones)
+	}
+}
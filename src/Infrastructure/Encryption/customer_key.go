@@ -0,0 +1,49 @@
+package encryption
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// CustomerAlgorithm is the only algorithm accepted for customer-provided keys, as in S3 SSE-C
+const CustomerAlgorithm = "AES256"
+
+var (
+	// ErrCustomerKeyRequired is returned when content encrypted with a customer-provided key is read without one
+	ErrCustomerKeyRequired = apierror.New(apierror.CodeCustomerKeyRequired, "file is encrypted with a customer-provided key, supply it to read the file")
+	// ErrCustomerKeyMismatch is returned when the supplied key is not the one the content was encrypted with
+	ErrCustomerKeyMismatch = apierror.New(apierror.CodeCustomerKeyMismatch, "the supplied customer key does not match the key the file was encrypted with")
+)
+
+// CustomerKey is an encryption key supplied by the client with a request. It is used for that request
+// only; the server keeps nothing but its MD5 to recognise the key on later requests.
+type CustomerKey struct {
+	Key []byte
+	MD5 string // base64, as sent in the key MD5 header
+}
+
+// ParseCustomerKey validates the SSE-C algorithm, key and key MD5 values of a request.
+// It returns nil when none of them are set.
+func ParseCustomerKey(algorithm, encodedKey, keyMD5 string) (*CustomerKey, error) {
+	if algorithm == "" && encodedKey == "" && keyMD5 == "" {
+		return nil, nil
+	}
+	if algorithm != CustomerAlgorithm {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "customer key algorithm must be %s", CustomerAlgorithm)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != KeySize {
+		return nil, apierror.Newf(apierror.CodeInvalidRequest, "customer key must be %d bytes encoded as base64", KeySize)
+	}
+
+	sum := md5.Sum(key)
+	expected := base64.StdEncoding.EncodeToString(sum[:])
+	if keyMD5 != "" && keyMD5 != expected {
+		return nil, apierror.New(apierror.CodeInvalidRequest, "customer key MD5 does not match the key")
+	}
+
+	return &CustomerKey{Key: key, MD5: expected}, nil
+}
@@ -0,0 +1,282 @@
+package encryption
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Content is protected with envelope encryption: every file gets its own data key, the data key is
+// stored wrapped by the bucket's active key, and bucket keys are stored wrapped by the KeyWrapper.
+// Rotating a bucket key only re-wraps data keys, content is never re-encrypted. A rotation also
+// moves a bucket to the configured key provider, older keys are unwrapped by the provider that
+// wrapped them.
+
+var (
+	// bucketKeys caches unwrapped bucket keys by key ID, a key version never changes once created
+	bucketKeys sync.Map
+	// keyMu serialises creating and rotating bucket keys so a bucket never has two active keys
+	keyMu sync.Mutex
+)
+
+// Keyring issues and unwraps data keys for buckets
+type Keyring struct {
+	dbContext *persistence.AppDbContext
+	wrapper   KeyWrapper
+}
+
+// NewKeyring returns a keyring using the server's key wrapper
+func NewKeyring(dbContext *persistence.AppDbContext) (*Keyring, error) {
+	wrapper, err := DefaultWrapper()
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{dbContext: dbContext, wrapper: wrapper}, nil
+}
+
+// ActiveKey returns the bucket's active key, creating the first one on demand
+func (k *Keyring) ActiveKey(bucketID uuid.UUID) (*entities.BucketKey, error) {
+	if key, err := k.findActiveKey(bucketID); err != nil || key != nil {
+		return key, err
+	}
+
+	keyMu.Lock()
+	defer keyMu.Unlock()
+
+	if key, err := k.findActiveKey(bucketID); err != nil || key != nil {
+		return key, err
+	}
+	return k.createKey(k.dbContext.GetDB(), bucketID, 1)
+}
+
+// Rotate retires the bucket's active key and makes a new version active.
+// It returns the new key; data keys wrapped by older versions stay readable.
+func (k *Keyring) Rotate(bucketID uuid.UUID) (*entities.BucketKey, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+
+	var created *entities.BucketKey
+	err := k.dbContext.GetDB().Transaction(func(tx *gorm.DB) error {
+		key, err := k.rotate(tx, bucketID)
+		created = key
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// rotate retires the bucket's active key and creates the next version in the transaction tx
+func (k *Keyring) rotate(tx *gorm.DB, bucketID uuid.UUID) (*entities.BucketKey, error) {
+	var latest int
+	if err := tx.Model(&entities.BucketKey{}).
+		Where(`"BucketId" = ?`, bucketID).
+		Select(`COALESCE(MAX("Version"), 0)`).
+		Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("failed to read bucket key versions: %w", err)
+	}
+
+	if err := tx.Model(&entities.BucketKey{}).
+		Where(&entities.BucketKey{BucketId: bucketID, Status: "active"}).
+		Updates(map[string]interface{}{"Status": "retired", "RetiredAt": time.Now()}).Error; err != nil {
+		return nil, fmt.Errorf("failed to retire bucket key: %w", err)
+	}
+
+	return k.createKey(tx, bucketID, latest+1)
+}
+
+// NewDataKey generates a data key for a new file in the bucket, returning it with its wrapped form
+func (k *Keyring) NewDataKey(bucketID uuid.UUID) ([]byte, entities.FileEncryption, error) {
+	bucketKey, err := k.ActiveKey(bucketID)
+	if err != nil {
+		return nil, entities.FileEncryption{}, err
+	}
+
+	dataKey, err := NewKey()
+	if err != nil {
+		return nil, entities.FileEncryption{}, err
+	}
+
+	enc, err := k.wrapDataKey(dataKey, bucketKey)
+	if err != nil {
+		return nil, entities.FileEncryption{}, err
+	}
+	return dataKey, enc, nil
+}
+
+// DataKey unwraps a file's data key
+func (k *Keyring) DataKey(enc entities.FileEncryption) ([]byte, error) {
+	if !enc.Encrypted() {
+		return nil, fmt.Errorf("content is not encrypted")
+	}
+
+	bucketKey, err := k.unwrapBucketKey(*enc.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	return openKey(bucketKey, enc.WrappedKey)
+}
+
+// Rewrap re-wraps a file's data key under another bucket key
+func (k *Keyring) Rewrap(enc entities.FileEncryption, to *entities.BucketKey) (entities.FileEncryption, error) {
+	dataKey, err := k.DataKey(enc)
+	if err != nil {
+		return entities.FileEncryption{}, err
+	}
+	return k.wrapDataKey(dataKey, to)
+}
+
+// Encrypt returns a reader encrypting content under a new data key of the bucket
+func (k *Keyring) Encrypt(bucketID uuid.UUID, content io.Reader) (io.Reader, entities.FileEncryption, error) {
+	dataKey, enc, err := k.NewDataKey(bucketID)
+	if err != nil {
+		return nil, entities.FileEncryption{}, err
+	}
+
+	reader, err := EncryptReader(content, dataKey)
+	if err != nil {
+		return nil, entities.FileEncryption{}, err
+	}
+	return reader, enc, nil
+}
+
+// Decrypt returns a reader producing the plaintext of content encrypted under enc
+func (k *Keyring) Decrypt(enc entities.FileEncryption, content io.Reader) (io.Reader, error) {
+	dataKey, err := k.DataKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptReader(content, dataKey)
+}
+
+func (k *Keyring) findActiveKey(bucketID uuid.UUID) (*entities.BucketKey, error) {
+	key, err := k.dbContext.BucketKeys.Where(&entities.BucketKey{BucketId: bucketID, Status: "active"}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bucket key: %w", err)
+	}
+	return key, nil
+}
+
+func (k *Keyring) createKey(db *gorm.DB, bucketID uuid.UUID, version int) (*entities.BucketKey, error) {
+	plainKey, err := NewKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := k.wrapper.Wrap(plainKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap bucket key: %w", err)
+	}
+
+	key := &entities.BucketKey{
+		Id:         uuid.New(),
+		BucketId:   bucketID,
+		Version:    version,
+		WrappedKey: wrapped,
+		Provider:   k.wrapper.Provider(),
+		Status:     "active",
+	}
+	if err := db.Create(key).Error; err != nil {
+		return nil, fmt.Errorf("failed to store bucket key: %w", err)
+	}
+
+	bucketKeys.Store(key.Id, plainKey)
+	return key, nil
+}
+
+func (k *Keyring) unwrapBucketKey(keyID uuid.UUID) ([]byte, error) {
+	if cached, ok := bucketKeys.Load(keyID); ok {
+		return cached.([]byte), nil
+	}
+
+	key, err := k.dbContext.BucketKeys.Where(&entities.BucketKey{Id: keyID}).FirstOrDefault()
+	if err != nil || key == nil {
+		return nil, fmt.Errorf("bucket key %s not found", keyID)
+	}
+
+	wrapper := k.wrapper
+	if key.Provider != wrapper.Provider() {
+		if wrapper, err = WrapperFor(key.Provider); err != nil {
+			return nil, fmt.Errorf("bucket key %s was wrapped by %s: %w", keyID, key.Provider, err)
+		}
+	}
+
+	plainKey, err := wrapper.Unwrap(key.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap bucket key %s: %w", keyID, err)
+	}
+
+	bucketKeys.Store(keyID, plainKey)
+	return plainKey, nil
+}
+
+func (k *Keyring) wrapDataKey(dataKey []byte, bucketKey *entities.BucketKey) (entities.FileEncryption, error) {
+	plainKey, err := k.unwrapBucketKey(bucketKey.Id)
+	if err != nil {
+		return entities.FileEncryption{}, err
+	}
+
+	wrapped, err := sealKey(plainKey, dataKey)
+	if err != nil {
+		return entities.FileEncryption{}, err
+	}
+
+	keyID := bucketKey.Id
+	return entities.FileEncryption{KeyId: &keyID, WrappedKey: wrapped}, nil
+}
+
+// RewrapFile re-wraps a file's data key under its bucket's active key if it was wrapped by a retired one
+func (k *Keyring) RewrapFile(file *entities.File) error {
+	enc, err := k.rewrapRow(&entities.File{}, file.Id, file.BucketId, file.Encryption)
+	if err == nil {
+		file.Encryption = enc
+	}
+	return err
+}
+
+// RewrapSnapshotFile re-wraps a snapshot copy's data key like RewrapFile
+func (k *Keyring) RewrapSnapshotFile(bucketID uuid.UUID, file *entities.SnapshotFile) error {
+	enc, err := k.rewrapRow(&entities.SnapshotFile{}, file.Id, bucketID, file.Encryption)
+	if err == nil {
+		file.Encryption = enc
+	}
+	return err
+}
+
+// rewrapRow re-wraps the data key stored on a file or snapshot file row, returning the encryption now stored
+func (k *Keyring) rewrapRow(model interface{}, id, bucketID uuid.UUID, enc entities.FileEncryption) (entities.FileEncryption, error) {
+	active, err := k.findActiveKey(bucketID)
+	if err != nil || active == nil || !enc.Encrypted() || *enc.KeyId == active.Id {
+		return enc, err
+	}
+
+	rewrapped, err := k.Rewrap(enc, active)
+	if err != nil {
+		return enc, err
+	}
+	if err := storeRewrapped(k.dbContext.GetDB(), model, id, *enc.KeyId, rewrapped); err != nil {
+		return enc, err
+	}
+	return rewrapped, nil
+}
+
+// storeRewrapped stores a re-wrapped data key on a file or snapshot file row. Only the key we read
+// is replaced, a concurrent re-wrap may already have stored a newer one.
+func storeRewrapped(db *gorm.DB, model interface{}, id, previousKeyID uuid.UUID, rewrapped entities.FileEncryption) error {
+	if err := db.Model(model).
+		Where(`"Id" = ? AND "encryption_KeyId" = ?`, id, previousKeyID).
+		Updates(map[string]interface{}{
+			"encryption_KeyId":      rewrapped.KeyId,
+			"encryption_WrappedKey": rewrapped.WrappedKey,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to store re-wrapped data key: %w", err)
+	}
+	return nil
+}
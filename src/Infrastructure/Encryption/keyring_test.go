@@ -0,0 +1,85 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+func testKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	wrapper, err := NewMasterKeyWrapper(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		t.Fatalf("NewMasterKeyWrapper() = %v", err)
+	}
+	return &Keyring{wrapper: wrapper}
+}
+
+// TestRotate retires the active key and creates the next version, leaving other buckets' keys alone
+func TestRotate(t *testing.T) {
+	db := sqlitetest.Open(t)
+	k := testKeyring(t)
+	bucketID, otherID := uuid.New(), uuid.New()
+	if _, err := k.rotate(db, otherID); err != nil {
+		t.Fatalf("rotate() of the other bucket = %v", err)
+	}
+
+	first, err := k.rotate(db, bucketID)
+	if err != nil {
+		t.Fatalf("rotate() = %v", err)
+	}
+	second, err := k.rotate(db, bucketID)
+	if err != nil {
+		t.Fatalf("rotate() again = %v", err)
+	}
+	if first.Version != 1 || second.Version != 2 {
+		t.Errorf("rotate() versions = %d, %d, want 1, 2", first.Version, second.Version)
+	}
+
+	var keys []entities.BucketKey
+	if err := db.Order(`"BucketId", "Version"`).Find(&keys).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		wantStatus := "active"
+		if key.Id == first.Id {
+			wantStatus = "retired"
+		}
+		if key.Status != wantStatus || (key.RetiredAt != nil) != (wantStatus == "retired") {
+			t.Errorf("key %d of bucket %s status = %q retired at %v, want %q", key.Version, key.BucketId, key.Status, key.RetiredAt, wantStatus)
+		}
+	}
+}
+
+// TestStoreRewrapped replaces the data key it read and not one a concurrent re-wrap stored
+func TestStoreRewrapped(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	oldKey, newKey := uuid.New(), uuid.New()
+	file := entities.File{BucketId: bucketID, Name: "a.txt", OriginalName: "a.txt", Path: "/data/a.txt",
+		Encryption: entities.FileEncryption{KeyId: &oldKey, WrappedKey: []byte("old")}}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	rewrapped := entities.FileEncryption{KeyId: &newKey, WrappedKey: []byte("new")}
+	if err := storeRewrapped(db, &entities.File{}, file.Id, oldKey, rewrapped); err != nil {
+		t.Fatalf("storeRewrapped() = %v", err)
+	}
+	stale := entities.FileEncryption{KeyId: &oldKey, WrappedKey: []byte("stale")}
+	if err := storeRewrapped(db, &entities.File{}, file.Id, uuid.New(), stale); err != nil {
+		t.Fatalf("storeRewrapped() of another key = %v", err)
+	}
+
+	var stored entities.File
+	if err := db.First(&stored, `"Id" = ?`, file.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Encryption.KeyId == nil || *stored.Encryption.KeyId != newKey || string(stored.Encryption.WrappedKey) != "new" {
+		t.Errorf("stored encryption = %+v, want the re-wrapped key", stored.Encryption)
+	}
+}
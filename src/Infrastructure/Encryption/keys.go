@@ -0,0 +1,168 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sync"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+)
+
+// KeySize is the size of master, bucket and data keys (AES-256)
+const KeySize = 32
+
+// ErrNotConfigured is returned when encryption is used without a master key
+var ErrNotConfigured = apierror.New(apierror.CodeUnavailable, "encryption is not configured, set ENCRYPTION_MASTER_KEY, ENCRYPTION_MASTER_KEY_FILE or a KMS provider")
+
+// Key providers, recorded on every bucket key so it is unwrapped by the provider that wrapped it
+const (
+	ProviderLocal = "local"
+	ProviderAWS   = "aws-kms"
+	ProviderGCP   = "gcp-kms"
+	ProviderVault = "vault"
+)
+
+// KeyWrapper protects bucket keys at rest. Bucket keys are only ever stored wrapped,
+// so the master key (or an external key service) never leaves the wrapper.
+type KeyWrapper interface {
+	Provider() string
+	Wrap(key []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// wrappers caches configured wrappers by provider, KMS wrappers hold credentials and tokens
+var wrappers sync.Map
+
+// masterKeyWrapper wraps keys locally with the configured master key
+type masterKeyWrapper struct {
+	masterKey []byte
+}
+
+// NewMasterKeyWrapper returns a wrapper using a base64 encoded 32 byte master key
+func NewMasterKeyWrapper(encodedKey string) (KeyWrapper, error) {
+	if encodedKey == "" {
+		return nil, ErrNotConfigured
+	}
+	masterKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(masterKey) != KeySize {
+		return nil, fmt.Errorf("ENCRYPTION_MASTER_KEY must be %d bytes encoded as base64", KeySize)
+	}
+	return &masterKeyWrapper{masterKey: masterKey}, nil
+}
+
+// NewMasterKeyFileWrapper returns a wrapper using the master key stored in a file,
+// either as 32 raw bytes or base64 encoded
+func NewMasterKeyFileWrapper(path string) (KeyWrapper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key file: %w", err)
+	}
+	if len(data) == KeySize {
+		return &masterKeyWrapper{masterKey: data}, nil
+	}
+	masterKey, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil || len(masterKey) != KeySize {
+		return nil, fmt.Errorf("master key file must hold a %d byte key, raw or encoded as base64", KeySize)
+	}
+	return &masterKeyWrapper{masterKey: masterKey}, nil
+}
+
+func (w *masterKeyWrapper) Provider() string {
+	return ProviderLocal
+}
+
+func (w *masterKeyWrapper) Wrap(key []byte) ([]byte, error) {
+	return sealKey(w.masterKey, key)
+}
+
+func (w *masterKeyWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	return openKey(w.masterKey, wrapped)
+}
+
+// DefaultWrapper returns the wrapper configured for this server
+func DefaultWrapper() (KeyWrapper, error) {
+	return WrapperFor(config.GetSettings().EncryptionKeyProvider)
+}
+
+// WrapperFor returns the wrapper of a key provider, configured from the server's settings
+func WrapperFor(provider string) (KeyWrapper, error) {
+	if provider == "" {
+		provider = ProviderLocal
+	}
+	if cached, ok := wrappers.Load(provider); ok {
+		return cached.(KeyWrapper), nil
+	}
+
+	wrapper, err := newWrapper(provider, config.GetSettings())
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := wrappers.LoadOrStore(provider, wrapper)
+	return actual.(KeyWrapper), nil
+}
+
+func newWrapper(provider string, settings *config.Settings) (KeyWrapper, error) {
+	switch provider {
+	case ProviderLocal:
+		if settings.EncryptionMasterKey == "" && settings.EncryptionMasterKeyFile != "" {
+			return NewMasterKeyFileWrapper(settings.EncryptionMasterKeyFile)
+		}
+		return NewMasterKeyWrapper(settings.EncryptionMasterKey)
+	case ProviderAWS:
+		return NewAWSKMSWrapper(settings)
+	case ProviderGCP:
+		return NewGCPKMSWrapper(settings)
+	case ProviderVault:
+		return NewVaultTransitWrapper(settings)
+	default:
+		return nil, fmt.Errorf("unknown encryption key provider %q", provider)
+	}
+}
+
+// Available reports whether encryption is configured on this server
+func Available() bool {
+	_, err := DefaultWrapper()
+	return err == nil
+}
+
+// NewKey generates a random key
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// sealKey encrypts key under kek, returning nonce | ciphertext
+func sealKey(kek, key []byte) ([]byte, error) {
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, key, nil), nil
+}
+
+// openKey decrypts a key sealed by sealKey
+func openKey(kek, wrapped []byte) ([]byte, error) {
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrCorrupted
+	}
+	key, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return key, nil
+}
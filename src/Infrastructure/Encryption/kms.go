@@ -0,0 +1,56 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// kmsTimeout bounds a single wrap or unwrap call to an external key service
+const kmsTimeout = 30 * time.Second
+
+// kmsRequest posts a JSON body to a key service and decodes the JSON response into out.
+// sign, when set, is called with the finished request and its body before it is sent.
+func kmsRequest(client *http.Client, url string, headers map[string]string, body interface{}, out interface{}, sign func(*http.Request, []byte)) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode key service request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create key service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if sign != nil {
+		sign(req, payload)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("key service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read key service response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("key service request failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode key service response: %w", err)
+	}
+	return nil
+}
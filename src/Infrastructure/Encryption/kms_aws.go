@@ -0,0 +1,87 @@
+package encryption
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/S3"
+)
+
+// awsKMSWrapper wraps keys with an AWS KMS key through the KMS JSON API
+type awsKMSWrapper struct {
+	keyID      string
+	endpoint   string
+	region     string
+	creds      s3.Credentials
+	httpClient *http.Client
+}
+
+// NewAWSKMSWrapper returns a wrapper using the AWS KMS key ENCRYPTION_KMS_KEY_ID
+func NewAWSKMSWrapper(settings *config.Settings) (KeyWrapper, error) {
+	if settings.EncryptionKMSKeyID == "" {
+		return nil, fmt.Errorf("aws-kms key provider requires ENCRYPTION_KMS_KEY_ID")
+	}
+	if settings.AWSAccessKeyID == "" || settings.AWSSecretAccessKey == "" {
+		return nil, fmt.Errorf("aws-kms key provider requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := settings.EncryptionKMSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", settings.AWSRegion)
+	}
+
+	return &awsKMSWrapper{
+		keyID:    settings.EncryptionKMSKeyID,
+		endpoint: strings.TrimRight(endpoint, "/") + "/",
+		region:   settings.AWSRegion,
+		creds: s3.Credentials{
+			AccessKey:    settings.AWSAccessKeyID,
+			SecretKey:    settings.AWSSecretAccessKey,
+			SessionToken: settings.AWSSessionToken,
+		},
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (w *awsKMSWrapper) Provider() string {
+	return ProviderAWS
+}
+
+func (w *awsKMSWrapper) Wrap(key []byte) ([]byte, error) {
+	var resp struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	if err := w.call("TrentService.Encrypt", map[string]interface{}{
+		"KeyId":     w.keyID,
+		"Plaintext": key,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.CiphertextBlob, nil
+}
+
+func (w *awsKMSWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	if err := w.call("TrentService.Decrypt", map[string]interface{}{
+		"KeyId":          w.keyID,
+		"CiphertextBlob": wrapped,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// call invokes a KMS action, []byte fields are sent and received base64 encoded as KMS expects
+func (w *awsKMSWrapper) call(target string, body interface{}, out interface{}) error {
+	headers := map[string]string{
+		"Content-Type": "application/x-amz-json-1.1",
+		"X-Amz-Target": target,
+	}
+	return kmsRequest(w.httpClient, w.endpoint, headers, body, out, func(req *http.Request, payload []byte) {
+		s3.SignRequest(req, payload, w.creds, w.region, "kms")
+	})
+}
@@ -0,0 +1,190 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"shbucket/src/Infrastructure/Config"
+)
+
+const (
+	gcpKMSEndpoint   = "https://cloudkms.googleapis.com/v1/"
+	gcpKMSScope      = "https://www.googleapis.com/auth/cloudkms"
+	gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// gcpKMSWrapper wraps keys with a Cloud KMS crypto key. It authenticates with a service account key
+// file when one is configured, otherwise with the identity of the GCE/GKE instance it runs on.
+type gcpKMSWrapper struct {
+	keyName        string
+	serviceAccount *gcpServiceAccount
+	httpClient     *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// gcpServiceAccount holds the fields of a service account key file used to request tokens
+type gcpServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewGCPKMSWrapper returns a wrapper using the Cloud KMS crypto key ENCRYPTION_KMS_KEY_ID
+func NewGCPKMSWrapper(settings *config.Settings) (KeyWrapper, error) {
+	if !strings.HasPrefix(settings.EncryptionKMSKeyID, "projects/") {
+		return nil, fmt.Errorf("gcp-kms key provider requires ENCRYPTION_KMS_KEY_ID as projects/.../cryptoKeys/...")
+	}
+
+	wrapper := &gcpKMSWrapper{
+		keyName:    settings.EncryptionKMSKeyID,
+		httpClient: &http.Client{},
+	}
+
+	if settings.GoogleCredentialsFile != "" {
+		data, err := os.ReadFile(settings.GoogleCredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		var account gcpServiceAccount
+		if err := json.Unmarshal(data, &account); err != nil || account.ClientEmail == "" || account.PrivateKey == "" {
+			return nil, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS must be a service account key file")
+		}
+		if account.TokenURI == "" {
+			account.TokenURI = "https://oauth2.googleapis.com/token"
+		}
+		wrapper.serviceAccount = &account
+	}
+
+	return wrapper, nil
+}
+
+func (w *gcpKMSWrapper) Provider() string {
+	return ProviderGCP
+}
+
+func (w *gcpKMSWrapper) Wrap(key []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := w.call("encrypt", map[string][]byte{"plaintext": key}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+func (w *gcpKMSWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	if err := w.call("decrypt", map[string][]byte{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+func (w *gcpKMSWrapper) call(operation string, body interface{}, out interface{}) error {
+	token, err := w.token()
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	return kmsRequest(w.httpClient, gcpKMSEndpoint+w.keyName+":"+operation, headers, body, out, nil)
+}
+
+// token returns a cached access token, requesting a new one shortly before it expires
+func (w *gcpKMSWrapper) token() (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.accessToken != "" && time.Now().Before(w.expiresAt.Add(-time.Minute)) {
+		return w.accessToken, nil
+	}
+
+	var (
+		resp struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		err error
+	)
+	if w.serviceAccount != nil {
+		err = w.serviceAccountToken(&resp)
+	} else {
+		err = w.metadataToken(&resp)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get Google access token: %w", err)
+	}
+
+	w.accessToken = resp.AccessToken
+	w.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return w.accessToken, nil
+}
+
+// serviceAccountToken exchanges a JWT signed with the service account's key for an access token
+func (w *gcpKMSWrapper) serviceAccountToken(out interface{}) error {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(w.serviceAccount.PrivateKey))
+	if err != nil {
+		return fmt.Errorf("invalid service account private key: %w", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   w.serviceAccount.ClientEmail,
+		"scope": gcpKMSScope,
+		"aud":   w.serviceAccount.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign token request: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", w.serviceAccount.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return w.doToken(req, out)
+}
+
+// metadataToken asks the metadata server for a token of the instance's service account
+func (w *gcpKMSWrapper) metadataToken(out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", gcpMetadataToken, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return w.doToken(req, out)
+}
+
+func (w *gcpKMSWrapper) doToken(req *http.Request, out interface{}) error {
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,69 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"shbucket/src/Infrastructure/Config"
+)
+
+// vaultTransitWrapper wraps keys with a HashiCorp Vault transit key
+type vaultTransitWrapper struct {
+	baseURL    string
+	keyName    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewVaultTransitWrapper returns a wrapper using the transit key ENCRYPTION_KMS_KEY_ID of the Vault at VAULT_ADDR
+func NewVaultTransitWrapper(settings *config.Settings) (KeyWrapper, error) {
+	if settings.EncryptionKMSKeyID == "" {
+		return nil, fmt.Errorf("vault key provider requires ENCRYPTION_KMS_KEY_ID")
+	}
+	if settings.VaultAddr == "" || settings.VaultToken == "" {
+		return nil, fmt.Errorf("vault key provider requires VAULT_ADDR and VAULT_TOKEN")
+	}
+
+	return &vaultTransitWrapper{
+		baseURL:    fmt.Sprintf("%s/v1/%s", strings.TrimRight(settings.VaultAddr, "/"), strings.Trim(settings.VaultTransitMount, "/")),
+		keyName:    settings.EncryptionKMSKeyID,
+		token:      settings.VaultToken,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (w *vaultTransitWrapper) Provider() string {
+	return ProviderVault
+}
+
+// Wrap returns Vault's ciphertext ("vault:v1:..."), which names the transit key version that wrapped it
+func (w *vaultTransitWrapper) Wrap(key []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := w.call("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)}, &resp); err != nil {
+		return nil, err
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (w *vaultTransitWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := w.call("decrypt", map[string]string{"ciphertext": string(wrapped)}, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+func (w *vaultTransitWrapper) call(operation string, body interface{}, out interface{}) error {
+	url := fmt.Sprintf("%s/%s/%s", w.baseURL, operation, w.keyName)
+	return kmsRequest(w.httpClient, url, map[string]string{"X-Vault-Token": w.token}, body, out, nil)
+}
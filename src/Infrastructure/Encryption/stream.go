@@ -0,0 +1,187 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stored content is encrypted in fixed-size chunks, each sealed with AES-256-GCM, so files of any
+// size can be streamed in both directions. Every chunk's nonce carries its position and whether
+// it is the last one, which detects reordered, dropped or truncated chunks.
+//
+// Layout: magic (4) | nonce prefix (7) | chunk... where a chunk is up to chunkSize bytes plus the GCM tag.
+const (
+	streamMagic     = "SHE1"
+	chunkSize       = 64 * 1024
+	noncePrefixSize = 7
+	headerSize      = len(streamMagic) + noncePrefixSize
+)
+
+// ErrCorrupted is returned when encrypted content fails authentication
+var ErrCorrupted = errors.New("encrypted content is corrupted or was encrypted with a different key")
+
+// EncryptedSize returns the stored size of content of the given plaintext size
+func EncryptedSize(size int64) int64 {
+	chunks := size / chunkSize
+	if size%chunkSize != 0 || size == 0 {
+		chunks++
+	}
+	return int64(headerSize) + size + chunks*16
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type encryptingReader struct {
+	source  io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte // plaintext read ahead, one byte more than a chunk to spot the last chunk
+	carry   int    // bytes of buf carried over from the previous read
+	out     bytes.Buffer
+	done    bool
+}
+
+// EncryptReader returns a reader producing the encrypted form of source under key
+func EncryptReader(source io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	r := &encryptingReader{source: source, aead: aead, prefix: prefix, buf: make([]byte, chunkSize+1)}
+	r.out.WriteString(streamMagic)
+	r.out.Write(prefix)
+	return r, nil
+}
+
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+	return r.out.Read(p)
+}
+
+func (r *encryptingReader) sealNext() error {
+	n, err := io.ReadFull(r.source, r.buf[r.carry:])
+	n += r.carry
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	last := n <= chunkSize
+	plain := r.buf[:min(n, chunkSize)]
+	r.out.Write(r.aead.Seal(nil, chunkNonce(r.prefix, r.counter, last), plain, nil))
+	r.counter++
+
+	if last {
+		r.done = true
+		return nil
+	}
+	// Keep the read-ahead byte for the next chunk
+	r.buf[0] = r.buf[chunkSize]
+	r.carry = 1
+	return nil
+}
+
+type decryptingReader struct {
+	source  io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	carry   int
+	out     bytes.Buffer
+	done    bool
+}
+
+// DecryptReader returns a reader producing the plaintext of content encrypted by EncryptReader under key
+func DecryptReader(source io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(source, header); err != nil || string(header[:len(streamMagic)]) != streamMagic {
+		return nil, ErrCorrupted
+	}
+
+	sealedSize := chunkSize + aead.Overhead()
+	return &decryptingReader{
+		source: source,
+		aead:   aead,
+		prefix: header[len(streamMagic):],
+		buf:    make([]byte, sealedSize+1),
+	}, nil
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openNext(); err != nil {
+			return 0, err
+		}
+	}
+	return r.out.Read(p)
+}
+
+func (r *decryptingReader) openNext() error {
+	sealedSize := len(r.buf) - 1
+
+	n, err := io.ReadFull(r.source, r.buf[r.carry:])
+	n += r.carry
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	last := n <= sealedSize
+	plain, err := r.aead.Open(nil, chunkNonce(r.prefix, r.counter, last), r.buf[:min(n, sealedSize)], nil)
+	if err != nil {
+		return ErrCorrupted
+	}
+	r.out.Write(plain)
+	r.counter++
+
+	if last {
+		r.done = true
+		return nil
+	}
+	r.buf[0] = r.buf[sealedSize]
+	r.carry = 1
+	return nil
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Event types recorded in the bucket event log
+const (
+	FileUploaded  = "file.uploaded"
+	FileDeleted   = "file.deleted"
+	FileShared    = "file.shared"
+
+	FileTokenCreated = "file.token_created"
+	FileTokenRevoked = "file.token_revoked"
+
+	FileRenamed = "file.renamed"
+
+	FileAliasSet     = "file.alias_set"
+	FileAliasDeleted = "file.alias_deleted"
+
+	FileLockUpdated    = "file.lock_updated"
+	FileLockOverridden = "file.lock_overridden"
+
+	UploadGrantCreated = "bucket.upload_grant_created"
+	UploadGrantRevoked = "bucket.upload_grant_revoked"
+
+	BucketCreated = "bucket.created"
+	BucketUpdated = "bucket.updated"
+	BucketDeleted = "bucket.deleted"
+
+	BucketAdminGranted  = "bucket.admin_granted"
+	BucketAdminRevoked  = "bucket.admin_revoked"
+	BucketAPIKeyRevoked = "bucket.api_key_revoked"
+
+	BucketKeyRotationStarted   = "bucket.key_rotation_started"
+	BucketKeyRotationCompleted = "bucket.key_rotation_completed"
+
+	BucketSaturated          = "bucket.saturated"
+	BucketSaturationResolved = "bucket.saturation_resolved"
+
+	BucketUsageAlert         = "bucket.usage_alert"
+	BucketUsageAlertResolved = "bucket.usage_alert_resolved"
+
+	CommentCreated = "comment.created"
+)
+
+// Publisher appends events to the bucket event log
+type Publisher struct {
+	dbContext *persistence.AppDbContext
+}
+
+func NewPublisher(dbContext *persistence.AppDbContext) *Publisher {
+	return &Publisher{dbContext: dbContext}
+}
+
+// Publish records an event for a bucket and queues its delivery to the bucket's webhooks. Failures are
+// logged rather than returned so that the operation which triggered the event is never rolled back
+// because of the event log.
+func (p *Publisher) Publish(eventType string, bucketID uuid.UUID, fileID *uuid.UUID, actorID uuid.UUID, data map[string]interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Warning: failed to marshal %s event for bucket %s: %v", eventType, bucketID, err)
+		return
+	}
+
+	event := entities.BucketEvent{
+		Id:        uuid.New(),
+		BucketId:  bucketID,
+		Type:      eventType,
+		FileId:    fileID,
+		ActorId:   actorID,
+		Data:      datatypes.JSON(payload),
+		CreatedAt: time.Now(),
+	}
+
+	p.dbContext.BucketEvents.Add(event)
+	if err := p.dbContext.SaveChanges(); err != nil {
+		log.Printf("Warning: failed to record %s event for bucket %s: %v", eventType, bucketID, err)
+		return
+	}
+
+	p.queueDeliveries(&event)
+}
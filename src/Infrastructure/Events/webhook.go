@@ -0,0 +1,133 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Jobs"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+// Headers sent with every webhook delivery
+const (
+	HeaderEvent     = "X-SHBucket-Event"
+	HeaderDelivery  = "X-SHBucket-Delivery" // the event ID, the same for every attempt and replay of an event
+	HeaderTimestamp = "X-SHBucket-Timestamp"
+	HeaderSignature = "X-SHBucket-Signature"
+	HeaderReplay    = "X-SHBucket-Replay"
+)
+
+// SignatureVersion prefixes signatures, so the scheme can change without breaking receivers
+const SignatureVersion = "v1"
+
+// DeliveryPayload is the payload of a webhook delivery job
+type DeliveryPayload struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	EventID   uuid.UUID `json:"event_id"`
+}
+
+// Sign returns the signature of a delivery: the HMAC-SHA256, keyed with the webhook's secret, of
+// the Unix timestamp in seconds, a dot and the body
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SignatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ToEventResponse is an event as it is delivered and listed
+func ToEventResponse(event *entities.BucketEvent) models.BucketEventResponse {
+	return models.BucketEventResponse{
+		ID:        event.Id,
+		Type:      event.Type,
+		BucketID:  event.BucketId,
+		FileID:    event.FileId,
+		ActorID:   event.ActorId,
+		Data:      utils.ConvertJSONToMap(event.Data),
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+// NewDeliveryRequest builds the request delivering an event to url. Deliveries are signed when a
+// secret is given, with the current time so a receiver can refuse stale ones.
+func NewDeliveryRequest(ctx context.Context, url, secret string, event *entities.BucketEvent, replay bool) (*http.Request, error) {
+	body, err := json.Marshal(ToEventResponse(event))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, event.Id.String())
+	if replay {
+		req.Header.Set(HeaderReplay, "true")
+	}
+	if secret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	}
+	return req, nil
+}
+
+// Subscribed reports whether a webhook receives events of a type
+func Subscribed(webhook *entities.BucketWebhook, eventType string) bool {
+	var types []string
+	if len(webhook.EventTypes) > 0 {
+		json.Unmarshal(webhook.EventTypes, &types)
+	}
+	return len(types) == 0 || slices.Contains(types, eventType)
+}
+
+// queueDeliveries queues a delivery job for every webhook of the event's bucket subscribed to it
+func (p *Publisher) queueDeliveries(event *entities.BucketEvent) {
+	webhooks, err := p.dbContext.BucketWebhooks.Where(&entities.BucketWebhook{BucketId: event.BucketId}).ToList()
+	if err != nil {
+		log.Printf("Warning: failed to load webhooks of bucket %s: %v", event.BucketId, err)
+		return
+	}
+
+	queued := 0
+	for i := range webhooks {
+		if !Subscribed(&webhooks[i], event.Type) {
+			continue
+		}
+		job, err := jobs.New(jobs.TypeWebhookDelivery, DeliveryPayload{WebhookID: webhooks[i].Id, EventID: event.Id}, jobs.Options{
+			BucketID:    &event.BucketId,
+			CreatedBy:   event.ActorId,
+			MaxAttempts: config.GetSettings().WebhookMaxAttempts,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to queue delivery of event %s: %v", event.Id, err)
+			continue
+		}
+		p.dbContext.Jobs.Add(*job)
+		queued++
+	}
+	if queued == 0 {
+		return
+	}
+	if err := p.dbContext.SaveChanges(); err != nil {
+		log.Printf("Warning: failed to queue webhook deliveries of event %s: %v", event.Id, err)
+	}
+}
@@ -0,0 +1,153 @@
+// Package headers checks the response headers buckets and files set for the content they serve,
+// and works out the headers a file is served with
+package headers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+const (
+	// MaxHeaders is how many headers a bucket or a file may set
+	MaxHeaders = 20
+	// maxValueLength bounds a header's value
+	maxValueLength = 1024
+)
+
+// Header names with their own handling when a file is served
+const (
+	CacheControl       = "Cache-Control"
+	ContentDisposition = "Content-Disposition"
+	ContentEncoding    = "Content-Encoding"
+)
+
+// ErrInvalidHeader is returned for headers that can't be set, or whose values aren't valid
+var ErrInvalidHeader = apierror.New(apierror.CodeInvalidRequest, "invalid response header")
+
+// standard are the standard response headers content may set. Content-Type comes from the file's
+// MIME type, CORS headers from the bucket's CORS rules, and the rest are the server's own.
+var standard = map[string]bool{
+	CacheControl:       true,
+	ContentDisposition: true,
+	ContentEncoding:    true,
+	"Content-Language": true,
+	"Expires":          true,
+	"Link":             true,
+	"X-Robots-Tag":     true,
+	"X-Frame-Options":  true,
+}
+
+// reserved are prefixes of X- headers the server sets itself
+var reserved = []string{"X-Amz-", "X-Shbucket-", "X-Ratelimit-", "X-Content-Type-Options", "X-Request-Id"}
+
+// Normalize checks the headers a bucket or a file sets and returns them with canonical names. Any
+// X- header not reserved by the server may be set besides the standard ones, with a single line
+// value.
+func Normalize(headers map[string]string) (map[string]string, error) {
+	if len(headers) > MaxHeaders {
+		return nil, fmt.Errorf("%w: at most %d headers can be set", ErrInvalidHeader, MaxHeaders)
+	}
+	normalized := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !settable(name) {
+			return nil, fmt.Errorf("%w: %s can't be set", ErrInvalidHeader, name)
+		}
+		if value == "" || len(value) > maxValueLength || strings.ContainsAny(value, "\r\n\x00") {
+			return nil, fmt.Errorf("%w: %s needs a single line value of at most %d bytes", ErrInvalidHeader, name, maxValueLength)
+		}
+		if name == ContentDisposition {
+			if kind := dispositionType(value); kind != "inline" && kind != "attachment" {
+				return nil, fmt.Errorf("%w: Content-Disposition must be inline or attachment", ErrInvalidHeader)
+			}
+		}
+		if _, duplicate := normalized[name]; duplicate {
+			return nil, fmt.Errorf("%w: %s is set twice", ErrInvalidHeader, name)
+		}
+		normalized[name] = value
+	}
+	return normalized, nil
+}
+
+func settable(name string) bool {
+	if standard[name] {
+		return true
+	}
+	if !strings.HasPrefix(name, "X-") || len(name) < 3 {
+		return false
+	}
+	for _, prefix := range reserved {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	for _, r := range name {
+		if !(r == '-' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func dispositionType(value string) string {
+	kind, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(kind))
+}
+
+// Decode reads stored headers, none when there are none or they can't be read
+func Decode(data datatypes.JSON) map[string]string {
+	headers := map[string]string{}
+	if len(data) > 0 {
+		json.Unmarshal(data, &headers)
+	}
+	return headers
+}
+
+// Encode stores headers
+func Encode(headers map[string]string) datatypes.JSON {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return datatypes.JSON("{}")
+	}
+	return datatypes.JSON(data)
+}
+
+// Resolve returns the headers a file is served with: the bucket's defaults, overridden by the
+// file's Cache-Control and Content-Disposition metadata and then by the file's own headers. A
+// Content-Disposition without a filename gets the file's name.
+func Resolve(defaults map[string]string, cacheControl, contentDisposition string, own map[string]string, filename string) map[string]string {
+	resolved := make(map[string]string, len(defaults)+len(own)+2)
+	for name, value := range defaults {
+		resolved[name] = value
+	}
+	if cacheControl != "" {
+		resolved[CacheControl] = cacheControl
+	}
+	if contentDisposition != "" {
+		resolved[ContentDisposition] = contentDisposition
+	}
+	for name, value := range own {
+		resolved[name] = value
+	}
+	if disposition, ok := resolved[ContentDisposition]; ok && !strings.Contains(strings.ToLower(disposition), "filename") {
+		resolved[ContentDisposition] = fmt.Sprintf("%s; filename=\"%s\"", dispositionType(disposition), filename)
+	}
+	return resolved
+}
+
+// Private reports whether a Cache-Control value keeps content out of shared caches, as content
+// that needs authentication must be
+func Private(cacheControl string) bool {
+	value := strings.ToLower(cacheControl)
+	return strings.Contains(value, "private") || strings.Contains(value, "no-store")
+}
@@ -0,0 +1,101 @@
+package hotlink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// ErrNotAllowed is returned for requests from pages a bucket doesn't let embed its files
+var ErrNotAllowed = apierror.New(apierror.CodeForbidden, "hotlinking not allowed")
+
+// Normalize checks the patterns of the sites allowed to embed a bucket's files and returns them as
+// lowercase host names. A pattern is a host name such as "example.com", which a URL or origin like
+// "https://example.com" is reduced to, or "*.example.com" for its subdomains.
+func Normalize(patterns []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if strings.Contains(pattern, "://") {
+			parsed, err := url.Parse(pattern)
+			if err != nil || parsed.Hostname() == "" {
+				return nil, apierror.Newf(apierror.CodeInvalidRequest, "invalid referer pattern %q", pattern)
+			}
+			pattern = parsed.Hostname()
+		}
+		host := strings.TrimPrefix(pattern, "*.")
+		if host == "" || strings.ContainsAny(host, "*/:@ ") {
+			return nil, apierror.Newf(apierror.CodeInvalidRequest, "invalid referer pattern %q, use a host name like example.com or *.example.com", pattern)
+		}
+		if !seen[pattern] {
+			seen[pattern] = true
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized, nil
+}
+
+// Check returns why a request with the Origin and Referer headers is refused by the allowed
+// patterns, nil when it isn't or no patterns are set. The Origin wins when both are sent. A
+// request with neither, such as a direct visit, is refused only with blockEmpty.
+func Check(allowed []string, blockEmpty bool, origin, referer string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	source := origin
+	if source == "" || source == "null" {
+		source = referer
+	}
+	if source == "" {
+		if blockEmpty {
+			return fmt.Errorf("%w: requests without a Referer or Origin header are refused", ErrNotAllowed)
+		}
+		return nil
+	}
+
+	parsed, err := url.Parse(source)
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Errorf("%w: the Referer or Origin header is malformed", ErrNotAllowed)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, pattern := range allowed {
+		if suffix, wildcard := strings.CutPrefix(pattern, "*."); wildcard {
+			if strings.HasSuffix(host, "."+suffix) {
+				return nil
+			}
+		} else if host == pattern {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w from %s", ErrNotAllowed, host)
+}
+
+// Decode reads stored patterns
+func Decode(data datatypes.JSON) []string {
+	patterns := []string{}
+	if len(data) > 0 {
+		json.Unmarshal(data, &patterns)
+	}
+	return patterns
+}
+
+// Encode stores patterns
+func Encode(patterns []string) datatypes.JSON {
+	if patterns == nil {
+		patterns = []string{}
+	}
+	data, err := json.Marshal(patterns)
+	if err != nil {
+		return datatypes.JSON("[]")
+	}
+	return datatypes.JSON(data)
+}
@@ -0,0 +1,108 @@
+package ipfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+
+	"gorm.io/datatypes"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// ErrDenied is returned for clients outside the ranges an API key or bucket allows
+var ErrDenied = apierror.New(apierror.CodeForbidden, "client IP not allowed")
+
+// Normalize checks a list of IP addresses and CIDR ranges and returns it as CIDR ranges, a single
+// address as a /32 or /128 range. Repeated ranges are dropped.
+func Normalize(entries []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[netip.Prefix]bool, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := parse(entry)
+		if err != nil {
+			return nil, apierror.Newf(apierror.CodeInvalidRequest, "invalid IP address or CIDR range %q", entry)
+		}
+		if !seen[prefix] {
+			seen[prefix] = true
+			normalized = append(normalized, prefix.String())
+		}
+	}
+	return normalized, nil
+}
+
+// Check returns why a client at ip is refused by the allow and deny lists, nil when it isn't. A
+// denied range wins over an allowed one, and an empty allow list allows every address not denied.
+func Check(ip string, allow, deny []string) error {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return fmt.Errorf("%w: client address %q is unknown", ErrDenied, ip)
+	}
+	addr = addr.Unmap()
+
+	for _, entry := range deny {
+		if prefix, err := parse(entry); err == nil && prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s is in the denied range %s", ErrDenied, addr, prefix)
+		}
+	}
+	if len(allow) == 0 {
+		return nil
+	}
+	for _, entry := range allow {
+		if prefix, err := parse(entry); err == nil && prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is outside the allowed ranges", ErrDenied, addr)
+}
+
+// Audit logs a client refused access to what
+func Audit(what, ip string, err error) {
+	log.Printf("Audit: refused %s to %s: %v", what, ip, err)
+}
+
+// Decode reads stored ranges
+func Decode(data datatypes.JSON) []string {
+	ranges := []string{}
+	if len(data) > 0 {
+		json.Unmarshal(data, &ranges)
+	}
+	return ranges
+}
+
+// Encode stores ranges
+func Encode(ranges []string) datatypes.JSON {
+	if ranges == nil {
+		ranges = []string{}
+	}
+	data, err := json.Marshal(ranges)
+	if err != nil {
+		return datatypes.JSON("[]")
+	}
+	return datatypes.JSON(data)
+}
+
+func parse(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,250 @@
+// Package internalapi defines the requests and responses of the internal endpoints the master stores,
+// reads and deletes content on storage nodes through. The master, the full server running as a node
+// and the node agent all use these types, so the roles can't drift apart without failing to compile.
+// It depends on nothing of the master's so the node agent can use it.
+package internalapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version is the version of the internal API spoken here. Requests carry it in VersionHeader, and a
+// node refuses requests of a newer version than its own. Requests without it come from masters
+// older than versioning and are served as version 1.
+const Version = 1
+
+// VersionHeader carries the internal API version of a request, and of a response's envelope
+const VersionHeader = "X-SHBucket-Internal-API"
+
+// Paths of the internal endpoints on a node
+const (
+	UploadPath = "/api/v1/internal/upload"
+	DeletePath = "/api/v1/internal/delete"
+	FilePath   = "/api/v1/internal/file"
+	// DiagnosticsPath answers with Diagnostics. Nodes older than it answer 404.
+	DiagnosticsPath = "/api/v1/internal/diagnostics"
+	// HealthPath answers any caller whether the node is up, it isn't authenticated
+	HealthPath = "/api/v1/health"
+)
+
+// FileField is the multipart part holding the content of an upload
+const FileField = "file"
+
+// Values looks up a request's query parameters or form fields by name, as fiber's Query and
+// FormValue do
+type Values func(key string, defaultValue ...string) string
+
+// ErrUnsupportedVersion is returned for requests of a newer internal API version than this one
+var ErrUnsupportedVersion = errors.New("unsupported internal API version")
+
+// Envelope wraps every JSON response of the internal endpoints. Error is set when Success isn't,
+// Data holds the endpoint's result otherwise.
+type Envelope[T any] struct {
+	APIVersion int    `json:"api_version"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Data       *T     `json:"data,omitempty"`
+}
+
+// Success wraps the result of a request that succeeded
+func Success[T any](message string, data T) Envelope[T] {
+	return Envelope[T]{APIVersion: Version, Success: true, Message: message, Data: &data}
+}
+
+// Failure wraps the error of a request that failed
+func Failure(message string) Envelope[struct{}] {
+	return Envelope[struct{}]{APIVersion: Version, Error: message}
+}
+
+// UploadRequest is the metadata of content stored on a node. It is sent as multipart fields next to
+// the FileField part, which a node may read before or after them.
+type UploadRequest struct {
+	BucketID    uuid.UUID `json:"bucket_id"`
+	BucketName  string    `json:"bucket_name"`
+	FileID      uuid.UUID `json:"file_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+}
+
+// WriteFields writes the request's fields to a multipart upload
+func (r UploadRequest) WriteFields(form *multipart.Writer) error {
+	for _, field := range [][2]string{
+		{"bucket_id", r.BucketID.String()},
+		{"bucket_name", r.BucketName},
+		{"file_id", r.FileID.String()},
+		{"filename", r.Filename},
+		{"content_type", r.ContentType},
+	} {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseUploadRequest reads an upload's metadata from its multipart fields
+func ParseUploadRequest(field Values) (UploadRequest, error) {
+	request := UploadRequest{
+		BucketName:  field("bucket_name"),
+		Filename:    field("filename"),
+		ContentType: field("content_type"),
+	}
+	if field("bucket_id") == "" || request.BucketName == "" || field("file_id") == "" || request.Filename == "" {
+		return request, fmt.Errorf("missing required metadata (bucket_id, bucket_name, file_id, filename)")
+	}
+	var err error
+	if request.BucketID, err = uuid.Parse(field("bucket_id")); err != nil {
+		return request, fmt.Errorf("invalid bucket ID format")
+	}
+	if request.FileID, err = uuid.Parse(field("file_id")); err != nil {
+		return request, fmt.Errorf("invalid file ID format")
+	}
+	return request, nil
+}
+
+// UploadResult is the Data of an upload's response
+type UploadResult struct {
+	FilePath string `json:"file_path"`
+	FileSize int64  `json:"file_size"`
+}
+
+// DeleteRequest names content to delete from a node, sent as query parameters
+type DeleteRequest struct {
+	BucketName string    `json:"bucket_name"`
+	FileID     uuid.UUID `json:"file_id"`
+}
+
+// Query returns the request's query parameters
+func (r DeleteRequest) Query() url.Values {
+	return url.Values{
+		"bucket_name": {r.BucketName},
+		"file_id":     {r.FileID.String()},
+	}
+}
+
+// ParseDeleteRequest reads a delete request from its query parameters. Masters older than
+// versioning send the file ID as file_name.
+func ParseDeleteRequest(query Values) (DeleteRequest, error) {
+	request := DeleteRequest{BucketName: query("bucket_name")}
+	fileID := query("file_id")
+	if fileID == "" {
+		fileID = query("file_name")
+	}
+	if request.BucketName == "" || fileID == "" {
+		return request, fmt.Errorf("missing required parameters (bucket_name, file_id)")
+	}
+	var err error
+	if request.FileID, err = uuid.Parse(fileID); err != nil {
+		return request, fmt.Errorf("invalid file ID format")
+	}
+	return request, nil
+}
+
+// DeleteResult is the Data of a delete's response
+type DeleteResult struct {
+	FilePath string `json:"file_path"`
+	Existed  bool   `json:"existed"` // false when there was nothing to delete
+}
+
+// FileRequest names content to read from a node, sent as query parameters. The response is the
+// content itself, or an Envelope when it fails.
+type FileRequest struct {
+	BucketID uuid.UUID `json:"bucket_id"`
+	FileID   uuid.UUID `json:"file_id"`
+	Filename string    `json:"filename"`
+}
+
+// Query returns the request's query parameters
+func (r FileRequest) Query() url.Values {
+	return url.Values{
+		"bucket_id": {r.BucketID.String()},
+		"file_id":   {r.FileID.String()},
+		"filename":  {r.Filename},
+	}
+}
+
+// ParseFileRequest reads a file request from its query parameters
+func ParseFileRequest(query Values) (FileRequest, error) {
+	request := FileRequest{Filename: query("filename")}
+	var err error
+	if request.BucketID, err = uuid.Parse(query("bucket_id")); err != nil {
+		return request, fmt.Errorf("invalid bucket ID format")
+	}
+	if request.FileID, err = uuid.Parse(query("file_id")); err != nil {
+		return request, fmt.Errorf("invalid file ID format")
+	}
+	return request, nil
+}
+
+// Diagnostics is the Data of a diagnostics response: what an operator looks at first when a node
+// misbehaves
+type Diagnostics struct {
+	Version     string    `json:"version"` // of the node's build, empty when it wasn't recorded
+	APIVersion  int       `json:"api_version"`
+	Time        time.Time `json:"time"` // the node's clock as it answered, for the master to work out skew
+	StoragePath string    `json:"storage_path"`
+	// Disk is the filesystem holding StoragePath, nil when the node can't tell
+	Disk         *DiskUsage `json:"disk,omitempty"`
+	DiskError    string     `json:"disk_error,omitempty"`
+	RecentErrors []LogEntry `json:"recent_errors"` // newest last
+}
+
+// DiskUsage is the space and inodes of a filesystem
+type DiskUsage struct {
+	TotalBytes  uint64 `json:"total_bytes"`
+	FreeBytes   uint64 `json:"free_bytes"` // available to the node, without blocks reserved for root
+	TotalInodes uint64 `json:"total_inodes"`
+	FreeInodes  uint64 `json:"free_inodes"`
+}
+
+// LogEntry is a line a node logged
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// Authorize authenticates a request to a node's internal endpoint with the node's auth key and
+// marks it with this version
+func Authorize(req *http.Request, authKey string) {
+	req.Header.Set("Authorization", "Bearer "+authKey)
+	req.Header.Set(VersionHeader, strconv.Itoa(Version))
+}
+
+// CheckVersion refuses requests of a newer internal API version than this one
+func CheckVersion(header string) error {
+	if header == "" {
+		return nil
+	}
+	version, err := strconv.Atoi(header)
+	if err != nil || version < 1 {
+		return fmt.Errorf("invalid internal API version %q", header)
+	}
+	if version > Version {
+		return fmt.Errorf("%w %d, this node speaks up to %d", ErrUnsupportedVersion, version, Version)
+	}
+	return nil
+}
+
+// ResponseError returns the error of a node's response that isn't a success, with the message of
+// its envelope when it has one
+func ResponseError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+		return nil
+	}
+	var envelope Envelope[struct{}]
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&envelope); err == nil && envelope.Error != "" {
+		return fmt.Errorf("node returned status %d: %s", resp.StatusCode, envelope.Error)
+	}
+	return fmt.Errorf("node returned status: %d", resp.StatusCode)
+}
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Job types
+const (
+	TypeBucketDelete    = "bucket.delete"
+	TypeNodeRepair      = "node.repair"
+	TypeAdminTask       = "admin.task"
+	TypeWebhookDelivery = "webhook.deliver"
+	TypeRebalance       = "storage.rebalance"
+)
+
+// Handler runs one attempt of a job. Returning an error retries the job later unless
+// the error is Permanent or the job has no attempts left.
+type Handler func(ctx context.Context, run *Run) error
+
+// Options describe a job being queued
+type Options struct {
+	BucketID    *uuid.UUID
+	CreatedBy   uuid.UUID
+	MaxAttempts int       // zero uses JOB_MAX_ATTEMPTS
+	RunAfter    time.Time // zero runs the job as soon as a worker is free
+}
+
+// New builds a queued job without saving it, so it can be saved together with the records it works on
+func New(jobType string, payload interface{}, opts Options) (*entities.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = max(config.GetSettings().JobMaxAttempts, 1)
+	}
+	runAfter := opts.RunAfter
+	if runAfter.IsZero() {
+		runAfter = time.Now()
+	}
+
+	return &entities.Job{
+		Id:          uuid.New(),
+		Type:        jobType,
+		Status:      StatusQueued,
+		Payload:     datatypes.JSON(data),
+		BucketId:    opts.BucketID,
+		MaxAttempts: maxAttempts,
+		CreatedBy:   opts.CreatedBy,
+		RunAfter:    runAfter,
+	}, nil
+}
+
+// Enqueue saves a new job for the runner to pick up
+func Enqueue(dbContext *persistence.AppDbContext, jobType string, payload interface{}, opts Options) (*entities.Job, error) {
+	job, err := New(jobType, payload, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dbContext.Jobs.Add(*job)
+	if err := dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+	return job, nil
+}
+
+// Active reports whether a job of the given type is queued or running for a bucket
+func Active(dbContext *persistence.AppDbContext, jobType string, bucketID uuid.UUID) (bool, error) {
+	return active(dbContext.GetDB(), jobType, bucketID)
+}
+
+func active(db *gorm.DB, jobType string, bucketID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&entities.Job{}).
+		Where(`"Type" = ? AND "BucketId" = ? AND "Status" IN ?`, jobType, bucketID, []string{StatusQueued, StatusRunning}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Run is a job attempt as seen by its handler
+type Run struct {
+	Job       *entities.Job
+	dbContext *persistence.AppDbContext
+	result    map[string]interface{}
+}
+
+// Decode reads the job's payload into v
+func (r *Run) Decode(v interface{}) error {
+	if err := json.Unmarshal(r.Job.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid job payload: %w", err))
+	}
+	return nil
+}
+
+// Progress records how much of the job is done, it also counts as a heartbeat
+func (r *Run) Progress(completed, total int64) {
+	r.Job.Completed = completed
+	r.Job.Total = total
+	now := time.Now()
+	r.dbContext.GetDB().Model(&entities.Job{}).Where(`"Id" = ?`, r.Job.Id).
+		Updates(map[string]interface{}{"Completed": completed, "Total": total, "HeartbeatAt": now})
+}
+
+// SetResult sets the result stored with the job once it completes
+func (r *Run) SetResult(result map[string]interface{}) {
+	r.result = result
+}
+
+// Final reports whether this is the job's last attempt, so a handler can record a final outcome
+func (r *Run) Final() bool {
+	return r.Job.Attempts >= r.Job.MaxAttempts
+}
+
+// permanentError marks a failure that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails without further attempts
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// maxRetryDelay caps the exponential backoff between attempts
+const maxRetryDelay = time.Hour
+
+// Runner runs queued jobs on a pool of workers. Jobs are claimed with row locks,
+// so several servers can share the queue and each job runs on one of them at a time.
+type Runner struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	handlers  map[string]Handler
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewRunner creates a new instance of Runner
+func NewRunner(dbContext *persistence.AppDbContext) *Runner {
+	return &Runner{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+		handlers:  make(map[string]Handler),
+	}
+}
+
+// Register sets the handler for a job type. Jobs of types without a handler stay queued.
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.handlers[jobType] = handler
+}
+
+// Start starts the workers and the recovery of jobs abandoned by a stopped server
+func (r *Runner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	workers := max(r.settings.JobWorkers, 1)
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.work(ctx)
+		}()
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.recover(ctx)
+	}()
+
+	log.Printf("Job runner started with %d worker(s)", workers)
+}
+
+// Stop interrupts running jobs and waits for the workers to exit. Interrupted jobs are queued
+// again without using up an attempt.
+func (r *Runner) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+		r.wg.Wait()
+	}
+}
+
+func (r *Runner) work(ctx context.Context) {
+	poll := time.Duration(max(r.settings.JobPollInterval, 1)) * time.Second
+	for {
+		job, err := r.claim()
+		if err != nil {
+			log.Printf("Job runner: failed to claim job: %v", err)
+		}
+		if job != nil {
+			r.execute(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poll):
+		}
+	}
+}
+
+// claim marks the next due job of a registered type as running and returns it
+func (r *Runner) claim() (*entities.Job, error) {
+	if len(r.handlers) == 0 {
+		return nil, nil
+	}
+	types := make([]string, 0, len(r.handlers))
+	for jobType := range r.handlers {
+		types = append(types, jobType)
+	}
+
+	return claimJob(r.dbContext.GetDB(), types, time.Now())
+}
+
+// claimJob marks the next job of one of types due at now as running and returns it
+func claimJob(db *gorm.DB, types []string, now time.Time) (*entities.Job, error) {
+	// SQLite has a single writer, so there are no other runners' rows to skip
+	locking := "FOR UPDATE SKIP LOCKED"
+	if persistence.IsSQLite(db) {
+		locking = ""
+	}
+
+	var jobs []entities.Job
+	err := db.Raw(`
+		UPDATE "Job" SET "Status" = ?, "Attempts" = "Attempts" + 1, "StartedAt" = ?, "HeartbeatAt" = ?
+		WHERE "Id" = (
+			SELECT "Id" FROM "Job"
+			WHERE "Status" = ? AND "RunAfter" <= ? AND "Type" IN ?
+			ORDER BY "RunAfter"
+			`+locking+`
+			LIMIT 1
+		)
+		RETURNING *`,
+		StatusRunning, now, now, StatusQueued, now, types).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+func (r *Runner) execute(ctx context.Context, job *entities.Job) {
+	run := &Run{Job: job, dbContext: r.dbContext}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	go r.heartbeat(heartbeatCtx, job)
+
+	err := r.invoke(ctx, run)
+	stopHeartbeat()
+
+	r.finish(r.dbContext.GetDB(), run, err, ctx.Err() != nil)
+}
+
+// finish saves the outcome of a job attempt that returned err. An interrupted attempt doesn't count.
+func (r *Runner) finish(db *gorm.DB, run *Run, err error, interrupted bool) {
+	job := run.Job
+	now := time.Now()
+	updates := map[string]interface{}{"HeartbeatAt": nil}
+
+	switch {
+	case err == nil:
+		updates["Status"] = StatusCompleted
+		updates["CompletedAt"] = now
+		updates["Error"] = ""
+		if run.result != nil {
+			if data, err := json.Marshal(run.result); err == nil {
+				updates["Result"] = datatypes.JSON(data)
+			}
+		}
+		log.Printf("Job %s (%s) completed", job.Id, job.Type)
+	case interrupted:
+		// Interrupted by shutdown, not by the job itself
+		updates["Status"] = StatusQueued
+		updates["Attempts"] = gorm.Expr(persistence.Greatest(db) + `("Attempts" - 1, 0)`)
+		updates["RunAfter"] = now
+	case isPermanent(err) || run.Final():
+		updates["Status"] = StatusFailed
+		updates["CompletedAt"] = now
+		updates["Error"] = err.Error()
+		log.Printf("Job %s (%s) failed after %d attempt(s): %v", job.Id, job.Type, job.Attempts, err)
+	default:
+		delay := retryDelay(r.settings.JobRetryDelay, job.Attempts)
+		updates["Status"] = StatusQueued
+		updates["Error"] = err.Error()
+		updates["RunAfter"] = now.Add(delay)
+		log.Printf("Job %s (%s) attempt %d failed, retrying in %s: %v", job.Id, job.Type, job.Attempts, delay, err)
+	}
+
+	if err := db.Model(&entities.Job{}).Where(`"Id" = ?`, job.Id).Updates(updates).Error; err != nil {
+		log.Printf("Job runner: failed to save outcome of job %s: %v", job.Id, err)
+	}
+}
+
+// invoke runs the job's handler, turning a panic into a failed attempt
+func (r *Runner) invoke(ctx context.Context, run *Run) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return r.handlers[run.Job.Type](ctx, run)
+}
+
+// heartbeat keeps a running job from being taken over while its handler works without reporting progress
+func (r *Runner) heartbeat(ctx context.Context, job *entities.Job) {
+	ticker := time.NewTicker(time.Duration(max(r.settings.JobStaleTimeout/3, 1)) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.dbContext.GetDB().Model(&entities.Job{}).Where(&entities.Job{Id: job.Id, Status: StatusRunning}).
+				Update("HeartbeatAt", time.Now())
+		}
+	}
+}
+
+// recover periodically hands jobs whose runner stopped heartbeating back to the queue
+func (r *Runner) recover(ctx context.Context) {
+	timeout := time.Duration(max(r.settings.JobStaleTimeout, 1)) * time.Second
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if recovered, err := recoverStalled(r.dbContext.GetDB(), now.Add(-timeout), now); err == nil && recovered > 0 {
+			log.Printf("Job runner: recovered %d stalled job(s)", recovered)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recoverStalled fails the running jobs that last heartbeated before cutoff and have no attempts
+// left, and queues the others again. It returns how many jobs it recovered.
+func recoverStalled(db *gorm.DB, cutoff, now time.Time) (int64, error) {
+	failed := db.Model(&entities.Job{}).
+		Where(`"Status" = ? AND "HeartbeatAt" < ? AND "Attempts" >= "MaxAttempts"`, StatusRunning, cutoff).
+		Updates(map[string]interface{}{"Status": StatusFailed, "CompletedAt": now, "Error": "job runner stopped responding"})
+	if failed.Error != nil {
+		return 0, failed.Error
+	}
+	requeued := db.Model(&entities.Job{}).
+		Where(`"Status" = ? AND "HeartbeatAt" < ? AND "Attempts" < "MaxAttempts"`, StatusRunning, cutoff).
+		Updates(map[string]interface{}{"Status": StatusQueued, "RunAfter": now})
+	if requeued.Error != nil {
+		return 0, requeued.Error
+	}
+	return failed.RowsAffected + requeued.RowsAffected, nil
+}
+
+// retryDelay doubles the base delay for every attempt made so far
+func retryDelay(baseSeconds, attempts int) time.Duration {
+	delay := time.Duration(max(baseSeconds, 1)) * time.Second
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+func createJob(t *testing.T, db *gorm.DB, job entities.Job) entities.Job {
+	t.Helper()
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatalf("failed to create job: %v", err)
+	}
+	return job
+}
+
+func loadJob(t *testing.T, db *gorm.DB, id uuid.UUID) entities.Job {
+	t.Helper()
+	var job entities.Job
+	if err := db.First(&job, `"Id" = ?`, id).Error; err != nil {
+		t.Fatalf("failed to load job %s: %v", id, err)
+	}
+	return job
+}
+
+// TestClaimJob claims the due job of a handled type that was due first, once
+func TestClaimJob(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	createJob(t, db, entities.Job{Type: TypeNodeRepair, Status: StatusQueued, RunAfter: now.Add(-2 * time.Hour)})
+	createJob(t, db, entities.Job{Type: TypeBucketDelete, Status: StatusQueued, RunAfter: now.Add(time.Hour)})
+	second := createJob(t, db, entities.Job{Type: TypeBucketDelete, Status: StatusQueued, RunAfter: now.Add(-time.Minute)})
+	first := createJob(t, db, entities.Job{Type: TypeBucketDelete, Status: StatusQueued, RunAfter: now.Add(-time.Hour)})
+
+	types := []string{TypeBucketDelete}
+	for _, want := range []uuid.UUID{first.Id, second.Id} {
+		job, err := claimJob(db, types, now)
+		if err != nil {
+			t.Fatalf("claimJob() = %v", err)
+		}
+		if job == nil || job.Id != want || job.Status != StatusRunning || job.Attempts != 1 || job.HeartbeatAt == nil {
+			t.Fatalf("claimJob() = %+v, want job %s running on its first attempt", job, want)
+		}
+	}
+	if job, err := claimJob(db, types, now); err != nil || job != nil {
+		t.Errorf("claimJob() without due jobs = %+v, %v, want nil", job, err)
+	}
+}
+
+// TestFinish queues failed attempts again until the job has no attempts left, and doesn't count interrupted ones
+func TestFinish(t *testing.T) {
+	db := sqlitetest.Open(t)
+	r := &Runner{settings: &config.Settings{JobRetryDelay: 60}}
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		attempts     int
+		err          error
+		interrupted  bool
+		wantStatus   string
+		wantAttempts int
+	}{
+		{"completed", 1, nil, false, StatusCompleted, 1},
+		{"retried", 1, errors.New("node unreachable"), false, StatusQueued, 1},
+		{"out of attempts", 3, errors.New("node unreachable"), false, StatusFailed, 3},
+		{"permanent", 1, Permanent(errors.New("bad payload")), false, StatusFailed, 1},
+		{"interrupted", 2, context.Canceled, true, StatusQueued, 1},
+	}
+	for _, tt := range tests {
+		job := createJob(t, db, entities.Job{Type: TypeNodeRepair, Status: StatusRunning, Attempts: tt.attempts, MaxAttempts: 3, RunAfter: now, HeartbeatAt: &now})
+		r.finish(db, &Run{Job: &job, result: map[string]interface{}{"repaired": 1}}, tt.err, tt.interrupted)
+
+		stored := loadJob(t, db, job.Id)
+		if stored.Status != tt.wantStatus || stored.Attempts != tt.wantAttempts || stored.HeartbeatAt != nil {
+			t.Errorf("%s: finish() saved status %q with %d attempt(s) and heartbeat %v, want %q with %d and no heartbeat",
+				tt.name, stored.Status, stored.Attempts, stored.HeartbeatAt, tt.wantStatus, tt.wantAttempts)
+		}
+		if tt.err != nil && !tt.interrupted && stored.Error != tt.err.Error() {
+			t.Errorf("%s: finish() saved error %q, want %q", tt.name, stored.Error, tt.err.Error())
+		}
+		if tt.wantStatus == StatusQueued && tt.err != nil && !tt.interrupted && !stored.RunAfter.After(now) {
+			t.Errorf("%s: finish() queued the job to run after %v, want a retry delay", tt.name, stored.RunAfter)
+		}
+	}
+}
+
+// TestRecoverStalled hands running jobs that stopped heartbeating back to the queue or fails them
+func TestRecoverStalled(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	stale, fresh := now.Add(-time.Hour), now.Add(-time.Second)
+	retried := createJob(t, db, entities.Job{Type: TypeNodeRepair, Status: StatusRunning, Attempts: 1, MaxAttempts: 3, RunAfter: stale, HeartbeatAt: &stale})
+	failed := createJob(t, db, entities.Job{Type: TypeNodeRepair, Status: StatusRunning, Attempts: 3, MaxAttempts: 3, RunAfter: stale, HeartbeatAt: &stale})
+	running := createJob(t, db, entities.Job{Type: TypeNodeRepair, Status: StatusRunning, Attempts: 1, MaxAttempts: 3, RunAfter: stale, HeartbeatAt: &fresh})
+
+	recovered, err := recoverStalled(db, now.Add(-time.Minute), now)
+	if err != nil {
+		t.Fatalf("recoverStalled() = %v", err)
+	}
+	if recovered != 2 {
+		t.Errorf("recoverStalled() = %d, want 2", recovered)
+	}
+	for id, want := range map[uuid.UUID]string{retried.Id: StatusQueued, failed.Id: StatusFailed, running.Id: StatusRunning} {
+		if job := loadJob(t, db, id); job.Status != want {
+			t.Errorf("job %d/%d with heartbeat %v status = %q, want %q", job.Attempts, job.MaxAttempts, job.HeartbeatAt, job.Status, want)
+		}
+	}
+}
+
+// TestActive finds queued and running jobs of a type for a bucket
+func TestActive(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID, otherID := uuid.New(), uuid.New()
+	createJob(t, db, entities.Job{Type: TypeBucketDelete, Status: StatusRunning, BucketId: &bucketID, RunAfter: time.Now()})
+	createJob(t, db, entities.Job{Type: TypeBucketDelete, Status: StatusCompleted, BucketId: &otherID, RunAfter: time.Now()})
+
+	for _, tt := range []struct {
+		jobType  string
+		bucketID uuid.UUID
+		want     bool
+	}{
+		{TypeBucketDelete, bucketID, true},
+		{TypeRebalance, bucketID, false},
+		{TypeBucketDelete, otherID, false},
+	} {
+		if got, err := active(db, tt.jobType, tt.bucketID); err != nil || got != tt.want {
+			t.Errorf("active(%s, %s) = %v, %v, want %v", tt.jobType, tt.bucketID, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+)
+
+// ErrNotConfigured is returned for sending email when no transport is configured
+var ErrNotConfigured = apierror.New(apierror.CodeUnavailable, "email isn't configured, set SMTP_HOST or MAIL_TRANSPORT")
+
+// Message is an email to one recipient, with a plain text and an HTML body
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport delivers messages from the sender address
+type Transport interface {
+	Send(ctx context.Context, from string, message Message) error
+}
+
+// Mailer renders the email templates and sends them with the configured transport
+type Mailer struct {
+	transport  Transport
+	from       string
+	systemName string
+	appURL     string
+}
+
+// NewMailer creates a mailer with the transport MAIL_TRANSPORT names. Without one, Enabled is
+// false and Send fails with ErrNotConfigured.
+func NewMailer(settings *config.Settings) *Mailer {
+	mailer := &Mailer{from: settings.MailFrom, systemName: settings.SystemName, appURL: strings.TrimRight(settings.AppURL, "/")}
+	switch settings.MailTransport {
+	case "smtp":
+		mailer.transport = &smtpTransport{
+			host:     settings.SMTPHost,
+			port:     settings.SMTPPort,
+			username: settings.SMTPUsername,
+			password: settings.SMTPPassword,
+			security: settings.SMTPSecurity,
+		}
+	case "console":
+		mailer.transport = consoleTransport{}
+	case "":
+	default:
+		log.Printf("Warning: unknown MAIL_TRANSPORT %q, no email is sent", settings.MailTransport)
+	}
+	return mailer
+}
+
+// Enabled reports whether email can be sent
+func (m *Mailer) Enabled() bool {
+	return m.transport != nil
+}
+
+// Link is a link into the web interface, for path and its query
+func (m *Mailer) Link(pathAndQuery string) string {
+	return m.appURL + pathAndQuery
+}
+
+// Send renders a template with data and sends it to the recipient. Every template can use
+// .SystemName besides data.
+func (m *Mailer) Send(ctx context.Context, to, template string, data map[string]interface{}) error {
+	if m.transport == nil {
+		return ErrNotConfigured
+	}
+
+	values := map[string]interface{}{"SystemName": m.systemName}
+	for key, value := range data {
+		values[key] = value
+	}
+	message, err := render(template, values)
+	if err != nil {
+		return err
+	}
+	message.To = to
+
+	if err := m.transport.Send(ctx, m.from, message); err != nil {
+		return fmt.Errorf("failed to send %s email: %w", template, err)
+	}
+	return nil
+}
+
+// consoleTransport writes messages to the log instead of sending them, for development
+type consoleTransport struct{}
+
+func (consoleTransport) Send(ctx context.Context, from string, message Message) error {
+	log.Printf("Email from %s to %s: %s\n%s", from, message.To, message.Subject, message.Text)
+	return nil
+}
@@ -0,0 +1,144 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds a whole delivery, from connecting to the server to its answer
+const smtpTimeout = time.Minute
+
+// smtpTransport sends messages through an SMTP server
+type smtpTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+	security string // "starttls", "tls" or "none"
+}
+
+func (t *smtpTransport) Send(ctx context.Context, from string, message Message) error {
+	body, err := compose(from, message)
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	if t.security == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: t.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s doesn't support STARTTLS, set SMTP_SECURITY to tls or none", address)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return err
+		}
+	}
+	if t.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose builds a multipart/alternative message with the text and HTML bodies
+func compose(from string, message Message) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	var composed bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", from},
+		{"To", message.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	} {
+		fmt.Fprintf(&composed, "%s: %s\r\n", header[0], header[1])
+	}
+	composed.WriteString("\r\n")
+	composed.Write(body.Bytes())
+	return composed.Bytes(), nil
+}
@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// Email templates
+const (
+	TemplateInvitation    = "invitation"
+	TemplatePasswordReset = "password_reset"
+	TemplateUsageAlert    = "usage_alert"
+	TemplateScannerAlert  = "scanner_alert"
+)
+
+// layout wraps the HTML body of every email
+const layout = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, 'Segoe UI', Helvetica, Arial, sans-serif; color: #1f2937; line-height: 1.5;">
+<div style="max-width: 560px; margin: 0 auto; padding: 24px;">
+{{template "body" .}}
+<p style="color: #6b7280; font-size: 12px; margin-top: 32px;">Sent by {{.SystemName}}</p>
+</div>
+</body>
+</html>`
+
+type template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+var templates = map[string]*template{
+	TemplateInvitation: parse(
+		`You're invited to {{.SystemName}}`,
+		`{{.InvitedBy}} invited you to {{.SystemName}} as {{.Role}}.
+
+Choose a username and password to create your account:
+{{.Link}}
+
+The invitation expires on {{.ExpiresAt}}.`,
+		`<p>{{.InvitedBy}} invited you to {{.SystemName}} as {{.Role}}.</p>
+<p><a href="{{.Link}}">Create your account</a> by choosing a username and password.</p>
+<p>The invitation expires on {{.ExpiresAt}}.</p>`),
+
+	TemplatePasswordReset: parse(
+		`Reset your {{.SystemName}} password`,
+		`Someone asked to reset the password of {{.Username}} on {{.SystemName}}. Choose a new password here:
+{{.Link}}
+
+The link expires on {{.ExpiresAt}}. If you didn't ask for it, ignore this email, your password stays as it is.`,
+		`<p>Someone asked to reset the password of {{.Username}} on {{.SystemName}}.</p>
+<p><a href="{{.Link}}">Choose a new password</a></p>
+<p>The link expires on {{.ExpiresAt}}. If you didn't ask for it, ignore this email, your password stays as it is.</p>`),
+
+	TemplateUsageAlert: parse(
+		`[{{.SystemName}}] {{.Summary}}`,
+		`{{.Summary}}.
+
+Used: {{.Used}} of {{.Capacity}} ({{printf "%.1f" .Utilization}}%)
+Alert threshold: {{.Threshold}}%`,
+		`<p>{{.Summary}}.</p>
+<p>Used: {{.Used}} of {{.Capacity}} ({{printf "%.1f" .Utilization}}%)<br>Alert threshold: {{.Threshold}}%</p>`),
+
+	TemplateScannerAlert: parse(
+		`[{{.SystemName}}] {{.Summary}}`,
+		`{{.Summary}}.
+
+Scanner: {{.Backend}}{{if .Engine}} ({{.Engine}}){{end}}
+Scans in the last hour: {{.RecentScans}}, {{.RecentFailures}} failed
+Uploads waiting on the scanner: {{.QueueDepth}}`,
+		`<p>{{.Summary}}.</p>
+<p>Scanner: {{.Backend}}{{if .Engine}} ({{.Engine}}){{end}}<br>Scans in the last hour: {{.RecentScans}}, {{.RecentFailures}} failed<br>Uploads waiting on the scanner: {{.QueueDepth}}</p>`),
+}
+
+func parse(subject, text, html string) *template {
+	return &template{
+		subject: texttemplate.Must(texttemplate.New("subject").Parse(subject)),
+		text:    texttemplate.Must(texttemplate.New("text").Parse(text)),
+		html:    htmltemplate.Must(htmltemplate.Must(htmltemplate.New("layout").Parse(layout)).New("body").Parse(html)),
+	}
+}
+
+// render fills in a template's subject and bodies
+func render(name string, data map[string]interface{}) (Message, error) {
+	t, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	return Message{Subject: subject.String(), Text: text.String(), HTML: html.String()}, nil
+}
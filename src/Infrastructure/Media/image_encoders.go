@@ -0,0 +1,150 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"shbucket/src/Infrastructure/Config"
+)
+
+// ImageEncoder encodes a decoded image into a single output format
+type ImageEncoder interface {
+	MimeType() string
+	Available() bool
+	Encode(img image.Image, quality int) ([]byte, error)
+}
+
+// ImageEncoders holds the output formats image transforms can produce
+type ImageEncoders struct {
+	encoders map[string]ImageEncoder
+}
+
+// NewImageEncoders registers the built-in JPEG/PNG encoders and the external WebP/AVIF encoders from settings
+func NewImageEncoders(settings *config.Settings) *ImageEncoders {
+	return &ImageEncoders{
+		encoders: map[string]ImageEncoder{
+			"jpeg": jpegEncoder{},
+			"png":  pngEncoder{},
+			"webp": &commandEncoder{mimeType: "image/webp", binary: settings.WebPEncoderPath, ext: ".webp"},
+			"avif": &commandEncoder{mimeType: "image/avif", binary: settings.AVIFEncoderPath, ext: ".avif"},
+		},
+	}
+}
+
+// NormalizeFormat maps a format name or alias to the name encoders are registered under
+func NormalizeFormat(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "jpg", "jpeg":
+		return "jpeg"
+	case "png":
+		return "png"
+	case "webp":
+		return "webp"
+	case "avif":
+		return "avif"
+	default:
+		return ""
+	}
+}
+
+// Get returns the encoder for a format if it is known and available on this server
+func (e *ImageEncoders) Get(format string) (ImageEncoder, bool) {
+	encoder, ok := e.encoders[NormalizeFormat(format)]
+	if !ok || !encoder.Available() {
+		return nil, false
+	}
+	return encoder, true
+}
+
+// Negotiate picks the most efficient modern format the client accepts and the limits allow, or "" to keep the default behaviour
+func (e *ImageEncoders) Negotiate(accept string, limits ImageLimits) string {
+	accept = strings.ToLower(accept)
+	for _, format := range []string{"avif", "webp"} {
+		if strings.Contains(accept, "image/"+format) && limits.FormatAllowed(format) {
+			if _, ok := e.Get(format); ok {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+type jpegEncoder struct{}
+
+func (jpegEncoder) MimeType() string { return "image/jpeg" }
+func (jpegEncoder) Available() bool  { return true }
+
+func (jpegEncoder) Encode(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	return buf.Bytes(), err
+}
+
+type pngEncoder struct{}
+
+func (pngEncoder) MimeType() string { return "image/png" }
+func (pngEncoder) Available() bool  { return true }
+
+func (pngEncoder) Encode(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// commandEncoder encodes through an external tool (cwebp, avifenc) invoked as: binary -q <quality> <input.png> -o <output>
+type commandEncoder struct {
+	mimeType string
+	binary   string
+	ext      string
+
+	once      sync.Once
+	available bool
+}
+
+func (e *commandEncoder) MimeType() string { return e.mimeType }
+
+func (e *commandEncoder) Available() bool {
+	e.once.Do(func() {
+		if e.binary == "" {
+			return
+		}
+		_, err := exec.LookPath(e.binary)
+		e.available = err == nil
+	})
+	return e.available
+}
+
+func (e *commandEncoder) Encode(img image.Image, quality int) ([]byte, error) {
+	tmpDir, err := os.MkdirTemp("", "shbucket-encode-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	input, err := (pngEncoder{}).Encode(img, quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare encoder input: %w", err)
+	}
+
+	inputPath := filepath.Join(tmpDir, "input.png")
+	outputPath := filepath.Join(tmpDir, "output"+e.ext)
+	if err := os.WriteFile(inputPath, input, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write encoder input: %w", err)
+	}
+
+	cmd := exec.Command(e.binary, "-q", strconv.Itoa(quality), inputPath, "-o", outputPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", filepath.Base(e.binary), err, strings.TrimSpace(string(output)))
+	}
+
+	return os.ReadFile(outputPath)
+}
@@ -0,0 +1,82 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/disintegration/imaging"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+)
+
+// ErrImageTooLarge is returned for images whose dimensions exceed the transform limits
+var ErrImageTooLarge = apierror.New(apierror.CodeUnprocessable, "image exceeds the transform limits")
+
+// ImageLimits bounds the work a single image transform may cause, so crafted images and
+// oversized width/height parameters can't exhaust memory or CPU
+type ImageLimits struct {
+	Enabled            bool
+	MaxInputDimension  int // 0 for no limit
+	MaxInputMegapixels int // 0 for no limit
+	MaxOutputDimension int // 0 for no limit
+	AllowedFormats     []string
+}
+
+// CurrentImageLimits returns the limits from the runtime settings, so changes apply to the next request
+func CurrentImageLimits() ImageLimits {
+	settings := config.GetRuntimeSettings()
+	limits := ImageLimits{
+		Enabled:            settings.ImageTransformsEnabled,
+		MaxInputDimension:  settings.ImageMaxInputDimension,
+		MaxInputMegapixels: settings.ImageMaxInputMegapixels,
+		MaxOutputDimension: settings.ImageMaxOutputDimension,
+	}
+	for _, format := range strings.Split(settings.ImageAllowedFormats, ",") {
+		if format = NormalizeFormat(format); format != "" {
+			limits.AllowedFormats = append(limits.AllowedFormats, format)
+		}
+	}
+	return limits
+}
+
+// FormatAllowed reports whether transforms may produce the given output format
+func (l ImageLimits) FormatAllowed(format string) bool {
+	return slices.Contains(l.AllowedFormats, NormalizeFormat(format))
+}
+
+// CheckOutput rejects output dimensions above the limit
+func (l ImageLimits) CheckOutput(width, height int) error {
+	if l.MaxOutputDimension > 0 && (width > l.MaxOutputDimension || height > l.MaxOutputDimension) {
+		return fmt.Errorf("%w: output is limited to %dx%d", ErrImageTooLarge, l.MaxOutputDimension, l.MaxOutputDimension)
+	}
+	return nil
+}
+
+// checkInput rejects source dimensions above the limits
+func (l ImageLimits) checkInput(width, height int) error {
+	if l.MaxInputDimension > 0 && (width > l.MaxInputDimension || height > l.MaxInputDimension) {
+		return fmt.Errorf("%w: source is %dx%d, the limit is %d pixels per side", ErrImageTooLarge, width, height, l.MaxInputDimension)
+	}
+	if l.MaxInputMegapixels > 0 && int64(width)*int64(height) > int64(l.MaxInputMegapixels)*1_000_000 {
+		return fmt.Errorf("%w: source is %dx%d, the limit is %d megapixels", ErrImageTooLarge, width, height, l.MaxInputMegapixels)
+	}
+	return nil
+}
+
+// Decode reads the image header first and refuses images beyond the input limits before their pixels are allocated
+func (l ImageLimits) Decode(r io.Reader) (image.Image, error) {
+	var header bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if err := l.checkInput(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+	return imaging.Decode(io.MultiReader(&header, r))
+}
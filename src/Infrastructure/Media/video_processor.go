@@ -0,0 +1,109 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// HLSRendition describes one quality level of an HLS stream
+type HLSRendition struct {
+	Name         string // directory name of the rendition, e.g. "720p"
+	Height       int
+	VideoBitrate int // bits per second
+	AudioBitrate int // bits per second
+}
+
+// DefaultHLSRenditions are the quality levels generated for uploaded videos
+var DefaultHLSRenditions = []HLSRendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800_000, AudioBitrate: 96_000},
+	{Name: "720p", Height: 720, VideoBitrate: 2_800_000, AudioBitrate: 128_000},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5_000_000, AudioBitrate: 192_000},
+}
+
+// HLSMasterPlaylist is the file name of the master playlist inside an HLS output directory
+const HLSMasterPlaylist = "master.m3u8"
+
+// VideoProcessor generates derived content for videos
+type VideoProcessor interface {
+	Available() bool
+	// Thumbnail writes a JPEG poster frame of the input video to output
+	Thumbnail(ctx context.Context, input, output string) error
+	// TranscodeHLS writes one playlist per rendition plus a master playlist into outputDir
+	TranscodeHLS(ctx context.Context, input, outputDir string, renditions []HLSRendition) error
+}
+
+// ffmpegProcessor implements VideoProcessor by invoking the ffmpeg binary
+type ffmpegProcessor struct {
+	binary string
+
+	once      sync.Once
+	available bool
+}
+
+// NewFFmpegProcessor creates a video processor backed by the ffmpeg binary at path
+func NewFFmpegProcessor(binary string) VideoProcessor {
+	return &ffmpegProcessor{binary: binary}
+}
+
+func (p *ffmpegProcessor) Available() bool {
+	p.once.Do(func() {
+		if p.binary == "" {
+			return
+		}
+		_, err := exec.LookPath(p.binary)
+		p.available = err == nil
+	})
+	return p.available
+}
+
+func (p *ffmpegProcessor) Thumbnail(ctx context.Context, input, output string) error {
+	// The thumbnail filter picks a representative frame, which avoids black intro frames
+	return p.run(ctx, "-y", "-i", input, "-vf", "thumbnail,scale='min(1280,iw)':-2", "-frames:v", "1", "-q:v", "3", output)
+}
+
+func (p *ffmpegProcessor) TranscodeHLS(ctx context.Context, input, outputDir string, renditions []HLSRendition) error {
+	var master strings.Builder
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+
+	for _, rendition := range renditions {
+		dir := filepath.Join(outputDir, rendition.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create rendition directory: %w", err)
+		}
+
+		// Never upscale: sources smaller than the rendition keep their own height
+		err := p.run(ctx, "-y", "-i", input,
+			"-vf", fmt.Sprintf("scale=-2:'min(%d,ih)'", rendition.Height),
+			"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+			"-b:v", fmt.Sprintf("%d", rendition.VideoBitrate),
+			"-c:a", "aac", "-b:a", fmt.Sprintf("%d", rendition.AudioBitrate), "-ac", "2",
+			"-f", "hls", "-hls_time", "6", "-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(dir, "segment_%03d.ts"),
+			filepath.Join(dir, "index.m3u8"))
+		if err != nil {
+			return fmt.Errorf("failed to transcode %s rendition: %w", rendition.Name, err)
+		}
+
+		fmt.Fprintf(&master, "#EXT-X-STREAM-INF:BANDWIDTH=%d\n%s/index.m3u8\n", rendition.VideoBitrate+rendition.AudioBitrate, rendition.Name)
+	}
+
+	// Written last so that a master playlist only exists for complete outputs
+	masterPath := filepath.Join(outputDir, HLSMasterPlaylist)
+	if err := os.WriteFile(masterPath+".tmp", []byte(master.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write master playlist: %w", err)
+	}
+	return os.Rename(masterPath+".tmp", masterPath)
+}
+
+func (p *ffmpegProcessor) run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, p.binary, append([]string{"-hide_banner", "-loglevel", "error"}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(p.binary), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
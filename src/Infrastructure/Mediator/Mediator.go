@@ -26,7 +26,13 @@ func (m *Mediator) RegisterHandler(command interface{}, handler Handler) {
 	m.handlers[commandType] = handler
 }
 
+// Send hands command to its handler with ctx, which ends with the request that sent it. A command
+// sent once ctx has ended isn't handled.
 func (m *Mediator) Send(ctx context.Context, command interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	commandType := reflect.TypeOf(command)
 	if commandType.Kind() == reflect.Ptr {
 		commandType = commandType.Elem()
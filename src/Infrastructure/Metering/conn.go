@@ -0,0 +1,105 @@
+package metering
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Listener wraps ln so the bytes written for downloads can be counted and throttled. Wrap the
+// plain TCP listener, before TLS, so encrypted bytes are what is counted.
+func Listener(ln net.Listener) net.Listener {
+	return &listener{Listener: ln}
+}
+
+// Untap stops counting a connection's writes when a new request arrives on it. It runs before
+// every route, so only responses of downloads that called Meter.Begin are counted.
+func Untap(c *fiber.Ctx) error {
+	if conn := connOf(c.Context().Conn()); conn != nil {
+		conn.tap = nil
+	}
+	return c.Next()
+}
+
+type listener struct {
+	net.Listener
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c}, nil
+}
+
+// tap counts a response's bytes toward its keys, at most rate bytes per second when rate is set
+type tap struct {
+	meter *Meter
+	keys  []key
+	rate  int64
+}
+
+// conn is a connection whose writes a download taps. A connection serves one request at a time
+// from a single goroutine, so its fields aren't locked.
+type conn struct {
+	net.Conn
+	tap      *tap
+	deadline time.Time
+}
+
+func connOf(c net.Conn) *conn {
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		c = tlsConn.NetConn()
+	}
+	metered, _ := c.(*conn)
+	return metered
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	t := c.tap
+	if t == nil {
+		return c.Conn.Write(p)
+	}
+
+	start := time.Now()
+	n, err := c.Conn.Write(p)
+	t.meter.add(t.keys, int64(n))
+	if t.rate > 0 && n > 0 {
+		if wait := time.Duration(n)*time.Second/time.Duration(t.rate) - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+			// The write timeout covers sending the response, not the time spent throttling it
+			if !c.deadline.IsZero() {
+				c.deadline = c.deadline.Add(wait)
+				c.Conn.SetWriteDeadline(c.deadline)
+			}
+		}
+	}
+	return n, err
+}
+
+// ReadFrom keeps sendfile for responses that aren't tapped
+func (c *conn) ReadFrom(r io.Reader) (int64, error) {
+	if readerFrom, ok := c.Conn.(io.ReaderFrom); ok && c.tap == nil {
+		return readerFrom.ReadFrom(r)
+	}
+	return io.Copy(writerOnly{c}, r)
+}
+
+func (c *conn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// writerOnly hides ReadFrom, so io.Copy goes through Write
+type writerOnly struct {
+	io.Writer
+}
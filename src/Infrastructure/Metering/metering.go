@@ -0,0 +1,421 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+const (
+	ScopeBucket = "bucket"
+	ScopeUser   = "user"
+	ScopeAPIKey = "api_key"
+
+	// Daily usage is counted apart from the billing cycle's, under its own scopes
+	ScopeBucketDaily = "bucket_daily"
+	ScopeAPIKeyDaily = "api_key_daily"
+
+	PolicyThrottle = "throttle"
+	PolicyBlock    = "block"
+)
+
+const (
+	// flushInterval is how often counted bytes are written, and how old the usage a quota is
+	// checked against may be. Quotas are soft: servers see each other's downloads this late.
+	flushInterval = 10 * time.Second
+	// quotaTTL is how long a user's quota is cached
+	quotaTTL = 30 * time.Second
+)
+
+// CycleStart returns the start of the billing cycle t falls in, midnight UTC on the billing day
+func CycleStart(t time.Time) time.Time {
+	day := config.GetSettings().EgressBillingDay
+	if day < 1 || day > 28 {
+		day = 1
+	}
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, time.UTC)
+	if t.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// CycleEnd returns the end of the billing cycle starting at start
+func CycleEnd(start time.Time) time.Time {
+	return start.AddDate(0, 1, 0)
+}
+
+// DayStart returns the start of the day t falls in, midnight UTC, which daily quotas reset at
+func DayStart(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// DailyScope returns the scope the daily usage of scope is counted under, empty when it has none
+func DailyScope(scope string) string {
+	switch scope {
+	case ScopeBucket:
+		return ScopeBucketDaily
+	case ScopeAPIKey:
+		return ScopeAPIKeyDaily
+	}
+	return ""
+}
+
+// Policy returns what happens to downloads of a bucket past a quota
+func Policy(bucket *entities.Bucket) string {
+	if bucket.Settings.EgressQuotaPolicy != "" {
+		return bucket.Settings.EgressQuotaPolicy
+	}
+	if config.GetSettings().EgressQuotaPolicy == PolicyBlock {
+		return PolicyBlock
+	}
+	return PolicyThrottle
+}
+
+// Usage returns the bytes recorded for a bucket, user or API key in the cycle or day starting at cycleStart
+func Usage(ctx context.Context, dbContext *persistence.AppDbContext, scope string, id uuid.UUID, cycleStart time.Time) (int64, error) {
+	return usage(dbContext.GetDB().WithContext(ctx), scope, id, cycleStart)
+}
+
+func usage(db *gorm.DB, scope string, id uuid.UUID, cycleStart time.Time) (int64, error) {
+	var usage entities.EgressUsage
+	err := db.Where(&entities.EgressUsage{Scope: scope, ScopeId: id, CycleStart: cycleStart}).First(&usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return usage.Bytes, err
+}
+
+// Downloads returns the downloads begun on all servers since the start of the hour since falls in
+func Downloads(ctx context.Context, dbContext *persistence.AppDbContext, since time.Time) (int64, error) {
+	return downloadsSince(dbContext.GetDB().WithContext(ctx), since)
+}
+
+func downloadsSince(db *gorm.DB, since time.Time) (int64, error) {
+	var downloads int64
+	err := db.Model(&entities.DownloadCount{}).
+		Where(`"Hour" >= ?`, since.UTC().Truncate(time.Hour)).
+		Select(`COALESCE(SUM("Downloads"), 0)`).Scan(&downloads).Error
+	return downloads, err
+}
+
+// Decision is what a download gets under the quotas of its bucket, the bucket's owner and the API
+// key it's made with
+type Decision struct {
+	Exceeded   bool
+	Scope      string    // the scope whose quota is exceeded
+	ResetAt    time.Time // when the exceeded quota resets
+	Policy     string
+	CycleStart time.Time
+
+	keys []key
+}
+
+// Blocked reports whether the download must be refused
+func (d Decision) Blocked() bool {
+	return d.Exceeded && d.Policy == PolicyBlock
+}
+
+type key struct {
+	scope string
+	id    uuid.UUID
+	cycle time.Time
+}
+
+type cachedValue struct {
+	value int64
+	at    time.Time
+}
+
+type cachedQuotas struct {
+	cycle int64
+	daily int64
+	at    time.Time
+}
+
+// quota is a limit on the bytes of a key, 0 for none
+type quota struct {
+	key   key
+	limit int64
+	reset time.Time
+}
+
+// Meter counts the bytes downloads write per bucket, per bucket owner and per API key, and checks
+// them against their monthly and daily quotas. It also counts the downloads per hour. Counts are kept in memory and added
+// to the database periodically.
+type Meter struct {
+	dbContext *persistence.AppDbContext
+
+	mu        sync.Mutex
+	pending   map[key]int64
+	totals    map[key]cachedValue
+	quotas    map[uuid.UUID]cachedValue
+	keyQuotas map[uuid.UUID]cachedQuotas
+	downloads map[time.Time]int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewMeter(dbContext *persistence.AppDbContext) *Meter {
+	return &Meter{
+		dbContext: dbContext,
+		pending:   make(map[key]int64),
+		totals:    make(map[key]cachedValue),
+		quotas:    make(map[uuid.UUID]cachedValue),
+		keyQuotas: make(map[uuid.UUID]cachedQuotas),
+		downloads: make(map[time.Time]int64),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start writes counted bytes every flushInterval until Stop
+func (m *Meter) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.flush()
+			case <-m.stop:
+				m.flush()
+				return
+			}
+		}
+	}()
+}
+
+// Stop writes what is still counted and stops the meter
+func (m *Meter) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+// Check reports whether a bucket, its owner or the API key a download is made with, uuid.Nil for
+// none, is past a quota in the current cycle or day
+func (m *Meter) Check(bucket *entities.Bucket, apiKeyID uuid.UUID) Decision {
+	now := time.Now()
+	decision := Decision{Policy: Policy(bucket), CycleStart: CycleStart(now)}
+	cycleEnd, day := CycleEnd(decision.CycleStart), DayStart(now)
+
+	quotas := []quota{
+		{key{ScopeBucket, bucket.Id, decision.CycleStart}, bucket.Settings.EgressQuota, cycleEnd},
+		{key{ScopeBucketDaily, bucket.Id, day}, bucket.Settings.EgressDailyQuota, day.Add(24 * time.Hour)},
+		{key{ScopeUser, bucket.OwnerId, decision.CycleStart}, m.userQuota(bucket.OwnerId), cycleEnd},
+	}
+	if apiKeyID != uuid.Nil {
+		keyQuotas := m.apiKeyQuotas(apiKeyID)
+		quotas = append(quotas,
+			quota{key{ScopeAPIKey, apiKeyID, decision.CycleStart}, keyQuotas.cycle, cycleEnd},
+			quota{key{ScopeAPIKeyDaily, apiKeyID, day}, keyQuotas.daily, day.Add(24 * time.Hour)})
+	}
+
+	for _, q := range quotas {
+		decision.keys = append(decision.keys, q.key)
+		if !decision.Exceeded && q.limit > 0 && m.used(q.key) >= q.limit {
+			decision.Exceeded = true
+			decision.Scope = q.key.scope
+			decision.ResetAt = q.reset
+		}
+	}
+	return decision
+}
+
+// Begin checks the quotas of a download of bucket, made with an API key unless apiKeyID is
+// uuid.Nil. Unless it is blocked, the bytes written for the response to c are counted toward the
+// bucket, its owner and the key, and throttled when a quota is exceeded.
+func (m *Meter) Begin(c *fiber.Ctx, bucket *entities.Bucket, apiKeyID uuid.UUID) Decision {
+	decision := m.Check(bucket, apiKeyID)
+	if decision.Blocked() || c.Method() == fiber.MethodHead {
+		return decision
+	}
+	m.mu.Lock()
+	m.downloads[time.Now().UTC().Truncate(time.Hour)]++
+	m.mu.Unlock()
+
+	conn := connOf(c.Context().Conn())
+	if conn == nil {
+		return decision
+	}
+	t := &tap{
+		meter: m,
+		keys:  decision.keys,
+	}
+	if decision.Exceeded {
+		t.rate = config.GetSettings().EgressThrottleRate
+	}
+	conn.tap = t
+	return decision
+}
+
+// Record counts n bytes served for a download somewhere other than this server's connection,
+// like a download redirected to a storage node, toward what the decision on it counts toward
+func (m *Meter) Record(decision Decision, n int64) {
+	m.add(decision.keys, n)
+}
+
+func (m *Meter) add(keys []key, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		m.pending[k] += n
+	}
+}
+
+// used returns the bytes of a key, as last read from the database plus what this server hasn't written yet
+func (m *Meter) used(k key) int64 {
+	m.mu.Lock()
+	cached, ok := m.totals[k]
+	m.mu.Unlock()
+
+	if !ok || time.Since(cached.at) > flushInterval {
+		bytes, err := Usage(context.Background(), m.dbContext, k.scope, k.id, k.cycle)
+		if err != nil {
+			log.Printf("Warning: failed to read egress usage of %s %s: %v", k.scope, k.id, err)
+		} else {
+			cached.value = bytes
+		}
+		cached.at = time.Now()
+		m.mu.Lock()
+		m.totals[k] = cached
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return cached.value + m.pending[k]
+}
+
+// apiKeyQuotas returns the monthly and daily quotas of an API key
+func (m *Meter) apiKeyQuotas(apiKeyID uuid.UUID) cachedQuotas {
+	m.mu.Lock()
+	cached, ok := m.keyQuotas[apiKeyID]
+	m.mu.Unlock()
+	if ok && time.Since(cached.at) <= quotaTTL {
+		return cached
+	}
+
+	apiKey, err := m.dbContext.APIKeys.Where(&entities.APIKey{Id: apiKeyID}).FirstOrDefault()
+	if err != nil {
+		log.Printf("Warning: failed to read egress quotas of API key %s: %v", apiKeyID, err)
+	} else if apiKey != nil {
+		cached.cycle = apiKey.EgressQuota
+		cached.daily = apiKey.EgressDailyQuota
+	}
+	cached.at = time.Now()
+
+	m.mu.Lock()
+	m.keyQuotas[apiKeyID] = cached
+	m.mu.Unlock()
+	return cached
+}
+
+func (m *Meter) userQuota(userID uuid.UUID) int64 {
+	m.mu.Lock()
+	cached, ok := m.quotas[userID]
+	m.mu.Unlock()
+	if ok && time.Since(cached.at) <= quotaTTL {
+		return cached.value
+	}
+
+	user, err := m.dbContext.Users.Where(&entities.User{Id: userID}).FirstOrDefault()
+	if err != nil {
+		log.Printf("Warning: failed to read egress quota of user %s: %v", userID, err)
+	} else if user != nil {
+		cached.value = user.EgressQuota
+	}
+	cached.at = time.Now()
+
+	m.mu.Lock()
+	m.quotas[userID] = cached
+	m.mu.Unlock()
+	return cached.value
+}
+
+// flush adds the counted bytes to the database. Counts that fail to be written are kept for the next flush.
+func (m *Meter) flush() {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[key]int64)
+	downloads := m.downloads
+	m.downloads = make(map[time.Time]int64)
+	m.mu.Unlock()
+
+	for hour, n := range downloads {
+		if err := addDownloads(m.dbContext.GetDB(), hour, n); err != nil {
+			m.mu.Lock()
+			m.downloads[hour] += n
+			m.mu.Unlock()
+			log.Printf("Warning: failed to record downloads of %s: %v", hour.Format(time.RFC3339), err)
+		}
+	}
+
+	for k, n := range pending {
+		if n == 0 {
+			continue
+		}
+		err := addUsage(m.dbContext.GetDB(), k, n)
+
+		m.mu.Lock()
+		if err != nil {
+			m.pending[k] += n
+		} else {
+			// Read again with the bytes just written
+			delete(m.totals, k)
+		}
+		m.mu.Unlock()
+		if err != nil {
+			log.Printf("Warning: failed to record egress of %s %s: %v", k.scope, k.id, err)
+		}
+	}
+
+	// Forget buckets, users and API keys that aren't being downloaded
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, cached := range m.totals {
+		if time.Since(cached.at) > quotaTTL {
+			delete(m.totals, k)
+		}
+	}
+	for userID, cached := range m.quotas {
+		if time.Since(cached.at) > quotaTTL {
+			delete(m.quotas, userID)
+		}
+	}
+	for apiKeyID, cached := range m.keyQuotas {
+		if time.Since(cached.at) > quotaTTL {
+			delete(m.keyQuotas, apiKeyID)
+		}
+	}
+}
+
+// addDownloads adds n downloads to the count of the hour
+func addDownloads(db *gorm.DB, hour time.Time, n int64) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "Hour"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"Downloads": gorm.Expr(`"DownloadCount"."Downloads" + ?`, n), "UpdatedAt": time.Now()}),
+	}).Create(&entities.DownloadCount{Hour: hour, Downloads: n}).Error
+}
+
+// addUsage adds n bytes to the usage counted under k
+func addUsage(db *gorm.DB, k key, n int64) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "Scope"}, {Name: "ScopeId"}, {Name: "CycleStart"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"Bytes": gorm.Expr(`"EgressUsage"."Bytes" + ?`, n), "UpdatedAt": time.Now()}),
+	}).Create(&entities.EgressUsage{Scope: k.scope, ScopeId: k.id, CycleStart: k.cycle, Bytes: n}).Error
+}
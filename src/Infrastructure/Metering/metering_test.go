@@ -0,0 +1,59 @@
+package metering
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestAddUsage adds bytes to a cycle's usage, starting it on the first write
+func TestAddUsage(t *testing.T) {
+	db := sqlitetest.Open(t)
+	cycle := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	k := key{scope: ScopeBucket, id: uuid.New(), cycle: cycle}
+
+	for _, n := range []int64{100, 50} {
+		if err := addUsage(db, k, n); err != nil {
+			t.Fatalf("addUsage(%d) = %v", n, err)
+		}
+	}
+	if err := addUsage(db, key{scope: ScopeUser, id: k.id, cycle: cycle}, 7); err != nil {
+		t.Fatalf("addUsage() of another scope = %v", err)
+	}
+
+	bytes, err := usage(db, ScopeBucket, k.id, cycle)
+	if err != nil {
+		t.Fatalf("usage() = %v", err)
+	}
+	if bytes != 150 {
+		t.Errorf("usage() = %d, want 150", bytes)
+	}
+	if bytes, err := usage(db, ScopeBucket, k.id, cycle.AddDate(0, 1, 0)); err != nil || bytes != 0 {
+		t.Errorf("usage() of the next cycle = %d, %v, want 0", bytes, err)
+	}
+}
+
+// TestAddDownloads adds downloads to an hour's count and sums the hours since a time
+func TestAddDownloads(t *testing.T) {
+	db := sqlitetest.Open(t)
+	hour := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+	for _, count := range []struct {
+		hour time.Time
+		n    int64
+	}{{hour.Add(-time.Hour), 4}, {hour, 2}, {hour, 3}} {
+		if err := addDownloads(db, count.hour, count.n); err != nil {
+			t.Fatalf("addDownloads(%d) = %v", count.n, err)
+		}
+	}
+
+	downloads, err := downloadsSince(db, hour.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("downloadsSince() = %v", err)
+	}
+	if downloads != 5 {
+		t.Errorf("downloadsSince() within the hour = %d, want 5", downloads)
+	}
+}
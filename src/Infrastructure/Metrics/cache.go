@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+)
+
+// CacheStats counts the reads a cache served and the space it uses
+type CacheStats struct {
+	Enabled   bool   `json:"enabled"`
+	Path      string `json:"path"`
+	MaxBytes  int64  `json:"max_bytes"`
+	Bytes     int64  `json:"bytes"`
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`      // reads served from the cache since the server started
+	Misses    uint64 `json:"misses"`    // reads that had to fetch the content
+	Evictions uint64 `json:"evictions"` // entries removed to make room
+}
+
+// HitRatio is the share of reads served from the cache, 0 before any read
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// WriteCachePrometheus writes a cache's counters in the Prometheus text exposition format, named with prefix
+func WriteCachePrometheus(w io.Writer, prefix, description string, stats CacheStats) {
+	write := func(name, help, kind string, value interface{}) {
+		fmt.Fprintf(w, "# HELP %s_%s %s %s\n", prefix, name, description, help)
+		fmt.Fprintf(w, "# TYPE %s_%s %s\n", prefix, name, kind)
+		fmt.Fprintf(w, "%s_%s %v\n", prefix, name, value)
+	}
+	write("hits_total", "reads served from the cache.", "counter", stats.Hits)
+	write("misses_total", "reads fetching the content.", "counter", stats.Misses)
+	write("evictions_total", "entries evicted to make room.", "counter", stats.Evictions)
+	write("bytes", "bytes stored.", "gauge", stats.Bytes)
+	write("max_bytes", "size limit in bytes.", "gauge", stats.MaxBytes)
+	write("entries", "entries stored.", "gauge", stats.Entries)
+}
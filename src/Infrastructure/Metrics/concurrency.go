@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// gauge counts the requests in flight for one route or bucket
+type gauge struct {
+	inFlight atomic.Int64
+	peak     atomic.Int64
+	total    atomic.Uint64
+}
+
+func (g *gauge) begin() {
+	current := g.inFlight.Add(1)
+	g.total.Add(1)
+	for {
+		peak := g.peak.Load()
+		if current <= peak || g.peak.CompareAndSwap(peak, current) {
+			return
+		}
+	}
+}
+
+func (g *gauge) end() {
+	g.inFlight.Add(-1)
+}
+
+func (g *gauge) snapshot(key string) Gauge {
+	return Gauge{Key: key, InFlight: g.inFlight.Load(), Peak: g.peak.Load(), Total: g.total.Load()}
+}
+
+// Concurrency tracks the requests in flight overall, per route and per bucket
+type Concurrency struct {
+	all     gauge
+	mu      sync.RWMutex
+	routes  map[string]*gauge
+	buckets map[uuid.UUID]*gauge
+}
+
+func NewConcurrency() *Concurrency {
+	return &Concurrency{
+		routes:  make(map[string]*gauge),
+		buckets: make(map[uuid.UUID]*gauge),
+	}
+}
+
+// Begin counts a request to route, and to bucketID unless it is uuid.Nil. Call the returned function when it ends.
+func (c *Concurrency) Begin(route string, bucketID uuid.UUID) (end func()) {
+	routeGauge := lookup(c, c.routes, route)
+	var bucketGauge *gauge
+	if bucketID != uuid.Nil {
+		bucketGauge = lookup(c, c.buckets, bucketID)
+	}
+
+	c.all.begin()
+	routeGauge.begin()
+	if bucketGauge != nil {
+		bucketGauge.begin()
+	}
+
+	return func() {
+		c.all.end()
+		routeGauge.end()
+		if bucketGauge != nil {
+			bucketGauge.end()
+		}
+	}
+}
+
+func lookup[K comparable](c *Concurrency, gauges map[K]*gauge, key K) *gauge {
+	c.mu.RLock()
+	g, ok := gauges[key]
+	c.mu.RUnlock()
+	if ok {
+		return g
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g, ok = gauges[key]; !ok {
+		g = &gauge{}
+		gauges[key] = g
+	}
+	return g
+}
+
+// Gauge is the state of one counter
+type Gauge struct {
+	Key      string `json:"key"`
+	InFlight int64  `json:"in_flight"`
+	Peak     int64  `json:"peak"`  // most requests in flight at once since the server started
+	Total    uint64 `json:"total"` // requests started since the server started
+}
+
+// Snapshot is the state of every counter, routes and buckets sorted by requests in flight
+type Snapshot struct {
+	Total   Gauge   `json:"total"`
+	Routes  []Gauge `json:"routes"`
+	Buckets []Gauge `json:"buckets"`
+}
+
+func (c *Concurrency) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshot := Snapshot{
+		Total:   c.all.snapshot("total"),
+		Routes:  make([]Gauge, 0, len(c.routes)),
+		Buckets: make([]Gauge, 0, len(c.buckets)),
+	}
+	for route, g := range c.routes {
+		snapshot.Routes = append(snapshot.Routes, g.snapshot(route))
+	}
+	for bucketID, g := range c.buckets {
+		snapshot.Buckets = append(snapshot.Buckets, g.snapshot(bucketID.String()))
+	}
+	sortGauges(snapshot.Routes)
+	sortGauges(snapshot.Buckets)
+	return snapshot
+}
+
+func sortGauges(gauges []Gauge) {
+	sort.Slice(gauges, func(i, j int) bool {
+		if gauges[i].InFlight != gauges[j].InFlight {
+			return gauges[i].InFlight > gauges[j].InFlight
+		}
+		return gauges[i].Key < gauges[j].Key
+	})
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WritePrometheus writes the gauges and firing alerts in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, snapshot Snapshot, alerts []Alert) {
+	fmt.Fprintln(w, "# HELP shbucket_requests_in_flight Requests currently being handled.")
+	fmt.Fprintln(w, "# TYPE shbucket_requests_in_flight gauge")
+	fmt.Fprintf(w, "shbucket_requests_in_flight %d\n", snapshot.Total.InFlight)
+
+	writeGauges(w, "shbucket_route_requests_in_flight", "Requests currently being handled per route.", "gauge", "route", snapshot.Routes,
+		func(g Gauge) string { return fmt.Sprint(g.InFlight) })
+	writeGauges(w, "shbucket_route_requests_in_flight_peak", "Most requests handled at once per route since the server started.", "gauge", "route", snapshot.Routes,
+		func(g Gauge) string { return fmt.Sprint(g.Peak) })
+	writeGauges(w, "shbucket_route_requests_total", "Requests started per route.", "counter", "route", snapshot.Routes,
+		func(g Gauge) string { return fmt.Sprint(g.Total) })
+	writeGauges(w, "shbucket_bucket_requests_in_flight", "Requests currently being handled per bucket.", "gauge", "bucket", snapshot.Buckets,
+		func(g Gauge) string { return fmt.Sprint(g.InFlight) })
+	writeGauges(w, "shbucket_bucket_requests_in_flight_peak", "Most requests handled at once per bucket since the server started.", "gauge", "bucket", snapshot.Buckets,
+		func(g Gauge) string { return fmt.Sprint(g.Peak) })
+
+	fmt.Fprintln(w, "# HELP shbucket_saturation_alert Saturation alerts currently firing.")
+	fmt.Fprintln(w, "# TYPE shbucket_saturation_alert gauge")
+	for _, alert := range alerts {
+		fmt.Fprintf(w, "shbucket_saturation_alert{scope=\"%s\",key=\"%s\",threshold=\"%d\"} 1\n", alert.Scope, escapeLabel(alert.Key), alert.Threshold)
+	}
+}
+
+func writeGauges(w io.Writer, name, help, kind, label string, gauges []Gauge, value func(Gauge) string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+	for _, g := range gauges {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", name, label, escapeLabel(g.Key), value(g))
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Alert scopes
+const (
+	ScopeTotal  = "total"
+	ScopeRoute  = "route"
+	ScopeBucket = "bucket"
+)
+
+// Alert is a threshold of requests in flight that has been exceeded for the sustain period
+type Alert struct {
+	Scope     string    `json:"scope"`
+	Key       string    `json:"key"`
+	InFlight  int64     `json:"in_flight"`
+	Threshold int64     `json:"threshold"`
+	Since     time.Time `json:"since"` // when the threshold was first exceeded
+	FiredAt   time.Time `json:"fired_at"`
+}
+
+type saturationState struct {
+	aboveSince time.Time
+	alert      *Alert
+}
+
+// SaturationMonitor samples the concurrency gauges every second and fires an alert when one
+// stays at or above its threshold for the sustain period, and resolves it once it drops below.
+// Alerts are logged, posted to the alert webhook when one is configured, and recorded as bucket events for buckets.
+type SaturationMonitor struct {
+	concurrency *Concurrency
+	events      *events.Publisher
+	settings    *config.Settings
+	httpClient  *http.Client
+
+	mu     sync.Mutex
+	states map[string]*saturationState // by scope and key
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewSaturationMonitor(dbContext *persistence.AppDbContext, concurrency *Concurrency) *SaturationMonitor {
+	return &SaturationMonitor{
+		concurrency: concurrency,
+		events:      events.NewPublisher(dbContext),
+		settings:    config.GetSettings(),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		states:      make(map[string]*saturationState),
+	}
+}
+
+// Start samples every second until Stop. Nothing runs when no threshold is configured.
+func (m *SaturationMonitor) Start() {
+	if m.settings.SaturationTotalThreshold <= 0 && m.settings.SaturationRouteThreshold <= 0 && m.settings.SaturationBucketThreshold <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.sample(ctx, now)
+			}
+		}
+	}()
+
+	log.Printf("Saturation monitor started")
+}
+
+func (m *SaturationMonitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+		<-m.done
+	}
+}
+
+// Alerts returns the alerts currently firing
+func (m *SaturationMonitor) Alerts() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := []Alert{}
+	for _, state := range m.states {
+		if state.alert != nil {
+			alerts = append(alerts, *state.alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].FiredAt.Before(alerts[j].FiredAt) })
+	return alerts
+}
+
+func (m *SaturationMonitor) sample(ctx context.Context, now time.Time) {
+	snapshot := m.concurrency.Snapshot()
+	sustain := time.Duration(m.settings.SaturationSustain) * time.Second
+
+	var fired, resolved []Alert
+	check := func(scope string, g Gauge, threshold int) {
+		if threshold <= 0 {
+			return
+		}
+		id := scope + "|" + g.Key
+		state, ok := m.states[id]
+		if g.InFlight < int64(threshold) {
+			if ok {
+				if state.alert != nil {
+					alert := *state.alert
+					alert.InFlight = g.InFlight
+					resolved = append(resolved, alert)
+				}
+				delete(m.states, id)
+			}
+			return
+		}
+
+		if !ok {
+			state = &saturationState{aboveSince: now}
+			m.states[id] = state
+		}
+		if state.alert != nil {
+			state.alert.InFlight = g.InFlight
+			return
+		}
+		if now.Sub(state.aboveSince) >= sustain {
+			state.alert = &Alert{Scope: scope, Key: g.Key, InFlight: g.InFlight, Threshold: int64(threshold), Since: state.aboveSince, FiredAt: now}
+			fired = append(fired, *state.alert)
+		}
+	}
+
+	m.mu.Lock()
+	check(ScopeTotal, snapshot.Total, m.settings.SaturationTotalThreshold)
+	for _, g := range snapshot.Routes {
+		check(ScopeRoute, g, m.settings.SaturationRouteThreshold)
+	}
+	for _, g := range snapshot.Buckets {
+		check(ScopeBucket, g, m.settings.SaturationBucketThreshold)
+	}
+	m.mu.Unlock()
+
+	for _, alert := range fired {
+		m.notify(ctx, "firing", alert)
+	}
+	for _, alert := range resolved {
+		m.notify(ctx, "resolved", alert)
+	}
+}
+
+func (m *SaturationMonitor) notify(ctx context.Context, status string, alert Alert) {
+	if status == "firing" {
+		log.Printf("Warning: %s %s saturated: %d requests in flight, threshold %d, since %s",
+			alert.Scope, alert.Key, alert.InFlight, alert.Threshold, alert.Since.Format(time.RFC3339))
+	} else {
+		log.Printf("%s %s no longer saturated: %d requests in flight", alert.Scope, alert.Key, alert.InFlight)
+	}
+
+	if alert.Scope == ScopeBucket {
+		if bucketID, err := uuid.Parse(alert.Key); err == nil {
+			eventType := events.BucketSaturated
+			if status == "resolved" {
+				eventType = events.BucketSaturationResolved
+			}
+			m.events.Publish(eventType, bucketID, nil, uuid.Nil, map[string]interface{}{
+				"in_flight": alert.InFlight,
+				"threshold": alert.Threshold,
+				"since":     alert.Since,
+			})
+		}
+	}
+
+	if m.settings.AlertWebhookURL != "" {
+		if err := m.deliver(ctx, status, alert); err != nil {
+			log.Printf("Warning: failed to deliver saturation alert: %v", err)
+		}
+	}
+}
+
+func (m *SaturationMonitor) deliver(ctx context.Context, status string, alert Alert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":   "saturation",
+		"status": status,
+		"alert":  alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", m.settings.AlertWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-SHBucket-Event", "alert.saturation")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// BodyLimit rejects request bodies over limit bytes with 413. The server streams request bodies,
+// so fiber no longer enforces its own limit: bodies declaring a larger Content-Length are rejected
+// before they are read, and chunked bodies are read here up to the limit.
+// Upload routes, given as "METHOD /path" patterns, are skipped. They stream their body to storage
+// and are held to the bucket's maximum file size instead.
+func BodyLimit(limit int64, uploads ...string) fiber.Handler {
+	routes := make([]routePattern, 0, len(uploads))
+	for _, upload := range uploads {
+		method, path, _ := strings.Cut(upload, " ")
+		routes = append(routes, routePattern{
+			method:   method,
+			path:     path,
+			segments: strings.Split(path, "/"),
+		})
+	}
+
+	return func(c *fiber.Ctx) error {
+		path := strings.TrimSuffix(c.Path(), "/")
+		for _, route := range routes {
+			if _, ok := route.match(c.Method(), path); ok {
+				return c.Next()
+			}
+		}
+
+		if int64(c.Request().Header.ContentLength()) > limit {
+			return BodyTooLarge(c, limit)
+		}
+
+		// A body without a declared length arrives as a stream, handlers get it whole once it fits
+		if stream := c.Context().RequestBodyStream(); stream != nil {
+			body, err := io.ReadAll(io.LimitReader(stream, limit+1))
+			if err != nil {
+				return apierror.Invalid("Failed to read request body")
+			}
+			if int64(len(body)) > limit {
+				return BodyTooLarge(c, limit)
+			}
+			c.Request().SetBody(body)
+		}
+		return c.Next()
+	}
+}
+
+// BodyTooLarge answers 413 for a request whose body is over limit bytes. The rest of the body is
+// never read, so the connection is closed rather than reused.
+func BodyTooLarge(c *fiber.Ctx, limit int64) error {
+	c.Context().SetConnectionClose()
+	return apierror.New(apierror.CodePayloadTooLarge, fmt.Sprintf("request body exceeds the limit of %d bytes", limit))
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+	"shbucket/src/Utils"
+)
+
+// BucketCORS applies the CORS rules of the bucket named by the :bucketId or :bucketName route parameter.
+// Buckets without rules fall back to the global CORS configuration.
+// Preflight requests are answered here; other requests continue to the next handler.
+func BucketCORS(dbContext *persistence.AppDbContext) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		origin := c.Get(fiber.HeaderOrigin)
+		preflight := c.Method() == fiber.MethodOptions && c.Get(fiber.HeaderAccessControlRequestMethod) != ""
+
+		if origin == "" {
+			if c.Method() == fiber.MethodOptions {
+				return c.SendStatus(fiber.StatusNoContent)
+			}
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderOrigin)
+
+		rules := globalCORSRules()
+		allowCredentials := config.GetRuntimeSettings().CORSAllowCredentials
+		if bucket := corsBucket(c, dbContext); bucket != nil {
+			if bucketRules := utils.ConvertJSONToCORSRules(bucket.Settings.CORSRules); len(bucketRules) > 0 {
+				rules = bucketRules
+				allowCredentials = false
+			}
+		}
+
+		method := c.Method()
+		if preflight {
+			method = c.Get(fiber.HeaderAccessControlRequestMethod)
+		}
+
+		rule := matchCORSRule(rules, origin, method)
+		if rule == nil || (preflight && !headersAllowed(rule, c.Get(fiber.HeaderAccessControlRequestHeaders))) {
+			if preflight {
+				return c.SendStatus(fiber.StatusForbidden)
+			}
+			// Without CORS headers the browser withholds the response from the page
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+		if allowCredentials {
+			c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
+		}
+
+		if !preflight {
+			if len(rule.ExposeHeaders) > 0 {
+				c.Set(fiber.HeaderAccessControlExposeHeaders, strings.Join(rule.ExposeHeaders, ","))
+			}
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderAccessControlAllowMethods, strings.Join(ruleMethods(rule), ","))
+		if requested := c.Get(fiber.HeaderAccessControlRequestHeaders); requested != "" {
+			c.Set(fiber.HeaderAccessControlAllowHeaders, requested)
+		}
+		if rule.MaxAge > 0 {
+			c.Set(fiber.HeaderAccessControlMaxAge, strconv.Itoa(rule.MaxAge))
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// corsBucket returns the bucket the route names by ID or by name, nil if it names none
+func corsBucket(c *fiber.Ctx, dbContext *persistence.AppDbContext) *entities.Bucket {
+	filter := &entities.Bucket{}
+	if bucketID, err := uuid.Parse(c.Params("bucketId")); err == nil {
+		filter.Id = bucketID
+	} else if name := c.Params("bucketName"); name != "" {
+		filter.Name = name
+	} else {
+		return nil
+	}
+	bucket, err := dbContext.Buckets.Where(filter).FirstOrDefault()
+	if err != nil {
+		return nil
+	}
+	return bucket
+}
+
+// globalCORSRules expresses the global CORS configuration as a single rule
+func globalCORSRules() []models.CORSRuleResponse {
+	settings := config.GetRuntimeSettings()
+	return []models.CORSRuleResponse{{
+		AllowedOrigins: splitList(settings.CORSAllowOrigins),
+		AllowedMethods: splitList(settings.CORSAllowMethods),
+		AllowedHeaders: splitList(settings.CORSAllowHeaders),
+		ExposeHeaders:  splitList(settings.CORSExposeHeaders),
+		MaxAge:         settings.CORSMaxAge,
+	}}
+}
+
+// matchCORSRule returns the first rule allowing the origin and method
+func matchCORSRule(rules []models.CORSRuleResponse, origin, method string) *models.CORSRuleResponse {
+	for i := range rules {
+		if originAllowed(rules[i].AllowedOrigins, origin) && methodAllowed(&rules[i], method) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		// Wildcard subdomains: "https://*.example.com"
+		if prefix, suffix, found := strings.Cut(pattern, "*"); found &&
+			len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleMethods(rule *models.CORSRuleResponse) []string {
+	if len(rule.AllowedMethods) == 0 {
+		return []string{fiber.MethodGet, fiber.MethodHead}
+	}
+	return rule.AllowedMethods
+}
+
+func methodAllowed(rule *models.CORSRuleResponse, method string) bool {
+	for _, allowed := range ruleMethods(rule) {
+		if allowed == "*" || strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func headersAllowed(rule *models.CORSRuleResponse, requested string) bool {
+	for _, header := range splitList(requested) {
+		allowed := false
+		for _, pattern := range rule.AllowedHeaders {
+			if pattern == "*" || strings.EqualFold(pattern, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
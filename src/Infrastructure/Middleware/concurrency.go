@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"cmp"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Metrics"
+)
+
+// TrackConcurrency counts every request in flight against the route it matches and,
+// for bucket and file routes, against the bucket it addresses.
+// Requests matching no route are counted as "unmatched".
+func TrackConcurrency(concurrency *metrics.Concurrency) fiber.Handler {
+	var (
+		once   sync.Once
+		routes []routePattern
+	)
+
+	return func(c *fiber.Ctx) error {
+		// Routes are only complete once the server is listening, so they are read on the first request
+		once.Do(func() { routes = routePatterns(c.App()) })
+
+		route, bucketID := "unmatched", uuid.Nil
+		path := strings.TrimSuffix(c.Path(), "/")
+		for _, pattern := range routes {
+			if params, ok := pattern.match(c.Method(), path); ok {
+				route = pattern.method + " " + pattern.path
+				bucketID = routeBucket(pattern.path, params)
+				break
+			}
+		}
+
+		end := concurrency.Begin(route, bucketID)
+		defer end()
+		return c.Next()
+	}
+}
+
+// routeBucket finds the bucket addressed by a route, named bucketId, or id on bucket routes
+func routeBucket(path string, params map[string]string) uuid.UUID {
+	value, ok := params["bucketId"]
+	if !ok && strings.HasPrefix(path, "/api/v1/buckets/:id") {
+		value, ok = params["id"]
+	}
+	if !ok {
+		return uuid.Nil
+	}
+	bucketID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil
+	}
+	return bucketID
+}
+
+type routePattern struct {
+	method   string
+	path     string
+	segments []string
+}
+
+// routePatterns lists the routes with handlers in the order fiber matches them, leaving out middleware
+func routePatterns(app *fiber.App) []routePattern {
+	var patterns []routePattern
+	seen := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		path := strings.TrimSuffix(route.Path, "/")
+		key := route.Method + " " + path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		patterns = append(patterns, routePattern{
+			method:   route.Method,
+			path:     cmp.Or(path, "/"),
+			segments: strings.Split(path, "/"),
+		})
+	}
+	return patterns
+}
+
+// match supports the parts of fiber's syntax the server uses: ":name" and ":name?" match a segment,
+// "*" and "+" the rest of the path
+func (p routePattern) match(method, path string) (map[string]string, bool) {
+	if p.method != method {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	segments := strings.Split(path, "/")
+	for i, part := range p.segments {
+		switch {
+		case part == "*" || part == "+":
+			rest := strings.Join(segments[min(i, len(segments)):], "/")
+			if part == "+" && rest == "" {
+				return nil, false
+			}
+			return params, true
+		case i >= len(segments):
+			if strings.HasPrefix(part, ":") && strings.HasSuffix(part, "?") {
+				continue
+			}
+			return nil, false
+		case strings.HasPrefix(part, ":"):
+			if segments[i] == "" && !strings.HasSuffix(part, "?") {
+				return nil, false
+			}
+			params[strings.TrimSuffix(part[1:], "?")] = segments[i]
+		case part != segments[i]:
+			return nil, false
+		}
+	}
+	return params, len(segments) <= len(p.segments)
+}
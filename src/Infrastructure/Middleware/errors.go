@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// ErrorHandler answers requests whose handler or middleware returned an error. An apierror.Error
+// anywhere in the error's chain gives the status, code, details and fields, and the error's
+// message. Errors of Fiber's own keep their status and message. A request that timed out or whose
+// client went away is answered as such, and any other error as an internal error, logged instead
+// of returned so the internals it describes stay on the server.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	response := apierror.As(err)
+	var fiberErr *fiber.Error
+	switch {
+	case response != nil:
+		if response.Error() != err.Error() {
+			wrapped := *response
+			wrapped.Message = err.Error()
+			response = &wrapped
+		}
+	case errors.As(err, &fiberErr):
+		response = apierror.New(apierror.ForStatus(fiberErr.Code), fiberErr.Message)
+	case errors.Is(err, context.DeadlineExceeded):
+		response = apierror.New(apierror.CodeTimeout, "Request timed out")
+	case errors.Is(err, context.Canceled):
+		response = apierror.New(apierror.CodeCanceled, "Request canceled")
+	default:
+		log.Printf("Error: %s %s: %v", c.Method(), c.Path(), err)
+		response = apierror.Internal("Internal server error")
+	}
+	return c.Status(response.Status()).JSON(response)
+}
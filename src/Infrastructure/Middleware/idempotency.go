@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+const (
+	// IdempotencyKeyHeader names the key a client retries an upload with
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes the route's requests that carry an Idempotency-Key header run once. A retry
+// with the same key gets the first response back, status and body, until the key expires after
+// IDEMPOTENCY_KEY_TTL seconds. A retry sent while the first request is still running is refused
+// with 409. Only successful responses are remembered, a failed request can be retried with the
+// same key. Keys are scoped to the user sending them, or to the upload link for link uploads.
+func Idempotency(dbContext *persistence.AppDbContext) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return apierror.Invalid("Idempotency-Key must be at most 255 characters")
+		}
+
+		settings := config.GetSettings()
+		scope, request := idempotencyScope(c)
+		record := &entities.IdempotencyKey{
+			Scope:   scope,
+			Key:     key,
+			Request: request,
+			// A request that never finishes, like one of a server that died, gives up its claim
+			// when an upload left half way is cleaned up
+			ExpiresAt: time.Now().Add(time.Duration(settings.PendingUploadTimeout) * time.Second),
+		}
+		existing, err := claimIdempotencyKey(dbContext.GetDB(), record)
+		if err != nil {
+			log.Printf("Warning: failed to claim idempotency key: %v", err)
+			return apierror.Internal("Failed to check Idempotency-Key")
+		}
+		if existing != nil {
+			return replayIdempotencyKey(c, existing, record.Request)
+		}
+
+		err = c.Next()
+		status := c.Response().StatusCode()
+		db := dbContext.GetDB()
+		if err != nil || status < 200 || status >= 300 {
+			if dbErr := db.Delete(&entities.IdempotencyKey{}, `"Id" = ?`, record.Id).Error; dbErr != nil {
+				log.Printf("Warning: failed to release idempotency key %s: %v", record.Id, dbErr)
+			}
+			return err
+		}
+
+		if dbErr := db.Model(record).Updates(map[string]interface{}{
+			"StatusCode":  status,
+			"ContentType": string(c.Response().Header.ContentType()),
+			"Response":    append([]byte(nil), c.Response().Body()...),
+			"ExpiresAt":   time.Now().Add(time.Duration(settings.IdempotencyKeyTTL) * time.Second),
+		}).Error; dbErr != nil {
+			log.Printf("Warning: failed to record the response for idempotency key %s: %v", record.Id, dbErr)
+		}
+		return nil
+	}
+}
+
+// claimIdempotencyKey records the key for the request about to run. When the key is already
+// claimed it returns the claim instead, after replacing it if it expired.
+func claimIdempotencyKey(db *gorm.DB, record *entities.IdempotencyKey) (*entities.IdempotencyKey, error) {
+	for attempt := 0; ; attempt++ {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing entities.IdempotencyKey
+		if err := db.Where(&entities.IdempotencyKey{Scope: record.Scope, Key: record.Key}).First(&existing).Error; err != nil {
+			return nil, err
+		}
+		if attempt > 0 || existing.ExpiresAt.After(time.Now()) {
+			return &existing, nil
+		}
+		// Only the request that removes the expired claim goes on to make its own
+		if err := db.Delete(&entities.IdempotencyKey{}, `"Id" = ? AND "ExpiresAt" = ?`, existing.Id, existing.ExpiresAt).Error; err != nil {
+			return nil, err
+		}
+	}
+}
+
+// replayIdempotencyKey answers a request whose key another request claimed
+func replayIdempotencyKey(c *fiber.Ctx, existing *entities.IdempotencyKey, request string) error {
+	if existing.Request != request {
+		return apierror.New(apierror.CodeUnprocessable, "Idempotency-Key was already used for "+existing.Request)
+	}
+	if existing.StatusCode == 0 {
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return apierror.Conflict("A request with this Idempotency-Key is still in progress")
+	}
+
+	c.Set(IdempotentReplayedHeader, "true")
+	if existing.ContentType != "" {
+		c.Set(fiber.HeaderContentType, existing.ContentType)
+	}
+	return c.Status(existing.StatusCode).Send(existing.Response)
+}
+
+// idempotencyScope returns who a key belongs to, the authenticated user or else the path, and
+// the request it is for. The path of a link upload holds the link's token, so it's only kept hashed.
+func idempotencyScope(c *fiber.Ctx) (string, string) {
+	if user, ok := c.Locals("user").(*auth.UserContext); ok && user != nil {
+		return "user:" + user.UserID.String(), c.Method() + " " + c.Path()
+	}
+	hash := sha256.Sum256([]byte(c.Path()))
+	return "path:" + hex.EncodeToString(hash[:]), c.Method() + " " + c.Route().Path
+}
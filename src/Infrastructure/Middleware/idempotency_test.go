@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestClaimIdempotencyKey returns the live claim of a key and replaces an expired one
+func TestClaimIdempotencyKey(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	first := &entities.IdempotencyKey{Scope: "user", Key: "k", Request: "PUT /a", ExpiresAt: now.Add(time.Minute)}
+	if existing, err := claimIdempotencyKey(db, first); err != nil || existing != nil {
+		t.Fatalf("claimIdempotencyKey() of a new key = %v, %v, want the claim", existing, err)
+	}
+
+	retry := &entities.IdempotencyKey{Scope: "user", Key: "k", Request: "PUT /a", ExpiresAt: now.Add(time.Minute)}
+	existing, err := claimIdempotencyKey(db, retry)
+	if err != nil || existing == nil || existing.Id != first.Id {
+		t.Fatalf("claimIdempotencyKey() of a claimed key = %+v, %v, want the first claim", existing, err)
+	}
+
+	if err := db.Model(first).Update("ExpiresAt", now.Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	later := &entities.IdempotencyKey{Scope: "user", Key: "k", Request: "PUT /b", ExpiresAt: now.Add(time.Minute)}
+	if existing, err := claimIdempotencyKey(db, later); err != nil || existing != nil {
+		t.Errorf("claimIdempotencyKey() of an expired key = %+v, %v, want a new claim", existing, err)
+	}
+	var claims []entities.IdempotencyKey
+	if err := db.Find(&claims).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 1 || claims[0].Request != "PUT /b" {
+		t.Errorf("claims after replacing an expired key = %+v, want only the new one", claims)
+	}
+}
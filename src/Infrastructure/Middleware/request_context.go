@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestContextKey holds a request's cancel among its fasthttp user values
+type requestContextKey struct{}
+
+// RequestContext gives each request a context of its own as its UserContext, ended after timeout
+// when it isn't zero, and in any case once the response has been written, streamed bodies
+// included, or the client went away. Database and node calls made with it stop with the request
+// instead of running on for a client that isn't there.
+func RequestContext(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(c.UserContext(), timeout)
+		} else {
+			ctx, cancel = context.WithCancel(c.UserContext())
+		}
+		// fasthttp closes user values once it is done with the request, which is after the
+		// response is written, or when writing it failed
+		c.Context().SetUserValue(requestContextKey{}, canceler(cancel))
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// canceler cancels a request's context when fasthttp closes it
+type canceler context.CancelFunc
+
+func (cancel canceler) Close() error {
+	cancel()
+	return nil
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+)
+
+// reloadingHandler rebuilds a fiber handler from the runtime settings whenever they change,
+// so settings saved through the admin API apply without a restart.
+type reloadingHandler struct {
+	mutex   sync.Mutex
+	version uint64
+	handler fiber.Handler
+	build   func(settings config.RuntimeSettings) fiber.Handler
+}
+
+func newReloadingHandler(build func(settings config.RuntimeSettings) fiber.Handler) fiber.Handler {
+	r := &reloadingHandler{build: build}
+	r.handler = build(config.GetRuntimeSettings())
+	r.version = config.RuntimeVersion()
+	return r.serve
+}
+
+func (r *reloadingHandler) serve(c *fiber.Ctx) error {
+	r.mutex.Lock()
+	if version := config.RuntimeVersion(); version != r.version {
+		if handler, ok := r.rebuild(); ok {
+			r.handler = handler
+		}
+		r.version = version
+	}
+	handler := r.handler
+	r.mutex.Unlock()
+
+	return handler(c)
+}
+
+// rebuild keeps the previous handler when the new settings are rejected by the middleware
+func (r *reloadingHandler) rebuild() (handler fiber.Handler, ok bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("Warning: keeping previous middleware configuration: %v", recovered)
+			ok = false
+		}
+	}()
+	return r.build(config.GetRuntimeSettings()), true
+}
+
+// GlobalCORS applies the global CORS configuration to the API and dashboard.
+// File-serving routes are skipped because they apply per-bucket rules instead.
+func GlobalCORS() fiber.Handler {
+	return newReloadingHandler(func(settings config.RuntimeSettings) fiber.Handler {
+		return cors.New(cors.Config{
+			Next: func(c *fiber.Ctx) bool {
+				return strings.HasPrefix(c.Path(), "/api/v1/file/")
+			},
+			AllowOrigins:     settings.CORSAllowOrigins,
+			AllowMethods:     settings.CORSAllowMethods,
+			AllowHeaders:     settings.CORSAllowHeaders,
+			ExposeHeaders:    settings.CORSExposeHeaders,
+			AllowCredentials: settings.CORSAllowCredentials,
+			MaxAge:           settings.CORSMaxAge,
+		})
+	})
+}
+
+// RateLimit limits API requests per client IP. Routes opt in through the route table, node-to-master
+// internal routes and the health check don't. Counters restart whenever the limits are changed.
+func RateLimit() fiber.Handler {
+	return newReloadingHandler(func(settings config.RuntimeSettings) fiber.Handler {
+		if settings.RateLimitRequests <= 0 {
+			return func(c *fiber.Ctx) error {
+				return c.Next()
+			}
+		}
+
+		window := settings.RateLimitWindow
+		if window <= 0 {
+			window = 60
+		}
+
+		return limiter.New(limiter.Config{
+			Max:        settings.RateLimitRequests,
+			Expiration: time.Duration(window) * time.Second,
+			LimitReached: func(c *fiber.Ctx) error {
+				return apierror.New(apierror.CodeRateLimited, "Too many requests")
+			},
+		})
+	})
+}
@@ -0,0 +1,232 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// snapshotFile is the model snapshot GoNtext keeps next to the migrations it generates
+const snapshotFile = "ModelSnapshot.json"
+
+// foreignKey is a foreign key of Table.Column referencing RefTable.RefColumn
+type foreignKey struct {
+	Table     string
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+func (fk foreignKey) String() string {
+	return fmt.Sprintf("%s.%s -> %s.%s", fk.Table, fk.Column, fk.RefTable, fk.RefColumn)
+}
+
+type modelSnapshot struct {
+	Entities map[string]snapshotEntity `json:"entities"`
+}
+
+type snapshotEntity struct {
+	TableName string                   `json:"table_name"`
+	Fields    map[string]snapshotField `json:"fields"`
+}
+
+type snapshotField struct {
+	ColumnName string            `json:"column_name"`
+	Type       string            `json:"type"`
+	IsPrimary  bool              `json:"is_primary"`
+	Tags       map[string]string `json:"tags"`
+}
+
+// primaryColumn returns the column of the entity's primary key
+func (e snapshotEntity) primaryColumn() string {
+	for _, field := range e.Fields {
+		if field.IsPrimary {
+			return field.ColumnName
+		}
+	}
+	return "Id"
+}
+
+// column returns the column of the named field
+func (e snapshotEntity) column(name string) string {
+	if field, ok := e.Fields[name]; ok && field.ColumnName != "" {
+		return field.ColumnName
+	}
+	return name
+}
+
+// declaredForeignKeys returns the foreign keys the entities declare with foreignKey tags in the model
+// snapshot of a migrations directory. A relation to one entity puts the key on the declaring entity,
+// a relation to many puts it on the other one.
+func declaredForeignKeys(dir string) (map[foreignKey]bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model snapshot: %w", err)
+	}
+	var snapshot modelSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse model snapshot: %w", err)
+	}
+
+	declared := make(map[foreignKey]bool)
+	for _, entity := range snapshot.Entities {
+		for _, field := range entity.Fields {
+			key := field.Tags["foreignKey"]
+			if key == "" {
+				continue
+			}
+			many := strings.HasPrefix(strings.TrimPrefix(field.Type, "*"), "[]")
+			typeName := strings.TrimLeft(field.Type, "*[]")
+			typeName = typeName[strings.LastIndex(typeName, ".")+1:]
+			related, ok := snapshot.Entities[typeName]
+			if !ok {
+				continue
+			}
+
+			child, parent := entity, related
+			if many {
+				child, parent = related, entity
+			}
+			declared[foreignKey{
+				Table:     child.TableName,
+				Column:    child.column(key),
+				RefTable:  parent.TableName,
+				RefColumn: parent.primaryColumn(),
+			}] = true
+		}
+	}
+	return declared, nil
+}
+
+// Generated migrations hold their SQL in Go string literals, so identifiers are quoted with \"
+var (
+	tableStatementPattern = regexp.MustCompile(`(?:CREATE|ALTER) TABLE (?:IF (?:NOT )?EXISTS )?\\"([^\\"]+)\\"`)
+	constraintPattern     = regexp.MustCompile(`(, )?CONSTRAINT \\"(fk_[^\\"]+)\\" FOREIGN KEY \(\\"([^\\"]+)\\"\) REFERENCES \\"([^\\"]+)\\" \(\\"([^\\"]+)\\"\)`)
+	dropConstraintPattern = regexp.MustCompile(`DROP CONSTRAINT IF EXISTS \\"(fk_[^\\"]+)\\"`)
+)
+
+// constraintsIn returns the foreign keys a migration line defines, by constraint name
+func constraintsIn(line string) map[string]foreignKey {
+	table := tableStatementPattern.FindStringSubmatch(line)
+	if table == nil {
+		return nil
+	}
+	constraints := make(map[string]foreignKey)
+	for _, match := range constraintPattern.FindAllStringSubmatch(line, -1) {
+		constraints[match[2]] = foreignKey{Table: table[1], Column: match[3], RefTable: match[4], RefColumn: match[5]}
+	}
+	return constraints
+}
+
+// fixForeignKeys rewrites the foreign keys of a generated migration to those the entities declare.
+// GoNtext turns every column next to a relation into a foreign key, often referencing the wrong
+// table or a column of another type, which fails on a clean database. A constraint on a column with
+// a declared foreign key is pointed at the declared reference, any other inside a CREATE TABLE is
+// dropped. It returns the constraints it changed, nothing when the migration was already right.
+func fixForeignKeys(path string, declared map[foreignKey]bool) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	references := make(map[[2]string]foreignKey)
+	for fk := range declared {
+		references[[2]string{fk.Table, fk.Column}] = fk
+	}
+
+	var changes []string
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		table := tableStatementPattern.FindStringSubmatch(line)
+		if table == nil {
+			continue
+		}
+		lines[i] = constraintPattern.ReplaceAllStringFunc(line, func(constraint string) string {
+			match := constraintPattern.FindStringSubmatch(constraint)
+			fk := foreignKey{Table: table[1], Column: match[3], RefTable: match[4], RefColumn: match[5]}
+			if declared[fk] {
+				return constraint
+			}
+			if want, ok := references[[2]string{fk.Table, fk.Column}]; ok {
+				changes = append(changes, fmt.Sprintf("%s: %s now references %s.%s", match[2], fk, want.RefTable, want.RefColumn))
+				return fmt.Sprintf(`%sCONSTRAINT \"%s\" FOREIGN KEY (\"%s\") REFERENCES \"%s\" (\"%s\")`,
+					match[1], match[2], want.Column, want.RefTable, want.RefColumn)
+			}
+			if match[1] == "" {
+				// Not part of a column list, left for verification to report
+				return constraint
+			}
+			changes = append(changes, fmt.Sprintf("%s: %s dropped, no such relation is declared", match[2], fk))
+			return ""
+		})
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// undeclaredForeignKeys returns the foreign keys the migrations in dir define that the entities
+// don't declare. A constraint a later migration drops, as a corrective migration does for one an
+// applied migration got wrong, doesn't count.
+func undeclaredForeignKeys(dir string, declared map[foreignKey]bool) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	type definition struct {
+		path string
+		name string
+		fk   foreignKey
+	}
+	var defined []definition
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			// Dropping a constraint discards what earlier migrations defined by that name
+			if table := tableStatementPattern.FindStringSubmatch(line); table != nil {
+				for _, match := range dropConstraintPattern.FindAllStringSubmatch(line, -1) {
+					defined = slices.DeleteFunc(defined, func(d definition) bool {
+						return d.fk.Table == table[1] && d.name == match[1]
+					})
+				}
+			}
+			for name, fk := range constraintsIn(line) {
+				defined = append(defined, definition{path: path, name: name, fk: fk})
+			}
+		}
+	}
+
+	var problems []string
+	for _, d := range defined {
+		if !declared[d.fk] {
+			problems = append(problems, fmt.Sprintf("%s: %s (%s) isn't a declared relation", filepath.Base(d.path), d.name, d.fk))
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// newestMigration returns the path of the migration in dir generated last
+func newestMigration(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no migrations in %s", dir)
+	}
+	sort.Strings(paths)
+	return paths[len(paths)-1], nil
+}
@@ -7,35 +7,59 @@ import (
 
 	"github.com/shepherrrd/gontext"
 	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
 )
 
 type MigrationCommands struct {
-	ctx     *gontext.DbContext
-	manager *gontext.MigrationManager
+	ctx           *gontext.DbContext
+	manager       *gontext.MigrationManager
+	connection    string
+	migrationsDir string
 }
 
 func NewMigrationCommands() (*MigrationCommands, error) {
-	ctx, err := CreateSHBucketDesignTimeContext()
+	connection := designTimeConnection()
+	ctx, err := createDesignTimeContext(connection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create design-time context: %w", err)
 	}
 
-	manager := gontext.NewMigrationManager(ctx, "./migrations", "migrations")
+	// Migrations are generated in the database's dialect, SQLite's apart from PostgreSQL's
+	migrationsDir := "./migrations"
+	if persistence.IsSQLite(ctx.GetDB()) {
+		migrationsDir = "./migrations/sqlite"
+	}
+	manager := gontext.NewMigrationManager(ctx, migrationsDir, "migrations")
 
 	return &MigrationCommands{
-		ctx:     ctx,
-		manager: manager,
+		ctx:           ctx,
+		manager:       manager,
+		connection:    connection,
+		migrationsDir: migrationsDir,
 	}, nil
 }
 
-func CreateSHBucketDesignTimeContext() (*gontext.DbContext, error) {
+// designTimeConnection returns the DATABASE_URL migrations run against
+func designTimeConnection() string {
 	connectionString := "postgres://postgres@localhost:5432/shbucket?sslmode=disable"
 	
 	if envURL := strings.TrimSpace(os.Getenv("DATABASE_URL")); envURL != "" {
 		connectionString = envURL
 	}
+	return connectionString
+}
+
+func CreateSHBucketDesignTimeContext() (*gontext.DbContext, error) {
+	return createDesignTimeContext(designTimeConnection())
+}
+
+func createDesignTimeContext(connectionString string) (*gontext.DbContext, error) {
+	driver, dsn := persistence.ParseDatabaseURL(connectionString)
+	if err := persistence.EnsureDatabaseDir(driver, dsn); err != nil {
+		return nil, err
+	}
 
-	ctx, err := gontext.NewDbContext(connectionString, "postgres")
+	ctx, err := gontext.NewDbContext(dsn, driver)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +73,45 @@ func CreateSHBucketDesignTimeContext() (*gontext.DbContext, error) {
 	gontext.RegisterEntity[entities.SignedURL](ctx)
 	gontext.RegisterEntity[entities.SetupConfig](ctx)
 	gontext.RegisterEntity[entities.NodeFileMetadata](ctx)
+	gontext.RegisterEntity[entities.BackupRun](ctx)
+	gontext.RegisterEntity[entities.BackupObject](ctx)
+	gontext.RegisterEntity[entities.S3ImportJob](ctx)
+	gontext.RegisterEntity[entities.S3ExportJob](ctx)
+	gontext.RegisterEntity[entities.BucketEvent](ctx)
+	gontext.RegisterEntity[entities.FileComment](ctx)
+	gontext.RegisterEntity[entities.Notification](ctx)
+	gontext.RegisterEntity[entities.VideoAsset](ctx)
+	gontext.RegisterEntity[entities.FavoriteFile](ctx)
+	gontext.RegisterEntity[entities.BucketSnapshot](ctx)
+	gontext.RegisterEntity[entities.SnapshotFile](ctx)
+	gontext.RegisterEntity[entities.SystemSettings](ctx)
+	gontext.RegisterEntity[entities.BucketKey](ctx)
+	gontext.RegisterEntity[entities.KeyRotationJob](ctx)
+	gontext.RegisterEntity[entities.PendingUpload](ctx)
+	gontext.RegisterEntity[entities.BucketDeletionJob](ctx)
+	gontext.RegisterEntity[entities.Job](ctx)
+	gontext.RegisterEntity[entities.FileToken](ctx)
+	gontext.RegisterEntity[entities.BucketFolder](ctx)
+	gontext.RegisterEntity[entities.SignedUploadGrant](ctx)
+	gontext.RegisterEntity[entities.BucketAdminGrant](ctx)
+	gontext.RegisterEntity[entities.EgressUsage](ctx)
+	gontext.RegisterEntity[entities.UploadSession](ctx)
+	gontext.RegisterEntity[entities.NodeRegistrationToken](ctx)
+	gontext.RegisterEntity[entities.ClusterMember](ctx)
+	gontext.RegisterEntity[entities.BucketWebhook](ctx)
+	gontext.RegisterEntity[entities.FileAlias](ctx)
+	gontext.RegisterEntity[entities.BucketSync](ctx)
+	gontext.RegisterEntity[entities.BucketReplication](ctx)
+	gontext.RegisterEntity[entities.ReplicatedFile](ctx)
+	gontext.RegisterEntity[entities.DownloadCount](ctx)
+	gontext.RegisterEntity[entities.UsageAlert](ctx)
+	gontext.RegisterEntity[entities.UserInvitation](ctx)
+	gontext.RegisterEntity[entities.PasswordResetToken](ctx)
+	gontext.RegisterEntity[entities.UserRecoveryCode](ctx)
+	gontext.RegisterEntity[entities.LoginChallenge](ctx)
+	gontext.RegisterEntity[entities.Role](ctx)
+	gontext.RegisterEntity[entities.IdempotencyKey](ctx)
+	gontext.RegisterEntity[entities.SigningKey](ctx)
 
 	return ctx, nil
 }
@@ -58,7 +121,26 @@ func (m *MigrationCommands) AddMigration(migrationName string) error {
 	if err := m.manager.AddMigration(migrationName); err != nil {
 		return fmt.Errorf("failed to add migration: %w", err)
 	}
+
+	// Generated foreign keys only follow the relations the entities declare
+	declared, err := declaredForeignKeys(m.migrationsDir)
+	if err != nil {
+		return err
+	}
+	path, err := newestMigration(m.migrationsDir)
+	if err != nil {
+		return err
+	}
+	changes, err := fixForeignKeys(path, declared)
+	if err != nil {
+		return fmt.Errorf("failed to fix foreign keys of %s: %w", path, err)
+	}
+	for _, change := range changes {
+		fmt.Printf("🔧 %s\n", change)
+	}
+
 	fmt.Printf("✅ Migration '%s' created successfully!\n", migrationName)
+	fmt.Println("💡 Run migrations:verify before committing it")
 	return nil
 }
 
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Secrets"
+)
+
+// ReencryptSecrets rewrites every secret stored in the database with the current master key:
+// secrets stored before they were encrypted, or while no master key was set, and secrets encrypted
+// with a master key listed in SECRETS_PREVIOUS_MASTER_KEYS. Once it is done the previous keys can
+// be dropped. It can run any number of times.
+func (m *MigrationCommands) ReencryptSecrets() error {
+	if err := secrets.Check(); err != nil {
+		return err
+	}
+	if !secrets.Enabled() {
+		return secrets.ErrNoMasterKey
+	}
+	fmt.Println("🔐 Re-encrypting secrets...")
+
+	dbContext, err := persistence.NewAppDbContext(m.connection)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer dbContext.Close()
+	db := dbContext.GetDB()
+
+	steps := []struct {
+		name string
+		run  func(*gorm.DB) (int, error)
+	}{
+		{"storage node auth keys", reencrypt[entities.StorageNode]("auth_key")},
+		{"setup configurations", reencrypt[entities.SetupConfig]("config_data")},
+		{"signing keys", reencrypt[entities.SigningKey]("secret")},
+		{"webhook secrets", reencrypt[entities.BucketWebhook]("secret")},
+		{"replication API keys", reencrypt[entities.BucketReplication]("api_key")},
+		{"sync API keys", reencrypt[entities.BucketSync]("api_key")},
+		{"two-factor secrets", reencrypt[entities.User]("totp_secret")},
+	}
+	for _, step := range steps {
+		count, err := step.run(db)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s: %w", step.name, err)
+		}
+		fmt.Printf("  %d %s\n", count, step.name)
+	}
+
+	fmt.Println("✅ Secrets re-encrypted successfully!")
+	return nil
+}
+
+// reencrypt returns a step rewriting the columns of every row of T. Reading a row decrypts them
+// and writing it back encrypts them with the current master key; nothing else about the row, not
+// even its updated_at, changes.
+func reencrypt[T any](columns ...string) func(*gorm.DB) (int, error) {
+	return func(db *gorm.DB) (int, error) {
+		count := 0
+		var rows []T
+		err := db.FindInBatches(&rows, 100, func(tx *gorm.DB, batch int) error {
+			for i := range rows {
+				if err := db.Model(&rows[i]).Select(columns).UpdateColumns(&rows[i]).Error; err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		}).Error
+		return count, err
+	}
+}
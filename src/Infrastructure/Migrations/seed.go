@@ -0,0 +1,163 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// Seed creates the baseline data of a master the setup wizard would otherwise create: the admin
+// from ADMIN_USERNAME, ADMIN_EMAIL and ADMIN_PASSWORD, and the master configuration with the
+// default settings new buckets start from. It can run any number of times, what already exists is
+// left as it is, so a changed ADMIN_PASSWORD doesn't reset the admin's password.
+func (m *MigrationCommands) Seed() error {
+	fmt.Println("🌱 Seeding database...")
+
+	dbContext, err := persistence.NewAppDbContext(m.connection)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer dbContext.Close()
+
+	setupConfig, err := dbContext.SetupConfigs.Where(&entities.SetupConfig{IsSetup: true}).FirstOrDefault()
+	if err != nil {
+		return fmt.Errorf("failed to read setup configuration: %w", err)
+	}
+	if setupConfig != nil && setupConfig.SetupType != "master" {
+		fmt.Printf("⏭️  Installation is set up as a %s, nothing to seed\n", setupConfig.SetupType)
+		return nil
+	}
+
+	admin, err := seedAdmin(dbContext)
+	if err != nil {
+		return err
+	}
+
+	if setupConfig != nil {
+		fmt.Println("⏭️  Master configuration exists, left unchanged")
+	} else if admin == nil {
+		fmt.Println("⏭️  No admin to set up the master with, the setup wizard stays open")
+	} else if err := seedMasterConfig(dbContext, admin); err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Database seeded successfully!")
+	return nil
+}
+
+// seedAdmin creates the admin the environment names unless a user of that name or email exists.
+// It returns the admin, or nil when the environment names none.
+func seedAdmin(dbContext *persistence.AppDbContext) (*entities.User, error) {
+	username := strings.TrimSpace(os.Getenv("ADMIN_USERNAME"))
+	if username == "" {
+		username = "admin"
+	}
+	email := strings.TrimSpace(os.Getenv("ADMIN_EMAIL"))
+	password := os.Getenv("ADMIN_PASSWORD")
+	if email == "" && password == "" {
+		fmt.Println("⏭️  ADMIN_EMAIL and ADMIN_PASSWORD aren't set, no admin to create")
+		return nil, nil
+	}
+	if email == "" || password == "" {
+		return nil, fmt.Errorf("ADMIN_EMAIL and ADMIN_PASSWORD must be set together")
+	}
+
+	existing, err := dbContext.Users.Where(&entities.User{Email: email}).OrField("Username", username).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up admin: %w", err)
+	}
+	if existing != nil {
+		if existing.Role != "admin" {
+			return nil, fmt.Errorf("user %s exists and isn't an admin", existing.Username)
+		}
+		fmt.Printf("⏭️  Admin %s exists, left unchanged\n", existing.Username)
+		return existing, nil
+	}
+
+	if len(password) < 6 {
+		return nil, fmt.Errorf("ADMIN_PASSWORD must be at least 6 characters")
+	}
+	if password == "admin123" {
+		fmt.Println("⚠️  ADMIN_PASSWORD is the example password, change it after signing in")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	dbContext.Users.Add(entities.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: string(hashedPassword),
+		Role:         "admin",
+		IsActive:     true,
+	})
+	if err := dbContext.SaveChanges(); err != nil {
+		return nil, fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	admin, err := dbContext.Users.Where(&entities.User{Email: email}).FirstOrDefault()
+	if err != nil || admin == nil {
+		return nil, fmt.Errorf("failed to read back admin: %w", err)
+	}
+	fmt.Printf("👤 Created admin %s\n", admin.Username)
+	return admin, nil
+}
+
+// seedMasterConfig marks the installation set up as a master, as the setup wizard does. The default
+// bucket settings come from the DEFAULT_BUCKET_* limits of the environment.
+func seedMasterConfig(dbContext *persistence.AppDbContext, admin *entities.User) error {
+	settings := config.GetSettings()
+	if err := os.MkdirAll(settings.StoragePath, 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	configData, err := json.Marshal(map[string]interface{}{
+		"system_name":       settings.SystemName,
+		"jwt_secret":        settings.JWTSecret,
+		"default_auth_rule": models.AuthRuleResponse{Type: "none", Config: map[string]interface{}{}},
+		"default_settings": models.BucketSettingsResponse{
+			MaxFileSize:       settings.DefaultBucketMaxFileSize,
+			MaxTotalSize:      settings.DefaultBucketMaxTotalSize,
+			MaxFilesPerBucket: settings.DefaultBucketMaxFiles,
+			AllowedMimeTypes:  []string{},
+			BlockedMimeTypes:  []string{},
+			AllowedExtensions: []string{},
+			BlockedExtensions: []string{},
+			AllowOverwrite:    true,
+		},
+		"admin_user_id": admin.Id.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal config data: %w", err)
+	}
+
+	dbContext.SetupConfigs.Add(entities.SetupConfig{
+		IsSetup:     true,
+		SetupType:   "master",
+		StoragePath: settings.StoragePath,
+		MaxStorage:  settings.MaxStorage,
+		ConfigData:  datatypes.JSON(configData),
+	})
+	if err := dbContext.SaveChanges(); err != nil {
+		return fmt.Errorf("failed to save setup configuration: %w", err)
+	}
+	fmt.Printf("⚙️  Set up %s as a master storing in %s\n", settings.SystemName, settings.StoragePath)
+	return nil
+}
+
+// Bootstrap brings a database up to date and seeds it, for CI and container entrypoints
+func (m *MigrationCommands) Bootstrap() error {
+	if err := m.Update(); err != nil {
+		return err
+	}
+	return m.Seed()
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shepherrrd/gontext"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Verify checks the migrations before they are committed. Every foreign key must follow a relation
+// the entities declare, and every migration must apply to an empty scratch database and roll back
+// again. The scratch database is created next to DATABASE_URL's and dropped afterwards, the
+// database itself is left alone.
+func (m *MigrationCommands) Verify() error {
+	fmt.Println("🔍 Verifying migrations...")
+
+	declared, err := declaredForeignKeys(m.migrationsDir)
+	if err != nil {
+		return err
+	}
+	problems, err := undeclaredForeignKeys(m.migrationsDir, declared)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Printf("❌ %s\n", problem)
+		}
+		return fmt.Errorf("%d foreign key(s) don't follow the entities' relations", len(problems))
+	}
+	fmt.Println("✅ Foreign keys follow the entities' relations")
+
+	paths, err := filepath.Glob(filepath.Join(m.migrationsDir, "*.go"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fmt.Println("✅ No migrations to apply")
+		return nil
+	}
+
+	scratchURL, drop, err := m.createScratchDatabase()
+	if err != nil {
+		return fmt.Errorf("failed to create scratch database: %w", err)
+	}
+	defer drop()
+
+	ctx, err := createDesignTimeContext(scratchURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to scratch database: %w", err)
+	}
+	defer ctx.Close()
+
+	manager := gontext.NewMigrationManager(ctx, m.migrationsDir, "migrations")
+	if err := manager.UpdateDatabase(); err != nil {
+		return fmt.Errorf("migrations failed to apply to an empty database: %w", err)
+	}
+	fmt.Printf("✅ %d migration(s) apply to an empty database\n", len(paths))
+
+	if err := manager.RollbackDatabase(len(paths)); err != nil {
+		return fmt.Errorf("migrations failed to roll back: %w", err)
+	}
+	fmt.Printf("✅ %d migration(s) roll back\n", len(paths))
+	return nil
+}
+
+// createScratchDatabase creates an empty database of the same kind as the migrations' and returns
+// its URL with a function dropping it
+func (m *MigrationCommands) createScratchDatabase() (string, func(), error) {
+	name := fmt.Sprintf("shbucket_verify_%d", time.Now().UnixNano())
+
+	if persistence.IsSQLite(m.ctx.GetDB()) {
+		dir, err := os.MkdirTemp("", name)
+		if err != nil {
+			return "", nil, err
+		}
+		return "sqlite://" + filepath.Join(dir, "verify.db"), func() { os.RemoveAll(dir) }, nil
+	}
+
+	scratch, err := url.Parse(m.connection)
+	if err != nil || scratch.Scheme == "" {
+		return "", nil, fmt.Errorf("DATABASE_URL must be a postgres:// URL to verify against a scratch database")
+	}
+	if err := m.ctx.GetDB().Exec(fmt.Sprintf(`CREATE DATABASE "%s"`, name)).Error; err != nil {
+		return "", nil, err
+	}
+	scratch.Path = "/" + name
+
+	drop := func() {
+		if err := m.ctx.GetDB().Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS "%s"`, name)).Error; err != nil {
+			fmt.Printf("⚠️  Failed to drop scratch database %s: %v\n", name, err)
+		}
+	}
+	return scratch.String(), drop, nil
+}
@@ -0,0 +1,91 @@
+// Package nodeagent is a storage node without the master's API or its Postgres database. It only
+// serves the internal endpoints the master stores content through, keeps the metadata of what it
+// holds in SQLite, and registers itself with the master.
+package nodeagent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Config is the node agent's configuration, read from the environment
+type Config struct {
+	Host string
+	Port string
+
+	// MasterURL is where the master is reached
+	MasterURL string
+	// RegistrationToken is the one-time token an admin of the master issued for this node. It is
+	// spent on first boot, after which the auth key the master returned is kept in the database.
+	RegistrationToken string
+	// AuthKey is the key shared with the master, for nodes an admin added with a key of their own
+	AuthKey string
+
+	Name string
+	// URL is where the master reaches the node, PublicURL where clients redirected to it do
+	URL        string
+	PublicURL  string
+	MaxStorage int64
+	Priority   int
+
+	StoragePath string
+	// DBPath is the SQLite database holding the metadata of the files stored on the node
+	DBPath       string
+	PingInterval time.Duration
+}
+
+// LoadConfig reads the node agent's configuration from the environment
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		Host:              getEnv("HOST", "0.0.0.0"),
+		Port:              getEnv("PORT", "8081"),
+		MasterURL:         os.Getenv("MASTER_URL"),
+		RegistrationToken: os.Getenv("NODE_REGISTRATION_TOKEN"),
+		AuthKey:           os.Getenv("NODE_AUTH_KEY"),
+		Name:              getEnv("NODE_NAME", "storage-node"),
+		URL:               os.Getenv("NODE_URL"),
+		PublicURL:         os.Getenv("NODE_PUBLIC_URL"),
+		MaxStorage:        getEnvAsInt64("MAX_STORAGE_SIZE", 10*1024*1024*1024), // 10GB default
+		Priority:          int(getEnvAsInt64("NODE_PRIORITY", 1)),
+		StoragePath:       getEnv("STORAGE_PATH", "./storage"),
+		DBPath:            os.Getenv("NODE_DB_PATH"),
+		PingInterval:      time.Duration(getEnvAsInt64("NODE_PING_INTERVAL", 30)) * time.Second,
+	}
+
+	if cfg.MasterURL == "" {
+		return nil, fmt.Errorf("MASTER_URL is required")
+	}
+	if cfg.URL == "" {
+		host := cfg.Host
+		if host == "" || host == "0.0.0.0" {
+			host = "localhost"
+		}
+		cfg.URL = fmt.Sprintf("http://%s:%s", host, cfg.Port)
+	}
+	if cfg.DBPath == "" {
+		cfg.DBPath = filepath.Join(cfg.StoragePath, agentDir, "node.db")
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+	return cfg, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,173 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// masterTimeout bounds every request to the master
+const masterTimeout = 30 * time.Second
+
+// AuthKey returns the key the node shares with its master. A node that isn't registered yet
+// registers itself with its registration token, and keeps the key the master returns.
+func AuthKey(ctx context.Context, cfg *Config, store *Store) (string, error) {
+	if cfg.AuthKey != "" {
+		return cfg.AuthKey, nil
+	}
+
+	registration, err := store.Registration()
+	if err != nil {
+		return "", fmt.Errorf("failed to read node registration: %w", err)
+	}
+	if registration != nil {
+		return registration.AuthKey, nil
+	}
+	if cfg.RegistrationToken == "" {
+		return "", fmt.Errorf("node is not registered, set NODE_REGISTRATION_TOKEN to a token issued by the master or NODE_AUTH_KEY to its auth key")
+	}
+
+	registration, err = register(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := store.SaveRegistration(registration); err != nil {
+		return "", fmt.Errorf("failed to save node registration: %w", err)
+	}
+	log.Printf("Registered with master %s as node %s", cfg.MasterURL, registration.NodeID)
+	return registration.AuthKey, nil
+}
+
+// register registers the node with the master, spending its registration token
+func register(ctx context.Context, cfg *Config) (*Registration, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"registration_token": cfg.RegistrationToken,
+		"name":               cfg.Name,
+		"url":                cfg.URL,
+		"public_url":         cfg.PublicURL,
+		"max_storage":        cfg.MaxStorage,
+		"priority":           cfg.Priority,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, masterTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, masterEndpoint(cfg, "/api/v1/node/register"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registration request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register with master server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("master server rejected the registration token, it may be used, revoked or expired")
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("master server rejected node registration: status %d", resp.StatusCode)
+	}
+
+	var registered struct {
+		Node struct {
+			ID string `json:"id"`
+		} `json:"node"`
+		AuthKey string `json:"auth_key"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		return nil, fmt.Errorf("failed to decode master response: %w", err)
+	}
+	if registered.AuthKey == "" {
+		return nil, fmt.Errorf("master server returned no auth key")
+	}
+
+	return &Registration{
+		MasterURL:    cfg.MasterURL,
+		NodeID:       registered.Node.ID,
+		AuthKey:      registered.AuthKey,
+		RegisteredAt: time.Now(),
+	}, nil
+}
+
+// Pinger tells the master the node is up, so it keeps placing content on it
+type Pinger struct {
+	cfg     *Config
+	authKey string
+	client  *http.Client
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewPinger creates a new instance of Pinger
+func NewPinger(cfg *Config, authKey string) *Pinger {
+	return &Pinger{
+		cfg:     cfg,
+		authKey: authKey,
+		client:  &http.Client{Timeout: masterTimeout},
+	}
+}
+
+// Start pings the master now and then on every interval
+func (p *Pinger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.cfg.PingInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := p.ping(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to ping master: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops pinging and waits for the pinger to exit
+func (p *Pinger) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+}
+
+func (p *Pinger) ping(ctx context.Context) error {
+	endpoint := masterEndpoint(p.cfg, "/api/v1/node/ping") + "?url=" + url.QueryEscape(p.cfg.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.authKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("master returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func masterEndpoint(cfg *Config, path string) string {
+	return strings.TrimSuffix(cfg.MasterURL, "/") + path
+}
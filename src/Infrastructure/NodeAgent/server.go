@@ -0,0 +1,332 @@
+package nodeagent
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Diagnostics"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/NodeURL"
+)
+
+const (
+	// bodyLimit is the most of a request held in memory, larger uploads are streamed to disk
+	bodyLimit = 4 * 1024 * 1024
+	// maxFieldSize bounds the multipart fields sent with an upload
+	maxFieldSize = 64 * 1024
+)
+
+// Server serves the endpoints the master stores, reads and deletes content on the node through,
+// and the signed URLs it redirects clients to
+type Server struct {
+	cfg     *Config
+	store   *Store
+	authKey string
+	app     *fiber.App
+}
+
+// NewServer creates the node agent's server, which checks requests from the master against authKey
+func NewServer(cfg *Config, store *Store, authKey string) (*Server, error) {
+	// Uploads are spooled next to the bucket directories so they can be moved into place, and
+	// spools left by a crash are cleared
+	if err := os.RemoveAll(spoolDir(cfg)); err != nil {
+		return nil, fmt.Errorf("failed to clear upload spools: %w", err)
+	}
+	if err := os.MkdirAll(spoolDir(cfg), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		store:   store,
+		authKey: authKey,
+	}
+	s.app = fiber.New(fiber.Config{
+		AppName:               "SHBucket node agent",
+		DisableStartupMessage: true,
+		// Transfers of large files take as long as they take, every transfer is authenticated
+		IdleTimeout:                  2 * time.Minute,
+		BodyLimit:                    bodyLimit,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
+	s.app.Use(recover.New())
+	s.app.Use(logger.New())
+
+	api := s.app.Group("/api/v1")
+	s.app.Get(internalapi.HealthPath, s.health)
+	s.app.Post(internalapi.UploadPath, s.authorize, s.upload)
+	s.app.Delete(internalapi.DeletePath, s.authorize, s.delete)
+	s.app.Get(internalapi.FilePath, s.authorize, s.file)
+	s.app.Get(internalapi.DiagnosticsPath, s.authorize, s.diagnostics)
+	api.Get("/node/file", s.nodeFile)
+	return s, nil
+}
+
+// Listen serves until Shutdown
+func (s *Server) Listen() error {
+	return s.app.Listen(s.cfg.Host + ":" + s.cfg.Port)
+}
+
+// Shutdown stops accepting requests and waits up to timeout for the running ones
+func (s *Server) Shutdown(timeout time.Duration) error {
+	return s.app.ShutdownWithTimeout(timeout)
+}
+
+func (s *Server) health(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": "healthy",
+		"time":   time.Now(),
+	})
+}
+
+// authorize lets through requests carrying the auth key the node shares with the master, of an
+// internal API version the node speaks
+func (s *Server) authorize(c *fiber.Ctx) error {
+	authHeader := c.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return c.Status(http.StatusUnauthorized).JSON(internalapi.Failure("Missing or invalid Authorization header"))
+	}
+	if subtle.ConstantTimeCompare([]byte(s.authKey), []byte(strings.TrimPrefix(authHeader, "Bearer "))) != 1 {
+		return c.Status(http.StatusUnauthorized).JSON(internalapi.Failure("Invalid auth key"))
+	}
+	if err := internalapi.CheckVersion(c.Get(internalapi.VersionHeader)); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
+	}
+	return c.Next()
+}
+
+// upload stores content the master sends at storage_path/bucket_name/file_id, replacing content
+// stored there before. The multipart body is read as it arrives: the content goes to a spool file,
+// which is moved into place once the fields after it named where.
+func (s *Server) upload(c *fiber.Ctx) error {
+	mediaType, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || mediaType != fiber.MIMEMultipartForm || params["boundary"] == "" {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Expected a multipart/form-data body"))
+	}
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	var spool string
+	defer func() {
+		if spool != "" {
+			os.Remove(spool)
+		}
+	}()
+
+	var size int64
+	fields := make(map[string]string)
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Malformed multipart body"))
+		}
+
+		if part.FormName() != internalapi.FileField {
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize))
+			if err != nil {
+				return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Malformed multipart body"))
+			}
+			fields[part.FormName()] = string(value)
+			continue
+		}
+		if spool != "" {
+			return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Only one file may be uploaded"))
+		}
+
+		out, err := os.CreateTemp(spoolDir(s.cfg), "upload-*")
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to save file"))
+		}
+		spool = out.Name()
+		size, err = io.Copy(out, part)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Failed to read uploaded file"))
+		}
+	}
+
+	if spool == "" {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("No file provided"))
+	}
+	request, err := internalapi.ParseUploadRequest(func(name string, _ ...string) string { return fields[name] })
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
+	}
+	if !validName(request.BucketName) {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Invalid bucket name"))
+	}
+
+	storageDir := filepath.Join(s.cfg.StoragePath, request.BucketName)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to create storage directory"))
+	}
+	filePath := filepath.Join(storageDir, request.FileID.String())
+	if err := os.Rename(spool, filePath); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to save file"))
+	}
+	spool = ""
+
+	// Without its record the file can't be read back, so the upload fails with it
+	if err := s.store.PutFile(&StoredFile{
+		ID:         request.FileID,
+		BucketID:   request.BucketID,
+		BucketName: request.BucketName,
+		Filename:   request.Filename,
+		Path:       filePath,
+		Size:       size,
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		log.Printf("Failed to record file %s: %v", request.FileID, err)
+		os.Remove(filePath)
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to record file"))
+	}
+
+	return c.JSON(internalapi.Success("File uploaded successfully to storage node", internalapi.UploadResult{
+		FilePath: filePath,
+		FileSize: size,
+	}))
+}
+
+// delete removes the content stored at storage_path/bucket_name/file_id and its record
+func (s *Server) delete(c *fiber.Ctx) error {
+	request, err := internalapi.ParseDeleteRequest(c.Query)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
+	}
+	if !validName(request.BucketName) {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure("Invalid bucket name"))
+	}
+
+	if err := s.store.DeleteFile(request.BucketName, request.FileID); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to delete file record"))
+	}
+
+	filePath := filepath.Join(s.cfg.StoragePath, request.BucketName, request.FileID.String())
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return c.JSON(internalapi.Success("File already deleted or does not exist", internalapi.DeleteResult{
+				FilePath: filePath,
+			}))
+		}
+		return c.Status(http.StatusInternalServerError).JSON(internalapi.Failure("Failed to delete file"))
+	}
+
+	return c.JSON(internalapi.Success("File deleted successfully from storage node", internalapi.DeleteResult{
+		FilePath: filePath,
+		Existed:  true,
+	}))
+}
+
+// file serves stored content to the master
+func (s *Server) file(c *fiber.Ctx) error {
+	request, err := internalapi.ParseFileRequest(c.Query)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(internalapi.Failure(err.Error()))
+	}
+
+	stored, status, err := s.storedFile(request.BucketID, request.FileID)
+	if err != nil {
+		return c.Status(status).JSON(internalapi.Failure(err.Error()))
+	}
+	return c.SendFile(stored.Path)
+}
+
+// diagnostics reports the node's build, clock, disk and recent errors to the master
+func (s *Server) diagnostics(c *fiber.Ctx) error {
+	return c.JSON(internalapi.Success("Node diagnostics", diagnostics.Collect(s.cfg.StoragePath)))
+}
+
+// nodeFile serves stored content to a client the master redirected here with a URL signed with the
+// node's auth key
+func (s *Server) nodeFile(c *fiber.Ctx) error {
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query string",
+		})
+	}
+	if err := nodeurl.Verify(s.authKey, query); err != nil {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	bucketID, err := uuid.Parse(query.Get("bucket_id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid bucket ID format",
+		})
+	}
+	fileID, err := uuid.Parse(query.Get("file_id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID format",
+		})
+	}
+
+	stored, status, err := s.storedFile(bucketID, fileID)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := c.SendFile(stored.Path); err != nil {
+		return err
+	}
+	// Set after SendFile, which sets a type from the stored file's extension
+	if contentType := query.Get("type"); contentType != "" {
+		c.Set("Content-Type", contentType)
+	}
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", query.Get("name")))
+	c.Set("Cache-Control", "private")
+	return nil
+}
+
+// storedFile looks up a file the node holds, with the status to answer when it doesn't
+func (s *Server) storedFile(bucketID, fileID uuid.UUID) (*StoredFile, int, error) {
+	stored, err := s.store.File(bucketID, fileID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to look up file")
+	}
+	if stored == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("file not found in node metadata")
+	}
+	if _, err := os.Stat(stored.Path); os.IsNotExist(err) {
+		return nil, http.StatusNotFound, fmt.Errorf("file not found on disk")
+	}
+	return stored, http.StatusOK, nil
+}
+
+// validName reports whether name is a single path element that can't reach the agent's own files
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
+
+func spoolDir(cfg *Config) string {
+	return filepath.Join(cfg.StoragePath, agentDir, "tmp")
+}
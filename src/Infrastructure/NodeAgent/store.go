@@ -0,0 +1,129 @@
+package nodeagent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// agentDir holds the agent's own files under the storage path, apart from the bucket directories
+const agentDir = ".node-agent"
+
+// StoredFile is the metadata of a file stored on the node
+type StoredFile struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:text"`
+	BucketID   uuid.UUID `gorm:"type:text;not null;index"`
+	BucketName string    `gorm:"not null"`
+	Filename   string    `gorm:"not null"`
+	Path       string    `gorm:"not null"`
+	Size       int64     `gorm:"not null"`
+	CreatedAt  time.Time
+}
+
+func (StoredFile) TableName() string {
+	return "node_files"
+}
+
+// Registration is what the master handed the node when it registered, there is at most one
+type Registration struct {
+	ID           int `gorm:"primaryKey"`
+	MasterURL    string
+	NodeID       string
+	AuthKey      string `gorm:"not null"`
+	RegisteredAt time.Time
+}
+
+func (Registration) TableName() string {
+	return "registration"
+}
+
+// Store keeps the node agent's metadata in SQLite
+type Store struct {
+	db *gorm.DB
+}
+
+// OpenStore opens the SQLite database at path, creating it and its tables when needed
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?_busy_timeout=5000&_journal_mode=WAL"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open node database: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite takes one writer at a time, and the agent's queries are small
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&StoredFile{}, &Registration{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate node database: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Registration returns the node's registration with its master, nil before it registered
+func (s *Store) Registration() (*Registration, error) {
+	var registration Registration
+	result := s.db.Where("id = ?", 1).Limit(1).Find(&registration)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &registration, nil
+}
+
+// SaveRegistration keeps the node's registration, replacing an earlier one
+func (s *Store) SaveRegistration(registration *Registration) error {
+	registration.ID = 1
+	return s.db.Save(registration).Error
+}
+
+// PutFile records a stored file, replacing the record of content stored under its ID before
+func (s *Store) PutFile(file *StoredFile) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(file).Error
+}
+
+// File returns a stored file of a bucket, nil when the node doesn't hold it
+func (s *Store) File(bucketID, fileID uuid.UUID) (*StoredFile, error) {
+	var file StoredFile
+	result := s.db.Where("id = ? AND bucket_id = ?", fileID, bucketID).Limit(1).Find(&file)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &file, nil
+}
+
+// DeleteFile removes the record of a file stored under a bucket's name
+func (s *Store) DeleteFile(bucketName string, fileID uuid.UUID) error {
+	return s.db.Where("id = ? AND bucket_name = ?", fileID, bucketName).Delete(&StoredFile{}).Error
+}
+
+// Usage returns how many files the node holds and their total size
+func (s *Store) Usage() (int64, int64, error) {
+	var usage struct {
+		Files int64
+		Bytes int64
+	}
+	err := s.db.Model(&StoredFile{}).Select("COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").Scan(&usage).Error
+	return usage.Files, usage.Bytes, err
+}
@@ -0,0 +1,286 @@
+// Package nodeclient is how the master calls storage nodes: the internal endpoints it stores, reads
+// and deletes content through, their health endpoint and their diagnostics. Every call is
+// authenticated with the node's auth key, waits a bounded time for the node to answer, and shares a
+// pool of connections to each node. Reads and deletes, which are safe to repeat, are retried with
+// backoff when the node can't be reached or fails.
+package nodeclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/InternalAPI"
+)
+
+// maxEnvelope bounds the JSON responses read from a node
+const maxEnvelope = 1024 * 1024
+
+// Node is where a call goes: a storage node, or a backup node, and the key it shares with the master
+type Node struct {
+	URL     string
+	AuthKey string
+}
+
+// Options configure a Client
+type Options struct {
+	// Timeout bounds calls without content, and how long a node gets to start answering a transfer,
+	// which otherwise runs as long as its context allows
+	Timeout time.Duration
+	// Retries is how many times a read or delete is repeated after the node couldn't be reached or
+	// answered with a server error
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further one. Waits are jittered
+	// so calls that failed together don't retry together.
+	Backoff time.Duration
+	// MaxIdleConnsPerNode is how many idle connections to each node are kept for reuse
+	MaxIdleConnsPerNode int
+}
+
+// Client calls storage nodes. It is safe for concurrent use.
+type Client struct {
+	options Options
+	http    *http.Client
+}
+
+// New returns a Client with options
+func New(options Options) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = max(options.MaxIdleConnsPerNode, 1)
+	transport.ResponseHeaderTimeout = options.Timeout
+	transport.DialContext = (&net.Dialer{
+		Timeout:   options.Timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+
+	return &Client{
+		options: options,
+		http:    &http.Client{Transport: transport},
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+)
+
+// Default returns the Client configured with the NODE_REQUEST_* settings, shared by the whole master
+func Default() *Client {
+	defaultOnce.Do(func() {
+		settings := config.GetSettings()
+		defaultClient = New(Options{
+			Timeout:             time.Duration(max(settings.NodeRequestTimeout, 1)) * time.Second,
+			Retries:             max(settings.NodeRequestRetries, 0),
+			Backoff:             time.Duration(max(settings.NodeRetryBackoff, 0)) * time.Millisecond,
+			MaxIdleConnsPerNode: 16,
+		})
+	})
+	return defaultClient
+}
+
+// Upload streams content to a node, which stores it under the bucket name and file ID, replacing
+// any content stored there before. Uploads aren't retried, their content can't be read twice.
+func (c *Client) Upload(ctx context.Context, node Node, upload internalapi.UploadRequest, content io.Reader) (*internalapi.UploadResult, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		fileWriter, err := form.CreateFormFile(internalapi.FileField, upload.Filename)
+		if err == nil {
+			_, err = io.Copy(fileWriter, content)
+		}
+		if err == nil {
+			err = upload.WriteFields(form)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	req, err := c.request(ctx, node, http.MethodPost, internalapi.UploadPath, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// The writer stops on the closed pipe rather than block forever
+		body.Close()
+		return nil, fmt.Errorf("failed to reach node: %w", err)
+	}
+	defer resp.Body.Close()
+	return decode[internalapi.UploadResult](resp)
+}
+
+// Delete removes content from a node. Content that is already gone isn't an error.
+func (c *Client) Delete(ctx context.Context, node Node, request internalapi.DeleteRequest) (*internalapi.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.callTimeout())
+	defer cancel()
+
+	resp, err := c.retry(ctx, func() (*http.Request, error) {
+		req, err := c.request(ctx, node, http.MethodDelete, internalapi.DeletePath, nil)
+		if err == nil {
+			req.URL.RawQuery = request.Query().Encode()
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decode[internalapi.DeleteResult](resp)
+}
+
+// Fetch streams content from a node, with its length or -1 when the node doesn't say. The caller
+// closes the content.
+func (c *Client) Fetch(ctx context.Context, node Node, request internalapi.FileRequest) (io.ReadCloser, int64, error) {
+	resp, err := c.retry(ctx, func() (*http.Request, error) {
+		req, err := c.request(ctx, node, http.MethodGet, internalapi.FilePath, nil)
+		if err == nil {
+			req.URL.RawQuery = request.Query().Encode()
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := internalapi.ResponseError(resp); err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// Health checks that a node answers its health endpoint. It isn't retried, a node that answers
+// only on the second try isn't healthy.
+func (c *Client) Health(ctx context.Context, node Node) error {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	req, err := c.request(ctx, node, http.MethodGet, internalapi.HealthPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxEnvelope))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("node returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Diagnostics asks a node for its diagnostics. It isn't retried, so its round trip can be timed
+// to work out the node's clock skew.
+func (c *Client) Diagnostics(ctx context.Context, node Node) (*internalapi.Diagnostics, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	req, err := c.request(ctx, node, http.MethodGet, internalapi.DiagnosticsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach node: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("node doesn't report diagnostics, it runs a version older than them")
+	}
+	return decode[internalapi.Diagnostics](resp)
+}
+
+// request builds an authenticated request to a node's path
+func (c *Client) request(ctx context.Context, node Node, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(node.URL, "/")+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	internalapi.Authorize(req, node.AuthKey)
+	return req, nil
+}
+
+// retry sends the request build makes until the node answers without a server error, or the
+// retries run out. The last answer is returned whatever its status.
+func (c *Client) retry(ctx context.Context, build func() (*http.Request, error)) (*http.Response, error) {
+	backoff := c.options.Backoff
+	for attempt := 0; ; attempt++ {
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.http.Do(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if attempt >= c.options.Retries || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("failed to reach node: %w", err)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxEnvelope))
+			resp.Body.Close()
+		}
+
+		// Jittered between half and one and a half times the backoff
+		wait := time.Duration(0)
+		if backoff > 0 {
+			wait = rand.N(backoff) + backoff/2
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to reach node: %w", ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// callTimeout bounds a call without content along with its retries
+func (c *Client) callTimeout() time.Duration {
+	timeout := c.options.Timeout
+	backoff := c.options.Backoff
+	for range c.options.Retries {
+		timeout += c.options.Timeout + backoff*3/2
+		backoff *= 2
+	}
+	return timeout
+}
+
+// decode reads the Data of a node's envelope, or the error of a response that isn't a success
+func decode[T any](resp *http.Response) (*T, error) {
+	if err := internalapi.ResponseError(resp); err != nil {
+		return nil, err
+	}
+	var envelope internalapi.Envelope[T]
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxEnvelope)).Decode(&envelope); err != nil {
+		return nil, errors.New("node answered with a malformed response")
+	}
+	if envelope.Data == nil {
+		return nil, errors.New("node answered without a result")
+	}
+	return envelope.Data, nil
+}
@@ -0,0 +1,42 @@
+// Package nodeurl signs and verifies the URLs at which storage nodes serve files without
+// credentials. It has no dependencies so the node agent can verify URLs without the master's.
+package nodeurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"shbucket/src/Infrastructure/APIError"
+)
+
+// Path is where storage nodes serve files to anyone holding a URL signed by the master
+const Path = "/api/v1/node/file"
+
+// ErrSignature is returned for node file URLs that are expired or not signed with the node's auth key
+var ErrSignature = apierror.New(apierror.CodeForbidden, "invalid or expired node file URL")
+
+// Sign signs every parameter of a node file URL's query but the signature with the node's auth key
+func Sign(authKey string, query url.Values) string {
+	mac := hmac.New(sha256.New, []byte(authKey))
+	for _, name := range []string{"bucket_id", "file_id", "name", "type", "expires"} {
+		mac.Write([]byte(query.Get(name)))
+		mac.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and expiry of a node file URL's query with the node's auth key
+func Verify(authKey string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return ErrSignature
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(Sign(authKey, query))) {
+		return ErrSignature
+	}
+	return nil
+}
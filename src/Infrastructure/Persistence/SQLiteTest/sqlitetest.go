@@ -0,0 +1,132 @@
+// Package sqlitetest opens SQLite databases migrated to the application's schema, for tests of
+// code that queries the database
+package sqlitetest
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	sqlitemigrations "shbucket/migrations/sqlite"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// Migration is the shape of a generated migration
+type Migration interface {
+	ID() string
+	Up(db *gorm.DB) error
+	Down(db *gorm.DB) error
+}
+
+// Migrations are the migrations of migrations/sqlite, in the order they were added. A migration
+// generated there must be added here too, Open refuses to run without it.
+var Migrations = []Migration{
+	&sqlitemigrations.Migration20261017093630{},
+	&sqlitemigrations.Migration20261017093700{},
+	&sqlitemigrations.Migration20261017093800{},
+	&sqlitemigrations.Migration20261017093900{},
+	&sqlitemigrations.Migration20261017094000{},
+	&sqlitemigrations.Migration20261017094100{},
+	&sqlitemigrations.Migration20261017094200{},
+	&sqlitemigrations.Migration20261017094300{},
+	&sqlitemigrations.Migration20261017094400{},
+	&sqlitemigrations.Migration20261017094500{},
+	&sqlitemigrations.Migration20261017094600{},
+	&sqlitemigrations.Migration20261017094700{},
+	&sqlitemigrations.Migration20261017094800{},
+	&sqlitemigrations.Migration20261017094900{},
+	&sqlitemigrations.Migration20261017095000{},
+	&sqlitemigrations.Migration20261017095100{},
+	&sqlitemigrations.Migration20261017095200{},
+	&sqlitemigrations.Migration20261017095300{},
+	&sqlitemigrations.Migration20261017095400{},
+	&sqlitemigrations.Migration20261017095500{},
+}
+
+// Open opens a SQLite database in a temporary directory through its DATABASE_URL, the way the
+// application does, and applies Migrations to it. The database is closed when the test ends.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	checkMigrations(t)
+
+	driver, dsn := persistence.ParseDatabaseURL("sqlite://" + filepath.Join(t.TempDir(), "data", "shbucket.db"))
+	if driver != persistence.DriverSQLite {
+		t.Fatalf("ParseDatabaseURL() driver = %q, want %q", driver, persistence.DriverSQLite)
+	}
+	if err := persistence.EnsureDatabaseDir(driver, dsn); err != nil {
+		t.Fatalf("EnsureDatabaseDir() = %v", err)
+	}
+	// The naming strategy is the one of the application's context: PascalCase tables and columns
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true, NoLowerCase: true},
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", dsn, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := persistence.RegisterUUIDKeys(db); err != nil {
+		t.Fatalf("RegisterUUIDKeys() = %v", err)
+	}
+
+	for _, m := range Migrations {
+		if err := m.Up(db); err != nil {
+			t.Fatalf("migration %s failed: %v", m.ID(), err)
+		}
+	}
+	return db
+}
+
+// CreateBucket creates a bucket with the given name and an owner for it, for records that
+// reference a bucket
+func CreateBucket(t testing.TB, db *gorm.DB, name string) entities.Bucket {
+	t.Helper()
+	owner := entities.User{Username: name + "-owner", Email: name + "-owner@example.com", PasswordHash: "hash", Role: "user", IsActive: true}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatalf("failed to create the owner of bucket %s: %v", name, err)
+	}
+	bucket := entities.Bucket{Name: name, OwnerId: owner.Id}
+	if err := db.Create(&bucket).Error; err != nil {
+		t.Fatalf("failed to create bucket %s: %v", name, err)
+	}
+	return bucket
+}
+
+// checkMigrations fails the test when Migrations misses a migration of migrations/sqlite or lists
+// them out of order
+func checkMigrations(t testing.TB) {
+	t.Helper()
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "migrations", "sqlite")
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, path := range paths {
+		if name := filepath.Base(path); !strings.HasSuffix(name, "_test.go") {
+			files = append(files, strings.TrimSuffix(name, ".go"))
+		}
+	}
+	sort.Strings(files)
+
+	listed := make([]string, len(Migrations))
+	for i, m := range Migrations {
+		listed[i] = m.ID()
+	}
+	if strings.Join(listed, " ") != strings.Join(files, " ") {
+		t.Fatalf("sqlitetest.Migrations = %v, want the migrations of migrations/sqlite: %v", listed, files)
+	}
+}
@@ -0,0 +1,78 @@
+package sqlitetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+)
+
+// TestMigrations applies the SQLite migrations, creates a user and a bucket of theirs and rolls
+// the migrations back again
+func TestMigrations(t *testing.T) {
+	db := Open(t)
+
+	user := entities.User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash", Role: "admin", IsActive: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	bucket := entities.Bucket{Name: "photos", OwnerId: user.Id}
+	bucket.AuthRule.Type = "jwt"
+	bucket.AuthRule.Enabled = true
+	bucket.Settings.AllowedMimeTypes = []string{"image/png", "image/jpeg"}
+	if err := db.Create(&bucket).Error; err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+
+	var stored entities.Bucket
+	if err := db.Preload("Owner").First(&stored, `"Id" = ?`, bucket.Id).Error; err != nil {
+		t.Fatalf("failed to read the bucket back: %v", err)
+	}
+	if stored.Owner.Username != user.Username {
+		t.Errorf("bucket owner = %q, want %q", stored.Owner.Username, user.Username)
+	}
+	if got := strings.Join(stored.Settings.AllowedMimeTypes, ","); got != "image/png,image/jpeg" {
+		t.Errorf("allowed MIME types = %q, want %q", got, "image/png,image/jpeg")
+	}
+
+	if err := db.Delete(&stored).Error; err != nil {
+		t.Fatalf("failed to delete bucket: %v", err)
+	}
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	for i := len(Migrations) - 1; i >= 0; i-- {
+		if err := Migrations[i].Down(db); err != nil {
+			t.Fatalf("rollback of %s failed: %v", Migrations[i].ID(), err)
+		}
+	}
+}
+
+// TestUUIDKeys gives records a UUID key, signed URLs included, whose hook always clears and
+// omits it for PostgreSQL to generate
+func TestUUIDKeys(t *testing.T) {
+	db := Open(t)
+
+	signedURL := entities.SignedURL{Signature: "sig", BucketName: "photos", FileName: "a.jpg", Method: "GET", ExpiresAt: time.Now()}
+	if err := db.Create(&signedURL).Error; err != nil {
+		t.Fatalf("failed to create signed URL: %v", err)
+	}
+	var stored entities.SignedURL
+	if err := db.First(&stored, `"ID" = ?`, signedURL.ID).Error; err != nil || signedURL.ID == uuid.Nil {
+		t.Errorf("signed URL %s wasn't stored under its key: %v", signedURL.ID, err)
+	}
+
+	users := []entities.User{
+		{Username: "alice", Email: "alice@example.com", PasswordHash: "hash", Role: "user"},
+		{Username: "bob", Email: "bob@example.com", PasswordHash: "hash", Role: "user"},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	if users[0].Id == uuid.Nil || users[0].Id == users[1].Id {
+		t.Errorf("user keys = %s and %s, want two distinct keys", users[0].Id, users[1].Id)
+	}
+}
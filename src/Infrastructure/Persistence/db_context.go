@@ -7,33 +7,81 @@ import (
 	"time"
 
 	"github.com/shepherrrd/gontext"
+	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
 )
 
 type AppDbContext struct {
 	*gontext.DbContext
-	
-	Users            *gontext.LinqDbSet[entities.User]
-	Sessions         *gontext.LinqDbSet[entities.Session]
-	Buckets          *gontext.LinqDbSet[entities.Bucket]
-	Files            *gontext.LinqDbSet[entities.File]
-	StorageNodes     *gontext.LinqDbSet[entities.StorageNode]
-	APIKeys          *gontext.LinqDbSet[entities.APIKey]
-	SignedURLs       *gontext.LinqDbSet[entities.SignedURL]
-	SetupConfigs     *gontext.LinqDbSet[entities.SetupConfig]
-	NodeFileMetadata *gontext.LinqDbSet[entities.NodeFileMetadata]
+
+	Users              *gontext.LinqDbSet[entities.User]
+	Sessions           *gontext.LinqDbSet[entities.Session]
+	Buckets            *gontext.LinqDbSet[entities.Bucket]
+	Files              *gontext.LinqDbSet[entities.File]
+	StorageNodes       *gontext.LinqDbSet[entities.StorageNode]
+	APIKeys            *gontext.LinqDbSet[entities.APIKey]
+	SignedURLs         *gontext.LinqDbSet[entities.SignedURL]
+	SetupConfigs       *gontext.LinqDbSet[entities.SetupConfig]
+	NodeFileMetadata   *gontext.LinqDbSet[entities.NodeFileMetadata]
+	BackupRuns         *gontext.LinqDbSet[entities.BackupRun]
+	BackupObjects      *gontext.LinqDbSet[entities.BackupObject]
+	S3ImportJobs       *gontext.LinqDbSet[entities.S3ImportJob]
+	S3ExportJobs       *gontext.LinqDbSet[entities.S3ExportJob]
+	BucketEvents       *gontext.LinqDbSet[entities.BucketEvent]
+	FileComments       *gontext.LinqDbSet[entities.FileComment]
+	Notifications      *gontext.LinqDbSet[entities.Notification]
+	VideoAssets        *gontext.LinqDbSet[entities.VideoAsset]
+	FavoriteFiles      *gontext.LinqDbSet[entities.FavoriteFile]
+	BucketSnapshots    *gontext.LinqDbSet[entities.BucketSnapshot]
+	SnapshotFiles      *gontext.LinqDbSet[entities.SnapshotFile]
+	SystemSettings     *gontext.LinqDbSet[entities.SystemSettings]
+	BucketKeys         *gontext.LinqDbSet[entities.BucketKey]
+	KeyRotationJobs    *gontext.LinqDbSet[entities.KeyRotationJob]
+	PendingUploads     *gontext.LinqDbSet[entities.PendingUpload]
+	BucketDeletionJobs *gontext.LinqDbSet[entities.BucketDeletionJob]
+	Jobs               *gontext.LinqDbSet[entities.Job]
+	FileTokens         *gontext.LinqDbSet[entities.FileToken]
+	BucketFolders      *gontext.LinqDbSet[entities.BucketFolder]
+	SignedUploadGrants *gontext.LinqDbSet[entities.SignedUploadGrant]
+	BucketAdminGrants  *gontext.LinqDbSet[entities.BucketAdminGrant]
+	EgressUsages       *gontext.LinqDbSet[entities.EgressUsage]
+	UploadSessions     *gontext.LinqDbSet[entities.UploadSession]
+	RegistrationTokens *gontext.LinqDbSet[entities.NodeRegistrationToken]
+	ClusterMembers     *gontext.LinqDbSet[entities.ClusterMember]
+	BucketWebhooks     *gontext.LinqDbSet[entities.BucketWebhook]
+	FileAliases        *gontext.LinqDbSet[entities.FileAlias]
+	BucketSyncs        *gontext.LinqDbSet[entities.BucketSync]
+	BucketReplications *gontext.LinqDbSet[entities.BucketReplication]
+	DownloadCounts     *gontext.LinqDbSet[entities.DownloadCount]
+	UserInvitations    *gontext.LinqDbSet[entities.UserInvitation]
+	PasswordResets     *gontext.LinqDbSet[entities.PasswordResetToken]
+	RecoveryCodes      *gontext.LinqDbSet[entities.UserRecoveryCode]
+	LoginChallenges    *gontext.LinqDbSet[entities.LoginChallenge]
+	Roles              *gontext.LinqDbSet[entities.Role]
+	IdempotencyKeys    *gontext.LinqDbSet[entities.IdempotencyKey]
+	SigningKeys        *gontext.LinqDbSet[entities.SigningKey]
 }
 
 func NewAppDbContext(databaseURL string) (*AppDbContext, error) {
 	logLevel := "info"
-	if envLevel := os.Getenv("DB_LOG_LEVEL"); envLevel != "" {
+	if envLevel := config.Getenv("DB_LOG_LEVEL"); envLevel != "" {
 		logLevel = envLevel
 	}
-	
-	ctx, err := gontext.NewDbContext(databaseURL, "postgres", logLevel)
+
+	driver, dsn := ParseDatabaseURL(databaseURL)
+	if err := EnsureDatabaseDir(driver, dsn); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	ctx, err := gontext.NewDbContext(dsn, driver, logLevel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GoNtext context: %w", err)
 	}
+	if driver == DriverSQLite {
+		if err := RegisterUUIDKeys(ctx.GetDB()); err != nil {
+			return nil, fmt.Errorf("failed to register UUID keys: %w", err)
+		}
+	}
 
 	users := gontext.RegisterEntity[entities.User](ctx)
 	sessions := gontext.RegisterEntity[entities.Session](ctx)
@@ -44,6 +92,45 @@ func NewAppDbContext(databaseURL string) (*AppDbContext, error) {
 	signedURLs := gontext.RegisterEntity[entities.SignedURL](ctx)
 	setupConfigs := gontext.RegisterEntity[entities.SetupConfig](ctx)
 	nodeFileMetadata := gontext.RegisterEntity[entities.NodeFileMetadata](ctx)
+	backupRuns := gontext.RegisterEntity[entities.BackupRun](ctx)
+	backupObjects := gontext.RegisterEntity[entities.BackupObject](ctx)
+	s3ImportJobs := gontext.RegisterEntity[entities.S3ImportJob](ctx)
+	s3ExportJobs := gontext.RegisterEntity[entities.S3ExportJob](ctx)
+	bucketEvents := gontext.RegisterEntity[entities.BucketEvent](ctx)
+	fileComments := gontext.RegisterEntity[entities.FileComment](ctx)
+	notifications := gontext.RegisterEntity[entities.Notification](ctx)
+	videoAssets := gontext.RegisterEntity[entities.VideoAsset](ctx)
+	favoriteFiles := gontext.RegisterEntity[entities.FavoriteFile](ctx)
+	bucketSnapshots := gontext.RegisterEntity[entities.BucketSnapshot](ctx)
+	snapshotFiles := gontext.RegisterEntity[entities.SnapshotFile](ctx)
+	systemSettings := gontext.RegisterEntity[entities.SystemSettings](ctx)
+	bucketKeys := gontext.RegisterEntity[entities.BucketKey](ctx)
+	keyRotationJobs := gontext.RegisterEntity[entities.KeyRotationJob](ctx)
+	pendingUploads := gontext.RegisterEntity[entities.PendingUpload](ctx)
+	bucketDeletionJobs := gontext.RegisterEntity[entities.BucketDeletionJob](ctx)
+	jobs := gontext.RegisterEntity[entities.Job](ctx)
+	fileTokens := gontext.RegisterEntity[entities.FileToken](ctx)
+	bucketFolders := gontext.RegisterEntity[entities.BucketFolder](ctx)
+	signedUploadGrants := gontext.RegisterEntity[entities.SignedUploadGrant](ctx)
+	bucketAdminGrants := gontext.RegisterEntity[entities.BucketAdminGrant](ctx)
+	egressUsages := gontext.RegisterEntity[entities.EgressUsage](ctx)
+	uploadSessions := gontext.RegisterEntity[entities.UploadSession](ctx)
+	registrationTokens := gontext.RegisterEntity[entities.NodeRegistrationToken](ctx)
+	clusterMembers := gontext.RegisterEntity[entities.ClusterMember](ctx)
+	bucketWebhooks := gontext.RegisterEntity[entities.BucketWebhook](ctx)
+	fileAliases := gontext.RegisterEntity[entities.FileAlias](ctx)
+	bucketSyncs := gontext.RegisterEntity[entities.BucketSync](ctx)
+	bucketReplications := gontext.RegisterEntity[entities.BucketReplication](ctx)
+	gontext.RegisterEntity[entities.ReplicatedFile](ctx)
+	downloadCounts := gontext.RegisterEntity[entities.DownloadCount](ctx)
+	gontext.RegisterEntity[entities.UsageAlert](ctx)
+	userInvitations := gontext.RegisterEntity[entities.UserInvitation](ctx)
+	passwordResets := gontext.RegisterEntity[entities.PasswordResetToken](ctx)
+	recoveryCodes := gontext.RegisterEntity[entities.UserRecoveryCode](ctx)
+	loginChallenges := gontext.RegisterEntity[entities.LoginChallenge](ctx)
+	roles := gontext.RegisterEntity[entities.Role](ctx)
+	idempotencyKeys := gontext.RegisterEntity[entities.IdempotencyKey](ctx)
+	signingKeys := gontext.RegisterEntity[entities.SigningKey](ctx)
 
 	sqlDB, err := ctx.GetDB().DB()
 	if err != nil {
@@ -55,27 +142,69 @@ func NewAppDbContext(databaseURL string) (*AppDbContext, error) {
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
 	return &AppDbContext{
-		DbContext:        ctx,
-		Users:            users,
-		Sessions:         sessions,
-		Buckets:          buckets,
-		Files:            files,
-		StorageNodes:     storageNodes,
-		APIKeys:          apiKeys,
-		SignedURLs:       signedURLs,
-		SetupConfigs:     setupConfigs,
-		NodeFileMetadata: nodeFileMetadata,
+		DbContext:          ctx,
+		Users:              users,
+		Sessions:           sessions,
+		Buckets:            buckets,
+		Files:              files,
+		StorageNodes:       storageNodes,
+		APIKeys:            apiKeys,
+		SignedURLs:         signedURLs,
+		SetupConfigs:       setupConfigs,
+		NodeFileMetadata:   nodeFileMetadata,
+		BackupRuns:         backupRuns,
+		BackupObjects:      backupObjects,
+		S3ImportJobs:       s3ImportJobs,
+		S3ExportJobs:       s3ExportJobs,
+		BucketEvents:       bucketEvents,
+		FileComments:       fileComments,
+		Notifications:      notifications,
+		VideoAssets:        videoAssets,
+		FavoriteFiles:      favoriteFiles,
+		BucketSnapshots:    bucketSnapshots,
+		SnapshotFiles:      snapshotFiles,
+		SystemSettings:     systemSettings,
+		BucketKeys:         bucketKeys,
+		KeyRotationJobs:    keyRotationJobs,
+		PendingUploads:     pendingUploads,
+		BucketDeletionJobs: bucketDeletionJobs,
+		Jobs:               jobs,
+		FileTokens:         fileTokens,
+		BucketFolders:      bucketFolders,
+		SignedUploadGrants: signedUploadGrants,
+		BucketAdminGrants:  bucketAdminGrants,
+		EgressUsages:       egressUsages,
+		UploadSessions:     uploadSessions,
+		RegistrationTokens: registrationTokens,
+		ClusterMembers:     clusterMembers,
+		BucketWebhooks:     bucketWebhooks,
+		FileAliases:        fileAliases,
+		BucketSyncs:        bucketSyncs,
+		BucketReplications: bucketReplications,
+		DownloadCounts:     downloadCounts,
+		UserInvitations:    userInvitations,
+		PasswordResets:     passwordResets,
+		RecoveryCodes:      recoveryCodes,
+		LoginChallenges:    loginChallenges,
+		Roles:              roles,
+		IdempotencyKeys:    idempotencyKeys,
+		SigningKeys:        signingKeys,
 	}, nil
 }
 
 func CreateDesignTimeContext() (*gontext.DbContext, error) {
 	connectionString := "postgres://postgres@localhost:5432/shbucket?sslmode=disable"
-	
+
 	if envURL := strings.TrimSpace(os.Getenv("DATABASE_URL")); envURL != "" {
 		connectionString = envURL
 	}
 
-	ctx, err := gontext.NewDbContext(connectionString, "postgres")
+	driver, dsn := ParseDatabaseURL(connectionString)
+	if err := EnsureDatabaseDir(driver, dsn); err != nil {
+		return nil, err
+	}
+
+	ctx, err := gontext.NewDbContext(dsn, driver)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +218,45 @@ func CreateDesignTimeContext() (*gontext.DbContext, error) {
 	gontext.RegisterEntity[entities.SignedURL](ctx)
 	gontext.RegisterEntity[entities.SetupConfig](ctx)
 	gontext.RegisterEntity[entities.NodeFileMetadata](ctx)
+	gontext.RegisterEntity[entities.BackupRun](ctx)
+	gontext.RegisterEntity[entities.BackupObject](ctx)
+	gontext.RegisterEntity[entities.S3ImportJob](ctx)
+	gontext.RegisterEntity[entities.S3ExportJob](ctx)
+	gontext.RegisterEntity[entities.BucketEvent](ctx)
+	gontext.RegisterEntity[entities.FileComment](ctx)
+	gontext.RegisterEntity[entities.Notification](ctx)
+	gontext.RegisterEntity[entities.VideoAsset](ctx)
+	gontext.RegisterEntity[entities.FavoriteFile](ctx)
+	gontext.RegisterEntity[entities.BucketSnapshot](ctx)
+	gontext.RegisterEntity[entities.SnapshotFile](ctx)
+	gontext.RegisterEntity[entities.SystemSettings](ctx)
+	gontext.RegisterEntity[entities.BucketKey](ctx)
+	gontext.RegisterEntity[entities.KeyRotationJob](ctx)
+	gontext.RegisterEntity[entities.PendingUpload](ctx)
+	gontext.RegisterEntity[entities.BucketDeletionJob](ctx)
+	gontext.RegisterEntity[entities.Job](ctx)
+	gontext.RegisterEntity[entities.FileToken](ctx)
+	gontext.RegisterEntity[entities.BucketFolder](ctx)
+	gontext.RegisterEntity[entities.SignedUploadGrant](ctx)
+	gontext.RegisterEntity[entities.BucketAdminGrant](ctx)
+	gontext.RegisterEntity[entities.EgressUsage](ctx)
+	gontext.RegisterEntity[entities.UploadSession](ctx)
+	gontext.RegisterEntity[entities.NodeRegistrationToken](ctx)
+	gontext.RegisterEntity[entities.ClusterMember](ctx)
+	gontext.RegisterEntity[entities.BucketWebhook](ctx)
+	gontext.RegisterEntity[entities.FileAlias](ctx)
+	gontext.RegisterEntity[entities.BucketSync](ctx)
+	gontext.RegisterEntity[entities.BucketReplication](ctx)
+	gontext.RegisterEntity[entities.ReplicatedFile](ctx)
+	gontext.RegisterEntity[entities.DownloadCount](ctx)
+	gontext.RegisterEntity[entities.UsageAlert](ctx)
+	gontext.RegisterEntity[entities.UserInvitation](ctx)
+	gontext.RegisterEntity[entities.PasswordResetToken](ctx)
+	gontext.RegisterEntity[entities.UserRecoveryCode](ctx)
+	gontext.RegisterEntity[entities.LoginChallenge](ctx)
+	gontext.RegisterEntity[entities.Role](ctx)
+	gontext.RegisterEntity[entities.IdempotencyKey](ctx)
+	gontext.RegisterEntity[entities.SigningKey](ctx)
 
 	return ctx, nil
 }
-
@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DriverPostgres is the database driver of multi-server and production deployments
+	DriverPostgres = "postgres"
+	// DriverSQLite is the database driver of single-box deployments, a local database file
+	DriverSQLite = "sqlite"
+)
+
+// sqliteOptions are added to every SQLite connection string: writers wait for each other instead
+// of failing, readers don't block the writer, and LIKE compares case-sensitively as in PostgreSQL
+const sqliteOptions = "_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on&_cslike=true&_txlock=immediate"
+
+// ParseDatabaseURL returns the driver and connection string of a DATABASE_URL. SQLite is used for
+// sqlite:// and sqlite: URLs and for paths ending in .db, .sqlite or .sqlite3, PostgreSQL otherwise.
+func ParseDatabaseURL(databaseURL string) (driver, dsn string) {
+	path, isSQLite := strings.CutPrefix(databaseURL, "sqlite://")
+	if !isSQLite {
+		path, isSQLite = strings.CutPrefix(databaseURL, "sqlite:")
+	}
+	if !isSQLite && !strings.Contains(databaseURL, "://") {
+		switch strings.ToLower(filepath.Ext(strings.SplitN(databaseURL, "?", 2)[0])) {
+		case ".db", ".sqlite", ".sqlite3":
+			path, isSQLite = databaseURL, true
+		}
+	}
+	if !isSQLite {
+		return DriverPostgres, databaseURL
+	}
+
+	path, query, _ := strings.Cut(path, "?")
+	if query != "" {
+		query = sqliteOptions + "&" + query
+	} else {
+		query = sqliteOptions
+	}
+	return DriverSQLite, "file:" + path + "?" + query
+}
+
+// EnsureDatabaseDir creates the directory of a SQLite database file, which SQLite doesn't do itself
+func EnsureDatabaseDir(driver, dsn string) error {
+	if driver != DriverSQLite {
+		return nil
+	}
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	if dir := filepath.Dir(path); dir != "." {
+		return os.MkdirAll(dir, 0755)
+	}
+	return nil
+}
+
+// IsSQLite reports whether db is a SQLite database. Queries that differ between the dialects check
+// it, everything else is written to run on both.
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverSQLite
+}
+
+// IsSQLite reports whether the application database is SQLite
+func (c *AppDbContext) IsSQLite() bool {
+	return IsSQLite(c.GetDB())
+}
+
+// Greatest returns the SQL function giving the largest of its arguments, GREATEST in PostgreSQL
+// and the multi-argument MAX in SQLite
+func Greatest(db *gorm.DB) string {
+	if IsSQLite(db) {
+		return "MAX"
+	}
+	return "GREATEST"
+}
+
+// RegisterUUIDKeys has SQLite records get their UUID primary key from the application. PostgreSQL
+// generates the keys the entities leave out with gen_random_uuid(), which SQLite doesn't have.
+// The keys are assigned after the entities' BeforeCreate hooks, which clear or omit them to have
+// PostgreSQL generate them.
+func RegisterUUIDKeys(db *gorm.DB) error {
+	return db.Callback().Create().After("gorm:before_create").Before("gorm:create").Register("shbucket:uuid_keys", assignUUIDKeys)
+}
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+func assignUUIDKeys(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil || field.FieldType != uuidType {
+		return
+	}
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	assigned := false
+	assign := func(record reflect.Value) {
+		record = reflect.Indirect(record)
+		if record.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := field.ValueOf(ctx, record); zero {
+			db.AddError(field.Set(ctx, record, uuid.New()))
+			assigned = true
+		}
+	}
+
+	switch value := db.Statement.ReflectValue; value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			assign(value.Index(i))
+		}
+	case reflect.Struct:
+		assign(value)
+	}
+
+	// A key omitted by a hook would leave the column to its missing default
+	if assigned {
+		omits := db.Statement.Omits[:0]
+		for _, omit := range db.Statement.Omits {
+			if !strings.EqualFold(omit, field.DBName) {
+				omits = append(omits, omit)
+			}
+		}
+		db.Statement.Omits = omits
+	}
+}
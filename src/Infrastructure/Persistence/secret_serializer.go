@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm/schema"
+
+	"shbucket/src/Infrastructure/Secrets"
+)
+
+// Entities encrypt their secret columns by tagging them with one of these serializers. Values are
+// sealed when they are written and opened when they are read, so the rest of the code only ever sees
+// plaintext. Updates made with a map skip serializers, so their secrets are sealed with secrets.Seal.
+func init() {
+	// secret encrypts a string column
+	schema.RegisterSerializer("secret", SecretSerializer{})
+	// secretjson encrypts the secrets inside a JSON column, see SecretJSONSerializer
+	schema.RegisterSerializer("secretjson", SecretJSONSerializer{})
+}
+
+// SecretSerializer seals a string field when it is stored and opens it when it is read
+type SecretSerializer struct{}
+
+// Scan opens the stored value into the field
+func (SecretSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported value for secret column %s: %T", field.DBName, dbValue)
+	}
+
+	value, err := secrets.Open(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.DBName, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Value seals the field for storage
+func (SecretSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	sealed, err := secrets.Seal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", field.DBName, err)
+	}
+	return sealed, nil
+}
+
+// SecretJSONSerializer seals the top-level string values of a JSON object whose keys end in _secret
+// or _key, like a setup configuration's jwt_secret and node_auth_key, leaving the rest readable and
+// the column valid JSON
+type SecretJSONSerializer struct{}
+
+// Scan opens the stored object's secrets into the field
+func (SecretJSONSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored []byte
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = []byte(v)
+	case []byte:
+		stored = v
+	default:
+		return fmt.Errorf("unsupported value for secret column %s: %T", field.DBName, dbValue)
+	}
+
+	value, err := mapSecretJSON(stored, secrets.Open)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.DBName, err)
+	}
+	field.ReflectValueOf(ctx, dst).Set(reflect.ValueOf(datatypes.JSON(value)))
+	return nil
+}
+
+// Value seals the secrets of the field's object for storage
+func (SecretJSONSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(datatypes.JSON)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	sealed, err := mapSecretJSON(value, secrets.Seal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", field.DBName, err)
+	}
+	return string(sealed), nil
+}
+
+// mapSecretJSON applies convert to the secrets of a JSON object. Anything but an object is returned
+// as it is.
+func mapSecretJSON(data []byte, convert func(string) (string, error)) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if len(data) == 0 || json.Unmarshal(data, &object) != nil {
+		return data, nil
+	}
+
+	changed := false
+	for key, raw := range object {
+		if !strings.HasSuffix(key, "_secret") && !strings.HasSuffix(key, "_key") {
+			continue
+		}
+		var value string
+		if json.Unmarshal(raw, &value) != nil || value == "" {
+			continue
+		}
+		converted, err := convert(value)
+		if err != nil {
+			return nil, err
+		}
+		if converted == value {
+			continue
+		}
+		if object[key], err = json.Marshal(converted); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+
+	if !changed {
+		return data, nil
+	}
+	return json.Marshal(object)
+}
@@ -0,0 +1,255 @@
+package placement
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+// Pinned reports whether a bucket's content must stay on one node, or within a node group or zone
+func Pinned(bucket *entities.Bucket) bool {
+	return bucket.Settings.PlacementNodeId != nil || bucket.Settings.PlacementGroup != "" || bucket.Settings.PlacementZone != ""
+}
+
+// Allows reports whether node may hold content of bucket. A nil node is the master's own
+// storage, which only buckets that aren't pinned use. A bucket pinned to a group and a zone
+// uses the nodes in both.
+func Allows(bucket *entities.Bucket, node *entities.StorageNode) bool {
+	if !Pinned(bucket) {
+		return true
+	}
+	if node == nil {
+		return false
+	}
+	if nodeID := bucket.Settings.PlacementNodeId; nodeID != nil {
+		return node.Id == *nodeID
+	}
+	if group := bucket.Settings.PlacementGroup; group != "" && node.Group != group {
+		return false
+	}
+	if zone := bucket.Settings.PlacementZone; zone != "" && node.Zone != zone {
+		return false
+	}
+	return true
+}
+
+// Describe names a bucket's placement for messages
+func Describe(bucket *entities.Bucket) string {
+	if nodeID := bucket.Settings.PlacementNodeId; nodeID != nil {
+		return "node " + nodeID.String()
+	}
+	group, zone := bucket.Settings.PlacementGroup, bucket.Settings.PlacementZone
+	switch {
+	case group != "" && zone != "":
+		return fmt.Sprintf("node group %q in zone %q", group, zone)
+	case group != "":
+		return fmt.Sprintf("node group %q", group)
+	case zone != "":
+		return fmt.Sprintf("zone %q", zone)
+	}
+	return "any node"
+}
+
+// Validate checks that a placement names an existing node, or a group or zone at least one node
+// belongs to
+func Validate(dbContext *persistence.AppDbContext, nodeID *uuid.UUID, group, zone string) error {
+	if nodeID != nil && (group != "" || zone != "") {
+		return apierror.New(apierror.CodeInvalidRequest, "a bucket can be pinned to a node or to a node group and zone, not both")
+	}
+	if nodeID != nil {
+		node, err := dbContext.StorageNodes.Where(&entities.StorageNode{Id: *nodeID}).FirstOrDefault()
+		if err != nil || node == nil {
+			return apierror.Newf(apierror.CodeInvalidRequest, "placement node %s not found", nodeID)
+		}
+	}
+	if group != "" {
+		count, err := dbContext.StorageNodes.Where(&entities.StorageNode{Group: group}).Count()
+		if err != nil {
+			return fmt.Errorf("failed to look up node group: %w", err)
+		}
+		if count == 0 {
+			return apierror.Newf(apierror.CodeInvalidRequest, "no storage node belongs to group %q", group)
+		}
+	}
+	if zone != "" {
+		query := &entities.StorageNode{Zone: zone, Group: group}
+		count, err := dbContext.StorageNodes.Where(query).Count()
+		if err != nil {
+			return fmt.Errorf("failed to look up zone: %w", err)
+		}
+		if count == 0 && group != "" {
+			return apierror.Newf(apierror.CodeInvalidRequest, "no storage node of group %q is in zone %q", group, zone)
+		}
+		if count == 0 {
+			return apierror.Newf(apierror.CodeInvalidRequest, "no storage node is in zone %q", zone)
+		}
+	}
+	return nil
+}
+
+// Zone returns the zone of node, the master's configured zone for a nil node. Empty means the
+// location isn't in a zone, and it counts toward no bucket's spread.
+func Zone(node *entities.StorageNode) string {
+	if node == nil {
+		return config.GetSettings().MasterZone
+	}
+	return node.Zone
+}
+
+// ValidateSpread checks that minZones zones exist for copies to spread across: those of storage
+// nodes that haven't failed, the master's and the backup destination's
+func ValidateSpread(dbContext *persistence.AppDbContext, minZones int) error {
+	if minZones <= 1 {
+		return nil
+	}
+
+	zones, err := nodeZones(dbContext.GetDB())
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(zones)+2)
+	for _, zone := range zones {
+		known[zone] = true
+	}
+	settings := config.GetSettings()
+	for _, zone := range []string{settings.MasterZone, settings.BackupZone} {
+		if zone != "" {
+			known[zone] = true
+		}
+	}
+	if len(known) < minZones {
+		return apierror.Newf(apierror.CodeInvalidRequest, "copies can't span %d zones, only %d are known", minZones, len(known))
+	}
+	return nil
+}
+
+// nodeZones lists the zones of the storage nodes that haven't failed
+func nodeZones(db *gorm.DB) ([]string, error) {
+	var zones []string
+	if err := db.Model(&entities.StorageNode{}).
+		Where(`"Zone" <> '' AND "FailedAt" IS NULL`).
+		Distinct("Zone").Pluck("Zone", &zones).Error; err != nil {
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	return zones, nil
+}
+
+// KeepsSpread reports whether the copy a file is served from may live on node without sharing a
+// zone with the file's backup copies, which a bucket that requires copies in several zones needs
+func KeepsSpread(bucket *entities.Bucket, node *entities.StorageNode) bool {
+	backupZone := config.GetSettings().BackupZone
+	return bucket.Settings.MinZones <= 1 || backupZone == "" || Zone(node) != backupZone
+}
+
+// SelectNode picks where new content of a pinned bucket goes: the active, healthy node out of
+// maintenance with the highest priority that its placement allows and that has room for size bytes, preferring nodes
+// that keep the bucket's copies spread across zones
+func SelectNode(dbContext *persistence.AppDbContext, bucket *entities.Bucket, size int64) (*entities.StorageNode, error) {
+	nodes, err := dbContext.StorageNodes.Where(&entities.StorageNode{IsActive: true, IsHealthy: true}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage nodes: %w", err)
+	}
+
+	var selected *entities.StorageNode
+	for i := range nodes {
+		node := &nodes[i]
+		if !node.Placeable() || !Allows(bucket, node) || node.MaxStorage-node.UsedStorage < size {
+			continue
+		}
+		if selected == nil {
+			selected = node
+			continue
+		}
+		spread, selectedSpread := KeepsSpread(bucket, node), KeepsSpread(bucket, selected)
+		if spread != selectedSpread {
+			if spread {
+				selected = node
+			}
+			continue
+		}
+		if node.Priority > selected.Priority {
+			selected = node
+		}
+	}
+	if selected == nil {
+		return nil, apierror.Newf(apierror.CodeInsufficientStorage, "no active storage node in %s has room for %d bytes", Describe(bucket), size)
+	}
+	return selected, nil
+}
+
+// AllowsPath reports whether content stored at path satisfies the bucket's placement.
+// Anything copying or moving content between nodes checks its target the same way.
+func AllowsPath(dbContext *persistence.AppDbContext, bucket *entities.Bucket, path string) (bool, error) {
+	if !Pinned(bucket) {
+		return true, nil
+	}
+	if !storage.IsNodePath(path) {
+		return false, nil
+	}
+	nodePath, err := storage.ParseNodePath(path)
+	if err != nil {
+		return false, err
+	}
+	if nodeID := bucket.Settings.PlacementNodeId; nodeID != nil {
+		return nodePath.NodeID == *nodeID, nil
+	}
+	node, err := dbContext.StorageNodes.Where(&entities.StorageNode{Id: nodePath.NodeID}).FirstOrDefault()
+	if err != nil || node == nil {
+		return false, err
+	}
+	return Allows(bucket, node), nil
+}
+
+// Misplaced counts the files of a bucket stored outside its placement, such as
+// files uploaded before the bucket was pinned
+func Misplaced(ctx context.Context, dbContext *persistence.AppDbContext, bucket *entities.Bucket) (int, error) {
+	if !Pinned(bucket) {
+		return 0, nil
+	}
+
+	paths, err := filePaths(dbContext.GetDB().WithContext(ctx), bucket.Id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	// Files on one node share the answer
+	allowedNodes := make(map[uuid.UUID]bool)
+	misplaced := 0
+	for _, path := range paths {
+		if !storage.IsNodePath(path) {
+			misplaced++
+			continue
+		}
+		nodePath, err := storage.ParseNodePath(path)
+		if err != nil {
+			misplaced++
+			continue
+		}
+		allowed, seen := allowedNodes[nodePath.NodeID]
+		if !seen {
+			if allowed, err = AllowsPath(dbContext, bucket, path); err != nil {
+				return 0, err
+			}
+			allowedNodes[nodePath.NodeID] = allowed
+		}
+		if !allowed {
+			misplaced++
+		}
+	}
+	return misplaced, nil
+}
+
+// filePaths lists where the bucket's files are stored
+func filePaths(db *gorm.DB, bucketID uuid.UUID) ([]string, error) {
+	var paths []string
+	err := db.Model(&entities.File{}).Where(`"BucketId" = ?`, bucketID).Pluck("Path", &paths).Error
+	return paths, err
+}
@@ -0,0 +1,62 @@
+package placement
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestFilePaths lists the paths of the bucket's files only
+func TestFilePaths(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	otherID := sqlitetest.CreateBucket(t, db, "videos").Id
+	for _, file := range []entities.File{
+		{BucketId: bucketID, Name: "a.txt", Path: "/data/a.txt"},
+		{BucketId: otherID, Name: "b.txt", Path: "/data/b.txt"},
+	} {
+		file.OriginalName = file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := filePaths(db, bucketID)
+	if err != nil {
+		t.Fatalf("filePaths() = %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/data/a.txt" {
+		t.Errorf("filePaths() = %v, want [/data/a.txt]", paths)
+	}
+}
+
+// TestNodeZones lists each zone of the nodes that haven't failed once
+func TestNodeZones(t *testing.T) {
+	db := sqlitetest.Open(t)
+	failedAt := time.Now()
+	for i, node := range []entities.StorageNode{
+		{Zone: "rack-1"},
+		{Zone: "rack-1"},
+		{Zone: ""},
+		{Zone: "rack-2"},
+		{Zone: "rack-3", FailedAt: &failedAt},
+	} {
+		node.Name, node.URL, node.AuthKey = fmt.Sprintf("node-%d", i), fmt.Sprintf("http://node-%d", i), "key"
+		if err := db.Create(&node).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zones, err := nodeZones(db)
+	if err != nil {
+		t.Fatalf("nodeZones() = %v", err)
+	}
+	slices.Sort(zones)
+	if !slices.Equal(zones, []string{"rack-1", "rack-2"}) {
+		t.Errorf("nodeZones() = %v, want [rack-1 rack-2]", zones)
+	}
+}
@@ -0,0 +1,252 @@
+package progress
+
+import (
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stages of a transfer, in the order an upload goes through them
+const (
+	StageWaiting   = "waiting"       // subscribed to, the upload hasn't started yet
+	StageReceiving = "receiving"     // chunks of a resumable upload are arriving
+	StageStoring   = "storing"       // content is written to the master's disk
+	StageNode      = "node_transfer" // content is sent on to a storage node
+	StageComplete  = "complete"
+	StageFailed    = "failed"
+)
+
+// retention is how long a transfer is kept once nothing happens to it, for late subscribers
+const retention = 10 * time.Minute
+
+// publishInterval is how often byte counts are published while a stage runs. Stage changes go out at once.
+const publishInterval = 250 * time.Millisecond
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Event is the state of a transfer as subscribers see it
+type Event struct {
+	TransferID string     `json:"transfer_id"`
+	Stage      string     `json:"stage"`
+	Bytes      int64      `json:"bytes"` // bytes of the stage done
+	Total      int64      `json:"total"` // bytes the stage takes, 0 when unknown
+	NodeID     *uuid.UUID `json:"node_id,omitempty"`
+	FileID     *uuid.UUID `json:"file_id,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Done reports whether the transfer has finished, successfully or not
+func (e Event) Done() bool {
+	return e.Stage == StageComplete || e.Stage == StageFailed
+}
+
+// key scopes transfer IDs to the user uploading, so no one else can watch or feed a transfer
+type key struct {
+	owner uuid.UUID
+	id    string
+}
+
+type entry struct {
+	event       Event
+	subscribers map[chan Event]struct{}
+	touched     time.Time
+}
+
+var registry = struct {
+	mutex   sync.Mutex
+	entries map[key]*entry
+}{entries: make(map[key]*entry)}
+
+// ValidID reports whether id can name a transfer: 1 to 128 letters, digits, ".", "_" or "-"
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
+
+// Transfer reports the progress of one upload. A nil Transfer, of an upload no one asked to
+// follow, ignores every call.
+type Transfer struct {
+	key       key
+	bytes     int64
+	published time.Time
+}
+
+// Start begins reporting an upload under the transfer ID its client chose. Transfers are kept
+// in memory, so they are only seen on the server handling the upload.
+func Start(owner uuid.UUID, id string) *Transfer {
+	t := &Transfer{key: key{owner: owner, id: id}}
+	t.update(func(e *Event) {
+		*e = Event{Stage: StageWaiting}
+	})
+	return t
+}
+
+// Stage moves the transfer to stage, with total bytes to go through
+func (t *Transfer) Stage(stage string, total int64) {
+	if t == nil {
+		return
+	}
+	t.bytes = 0
+	t.update(func(e *Event) {
+		e.Stage, e.Bytes, e.Total = stage, 0, total
+	})
+}
+
+// Node names the storage node the content is sent to
+func (t *Transfer) Node(nodeID uuid.UUID) {
+	if t == nil {
+		return
+	}
+	t.update(func(e *Event) { e.NodeID = &nodeID })
+}
+
+// Set reports the bytes of the current stage done so far
+func (t *Transfer) Set(bytes int64) {
+	if t == nil {
+		return
+	}
+	t.bytes = bytes
+	t.update(func(e *Event) { e.Bytes = bytes })
+}
+
+// Add reports n more bytes of the current stage done. Counts are published at most every publishInterval.
+func (t *Transfer) Add(n int64) {
+	if t == nil {
+		return
+	}
+	t.bytes += n
+	if time.Since(t.published) < publishInterval {
+		return
+	}
+	bytes := t.bytes
+	t.update(func(e *Event) { e.Bytes = bytes })
+}
+
+// Reader counts what is read from r toward the current stage
+func (t *Transfer) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &countingReader{reader: r, transfer: t}
+}
+
+// Complete reports the upload stored as fileID
+func (t *Transfer) Complete(fileID uuid.UUID) {
+	if t == nil {
+		return
+	}
+	t.update(func(e *Event) {
+		e.Stage, e.Bytes, e.FileID = StageComplete, e.Total, &fileID
+	})
+}
+
+// Fail reports the upload failed with err
+func (t *Transfer) Fail(err error) {
+	if t == nil {
+		return
+	}
+	t.update(func(e *Event) {
+		e.Stage, e.Bytes, e.Error = StageFailed, t.bytes, err.Error()
+	})
+}
+
+// update changes the transfer's event and publishes it to its subscribers
+func (t *Transfer) update(change func(*Event)) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	now := time.Now()
+	e := lookup(t.key, now)
+	change(&e.event)
+	e.event.TransferID = t.key.id
+	e.event.UpdatedAt = now
+	t.published = now
+	for subscriber := range e.subscribers {
+		deliver(subscriber, e.event)
+	}
+}
+
+// Get returns the current state of a transfer of owner
+func Get(owner uuid.UUID, id string) (Event, bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	e, ok := registry.entries[key{owner: owner, id: id}]
+	if !ok {
+		return Event{}, false
+	}
+	return e.event, true
+}
+
+// Subscribe follows a transfer of owner, which needn't have started yet. The channel holds the
+// latest event, starting with the current one; events in between may be skipped. Call the
+// returned function to stop following.
+func Subscribe(owner uuid.UUID, id string) (<-chan Event, func()) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	k := key{owner: owner, id: id}
+	e := lookup(k, time.Now())
+	if e.event.Stage == "" {
+		e.event = Event{TransferID: id, Stage: StageWaiting, UpdatedAt: time.Now()}
+	}
+
+	events := make(chan Event, 1)
+	events <- e.event
+	e.subscribers[events] = struct{}{}
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			registry.mutex.Lock()
+			defer registry.mutex.Unlock()
+			if e, ok := registry.entries[k]; ok {
+				delete(e.subscribers, events)
+				e.touched = time.Now()
+			}
+		})
+	}
+}
+
+// lookup returns the entry of k, creating it, and drops transfers left alone past retention.
+// The registry must be locked.
+func lookup(k key, now time.Time) *entry {
+	for other, e := range registry.entries {
+		if len(e.subscribers) == 0 && now.Sub(e.touched) > retention {
+			delete(registry.entries, other)
+		}
+	}
+
+	e, ok := registry.entries[k]
+	if !ok {
+		e = &entry{subscribers: make(map[chan Event]struct{})}
+		registry.entries[k] = e
+	}
+	e.touched = now
+	return e
+}
+
+// deliver replaces whatever event a subscriber hasn't taken yet with event, never blocking
+func deliver(subscriber chan Event, event Event) {
+	select {
+	case <-subscriber:
+	default:
+	}
+	subscriber <- event
+}
+
+type countingReader struct {
+	reader   io.Reader
+	transfer *Transfer
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.transfer.Add(int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,201 @@
+// Package replicas picks which copy of a file serves a read: the one in the reader's zone when the
+// file has one there, so downloads don't cross zones when they needn't.
+package replicas
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Placement"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+// Replica is a copy of a file's content reads can be served from
+type Replica struct {
+	Node    *entities.StorageNode // nil for the master's own storage
+	Path    string                // the file's path for its primary copy, a node:// path for others
+	Zone    string
+	Primary bool
+}
+
+// zoneRange is a ZONE_RANGES entry
+type zoneRange struct {
+	prefix netip.Prefix
+	zone   string
+}
+
+var (
+	rangesOnce sync.Once
+	ranges     []zoneRange
+)
+
+// ClientZone returns the zone of the client making a request: the one it names in the configured
+// header, otherwise the one of the most specific range of ZONE_RANGES holding its address. Empty
+// when neither says.
+func ClientZone(c *fiber.Ctx) string {
+	settings := config.GetSettings()
+	if settings.ClientZoneHeader != "" {
+		if zone := strings.TrimSpace(c.Get(settings.ClientZoneHeader)); zone != "" {
+			return zone
+		}
+	}
+
+	addr, err := netip.ParseAddr(c.IP())
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	zone, bits := "", -1
+	for _, r := range zoneRanges() {
+		if r.prefix.Contains(addr) && r.prefix.Bits() > bits {
+			zone, bits = r.zone, r.prefix.Bits()
+		}
+	}
+	return zone
+}
+
+// zoneRanges parses ZONE_RANGES once, logging and leaving out entries that aren't zone=CIDR
+func zoneRanges() []zoneRange {
+	rangesOnce.Do(func() {
+		for _, entry := range config.GetSettings().ZoneRanges {
+			zone, cidr, ok := strings.Cut(entry, "=")
+			zone, cidr = strings.TrimSpace(zone), strings.TrimSpace(cidr)
+			prefix, err := netip.ParsePrefix(cidr)
+			if !ok || zone == "" || err != nil {
+				log.Printf("Ignoring ZONE_RANGES entry %q, expected zone=CIDR", entry)
+				continue
+			}
+			ranges = append(ranges, zoneRange{prefix: prefix.Masked(), zone: zone})
+		}
+	})
+	return ranges
+}
+
+// Nearest returns the path to read a file's content from for a reader in zone: its primary copy
+// at path when that is in the zone, or the zone is unknown, otherwise a replica in the zone, and
+// the primary copy again when there is none. A primary copy on a node draining reads for
+// maintenance gives way to any other replica, one in the zone first.
+func Nearest(ctx context.Context, dbContext *persistence.AppDbContext, fileID uuid.UUID, path, zone string) string {
+	if zone == "" && !drainingPath(dbContext.GetDB().WithContext(ctx), path) {
+		return path
+	}
+	replicas, err := List(ctx, dbContext, fileID)
+	if err != nil {
+		log.Printf("Failed to list replicas of file %s: %v", fileID, err)
+		return path
+	}
+
+	var fallback string
+	// The primary copy comes first, so it is kept whenever it is in the zone
+	for _, replica := range replicas {
+		if replica.Primary && replica.Node != nil && replica.Node.Draining() {
+			continue
+		}
+		if zone != "" && replica.Zone == zone {
+			return replica.Path
+		}
+		if fallback == "" && !replica.Primary {
+			fallback = replica.Path
+		}
+	}
+	if fallback != "" && replicas[0].Node != nil && replicas[0].Node.Draining() {
+		return fallback
+	}
+	return path
+}
+
+// drainingPath reports whether path is on a node draining reads for maintenance
+func drainingPath(db *gorm.DB, path string) bool {
+	if !storage.IsNodePath(path) {
+		return false
+	}
+	nodePath, err := storage.ParseNodePath(path)
+	if err != nil {
+		return false
+	}
+	var node entities.StorageNode
+	if err := db.First(&node, `"Id" = ?`, nodePath.NodeID).Error; err != nil {
+		return false
+	}
+	return node.Draining()
+}
+
+// List returns the copies of a file that can serve reads: its primary copy, then its backup
+// copies on registered storage nodes that are active, healthy and not draining reads, and hold
+// its current content, in BACKUP_ZONE or their node's zone. Backups elsewhere, such as in S3, can't be read from directly.
+func List(ctx context.Context, dbContext *persistence.AppDbContext, fileID uuid.UUID) ([]Replica, error) {
+	return listReplicas(dbContext.GetDB().WithContext(ctx), fileID)
+}
+
+// listReplicas lists the copies of the file as List does
+func listReplicas(db *gorm.DB, fileID uuid.UUID) ([]Replica, error) {
+	var file entities.File
+	if err := db.First(&file, `"Id" = ?`, fileID).Error; err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+
+	primary := Replica{Path: file.Path, Primary: true}
+	if storage.IsNodePath(file.Path) {
+		nodePath, err := storage.ParseNodePath(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		var node entities.StorageNode
+		if err := db.First(&node, `"Id" = ?`, nodePath.NodeID).Error; err == nil {
+			primary.Node = &node
+		}
+	}
+	if primary.Node != nil || !storage.IsNodePath(file.Path) {
+		primary.Zone = placement.Zone(primary.Node)
+	}
+	replicas := []Replica{primary}
+
+	var backups []entities.BackupObject
+	if err := db.Where(`"FileId" = ? AND "Destination" LIKE ?`, file.Id, "node:%").Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch backup copies: %w", err)
+	}
+	backupZone := config.GetSettings().BackupZone
+	for i := range backups {
+		backup := &backups[i]
+		if !backup.Covers(&file) {
+			continue
+		}
+		node, err := readableNode(db, strings.TrimPrefix(backup.Destination, "node:"))
+		if err != nil {
+			continue
+		}
+		replica := Replica{
+			Node: node,
+			Path: fmt.Sprintf("node://%s/%s/%s", node.Id, file.BucketId, file.Id),
+			Zone: backupZone,
+		}
+		if replica.Zone == "" {
+			replica.Zone = node.Zone
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+// readableNode finds the registered node at url that can serve reads: active, healthy and not
+// draining reads
+func readableNode(db *gorm.DB, url string) (*entities.StorageNode, error) {
+	var node entities.StorageNode
+	err := db.Where(`RTRIM("URL", '/') = ? AND "IsActive" AND "IsHealthy" AND "FailedAt" IS NULL AND ("MaintenanceAt" IS NULL OR NOT "DrainReads")`, url).
+		First(&node).Error
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
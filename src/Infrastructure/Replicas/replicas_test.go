@@ -0,0 +1,75 @@
+package replicas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestListReplicas lists a file's primary copy first, in the zone of its node
+func TestListReplicas(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	node := entities.StorageNode{Name: "node-1", URL: "http://node-1", AuthKey: "key", IsActive: true, IsHealthy: true, Zone: "rack-1"}
+	if err := db.Create(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	file := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "node://" + node.Id.String() + "/" + bucket.Id.String() + "/" + uuid.NewString()}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	replicas, err := listReplicas(db, file.Id)
+	if err != nil {
+		t.Fatalf("listReplicas() = %v", err)
+	}
+	if len(replicas) != 1 || !replicas[0].Primary || replicas[0].Path != file.Path || replicas[0].Zone != "rack-1" {
+		t.Errorf("listReplicas() = %+v, want the primary copy in rack-1", replicas)
+	}
+	if _, err := listReplicas(db, uuid.New()); err == nil {
+		t.Error("listReplicas() of a missing file = nil error, want one")
+	}
+}
+
+// TestBackupReplicas adds the backup copies on nodes that can serve reads, in their node's zone
+func TestBackupReplicas(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	now := time.Now()
+	readable := entities.StorageNode{Name: "readable", URL: "http://readable/", Zone: "rack-2"}
+	draining := entities.StorageNode{Name: "draining", URL: "http://draining", Zone: "rack-3", MaintenanceAt: &now, DrainReads: true}
+	for _, node := range []*entities.StorageNode{&readable, &draining} {
+		node.AuthKey, node.IsActive, node.IsHealthy = "key", true, true
+		if err := db.Create(node).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := entities.File{BucketId: bucket.Id, Name: "a.jpg", OriginalName: "a.jpg", Path: "/data/photos/a", Size: 10}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, destination := range []string{"node:http://readable", "node:http://draining", "s3:backups"} {
+		backup := entities.BackupObject{Destination: destination, FileId: file.Id, BucketId: bucket.Id, BucketName: bucket.Name, Name: file.Name,
+			OriginalName: file.Name, Size: file.Size, RunId: uuid.New(), UploadedBy: bucket.OwnerId, BackedUpAt: now.Add(time.Minute)}
+		if err := db.Create(&backup).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replicas, err := listReplicas(db, file.Id)
+	if err != nil {
+		t.Fatalf("listReplicas() = %v", err)
+	}
+	if len(replicas) != 2 || replicas[1].Node == nil || replicas[1].Node.Id != readable.Id || replicas[1].Zone != "rack-2" {
+		t.Errorf("listReplicas() = %+v, want the primary copy and the backup on the readable node", replicas)
+	}
+
+	onDraining := "node://" + draining.Id.String() + "/" + bucket.Id.String() + "/" + file.Id.String()
+	if !drainingPath(db, onDraining) || drainingPath(db, file.Path) {
+		t.Error("drainingPath() doesn't tell the draining node's path from the master's")
+	}
+}
@@ -0,0 +1,309 @@
+package routing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AnyMethod registers a route for every request method, WebDAV's included
+const AnyMethod = "*"
+
+// Roles that can be required of a route's caller, lowest first
+var Roles = []string{"viewer", "editor", "manager", "admin"}
+
+// Permissions custom roles are made of. The built-in roles stay a hierarchy, custom roles hold
+// exactly the permissions an admin gives them.
+var Permissions = []string{
+	PermissionAccount, "read", "list", "download", "write", "upload", "delete",
+	"manage_buckets", "manage_nodes", "manage_users", "manage_system",
+}
+
+// PermissionAccount is managing one's own account, such as signing out or API keys, which every role may
+const PermissionAccount = "account"
+
+// rolePermissions is the permission a route requiring a role asks of custom roles, unless the
+// route names a more specific one
+var rolePermissions = map[string]string{
+	"viewer":  "read",
+	"editor":  "write",
+	"manager": "manage_buckets",
+	"admin":   "manage_system",
+}
+
+// Access kinds. The zero value is no policy at all, which Validate rejects.
+const (
+	accessRole     = "role"
+	accessPublic   = "public"
+	accessVerified = "verified"
+)
+
+// Access is who may call a route
+type Access struct {
+	kind       string
+	role       string
+	permission string
+	// credentials describes what a route that checks its own credentials accepts
+	credentials string
+}
+
+// Role lets users, and API keys, with at least role call a route
+func Role(role string) Access {
+	return Access{kind: accessRole, role: role}
+}
+
+// WithPermission names the permission a custom role needs to call a route, instead of the one
+// derived from its role. Built-in roles are only held to the role.
+func (a Access) WithPermission(permission string) Access {
+	a.permission = permission
+	return a
+}
+
+// Public lets anyone call a route
+func Public() Access {
+	return Access{kind: accessPublic}
+}
+
+// Verified marks a route whose handler checks the caller's credentials itself, such as a token
+// in the path or a storage node's auth key. credentials describes what it accepts.
+func Verified(credentials string) Access {
+	return Access{kind: accessVerified, credentials: credentials}
+}
+
+// RequiredRole is the role the route's caller needs, empty when none is checked before the handler
+func (a Access) RequiredRole() string {
+	return a.role
+}
+
+// RequiredPermission is the permission a custom role needs to call the route, empty when no role is checked
+func (a Access) RequiredPermission() string {
+	if a.permission != "" {
+		return a.permission
+	}
+	return rolePermissions[a.role]
+}
+
+// IsPublic reports whether anyone can call the route
+func (a Access) IsPublic() bool {
+	return a.kind == accessPublic
+}
+
+// Credentials describes what a route that checks its own credentials accepts
+func (a Access) Credentials() string {
+	return a.credentials
+}
+
+func (a Access) String() string {
+	switch a.kind {
+	case accessRole:
+		if a.permission != "" {
+			return "role " + a.role + " or permission " + a.permission
+		}
+		return "role " + a.role
+	case accessVerified:
+		return "verified by the handler: " + a.credentials
+	case "":
+		return "none"
+	}
+	return a.kind
+}
+
+// RateLimit is whether a route counts against the per-client API rate limit
+type RateLimit int
+
+const (
+	RateLimited RateLimit = iota
+	// Unlimited is for node-to-master traffic, health checks and routes outside the API
+	Unlimited
+)
+
+// BodyLimit is how a route's request body is limited
+type BodyLimit int
+
+const (
+	// LimitedBody holds the body to BODY_LIMIT and hands it to the handler whole
+	LimitedBody BodyLimit = iota
+	// StreamedBody is for uploads, which stream their body to storage and are held to the
+	// bucket's maximum file size instead
+	StreamedBody
+)
+
+// Operation is the kind of work a route does, which bounds how long its requests may run
+type Operation int
+
+const (
+	// MetadataOperation reads and writes records and makes short calls to nodes, held to REQUEST_TIMEOUT
+	MetadataOperation Operation = iota
+	// TransferOperation moves file content for as long as the client keeps up, without a deadline
+	TransferOperation
+	// MaintenanceOperation is administrative work that may go through many files in one request,
+	// held to MAINTENANCE_REQUEST_TIMEOUT
+	MaintenanceOperation
+)
+
+// Route is one entry of the route table
+type Route struct {
+	Method string
+	// Path is the full path, in fiber's syntax
+	Path    string
+	Handler fiber.Handler
+	// Middleware runs after the route's access is checked, before Handler
+	Middleware []fiber.Handler
+	Access     Access
+	RateLimit  RateLimit
+	BodyLimit  BodyLimit
+	Operation  Operation
+}
+
+// IDParams are the path parameters that hold IDs, which routes only accept as UUIDs
+var IDParams = []string{
+	"id", "bucketId", "fileId", "userId", "keyId", "jobId", "webhookId", "grantId", "uploadId", "tokenId", "commentId",
+}
+
+// IDParams lists the route's path parameters that hold IDs
+func (r Route) IDParams() []string {
+	var names []string
+	for _, segment := range strings.Split(r.Path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok && isIDParam(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Pattern is the route as "METHOD /path"
+func (r Route) Pattern() string {
+	return r.Method + " " + r.Path
+}
+
+// Table is every route the server serves, in the order they are matched
+type Table []Route
+
+// Guards build the middleware the table's policies are enforced with
+type Guards struct {
+	// Authorize checks the caller has at least role, or a custom role holding permission
+	Authorize func(role, permission string) fiber.Handler
+	RateLimit fiber.Handler
+	// Context gives requests the context of their operation, handlers read it as UserContext
+	Context func(operation Operation) fiber.Handler
+	// Params checks the route's ID parameters before its handler runs
+	Params func(names []string) fiber.Handler
+}
+
+// Validate checks every route has a handler and an access policy, and that no route is declared twice
+func (t Table) Validate() error {
+	seen := make(map[string]bool, len(t))
+	for _, route := range t {
+		pattern := route.Pattern()
+		if route.Method == "" || route.Path == "" {
+			return fmt.Errorf("route %q: missing method or path", pattern)
+		}
+		if seen[pattern] {
+			return fmt.Errorf("route %q: declared twice", pattern)
+		}
+		seen[pattern] = true
+		if route.Handler == nil {
+			return fmt.Errorf("route %q: missing handler", pattern)
+		}
+
+		switch route.Access.kind {
+		case accessRole:
+			if !isRole(route.Access.role) {
+				return fmt.Errorf("route %q: unknown role %q", pattern, route.Access.role)
+			}
+			if !IsPermission(route.Access.RequiredPermission()) {
+				return fmt.Errorf("route %q: unknown permission %q", pattern, route.Access.permission)
+			}
+		case accessPublic:
+		case accessVerified:
+			if strings.TrimSpace(route.Access.credentials) == "" {
+				return fmt.Errorf("route %q: verified access must say what credentials it accepts", pattern)
+			}
+		default:
+			return fmt.Errorf("route %q: no access policy", pattern)
+		}
+
+		if route.BodyLimit == StreamedBody && route.Access.IsPublic() {
+			return fmt.Errorf("route %q: public routes can't stream unlimited bodies", pattern)
+		}
+		if route.BodyLimit == StreamedBody && route.Operation != TransferOperation {
+			return fmt.Errorf("route %q: streamed bodies are transfers, they can't be held to a deadline", pattern)
+		}
+	}
+	return nil
+}
+
+// Streamed lists the routes with streamed bodies as "METHOD /path", for middleware.BodyLimit
+func (t Table) Streamed() []string {
+	var patterns []string
+	for _, route := range t {
+		if route.BodyLimit == StreamedBody {
+			patterns = append(patterns, route.Pattern())
+		}
+	}
+	return patterns
+}
+
+// Register adds the table's routes to app, each with the context of its operation and behind the
+// rate limit and access check its policy asks for, with its ID parameters checked after access
+func (t Table) Register(app *fiber.App, guards Guards) {
+	authorize := make(map[string]fiber.Handler)
+	contexts := make(map[Operation]fiber.Handler)
+	for _, route := range t {
+		if _, ok := contexts[route.Operation]; !ok {
+			contexts[route.Operation] = guards.Context(route.Operation)
+		}
+		handlers := []fiber.Handler{contexts[route.Operation]}
+		if route.RateLimit == RateLimited {
+			handlers = append(handlers, guards.RateLimit)
+		}
+		if role := route.Access.RequiredRole(); role != "" {
+			permission := route.Access.RequiredPermission()
+			key := role + " " + permission
+			if _, ok := authorize[key]; !ok {
+				authorize[key] = guards.Authorize(role, permission)
+			}
+			handlers = append(handlers, authorize[key])
+		}
+		if names := route.IDParams(); len(names) > 0 {
+			handlers = append(handlers, guards.Params(names))
+		}
+		handlers = append(handlers, route.Middleware...)
+		handlers = append(handlers, route.Handler)
+
+		if route.Method == AnyMethod {
+			app.All(route.Path, handlers...)
+		} else {
+			app.Add(route.Method, route.Path, handlers...)
+		}
+	}
+}
+
+func isIDParam(name string) bool {
+	for _, known := range IDParams {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+func isRole(role string) bool {
+	for _, known := range Roles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPermission reports whether permission is one custom roles can hold
+func IsPermission(permission string) bool {
+	for _, known := range Permissions {
+		if permission == known {
+			return true
+		}
+	}
+	return false
+}
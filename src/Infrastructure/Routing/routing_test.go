@@ -0,0 +1,87 @@
+package routing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func handler(c *fiber.Ctx) error {
+	return nil
+}
+
+func TestValidateAcceptsEveryPolicy(t *testing.T) {
+	table := Table{
+		{Method: fiber.MethodGet, Path: "/public", Handler: handler, Access: Public()},
+		{Method: fiber.MethodGet, Path: "/viewer", Handler: handler, Access: Role("viewer")},
+		{Method: fiber.MethodPost, Path: "/upload", Handler: handler, Access: Role("editor").WithPermission("upload"), BodyLimit: StreamedBody, Operation: TransferOperation},
+		{Method: fiber.MethodPost, Path: "/node", Handler: handler, Access: Verified("node auth key")},
+	}
+	if err := table.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		table Table
+		want  string
+	}{
+		{
+			name:  "zero-value access",
+			table: Table{{Method: fiber.MethodGet, Path: "/files", Handler: handler}},
+			want:  "no access policy",
+		},
+		{
+			name: "duplicate pattern",
+			table: Table{
+				{Method: fiber.MethodGet, Path: "/files", Handler: handler, Access: Role("viewer")},
+				{Method: fiber.MethodGet, Path: "/files", Handler: handler, Access: Public()},
+			},
+			want: "declared twice",
+		},
+		{
+			name:  "public streamed body",
+			table: Table{{Method: fiber.MethodPost, Path: "/upload", Handler: handler, Access: Public(), BodyLimit: StreamedBody, Operation: TransferOperation}},
+			want:  "public routes can't stream",
+		},
+		{
+			name:  "streamed body held to a deadline",
+			table: Table{{Method: fiber.MethodPost, Path: "/upload", Handler: handler, Access: Role("editor"), BodyLimit: StreamedBody}},
+			want:  "streamed bodies are transfers",
+		},
+		{
+			name:  "missing handler",
+			table: Table{{Method: fiber.MethodGet, Path: "/files", Access: Role("viewer")}},
+			want:  "missing handler",
+		},
+		{
+			name:  "unknown role",
+			table: Table{{Method: fiber.MethodGet, Path: "/files", Handler: handler, Access: Role("owner")}},
+			want:  "unknown role",
+		},
+		{
+			name:  "unknown permission",
+			table: Table{{Method: fiber.MethodGet, Path: "/files", Handler: handler, Access: Role("viewer").WithPermission("browse")}},
+			want:  "unknown permission",
+		},
+		{
+			name:  "verified without credentials",
+			table: Table{{Method: fiber.MethodGet, Path: "/files", Handler: handler, Access: Verified(" ")}},
+			want:  "must say what credentials",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.table.Validate()
+			if err == nil {
+				t.Fatalf("Validate() = nil, want an error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() = %q, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/swaggo/swag"
+)
+
+// Document registers spec's swagger document under name with each operation's security taken from
+// the table, so the documentation can't drift from what the server enforces. Role routes take a
+// bearer token or API key and name the role they need as x-required-role, public routes need no
+// credentials and routes that check their own credentials describe them as x-credentials. ID
+// parameters are documented as UUIDs, and operations with them or a body as answering 422 when
+// they fail validation.
+func (t Table) Document(name string, spec *swag.Spec) {
+	swag.Register(name, &document{table: t, spec: spec})
+}
+
+type document struct {
+	table Table
+	spec  *swag.Spec
+	once  sync.Once
+	doc   string
+}
+
+func (d *document) ReadDoc() string {
+	d.once.Do(func() {
+		doc, err := d.table.secure(d.spec.ReadDoc(), d.spec.BasePath)
+		if err != nil {
+			log.Printf("Warning: failed to apply route security to swagger document: %v", err)
+			doc = d.spec.ReadDoc()
+		}
+		d.doc = doc
+	})
+	return d.doc
+}
+
+func (t Table) secure(raw, basePath string) (string, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return "", err
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	definitions, _ := doc["definitions"].(map[string]interface{})
+	_, errorSchema := definitions["apierror.Error"]
+
+	// Parameter names in the documentation don't always match the route's
+	documented := make(map[string]string, len(paths))
+	for path := range paths {
+		documented[normalizePath(path)] = path
+	}
+
+	for _, route := range t {
+		path, ok := strings.CutPrefix(route.Path, basePath)
+		if !ok || route.Method == AnyMethod {
+			continue
+		}
+		docPath := documented[normalizePath(path)]
+		operations, _ := paths[docPath].(map[string]interface{})
+		operation, ok := operations[strings.ToLower(route.Method)].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch route.Access.kind {
+		case accessRole:
+			operation["security"] = []interface{}{
+				map[string]interface{}{"Bearer": []interface{}{}},
+				map[string]interface{}{"ApiKeyAuth": []interface{}{}},
+			}
+			operation["x-required-role"] = route.Access.role
+		case accessPublic:
+			operation["security"] = []interface{}{}
+		case accessVerified:
+			operation["x-credentials"] = route.Access.credentials
+		}
+		documentValidation(operation, path, docPath, errorSchema)
+	}
+
+	secured, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(secured), nil
+}
+
+// documentValidation marks the operation's ID parameters, found by their place in the route's
+// path, as UUIDs and adds the 422 answered when they, or its body, fail validation
+func documentValidation(operation map[string]interface{}, path, docPath string, errorSchema bool) {
+	ids := make(map[string]bool)
+	docSegments := strings.Split(docPath, "/")
+	for i, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok && isIDParam(name) && i < len(docSegments) {
+			ids[strings.Trim(docSegments[i], "{}")] = true
+		}
+	}
+
+	validated := false
+	parameters, _ := operation["parameters"].([]interface{})
+	for _, item := range parameters {
+		parameter, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := parameter["name"].(string)
+		switch {
+		case parameter["in"] == "body":
+			validated = true
+		case parameter["in"] == "path" && ids[name]:
+			parameter["format"] = "uuid"
+			validated = true
+		}
+	}
+
+	responses, _ := operation["responses"].(map[string]interface{})
+	if !validated || responses == nil {
+		return
+	}
+	// Other failures the handler answers 422 keep their description
+	if documented, ok := responses["422"].(map[string]interface{}); ok {
+		documented["description"] = fmt.Sprintf("%v, or invalid parameters or body", documented["description"])
+		return
+	}
+	response := map[string]interface{}{"description": "Invalid parameters or body, each failure listed in fields"}
+	if errorSchema {
+		response["schema"] = map[string]interface{}{"$ref": "#/definitions/apierror.Error"}
+	}
+	responses["422"] = response
+}
+
+// normalizePath turns fiber's ":name" and swagger's "{name}" parameters into the same placeholder
+func normalizePath(path string) string {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")) {
+			segments[i] = "{}"
+		}
+	}
+	if normalized := strings.Join(segments, "/"); normalized != "" {
+		return normalized
+	}
+	return "/"
+}
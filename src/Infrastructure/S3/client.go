@@ -0,0 +1,405 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// Config holds connection details for an S3-compatible endpoint (AWS S3, MinIO, ...)
+type Config struct {
+	Endpoint  string `json:"endpoint" validate:"required,url"`
+	Region    string `json:"region"`
+	AccessKey string `json:"access_key" validate:"required"`
+	SecretKey string `json:"secret_key" validate:"required"`
+}
+
+// Client is a minimal path-style S3 client signed with AWS Signature Version 4
+type Client struct {
+	endpoint   *url.URL
+	region     string
+	accessKey  string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// ObjectInfo describes a single object returned by list, head or get calls
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+	ContentType  string
+	Metadata     map[string]string
+}
+
+// ListObjectsResult is a single page of a ListObjectsV2 call
+type ListObjectsResult struct {
+	Objects               []ObjectInfo
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// Error is an error response returned by the S3 endpoint
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3 request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3 request failed with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 returned by the S3 endpoint
+func IsNotFound(err error) bool {
+	s3Err, ok := err.(*Error)
+	return ok && s3Err.StatusCode == http.StatusNotFound
+}
+
+// NewClient creates a new S3 client for the given configuration
+func NewClient(cfg Config) (*Client, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.Endpoint)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &Client{
+		endpoint:   endpoint,
+		region:     region,
+		accessKey:  cfg.AccessKey,
+		secretKey:  cfg.SecretKey,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// ListObjectsOptions narrows a ListObjectsV2 call
+type ListObjectsOptions struct {
+	Prefix            string
+	StartAfter        string
+	ContinuationToken string
+	MaxKeys           int
+}
+
+// ListObjectsV2 lists a single page of objects
+func (c *Client) ListObjectsV2(ctx context.Context, bucket string, opts ListObjectsOptions) (*ListObjectsResult, error) {
+	query := url.Values{}
+	query.Set("list-type", "2")
+	if opts.Prefix != "" {
+		query.Set("prefix", opts.Prefix)
+	}
+	if opts.StartAfter != "" {
+		query.Set("start-after", opts.StartAfter)
+	}
+	if opts.ContinuationToken != "" {
+		query.Set("continuation-token", opts.ContinuationToken)
+	}
+	if opts.MaxKeys > 0 {
+		query.Set("max-keys", strconv.Itoa(opts.MaxKeys))
+	}
+
+	resp, err := c.do(ctx, "GET", bucket, "", query, nil, -1, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+		Contents              []struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			ETag         string    `xml:"ETag"`
+			LastModified time.Time `xml:"LastModified"`
+		} `xml:"Contents"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode list response: %w", err)
+	}
+
+	result := &ListObjectsResult{
+		IsTruncated:           body.IsTruncated,
+		NextContinuationToken: body.NextContinuationToken,
+	}
+	for _, content := range body.Contents {
+		result.Objects = append(result.Objects, ObjectInfo{
+			Key:          content.Key,
+			Size:         content.Size,
+			ETag:         strings.Trim(content.ETag, "\""),
+			LastModified: content.LastModified,
+		})
+	}
+
+	return result, nil
+}
+
+// HeadObject returns the metadata of an object without its content
+func (c *Client) HeadObject(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	resp, err := c.do(ctx, "HEAD", bucket, key, nil, nil, -1, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return objectInfoFromResponse(key, resp), nil
+}
+
+// GetObject streams an object's content. The caller must close the returned reader.
+func (c *Client) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error) {
+	resp, err := c.do(ctx, "GET", bucket, key, nil, nil, -1, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Body, objectInfoFromResponse(key, resp), nil
+}
+
+// PutObject uploads size bytes from body to bucket/key
+func (c *Client) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string, metadata map[string]string) error {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	for name, value := range metadata {
+		headers["X-Amz-Meta-"+name] = value
+	}
+
+	resp, err := c.do(ctx, "PUT", bucket, key, nil, body, size, headers)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// DeleteObject removes an object
+func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	resp, err := c.do(ctx, "DELETE", bucket, key, nil, nil, -1, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, bucket, key string, query url.Values, body io.Reader, size int64, headers map[string]string) (*http.Response, error) {
+	path := "/" + bucket
+	if key != "" {
+		path += "/" + key
+	}
+	canonicalURI := c.endpoint.Path + encodePath(path)
+
+	reqURL := *c.endpoint
+	reqURL.Opaque = "//" + c.endpoint.Host + canonicalURI
+	reqURL.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	req.URL.Opaque = "//" + c.endpoint.Host + canonicalURI
+	if size >= 0 {
+		req.ContentLength = size
+	}
+
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		payloadHash = unsignedPayload
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	c.sign(req, canonicalURI, payloadHash, time.Now().UTC())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		s3Err := &Error{StatusCode: resp.StatusCode}
+		if method != "HEAD" {
+			xml.NewDecoder(resp.Body).Decode(s3Err)
+		}
+		return nil, s3Err
+	}
+
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req
+func (c *Client) sign(req *http.Request, canonicalURI, payloadHash string, now time.Time) {
+	signV4(req, canonicalURI, payloadHash, Credentials{AccessKey: c.accessKey, SecretKey: c.secretKey}, c.region, "s3", now)
+}
+
+// Credentials are the AWS access key used to sign requests
+type Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // set for temporary credentials
+}
+
+// SignRequest signs a request to another AWS service (e.g. "kms") with Signature Version 4.
+// payload must be the request body, which is signed along with the headers.
+func SignRequest(req *http.Request, payload []byte, creds Credentials, region, service string) {
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	signV4(req, canonicalURI, hashHex(payload), creds, region, service, time.Now().UTC())
+}
+
+func signV4(req *http.Request, canonicalURI, payloadHash string, creds Credentials, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headerValues := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headerValues[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	headerNames := make([]string, 0, len(headerValues))
+	for name := range headerValues {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headerValues[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.SecretKey), dateStamp)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKey, scope, signedHeaders, signature))
+}
+
+func objectInfoFromResponse(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ETag:        strings.Trim(resp.Header.Get("ETag"), "\""),
+		ContentType: resp.Header.Get("Content-Type"),
+		Metadata:    map[string]string{},
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+	for name, values := range resp.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") && len(values) > 0 {
+			info.Metadata[strings.ToLower(strings.TrimPrefix(strings.ToLower(name), "x-amz-meta-"))] = values[0]
+		}
+	}
+	return info
+}
+
+// canonicalQuery encodes query parameters sorted by key as required by SigV4
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func encodePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// uriEncode percent-encodes everything except unreserved characters (and '/' when encodeSlash is false)
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
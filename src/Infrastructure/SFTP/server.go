@@ -0,0 +1,251 @@
+package sftp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/WebDAV"
+)
+
+// Server is an SFTP server exposing the buckets a user owns as directories, through the same
+// file system as WebDAV. Users log in with their username or email and either their password
+// or one of their API keys. Nothing listens unless SFTP is enabled in the settings.
+type Server struct {
+	dbContext   *persistence.AppDbContext
+	mediator    *mediator.Mediator
+	authService *auth.AuthorizationService
+	settings    *config.Settings
+
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[*ssh.ServerConn]struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewServer(dbContext *persistence.AppDbContext, mediator *mediator.Mediator, authService *auth.AuthorizationService) *Server {
+	return &Server{
+		dbContext:   dbContext,
+		mediator:    mediator,
+		authService: authService,
+		settings:    config.GetSettings(),
+		conns:       make(map[*ssh.ServerConn]struct{}),
+	}
+}
+
+// Start listens on the SFTP port and serves connections until Stop
+func (s *Server) Start() error {
+	if !s.settings.SFTPEnabled {
+		return nil
+	}
+
+	hostKey, err := loadHostKey(s.settings.SFTPHostKeyFile)
+	if err != nil {
+		return err
+	}
+	sshConfig := &ssh.ServerConfig{
+		PasswordCallback: s.authenticate,
+		ServerVersion:    "SSH-2.0-SHBucket",
+	}
+	sshConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", ":"+s.settings.SFTPPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen for SFTP: %w", err)
+	}
+	s.listener = listener
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					log.Printf("SFTP accept failed: %v", err)
+				}
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serveConn(ctx, conn, sshConfig)
+			}()
+		}
+	}()
+
+	log.Printf("SFTP server listening on port %s", s.settings.SFTPPort)
+	return nil
+}
+
+// Stop closes the listener and every open connection. Uploads that were not closed by their client are dropped.
+func (s *Server) Stop() {
+	if s.listener == nil {
+		return
+	}
+	s.listener.Close()
+	s.cancel()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// authenticate checks the password and carries what the user may do to the session in the permission extensions
+func (s *Server) authenticate(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	clientIP := meta.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(clientIP); err == nil {
+		clientIP = host
+	}
+	user, err := s.authService.AuthenticatePassword(meta.User(), string(password), clientIP, s.dbContext)
+	if err != nil {
+		log.Printf("SFTP login failed for %q from %s", meta.User(), meta.RemoteAddr())
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	access := webdav.NewAccess(s.authService, s.dbContext, user)
+	return &ssh.Permissions{Extensions: map[string]string{
+		"user_id": access.UserID.String(),
+		"read":    strconv.FormatBool(access.Read),
+		"write":   strconv.FormatBool(access.Write),
+		"buckets": strings.Join(access.Buckets, ","),
+	}}, nil
+}
+
+func accessFromPermissions(permissions *ssh.Permissions) (webdav.Access, error) {
+	userID, err := uuid.Parse(permissions.Extensions["user_id"])
+	if err != nil {
+		return webdav.Access{}, err
+	}
+	access := webdav.Access{
+		UserID: userID,
+		Read:   permissions.Extensions["read"] == "true",
+		Write:  permissions.Extensions["write"] == "true",
+	}
+	if buckets := permissions.Extensions["buckets"]; buckets != "" {
+		access.Buckets = strings.Split(buckets, ",")
+	}
+	return access, nil
+}
+
+func (s *Server) serveConn(ctx context.Context, netConn net.Conn, sshConfig *ssh.ServerConfig) {
+	conn, channels, requests, err := ssh.NewServerConn(netConn, sshConfig)
+	if err != nil {
+		netConn.Close()
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	access, err := accessFromPermissions(conn.Permissions)
+	if err != nil {
+		log.Printf("SFTP session for %q rejected: %v", conn.User(), err)
+		return
+	}
+
+	go ssh.DiscardRequests(requests)
+
+	var sessions sync.WaitGroup
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+
+		sessions.Add(1)
+		go func() {
+			defer sessions.Done()
+			defer channel.Close()
+
+			// Only the sftp subsystem is offered, no shell or commands
+			for request := range channelRequests {
+				if request.Type != "subsystem" || string(request.Payload[min(4, len(request.Payload)):]) != "sftp" {
+					request.Reply(false, nil)
+					continue
+				}
+				request.Reply(true, nil)
+				go ssh.DiscardRequests(channelRequests)
+
+				session := newSession(ctx, webdav.NewFileSystem(s.dbContext, s.mediator, access), channel)
+				err := session.serve()
+				status := uint32(0)
+				if err != nil {
+					log.Printf("SFTP session for %q ended: %v", conn.User(), err)
+					status = 1
+				}
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				return
+			}
+		}()
+	}
+	sessions.Wait()
+}
+
+// loadHostKey reads the server's host key, generating one on first start so clients see the same key
+// after restarts. Servers behind one address should share the key file.
+func loadHostKey(keyFile string) (ssh.Signer, error) {
+	keyPEM, err := os.ReadFile(keyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate SFTP host key: %w", err)
+		}
+		block, err := ssh.MarshalPrivateKey(key, "shbucket")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode SFTP host key: %w", err)
+		}
+		keyPEM = pem.EncodeToMemory(block)
+		if err := os.MkdirAll(filepath.Dir(keyFile), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to save SFTP host key: %w", err)
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save SFTP host key: %w", err)
+		}
+		log.Printf("Generated SFTP host key at %s", keyFile)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read SFTP host key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SFTP host key: %w", err)
+	}
+	return signer, nil
+}
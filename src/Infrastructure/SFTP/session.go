@@ -0,0 +1,625 @@
+package sftp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	netdav "golang.org/x/net/webdav"
+
+	"shbucket/src/Infrastructure/WebDAV"
+)
+
+// Packet types of SFTP version 3, the version OpenSSH and most clients speak
+const (
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpOpen     = 3
+	fxpClose    = 4
+	fxpRead     = 5
+	fxpWrite    = 6
+	fxpLstat    = 7
+	fxpFstat    = 8
+	fxpSetstat  = 9
+	fxpFsetstat = 10
+	fxpOpendir  = 11
+	fxpReaddir  = 12
+	fxpRemove   = 13
+	fxpMkdir    = 14
+	fxpRmdir    = 15
+	fxpRealpath = 16
+	fxpStat     = 17
+	fxpRename   = 18
+	fxpReadlink = 19
+	fxpSymlink  = 20
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpData     = 103
+	fxpName     = 104
+	fxpAttrs    = 105
+	fxpExtended = 200
+)
+
+// Status codes
+const (
+	fxOK               = 0
+	fxEOF              = 1
+	fxNoSuchFile       = 2
+	fxPermissionDenied = 3
+	fxFailure          = 4
+	fxBadMessage       = 5
+	fxOpUnsupported    = 8
+)
+
+// Open flags
+const (
+	fxfRead   = 0x01
+	fxfWrite  = 0x02
+	fxfAppend = 0x04
+	fxfCreat  = 0x08
+	fxfTrunc  = 0x10
+	fxfExcl   = 0x20
+)
+
+// Attribute flags
+const (
+	attrSize        = 0x01
+	attrPermissions = 0x04
+	attrACModTime   = 0x08
+)
+
+const (
+	maxPacketSize = 1 << 20 // larger than any request a client sends
+	maxReadSize   = 1 << 18 // most a single read returns
+	readdirBatch  = 100
+)
+
+// posixRename is the OpenSSH extension renaming over an existing file, which plain SFTP rename refuses
+const posixRename = "posix-rename@openssh.com"
+
+var errBadMessage = errors.New("malformed packet")
+
+// session serves the SFTP requests of one channel in the order they arrive.
+// Replies carry the request ID, so clients that send several requests at once still match them up.
+type session struct {
+	ctx     context.Context
+	fs      *webdav.FileSystem
+	channel io.ReadWriter
+	handles map[string]interface{} // *fileHandle or *dirHandle
+	next    uint64
+}
+
+type fileHandle struct {
+	file  netdav.File
+	write bool
+}
+
+type dirHandle struct {
+	dir     string
+	entries []os.FileInfo
+}
+
+func newSession(ctx context.Context, fs *webdav.FileSystem, channel io.ReadWriter) *session {
+	return &session{ctx: ctx, fs: fs, channel: channel, handles: make(map[string]interface{})}
+}
+
+func (s *session) serve() error {
+	defer s.closeHandles()
+
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(s.channel, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length < 1 || length > maxPacketSize {
+			return fmt.Errorf("packet of %d bytes", length)
+		}
+		payload := make([]byte, length-1)
+		if _, err := io.ReadFull(s.channel, payload); err != nil {
+			return err
+		}
+
+		reply := s.handle(header[4], payload)
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(reply)))
+		if _, err := s.channel.Write(append(out, reply...)); err != nil {
+			return err
+		}
+	}
+}
+
+// closeHandles drops what a client left open when it went away; unfinished uploads are discarded, not stored
+func (s *session) closeHandles() {
+	for _, h := range s.handles {
+		if fh, ok := h.(*fileHandle); ok {
+			if aborter, ok := fh.file.(interface{ Abort() error }); ok && fh.write {
+				aborter.Abort()
+			} else {
+				fh.file.Close()
+			}
+		}
+	}
+}
+
+func (s *session) handle(packetType byte, payload []byte) []byte {
+	r := reader(payload)
+	if packetType == fxpInit {
+		// Every client speaks version 3, whichever version it offers
+		reply := []byte{fxpVersion}
+		reply = binary.BigEndian.AppendUint32(reply, 3)
+		reply = appendString(reply, posixRename)
+		return appendString(reply, "1")
+	}
+
+	id, err := r.uint32()
+	if err != nil {
+		return statusReply(0, fxBadMessage, errBadMessage.Error())
+	}
+
+	var reply []byte
+	switch packetType {
+	case fxpOpen:
+		reply, err = s.open(id, &r)
+	case fxpClose:
+		reply, err = s.close(id, &r)
+	case fxpRead:
+		reply, err = s.read(id, &r)
+	case fxpWrite:
+		reply, err = s.write(id, &r)
+	case fxpLstat, fxpStat:
+		reply, err = s.stat(id, &r)
+	case fxpFstat:
+		reply, err = s.fstat(id, &r)
+	case fxpSetstat, fxpFsetstat:
+		// Stored files have no owner, permissions or times to set, and clients set them after every upload
+		reply = statusReply(id, fxOK, "")
+	case fxpOpendir:
+		reply, err = s.opendir(id, &r)
+	case fxpReaddir:
+		reply, err = s.readdir(id, &r)
+	case fxpRemove:
+		reply, err = s.remove(id, &r)
+	case fxpMkdir:
+		reply, err = s.mkdir(id, &r)
+	case fxpRmdir:
+		reply, err = s.rmdir(id, &r)
+	case fxpRealpath:
+		reply, err = s.realpath(id, &r)
+	case fxpRename:
+		reply, err = s.rename(id, &r, false)
+	case fxpExtended:
+		name, nameErr := r.string()
+		if nameErr == nil && name == posixRename {
+			reply, err = s.rename(id, &r, true)
+		} else {
+			reply = statusReply(id, fxOpUnsupported, "unsupported extension")
+		}
+	case fxpReadlink, fxpSymlink:
+		reply = statusReply(id, fxOpUnsupported, "links are not supported")
+	default:
+		reply = statusReply(id, fxOpUnsupported, "unsupported request")
+	}
+	if err != nil {
+		return errorReply(id, err)
+	}
+	return reply
+}
+
+func (s *session) open(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	pflags, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if pflags&(fxfWrite|fxfCreat|fxfTrunc|fxfAppend) == 0 {
+		file, err := s.fs.OpenFile(s.ctx, name, os.O_RDONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		return handleReply(id, s.addHandle(&fileHandle{file: file})), nil
+	}
+
+	// Content is stored whole, so a write replaces the file rather than changing part of it
+	if pflags&fxfAppend != 0 {
+		return statusReply(id, fxOpUnsupported, "appending to files is not supported"), nil
+	}
+	if pflags&fxfExcl != 0 {
+		if _, err := s.fs.Stat(s.ctx, name); err == nil {
+			return nil, os.ErrExist
+		}
+	}
+	file, err := s.fs.OpenFile(s.ctx, name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0)
+	if err != nil {
+		return nil, err
+	}
+	return handleReply(id, s.addHandle(&fileHandle{file: file, write: true})), nil
+}
+
+// close uploads a written file, so a failed upload is reported here
+func (s *session) close(id uint32, r *reader) ([]byte, error) {
+	handle, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+	h, ok := s.handles[handle]
+	if !ok {
+		return statusReply(id, fxFailure, "invalid handle"), nil
+	}
+	delete(s.handles, handle)
+
+	if fh, ok := h.(*fileHandle); ok {
+		if err := fh.file.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return statusReply(id, fxOK, ""), nil
+}
+
+func (s *session) read(id uint32, r *reader) ([]byte, error) {
+	fh, err := s.fileHandle(r)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := r.uint64()
+	if err != nil {
+		return nil, err
+	}
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if fh.write {
+		return nil, os.ErrPermission
+	}
+
+	if _, err := fh.file.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, min(length, maxReadSize))
+	n, err := io.ReadFull(fh.file, data)
+	if n == 0 {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return statusReply(id, fxEOF, ""), nil
+		}
+		return nil, err
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	reply := binary.BigEndian.AppendUint32([]byte{fxpData}, id)
+	return appendString(reply, string(data[:n])), nil
+}
+
+func (s *session) write(id uint32, r *reader) ([]byte, error) {
+	fh, err := s.fileHandle(r)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := r.uint64()
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+
+	writer, ok := fh.file.(io.WriterAt)
+	if !fh.write || !ok {
+		return nil, os.ErrPermission
+	}
+	if _, err := writer.WriteAt([]byte(data), int64(offset)); err != nil {
+		return nil, err
+	}
+	return statusReply(id, fxOK, ""), nil
+}
+
+func (s *session) stat(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.fs.Stat(s.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return appendAttrs(binary.BigEndian.AppendUint32([]byte{fxpAttrs}, id), info), nil
+}
+
+func (s *session) fstat(id uint32, r *reader) ([]byte, error) {
+	fh, err := s.fileHandle(r)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fh.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return appendAttrs(binary.BigEndian.AppendUint32([]byte{fxpAttrs}, id), info), nil
+}
+
+func (s *session) opendir(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.fs.OpenFile(s.ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	info, err := dir.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return statusReply(id, fxFailure, name+" is not a directory"), nil
+	}
+	entries, err := dir.Readdir(0)
+	if err != nil {
+		return nil, err
+	}
+	return handleReply(id, s.addHandle(&dirHandle{dir: name, entries: entries})), nil
+}
+
+func (s *session) readdir(id uint32, r *reader) ([]byte, error) {
+	handle, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+	dh, ok := s.handles[handle].(*dirHandle)
+	if !ok {
+		return statusReply(id, fxFailure, "invalid handle"), nil
+	}
+	if len(dh.entries) == 0 {
+		return statusReply(id, fxEOF, ""), nil
+	}
+
+	n := min(readdirBatch, len(dh.entries))
+	entries := dh.entries[:n]
+	dh.entries = dh.entries[n:]
+	return nameReply(id, entries), nil
+}
+
+func (s *session) remove(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.fs.Stat(s.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return statusReply(id, fxFailure, name+" is a directory"), nil
+	}
+	if err := s.fs.RemoveAll(s.ctx, name); err != nil {
+		return nil, err
+	}
+	return statusReply(id, fxOK, ""), nil
+}
+
+func (s *session) mkdir(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fs.Mkdir(s.ctx, name, 0o755); err != nil {
+		return nil, err
+	}
+	return statusReply(id, fxOK, ""), nil
+}
+
+// rmdir removes an empty directory, like rmdir(2); clients remove the contents first
+func (s *session) rmdir(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.fs.OpenFile(s.ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	info, err := dir.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return statusReply(id, fxFailure, name+" is not a directory"), nil
+	}
+	if entries, err := dir.Readdir(1); err == nil && len(entries) > 0 {
+		return statusReply(id, fxFailure, name+" is not empty"), nil
+	}
+	if err := s.fs.RemoveAll(s.ctx, name); err != nil {
+		return nil, err
+	}
+	return statusReply(id, fxOK, ""), nil
+}
+
+// realpath resolves a path against the root, which is every session's home directory
+func (s *session) realpath(id uint32, r *reader) ([]byte, error) {
+	name, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	reply := binary.BigEndian.AppendUint32([]byte{fxpName}, id)
+	reply = binary.BigEndian.AppendUint32(reply, 1)
+	reply = appendString(reply, name)
+	reply = appendString(reply, name)
+	return binary.BigEndian.AppendUint32(reply, 0), nil
+}
+
+// rename moves a file or directory within its bucket. Plain SFTP rename fails when the target
+// exists; the OpenSSH extension replaces a target file.
+func (s *session) rename(id uint32, r *reader, overwrite bool) ([]byte, error) {
+	oldName, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+	newName, err := r.path()
+	if err != nil {
+		return nil, err
+	}
+
+	if target, err := s.fs.Stat(s.ctx, newName); err == nil {
+		if !overwrite || target.IsDir() {
+			return statusReply(id, fxFailure, newName+" already exists"), nil
+		}
+		if err := s.fs.RemoveAll(s.ctx, newName); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.fs.Rename(s.ctx, oldName, newName); err != nil {
+		return nil, err
+	}
+	return statusReply(id, fxOK, ""), nil
+}
+
+func (s *session) addHandle(h interface{}) string {
+	s.next++
+	handle := strconv.FormatUint(s.next, 10)
+	s.handles[handle] = h
+	return handle
+}
+
+func (s *session) fileHandle(r *reader) (*fileHandle, error) {
+	handle, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+	fh, ok := s.handles[handle].(*fileHandle)
+	if !ok {
+		return nil, fmt.Errorf("invalid handle")
+	}
+	return fh, nil
+}
+
+// reader decodes the fields of a request
+type reader []byte
+
+func (r *reader) uint32() (uint32, error) {
+	if len(*r) < 4 {
+		return 0, errBadMessage
+	}
+	v := binary.BigEndian.Uint32(*r)
+	*r = (*r)[4:]
+	return v, nil
+}
+
+func (r *reader) uint64() (uint64, error) {
+	if len(*r) < 8 {
+		return 0, errBadMessage
+	}
+	v := binary.BigEndian.Uint64(*r)
+	*r = (*r)[8:]
+	return v, nil
+}
+
+func (r *reader) string() (string, error) {
+	length, err := r.uint32()
+	if err != nil {
+		return "", err
+	}
+	if uint32(len(*r)) < length {
+		return "", errBadMessage
+	}
+	v := string((*r)[:length])
+	*r = (*r)[length:]
+	return v, nil
+}
+
+// path reads a path, relative ones being relative to the root
+func (r *reader) path() (string, error) {
+	name, err := r.string()
+	if err != nil {
+		return "", err
+	}
+	return path.Join("/", name), nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func appendAttrs(b []byte, info os.FileInfo) []byte {
+	mode := uint32(0o100644)
+	if info.IsDir() {
+		mode = 0o040755
+	}
+	mtime := uint32(info.ModTime().Unix())
+	b = binary.BigEndian.AppendUint32(b, attrSize|attrPermissions|attrACModTime)
+	b = binary.BigEndian.AppendUint64(b, uint64(info.Size()))
+	b = binary.BigEndian.AppendUint32(b, mode)
+	b = binary.BigEndian.AppendUint32(b, mtime)
+	return binary.BigEndian.AppendUint32(b, mtime)
+}
+
+func statusReply(id uint32, code uint32, message string) []byte {
+	b := binary.BigEndian.AppendUint32([]byte{fxpStatus}, id)
+	b = binary.BigEndian.AppendUint32(b, code)
+	b = appendString(b, message)
+	return appendString(b, "")
+}
+
+func handleReply(id uint32, handle string) []byte {
+	return appendString(binary.BigEndian.AppendUint32([]byte{fxpHandle}, id), handle)
+}
+
+func nameReply(id uint32, entries []os.FileInfo) []byte {
+	b := binary.BigEndian.AppendUint32([]byte{fxpName}, id)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(entries)))
+	for _, info := range entries {
+		b = appendString(b, info.Name())
+		b = appendString(b, longName(info))
+		b = appendAttrs(b, info)
+	}
+	return b
+}
+
+// longName is the ls -l style line clients like OpenSSH's sftp print for a listing
+func longName(info os.FileInfo) string {
+	mode := "-rw-r--r--"
+	if info.IsDir() {
+		mode = "drwxr-xr-x"
+	}
+	modTime := info.ModTime()
+	stamp := modTime.Format("Jan _2 15:04")
+	if time.Since(modTime) > 180*24*time.Hour {
+		stamp = modTime.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s 1 shbucket shbucket %8d %s %s", mode, info.Size(), stamp, info.Name())
+}
+
+// errorReply maps file system errors onto SFTP status codes
+func errorReply(id uint32, err error) []byte {
+	switch {
+	case errors.Is(err, errBadMessage):
+		return statusReply(id, fxBadMessage, err.Error())
+	case errors.Is(err, os.ErrNotExist):
+		return statusReply(id, fxNoSuchFile, "no such file")
+	case errors.Is(err, os.ErrPermission):
+		return statusReply(id, fxPermissionDenied, "permission denied")
+	case errors.Is(err, os.ErrExist):
+		return statusReply(id, fxFailure, "file already exists")
+	default:
+		return statusReply(id, fxFailure, err.Error())
+	}
+}
@@ -0,0 +1,146 @@
+package scanning
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunk is how much content is sent to clamd per INSTREAM chunk
+const clamavChunk = 64 * 1024
+
+// clamavScanner streams content to a ClamAV daemon with the INSTREAM command
+type clamavScanner struct {
+	address string // host:port, or a unix socket path
+}
+
+func (s *clamavScanner) Name() string {
+	return "clamav"
+}
+
+func (s *clamavScanner) Scan(ctx context.Context, name string, content io.Reader) (Result, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	sendErr := s.send(conn, content)
+	// clamd answers, and closes the connection, when the stream exceeds its StreamMaxLength
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		if sendErr != nil {
+			return Result{}, fmt.Errorf("failed to send content to clamd: %w", sendErr)
+		}
+		return Result{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamavReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// Version asks clamd for its engine and virus definitions with the VERSION command
+func (s *clamavScanner) Version(ctx context.Context) (Engine, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return Engine{}, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zVERSION\x00")); err != nil {
+		return Engine{}, fmt.Errorf("failed to query clamd: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Engine{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamavVersion(strings.TrimRight(reply, "\x00\n"))
+}
+
+// dial connects to clamd, the connection ends at the context's deadline
+func (s *clamavScanner) dial(ctx context.Context) (net.Conn, error) {
+	network, address := "tcp", s.address
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	} else if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// send writes content as INSTREAM chunks, each prefixed with its length, ending with an empty chunk
+func (s *clamavScanner) send(conn net.Conn, content io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+clamavChunk)
+	for {
+		n, err := content.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := conn.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseClamavReply reads a reply such as "stream: OK" or "stream: Eicar-Signature FOUND"
+func parseClamavReply(reply string) (Result, error) {
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// parseClamavVersion reads a reply such as "ClamAV 1.3.1/27421/Thu Oct 15 08:26:29 2026": the
+// engine, the definitions' version and when they were published. clamd leaves out the last two
+// when it has no definitions loaded.
+func parseClamavVersion(reply string) (Engine, error) {
+	parts := strings.SplitN(reply, "/", 3)
+	if !strings.HasPrefix(parts[0], "ClamAV ") {
+		return Engine{}, fmt.Errorf("clamd: %s", reply)
+	}
+
+	engine := Engine{Version: parts[0]}
+	if len(parts) > 1 {
+		engine.Definitions = parts[1]
+	}
+	if len(parts) > 2 {
+		// clamd writes the date in its own local time, without a zone
+		updated, err := time.Parse("Mon Jan _2 15:04:05 2006", strings.TrimSpace(parts[2]))
+		if err != nil {
+			return Engine{}, fmt.Errorf("clamd: invalid definitions date in %q", reply)
+		}
+		engine.DefinitionsUpdated = &updated
+	}
+	return engine, nil
+}
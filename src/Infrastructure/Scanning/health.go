@@ -0,0 +1,156 @@
+package scanning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shbucket/src/Infrastructure/Config"
+)
+
+// Kinds of scanner problems, each alerts on its own
+const (
+	ProblemUnreachable      = "unreachable"       // the scanner didn't answer a health check
+	ProblemStaleDefinitions = "stale_definitions" // the virus definitions are older than SCAN_DEFINITIONS_MAX_AGE
+	ProblemFailures         = "failures"          // too many of the last hour's scans failed
+	ProblemQueue            = "queue"             // too many uploads are waiting on the scanner
+)
+
+// Engine is what a scanner reports about itself
+type Engine struct {
+	Version            string     // e.g. "ClamAV 1.3.1"
+	Definitions        string     // version of the virus definitions, e.g. "27421"
+	DefinitionsUpdated *time.Time // when the definitions were published, nil when unknown
+}
+
+// versioned is a scanner that can report its engine. Scanners that can't, like the webhook, are
+// only watched through their queue and failures.
+type versioned interface {
+	Version(ctx context.Context) (Engine, error)
+}
+
+// Problem is something keeping the scanner from protecting uploads
+type Problem struct {
+	Kind    string
+	Message string
+}
+
+// Health is the scanner's state as this server sees it
+type Health struct {
+	Backend        string
+	Engine         *Engine // nil when the scanner can't report it or didn't answer
+	EngineError    error   // why the scanner didn't answer
+	QueueDepth     int64   // uploads being scanned right now
+	RecentScans    int64   // scans finished in the last hour
+	RecentFailures int64   // scans of the last hour the scanner couldn't complete
+}
+
+// FailureRate is the share of the last hour's scans that failed, 0 without scans
+func (h Health) FailureRate() float64 {
+	if h.RecentScans == 0 {
+		return 0
+	}
+	return float64(h.RecentFailures) / float64(h.RecentScans)
+}
+
+// DefinitionAge is how old the virus definitions are, false when the scanner doesn't tell
+func (h Health) DefinitionAge(now time.Time) (time.Duration, bool) {
+	if h.Engine == nil || h.Engine.DefinitionsUpdated == nil {
+		return 0, false
+	}
+	return now.Sub(*h.Engine.DefinitionsUpdated), true
+}
+
+// Problems lists what exceeds the thresholds in settings
+func (h Health) Problems(settings *config.Settings, now time.Time) []Problem {
+	problems := []Problem{}
+	if h.EngineError != nil {
+		problems = append(problems, Problem{ProblemUnreachable, fmt.Sprintf("%s is not answering: %v", h.Backend, h.EngineError)})
+	}
+	if age, ok := h.DefinitionAge(now); ok && settings.ScanDefinitionsMaxAge > 0 && age > time.Duration(settings.ScanDefinitionsMaxAge)*time.Hour {
+		problems = append(problems, Problem{ProblemStaleDefinitions, fmt.Sprintf("virus definitions %s were last updated %.0f hours ago", h.Engine.Definitions, age.Hours())})
+	} else if h.Engine != nil && h.Engine.Definitions == "" {
+		problems = append(problems, Problem{ProblemStaleDefinitions, fmt.Sprintf("%s has no virus definitions loaded", h.Backend)})
+	}
+	if settings.ScanFailureRateAlert > 0 && h.RecentFailures > 0 && h.FailureRate()*100 >= float64(settings.ScanFailureRateAlert) {
+		problems = append(problems, Problem{ProblemFailures, fmt.Sprintf("%d of %d scans failed in the last hour", h.RecentFailures, h.RecentScans)})
+	}
+	if settings.ScanQueueAlert > 0 && h.QueueDepth >= int64(settings.ScanQueueAlert) {
+		problems = append(problems, Problem{ProblemQueue, fmt.Sprintf("%d uploads are waiting on the scanner", h.QueueDepth)})
+	}
+	return problems
+}
+
+// CheckHealth asks the scanner for its engine and adds the scans of this server
+func CheckHealth(ctx context.Context, scanner Scanner) Health {
+	health := Health{Backend: scanner.Name(), QueueDepth: activity.inFlight.Load()}
+	health.RecentScans, health.RecentFailures = activity.recent(time.Now())
+	if v, ok := scanner.(versioned); ok {
+		engine, err := v.Version(ctx)
+		if err != nil {
+			health.EngineError = err
+		} else {
+			health.Engine = &engine
+		}
+	}
+	return health
+}
+
+// activity counts the scans of this server
+var activity = &scanActivity{}
+
+// scanActivity keeps the scans in flight and the outcomes of the last hour by minute
+type scanActivity struct {
+	inFlight atomic.Int64
+
+	mu      sync.Mutex
+	minutes [60]scanMinute
+}
+
+type scanMinute struct {
+	minute   int64 // minutes since the epoch the counts are for
+	scans    int64
+	failures int64
+}
+
+func (a *scanActivity) start() {
+	a.inFlight.Add(1)
+}
+
+// finish records a scan's outcome. Scans stopped because their upload didn't complete say nothing
+// about the scanner and aren't counted.
+func (a *scanActivity) finish(now time.Time, err error) {
+	a.inFlight.Add(-1)
+	if errors.Is(err, errIncomplete) {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	minute := now.Unix() / 60
+	slot := &a.minutes[minute%int64(len(a.minutes))]
+	if slot.minute != minute {
+		*slot = scanMinute{minute: minute}
+	}
+	slot.scans++
+	if err != nil {
+		slot.failures++
+	}
+}
+
+// recent returns the scans and failures of the last hour
+func (a *scanActivity) recent(now time.Time) (scans, failures int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	minute := now.Unix() / 60
+	for _, slot := range a.minutes {
+		if minute-slot.minute < int64(len(a.minutes)) {
+			scans += slot.scans
+			failures += slot.failures
+		}
+	}
+	return scans, failures
+}
@@ -0,0 +1,98 @@
+package scanning
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Config"
+)
+
+func TestParseClamavVersion(t *testing.T) {
+	engine, err := parseClamavVersion("ClamAV 1.3.1/27421/Thu Oct 15 08:26:29 2026")
+	if err != nil {
+		t.Fatalf("parseClamavVersion() = %v", err)
+	}
+	updated := time.Date(2026, time.October, 15, 8, 26, 29, 0, time.UTC)
+	if engine.Version != "ClamAV 1.3.1" || engine.Definitions != "27421" || engine.DefinitionsUpdated == nil || !engine.DefinitionsUpdated.Equal(updated) {
+		t.Errorf("parseClamavVersion() = %+v, want ClamAV 1.3.1 with definitions 27421 of %s", engine, updated)
+	}
+
+	// Without definitions loaded clamd only names its engine
+	if engine, err := parseClamavVersion("ClamAV 1.3.1"); err != nil || engine.Definitions != "" || engine.DefinitionsUpdated != nil {
+		t.Errorf("parseClamavVersion() without definitions = %+v, %v", engine, err)
+	}
+	if _, err := parseClamavVersion("UNKNOWN COMMAND"); err == nil {
+		t.Errorf("parseClamavVersion() accepted an error reply")
+	}
+}
+
+// TestCheckHealth asks a fake clamd for its version and checks the problems reported for it
+func TestCheckHealth(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if command, _ := bufio.NewReader(conn).ReadString(0); command == "zVERSION\x00" {
+				conn.Write([]byte("ClamAV 1.3.1/27421/Thu Oct 15 08:26:29 2026\x00"))
+			}
+			conn.Close()
+		}
+	}()
+
+	settings := &config.Settings{ScanDefinitionsMaxAge: 48}
+	health := CheckHealth(context.Background(), &clamavScanner{address: listener.Addr().String()})
+	if health.EngineError != nil || health.Engine == nil || health.Engine.Definitions != "27421" {
+		t.Fatalf("CheckHealth() = %+v, want the fake clamd's engine", health)
+	}
+	if problems := health.Problems(settings, time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC)); len(problems) != 0 {
+		t.Errorf("problems with day old definitions = %v, want none", problems)
+	}
+	if problems := health.Problems(settings, time.Date(2026, time.October, 18, 8, 0, 0, 0, time.UTC)); len(problems) != 1 || problems[0].Kind != ProblemStaleDefinitions {
+		t.Errorf("problems with three day old definitions = %v, want stale definitions", problems)
+	}
+
+	listener.Close()
+	health = CheckHealth(context.Background(), &clamavScanner{address: listener.Addr().String()})
+	if problems := health.Problems(settings, time.Now()); len(problems) != 1 || problems[0].Kind != ProblemUnreachable {
+		t.Errorf("problems with clamd down = %v, want unreachable", problems)
+	}
+}
+
+func TestScanActivity(t *testing.T) {
+	var a scanActivity
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		a.start()
+	}
+	a.finish(now.Add(-2*time.Hour), nil)
+	a.finish(now.Add(-time.Minute), errors.New("clamd: timeout"))
+	a.start()
+	a.finish(now, errIncomplete)
+	if depth := a.inFlight.Load(); depth != 1 {
+		t.Errorf("in flight = %d, want 1", depth)
+	}
+	// The scan of two hours ago is out of the window and the incomplete upload isn't counted
+	if scans, failures := a.recent(now); scans != 1 || failures != 1 {
+		t.Errorf("recent() = %d scans, %d failures, want 1 and 1", scans, failures)
+	}
+
+	health := Health{RecentScans: 10, RecentFailures: 1, QueueDepth: 5}
+	problems := health.Problems(&config.Settings{ScanFailureRateAlert: 10, ScanQueueAlert: 5}, now)
+	if len(problems) != 2 || problems[0].Kind != ProblemFailures || problems[1].Kind != ProblemQueue {
+		t.Errorf("Problems() = %v, want failures and queue", problems)
+	}
+	if problems := health.Problems(&config.Settings{}, now); len(problems) != 0 {
+		t.Errorf("Problems() with checks disabled = %v, want none", problems)
+	}
+}
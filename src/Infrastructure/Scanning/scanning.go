@@ -0,0 +1,166 @@
+package scanning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+)
+
+// Scan statuses recorded with a file
+const (
+	StatusClean    = "clean"
+	StatusInfected = "infected"
+	StatusError    = "error" // the scanner couldn't check the content and SCAN_ON_ERROR let it through
+)
+
+// What happens to infected uploads
+const (
+	ActionReject     = "reject"
+	ActionQuarantine = "quarantine"
+)
+
+// What happens to uploads the scanner couldn't check
+const (
+	OnErrorReject = "reject"
+	OnErrorAllow  = "allow"
+)
+
+// ErrInfected is returned for infected uploads that are rejected
+var ErrInfected = apierror.New(apierror.CodeFileInfected, "upload rejected: malware detected")
+
+// errIncomplete stops a scan whose content wasn't stored in full
+var errIncomplete = errors.New("upload did not complete")
+
+// Result is a scanner's verdict on some content
+type Result struct {
+	Infected  bool
+	Signature string // name of what was found in infected content
+}
+
+// Scanner checks content for malware
+type Scanner interface {
+	// Name identifies the scanner in logs
+	Name() string
+	Scan(ctx context.Context, name string, content io.Reader) (Result, error)
+}
+
+// NewScanner builds the scanner configured in settings, nil when uploads aren't scanned
+func NewScanner(settings *config.Settings) (Scanner, error) {
+	switch settings.ScanBackend {
+	case "":
+		return nil, nil
+	case "clamav":
+		if settings.ClamAVAddress == "" {
+			return nil, fmt.Errorf("clamav scanning requires CLAMAV_ADDRESS")
+		}
+		return &clamavScanner{address: settings.ClamAVAddress}, nil
+	case "webhook":
+		if settings.ScanWebhookURL == "" {
+			return nil, fmt.Errorf("webhook scanning requires SCAN_WEBHOOK_URL")
+		}
+		return &webhookScanner{url: settings.ScanWebhookURL, secret: settings.ScanWebhookSecret}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scan backend: %s", settings.ScanBackend)
+	}
+}
+
+// Verdict is what is recorded about a scanned upload
+type Verdict struct {
+	Status    string
+	Signature string
+	ScannedAt *time.Time
+}
+
+// Scan checks an upload while it is being stored, so content is read once and the verdict is
+// ready as soon as the content is stored
+type Scan struct {
+	scanner Scanner
+	name    string
+	writer  *io.PipeWriter
+	done    chan struct{}
+	result  Result
+	err     error
+}
+
+// Begin starts scanning the content of the upload called name, returning the reader the content
+// must be stored from. With a nil scanner content is returned unchanged and the scan is nil.
+func Begin(ctx context.Context, scanner Scanner, name string, content io.Reader) (io.Reader, *Scan) {
+	if scanner == nil {
+		return content, nil
+	}
+
+	reader, writer := io.Pipe()
+	s := &Scan{scanner: scanner, name: name, writer: writer, done: make(chan struct{})}
+	activity.start()
+	go func() {
+		defer close(s.done)
+		scanCtx, cancel := context.WithTimeout(ctx, time.Duration(config.GetSettings().ScanTimeout)*time.Second)
+		defer cancel()
+		s.result, s.err = scanner.Scan(scanCtx, name, reader)
+		activity.finish(time.Now(), s.err)
+		// A scanner that stopped reading early mustn't hold up storing the rest
+		io.Copy(io.Discard, reader)
+	}()
+	return &teeReader{content: content, writer: writer}, s
+}
+
+// Close stops a scan whose content won't be read in full, it is safe to call after Wait
+func (s *Scan) Close() {
+	if s == nil {
+		return
+	}
+	s.writer.CloseWithError(errIncomplete)
+}
+
+// Wait returns the verdict once the content has been stored. The error is set when the upload
+// must be rejected: it is infected and SCAN_ACTION is reject, or it couldn't be scanned and
+// SCAN_ON_ERROR is reject. A nil scan returns an empty verdict.
+func (s *Scan) Wait() (Verdict, error) {
+	if s == nil {
+		return Verdict{}, nil
+	}
+	s.Close()
+	<-s.done
+
+	settings := config.GetSettings()
+	now := time.Now()
+	if s.err != nil {
+		log.Printf("Warning: %s failed to scan %s: %v", s.scanner.Name(), s.name, s.err)
+		if settings.ScanOnError != OnErrorAllow {
+			return Verdict{}, apierror.New(apierror.CodeUnavailable, "upload could not be scanned for malware")
+		}
+		return Verdict{Status: StatusError, ScannedAt: &now}, nil
+	}
+	if s.result.Infected {
+		log.Printf("Warning: %s found %s in %s", s.scanner.Name(), s.result.Signature, s.name)
+		if settings.ScanAction != ActionQuarantine {
+			return Verdict{}, fmt.Errorf("%w (%s)", ErrInfected, s.result.Signature)
+		}
+		return Verdict{Status: StatusInfected, Signature: s.result.Signature, ScannedAt: &now}, nil
+	}
+	return Verdict{Status: StatusClean, ScannedAt: &now}, nil
+}
+
+// teeReader passes content through to the scan as it is read, and ends the scan's input at its end
+type teeReader struct {
+	content io.Reader
+	writer  *io.PipeWriter
+}
+
+func (r *teeReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if n > 0 {
+		// A failed write only means the scan is over, storing carries on
+		r.writer.Write(p[:n])
+	}
+	if err == io.EOF {
+		r.writer.Close()
+	}
+	return n, err
+}
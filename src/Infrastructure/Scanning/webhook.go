@@ -0,0 +1,51 @@
+package scanning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// webhookScanner posts content to an HTTP scanning service. The service answers with JSON such
+// as {"infected": true, "signature": "Eicar-Signature"}.
+type webhookScanner struct {
+	url    string
+	secret string // sent as a bearer token when set
+}
+
+func (s *webhookScanner) Name() string {
+	return "scan webhook"
+}
+
+func (s *webhookScanner) Scan(ctx context.Context, name string, content io.Reader) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, content)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-File-Name", name)
+	if s.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+s.secret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Result{}, fmt.Errorf("scanner returned status: %d", resp.StatusCode)
+	}
+
+	var verdict struct {
+		Infected  bool   `json:"infected"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&verdict); err != nil {
+		return Result{}, fmt.Errorf("invalid scanner response: %w", err)
+	}
+	return Result{Infected: verdict.Infected, Signature: verdict.Signature}, nil
+}
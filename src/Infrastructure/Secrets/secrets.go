@@ -0,0 +1,229 @@
+// Package secrets seals secrets stored in the database, such as node auth keys, with envelope
+// encryption. Every value is encrypted with a data key of its own, which is stored next to it
+// encrypted with the master key of ENCRYPTION_MASTER_KEY or ENCRYPTION_MASTER_KEY_FILE. Without a
+// master key values are stored as they are. Values stored before they were sealed, or while no
+// master key was set, read as they are until they are written again.
+//
+// Layout: "enc:v1:" followed by base64 of master key ID (4) | sealed data key (60) | nonce (12) |
+// ciphertext, where the master key ID is the start of the key's SHA-256 and both seals are AES-256-GCM.
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"shbucket/src/Infrastructure/Config"
+)
+
+// Prefix starts every sealed value
+const Prefix = "enc:v1:"
+
+const (
+	keySize       = 32
+	keyIDSize     = 4
+	nonceSize     = 12
+	sealedKeySize = nonceSize + keySize + 16
+)
+
+var (
+	// ErrNoMasterKey is returned for a sealed value when no master key is set
+	ErrNoMasterKey = errors.New("secret is encrypted but no master key is set, set ENCRYPTION_MASTER_KEY or ENCRYPTION_MASTER_KEY_FILE")
+	// ErrUnknownMasterKey is returned for a value sealed with a master key that is neither the
+	// current one nor one of SECRETS_PREVIOUS_MASTER_KEYS
+	ErrUnknownMasterKey = errors.New("secret was encrypted with an unknown master key, list it in SECRETS_PREVIOUS_MASTER_KEYS")
+	// ErrCorrupted is returned for a sealed value that fails authentication
+	ErrCorrupted = errors.New("encrypted secret is corrupted")
+)
+
+// masterKey is a key that seals data keys, with its ID
+type masterKey struct {
+	id  []byte
+	key []byte
+}
+
+var (
+	loadOnce sync.Once
+	// current seals new values, nil without a master key
+	current *masterKey
+	// known open values, the current key and the previous ones
+	known   []masterKey
+	loadErr error
+)
+
+// load reads the master keys from the settings the first time they are needed
+func load() error {
+	loadOnce.Do(func() {
+		settings := config.GetSettings()
+		key, err := readMasterKey(settings.EncryptionMasterKey, settings.EncryptionMasterKeyFile)
+		if err != nil {
+			loadErr = err
+			return
+		}
+		if key != nil {
+			current = newMasterKey(key)
+			known = append(known, *current)
+		}
+		for _, encoded := range settings.SecretsPreviousMasterKeys {
+			previous, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(previous) != keySize {
+				loadErr = fmt.Errorf("SECRETS_PREVIOUS_MASTER_KEYS must hold %d byte keys encoded as base64", keySize)
+				return
+			}
+			known = append(known, *newMasterKey(previous))
+		}
+	})
+	return loadErr
+}
+
+// readMasterKey returns the master key given directly or in a file, or nil when there is none
+func readMasterKey(encoded, path string) ([]byte, error) {
+	if encoded == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		if len(data) == keySize {
+			return data, nil
+		}
+		encoded = string(bytes.TrimSpace(data))
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("the master key must be %d bytes encoded as base64", keySize)
+	}
+	return key, nil
+}
+
+func newMasterKey(key []byte) *masterKey {
+	sum := sha256.Sum256(key)
+	return &masterKey{id: sum[:keyIDSize], key: key}
+}
+
+// Enabled reports whether a master key is set, so values are sealed when stored
+func Enabled() bool {
+	return load() == nil && current != nil
+}
+
+// Check reports a master key that is set but can't be used
+func Check() error {
+	return load()
+}
+
+// IsSealed reports whether a stored value is sealed
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Seal encrypts a value for storage. Empty values, and every value when no master key is set, are
+// stored as they are.
+func Seal(value string) (string, error) {
+	if err := load(); err != nil {
+		return "", err
+	}
+	if value == "" || current == nil {
+		return value, nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	sealedKey, err := seal(current.key, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	envelope := make([]byte, 0, keyIDSize+len(sealedKey)+len(ciphertext))
+	envelope = append(envelope, current.id...)
+	envelope = append(envelope, sealedKey...)
+	envelope = append(envelope, ciphertext...)
+	return Prefix + base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// Open decrypts a stored value. Values that aren't sealed are returned as they are.
+func Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	if err := load(); err != nil {
+		return "", err
+	}
+	if len(known) == 0 {
+		return "", ErrNoMasterKey
+	}
+
+	envelope, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil || len(envelope) < keyIDSize+sealedKeySize+nonceSize {
+		return "", ErrCorrupted
+	}
+	id, sealedKey, ciphertext := envelope[:keyIDSize], envelope[keyIDSize:keyIDSize+sealedKeySize], envelope[keyIDSize+sealedKeySize:]
+
+	for _, master := range known {
+		if !bytes.Equal(master.id, id) {
+			continue
+		}
+		dataKey, err := open(master.key, sealedKey)
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := open(dataKey, ciphertext)
+		if err != nil {
+			return "", err
+		}
+		return string(plaintext), nil
+	}
+	return "", ErrUnknownMasterKey
+}
+
+// seal encrypts plaintext under key, returning nonce | ciphertext
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts what seal encrypted
+func open(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < nonceSize {
+		return nil, ErrCorrupted
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/robfig/cron/v3"
+	"shbucket/src/Application/Backup"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Mediator"
+)
+
+// BackupScheduler triggers backup runs on the cron schedule configured in BACKUP_SCHEDULE
+type BackupScheduler struct {
+	mediator *mediator.Mediator
+	settings *config.Settings
+	cron     *cron.Cron
+}
+
+// NewBackupScheduler creates a new instance of BackupScheduler
+func NewBackupScheduler(mediator *mediator.Mediator) *BackupScheduler {
+	return &BackupScheduler{
+		mediator: mediator,
+		settings: config.GetSettings(),
+	}
+}
+
+// Start schedules backups, it does nothing when no schedule is configured
+func (s *BackupScheduler) Start() error {
+	if s.settings.BackupSchedule == "" {
+		return nil
+	}
+
+	s.cron = cron.New()
+	_, err := s.cron.AddFunc(s.settings.BackupSchedule, func() {
+		response, err := s.mediator.Send(context.Background(), &backup.RunBackupCommand{Trigger: "schedule"})
+		if err != nil {
+			log.Printf("Scheduled backup failed to start: %v", err)
+			return
+		}
+		log.Printf("Scheduled backup started: %s", response.(*backup.RunBackupResponse).Run.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("invalid BACKUP_SCHEDULE %q: %w", s.settings.BackupSchedule, err)
+	}
+
+	s.cron.Start()
+	log.Printf("Backup scheduler started with schedule: %s", s.settings.BackupSchedule)
+	return nil
+}
+
+// Validate checks the configured schedule without scheduling anything
+func (s *BackupScheduler) Validate() error {
+	if s.settings.BackupSchedule == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(s.settings.BackupSchedule); err != nil {
+		return fmt.Errorf("invalid BACKUP_SCHEDULE %q: %w", s.settings.BackupSchedule, err)
+	}
+	return nil
+}
+
+// Stop stops scheduling new backups
+func (s *BackupScheduler) Stop() {
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+}
@@ -0,0 +1,523 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/pkg/client"
+	"shbucket/src/Application/BucketSync"
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+const (
+	// bucketSyncCheckInterval is how often the worker looks for bucket syncs that are due
+	bucketSyncCheckInterval = 30 * time.Second
+	// bucketSyncPageSize is how many changes are read from the remote per request
+	bucketSyncPageSize = 200
+)
+
+// sha256Pattern matches checksums the copied content can be verified against
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// remoteOnlyMetadata are custom metadata keys describing where the remote stored a file, which
+// aren't copied with it
+var remoteOnlyMetadata = []string{"storage_node_id", "storage_node_url"}
+
+// errSyncChanged stops a pass whose sync was reconfigured or reset while it ran
+var errSyncChanged = errors.New("sync changed while running")
+
+// errSourceFileGone is returned for a file of a local source bucket deleted since its change was read
+var errSourceFileGone = errors.New("source file not found")
+
+// BucketSyncWorker keeps buckets mirrored from buckets of other SHBucket installations or of this
+// one. Each due sync reads the source bucket's changes feed from where its last pass stopped and
+// applies the changes, copying only files whose content changed. Files a remote source no longer
+// has are removed; a local source only adds and updates files, so promoting assets to a bucket
+// never takes any away.
+type BucketSyncWorker struct {
+	dbContext *persistence.AppDbContext
+	mediator  *mediator.Mediator
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewBucketSyncWorker creates a new instance of BucketSyncWorker
+func NewBucketSyncWorker(dbContext *persistence.AppDbContext, mediator *mediator.Mediator) *BucketSyncWorker {
+	return &BucketSyncWorker{
+		dbContext: dbContext,
+		mediator:  mediator,
+	}
+}
+
+// Start runs the due syncs now and then on every check
+func (w *BucketSyncWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(bucketSyncCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			w.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Bucket sync worker started")
+}
+
+// Stop cancels the running pass and waits for the worker to exit. The pass resumes from its last
+// saved page on the next leader.
+func (w *BucketSyncWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+func (w *BucketSyncWorker) run(ctx context.Context) {
+	due, err := dueSyncs(w.dbContext.GetDB().WithContext(ctx), time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Bucket sync: failed to list due syncs: %v", err)
+		}
+		return
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		w.sync(ctx, &due[i])
+	}
+}
+
+// dueSyncs lists the syncs due at now, the longest due first. On demand syncs are due when a run
+// was asked for after their last pass.
+func dueSyncs(db *gorm.DB, now time.Time) ([]entities.BucketSync, error) {
+	var due []entities.BucketSync
+	err := db.Where(`"NextRunAt" <= ? AND ("OnDemand" = ? OR "LastRunAt" IS NULL OR "LastRunAt" < "NextRunAt")`, now, false).
+		Order(`"NextRunAt"`).Find(&due).Error
+	return due, err
+}
+
+// syncPass counts what a pass did
+type syncPass struct {
+	copied  int64
+	deleted int64
+	skipped int64
+	bytes   int64
+}
+
+// sync runs one pass of a bucket's sync and schedules the next
+func (w *BucketSyncWorker) sync(ctx context.Context, sync *entities.BucketSync) {
+	db := w.dbContext.GetDB()
+	startedAt := time.Now()
+	db.Model(&entities.BucketSync{Id: sync.Id}).
+		Updates(map[string]interface{}{"Status": bucketsync.StatusSyncing, "LastRunAt": startedAt})
+
+	err := w.pull(ctx, sync)
+	if ctx.Err() != nil {
+		// Stopping, the sync stays due
+		return
+	}
+
+	updates := map[string]interface{}{"Status": bucketsync.StatusSynced, "LastError": ""}
+	if err != nil && !errors.Is(err, errSyncChanged) {
+		log.Printf("Bucket sync: bucket %s from %s: %v", sync.BucketId, describeSource(sync), err)
+		updates = map[string]interface{}{"Status": bucketsync.StatusFailed, "LastError": err.Error()}
+	} else if err == nil {
+		updates["LastSyncedAt"] = time.Now()
+	}
+	db.Model(&entities.BucketSync{Id: sync.Id}).Updates(updates)
+
+	// A run requested during the pass keeps the sync due
+	if !sync.OnDemand {
+		db.Model(&entities.BucketSync{}).Where(`"Id" = ? AND "NextRunAt" = ?`, sync.Id, sync.NextRunAt).
+			Update("NextRunAt", time.Now().Add(time.Duration(sync.IntervalSeconds)*time.Second))
+	}
+}
+
+// syncSource is the bucket a sync reads: a bucket of another installation, read through its API,
+// or a bucket of this one, read directly
+type syncSource interface {
+	Changes(ctx context.Context, cursor string, limit int) (*client.ChangesPage, error)
+	DownloadTo(ctx context.Context, fileID uuid.UUID, w io.Writer) (int64, error)
+}
+
+type remoteSource struct {
+	remote   *client.Client
+	bucketID uuid.UUID
+}
+
+func (s *remoteSource) Changes(ctx context.Context, cursor string, limit int) (*client.ChangesPage, error) {
+	return s.remote.Changes(ctx, s.bucketID, cursor, limit)
+}
+
+func (s *remoteSource) DownloadTo(ctx context.Context, fileID uuid.UUID, w io.Writer) (int64, error) {
+	return s.remote.DownloadTo(ctx, s.bucketID, fileID, w)
+}
+
+type localSource struct {
+	dbContext *persistence.AppDbContext
+	mediator  *mediator.Mediator
+	bucketID  uuid.UUID
+}
+
+func (s *localSource) Changes(ctx context.Context, cursor string, limit int) (*client.ChangesPage, error) {
+	response, err := s.mediator.Send(ctx, &bucketsync.GetBucketChangesCommand{BucketID: s.bucketID, Cursor: cursor, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	changes := response.(*bucketsync.GetBucketChangesResponse)
+	page := &client.ChangesPage{
+		Changes:         make([]client.FileChange, len(changes.Changes)),
+		Listing:         changes.Listing,
+		ListedAfter:     changes.ListedAfter,
+		ListingComplete: changes.ListingComplete,
+		Cursor:          changes.Cursor,
+		HasMore:         changes.HasMore,
+	}
+	for i, change := range changes.Changes {
+		page.Changes[i] = client.FileChange{
+			Name:              change.Name,
+			Deleted:           change.Deleted,
+			FileID:            change.FileID,
+			Version:           change.Version,
+			Size:              change.Size,
+			Checksum:          change.Checksum,
+			MimeType:          change.MimeType,
+			CustomMetadata:    change.CustomMetadata,
+			CustomerEncrypted: change.CustomerEncrypted,
+			UpdatedAt:         change.UpdatedAt,
+		}
+	}
+	return page, nil
+}
+
+func (s *localSource) DownloadTo(ctx context.Context, fileID uuid.UUID, w io.Writer) (int64, error) {
+	var files []entities.File
+	if err := s.dbContext.GetDB().WithContext(ctx).Where(&entities.File{Id: fileID, BucketId: s.bucketID}).
+		Limit(1).Find(&files).Error; err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, errSourceFileGone
+	}
+	content, err := storage.OpenFile(ctx, s.dbContext, &files[0])
+	if err != nil {
+		return 0, err
+	}
+	defer content.Close()
+	return io.Copy(w, content)
+}
+
+// source opens the bucket a sync reads
+func (w *BucketSyncWorker) source(sync *entities.BucketSync) (syncSource, error) {
+	if bucketsync.Local(sync) {
+		source, err := w.dbContext.Buckets.Where(&entities.Bucket{Id: *sync.SourceBucketId}).FirstOrDefault()
+		if err != nil || source == nil {
+			return nil, fmt.Errorf("source bucket not found")
+		}
+		return &localSource{dbContext: w.dbContext, mediator: w.mediator, bucketID: source.Id}, nil
+	}
+	remote, err := client.New(sync.RemoteURL, client.WithAPIKey(sync.APIKey))
+	if err != nil {
+		return nil, err
+	}
+	return &remoteSource{remote: remote, bucketID: sync.RemoteBucketId}, nil
+}
+
+// describeSource names a sync's source bucket for logs
+func describeSource(sync *entities.BucketSync) string {
+	if bucketsync.Local(sync) {
+		return "bucket " + sync.SourceBucketId.String()
+	}
+	return sync.RemoteURL
+}
+
+// pull reads the source's changes from the sync's cursor until it has caught up, saving the cursor
+// after every page so an interrupted pass resumes where it stopped
+func (w *BucketSyncWorker) pull(ctx context.Context, sync *entities.BucketSync) error {
+	bucket, err := w.dbContext.Buckets.Where(&entities.Bucket{Id: sync.BucketId}).FirstOrDefault()
+	if err != nil || bucket == nil {
+		return fmt.Errorf("bucket not found")
+	}
+	source, err := w.source(sync)
+	if err != nil {
+		return err
+	}
+
+	cursor := sync.Cursor
+	for {
+		page, err := source.Changes(ctx, cursor, bucketSyncPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read source changes: %w", err)
+		}
+
+		var pass syncPass
+		for i := range page.Changes {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := w.apply(ctx, source, sync, bucket, &page.Changes[i], &pass); err != nil {
+				return err
+			}
+		}
+		if page.Listing && !bucketsync.Local(sync) {
+			if err := w.removeUnlisted(ctx, bucket, page, &pass); err != nil {
+				return err
+			}
+		}
+
+		if err := saveSyncProgress(w.dbContext.GetDB(), sync, cursor, page.Cursor, &pass); err != nil {
+			return err
+		}
+		cursor = page.Cursor
+
+		if !page.HasMore {
+			return nil
+		}
+	}
+}
+
+// apply makes the local bucket serve what the source serves for a name the sync's filters select
+func (w *BucketSyncWorker) apply(ctx context.Context, source syncSource, sync *entities.BucketSync, bucket *entities.Bucket, change *client.FileChange, pass *syncPass) error {
+	if !bucketsync.Matches(sync, change.Name, change.CustomMetadata) {
+		return nil
+	}
+	if change.Deleted || change.FileID == nil {
+		if bucketsync.Local(sync) {
+			return nil
+		}
+		return w.removeName(ctx, bucket, change.Name, pass)
+	}
+	if change.CustomerEncrypted {
+		// Only readable with a key the sync doesn't have
+		pass.skipped++
+		return nil
+	}
+
+	local, err := bucketsync.CurrentFile(ctx, w.dbContext, bucket.Id, change.Name)
+	if err != nil {
+		return err
+	}
+	if local != nil && unchanged(local, change) {
+		pass.skipped++
+		return nil
+	}
+
+	copied, err := w.copy(ctx, source, sync, bucket, change)
+	if err != nil {
+		if client.IsNotFound(err) || errors.Is(err, errSourceFileGone) {
+			// Gone since the page was read, a later change removes it
+			pass.skipped++
+			return nil
+		}
+		if errors.Is(err, file.ErrFileTooLarge) {
+			log.Printf("Bucket sync: skipped %s, it exceeds bucket %s's maximum file size", change.Name, bucket.Name)
+			pass.skipped++
+			return nil
+		}
+		return fmt.Errorf("failed to copy %s: %w", change.Name, err)
+	}
+	pass.copied++
+	pass.bytes += change.Size
+
+	// Buckets without versioning keep one file per name
+	if bucket.Settings.Versioning {
+		return nil
+	}
+	var older []entities.File
+	if err := w.dbContext.GetDB().WithContext(ctx).Select("Id").
+		Where(&entities.File{BucketId: bucket.Id, Name: change.Name}).Where(`"Id" <> ?`, copied).
+		Find(&older).Error; err != nil {
+		return fmt.Errorf("failed to list replaced files: %w", err)
+	}
+	for _, stored := range older {
+		if err := w.deleteFile(ctx, bucket, stored.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveSyncProgress moves a sync's cursor from from to to and adds what the pass did to its counts.
+// The cursor only moves while the sync still follows the same source, with the same filters, from
+// the same place, errSyncChanged otherwise.
+func saveSyncProgress(db *gorm.DB, sync *entities.BucketSync, from, to string, pass *syncPass) error {
+	result := db.Model(&entities.BucketSync{}).
+		Where(`"Id" = ? AND "Cursor" = ? AND "RemoteURL" = ? AND "RemoteBucketId" = ? AND "SourceBucketId" IS NOT DISTINCT FROM ? AND "Prefix" = ? AND "Tag" = ?`,
+			sync.Id, from, sync.RemoteURL, sync.RemoteBucketId, sync.SourceBucketId, sync.Prefix, sync.Tag).
+		Updates(map[string]interface{}{
+			"Cursor":       to,
+			"FilesCopied":  gorm.Expr(`"FilesCopied" + ?`, pass.copied),
+			"FilesDeleted": gorm.Expr(`"FilesDeleted" + ?`, pass.deleted),
+			"FilesSkipped": gorm.Expr(`"FilesSkipped" + ?`, pass.skipped),
+			"BytesCopied":  gorm.Expr(`"BytesCopied" + ?`, pass.bytes),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save sync progress: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errSyncChanged
+	}
+	return nil
+}
+
+// unchanged reports whether the local file already has the remote file's content
+func unchanged(local *entities.File, change *client.FileChange) bool {
+	var metadata map[string]interface{}
+	json.Unmarshal(local.Metadata.CustomMetadata, &metadata)
+	if source, _ := metadata[bucketsync.SourceFileIDKey].(string); source == change.FileID.String() {
+		return true
+	}
+	return sha256Pattern.MatchString(change.Checksum) && local.Checksum == change.Checksum && local.Size == change.Size
+}
+
+// copy downloads a source file to a spool file, checks it arrived whole and stores it in the bucket
+func (w *BucketSyncWorker) copy(ctx context.Context, source syncSource, sync *entities.BucketSync, bucket *entities.Bucket, change *client.FileChange) (uuid.UUID, error) {
+	spool, err := os.CreateTemp("", "shbucket-sync-*")
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hash := sha256.New()
+	size, err := source.DownloadTo(ctx, *change.FileID, io.MultiWriter(spool, hash))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if size != change.Size {
+		return uuid.Nil, fmt.Errorf("downloaded %d bytes, expected %d", size, change.Size)
+	}
+	if sha256Pattern.MatchString(change.Checksum) && hex.EncodeToString(hash.Sum(nil)) != change.Checksum {
+		return uuid.Nil, fmt.Errorf("checksum mismatch")
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return uuid.Nil, err
+	}
+
+	metadata := make(map[string]interface{}, len(change.CustomMetadata)+1)
+	for key, value := range change.CustomMetadata {
+		metadata[key] = value
+	}
+	for _, key := range remoteOnlyMetadata {
+		delete(metadata, key)
+	}
+	metadata[bucketsync.SourceFileIDKey] = change.FileID.String()
+
+	response, err := w.mediator.Send(ctx, &file.DistributedUploadCommand{
+		BucketID:    bucket.Id,
+		FileReader:  spool,
+		FileSize:    size,
+		FileName:    change.Name,
+		ContentType: change.MimeType,
+		Metadata:    metadata,
+		UploadedBy:  sync.CreatedBy,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return response.(*file.DistributedUploadResponse).File.ID, nil
+}
+
+// removeUnlisted removes the local names a listing page covers but doesn't list
+func (w *BucketSyncWorker) removeUnlisted(ctx context.Context, bucket *entities.Bucket, page *client.ChangesPage, pass *syncPass) error {
+	listed := make(map[string]bool, len(page.Changes))
+	for _, change := range page.Changes {
+		listed[change.Name] = true
+	}
+
+	if !page.ListingComplete && len(page.Changes) == 0 {
+		return nil
+	}
+	names, err := localNames(w.dbContext.GetDB().WithContext(ctx), bucket.Id, page)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if !listed[name] {
+			if err := w.removeName(ctx, bucket, name, pass); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// localNames lists the names of the bucket a listing page covers: those after its ListedAfter, up
+// to its last change unless the listing is complete
+func localNames(db *gorm.DB, bucketID uuid.UUID, page *client.ChangesPage) ([]string, error) {
+	name := bucketsync.ByteOrderedName(db)
+	query := db.Model(&entities.File{}).Where(`"BucketId" = ? AND `+name+` > ?`, bucketID, page.ListedAfter)
+	if !page.ListingComplete {
+		query = query.Where(name+` <= ?`, page.Changes[len(page.Changes)-1].Name)
+	}
+	var names []string
+	if err := query.Distinct("Name").Pluck("Name", &names).Error; err != nil {
+		return nil, fmt.Errorf("failed to list local files: %w", err)
+	}
+	return names, nil
+}
+
+// removeName removes every version of a name from the bucket
+func (w *BucketSyncWorker) removeName(ctx context.Context, bucket *entities.Bucket, name string, pass *syncPass) error {
+	var stored []entities.File
+	if err := w.dbContext.GetDB().WithContext(ctx).Select("Id").
+		Where(&entities.File{BucketId: bucket.Id, Name: name}).Find(&stored).Error; err != nil {
+		return fmt.Errorf("failed to list files named %s: %w", name, err)
+	}
+	for _, f := range stored {
+		if err := w.deleteFile(ctx, bucket, f.Id); err != nil {
+			return err
+		}
+	}
+	if len(stored) > 0 {
+		pass.deleted++
+	}
+	return nil
+}
+
+// deleteFile deletes as the owner through the regular delete path, like the lifecycle worker.
+// Locked files are kept, a sync doesn't fail over them.
+func (w *BucketSyncWorker) deleteFile(ctx context.Context, bucket *entities.Bucket, fileID uuid.UUID) error {
+	if _, err := w.mediator.Send(ctx, &file.DeleteFileCommand{
+		FileID:   fileID,
+		BucketID: bucket.Id,
+		UserID:   bucket.OwnerId,
+	}); err != nil {
+		if errors.Is(err, file.ErrFileLocked) {
+			log.Printf("Bucket sync: keeping locked file %s in bucket %s", fileID, bucket.Name)
+			return nil
+		}
+		return fmt.Errorf("failed to delete file %s: %w", fileID, err)
+	}
+	return nil
+}
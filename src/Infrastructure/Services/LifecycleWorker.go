@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// LifecycleWorker periodically enforces bucket lifecycle settings, removing noncurrent
+// versions that fall outside a versioned bucket's version count and retention limits
+type LifecycleWorker struct {
+	dbContext *persistence.AppDbContext
+	mediator  *mediator.Mediator
+	settings  *config.Settings
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewLifecycleWorker creates a new instance of LifecycleWorker
+func NewLifecycleWorker(dbContext *persistence.AppDbContext, mediator *mediator.Mediator) *LifecycleWorker {
+	return &LifecycleWorker{
+		dbContext: dbContext,
+		mediator:  mediator,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Start runs a lifecycle pass now and then on every interval
+func (w *LifecycleWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(time.Duration(w.settings.LifecycleWorkerInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			w.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Lifecycle worker started")
+}
+
+// Stop waits for the current pass to reach a safe point and the worker to exit
+func (w *LifecycleWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+func (w *LifecycleWorker) run(ctx context.Context) {
+	buckets, err := w.dbContext.Buckets.Where(&entities.Bucket{
+		Settings: entities.BucketSettings{Versioning: true},
+	}).ToList()
+	if err != nil {
+		log.Printf("Lifecycle: failed to list versioned buckets: %v", err)
+		return
+	}
+
+	for i := range buckets {
+		if ctx.Err() != nil {
+			return
+		}
+		w.expireVersions(ctx, &buckets[i])
+	}
+}
+
+func (w *LifecycleWorker) expireVersions(ctx context.Context, bucket *entities.Bucket) {
+	expired, err := file.ExpiredVersions(ctx, w.dbContext.GetDB(), bucket, time.Now())
+	if err != nil {
+		log.Printf("Lifecycle: bucket %s: %v", bucket.Name, err)
+		return
+	}
+
+	removed := 0
+	for _, version := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// Deleting as the owner goes through the regular delete path, so content,
+		// cached variants, comments and events are all handled the same way
+		_, err := w.mediator.Send(ctx, &file.DeleteFileCommand{
+			FileID:   version.Id,
+			BucketID: bucket.Id,
+			UserID:   bucket.OwnerId,
+		})
+		if err != nil {
+			log.Printf("Lifecycle: failed to remove version %d of %s in bucket %s: %v", version.Version, version.Name, bucket.Name, err)
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		log.Printf("Lifecycle: removed %d expired version(s) from bucket %s", removed, bucket.Name)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"shbucket/src/Application/Rebalance"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// RebalanceWorker watches how full the master and nodes are and starts a rebalancing run when
+// cold files could move off a location over the policy's band
+type RebalanceWorker struct {
+	dbContext *persistence.AppDbContext
+	mediator  *mediator.Mediator
+	settings  *config.Settings
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewRebalanceWorker creates a new instance of RebalanceWorker
+func NewRebalanceWorker(dbContext *persistence.AppDbContext, mediator *mediator.Mediator) *RebalanceWorker {
+	return &RebalanceWorker{
+		dbContext: dbContext,
+		mediator:  mediator,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Start checks for skew now and then on every interval. Nothing runs when no interval is configured.
+func (w *RebalanceWorker) Start() {
+	if w.settings.RebalanceInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(time.Duration(w.settings.RebalanceInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			w.check(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Rebalance worker started")
+}
+
+// Stop waits for the current check to finish and the worker to exit
+func (w *RebalanceWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// check queues a rebalancing run when the plan has anything to move. The run plans again itself,
+// this only keeps runs from being queued with nothing to do.
+func (w *RebalanceWorker) check(ctx context.Context) {
+	plan, err := rebalance.BuildPlan(ctx, w.dbContext, rebalance.CurrentPolicy(), time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Rebalance: %v", err)
+		}
+		return
+	}
+	if len(plan.Moves) == 0 {
+		return
+	}
+
+	if _, err := w.mediator.Send(ctx, &rebalance.StartRebalanceCommand{}); err != nil && !errors.Is(err, rebalance.ErrRebalanceRunning) {
+		log.Printf("Rebalance: failed to start a run: %v", err)
+		return
+	}
+	log.Printf("Rebalance: %d cold file(s), %d bytes, can move off full locations", len(plan.Moves), plan.Bytes)
+}
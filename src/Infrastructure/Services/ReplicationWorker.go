@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"shbucket/pkg/client"
+	"shbucket/src/Application/BucketSync"
+	"shbucket/src/Application/Replication"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+	"shbucket/src/Models"
+)
+
+const (
+	// replicationCheckInterval is how often the worker pushes the changes of replicated buckets
+	replicationCheckInterval = 15 * time.Second
+	// replicationPageSize is how many changes are read from the bucket's feed at a time
+	replicationPageSize = 200
+	// remoteLookupPageSize is how many files are listed at a time to find a name on the remote
+	remoteLookupPageSize = 100
+)
+
+// errReplicationChanged stops a pass whose replication was reconfigured or removed while it ran
+var errReplicationChanged = errors.New("replication changed while running")
+
+// ReplicationWorker pushes the files of replicated buckets to buckets of other SHBucket
+// installations. It follows each bucket's own changes feed from where its last pass stopped, so
+// files are pushed shortly after they are uploaded, and a replication that fell behind, e.g. while
+// the remote was down, catches up from where it was.
+type ReplicationWorker struct {
+	dbContext *persistence.AppDbContext
+	mediator  *mediator.Mediator
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewReplicationWorker creates a new instance of ReplicationWorker
+func NewReplicationWorker(dbContext *persistence.AppDbContext, mediator *mediator.Mediator) *ReplicationWorker {
+	return &ReplicationWorker{
+		dbContext: dbContext,
+		mediator:  mediator,
+	}
+}
+
+// Start runs a pass of every replication now and then on every check
+func (w *ReplicationWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(replicationCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			w.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Replication worker started")
+}
+
+// Stop cancels the running pass and waits for the worker to exit. The pass resumes from its last
+// saved page on the next leader.
+func (w *ReplicationWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+func (w *ReplicationWorker) run(ctx context.Context) {
+	var replications []entities.BucketReplication
+	if err := w.dbContext.GetDB().WithContext(ctx).Order(`"CreatedAt"`).Find(&replications).Error; err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Replication: failed to list replications: %v", err)
+		}
+		return
+	}
+
+	for i := range replications {
+		if ctx.Err() != nil {
+			return
+		}
+		w.replicate(ctx, &replications[i])
+	}
+}
+
+// replicationPass counts what a pass did
+type replicationPass struct {
+	pushed    int64
+	deleted   int64
+	skipped   int64
+	conflicts int64
+	bytes     int64
+}
+
+// replicate runs one pass of a bucket's replication
+func (w *ReplicationWorker) replicate(ctx context.Context, r *entities.BucketReplication) {
+	db := w.dbContext.GetDB()
+	db.Model(&entities.BucketReplication{Id: r.Id}).
+		Updates(map[string]interface{}{"Status": replication.StatusReplicating, "LastRunAt": time.Now()})
+
+	err := w.push(ctx, r)
+	if ctx.Err() != nil {
+		// Stopping, the next leader resumes from the saved cursor
+		return
+	}
+
+	updates := map[string]interface{}{"Status": replication.StatusReplicated, "LastError": ""}
+	if err != nil && !errors.Is(err, errReplicationChanged) {
+		log.Printf("Replication: bucket %s to %s: %v", r.BucketId, r.RemoteURL, err)
+		updates = map[string]interface{}{"Status": replication.StatusFailed, "LastError": err.Error()}
+	} else if err == nil {
+		updates["LastReplicatedAt"] = time.Now()
+	}
+	db.Model(&entities.BucketReplication{Id: r.Id}).Updates(updates)
+}
+
+// push reads the bucket's changes from the replication's cursor until it has caught up, saving the
+// cursor after every page so an interrupted pass resumes where it stopped
+func (w *ReplicationWorker) push(ctx context.Context, r *entities.BucketReplication) error {
+	remote, err := client.New(r.RemoteURL, client.WithAPIKey(r.APIKey))
+	if err != nil {
+		return err
+	}
+
+	cursor := r.Cursor
+	for {
+		response, err := w.mediator.Send(ctx, &bucketsync.GetBucketChangesCommand{
+			BucketID: r.BucketId,
+			Cursor:   cursor,
+			Limit:    replicationPageSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read bucket changes: %w", err)
+		}
+		page := response.(*bucketsync.GetBucketChangesResponse)
+
+		var pass replicationPass
+		for i := range page.Changes {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := w.apply(ctx, remote, r, &page.Changes[i], &pass); err != nil {
+				return err
+			}
+		}
+
+		if err := saveReplicationProgress(w.dbContext.GetDB(), r, cursor, page.Cursor, &pass); err != nil {
+			return err
+		}
+		cursor = page.Cursor
+
+		if !page.HasMore {
+			return nil
+		}
+	}
+}
+
+// saveReplicationProgress moves a replication's cursor from from to to and adds what the pass did to
+// its counts. The cursor only moves while the replication still pushes to the same remote from the
+// same place, errReplicationChanged otherwise.
+func saveReplicationProgress(db *gorm.DB, r *entities.BucketReplication, from, to string, pass *replicationPass) error {
+	result := db.Model(&entities.BucketReplication{}).
+		Where(`"Id" = ? AND "Cursor" = ? AND "RemoteURL" = ? AND "RemoteBucketId" = ?`, r.Id, from, r.RemoteURL, r.RemoteBucketId).
+		Updates(map[string]interface{}{
+			"Cursor":       to,
+			"FilesPushed":  gorm.Expr(`"FilesPushed" + ?`, pass.pushed),
+			"FilesDeleted": gorm.Expr(`"FilesDeleted" + ?`, pass.deleted),
+			"FilesSkipped": gorm.Expr(`"FilesSkipped" + ?`, pass.skipped),
+			"Conflicts":    gorm.Expr(`"Conflicts" + ?`, pass.conflicts),
+			"BytesPushed":  gorm.Expr(`"BytesPushed" + ?`, pass.bytes),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save replication progress: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errReplicationChanged
+	}
+	return nil
+}
+
+// apply makes the remote bucket serve what the local bucket serves for a name, as far as the
+// replication's conflict policy allows
+func (w *ReplicationWorker) apply(ctx context.Context, remote *client.Client, r *entities.BucketReplication, change *models.FileChangeResponse, pass *replicationPass) error {
+	if change.Deleted || change.FileID == nil {
+		if !r.ReplicateDeletes {
+			return nil
+		}
+		return w.removeName(ctx, remote, r, change.Name, pass)
+	}
+	if change.CustomerEncrypted {
+		// Only readable with a key the server never stores
+		pass.skipped++
+		return nil
+	}
+
+	pushed, err := lastPushed(w.dbContext.GetDB().WithContext(ctx), r, change.Name)
+	if err != nil {
+		return err
+	}
+	current, err := remoteCurrent(ctx, remote, r.RemoteBucketId, change.Name)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s on the remote: %w", change.Name, err)
+	}
+
+	ours := current != nil && pushed != nil && pushed.RemoteFileId == current.ID
+	if ours && pushed.LocalFileId == *change.FileID {
+		pass.skipped++
+		return nil
+	}
+	if current != nil && sha256Pattern.MatchString(change.Checksum) && current.Checksum == change.Checksum {
+		// The remote already serves this content
+		pass.skipped++
+		return recordPushed(w.dbContext.GetDB().WithContext(ctx), r, change.Name, *change.FileID, current.ID)
+	}
+	if current != nil && !ours {
+		switch r.ConflictPolicy {
+		case replication.ConflictSkip:
+			pass.conflicts++
+			return nil
+		case replication.ConflictNewer:
+			if change.UpdatedAt == nil || !change.UpdatedAt.After(current.UpdatedAt) {
+				pass.conflicts++
+				return nil
+			}
+		}
+		// Overwritten, still counted so the conflict shows
+		pass.conflicts++
+	}
+
+	file, size, err := w.upload(ctx, remote, r, change)
+	if err != nil {
+		if errors.Is(err, errSourceFileGone) {
+			// Deleted since the change was read, the feed reports the delete next
+			return nil
+		}
+		if client.IsConflict(err) {
+			// The remote bucket doesn't take new versions of an existing name
+			pass.conflicts++
+			return nil
+		}
+		return fmt.Errorf("failed to push %s: %w", change.Name, err)
+	}
+	pass.pushed++
+	pass.bytes += size
+	return recordPushed(w.dbContext.GetDB().WithContext(ctx), r, change.Name, *change.FileID, file.ID)
+}
+
+// upload stores a local file's content under its name in the remote bucket, letting the remote
+// reuse identical content it already holds instead of sending it again
+func (w *ReplicationWorker) upload(ctx context.Context, remote *client.Client, r *entities.BucketReplication, change *models.FileChangeResponse) (*client.File, int64, error) {
+	var files []entities.File
+	if err := w.dbContext.GetDB().WithContext(ctx).Where(&entities.File{Id: *change.FileID, BucketId: r.BucketId}).
+		Limit(1).Find(&files).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(files) == 0 {
+		return nil, 0, errSourceFileGone
+	}
+	local := &files[0]
+
+	if sha256Pattern.MatchString(local.Checksum) {
+		file, exists, err := remote.PrecheckUpload(ctx, r.RemoteBucketId, local.Name, local.Checksum, local.Size, local.MimeType)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exists {
+			return file, 0, nil
+		}
+	}
+
+	content, err := storage.OpenFile(ctx, w.dbContext, local)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer content.Close()
+	file, err := remote.Upload(ctx, r.RemoteBucketId, local.Name, content, &client.UploadOptions{ContentType: local.MimeType})
+	if err != nil {
+		return nil, 0, err
+	}
+	return file, local.Size, nil
+}
+
+// removeName deletes the remote file the replication pushed for a name deleted locally. A file
+// written on the remote since is left alone.
+func (w *ReplicationWorker) removeName(ctx context.Context, remote *client.Client, r *entities.BucketReplication, name string, pass *replicationPass) error {
+	pushed, err := lastPushed(w.dbContext.GetDB().WithContext(ctx), r, name)
+	if err != nil || pushed == nil {
+		return err
+	}
+	current, err := remoteCurrent(ctx, remote, r.RemoteBucketId, name)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s on the remote: %w", name, err)
+	}
+	if current != nil && current.ID != pushed.RemoteFileId {
+		pass.conflicts++
+	} else if current != nil {
+		if err := remote.DeleteFile(ctx, r.RemoteBucketId, current.ID); err != nil && !client.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s on the remote: %w", name, err)
+		}
+		pass.deleted++
+	}
+	return w.dbContext.GetDB().WithContext(ctx).Delete(&entities.ReplicatedFile{}, `"Id" = ?`, pushed.Id).Error
+}
+
+// lastPushed returns the record of what the replication last pushed for a name, nil when nothing
+func lastPushed(db *gorm.DB, r *entities.BucketReplication, name string) (*entities.ReplicatedFile, error) {
+	var pushed []entities.ReplicatedFile
+	if err := db.Where(&entities.ReplicatedFile{ReplicationId: r.Id, Name: name}).
+		Limit(1).Find(&pushed).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up replicated file: %w", err)
+	}
+	if len(pushed) == 0 {
+		return nil, nil
+	}
+	return &pushed[0], nil
+}
+
+// recordPushed remembers the remote file serving a name as the replication's own
+func recordPushed(db *gorm.DB, r *entities.BucketReplication, name string, localFileID, remoteFileID uuid.UUID) error {
+	record := entities.ReplicatedFile{
+		Id:            uuid.New(),
+		ReplicationId: r.Id,
+		Name:          name,
+		LocalFileId:   localFileID,
+		RemoteFileId:  remoteFileID,
+		UpdatedAt:     time.Now(),
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ReplicationId"}, {Name: "Name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"LocalFileId", "RemoteFileId", "UpdatedAt"}),
+	}).Create(&record).Error
+	if err != nil {
+		return fmt.Errorf("failed to record replicated file: %w", err)
+	}
+	return nil
+}
+
+// remoteCurrent returns the highest version of a name in a remote bucket, nil when it has none
+func remoteCurrent(ctx context.Context, remote *client.Client, bucketID uuid.UUID, name string) (*client.File, error) {
+	opts := &client.ListOptions{Page: 1, Limit: remoteLookupPageSize, SortBy: "version", Order: "desc", Name: name}
+	for {
+		page, err := remote.ListFiles(ctx, bucketID, opts)
+		if err != nil {
+			return nil, err
+		}
+		// The name filter matches names containing it, the exact name is picked out
+		for i := range page.Files {
+			if page.Files[i].Name == name {
+				return &page.Files[i], nil
+			}
+		}
+		if len(page.Files) < opts.Limit {
+			return nil, nil
+		}
+		opts.Page++
+	}
+}
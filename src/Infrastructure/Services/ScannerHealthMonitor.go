@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Mail"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Scanning"
+	"shbucket/src/Models"
+)
+
+// scannerCheckTimeout is how long the scanner has to answer a health check
+const scannerCheckTimeout = 10 * time.Second
+
+// ScannerHealthMonitor checks the upload scanner on every interval and alerts when it stops
+// protecting uploads: clamd doesn't answer, its virus definitions are stale, scans keep failing or
+// uploads pile up waiting on it. Each kind of problem fires once when it starts and resolves when
+// it goes away. Queue and failures are those of this server, so the monitor runs on every server.
+// Alerts are logged, sent to the admins as notifications and emails, and posted to the alert
+// webhook when one is configured.
+type ScannerHealthMonitor struct {
+	dbContext  *persistence.AppDbContext
+	scanner    scanning.Scanner
+	settings   *config.Settings
+	mailer     *mail.Mailer
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	firing map[string]*models.ScannerAlertResponse // by problem kind
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewScannerHealthMonitor creates a new instance of ScannerHealthMonitor for the configured scanner
+func NewScannerHealthMonitor(dbContext *persistence.AppDbContext) *ScannerHealthMonitor {
+	settings := config.GetSettings()
+	// main refuses to start with a misconfigured scanner
+	scanner, _ := scanning.NewScanner(settings)
+	return &ScannerHealthMonitor{
+		dbContext:  dbContext,
+		scanner:    scanner,
+		settings:   settings,
+		mailer:     mail.NewMailer(settings),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		firing:     make(map[string]*models.ScannerAlertResponse),
+	}
+}
+
+// Start checks the scanner now and then on every interval. Nothing runs when uploads aren't scanned.
+func (m *ScannerHealthMonitor) Start() {
+	if m.scanner == nil || m.settings.ScanHealthInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(time.Duration(m.settings.ScanHealthInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			m.check(ctx, time.Now())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Scanner health monitor started")
+}
+
+// Stop waits for the current check to finish and the monitor to exit
+func (m *ScannerHealthMonitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+		<-m.done
+	}
+}
+
+// check fires the alerts of problems that started and resolves those that went away
+func (m *ScannerHealthMonitor) check(ctx context.Context, now time.Time) {
+	checkCtx, cancel := context.WithTimeout(ctx, scannerCheckTimeout)
+	health := scanning.CheckHealth(checkCtx, m.scanner)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	response := ToScannerHealthResponse(health, m.settings, now)
+
+	m.mu.Lock()
+	var fired, resolved []models.ScannerAlertResponse
+	current := make(map[string]bool, len(response.Problems))
+	for _, problem := range response.Problems {
+		current[problem.Kind] = true
+		if alert, ok := m.firing[problem.Kind]; ok {
+			alert.Message = problem.Message
+			alert.Scanner = response
+			continue
+		}
+		alert := &models.ScannerAlertResponse{Kind: problem.Kind, Message: problem.Message, FiredAt: now, Scanner: response}
+		m.firing[problem.Kind] = alert
+		fired = append(fired, *alert)
+	}
+	for kind, alert := range m.firing {
+		if current[kind] {
+			continue
+		}
+		delete(m.firing, kind)
+		resolvedAt := now
+		alert.ResolvedAt = &resolvedAt
+		alert.Scanner = response
+		resolved = append(resolved, *alert)
+	}
+	m.mu.Unlock()
+
+	for _, alert := range fired {
+		log.Printf("Warning: upload scanner: %s", alert.Message)
+		m.notify(ctx, "firing", alert)
+	}
+	for _, alert := range resolved {
+		log.Printf("Upload scanner recovered: %s", alert.Message)
+		m.notify(ctx, "resolved", alert)
+	}
+}
+
+// notify tells the admins about an alert firing or resolving, and posts it to the alert webhook
+func (m *ScannerHealthMonitor) notify(ctx context.Context, status string, alert models.ScannerAlertResponse) {
+	message := "Upload scanner problem: " + alert.Message
+	if status == "resolved" {
+		message = "Upload scanner recovered: " + alert.Message
+	}
+
+	db := m.dbContext.GetDB().WithContext(ctx)
+	var admins []entities.User
+	if err := db.Where(&entities.User{Role: "admin", IsActive: true}).Find(&admins).Error; err != nil {
+		log.Printf("Warning: failed to list admins to notify of scanner alert %s: %v", alert.Kind, err)
+	}
+	for _, admin := range admins {
+		notification := entities.Notification{UserId: admin.Id, Type: "scanner_alert", Message: message}
+		if err := db.Create(&notification).Error; err != nil {
+			log.Printf("Warning: failed to notify user %s of scanner alert %s: %v", admin.Id, alert.Kind, err)
+		}
+	}
+	if m.mailer.Enabled() {
+		m.email(ctx, admins, message, alert)
+	}
+
+	if m.settings.AlertWebhookURL != "" {
+		if err := m.deliver(ctx, status, alert); err != nil {
+			log.Printf("Warning: failed to deliver scanner alert: %v", err)
+		}
+	}
+}
+
+// email sends the alert to the admins' email addresses
+func (m *ScannerHealthMonitor) email(ctx context.Context, admins []entities.User, summary string, alert models.ScannerAlertResponse) {
+	engine := alert.Scanner.EngineVersion
+	if alert.Scanner.DefinitionsVersion != "" {
+		engine += ", definitions " + alert.Scanner.DefinitionsVersion
+	}
+	for _, admin := range admins {
+		err := m.mailer.Send(ctx, admin.Email, mail.TemplateScannerAlert, map[string]interface{}{
+			"Summary":        summary,
+			"Backend":        alert.Scanner.Backend,
+			"Engine":         engine,
+			"RecentScans":    alert.Scanner.RecentScans,
+			"RecentFailures": alert.Scanner.RecentFailures,
+			"QueueDepth":     alert.Scanner.QueueDepth,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to email scanner alert %s: %v", alert.Kind, err)
+		}
+	}
+}
+
+func (m *ScannerHealthMonitor) deliver(ctx context.Context, status string, alert models.ScannerAlertResponse) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":   "scanner",
+		"status": status,
+		"alert":  alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", m.settings.AlertWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-SHBucket-Event", "alert.scanner")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ToScannerHealthResponse converts the scanner's health to its API model, with the problems the
+// settings' thresholds alert on
+func ToScannerHealthResponse(health scanning.Health, settings *config.Settings, now time.Time) models.ScannerHealthResponse {
+	response := models.ScannerHealthResponse{
+		Backend:        health.Backend,
+		QueueDepth:     health.QueueDepth,
+		RecentScans:    health.RecentScans,
+		RecentFailures: health.RecentFailures,
+		FailureRate:    health.FailureRate(),
+		Problems:       []models.ScannerProblemResponse{},
+	}
+	if health.EngineError != nil {
+		reachable := false
+		response.Reachable = &reachable
+		response.Error = health.EngineError.Error()
+	}
+	if health.Engine != nil {
+		reachable := true
+		response.Reachable = &reachable
+		response.EngineVersion = health.Engine.Version
+		response.DefinitionsVersion = health.Engine.Definitions
+		response.DefinitionsUpdatedAt = health.Engine.DefinitionsUpdated
+	}
+	if age, ok := health.DefinitionAge(now); ok {
+		hours := age.Hours()
+		response.DefinitionAgeHours = &hours
+	}
+	for _, problem := range health.Problems(settings, now) {
+		response.Problems = append(response.Problems, models.ScannerProblemResponse{Kind: problem.Kind, Message: problem.Message})
+	}
+	return response
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"shbucket/src/Application/Setting"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// SettingsReloader periodically applies the settings saved through the admin API, so settings
+// saved through one server reach every other server sharing the database
+type SettingsReloader struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewSettingsReloader creates a new instance of SettingsReloader
+func NewSettingsReloader(dbContext *persistence.AppDbContext) *SettingsReloader {
+	return &SettingsReloader{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Start reloads the settings on every interval until Stop
+func (r *SettingsReloader) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(time.Duration(max(r.settings.SettingsReloadInterval, 1)) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := setting.LoadSystemSettings(r.dbContext); err != nil {
+					log.Printf("Warning: failed to reload system settings: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops reloading the settings
+func (r *SettingsReloader) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+	}
+}
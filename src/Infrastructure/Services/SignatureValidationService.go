@@ -1,6 +1,10 @@
 package services
 
 import (
+	"context"
+
+	"github.com/google/uuid"
+
 	"shbucket/src/Application/File"
 	"shbucket/src/Infrastructure/Config"
 	"shbucket/src/Infrastructure/Data/Entities"
@@ -25,15 +29,15 @@ func NewSignatureValidationService(dbContext *persistence.AppDbContext) *Signatu
 
 // ValidateAndConsumeSignature is deprecated - use ValidateSignatureOnly and MarkSignatureAsUsed separately
 // This was overcomplicated, the simple approach in ServeFile is better
-func (s *SignatureValidationService) ValidateAndConsumeSignature(signature string) (*entities.SignedURL, *entities.File, *entities.Bucket, error) {
+func (s *SignatureValidationService) ValidateAndConsumeSignature(ctx context.Context, signature string) (*entities.SignedURL, *entities.File, *entities.Bucket, error) {
 	// First validate the signature
-	signedURL, err := s.signedURLHandler.ValidateSignedURL(signature)
+	signedURL, err := s.signedURLHandler.ValidateSignedURL(ctx, signature)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	// Get file and bucket information
-	file, bucket, err := s.signedURLHandler.GetFileInfoFromSignature(signature)
+	file, bucket, err := s.signedURLHandler.GetFileInfoFromSignature(ctx, signature)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -50,13 +54,19 @@ func (s *SignatureValidationService) ValidateAndConsumeSignature(signature strin
 
 // ValidateSignatureOnly validates a signature without marking it as used
 // Use this for checking validity without consuming the signature
-func (s *SignatureValidationService) ValidateSignatureOnly(signature string) (*entities.SignedURL, error) {
-	return s.signedURLHandler.ValidateSignedURL(signature)
+func (s *SignatureValidationService) ValidateSignatureOnly(ctx context.Context, signature string) (*entities.SignedURL, error) {
+	return s.signedURLHandler.ValidateSignedURL(ctx, signature)
+}
+
+// ValidateSignatureForFile validates a signature like ValidateSignatureOnly and checks that it was
+// issued for the file, failing with file.ErrSignedForOtherFile when it wasn't
+func (s *SignatureValidationService) ValidateSignatureForFile(ctx context.Context, signature string, fileID uuid.UUID) (*entities.SignedURL, error) {
+	return s.signedURLHandler.ValidateSignedURLForFile(ctx, signature, fileID)
 }
 
 // GetFileInfoFromSignature returns file and bucket information from a signature
-func (s *SignatureValidationService) GetFileInfoFromSignature(signature string) (*entities.File, *entities.Bucket, error) {
-	return s.signedURLHandler.GetFileInfoFromSignature(signature)
+func (s *SignatureValidationService) GetFileInfoFromSignature(ctx context.Context, signature string) (*entities.File, *entities.Bucket, error) {
+	return s.signedURLHandler.GetFileInfoFromSignature(ctx, signature)
 }
 
 // MarkSignatureAsUsed manually marks a signature as used (for single-use URLs)
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+// UploadCleanupWorker removes the content of uploads that were abandoned between writing
+// content and committing the file record, on the master's disk or on a storage node, partially
+// written files left by a server that died mid-write, and expired idempotency keys. Everything it works from is in
+// the database or the shared storage directories, so any replica can clean up after another.
+type UploadCleanupWorker struct {
+	dbContext *persistence.AppDbContext
+	settings  *config.Settings
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewUploadCleanupWorker creates a new instance of UploadCleanupWorker
+func NewUploadCleanupWorker(dbContext *persistence.AppDbContext) *UploadCleanupWorker {
+	return &UploadCleanupWorker{
+		dbContext: dbContext,
+		settings:  config.GetSettings(),
+	}
+}
+
+// Start runs a cleanup pass now and then on every interval
+func (w *UploadCleanupWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(time.Duration(w.settings.UploadCleanupInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			w.Sweep(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Upload cleanup worker started")
+}
+
+// Stop waits for the current pass to reach a safe point and the worker to exit
+func (w *UploadCleanupWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// UploadCleanupResult counts what a cleanup pass removed
+type UploadCleanupResult struct {
+	AbandonedUploads       int
+	PartialFiles           int
+	ExpiredSessions        int
+	ExpiredIdempotencyKeys int
+}
+
+// Sweep runs one cleanup pass, it is also run on demand as an admin task
+func (w *UploadCleanupWorker) Sweep(ctx context.Context) UploadCleanupResult {
+	var result UploadCleanupResult
+	cutoff := time.Now().Add(-time.Duration(w.settings.PendingUploadTimeout) * time.Second)
+
+	abandoned, err := abandonedUploads(w.dbContext.GetDB(), cutoff)
+	if err != nil {
+		log.Printf("Upload cleanup: failed to list pending uploads: %v", err)
+		return result
+	}
+
+	removed := 0
+	for _, pending := range abandoned {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// A file record with the same ID means the upload completed and only clearing the record failed
+		committed, err := w.dbContext.Files.Where(&entities.File{Id: pending.Id}).FirstOrDefault()
+		if err != nil {
+			log.Printf("Upload cleanup: failed to check upload %s: %v", pending.Id, err)
+			continue
+		}
+		if committed == nil {
+			if err := storage.RemoveFile(ctx, w.dbContext, pending.Path); err != nil {
+				log.Printf("Upload cleanup: failed to remove content of abandoned upload %s: %v", pending.Id, err)
+				continue
+			}
+			removed++
+		}
+
+		if err := w.dbContext.GetDB().Delete(&entities.PendingUpload{}, `"Id" = ?`, pending.Id).Error; err != nil {
+			log.Printf("Upload cleanup: failed to clear pending upload %s: %v", pending.Id, err)
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("Upload cleanup: removed content of %d abandoned upload(s)", removed)
+	}
+	result.AbandonedUploads = removed
+
+	// A partial write is stale once it hasn't grown for as long as a pending upload may stay open
+	for _, root := range storage.LocalRoots(w.dbContext) {
+		spools, err := storage.CleanupStaleSpoolFiles(root, cutoff)
+		if err != nil {
+			log.Printf("Upload cleanup: failed to clean up partially written files in %s: %v", root, err)
+		}
+		if spools > 0 {
+			log.Printf("Upload cleanup: removed %d partially written file(s) from %s", spools, root)
+		}
+		result.PartialFiles += spools
+	}
+
+	result.ExpiredSessions = w.expireUploadSessions(ctx)
+
+	// Idempotency keys of uploads no retry can come for anymore
+	expired, err := expireIdempotencyKeys(w.dbContext.GetDB().WithContext(ctx), time.Now())
+	if err != nil {
+		log.Printf("Upload cleanup: failed to remove expired idempotency keys: %v", err)
+	}
+	result.ExpiredIdempotencyKeys = int(expired)
+	return result
+}
+
+// expireIdempotencyKeys removes the idempotency keys that expired before now
+func expireIdempotencyKeys(db *gorm.DB, now time.Time) (int64, error) {
+	expired := db.Delete(&entities.IdempotencyKey{}, `"ExpiresAt" < ?`, now)
+	return expired.RowsAffected, expired.Error
+}
+
+// abandonedUploads lists the pending uploads started before cutoff, oldest first
+func abandonedUploads(db *gorm.DB, cutoff time.Time) ([]entities.PendingUpload, error) {
+	var abandoned []entities.PendingUpload
+	err := db.Where(`"CreatedAt" < ?`, cutoff).Order(`"CreatedAt"`).Find(&abandoned).Error
+	return abandoned, err
+}
+
+// expiredSessions lists the upload sessions that expired before now
+func expiredSessions(db *gorm.DB, now time.Time) ([]entities.UploadSession, error) {
+	var expired []entities.UploadSession
+	err := db.Where(`"ExpiresAt" < ?`, now).Find(&expired).Error
+	return expired, err
+}
+
+// expireUploadSessions removes resumable uploads that received no chunk within their time to live,
+// and staged content whose session was never saved. It returns how many sessions it removed.
+func (w *UploadCleanupWorker) expireUploadSessions(ctx context.Context) int {
+	expired, err := expiredSessions(w.dbContext.GetDB(), time.Now())
+	if err != nil {
+		log.Printf("Upload cleanup: failed to list expired upload sessions: %v", err)
+		return 0
+	}
+
+	removed := 0
+	for _, session := range expired {
+		if ctx.Err() != nil {
+			return removed
+		}
+		if err := os.Remove(session.StagingPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Upload cleanup: failed to remove content of expired upload session %s: %v", session.Id, err)
+			continue
+		}
+		if err := w.dbContext.GetDB().Delete(&entities.UploadSession{}, `"Id" = ?`, session.Id).Error; err != nil {
+			log.Printf("Upload cleanup: failed to delete expired upload session %s: %v", session.Id, err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		log.Printf("Upload cleanup: removed %d expired upload session(s)", removed)
+	}
+
+	entries, err := os.ReadDir(w.settings.UploadSessionPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Upload cleanup: failed to read upload staging directory: %v", err)
+		}
+		return removed
+	}
+	cutoff := time.Now().Add(-time.Duration(w.settings.UploadSessionTTL) * time.Second)
+	for _, entry := range entries {
+		name, staged := strings.CutSuffix(entry.Name(), ".part")
+		id, err := uuid.Parse(name)
+		if !staged || err != nil || !entry.Type().IsRegular() {
+			continue
+		}
+		if info, err := entry.Info(); err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if session, err := w.dbContext.UploadSessions.Where(&entities.UploadSession{Id: id}).FirstOrDefault(); err == nil && session == nil {
+			os.Remove(filepath.Join(w.settings.UploadSessionPath, entry.Name()))
+		}
+	}
+	return removed
+}
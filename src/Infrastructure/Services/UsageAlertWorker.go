@@ -0,0 +1,430 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Mail"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Models"
+)
+
+// Usage alert scopes
+const (
+	UsageScopeBucket = "bucket"
+	UsageScopeMaster = "master"
+	UsageScopeNode   = "node"
+)
+
+// usageTarget is something with a capacity usage alerts watch: a bucket with a size limit, the
+// master or a node with a maximum storage
+type usageTarget struct {
+	scope    string
+	id       uuid.UUID // nil for the master
+	name     string
+	ownerID  uuid.UUID // the bucket's owner, nil for the master and nodes
+	used     int64
+	capacity int64
+}
+
+func (t usageTarget) utilization() float64 {
+	return 100 * float64(t.used) / float64(t.capacity)
+}
+
+func (t usageTarget) key(threshold int) string {
+	return fmt.Sprintf("%s|%s|%d", t.scope, t.id, threshold)
+}
+
+// UsageAlertWorker checks the utilization of bucket size limits and of the master's and nodes'
+// capacity against the configured thresholds. An alert fires when utilization reaches a threshold
+// and resolves once it falls below the threshold by the hysteresis, so utilization hovering around
+// a threshold doesn't alert over and over. Alerts are kept in the database, a new leader carries on
+// with the alerts already firing.
+type UsageAlertWorker struct {
+	dbContext  *persistence.AppDbContext
+	settings   *config.Settings
+	events     *events.Publisher
+	mailer     *mail.Mailer
+	httpClient *http.Client
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// NewUsageAlertWorker creates a new instance of UsageAlertWorker
+func NewUsageAlertWorker(dbContext *persistence.AppDbContext) *UsageAlertWorker {
+	return &UsageAlertWorker{
+		dbContext:  dbContext,
+		settings:   config.GetSettings(),
+		events:     events.NewPublisher(dbContext),
+		mailer:     mail.NewMailer(config.GetSettings()),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start checks utilization now and then on every interval. Nothing runs when no threshold is configured.
+func (w *UsageAlertWorker) Start() {
+	if len(w.settings.UsageAlertThresholds) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(time.Duration(w.settings.UsageAlertInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			if err := w.check(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				log.Printf("Usage alerts: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Usage alert worker started")
+}
+
+// Stop waits for the current check to finish and the worker to exit
+func (w *UsageAlertWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// check fires the alerts of thresholds newly reached and resolves those utilization fell back from
+func (w *UsageAlertWorker) check(ctx context.Context, now time.Time) error {
+	db := w.dbContext.GetDB().WithContext(ctx)
+
+	targets, err := w.targets(db)
+	if err != nil {
+		return err
+	}
+
+	firing, err := firingAlerts(db)
+	if err != nil {
+		return err
+	}
+	active := make(map[string]*entities.UsageAlert, len(firing))
+	for i := range firing {
+		alert := &firing[i]
+		active[fmt.Sprintf("%s|%s|%d", alert.Scope, alert.TargetId, alert.Threshold)] = alert
+	}
+
+	for _, target := range targets {
+		utilization := target.utilization()
+		for _, threshold := range w.settings.UsageAlertThresholds {
+			key := target.key(threshold)
+			alert, ok := active[key]
+			delete(active, key)
+
+			switch {
+			case ok && utilization < float64(threshold-w.settings.UsageAlertHysteresis):
+				w.resolve(db, alert, &target, now)
+			case ok:
+				if err := db.Model(alert).Updates(map[string]interface{}{
+					"Name": target.name, "Utilization": utilization, "Used": target.used, "Capacity": target.capacity,
+				}).Error; err != nil {
+					log.Printf("Warning: failed to update usage alert %s: %v", alert.Id, err)
+				}
+			case utilization >= float64(threshold):
+				w.fire(db, &target, threshold, now)
+			}
+		}
+	}
+
+	// What has no capacity any more, is gone or has a threshold no longer configured can't be over it
+	for _, alert := range active {
+		w.resolve(db, alert, nil, now)
+	}
+	return nil
+}
+
+// targets lists the buckets with a size limit, the master and the nodes with their capacity and
+// what they hold. What a location holds is summed from the files stored there, as system stats do.
+func (w *UsageAlertWorker) targets(db *gorm.DB) ([]usageTarget, error) {
+	targets, err := bucketTargets(db)
+	if err != nil {
+		return nil, err
+	}
+	used, err := locationUsage(db)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := w.dbContext.SetupConfigs.Where(&entities.SetupConfig{SetupType: "master"}).FirstOrDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch master configuration: %w", err)
+	}
+	if master != nil && master.MaxStorage > 0 {
+		targets = append(targets, usageTarget{scope: UsageScopeMaster, name: "master", used: used[""], capacity: master.MaxStorage})
+	}
+
+	var nodes []entities.StorageNode
+	if err := db.Where(`"MaxStorage" > 0 AND "FailedAt" IS NULL`).Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch storage nodes: %w", err)
+	}
+	for _, node := range nodes {
+		targets = append(targets, usageTarget{scope: UsageScopeNode, id: node.Id, name: node.Name, used: used[node.Id.String()], capacity: node.MaxStorage})
+	}
+	return targets, nil
+}
+
+// firingAlerts lists the alerts not resolved yet
+func firingAlerts(db *gorm.DB) ([]entities.UsageAlert, error) {
+	var firing []entities.UsageAlert
+	if err := db.Where(`"ResolvedAt" IS NULL`).Find(&firing).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch firing alerts: %w", err)
+	}
+	return firing, nil
+}
+
+// bucketTargets lists the buckets with a size limit, with the size of their files
+func bucketTargets(db *gorm.DB) ([]usageTarget, error) {
+	var buckets []struct {
+		Id       uuid.UUID
+		Name     string
+		OwnerId  uuid.UUID
+		Capacity int64
+		Used     int64
+	}
+	if err := db.Model(&entities.Bucket{}).
+		Select(`"Bucket"."Id", "Bucket"."Name", "Bucket"."OwnerId", "Bucket"."settings_MaxTotalSize" AS "Capacity", COALESCE(SUM("File"."Size"), 0) AS "Used"`).
+		Joins(`LEFT JOIN "File" ON "File"."BucketId" = "Bucket"."Id"`).
+		Where(`"Bucket"."settings_MaxTotalSize" > 0`).
+		Group(`"Bucket"."Id", "Bucket"."Name", "Bucket"."OwnerId", "Bucket"."settings_MaxTotalSize"`).
+		Scan(&buckets).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum bucket sizes: %w", err)
+	}
+
+	var targets []usageTarget
+	for _, bucket := range buckets {
+		targets = append(targets, usageTarget{scope: UsageScopeBucket, id: bucket.Id, name: bucket.Name, ownerID: bucket.OwnerId, used: bucket.Used, capacity: bucket.Capacity})
+	}
+	return targets, nil
+}
+
+// locationUsage sums the size of the files of each location by node ID, "" for the master
+func locationUsage(db *gorm.DB) (map[string]int64, error) {
+	var locations []struct {
+		NodeId string
+		Bytes  int64
+	}
+	location := `CASE WHEN "Path" LIKE 'node://%' THEN SUBSTR("Path", 8, 36) ELSE '' END`
+	if err := db.Model(&entities.File{}).
+		Select(location + ` AS "NodeId", COALESCE(SUM("Size"), 0) AS "Bytes"`).
+		Group(location).
+		Scan(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum storage locations: %w", err)
+	}
+	used := make(map[string]int64, len(locations))
+	for _, location := range locations {
+		used[location.NodeId] = location.Bytes
+	}
+	return used, nil
+}
+
+func (w *UsageAlertWorker) fire(db *gorm.DB, target *usageTarget, threshold int, now time.Time) {
+	alert := entities.UsageAlert{
+		Id:          uuid.New(),
+		Scope:       target.scope,
+		TargetId:    target.id,
+		Name:        target.name,
+		Threshold:   threshold,
+		Utilization: target.utilization(),
+		Used:        target.used,
+		Capacity:    target.capacity,
+		FiredAt:     now,
+	}
+	if err := db.Create(&alert).Error; err != nil {
+		log.Printf("Warning: failed to record usage alert for %s %s: %v", target.scope, target.name, err)
+		return
+	}
+	log.Printf("Warning: %s %s is at %.1f%% of its capacity, over the %d%% threshold", target.scope, target.name, alert.Utilization, threshold)
+	w.notify(db, "firing", &alert, target)
+}
+
+// resolve resolves an alert. target is what it was checked against, nil when nothing was.
+func (w *UsageAlertWorker) resolve(db *gorm.DB, alert *entities.UsageAlert, target *usageTarget, now time.Time) {
+	updates := map[string]interface{}{"ResolvedAt": now}
+	if target != nil {
+		updates["Utilization"] = target.utilization()
+		updates["Used"] = target.used
+		updates["Capacity"] = target.capacity
+	}
+	if err := db.Model(alert).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to resolve usage alert %s: %v", alert.Id, err)
+		return
+	}
+	alert.ResolvedAt = &now
+	log.Printf("%s %s is back under the %d%% threshold", alert.Scope, alert.Name, alert.Threshold)
+	w.notify(db, "resolved", alert, target)
+}
+
+// notify tells about an alert firing or resolving: in the bucket's event log, which reaches its
+// webhooks, as notifications and emails to the bucket's owner or, for the master and nodes, to
+// the admins, and on the alert webhook when one is configured
+func (w *UsageAlertWorker) notify(db *gorm.DB, status string, alert *entities.UsageAlert, target *usageTarget) {
+	response := ToUsageAlertResponse(alert)
+
+	var message string
+	if status == "firing" {
+		message = fmt.Sprintf("%s is at %.0f%% of its capacity, over the %d%% alert threshold", describeUsageTarget(alert), alert.Utilization, alert.Threshold)
+	} else {
+		message = fmt.Sprintf("%s is back under the %d%% alert threshold", describeUsageTarget(alert), alert.Threshold)
+	}
+
+	var recipients []uuid.UUID
+	if alert.Scope == UsageScopeBucket {
+		eventType := events.BucketUsageAlert
+		if status == "resolved" {
+			eventType = events.BucketUsageAlertResolved
+		}
+		w.events.Publish(eventType, alert.TargetId, nil, uuid.Nil, map[string]interface{}{
+			"threshold":   alert.Threshold,
+			"utilization": alert.Utilization,
+			"used":        alert.Used,
+			"capacity":    alert.Capacity,
+		})
+		if target != nil {
+			recipients = append(recipients, target.ownerID)
+		}
+	} else if err := db.Model(&entities.User{}).Where(`"Role" = ? AND "IsActive" = ?`, "admin", true).
+		Pluck("Id", &recipients).Error; err != nil {
+		log.Printf("Warning: failed to list admins to notify of usage alert %s: %v", alert.Id, err)
+	}
+
+	for _, userID := range recipients {
+		notification := entities.Notification{UserId: userID, Type: "usage_alert", Message: message}
+		if alert.Scope == UsageScopeBucket {
+			notification.BucketId = &alert.TargetId
+		}
+		if err := db.Create(&notification).Error; err != nil {
+			log.Printf("Warning: failed to notify user %s of usage alert %s: %v", userID, alert.Id, err)
+		}
+	}
+	if w.mailer.Enabled() && len(recipients) > 0 {
+		w.email(db, recipients, message, alert)
+	}
+
+	if w.settings.AlertWebhookURL != "" {
+		if err := w.deliver(status, response); err != nil {
+			log.Printf("Warning: failed to deliver usage alert: %v", err)
+		}
+	}
+}
+
+// email sends the alert to the recipients' email addresses
+func (w *UsageAlertWorker) email(db *gorm.DB, recipients []uuid.UUID, summary string, alert *entities.UsageAlert) {
+	var addresses []string
+	if err := db.Model(&entities.User{}).Where(`"Id" IN ? AND "IsActive" = ?`, recipients, true).
+		Pluck("Email", &addresses).Error; err != nil {
+		log.Printf("Warning: failed to look up emails for usage alert %s: %v", alert.Id, err)
+		return
+	}
+
+	for _, address := range addresses {
+		err := w.mailer.Send(context.Background(), address, mail.TemplateUsageAlert, map[string]interface{}{
+			"Summary":     summary,
+			"Used":        formatUsageBytes(alert.Used),
+			"Capacity":    formatUsageBytes(alert.Capacity),
+			"Utilization": alert.Utilization,
+			"Threshold":   alert.Threshold,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to email usage alert %s: %v", alert.Id, err)
+		}
+	}
+}
+
+func (w *UsageAlertWorker) deliver(status string, alert models.UsageAlertResponse) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":   "usage",
+		"status": status,
+		"alert":  alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.settings.AlertWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-SHBucket-Event", "alert.usage")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// formatUsageBytes formats a size in binary units, e.g. 1.5 GiB
+func formatUsageBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func describeUsageTarget(alert *entities.UsageAlert) string {
+	switch alert.Scope {
+	case UsageScopeBucket:
+		return fmt.Sprintf("Bucket %s", alert.Name)
+	case UsageScopeNode:
+		return fmt.Sprintf("Storage node %s", alert.Name)
+	default:
+		return "The master's storage"
+	}
+}
+
+// ToUsageAlertResponse converts a usage alert to its API model
+func ToUsageAlertResponse(alert *entities.UsageAlert) models.UsageAlertResponse {
+	response := models.UsageAlertResponse{
+		ID:          alert.Id,
+		Scope:       alert.Scope,
+		Name:        alert.Name,
+		Threshold:   alert.Threshold,
+		Utilization: alert.Utilization,
+		Used:        alert.Used,
+		Capacity:    alert.Capacity,
+		FiredAt:     alert.FiredAt,
+		ResolvedAt:  alert.ResolvedAt,
+	}
+	if alert.TargetId != uuid.Nil {
+		targetID := alert.TargetId
+		response.TargetID = &targetID
+	}
+	return response
+}
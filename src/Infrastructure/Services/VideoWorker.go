@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Media"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+// VideoWorker generates poster thumbnails and HLS renditions for videos queued at upload time
+type VideoWorker struct {
+	dbContext    *persistence.AppDbContext
+	settings     *config.Settings
+	processor    media.VideoProcessor
+	derivedCache *storage.DerivedCache
+	cancel       context.CancelFunc
+	done         chan struct{}
+}
+
+// NewVideoWorker creates a new instance of VideoWorker
+func NewVideoWorker(dbContext *persistence.AppDbContext) *VideoWorker {
+	settings := config.GetSettings()
+	return &VideoWorker{
+		dbContext:    dbContext,
+		settings:     settings,
+		processor:    media.NewFFmpegProcessor(settings.FFmpegPath),
+		derivedCache: storage.NewDerivedCache(settings.DerivedCachePath),
+	}
+}
+
+// Start begins polling for queued videos, it does nothing when ffmpeg is not available
+func (w *VideoWorker) Start() {
+	if !w.processor.Available() {
+		log.Printf("Video worker disabled: ffmpeg not found at %q", w.settings.FFmpegPath)
+		return
+	}
+
+	// Assets left in processing by a previous shutdown are picked up again
+	if err := requeueInterruptedVideos(w.dbContext.GetDB()); err != nil {
+		log.Printf("Warning: failed to requeue interrupted video processing: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(time.Duration(w.settings.VideoWorkerInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			// Drain the queue before waiting for the next tick
+			for w.processNext(ctx) {
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("Video worker started")
+}
+
+// Stop cancels in-flight processing and waits for the worker to exit
+func (w *VideoWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// requeueInterruptedVideos puts assets left in processing back in the queue
+func requeueInterruptedVideos(db *gorm.DB) error {
+	return db.Model(&entities.VideoAsset{}).Where(&entities.VideoAsset{Status: "processing"}).Update("Status", "pending").Error
+}
+
+// processNext handles the oldest pending asset and reports whether one was found
+func (w *VideoWorker) processNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	asset, err := w.dbContext.VideoAssets.Where(&entities.VideoAsset{Status: "pending"}).OrderBy("CreatedAt").FirstOrDefault()
+	if err != nil || asset == nil {
+		return false
+	}
+
+	asset.Status = "processing"
+	w.dbContext.VideoAssets.Update(*asset)
+	if err := w.dbContext.SaveChanges(); err != nil {
+		log.Printf("Warning: failed to mark video %s as processing: %v", asset.FileId, err)
+		return false
+	}
+
+	err = w.process(ctx, asset)
+	if ctx.Err() != nil {
+		// Shutting down, leave the asset to be requeued on the next start
+		return false
+	}
+
+	now := time.Now()
+	asset.CompletedAt = &now
+	if err != nil {
+		log.Printf("Video processing failed for file %s: %v", asset.FileId, err)
+		asset.Status = "failed"
+		asset.Error = err.Error()
+	} else {
+		asset.Status = "ready"
+		asset.Error = ""
+	}
+
+	w.dbContext.VideoAssets.Update(*asset)
+	if err := w.dbContext.SaveChanges(); err != nil {
+		log.Printf("Warning: failed to update video processing status for file %s: %v", asset.FileId, err)
+	}
+	return true
+}
+
+func (w *VideoWorker) process(ctx context.Context, asset *entities.VideoAsset) error {
+	file, err := w.dbContext.Files.Where(&entities.File{Id: asset.FileId}).FirstOrDefault()
+	if err != nil || file == nil {
+		return fmt.Errorf("file not found")
+	}
+
+	input, cleanup, err := w.localCopy(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	outputDir := w.derivedCache.Dir(file.Id)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := w.processor.Thumbnail(ctx, input, filepath.Join(outputDir, "thumbnail.jpg")); err != nil {
+		return fmt.Errorf("failed to generate thumbnail: %w", err)
+	}
+
+	hlsDir := filepath.Join(outputDir, "hls")
+	os.RemoveAll(hlsDir)
+	if err := w.processor.TranscodeHLS(ctx, input, hlsDir, media.DefaultHLSRenditions); err != nil {
+		return fmt.Errorf("failed to generate HLS renditions: %w", err)
+	}
+
+	return nil
+}
+
+// localCopy returns a path ffmpeg can read. Node-stored videos are downloaded to a temp file first.
+func (w *VideoWorker) localCopy(ctx context.Context, file *entities.File) (string, func(), error) {
+	if !storage.IsNodePath(file.Path) {
+		return file.Path, func() {}, nil
+	}
+
+	reader, err := storage.OpenFile(ctx, w.dbContext, file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "shbucket-video-*"+filepath.Ext(file.Name))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to download video from node: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	return tmp.Name(), cleanup, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/pkg/client"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestLocalNames lists each local name a listing page covers once
+func TestLocalNames(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	for _, file := range []entities.File{
+		{BucketId: bucket.Id, Name: "a.jpg", Version: 1},
+		{BucketId: bucket.Id, Name: "b.jpg", Version: 1},
+		{BucketId: bucket.Id, Name: "b.jpg", Version: 2},
+		{BucketId: bucket.Id, Name: "c.jpg", Version: 1},
+		{BucketId: bucket.Id, Name: "d.jpg", Version: 1},
+	} {
+		file.OriginalName = file.Name
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := &client.ChangesPage{ListedAfter: "a.jpg", Changes: []client.FileChange{{Name: "c.jpg"}}}
+	names, err := localNames(db, bucket.Id, page)
+	if err != nil {
+		t.Fatalf("localNames() = %v", err)
+	}
+	if len(names) != 2 || !slices.Contains(names, "b.jpg") || !slices.Contains(names, "c.jpg") {
+		t.Errorf("localNames() of a page up to c.jpg = %v, want b.jpg and c.jpg", names)
+	}
+
+	page.ListingComplete = true
+	if names, err = localNames(db, bucket.Id, page); err != nil || len(names) != 3 {
+		t.Errorf("localNames() of the last page = %v, %v, want b.jpg, c.jpg and d.jpg", names, err)
+	}
+}
+
+// TestDueSyncs lists the syncs past their next run, on demand syncs only once a run was asked for
+func TestDueSyncs(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	lastPass, earlier, before, after := now.Add(-3*time.Minute), now.Add(-2*time.Minute), now.Add(-time.Minute), now.Add(time.Minute)
+	for _, sync := range []entities.BucketSync{
+		{RemoteURL: "https://due", NextRunAt: before},
+		{RemoteURL: "https://later", NextRunAt: after},
+		{RemoteURL: "https://asked", OnDemand: true, NextRunAt: earlier, LastRunAt: &lastPass},
+		{RemoteURL: "https://idle", OnDemand: true, NextRunAt: earlier, LastRunAt: &now},
+	} {
+		sync.BucketId, sync.RemoteBucketId, sync.CreatedBy = sqlitetest.CreateBucket(t, db, uuid.NewString()).Id, uuid.New(), uuid.New()
+		if err := db.Create(&sync).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	due, err := dueSyncs(db, now)
+	if err != nil {
+		t.Fatalf("dueSyncs() = %v", err)
+	}
+	if len(due) != 2 || due[0].RemoteURL != "https://asked" || due[1].RemoteURL != "https://due" {
+		t.Errorf("dueSyncs() = %+v, want the on demand sync asked to run, then the due sync", due)
+	}
+}
+
+// TestSaveSyncProgress moves the cursor and adds to the counts, unless the sync changed since
+func TestSaveSyncProgress(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	sync := entities.BucketSync{BucketId: bucket.Id, RemoteURL: "https://remote", RemoteBucketId: uuid.New(), CreatedBy: bucket.OwnerId,
+		Cursor: "a", FilesCopied: 1, NextRunAt: time.Now()}
+	if err := db.Create(&sync).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveSyncProgress(db, &sync, "a", "b", &syncPass{copied: 2, bytes: 10}); err != nil {
+		t.Fatalf("saveSyncProgress() = %v", err)
+	}
+	var stored entities.BucketSync
+	if err := db.First(&stored, `"Id" = ?`, sync.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Cursor != "b" || stored.FilesCopied != 3 || stored.BytesCopied != 10 {
+		t.Errorf("sync after saveSyncProgress() = cursor %q, %d copied, %d bytes, want cursor b, 3 copied, 10 bytes", stored.Cursor, stored.FilesCopied, stored.BytesCopied)
+	}
+
+	sync.Prefix = "photos/"
+	if err := saveSyncProgress(db, &sync, "b", "c", &syncPass{}); !errors.Is(err, errSyncChanged) {
+		t.Errorf("saveSyncProgress() with another prefix = %v, want errSyncChanged", err)
+	}
+}
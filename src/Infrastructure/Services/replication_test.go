@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestSaveReplicationProgress moves the cursor and adds to the counts, unless the replication
+// changed since
+func TestSaveReplicationProgress(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	r := entities.BucketReplication{BucketId: bucket.Id, RemoteURL: "https://remote", RemoteBucketId: uuid.New(), CreatedBy: bucket.OwnerId,
+		Cursor: "a", Conflicts: 1}
+	if err := db.Create(&r).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveReplicationProgress(db, &r, "a", "b", &replicationPass{pushed: 2, conflicts: 1, bytes: 10}); err != nil {
+		t.Fatalf("saveReplicationProgress() = %v", err)
+	}
+	var stored entities.BucketReplication
+	if err := db.First(&stored, `"Id" = ?`, r.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Cursor != "b" || stored.FilesPushed != 2 || stored.Conflicts != 2 || stored.BytesPushed != 10 {
+		t.Errorf("replication after saveReplicationProgress() = %+v, want cursor b, 2 pushed, 2 conflicts, 10 bytes", stored)
+	}
+
+	if err := saveReplicationProgress(db, &r, "a", "c", &replicationPass{}); !errors.Is(err, errReplicationChanged) {
+		t.Errorf("saveReplicationProgress() from a passed cursor = %v, want errReplicationChanged", err)
+	}
+}
+
+// TestRecordPushed keeps one record per name, the remote file last pushed for it
+func TestRecordPushed(t *testing.T) {
+	db := sqlitetest.Open(t)
+	r := &entities.BucketReplication{Id: uuid.New()}
+	first, second := uuid.New(), uuid.New()
+	for _, remoteFileID := range []uuid.UUID{first, second} {
+		if err := recordPushed(db, r, "a.jpg", uuid.New(), remoteFileID); err != nil {
+			t.Fatalf("recordPushed() = %v", err)
+		}
+	}
+
+	pushed, err := lastPushed(db, r, "a.jpg")
+	if err != nil {
+		t.Fatalf("lastPushed() = %v", err)
+	}
+	if pushed == nil || pushed.RemoteFileId != second {
+		t.Errorf("lastPushed() = %+v, want the second remote file", pushed)
+	}
+	if pushed, err := lastPushed(db, r, "b.jpg"); err != nil || pushed != nil {
+		t.Errorf("lastPushed() of a name never pushed = %+v, %v, want nil", pushed, err)
+	}
+}
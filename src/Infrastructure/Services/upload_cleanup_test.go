@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestAbandonedUploads lists the pending uploads started before the cutoff, oldest first
+func TestAbandonedUploads(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "photos").Id
+	cutoff := time.Now().Add(-time.Hour)
+	for _, pending := range []entities.PendingUpload{
+		{BucketId: bucketID, Path: "/data/b.txt", CreatedAt: cutoff.Add(-time.Minute)},
+		{BucketId: bucketID, Path: "/data/a.txt", CreatedAt: cutoff.Add(-time.Hour)},
+		{BucketId: bucketID, Path: "/data/c.txt", CreatedAt: cutoff.Add(time.Minute)},
+	} {
+		if err := db.Create(&pending).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	abandoned, err := abandonedUploads(db, cutoff)
+	if err != nil {
+		t.Fatalf("abandonedUploads() = %v", err)
+	}
+	if len(abandoned) != 2 || abandoned[0].Path != "/data/a.txt" || abandoned[1].Path != "/data/b.txt" {
+		t.Errorf("abandonedUploads() = %+v, want the two uploads before the cutoff, oldest first", abandoned)
+	}
+}
+
+// TestExpiredSessions lists the upload sessions past their expiry
+func TestExpiredSessions(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	now := time.Now()
+	for name, expiresAt := range map[string]time.Time{"expired": now.Add(-time.Minute), "open": now.Add(time.Minute)} {
+		session := entities.UploadSession{BucketId: bucket.Id, UserId: bucket.OwnerId, FileName: name, StagingPath: "/tmp/" + name, ExpiresAt: expiresAt}
+		if err := db.Create(&session).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := expiredSessions(db, now)
+	if err != nil {
+		t.Fatalf("expiredSessions() = %v", err)
+	}
+	if len(expired) != 1 || expired[0].FileName != "expired" {
+		t.Errorf("expiredSessions() = %+v, want the expired session", expired)
+	}
+}
+
+// TestExpireIdempotencyKeys removes the idempotency keys past their expiry only
+func TestExpireIdempotencyKeys(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	for key, expiresAt := range map[string]time.Time{"expired": now.Add(-time.Minute), "open": now.Add(time.Minute)} {
+		record := entities.IdempotencyKey{Scope: "user", Key: key, Request: "PUT /files", ExpiresAt: expiresAt}
+		if err := db.Create(&record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := expireIdempotencyKeys(db, now)
+	if err != nil || expired != 1 {
+		t.Fatalf("expireIdempotencyKeys() = %d, %v, want 1", expired, err)
+	}
+	var left []entities.IdempotencyKey
+	if err := db.Find(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].Key != "open" {
+		t.Errorf("after expireIdempotencyKeys() = %+v, want the open key", left)
+	}
+}
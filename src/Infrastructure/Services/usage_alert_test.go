@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestUsageTargets sums the files of the buckets with a size limit and of each storage location
+func TestUsageTargets(t *testing.T) {
+	db := sqlitetest.Open(t)
+	limited := sqlitetest.CreateBucket(t, db, "photos")
+	unlimited := sqlitetest.CreateBucket(t, db, "videos")
+	if err := db.Model(&limited).Update("settings_MaxTotalSize", 100).Error; err != nil {
+		t.Fatal(err)
+	}
+	nodeID := uuid.New()
+	for i, file := range []entities.File{
+		{BucketId: limited.Id, Path: "/data/photos/a", Size: 10},
+		{BucketId: limited.Id, Path: "node://" + nodeID.String() + "/" + limited.Id.String() + "/b", Size: 20},
+		{BucketId: unlimited.Id, Path: "/data/videos/c", Size: 40},
+	} {
+		file.Name, file.OriginalName = uuid.NewString(), "a.txt"
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file %d: %v", i, err)
+		}
+	}
+
+	targets, err := bucketTargets(db)
+	if err != nil {
+		t.Fatalf("bucketTargets() = %v", err)
+	}
+	if len(targets) != 1 || targets[0].id != limited.Id || targets[0].used != 30 || targets[0].capacity != 100 || targets[0].ownerID != limited.OwnerId {
+		t.Errorf("bucketTargets() = %+v, want photos using 30 of 100 bytes", targets)
+	}
+
+	used, err := locationUsage(db)
+	if err != nil {
+		t.Fatalf("locationUsage() = %v", err)
+	}
+	if used[""] != 50 || used[nodeID.String()] != 20 {
+		t.Errorf("locationUsage() = %v, want 50 bytes on the master and 20 on the node", used)
+	}
+}
+
+// TestFiringAlerts lists the alerts not resolved yet
+func TestFiringAlerts(t *testing.T) {
+	db := sqlitetest.Open(t)
+	now := time.Now()
+	for _, resolvedAt := range []*time.Time{nil, &now} {
+		alert := entities.UsageAlert{Scope: UsageScopeMaster, Name: "master", Threshold: 80, FiredAt: now, ResolvedAt: resolvedAt}
+		if err := db.Create(&alert).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	firing, err := firingAlerts(db)
+	if err != nil {
+		t.Fatalf("firingAlerts() = %v", err)
+	}
+	if len(firing) != 1 || firing[0].ResolvedAt != nil {
+		t.Errorf("firingAlerts() = %+v, want the unresolved alert", firing)
+	}
+}
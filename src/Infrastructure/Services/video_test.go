@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRequeueInterruptedVideos puts videos a shutdown left in processing back in the queue
+func TestRequeueInterruptedVideos(t *testing.T) {
+	db := sqlitetest.Open(t)
+	for _, status := range []string{"processing", "ready"} {
+		asset := entities.VideoAsset{FileId: uuid.New(), BucketId: uuid.New(), Status: status}
+		if err := db.Create(&asset).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := requeueInterruptedVideos(db); err != nil {
+		t.Fatalf("requeueInterruptedVideos() = %v", err)
+	}
+
+	var statuses []string
+	if err := db.Model(&entities.VideoAsset{}).Order(`"Status"`).Pluck("Status", &statuses).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0] != "pending" || statuses[1] != "ready" {
+		t.Errorf("statuses = %v, want pending and ready", statuses)
+	}
+}
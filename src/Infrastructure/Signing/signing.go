@@ -0,0 +1,267 @@
+// Package signing holds the keys signed URLs and upload policies are signed with. One key signs at
+// a time. Rotating starts a new one and gives the one it replaces a retirement date, until which
+// what it signed still verifies, so outstanding URLs keep working. A signature names its key: the
+// key's ID, a dot and the MAC. The key SIGNATURE_SECRET sets is the first, with the ID "default",
+// and signatures from before keys had IDs are checked against it.
+package signing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// DefaultKeyID identifies the key SIGNATURE_SECRET sets
+const DefaultKeyID = "default"
+
+const (
+	// refreshInterval is how often the keys are reloaded, so a rotation on another server is
+	// picked up
+	refreshInterval = time.Minute
+	// missInterval is how soon a signature naming an unknown key may reload the keys again
+	missInterval = 5 * time.Second
+)
+
+var (
+	// ErrInvalid is a signature that doesn't match what it signs, or names no key
+	ErrInvalid = errors.New("invalid signature")
+	// ErrRetired is a signature made with a key that has retired
+	ErrRetired = errors.New("signature made with a retired key")
+)
+
+// Legacy encodings of the MACs of signatures from before keys had IDs
+var (
+	LegacyBase64 = base64.URLEncoding.EncodeToString
+	LegacyHex    = hex.EncodeToString
+)
+
+// Keyring signs and verifies with the signing keys stored in the database. It is safe for
+// concurrent use.
+type Keyring struct {
+	dbContext *persistence.AppDbContext
+	// secret is SIGNATURE_SECRET, the default key's
+	secret string
+
+	mu       sync.Mutex
+	keys     []entities.SigningKey
+	loadedAt time.Time
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Keyring
+)
+
+// Keys returns the Keyring shared by the whole server
+func Keys(dbContext *persistence.AppDbContext) *Keyring {
+	sharedOnce.Do(func() {
+		shared = &Keyring{dbContext: dbContext, secret: config.GetSettings().SignatureSecret}
+	})
+	return shared
+}
+
+// Sign returns the signature of message with the active key
+func (k *Keyring) Sign(ctx context.Context, message string) (string, error) {
+	keys, err := k.load(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	key := active(keys)
+	return key.KeyID + "." + base64.RawURLEncoding.EncodeToString(k.mac(key, message)), nil
+}
+
+// Verify checks a signature of message. A signature without a key ID is checked against the default
+// key, with its MAC encoded by legacy.
+func (k *Keyring) Verify(ctx context.Context, message, signature string, legacy func([]byte) string) error {
+	keyID, sum, keyed := strings.Cut(signature, ".")
+	if !keyed {
+		keyID, sum = DefaultKeyID, signature
+	}
+
+	key, err := k.find(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return ErrInvalid
+	}
+
+	mac := k.mac(*key, message)
+	expected := base64.RawURLEncoding.EncodeToString(mac)
+	if !keyed {
+		expected = legacy(mac)
+	}
+	if !hmac.Equal([]byte(sum), []byte(expected)) {
+		return ErrInvalid
+	}
+	if key.RetiresAt != nil && !key.RetiresAt.After(time.Now()) {
+		return ErrRetired
+	}
+	return nil
+}
+
+// List returns the signing keys, newest first
+func (k *Keyring) List(ctx context.Context) ([]entities.SigningKey, error) {
+	keys, err := k.load(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return append([]entities.SigningKey(nil), keys...), nil
+}
+
+// Rotate starts signing with a new key and retires the active one after retireIn. What the retired
+// key signed still verifies until then; zero retires it at once, for a key that leaked.
+func (k *Keyring) Rotate(ctx context.Context, retireIn time.Duration) (*entities.SigningKey, error) {
+	if _, err := k.load(ctx, true); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	key := entities.SigningKey{
+		KeyID:  hex.EncodeToString(id),
+		Secret: base64.StdEncoding.EncodeToString(secret),
+	}
+
+	retiresAt := time.Now().Add(retireIn)
+	if err := rotateKey(k.dbContext.GetDB().WithContext(ctx), &key, retiresAt); err != nil {
+		return nil, err
+	}
+
+	if _, err := k.load(ctx, true); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// find returns the key with the ID, reloading the keys for one this server hasn't seen yet, or
+// nil when there is none
+func (k *Keyring) find(ctx context.Context, keyID string) (*entities.SigningKey, error) {
+	keys, err := k.load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if key := lookup(keys, keyID); key != nil {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	recent := time.Since(k.loadedAt) < missInterval
+	k.mu.Unlock()
+	if recent {
+		return nil, nil
+	}
+	keys, err = k.load(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return lookup(keys, keyID), nil
+}
+
+// load returns the keys, newest first, reloading them when asked or when they are stale. The
+// default key is stored the first time the keys are loaded.
+func (k *Keyring) load(ctx context.Context, reload bool) ([]entities.SigningKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !reload && k.keys != nil && time.Since(k.loadedAt) < refreshInterval {
+		return k.keys, nil
+	}
+
+	keys, err := loadKeys(k.dbContext.GetDB().WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	k.keys = keys
+	k.loadedAt = time.Now()
+	return keys, nil
+}
+
+// loadKeys reads the keys, newest first, storing the default key when there are none
+func loadKeys(db *gorm.DB) ([]entities.SigningKey, error) {
+	var keys []entities.SigningKey
+	if err := db.Order(`"CreatedAt" DESC`).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	if len(keys) == 0 {
+		// Another server storing it first is fine, either way it is there to load
+		db.Create(&entities.SigningKey{KeyID: DefaultKeyID})
+		if err := db.Order(`"CreatedAt" DESC`).Find(&keys).Error; err != nil {
+			return nil, fmt.Errorf("failed to load signing keys: %w", err)
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("failed to store the default signing key")
+		}
+	}
+	return keys, nil
+}
+
+// rotateKey stores key and gives the keys without a retirement date retiresAt
+func rotateKey(db *gorm.DB, key *entities.SigningKey, retiresAt time.Time) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.SigningKey{}).Where(`"RetiresAt" IS NULL`).Update("RetiresAt", retiresAt).Error; err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rotate signing key: %w", err)
+	}
+	return nil
+}
+
+// mac signs message with key
+func (k *Keyring) mac(key entities.SigningKey, message string) []byte {
+	secret := key.Secret
+	if secret == "" {
+		secret = k.secret
+	}
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(message))
+	return hash.Sum(nil)
+}
+
+// Active reports whether key is the one signing among keys
+func Active(keys []entities.SigningKey, key entities.SigningKey) bool {
+	return active(keys).KeyID == key.KeyID
+}
+
+// active returns the newest key without a retirement date, which signs. When two rotations raced
+// and both left a key without one, the newer of them signs.
+func active(keys []entities.SigningKey) entities.SigningKey {
+	for _, key := range keys {
+		if key.RetiresAt == nil {
+			return key
+		}
+	}
+	return keys[0]
+}
+
+// lookup returns the key with the ID among keys, or nil when there is none
+func lookup(keys []entities.SigningKey, keyID string) *entities.SigningKey {
+	for i := range keys {
+		if keys[i].KeyID == keyID {
+			return &keys[i]
+		}
+	}
+	return nil
+}
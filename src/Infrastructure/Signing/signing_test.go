@@ -0,0 +1,66 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestRotate signs signed URLs with the new key while the replaced one verifies until it retires
+func TestRotate(t *testing.T) {
+	db := sqlitetest.Open(t)
+	ctx := context.Background()
+	keys, err := loadKeys(db)
+	if err != nil || len(keys) != 1 || keys[0].KeyID != DefaultKeyID {
+		t.Fatalf("loadKeys() = %+v, %v, want the default key", keys, err)
+	}
+	k := &Keyring{secret: "secret", keys: keys, loadedAt: time.Now()}
+	reload := func() {
+		t.Helper()
+		if k.keys, err = loadKeys(db); err != nil {
+			t.Fatalf("loadKeys() = %v", err)
+		}
+	}
+
+	url := uuid.NewString() + ":" + uuid.NewString()
+	before, err := k.Sign(ctx, url)
+	if err != nil || !strings.HasPrefix(before, DefaultKeyID+".") {
+		t.Fatalf("Sign() = %q, %v, want a signature of the default key", before, err)
+	}
+
+	if err := rotateKey(db, &entities.SigningKey{KeyID: "next", Secret: "next-secret"}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("rotateKey() = %v", err)
+	}
+	reload()
+	after, err := k.Sign(ctx, url)
+	if err != nil || !strings.HasPrefix(after, "next.") {
+		t.Fatalf("Sign() after rotating = %q, %v, want a signature of the new key", after, err)
+	}
+	for _, signature := range []string{before, after} {
+		if err := k.Verify(ctx, url, signature, LegacyBase64); err != nil {
+			t.Errorf("Verify(%q) = %v, want nil", signature, err)
+		}
+	}
+	if err := k.Verify(ctx, url+"x", after, LegacyBase64); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify() of another URL = %v, want ErrInvalid", err)
+	}
+
+	// Rotating again at once retires the key that was signing, not the one already retiring
+	if err := rotateKey(db, &entities.SigningKey{KeyID: "last", Secret: "last-secret"}, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("rotateKey() = %v", err)
+	}
+	reload()
+	if err := k.Verify(ctx, url, after, LegacyBase64); !errors.Is(err, ErrRetired) {
+		t.Errorf("Verify() with a retired key = %v, want ErrRetired", err)
+	}
+	if err := k.Verify(ctx, url, before, LegacyBase64); err != nil {
+		t.Errorf("Verify() with a retiring key = %v, want nil", err)
+	}
+}
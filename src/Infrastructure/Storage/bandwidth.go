@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Bandwidth caps the rate at which a set of transfers reads content, shared between them however
+// many run at once. A nil Bandwidth doesn't limit anything.
+type Bandwidth struct {
+	rate  int64 // bytes per second
+	mutex sync.Mutex
+	next  time.Time // when the bytes read so far are paid for
+}
+
+// NewBandwidth returns a Bandwidth of rate bytes per second, nil when rate isn't positive
+func NewBandwidth(rate int64) *Bandwidth {
+	if rate <= 0 {
+		return nil
+	}
+	return &Bandwidth{rate: rate}
+}
+
+// Reader reads from r within the bandwidth, waiting as long as it must unless ctx ends first
+func (b *Bandwidth) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if b == nil {
+		return r
+	}
+	return &limitedReader{ctx: ctx, reader: r, bandwidth: b}
+}
+
+// reserve books n bytes and returns when they may be read
+func (b *Bandwidth) reserve(n int) time.Time {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	b.next = b.next.Add(time.Duration(n) * time.Second / time.Duration(b.rate))
+	return b.next
+}
+
+type limitedReader struct {
+	ctx       context.Context
+	reader    io.Reader
+	bandwidth *Bandwidth
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	// Small reads keep the rate smooth at low bandwidths
+	if limit := max(r.bandwidth.rate/10, 1); int64(len(p)) > limit {
+		p = p[:limit]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		wait := time.Until(r.bandwidth.reserve(n))
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-r.ctx.Done():
+				return n, r.ctx.Err()
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+)
+
+// Content encodings files can be stored with
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// compressChunk is how much content is read from the source at a time while compressing
+const compressChunk = 32 * 1024
+
+// Compressible reports whether content of mimeType is text-like and shrinks when compressed
+func Compressible(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/ld+json", "application/x-ndjson",
+		"application/javascript", "application/x-javascript", "application/ecmascript",
+		"application/xml", "image/svg+xml",
+		"application/yaml", "application/x-yaml",
+		"application/csv", "application/sql", "application/graphql",
+		"application/x-sh", "application/x-tex", "application/rtf":
+		return true
+	}
+	return false
+}
+
+// ContentEncodingFor returns the encoding content of mimeType is stored with in bucket, empty when
+// the bucket doesn't compress or the content isn't text-like
+func ContentEncodingFor(bucket *entities.Bucket, mimeType string) string {
+	if bucket.Settings.Compression == "" || !Compressible(mimeType) {
+		return ""
+	}
+	return bucket.Settings.Compression
+}
+
+// Compress returns content compressed with encoding as it is read, content unchanged for no encoding
+func Compress(encoding string, content io.Reader) (io.Reader, error) {
+	if encoding == "" {
+		return content, nil
+	}
+
+	r := &compressingReader{src: content, chunk: make([]byte, compressChunk)}
+	switch encoding {
+	case EncodingGzip:
+		r.w = gzip.NewWriter(&r.buf)
+	case EncodingZstd:
+		w, err := zstd.NewWriter(&r.buf, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		r.w = w
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	return r, nil
+}
+
+// compressingReader compresses its source a chunk at a time into a buffer it is read from, so
+// compression keeps the pace of the reader without a goroutine
+type compressingReader struct {
+	src   io.Reader
+	w     io.WriteCloser
+	buf   bytes.Buffer
+	chunk []byte
+	done  bool
+}
+
+func (r *compressingReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := r.src.Read(r.chunk)
+		if n > 0 {
+			if _, err := r.w.Write(r.chunk[:n]); err != nil {
+				return 0, err
+			}
+		}
+		if err == io.EOF {
+			if err := r.w.Close(); err != nil {
+				return 0, err
+			}
+			r.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// Decompress returns content decompressed from encoding as it is read, content unchanged for no
+// encoding. Closing the returned reader closes content.
+func Decompress(encoding string, content io.ReadCloser) (io.ReadCloser, error) {
+	switch encoding {
+	case "":
+		return content, nil
+	case EncodingGzip:
+		r, err := gzip.NewReader(content)
+		if err != nil {
+			content.Close()
+			return nil, fmt.Errorf("failed to decompress file: %w", err)
+		}
+		return decompressedFile{Reader: r, decoder: r, content: content}, nil
+	case EncodingZstd:
+		r, err := zstd.NewReader(content, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			content.Close()
+			return nil, fmt.Errorf("failed to decompress file: %w", err)
+		}
+		decoder := r.IOReadCloser()
+		return decompressedFile{Reader: decoder, decoder: decoder, content: content}, nil
+	default:
+		content.Close()
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// decompressedFile reads decompressed content while closing the decoder and the stored content
+type decompressedFile struct {
+	io.Reader
+	decoder io.Closer
+	content io.Closer
+}
+
+func (f decompressedFile) Close() error {
+	f.decoder.Close()
+	return f.content.Close()
+}
+
+// AcceptsEncoding reports whether an Accept-Encoding header allows a response in encoding
+func AcceptsEncoding(header, encoding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != encoding && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		// An explicit entry for the encoding overrides the wildcard
+		if coding == encoding {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// derivedExtensions maps derived content types to the file extension used on disk
+var derivedExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/avif": ".avif",
+}
+
+// DerivedCache stores transformed variants of files (resized images, ...) on disk,
+// keyed by file ID and the transform parameters that produced them
+type DerivedCache struct {
+	dir string
+}
+
+// NewDerivedCache creates a derived cache rooted at dir
+func NewDerivedCache(dir string) *DerivedCache {
+	return &DerivedCache{dir: dir}
+}
+
+// VariantKey builds a stable cache key from transform parameters
+func VariantKey(params string) string {
+	sum := sha256.Sum256([]byte(params))
+	return fmt.Sprintf("%x", sum[:16])
+}
+
+// Get returns the path and content type of a cached variant if it exists
+func (c *DerivedCache) Get(fileID uuid.UUID, variant string) (string, string, bool) {
+	for mimeType, ext := range derivedExtensions {
+		path := c.path(fileID, variant, ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, mimeType, true
+		}
+	}
+	return "", "", false
+}
+
+// Put stores a variant and returns its path
+func (c *DerivedCache) Put(fileID uuid.UUID, variant string, data []byte, mimeType string) (string, error) {
+	ext, ok := derivedExtensions[mimeType]
+	if !ok {
+		return "", fmt.Errorf("unsupported derived content type: %s", mimeType)
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.dir, fileID.String()), 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	path := c.path(fileID, variant, ext)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to move cache entry into place: %w", err)
+	}
+	return path, nil
+}
+
+// Dir returns the directory holding a file's derived content, for outputs that span several files (HLS renditions)
+func (c *DerivedCache) Dir(fileID uuid.UUID) string {
+	return filepath.Join(c.dir, fileID.String())
+}
+
+// Invalidate removes every cached variant of a file
+func (c *DerivedCache) Invalidate(fileID uuid.UUID) error {
+	return os.RemoveAll(c.Dir(fileID))
+}
+
+func (c *DerivedCache) path(fileID uuid.UUID, variant, ext string) string {
+	return filepath.Join(c.dir, fileID.String(), variant+ext)
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/NodeClient"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// ErrQuarantined is returned when opening a file whose content was found infected
+var ErrQuarantined = apierror.New(apierror.CodeForbidden, "file is quarantined")
+
+// NodePath holds the parts of a node:// file path
+// Format: node://{nodeID}/{bucketID}/{fileID}
+type NodePath struct {
+	NodeID   uuid.UUID
+	BucketID uuid.UUID
+	FileID   uuid.UUID
+}
+
+// IsNodePath reports whether the file path points at a storage node
+func IsNodePath(path string) bool {
+	return strings.HasPrefix(path, "node://")
+}
+
+// ParseNodePath splits a node:// path into its node, bucket and file IDs
+func ParseNodePath(path string) (*NodePath, error) {
+	if !IsNodePath(path) {
+		return nil, fmt.Errorf("not a node path: %s", path)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "node://"), "/")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("invalid node path format: %s", path)
+	}
+
+	nodeID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID in path: %w", err)
+	}
+	bucketID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bucket ID in path: %w", err)
+	}
+	fileID, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid file ID in path: %w", err)
+	}
+
+	return &NodePath{NodeID: nodeID, BucketID: bucketID, FileID: fileID}, nil
+}
+
+// OpenFile opens the content of a file regardless of where it is stored.
+// Local files are opened from disk, node files are streamed from the node's internal endpoint.
+// Encrypted content is decrypted, and its data key is re-wrapped if the bucket key has been rotated since.
+// Compressed content is decompressed.
+// Content encrypted with a customer-provided key can only be opened with OpenFileWithKey.
+// The caller is responsible for closing the returned reader.
+func OpenFile(ctx context.Context, dbContext *persistence.AppDbContext, file *entities.File) (io.ReadCloser, error) {
+	return OpenFileWithKey(ctx, dbContext, file, nil)
+}
+
+// OpenFileWithKey opens a file like OpenFile, using customerKey for content encrypted with a customer-provided key
+func OpenFileWithKey(ctx context.Context, dbContext *persistence.AppDbContext, file *entities.File, customerKey *encryption.CustomerKey) (io.ReadCloser, error) {
+	content, err := OpenStored(ctx, dbContext, file, customerKey)
+	if err != nil {
+		return nil, err
+	}
+	return Decompress(file.Metadata.ContentEncoding, content)
+}
+
+// OpenStored opens a file like OpenFileWithKey but leaves compressed content as stored, encoded
+// with the file's Metadata.ContentEncoding. Quarantined files can't be opened.
+func OpenStored(ctx context.Context, dbContext *persistence.AppDbContext, file *entities.File, customerKey *encryption.CustomerKey) (io.ReadCloser, error) {
+	if file.Metadata.Quarantined() {
+		return nil, ErrQuarantined
+	}
+	if !file.Encryption.Encrypted() {
+		return OpenEncrypted(ctx, dbContext, file.Path, file.Name, file.Encryption, customerKey)
+	}
+
+	keyring, err := encryption.NewKeyring(dbContext)
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.RewrapFile(file); err != nil {
+		// Reading still works with the retired key, the next read tries again
+		log.Printf("Warning: failed to re-wrap data key of file %s: %v", file.Id, err)
+	}
+	return openDecrypted(ctx, dbContext, keyring, file.Path, file.Name, file.Encryption)
+}
+
+// OpenEncrypted opens stored content encrypted under enc, for content that isn't a live file such as snapshot copies.
+// customerKey is only needed for content encrypted with a customer-provided key.
+func OpenEncrypted(ctx context.Context, dbContext *persistence.AppDbContext, path, name string, enc entities.FileEncryption, customerKey *encryption.CustomerKey) (io.ReadCloser, error) {
+	if enc.CustomerEncrypted() {
+		return openWithCustomerKey(ctx, dbContext, path, name, enc, customerKey)
+	}
+	if !enc.Encrypted() {
+		return OpenPath(ctx, dbContext, path, name)
+	}
+
+	keyring, err := encryption.NewKeyring(dbContext)
+	if err != nil {
+		return nil, err
+	}
+	return openDecrypted(ctx, dbContext, keyring, path, name, enc)
+}
+
+func openDecrypted(ctx context.Context, dbContext *persistence.AppDbContext, keyring *encryption.Keyring, path, name string, enc entities.FileEncryption) (io.ReadCloser, error) {
+	content, err := OpenPath(ctx, dbContext, path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := keyring.Decrypt(enc, content)
+	if err != nil {
+		content.Close()
+		return nil, fmt.Errorf("failed to decrypt file: %w", err)
+	}
+	return decryptedFile{Reader: plain, Closer: content}, nil
+}
+
+func openWithCustomerKey(ctx context.Context, dbContext *persistence.AppDbContext, path, name string, enc entities.FileEncryption, customerKey *encryption.CustomerKey) (io.ReadCloser, error) {
+	if customerKey == nil {
+		return nil, encryption.ErrCustomerKeyRequired
+	}
+	if customerKey.MD5 != enc.CustomerKeyMD5 {
+		return nil, encryption.ErrCustomerKeyMismatch
+	}
+
+	content, err := OpenPath(ctx, dbContext, path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := encryption.DecryptReader(content, customerKey.Key)
+	if err != nil {
+		content.Close()
+		return nil, fmt.Errorf("failed to decrypt file: %w", err)
+	}
+	return decryptedFile{Reader: plain, Closer: content}, nil
+}
+
+// decryptedFile reads plaintext while closing the underlying stored content
+type decryptedFile struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenPath opens stored content by its path and file name, for callers that only hold a file response.
+// Node content is served from the node cache when it holds a copy.
+func OpenPath(ctx context.Context, dbContext *persistence.AppDbContext, path, name string) (io.ReadCloser, error) {
+	if !IsNodePath(path) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		return f, nil
+	}
+
+	nodePath, err := ParseNodePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hot content is read from the master's cache of node-stored content
+	return DefaultNodeCache().Open(nodePath, func() (io.ReadCloser, int64, error) {
+		return fetchFromNode(ctx, dbContext, nodePath, name)
+	})
+}
+
+// fetchFromNode streams content from its node's internal endpoint, with its length or -1 when unknown
+func fetchFromNode(ctx context.Context, dbContext *persistence.AppDbContext, nodePath *NodePath, name string) (io.ReadCloser, int64, error) {
+	storageNode, err := dbContext.StorageNodes.Where(&entities.StorageNode{Id: nodePath.NodeID}).FirstOrDefault()
+	if err != nil || storageNode == nil {
+		return nil, 0, fmt.Errorf("storage node not found")
+	}
+
+	content, size, err := nodeclient.Default().Fetch(ctx, nodeclient.Node{URL: storageNode.URL, AuthKey: storageNode.AuthKey}, internalapi.FileRequest{
+		BucketID: nodePath.BucketID,
+		FileID:   nodePath.FileID,
+		Filename: name,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch file from node: %w", err)
+	}
+	return content, size, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/NodeClient"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// RemoveFile deletes stored content regardless of where it lives.
+// Local files are removed from disk, node files through the node's internal delete endpoint.
+// Content that is already gone is not an error. A copy of node content in the node cache is dropped.
+func RemoveFile(ctx context.Context, dbContext *persistence.AppDbContext, path string) error {
+	if !IsNodePath(path) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file: %w", err)
+		}
+		return nil
+	}
+
+	nodePath, err := ParseNodePath(path)
+	if err != nil {
+		return err
+	}
+	DefaultNodeCache().Invalidate(nodePath.BucketID, nodePath.FileID)
+
+	bucket, err := dbContext.Buckets.First(&entities.Bucket{Id: nodePath.BucketID})
+	if err != nil {
+		return fmt.Errorf("bucket not found: %w", err)
+	}
+
+	storageNode, err := dbContext.StorageNodes.First(&entities.StorageNode{Id: nodePath.NodeID})
+	if err != nil {
+		return fmt.Errorf("storage node not found: %w", err)
+	}
+
+	// Files are stored using just the fileID on nodes
+	node := nodeclient.Node{URL: storageNode.URL, AuthKey: storageNode.AuthKey}
+	if _, err := nodeclient.Default().Delete(ctx, node, internalapi.DeleteRequest{BucketName: bucket.Name, FileID: nodePath.FileID}); err != nil {
+		return fmt.Errorf("node deletion failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Encryption"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// SaveFile streams content to path and returns its sha256 checksum and size.
+// The content is written to a temporary file first so a failed write never leaves a partial file behind.
+func SaveFile(path string, content io.Reader) (string, int64, error) {
+	tmpPath := path + spoolSuffix
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	trackSpool(tmpPath)
+	defer untrackSpool(tmpPath)
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), content)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", 0, fmt.Errorf("failed to move file into place: %w", err)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), size, nil
+}
+
+// EncryptContent returns content encrypted with the customer-provided key when one is given, under a new
+// data key when the bucket has encryption enabled, and content unchanged with empty encryption otherwise
+func EncryptContent(dbContext *persistence.AppDbContext, bucket *entities.Bucket, customerKey *encryption.CustomerKey, content io.Reader) (io.Reader, entities.FileEncryption, error) {
+	if customerKey != nil {
+		reader, err := encryption.EncryptReader(content, customerKey.Key)
+		if err != nil {
+			return nil, entities.FileEncryption{}, err
+		}
+		return reader, entities.FileEncryption{CustomerKeyMD5: customerKey.MD5}, nil
+	}
+	if !bucket.Settings.Encryption {
+		return content, entities.FileEncryption{}, nil
+	}
+
+	keyring, err := encryption.NewKeyring(dbContext)
+	if err != nil {
+		return nil, entities.FileEncryption{}, err
+	}
+	return keyring.Encrypt(bucket.Id, content)
+}
+
+// SaveBucketFile streams content to path like SaveFile, compressing it with contentEncoding and then
+// encrypting it as EncryptContent does. The checksum and size returned are always those of the plaintext.
+func SaveBucketFile(dbContext *persistence.AppDbContext, bucket *entities.Bucket, customerKey *encryption.CustomerKey, contentEncoding string, path string, content io.Reader) (string, int64, entities.FileEncryption, error) {
+	if customerKey == nil && !bucket.Settings.Encryption && contentEncoding == "" {
+		checksum, size, err := SaveFile(path, content)
+		return checksum, size, entities.FileEncryption{}, err
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	compressed, err := Compress(contentEncoding, io.TeeReader(content, io.MultiWriter(hash, counter)))
+	if err != nil {
+		return "", 0, entities.FileEncryption{}, err
+	}
+	encrypted, enc, err := EncryptContent(dbContext, bucket, customerKey, compressed)
+	if err != nil {
+		return "", 0, entities.FileEncryption{}, err
+	}
+
+	if _, _, err := SaveFile(path, encrypted); err != nil {
+		return "", 0, entities.FileEncryption{}, err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), counter.n, enc, nil
+}
+
+// ChecksumReader passes content through, computing its sha256 checksum and size as it is read
+type ChecksumReader struct {
+	source io.Reader
+	hash   hash.Hash
+	size   int64
+}
+
+// NewChecksumReader returns a ChecksumReader reading from source
+func NewChecksumReader(source io.Reader) *ChecksumReader {
+	return &ChecksumReader{source: source, hash: sha256.New()}
+}
+
+func (r *ChecksumReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+	return n, err
+}
+
+// Checksum returns the hex encoded sha256 of the content read so far
+func (r *ChecksumReader) Checksum() string {
+	return fmt.Sprintf("%x", r.hash.Sum(nil))
+}
+
+// Size returns how many bytes were read so far
+func (r *ChecksumReader) Size() int64 {
+	return r.size
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// LinkFile hard-links src to dst, so both share the content, copying it with perm when linking
+// isn't possible (e.g. across filesystems)
+func LinkFile(src, dst string, perm os.FileMode) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"container/list"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Metrics"
+)
+
+// nodeCachePartSuffix marks cache entries still being filled
+const nodeCachePartSuffix = ".part"
+
+// nodeCacheMaxShare limits a single entry to a share of the cache, so one large file can't evict everything
+const nodeCacheMaxShare = 8
+
+var (
+	nodeCacheOnce sync.Once
+	nodeCache     *NodeCache
+)
+
+// DefaultNodeCache returns the cache of node-stored content configured with NODE_CACHE_PATH and NODE_CACHE_SIZE
+func DefaultNodeCache() *NodeCache {
+	nodeCacheOnce.Do(func() {
+		settings := config.GetSettings()
+		nodeCache = NewNodeCache(settings.NodeCachePath, settings.NodeCacheSize)
+	})
+	return nodeCache
+}
+
+// nodeCacheEntry is a file's content held in the cache
+type nodeCacheEntry struct {
+	key  string
+	size int64
+}
+
+// NodeCache keeps copies of content stored on storage nodes on the master's disk, so hot files are
+// read locally instead of fetched from their node on every request. It holds at most maxBytes and
+// evicts the least recently read content first. Content is kept as stored, still encrypted or
+// compressed, and is keyed by bucket and file ID, which are never reused for other content.
+type NodeCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // values are *nodeCacheEntry
+	lru     *list.List               // most recently read first
+	size    int64
+	filling map[string]uint64 // keys being filled, with the fill's token
+	fills   uint64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// NewNodeCache creates a cache in dir holding up to maxBytes, content already in dir is kept.
+// A maxBytes of 0 disables the cache.
+func NewNodeCache(dir string, maxBytes int64) *NodeCache {
+	c := &NodeCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		filling:  make(map[string]uint64),
+	}
+	if c.Enabled() {
+		c.load()
+	}
+	return c
+}
+
+// Enabled reports whether content is cached
+func (c *NodeCache) Enabled() bool {
+	return c.maxBytes > 0 && c.dir != ""
+}
+
+// Open returns the content of the file at nodePath from the cache, or from fetch when it isn't
+// cached. fetch returns the content and its length, -1 when unknown. Fetched content of a known
+// length is cached as it is read, once it has been read up to its length.
+func (c *NodeCache) Open(nodePath *NodePath, fetch func() (io.ReadCloser, int64, error)) (io.ReadCloser, error) {
+	if !c.Enabled() {
+		content, _, err := fetch()
+		return content, err
+	}
+
+	key := nodeCacheKey(nodePath.BucketID, nodePath.FileID)
+	if f := c.get(key); f != nil {
+		c.hits.Add(1)
+		return f, nil
+	}
+	c.misses.Add(1)
+
+	content, length, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || length > c.maxBytes/nodeCacheMaxShare {
+		return content, nil
+	}
+
+	token, ok := c.beginFill(key)
+	if !ok {
+		// Another read is already filling the entry
+		return content, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path(key)), 0755); err != nil {
+		c.endFill(key, token)
+		return content, nil
+	}
+	part, err := os.CreateTemp(filepath.Dir(c.path(key)), filepath.Base(key)+".*"+nodeCachePartSuffix)
+	if err != nil {
+		c.endFill(key, token)
+		return content, nil
+	}
+	return &nodeCacheFill{cache: c, key: key, token: token, length: length, content: content, part: part}, nil
+}
+
+// Invalidate drops the cached content of a file, and any fill in progress is discarded
+func (c *NodeCache) Invalidate(bucketID, fileID uuid.UUID) {
+	if !c.Enabled() {
+		return
+	}
+	key := nodeCacheKey(bucketID, fileID)
+
+	c.mu.Lock()
+	delete(c.filling, key)
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.mu.Unlock()
+
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove cached content of file %s: %v", fileID, err)
+	}
+}
+
+// Stats returns the cache's counters since the server started
+func (c *NodeCache) Stats() metrics.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return metrics.CacheStats{
+		Enabled:   c.Enabled(),
+		Path:      c.dir,
+		MaxBytes:  c.maxBytes,
+		Bytes:     c.size,
+		Entries:   len(c.entries),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// get opens a cached entry and marks it most recently read
+func (c *NodeCache) get(key string) *os.File {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		// Removed from disk behind the cache's back
+		c.remove(element)
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	// The modification time orders entries again after a restart
+	now := time.Now()
+	os.Chtimes(c.path(key), now, now)
+	return f
+}
+
+func (c *NodeCache) beginFill(key string) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.filling[key]; ok {
+		return 0, false
+	}
+	c.fills++
+	c.filling[key] = c.fills
+	return c.fills, true
+}
+
+func (c *NodeCache) endFill(key string, token uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.filling[key] == token {
+		delete(c.filling, key)
+	}
+}
+
+// commit moves a filled entry into place unless it was invalidated meanwhile, then evicts down to the limit
+func (c *NodeCache) commit(key string, token uint64, partPath string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.filling[key] != token {
+		os.Remove(partPath)
+		return
+	}
+	delete(c.filling, key)
+
+	if err := os.Rename(partPath, c.path(key)); err != nil {
+		os.Remove(partPath)
+		log.Printf("Warning: failed to move cached content into place: %v", err)
+		return
+	}
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(&nodeCacheEntry{key: key, size: size})
+	c.size += size
+	c.evict()
+}
+
+// evict removes the least recently read entries until the cache fits its limit. Readers that
+// already opened an evicted entry finish reading it.
+func (c *NodeCache) evict() {
+	for c.size > c.maxBytes {
+		element := c.lru.Back()
+		if element == nil {
+			return
+		}
+		entry := element.Value.(*nodeCacheEntry)
+		c.remove(element)
+		os.Remove(c.path(entry.key))
+		c.evictions.Add(1)
+	}
+}
+
+func (c *NodeCache) remove(element *list.Element) {
+	entry := element.Value.(*nodeCacheEntry)
+	c.lru.Remove(element)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// load indexes the content left in the cache directory by an earlier run, most recently read
+// first, and removes unfinished entries
+func (c *NodeCache) load() {
+	type found struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var entries []found
+	filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), nodeCachePartSuffix) {
+			os.Remove(path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		key, err := filepath.Rel(c.dir, path)
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, found{key: filepath.ToSlash(key), size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.After(entries[j].modTime) })
+	for _, entry := range entries {
+		c.entries[entry.key] = c.lru.PushBack(&nodeCacheEntry{key: entry.key, size: entry.size})
+		c.size += entry.size
+	}
+	c.evict()
+}
+
+func (c *NodeCache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key))
+}
+
+func nodeCacheKey(bucketID, fileID uuid.UUID) string {
+	return bucketID.String() + "/" + fileID.String()
+}
+
+// nodeCacheFill reads fetched content while writing it to a cache entry. The entry is kept when
+// the content was read up to its length, a reader stopping early discards it.
+type nodeCacheFill struct {
+	cache   *NodeCache
+	key     string
+	token   uint64
+	length  int64
+	content io.ReadCloser
+	part    *os.File
+	written int64
+	failed  bool
+	closed  bool
+}
+
+func (f *nodeCacheFill) Read(p []byte) (int, error) {
+	n, err := f.content.Read(p)
+	if n > 0 && !f.failed {
+		if _, werr := f.part.Write(p[:n]); werr != nil {
+			f.failed = true
+		}
+		f.written += int64(n)
+	}
+	return n, err
+}
+
+func (f *nodeCacheFill) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	err := f.content.Close()
+	partPath := f.part.Name()
+	if cerr := f.part.Close(); cerr != nil {
+		f.failed = true
+	}
+
+	if !f.failed && f.written == f.length {
+		f.cache.commit(f.key, f.token, partPath, f.written)
+	} else {
+		f.cache.endFill(f.key, f.token)
+		os.Remove(partPath)
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/NodeURL"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// NodeFilePath is where storage nodes serve files to anyone holding a URL signed by the master
+const NodeFilePath = nodeurl.Path
+
+// ErrNodeURLSignature is returned for node file URLs that are expired or not signed with the node's auth key
+var ErrNodeURLSignature = nodeurl.ErrSignature
+
+// SignedNodeURL returns a URL at which node serves the file at nodePath until expires, without
+// credentials. It is signed with the node's auth key, which only the master and the node hold.
+// The node answers with the given name and content type, since it doesn't know either.
+func SignedNodeURL(node *entities.StorageNode, nodePath *NodePath, name, contentType string, expires time.Time) string {
+	query := url.Values{}
+	query.Set("bucket_id", nodePath.BucketID.String())
+	query.Set("file_id", nodePath.FileID.String())
+	query.Set("name", name)
+	query.Set("type", contentType)
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", nodeurl.Sign(node.AuthKey, query))
+
+	base := node.PublicURL
+	if base == "" {
+		base = node.URL
+	}
+	return strings.TrimSuffix(base, "/") + NodeFilePath + "?" + query.Encode()
+}
+
+// NodeDownloadURL returns a signed URL at which the node holding the file at path serves it for
+// ttl, for redirecting a download there. It fails for nodes that are inactive, unhealthy or
+// draining reads for maintenance, whose downloads stay proxied through the master.
+func NodeDownloadURL(ctx context.Context, dbContext *persistence.AppDbContext, path, name, contentType string, ttl time.Duration) (string, error) {
+	return nodeDownloadURL(dbContext.GetDB().WithContext(ctx), path, name, contentType, ttl)
+}
+
+func nodeDownloadURL(db *gorm.DB, path, name, contentType string, ttl time.Duration) (string, error) {
+	nodePath, err := ParseNodePath(path)
+	if err != nil {
+		return "", err
+	}
+	var node entities.StorageNode
+	if err := db.First(&node, `"Id" = ?`, nodePath.NodeID).Error; err != nil {
+		return "", fmt.Errorf("storage node not found: %w", err)
+	}
+	if !node.IsActive || !node.IsHealthy || node.Draining() {
+		return "", apierror.Newf(apierror.CodeUnavailable, "storage node %s is not serving", node.Name)
+	}
+	return SignedNodeURL(&node, nodePath, name, contentType, time.Now().Add(ttl)), nil
+}
+
+// VerifyNodeURL checks the signature and expiry of a node file URL's query with the node's auth key
+func VerifyNodeURL(authKey string, query url.Values) error {
+	return nodeurl.Verify(authKey, query)
+}
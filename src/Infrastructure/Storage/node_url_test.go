@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestNodeDownloadURL signs URLs at the node holding a file, unless the node isn't serving
+func TestNodeDownloadURL(t *testing.T) {
+	db := sqlitetest.Open(t)
+	serving := entities.StorageNode{Name: "serving", URL: "http://serving", PublicURL: "https://files.example.com", AuthKey: "key", IsActive: true, IsHealthy: true}
+	down := entities.StorageNode{Name: "down", URL: "http://down", AuthKey: "key", IsActive: true}
+	for _, node := range []*entities.StorageNode{&serving, &down} {
+		if err := db.Create(node).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	bucketID, fileID := uuid.New(), uuid.New()
+
+	signed, err := nodeDownloadURL(db, "node://"+serving.Id.String()+"/"+bucketID.String()+"/"+fileID.String(), "a.jpg", "image/jpeg", time.Minute)
+	if err != nil {
+		t.Fatalf("nodeDownloadURL() = %v", err)
+	}
+	if !strings.HasPrefix(signed, "https://files.example.com"+NodeFilePath+"?") {
+		t.Errorf("nodeDownloadURL() = %q, want a URL at the node's public URL", signed)
+	}
+
+	if _, err := nodeDownloadURL(db, "node://"+down.Id.String()+"/"+bucketID.String()+"/"+fileID.String(), "a.jpg", "image/jpeg", time.Minute); err == nil {
+		t.Error("nodeDownloadURL() at an unhealthy node = nil, want an error")
+	}
+}
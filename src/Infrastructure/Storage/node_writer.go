@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+	"shbucket/src/Infrastructure/InternalAPI"
+	"shbucket/src/Infrastructure/NodeClient"
+)
+
+// NodeUpload describes content written to a storage node
+type NodeUpload struct {
+	BucketID    uuid.UUID
+	BucketName  string
+	FileID      uuid.UUID
+	Name        string
+	ContentType string
+}
+
+// UploadToNode streams content to the node at nodeURL through its internal upload endpoint.
+// The node stores it under the bucket name and file ID, at node://{nodeID}/{bucketID}/{fileID},
+// replacing any content stored there before.
+func UploadToNode(ctx context.Context, nodeURL, authKey string, upload NodeUpload, content io.Reader) error {
+	_, err := nodeclient.Default().Upload(ctx, nodeclient.Node{URL: nodeURL, AuthKey: authKey}, internalapi.UploadRequest{
+		BucketID:    upload.BucketID,
+		BucketName:  upload.BucketName,
+		FileID:      upload.FileID,
+		Filename:    upload.Name,
+		ContentType: upload.ContentType,
+	}, content)
+	if err != nil {
+		return err
+	}
+	// The file's content on the node was replaced, a cached copy may be out of date
+	DefaultNodeCache().Invalidate(upload.BucketID, upload.FileID)
+	return nil
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Config"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// ErrShuttingDown is returned by BeginTransfer once the server has started draining
+var ErrShuttingDown = apierror.New(apierror.CodeUnavailable, "server is shutting down")
+
+// spoolSuffix marks content that is still being written, see SaveFile
+const spoolSuffix = ".tmp"
+
+var transfers = struct {
+	mutex    sync.Mutex
+	active   sync.WaitGroup
+	draining bool
+}{}
+
+// BeginTransfer registers a write of object content (an upload, a copy to a node, an import)
+// so shutdown waits for it. Call the returned function when the transfer has finished.
+func BeginTransfer() (func(), error) {
+	transfers.mutex.Lock()
+	defer transfers.mutex.Unlock()
+
+	if transfers.draining {
+		return nil, ErrShuttingDown
+	}
+	transfers.active.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(transfers.active.Done)
+	}, nil
+}
+
+// DrainTransfers stops new transfers from starting and waits for the running ones to finish.
+// It returns false when ctx ends first.
+func DrainTransfers(ctx context.Context) bool {
+	transfers.mutex.Lock()
+	transfers.draining = true
+	transfers.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		transfers.active.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// spools holds the spool files this process is writing. Replicas sharing a storage volume each
+// clean up only their own spools on shutdown, and anyone's once they are stale.
+var spools = struct {
+	mutex sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+func trackSpool(path string) {
+	spools.mutex.Lock()
+	defer spools.mutex.Unlock()
+	spools.paths[filepath.Clean(path)] = true
+}
+
+func untrackSpool(path string) {
+	spools.mutex.Lock()
+	defer spools.mutex.Unlock()
+	delete(spools.paths, filepath.Clean(path))
+}
+
+// CleanupSpoolFiles removes the spool files of writes this process started and never finished,
+// and returns how many were removed. It must not run while transfers are in progress.
+func CleanupSpoolFiles() int {
+	spools.mutex.Lock()
+	defer spools.mutex.Unlock()
+
+	removed := 0
+	for path := range spools.paths {
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+		delete(spools.paths, path)
+	}
+	return removed
+}
+
+// CleanupStaleSpoolFiles removes spool files under root that were last written before cutoff,
+// left by a server that died mid-write, and returns how many were removed.
+// Spools this process is still writing are kept however old they are.
+func CleanupStaleSpoolFiles(root string, cutoff time.Time) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		// Stored content is named by file ID, so only "<uuid>.tmp" can be a spool file
+		name, isSpool := strings.CutSuffix(entry.Name(), spoolSuffix)
+		if _, err := uuid.Parse(name); err != nil {
+			isSpool = false
+		}
+		if !entry.Type().IsRegular() || !isSpool {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		spools.mutex.Lock()
+		writing := spools.paths[filepath.Clean(path)]
+		spools.mutex.Unlock()
+		if !writing {
+			if err := os.Remove(path); err == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// LocalRoots returns every local directory object content is stored under:
+// the configured storage path and the storage paths of this installation's setup configs
+func LocalRoots(dbContext *persistence.AppDbContext) []string {
+	roots := []string{config.GetSettings().StoragePath}
+	seen := map[string]bool{filepath.Clean(roots[0]): true}
+
+	if setupConfigs, err := dbContext.SetupConfigs.ToList(); err == nil {
+		for _, setupConfig := range setupConfigs {
+			if setupConfig.StoragePath != "" && !seen[filepath.Clean(setupConfig.StoragePath)] {
+				seen[filepath.Clean(setupConfig.StoragePath)] = true
+				roots = append(roots, setupConfig.StoragePath)
+			}
+		}
+	}
+	return roots
+}
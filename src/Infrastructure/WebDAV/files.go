@@ -0,0 +1,227 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"time"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+	"shbucket/src/Infrastructure/Storage"
+)
+
+var errNotReadable = errors.New("not opened for reading")
+
+// dirFile lists a folder, a bucket or the bucket list
+type dirFile struct {
+	ctx     context.Context
+	fs      *FileSystem
+	name    string
+	info    *fileInfo
+	entries []os.FileInfo
+	listed  bool
+}
+
+func (d *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.listed {
+		entries, err := d.fs.entries(d.ctx, d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.listed = true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+func (d *dirFile) Stat() (os.FileInfo, error)                   { return d.info, nil }
+func (d *dirFile) Read(p []byte) (int, error)                   { return 0, fmt.Errorf("%s is a folder", d.name) }
+func (d *dirFile) Write(p []byte) (int, error)                  { return 0, fmt.Errorf("%s is a folder", d.name) }
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d *dirFile) Close() error                                 { return nil }
+
+// readFile reads the current version of a file. Content is opened on the first read
+// and reopened when seeking backwards, which serving a range does at most once.
+type readFile struct {
+	ctx       context.Context
+	dbContext *persistence.AppDbContext
+	file      *entities.File
+	info      *fileInfo
+	content   io.ReadCloser
+	read      int64 // bytes read from content
+	offset    int64
+}
+
+func (f *readFile) Read(p []byte) (int, error) {
+	if f.content != nil && f.read != f.offset {
+		f.content.Close()
+		f.content = nil
+	}
+	if f.content == nil {
+		content, err := storage.OpenFile(f.ctx, f.dbContext, f.file)
+		if err != nil {
+			return 0, err
+		}
+		f.content = content
+		f.read = 0
+		if _, err := io.CopyN(io.Discard, content, f.offset); err != nil {
+			return 0, err
+		}
+		f.read = f.offset
+	}
+
+	n, err := f.content.Read(p)
+	f.read += int64(n)
+	f.offset = f.read
+	return n, err
+}
+
+func (f *readFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.file.Size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *readFile) Close() error {
+	if f.content != nil {
+		return f.content.Close()
+	}
+	return nil
+}
+
+func (f *readFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *readFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("%s is not a folder", f.file.Name)
+}
+
+func (f *readFile) Write(p []byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+// writeFile collects the content of a PUT in a temporary file and uploads it when closed,
+// since an upload needs to know the size before it starts. Buckets without versioning
+// keep only the new file under the name.
+type writeFile struct {
+	ctx      context.Context
+	fs       *FileSystem
+	bucket   *entities.Bucket
+	filePath string
+	spool    *os.File
+	size     int64
+	failed   bool
+}
+
+func newWriteFile(ctx context.Context, fs *FileSystem, bucket *entities.Bucket, filePath string) (*writeFile, error) {
+	spool, err := os.CreateTemp("", "shbucket-dav-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload spool: %w", err)
+	}
+	return &writeFile{ctx: ctx, fs: fs, bucket: bucket, filePath: filePath, spool: spool}, nil
+}
+
+func (f *writeFile) Write(p []byte) (int, error) {
+	n, err := f.spool.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.failed = true
+	}
+	return n, err
+}
+
+// WriteAt writes at an offset, for protocols like SFTP that send content in blocks that may arrive out of order
+func (f *writeFile) WriteAt(p []byte, offset int64) (int, error) {
+	n, err := f.spool.WriteAt(p, offset)
+	f.size = max(f.size, offset+int64(n))
+	if err != nil {
+		f.failed = true
+	}
+	return n, err
+}
+
+// Abort drops the content written so far without uploading it, for a transfer the client gave up on
+func (f *writeFile) Abort() error {
+	f.spool.Close()
+	return os.Remove(f.spool.Name())
+}
+
+func (f *writeFile) Close() error {
+	defer os.Remove(f.spool.Name())
+	defer f.spool.Close()
+
+	// Content that didn't arrive in full is never uploaded
+	if f.failed {
+		return fmt.Errorf("upload of %s incomplete", f.filePath)
+	}
+	if _, err := f.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// A locked file isn't replaced by a new one of its name
+	if !f.bucket.Settings.Versioning {
+		if locked, err := f.fs.locked(f.ctx, f.bucket, `"Name" = ?`, f.filePath); err != nil || locked {
+			return cmpErr(err, os.ErrPermission)
+		}
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(f.filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	response, err := f.fs.mediator.Send(f.ctx, &file.DistributedUploadCommand{
+		BucketID:    f.bucket.Id,
+		FileReader:  f.spool,
+		FileSize:    f.size,
+		FileName:    f.filePath,
+		ContentType: contentType,
+		UploadedBy:  f.fs.access.UserID,
+	})
+	if errors.Is(err, file.ErrFileExists) || errors.Is(err, file.ErrFileLocked) {
+		return os.ErrPermission
+	}
+	if err != nil {
+		return err
+	}
+
+	uploaded := response.(*file.DistributedUploadResponse)
+	return f.fs.replaceOlder(f.ctx, f.bucket, f.filePath, uploaded.File.ID)
+}
+
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	return &fileInfo{name: path.Base(f.filePath), size: f.size, modTime: time.Now()}, nil
+}
+
+func (f *writeFile) Read(p []byte) (int, error)                   { return 0, errNotReadable }
+func (f *writeFile) Seek(offset int64, whence int) (int64, error) { return 0, errNotReadable }
+func (f *writeFile) Readdir(count int) ([]os.FileInfo, error)     { return nil, errNotReadable }
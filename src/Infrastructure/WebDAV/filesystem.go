@@ -0,0 +1,553 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Application/File"
+	"shbucket/src/Infrastructure/Auth"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Events"
+	"shbucket/src/Infrastructure/Mediator"
+	"shbucket/src/Infrastructure/Persistence"
+
+	netdav "golang.org/x/net/webdav"
+)
+
+// Access is what an authenticated client may do through WebDAV or SFTP
+type Access struct {
+	UserID  uuid.UUID
+	Read    bool
+	Write   bool
+	Buckets []string // bucket IDs an API key is limited to, empty for every bucket
+}
+
+// NewAccess derives what a user may do from their role and, for an API key, the key's permissions:
+// reading needs the viewer role and changes the editor role, or the read and write permissions of a custom role
+func NewAccess(authService *auth.AuthorizationService, dbContext *persistence.AppDbContext, user *auth.APIKeyUserContext) Access {
+	return Access{
+		UserID:  user.UserID,
+		Read:    user.Permissions.Read && authService.CredentialsAllowed(dbContext, user, "viewer", "read"),
+		Write:   user.Permissions.Write && authService.CredentialsAllowed(dbContext, user, "editor", "write"),
+		Buckets: user.Permissions.Buckets,
+	}
+}
+
+// FileSystem maps a user's buckets onto a WebDAV tree, which SFTP serves as well. The root lists
+// the buckets the user owns, and each bucket holds its files with the "/"-separated parts of their
+// names as folders. Uploads and deletions go through the same handlers as the API.
+type FileSystem struct {
+	dbContext *persistence.AppDbContext
+	db        *gorm.DB
+	mediator  *mediator.Mediator
+	events    *events.Publisher
+	access    Access
+}
+
+var _ netdav.FileSystem = (*FileSystem)(nil)
+
+func NewFileSystem(dbContext *persistence.AppDbContext, mediator *mediator.Mediator, access Access) *FileSystem {
+	return &FileSystem{
+		dbContext: dbContext,
+		db:        dbContext.GetDB(),
+		mediator:  mediator,
+		events:    events.NewPublisher(dbContext),
+		access:    access,
+	}
+}
+
+// split parses a WebDAV path into the bucket name and the file or folder path inside the bucket
+func split(name string) (bucketName, filePath string) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	bucketName, filePath, _ = strings.Cut(name, "/")
+	return bucketName, filePath
+}
+
+// bucket finds a bucket by name. Buckets the client may not see don't exist as far as it can tell.
+func (fs *FileSystem) bucket(name string) (*entities.Bucket, error) {
+	if !fs.access.Read {
+		return nil, os.ErrNotExist
+	}
+	bucket, err := fs.dbContext.Buckets.Where(&entities.Bucket{Name: name}).FirstOrDefault()
+	if err != nil {
+		return nil, err
+	}
+	if bucket == nil || !fs.visible(bucket) {
+		return nil, os.ErrNotExist
+	}
+	return bucket, nil
+}
+
+func (fs *FileSystem) visible(bucket *entities.Bucket) bool {
+	if bucket.OwnerId != fs.access.UserID {
+		return false
+	}
+	if len(fs.access.Buckets) == 0 {
+		return true
+	}
+	for _, allowed := range fs.access.Buckets {
+		if allowed == bucket.Id.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// writableBucket finds a bucket like bucket, for a change to the files in it
+func (fs *FileSystem) writableBucket(name string) (*entities.Bucket, error) {
+	bucket, err := fs.bucket(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fs.access.Write {
+		return nil, os.ErrPermission
+	}
+	return bucket, nil
+}
+
+// current returns the current version of the file named filePath, nil when there is none
+func (fs *FileSystem) current(ctx context.Context, bucket *entities.Bucket, filePath string) (*entities.File, error) {
+	var file entities.File
+	err := fs.db.WithContext(ctx).
+		Where(`"BucketId" = ? AND "Name" = ?`, bucket.Id, filePath).
+		Order(`"Version" DESC`).Order(`"CreatedAt" DESC`).First(&file).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// isFolder reports whether filePath is a folder: the bucket root, a folder created explicitly,
+// or the leading part of a file's name
+func (fs *FileSystem) isFolder(ctx context.Context, bucket *entities.Bucket, filePath string) (bool, error) {
+	if filePath == "" {
+		return true, nil
+	}
+	db := fs.db.WithContext(ctx)
+	nested := likePrefix(filePath + "/")
+
+	var folders int64
+	if err := db.Model(&entities.BucketFolder{}).
+		Where(`"BucketId" = ? AND ("Path" = ? OR "Path" LIKE ? ESCAPE '\')`, bucket.Id, filePath, nested).
+		Count(&folders).Error; err != nil {
+		return false, err
+	}
+	if folders > 0 {
+		return true, nil
+	}
+
+	var files int64
+	if err := db.Model(&entities.File{}).
+		Where(`"BucketId" = ? AND "Name" LIKE ? ESCAPE '\'`, bucket.Id, nested).
+		Count(&files).Error; err != nil {
+		return false, err
+	}
+	return files > 0, nil
+}
+
+// likePrefix returns a LIKE pattern matching everything starting with prefix
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// locked reports whether any file of the bucket matching the condition is locked by object lock
+func (fs *FileSystem) locked(ctx context.Context, bucket *entities.Bucket, condition string, args ...interface{}) (bool, error) {
+	var count int64
+	err := fs.db.WithContext(ctx).Model(&entities.File{}).
+		Where(`"BucketId" = ? AND ("lock_LegalHold" = ? OR "lock_RetainUntil" > ?)`, bucket.Id, true, time.Now()).
+		Where(condition, args...).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check locked files: %w", err)
+	}
+	return count > 0, nil
+}
+
+// parentExists reports whether the folder a new file or folder at filePath would go into exists
+func (fs *FileSystem) parentExists(ctx context.Context, bucket *entities.Bucket, filePath string) (bool, error) {
+	parent := path.Dir(filePath)
+	if parent == "." {
+		return true, nil
+	}
+	return fs.isFolder(ctx, bucket, parent)
+}
+
+func (fs *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	bucketName, filePath := split(name)
+	if bucketName == "" {
+		return &fileInfo{name: "/", dir: true}, nil
+	}
+
+	bucket, err := fs.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		return &fileInfo{name: bucket.Name, dir: true, modTime: bucket.UpdatedAt}, nil
+	}
+
+	file, err := fs.current(ctx, bucket, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file != nil {
+		return newFileInfo(file), nil
+	}
+
+	folder, err := fs.isFolder(ctx, bucket, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !folder {
+		return nil, os.ErrNotExist
+	}
+	return &fileInfo{name: path.Base(filePath), dir: true}, nil
+}
+
+func (fs *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (netdav.File, error) {
+	bucketName, filePath := split(name)
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		if filePath == "" {
+			return nil, os.ErrPermission
+		}
+		bucket, err := fs.writableBucket(bucketName)
+		if err != nil {
+			return nil, err
+		}
+		if exists, err := fs.parentExists(ctx, bucket, filePath); err != nil || !exists {
+			return nil, cmpErr(err, os.ErrNotExist)
+		}
+		if folder, err := fs.isFolder(ctx, bucket, filePath); err != nil || folder {
+			return nil, cmpErr(err, fmt.Errorf("%s is a folder", name))
+		}
+		return newWriteFile(ctx, fs, bucket, filePath)
+	}
+
+	info, err := fs.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dirFile{ctx: ctx, fs: fs, name: name, info: info.(*fileInfo)}, nil
+	}
+
+	file := info.(*fileInfo).file
+	// Content encrypted with a customer-provided key can't be read without the key, which WebDAV can't send
+	if file.Encryption.CustomerEncrypted() {
+		return nil, os.ErrPermission
+	}
+	return &readFile{ctx: ctx, dbContext: fs.dbContext, file: file, info: info.(*fileInfo)}, nil
+}
+
+func (fs *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	bucketName, filePath := split(name)
+	// Buckets are created through the API, with their settings
+	if filePath == "" {
+		return os.ErrPermission
+	}
+	bucket, err := fs.writableBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	if info, err := fs.Stat(ctx, name); err == nil && info != nil {
+		return os.ErrExist
+	}
+	if exists, err := fs.parentExists(ctx, bucket, filePath); err != nil || !exists {
+		return cmpErr(err, os.ErrNotExist)
+	}
+
+	fs.dbContext.BucketFolders.Add(entities.BucketFolder{
+		BucketId:  bucket.Id,
+		Path:      filePath,
+		CreatedBy: fs.access.UserID,
+	})
+	if err := fs.dbContext.SaveChanges(); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	return nil
+}
+
+// RemoveAll deletes the file at name with all its versions, or the folder at name with everything in it
+func (fs *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	bucketName, filePath := split(name)
+	// Buckets are deleted through the API, which can run the deletion as a job
+	if filePath == "" {
+		return os.ErrPermission
+	}
+	bucket, err := fs.writableBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	db := fs.db.WithContext(ctx)
+	nested := likePrefix(filePath + "/")
+
+	// Nothing is removed from a folder holding locked files
+	if locked, err := fs.locked(ctx, bucket, `"Name" = ? OR "Name" LIKE ? ESCAPE '\'`, filePath, nested); err != nil || locked {
+		return cmpErr(err, os.ErrPermission)
+	}
+
+	var files []entities.File
+	if err := db.Select("Id").Where(`"BucketId" = ? AND ("Name" = ? OR "Name" LIKE ? ESCAPE '\')`, bucket.Id, filePath, nested).
+		Find(&files).Error; err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	for _, stored := range files {
+		if _, err := fs.mediator.Send(ctx, &file.DeleteFileCommand{
+			FileID:   stored.Id,
+			BucketID: bucket.Id,
+			UserID:   fs.access.UserID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := db.Where(`"BucketId" = ? AND ("Path" = ? OR "Path" LIKE ? ESCAPE '\')`, bucket.Id, filePath, nested).
+		Delete(&entities.BucketFolder{}).Error; err != nil {
+		return fmt.Errorf("failed to delete folders: %w", err)
+	}
+	return nil
+}
+
+// Rename renames a file with all its versions, or a folder with everything in it.
+// Content stays where it is, so files can't be moved to another bucket this way; copy them instead.
+func (fs *FileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldBucket, oldPath := split(oldName)
+	newBucket, newPath := split(newName)
+	if oldPath == "" || newPath == "" || oldBucket != newBucket {
+		return os.ErrPermission
+	}
+	bucket, err := fs.writableBucket(oldBucket)
+	if err != nil {
+		return err
+	}
+	if exists, err := fs.parentExists(ctx, bucket, newPath); err != nil || !exists {
+		return cmpErr(err, os.ErrNotExist)
+	}
+
+	files, err := fs.rename(ctx, bucket, oldPath, newPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			return err
+		}
+		return fmt.Errorf("failed to rename %s: %w", oldName, err)
+	}
+
+	for _, file := range files {
+		fs.events.Publish(events.FileRenamed, bucket.Id, &file.Id, fs.access.UserID, map[string]interface{}{
+			"name":     newPath + strings.TrimPrefix(file.Name, oldPath),
+			"old_name": file.Name,
+			"version":  file.Version,
+		})
+	}
+	return nil
+}
+
+// rename moves the files and folders at oldPath of the bucket to newPath in one transaction and
+// returns the files as they were named before
+func (fs *FileSystem) rename(ctx context.Context, bucket *entities.Bucket, oldPath, newPath string) ([]entities.File, error) {
+	nested := likePrefix(oldPath + "/")
+	renamed := func(name string) string {
+		return newPath + strings.TrimPrefix(name, oldPath)
+	}
+
+	var files []entities.File
+	err := fs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(`"BucketId" = ? AND ("Name" = ? OR "Name" LIKE ? ESCAPE '\')`, bucket.Id, oldPath, nested).
+			Find(&files).Error; err != nil {
+			return err
+		}
+		for _, file := range files {
+			if file.Lock.Active(time.Now()) {
+				return os.ErrPermission
+			}
+		}
+		for _, file := range files {
+			if err := tx.Model(&entities.File{}).Where(`"Id" = ?`, file.Id).Update("Name", renamed(file.Name)).Error; err != nil {
+				return err
+			}
+		}
+
+		var folders []entities.BucketFolder
+		if err := tx.Where(`"BucketId" = ? AND ("Path" = ? OR "Path" LIKE ? ESCAPE '\')`, bucket.Id, oldPath, nested).
+			Find(&folders).Error; err != nil {
+			return err
+		}
+		for _, folder := range folders {
+			if err := tx.Model(&entities.BucketFolder{}).Where(`"Id" = ?`, folder.Id).Update("Path", renamed(folder.Path)).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(files) == 0 && len(folders) == 0 {
+			return os.ErrNotExist
+		}
+		return nil
+	})
+	return files, err
+}
+
+// entries lists what is directly inside a folder of a bucket, or the buckets for the root
+func (fs *FileSystem) entries(ctx context.Context, name string) ([]os.FileInfo, error) {
+	bucketName, filePath := split(name)
+	if bucketName == "" {
+		return fs.bucketEntries()
+	}
+
+	bucket, err := fs.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if filePath != "" {
+		prefix = filePath + "/"
+	}
+	return fs.folderEntries(ctx, bucket, prefix)
+}
+
+// folderEntries lists the files and folders of the bucket directly under prefix
+func (fs *FileSystem) folderEntries(ctx context.Context, bucket *entities.Bucket, prefix string) ([]os.FileInfo, error) {
+	db := fs.db.WithContext(ctx)
+
+	// Newest version first, so the first file seen of each name is the current one
+	var files []entities.File
+	if err := db.Where(`"BucketId" = ? AND "Name" LIKE ? ESCAPE '\'`, bucket.Id, likePrefix(prefix)).
+		Order(`"Name"`).Order(`"Version" DESC`).Order(`"CreatedAt" DESC`).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	var folders []entities.BucketFolder
+	if err := db.Where(`"BucketId" = ? AND "Path" LIKE ? ESCAPE '\'`, bucket.Id, likePrefix(prefix)).
+		Find(&folders).Error; err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var infos []os.FileInfo
+	addFolder := func(rest string) {
+		child, _, _ := strings.Cut(rest, "/")
+		if child != "" && !seen[child] {
+			seen[child] = true
+			infos = append(infos, &fileInfo{name: child, dir: true})
+		}
+	}
+	for i := range files {
+		rest := strings.TrimPrefix(files[i].Name, prefix)
+		if strings.Contains(rest, "/") {
+			addFolder(rest)
+		} else if rest != "" && !seen[rest] {
+			seen[rest] = true
+			infos = append(infos, newFileInfo(&files[i]))
+		}
+	}
+	for _, folder := range folders {
+		addFolder(strings.TrimPrefix(folder.Path, prefix))
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (fs *FileSystem) bucketEntries() ([]os.FileInfo, error) {
+	if !fs.access.Read {
+		return nil, nil
+	}
+	buckets, err := fs.dbContext.Buckets.Where(&entities.Bucket{OwnerId: fs.access.UserID}).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
+	var infos []os.FileInfo
+	for i := range buckets {
+		if fs.visible(&buckets[i]) {
+			infos = append(infos, &fileInfo{name: buckets[i].Name, dir: true, modTime: buckets[i].UpdatedAt})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+// replaceOlder removes the files named filePath other than current, for buckets that keep one version of a name
+func (fs *FileSystem) replaceOlder(ctx context.Context, bucket *entities.Bucket, filePath string, current uuid.UUID) error {
+	if bucket.Settings.Versioning {
+		return nil
+	}
+
+	var older []entities.File
+	if err := fs.db.WithContext(ctx).Select("Id").
+		Where(`"BucketId" = ? AND "Name" = ? AND "Id" <> ?`, bucket.Id, filePath, current).
+		Find(&older).Error; err != nil {
+		return fmt.Errorf("failed to list replaced files: %w", err)
+	}
+	for _, stored := range older {
+		if _, err := fs.mediator.Send(ctx, &file.DeleteFileCommand{
+			FileID:   stored.Id,
+			BucketID: bucket.Id,
+			UserID:   fs.access.UserID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cmpErr returns err, or fallback when err is nil
+func cmpErr(err, fallback error) error {
+	if err != nil {
+		return err
+	}
+	return fallback
+}
+
+// fileInfo describes a bucket, a folder or the current version of a file
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	file    *entities.File
+}
+
+func newFileInfo(file *entities.File) *fileInfo {
+	return &fileInfo{name: path.Base(file.Name), size: file.Size, modTime: file.UpdatedAt, file: file}
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() interface{}   { return nil }
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ETag is the same strong ETag file serving sends
+func (i *fileInfo) ETag(ctx context.Context) (string, error) {
+	if i.file == nil {
+		return "", netdav.ErrNotImplemented
+	}
+	return i.file.ETag(), nil
+}
+
+func (i *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if i.file == nil || i.file.MimeType == "" {
+		return "", netdav.ErrNotImplemented
+	}
+	return i.file.MimeType, nil
+}
@@ -0,0 +1,117 @@
+package webdav
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// testFileSystem returns a file system over a bucket holding docs/a.txt in two versions,
+// docs/deep/b.txt, top.txt and an empty folder empty/
+func testFileSystem(t *testing.T) (*FileSystem, *gorm.DB, *entities.Bucket) {
+	t.Helper()
+	db := sqlitetest.Open(t)
+	bucket := sqlitetest.CreateBucket(t, db, "photos")
+	for _, file := range []entities.File{
+		{Name: "docs/a.txt", Version: 1, Size: 1},
+		{Name: "docs/a.txt", Version: 2, Size: 2},
+		{Name: "docs/deep/b.txt", Version: 1},
+		{Name: "top.txt", Version: 1},
+	} {
+		file.BucketId, file.OriginalName, file.Path = bucket.Id, file.Name, "/data/"+uuid.NewString()
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	folder := entities.BucketFolder{BucketId: bucket.Id, Path: "empty", CreatedBy: uuid.New()}
+	if err := db.Create(&folder).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &FileSystem{db: db, access: Access{Read: true, Write: true}}, db, &bucket
+}
+
+// TestCurrent finds the newest version of a file
+func TestCurrent(t *testing.T) {
+	fs, _, bucket := testFileSystem(t)
+	file, err := fs.current(context.Background(), bucket, "docs/a.txt")
+	if err != nil {
+		t.Fatalf("current() = %v", err)
+	}
+	if file == nil || file.Version != 2 {
+		t.Errorf("current() = %+v, want version 2", file)
+	}
+	if file, err := fs.current(context.Background(), bucket, "docs"); err != nil || file != nil {
+		t.Errorf("current() of a folder = %+v, %v, want nil", file, err)
+	}
+}
+
+// TestIsFolder recognizes explicit folders and the leading parts of file names, not files
+func TestIsFolder(t *testing.T) {
+	fs, _, bucket := testFileSystem(t)
+	for filePath, want := range map[string]bool{"docs": true, "docs/deep": true, "empty": true, "top.txt": false, "do": false} {
+		if got, err := fs.isFolder(context.Background(), bucket, filePath); err != nil || got != want {
+			t.Errorf("isFolder(%q) = %v, %v, want %v", filePath, got, err, want)
+		}
+	}
+}
+
+// TestFolderEntries lists a folder's files once each and its subfolders
+func TestFolderEntries(t *testing.T) {
+	fs, _, bucket := testFileSystem(t)
+	infos, err := fs.folderEntries(context.Background(), bucket, "docs/")
+	if err != nil {
+		t.Fatalf("folderEntries() = %v", err)
+	}
+	if len(infos) != 2 || infos[0].Name() != "a.txt" || infos[0].Size() != 2 || infos[1].Name() != "deep" || !infos[1].IsDir() {
+		t.Errorf("folderEntries(docs/) = %v, want the current a.txt and the deep folder", infos)
+	}
+}
+
+// TestRename moves a folder with its files, and an explicit folder
+func TestRename(t *testing.T) {
+	fs, db, bucket := testFileSystem(t)
+	files, err := fs.rename(context.Background(), bucket, "docs", "archive")
+	if err != nil {
+		t.Fatalf("rename() = %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("rename() = %d files, want 3", len(files))
+	}
+	if ok, err := fs.isFolder(context.Background(), bucket, "docs"); err != nil || ok {
+		t.Errorf("isFolder(docs) after rename = %v, %v, want false", ok, err)
+	}
+	if file, err := fs.current(context.Background(), bucket, "archive/deep/b.txt"); err != nil || file == nil {
+		t.Errorf("current(archive/deep/b.txt) = %v, %v, want the renamed file", file, err)
+	}
+
+	if _, err := fs.rename(context.Background(), bucket, "missing", "other"); err != os.ErrNotExist {
+		t.Errorf("rename() of a missing path = %v, want %v", err, os.ErrNotExist)
+	}
+	if _, err := fs.rename(context.Background(), bucket, "empty", "blank"); err != nil {
+		t.Fatalf("rename() of a folder = %v", err)
+	}
+	var folder entities.BucketFolder
+	if err := db.Where(&entities.BucketFolder{BucketId: bucket.Id}).First(&folder).Error; err != nil || folder.Path != "blank" {
+		t.Errorf("folder after rename = %+v, %v, want blank", folder, err)
+	}
+}
+
+// TestLocked finds the locked files matching a condition
+func TestLocked(t *testing.T) {
+	fs, db, bucket := testFileSystem(t)
+	if err := db.Model(&entities.File{}).Where(`"Name" = ?`, "top.txt").Update("lock_LegalHold", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"top.txt": true, "docs/a.txt": false} {
+		if got, err := fs.locked(context.Background(), bucket, `"Name" = ?`, name); err != nil || got != want {
+			t.Errorf("locked(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package website
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"shbucket/src/Infrastructure/APIError"
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence"
+)
+
+// DefaultIndexDocument is served for the site root and folder paths when a bucket doesn't name one
+const DefaultIndexDocument = "index.html"
+
+// Configure checks a bucket's website settings and fills in their defaults. A site is only served
+// from a public bucket and its documents must be plain file names in the bucket. The website
+// domain is checked with the bucket's other domains by domains.Configure.
+func Configure(settings *entities.BucketSettings) error {
+	settings.WebsiteIndexDocument = strings.TrimPrefix(strings.TrimSpace(settings.WebsiteIndexDocument), "/")
+	settings.WebsiteErrorDocument = strings.TrimPrefix(strings.TrimSpace(settings.WebsiteErrorDocument), "/")
+	if settings.WebsiteIndexDocument == "" {
+		settings.WebsiteIndexDocument = DefaultIndexDocument
+	}
+
+	if !settings.WebsiteEnabled {
+		return nil
+	}
+	if !settings.PublicRead {
+		return apierror.New(apierror.CodeInvalidRequest, "website hosting needs public_read, sites are served without authentication")
+	}
+	if strings.Contains(settings.WebsiteIndexDocument, "/") {
+		return apierror.New(apierror.CodeInvalidRequest, "website_index_document must be a file name, it is looked up in every folder")
+	}
+	if strings.Contains(settings.WebsiteErrorDocument, "..") {
+		return apierror.Newf(apierror.CodeInvalidRequest, "invalid website_error_document %q", settings.WebsiteErrorDocument)
+	}
+	return nil
+}
+
+// Current returns the current version of the file named name in a bucket, nil when there is none
+func Current(ctx context.Context, dbContext *persistence.AppDbContext, bucketID uuid.UUID, name string) (*entities.File, error) {
+	return current(dbContext.GetDB().WithContext(ctx), bucketID, name)
+}
+
+func current(db *gorm.DB, bucketID uuid.UUID, name string) (*entities.File, error) {
+	var file entities.File
+	err := db.Where(`"BucketId" = ? AND "Name" = ?`, bucketID, name).
+		Order(`"Version" DESC`).Order(`"CreatedAt" DESC`).First(&file).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
@@ -0,0 +1,28 @@
+package website
+
+import (
+	"testing"
+
+	"shbucket/src/Infrastructure/Data/Entities"
+	"shbucket/src/Infrastructure/Persistence/SQLiteTest"
+)
+
+// TestCurrent finds the newest version of a site's file, nil for a missing one
+func TestCurrent(t *testing.T) {
+	db := sqlitetest.Open(t)
+	bucketID := sqlitetest.CreateBucket(t, db, "site").Id
+	for version := 1; version <= 2; version++ {
+		file := entities.File{BucketId: bucketID, Name: "index.html", OriginalName: "index.html", Path: "/data/index.html", Version: version}
+		if err := db.Create(&file).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := current(db, bucketID, "index.html")
+	if err != nil || file == nil || file.Version != 2 {
+		t.Errorf("current(index.html) = %+v, %v, want version 2", file, err)
+	}
+	if file, err := current(db, bucketID, "404.html"); err != nil || file != nil {
+		t.Errorf("current(404.html) = %+v, %v, want nil", file, err)
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+// Favorite file response model
+type FavoriteResponse struct {
+	FileID      uuid.UUID `json:"file_id"`
+	BucketID    uuid.UUID `json:"bucket_id"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	MimeType    string    `json:"mime_type"`
+	FavoritedAt time.Time `json:"favorited_at"`
+}
+
+// Activity feed entry response model
+type ActivityResponse struct {
+	ID            uuid.UUID              `json:"id"`
+	Type          string                 `json:"type"`
+	BucketID      uuid.UUID              `json:"bucket_id"`
+	BucketName    string                 `json:"bucket_name"`
+	FileID        *uuid.UUID             `json:"file_id,omitempty"`
+	ActorID       uuid.UUID              `json:"actor_id"`
+	ActorUsername string                 `json:"actor_username"`
+	Data          map[string]interface{} `json:"data"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
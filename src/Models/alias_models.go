@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AliasResponse describes a name within a bucket pointing at one of its files
+type AliasResponse struct {
+	ID        uuid.UUID `json:"id"`
+	BucketID  uuid.UUID `json:"bucket_id"`
+	Name      string    `json:"name"`
+	FileID    uuid.UUID `json:"file_id"`
+	URL       string    `json:"url"` // serves whichever file the alias points at
+	UpdatedBy uuid.UUID `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
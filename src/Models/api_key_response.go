@@ -14,6 +14,9 @@ type APIKeyResponse struct {
 	Username    string                      `json:"username"`
 	IsActive    bool                        `json:"is_active"`
 	Permissions entities.APIKeyPermission  `json:"permissions"`
+	Role        string                      `json:"role,omitempty"`
+	EgressQuota      int64                  `json:"egress_quota"`       // bytes downloaded with the key per billing cycle, 0 for no quota
+	EgressDailyQuota int64                  `json:"egress_daily_quota"` // bytes downloaded with the key per day, 0 for no quota
 	ExpiresAt   *time.Time                  `json:"expires_at"`
 	LastUsed    *time.Time                  `json:"last_used"`
 	CreatedAt   time.Time                   `json:"created_at"`
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+type BackupRunResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Destination  string     `json:"destination"`
+	Status       string     `json:"status"`
+	Trigger      string     `json:"trigger"`
+	FilesCopied  int64      `json:"files_copied"`
+	FilesSkipped int64      `json:"files_skipped"`
+	FilesFailed  int64      `json:"files_failed"`
+	BytesCopied  int64      `json:"bytes_copied"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
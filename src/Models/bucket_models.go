@@ -25,7 +25,43 @@ type BucketSettingsResponse struct {
 	Versioning          bool     `json:"versioning"`
 	Encryption          bool     `json:"encryption"`
 	AllowOverwrite      bool     `json:"allow_overwrite"`
+	KeyedObjects        bool     `json:"keyed_objects"` // names are unique: uploading one that exists overwrites it, a new version when versioning, or fails without allow_overwrite
 	RequireContentType  bool     `json:"require_content_type"`
+	VideoProcessing     bool     `json:"video_processing"`
+	CORSRules           []CORSRuleResponse `json:"cors_rules" validate:"omitempty,dive"`
+	MaxVersions         int      `json:"max_versions" validate:"min=0"`
+	VersionRetentionDays int     `json:"version_retention_days" validate:"min=0"`
+	DisableImageTransforms bool  `json:"disable_image_transforms"`
+	PlacementNodeID     *uuid.UUID `json:"placement_node_id,omitempty"` // pins all content to this storage node
+	PlacementGroup      string   `json:"placement_group" validate:"max=100"` // pins all content to nodes of this group
+	PlacementZone       string   `json:"placement_zone" validate:"max=100"`  // pins all content to nodes in this zone
+	MinZones            int      `json:"min_zones" validate:"min=0,max=10"`  // zones a file's healthy copies must span, 0 for no requirement
+	WebsiteEnabled      bool     `json:"website_enabled"`                                 // serve the bucket as a static site, needs public_read
+	WebsiteIndexDocument string  `json:"website_index_document" validate:"max=255"`      // defaults to index.html
+	WebsiteErrorDocument string  `json:"website_error_document" validate:"max=255"`
+	WebsiteDomain       string   `json:"website_domain" validate:"omitempty,fqdn,max=253"` // host name serving the site at its root
+	CustomDomain        string   `json:"custom_domain" validate:"omitempty,fqdn,max=253"`  // host name serving files by name at its root
+	EgressQuota         int64    `json:"egress_quota" validate:"min=0"`                    // bytes served per billing cycle, 0 for no quota
+	EgressDailyQuota    int64    `json:"egress_daily_quota" validate:"min=0"`              // bytes served per day (UTC), 0 for no quota
+	EgressQuotaPolicy   string   `json:"egress_quota_policy" validate:"omitempty,oneof=throttle block"` // empty for the server default
+	Compression         string   `json:"compression" validate:"omitempty,oneof=gzip zstd"`               // compresses text-like content at rest, empty for none
+	DefaultHeaders      map[string]string `json:"default_headers,omitempty"`                              // response headers for files that don't set their own
+	ObjectLock          bool     `json:"object_lock"`                                                    // lets files be locked against deletion, permanent once on
+	DefaultRetentionDays int     `json:"default_retention_days" validate:"min=0,max=36500"`              // days new uploads are locked for, needs object_lock
+	PublicReadAllowedIPs []string `json:"public_read_allowed_ips"`                                      // IP addresses or CIDR ranges public reads are limited to, empty for anywhere
+	PublicReadDeniedIPs []string  `json:"public_read_denied_ips"`                                       // IP addresses or CIDR ranges refused public reads
+	HotlinkAllowedReferers []string `json:"hotlink_allowed_referers"`                                  // hosts like example.com or *.example.com whose pages may embed public files, empty for any
+	HotlinkBlockEmptyReferer bool   `json:"hotlink_block_empty_referer"`                               // refuse requests with no Referer or Origin, such as direct visits
+	HotlinkPlaceholderFileID *uuid.UUID `json:"hotlink_placeholder_file_id,omitempty"`                 // file of the bucket served to other referers instead of a 403
+}
+
+// CORSRule model for per-bucket cross-origin access to served files
+type CORSRuleResponse struct {
+	AllowedOrigins []string `json:"allowed_origins" validate:"required,min=1"` // exact origins, "*" or wildcard subdomains like "https://*.example.com"
+	AllowedMethods []string `json:"allowed_methods"`                           // defaults to GET and HEAD
+	AllowedHeaders []string `json:"allowed_headers"`                           // request headers allowed in preflight, "*" allows any
+	ExposeHeaders  []string `json:"expose_headers"`
+	MaxAge         int      `json:"max_age"` // seconds browsers may cache a preflight response
 }
 
 // BucketStats model for API responses
@@ -44,6 +80,7 @@ type BucketResponse struct {
 	AuthRule    AuthRuleResponse        `json:"auth_rule"`
 	Settings    BucketSettingsResponse  `json:"settings"`
 	Stats       BucketStatsResponse     `json:"stats"`
+	Access      string                  `json:"access,omitempty"` // in listings: owner, shared (bucket admin) or admin
 	CreatedAt   time.Time               `json:"created_at"`
 	UpdatedAt   time.Time               `json:"updated_at"`
 }
@@ -90,4 +127,29 @@ type ListBucketsResponse struct {
 	Total   int              `json:"total"`
 	Page    int              `json:"page"`
 	Limit   int              `json:"limit"`
-}
\ No newline at end of file
+}
+
+// BucketDeletionJobResponse reports the progress of a forced bucket deletion
+type BucketDeletionJobResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	BucketID     uuid.UUID  `json:"bucket_id"`
+	BucketName   string     `json:"bucket_name"`
+	JobID        *uuid.UUID `json:"job_id,omitempty"` // background job, also readable at /jobs/{id}
+	Status       string     `json:"status"`
+	TotalFiles   int64      `json:"total_files"`
+	DeletedFiles int64      `json:"deleted_files"`
+	FailedFiles  int64      `json:"failed_files"`
+	DeletedBytes int64      `json:"deleted_bytes"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// BucketAdmin model for users granted management of a bucket they don't own
+type BucketAdminResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	GrantedBy uuid.UUID `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
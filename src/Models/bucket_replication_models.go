@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BucketReplicationResponse describes a bucket whose files are pushed to a bucket of another installation
+type BucketReplicationResponse struct {
+	ID               uuid.UUID  `json:"id"`
+	BucketID         uuid.UUID  `json:"bucket_id"`
+	RemoteURL        string     `json:"remote_url"`
+	RemoteBucketID   uuid.UUID  `json:"remote_bucket_id"`
+	ConflictPolicy   string     `json:"conflict_policy"`
+	ReplicateDeletes bool       `json:"replicate_deletes"`
+	Status           string     `json:"status"`
+	LastError        string     `json:"last_error,omitempty"`
+	FilesPushed      int64      `json:"files_pushed"`
+	FilesDeleted     int64      `json:"files_deleted"`
+	FilesSkipped     int64      `json:"files_skipped"` // already on the remote, and files only readable with a customer key
+	Conflicts        int64      `json:"conflicts"`     // names written on the remote by others
+	BytesPushed      int64      `json:"bytes_pushed"`
+	LastRunAt        *time.Time `json:"last_run_at,omitempty"`
+	LastReplicatedAt *time.Time `json:"last_replicated_at,omitempty"`
+	CreatedBy        uuid.UUID  `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// BucketReplicationStatusResponse is how far a replication is behind its bucket
+type BucketReplicationStatusResponse struct {
+	Status  string `json:"status"`
+	Healthy bool   `json:"healthy"` // the last pass succeeded and the lag is under the threshold
+	// PendingChanges counts the names left to push on the first pass, and the changes since the last one
+	PendingChanges   int64      `json:"pending_changes"`
+	LagSeconds       int64      `json:"lag_seconds"` // age of the oldest change not pushed yet
+	OldestPendingAt  *time.Time `json:"oldest_pending_at,omitempty"`
+	LastRunAt        *time.Time `json:"last_run_at,omitempty"`
+	LastReplicatedAt *time.Time `json:"last_replicated_at,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	Conflicts        int64      `json:"conflicts"`
+	CheckedAt        time.Time  `json:"checked_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileChangeResponse is the current state of a name in a bucket's changes feed
+type FileChangeResponse struct {
+	Name    string     `json:"name"`
+	Deleted bool       `json:"deleted"` // no version of the name is left to serve
+	FileID  *uuid.UUID `json:"file_id,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Size    int64      `json:"size"`
+	// Checksum is the hex encoded sha256 of the content, "stored-on-node" for content stored on a node
+	Checksum       string                 `json:"checksum,omitempty"`
+	MimeType       string                 `json:"mime_type,omitempty"`
+	CustomMetadata map[string]interface{} `json:"custom_metadata,omitempty"`
+	// CustomerEncrypted content can only be downloaded with the customer's key
+	CustomerEncrypted bool       `json:"customer_encrypted,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// BucketSyncResponse describes a bucket kept as a mirror of a bucket of another installation, or
+// of another bucket of this one
+type BucketSyncResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	BucketID        uuid.UUID  `json:"bucket_id"`
+	RemoteURL       string     `json:"remote_url,omitempty"`
+	RemoteBucketID  *uuid.UUID `json:"remote_bucket_id,omitempty"`
+	SourceBucketID  *uuid.UUID `json:"source_bucket_id,omitempty"`
+	Prefix          string     `json:"prefix,omitempty"`
+	Tag             string     `json:"tag,omitempty"`
+	IntervalSeconds int        `json:"interval_seconds"`
+	OnDemand        bool       `json:"on_demand"`
+	Status          string     `json:"status"`
+	LastError       string     `json:"last_error,omitempty"`
+	FilesCopied     int64      `json:"files_copied"`
+	FilesDeleted    int64      `json:"files_deleted"`
+	FilesSkipped    int64      `json:"files_skipped"` // unchanged files, and files only readable with a customer key
+	BytesCopied     int64      `json:"bytes_copied"`
+	NextRunAt       time.Time  `json:"next_run_at"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
+	CreatedBy       uuid.UUID  `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
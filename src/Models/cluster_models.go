@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClusterMemberResponse describes a master server sharing the database
+type ClusterMemberResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Hostname    string    `json:"hostname"`
+	Profile     string    `json:"profile"`
+	Leader      bool      `json:"leader"` // runs the background work that runs on one server at a time
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
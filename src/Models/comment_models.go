@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+// File comment response model
+type CommentResponse struct {
+	ID             uuid.UUID   `json:"id"`
+	FileID         uuid.UUID   `json:"file_id"`
+	BucketID       uuid.UUID   `json:"bucket_id"`
+	AuthorID       uuid.UUID   `json:"author_id"`
+	AuthorUsername string      `json:"author_username"`
+	Body           string      `json:"body"`
+	Mentions       []uuid.UUID `json:"mentions"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// Notification response model
+type NotificationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	BucketID  *uuid.UUID `json:"bucket_id,omitempty"`
+	FileID    *uuid.UUID `json:"file_id,omitempty"`
+	CommentID *uuid.UUID `json:"comment_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
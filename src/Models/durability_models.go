@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Replica response model: one stored copy of a file version
+type ReplicaResponse struct {
+	Type       string     `json:"type"`              // "primary" for the copy files are served from, "backup" for backup copies
+	Location   string     `json:"location"`          // "master", the node name or the backup destination
+	NodeID     *uuid.UUID `json:"node_id,omitempty"` // for copies on a storage node
+	Zone       string     `json:"zone,omitempty"`    // failure domain the copy is in
+	Healthy    bool       `json:"healthy"`           // reachable, and for backups holding the current content
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// Replication status response model: how many copies of a file version exist and whether they are intact
+type ReplicationStatusResponse struct {
+	Replicas        int               `json:"replicas"`
+	HealthyReplicas int               `json:"healthy_replicas"`
+	LastVerifiedAt  *time.Time        `json:"last_verified_at,omitempty"` // latest time a copy was confirmed
+	Degraded        bool              `json:"degraded"`                   // some copy is unhealthy
+	AtRisk          bool              `json:"at_risk"`                    // fewer than two healthy copies, so one more loss loses the content
+	Unavailable     bool              `json:"unavailable"`                // the primary copy can't be served
+	Zones           int               `json:"zones"`                      // zones the healthy copies span
+	ZoneShortfall   bool              `json:"zone_shortfall"`             // the healthy copies span fewer zones than the bucket requires
+	Copies          []ReplicaResponse `json:"copies"`
+}
+
+// Durable object response model: a file version in a durability report
+type DurableObjectResponse struct {
+	FileID      uuid.UUID                 `json:"file_id"`
+	Name        string                    `json:"name"`
+	Version     int                       `json:"version"`
+	Size        int64                     `json:"size"`
+	Replication ReplicationStatusResponse `json:"replication"`
+}
+
+// Bucket durability response model
+type BucketDurabilityResponse struct {
+	BucketID      uuid.UUID               `json:"bucket_id"`
+	BucketName    string                  `json:"bucket_name"`
+	Objects       int64                   `json:"objects"`
+	Healthy       int64                   `json:"healthy"` // objects with every copy healthy, at least two of them and across the zones required
+	Degraded      int64                   `json:"degraded"`
+	AtRisk        int64                   `json:"at_risk"`
+	Unavailable   int64                   `json:"unavailable"`
+	ZoneShortfall int64                   `json:"zone_shortfall"`
+	MinZones      int                     `json:"min_zones,omitempty"` // zones the bucket requires copies in
+	Issues        []DurableObjectResponse `json:"issues"`              // degraded, at-risk or short of zones objects, one page of them
+	Total         int64                   `json:"total"`               // objects with issues matching the filter
+	Page          int                     `json:"page"`
+	Limit         int                     `json:"limit"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EgressUsageResponse is the egress of a bucket, of all buckets a user owns, or of an API key, in
+// the current billing cycle and day
+type EgressUsageResponse struct {
+	Scope      string                `json:"scope"` // "bucket", "user" or "api_key"
+	ID         uuid.UUID             `json:"id"`
+	CycleStart time.Time             `json:"cycle_start"`
+	CycleEnd   time.Time             `json:"cycle_end"` // usage resets at this time
+	Bytes      int64                 `json:"bytes"`
+	Quota      int64                 `json:"quota"`       // 0 for no quota
+	DailyBytes int64                 `json:"daily_bytes"` // today (UTC), buckets and API keys only
+	DailyQuota int64                 `json:"daily_quota"` // 0 for no quota
+	Exceeded   bool                  `json:"exceeded"`    // past either quota
+	History    []EgressCycleResponse `json:"history"`     // earlier cycles, newest first
+}
+
+// EgressCycleResponse is the egress of an earlier billing cycle
+type EgressCycleResponse struct {
+	CycleStart time.Time `json:"cycle_start"`
+	Bytes      int64     `json:"bytes"`
+}
+
+// BucketEgressResponse is a bucket's egress with its owner's, both of which can limit its downloads
+type BucketEgressResponse struct {
+	Bucket       EgressUsageResponse `json:"bucket"`
+	Owner        EgressUsageResponse `json:"owner"`
+	Policy       string              `json:"policy"`        // "throttle" or "block" past a quota
+	ThrottleRate int64               `json:"throttle_rate"` // bytes per second per download while throttled
+}
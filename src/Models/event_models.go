@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+type BucketEventResponse struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	BucketID  uuid.UUID              `json:"bucket_id"`
+	FileID    *uuid.UUID             `json:"file_id,omitempty"`
+	ActorID   uuid.UUID              `json:"actor_id"`
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+type FailedObjectResponse struct {
+	FileID uuid.UUID `json:"file_id"`
+	Name   string    `json:"name"`
+	Reason string    `json:"reason"`
+}
+
+type S3ExportJobResponse struct {
+	ID              uuid.UUID              `json:"id"`
+	BucketID        uuid.UUID              `json:"bucket_id"`
+	TargetEndpoint  string                 `json:"target_endpoint"`
+	TargetBucket    string                 `json:"target_bucket"`
+	Prefix          string                 `json:"prefix"`
+	BandwidthLimit  int64                  `json:"bandwidth_limit"`
+	Status          string                 `json:"status"`
+	ObjectsExported int64                  `json:"objects_exported"`
+	ObjectsFailed   int64                  `json:"objects_failed"`
+	BytesExported   int64                  `json:"bytes_exported"`
+	FailedObjects   []FailedObjectResponse `json:"failed_objects"`
+	ManifestKey     string                 `json:"manifest_key,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	StartedAt       time.Time              `json:"started_at"`
+	CompletedAt     *time.Time             `json:"completed_at,omitempty"`
+}
+
+// ExportManifestEntry describes one exported object in the manifest written next to the export
+type ExportManifestEntry struct {
+	FileID         uuid.UUID              `json:"file_id"`
+	Key            string                 `json:"key"`
+	Name           string                 `json:"name"`
+	OriginalName   string                 `json:"original_name"`
+	Size           int64                  `json:"size"`
+	MimeType       string                 `json:"mime_type"`
+	SHA256         string                 `json:"sha256"`
+	MD5            string                 `json:"md5"`
+	CustomMetadata map[string]interface{} `json:"custom_metadata"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
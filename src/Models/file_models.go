@@ -11,7 +11,11 @@ type FileMetadataResponse struct {
 	ContentEncoding    string                 `json:"content_encoding,omitempty"`
 	ContentDisposition string                 `json:"content_disposition,omitempty"`
 	CacheControl       string                 `json:"cache_control,omitempty"`
+	Headers            map[string]string      `json:"headers,omitempty"` // response headers served with the file
 	CustomMetadata     map[string]interface{} `json:"custom_metadata,omitempty"`
+	ScanStatus         string                 `json:"scan_status,omitempty"` // clean, infected (quarantined) or error
+	ScanSignature      string                 `json:"scan_signature,omitempty"`
+	ScannedAt          *time.Time             `json:"scanned_at,omitempty"`
 }
 
 // File response model
@@ -25,12 +29,23 @@ type FileResponse struct {
 	MimeType     string                `json:"mime_type"`
 	Checksum     string                `json:"checksum"`
 	Version      int                   `json:"version"`
+	Encrypted    bool                  `json:"encrypted"`
+	CustomerKeyMD5 string              `json:"customer_key_md5,omitempty"` // set for content encrypted with a customer-provided key
 	AuthRule     *AuthRuleResponse     `json:"auth_rule,omitempty"`
 	Metadata     FileMetadataResponse  `json:"metadata"`
 	SecuredUrl   string                `json:"secured_url,omitempty"`
 	CreatedAt    time.Time             `json:"created_at"`
 	UpdatedAt    time.Time             `json:"updated_at"`
 	AccessedAt   *time.Time            `json:"accessed_at,omitempty"`
+	Replication  *ReplicationStatusResponse `json:"replication,omitempty"` // only on file info
+	Lock         *FileLockResponse     `json:"lock,omitempty"`        // only on file info, for buckets with object lock
+}
+
+// FileLock model: what keeps a file version from being deleted, overwritten or renamed
+type FileLockResponse struct {
+	RetainUntil *time.Time `json:"retain_until,omitempty"`
+	LegalHold   bool       `json:"legal_hold"`
+	Locked      bool       `json:"locked"` // the retention date hasn't passed or a legal hold is placed
 }
 
 // Upload file response schema
@@ -128,4 +143,16 @@ type GenerateSignedURLResponse struct {
 	ExpiresAt time.Time `json:"expires_at"`
 	Success   bool      `json:"success"`
 	Message   string    `json:"message"`
-}
\ No newline at end of file
+}
+// File token models
+type FileTokenResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	FileID      uuid.UUID  `json:"file_id"`
+	BucketID    uuid.UUID  `json:"bucket_id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	CreatedBy   uuid.UUID  `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
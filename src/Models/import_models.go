@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+type SkippedObjectResponse struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+type S3ImportJobResponse struct {
+	ID              uuid.UUID               `json:"id"`
+	SourceEndpoint  string                  `json:"source_endpoint"`
+	SourceBucket    string                  `json:"source_bucket"`
+	Prefix          string                  `json:"prefix"`
+	TargetBucketID  uuid.UUID               `json:"target_bucket_id"`
+	Status          string                  `json:"status"`
+	LastKey         string                  `json:"last_key"`
+	ObjectsImported int64                   `json:"objects_imported"`
+	ObjectsSkipped  int64                   `json:"objects_skipped"`
+	BytesImported   int64                   `json:"bytes_imported"`
+	SkippedObjects  []SkippedObjectResponse `json:"skipped_objects"`
+	Error           string                  `json:"error,omitempty"`
+	StartedAt       time.Time               `json:"started_at"`
+	CompletedAt     *time.Time              `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobResponse reports the state of a background job
+type JobResponse struct {
+	ID                    uuid.UUID              `json:"id"`
+	Type                  string                 `json:"type"`
+	Status                string                 `json:"status"` // queued, running, completed, failed
+	BucketID              *uuid.UUID             `json:"bucket_id,omitempty"`
+	Total                 int64                  `json:"total"`
+	Completed             int64                  `json:"completed"`
+	Attempts              int                    `json:"attempts"`
+	MaxAttempts           int                    `json:"max_attempts"`
+	Error                 string                 `json:"error,omitempty"` // last failed attempt, kept while the job is retried
+	Result                map[string]interface{} `json:"result,omitempty"`
+	NextRunAt             *time.Time             `json:"next_run_at,omitempty"` // when a queued job becomes due
+	CreatedAt             time.Time              `json:"created_at"`
+	StartedAt             *time.Time             `json:"started_at,omitempty"`
+	CompletedAt           *time.Time             `json:"completed_at,omitempty"`
+	EstimatedCompletionAt *time.Time             `json:"estimated_completion_at,omitempty"` // for running jobs, from the pace so far
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeyRotationJobResponse reports the progress of re-wrapping a bucket's data keys under a new bucket key
+type KeyRotationJobResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	BucketID      uuid.UUID  `json:"bucket_id"`
+	KeyID         uuid.UUID  `json:"key_id"`
+	KeyVersion    int        `json:"key_version"`
+	Mode          string     `json:"mode"`
+	Status        string     `json:"status"`
+	TotalKeys     int64      `json:"total_keys"`
+	RewrappedKeys int64      `json:"rewrapped_keys"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// List query request model: the sorting and filtering parameters list endpoints share
+type ListQuery struct {
+	SortBy        string     `json:"sort_by"`
+	Order         string     `json:"order"` // asc or desc, the endpoint's default when empty
+	Name          string     `json:"name"`  // the name contains, case-insensitively
+	CreatedBefore *time.Time `json:"created_before"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	Cursor        string     `json:"cursor"` // the next_cursor of the previous page, replaces page
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Principal types of a permission export
+const (
+	PrincipalUser        = "user"
+	PrincipalAPIKey      = "api_key"
+	PrincipalFileToken   = "file_token"
+	PrincipalUploadGrant = "upload_grant"
+	PrincipalSignedURL   = "signed_url"
+	PrincipalPublic      = "public"
+)
+
+// Rights a principal can hold on a bucket
+const (
+	RightRead         = "read"          // list, download and preview files
+	RightSignURLs     = "sign_urls"     // issue signed URLs for files
+	RightWrite        = "write"         // upload, overwrite and delete files
+	RightUpload       = "upload"        // upload new files only, as through an upload link
+	RightManage       = "manage"        // change settings, bucket admins, scoped API keys, snapshots and keys
+	RightDeleteBucket = "delete_bucket" // delete the bucket itself
+)
+
+// PermissionEntryResponse is what one principal can do in one bucket, and why
+type PermissionEntryResponse struct {
+	PrincipalType string     `json:"principal_type"`
+	PrincipalID   *uuid.UUID `json:"principal_id,omitempty"`
+	Principal     string     `json:"principal"`         // username, key name or credential prefix
+	UserID        *uuid.UUID `json:"user_id,omitempty"` // user the credential belongs to or was issued by
+	Username      string     `json:"username,omitempty"`
+	BucketID      uuid.UUID  `json:"bucket_id"`
+	BucketName    string     `json:"bucket_name"`
+	FileID        *uuid.UUID `json:"file_id,omitempty"` // set for credentials limited to one file
+	FileName      string     `json:"file_name,omitempty"`
+	Rights        []string   `json:"rights"`
+	Via           []string   `json:"via"` // what grants the rights, e.g. "role:editor", "owner", "bucket_admin"
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Reclaimable item response model
+type ReclaimableItemResponse struct {
+	Reference  string    `json:"reference"` // file path, or record ID for database rows
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// Reclamation category response model
+type ReclamationCategoryResponse struct {
+	Category    string                    `json:"category"`
+	Description string                    `json:"description"`
+	Count       int                       `json:"count"`
+	Bytes       int64                     `json:"bytes"`
+	Items       []ReclaimableItemResponse `json:"items"` // a sample, at most 50 items
+}
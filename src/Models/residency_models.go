@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// Residency location response model: one place that holds content of a bucket
+type ResidencyLocationResponse struct {
+	Type           string     `json:"type"`              // "master", "node" or "backup"
+	NodeID         *uuid.UUID `json:"node_id,omitempty"` // for nodes, and backup nodes that are registered storage nodes
+	Name           string     `json:"name"`              // node name, "master" or the backup destination
+	Group          string     `json:"group,omitempty"`   // node group, such as a region
+	Zone           string     `json:"zone,omitempty"`    // failure domain, such as a rack
+	Files          int64      `json:"files"`             // file versions stored here
+	SnapshotCopies int64      `json:"snapshot_copies"`   // snapshot content kept here
+	CachedFiles    int64      `json:"cached_files"`      // files with resized images or video renditions cached here
+	BackupCopies   int64      `json:"backup_copies"`
+	Bytes          int64      `json:"bytes"`
+	Allowed        bool       `json:"allowed"` // whether the bucket's placement allows content here
+}
+
+// Bucket residency response model
+type BucketResidencyResponse struct {
+	BucketID        uuid.UUID                   `json:"bucket_id"`
+	BucketName      string                      `json:"bucket_name"`
+	PlacementNodeID *uuid.UUID                  `json:"placement_node_id,omitempty"`
+	PlacementGroup  string                      `json:"placement_group,omitempty"`
+	PlacementZone   string                      `json:"placement_zone,omitempty"`
+	Compliant       bool                        `json:"compliant"` // every location is allowed by the placement
+	Locations       []ResidencyLocationResponse `json:"locations"`
+}
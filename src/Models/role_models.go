@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role response model: a built-in role or a custom role an admin created
+type RoleResponse struct {
+	ID          *uuid.UUID `json:"id,omitempty"` // nil for built-in roles
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Permissions []string   `json:"permissions"`
+	Builtin     bool       `json:"builtin"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
@@ -30,6 +30,8 @@ type NodeSetupRequest struct {
 	StoragePath   string `json:"storage_path" validate:"required"`
 	MaxStorage    int64  `json:"max_storage" validate:"min=1"`
 	MasterAPIKey  string `json:"master_api_key" validate:"required"`
+	// RegistrationToken is the one-time token an admin of the master issued for this node
+	RegistrationToken string `json:"registration_token" validate:"required"`
 }
 
 type SetupResponse struct {
@@ -44,6 +46,7 @@ type SetupResponse struct {
 type SystemInfoResponse struct {
 	SystemName    string    `json:"system_name"`
 	Version       string    `json:"version"`
+	Profile       string    `json:"profile"` // deployment profile: dev, staging or prod
 	SetupType     string    `json:"setup_type"`
 	StoragePath   string    `json:"storage_path"`
 	MaxStorage    int64     `json:"max_storage"`
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningKeyResponse describes a key signed URLs and upload policies are signed with, without its secret
+type SigningKeyResponse struct {
+	KeyID     string     `json:"key_id"`
+	Active    bool       `json:"active"` // signs new URLs and policies
+	Retired   bool       `json:"retired"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiresAt *time.Time `json:"retires_at,omitempty"` // what the key signed verifies until then
+}
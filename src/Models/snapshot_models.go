@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+// Bucket snapshot response model
+type SnapshotResponse struct {
+	ID        uuid.UUID `json:"id"`
+	BucketID  uuid.UUID `json:"bucket_id"`
+	Name      string    `json:"name"`
+	FileCount int64     `json:"file_count"`
+	TotalSize int64     `json:"total_size"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Snapshot file response model
+type SnapshotFileResponse struct {
+	FileID    uuid.UUID `json:"file_id"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	MimeType  string    `json:"mime_type"`
+	Checksum  string    `json:"checksum"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Snapshot file change response model
+type SnapshotFileChangeResponse struct {
+	Name   string               `json:"name"`
+	Before SnapshotFileResponse `json:"before"`
+	After  SnapshotFileResponse `json:"after"`
+}
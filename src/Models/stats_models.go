@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// System statistics response model
+type SystemStatsResponse struct {
+	Buckets     int64                      `json:"buckets"`
+	Files       int64                      `json:"files"` // file versions, across all buckets
+	Bytes       int64                      `json:"bytes"`
+	Users       UserStatsResponse          `json:"users"`
+	APIKeys     int64                      `json:"api_keys"` // active and unexpired
+	Last24Hours TransferStatsResponse      `json:"last_24_hours"`
+	Storage     []StorageStatsResponse     `json:"storage"`           // the master first, then each node
+	PerBucket   []BucketUsageStatsResponse `json:"per_bucket"`        // largest first
+	Alerts      []UsageAlertResponse       `json:"alerts"`            // usage alerts firing, most utilized first
+	Scanner     *ScannerHealthResponse     `json:"scanner,omitempty"` // nil when uploads aren't scanned
+	GeneratedAt time.Time                  `json:"generated_at"`
+}
+
+// User statistics response model
+type UserStatsResponse struct {
+	Total       int64 `json:"total"`
+	Active      int64 `json:"active"`        // not deactivated
+	SignedIn24h int64 `json:"signed_in_24h"` // signed in during the last 24 hours
+}
+
+// Transfer statistics response model
+type TransferStatsResponse struct {
+	Uploads     int64 `json:"uploads"`
+	UploadBytes int64 `json:"upload_bytes"`
+	Downloads   int64 `json:"downloads"` // by the hour, so up to an hour more than 24 hours is counted
+}
+
+// Storage statistics response model: the content one storage location holds
+type StorageStatsResponse struct {
+	Type        string     `json:"type"` // "master" or "node"
+	NodeID      *uuid.UUID `json:"node_id,omitempty"`
+	Name        string     `json:"name"`
+	Files       int64      `json:"files"`
+	Bytes       int64      `json:"bytes"`
+	MaxStorage  int64      `json:"max_storage"` // 0 when unlimited
+	Utilization float64    `json:"utilization"` // bytes over max storage, 0 when unlimited
+	IsHealthy   bool       `json:"is_healthy"`
+	Status      string     `json:"status"` // healthy, or for nodes unhealthy, inactive, maintenance or failed
+}
+
+// Usage alert response model: a utilization threshold a bucket, the master or a node crossed
+type UsageAlertResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Scope       string     `json:"scope"`               // "bucket", "master" or "node"
+	TargetID    *uuid.UUID `json:"target_id,omitempty"` // the bucket or node
+	Name        string     `json:"name"`
+	Threshold   int        `json:"threshold"`   // percentage crossed
+	Utilization float64    `json:"utilization"` // percentage when last checked
+	Used        int64      `json:"used"`
+	Capacity    int64      `json:"capacity"`
+	FiredAt     time.Time  `json:"fired_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Scanner health response model: whether uploads are still being scanned, as the server answering sees it
+type ScannerHealthResponse struct {
+	Backend              string                   `json:"backend"`             // "clamav" or "webhook"
+	Reachable            *bool                    `json:"reachable,omitempty"` // nil when the scanner can't be asked, like the webhook
+	Error                string                   `json:"error,omitempty"`     // why the scanner didn't answer
+	EngineVersion        string                   `json:"engine_version,omitempty"`
+	DefinitionsVersion   string                   `json:"definitions_version,omitempty"`
+	DefinitionsUpdatedAt *time.Time               `json:"definitions_updated_at,omitempty"`
+	DefinitionAgeHours   *float64                 `json:"definition_age_hours,omitempty"`
+	QueueDepth           int64                    `json:"queue_depth"`     // uploads being scanned right now
+	RecentScans          int64                    `json:"recent_scans"`    // scans finished in the last hour
+	RecentFailures       int64                    `json:"recent_failures"` // scans of the last hour that couldn't complete
+	FailureRate          float64                  `json:"failure_rate"`    // recent failures over recent scans, 0 without scans
+	Problems             []ScannerProblemResponse `json:"problems"`        // what scanner alerts fire for, empty when healthy
+}
+
+// Scanner problem response model: something keeping the scanner from protecting uploads
+type ScannerProblemResponse struct {
+	Kind    string `json:"kind"` // unreachable, stale_definitions, failures or queue
+	Message string `json:"message"`
+}
+
+// Scanner alert response model: a scanner problem that started or went away
+type ScannerAlertResponse struct {
+	Kind       string                `json:"kind"`
+	Message    string                `json:"message"`
+	FiredAt    time.Time             `json:"fired_at"`
+	ResolvedAt *time.Time            `json:"resolved_at,omitempty"`
+	Scanner    ScannerHealthResponse `json:"scanner"` // health when the alert fired or resolved
+}
+
+// Bucket usage statistics response model
+type BucketUsageStatsResponse struct {
+	BucketID    uuid.UUID `json:"bucket_id"`
+	BucketName  string    `json:"bucket_name"`
+	Files       int64     `json:"files"`
+	Bytes       int64     `json:"bytes"`
+	EgressBytes int64     `json:"egress_bytes"` // served in the current billing cycle
+	EgressToday int64     `json:"egress_today"` // served today (UTC)
+}
@@ -9,31 +9,54 @@ type StorageNodeResponse struct {
 	ID          uuid.UUID  `json:"id"`
 	Name        string     `json:"name"`
 	URL         string     `json:"url"`
+	PublicURL   string     `json:"public_url,omitempty"` // where clients reach the node for redirected downloads
 	MaxStorage  int64      `json:"max_storage"`
 	UsedStorage int64      `json:"used_storage"`
 	Priority    int        `json:"priority"`
+	Group       string     `json:"group"`
+	Zone        string     `json:"zone"` // failure domain, such as a rack or availability zone
 	IsActive    bool       `json:"is_active"`
 	IsHealthy   bool       `json:"is_healthy"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	LastPing    *time.Time `json:"last_ping,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"`     // marked permanently failed
+	RepairJobID *uuid.UUID `json:"repair_job_id,omitempty"` // the job repairing its content, see GET /admin/nodes/{id}/repair
+	Status      string     `json:"status"`                  // healthy, unhealthy, inactive, maintenance or failed
+	Maintenance *NodeMaintenanceResponse `json:"maintenance,omitempty"` // set while the node is in maintenance
+}
+
+// NodeMaintenanceResponse describes a node's maintenance: it takes no new content meanwhile
+type NodeMaintenanceResponse struct {
+	Since      time.Time `json:"since"`
+	DrainReads bool      `json:"drain_reads"` // reads are served from other copies where there are any
 }
 
 type RegisterNodeRequest struct {
 	Name       string `json:"name" validate:"required,min=3,max=100"`
 	URL        string `json:"url" validate:"required,url"`
+	PublicURL  string `json:"public_url" validate:"omitempty,url"` // where clients reach the node, when not at URL
 	AuthKey    string `json:"auth_key" validate:"required,min=32"`
 	MaxStorage int64  `json:"max_storage" validate:"min=0"`
 	Priority   int    `json:"priority" validate:"min=0,max=100"`
+	Group      string `json:"group" validate:"max=100"`
+	Zone       string `json:"zone" validate:"max=100"` // failure domain, such as a rack or availability zone
 	IsActive   bool   `json:"is_active"`
 }
 
 type UpdateNodeRequest struct {
-	Name        *string   `json:"name,omitempty" validate:"omitempty,min=3,max=100"`
-	StoragePath *string   `json:"storage_path,omitempty"`
-	MaxStorage  *int64    `json:"max_storage,omitempty" validate:"omitempty,min=0"`
-	IsActive    *bool     `json:"is_active,omitempty"`
-	Tags        *[]string `json:"tags,omitempty"`
+	Name       *string `json:"name,omitempty" validate:"omitempty,min=3,max=100"`
+	PublicURL  *string `json:"public_url,omitempty" validate:"omitempty,url"` // empty to serve redirected downloads from url
+	MaxStorage *int64  `json:"max_storage,omitempty" validate:"omitempty,min=0"`
+	Priority   *int    `json:"priority,omitempty" validate:"omitempty,min=0,max=100"`
+	Group      *string `json:"group,omitempty" validate:"omitempty,max=100"`
+	Zone       *string `json:"zone,omitempty" validate:"omitempty,max=100"`
+	IsActive   *bool   `json:"is_active,omitempty"`
+}
+
+type NodeMaintenanceRequest struct {
+	Enabled    *bool `json:"enabled" validate:"required"` // false ends maintenance
+	DrainReads bool  `json:"drain_reads"`                 // serve reads from other copies where there are any
 }
 
 type NodeHealthCheckRequest struct {
@@ -43,6 +66,7 @@ type NodeHealthCheckRequest struct {
 type NodeHealthCheckResponse struct {
 	NodeID      uuid.UUID `json:"node_id"`
 	IsHealthy   bool      `json:"is_healthy"`
+	Status      string    `json:"status"` // healthy or unhealthy as the check found it, maintenance for a node in maintenance
 	ResponseTime int64     `json:"response_time_ms"`
 	Error       string    `json:"error,omitempty"`
 	Success     bool      `json:"success"`
@@ -64,4 +88,53 @@ type NodeInstallationResponse struct {
 	StartCommand string              `json:"start_command"`
 	Success      bool                `json:"success"`
 	Message      string              `json:"message"`
-}
\ No newline at end of file
+}
+// NodeRegistrationTokenResponse describes a registration token, never the token itself
+type NodeRegistrationTokenResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedBy   uuid.UUID  `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	NodeID      *uuid.UUID `json:"node_id,omitempty"` // the node registered with it
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NodeDiagnosticsResponse is what the master pulled from a storage node's diagnostics endpoint
+type NodeDiagnosticsResponse struct {
+	NodeID    uuid.UUID `json:"node_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Status    string    `json:"status"`
+	Reachable bool      `json:"reachable"`
+	Error     string    `json:"error,omitempty"` // why the node couldn't be asked, or its disk usage read
+	CheckedAt time.Time `json:"checked_at"`
+	// RoundTripMs is how long the node took to answer
+	RoundTripMs int64 `json:"round_trip_ms"`
+	// ClockSkewMs is how far the node's clock is ahead of the master's, negative when behind,
+	// give or take half the round trip
+	ClockSkewMs  int64                  `json:"clock_skew_ms"`
+	Version      string                 `json:"version,omitempty"`
+	APIVersion   int                    `json:"api_version,omitempty"`
+	StoragePath  string                 `json:"storage_path,omitempty"`
+	Disk         *NodeDiskResponse      `json:"disk,omitempty"`
+	RecentErrors []NodeLogEntryResponse `json:"recent_errors"`
+}
+
+// NodeDiskResponse is the space and inodes of the filesystem a node stores content on
+type NodeDiskResponse struct {
+	TotalBytes        uint64  `json:"total_bytes"`
+	FreeBytes         uint64  `json:"free_bytes"`
+	UsedPercent       float64 `json:"used_percent"`
+	TotalInodes       uint64  `json:"total_inodes"`
+	FreeInodes        uint64  `json:"free_inodes"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+}
+
+// NodeLogEntryResponse is an error a node logged
+type NodeLogEntryResponse struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
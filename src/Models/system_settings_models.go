@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+	"github.com/google/uuid"
+)
+
+// RestartRequiredSettingsResponse lists environment settings that only change on restart
+type RestartRequiredSettingsResponse struct {
+	BaseURL        string `json:"base_url"`
+	Port           string `json:"port"`
+	JWTExpiryHours int    `json:"jwt_expiry_hours"`
+	StoragePath    string `json:"storage_path"`
+	MaxStorage     int64  `json:"max_storage"`
+	SystemName     string `json:"system_name"`
+}
+
+// SystemSettingsMetadataResponse describes where the runtime settings came from
+type SystemSettingsMetadataResponse struct {
+	Source    string     `json:"source"` // "environment" or "database"
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload link models
+type UploadGrantResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	BucketID      uuid.UUID  `json:"bucket_id"`
+	Name          string     `json:"name"`
+	Prefix        string     `json:"prefix"`
+	TokenPrefix   string     `json:"token_prefix"`
+	MaxFileSize   int64      `json:"max_file_size"`
+	MaxFiles      int        `json:"max_files"`
+	UploadCount   int        `json:"upload_count"`
+	UploadedBytes int64      `json:"uploaded_bytes"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedBy     uuid.UUID  `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// UploadLinkInfoResponse is what an anonymous holder of an upload link sees of it
+type UploadLinkInfoResponse struct {
+	Name           string    `json:"name"`
+	MaxFileSize    int64     `json:"max_file_size"`             // bytes per file, 0 for no limit
+	MaxFiles       int       `json:"max_files"`                 // 0 for no limit
+	RemainingFiles *int      `json:"remaining_files,omitempty"` // unset when there is no limit
+	ExpiresAt      time.Time `json:"expires_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resumable upload models
+type UploadSessionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	BucketID    uuid.UUID `json:"bucket_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset"` // bytes received so far, where the next chunk starts
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
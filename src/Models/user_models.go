@@ -7,14 +7,15 @@ import (
 
 // User response model
 type UserResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	Role      string     `json:"role"`
-	IsActive  bool       `json:"is_active"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	LastLogin *time.Time `json:"last_login,omitempty"`
+	ID               uuid.UUID  `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	IsActive         bool       `json:"is_active"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	LastLogin        *time.Time `json:"last_login,omitempty"`
 }
 
 // Login request schema
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookResponse describes a bucket webhook. Its secret is only returned when it is issued.
+type WebhookResponse struct {
+	ID           uuid.UUID `json:"id"`
+	BucketID     uuid.UUID `json:"bucket_id"`
+	URL          string    `json:"url"`
+	SecretPrefix string    `json:"secret_prefix"`
+	EventTypes   []string  `json:"event_types"` // empty for every event
+	CreatedBy    uuid.UUID `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
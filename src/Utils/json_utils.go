@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"gorm.io/datatypes"
+	"shbucket/src/Models"
 )
 
 // ConvertJSONToMap converts datatypes.JSON to map[string]interface{}
@@ -33,4 +34,32 @@ func ConvertMapToJSON(data map[string]interface{}) datatypes.JSON {
 	}
 	
 	return datatypes.JSON(jsonData)
-}
\ No newline at end of file
+}
+// ConvertJSONToCORSRules converts stored bucket CORS rules to their response model
+func ConvertJSONToCORSRules(jsonData datatypes.JSON) []models.CORSRuleResponse {
+	rules := []models.CORSRuleResponse{}
+	if len(jsonData) == 0 {
+		return rules
+	}
+	
+	if err := json.Unmarshal(jsonData, &rules); err != nil {
+		// Return no rules if unmarshal fails
+		return []models.CORSRuleResponse{}
+	}
+	
+	return rules
+}
+
+// ConvertCORSRulesToJSON converts bucket CORS rules to datatypes.JSON for storage
+func ConvertCORSRulesToJSON(rules []models.CORSRuleResponse) datatypes.JSON {
+	if rules == nil {
+		rules = []models.CORSRuleResponse{}
+	}
+	
+	jsonData, err := json.Marshal(rules)
+	if err != nil {
+		return datatypes.JSON("[]")
+	}
+	
+	return datatypes.JSON(jsonData)
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shbucket/src/Models"
+)
+
+// ListSort is what a list endpoint can be sorted by: the columns of its sort_by values, and the
+// sort applied when none is asked for. Columns are named as in the schema and quoted when used.
+type ListSort struct {
+	Columns      map[string]string
+	DefaultField string
+	DefaultOrder string
+}
+
+// cursorField is the field keyset pagination sorts by, with the primary key breaking ties
+const cursorField = "created_at"
+
+// createdAtColumn is the column the created_after and created_before filters compare
+var createdAtColumn = clause.Column{Name: "CreatedAt"}
+
+// ListCursor is where a page of a list sorted by creation ended, the next page starts after it
+type ListCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns the cursor as the opaque token clients pass back as ?cursor=
+func (c ListCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()))
+}
+
+// DecodeListCursor reads a cursor token
+func DecodeListCursor(token string) (ListCursor, error) {
+	var cursor ListCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// NextListCursor returns the cursor of the page after one ending with the given row, or "" when
+// the page wasn't full or the list isn't sorted by creation so can't continue from a cursor
+func NextListCursor(list models.ListQuery, sortable ListSort, full bool, createdAt time.Time, id uuid.UUID) string {
+	if !full || sortField(list, sortable) != cursorField {
+		return ""
+	}
+	return ListCursor{CreatedAt: createdAt, ID: id}.Encode()
+}
+
+// sortField is the field a list is sorted by. Lists continued from a cursor are sorted by creation.
+func sortField(list models.ListQuery, sortable ListSort) string {
+	switch {
+	case list.SortBy != "":
+		return list.SortBy
+	case list.Cursor != "":
+		return cursorField
+	default:
+		return sortable.DefaultField
+	}
+}
+
+// ApplyListQuery sorts and filters a list query by the shared list parameters. The name filter
+// matches any of nameColumns, named as in the schema. An unknown sort_by or order is refused rather
+// than ignored. Lists sorted by creation are also sorted by primary key, so they can be continued
+// after a cursor: the rows after it are selected by key rather than skipped by offset, which stays
+// fast however deep the page.
+func ApplyListQuery(query *gorm.DB, list models.ListQuery, sortable ListSort, nameColumns ...string) (*gorm.DB, error) {
+	field := sortField(list, sortable)
+	column, ok := sortable.Columns[field]
+	if !ok {
+		fields := make([]string, 0, len(sortable.Columns))
+		for name := range sortable.Columns {
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+		return nil, fmt.Errorf("cannot sort by %q, sort_by must be one of: %s", field, strings.Join(fields, ", "))
+	}
+
+	order := strings.ToLower(list.Order)
+	if order == "" {
+		order = sortable.DefaultOrder
+	}
+	if order != "asc" && order != "desc" {
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if list.Name != "" && len(nameColumns) > 0 {
+		pattern := ContainsPattern(list.Name)
+		conditions := make([]string, len(nameColumns))
+		args := make([]interface{}, 0, 2*len(nameColumns))
+		for i, nameColumn := range nameColumns {
+			conditions[i] = "LOWER(?) LIKE ? ESCAPE '\\'"
+			args = append(args, clause.Column{Name: nameColumn}, pattern)
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	if list.CreatedAfter != nil {
+		query = query.Where("? >= ?", createdAtColumn, *list.CreatedAfter)
+	}
+	if list.CreatedBefore != nil {
+		query = query.Where("? < ?", createdAtColumn, *list.CreatedBefore)
+	}
+	if list.CreatedAfter != nil && list.CreatedBefore != nil && !list.CreatedAfter.Before(*list.CreatedBefore) {
+		return nil, fmt.Errorf("created_after must be before created_before")
+	}
+
+	desc := order == "desc"
+	if field != cursorField {
+		if list.Cursor != "" {
+			return nil, fmt.Errorf("a cursor continues lists sorted by %s only", cursorField)
+		}
+		return query.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc}), nil
+	}
+
+	if list.Cursor != "" {
+		cursor, err := DecodeListCursor(list.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		comparison := ">"
+		if desc {
+			comparison = "<"
+		}
+		sortColumn := clause.Column{Name: column}
+		query = query.Where("(? "+comparison+" ? OR (? = ? AND ? "+comparison+" ?))",
+			sortColumn, cursor.CreatedAt, sortColumn, cursor.CreatedAt, clause.PrimaryColumn, cursor.ID)
+	}
+	return query.Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: column}, Desc: desc},
+		{Column: clause.PrimaryColumn, Desc: desc},
+	}}), nil
+}
+
+// ContainsPattern returns the LIKE pattern of values containing s, for matching against a lowercased
+// column with backslash as the escape character
+func ContainsPattern(s string) string {
+	return "%" + escapeLike(strings.ToLower(s)) + "%"
+}
+
+// PrefixPattern returns the LIKE pattern of values starting with s, with backslash as the escape
+// character
+func PrefixPattern(s string) string {
+	return escapeLike(s) + "%"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
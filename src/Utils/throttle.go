@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"io"
+	"time"
+)
+
+// ThrottledReader limits the rate at which bytes are read from the wrapped reader
+type ThrottledReader struct {
+	reader         io.Reader
+	bytesPerSecond int64
+	start          time.Time
+	read           int64
+}
+
+// NewThrottledReader wraps reader so it is read at no more than bytesPerSecond.
+// A limit of zero or less returns the reader unchanged.
+func NewThrottledReader(reader io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return reader
+	}
+	return &ThrottledReader{
+		reader:         reader,
+		bytesPerSecond: bytesPerSecond,
+		start:          time.Now(),
+	}
+}
+
+func (t *ThrottledReader) Read(p []byte) (int, error) {
+	// Never read more than a tenth of a second's worth at once so the rate stays smooth
+	if maxChunk := t.bytesPerSecond / 10; maxChunk > 0 && int64(len(p)) > maxChunk {
+		p = p[:maxChunk]
+	}
+
+	n, err := t.reader.Read(p)
+	t.read += int64(n)
+
+	expected := time.Duration(float64(t.read) / float64(t.bytesPerSecond) * float64(time.Second))
+	if elapsed := time.Since(t.start); expected > elapsed {
+		time.Sleep(expected - elapsed)
+	}
+
+	return n, err
+}
@@ -37,6 +37,7 @@ interface NodeSetupForm {
   storagePath: string;
   maxStorage: number;
   masterApiKey: string;
+  registrationToken: string;
 }
 
 const Setup: React.FC = () => {
@@ -74,7 +75,8 @@ const Setup: React.FC = () => {
     nodeApiKey: '',
     storagePath: './storage',
     maxStorage: 10737418240, // 10GB
-    masterApiKey: ''
+    masterApiKey: '',
+    registrationToken: ''
   });
 
   useEffect(() => {
@@ -170,7 +172,8 @@ const Setup: React.FC = () => {
         node_api_key: nodeForm.nodeApiKey,
         storage_path: nodeForm.storagePath,
         max_storage: nodeForm.maxStorage,
-        master_api_key: nodeForm.masterApiKey
+        master_api_key: nodeForm.masterApiKey,
+        registration_token: nodeForm.registrationToken
       };
 
       const response = await api.setupNode(payload);
@@ -485,6 +488,18 @@ const Setup: React.FC = () => {
                 />
               </div>
 
+              <div>
+                <label className="block text-sm font-medium text-dark-200">Registration Token</label>
+                <input
+                  type="text"
+                  value={nodeForm.registrationToken}
+                  onChange={(e) => setNodeForm(prev => ({ ...prev, registrationToken: e.target.value }))}
+                  placeholder="shn_... issued by an admin of the master server"
+                  className="mt-1 block w-full bg-dark-700 border border-dark-600 text-dark-100 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-primary-500 focus:border-primary-500"
+                  required
+                />
+              </div>
+
               <div>
                 <label className="block text-sm font-medium text-dark-200">Storage Path</label>
                 <input
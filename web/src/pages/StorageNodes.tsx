@@ -11,6 +11,7 @@ import {
   ArrowLeftIcon,
   CheckCircleIcon,
   XCircleIcon,
+  WrenchScrewdriverIcon,
 } from '@heroicons/react/24/outline';
 import type { StorageNode, CreateNodeRequest } from '../types';
 
@@ -69,6 +70,18 @@ export default function StorageNodes() {
     },
   });
 
+  const maintenanceMutation = useMutation({
+    mutationFn: ({ id, enabled, drainReads }: { id: string; enabled: boolean; drainReads: boolean }) =>
+      apiClient.setNodeMaintenance(id, enabled, drainReads),
+    onSuccess: (_, { enabled }) => {
+      queryClient.invalidateQueries({ queryKey: ['nodes'] });
+      toast.success(enabled ? 'Node is in maintenance' : 'Node is out of maintenance');
+    },
+    onError: (error: any) => {
+      toast.error(error.message || 'Failed to update maintenance');
+    },
+  });
+
   const nodes = nodesData?.nodes || [];
 
   const handleCreateNode = (e: React.FormEvent) => {
@@ -91,20 +104,36 @@ export default function StorageNodes() {
     }
   };
 
-  const getHealthStatusIcon = (isHealthy: boolean) => {
-    if (isHealthy) {
+  const handleToggleMaintenance = (node: StorageNode) => {
+    if (node.maintenance) {
+      maintenanceMutation.mutate({ id: node.id, enabled: false, drainReads: false });
+      return;
+    }
+    const drainReads = confirm(`Also serve reads of "${node.name}" from other copies where there are any?`);
+    maintenanceMutation.mutate({ id: node.id, enabled: true, drainReads });
+  };
+
+  const getHealthStatusIcon = (node: StorageNode) => {
+    if (node.status === 'maintenance') {
+      return <WrenchScrewdriverIcon className="h-5 w-5 text-yellow-400" />;
+    }
+    if (node.is_healthy) {
       return <CheckCircleIcon className="h-5 w-5 text-green-400" />;
     } else {
       return <XCircleIcon className="h-5 w-5 text-red-400" />;
     }
   };
 
-  const getHealthStatusColor = (isHealthy: boolean) => {
-    return isHealthy ? 'text-green-400' : 'text-red-400';
+  const getHealthStatusColor = (node: StorageNode) => {
+    if (node.status === 'maintenance') return 'text-yellow-400';
+    return node.is_healthy ? 'text-green-400' : 'text-red-400';
   };
 
-  const getHealthStatusText = (isHealthy: boolean) => {
-    return isHealthy ? 'Healthy' : 'Unhealthy';
+  const getHealthStatusText = (node: StorageNode) => {
+    if (node.status === 'maintenance') {
+      return node.maintenance?.drain_reads ? 'Maintenance (draining reads)' : 'Maintenance';
+    }
+    return node.is_healthy ? 'Healthy' : 'Unhealthy';
   };
 
   const formatBytes = (bytes: number) => {
@@ -199,9 +228,9 @@ export default function StorageNodes() {
                     </div>
                   </div>
                   <div className="flex items-center space-x-2">
-                    {getHealthStatusIcon(node.is_healthy)}
-                    <span className={`text-sm ${getHealthStatusColor(node.is_healthy)}`}>
-                      {getHealthStatusText(node.is_healthy)}
+                    {getHealthStatusIcon(node)}
+                    <span className={`text-sm ${getHealthStatusColor(node)}`}>
+                      {getHealthStatusText(node)}
                     </span>
                   </div>
                 </div>
@@ -257,6 +286,14 @@ export default function StorageNodes() {
                     >
                       <CheckCircleIcon className="h-4 w-4" />
                     </button>
+                    <button
+                      onClick={() => handleToggleMaintenance(node)}
+                      disabled={maintenanceMutation.isPending || node.status === 'failed'}
+                      className={`p-1 hover:text-yellow-400 ${node.maintenance ? 'text-yellow-400' : 'text-dark-400'}`}
+                      title={node.maintenance ? 'End Maintenance' : 'Start Maintenance'}
+                    >
+                      <WrenchScrewdriverIcon className="h-4 w-4" />
+                    </button>
                     <button
                       onClick={() => {/* TODO: Implement edit functionality */}}
                       className="p-1 text-dark-400 hover:text-white"
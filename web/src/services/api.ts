@@ -229,6 +229,10 @@ class APIClient {
     return this.request('DELETE', `/nodes/${id}`);
   }
 
+  async setNodeMaintenance(id: string, enabled: boolean, drainReads = false): Promise<{ node: StorageNode }> {
+    return this.request('POST', `/nodes/${id}/maintenance`, { enabled, drain_reads: drainReads });
+  }
+
   async checkNodeHealth(id: string): Promise<any> {
     return this.request('GET', `/nodes/${id}/health`);
   }
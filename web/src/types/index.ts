@@ -115,6 +115,11 @@ export interface StorageNode {
   created_at: string;
   updated_at: string;
   last_ping?: string;
+  status: 'healthy' | 'unhealthy' | 'inactive' | 'maintenance' | 'failed';
+  maintenance?: {
+    since: string;
+    drain_reads: boolean;
+  };
 }
 
 export interface CreateNodeRequest {